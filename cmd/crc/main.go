@@ -0,0 +1,173 @@
+// Package main provides CRC-16 utilities for ACARS messages.
+//
+// Usage:
+//
+//	crc identify [options] [TEXT]
+//	crc compute [options] [TEXT]
+//	crc variants
+//
+// identify works out which CRC-16 variant produced a checksum. By default the
+// checksum is the trailing 4 hex characters of TEXT, as in H1 FPN uplinks.
+// It exits with status 1 when no known variant matches.
+//
+// compute prints the checksum of TEXT for one variant, or for every variant.
+//
+// TEXT is read from stdin when omitted. Trailing newlines are removed.
+//
+// Options:
+//
+//	-sum HEX            identify: checksum to test instead of the trailing hex
+//	-hex                Treat TEXT as hex-encoded binary rather than ASCII
+//	-variant NAME       compute: variant to use (default: all)
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"acars_parser/internal/crc"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "identify":
+		os.Exit(runIdentify(os.Args[2:]))
+	case "compute":
+		os.Exit(runCompute(os.Args[2:]))
+	case "variants":
+		printVariants()
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: crc identify|compute|variants [options] [TEXT]")
+}
+
+func runIdentify(args []string) int {
+	fs := flag.NewFlagSet("identify", flag.ExitOnError)
+	sumHex := fs.String("sum", "", "Checksum to test instead of the trailing hex of TEXT")
+	isHex := fs.Bool("hex", false, "Treat TEXT as hex-encoded binary")
+	_ = fs.Parse(args)
+
+	text, err := readText(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading message: %v\n", err)
+		return 2
+	}
+
+	var data, sum []byte
+	switch {
+	case *sumHex != "":
+		sum, err = hex.DecodeString(*sumHex)
+		if err != nil || len(sum) != 2 {
+			fmt.Fprintf(os.Stderr, "Error: -sum must be 4 hex characters\n")
+			return 2
+		}
+		data, err = decode(text, *isHex)
+	case *isHex:
+		// The checksum is the last 2 bytes of the binary.
+		data, err = hex.DecodeString(strings.TrimSpace(text))
+		if err == nil && len(data) < 3 {
+			err = fmt.Errorf("need at least 3 bytes")
+		}
+		if err == nil {
+			data, sum = data[:len(data)-2], data[len(data)-2:]
+		}
+	default:
+		var body string
+		body, sum, err = crc.SplitHexChecksum(text)
+		data = []byte(body)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	fmt.Printf("Checksum: %X (%d bytes of data)\n", sum, len(data))
+	matches := crc.FindVariant(data, sum)
+	if len(matches) == 0 {
+		fmt.Println("No known CRC-16 variant matches.")
+		return 1
+	}
+	for _, m := range matches {
+		order := "usual byte order"
+		if m.Swapped {
+			order = "bytes swapped"
+		}
+		fmt.Printf("Match:    %s (%s)\n", m.Variant.Name, order)
+	}
+	return 0
+}
+
+func runCompute(args []string) int {
+	fs := flag.NewFlagSet("compute", flag.ExitOnError)
+	variantName := fs.String("variant", "", "Variant to use (default: all)")
+	isHex := fs.Bool("hex", false, "Treat TEXT as hex-encoded binary")
+	_ = fs.Parse(args)
+
+	text, err := readText(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading message: %v\n", err)
+		return 2
+	}
+	data, err := decode(text, *isHex)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	variants := crc.Variants
+	if *variantName != "" {
+		v := crc.LookupVariant(*variantName)
+		if v == nil {
+			fmt.Fprintf(os.Stderr, "Error: unknown variant %q (see crc variants)\n", *variantName)
+			return 2
+		}
+		variants = []*crc.Variant{v}
+	}
+
+	for _, v := range variants {
+		sum := v.Compute(data)
+		fmt.Printf("%-20s %04X  appended as %X\n", v.Name, sum, v.Bytes(sum))
+	}
+	return 0
+}
+
+func printVariants() {
+	fmt.Printf("%-20s %-6s %-6s %-9s %-6s %s\n", "Name", "Poly", "Init", "Reflected", "XorOut", "Residue")
+	for _, v := range crc.Variants {
+		fmt.Printf("%-20s %04X   %04X   %-9v %04X   %04X\n", v.Name, v.Poly, v.Init, v.Reflected, v.XorOut, v.Residue())
+	}
+}
+
+// readText returns the message from the arguments, or from stdin when there are none.
+func readText(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// decode returns the message bytes, hex-decoding them when isHex is set.
+func decode(text string, isHex bool) ([]byte, error) {
+	if !isHex {
+		return []byte(text), nil
+	}
+	return hex.DecodeString(strings.TrimSpace(text))
+}
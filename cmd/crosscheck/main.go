@@ -0,0 +1,227 @@
+// Package main provides the crosscheck tool, which compares our ADS-C and
+// CPDLC decoders with libacars over captured dumpvdl2 or dumphfdl output.
+//
+// Both tools decode ARINC 622 applications with libacars and write its decode
+// beside the raw ACARS text, so each frame is run through our parser and the
+// fields mapped in internal/crosscheck are compared with libacars' values.
+// Frames libacars did not decode, or found the CRC of invalid, are skipped.
+// MIAM frames are counted but not compared, as there is no MIAM decoder here.
+//
+// Usage:
+//
+//	crosscheck [options] [FILE...]
+//
+// Input is read from the files given, or from stdin when there are none.
+// Capture it with dumpvdl2 or dumphfdl writing JSON, e.g.
+// --output decoded:json:file:path=capture.jsonl.
+//
+// Each frame with a disagreement is written as a JSON line holding the
+// message and the fields that differ, so that it can be made a parser fixture:
+//
+//	{"timestamp":"...","label":"B6","tail":"F-GXLI","decoder":"adsc","text":"/XYTGL7X.ADS...","disagreements":[{"field":"altitude","ours":13794,"libacars":13800}]}
+//
+// A summary of the fields compared and the disagreements on each is written
+// to stderr.
+//
+// Options:
+//
+//	-o FILE   Output JSONL file (default: stdout)
+//	-v        Report lines that could not be read
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"acars_parser/internal/crosscheck"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
+)
+
+// Record is a frame our decoder and libacars disagree on.
+type Record struct {
+	Timestamp     string                    `json:"timestamp"`
+	Label         string                    `json:"label"`
+	Tail          string                    `json:"tail,omitempty"`
+	Decoder       string                    `json:"decoder"`
+	Text          string                    `json:"text"`
+	Disagreements []crosscheck.Disagreement `json:"disagreements"`
+}
+
+// fieldKey is a field of one decoder's results.
+type fieldKey struct {
+	decoder, field string
+}
+
+type counts struct {
+	lines, unreadable, messages, skipped, miam int
+	compared, disagreeing                      map[string]int // By decoder.
+	fields, fieldDisagreements                 map[fieldKey]int
+}
+
+func main() {
+	outPath := flag.String("o", "", "Output JSONL file (default: stdout)")
+	verbose := flag.Bool("v", false, "Report lines that could not be read")
+	flag.Parse()
+
+	reg := registry.Default()
+	reg.Sort()
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fatalf("Error creating output: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	c := &counts{
+		compared:           make(map[string]int),
+		disagreeing:        make(map[string]int),
+		fields:             make(map[fieldKey]int),
+		fieldDisagreements: make(map[fieldKey]int),
+	}
+	check := func(name string, r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			c.lines++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			f, err := crosscheck.ParseFrame(scanner.Bytes())
+			if errors.Is(err, crosscheck.ErrNoMessage) {
+				continue
+			}
+			if err != nil {
+				c.unreadable++
+				if *verbose {
+					fmt.Fprintf(os.Stderr, "%s:%d: %v\n", name, c.lines, err)
+				}
+				continue
+			}
+			c.messages++
+			if f.MIAM {
+				c.miam++
+			}
+			report, err := crosscheck.Compare(reg, f)
+			if err != nil {
+				fatalf("Error comparing %s:%d: %v", name, c.lines, err)
+			}
+			if report == nil {
+				c.skipped++
+				continue
+			}
+			c.add(report)
+			if len(report.Disagreements) == 0 {
+				continue
+			}
+			rec := Record{
+				Timestamp:     f.Message.Timestamp,
+				Label:         f.Message.Label,
+				Tail:          f.Message.Tail,
+				Decoder:       report.Decoder,
+				Text:          f.Message.Text,
+				Disagreements: report.Disagreements,
+			}
+			if err := enc.Encode(rec); err != nil {
+				fatalf("Error writing output: %v", err)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fatalf("Error reading %s: %v", name, err)
+		}
+	}
+
+	if flag.NArg() == 0 {
+		check("stdin", os.Stdin)
+	}
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			fatalf("Error opening input: %v", err)
+		}
+		check(name, f)
+		_ = f.Close()
+	}
+	if err := w.Flush(); err != nil {
+		fatalf("Error writing output: %v", err)
+	}
+
+	printSummary(c)
+}
+
+func (c *counts) add(report *crosscheck.Report) {
+	c.compared[report.Decoder]++
+	if len(report.Disagreements) > 0 {
+		c.disagreeing[report.Decoder]++
+	}
+	for _, field := range report.Compared {
+		c.fields[fieldKey{report.Decoder, field}]++
+	}
+	for _, d := range report.Disagreements {
+		c.fieldDisagreements[fieldKey{report.Decoder, d.Field}]++
+	}
+}
+
+// printSummary writes the frames compared and, for each field, how often it
+// was compared and disagreed on. A field never compared may be mapped to a
+// libacars path its output does not have.
+func printSummary(c *counts) {
+	fmt.Fprintf(os.Stderr, "Lines: %d, messages: %d, unreadable: %d, not compared: %d (MIAM: %d)\n",
+		c.lines, c.messages, c.unreadable, c.skipped, c.miam)
+
+	decoders := make([]string, 0, len(c.compared))
+	for d := range c.compared {
+		decoders = append(decoders, d)
+	}
+	sort.Strings(decoders)
+	for _, d := range decoders {
+		fmt.Fprintf(os.Stderr, "%s: %d compared, %d with disagreements\n", d, c.compared[d], c.disagreeing[d])
+	}
+
+	keys := make(map[fieldKey]bool)
+	for _, f := range crosscheck.Fields {
+		if c.compared[f.Decoder] > 0 {
+			keys[fieldKey{f.Decoder, f.Name}] = true
+		}
+	}
+	for k := range c.fields {
+		keys[k] = true
+	}
+	for k := range c.fieldDisagreements {
+		keys[k] = true
+	}
+	sorted := make([]fieldKey, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].decoder != sorted[j].decoder {
+			return sorted[i].decoder < sorted[j].decoder
+		}
+		return sorted[i].field < sorted[j].field
+	})
+	if len(sorted) > 0 {
+		fmt.Fprintf(os.Stderr, "\n%-6s %-28s %9s %9s\n", "", "Field", "Compared", "Differ")
+	}
+	for _, k := range sorted {
+		fmt.Fprintf(os.Stderr, "%-6s %-28s %9d %9d\n", k.decoder, k.field, c.fields[k], c.fieldDisagreements[k])
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,455 @@
+// Package main provides the decode tool, which parses receiver and feed output.
+//
+// Each input line is decoded (see internal/input for the supported formats),
+// dispatched through the parser registry, and written as one JSON line holding
+// the message metadata and every result, with the parser and version behind
+// each. With -envelope, each result is written on its own line instead, in the
+// envelope published to the sinks (see output.Event). Data that only exists at the link
+// layer, such as HFDL squitters and performance data reports or VDL2 XIDs, is
+// written as
+// results too, so frames without an ACARS message are not dropped.
+//
+// Usage:
+//
+//	decode [options] [FILE...]
+//
+// Input is read from the files given, or from stdin when there are none. On
+// SIGINT or SIGTERM the input is closed, the lines already read are finished,
+// and the output and sinks are flushed before exiting.
+//
+// Options:
+//
+//	-format FORMAT Input format: json (JSON lines, detected per line) or raw
+//	               (acarsdec text output or raw ACARS frames) (default: json,
+//	               env: INPUT_FORMAT)
+//	-output FILE   Output JSONL file (default: stdout)
+//	-all           Also write messages that no parser matched
+//	-envelope      Write one line per result in the canonical envelope (the
+//	               result events published to the sinks) instead of one line
+//	               per message; -all has no effect
+//	-v             Report lines that could not be decoded, and publish and alert errors
+//
+// Feeds from several receiver sites can be merged. Each message is attributed
+// to a feeder: the "feeder" field of the input, the -feeder-id flag, or else
+// its receiving station. With -dedup-window, copies of a message delivered by
+// more than one feeder are written once, and the messages each feeder
+// delivered first are reported (see internal/dedup). Messages that name no
+// "tenant", the partner network whose feed supplied them, are attributed to
+// the -tenant flag:
+//
+//	-feeder-id ID       Feeder of messages that do not name one (env: FEEDER_ID)
+//	-tenant ID          Tenant of messages that do not name one (env: TENANT)
+//	-dedup-window DUR   Suppress copies of a message (same tail, label and text)
+//	                    received within this window (default: 0, off, env: DEDUP_WINDOW)
+//
+// Results can also be published to MQTT, Kafka and NATS (see internal/output):
+//
+//	-mqtt URL           MQTT broker, e.g. tcp://localhost:1883 (env: MQTT_BROKER)
+//	-mqtt-topic TMPL    MQTT topic template (default: acars/{kind}/{label}/{icao},
+//	                    env: MQTT_TOPIC)
+//	-mqtt-user USER     MQTT user (env: MQTT_USER)
+//	-mqtt-password PASS MQTT password (env: MQTT_PASSWORD)
+//	-mqtt-qos N         MQTT QoS, 0 to 2 (default: 0, env: MQTT_QOS)
+//	-mqtt-retain        Publish retained MQTT messages (env: MQTT_RETAIN)
+//	-kafka BROKERS      Comma-separated Kafka brokers (env: KAFKA_BROKERS)
+//	-kafka-topic TMPL   Kafka topic template (default: acars.{kind}, env: KAFKA_TOPIC)
+//	-nats URL           NATS server, e.g. nats://localhost:4222 (env: NATS_URL)
+//	-nats-subject TMPL  NATS subject template (default: acars.{kind}.{label},
+//	                    env: NATS_SUBJECT)
+//	-nats-creds FILE    NATS credentials file (env: NATS_CREDS)
+//	-sink-format FMT    Payload: event (result with message metadata) or data
+//	                    (result only) (default: event, env: SINK_FORMAT)
+//
+// Positions, winds aloft and engine metrics can be written as time-series
+// points (see internal/timeseries):
+//
+//	-influx URL         InfluxDB URL, e.g. http://localhost:8086 (env: INFLUX_URL)
+//	-influx-token TOKEN InfluxDB API token (env: INFLUX_TOKEN)
+//	-influx-org ORG     InfluxDB 2.x organisation (env: INFLUX_ORG)
+//	-influx-bucket NAME InfluxDB 2.x bucket (env: INFLUX_BUCKET)
+//	-influx-db NAME     InfluxDB 1.x database, instead of a bucket (env: INFLUX_DB)
+//	-timescale DSN      TimescaleDB connection URL (env: TIMESCALE_DSN)
+//
+// Results reporting an emergency (ADS-C emergency reports, CPDLC MAYDAY and
+// PAN downlinks, 7700 squawk assignments) carry its kind in their "emergency"
+// field, and an emergency event is published to the sinks at once, ahead of
+// the batched result events (see state.Emergencies).
+//
+// Messages matching the rules in an alert rules file are sent to webhooks and
+// NATS subjects (see internal/alert):
+//
+//	-alerts FILE        Alert rules file, JSON (env: ALERT_RULES)
+//
+// Message times are normalised to UTC (see internal/msgtime):
+//
+//	-clock-skew PAIRS        Receiver clock offsets to remove, as STATION=DURATION
+//	                         pairs, e.g. YSSY-1=90s (env: CLOCK_SKEW)
+//	-estimate-skew           Estimate receiver clock offsets from arrival times
+//	                         (live input only, env: ESTIMATE_SKEW)
+//	-min-skew DUR            Smallest estimated offset that is corrected (default: 5s,
+//	                         env: MIN_SKEW)
+//	-max-embedded-skew DUR   Flag report times further than this from the
+//	                         message time (default: 1h, 0 disables, env: MAX_EMBEDDED_SKEW)
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/alert"
+	"acars_parser/internal/dedup"
+	"acars_parser/internal/envflag"
+	"acars_parser/internal/input"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/output"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/state"
+	"acars_parser/internal/timeseries"
+	"acars_parser/internal/units"
+)
+
+// Record is one line of output.
+type Record struct {
+	Format    string   `json:"format"`
+	Timestamp string   `json:"timestamp,omitempty"`
+	Label     string   `json:"label,omitempty"`
+	Mode      string   `json:"mode,omitempty"`
+	BlockID   string   `json:"block_id,omitempty"`
+	Ack       string   `json:"ack,omitempty"`
+	MsgNo     string   `json:"msgno,omitempty"`
+	Tail      string   `json:"tail,omitempty"`
+	ICAOHex   string   `json:"icao_hex,omitempty"`
+	Direction string   `json:"link_direction,omitempty"`
+	Flight    string   `json:"flight,omitempty"`
+	Frequency float64  `json:"frequency,omitempty"`
+	StationID string   `json:"station_id,omitempty"`
+	Feeder    string   `json:"feeder,omitempty"`
+	Tenant    string   `json:"tenant,omitempty"`
+	Channel   *int     `json:"channel,omitempty"`
+	TimeFlags []string `json:"time_flags,omitempty"`
+	Text      string   `json:"text,omitempty"`
+	Results   []Result `json:"results,omitempty"`
+}
+
+// Result is one parser or link-layer result.
+type Result struct {
+	Type          string          `json:"type"`
+	Parser        string          `json:"parser,omitempty"` // Empty for link-layer results.
+	ParserVersion int             `json:"parser_version,omitempty"`
+	Emergency     string          `json:"emergency,omitempty"` // Emergency kind, if the result reports one.
+	Units         units.Values    `json:"units,omitempty"`     // Altitudes, speeds and temperatures in canonical units.
+	Data          registry.Result `json:"data"`
+}
+
+// counts summarises a run.
+type counts struct {
+	lines, messages, parsed, written, failed int
+	published, publishFailed                 int
+	alerted, alertFailed                     int
+	emergencies, duplicates                  int
+}
+
+func main() {
+	format := flag.String("format", envflag.String("INPUT_FORMAT", "json"), "Input format: json or raw")
+	outPath := flag.String("output", "", "Output JSONL file (default: stdout)")
+	all := flag.Bool("all", false, "Also write messages that no parser matched")
+	envelope := flag.Bool("envelope", false, "Write one envelope per result, as published to the sinks, instead of one record per message")
+	verbose := flag.Bool("v", false, "Report lines that could not be decoded, and publish and alert errors")
+	feederID := flag.String("feeder-id", envflag.String("FEEDER_ID", ""), "Feeder of messages that do not name one")
+	tenant := flag.String("tenant", envflag.String("TENANT", ""), "Tenant of messages that do not name one")
+	dedupWindow := flag.Duration("dedup-window", envflag.Duration("DEDUP_WINDOW", 0), "Suppress copies of a message received within this window (0 disables)")
+	sinkCfg := output.AddFlags(flag.CommandLine)
+	tsCfg := timeseries.AddFlags(flag.CommandLine)
+	timeFlags := msgtime.AddFlags(flag.CommandLine)
+	alertFlags := alert.AddFlags(flag.CommandLine)
+
+	flag.Parse()
+	// Publishing and alerting use ctx, so a signal stops the input without
+	// cutting off the flush of what was already read.
+	ctx := context.Background()
+	stopCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *dedupWindow < 0 {
+		fatalf("Invalid -dedup-window: %v is negative", *dedupWindow)
+	}
+	timeCfg, err := timeFlags.Config()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	clock := msgtime.New(timeCfg)
+
+	sink, err := sinkCfg.Open()
+	if err != nil {
+		fatalf("Error opening sink: %v", err)
+	}
+	tsSink, err := tsCfg.Open(ctx)
+	if err != nil {
+		fatalf("Error opening time-series sink: %v", err)
+	}
+	if tsSink != nil {
+		if sink != nil {
+			sink = output.Multi{sink, tsSink}
+		} else {
+			sink = tsSink
+		}
+	}
+	if sink != nil {
+		defer func() {
+			if err := sink.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing sink: %v\n", err)
+			}
+		}()
+	}
+
+	alerts, err := alertFlags.Open()
+	if err != nil {
+		fatalf("Error loading alert rules: %v", err)
+	}
+	if alerts != nil {
+		defer func() {
+			if err := alerts.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing alerts: %v\n", err)
+			}
+		}()
+	}
+
+	out := os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fatalf("Error creating output: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	enc := json.NewEncoder(w)
+
+	reg := registry.Default()
+	reg.Sort()
+
+	var filter *dedup.Filter
+	if *dedupWindow > 0 {
+		filter = dedup.New(*dedupWindow)
+	}
+
+	var c counts
+	decodeFile := func(name string, r io.ReadCloser) {
+		// Closing the input on a signal unblocks a read waiting for a live feed.
+		defer context.AfterFunc(stopCtx, func() { _ = r.Close() })()
+		in, err := input.NewStream(*format, r)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		for {
+			d, err := in.Next()
+			if errors.Is(err, io.EOF) || stopCtx.Err() != nil {
+				return
+			}
+			c.lines++
+			if err != nil {
+				c.failed++
+				if *verbose {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				}
+				continue
+			}
+			if d.Message != nil && d.Message.Feeder == "" {
+				d.Message.Feeder = *feederID
+			}
+			if d.Message != nil && d.Message.Tenant == "" {
+				d.Message.Tenant = *tenant
+			}
+			rec, msg, duplicate := decode(reg, clock, filter, d, &c)
+			if duplicate {
+				c.duplicates++
+				continue
+			}
+			results := make([]registry.Result, len(rec.Results))
+			attributed := make([]registry.Attributed, len(rec.Results))
+			for i, r := range rec.Results {
+				results[i] = r.Data
+				attributed[i] = registry.Attributed{Result: r.Data, Parser: r.Parser, Version: r.ParserVersion}
+			}
+			events := output.ResultEvents(msg, attributed, time.Now())
+			if msg != nil {
+				for _, e := range state.Emergencies(msg.Time, msg, results) {
+					c.emergencies++
+					if sink == nil {
+						continue
+					}
+					if err := output.PublishNow(ctx, sink, output.EmergencyEvent(e)); err != nil {
+						c.publishFailed++
+						if *verbose {
+							fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+						}
+						continue
+					}
+					c.published++
+				}
+			}
+			if alerts != nil && msg != nil {
+				sent, err := alerts.Notify(ctx, msg, results)
+				c.alerted += sent
+				if err != nil {
+					c.alertFailed++
+					if *verbose {
+						fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+					}
+				}
+			}
+			if len(rec.Results) == 0 && !(*all && d.Message != nil) {
+				continue
+			}
+			if sink != nil && len(rec.Results) > 0 {
+				for _, e := range events {
+					if err := sink.Publish(ctx, e); err != nil {
+						c.publishFailed++
+						if *verbose {
+							fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+						}
+						continue
+					}
+					c.published++
+				}
+			}
+			if *envelope {
+				for _, e := range events {
+					if err := enc.Encode(e); err != nil {
+						fatalf("Error writing output: %v", err)
+					}
+					c.written++
+				}
+				continue
+			}
+			if err := enc.Encode(rec); err != nil {
+				fatalf("Error writing output: %v", err)
+			}
+			c.written++
+		}
+	}
+
+	if flag.NArg() == 0 {
+		decodeFile("stdin", os.Stdin)
+	}
+	for _, name := range flag.Args() {
+		if stopCtx.Err() != nil {
+			break
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			fatalf("Error opening input: %v", err)
+		}
+		decodeFile(name, f)
+		f.Close()
+	}
+	if stopCtx.Err() != nil {
+		fmt.Fprintf(os.Stderr, "Interrupted; flushing output\n")
+	}
+
+	fmt.Fprintf(os.Stderr, "Lines: %d, messages: %d, parsed: %d, written: %d, undecodable: %d\n",
+		c.lines, c.messages, c.parsed, c.written, c.failed)
+	if sink != nil {
+		fmt.Fprintf(os.Stderr, "Published: %d events, %d failed\n", c.published, c.publishFailed)
+	}
+	if filter != nil {
+		printFeeders(filter.Stats())
+	}
+	if c.emergencies > 0 {
+		fmt.Fprintf(os.Stderr, "Emergencies: %d\n", c.emergencies)
+	}
+	if alerts != nil {
+		fmt.Fprintf(os.Stderr, "Alerts: %d sent, %d messages with failed alerts\n", c.alerted, c.alertFailed)
+	}
+}
+
+// decode dispatches a decoded line and builds its output record. The message
+// is returned after quality repair, or nil for frames without one. A copy of
+// a message already seen by the filter, if there is one, is reported as a
+// duplicate before it is parsed.
+func decode(reg *registry.Registry, clock *msgtime.Normaliser, filter *dedup.Filter, d *input.Decoded, c *counts) (Record, *acars.Message, bool) {
+	rec := Record{Format: d.Format}
+	for _, r := range d.Results {
+		rec.Results = append(rec.Results, Result{Type: r.Type(), Data: r})
+	}
+	if d.Message == nil {
+		return rec, nil, false
+	}
+
+	c.messages++
+	clock.Normalise(d.Message, time.Now())
+	// The same downlink is delivered once per site that heard it.
+	if filter != nil && filter.Duplicate(d.Message, d.Message.Time) {
+		return rec, d.Message, true
+	}
+	msg, report := quality.Prepare(d.Message)
+	attributed := reg.DispatchAttributed(msg)
+	parsed := registry.Results(attributed)
+	clock.CheckEmbedded(msg, parsed)
+	results := quality.Annotate(parsed, report)
+	if len(results) > 0 {
+		c.parsed++
+	}
+	for i, r := range results {
+		rec.Results = append(rec.Results, Result{Type: r.Type(), Parser: attributed[i].Parser,
+			ParserVersion: attributed[i].Version, Emergency: state.EmergencyKind(r), Units: units.Normalise(r), Data: r})
+	}
+
+	rec.Timestamp, rec.Label, rec.Tail = msg.Timestamp, msg.Label, msg.Tail
+	rec.Frequency, rec.Text = msg.Frequency, msg.Text
+	rec.Direction, rec.MsgNo = msg.LinkDirection, msg.MsgNo
+	rec.Mode, rec.BlockID, rec.Ack = msg.Mode, msg.BlockID, msg.Ack
+	rec.StationID, rec.Channel = msg.StationID(), msg.Channel
+	rec.Feeder, rec.Tenant = msg.Feeder, msg.Tenant
+	rec.TimeFlags = msg.TimeFlags
+	rec.ICAOHex = msg.AircraftICAO()
+	if msg.Flight != nil {
+		rec.Flight = strings.TrimSpace(msg.Flight.Flight)
+	}
+	return rec, msg, false
+}
+
+// printFeeders reports the duplicates suppressed and each feeder's
+// contribution: the messages it delivered first, as a share of the merged feed.
+func printFeeders(s dedup.Stats) {
+	fmt.Fprintf(os.Stderr, "Duplicates: %d suppressed\n", s.Suppressed)
+	unique := s.Checked - s.Suppressed
+	feeders := make([]string, 0, len(s.Feeders))
+	for f := range s.Feeders {
+		feeders = append(feeders, f)
+	}
+	sort.Strings(feeders)
+	for _, f := range feeders {
+		fs := s.Feeders[f]
+		var share float64
+		if unique > 0 {
+			share = float64(fs.First) / float64(unique) * 100
+		}
+		name := f
+		if name == "" {
+			name = "(unknown)"
+		}
+		fmt.Fprintf(os.Stderr, "Feeder %s: %d messages, %d first (%.1f%%)\n", name, fs.Messages, fs.First, share)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -16,14 +16,41 @@
 //	-pg-database DB     PostgreSQL database (default: acars_state, env: POSTGRES_DATABASE)
 //	-pg-user USER       PostgreSQL user (default: acars, env: POSTGRES_USER)
 //	-pg-password PASS   PostgreSQL password (default: acars, env: POSTGRES_PASSWORD)
-//	-port N             HTTP port (default: 8081)
-//	-auth               Enable API key authentication
-//	-api-keys KEYS      Comma-separated list of valid API keys
+//	-pg-sslmode MODE    PostgreSQL SSL mode (default: disable, env: POSTGRES_SSLMODE)
+//	-search             Serve message search from ClickHouse (env: API_SEARCH)
+//	-ch-host HOST       ClickHouse host for -search (default: localhost, env: CLICKHOUSE_HOST)
+//	-ch-port PORT       ClickHouse port (default: 9000, env: CLICKHOUSE_PORT)
+//	-ch-database DB     ClickHouse database (default: acars, env: CLICKHOUSE_DATABASE)
+//	-ch-user USER       ClickHouse user (default: default, env: CLICKHOUSE_USER)
+//	-ch-password PASS   ClickHouse password (env: CLICKHOUSE_PASSWORD)
+//	-port N             HTTP port (default: 8081, env: API_PORT)
+//	-grpc-port N        gRPC port (default: 0, off, env: GRPC_PORT)
+//	-auth               Enable API key authentication (env: API_AUTH)
+//	-api-keys KEYS      Comma-separated list of valid API keys, each optionally
+//	                    scoped to tenants as KEY:TENANT|TENANT (env: API_KEYS)
+//	-cache-ttl DUR      Cache enrichment lookups for this long (default: 30s, 0 = off,
+//	                    env: CACHE_TTL)
+//	-redis-addr ADDR    Share the cache in Redis at ADDR (env: REDIS_ADDR)
+//	-redis-password P   Redis password (env: REDIS_PASSWORD)
+//	-prune-interval DUR Apply the retention policy this often (default: 0, off,
+//	                    env: PRUNE_INTERVAL)
+//	-keep-* DUR         Retention per table, as for the maintenance tool (env: KEEP_*)
+//	-shutdown-timeout DUR
+//	                    Time allowed for in-flight requests on shutdown (default: 20s,
+//	                    env: SHUTDOWN_TIMEOUT)
+//
+// On SIGINT or SIGTERM the server fails readiness checks, stops accepting
+// connections, waits for in-flight HTTP requests and gRPC calls (up to
+// -shutdown-timeout), stops the retention loop and closes the PostgreSQL pool.
 //
 // API Endpoints:
 //
 //	GET /api/v1/health
-//	    Health check endpoint.
+//	    Liveness check: the process is serving. Needs no API key.
+//
+//	GET /api/v1/ready
+//	    Readiness check: PostgreSQL answers and the server is not shutting
+//	    down; 503 otherwise. Needs no API key.
 //
 //	GET /api/v1/enrichment/{icao_hex}
 //	    Get all enrichments for an aircraft on today's date.
@@ -37,51 +64,119 @@
 //	POST /api/v1/enrichment/batch
 //	    Batch lookup for multiple aircraft. Body: {"aircraft": [{"icao_hex": "..."}]}
 //
+//	GET /api/v1/airlines
+//	    List the airline reference table.
+//
+//	GET /api/v1/airlines/{code}
+//	    Look up airlines by IATA or ICAO designator.
+//
+//	GET /api/v1/callsign/{flight}
+//	    Convert an IATA flight number to its ICAO callsign.
+//
+//	GET /api/v1/aircraft/{icao_hex}/flights
+//	    List an aircraft's past and current flights (?from, ?to, ?limit).
+//
+//	GET /api/v1/aircraft/{icao_hex}/flights/{callsign}/{date}/track
+//	    Export a flight's ACARS-derived position track as GeoJSON.
+//
+//	GET /api/v1/aircraft/{icao_hex}/flights/{callsign}/{date}/comms
+//	    List a flight's SELCAL codes and assigned frequencies.
+//
+//	GET /api/v1/aircraft/{icao_hex}/flights/{callsign}/{date}/squawks
+//	    List a flight's squawk assignment history.
+//
+//	GET /api/v1/stats/coverage
+//	    Parse coverage per day and label, recorded by the replay tool.
+//
+//	GET /api/v1/positions?bbox=WEST,SOUTH,EAST,NORTH
+//	    Latest ACARS position of each flight in an area (?since, ?limit).
+//
+//	GET /api/v1/positions/near?lat=&lon=&radius=
+//	    Flights with a recent position within radius NM of a point, nearest first.
+//
+//	GET /api/v1/aircraft/{icao_hex}/position
+//	    An aircraft's position dead-reckoned from its latest ACARS position
+//	    (?at, ?max_age).
+//
+//	GET /api/v1/messages
+//	    Search stored messages (?tail, ?flight, ?label, ?parser_type, ?from,
+//	    ?to, ?text, ?regex, ?limit, ?offset), newest first. Needs -search.
+//
+//	GET /api/v1/messages/{id}
+//	    Get one stored message with its parse result. Needs -search.
+//
+// gRPC:
+//
+//	With -grpc-port, the Acars service in api/acars.proto is also served:
+//	ParseMessage, GetEnrichment and StreamParsedMessages.
+//
 // Authentication:
 //
 //	When -auth is enabled, requests must include an API key via:
-//	  - X-API-Key header
-//	  - Authorization: Bearer <key> header
+//	  - X-API-Key header (x-api-key metadata for gRPC)
+//	  - Authorization: Bearer <key> header (authorization metadata for gRPC)
 //	  - ?api_key=<key> query parameter
+//
+//	A key scoped to tenants only finds enrichment derived from those tenants'
+//	feeds and messages they supplied, and is refused (403) the endpoints whose
+//	data is not kept by tenant: aircraft flights, stats, emergencies,
+//	positions, winds, turbulence and runway configuration.
 package main
 
 import (
 	"context"
 	"flag"
 	"fmt"
+	"log"
+	"net"
 	"os"
-	"strconv"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
 
 	"acars_parser/internal/api"
+	"acars_parser/internal/envflag"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
 	"acars_parser/internal/storage"
 )
 
 func main() {
 	// PostgreSQL connection flags.
-	pgHost := flag.String("pg-host", envOrDefault("POSTGRES_HOST", "localhost"), "PostgreSQL host")
-	pgPort := flag.Int("pg-port", envOrDefaultInt("POSTGRES_PORT", 5432), "PostgreSQL port")
-	pgUser := flag.String("pg-user", envOrDefault("POSTGRES_USER", "acars"), "PostgreSQL user")
-	pgPassword := flag.String("pg-password", envOrDefault("POSTGRES_PASSWORD", "acars"), "PostgreSQL password")
-	pgDB := flag.String("pg-database", envOrDefault("POSTGRES_DATABASE", "acars_state"), "PostgreSQL database")
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
+
+	// Message search flags.
+	search := flag.Bool("search", envflag.Bool("API_SEARCH", false), "Serve message search from ClickHouse")
+	chCfg := storage.AddClickHouseFlags(flag.CommandLine)
 
 	// API server flags.
-	port := flag.Int("port", 8081, "HTTP port for API server")
-	authEnabled := flag.Bool("auth", false, "Enable API key authentication")
-	apiKeys := flag.String("api-keys", "", "Comma-separated list of valid API keys (when auth enabled)")
+	port := flag.Int("port", envflag.Int("API_PORT", 8081), "HTTP port for API server")
+	grpcPort := flag.Int("grpc-port", envflag.Int("GRPC_PORT", 0), "gRPC port (0 = off)")
+	authEnabled := flag.Bool("auth", envflag.Bool("API_AUTH", false), "Enable API key authentication")
+	apiKeys := flag.String("api-keys", envflag.String("API_KEYS", ""), "Comma-separated list of valid API keys, each optionally scoped as KEY:TENANT|TENANT (when auth enabled)")
+
+	// Cache flags.
+	cacheTTL := flag.Duration("cache-ttl", envflag.Duration("CACHE_TTL", 30*time.Second), "Cache enrichment lookups for this long (0 = off)")
+	redisAddr := flag.String("redis-addr", envflag.String("REDIS_ADDR", ""), "Redis address for a shared cache (default: in-process)")
+	redisPassword := flag.String("redis-password", envflag.String("REDIS_PASSWORD", ""), "Redis password")
+
+	// Retention flags.
+	pruneInterval := flag.Duration("prune-interval", envflag.Duration("PRUNE_INTERVAL", 0), "Apply the retention policy this often (0 = off)")
+	retention := storage.AddRetentionFlags(flag.CommandLine)
+
+	shutdownTimeout := flag.Duration("shutdown-timeout", envflag.Duration("SHUTDOWN_TIMEOUT", api.DefaultShutdownTimeout), "Time allowed for in-flight requests on shutdown")
 
 	flag.Parse()
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Open PostgreSQL database.
-	pg, err := storage.OpenPostgres(ctx, storage.PostgresConfig{
-		Host:     *pgHost,
-		Port:     *pgPort,
-		Database: *pgDB,
-		User:     *pgUser,
-		Password: *pgPassword,
-	})
+	pg, err := storage.OpenPostgres(ctx, *pgCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening PostgreSQL: %v\n", err)
 		os.Exit(1)
@@ -97,31 +192,104 @@ func main() {
 		}
 	}
 
+	// Background work that uses the pool, waited for before it is closed.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if *pruneInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pruneLoop(ctx, pg, *retention, *pruneInterval)
+		}()
+	}
+
 	// Create and run server.
 	server := api.NewEnrichmentServer(pg, api.Config{
 		Port:        *port,
 		AuthEnabled: *authEnabled,
 		APIKeys:     keys,
+
+		CacheTTL:      *cacheTTL,
+		RedisAddr:     *redisAddr,
+		RedisPassword: *redisPassword,
+
+		ShutdownTimeout: *shutdownTimeout,
 	})
 
-	if err := server.Run(); err != nil {
+	if *search {
+		ch, err := storage.OpenClickHouse(ctx, *chCfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening ClickHouse: %v\n", err)
+			os.Exit(1)
+		}
+		defer ch.Close()
+		server.SetMessages(ch)
+	}
+
+	if *grpcPort > 0 {
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", *grpcPort))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listening for gRPC: %v\n", err)
+			os.Exit(1)
+		}
+		reg := registry.Default()
+		reg.Sort()
+		grpcServer := server.GRPCServer(reg)
+		log.Printf("gRPC API starting at localhost:%d", *grpcPort)
+		go func() {
+			if err := grpcServer.Serve(ln); err != nil {
+				fmt.Fprintf(os.Stderr, "gRPC server error: %v\n", err)
+				os.Exit(1)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			stopGRPC(grpcServer, *shutdownTimeout)
+		}()
+	}
+
+	if err := server.Run(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func envOrDefault(key, defaultVal string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// stopGRPC stops the gRPC server gracefully, cancelling the calls still
+// running (such as message streams) after timeout.
+func stopGRPC(gs *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		gs.Stop()
 	}
-	return defaultVal
 }
 
-func envOrDefaultInt(key string, defaultVal int) int {
-	if v := os.Getenv(key); v != "" {
-		if i, err := strconv.Atoi(v); err == nil {
-			return i
+// pruneLoop applies the retention policy every interval until ctx is done.
+func pruneLoop(ctx context.Context, pg *storage.PostgresDB, retention storage.Retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		results, err := pg.Prune(ctx, retention, time.Now().UTC(), false)
+		for _, r := range results {
+			if r.Rows > 0 {
+				log.Printf("Pruned %d rows from %s (before %s)", r.Rows, r.Table, r.Cutoff.Format(time.RFC3339))
+			}
+		}
+		if err != nil {
+			log.Printf("Prune failed: %v", err)
 		}
 	}
-	return defaultVal
-}
\ No newline at end of file
+}
@@ -0,0 +1,115 @@
+// Package main provides the corpus explorer, a terminal UI for the stored
+// messages.
+//
+// Messages are listed a page at a time, newest first, filtered by label,
+// parser type or raw text. The selected message's raw text is shown beside
+// its trace through the current parser registry (see cmd/trace), and it can
+// be marked golden or flagged for follow-up with a keystroke. The marks are
+// the review UI's annotations in PostgreSQL (see internal/explore).
+//
+// Usage:
+//
+//	explore [options]
+//
+// Keys:
+//
+//	j, k, arrows         Select the next or previous message
+//	n, p, PgDn, PgUp     Next or previous page
+//	g                    Mark or unmark the message as golden
+//	f                    Flag the message (asks for a reason), or unflag it
+//	l, t, /              Filter by label, parser type or raw text
+//	c                    Clear the filters
+//	r                    Reload the page
+//	q, Ctrl-C            Quit
+//
+// Options:
+//
+//	-ch-host HOST       ClickHouse host (default: localhost, env: CLICKHOUSE_HOST)
+//	-ch-port PORT       ClickHouse port (default: 9000, env: CLICKHOUSE_PORT)
+//	-ch-user USER       ClickHouse user (default: default, env: CLICKHOUSE_USER)
+//	-ch-password PASS   ClickHouse password (env: CLICKHOUSE_PASSWORD)
+//	-ch-database DB     ClickHouse database (default: acars, env: CLICKHOUSE_DATABASE)
+//	-pg-host HOST       PostgreSQL host (default: localhost, env: POSTGRES_HOST)
+//	-pg-port PORT       PostgreSQL port (default: 5432, env: POSTGRES_PORT)
+//	-pg-database DB     PostgreSQL database (default: acars_state, env: POSTGRES_DATABASE)
+//	-pg-user USER       PostgreSQL user (default: acars, env: POSTGRES_USER)
+//	-pg-password PASS   PostgreSQL password (default: acars, env: POSTGRES_PASSWORD)
+//	-pg-sslmode MODE    PostgreSQL SSL mode (default: disable, env: POSTGRES_SSLMODE)
+//	-label LABEL        Initial label filter
+//	-type TYPE          Initial parser type filter
+//	-text TEXT          Initial raw text filter
+//	-page N             Messages per page (default: 10)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"acars_parser/internal/explore"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+func main() {
+	// ClickHouse connection flags.
+	chCfg := storage.AddClickHouseFlags(flag.CommandLine)
+
+	// PostgreSQL connection flags.
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
+
+	label := flag.String("label", "", "Initial label filter")
+	parserType := flag.String("type", "", "Initial parser type filter")
+	text := flag.String("text", "", "Initial raw text filter")
+	pageSize := flag.Int("page", 10, "Messages per page")
+
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	ch, err := storage.OpenClickHouse(ctx, *chCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to ClickHouse: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = ch.Close() }()
+
+	pg, err := storage.OpenPostgres(ctx, *pgCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to PostgreSQL: %v\n", err)
+		os.Exit(1)
+	}
+	defer pg.Close()
+
+	reg := registry.Default()
+	reg.Sort()
+	filter := explore.Filter{Label: *label, ParserType: *parserType, Text: *text}
+	m := explore.New(explore.NewStore(ch, pg), reg, filter, *pageSize)
+
+	if err := run(ctx, m); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run puts the terminal in raw mode, runs the explorer and restores the
+// terminal.
+func run(ctx context.Context, m *explore.Model) error {
+	fd := int(os.Stdin.Fd())
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("stdin is not a terminal: %w", err)
+	}
+	defer restore()
+
+	resize := make(chan explore.ResizeMsg, 1)
+	stopResize := watchSize(int(os.Stdout.Fd()), resize)
+	defer stopResize()
+
+	return explore.Run(ctx, m, os.Stdin, os.Stdout, resize)
+}
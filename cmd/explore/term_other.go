@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"errors"
+
+	"acars_parser/internal/explore"
+)
+
+func makeRaw(int) (func(), error) {
+	return nil, errors.New("terminal raw mode is not supported on this platform")
+}
+
+func watchSize(int, chan<- explore.ResizeMsg) func() {
+	return func() {}
+}
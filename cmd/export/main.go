@@ -0,0 +1,228 @@
+// Package main provides the export tool, which writes the stored results of one
+// parser type to a CSV or Parquet file.
+//
+// Each row is one message: the message metadata (id, timestamp, label, parser,
+// flight, tail, origin, destination, confidence, missing fields, raw text)
+// followed by the parsed result flattened into columns. Nested objects become
+// dotted column names and arrays are kept as JSON strings; see the export
+// package for the column rules. The matching messages are read twice, once to
+// build the column schema and once to write the rows.
+//
+// Usage:
+//
+//	export -type TYPE -o FILE [options]
+//
+// Options:
+//
+//	-ch-host HOST       ClickHouse host (default: localhost, env: CLICKHOUSE_HOST)
+//	-ch-port PORT       ClickHouse port (default: 9000, env: CLICKHOUSE_PORT)
+//	-ch-user USER       ClickHouse user (default: default, env: CLICKHOUSE_USER)
+//	-ch-password PASS   ClickHouse password (env: CLICKHOUSE_PASSWORD)
+//	-ch-database DB     ClickHouse database (default: acars, env: CLICKHOUSE_DATABASE)
+//	-type TYPE          Parser type to export (e.g. flight_plan, adsc, pdc)
+//	-o FILE             Output file ("-" for stdout)
+//	-format FORMAT      csv or parquet (default: from the -o extension, else csv)
+//	-label LABEL        Only export messages with this ACARS label
+//	-from DATE          Only export messages at or after this time (RFC 3339 or YYYY-MM-DD)
+//	-to DATE            Only export messages before this time (RFC 3339 or YYYY-MM-DD)
+//	-batch N            Messages per ClickHouse round trip (default: 5000)
+//	-limit N            Maximum number of messages to export (0 = all)
+//	-list               List stored parser types with message counts
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"acars_parser/internal/export"
+	"acars_parser/internal/storage"
+)
+
+func main() {
+	// ClickHouse connection flags.
+	chCfg := storage.AddClickHouseFlags(flag.CommandLine)
+
+	parserType := flag.String("type", "", "Parser type to export (e.g. flight_plan, adsc, pdc)")
+	outPath := flag.String("o", "", "Output file (- for stdout)")
+	format := flag.String("format", "", "csv or parquet (default: from the -o extension, else csv)")
+	label := flag.String("label", "", "Only export messages with this ACARS label")
+	from := flag.String("from", "", "Only export messages at or after this time (RFC 3339 or YYYY-MM-DD)")
+	to := flag.String("to", "", "Only export messages before this time (RFC 3339 or YYYY-MM-DD)")
+	batchSize := flag.Int("batch", 5000, "Messages per ClickHouse round trip")
+	limit := flag.Int("limit", 0, "Maximum number of messages to export (0 = all)")
+	list := flag.Bool("list", false, "List stored parser types with message counts")
+
+	flag.Parse()
+
+	if !*list && (*parserType == "" || *outPath == "") {
+		fatalf("Either -list, or -type and -o, are required")
+	}
+
+	sel := storage.CHQueryParams{ParserType: *parserType, Label: *label}
+	var err error
+	if sel.From, err = parseTimeFlag(*from); err != nil {
+		fatalf("Invalid -from: %v", err)
+	}
+	if sel.To, err = parseTimeFlag(*to); err != nil {
+		fatalf("Invalid -to: %v", err)
+	}
+
+	if *format == "" {
+		*format = "csv"
+		if strings.EqualFold(filepath.Ext(*outPath), ".parquet") {
+			*format = "parquet"
+		}
+	}
+	if *format != "csv" && *format != "parquet" {
+		fatalf("Unknown -format %q (want csv or parquet)", *format)
+	}
+	if *format == "parquet" && *outPath == "-" {
+		fatalf("Parquet output needs a file, not stdout")
+	}
+
+	ctx := context.Background()
+	ch, err := storage.OpenClickHouse(ctx, *chCfg)
+	if err != nil {
+		fatalf("Error opening ClickHouse: %v", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	if *list {
+		if err := printTypes(ctx, ch); err != nil {
+			fatalf("Error counting messages: %v", err)
+		}
+		return
+	}
+
+	start := time.Now()
+
+	// First pass: collect the result columns.
+	schema := export.NewSchema()
+	total, err := scan(ctx, ch, sel, *batchSize, *limit, schema.Observe)
+	if err != nil {
+		fatalf("Error reading messages: %v", err)
+	}
+	if total == 0 {
+		fatalf("No stored messages of type %q match", *parserType)
+	}
+	cols := schema.Columns()
+
+	var out io.Writer = os.Stdout
+	if *outPath != "-" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fatalf("Error creating output: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+
+	var w export.Writer
+	if *format == "parquet" {
+		w, err = export.NewParquetWriter(out, cols)
+	} else {
+		w, err = export.NewCSVWriter(out, cols)
+	}
+	if err != nil {
+		fatalf("Error writing output: %v", err)
+	}
+
+	// Second pass: write the rows. Stopping at the first-pass count keeps
+	// messages stored in between out of the file, as they may carry fields
+	// the schema has not seen.
+	written, err := scan(ctx, ch, sel, *batchSize, total, func(m storage.CHMessage) error {
+		row, err := export.Row(cols, m)
+		if err != nil {
+			return err
+		}
+		return w.WriteRow(row)
+	})
+	if err != nil {
+		fatalf("Error writing output: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		fatalf("Error writing output: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d %s messages (%d columns) to %s in %s\n",
+		written, *parserType, len(cols), *outPath, time.Since(start).Round(time.Millisecond))
+}
+
+// scan calls fn for each message matching sel in ID order, paging by ID, and
+// returns the number of messages visited.
+func scan(ctx context.Context, ch *storage.ClickHouseDB, sel storage.CHQueryParams,
+	batchSize, limit int, fn func(storage.CHMessage) error) (int, error) {
+	sel.Limit = batchSize
+	sel.OrderBy = "id"
+
+	n := 0
+	for {
+		if limit > 0 && n >= limit {
+			return n, nil
+		}
+		if limit > 0 && limit-n < sel.Limit {
+			sel.Limit = limit - n
+		}
+
+		messages, err := ch.Query(ctx, sel)
+		if err != nil {
+			return n, err
+		}
+		if len(messages) == 0 {
+			return n, nil
+		}
+		for _, m := range messages {
+			if err := fn(m); err != nil {
+				return n, err
+			}
+			n++
+		}
+		sel.AfterID = messages[len(messages)-1].ID
+	}
+}
+
+// printTypes lists the stored parser types, most common first.
+func printTypes(ctx context.Context, ch *storage.ClickHouseDB) error {
+	counts, err := ch.CountByType(ctx)
+	if err != nil {
+		return err
+	}
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	fmt.Printf("%-24s %10s\n", "Type", "Messages")
+	for _, t := range types {
+		fmt.Printf("%-24s %10d\n", t, counts[t])
+	}
+	return nil
+}
+
+func parseTimeFlag(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
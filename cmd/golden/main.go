@@ -0,0 +1,192 @@
+// Package main provides the golden regression runner.
+//
+// Every golden message is re-parsed with the live parser registry and the output
+// is compared against its expected JSON field by field. The process exits with
+// status 1 when any golden message regresses, so it can gate parser changes in CI.
+//
+// Usage:
+//
+//	golden [options]
+//
+// Golden messages are read from the first configured source:
+//
+//	-file FILE          JSON export from the review UI (/api/export/json)
+//	-db FILE            Legacy SQLite messages database (is_golden = 1)
+//	(default)           PostgreSQL golden_annotations joined with ClickHouse messages
+//
+// Options:
+//
+//	-ch-host HOST       ClickHouse host (default: localhost, env: CLICKHOUSE_HOST)
+//	-ch-port PORT       ClickHouse port (default: 9000, env: CLICKHOUSE_PORT)
+//	-ch-user USER       ClickHouse user (default: default, env: CLICKHOUSE_USER)
+//	-ch-password PASS   ClickHouse password (env: CLICKHOUSE_PASSWORD)
+//	-ch-database DB     ClickHouse database (default: acars, env: CLICKHOUSE_DATABASE)
+//	-pg-host HOST       PostgreSQL host (default: localhost, env: POSTGRES_HOST)
+//	-pg-port PORT       PostgreSQL port (default: 5432, env: POSTGRES_PORT)
+//	-pg-database DB     PostgreSQL database (default: acars_state, env: POSTGRES_DATABASE)
+//	-pg-user USER       PostgreSQL user (default: acars, env: POSTGRES_USER)
+//	-pg-password PASS   PostgreSQL password (default: acars, env: POSTGRES_PASSWORD)
+//	-pg-sslmode MODE    PostgreSQL SSL mode (default: disable, env: POSTGRES_SSLMODE)
+//	-type TYPE          Only run golden messages of this parser type
+//	-extra              Also report fields present in the output but not in the expectation
+//	-json               Output outcomes as JSON
+//	-v                  Verbose output: list passing messages too
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"acars_parser/internal/golden"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// Exit codes.
+const (
+	exitOK         = 0
+	exitRegression = 1
+	exitError      = 2
+)
+
+func main() {
+	file := flag.String("file", "", "JSON export of golden messages")
+	dbPath := flag.String("db", "", "Legacy SQLite messages database")
+
+	// ClickHouse connection flags.
+	chCfg := storage.AddClickHouseFlags(flag.CommandLine)
+
+	// PostgreSQL connection flags.
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
+
+	parserType := flag.String("type", "", "Only run golden messages of this parser type")
+	showExtra := flag.Bool("extra", false, "Report fields present in the output but not in the expectation")
+	jsonOut := flag.Bool("json", false, "Output outcomes as JSON")
+	verbose := flag.Bool("v", false, "List passing messages too")
+
+	flag.Parse()
+
+	ctx := context.Background()
+
+	var cases []golden.Case
+	var err error
+	switch {
+	case *file != "":
+		cases, err = golden.LoadFile(*file)
+	case *dbPath != "":
+		cases, err = loadSQLite(*dbPath)
+	default:
+		cases, err = loadPostgres(ctx, *chCfg, *pgCfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading golden messages: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	if *parserType != "" {
+		filtered := cases[:0]
+		for _, c := range cases {
+			if c.ParserType == *parserType {
+				filtered = append(filtered, c)
+			}
+		}
+		cases = filtered
+	}
+
+	reg := registry.Default()
+	reg.Sort()
+	outcomes := golden.RunAll(reg, cases)
+
+	regressions := 0
+	for _, o := range outcomes {
+		if o.Regressed {
+			regressions++
+		}
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(outcomes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+			os.Exit(exitError)
+		}
+	} else {
+		printOutcomes(outcomes, *showExtra, *verbose)
+		fmt.Printf("\n%d golden messages, %d passed, %d regressed\n", len(outcomes), len(outcomes)-regressions, regressions)
+	}
+
+	if regressions > 0 {
+		os.Exit(exitRegression)
+	}
+	os.Exit(exitOK)
+}
+
+// printOutcomes writes a human-readable report of the outcomes.
+func printOutcomes(outcomes []golden.Outcome, showExtra, verbose bool) {
+	for _, o := range outcomes {
+		if !o.Regressed && !verbose && !(showExtra && len(o.Diffs) > 0) {
+			continue
+		}
+
+		status := "PASS"
+		if o.Regressed {
+			status = "FAIL"
+		}
+		fmt.Printf("%s  message %d  label=%s  type=%s\n", status, o.Case.ID, o.Case.Label, o.Case.ParserType)
+		if o.Case.Annotation != "" && o.Regressed {
+			fmt.Printf("      note: %s\n", o.Case.Annotation)
+		}
+
+		for _, d := range o.Diffs {
+			switch d.Kind {
+			case golden.DiffMissing:
+				fmt.Printf("      - %s: missing (expected %v)\n", d.Path, formatValue(d.Expected))
+			case golden.DiffChanged:
+				fmt.Printf("      ~ %s: expected %v, got %v\n", d.Path, formatValue(d.Expected), formatValue(d.Actual))
+			case golden.DiffExtra:
+				if showExtra {
+					fmt.Printf("      + %s: %v\n", d.Path, formatValue(d.Actual))
+				}
+			}
+		}
+	}
+}
+
+// formatValue renders a decoded JSON value compactly.
+func formatValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func loadSQLite(path string) ([]golden.Case, error) {
+	db, err := storage.OpenSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+	return golden.LoadSQLite(db)
+}
+
+func loadPostgres(ctx context.Context, chCfg storage.ClickHouseConfig, pgCfg storage.PostgresConfig) ([]golden.Case, error) {
+	ch, err := storage.OpenClickHouse(ctx, chCfg)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse: %w", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	pg, err := storage.OpenPostgres(ctx, pgCfg)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	defer pg.Close()
+
+	return golden.LoadPostgres(ctx, pg, ch)
+}
@@ -0,0 +1,138 @@
+// Package main provides the maintenance tool for the PostgreSQL state store.
+//
+// The prune command applies the retention policy: current flights not seen
+// within their retention are archived to flight_history, and rows older than
+// their table's retention are deleted. Run it from cron, or use the
+// enrichment API's -prune-interval to prune in the background.
+//
+// The nat-tracks command imports the North Atlantic tracks of a NAT track
+// message, in the published format, into nat_tracks, where process and replay
+// find them to resolve the track letters of oceanic clearances. Run it from
+// cron as each day's tracks are published; a message imported again replaces
+// the tracks it gave before.
+//
+// Usage:
+//
+//	maintenance [options] prune
+//	maintenance [options] nat-tracks FILE
+//
+// Options:
+//
+//	-pg-host HOST            PostgreSQL host (default: localhost, env: POSTGRES_HOST)
+//	-pg-port PORT            PostgreSQL port (default: 5432, env: POSTGRES_PORT)
+//	-pg-database DB          PostgreSQL database (default: acars_state, env: POSTGRES_DATABASE)
+//	-pg-user USER            PostgreSQL user (default: acars, env: POSTGRES_USER)
+//	-pg-password PASS        PostgreSQL password (default: acars, env: POSTGRES_PASSWORD)
+//	-pg-sslmode MODE         PostgreSQL SSL mode (default: disable, env: POSTGRES_SSLMODE)
+//	-keep-flight-state DUR   Archive current flights not seen for this long, and delete ETA
+//	                         reports as old (default: 2d)
+//	-keep-flight-history DUR Delete archived flights completed this long ago (default: 0, keep)
+//	-keep-positions DUR      Delete flight positions older than this (default: 90d)
+//	-keep-comms DUR          Delete comm assignments older than this (default: 90d)
+//	-keep-squawks DUR        Delete squawk history older than this (default: 90d)
+//	-keep-route-changes DUR  Delete route changes, flight plans and arrival runways older than this (default: 90d)
+//	-keep-emergencies DUR    Delete emergency events older than this (default: 0, keep)
+//	-keep-logons DUR         Delete AFN logons older than this (default: 90d)
+//	-keep-winds DUR          Delete wind grid cells older than this (default: 30d)
+//	-keep-observations DUR   Delete weather observations older than this (default: 90d)
+//	-keep-turbulence DUR     Delete turbulence reports older than this (default: 90d)
+//	-keep-enrichment DUR     Delete flight enrichment for older flights (default: 0, keep)
+//	-keep-atis DUR           Delete ATIS not updated for this long (default: 30d)
+//	-dry-run                 Report what would be pruned without changing anything
+//
+// Durations are Go durations (48h) or whole days (90d); 0 keeps a table's
+// rows forever. Each -keep-* flag also takes its default from the matching
+// KEEP_* environment variable, e.g. KEEP_POSITIONS for -keep-positions.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"acars_parser/internal/navdata"
+	"acars_parser/internal/storage"
+)
+
+func main() {
+	// PostgreSQL connection flags.
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
+
+	retention := storage.AddRetentionFlags(flag.CommandLine)
+	dryRun := flag.Bool("dry-run", false, "Report what would be pruned without changing anything")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] prune\n       %s [options] nat-tracks FILE\n\nOptions:\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch {
+	case flag.NArg() == 1 && flag.Arg(0) == "prune":
+	case flag.NArg() == 2 && flag.Arg(0) == "nat-tracks":
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	pg, err := storage.OpenPostgres(ctx, *pgCfg)
+	if err != nil {
+		fatalf("Error opening PostgreSQL: %v", err)
+	}
+	defer pg.Close()
+
+	if flag.Arg(0) == "nat-tracks" {
+		n, err := importNATTracks(ctx, pg, flag.Arg(1))
+		if err != nil {
+			pg.Close()
+			fatalf("Error importing NAT tracks: %v", err)
+		}
+		fmt.Printf("Imported %d NAT tracks\n", n)
+		return
+	}
+
+	results, err := pg.Prune(ctx, *retention, time.Now().UTC(), *dryRun)
+	verb := "pruned"
+	if *dryRun {
+		verb = "would be pruned"
+	}
+	for _, r := range results {
+		fmt.Printf("%-18s %8d rows %s (before %s)\n", r.Table, r.Rows, verb, r.Cutoff.Format(time.RFC3339))
+	}
+	if err != nil {
+		pg.Close()
+		fatalf("Error pruning: %v", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("No retention configured; nothing pruned.")
+	}
+}
+
+// importNATTracks imports the tracks of a NAT track message file into
+// nat_tracks, dating them in the year nearest now, and returns the number of
+// tracks imported.
+func importNATTracks(ctx context.Context, pg *storage.PostgresDB, path string) (int, error) {
+	tracks, err := navdata.LoadNATTrackFile(path, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	rows := make([]storage.NATTrack, len(tracks))
+	for i, t := range tracks {
+		rows[i] = storage.NATTrack{
+			Letter: t.Letter, Points: t.Points, ValidFrom: t.ValidFrom, ValidTo: t.ValidTo,
+			TMI: t.TMI, EastLevels: t.EastLevels, WestLevels: t.WestLevels,
+		}
+	}
+	if err := pg.UpsertNATTracks(ctx, rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
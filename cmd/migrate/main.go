@@ -0,0 +1,145 @@
+// Package main provides the migrate tool, which applies and reverts the
+// versioned PostgreSQL schema migrations.
+//
+// Migrations are embedded in the binary from internal/storage/migrations and
+// recorded in the schema_migrations table as they are applied. The replay tool
+// applies pending migrations on start; this tool is for applying them ahead of
+// a deployment, inspecting the schema version and rolling back.
+//
+// Usage:
+//
+//	migrate [options] status
+//	migrate [options] up [VERSION]
+//	migrate [options] down VERSION
+//
+// Commands:
+//
+//	status              List migrations and whether each has been applied
+//	up [VERSION]        Apply pending migrations up to VERSION (default: all)
+//	down VERSION        Revert applied migrations newer than VERSION
+//	                    (0 reverts everything)
+//
+// Options:
+//
+//	-pg-host HOST       PostgreSQL host (default: localhost, env: POSTGRES_HOST)
+//	-pg-port PORT       PostgreSQL port (default: 5432, env: POSTGRES_PORT)
+//	-pg-database DB     PostgreSQL database (default: acars_state, env: POSTGRES_DATABASE)
+//	-pg-user USER       PostgreSQL user (default: acars, env: POSTGRES_USER)
+//	-pg-password PASS   PostgreSQL password (default: acars, env: POSTGRES_PASSWORD)
+//	-pg-sslmode MODE    PostgreSQL SSL mode (default: disable, env: POSTGRES_SSLMODE)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"acars_parser/internal/storage"
+)
+
+func main() {
+	// PostgreSQL connection flags.
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] status | up [VERSION] | down VERSION\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 || len(args) > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := args[0]
+	target := 0
+	if len(args) == 2 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 0 {
+			fatalf("Invalid version %q", args[1])
+		}
+		target = v
+	}
+	switch {
+	case command == "down" && len(args) != 2:
+		fatalf("down needs the version to revert to (0 reverts everything)")
+	case command == "status" && len(args) != 1:
+		fatalf("status takes no arguments")
+	case command != "status" && command != "up" && command != "down":
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	pg, err := storage.OpenPostgres(ctx, *pgCfg)
+	if err != nil {
+		fatalf("Error opening PostgreSQL: %v", err)
+	}
+	defer pg.Close()
+
+	switch command {
+	case "status":
+		err = printStatus(ctx, pg)
+	case "up":
+		var done []storage.Migration
+		done, err = pg.MigrateUp(ctx, target)
+		for _, m := range done {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("Schema is up to date.")
+		}
+	case "down":
+		var done []storage.Migration
+		done, err = pg.MigrateDown(ctx, target)
+		for _, m := range done {
+			fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("Nothing to revert.")
+		}
+	}
+	if err != nil {
+		pg.Close()
+		fatalf("Error: %v", err)
+	}
+}
+
+// printStatus lists the embedded migrations with the time each was applied.
+func printStatus(ctx context.Context, pg *storage.PostgresDB) error {
+	migrations, err := storage.PostgresMigrations()
+	if err != nil {
+		return err
+	}
+	applied, err := pg.AppliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	appliedAt := make(map[int]string, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt.UTC().Format("2006-01-02 15:04:05Z")
+	}
+
+	for _, m := range migrations {
+		status := "pending"
+		if at, ok := appliedAt[m.Version]; ok {
+			status = "applied " + at
+		}
+		fmt.Printf("%04d_%-30s %s\n", m.Version, m.Name, status)
+	}
+	// Versions recorded by a newer build than this one.
+	for _, a := range applied {
+		if a.Version > migrations[len(migrations)-1].Version {
+			fmt.Printf("%04d_%-30s applied, unknown to this build\n", a.Version, a.Name)
+		}
+	}
+	return nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,268 @@
+// Package main provides the parse tool, which parses a single message.
+//
+// The message is dispatched through the parser registry as decode would
+// dispatch it, and the tool prints a human-readable summary of each result
+// followed by the results as JSON, in the envelope published to the sinks
+// (see output.Event), with their parser, confidence and canonical units. It
+// is the quickest way to see what one odd message parses to; to see why it
+// did not parse, use trace.
+//
+// Usage:
+//
+//	parse [options] -text TEXT
+//	parse [options] < message.json
+//
+// With -text, the message is TEXT with the label given by -label; a literal
+// "\n" in TEXT is treated as a newline. Otherwise a single JSON object is read
+// from stdin, in any of the formats decode accepts (see internal/input), so a
+// line copied from a feed or a JSONL file can be pasted in as it is. Corrupt
+// text is scored and repaired (see internal/quality) before it is dispatched.
+//
+// Options:
+//
+//	-text TEXT   Message text, instead of a JSON message on stdin
+//	-label LABEL ACARS label of the message given with -text (default: H1)
+//	-json        Print only the results as JSON
+//
+// The exit status is 0 when the message parsed, 1 when no parser matched it,
+// and 2 on an error.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/input"
+	"acars_parser/internal/output"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+)
+
+// Exit codes.
+const (
+	exitOK       = 0
+	exitUnparsed = 1
+	exitError    = 2
+)
+
+func main() {
+	text := flag.String("text", "", "Message text, instead of a JSON message on stdin")
+	label := flag.String("label", "H1", "ACARS label of the message given with -text")
+	jsonOut := flag.Bool("json", false, "Print only the results as JSON")
+
+	flag.Parse()
+
+	d, err := readMessage(*text, *label, os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	attributed := registry.Unattributed(d.Results)
+	var report quality.Report
+	msg := d.Message
+	if msg != nil {
+		reg := registry.Default()
+		reg.Sort()
+		msg, report = quality.Prepare(msg)
+		parsed := reg.DispatchAttributed(msg)
+		annotated := quality.Annotate(registry.Results(parsed), report)
+		for i := range parsed {
+			parsed[i].Result = annotated[i]
+		}
+		attributed = append(attributed, parsed...)
+	}
+	events := output.ResultEvents(msg, attributed, time.Now())
+
+	if !*jsonOut {
+		printSummary(msg, report, events)
+	}
+	if len(events) > 0 {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(events); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+	if len(events) == 0 {
+		os.Exit(exitUnparsed)
+	}
+	os.Exit(exitOK)
+}
+
+// readMessage returns the message given with -text, or else the JSON message
+// read from r.
+func readMessage(text, label string, r io.Reader) (*input.Decoded, error) {
+	if text != "" {
+		text = strings.ReplaceAll(text, `\n`, "\n")
+		return &input.Decoded{Message: &acars.Message{Label: label, Text: text}}, nil
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, fmt.Errorf("no message given: use -text, or write a JSON message to stdin")
+	}
+	return input.Decode(b)
+}
+
+// printSummary writes the message and a few lines per result.
+func printSummary(msg *acars.Message, report quality.Report, events []output.Event) {
+	if msg != nil {
+		header := []string{"Label " + orNone(msg.Label)}
+		if msg.Tail != "" {
+			header = append(header, "tail "+msg.Tail)
+		}
+		if msg.Flight != nil && strings.TrimSpace(msg.Flight.Flight) != "" {
+			header = append(header, "flight "+strings.TrimSpace(msg.Flight.Flight))
+		}
+		if msg.Timestamp != "" {
+			header = append(header, msg.Timestamp)
+		}
+		fmt.Printf("Message: %s\n", strings.Join(header, ", "))
+		if len(report.Issues) > 0 {
+			fmt.Printf("Quality: %.2f (%s)\n", report.Score, strings.Join(report.Issues, ", "))
+		} else {
+			fmt.Printf("Quality: %.2f\n", report.Score)
+		}
+	}
+	if len(events) == 0 {
+		fmt.Println("No parser matched this message; run trace to see why.")
+		return
+	}
+	for _, e := range events {
+		fmt.Println()
+		printResult(e)
+	}
+	fmt.Println()
+}
+
+// printResult writes the type and provenance of a result, its headline
+// fields, its canonical units and the rest of its fields.
+func printResult(e output.Event) {
+	provenance := "link layer"
+	if e.Parser != "" {
+		provenance = fmt.Sprintf("parser %s v%d", e.Parser, e.ParserVersion)
+	}
+	if e.Confidence != nil {
+		provenance += fmt.Sprintf(", confidence %.2f", *e.Confidence)
+	}
+	fmt.Printf("%s (%s)\n", e.Type, provenance)
+
+	fields := resultFields(e.Data)
+	shown := map[string]bool{"quality": true, "message_id": true}
+	line := func(name, value string) {
+		fmt.Printf("  %-18s %s\n", name+":", value)
+	}
+
+	for _, k := range []string{"flight", "flight_number", "callsign", "tail", "registration", "aircraft_type"} {
+		if v, ok := scalar(fields[k]); ok {
+			line(k, v)
+			shown[k] = true
+		}
+	}
+	origin, hasOrigin := scalar(fields["origin"])
+	destination, hasDestination := scalar(fields["destination"])
+	if hasOrigin || hasDestination {
+		line("route", orNone(origin)+" -> "+orNone(destination))
+		shown["origin"], shown["destination"] = true, true
+	}
+	if lat, ok := fields["latitude"].(float64); ok {
+		if lon, ok := fields["longitude"].(float64); ok {
+			line("position", fmt.Sprintf("%.4f, %.4f", lat, lon))
+			shown["latitude"], shown["longitude"] = true, true
+		}
+	}
+
+	quantities := make([]string, 0, len(e.Units))
+	for q := range e.Units {
+		quantities = append(quantities, q)
+	}
+	sort.Strings(quantities)
+	for _, q := range quantities {
+		v := e.Units[q]
+		original, _ := json.Marshal(v.Original)
+		line(q, fmt.Sprintf("%s %s (%s %s)", formatNumber(v.Value), v.Unit, v.Field, original))
+		if !strings.Contains(v.Field, ".") {
+			shown[v.Field] = true
+		}
+	}
+
+	var rest []string
+	for k := range fields {
+		if !shown[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	for _, k := range rest {
+		if v, ok := scalar(fields[k]); ok {
+			line(k, v)
+			continue
+		}
+		switch v := fields[k].(type) {
+		case []interface{}:
+			if len(v) > 0 {
+				line(k, fmt.Sprintf("%d items", len(v)))
+			}
+		case map[string]interface{}:
+			if len(v) > 0 {
+				line(k, fmt.Sprintf("%d fields", len(v)))
+			}
+		}
+	}
+}
+
+// resultFields returns the top-level fields of a result as JSON decodes them.
+func resultFields(r interface{}) map[string]interface{} {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// scalar formats a non-empty string, number or true boolean.
+func scalar(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		return formatNumber(v), true
+	case bool:
+		return "yes", v
+	}
+	return "", false
+}
+
+// formatNumber writes whole numbers without a decimal point.
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%g", v)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,477 @@
+// Package main provides the process tool, which runs ingest, parsing, state
+// tracking and publishing in one daemon.
+//
+// Messages are read from a NATS subject, from files, or from stdin, then
+// deduplicated, parsed, matched against alert rules, published to the
+// configured sinks, and applied to the PostgreSQL state tables (see
+// internal/pipeline for the stages). A deployment is this one binary and one
+// configuration file, or environment variables alone, instead of decode piped
+// into separate consumers.
+//
+// Usage:
+//
+//	process [-config FILE] [-v]
+//
+// Options:
+//
+//	-config FILE   Configuration file, JSON (env: PROCESS_CONFIG)
+//	-v             Report input that could not be decoded and per-message errors
+//
+// The configuration file holds every setting; a section left out takes the
+// default of the decode and replay commands. $VAR and ${VAR} are replaced with
+// environment variables before parsing. Without a file the defaults are used.
+// Either way, the environment variables of the matching decode and replay
+// flags (POSTGRES_*, INPUT_FORMAT, FEEDER_ID, TENANT, DEDUP_WINDOW,
+// MIN_QUALITY, CLOCK_SKEW, NATS_URL, MQTT_BROKER, KAFKA_BROKERS and so on)
+// override the file, and INPUT_NATS_URL, INPUT_NATS_SUBJECT, INPUT_NATS_QUEUE and
+// INPUT_NATS_CREDS configure the NATS input:
+//
+//	{
+//	  "input": {
+//	    "nats": {"url": "nats://localhost:4222", "subject": "acars.raw", "queue": "process"},
+//	    "feeder_id": "SYD-1",
+//	    "tenant": "plane-watch",
+//	    "tenants": {"partner-a-1": "partner-a"}
+//	  },
+//	  "dedup_window": "1m",
+//	  "min_quality": 0.5,
+//	  "clock": {"skew": {"YSSY-1": "90s"}, "estimate_skew": true},
+//	  "postgres": {"host": "localhost", "user": "acars", "password": "${POSTGRES_PASSWORD}"},
+//	  "lifecycle": {"inactivity": "6h", "arrival_grace": "30m"},
+//	  "registry_file": "registry.csv",
+//	  "airways_file": "airways.csv",
+//	  "cifp_file": "FAACIFP18",
+//	  "pdc_formats": "pdc-formats/",
+//	  "alerts": "alerts.json",
+//	  "output": {"nats": {"url": "nats://localhost:4222", "subject": "acars.{kind}.{label}"}},
+//	  "queue": {"size": 10000, "spill_dir": "/var/lib/acars/queue", "policies": {"unparsed": "drop"}},
+//	  "wal": {"dir": "/var/lib/acars/wal", "segment_age": "1h", "retention": "720h"},
+//	  "stats_interval": "5m"
+//	}
+//
+// Without "nats", the files in "files" are read in turn, or stdin when there
+// are none, in the "format" of the input section (json or raw); the process
+// exits at the end of the input. Airlines, airports and ground stations are
+// read from PostgreSQL, so import them with replay first. NAT tracks, imported
+// with maintenance nat-tracks, are read at start and every 15 minutes after. Parse coverage statistics
+// are not recorded; replay records them.
+//
+// With a "queue" size above 0, state updates are applied behind a queue of
+// that many messages, so that a PostgreSQL outage holds up state but not
+// parsing and publishing; updates failing while the database is down are
+// retried until it is back. Once the queue is full, messages are written to
+// "spill_dir" if one is given, and otherwise the input waits, except for the
+// result types the "policies" set to "drop" (with "unparsed" for messages no
+// parser matched), which are dropped. QUEUE_SIZE and QUEUE_SPILL_DIR override
+// the file.
+//
+// With a "wal" directory, every line of input is appended, before it is
+// decoded, to a write-ahead log of compressed segments (see internal/wal),
+// which replay -wal reads back. Raw input is logged as the messages decoded
+// from it. WAL_DIR and WAL_RETENTION override the file.
+//
+// On SIGINT or SIGTERM the input is closed, the messages already received are
+// processed, flights are archived as usual, and the sinks are flushed before
+// exiting. The state queue is given "drain_timeout" (QUEUE_DRAIN_TIMEOUT,
+// default 30s) to empty; what is left is kept in the spill directory for the
+// next run, or lost without one.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"acars_parser/internal/airline"
+	"acars_parser/internal/airport"
+	"acars_parser/internal/alert"
+	"acars_parser/internal/dedup"
+	"acars_parser/internal/input"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/navdata"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/parsers/h1"
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/pipeline"
+	"acars_parser/internal/registration"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+	"acars_parser/internal/wal"
+)
+
+func main() {
+	configPath := flag.String("config", os.Getenv("PROCESS_CONFIG"), "Configuration file, JSON")
+	verbose := flag.Bool("v", false, "Report undecodable input and per-message errors")
+	flag.Parse()
+
+	cfg, err := pipeline.Load(*configPath)
+	if err != nil {
+		fatalf("Error loading configuration: %v", err)
+	}
+
+	if cfg.AirwaysFile != "" {
+		airways, err := navdata.LoadAirwaysFile(cfg.AirwaysFile)
+		if err != nil {
+			fatalf("Error loading airways: %v", err)
+		}
+		h1.SetAirways(airways)
+		fmt.Printf("Loaded %d airways from %s\n", airways.Len(), cfg.AirwaysFile)
+	}
+	if cfg.CIFPFile != "" {
+		procedures, err := navdata.LoadCIFPFile(cfg.CIFPFile)
+		if err != nil {
+			fatalf("Error loading procedures: %v", err)
+		}
+		navdata.SetDefaultProcedures(procedures)
+		fmt.Printf("Loaded %d procedures from %s\n", procedures.Len(), cfg.CIFPFile)
+	}
+	if cfg.PDCFormats != "" {
+		n, err := pdc.LoadFormats(cfg.PDCFormats)
+		if err != nil {
+			fatalf("Error loading PDC formats: %v", err)
+		}
+		fmt.Printf("Loaded %d PDC formats from %s\n", n, cfg.PDCFormats)
+	}
+
+	// Writes use ctx, so a signal stops the input without cutting off the
+	// processing of what was already received.
+	ctx := context.Background()
+	stopCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pg, err := storage.OpenPostgres(ctx, cfg.StorageConfig())
+	if err != nil {
+		fatalf("Error opening PostgreSQL: %v", err)
+	}
+	defer pg.Close()
+	if err := pg.CreateSchema(ctx); err != nil {
+		fatalf("Error creating schema: %v", err)
+	}
+
+	tracker := state.NewTracker(pg)
+	tracker.SetLifecycle(cfg.StateLifecycle())
+	if cfg.RegistryFile != "" {
+		resolver := registration.NewResolver()
+		if err := resolver.LoadFile(cfg.RegistryFile); err != nil {
+			fatalf("Error loading registry: %v", err)
+		}
+		tracker.SetResolver(resolver)
+		fmt.Printf("Loaded %d registrations from %s\n", resolver.Len(), cfg.RegistryFile)
+	}
+	airlines, err := loadAirlines(ctx, pg)
+	if err != nil {
+		fatalf("Error loading airlines: %v", err)
+	}
+	tracker.SetAirlines(airlines)
+	airports, err := loadAirports(ctx, pg)
+	if err != nil {
+		fatalf("Error loading airports: %v", err)
+	}
+	airport.SetDefault(airports)
+	natTracks, err := loadNATTracks(ctx, pg, time.Now().Add(-natTrackHistory))
+	if err != nil {
+		fatalf("Error loading NAT tracks: %v", err)
+	}
+	navdata.SetDefaultNATTracks(natTracks)
+
+	sink, err := cfg.SinkConfig().Open()
+	if err != nil {
+		fatalf("Error opening sink: %v", err)
+	}
+	if sink != nil {
+		defer func() {
+			if err := sink.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing sink: %v\n", err)
+			}
+		}()
+		tracker.SetSink(sink)
+	}
+
+	var alerts *alert.Engine
+	if cfg.Alerts != "" {
+		rules, err := alert.Load(cfg.Alerts)
+		if err != nil {
+			fatalf("Error loading alert rules: %v", err)
+		}
+		if alerts, err = alert.New(rules); err != nil {
+			fatalf("Error loading alert rules: %v", err)
+		}
+		defer func() {
+			if err := alerts.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing alerts: %v\n", err)
+			}
+		}()
+	}
+
+	reg := registry.Default()
+	reg.Sort()
+	stages := pipeline.Stages{
+		Registry:   reg,
+		Clock:      msgtime.New(cfg.TimeConfig()),
+		Tracker:    tracker,
+		Sink:       sink,
+		Alerts:     alerts,
+		FeederID:   cfg.Input.FeederID,
+		Tenants:    cfg.Input.Tenants,
+		TenantID:   cfg.Input.Tenant,
+		MinQuality: cfg.MinQuality,
+		Ready:      pg.Ping,
+	}
+	if cfg.DedupWindow > 0 {
+		stages.Filter = dedup.New(time.Duration(cfg.DedupWindow))
+	}
+	p := pipeline.New(stages)
+
+	report := func(err error) {
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+	if cfg.Queue.Size > 0 {
+		sq := cfg.StateQueue()
+		sq.Report = report
+		if err := p.SetQueue(sq); err != nil {
+			fatalf("Error opening state queue: %v", err)
+		}
+		if s := p.Stats(); s.Spilled > 0 {
+			fmt.Printf("Resuming %d messages spilled to %s\n", s.Spilled, cfg.Queue.SpillDir)
+		}
+	}
+
+	var walLog *wal.Writer
+	var record input.Recorder
+	if cfg.WAL.Dir != "" {
+		if walLog, err = wal.Open(cfg.WALConfig()); err != nil {
+			fatalf("Error opening write-ahead log: %v", err)
+		}
+		defer func() {
+			if err := walLog.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing write-ahead log: %v\n", err)
+			}
+		}()
+		record = func(line []byte) {
+			if err := walLog.Append(line); err != nil {
+				report(err)
+			}
+		}
+	}
+
+	start := time.Now()
+	// NAT tracks are published twice a day, so reload them while running.
+	stopTickers := startTickers(
+		ticker{interval: time.Duration(cfg.StatsInterval), fn: func() { printProgress(p.Stats(), start) }},
+		ticker{interval: natTrackRefresh, fn: func() {
+			tracks, err := loadNATTracks(ctx, pg, time.Now().Add(-natTrackHistory))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading NAT tracks: %v\n", err)
+				return
+			}
+			navdata.SetDefaultNATTracks(tracks)
+		}},
+	)
+	defer stopTickers()
+
+	if n := cfg.Input.NATS; n != nil {
+		in, err := input.NewNATSStream(input.NATSConfig{URL: n.URL, Creds: n.Creds, Subject: n.Subject, Queue: n.Queue})
+		if err != nil {
+			fatalf("Error opening input: %v", err)
+		}
+		fmt.Printf("Reading %s from %s\n", n.Subject, n.URL)
+		run(ctx, stopCtx, p, in, in, record, report)
+	} else if len(cfg.Input.Files) == 0 {
+		run(ctx, stopCtx, p, streamOf(cfg.Input.Format, os.Stdin), os.Stdin, record, report)
+	}
+	for _, name := range cfg.Input.Files {
+		if stopCtx.Err() != nil {
+			break
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			fatalf("Error opening input: %v", err)
+		}
+		run(ctx, stopCtx, p, streamOf(cfg.Input.Format, f), f, record, report)
+		f.Close()
+	}
+
+	drainCtx, cancel := drainContext(ctx, stopCtx, time.Duration(cfg.Queue.DrainTimeout))
+	if err := p.Close(drainCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error closing state queue: %v\n", err)
+	}
+	cancel()
+
+	if _, err := tracker.Expire(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error archiving flights: %v\n", err)
+	}
+
+	if stopCtx.Err() != nil {
+		fmt.Printf("\nInterrupted after %s\n", time.Since(start).Round(time.Second))
+	} else {
+		fmt.Printf("\nInput complete in %s\n", time.Since(start).Round(time.Second))
+	}
+	s := p.Stats()
+	fmt.Printf("  Inputs:      %d (%d undecodable)\n", s.Inputs, s.Undecodable)
+	fmt.Printf("  Messages:    %d (%d duplicates suppressed)\n", s.Messages, s.Duplicates)
+	fmt.Printf("  Parsed:      %d\n", s.Parsed)
+	if cfg.MinQuality > 0 {
+		fmt.Printf("  Low quality: %d skipped\n", s.LowQuality)
+	}
+	fmt.Printf("  Errors:      %d\n", s.StateFailed)
+	if cfg.Queue.Size > 0 {
+		fmt.Printf("  Queue:       %d spilled, %d dropped, %d retries\n", s.Spilled, s.Dropped, s.StateRetries)
+	}
+	if sink != nil {
+		fmt.Printf("  Published:   %d events, %d failed\n", s.Published, s.PublishFailed)
+	}
+	if alerts != nil {
+		fmt.Printf("  Alerts:      %d sent, %d messages with failed alerts\n", s.Alerted, s.AlertFailed)
+	}
+	if walLog != nil {
+		ws := walLog.Stats()
+		fmt.Printf("  WAL:         %d lines in %d segments, %d failed\n", ws.Lines, ws.Segments, ws.Failed)
+	}
+	ts := tracker.Stats()
+	fmt.Printf("  Flights:     %d upserts, %d archived\n", ts.Flights, ts.Archived)
+	fmt.Printf("  Positions:   %d recorded, %d rejected as implausible\n", ts.Positions, ts.RejectedPositions)
+	fmt.Printf("  Emergencies: %d events\n", ts.Emergencies)
+}
+
+const (
+	// natTrackHistory is how long after they expire NAT tracks are kept, for
+	// messages that arrive late.
+	natTrackHistory = 24 * time.Hour
+	// natTrackRefresh is how often the NAT tracks are reloaded.
+	natTrackRefresh = 15 * time.Minute
+)
+
+// run processes one input until it ends or stop is cancelled, passing what is
+// read to record if it is set. Closing the input on a signal unblocks a read
+// waiting for a live feed.
+func run(ctx, stop context.Context, p *pipeline.Pipeline, in input.Stream, closer io.Closer, record input.Recorder, report func(error)) {
+	if r, ok := in.(input.Recordable); ok && record != nil {
+		r.SetRecorder(record)
+	}
+	defer context.AfterFunc(stop, func() { _ = closer.Close() })()
+	if err := p.Run(ctx, in, report); err != nil {
+		fatalf("Error reading input: %v", err)
+	}
+}
+
+// ticker is a task run at an interval while processing.
+type ticker struct {
+	interval time.Duration // Not run if zero.
+	fn       func()
+}
+
+// startTickers runs each task every interval, on a goroutine of its own. The
+// returned function stops them all and waits for them to return.
+func startTickers(tasks ...ticker) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		if task.interval <= 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(task ticker) {
+			defer wg.Done()
+			t := time.NewTicker(task.interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-t.C:
+					task.fn()
+				case <-done:
+					return
+				}
+			}
+		}(task)
+	}
+	return func() { close(done); wg.Wait() }
+}
+
+// drainContext returns the context the state queue is drained with: it is
+// cancelled timeout after stop is, so that a signal during the drain, or
+// before it, cuts it short.
+func drainContext(ctx, stop context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(ctx)
+	unwatch := context.AfterFunc(stop, func() { time.AfterFunc(timeout, cancel) })
+	return drainCtx, func() { unwatch(); cancel() }
+}
+
+// streamOf returns a stream over r. The format was checked when the
+// configuration was loaded.
+func streamOf(format string, r io.Reader) input.Stream {
+	in, err := input.NewStream(format, r)
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	return in
+}
+
+// printProgress writes the running totals.
+func printProgress(s pipeline.Stats, start time.Time) {
+	fmt.Printf("Processed %d messages (%d parsed, %d duplicates, %d errors) in %s\n",
+		s.Messages, s.Parsed, s.Duplicates, s.StateFailed, time.Since(start).Round(time.Second))
+	if s.Queued+s.Spilled+s.Dropped > 0 {
+		fmt.Printf("  State queue: %d queued, %d spilled, %d dropped\n", s.Queued, s.Spilled, s.Dropped)
+	}
+}
+
+// loadAirlines returns the airline table stored in PostgreSQL.
+func loadAirlines(ctx context.Context, pg *storage.PostgresDB) (*airline.Table, error) {
+	rows, err := pg.ListAirlines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := airline.NewTable()
+	for _, r := range rows {
+		if err := table.Add(airline.Airline{ICAO: r.ICAOCode, IATA: r.IATACode, Name: r.Name}); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// loadAirports returns the airport table stored in PostgreSQL.
+func loadAirports(ctx context.Context, pg *storage.PostgresDB) (*airport.Table, error) {
+	rows, err := pg.ListAirports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := airport.NewTable()
+	for _, r := range rows {
+		if err := table.Add(airport.Airport{ICAO: r.ICAOCode, IATA: r.IATACode, Name: r.Name, Country: r.Country, TZ: r.TZ}); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// loadNATTracks returns the NAT tracks stored in PostgreSQL that are valid
+// after a time.
+func loadNATTracks(ctx context.Context, pg *storage.PostgresDB, after time.Time) (*navdata.NATTracks, error) {
+	rows, err := pg.ListNATTracks(ctx, after)
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]navdata.NATTrack, 0, len(rows))
+	for _, r := range rows {
+		tracks = append(tracks, navdata.NATTrack{
+			Letter: r.Letter, Points: r.Points, ValidFrom: r.ValidFrom, ValidTo: r.ValidTo,
+			TMI: r.TMI, EastLevels: r.EastLevels, WestLevels: r.WestLevels,
+		})
+	}
+	return navdata.NewNATTracks(tracks), nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
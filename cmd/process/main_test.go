@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartTickersStop(t *testing.T) {
+	var stats, nat atomic.Int32
+	stop := startTickers(
+		ticker{interval: time.Millisecond, fn: func() { stats.Add(1) }},
+		ticker{interval: time.Millisecond, fn: func() { nat.Add(1) }},
+		ticker{fn: func() { t.Error("a task without an interval ran") }},
+	)
+	deadline := time.Now().Add(time.Second)
+	for (stats.Load() == 0 || nat.Load() == 0) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats.Load() == 0 || nat.Load() == 0 {
+		t.Fatalf("tasks ran %d and %d times, want both to run", stats.Load(), nat.Load())
+	}
+
+	stopped := make(chan struct{})
+	go func() { stop(); close(stopped) }()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop did not return")
+	}
+	n := stats.Load()
+	time.Sleep(5 * time.Millisecond)
+	if stats.Load() != n {
+		t.Error("a task ran after stop returned")
+	}
+}
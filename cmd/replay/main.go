@@ -0,0 +1,584 @@
+// Package main provides the replay tool, which rebuilds PostgreSQL state from
+// the SQLite message corpus.
+//
+// Every message in messages.db is re-parsed with the current parser registry in
+// timestamp order, and the extracted data is written to the aircraft, waypoints,
+// routes, atis_current, flight_state and flight_enrichment tables. Flights that
+// have arrived or gone quiet are archived to flight_history as replay time
+// passes them. This materialises the output of newly added parsers for
+// historical messages.
+//
+// Parse coverage is recorded per day and label in parse_stats (with the
+// messages each parser matched in parse_stats_parsers), replacing the stored
+// figures for every day replayed. Replaying recent days regularly therefore
+// builds a coverage trend, served by the enrichment API's
+// /api/v1/stats/coverage endpoint.
+//
+// Usage:
+//
+//	replay [options]
+//
+// Options:
+//
+//	-db FILE            SQLite messages database (default: messages.db)
+//	-wal DIR            Replay the write-ahead log of raw input kept by process
+//	                    in DIR instead of the SQLite database
+//	-pg-host HOST       PostgreSQL host (default: localhost, env: POSTGRES_HOST)
+//	-pg-port PORT       PostgreSQL port (default: 5432, env: POSTGRES_PORT)
+//	-pg-database DB     PostgreSQL database (default: acars_state, env: POSTGRES_DATABASE)
+//	-pg-user USER       PostgreSQL user (default: acars, env: POSTGRES_USER)
+//	-pg-password PASS   PostgreSQL password (default: acars, env: POSTGRES_PASSWORD)
+//	-pg-sslmode MODE    PostgreSQL SSL mode (default: disable, env: POSTGRES_SSLMODE)
+//	-label LABEL        Only replay messages with this ACARS label
+//	-from DATE          Only replay messages at or after this time (RFC 3339 or YYYY-MM-DD)
+//	-to DATE            Only replay messages before this time (RFC 3339 or YYYY-MM-DD)
+//	-limit N            Maximum number of messages to replay (0 = all)
+//	-reset              Truncate derived state tables before replaying
+//	-airways FILE       Airway database CSV used to expand FPN routes (env: AIRWAYS_FILE)
+//	-registry FILE      Registration to ICAO hex CSV for aircraft outside the
+//	                    algorithmic (US, Australian) blocks (env: REGISTRY_FILE)
+//	-cifp FILE          ARINC 424 (CIFP) file used to resolve SIDs and STARs (env: CIFP_FILE)
+//	-pdc-formats PATH   PDC format definitions (JSON or YAML file, or a directory of
+//	                    them) added to the built-in formats (env: PDC_FORMATS)
+//	-airlines FILE      Airline CSV (iata,icao,name) imported into the airlines table
+//	                    before replaying (env: AIRLINES_FILE)
+//	-airports FILE      Airport CSV (iata,icao,name,country) imported into the airports
+//	                    table and used to resolve IATA-only clearances (env: AIRPORTS_FILE)
+//	-ground-stations FILE
+//	                    Ground station CSV (kind,id,provider,name,region) imported
+//	                    into the ground_station_info table (env: GROUND_STATIONS_FILE)
+//	-dedup-window DUR   Suppress copies of a message (same tail, label and text)
+//	                    received within this window; 0 replays every stored copy
+//	                    (default: 1m, env: DEDUP_WINDOW)
+//	-min-quality N      Skip state updates from messages whose text quality score
+//	                    is below N, from 0 to 1 (default: 0, env: MIN_QUALITY)
+//	-inactivity DUR     Archive flights with no message for this long (default: 6h,
+//	                    env: INACTIVITY)
+//	-arrival-grace DUR  Keep arrived flights current for this long before archiving
+//	                    them (default: 30m, env: ARRIVAL_GRACE)
+//	-mqtt URL           Publish flight enrichment updates to this MQTT broker
+//	                    (env: MQTT_BROKER); see cmd/decode for the other sink
+//	                    flags (-mqtt-topic, -kafka, -kafka-topic, -nats, -sink-format, ...)
+//	-kafka BROKERS      Publish flight enrichment updates to these Kafka brokers
+//	                    (env: KAFKA_BROKERS)
+//	-dry-run            Parse messages and report counts without writing to PostgreSQL
+//	-v                  Verbose output
+//
+// Unless it is a dry run, the NAT tracks imported with maintenance nat-tracks
+// are read to resolve the track letters of oceanic clearances.
+//
+// With -wal, the messages are read from the segments of a write-ahead log (see
+// internal/wal) rather than from messages.db: exactly what process received,
+// decoded afresh in the order it was received, so that improved decoders and
+// parsers can be run over it whatever has changed in the stored schema since.
+// -from and -to select segments as well as messages, so a short window reads
+// only the segments that cover it. Input lines that cannot be decoded are
+// counted and, with -v, reported.
+//
+// On SIGINT or SIGTERM the replay stops after the message in hand, saves the
+// parse stats, archives flights as usual, and flushes the sinks before exiting;
+// rerun it with -from to continue.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/airline"
+	"acars_parser/internal/airport"
+	"acars_parser/internal/dedup"
+	"acars_parser/internal/envflag"
+	"acars_parser/internal/groundstation"
+	"acars_parser/internal/input"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/navdata"
+	"acars_parser/internal/output"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/parsers/h1"
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registration"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+	"acars_parser/internal/wal"
+)
+
+// progressInterval is the number of messages between progress reports.
+const progressInterval = 100000
+
+func main() {
+	dbPath := flag.String("db", "messages.db", "SQLite messages database")
+	walDir := flag.String("wal", "", "Replay the write-ahead log in this directory instead of the SQLite database")
+
+	// PostgreSQL connection flags.
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
+
+	// Replay selection flags.
+	label := flag.String("label", "", "Only replay messages with this ACARS label")
+	from := flag.String("from", "", "Only replay messages at or after this time (RFC 3339 or YYYY-MM-DD)")
+	to := flag.String("to", "", "Only replay messages before this time (RFC 3339 or YYYY-MM-DD)")
+	limit := flag.Int("limit", 0, "Maximum number of messages to replay (0 = all)")
+	reset := flag.Bool("reset", false, "Truncate derived state tables before replaying")
+	airwaysFile := flag.String("airways", envflag.String("AIRWAYS_FILE", ""), "Airway database CSV used to expand FPN routes")
+	registryFile := flag.String("registry", envflag.String("REGISTRY_FILE", ""), "Registration to ICAO hex CSV")
+	cifpFile := flag.String("cifp", envflag.String("CIFP_FILE", ""), "ARINC 424 (CIFP) file used to resolve SIDs and STARs")
+	pdcFormats := flag.String("pdc-formats", envflag.String("PDC_FORMATS", ""), "PDC format definitions (file or directory) added to the built-in formats")
+	airlinesFile := flag.String("airlines", envflag.String("AIRLINES_FILE", ""), "Airline CSV imported into the airlines table")
+	airportsFile := flag.String("airports", envflag.String("AIRPORTS_FILE", ""), "Airport CSV imported into the airports table")
+	groundStationsFile := flag.String("ground-stations", envflag.String("GROUND_STATIONS_FILE", ""), "Ground station CSV imported into the ground_station_info table")
+	dedupWindow := flag.Duration("dedup-window", envflag.Duration("DEDUP_WINDOW", dedup.DefaultWindow), "Suppress copies of a message received within this window (0 disables)")
+	minQuality := flag.Float64("min-quality", envflag.Float64("MIN_QUALITY", 0), "Skip state updates from messages scoring below this text quality (0-1)")
+	lifecycle := state.DefaultLifecycle()
+	flag.DurationVar(&lifecycle.Inactivity, "inactivity", envflag.Duration("INACTIVITY", lifecycle.Inactivity), "Archive flights with no message for this long")
+	flag.DurationVar(&lifecycle.Grace, "arrival-grace", envflag.Duration("ARRIVAL_GRACE", lifecycle.Grace), "Keep arrived flights current for this long before archiving")
+	sinkCfg := output.AddFlags(flag.CommandLine)
+	dryRun := flag.Bool("dry-run", false, "Parse messages without writing to PostgreSQL")
+	verbose := flag.Bool("v", false, "Verbose output")
+
+	flag.Parse()
+
+	params := storage.ScanParams{Label: *label, Limit: *limit}
+	var err error
+	if params.From, err = parseTimeFlag(*from); err != nil {
+		fatalf("Invalid -from: %v", err)
+	}
+	if params.To, err = parseTimeFlag(*to); err != nil {
+		fatalf("Invalid -to: %v", err)
+	}
+	if *dedupWindow < 0 {
+		fatalf("Invalid -dedup-window: %v is negative", *dedupWindow)
+	}
+
+	if *airwaysFile != "" {
+		airways, err := navdata.LoadAirwaysFile(*airwaysFile)
+		if err != nil {
+			fatalf("Error loading airways: %v", err)
+		}
+		h1.SetAirways(airways)
+		if *verbose {
+			fmt.Printf("Loaded %d airways from %s\n", airways.Len(), *airwaysFile)
+		}
+	}
+
+	if *cifpFile != "" {
+		procedures, err := navdata.LoadCIFPFile(*cifpFile)
+		if err != nil {
+			fatalf("Error loading procedures: %v", err)
+		}
+		navdata.SetDefaultProcedures(procedures)
+		if *verbose {
+			fmt.Printf("Loaded %d procedures from %s\n", procedures.Len(), *cifpFile)
+		}
+	}
+
+	if *pdcFormats != "" {
+		n, err := pdc.LoadFormats(*pdcFormats)
+		if err != nil {
+			fatalf("Error loading PDC formats: %v", err)
+		}
+		if *verbose {
+			fmt.Printf("Loaded %d PDC formats from %s\n", n, *pdcFormats)
+		}
+	}
+
+	// Writes use ctx, so a signal stops the replay between messages rather
+	// than cancelling one half written.
+	ctx := context.Background()
+	stopCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var db *storage.SQLiteDB
+	if *walDir == "" {
+		if db, err = storage.OpenSQLite(*dbPath); err != nil {
+			fatalf("Error opening SQLite: %v", err)
+		}
+		defer func() { _ = db.Close() }()
+	}
+
+	var pg *storage.PostgresDB
+	var tracker *state.Tracker
+	if !*dryRun {
+		pg, err = storage.OpenPostgres(ctx, *pgCfg)
+		if err != nil {
+			fatalf("Error opening PostgreSQL: %v", err)
+		}
+		defer pg.Close()
+
+		if err := pg.CreateSchema(ctx); err != nil {
+			fatalf("Error creating schema: %v", err)
+		}
+		if *reset {
+			if err := pg.ResetDerivedState(ctx); err != nil {
+				fatalf("Error resetting state: %v", err)
+			}
+			fmt.Println("Derived state tables truncated.")
+		}
+		tracker = state.NewTracker(pg)
+		tracker.SetLifecycle(lifecycle)
+
+		sink, err := sinkCfg.Open()
+		if err != nil {
+			fatalf("Error opening sink: %v", err)
+		}
+		if sink != nil {
+			defer func() {
+				if err := sink.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error closing sink: %v\n", err)
+				}
+			}()
+			tracker.SetSink(sink)
+		}
+
+		if *registryFile != "" {
+			resolver := registration.NewResolver()
+			if err := resolver.LoadFile(*registryFile); err != nil {
+				fatalf("Error loading registry: %v", err)
+			}
+			tracker.SetResolver(resolver)
+			if *verbose {
+				fmt.Printf("Loaded %d registrations from %s\n", resolver.Len(), *registryFile)
+			}
+		}
+
+		airlines, err := loadAirlines(ctx, pg, *airlinesFile)
+		if err != nil {
+			fatalf("Error loading airlines: %v", err)
+		}
+		tracker.SetAirlines(airlines)
+		if *verbose {
+			fmt.Printf("Loaded %d airlines\n", airlines.Len())
+		}
+
+		// Replayed messages may be of any age, so every stored track is loaded.
+		natTracks, err := loadNATTracks(ctx, pg)
+		if err != nil {
+			fatalf("Error loading NAT tracks: %v", err)
+		}
+		navdata.SetDefaultNATTracks(natTracks)
+		if *verbose {
+			fmt.Printf("Loaded %d NAT tracks\n", natTracks.Len())
+		}
+
+		if *groundStationsFile != "" {
+			n, err := importGroundStations(ctx, pg, *groundStationsFile)
+			if err != nil {
+				fatalf("Error loading ground stations: %v", err)
+			}
+			if *verbose {
+				fmt.Printf("Loaded %d ground stations\n", n)
+			}
+		}
+	}
+
+	airports, err := loadAirports(ctx, pg, *airportsFile)
+	if err != nil {
+		fatalf("Error loading airports: %v", err)
+	}
+	airport.SetDefault(airports)
+	if *verbose {
+		fmt.Printf("Loaded %d airports\n", airports.Len())
+	}
+
+	reg := registry.Default()
+	reg.Sort()
+
+	var filter *dedup.Filter
+	if *dedupWindow > 0 {
+		filter = dedup.New(*dedupWindow)
+	}
+
+	counter := state.NewParseCounter()
+	saveStats := func() {
+		if pg == nil {
+			return
+		}
+		if err := pg.SaveParseStats(ctx, counter.Stats()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving parse stats: %v\n", err)
+		}
+	}
+
+	var processed, parsed, failed, lowQuality, undecodable int
+	start := time.Now()
+
+	replayMessage := func(msg *acars.Message, ts time.Time) error {
+		if err := stopCtx.Err(); err != nil {
+			return err
+		}
+		processed++
+
+		// The same downlink is often stored once per receiving station.
+		if filter != nil && filter.Duplicate(msg, ts) {
+			return nil
+		}
+
+		msg, report := quality.Prepare(msg)
+		attributed := reg.DispatchAttributed(msg)
+		counter.Count(ts, msg.Label, attributed)
+		results := make([]registry.Result, len(attributed))
+		for i, a := range attributed {
+			results[i] = a.Result
+		}
+		if len(results) > 0 {
+			parsed++
+		}
+		results = quality.Annotate(results, report)
+
+		if !report.OK(*minQuality) {
+			lowQuality++
+		} else if tracker != nil {
+			if err := tracker.Apply(ctx, msg, results); err != nil {
+				// A single bad row should not abort a multi-hour replay.
+				failed++
+				if *verbose {
+					fmt.Fprintf(os.Stderr, "Message %d: %v\n", msg.ID, err)
+				}
+			}
+		}
+
+		if processed%progressInterval == 0 {
+			saveStats()
+			fmt.Printf("Processed %d messages (%d parsed, %d errors) in %s\n",
+				processed, parsed, failed, time.Since(start).Round(time.Second))
+		}
+		return nil
+	}
+
+	if *walDir != "" {
+		err = replayWAL(*walDir, params, replayMessage, func(err error) {
+			undecodable++
+			if *verbose {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+			}
+		})
+	} else {
+		err = db.ForEachByTime(params, func(m *storage.Message) error {
+			msg := &acars.Message{
+				ID:        acars.FlexInt64(m.ID),
+				Timestamp: m.Timestamp.UTC().Format(time.RFC3339),
+				Label:     m.Label,
+				Text:      m.RawText,
+				Tail:      m.Tail,
+			}
+			if m.Flight != "" {
+				msg.Flight = &acars.Flight{Flight: m.Flight}
+			}
+			return replayMessage(msg, m.Timestamp)
+		})
+	}
+	interrupted := errors.Is(err, context.Canceled)
+	if err != nil && !interrupted {
+		fatalf("Error reading messages: %v", err)
+	}
+	saveStats()
+	if tracker != nil {
+		if _, err := tracker.Expire(ctx); err != nil {
+			fatalf("Error archiving flights: %v", err)
+		}
+	}
+
+	if interrupted {
+		fmt.Printf("\nReplay interrupted after %s\n", time.Since(start).Round(time.Second))
+	} else {
+		fmt.Printf("\nReplay complete in %s\n", time.Since(start).Round(time.Second))
+	}
+	fmt.Printf("  Messages:    %d\n", processed)
+	if *walDir != "" {
+		fmt.Printf("  Undecodable: %d input lines\n", undecodable)
+	}
+	fmt.Printf("  Parsed:      %d\n", parsed)
+	fmt.Printf("  Errors:      %d\n", failed)
+	if *minQuality > 0 {
+		fmt.Printf("  Low quality: %d skipped\n", lowQuality)
+	}
+	if filter != nil {
+		fmt.Printf("  Duplicates:  %d suppressed\n", filter.Stats().Suppressed)
+	}
+	if tracker != nil {
+		s := tracker.Stats()
+		fmt.Printf("  Aircraft:    %d upserts\n", s.Aircraft)
+		fmt.Printf("  Waypoints:   %d upserts\n", s.Waypoints)
+		fmt.Printf("  Routes:      %d upserts\n", s.Routes)
+		fmt.Printf("  ATIS:        %d upserts\n", s.ATIS)
+		fmt.Printf("  Enrichments: %d upserts\n", s.Enrichments)
+		fmt.Printf("  Flights:     %d upserts, %d archived\n", s.Flights, s.Archived)
+		fmt.Printf("  Positions:   %d recorded, %d rejected as implausible\n", s.Positions, s.RejectedPositions)
+		fmt.Printf("  Comms:       %d SELCAL codes and frequencies\n", s.Comms)
+		fmt.Printf("  Squawks:     %d assignments\n", s.Squawks)
+		fmt.Printf("  Plans:       %d route changes, %d arrival runways\n", s.RouteChanges, s.ArrivalRunways)
+		fmt.Printf("  ETAs:        %d reports\n", s.ETAReports)
+		fmt.Printf("  Emergencies: %d events\n", s.Emergencies)
+		fmt.Printf("  Stations:    %d ground station messages\n", s.GroundStations)
+		fmt.Printf("  Logons:      %d AFN logons, %d CPDLC messages linked\n", s.Logons, s.LinkedCPDLC)
+		fmt.Printf("  Winds:       %d weather observations recorded and gridded\n", s.Winds)
+		fmt.Printf("  Turbulence:  %d turbulence and wind shear reports\n", s.Turbulence)
+	}
+}
+
+// replayWAL passes fn the messages in the write-ahead log in dir that params
+// selects, in the order they were received, and report the input that cannot
+// be decoded or has no usable timestamp. Frames without an ACARS message are
+// skipped.
+func replayWAL(dir string, params storage.ScanParams, fn func(*acars.Message, time.Time) error, report func(error)) error {
+	paths, err := wal.Segments(dir, params.From, params.To)
+	if err != nil {
+		return err
+	}
+	r := wal.NewReader(paths)
+	defer func() { _ = r.Close() }()
+	in := input.NewReader(r)
+	visited := 0
+	for params.Limit == 0 || visited < params.Limit {
+		d, err := in.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, wal.ErrRead) {
+			return err
+		}
+		if err != nil {
+			report(err)
+			continue
+		}
+		msg := d.Message
+		if msg == nil || params.Label != "" && msg.Label != params.Label {
+			continue
+		}
+		ts, ok := msgtime.Parse(msg.Timestamp)
+		if !ok {
+			report(fmt.Errorf("line %d: no usable timestamp %q", in.Line(), msg.Timestamp))
+			continue
+		}
+		if !params.From.IsZero() && ts.Before(params.From) || !params.To.IsZero() && !ts.Before(params.To) {
+			continue
+		}
+		visited++
+		if err := fn(msg, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadAirlines imports the airline CSV, if given, into PostgreSQL and returns
+// the airline table as stored, so that earlier imports also apply.
+func loadAirlines(ctx context.Context, pg *storage.PostgresDB, path string) (*airline.Table, error) {
+	if path != "" {
+		file := airline.NewTable()
+		if err := file.LoadFile(path); err != nil {
+			return nil, err
+		}
+		rows := make([]storage.Airline, 0, file.Len())
+		for _, a := range file.All() {
+			rows = append(rows, storage.Airline{ICAOCode: a.ICAO, IATACode: a.IATA, Name: a.Name})
+		}
+		if err := pg.UpsertAirlines(ctx, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := pg.ListAirlines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := airline.NewTable()
+	for _, r := range rows {
+		if err := table.Add(airline.Airline{ICAO: r.ICAOCode, IATA: r.IATACode, Name: r.Name}); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// loadNATTracks returns every NAT track stored in PostgreSQL.
+func loadNATTracks(ctx context.Context, pg *storage.PostgresDB) (*navdata.NATTracks, error) {
+	rows, err := pg.ListNATTracks(ctx, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]navdata.NATTrack, 0, len(rows))
+	for _, r := range rows {
+		tracks = append(tracks, navdata.NATTrack{
+			Letter: r.Letter, Points: r.Points, ValidFrom: r.ValidFrom, ValidTo: r.ValidTo,
+			TMI: r.TMI, EastLevels: r.EastLevels, WestLevels: r.WestLevels,
+		})
+	}
+	return navdata.NewNATTracks(tracks), nil
+}
+
+// loadAirports imports the airport CSV, if given, into PostgreSQL and returns
+// the airport table as stored, so that earlier imports also apply. Without
+// PostgreSQL (a dry run) only the file is read.
+func loadAirports(ctx context.Context, pg *storage.PostgresDB, path string) (*airport.Table, error) {
+	file := airport.NewTable()
+	if path != "" {
+		if err := file.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if pg == nil {
+		return file, nil
+	}
+	if path != "" {
+		rows := make([]storage.Airport, 0, file.Len())
+		for _, a := range file.All() {
+			rows = append(rows, storage.Airport{ICAOCode: a.ICAO, IATACode: a.IATA, Name: a.Name, Country: a.Country, TZ: a.TZ})
+		}
+		if err := pg.UpsertAirports(ctx, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := pg.ListAirports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := airport.NewTable()
+	for _, r := range rows {
+		if err := table.Add(airport.Airport{ICAO: r.ICAOCode, IATA: r.IATACode, Name: r.Name, Country: r.Country, TZ: r.TZ}); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// importGroundStations imports a ground station CSV into the
+// ground_station_info table and returns the number of stations imported.
+func importGroundStations(ctx context.Context, pg *storage.PostgresDB, path string) (int, error) {
+	stations, err := groundstation.LoadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	rows := make([]storage.GroundStationInfo, len(stations))
+	for i, s := range stations {
+		rows[i] = storage.GroundStationInfo{Kind: s.Kind, StationID: s.ID.ID, Provider: s.Provider, Name: s.Name, Region: s.Region}
+	}
+	if err := pg.UpsertGroundStationInfo(ctx, rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// parseTimeFlag parses an RFC 3339 timestamp or a YYYY-MM-DD date. An empty string yields the zero time.
+func parseTimeFlag(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
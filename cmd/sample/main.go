@@ -0,0 +1,240 @@
+// Package main provides the sample tool, which draws a stratified sample of
+// stored messages and writes it, with registrations and flight numbers
+// scrubbed, as a JSONL fixture set that can be shared.
+//
+// Up to -per messages are kept from each label and parser outcome (the parser
+// type, or "unparsed"), chosen at random from all the matching messages, so
+// that rare message kinds are as well represented as common ones. Tails,
+// flight numbers, and the registrations and flight numbers the stored result
+// names, are replaced in each message with pseudonyms of the same shape (see
+// internal/sample). Other identifying text, such as names in free text, is
+// kept, so look through a set before sharing it.
+//
+// Each line is a message in the flat JSON that decode and process read
+// (id, timestamp, label, tail, flight, text), with the stored parser_type:
+//
+//	{"id":1001,"timestamp":"2026-03-01T10:36:00Z","label":"H1","tail":"VH-XNA","flight":{"flight":"JST577"},"text":"...","parser_type":"pdc"}
+//
+// Usage:
+//
+//	sample [options]
+//
+// Messages are read from ClickHouse, or with -db from a legacy SQLite
+// messages database.
+//
+// Options:
+//
+//	-ch-host HOST       ClickHouse host (default: localhost, env: CLICKHOUSE_HOST)
+//	-ch-port PORT       ClickHouse port (default: 9000, env: CLICKHOUSE_PORT)
+//	-ch-user USER       ClickHouse user (default: default, env: CLICKHOUSE_USER)
+//	-ch-password PASS   ClickHouse password (env: CLICKHOUSE_PASSWORD)
+//	-ch-database DB     ClickHouse database (default: acars, env: CLICKHOUSE_DATABASE)
+//	-db FILE            Legacy SQLite messages database to read instead of ClickHouse
+//	-o FILE             Output file (default: stdout)
+//	-per N              Messages to keep from each label and outcome (default: 20)
+//	-label LABEL        Only sample messages with this ACARS label
+//	-from DATE          Only sample messages at or after this time (RFC 3339 or YYYY-MM-DD)
+//	-to DATE            Only sample messages before this time (RFC 3339 or YYYY-MM-DD)
+//	-seed N             Random seed; the same seed over the same corpus gives the same sample (default: 1)
+//	-key KEY            Key the pseudonyms are derived under (env: SAMPLE_KEY). With the same
+//	                    key, an aircraft or flight has the same pseudonym in every set; without
+//	                    one a random key is used and the pseudonyms differ between runs.
+//	-batch N            Messages per ClickHouse round trip (default: 5000)
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"acars_parser/internal/envflag"
+	"acars_parser/internal/sample"
+	"acars_parser/internal/storage"
+)
+
+func main() {
+	// ClickHouse connection flags.
+	chCfg := storage.AddClickHouseFlags(flag.CommandLine)
+
+	dbPath := flag.String("db", "", "Legacy SQLite messages database to read instead of ClickHouse")
+	outPath := flag.String("o", "", "Output file (default: stdout)")
+	per := flag.Int("per", 20, "Messages to keep from each label and outcome")
+	label := flag.String("label", "", "Only sample messages with this ACARS label")
+	from := flag.String("from", "", "Only sample messages at or after this time (RFC 3339 or YYYY-MM-DD)")
+	to := flag.String("to", "", "Only sample messages before this time (RFC 3339 or YYYY-MM-DD)")
+	seed := flag.Int64("seed", 1, "Random seed")
+	key := flag.String("key", envflag.String("SAMPLE_KEY", ""), "Key the pseudonyms are derived under (default: random)")
+	batchSize := flag.Int("batch", 5000, "Messages per ClickHouse round trip")
+
+	flag.Parse()
+
+	if *per < 1 {
+		fatalf("-per must be at least 1")
+	}
+	fromTime, err := parseTimeFlag(*from)
+	if err != nil {
+		fatalf("Invalid -from: %v", err)
+	}
+	toTime, err := parseTimeFlag(*to)
+	if err != nil {
+		fatalf("Invalid -to: %v", err)
+	}
+
+	scrubKey := []byte(*key)
+	if len(scrubKey) == 0 {
+		scrubKey = make([]byte, 32)
+		if _, err := rand.Read(scrubKey); err != nil {
+			fatalf("Error generating key: %v", err)
+		}
+	}
+
+	start := time.Now()
+	sampler := sample.NewSampler(*per, *seed)
+	if *dbPath != "" {
+		err = scanSQLite(*dbPath, storage.ScanParams{Label: *label, From: fromTime, To: toTime}, sampler.Add)
+	} else {
+		sel := storage.CHQueryParams{Label: *label, From: fromTime, To: toTime}
+		err = scanClickHouse(context.Background(), *chCfg, sel, *batchSize, sampler.Add)
+	}
+	if err != nil {
+		fatalf("Error reading messages: %v", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fatalf("Error creating output: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	scrubber := sample.NewScrubber(scrubKey)
+	records := sampler.Records()
+	for _, r := range records {
+		if err := enc.Encode(scrubber.Scrub(r)); err != nil {
+			fatalf("Error writing output: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		fatalf("Error writing output: %v", err)
+	}
+
+	printStrata(sampler.Seen(), *per)
+	fmt.Fprintf(os.Stderr, "Sampled %d messages from %d strata in %s\n",
+		len(records), len(sampler.Seen()), time.Since(start).Round(time.Millisecond))
+}
+
+// scanSQLite offers every message of a SQLite database matching p to fn.
+func scanSQLite(path string, p storage.ScanParams, fn func(sample.Record)) error {
+	db, err := storage.OpenSQLite(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	return db.ForEachByTime(p, func(m *storage.Message) error {
+		fn(sample.Record{
+			ID:         m.ID,
+			Timestamp:  m.Timestamp.UTC(),
+			Label:      m.Label,
+			Tail:       m.Tail,
+			Flight:     flightOf(m.Flight),
+			Text:       m.RawText,
+			ParserType: m.ParserType,
+			Parsed:     m.ParsedJSON,
+		})
+		return nil
+	})
+}
+
+// scanClickHouse offers every message matching sel to fn, paging by ID.
+func scanClickHouse(ctx context.Context, cfg storage.ClickHouseConfig, sel storage.CHQueryParams,
+	batchSize int, fn func(sample.Record)) error {
+	ch, err := storage.OpenClickHouse(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ch.Close() }()
+
+	sel.Limit = batchSize
+	sel.OrderBy = "id"
+	for {
+		messages, err := ch.Query(ctx, sel)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		for _, m := range messages {
+			fn(sample.Record{
+				ID:         int64(m.ID),
+				Timestamp:  m.Timestamp.UTC(),
+				Label:      m.Label,
+				Tail:       m.Tail,
+				Flight:     flightOf(m.Flight),
+				Text:       m.RawText,
+				ParserType: m.ParserType,
+				Parsed:     m.ParsedJSON,
+			})
+		}
+		sel.AfterID = messages[len(messages)-1].ID
+	}
+}
+
+func flightOf(flight string) *sample.Flight {
+	if flight == "" {
+		return nil
+	}
+	return &sample.Flight{Flight: flight}
+}
+
+// printStrata lists the strata with fewer messages than were asked for, so
+// that gaps in the sample are visible.
+func printStrata(seen map[sample.Stratum]int, per int) {
+	var short []sample.Stratum
+	for s, n := range seen {
+		if n < per {
+			short = append(short, s)
+		}
+	}
+	if len(short) == 0 {
+		return
+	}
+	sort.Slice(short, func(i, j int) bool {
+		if short[i].Label != short[j].Label {
+			return short[i].Label < short[j].Label
+		}
+		return short[i].Outcome < short[j].Outcome
+	})
+	fmt.Fprintf(os.Stderr, "%d strata have fewer than %d messages:\n", len(short), per)
+	for _, s := range short {
+		fmt.Fprintf(os.Stderr, "  %-4s %-24s %d\n", s.Label, s.Outcome, seen[s])
+	}
+}
+
+func parseTimeFlag(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
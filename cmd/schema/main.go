@@ -0,0 +1,88 @@
+// Package main provides the result schema tool.
+//
+// Every parse result type has a versioned JSON Schema, generated from its
+// struct (see internal/schema). The schemas of the current versions are
+// checked in under api/schemas; this tool lists and prints them, writes the
+// files of new versions, and checks that no result struct has changed
+// without a version bump.
+//
+// Usage:
+//
+//	schema [options]            List result types and their schema versions
+//	schema [options] TYPE...    Print the schemas of result types
+//	schema -check [options]     Check the schema files; exit with status 1 on drift
+//	schema -write [options]     Write the schema files of new versions
+//
+// Options:
+//
+//	-dir DIR            Directory of schema files (default: api/schemas)
+//	-check              Check every schema against its file
+//	-write              Write missing schema files; existing files are never changed
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"acars_parser/internal/schema"
+)
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitDrift = 1
+	exitError = 2
+)
+
+func main() {
+	dir := flag.String("dir", "api/schemas", "Directory of schema files")
+	check := flag.Bool("check", false, "Check every schema against its file")
+	write := flag.Bool("write", false, "Write missing schema files")
+
+	flag.Parse()
+
+	switch {
+	case *check:
+		problems := schema.Check(*dir)
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		if len(problems) > 0 {
+			os.Exit(exitDrift)
+		}
+		fmt.Printf("%d schemas match %s\n", len(schema.Entries()), *dir)
+
+	case *write:
+		written, err := schema.Write(*dir)
+		for _, name := range written {
+			fmt.Printf("Wrote %s\n", name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitDrift)
+		}
+
+	case flag.NArg() > 0:
+		for _, typ := range flag.Args() {
+			e, ok := schema.Lookup(typ)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown result type %q\n", typ)
+				os.Exit(exitError)
+			}
+			b, err := e.JSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			os.Stdout.Write(b)
+		}
+
+	default:
+		fmt.Printf("%-22s %7s  %s\n", "Type", "Version", "ID")
+		for _, e := range schema.Entries() {
+			fmt.Printf("%-22s %7d  %s\n", e.Type, e.Version, e.ID())
+		}
+	}
+	os.Exit(exitOK)
+}
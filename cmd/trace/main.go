@@ -0,0 +1,218 @@
+// Package main provides the parse trace tool.
+//
+// A single raw message is dispatched through the parser registry and the tool
+// reports, for every candidate parser, whether QuickCheck passed, whether the
+// parser matched, the fields it extracted, and any panic. Parsers that implement
+// registry.Traceable also show the formats and extractors they tried. This is
+// the quickest way to find out why a message fell through to unparsed.
+//
+// Usage:
+//
+//	trace [options] [TEXT]
+//
+// The message text is taken from TEXT, or read from stdin when TEXT is omitted.
+// A literal "\n" in TEXT is treated as a newline. Corrupt text is scored and
+// repaired (see internal/quality) before it is dispatched.
+//
+// Options:
+//
+//	-label LABEL        ACARS label of the message (default: H1)
+//	-json               Output the trace as JSON
+//	-pdc-formats PATH   PDC format definitions (JSON or YAML file, or a directory of
+//	                    them) added to the built-in formats, to try out new formats
+//	                    (env: PDC_FORMATS)
+//	-v                  Show format and extractor details for every parser,
+//	                    not just those whose QuickCheck passed
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/envflag"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+)
+
+func main() {
+	label := flag.String("label", "H1", "ACARS label of the message")
+	jsonOut := flag.Bool("json", false, "Output the trace as JSON")
+	pdcFormats := flag.String("pdc-formats", envflag.String("PDC_FORMATS", ""), "PDC format definitions (file or directory) added to the built-in formats")
+	verbose := flag.Bool("v", false, "Show details for every parser")
+
+	flag.Parse()
+
+	text, err := readText(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading message: %v\n", err)
+		os.Exit(1)
+	}
+	if strings.TrimSpace(text) == "" {
+		fmt.Fprintln(os.Stderr, "Error: no message text given")
+		os.Exit(1)
+	}
+
+	if *pdcFormats != "" {
+		if _, err := pdc.LoadFormats(*pdcFormats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading PDC formats: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	reg := registry.Default()
+	reg.Sort()
+	msg, report := quality.Prepare(&acars.Message{Label: *label, Text: text})
+	trace := reg.DispatchWithTrace(msg)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(trace); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printQuality(report)
+	printTrace(trace, *verbose)
+}
+
+// printQuality writes the text quality score and any issues found.
+func printQuality(r quality.Report) {
+	if len(r.Issues) == 0 {
+		fmt.Printf("Quality: %.2f\n", r.Score)
+		return
+	}
+	repaired := ""
+	if r.Repaired {
+		repaired = ", repaired"
+	}
+	fmt.Printf("Quality: %.2f (%s%s)\n", r.Score, strings.Join(r.Issues, ", "), repaired)
+}
+
+// readText returns the message from the arguments, or from stdin when there are none.
+func readText(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.ReplaceAll(strings.Join(args, " "), `\n`, "\n"), nil
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// printTrace writes a human-readable trace.
+func printTrace(trace *registry.DispatchTrace, verbose bool) {
+	label := trace.Label
+	if label == "" {
+		label = "(empty)"
+	}
+	fmt.Printf("Label:   %s\n", label)
+
+	if len(trace.Results) == 0 {
+		fmt.Println("Results: none (unparsed)")
+	} else {
+		types := make([]string, len(trace.Results))
+		for i, r := range trace.Results {
+			types[i] = r.Type()
+		}
+		fmt.Printf("Results: %s\n", strings.Join(types, ", "))
+	}
+	fmt.Println()
+
+	if len(trace.Parsers) == 0 {
+		fmt.Println("No parsers are registered for this label and there are no content-based parsers.")
+		return
+	}
+
+	fmt.Printf("%-22s %-10s %5s %-6s %s\n", "Parser", "Stage", "Prio", "Check", "Match")
+	for _, pt := range trace.Parsers {
+		fmt.Printf("%-22s %-10s %5d %-6s %s\n", pt.Parser, pt.Stage, pt.Priority, passFail(pt.QuickCheck), yesNo(pt.Matched))
+
+		if pt.Error != "" {
+			fmt.Printf("    error: %s\n", pt.Error)
+		}
+		if pt.Detail != nil && pt.Detail.QuickCheck != nil && pt.Detail.QuickCheck.Reason != "" {
+			fmt.Printf("    check: %s\n", pt.Detail.QuickCheck.Reason)
+		}
+		if !pt.QuickCheck && !verbose {
+			continue
+		}
+
+		if pt.Detail != nil {
+			for _, f := range pt.Detail.Formats {
+				selected := ""
+				if f.Selected {
+					selected = " (selected)"
+				}
+				fmt.Printf("    format %-24s %s%s\n", f.Name, yesNo(f.Matched), selected)
+				if f.Matched {
+					printCaptures(f.Captures)
+				}
+			}
+			for _, e := range pt.Detail.Extractors {
+				if e.Matched {
+					fmt.Printf("    extract %-23s %s\n", e.Name, e.Value)
+				} else if verbose {
+					fmt.Printf("    extract %-23s -\n", e.Name)
+				}
+			}
+		}
+
+		if pt.Matched {
+			printFields(pt.Fields)
+		}
+	}
+}
+
+func printCaptures(captures map[string]string) {
+	keys := make([]string, 0, len(captures))
+	for k := range captures {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if captures[k] != "" {
+			fmt.Printf("        %-20s %s\n", k, captures[k])
+		}
+	}
+}
+
+func printFields(fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := json.Marshal(fields[k])
+		if err != nil {
+			continue
+		}
+		fmt.Printf("    field  %-24s %s\n", k, v)
+	}
+}
+
+func passFail(b bool) string {
+	if b {
+		return "pass"
+	}
+	return "fail"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,283 @@
+// Package main provides the upgrade tool, which reparses stored messages whose
+// result came from an older version of a parser.
+//
+// Every message stored in ClickHouse records the name and version of the
+// parser that produced its result. When a parser's Version() is bumped, this
+// tool finds the messages it parsed with an earlier version and replaces their
+// stored result with the current parser output, so improvements reach the
+// history without a full corpus replay.
+//
+// Usage:
+//
+//	upgrade -list
+//	upgrade -parser NAME [options]
+//
+// Options:
+//
+//	-ch-host HOST       ClickHouse host (default: localhost, env: CLICKHOUSE_HOST)
+//	-ch-port PORT       ClickHouse port (default: 9000, env: CLICKHOUSE_PORT)
+//	-ch-user USER       ClickHouse user (default: default, env: CLICKHOUSE_USER)
+//	-ch-password PASS   ClickHouse password (env: CLICKHOUSE_PASSWORD)
+//	-ch-database DB     ClickHouse database (default: acars, env: CLICKHOUSE_DATABASE)
+//	-list               List parser versions and the stored messages per version
+//	-parser NAME        Reparse messages stored by an older version of this parser
+//	-legacy-type TYPE   Also reparse messages of this parser type stored before
+//	                    versioning (no parser name recorded)
+//	-batch N            Messages per ClickHouse round trip (default: 1000)
+//	-limit N            Maximum number of messages to reparse (0 = all)
+//	-dry-run            Report what would change without writing
+//	-v                  Verbose output (prints each changed message)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/extractor"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// unparsedType is stored as the parser type of messages that no parser matches.
+const unparsedType = "unparsed"
+
+func main() {
+	// ClickHouse connection flags.
+	chCfg := storage.AddClickHouseFlags(flag.CommandLine)
+
+	list := flag.Bool("list", false, "List parser versions and stored messages per version")
+	parserName := flag.String("parser", "", "Reparse messages stored by an older version of this parser")
+	legacyType := flag.String("legacy-type", "", "Also reparse messages of this type stored before versioning")
+	batchSize := flag.Int("batch", 1000, "Messages per ClickHouse round trip")
+	limit := flag.Int("limit", 0, "Maximum number of messages to reparse (0 = all)")
+	dryRun := flag.Bool("dry-run", false, "Report what would change without writing")
+	verbose := flag.Bool("v", false, "Verbose output")
+
+	flag.Parse()
+
+	if !*list && *parserName == "" {
+		fatalf("Either -list or -parser is required")
+	}
+
+	reg := registry.Default()
+	reg.Sort()
+
+	ctx := context.Background()
+	ch, err := storage.OpenClickHouse(ctx, *chCfg)
+	if err != nil {
+		fatalf("Error opening ClickHouse: %v", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	if err := ch.CreateSchema(ctx); err != nil {
+		fatalf("Error creating schema: %v", err)
+	}
+
+	if *list {
+		if err := printVersions(ctx, ch, reg); err != nil {
+			fatalf("Error counting messages: %v", err)
+		}
+		return
+	}
+
+	p := reg.Lookup(*parserName)
+	if p == nil {
+		fatalf("Unknown parser %q", *parserName)
+	}
+	version := uint32(registry.ParserVersion(p))
+
+	selections := []storage.CHQueryParams{{ParserName: p.Name(), BelowVersion: version}}
+	if *legacyType != "" {
+		selections = append(selections, storage.CHQueryParams{ParserType: *legacyType, BelowVersion: 1})
+	}
+
+	start := time.Now()
+	var s upgradeStats
+	for _, sel := range selections {
+		if err := upgrade(ctx, ch, reg, p.Name(), sel, *batchSize, *limit, *dryRun, *verbose, &s); err != nil {
+			fatalf("Error reparsing: %v", err)
+		}
+	}
+
+	verb := "Reparsed"
+	if *dryRun {
+		verb = "Would reparse"
+	}
+	fmt.Printf("%s %d messages to %s v%d in %s\n", verb, s.checked, p.Name(), version, time.Since(start).Round(time.Second))
+	fmt.Printf("  Kept type:    %d\n", s.sameType)
+	fmt.Printf("  Changed type: %d\n", s.changedType)
+	fmt.Printf("  Unparsed:     %d\n", s.unparsed)
+}
+
+// upgradeStats counts the outcome of reparsing.
+type upgradeStats struct {
+	checked     int
+	sameType    int
+	changedType int
+	unparsed    int
+}
+
+// upgrade reparses the messages matching sel in batches, paging by ID so that
+// replaced rows do not shift the remaining ones.
+func upgrade(ctx context.Context, ch *storage.ClickHouseDB, reg *registry.Registry, parser string,
+	sel storage.CHQueryParams, batchSize, limit int, dryRun, verbose bool, s *upgradeStats) error {
+	sel.Limit = batchSize
+	sel.OrderBy = "id"
+
+	for {
+		if limit > 0 && s.checked >= limit {
+			return nil
+		}
+		if limit > 0 && limit-s.checked < sel.Limit {
+			sel.Limit = limit - s.checked
+		}
+
+		messages, err := ch.Query(ctx, sel)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		batch := make([]storage.CHInsertParams, 0, len(messages))
+		for _, m := range messages {
+			row := reparse(reg, m, parser)
+			s.checked++
+			switch {
+			case row.ParserType == unparsedType:
+				s.unparsed++
+			case row.ParserType == m.ParserType:
+				s.sameType++
+			default:
+				s.changedType++
+			}
+			if verbose && row.ParserType != m.ParserType {
+				fmt.Printf("Message %d: %s -> %s\n", m.ID, m.ParserType, row.ParserType)
+			}
+			batch = append(batch, row)
+		}
+
+		if !dryRun {
+			if err := ch.ReplaceBatch(ctx, batch); err != nil {
+				return err
+			}
+		}
+		sel.AfterID = messages[len(messages)-1].ID
+	}
+}
+
+// reparse runs a stored message through the registry and returns the row that
+// replaces it. The result of the upgraded parser is preferred when several
+// parsers match; message metadata is kept from the stored row.
+func reparse(reg *registry.Registry, m storage.CHMessage, parser string) storage.CHInsertParams {
+	msg := &acars.Message{
+		ID:        acars.FlexInt64(m.ID),
+		Timestamp: m.Timestamp.UTC().Format(time.RFC3339),
+		Label:     m.Label,
+		Text:      m.RawText,
+		Tail:      m.Tail,
+		Frequency: m.Frequency,
+		Feeder:    m.Feeder,
+		Tenant:    m.Tenant,
+	}
+	if m.StationID != "" {
+		msg.Station = &acars.Station{ID: m.StationID}
+	}
+	if m.Flight != "" {
+		msg.Flight = &acars.Flight{Flight: m.Flight}
+	}
+
+	row := storage.CHInsertParams{
+		ID:          m.ID,
+		Timestamp:   m.Timestamp,
+		Label:       m.Label,
+		ParserType:  unparsedType,
+		Flight:      m.Flight,
+		Tail:        m.Tail,
+		Frequency:   m.Frequency,
+		StationID:   m.StationID,
+		Feeder:      m.Feeder,
+		Tenant:      m.Tenant,
+		Origin:      m.Origin,
+		Destination: m.Destination,
+		RawText:     m.RawText,
+		Confidence:  m.Confidence,
+	}
+
+	attributed := reg.DispatchAttributed(msg)
+	if len(attributed) == 0 {
+		return row
+	}
+	chosen := attributed[0]
+	for _, a := range attributed {
+		if a.Parser == parser {
+			chosen = a
+			break
+		}
+	}
+
+	row.ParserType = chosen.Result.Type()
+	row.ParserName = chosen.Parser
+	row.ParserVersion = uint32(chosen.Version)
+	row.ParsedData = chosen.Result
+
+	if f := extractor.Extract(msg, []registry.Result{chosen.Result}).Flight; f != nil {
+		if f.Origin != "" {
+			row.Origin = f.Origin
+		}
+		if f.Destination != "" {
+			row.Destination = f.Destination
+		}
+	}
+	return row
+}
+
+// printVersions lists every registered parser's current version alongside
+// the stored messages for each version, flagging outdated ones.
+func printVersions(ctx context.Context, ch *storage.ClickHouseDB, reg *registry.Registry) error {
+	counts, err := ch.CountByParserVersion(ctx)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string][]storage.ParserVersionCount)
+	for _, c := range counts {
+		stored[c.ParserName] = append(stored[c.ParserName], c)
+	}
+
+	fmt.Printf("%-22s %7s  %s\n", "Parser", "Version", "Stored (version: messages)")
+	for _, info := range reg.Versions() {
+		line := ""
+		for _, c := range stored[info.Name] {
+			mark := ""
+			if c.ParserVersion < uint32(info.Version) {
+				mark = " (outdated)"
+			}
+			line += fmt.Sprintf("v%d: %d%s  ", c.ParserVersion, c.Count, mark)
+		}
+		if line == "" {
+			line = "-"
+		}
+		fmt.Printf("%-22s %7d  %s\n", info.Name, info.Version, line)
+	}
+
+	var legacy uint64
+	for _, c := range stored[""] {
+		legacy += c.Count
+	}
+	if legacy > 0 {
+		fmt.Printf("\n%d messages have no parser recorded (stored before versioning, or unparsed);\n", legacy)
+		fmt.Println("reparse them by type with -legacy-type.")
+	}
+	return nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,38 @@
+//go:build js && wasm
+
+// Package main is the WebAssembly build of the parser, for decoding messages
+// in the browser. It defines one JavaScript function:
+//
+//	acarsParseJSON(line) -> string
+//
+// line is one JSON message in any format the decode tool reads. The result is
+// a JSON object with the parser results, as in the decode tool's output:
+//
+//	{"results": [{"type": "pdc", "data": {...}}]}
+//
+// or {"error": "..."} if the line cannot be decoded.
+//
+// Build with Go or TinyGo, copying the matching wasm_exec.js alongside:
+//
+//	GOOS=js GOARCH=wasm go build -o acars.wasm ./cmd/wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" .
+//
+//	tinygo build -o acars.wasm -target wasm -no-debug ./cmd/wasm
+//	cp "$(tinygo env TINYGOROOT)/targets/wasm_exec.js" .
+//
+// Built into internal/review/static, the review UI uses it for live previews.
+package main
+
+import "syscall/js"
+
+func main() {
+	js.Global().Set("acarsParseJSON", js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+		if len(args) != 1 || args[0].Type() != js.TypeString {
+			return `{"error":"acarsParseJSON takes one string"}`
+		}
+		return parseJSON(args[0].String())
+	}))
+
+	// Keep the functions available for the life of the page.
+	select {}
+}
@@ -0,0 +1,13 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Fprintln(os.Stderr, "wasm must be built for WebAssembly: GOOS=js GOARCH=wasm go build ./cmd/wasm")
+	os.Exit(2)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+
+	"acars_parser/pkg/acarsparser"
+)
+
+// output is the JSON returned to JavaScript.
+type output struct {
+	Results []result `json:"results"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// result is one parser result, as in the decode tool's output.
+type result struct {
+	Type string             `json:"type"`
+	Data acarsparser.Result `json:"data"`
+}
+
+// parseJSON parses one JSON message line and returns the results as JSON.
+func parseJSON(line string) string {
+	var out output
+	_, results, err := acarsparser.ParseJSON([]byte(line))
+	if err != nil {
+		out.Error = err.Error()
+	}
+	out.Results = make([]result, 0, len(results))
+	for _, r := range results {
+		out.Results = append(out.Results, result{Type: r.Type(), Data: r})
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return `{"error":"encode results"}`
+	}
+	return string(b)
+}
@@ -4,6 +4,7 @@ package acars
 import (
 	"encoding/json"
 	"strconv"
+	"time"
 )
 
 // FlexInt64 handles JSON fields that can be either string or number.
@@ -48,9 +49,39 @@ type Message struct {
 	Label     string    `json:"label"`
 	Frequency float64   `json:"frequency"`
 
+	// Time is the canonical UTC time of the message, set from Timestamp by
+	// msgtime.Normaliser. TimeFlags lists the disagreements it found, such
+	// as a report time in the text far from the message time.
+	Time      time.Time `json:"-"`
+	TimeFlags []string  `json:"time_flags,omitempty"`
+
 	// Direction indicators from the transport layer.
 	BlockID       string `json:"block_id,omitempty"`       // ACARS block ID ('0'-'9' = downlink, 'A'-'X' = uplink).
 	LinkDirection string `json:"link_direction,omitempty"` // Explicit direction: "uplink" or "downlink".
+	MsgNo         string `json:"msgno,omitempty"`          // Downlink message number, e.g. "M01A".
+
+	// Remaining ACARS header fields (ARINC 618).
+	Mode string `json:"mode,omitempty"` // Mode character: '2' for category A, '@'-']' for category B.
+	Ack  string `json:"ack,omitempty"`  // Technical acknowledgement: the acknowledged block ID, or NoAck.
+
+	// Channel is the receiver channel index reported by the decoder, counted
+	// from 0. Nil when the decoder does not report one.
+	Channel *int `json:"channel,omitempty"`
+
+	// Link-layer addresses of the sender and receiver, as 24-bit hex. Aircraft
+	// use their ICAO address; ground stations use their VDL2 or HFDL address.
+	FromHex string `json:"from_hex,omitempty"`
+	ToHex   string `json:"to_hex,omitempty"`
+
+	// Feeder identifies the site that supplied the message when feeds from
+	// several receiver sites are merged. Set from the input, or by the
+	// decode tool's -feeder-id flag.
+	Feeder string `json:"feeder,omitempty"`
+
+	// Tenant identifies the partner network whose feed supplied the message,
+	// when one pipeline ingests the feeds of several. Set from the input, or
+	// by the tenant settings of the decode and process commands.
+	Tenant string `json:"tenant,omitempty"`
 
 	// These may be present in the message itself (old format) or at wrapper level (NATS)
 	Airframe *Airframe `json:"airframe,omitempty"`
@@ -58,6 +89,9 @@ type Message struct {
 	Station  *Station  `json:"station,omitempty"`
 }
 
+// NoAck is the Ack of a message that acknowledges no block (NAK on the air).
+const NoAck = "!"
+
 // Airframe contains aircraft identification data.
 type Airframe struct {
 	ID                string `json:"id,omitempty"`
@@ -96,6 +130,8 @@ type Station struct {
 type NATSWrapper struct {
 	Source   *NATSSource `json:"source,omitempty"`
 	Station  *Station    `json:"station,omitempty"`
+	Feeder   string      `json:"feeder,omitempty"`
+	Tenant   string      `json:"tenant,omitempty"`
 	Airframe *Airframe   `json:"airframe,omitempty"`
 	Flight   *Flight     `json:"flight,omitempty"`
 	Message  *NATSInner  `json:"message,omitempty"`
@@ -120,6 +156,10 @@ type NATSInner struct {
 	ToHex         string    `json:"to_hex,omitempty"`
 	BlockID       string    `json:"block_id,omitempty"`       // ACARS block ID ('0'-'9' = downlink, 'A'-'X' = uplink).
 	LinkDirection string    `json:"link_direction,omitempty"` // Explicit direction: "uplink" or "downlink".
+	MsgNo         string    `json:"msgno,omitempty"`
+	Mode          string    `json:"mode,omitempty"`
+	Ack           string    `json:"ack,omitempty"`
+	Channel       *int      `json:"channel,omitempty"`
 }
 
 // ToMessage converts a NATSWrapper to a unified Message.
@@ -137,9 +177,17 @@ func (w *NATSWrapper) ToMessage() *Message {
 		Frequency:     w.Message.Frequency,
 		BlockID:       w.Message.BlockID,
 		LinkDirection: w.Message.LinkDirection,
+		MsgNo:         w.Message.MsgNo,
+		Mode:          w.Message.Mode,
+		Ack:           w.Message.Ack,
+		Channel:       w.Message.Channel,
+		FromHex:       w.Message.FromHex,
+		ToHex:         w.Message.ToHex,
 		Airframe:      w.Airframe,
 		Flight:        w.Flight,
 		Station:       w.Station,
+		Feeder:        w.Feeder,
+		Tenant:        w.Tenant,
 	}
 
 	// Use tail from airframe if not in message
@@ -149,3 +197,49 @@ func (w *NATSWrapper) ToMessage() *Message {
 
 	return msg
 }
+
+// AircraftICAO returns the ICAO address of the aircraft: from the airframe
+// when known, otherwise the link-layer address at the aircraft end of the
+// link. Returns "" when neither is available.
+func (m *Message) AircraftICAO() string {
+	if m.Airframe != nil && m.Airframe.ICAO != "" {
+		return m.Airframe.ICAO
+	}
+	switch m.LinkDirection {
+	case "downlink":
+		return m.FromHex
+	case "uplink":
+		return m.ToHex
+	}
+	return ""
+}
+
+// StationID returns the identifier of the receiving station, or "" when the
+// feed did not name one.
+func (m *Message) StationID() string {
+	if m.Station == nil {
+		return ""
+	}
+	return m.Station.ID
+}
+
+// FeederID returns the site that supplied the message: its feeder, or the
+// receiving station when no feeder was given, or "" when neither is known.
+func (m *Message) FeederID() string {
+	if m.Feeder != "" {
+		return m.Feeder
+	}
+	return m.StationID()
+}
+
+// GroundStationHex returns the link-layer address of the ground station end
+// of the link, or "" when the direction is not known.
+func (m *Message) GroundStationHex() string {
+	switch m.LinkDirection {
+	case "downlink":
+		return m.ToHex
+	case "uplink":
+		return m.FromHex
+	}
+	return ""
+}
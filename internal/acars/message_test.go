@@ -130,6 +130,11 @@ func TestMessage_JSONRoundTrip(t *testing.T) {
 		Text:      "FPN/FN123:DA:YSSY:AA:KLAX",
 		Tail:      "VH-OQA",
 		Frequency: 131.55,
+		Mode:      "2",
+		BlockID:   "5",
+		Ack:       NoAck,
+		MsgNo:     "D01A",
+		Channel:   new(int),
 		Airframe: &Airframe{
 			Tail: "VH-OQA",
 			ICAO: "7C6B2D",
@@ -163,6 +168,12 @@ func TestMessage_JSONRoundTrip(t *testing.T) {
 	if decoded.Text != original.Text {
 		t.Errorf("Text = %s, want %s", decoded.Text, original.Text)
 	}
+	if decoded.Mode != "2" || decoded.BlockID != "5" || decoded.Ack != NoAck || decoded.MsgNo != "D01A" {
+		t.Errorf("header = mode %q, block %q, ack %q, msgno %q", decoded.Mode, decoded.BlockID, decoded.Ack, decoded.MsgNo)
+	}
+	if decoded.Channel == nil || *decoded.Channel != 0 {
+		t.Errorf("Channel = %v, want 0", decoded.Channel)
+	}
 	if decoded.Airframe == nil {
 		t.Error("Airframe should not be nil")
 	} else if decoded.Airframe.ICAO != original.Airframe.ICAO {
@@ -174,3 +185,45 @@ func TestMessage_JSONRoundTrip(t *testing.T) {
 		t.Errorf("Flight.Flight = %s, want %s", decoded.Flight.Flight, original.Flight.Flight)
 	}
 }
+
+func TestMessage_AircraftICAO(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         Message
+		wantAC      string
+		wantStation string
+	}{
+		{"airframe", Message{Airframe: &Airframe{ICAO: "7C6DB8"}, LinkDirection: "uplink", FromHex: "10916D", ToHex: "ABCDEF"}, "7C6DB8", "10916D"},
+		{"downlink", Message{LinkDirection: "downlink", FromHex: "7C6DB8", ToHex: "10916D"}, "7C6DB8", "10916D"},
+		{"uplink", Message{LinkDirection: "uplink", FromHex: "10916D", ToHex: "7C6DB8"}, "7C6DB8", "10916D"},
+		{"no direction", Message{FromHex: "7C6DB8", ToHex: "10916D"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.msg.AircraftICAO(); got != tt.wantAC {
+				t.Errorf("AircraftICAO() = %q, want %q", got, tt.wantAC)
+			}
+			if got := tt.msg.GroundStationHex(); got != tt.wantStation {
+				t.Errorf("GroundStationHex() = %q, want %q", got, tt.wantStation)
+			}
+		})
+	}
+}
+
+func TestMessage_FeederID(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want string
+	}{
+		{"feeder", Message{Feeder: "site-a", Station: &Station{ID: "YSSY1"}}, "site-a"},
+		{"station", Message{Station: &Station{ID: "YSSY1"}}, "YSSY1"},
+		{"neither", Message{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.FeederID(); got != tt.want {
+			t.Errorf("%s: FeederID() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package airline maps airline IATA designators to ICAO designators so that a
+// flight reported as "QF1255" and as "QFA1255" is stored under one callsign.
+package airline
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Airline is an entry in the airline reference table.
+type Airline struct {
+	IATA string `json:"iata,omitempty"`
+	ICAO string `json:"icao"`
+	Name string `json:"name,omitempty"`
+}
+
+// Table maps IATA and ICAO airline designators. IATA designators are reused
+// by unrelated airlines (often one defunct), so an IATA code listed against
+// more than one ICAO code is ambiguous and is not converted. It is safe for
+// concurrent use.
+type Table struct {
+	mu     sync.RWMutex
+	byICAO map[string]Airline
+	byIATA map[string][]string // IATA code to ICAO codes.
+}
+
+// NewTable returns an empty airline table.
+func NewTable() *Table {
+	return &Table{
+		byICAO: make(map[string]Airline),
+		byIATA: make(map[string][]string),
+	}
+}
+
+// Add records an airline, replacing any entry with the same ICAO code. The
+// IATA code is optional.
+func (t *Table) Add(a Airline) error {
+	a.ICAO = strings.ToUpper(strings.TrimSpace(a.ICAO))
+	a.IATA = strings.ToUpper(strings.TrimSpace(a.IATA))
+	a.Name = strings.TrimSpace(a.Name)
+	if !validICAO(a.ICAO) {
+		return fmt.Errorf("invalid ICAO code %q", a.ICAO)
+	}
+	if a.IATA != "" && !validIATA(a.IATA) {
+		return fmt.Errorf("invalid IATA code %q", a.IATA)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.byICAO[a.ICAO]; ok && old.IATA != "" {
+		t.byIATA[old.IATA] = remove(t.byIATA[old.IATA], a.ICAO)
+	}
+	t.byICAO[a.ICAO] = a
+	if a.IATA != "" {
+		t.byIATA[a.IATA] = append(t.byIATA[a.IATA], a.ICAO)
+	}
+	return nil
+}
+
+// Len returns the number of airlines in the table.
+func (t *Table) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.byICAO)
+}
+
+// All returns the airlines in the table, sorted by ICAO code.
+func (t *Table) All() []Airline {
+	t.mu.RLock()
+	all := make([]Airline, 0, len(t.byICAO))
+	for _, a := range t.byICAO {
+		all = append(all, a)
+	}
+	t.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].ICAO < all[j].ICAO })
+	return all
+}
+
+// ByICAO returns the airline with an ICAO code.
+func (t *Table) ByICAO(code string) (Airline, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	a, ok := t.byICAO[strings.ToUpper(code)]
+	return a, ok
+}
+
+// ByIATA returns the airline with an IATA code. It returns false when the code
+// is unknown or ambiguous.
+func (t *Table) ByIATA(code string) (Airline, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	icao := t.byIATA[strings.ToUpper(code)]
+	if len(icao) != 1 {
+		return Airline{}, false
+	}
+	return t.byICAO[icao[0]], true
+}
+
+// NormaliseCallsign converts a flight number with an IATA prefix to the ICAO
+// callsign ("QF1255" to "QFA1255"). Callsigns that already use an ICAO prefix,
+// and those whose prefix is unknown or ambiguous, are returned unchanged.
+func (t *Table) NormaliseCallsign(callsign string) string {
+	iata, number, ok := SplitIATA(callsign)
+	if !ok {
+		return callsign
+	}
+	a, ok := t.ByIATA(iata)
+	if !ok {
+		return callsign
+	}
+	return a.ICAO + number
+}
+
+// SplitIATA splits a flight number such as "QF1255" or "U2123" into its IATA
+// designator and flight number. It returns false when the callsign does not
+// have that form, e.g. because it has a three-letter ICAO prefix.
+func SplitIATA(callsign string) (string, string, bool) {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+	if len(callsign) < 3 || !validIATA(callsign[:2]) {
+		return "", "", false
+	}
+	number := callsign[2:]
+
+	// One to four digits, optionally followed by a letter (operational suffix).
+	digits := 0
+	for digits < len(number) && isDigit(number[digits]) {
+		digits++
+	}
+	rest := number[digits:]
+	if digits == 0 || digits > 4 || len(rest) > 1 || (rest != "" && !isLetter(rest[0])) {
+		return "", "", false
+	}
+	return callsign[:2], number, true
+}
+
+// LoadFile reads airlines from a CSV file. See LoadCSV.
+func (t *Table) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := t.LoadCSV(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadCSV reads airlines from CSV with the columns
+//
+//	iata,icao,name
+//
+// The IATA code and name may be empty, and a header row is skipped.
+func (t *Table) LoadCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(rec) < 2 {
+			return fmt.Errorf("line %d: want iata,icao,name", line)
+		}
+		a := Airline{IATA: rec[0], ICAO: rec[1]}
+		if len(rec) > 2 {
+			a.Name = rec[2]
+		}
+		if err := t.Add(a); err != nil {
+			if line == 1 {
+				continue // Header row.
+			}
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// validICAO reports whether s is a three-letter ICAO airline designator.
+func validICAO(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isLetter(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// validIATA reports whether s is a two-character IATA airline designator.
+// Designators may contain one digit ("U2", "9W") but not two.
+func validIATA(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for i := 0; i < 2; i++ {
+		if !isLetter(s[i]) && !isDigit(s[i]) {
+			return false
+		}
+	}
+	return isLetter(s[0]) || isLetter(s[1])
+}
+
+func isLetter(c byte) bool { return c >= 'A' && c <= 'Z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func remove(codes []string, code string) []string {
+	out := codes[:0]
+	for _, c := range codes {
+		if c != code {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package airline
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCSV = `iata,icao,name
+QF,QFA,Qantas
+VA,VOZ,Virgin Australia
+U2,EZY,easyJet
+# Two airlines have held the IATA code 3K.
+3K,JSA,Jetstar Asia
+3K,AAA,Example Defunct
+,RFD,Royal Flying Doctor Service
+`
+
+func loadTestTable(t *testing.T) *Table {
+	t.Helper()
+	tbl := NewTable()
+	if err := tbl.LoadCSV(strings.NewReader(testCSV)); err != nil {
+		t.Fatalf("LoadCSV: %v", err)
+	}
+	return tbl
+}
+
+func TestLoadCSV(t *testing.T) {
+	tbl := loadTestTable(t)
+	if tbl.Len() != 6 {
+		t.Errorf("Len() = %d, want 6", tbl.Len())
+	}
+
+	a, ok := tbl.ByICAO("qfa")
+	if !ok || a.IATA != "QF" || a.Name != "Qantas" {
+		t.Errorf("ByICAO(qfa) = %+v, %v", a, ok)
+	}
+	if a, ok := tbl.ByIATA("U2"); !ok || a.ICAO != "EZY" {
+		t.Errorf("ByIATA(U2) = %+v, %v", a, ok)
+	}
+	if _, ok := tbl.ByIATA("3K"); ok {
+		t.Error("ByIATA(3K) should be ambiguous")
+	}
+	if a, ok := tbl.ByICAO("RFD"); !ok || a.IATA != "" {
+		t.Errorf("ByICAO(RFD) = %+v, %v", a, ok)
+	}
+
+	all := tbl.All()
+	if len(all) != 6 || all[0].ICAO != "AAA" || all[5].ICAO != "VOZ" {
+		t.Errorf("All() not sorted by ICAO: %+v", all)
+	}
+}
+
+func TestLoadCSVInvalid(t *testing.T) {
+	tbl := NewTable()
+	err := tbl.LoadCSV(strings.NewReader("QF,QFA,Qantas\nQF,QANTAS,Qantas\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("LoadCSV error = %v, want line 2 error", err)
+	}
+}
+
+func TestAddReplacesIATA(t *testing.T) {
+	tbl := NewTable()
+	_ = tbl.Add(Airline{IATA: "DJ", ICAO: "VOZ"})
+	_ = tbl.Add(Airline{IATA: "VA", ICAO: "VOZ"})
+
+	if _, ok := tbl.ByIATA("DJ"); ok {
+		t.Error("old IATA code DJ still maps to VOZ")
+	}
+	if a, ok := tbl.ByIATA("VA"); !ok || a.ICAO != "VOZ" {
+		t.Errorf("ByIATA(VA) = %+v, %v", a, ok)
+	}
+}
+
+func TestNormaliseCallsign(t *testing.T) {
+	tbl := loadTestTable(t)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"QF1255", "QFA1255"},
+		{"QFA1255", "QFA1255"}, // Already ICAO.
+		{"VA512", "VOZ512"},
+		{"U2123", "EZY123"},
+		{"QF12A", "QFA12A"},    // Operational suffix.
+		{"3K681", "3K681"},     // Ambiguous IATA code.
+		{"ZZ100", "ZZ100"},     // Unknown IATA code.
+		{"QF12345", "QF12345"}, // Too many digits.
+		{"VHOQA", "VHOQA"},     // Registration, not a flight number.
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tbl.NormaliseCallsign(tt.input); got != tt.want {
+			t.Errorf("NormaliseCallsign(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSplitIATA(t *testing.T) {
+	tests := []struct {
+		input  string
+		iata   string
+		number string
+		ok     bool
+	}{
+		{"QF1255", "QF", "1255", true},
+		{"9W7", "9W", "7", true},
+		{"QFA1255", "", "", false},
+		{"12345", "", "", false},
+		{"QF", "", "", false},
+	}
+
+	for _, tt := range tests {
+		iata, number, ok := SplitIATA(tt.input)
+		if iata != tt.iata || number != tt.number || ok != tt.ok {
+			t.Errorf("SplitIATA(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.input, iata, number, ok, tt.iata, tt.number, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package airport maps airport IATA codes to ICAO codes, so that clearances
+// that name airports only by IATA code ("SYD") still give the ICAO origin and
+// destination ("YSSY") that enrichment and route tracking use. Airports may
+// also carry their time zone, so enrichment can date a flight by the local
+// day at its origin.
+package airport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	_ "time/tzdata" // Time zones do not depend on the host's zoneinfo.
+)
+
+// Airport is an entry in the airport reference table.
+type Airport struct {
+	IATA    string `json:"iata,omitempty"`
+	ICAO    string `json:"icao"`
+	Name    string `json:"name,omitempty"`
+	Country string `json:"country,omitempty"`
+	TZ      string `json:"tz,omitempty"` // IANA time zone, such as Australia/Sydney.
+}
+
+// Table maps IATA and ICAO airport codes. Published data lists some IATA
+// codes against more than one airport (often a closed airport that once held
+// the code), so an IATA code listed against more than one ICAO code is
+// ambiguous and is not resolved. It is safe for
+// concurrent use.
+type Table struct {
+	mu     sync.RWMutex
+	byICAO map[string]Airport
+	byIATA map[string][]string       // IATA code to ICAO codes.
+	locs   map[string]*time.Location // ICAO code to time zone.
+}
+
+// NewTable returns an empty airport table.
+func NewTable() *Table {
+	return &Table{
+		byICAO: make(map[string]Airport),
+		byIATA: make(map[string][]string),
+		locs:   make(map[string]*time.Location),
+	}
+}
+
+// Add records an airport, replacing any entry with the same ICAO code. The
+// IATA code and time zone are optional.
+func (t *Table) Add(a Airport) error {
+	a.ICAO = strings.ToUpper(strings.TrimSpace(a.ICAO))
+	a.IATA = strings.ToUpper(strings.TrimSpace(a.IATA))
+	a.Name = strings.TrimSpace(a.Name)
+	a.Country = strings.TrimSpace(a.Country)
+	a.TZ = strings.TrimSpace(a.TZ)
+	if !validICAO(a.ICAO) {
+		return fmt.Errorf("invalid ICAO code %q", a.ICAO)
+	}
+	if a.IATA != "" && !validIATA(a.IATA) {
+		return fmt.Errorf("invalid IATA code %q", a.IATA)
+	}
+	var loc *time.Location
+	if a.TZ != "" {
+		var err error
+		if loc, err = time.LoadLocation(a.TZ); err != nil || a.TZ == "Local" {
+			return fmt.Errorf("invalid time zone %q", a.TZ)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.byICAO[a.ICAO]; ok && old.IATA != "" {
+		t.byIATA[old.IATA] = remove(t.byIATA[old.IATA], a.ICAO)
+	}
+	t.byICAO[a.ICAO] = a
+	if loc != nil {
+		t.locs[a.ICAO] = loc
+	} else {
+		delete(t.locs, a.ICAO)
+	}
+	if a.IATA != "" {
+		t.byIATA[a.IATA] = append(t.byIATA[a.IATA], a.ICAO)
+	}
+	return nil
+}
+
+// Len returns the number of airports in the table.
+func (t *Table) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.byICAO)
+}
+
+// All returns the airports in the table, sorted by ICAO code.
+func (t *Table) All() []Airport {
+	t.mu.RLock()
+	all := make([]Airport, 0, len(t.byICAO))
+	for _, a := range t.byICAO {
+		all = append(all, a)
+	}
+	t.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].ICAO < all[j].ICAO })
+	return all
+}
+
+// ByICAO returns the airport with an ICAO code.
+func (t *Table) ByICAO(code string) (Airport, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	a, ok := t.byICAO[strings.ToUpper(code)]
+	return a, ok
+}
+
+// Location returns the time zone of the airport with an ICAO code. It returns
+// false when the airport is unknown or has no time zone, and for a nil table.
+func (t *Table) Location(code string) (*time.Location, bool) {
+	if t == nil {
+		return nil, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	loc, ok := t.locs[strings.ToUpper(strings.TrimSpace(code))]
+	return loc, ok
+}
+
+// ByIATA returns the airport with an IATA code. It returns false when the code
+// is unknown or ambiguous.
+func (t *Table) ByIATA(code string) (Airport, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	icao := t.byIATA[strings.ToUpper(strings.TrimSpace(code))]
+	if len(icao) != 1 {
+		return Airport{}, false
+	}
+	return t.byICAO[icao[0]], true
+}
+
+// Ambiguous returns the ICAO codes of the airports sharing an IATA code, or
+// nil when the code names at most one airport.
+func (t *Table) Ambiguous(code string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	icao := t.byIATA[strings.ToUpper(strings.TrimSpace(code))]
+	if len(icao) < 2 {
+		return nil
+	}
+	out := append([]string(nil), icao...)
+	sort.Strings(out)
+	return out
+}
+
+// ResolveIATA returns the ICAO code of the airport with an IATA code. It
+// returns "" when the code is unknown or ambiguous. A nil table resolves
+// nothing.
+func (t *Table) ResolveIATA(code string) string {
+	if t == nil {
+		return ""
+	}
+	a, ok := t.ByIATA(code)
+	if !ok {
+		return ""
+	}
+	return a.ICAO
+}
+
+// LoadFile reads airports from a CSV file. See LoadCSV.
+func (t *Table) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := t.LoadCSV(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadCSV reads airports from CSV with the columns
+//
+//	iata,icao,name,country,tz
+//
+// The IATA code, name, country and IANA time zone may be empty, the time zone
+// column may be left out, and a header row is skipped.
+func (t *Table) LoadCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(rec) < 2 {
+			return fmt.Errorf("line %d: want iata,icao,name,country,tz", line)
+		}
+		a := Airport{IATA: rec[0], ICAO: rec[1]}
+		if len(rec) > 2 {
+			a.Name = rec[2]
+		}
+		if len(rec) > 3 {
+			a.Country = rec[3]
+		}
+		if len(rec) > 4 {
+			a.TZ = rec[4]
+		}
+		if err := t.Add(a); err != nil {
+			if line == 1 {
+				continue // Header row.
+			}
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// The default table is shared by the parsers that resolve IATA airport codes.
+var defaultTable atomic.Pointer[Table]
+
+// SetDefault configures the airport table used by parsers to resolve IATA
+// airport codes. Pass nil to disable resolution.
+func SetDefault(t *Table) {
+	defaultTable.Store(t)
+}
+
+// Default returns the configured airport table, or nil.
+func Default() *Table {
+	return defaultTable.Load()
+}
+
+// Location returns the time zone of an airport from the default table, or
+// false when no table is configured or the airport has no time zone.
+func Location(icao string) (*time.Location, bool) {
+	return Default().Location(icao)
+}
+
+// ResolveIATA returns the ICAO code for an IATA airport code from the default
+// table, or "" when no table is configured or the code is unknown or
+// ambiguous.
+func ResolveIATA(code string) string {
+	if code == "" {
+		return ""
+	}
+	return Default().ResolveIATA(code)
+}
+
+// validICAO reports whether s is a four-character ICAO airport code. Codes are
+// letters, apart from some small aerodromes with digits ("Y123").
+func validICAO(s string) bool {
+	if len(s) != 4 || !isLetter(s[0]) {
+		return false
+	}
+	for i := 1; i < 4; i++ {
+		if !isLetter(s[i]) && !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// validIATA reports whether s is a three-letter IATA airport code.
+func validIATA(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for i := 0; i < 3; i++ {
+		if !isLetter(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool { return c >= 'A' && c <= 'Z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func remove(codes []string, code string) []string {
+	out := codes[:0]
+	for _, c := range codes {
+		if c != code {
+			out = append(out, c)
+		}
+	}
+	return out
+}
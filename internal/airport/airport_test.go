@@ -0,0 +1,124 @@
+package airport
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCSV = `iata,icao,name,country,tz
+SYD,YSSY,Sydney Kingsford Smith,AU,Australia/Sydney
+MEL,YMML,Melbourne,AU
+DTW,KDTW,Detroit Metropolitan Wayne County,US,America/Detroit
+# Subang held KUL until 1998, and older data still lists it.
+KUL,WMKK,Kuala Lumpur International,MY
+KUL,WMSA,Sultan Abdul Aziz Shah,MY
+,YSCB,Canberra,AU
+`
+
+func loadTestTable(t *testing.T) *Table {
+	t.Helper()
+	tbl := NewTable()
+	if err := tbl.LoadCSV(strings.NewReader(testCSV)); err != nil {
+		t.Fatalf("LoadCSV: %v", err)
+	}
+	return tbl
+}
+
+func TestLoadCSV(t *testing.T) {
+	tbl := loadTestTable(t)
+	if tbl.Len() != 6 {
+		t.Errorf("Len() = %d, want 6", tbl.Len())
+	}
+
+	a, ok := tbl.ByICAO("yssy")
+	if !ok || a.IATA != "SYD" || a.Name != "Sydney Kingsford Smith" || a.Country != "AU" {
+		t.Errorf("ByICAO(yssy) = %+v, %v", a, ok)
+	}
+	if a, ok := tbl.ByIATA("dtw"); !ok || a.ICAO != "KDTW" {
+		t.Errorf("ByIATA(dtw) = %+v, %v", a, ok)
+	}
+	if _, ok := tbl.ByIATA("KUL"); ok {
+		t.Error("ByIATA(KUL) should be ambiguous")
+	}
+	if got := tbl.Ambiguous("KUL"); len(got) != 2 || got[0] != "WMKK" || got[1] != "WMSA" {
+		t.Errorf("Ambiguous(KUL) = %v", got)
+	}
+	if got := tbl.Ambiguous("SYD"); got != nil {
+		t.Errorf("Ambiguous(SYD) = %v, want nil", got)
+	}
+
+	all := tbl.All()
+	if len(all) != 6 || all[0].ICAO != "KDTW" || all[5].ICAO != "YSSY" {
+		t.Errorf("All() not sorted by ICAO: %+v", all)
+	}
+}
+
+func TestLoadCSVInvalid(t *testing.T) {
+	tbl := NewTable()
+	err := tbl.LoadCSV(strings.NewReader("SYD,YSSY,Sydney\nSY,YSSY,Sydney\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("LoadCSV error = %v, want line 2 error", err)
+	}
+}
+
+func TestAddReplaces(t *testing.T) {
+	tbl := loadTestTable(t)
+	// Clearing Subang's old code leaves KUL with one airport.
+	if err := tbl.Add(Airport{ICAO: "WMSA", Name: "Sultan Abdul Aziz Shah"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tbl.ResolveIATA("KUL"); got != "WMKK" {
+		t.Errorf("ResolveIATA(KUL) = %q, want WMKK", got)
+	}
+}
+
+func TestResolveIATA(t *testing.T) {
+	tbl := loadTestTable(t)
+	tests := map[string]string{
+		"SYD":  "YSSY",
+		" mel": "YMML",
+		"KUL":  "", // Ambiguous.
+		"LAX":  "", // Unknown.
+		"":     "",
+	}
+	for code, want := range tests {
+		if got := tbl.ResolveIATA(code); got != want {
+			t.Errorf("ResolveIATA(%q) = %q, want %q", code, got, want)
+		}
+	}
+
+	var none *Table
+	if got := none.ResolveIATA("SYD"); got != "" {
+		t.Errorf("nil table ResolveIATA(SYD) = %q", got)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tbl := loadTestTable(t)
+	loc, ok := tbl.Location("yssy")
+	if !ok || loc.String() != "Australia/Sydney" {
+		t.Errorf("Location(yssy) = %v, %v", loc, ok)
+	}
+	if a, _ := tbl.ByICAO("KDTW"); a.TZ != "America/Detroit" {
+		t.Errorf("KDTW time zone = %q", a.TZ)
+	}
+	if _, ok := tbl.Location("YMML"); ok {
+		t.Error("Location(YMML) found a time zone the file does not give")
+	}
+
+	// Replacing an airport without a time zone clears it.
+	if err := tbl.Add(Airport{ICAO: "YSSY", IATA: "SYD"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tbl.Location("YSSY"); ok {
+		t.Error("time zone kept after the airport was replaced")
+	}
+
+	if err := tbl.Add(Airport{ICAO: "YSSY", TZ: "Australia/Nowhere"}); err == nil {
+		t.Error("unknown time zone accepted")
+	}
+	var none *Table
+	if _, ok := none.Location("YSSY"); ok {
+		t.Error("nil table found a time zone")
+	}
+}
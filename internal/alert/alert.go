@@ -0,0 +1,248 @@
+// Package alert matches decoded messages against user-defined rules and
+// notifies webhooks and NATS subjects when one matches.
+//
+// Rules are read from a JSON file (see Config). A rule can select messages by
+// aircraft registration, label, a regular expression on the text, a bounding
+// box around any position the parsers decoded, and ADS-C emergency reports;
+// every condition a rule gives must hold. Each match becomes an Alert, sent
+// to the rule's webhook and NATS subject or to the file's defaults.
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/state"
+)
+
+// Alert is the payload sent when a rule matches.
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Timestamp string    `json:"timestamp,omitempty"`
+	Label     string    `json:"label,omitempty"`
+	ICAOHex   string    `json:"icao_hex,omitempty"`
+	Tail      string    `json:"tail,omitempty"`
+	Flight    string    `json:"flight,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	Position  *Position `json:"position,omitempty"` // The position inside the rule's area.
+	Emergency bool      `json:"emergency,omitempty"`
+	Results   []Result  `json:"results,omitempty"`
+}
+
+// Position is a decoded position.
+type Position struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  int     `json:"altitude,omitempty"`
+}
+
+// Result is one parser result of the alerted message.
+type Result struct {
+	Type string          `json:"type"`
+	Data registry.Result `json:"data"`
+}
+
+// Engine matches messages against rules and sends the alerts.
+type Engine struct {
+	rules   []*rule
+	webhook *webhook
+	nats    *natsPublisher
+}
+
+// New validates the rules and connects to NATS if any rule publishes there.
+func New(cfg *Config) (*Engine, error) {
+	rules, err := cfg.compile()
+	if err != nil {
+		return nil, err
+	}
+	e := &Engine{rules: rules, webhook: newWebhook()}
+	for _, r := range rules {
+		if r.subject != "" {
+			if e.nats, err = newNATS(cfg.NATS); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return e, nil
+}
+
+// Match returns an alert for each rule the message and its results match.
+func (e *Engine) Match(msg *acars.Message, results []registry.Result) []Alert {
+	matches := e.match(msg, results)
+	alerts := make([]Alert, len(matches))
+	for i, m := range matches {
+		alerts[i] = m.alert
+	}
+	return alerts
+}
+
+// Notify matches a message and sends its alerts. It returns the number of
+// alerts sent, and the delivery errors joined.
+func (e *Engine) Notify(ctx context.Context, msg *acars.Message, results []registry.Result) (int, error) {
+	var sent int
+	var errs []error
+	for _, m := range e.match(msg, results) {
+		if err := e.send(ctx, m.rule, m.alert); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// match is an alert with the rule that raised it.
+type match struct {
+	rule  *rule
+	alert Alert
+}
+
+func (e *Engine) match(msg *acars.Message, results []registry.Result) []match {
+	if msg == nil {
+		return nil
+	}
+
+	emergency := hasEmergency(results)
+	var positions []state.TrackPoint
+	var positionsRead bool
+
+	var matches []match
+	for _, r := range e.rules {
+		if r.registrations != nil && !r.registrations[registrationKey(msg.Tail)] {
+			continue
+		}
+		if r.labels != nil && !r.labels[strings.ToUpper(msg.Label)] {
+			continue
+		}
+		if r.text != nil && !r.text.MatchString(msg.Text) {
+			continue
+		}
+		if r.Emergency && !emergency {
+			continue
+		}
+		var pos *Position
+		if r.Area != nil {
+			if !positionsRead {
+				positions, positionsRead = state.Positions(msg.Time, results), true
+			}
+			for _, p := range positions {
+				if r.Area.Contains(p.Latitude, p.Longitude) {
+					pos = &Position{Latitude: p.Latitude, Longitude: p.Longitude, Altitude: p.Altitude}
+					break
+				}
+			}
+			if pos == nil {
+				continue
+			}
+		}
+		matches = append(matches, match{rule: r, alert: newAlert(r.Name, msg, results, pos, emergency)})
+	}
+	return matches
+}
+
+// send delivers an alert to its rule's webhook and NATS subject.
+func (e *Engine) send(ctx context.Context, r *rule, a Alert) error {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("encode alert %q: %w", a.Rule, err)
+	}
+	var errs []error
+	if r.webhook != "" {
+		if err := e.webhook.post(ctx, r.webhook, payload); err != nil {
+			errs = append(errs, fmt.Errorf("alert %q: %w", a.Rule, err))
+		}
+	}
+	if r.subject != "" {
+		if err := e.nats.publish(Subject(r.subject, a), payload); err != nil {
+			errs = append(errs, fmt.Errorf("alert %q: %w", a.Rule, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes and closes the NATS connection.
+func (e *Engine) Close() error {
+	if e.nats != nil {
+		return e.nats.close()
+	}
+	return nil
+}
+
+func newAlert(name string, msg *acars.Message, results []registry.Result, pos *Position, emergency bool) Alert {
+	a := Alert{
+		Rule:      name,
+		Timestamp: msg.Timestamp,
+		Label:     msg.Label,
+		ICAOHex:   strings.ToUpper(msg.AircraftICAO()),
+		Tail:      msg.Tail,
+		Text:      msg.Text,
+		Position:  pos,
+		Emergency: emergency,
+	}
+	if !msg.Time.IsZero() {
+		a.Timestamp = msg.Time.UTC().Format(time.RFC3339)
+	}
+	if msg.Flight != nil {
+		a.Flight = strings.TrimSpace(msg.Flight.Flight)
+	}
+	for _, r := range results {
+		a.Results = append(a.Results, Result{Type: r.Type(), Data: r})
+	}
+	return a
+}
+
+// hasEmergency reports whether any result is an ADS-C emergency report.
+func hasEmergency(results []registry.Result) bool {
+	for _, r := range results {
+		if r.Type() != "adsc" {
+			continue
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m struct {
+			MessageType string `json:"message_type"`
+		}
+		if json.Unmarshal(b, &m) == nil && m.MessageType == "emergency" {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject expands a NATS subject template for an alert. The placeholders
+// {rule}, {label}, {icao}, {tail} and {flight} are replaced with the alert's
+// values, with anything other than letters, digits, '-' and '_' replaced by
+// '_' so that a value stays one subject token. An empty value becomes
+// "unknown".
+func Subject(template string, a Alert) string {
+	r := strings.NewReplacer(
+		"{rule}", subjectToken(a.Rule),
+		"{label}", subjectToken(a.Label),
+		"{icao}", subjectToken(a.ICAOHex),
+		"{tail}", subjectToken(a.Tail),
+		"{flight}", subjectToken(a.Flight),
+	)
+	return r.Replace(template)
+}
+
+func subjectToken(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
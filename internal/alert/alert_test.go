@@ -0,0 +1,206 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+type testResult struct {
+	MessageType string  `json:"message_type,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	kind        string
+}
+
+func (r *testResult) Type() string     { return r.kind }
+func (r *testResult) MessageID() int64 { return 1 }
+
+func newEngine(t *testing.T, rules ...Rule) *Engine {
+	t.Helper()
+	e, err := New(&Config{Webhook: "http://localhost/hook", Rules: rules})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return e
+}
+
+func ruleNames(alerts []Alert) string {
+	names := make([]string, len(alerts))
+	for i, a := range alerts {
+		names[i] = a.Rule
+	}
+	return strings.Join(names, ",")
+}
+
+func TestMatch(t *testing.T) {
+	e := newEngine(t,
+		Rule{Name: "watchlist", Registrations: []string{"VH-OQA", "N123AB"}},
+		Rule{Name: "h1-fuel", Labels: []string{"h1"}, Text: `\bFUEL\b`},
+		Rule{Name: "tasman", Area: &Area{MinLat: -45, MaxLat: -30, MinLon: 150, MaxLon: 180}},
+		Rule{Name: "pacific", Area: &Area{MinLat: -30, MaxLat: 30, MinLon: 170, MaxLon: -150}},
+		Rule{Name: "emergency", Emergency: true},
+		Rule{Name: "watchlist-emergency", Registrations: []string{"VH-OQA"}, Emergency: true},
+	)
+
+	adsc := func(msgType string, lat, lon float64) registry.Result {
+		return &testResult{kind: "adsc", MessageType: msgType, Latitude: lat, Longitude: lon}
+	}
+
+	tests := []struct {
+		name    string
+		msg     *acars.Message
+		results []registry.Result
+		want    string
+	}{
+		{"registration without dash", &acars.Message{Tail: ".VHOQA", Label: "5Z"}, nil, "watchlist"},
+		{"label and text", &acars.Message{Tail: "G-ABCD", Label: "H1", Text: "LOW FUEL ADVISORY"}, nil, "h1-fuel"},
+		{"text needs the label", &acars.Message{Tail: "G-ABCD", Label: "RA", Text: "LOW FUEL ADVISORY"}, nil, ""},
+		{"position in area", &acars.Message{Label: "B6"}, []registry.Result{adsc("basic", -38.5, 165.2)}, "tasman"},
+		{"area across the antimeridian", &acars.Message{Label: "B6"}, []registry.Result{adsc("basic", 5, -175)}, "pacific"},
+		{"position outside every area", &acars.Message{Label: "B6"}, []registry.Result{adsc("basic", 51.5, -0.1)}, ""},
+		{"emergency", &acars.Message{Tail: "N123AB", Label: "B6"}, []registry.Result{adsc("emergency", 51.5, -0.1)}, "watchlist,emergency"},
+		{"emergency on watchlist", &acars.Message{Tail: "VH-OQA", Label: "B6"}, []registry.Result{adsc("emergency", -38.5, 165.2)}, "watchlist,tasman,emergency,watchlist-emergency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleNames(e.Match(tt.msg, tt.results)); got != tt.want {
+				t.Errorf("Match() rules = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAlert(t *testing.T) {
+	e := newEngine(t, Rule{Name: "tasman", Area: &Area{MinLat: -45, MaxLat: -30, MinLon: 150, MaxLon: 180}})
+	msg := &acars.Message{
+		Timestamp:     "2026-01-24T10:00:00Z",
+		Time:          time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC),
+		Tail:          "VH-OQA",
+		Label:         "B6",
+		LinkDirection: "downlink",
+		FromHex:       "7c6db8",
+		Flight:        &acars.Flight{Flight: " QF1 "},
+	}
+	alerts := e.Match(msg, []registry.Result{&testResult{kind: "adsc", MessageType: "emergency", Latitude: -38.5, Longitude: 165.2}})
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(alerts))
+	}
+	a := alerts[0]
+	if a.ICAOHex != "7C6DB8" || a.Flight != "QF1" || !a.Emergency || a.Timestamp != "2026-01-24T10:00:00Z" {
+		t.Errorf("alert = %+v", a)
+	}
+	if a.Position == nil || a.Position.Latitude != -38.5 || a.Position.Longitude != 165.2 {
+		t.Errorf("Position = %+v, want -38.5,165.2", a.Position)
+	}
+	if len(a.Results) != 1 || a.Results[0].Type != "adsc" {
+		t.Errorf("Results = %+v", a.Results)
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var got []Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		var a Alert
+		if err := json.Unmarshal(body, &a); err != nil {
+			t.Errorf("decode alert: %v", err)
+		}
+		got = append(got, a)
+		if a.Rule == "failing" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	e, err := New(&Config{
+		Webhook: srv.URL,
+		Rules: []Rule{
+			{Name: "fuel", Text: "FUEL"},
+			{Name: "failing", Text: "FUEL"},
+			{Name: "other", Text: "GATE"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	sent, err := e.Notify(context.Background(), &acars.Message{Label: "H1", Text: "FUEL LOW"}, nil)
+	if sent != 1 || err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Notify() = %d, %v, want 1 sent and a 500 error", sent, err)
+	}
+	if len(got) != 2 || got[0].Rule != "fuel" || got[0].Text != "FUEL LOW" {
+		t.Errorf("webhook received %+v", got)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"no rules", Config{Webhook: "http://x"}, "no rules"},
+		{"no name", Config{Webhook: "http://x", Rules: []Rule{{Labels: []string{"H1"}}}}, "no name"},
+		{"duplicate", Config{Webhook: "http://x", Rules: []Rule{{Name: "a", Labels: []string{"H1"}}, {Name: "a", Labels: []string{"H2"}}}}, "duplicate"},
+		{"no conditions", Config{Webhook: "http://x", Rules: []Rule{{Name: "a"}}}, "no conditions"},
+		{"bad regexp", Config{Webhook: "http://x", Rules: []Rule{{Name: "a", Text: "("}}}, "text"},
+		{"bad area", Config{Webhook: "http://x", Rules: []Rule{{Name: "a", Area: &Area{MinLat: 10, MaxLat: -10}}}}, "area"},
+		{"no destination", Config{Rules: []Rule{{Name: "a", Labels: []string{"H1"}}}}, "no webhook"},
+		{"subject without server", Config{Rules: []Rule{{Name: "a", Labels: []string{"H1"}, NATSSubject: "alerts"}}}, "nats url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rules := `{
+		"webhook": "http://localhost/hook",
+		"rules": [
+			{"name": "oceanic", "labels": ["B6"], "area": {"min_lat": -45, "max_lat": -30, "min_lon": 150, "max_lon": 180}},
+			{"name": "emergency", "emergency": true, "webhook": "http://localhost/urgent"}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e, err := (&Flags{Rules: path}).Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(e.rules) != 2 || e.rules[0].webhook != "http://localhost/hook" || e.rules[1].webhook != "http://localhost/urgent" {
+		t.Errorf("rules = %+v, %+v", e.rules[0], e.rules[1])
+	}
+
+	if e, err := (&Flags{}).Open(); e != nil || err != nil {
+		t.Errorf("Open() without a file = %v, %v, want nil, nil", e, err)
+	}
+}
+
+func TestSubject(t *testing.T) {
+	a := Alert{Rule: "watch list", Label: "H1", Tail: "VH-OQA"}
+	if got := Subject("acars.alerts.{rule}.{tail}.{flight}", a); got != "acars.alerts.watch_list.VH-OQA.unknown" {
+		t.Errorf("Subject() = %q", got)
+	}
+}
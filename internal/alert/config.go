@@ -0,0 +1,193 @@
+package alert
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"acars_parser/internal/registration"
+)
+
+// Config is an alert rules file.
+type Config struct {
+	// Webhook is the URL alerts are POSTed to when a rule names none.
+	Webhook string `json:"webhook,omitempty"`
+	NATS    *NATS  `json:"nats,omitempty"`
+	Rules   []Rule `json:"rules"`
+}
+
+// NATS configures publishing alerts to a NATS server.
+type NATS struct {
+	URL   string `json:"url"`
+	Creds string `json:"creds,omitempty"` // Credentials file.
+	// Subject is the subject template alerts are published to when a rule
+	// names none (see Subject).
+	Subject string `json:"subject,omitempty"`
+}
+
+// Rule selects the messages to alert on. Every condition given must hold;
+// a list matches when any of its entries does.
+type Rule struct {
+	Name          string   `json:"name"`
+	Registrations []string `json:"registrations,omitempty"`
+	Labels        []string `json:"labels,omitempty"`
+	Text          string   `json:"text,omitempty"`      // Regular expression on the message text.
+	Area          *Area    `json:"area,omitempty"`      // Box around a decoded position.
+	Emergency     bool     `json:"emergency,omitempty"` // ADS-C emergency reports only.
+
+	Webhook     string `json:"webhook,omitempty"`
+	NATSSubject string `json:"nats_subject,omitempty"`
+}
+
+// Area is a latitude/longitude bounding box. A box whose MinLon is greater
+// than its MaxLon crosses the antimeridian.
+type Area struct {
+	MinLat float64 `json:"min_lat"`
+	MaxLat float64 `json:"max_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+// Contains reports whether a position is inside the box.
+func (a *Area) Contains(lat, lon float64) bool {
+	if lat < a.MinLat || lat > a.MaxLat {
+		return false
+	}
+	if a.MinLon <= a.MaxLon {
+		return lon >= a.MinLon && lon <= a.MaxLon
+	}
+	return lon >= a.MinLon || lon <= a.MaxLon
+}
+
+func (a *Area) validate() error {
+	switch {
+	case a.MinLat > a.MaxLat:
+		return fmt.Errorf("min_lat %g is above max_lat %g", a.MinLat, a.MaxLat)
+	case math.Abs(a.MinLat) > 90 || math.Abs(a.MaxLat) > 90:
+		return fmt.Errorf("latitude out of range")
+	case math.Abs(a.MinLon) > 180 || math.Abs(a.MaxLon) > 180:
+		return fmt.Errorf("longitude out of range")
+	}
+	return nil
+}
+
+// Load reads a rules file.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// rule is a Rule ready for matching.
+type rule struct {
+	Rule
+	registrations map[string]bool
+	labels        map[string]bool
+	text          *regexp.Regexp
+	webhook       string
+	subject       string
+}
+
+// compile validates the rules and resolves their destinations.
+func (c *Config) compile() ([]*rule, error) {
+	if len(c.Rules) == 0 {
+		return nil, fmt.Errorf("no rules")
+	}
+	seen := make(map[string]bool)
+	rules := make([]*rule, 0, len(c.Rules))
+	for i, r := range c.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rule %d: no name", i+1)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("rule %q: duplicate name", r.Name)
+		}
+		seen[r.Name] = true
+
+		cr := &rule{Rule: r, webhook: r.Webhook, subject: r.NATSSubject}
+		if len(r.Registrations) > 0 {
+			cr.registrations = make(map[string]bool)
+			for _, reg := range r.Registrations {
+				cr.registrations[registrationKey(reg)] = true
+			}
+		}
+		if len(r.Labels) > 0 {
+			cr.labels = make(map[string]bool)
+			for _, l := range r.Labels {
+				cr.labels[strings.ToUpper(strings.TrimSpace(l))] = true
+			}
+		}
+		if r.Text != "" {
+			re, err := regexp.Compile(r.Text)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: text: %w", r.Name, err)
+			}
+			cr.text = re
+		}
+		if r.Area != nil {
+			if err := r.Area.validate(); err != nil {
+				return nil, fmt.Errorf("rule %q: area: %w", r.Name, err)
+			}
+		}
+		if cr.registrations == nil && cr.labels == nil && cr.text == nil && r.Area == nil && !r.Emergency {
+			return nil, fmt.Errorf("rule %q: no conditions", r.Name)
+		}
+
+		if cr.webhook == "" {
+			cr.webhook = c.Webhook
+		}
+		if cr.subject == "" && c.NATS != nil {
+			cr.subject = c.NATS.Subject
+		}
+		if cr.subject != "" && (c.NATS == nil || c.NATS.URL == "") {
+			return nil, fmt.Errorf("rule %q: nats subject without a nats url", r.Name)
+		}
+		if cr.webhook == "" && cr.subject == "" {
+			return nil, fmt.Errorf("rule %q: no webhook or nats subject", r.Name)
+		}
+		rules = append(rules, cr)
+	}
+	return rules, nil
+}
+
+// registrationKey normalises a registration for comparison, ignoring the
+// dash that ACARS tails often drop.
+func registrationKey(reg string) string {
+	return strings.ReplaceAll(registration.Normalise(reg), "-", "")
+}
+
+// Flags holds the alerting flags of a command.
+type Flags struct {
+	Rules string // Rules file; empty disables alerting.
+}
+
+// AddFlags registers the alerting flags on fs, with defaults from the
+// environment, and returns the Flags they fill.
+func AddFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.Rules, "alerts", os.Getenv("ALERT_RULES"), "Alert rules file (JSON)")
+	return f
+}
+
+// Open loads the rules file and connects to its destinations. It returns nil
+// when no rules file is given.
+func (f *Flags) Open() (*Engine, error) {
+	if f.Rules == "" {
+		return nil, nil
+	}
+	cfg, err := Load(f.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// notifyTimeout bounds each webhook request, and connecting to NATS.
+const notifyTimeout = 10 * time.Second
+
+// webhook POSTs alerts as JSON.
+type webhook struct {
+	client *http.Client
+}
+
+func newWebhook() *webhook {
+	return &webhook{client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// post sends a payload and expects a 2xx response.
+func (w *webhook) post(ctx context.Context, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// natsPublisher publishes alerts to NATS subjects.
+type natsPublisher struct {
+	conn *nats.Conn
+}
+
+// newNATS connects to the server. The client reconnects automatically if the
+// connection drops later.
+func newNATS(cfg *NATS) (*natsPublisher, error) {
+	opts := []nats.Option{nats.Name("acars_parser alerts"), nats.Timeout(notifyTimeout)}
+	if cfg.Creds != "" {
+		opts = append(opts, nats.UserCredentials(cfg.Creds))
+	}
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats %s: %w", cfg.URL, err)
+	}
+	return &natsPublisher{conn: conn}, nil
+}
+
+func (p *natsPublisher) publish(subject string, payload []byte) error {
+	if err := p.conn.Publish(subject, payload); err != nil {
+		return fmt.Errorf("publish to %s: %w", subject, err)
+	}
+	return nil
+}
+
+// close flushes pending alerts and disconnects.
+func (p *natsPublisher) close() error {
+	err := p.conn.FlushTimeout(notifyTimeout)
+	p.conn.Close()
+	return err
+}
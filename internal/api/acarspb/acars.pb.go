@@ -0,0 +1,715 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: acars.proto
+
+package acarspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Message struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Timestamp     string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Tail          string                 `protobuf:"bytes,3,opt,name=tail,proto3" json:"tail,omitempty"`
+	Label         string                 `protobuf:"bytes,4,opt,name=label,proto3" json:"label,omitempty"`
+	Text          string                 `protobuf:"bytes,5,opt,name=text,proto3" json:"text,omitempty"`
+	Frequency     float64                `protobuf:"fixed64,6,opt,name=frequency,proto3" json:"frequency,omitempty"`
+	BlockId       string                 `protobuf:"bytes,7,opt,name=block_id,json=blockId,proto3" json:"block_id,omitempty"`
+	LinkDirection string                 `protobuf:"bytes,8,opt,name=link_direction,json=linkDirection,proto3" json:"link_direction,omitempty"`
+	Msgno         string                 `protobuf:"bytes,9,opt,name=msgno,proto3" json:"msgno,omitempty"`
+	Mode          string                 `protobuf:"bytes,10,opt,name=mode,proto3" json:"mode,omitempty"`
+	Ack           string                 `protobuf:"bytes,11,opt,name=ack,proto3" json:"ack,omitempty"`
+	Flight        string                 `protobuf:"bytes,12,opt,name=flight,proto3" json:"flight,omitempty"`
+	IcaoHex       string                 `protobuf:"bytes,13,opt,name=icao_hex,json=icaoHex,proto3" json:"icao_hex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_acars_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_acars_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_acars_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Message) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Message) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *Message) GetTail() string {
+	if x != nil {
+		return x.Tail
+	}
+	return ""
+}
+
+func (x *Message) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Message) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Message) GetFrequency() float64 {
+	if x != nil {
+		return x.Frequency
+	}
+	return 0
+}
+
+func (x *Message) GetBlockId() string {
+	if x != nil {
+		return x.BlockId
+	}
+	return ""
+}
+
+func (x *Message) GetLinkDirection() string {
+	if x != nil {
+		return x.LinkDirection
+	}
+	return ""
+}
+
+func (x *Message) GetMsgno() string {
+	if x != nil {
+		return x.Msgno
+	}
+	return ""
+}
+
+func (x *Message) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Message) GetAck() string {
+	if x != nil {
+		return x.Ack
+	}
+	return ""
+}
+
+func (x *Message) GetFlight() string {
+	if x != nil {
+		return x.Flight
+	}
+	return ""
+}
+
+func (x *Message) GetIcaoHex() string {
+	if x != nil {
+		return x.IcaoHex
+	}
+	return ""
+}
+
+type ParseMessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       *Message               `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseMessageRequest) Reset() {
+	*x = ParseMessageRequest{}
+	mi := &file_acars_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseMessageRequest) ProtoMessage() {}
+
+func (x *ParseMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_acars_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseMessageRequest.ProtoReflect.Descriptor instead.
+func (*ParseMessageRequest) Descriptor() ([]byte, []int) {
+	return file_acars_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ParseMessageRequest) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_acars_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_acars_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_acars_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Result) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Result) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ParseMessageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     int64                  `protobuf:"varint,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Results       []*Result              `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseMessageResponse) Reset() {
+	*x = ParseMessageResponse{}
+	mi := &file_acars_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseMessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseMessageResponse) ProtoMessage() {}
+
+func (x *ParseMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_acars_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseMessageResponse.ProtoReflect.Descriptor instead.
+func (*ParseMessageResponse) Descriptor() ([]byte, []int) {
+	return file_acars_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ParseMessageResponse) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *ParseMessageResponse) GetResults() []*Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type GetEnrichmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IcaoHex       string                 `protobuf:"bytes,1,opt,name=icao_hex,json=icaoHex,proto3" json:"icao_hex,omitempty"`
+	Callsign      string                 `protobuf:"bytes,2,opt,name=callsign,proto3" json:"callsign,omitempty"`
+	Date          string                 `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEnrichmentRequest) Reset() {
+	*x = GetEnrichmentRequest{}
+	mi := &file_acars_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEnrichmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEnrichmentRequest) ProtoMessage() {}
+
+func (x *GetEnrichmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_acars_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEnrichmentRequest.ProtoReflect.Descriptor instead.
+func (*GetEnrichmentRequest) Descriptor() ([]byte, []int) {
+	return file_acars_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetEnrichmentRequest) GetIcaoHex() string {
+	if x != nil {
+		return x.IcaoHex
+	}
+	return ""
+}
+
+func (x *GetEnrichmentRequest) GetCallsign() string {
+	if x != nil {
+		return x.Callsign
+	}
+	return ""
+}
+
+func (x *GetEnrichmentRequest) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+type Enrichment struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IcaoHex         string                 `protobuf:"bytes,1,opt,name=icao_hex,json=icaoHex,proto3" json:"icao_hex,omitempty"`
+	Callsign        string                 `protobuf:"bytes,2,opt,name=callsign,proto3" json:"callsign,omitempty"`
+	FlightDate      string                 `protobuf:"bytes,3,opt,name=flight_date,json=flightDate,proto3" json:"flight_date,omitempty"`
+	Origin          string                 `protobuf:"bytes,4,opt,name=origin,proto3" json:"origin,omitempty"`
+	Destination     string                 `protobuf:"bytes,5,opt,name=destination,proto3" json:"destination,omitempty"`
+	Route           []string               `protobuf:"bytes,6,rep,name=route,proto3" json:"route,omitempty"`
+	Eta             string                 `protobuf:"bytes,7,opt,name=eta,proto3" json:"eta,omitempty"`
+	DepartureRunway string                 `protobuf:"bytes,8,opt,name=departure_runway,json=departureRunway,proto3" json:"departure_runway,omitempty"`
+	ArrivalRunway   string                 `protobuf:"bytes,9,opt,name=arrival_runway,json=arrivalRunway,proto3" json:"arrival_runway,omitempty"`
+	Sid             string                 `protobuf:"bytes,10,opt,name=sid,proto3" json:"sid,omitempty"`
+	Star            string                 `protobuf:"bytes,11,opt,name=star,proto3" json:"star,omitempty"`
+	SidWaypoints    []string               `protobuf:"bytes,12,rep,name=sid_waypoints,json=sidWaypoints,proto3" json:"sid_waypoints,omitempty"`
+	StarWaypoints   []string               `protobuf:"bytes,13,rep,name=star_waypoints,json=starWaypoints,proto3" json:"star_waypoints,omitempty"`
+	Squawk          string                 `protobuf:"bytes,14,opt,name=squawk,proto3" json:"squawk,omitempty"`
+	PaxCount        int32                  `protobuf:"varint,15,opt,name=pax_count,json=paxCount,proto3" json:"pax_count,omitempty"`
+	PaxBreakdown    map[string]int32       `protobuf:"bytes,16,rep,name=pax_breakdown,json=paxBreakdown,proto3" json:"pax_breakdown,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	LastUpdated     string                 `protobuf:"bytes,17,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Enrichment) Reset() {
+	*x = Enrichment{}
+	mi := &file_acars_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enrichment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enrichment) ProtoMessage() {}
+
+func (x *Enrichment) ProtoReflect() protoreflect.Message {
+	mi := &file_acars_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enrichment.ProtoReflect.Descriptor instead.
+func (*Enrichment) Descriptor() ([]byte, []int) {
+	return file_acars_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Enrichment) GetIcaoHex() string {
+	if x != nil {
+		return x.IcaoHex
+	}
+	return ""
+}
+
+func (x *Enrichment) GetCallsign() string {
+	if x != nil {
+		return x.Callsign
+	}
+	return ""
+}
+
+func (x *Enrichment) GetFlightDate() string {
+	if x != nil {
+		return x.FlightDate
+	}
+	return ""
+}
+
+func (x *Enrichment) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *Enrichment) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *Enrichment) GetRoute() []string {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+func (x *Enrichment) GetEta() string {
+	if x != nil {
+		return x.Eta
+	}
+	return ""
+}
+
+func (x *Enrichment) GetDepartureRunway() string {
+	if x != nil {
+		return x.DepartureRunway
+	}
+	return ""
+}
+
+func (x *Enrichment) GetArrivalRunway() string {
+	if x != nil {
+		return x.ArrivalRunway
+	}
+	return ""
+}
+
+func (x *Enrichment) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *Enrichment) GetStar() string {
+	if x != nil {
+		return x.Star
+	}
+	return ""
+}
+
+func (x *Enrichment) GetSidWaypoints() []string {
+	if x != nil {
+		return x.SidWaypoints
+	}
+	return nil
+}
+
+func (x *Enrichment) GetStarWaypoints() []string {
+	if x != nil {
+		return x.StarWaypoints
+	}
+	return nil
+}
+
+func (x *Enrichment) GetSquawk() string {
+	if x != nil {
+		return x.Squawk
+	}
+	return ""
+}
+
+func (x *Enrichment) GetPaxCount() int32 {
+	if x != nil {
+		return x.PaxCount
+	}
+	return 0
+}
+
+func (x *Enrichment) GetPaxBreakdown() map[string]int32 {
+	if x != nil {
+		return x.PaxBreakdown
+	}
+	return nil
+}
+
+func (x *Enrichment) GetLastUpdated() string {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return ""
+}
+
+type GetEnrichmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enrichments   []*Enrichment          `protobuf:"bytes,1,rep,name=enrichments,proto3" json:"enrichments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEnrichmentResponse) Reset() {
+	*x = GetEnrichmentResponse{}
+	mi := &file_acars_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEnrichmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEnrichmentResponse) ProtoMessage() {}
+
+func (x *GetEnrichmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_acars_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEnrichmentResponse.ProtoReflect.Descriptor instead.
+func (*GetEnrichmentResponse) Descriptor() ([]byte, []int) {
+	return file_acars_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetEnrichmentResponse) GetEnrichments() []*Enrichment {
+	if x != nil {
+		return x.Enrichments
+	}
+	return nil
+}
+
+var File_acars_proto protoreflect.FileDescriptor
+
+const file_acars_proto_rawDesc = "" +
+	"\n" +
+	"\vacars.proto\x12\bacars.v1\"\xc4\x02\n" +
+	"\aMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\tR\ttimestamp\x12\x12\n" +
+	"\x04tail\x18\x03 \x01(\tR\x04tail\x12\x14\n" +
+	"\x05label\x18\x04 \x01(\tR\x05label\x12\x12\n" +
+	"\x04text\x18\x05 \x01(\tR\x04text\x12\x1c\n" +
+	"\tfrequency\x18\x06 \x01(\x01R\tfrequency\x12\x19\n" +
+	"\bblock_id\x18\a \x01(\tR\ablockId\x12%\n" +
+	"\x0elink_direction\x18\b \x01(\tR\rlinkDirection\x12\x14\n" +
+	"\x05msgno\x18\t \x01(\tR\x05msgno\x12\x12\n" +
+	"\x04mode\x18\n" +
+	" \x01(\tR\x04mode\x12\x10\n" +
+	"\x03ack\x18\v \x01(\tR\x03ack\x12\x16\n" +
+	"\x06flight\x18\f \x01(\tR\x06flight\x12\x19\n" +
+	"\bicao_hex\x18\r \x01(\tR\aicaoHex\"B\n" +
+	"\x13ParseMessageRequest\x12+\n" +
+	"\amessage\x18\x01 \x01(\v2\x11.acars.v1.MessageR\amessage\"0\n" +
+	"\x06Result\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"a\n" +
+	"\x14ParseMessageResponse\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\x03R\tmessageId\x12*\n" +
+	"\aresults\x18\x02 \x03(\v2\x10.acars.v1.ResultR\aresults\"a\n" +
+	"\x14GetEnrichmentRequest\x12\x19\n" +
+	"\bicao_hex\x18\x01 \x01(\tR\aicaoHex\x12\x1a\n" +
+	"\bcallsign\x18\x02 \x01(\tR\bcallsign\x12\x12\n" +
+	"\x04date\x18\x03 \x01(\tR\x04date\"\xf0\x04\n" +
+	"\n" +
+	"Enrichment\x12\x19\n" +
+	"\bicao_hex\x18\x01 \x01(\tR\aicaoHex\x12\x1a\n" +
+	"\bcallsign\x18\x02 \x01(\tR\bcallsign\x12\x1f\n" +
+	"\vflight_date\x18\x03 \x01(\tR\n" +
+	"flightDate\x12\x16\n" +
+	"\x06origin\x18\x04 \x01(\tR\x06origin\x12 \n" +
+	"\vdestination\x18\x05 \x01(\tR\vdestination\x12\x14\n" +
+	"\x05route\x18\x06 \x03(\tR\x05route\x12\x10\n" +
+	"\x03eta\x18\a \x01(\tR\x03eta\x12)\n" +
+	"\x10departure_runway\x18\b \x01(\tR\x0fdepartureRunway\x12%\n" +
+	"\x0earrival_runway\x18\t \x01(\tR\rarrivalRunway\x12\x10\n" +
+	"\x03sid\x18\n" +
+	" \x01(\tR\x03sid\x12\x12\n" +
+	"\x04star\x18\v \x01(\tR\x04star\x12#\n" +
+	"\rsid_waypoints\x18\f \x03(\tR\fsidWaypoints\x12%\n" +
+	"\x0estar_waypoints\x18\r \x03(\tR\rstarWaypoints\x12\x16\n" +
+	"\x06squawk\x18\x0e \x01(\tR\x06squawk\x12\x1b\n" +
+	"\tpax_count\x18\x0f \x01(\x05R\bpaxCount\x12K\n" +
+	"\rpax_breakdown\x18\x10 \x03(\v2&.acars.v1.Enrichment.PaxBreakdownEntryR\fpaxBreakdown\x12!\n" +
+	"\flast_updated\x18\x11 \x01(\tR\vlastUpdated\x1a?\n" +
+	"\x11PaxBreakdownEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"O\n" +
+	"\x15GetEnrichmentResponse\x126\n" +
+	"\venrichments\x18\x01 \x03(\v2\x14.acars.v1.EnrichmentR\venrichments2\x83\x02\n" +
+	"\x05Acars\x12M\n" +
+	"\fParseMessage\x12\x1d.acars.v1.ParseMessageRequest\x1a\x1e.acars.v1.ParseMessageResponse\x12P\n" +
+	"\rGetEnrichment\x12\x1e.acars.v1.GetEnrichmentRequest\x1a\x1f.acars.v1.GetEnrichmentResponse\x12Y\n" +
+	"\x14StreamParsedMessages\x12\x1d.acars.v1.ParseMessageRequest\x1a\x1e.acars.v1.ParseMessageResponse(\x010\x01B#Z!acars_parser/internal/api/acarspbb\x06proto3"
+
+var (
+	file_acars_proto_rawDescOnce sync.Once
+	file_acars_proto_rawDescData []byte
+)
+
+func file_acars_proto_rawDescGZIP() []byte {
+	file_acars_proto_rawDescOnce.Do(func() {
+		file_acars_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_acars_proto_rawDesc), len(file_acars_proto_rawDesc)))
+	})
+	return file_acars_proto_rawDescData
+}
+
+var file_acars_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_acars_proto_goTypes = []any{
+	(*Message)(nil),               // 0: acars.v1.Message
+	(*ParseMessageRequest)(nil),   // 1: acars.v1.ParseMessageRequest
+	(*Result)(nil),                // 2: acars.v1.Result
+	(*ParseMessageResponse)(nil),  // 3: acars.v1.ParseMessageResponse
+	(*GetEnrichmentRequest)(nil),  // 4: acars.v1.GetEnrichmentRequest
+	(*Enrichment)(nil),            // 5: acars.v1.Enrichment
+	(*GetEnrichmentResponse)(nil), // 6: acars.v1.GetEnrichmentResponse
+	nil,                           // 7: acars.v1.Enrichment.PaxBreakdownEntry
+}
+var file_acars_proto_depIdxs = []int32{
+	0, // 0: acars.v1.ParseMessageRequest.message:type_name -> acars.v1.Message
+	2, // 1: acars.v1.ParseMessageResponse.results:type_name -> acars.v1.Result
+	7, // 2: acars.v1.Enrichment.pax_breakdown:type_name -> acars.v1.Enrichment.PaxBreakdownEntry
+	5, // 3: acars.v1.GetEnrichmentResponse.enrichments:type_name -> acars.v1.Enrichment
+	1, // 4: acars.v1.Acars.ParseMessage:input_type -> acars.v1.ParseMessageRequest
+	4, // 5: acars.v1.Acars.GetEnrichment:input_type -> acars.v1.GetEnrichmentRequest
+	1, // 6: acars.v1.Acars.StreamParsedMessages:input_type -> acars.v1.ParseMessageRequest
+	3, // 7: acars.v1.Acars.ParseMessage:output_type -> acars.v1.ParseMessageResponse
+	6, // 8: acars.v1.Acars.GetEnrichment:output_type -> acars.v1.GetEnrichmentResponse
+	3, // 9: acars.v1.Acars.StreamParsedMessages:output_type -> acars.v1.ParseMessageResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_acars_proto_init() }
+func file_acars_proto_init() {
+	if File_acars_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_acars_proto_rawDesc), len(file_acars_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_acars_proto_goTypes,
+		DependencyIndexes: file_acars_proto_depIdxs,
+		MessageInfos:      file_acars_proto_msgTypes,
+	}.Build()
+	File_acars_proto = out.File
+	file_acars_proto_goTypes = nil
+	file_acars_proto_depIdxs = nil
+}
@@ -0,0 +1,192 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: acars.proto
+
+package acarspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Acars_ParseMessage_FullMethodName         = "/acars.v1.Acars/ParseMessage"
+	Acars_GetEnrichment_FullMethodName        = "/acars.v1.Acars/GetEnrichment"
+	Acars_StreamParsedMessages_FullMethodName = "/acars.v1.Acars/StreamParsedMessages"
+)
+
+// AcarsClient is the client API for Acars service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AcarsClient interface {
+	ParseMessage(ctx context.Context, in *ParseMessageRequest, opts ...grpc.CallOption) (*ParseMessageResponse, error)
+	GetEnrichment(ctx context.Context, in *GetEnrichmentRequest, opts ...grpc.CallOption) (*GetEnrichmentResponse, error)
+	StreamParsedMessages(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ParseMessageRequest, ParseMessageResponse], error)
+}
+
+type acarsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAcarsClient(cc grpc.ClientConnInterface) AcarsClient {
+	return &acarsClient{cc}
+}
+
+func (c *acarsClient) ParseMessage(ctx context.Context, in *ParseMessageRequest, opts ...grpc.CallOption) (*ParseMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ParseMessageResponse)
+	err := c.cc.Invoke(ctx, Acars_ParseMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acarsClient) GetEnrichment(ctx context.Context, in *GetEnrichmentRequest, opts ...grpc.CallOption) (*GetEnrichmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEnrichmentResponse)
+	err := c.cc.Invoke(ctx, Acars_GetEnrichment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *acarsClient) StreamParsedMessages(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ParseMessageRequest, ParseMessageResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Acars_ServiceDesc.Streams[0], Acars_StreamParsedMessages_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ParseMessageRequest, ParseMessageResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Acars_StreamParsedMessagesClient = grpc.BidiStreamingClient[ParseMessageRequest, ParseMessageResponse]
+
+// AcarsServer is the server API for Acars service.
+// All implementations must embed UnimplementedAcarsServer
+// for forward compatibility.
+type AcarsServer interface {
+	ParseMessage(context.Context, *ParseMessageRequest) (*ParseMessageResponse, error)
+	GetEnrichment(context.Context, *GetEnrichmentRequest) (*GetEnrichmentResponse, error)
+	StreamParsedMessages(grpc.BidiStreamingServer[ParseMessageRequest, ParseMessageResponse]) error
+	mustEmbedUnimplementedAcarsServer()
+}
+
+// UnimplementedAcarsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAcarsServer struct{}
+
+func (UnimplementedAcarsServer) ParseMessage(context.Context, *ParseMessageRequest) (*ParseMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ParseMessage not implemented")
+}
+func (UnimplementedAcarsServer) GetEnrichment(context.Context, *GetEnrichmentRequest) (*GetEnrichmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEnrichment not implemented")
+}
+func (UnimplementedAcarsServer) StreamParsedMessages(grpc.BidiStreamingServer[ParseMessageRequest, ParseMessageResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamParsedMessages not implemented")
+}
+func (UnimplementedAcarsServer) mustEmbedUnimplementedAcarsServer() {}
+func (UnimplementedAcarsServer) testEmbeddedByValue()               {}
+
+// UnsafeAcarsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AcarsServer will
+// result in compilation errors.
+type UnsafeAcarsServer interface {
+	mustEmbedUnimplementedAcarsServer()
+}
+
+func RegisterAcarsServer(s grpc.ServiceRegistrar, srv AcarsServer) {
+	// If the following call pancis, it indicates UnimplementedAcarsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Acars_ServiceDesc, srv)
+}
+
+func _Acars_ParseMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParseMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcarsServer).ParseMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acars_ParseMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcarsServer).ParseMessage(ctx, req.(*ParseMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acars_GetEnrichment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEnrichmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AcarsServer).GetEnrichment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Acars_GetEnrichment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AcarsServer).GetEnrichment(ctx, req.(*GetEnrichmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Acars_StreamParsedMessages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AcarsServer).StreamParsedMessages(&grpc.GenericServerStream[ParseMessageRequest, ParseMessageResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Acars_StreamParsedMessagesServer = grpc.BidiStreamingServer[ParseMessageRequest, ParseMessageResponse]
+
+// Acars_ServiceDesc is the grpc.ServiceDesc for Acars service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Acars_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "acars.v1.Acars",
+	HandlerType: (*AcarsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ParseMessage",
+			Handler:    _Acars_ParseMessage_Handler,
+		},
+		{
+			MethodName: "GetEnrichment",
+			Handler:    _Acars_GetEnrichment_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamParsedMessages",
+			Handler:       _Acars_StreamParsedMessages_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "acars.proto",
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"acars_parser/internal/airline"
+	"acars_parser/internal/extractor"
+	"acars_parser/internal/storage"
+)
+
+// AirlineResponse is the JSON representation of an airline reference record.
+type AirlineResponse struct {
+	ICAO string `json:"icao"`
+	IATA string `json:"iata,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// CallsignResponse is the JSON response for callsign normalisation. When the
+// IATA designator is shared by several airlines, Callsign is left as given and
+// the candidates are listed.
+type CallsignResponse struct {
+	Flight     string            `json:"flight"`
+	Callsign   string            `json:"callsign"`
+	Airline    *AirlineResponse  `json:"airline,omitempty"`
+	Candidates []AirlineResponse `json:"candidates,omitempty"`
+}
+
+func airlineToResponse(a storage.Airline) AirlineResponse {
+	return AirlineResponse{ICAO: a.ICAOCode, IATA: a.IATACode, Name: a.Name}
+}
+
+// resolveCallsign converts a flight number to its ICAO callsign using the
+// airlines that match its designator.
+func resolveCallsign(flight string, airlines []storage.Airline) CallsignResponse {
+	flight = extractor.NormaliseFlightNumber(strings.ToUpper(flight))
+	resp := CallsignResponse{Flight: flight, Callsign: flight}
+
+	iata, number, isIATA := airline.SplitIATA(flight)
+	var matches []storage.Airline
+	for _, a := range airlines {
+		if isIATA && a.IATACode == iata {
+			matches = append(matches, a)
+		} else if !isIATA && strings.HasPrefix(flight, a.ICAOCode) {
+			matches = append(matches, a)
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+		ar := airlineToResponse(matches[0])
+		resp.Airline = &ar
+		if isIATA {
+			resp.Callsign = ar.ICAO + number
+		}
+	case len(matches) > 1:
+		for _, a := range matches {
+			resp.Candidates = append(resp.Candidates, airlineToResponse(a))
+		}
+	}
+	return resp
+}
+
+// callsignDesignator returns the airline designator to look up for a flight
+// number: the IATA code when it has that form, otherwise a three-letter prefix.
+func callsignDesignator(flight string) string {
+	if iata, _, ok := airline.SplitIATA(flight); ok {
+		return iata
+	}
+	if len(flight) >= 3 {
+		return flight[:3]
+	}
+	return flight
+}
+
+func (s *EnrichmentServer) handleListAirlines(w http.ResponseWriter, r *http.Request) {
+	airlines, err := s.pg.ListAirlines(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := make([]AirlineResponse, 0, len(airlines))
+	for _, a := range airlines {
+		results = append(results, airlineToResponse(a))
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *EnrichmentServer) handleGetAirline(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	if len(code) != 2 && len(code) != 3 {
+		writeError(w, http.StatusBadRequest, "code must be a 2-character IATA or 3-letter ICAO designator")
+		return
+	}
+
+	airlines, err := s.pg.GetAirlinesByCode(r.Context(), code)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if len(airlines) == 0 {
+		writeError(w, http.StatusNotFound, "No airline found")
+		return
+	}
+
+	results := make([]AirlineResponse, 0, len(airlines))
+	for _, a := range airlines {
+		results = append(results, airlineToResponse(a))
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *EnrichmentServer) handleNormaliseCallsign(w http.ResponseWriter, r *http.Request) {
+	flight := extractor.NormaliseFlightNumber(strings.ToUpper(chi.URLParam(r, "flight")))
+	if flight == "" {
+		writeError(w, http.StatusBadRequest, "flight is required")
+		return
+	}
+
+	airlines, err := s.pg.GetAirlinesByCode(r.Context(), callsignDesignator(flight))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resolveCallsign(flight, airlines))
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"testing"
+
+	"acars_parser/internal/storage"
+)
+
+func TestResolveCallsign(t *testing.T) {
+	qantas := storage.Airline{ICAOCode: "QFA", IATACode: "QF", Name: "Qantas"}
+	jetstarAsia := storage.Airline{ICAOCode: "JSA", IATACode: "3K", Name: "Jetstar Asia"}
+	other := storage.Airline{ICAOCode: "AAA", IATACode: "3K", Name: "Example Defunct"}
+
+	tests := []struct {
+		name       string
+		flight     string
+		airlines   []storage.Airline
+		want       string
+		wantICAO   string
+		candidates int
+	}{
+		{"IATA converted", "qf0012", []storage.Airline{qantas}, "QFA12", "QFA", 0},
+		{"ICAO unchanged", "QFA1255", []storage.Airline{qantas}, "QFA1255", "QFA", 0},
+		{"ambiguous IATA", "3K681", []storage.Airline{jetstarAsia, other}, "3K681", "", 2},
+		{"unknown", "ZZ100", nil, "ZZ100", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := resolveCallsign(tt.flight, tt.airlines)
+			if resp.Callsign != tt.want {
+				t.Errorf("Callsign = %q, want %q", resp.Callsign, tt.want)
+			}
+			gotICAO := ""
+			if resp.Airline != nil {
+				gotICAO = resp.Airline.ICAO
+			}
+			if gotICAO != tt.wantICAO {
+				t.Errorf("Airline.ICAO = %q, want %q", gotICAO, tt.wantICAO)
+			}
+			if len(resp.Candidates) != tt.candidates {
+				t.Errorf("len(Candidates) = %d, want %d", len(resp.Candidates), tt.candidates)
+			}
+		})
+	}
+}
+
+func TestCallsignDesignator(t *testing.T) {
+	tests := map[string]string{
+		"QF1255":  "QF",
+		"QFA1255": "QFA",
+		"U2123":   "U2",
+		"QF":      "QF",
+	}
+	for flight, want := range tests {
+		if got := callsignDesignator(flight); got != want {
+			t.Errorf("callsignDesignator(%q) = %q, want %q", flight, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache holds encoded enrichment lookups, grouped by aircraft so that a
+// change to one aircraft's enrichment drops every lookup that could include
+// it. Implementations must be safe for concurrent use; failures are treated
+// as misses.
+type Cache interface {
+	Get(ctx context.Context, icaoHex, key string) ([]byte, bool)
+	Set(ctx context.Context, icaoHex, key string, value []byte)
+	// Invalidate drops the lookups of an aircraft, or of every aircraft if
+	// icaoHex is empty.
+	Invalidate(ctx context.Context, icaoHex string)
+}
+
+// maxMemoryCacheEntries bounds the in-process cache. Beyond it, expired
+// entries are swept, and if none have expired the cache is emptied.
+const maxMemoryCacheEntries = 100000
+
+// memoryCache is an in-process Cache with a fixed TTL.
+type memoryCache struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	aircraft map[string]map[string]cacheEntry
+	entries  int
+	now      func() time.Time // For tests.
+}
+
+type cacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns an in-process cache whose entries live for ttl.
+func NewMemoryCache(ttl time.Duration) Cache {
+	return &memoryCache{
+		ttl:      ttl,
+		aircraft: make(map[string]map[string]cacheEntry),
+		now:      time.Now,
+	}
+}
+
+func (c *memoryCache) Get(_ context.Context, icaoHex, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.aircraft[icaoHex][key]
+	if !ok || !c.now().Before(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *memoryCache) Set(_ context.Context, icaoHex, key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries >= maxMemoryCacheEntries {
+		c.sweep()
+	}
+	lookups := c.aircraft[icaoHex]
+	if lookups == nil {
+		lookups = make(map[string]cacheEntry)
+		c.aircraft[icaoHex] = lookups
+	}
+	if _, ok := lookups[key]; !ok {
+		c.entries++
+	}
+	lookups[key] = cacheEntry{value: value, expires: c.now().Add(c.ttl)}
+}
+
+func (c *memoryCache) Invalidate(_ context.Context, icaoHex string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if icaoHex == "" {
+		c.aircraft = make(map[string]map[string]cacheEntry)
+		c.entries = 0
+		return
+	}
+	c.entries -= len(c.aircraft[icaoHex])
+	delete(c.aircraft, icaoHex)
+}
+
+// sweep drops expired entries, or everything if none have expired. The
+// caller holds c.mu.
+func (c *memoryCache) sweep() {
+	now := c.now()
+	for icaoHex, lookups := range c.aircraft {
+		for key, e := range lookups {
+			if !now.Before(e.expires) {
+				delete(lookups, key)
+				c.entries--
+			}
+		}
+		if len(lookups) == 0 {
+			delete(c.aircraft, icaoHex)
+		}
+	}
+	if c.entries >= maxMemoryCacheEntries {
+		c.aircraft = make(map[string]map[string]cacheEntry)
+		c.entries = 0
+	}
+}
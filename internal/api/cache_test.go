@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 30, 12, 0, 0, 0, time.UTC)
+	c := NewMemoryCache(10 * time.Second).(*memoryCache)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "7C6CA3", "QFA9/2026-01-30", []byte("a"))
+	c.Set(ctx, "7C6CA3", "/2026-01-30", []byte("b"))
+	c.Set(ctx, "780AB6", "/2026-01-30", []byte("c"))
+
+	if v, ok := c.Get(ctx, "7C6CA3", "QFA9/2026-01-30"); !ok || string(v) != "a" {
+		t.Errorf("Get() = %q, %v", v, ok)
+	}
+	if _, ok := c.Get(ctx, "7C6CA3", "QFA1/2026-01-30"); ok {
+		t.Error("Get() of unset key hit")
+	}
+
+	// Invalidating an aircraft drops all of its lookups only.
+	c.Invalidate(ctx, "7C6CA3")
+	if _, ok := c.Get(ctx, "7C6CA3", "/2026-01-30"); ok {
+		t.Error("Get() hit after Invalidate")
+	}
+	if _, ok := c.Get(ctx, "780AB6", "/2026-01-30"); !ok {
+		t.Error("Invalidate dropped another aircraft")
+	}
+	if c.entries != 1 {
+		t.Errorf("entries = %d, want 1", c.entries)
+	}
+
+	// Entries expire after the TTL.
+	now = now.Add(10 * time.Second)
+	if _, ok := c.Get(ctx, "780AB6", "/2026-01-30"); ok {
+		t.Error("Get() hit after expiry")
+	}
+
+	c.Set(ctx, "780AB6", "/2026-01-30", []byte("d"))
+	c.Invalidate(ctx, "")
+	if _, ok := c.Get(ctx, "780AB6", "/2026-01-30"); ok || c.entries != 0 {
+		t.Errorf("Invalidate(\"\") kept entries: %d", c.entries)
+	}
+}
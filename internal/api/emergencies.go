@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+)
+
+// Limits on the emergency events endpoint.
+const (
+	defaultEmergencyWindow = 24 * time.Hour
+	defaultEmergencyLimit  = 100
+	maxEmergencyLimit      = 1000
+)
+
+// emergencyKinds are the kinds the emergency events endpoint can filter on.
+var emergencyKinds = map[string]bool{
+	state.EmergencyADSC:   true,
+	state.EmergencyMayday: true,
+	state.EmergencyPan:    true,
+	state.EmergencySquawk: true,
+}
+
+// EmergencyEventResponse is the JSON representation of an emergency event.
+type EmergencyEventResponse struct {
+	Kind         string   `json:"kind"`
+	Timestamp    string   `json:"timestamp"`
+	ICAOHex      string   `json:"icao_hex,omitempty"`
+	Registration string   `json:"registration,omitempty"`
+	Flight       string   `json:"flight,omitempty"`
+	Label        string   `json:"label,omitempty"`
+	Source       string   `json:"source,omitempty"`
+	Detail       string   `json:"detail,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	Altitude     *int     `json:"altitude,omitempty"`
+	MessageID    int64    `json:"message_id,omitempty"`
+}
+
+// EmergencyEventsResponse is the JSON response for recent emergency events.
+type EmergencyEventsResponse struct {
+	Since  string                   `json:"since"`
+	Kind   string                   `json:"kind,omitempty"`
+	Events []EmergencyEventResponse `json:"events"`
+}
+
+// parseEmergencyQuery reads the since, kind and limit query parameters. Since
+// is an RFC 3339 time or a date, and defaults to a day before now.
+func parseEmergencyQuery(q url.Values, now time.Time) (since time.Time, kind string, limit int, err error) {
+	since = now.Add(-defaultEmergencyWindow)
+	if v := q.Get("since"); v != "" {
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			if since, err = time.Parse("2006-01-02", v); err != nil {
+				return since, "", 0, errors.New("invalid since (use RFC 3339 or YYYY-MM-DD)")
+			}
+		}
+	}
+
+	kind = strings.ToLower(q.Get("kind"))
+	if kind != "" && !emergencyKinds[kind] {
+		return since, "", 0, errors.New("unknown emergency kind")
+	}
+
+	limit = defaultEmergencyLimit
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return since, "", 0, errors.New("limit must be a positive integer")
+		}
+		if limit > maxEmergencyLimit {
+			limit = maxEmergencyLimit
+		}
+	}
+	return since, kind, limit, nil
+}
+
+func emergencyEventToResponse(e storage.EmergencyEvent) EmergencyEventResponse {
+	return EmergencyEventResponse{
+		Kind:         e.Kind,
+		Timestamp:    e.Timestamp.UTC().Format(time.RFC3339),
+		ICAOHex:      e.ICAOHex,
+		Registration: e.Registration,
+		Flight:       e.Flight,
+		Label:        e.Label,
+		Source:       e.Source,
+		Detail:       e.Detail,
+		Latitude:     e.Latitude,
+		Longitude:    e.Longitude,
+		Altitude:     e.Altitude,
+		MessageID:    e.MessageID,
+	}
+}
+
+func (s *EnrichmentServer) handleGetEmergencies(w http.ResponseWriter, r *http.Request) {
+	since, kind, limit, err := parseEmergencyQuery(r.URL.Query(), time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := s.pg.GetEmergencyEvents(r.Context(), kind, since, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := EmergencyEventsResponse{
+		Since:  since.UTC().Format(time.RFC3339),
+		Kind:   kind,
+		Events: make([]EmergencyEventResponse, 0, len(events)),
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, emergencyEventToResponse(e))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
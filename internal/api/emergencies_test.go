@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseEmergencyQuery(t *testing.T) {
+	now := time.Date(2026, 1, 30, 12, 0, 0, 0, time.UTC)
+
+	since, kind, limit, err := parseEmergencyQuery(url.Values{}, now)
+	if err != nil || !since.Equal(now.Add(-24*time.Hour)) || kind != "" || limit != defaultEmergencyLimit {
+		t.Errorf("defaults = %v, %q, %d, %v", since, kind, limit, err)
+	}
+
+	q := url.Values{"since": {"2026-01-30T09:00:00Z"}, "kind": {"MAYDAY"}, "limit": {"5000"}}
+	since, kind, limit, err = parseEmergencyQuery(q, now)
+	if err != nil || !since.Equal(time.Date(2026, 1, 30, 9, 0, 0, 0, time.UTC)) || kind != "mayday" || limit != maxEmergencyLimit {
+		t.Errorf("parsed = %v, %q, %d, %v", since, kind, limit, err)
+	}
+
+	if since, _, _, err := parseEmergencyQuery(url.Values{"since": {"2026-01-29"}}, now); err != nil || !since.Equal(time.Date(2026, 1, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date since = %v, %v", since, err)
+	}
+
+	for _, bad := range []url.Values{
+		{"since": {"yesterday"}},
+		{"kind": {"fire"}},
+		{"limit": {"0"}},
+	} {
+		if _, _, _, err := parseEmergencyQuery(bad, now); err == nil {
+			t.Errorf("parseEmergencyQuery(%v) succeeded, want error", bad)
+		}
+	}
+}
@@ -4,10 +4,14 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -21,35 +25,97 @@ type EnrichmentServer struct {
 	pg          *storage.PostgresDB
 	port        int
 	authEnabled bool
-	apiKeys     map[string]bool // Simple API key auth (when enabled).
+	apiKeys     map[string][]string // Simple API key auth (when enabled), to the key's tenants.
+
+	messages *storage.ClickHouseDB // Stored messages for search; nil when off.
+
+	cache          Cache // Enrichment lookups; nil when caching is off.
+	invalidateOnce sync.Once
+
+	shutdownTimeout time.Duration
+	draining        atomic.Bool // Set once shutdown starts; fails readiness.
 }
 
 // Config holds configuration for the enrichment API server.
 type Config struct {
 	Port        int
 	AuthEnabled bool
-	APIKeys     []string // List of valid API keys.
+	APIKeys     []string // List of valid API keys, each optionally scoped as "KEY:TENANT|TENANT".
+
+	// CacheTTL is how long enrichment lookups are cached; 0 disables the
+	// cache. Entries are also dropped as soon as the enrichment changes.
+	CacheTTL time.Duration
+	// RedisAddr selects a Redis cache shared between instances instead of
+	// the in-process cache.
+	RedisAddr     string
+	RedisPassword string
+
+	// ShutdownTimeout is how long Run waits for in-flight requests to
+	// finish once its context is done (default: DefaultShutdownTimeout).
+	ShutdownTimeout time.Duration
 }
 
+// DefaultShutdownTimeout is how long in-flight requests are given to finish
+// on shutdown, within the 30 second grace period Kubernetes allows by default.
+const DefaultShutdownTimeout = 20 * time.Second
+
 // NewEnrichmentServer creates a new enrichment API server.
 func NewEnrichmentServer(pg *storage.PostgresDB, cfg Config) *EnrichmentServer {
-	keys := make(map[string]bool)
+	keys := make(map[string][]string)
 	for _, k := range cfg.APIKeys {
-		if k != "" {
-			keys[k] = true
+		if key, tenants := parseAPIKey(k); key != "" {
+			keys[key] = tenants
 		}
 	}
 
+	var cache Cache
+	switch {
+	case cfg.CacheTTL <= 0:
+	case cfg.RedisAddr != "":
+		cache = NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.CacheTTL)
+	default:
+		cache = NewMemoryCache(cfg.CacheTTL)
+	}
+
+	shutdownTimeout := cfg.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+
 	return &EnrichmentServer{
-		pg:          pg,
-		port:        cfg.Port,
-		authEnabled: cfg.AuthEnabled,
-		apiKeys:     keys,
+		pg:              pg,
+		port:            cfg.Port,
+		authEnabled:     cfg.AuthEnabled,
+		apiKeys:         keys,
+		cache:           cache,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// SetMessages enables the message search endpoints over the messages stored
+// in ClickHouse. Without it they answer 503.
+func (s *EnrichmentServer) SetMessages(ch *storage.ClickHouseDB) {
+	s.messages = ch
+}
+
+// startCacheInvalidation starts dropping cached lookups as enrichment
+// changes, once per server, until ctx is done.
+func (s *EnrichmentServer) startCacheInvalidation(ctx context.Context) {
+	if s.cache == nil || s.pg == nil {
+		return
 	}
+	s.invalidateOnce.Do(func() {
+		go s.pg.ListenEnrichmentChanges(ctx, func(icaoHex string) {
+			s.cache.Invalidate(ctx, icaoHex)
+		})
+	})
 }
 
-// Run starts the HTTP server.
-func (s *EnrichmentServer) Run() error {
+// Run starts the HTTP server and serves until ctx is done. It then fails
+// readiness checks, stops accepting connections and waits up to the shutdown
+// timeout for in-flight requests before returning.
+func (s *EnrichmentServer) Run(ctx context.Context) error {
+	s.startCacheInvalidation(ctx)
 	r := chi.NewRouter()
 
 	// Standard middleware.
@@ -61,23 +127,73 @@ func (s *EnrichmentServer) Run() error {
 	// CORS for browser access.
 	r.Use(corsMiddleware)
 
-	// Optional authentication.
-	if s.authEnabled {
-		r.Use(s.authMiddleware)
-	}
-
 	// API routes.
 	r.Route("/api/v1", func(r chi.Router) {
-		// Health check (no auth required).
+		// Liveness and readiness checks, open to probes without a key.
 		r.Get("/health", s.handleHealth)
+		r.Get("/ready", s.handleReady)
 
-		// Enrichment endpoints.
-		r.Get("/enrichment/{icao_hex}", s.handleGetEnrichment)
-		r.Get("/enrichment/{icao_hex}/{callsign}", s.handleGetEnrichmentByCallsign)
-		r.Get("/enrichment/{icao_hex}/{callsign}/{date}", s.handleGetEnrichmentByDate)
+		r.Group(func(r chi.Router) {
+			// Optional authentication.
+			if s.authEnabled {
+				r.Use(s.authMiddleware)
+			}
 
-		// Batch lookup for multiple aircraft.
-		r.Post("/enrichment/batch", s.handleBatchEnrichment)
+			// Enrichment endpoints.
+			r.Get("/enrichment/{icao_hex}", s.handleGetEnrichment)
+			r.Get("/enrichment/{icao_hex}/{callsign}", s.handleGetEnrichmentByCallsign)
+			r.Get("/enrichment/{icao_hex}/{callsign}/{date}", s.handleGetEnrichmentByDate)
+			r.Get("/enrichment/{icao_hex}/{callsign}/{date}/audit", s.handleGetEnrichmentAudit)
+
+			// Batch lookup for multiple aircraft.
+			r.Post("/enrichment/batch", s.handleBatchEnrichment)
+
+			// Airline reference data and callsign normalisation.
+			r.Get("/airlines", s.handleListAirlines)
+			r.Get("/airlines/{code}", s.handleGetAirline)
+			r.Get("/callsign/{flight}", s.handleNormaliseCallsign)
+
+			// Versioned JSON Schemas of the parse results.
+			r.Get("/schemas", s.handleListSchemas)
+			r.Get("/schemas/{type}", s.handleGetSchema)
+
+			// Search over the stored messages.
+			r.Get("/messages", s.handleSearchMessages)
+			r.Get("/messages/{id}", s.handleGetMessage)
+
+			// State not kept by tenant, closed to scoped keys.
+			r.Group(func(r chi.Router) {
+				r.Use(unscoped)
+
+				// Flight history per airframe.
+				r.Get("/aircraft/{icao_hex}/flights", s.handleGetAircraftFlights)
+				r.Get("/aircraft/{icao_hex}/flights/{callsign}/{date}/track", s.handleGetFlightTrack)
+				r.Get("/aircraft/{icao_hex}/flights/{callsign}/{date}/comms", s.handleGetFlightComms)
+				r.Get("/aircraft/{icao_hex}/flights/{callsign}/{date}/squawks", s.handleGetFlightSquawks)
+
+				// Current position dead-reckoned from the latest ACARS position.
+				r.Get("/aircraft/{icao_hex}/position", s.handleGetPositionEstimate)
+
+				// Parse coverage trend and ground station coverage.
+				r.Get("/stats/coverage", s.handleGetCoverage)
+				r.Get("/stats/ground-stations", s.handleGetGroundStations)
+
+				// Recent emergency events.
+				r.Get("/emergencies", s.handleGetEmergencies)
+
+				// Latest ACARS positions by area.
+				r.Get("/positions", s.handleGetPositions)
+				r.Get("/positions/near", s.handleGetPositionsNear)
+
+				// Winds aloft and turbulence reported by aircraft.
+				r.Get("/winds", s.handleGetWinds)
+				r.Get("/winds/observations", s.handleGetWeatherObservations)
+				r.Get("/turbulence", s.handleGetTurbulence)
+
+				// Arrival runway configuration per airport.
+				r.Get("/airports/{icao}/runway-config", s.handleGetRunwayConfig)
+			})
+		})
 	})
 
 	addr := ":" + itoa(s.port)
@@ -87,24 +203,77 @@ func (s *EnrichmentServer) Run() error {
 	} else {
 		log.Printf("Authentication: DISABLED (open access)")
 	}
+	if s.cache != nil {
+		log.Printf("Cache: ENABLED")
+	}
+
+	srv := &http.Server{Addr: addr, Handler: r}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
 
-	return http.ListenAndServe(addr, r)
+	log.Printf("Enrichment API shutting down")
+	s.draining.Store(true)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // Router returns the configured chi router for embedding in other servers.
 func (s *EnrichmentServer) Router() chi.Router {
+	s.startCacheInvalidation(context.Background())
 	r := chi.NewRouter()
+	r.Get("/health", s.handleHealth)
+	r.Get("/ready", s.handleReady)
 
-	// Optional authentication.
-	if s.authEnabled {
-		r.Use(s.authMiddleware)
-	}
+	r.Group(func(r chi.Router) {
+		// Optional authentication, for everything but the probes.
+		if s.authEnabled {
+			r.Use(s.authMiddleware)
+		}
 
-	r.Get("/health", s.handleHealth)
-	r.Get("/enrichment/{icao_hex}", s.handleGetEnrichment)
-	r.Get("/enrichment/{icao_hex}/{callsign}", s.handleGetEnrichmentByCallsign)
-	r.Get("/enrichment/{icao_hex}/{callsign}/{date}", s.handleGetEnrichmentByDate)
-	r.Post("/enrichment/batch", s.handleBatchEnrichment)
+		r.Get("/enrichment/{icao_hex}", s.handleGetEnrichment)
+		r.Get("/enrichment/{icao_hex}/{callsign}", s.handleGetEnrichmentByCallsign)
+		r.Get("/enrichment/{icao_hex}/{callsign}/{date}", s.handleGetEnrichmentByDate)
+		r.Get("/enrichment/{icao_hex}/{callsign}/{date}/audit", s.handleGetEnrichmentAudit)
+		r.Post("/enrichment/batch", s.handleBatchEnrichment)
+		r.Get("/airlines", s.handleListAirlines)
+		r.Get("/airlines/{code}", s.handleGetAirline)
+		r.Get("/callsign/{flight}", s.handleNormaliseCallsign)
+		r.Get("/schemas", s.handleListSchemas)
+		r.Get("/schemas/{type}", s.handleGetSchema)
+		r.Get("/messages", s.handleSearchMessages)
+		r.Get("/messages/{id}", s.handleGetMessage)
+
+		r.Group(func(r chi.Router) {
+			r.Use(unscoped)
+			r.Get("/aircraft/{icao_hex}/flights", s.handleGetAircraftFlights)
+			r.Get("/aircraft/{icao_hex}/flights/{callsign}/{date}/track", s.handleGetFlightTrack)
+			r.Get("/aircraft/{icao_hex}/flights/{callsign}/{date}/comms", s.handleGetFlightComms)
+			r.Get("/aircraft/{icao_hex}/flights/{callsign}/{date}/squawks", s.handleGetFlightSquawks)
+			r.Get("/aircraft/{icao_hex}/position", s.handleGetPositionEstimate)
+			r.Get("/stats/coverage", s.handleGetCoverage)
+			r.Get("/stats/ground-stations", s.handleGetGroundStations)
+			r.Get("/emergencies", s.handleGetEmergencies)
+			r.Get("/positions", s.handleGetPositions)
+			r.Get("/positions/near", s.handleGetPositionsNear)
+			r.Get("/winds", s.handleGetWinds)
+			r.Get("/winds/observations", s.handleGetWeatherObservations)
+			r.Get("/turbulence", s.handleGetTurbulence)
+			r.Get("/airports/{icao}/runway-config", s.handleGetRunwayConfig)
+		})
+	})
 
 	return r
 }
@@ -144,36 +313,73 @@ func (s *EnrichmentServer) authMiddleware(next http.Handler) http.Handler {
 			apiKey = r.URL.Query().Get("api_key")
 		}
 
-		if apiKey == "" {
-			writeError(w, http.StatusUnauthorized, "API key required")
+		tenants, err := s.checkAPIKey(apiKey)
+		switch err {
+		case errAPIKeyRequired:
+			writeError(w, http.StatusUnauthorized, err.Error())
 			return
-		}
-
-		if !s.apiKeys[apiKey] {
-			writeError(w, http.StatusForbidden, "Invalid API key")
+		case errInvalidAPIKey:
+			writeError(w, http.StatusForbidden, err.Error())
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(withTenants(r.Context(), tenants)))
 	})
 }
 
+// API key errors, shared by the REST and gRPC APIs.
+var (
+	errAPIKeyRequired = errors.New("API key required")
+	errInvalidAPIKey  = errors.New("Invalid API key")
+)
+
+// checkAPIKey reports whether apiKey may use the API when auth is enabled,
+// and returns the tenants it is scoped to (nil for none).
+func (s *EnrichmentServer) checkAPIKey(apiKey string) ([]string, error) {
+	if apiKey == "" {
+		return nil, errAPIKeyRequired
+	}
+	tenants, ok := s.apiKeys[apiKey]
+	if !ok {
+		return nil, errInvalidAPIKey
+	}
+	return tenants, nil
+}
+
 // EnrichmentResponse is the JSON response for enrichment queries.
 type EnrichmentResponse struct {
-	ICAOHex         string            `json:"icao_hex"`
-	Callsign        string            `json:"callsign"`
-	FlightDate      string            `json:"flight_date"`
-	Origin          string            `json:"origin,omitempty"`
-	Destination     string            `json:"destination,omitempty"`
-	Route           []string          `json:"route,omitempty"`
-	ETA             string            `json:"eta,omitempty"`
-	DepartureRunway string            `json:"departure_runway,omitempty"`
-	ArrivalRunway   string            `json:"arrival_runway,omitempty"`
-	SID             string            `json:"sid,omitempty"`
-	Squawk          string            `json:"squawk,omitempty"`
-	PaxCount        int               `json:"pax_count,omitempty"`
-	PaxBreakdown    map[string]int    `json:"pax_breakdown,omitempty"`
-	LastUpdated     string            `json:"last_updated"`
+	ICAOHex          string                    `json:"icao_hex"`
+	Callsign         string                    `json:"callsign"`
+	FlightDate       string                    `json:"flight_date"`     // Date of departure at the origin, when known.
+	FlightDateUTC    string                    `json:"flight_date_utc"` // Date of departure in UTC.
+	ScheduledDep     string                    `json:"scheduled_departure,omitempty"`
+	Origin           string                    `json:"origin,omitempty"`
+	Destination      string                    `json:"destination,omitempty"`
+	Route            []string                  `json:"route,omitempty"`
+	ETA              string                    `json:"eta,omitempty"`
+	ETASource        string                    `json:"eta_source,omitempty"`              // Sources the ETA was fused from.
+	ETAUncertainty   int                       `json:"eta_uncertainty_minutes,omitempty"` // One standard deviation.
+	DepartureRunway  string                    `json:"departure_runway,omitempty"`
+	ArrivalRunway    string                    `json:"arrival_runway,omitempty"`
+	SID              string                    `json:"sid,omitempty"`
+	STAR             string                    `json:"star,omitempty"`
+	SIDWaypoints     []string                  `json:"sid_waypoints,omitempty"`
+	STARWaypoints    []string                  `json:"star_waypoints,omitempty"`
+	Squawk           string                    `json:"squawk,omitempty"`
+	PaxCount         int                       `json:"pax_count,omitempty"`
+	PaxBreakdown     map[string]int            `json:"pax_breakdown,omitempty"`
+	OceanicClearance *OceanicClearanceResponse `json:"oceanic_clearance,omitempty"`
+	LastUpdated      string                    `json:"last_updated"`
+}
+
+// OceanicClearanceResponse is the oceanic clearance of an enrichment.
+type OceanicClearanceResponse struct {
+	Track       string   `json:"track,omitempty"` // NAT track letter; omitted for a random route.
+	Route       []string `json:"route,omitempty"`
+	EntryPoint  string   `json:"entry_point,omitempty"`
+	EntryTime   string   `json:"entry_time,omitempty"` // HH:MM UTC.
+	FlightLevel int      `json:"flight_level,omitempty"`
+	Mach        float64  `json:"mach,omitempty"`
 }
 
 func enrichmentToResponse(e *storage.FlightEnrichment) EnrichmentResponse {
@@ -181,29 +387,114 @@ func enrichmentToResponse(e *storage.FlightEnrichment) EnrichmentResponse {
 		ICAOHex:         e.ICAOHex,
 		Callsign:        e.Callsign,
 		FlightDate:      e.FlightDate.Format("2006-01-02"),
+		FlightDateUTC:   e.FlightDateUTC.Format("2006-01-02"),
 		Origin:          e.Origin,
 		Destination:     e.Destination,
 		Route:           e.Route,
+		ETASource:       e.ETASource,
 		DepartureRunway: e.DepartureRunway,
 		ArrivalRunway:   e.ArrivalRunway,
 		SID:             e.SID,
+		STAR:            e.STAR,
+		SIDWaypoints:    e.SIDWaypoints,
+		STARWaypoints:   e.STARWaypoints,
 		Squawk:          e.Squawk,
 		LastUpdated:     e.UpdatedAt.Format(time.RFC3339),
 	}
 
+	if e.ScheduledDeparture != nil {
+		resp.ScheduledDep = e.ScheduledDeparture.UTC().Format(time.RFC3339)
+	}
 	if e.ETA != nil {
 		resp.ETA = e.ETA.Format("15:04")
 	}
+	if e.ETAUncertainty != nil {
+		resp.ETAUncertainty = *e.ETAUncertainty
+	}
 	if e.PaxCount != nil {
 		resp.PaxCount = *e.PaxCount
 	}
 	if len(e.PaxBreakdown) > 0 {
 		resp.PaxBreakdown = e.PaxBreakdown
 	}
+	if c := e.OceanicClearance; c != nil {
+		resp.OceanicClearance = &OceanicClearanceResponse{
+			Track:       c.Track,
+			Route:       c.Route,
+			EntryPoint:  c.EntryPoint,
+			FlightLevel: c.FlightLevel,
+			Mach:        c.Mach,
+		}
+		if c.EntryTime != nil {
+			resp.OceanicClearance.EntryTime = c.EntryTime.UTC().Format("15:04")
+		}
+	}
 
 	return resp
 }
 
+// parseDateBasis reads the date_basis option: "local" (the default) matches
+// flights by their date at the origin airport, "utc" by their UTC date.
+func parseDateBasis(v string) (storage.DateBasis, bool) {
+	switch strings.ToLower(v) {
+	case "", "local":
+		return storage.DateLocal, true
+	case "utc":
+		return storage.DateUTC, true
+	}
+	return storage.DateLocal, false
+}
+
+// errDateBasis is the error for an unknown date_basis.
+const errDateBasis = "Invalid date_basis (use local or utc)"
+
+// lookupEnrichments returns an aircraft's enrichments for a date, limited to
+// one callsign if given and to the tenants of the request's API key, from the
+// cache when possible. Empty results are cached too, as most polled aircraft
+// have no enrichment.
+func (s *EnrichmentServer) lookupEnrichments(ctx context.Context, icaoHex, callsign string, date time.Time, basis storage.DateBasis) ([]EnrichmentResponse, error) {
+	tenants := requestTenants(ctx)
+	key := callsign + "/" + date.Format("2006-01-02")
+	if basis == storage.DateUTC {
+		key += "/utc"
+	}
+	key += tenantsCacheKey(tenants)
+	if s.cache != nil {
+		if b, ok := s.cache.Get(ctx, icaoHex, key); ok {
+			var cached []EnrichmentResponse
+			if err := json.Unmarshal(b, &cached); err == nil {
+				return cached, nil
+			}
+		}
+	}
+
+	results := []EnrichmentResponse{}
+	if callsign != "" {
+		enrichment, err := s.pg.GetFlightEnrichment(ctx, icaoHex, callsign, date, basis, tenants)
+		if err != nil {
+			return nil, err
+		}
+		if enrichment != nil {
+			results = append(results, enrichmentToResponse(enrichment))
+		}
+	} else {
+		enrichments, err := s.pg.GetFlightEnrichmentsByAircraft(ctx, icaoHex, date, basis, tenants)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range enrichments {
+			results = append(results, enrichmentToResponse(&e))
+		}
+	}
+
+	if s.cache != nil {
+		if b, err := json.Marshal(results); err == nil {
+			s.cache.Set(ctx, icaoHex, key, b)
+		}
+	}
+	return results, nil
+}
+
 func (s *EnrichmentServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{
 		"status": "ok",
@@ -211,6 +502,35 @@ func (s *EnrichmentServer) handleHealth(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// readyTimeout bounds the database check of a readiness probe.
+const readyTimeout = 2 * time.Second
+
+// handleReady reports whether the server should receive traffic: it is not
+// shutting down and PostgreSQL answers. Unlike /health, a failure here is
+// expected to be temporary, so the server is taken out of rotation rather
+// than restarted.
+func (s *EnrichmentServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	status, reason := http.StatusOK, ""
+	if s.draining.Load() {
+		status, reason = http.StatusServiceUnavailable, "shutting down"
+	} else if s.pg != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		if err := s.pg.Ping(ctx); err != nil {
+			status, reason = http.StatusServiceUnavailable, "postgres: "+err.Error()
+		}
+	}
+
+	resp := map[string]string{
+		"status": "ready",
+		"time":   time.Now().UTC().Format(time.RFC3339),
+	}
+	if status != http.StatusOK {
+		resp["status"], resp["reason"] = "unavailable", reason
+	}
+	writeJSON(w, status, resp)
+}
+
 func (s *EnrichmentServer) handleGetEnrichment(w http.ResponseWriter, r *http.Request) {
 	icaoHex := strings.ToUpper(chi.URLParam(r, "icao_hex"))
 	if icaoHex == "" {
@@ -218,27 +538,28 @@ func (s *EnrichmentServer) handleGetEnrichment(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	ctx := context.Background()
+	basis, ok := parseDateBasis(r.URL.Query().Get("date_basis"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, errDateBasis)
+		return
+	}
+
+	ctx := r.Context()
 
 	// Get all enrichments for this aircraft on today's date.
 	today := time.Now().UTC().Truncate(24 * time.Hour)
-	enrichments, err := s.pg.GetFlightEnrichmentsByAircraft(ctx, icaoHex, today)
+	results, err := s.lookupEnrichments(ctx, icaoHex, "", today, basis)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if len(enrichments) == 0 {
+	if len(results) == 0 {
 		writeError(w, http.StatusNotFound, "No enrichment data found for aircraft")
 		return
 	}
 
 	// Return the most recent enrichment (or all if multiple callsigns).
-	var results []EnrichmentResponse
-	for _, e := range enrichments {
-		results = append(results, enrichmentToResponse(&e))
-	}
-
 	writeJSON(w, http.StatusOK, results)
 }
 
@@ -251,22 +572,28 @@ func (s *EnrichmentServer) handleGetEnrichmentByCallsign(w http.ResponseWriter,
 		return
 	}
 
-	ctx := context.Background()
+	basis, ok := parseDateBasis(r.URL.Query().Get("date_basis"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, errDateBasis)
+		return
+	}
+
+	ctx := r.Context()
 
 	// Default to today.
 	today := time.Now().UTC().Truncate(24 * time.Hour)
-	enrichment, err := s.pg.GetFlightEnrichment(ctx, icaoHex, callsign, today)
+	results, err := s.lookupEnrichments(ctx, icaoHex, callsign, today, basis)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if enrichment == nil {
+	if len(results) == 0 {
 		writeError(w, http.StatusNotFound, "No enrichment data found")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, enrichmentToResponse(enrichment))
+	writeJSON(w, http.StatusOK, results[0])
 }
 
 func (s *EnrichmentServer) handleGetEnrichmentByDate(w http.ResponseWriter, r *http.Request) {
@@ -284,37 +611,190 @@ func (s *EnrichmentServer) handleGetEnrichmentByDate(w http.ResponseWriter, r *h
 		writeError(w, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
 		return
 	}
+	basis, ok := parseDateBasis(r.URL.Query().Get("date_basis"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, errDateBasis)
+		return
+	}
 
-	ctx := context.Background()
-	enrichment, err := s.pg.GetFlightEnrichment(ctx, icaoHex, callsign, date)
+	ctx := r.Context()
+	results, err := s.lookupEnrichments(ctx, icaoHex, callsign, date, basis)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if enrichment == nil {
+	if len(results) == 0 {
+		writeError(w, http.StatusNotFound, "No enrichment data found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results[0])
+}
+
+// EnrichmentChangeResponse is the JSON representation of a change made to
+// one field of an enrichment.
+type EnrichmentChangeResponse struct {
+	ChangedAt string          `json:"changed_at"`
+	Field     string          `json:"field"`
+	OldValue  json.RawMessage `json:"old_value,omitempty"`
+	NewValue  json.RawMessage `json:"new_value,omitempty"`
+	Callsign  string          `json:"callsign"`         // Callsign the update was made under.
+	Merged    bool            `json:"merged,omitempty"` // Merged into a row with another callsign.
+	MessageID int64           `json:"message_id,omitempty"`
+	Parser    string          `json:"parser,omitempty"`
+}
+
+// EnrichmentAuditResponse is the JSON response for an enrichment's audit
+// trail: its current values and the changes that led to them, oldest first.
+type EnrichmentAuditResponse struct {
+	Enrichment EnrichmentResponse         `json:"enrichment"`
+	Changes    []EnrichmentChangeResponse `json:"changes"`
+}
+
+func (s *EnrichmentServer) handleGetEnrichmentAudit(w http.ResponseWriter, r *http.Request) {
+	icaoHex := strings.ToUpper(chi.URLParam(r, "icao_hex"))
+	callsign := strings.ToUpper(chi.URLParam(r, "callsign"))
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+	basis, ok := parseDateBasis(r.URL.Query().Get("date_basis"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, errDateBasis)
+		return
+	}
+
+	ctx := r.Context()
+	tenants := requestTenants(ctx)
+	e, err := s.pg.GetFlightEnrichment(ctx, icaoHex, callsign, date, basis, tenants)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if e == nil {
 		writeError(w, http.StatusNotFound, "No enrichment data found")
 		return
 	}
+	changes, err := s.pg.GetEnrichmentAudit(ctx, icaoHex, callsign, date, basis, tenants)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	writeJSON(w, http.StatusOK, enrichmentToResponse(enrichment))
+	resp := EnrichmentAuditResponse{
+		Enrichment: enrichmentToResponse(e),
+		Changes:    make([]EnrichmentChangeResponse, 0, len(changes)),
+	}
+	for _, c := range changes {
+		resp.Changes = append(resp.Changes, EnrichmentChangeResponse{
+			ChangedAt: c.ChangedAt.UTC().Format(time.RFC3339),
+			Field:     c.Field,
+			OldValue:  c.OldValue,
+			NewValue:  c.NewValue,
+			Callsign:  c.UpdateCallsign,
+			Merged:    c.Merged,
+			MessageID: c.MessageID,
+			Parser:    c.Parser,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
+// Batch request limits. A request may name up to maxBatchAircraft queries, of
+// which at most limit (maxBatchLimit) are answered per call; the caller pages
+// through the rest with next_offset.
+const (
+	maxBatchAircraft  = 5000
+	defaultBatchLimit = 100
+	maxBatchLimit     = 500
+)
+
 // BatchRequest is the request body for batch enrichment lookups.
 type BatchRequest struct {
 	Aircraft []BatchAircraftQuery `json:"aircraft"`
+	Fields   []string             `json:"fields,omitempty"` // Optional: response fields to include (default all).
+	Offset   int                  `json:"offset,omitempty"` // Index of the first query to answer.
+	Limit    int                  `json:"limit,omitempty"`  // Queries answered per call (default 100, max 500).
+	// DateBasis is how query dates match flights: "local" (default) by the
+	// date of departure at the origin, "utc" by the UTC date.
+	DateBasis string `json:"date_basis,omitempty"`
 }
 
 // BatchAircraftQuery represents a single aircraft query in a batch request.
 type BatchAircraftQuery struct {
 	ICAOHex  string `json:"icao_hex"`
 	Callsign string `json:"callsign,omitempty"` // Optional: if provided, filters to specific callsign.
+	Date     string `json:"date,omitempty"`     // Optional: flight date (YYYY-MM-DD, default today), by the request's date_basis.
 }
 
 // BatchResponse is the response for batch enrichment lookups.
 type BatchResponse struct {
-	Results map[string][]EnrichmentResponse `json:"results"` // Keyed by icao_hex.
-	Errors  map[string]string               `json:"errors,omitempty"`
+	Results    map[string][]EnrichmentResponse `json:"results"` // Keyed by icao_hex.
+	Errors     map[string]string               `json:"errors,omitempty"`
+	Total      int                             `json:"total"`                 // Queries in the request.
+	NextOffset int                             `json:"next_offset,omitempty"` // Offset of the next page, if any.
+}
+
+// batchFields are the enrichment fields a batch request may select. The
+// identifying fields are always included, whether selected or not.
+var batchFields = map[string]bool{
+	"icao_hex": true, "callsign": true, "flight_date": true, "flight_date_utc": true,
+	"last_updated": true, "scheduled_departure": true, "origin": true, "destination": true, "route": true, "eta": true,
+	"departure_runway": true, "arrival_runway": true, "sid": true, "star": true,
+	"sid_waypoints": true, "star_waypoints": true, "squawk": true,
+	"pax_count": true, "pax_breakdown": true, "oceanic_clearance": true,
+}
+
+// selectFields clears the optional fields of resp not in fields, so that they
+// are omitted from the JSON.
+func selectFields(resp *EnrichmentResponse, fields map[string]bool) {
+	if !fields["scheduled_departure"] {
+		resp.ScheduledDep = ""
+	}
+	if !fields["origin"] {
+		resp.Origin = ""
+	}
+	if !fields["destination"] {
+		resp.Destination = ""
+	}
+	if !fields["route"] {
+		resp.Route = nil
+	}
+	if !fields["eta"] {
+		resp.ETA, resp.ETASource, resp.ETAUncertainty = "", "", 0
+	}
+	if !fields["departure_runway"] {
+		resp.DepartureRunway = ""
+	}
+	if !fields["arrival_runway"] {
+		resp.ArrivalRunway = ""
+	}
+	if !fields["sid"] {
+		resp.SID = ""
+	}
+	if !fields["star"] {
+		resp.STAR = ""
+	}
+	if !fields["sid_waypoints"] {
+		resp.SIDWaypoints = nil
+	}
+	if !fields["star_waypoints"] {
+		resp.STARWaypoints = nil
+	}
+	if !fields["squawk"] {
+		resp.Squawk = ""
+	}
+	if !fields["pax_count"] {
+		resp.PaxCount = 0
+	}
+	if !fields["pax_breakdown"] {
+		resp.PaxBreakdown = nil
+	}
+	if !fields["oceanic_clearance"] {
+		resp.OceanicClearance = nil
+	}
 }
 
 func (s *EnrichmentServer) handleBatchEnrichment(w http.ResponseWriter, r *http.Request) {
@@ -329,46 +809,90 @@ func (s *EnrichmentServer) handleBatchEnrichment(w http.ResponseWriter, r *http.
 		return
 	}
 
-	if len(req.Aircraft) > 100 {
-		writeError(w, http.StatusBadRequest, "Maximum 100 aircraft per batch request")
+	if len(req.Aircraft) > maxBatchAircraft {
+		writeError(w, http.StatusBadRequest, "Maximum "+itoa(maxBatchAircraft)+" aircraft per batch request")
+		return
+	}
+
+	limit := req.Limit
+	switch {
+	case limit == 0:
+		limit = defaultBatchLimit
+	case limit < 0 || limit > maxBatchLimit:
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and "+itoa(maxBatchLimit))
+		return
+	}
+	if req.Offset < 0 || req.Offset >= len(req.Aircraft) {
+		writeError(w, http.StatusBadRequest, "offset out of range")
+		return
+	}
+
+	var fields map[string]bool
+	if len(req.Fields) > 0 {
+		fields = make(map[string]bool, len(req.Fields))
+		for _, f := range req.Fields {
+			if !batchFields[f] {
+				writeError(w, http.StatusBadRequest, "Unknown field: "+f)
+				return
+			}
+			fields[f] = true
+		}
+	}
+
+	basis, ok := parseDateBasis(req.DateBasis)
+	if !ok {
+		writeError(w, http.StatusBadRequest, errDateBasis)
 		return
 	}
 
-	ctx := context.Background()
+	// Validate every date up front, so a bad entry on a later page is not
+	// discovered after the caller has consumed the earlier ones.
 	today := time.Now().UTC().Truncate(24 * time.Hour)
+	dates := make([]time.Time, len(req.Aircraft))
+	for i, q := range req.Aircraft {
+		dates[i] = today
+		if q.Date == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", q.Date)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD): "+q.Date)
+			return
+		}
+		dates[i] = date
+	}
+
+	ctx := r.Context()
 
+	end := min(req.Offset+limit, len(req.Aircraft))
 	resp := BatchResponse{
 		Results: make(map[string][]EnrichmentResponse),
 		Errors:  make(map[string]string),
+		Total:   len(req.Aircraft),
+	}
+	if end < len(req.Aircraft) {
+		resp.NextOffset = end
 	}
 
-	for _, q := range req.Aircraft {
+	for i := req.Offset; i < end; i++ {
+		q := req.Aircraft[i]
 		icaoHex := strings.ToUpper(q.ICAOHex)
 		if icaoHex == "" {
 			continue
 		}
 
-		if q.Callsign != "" {
-			// Specific callsign lookup.
-			callsign := strings.ToUpper(q.Callsign)
-			enrichment, err := s.pg.GetFlightEnrichment(ctx, icaoHex, callsign, today)
-			if err != nil {
-				resp.Errors[icaoHex] = err.Error()
-				continue
-			}
-			if enrichment != nil {
-				resp.Results[icaoHex] = []EnrichmentResponse{enrichmentToResponse(enrichment)}
-			}
-		} else {
-			// Get all enrichments for this aircraft.
-			enrichments, err := s.pg.GetFlightEnrichmentsByAircraft(ctx, icaoHex, today)
-			if err != nil {
-				resp.Errors[icaoHex] = err.Error()
-				continue
-			}
-			for _, e := range enrichments {
-				resp.Results[icaoHex] = append(resp.Results[icaoHex], enrichmentToResponse(&e))
+		// With a callsign, look up that flight; otherwise all of the
+		// aircraft's flights.
+		results, err := s.lookupEnrichments(ctx, icaoHex, strings.ToUpper(q.Callsign), dates[i], basis)
+		if err != nil {
+			resp.Errors[icaoHex] = err.Error()
+			continue
+		}
+		for _, er := range results {
+			if fields != nil {
+				selectFields(&er, fields)
 			}
+			resp.Results[icaoHex] = append(resp.Results[icaoHex], er)
 		}
 	}
 
@@ -394,4 +918,4 @@ func writeError(w http.ResponseWriter, status int, message string) {
 
 func itoa(i int) string {
 	return strconv.Itoa(i)
-}
\ No newline at end of file
+}
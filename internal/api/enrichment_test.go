@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,8 +34,8 @@ func (m *mockPostgresDB) addEnrichment(e storage.FlightEnrichment) {
 // EnrichmentStore defines the interface for enrichment storage.
 // This allows us to mock the database in tests.
 type EnrichmentStore interface {
-	GetFlightEnrichment(ctx context.Context, icaoHex, callsign string, flightDate time.Time) (*storage.FlightEnrichment, error)
-	GetFlightEnrichmentsByAircraft(ctx context.Context, icaoHex string, flightDate time.Time) ([]storage.FlightEnrichment, error)
+	GetFlightEnrichment(ctx context.Context, icaoHex, callsign string, flightDate time.Time, basis storage.DateBasis, tenants []string) (*storage.FlightEnrichment, error)
+	GetFlightEnrichmentsByAircraft(ctx context.Context, icaoHex string, flightDate time.Time, basis storage.DateBasis, tenants []string) ([]storage.FlightEnrichment, error)
 }
 
 func TestHealthEndpoint(t *testing.T) {
@@ -66,7 +67,9 @@ func TestAuthMiddleware(t *testing.T) {
 		AuthEnabled: true,
 		APIKeys:     []string{"test-key-123", "another-key"},
 	})
-	router := server.Router()
+	router := server.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
 
 	tests := []struct {
 		name       string
@@ -101,7 +104,7 @@ func TestAuthMiddleware(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			req := httptest.NewRequest(http.MethodGet, "/airlines", nil)
 			if tt.apiKey != "" {
 				if tt.keyHeader == "Authorization" {
 					req.Header.Set("Authorization", "Bearer "+tt.apiKey)
@@ -126,9 +129,11 @@ func TestAuthMiddlewareQueryParam(t *testing.T) {
 		AuthEnabled: true,
 		APIKeys:     []string{"query-key"},
 	})
-	router := server.Router()
+	router := server.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
 
-	req := httptest.NewRequest(http.MethodGet, "/health?api_key=query-key", nil)
+	req := httptest.NewRequest(http.MethodGet, "/airlines?api_key=query-key", nil)
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
@@ -138,6 +143,48 @@ func TestAuthMiddlewareQueryParam(t *testing.T) {
 	}
 }
 
+func TestProbesSkipAuth(t *testing.T) {
+	server := NewEnrichmentServer(nil, Config{
+		Port:        8081,
+		AuthEnabled: true,
+		APIKeys:     []string{"test-key-123"},
+	})
+	router := server.Router()
+
+	for _, path := range []string{"/health", "/ready"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200 without a key, got %d", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/enrichment/7C6CA3/health", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for an API route without a key, got %d", rec.Code)
+	}
+}
+
+func TestReadyWhileDraining(t *testing.T) {
+	server := NewEnrichmentServer(nil, Config{Port: 8081})
+	router := server.Router()
+	server.draining.Store(true)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 while draining, got %d", rec.Code)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp["status"] != "unavailable" || resp["reason"] != "shutting down" {
+		t.Errorf("unexpected response %v", resp)
+	}
+}
+
 func TestEnrichmentResponseFormat(t *testing.T) {
 	now := time.Now().UTC()
 	eta := now.Add(2 * time.Hour)
@@ -159,8 +206,19 @@ func TestEnrichmentResponseFormat(t *testing.T) {
 		UpdatedAt:       now,
 	}
 
+	departure := now.Add(-time.Hour)
+	e.FlightDateUTC = e.FlightDate
+	e.ScheduledDeparture = &departure
+
 	resp := enrichmentToResponse(e)
 
+	if resp.FlightDateUTC != e.FlightDate.Format("2006-01-02") {
+		t.Errorf("expected FlightDateUTC %q, got %q", e.FlightDate.Format("2006-01-02"), resp.FlightDateUTC)
+	}
+	if resp.ScheduledDep != departure.Format(time.RFC3339) {
+		t.Errorf("expected ScheduledDep %q, got %q", departure.Format(time.RFC3339), resp.ScheduledDep)
+	}
+
 	if resp.ICAOHex != "7C6CA3" {
 		t.Errorf("expected ICAOHex '7C6CA3', got %q", resp.ICAOHex)
 	}
@@ -225,6 +283,30 @@ func TestBatchRequestValidation(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 			wantError:  "No aircraft specified",
 		},
+		{
+			name:       "invalid date",
+			body:       `{"aircraft": [{"icao_hex": "7C6CA3", "date": "30/01/2026"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid date format",
+		},
+		{
+			name:       "unknown field",
+			body:       `{"aircraft": [{"icao_hex": "7C6CA3"}], "fields": ["origin", "routing"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Unknown field",
+		},
+		{
+			name:       "limit too large",
+			body:       `{"aircraft": [{"icao_hex": "7C6CA3"}], "limit": 1000}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "limit must be",
+		},
+		{
+			name:       "offset past end",
+			body:       `{"aircraft": [{"icao_hex": "7C6CA3"}], "offset": 1}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "offset out of range",
+		},
 	}
 
 	for _, tt := range tests {
@@ -241,14 +323,38 @@ func TestBatchRequestValidation(t *testing.T) {
 
 			var resp map[string]string
 			if err := json.NewDecoder(rec.Body).Decode(&resp); err == nil {
-				if tt.wantError != "" && resp["error"] == "" {
-					t.Errorf("expected error containing %q", tt.wantError)
+				if tt.wantError != "" && !strings.Contains(resp["error"], tt.wantError) {
+					t.Errorf("expected error containing %q, got %q", tt.wantError, resp["error"])
 				}
 			}
 		})
 	}
 }
 
+func TestSelectFields(t *testing.T) {
+	resp := EnrichmentResponse{
+		ICAOHex:     "7C6CA3",
+		Callsign:    "QFA9",
+		FlightDate:  "2026-01-30",
+		Origin:      "YPPH",
+		Destination: "EGLL",
+		Route:       []string{"JULIM", "BEVLY"},
+		Squawk:      "4521",
+		LastUpdated: "2026-01-30T08:45:00Z",
+	}
+	selectFields(&resp, map[string]bool{"origin": true, "destination": true})
+
+	if resp.Origin != "YPPH" || resp.Destination != "EGLL" {
+		t.Errorf("selected fields cleared: %+v", resp)
+	}
+	if resp.Route != nil || resp.Squawk != "" {
+		t.Errorf("unselected fields kept: %+v", resp)
+	}
+	if resp.ICAOHex != "7C6CA3" || resp.Callsign != "QFA9" || resp.FlightDate == "" || resp.LastUpdated == "" {
+		t.Errorf("identifying fields cleared: %+v", resp)
+	}
+}
+
 func TestCORSHeaders(t *testing.T) {
 	server := NewEnrichmentServer(nil, Config{Port: 8081})
 
@@ -279,6 +385,41 @@ func TestCORSHeaders(t *testing.T) {
 	_ = server
 }
 
+func TestDateBasis(t *testing.T) {
+	for v, want := range map[string]storage.DateBasis{"": storage.DateLocal, "local": storage.DateLocal, "UTC": storage.DateUTC} {
+		if got, ok := parseDateBasis(v); !ok || got != want {
+			t.Errorf("parseDateBasis(%q) = %v, %v", v, got, ok)
+		}
+	}
+
+	server := NewEnrichmentServer(nil, Config{Port: 8081})
+	router := chi.NewRouter()
+	router.Get("/enrichment/{icao_hex}/{callsign}/{date}", server.handleGetEnrichmentByDate)
+	router.Post("/enrichment/batch", server.handleBatchEnrichment)
+
+	req := httptest.NewRequest(http.MethodGet, "/enrichment/7C6CA3/QFA9/2026-01-30?date_basis=zulu", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "date_basis") {
+		t.Errorf("unknown date_basis: %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/enrichment/7C6CA3/QFA9/2026-01-30/audit?date_basis=zulu", nil)
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "date_basis") {
+		t.Errorf("unknown audit date_basis: %d %s", rec.Code, rec.Body.String())
+	}
+
+	body := `{"aircraft": [{"icao_hex": "7C6CA3"}], "date_basis": "origin"}`
+	req = httptest.NewRequest(http.MethodPost, "/enrichment/batch", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "date_basis") {
+		t.Errorf("unknown batch date_basis: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestDateParsing(t *testing.T) {
 	server := NewEnrichmentServer(nil, Config{Port: 8081})
 	router := chi.NewRouter()
@@ -0,0 +1,376 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+)
+
+// Limits on the number of flights returned by the flight history endpoint.
+const (
+	defaultFlightLimit = 100
+	maxFlightLimit     = 1000
+)
+
+// AircraftFlightResponse is the JSON representation of one flight in an
+// aircraft's history.
+type AircraftFlightResponse struct {
+	Callsign     string        `json:"callsign"`
+	FlightDate   string        `json:"flight_date"`
+	Registration string        `json:"registration,omitempty"`
+	Origin       string        `json:"origin,omitempty"`
+	Destination  string        `json:"destination,omitempty"`
+	FirstSeen    string        `json:"first_seen"`
+	LastSeen     string        `json:"last_seen"`
+	MessageCount int           `json:"message_count,omitempty"`
+	Status       string        `json:"status"` // "in_progress", "arrived", "inactive" or "unknown".
+	Source       string        `json:"source"` // "current", "history" or "enrichment".
+	Fuel         *FuelResponse `json:"fuel,omitempty"`
+}
+
+// FuelResponse is the fuel on board at each OOOI event and the fuel burnt
+// between them, in kg. Burns are present only when both ends were reported.
+type FuelResponse struct {
+	Out          *int `json:"out_kg,omitempty"`
+	Off          *int `json:"off_kg,omitempty"`
+	On           *int `json:"on_kg,omitempty"`
+	In           *int `json:"in_kg,omitempty"`
+	BlockBurn    *int `json:"block_burn_kg,omitempty"`
+	AirborneBurn *int `json:"airborne_burn_kg,omitempty"`
+	TaxiOutBurn  *int `json:"taxi_out_burn_kg,omitempty"`
+	TaxiInBurn   *int `json:"taxi_in_burn_kg,omitempty"`
+}
+
+// AircraftFlightsResponse is the JSON response for an aircraft's flight history.
+type AircraftFlightsResponse struct {
+	ICAOHex string                   `json:"icao_hex"`
+	From    string                   `json:"from"`
+	To      string                   `json:"to"`
+	Flights []AircraftFlightResponse `json:"flights"`
+}
+
+func aircraftFlightToResponse(f storage.AircraftFlight) AircraftFlightResponse {
+	resp := AircraftFlightResponse{
+		Callsign:     f.Callsign,
+		FlightDate:   f.FlightDate.Format("2006-01-02"),
+		Registration: f.Registration,
+		Origin:       f.Origin,
+		Destination:  f.Destination,
+		FirstSeen:    f.FirstSeen.UTC().Format(time.RFC3339),
+		LastSeen:     f.LastSeen.UTC().Format(time.RFC3339),
+		MessageCount: f.MsgCount,
+		Status:       f.Completion,
+		Source:       f.Source,
+	}
+	switch {
+	case f.Source == "enrichment":
+		resp.Status = "unknown"
+	case f.Completion == "":
+		resp.Status = "in_progress"
+	}
+	resp.Fuel = fuelToResponse(state.Fuel{Out: f.FuelOut, Off: f.FuelOff, On: f.FuelOn, In: f.FuelIn})
+	return resp
+}
+
+// fuelToResponse returns the fuel figures of a flight, or nil if none were
+// reported.
+func fuelToResponse(f state.Fuel) *FuelResponse {
+	if f == (state.Fuel{}) {
+		return nil
+	}
+	b := f.Burn()
+	return &FuelResponse{
+		Out:          f.Out,
+		Off:          f.Off,
+		On:           f.On,
+		In:           f.In,
+		BlockBurn:    b.Block,
+		AirborneBurn: b.Airborne,
+		TaxiOutBurn:  b.TaxiOut,
+		TaxiInBurn:   b.TaxiIn,
+	}
+}
+
+// parseDateRange reads the from and to query parameters. The range defaults
+// to the 30 days up to today, and dates are inclusive.
+func parseDateRange(q url.Values, today time.Time) (from, to time.Time, err error) {
+	to = today
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, errors.New("invalid to date (use YYYY-MM-DD)")
+		}
+	}
+	from = to.AddDate(0, 0, -30)
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, errors.New("invalid from date (use YYYY-MM-DD)")
+		}
+	}
+	if from.After(to) {
+		return from, to, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// parseFlightRange reads the from, to and limit query parameters, with the
+// range as for parseDateRange.
+func parseFlightRange(q url.Values, today time.Time) (from, to time.Time, limit int, err error) {
+	if from, to, err = parseDateRange(q, today); err != nil {
+		return from, to, 0, err
+	}
+
+	limit = defaultFlightLimit
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return from, to, 0, errors.New("limit must be a positive integer")
+		}
+		if limit > maxFlightLimit {
+			limit = maxFlightLimit
+		}
+	}
+	return from, to, limit, nil
+}
+
+func (s *EnrichmentServer) handleGetAircraftFlights(w http.ResponseWriter, r *http.Request) {
+	icaoHex := strings.ToUpper(chi.URLParam(r, "icao_hex"))
+	if icaoHex == "" {
+		writeError(w, http.StatusBadRequest, "icao_hex is required")
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to, limit, err := parseFlightRange(r.URL.Query(), today)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flights, err := s.pg.ListAircraftFlights(r.Context(), icaoHex, from, to, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := AircraftFlightsResponse{
+		ICAOHex: icaoHex,
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Flights: make([]AircraftFlightResponse, 0, len(flights)),
+	}
+	for _, f := range flights {
+		resp.Flights = append(resp.Flights, aircraftFlightToResponse(f))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *EnrichmentServer) handleGetFlightTrack(w http.ResponseWriter, r *http.Request) {
+	icaoHex := strings.ToUpper(chi.URLParam(r, "icao_hex"))
+	callsign := strings.ToUpper(chi.URLParam(r, "callsign"))
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+
+	ctx := r.Context()
+	flights, err := s.pg.ListAircraftFlights(ctx, icaoHex, date, date, maxFlightLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	flight := findTrackedFlight(flights, callsign)
+	if flight == nil {
+		writeError(w, http.StatusNotFound, "No tracked flight found")
+		return
+	}
+
+	positions, err := s.pg.GetFlightPositions(ctx, flight.Key, flight.FirstSeen, flight.LastSeen)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	body, err := state.TrackGeoJSON(state.TrackFromPositions(positions), map[string]interface{}{
+		"icao_hex":     icaoHex,
+		"callsign":     flight.Callsign,
+		"flight_date":  flight.FlightDate.Format("2006-01-02"),
+		"registration": flight.Registration,
+		"origin":       flight.Origin,
+		"destination":  flight.Destination,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// CommAssignmentResponse is the JSON representation of a SELCAL code or
+// frequency given to a flight.
+type CommAssignmentResponse struct {
+	Timestamp    string `json:"timestamp"`
+	Kind         string `json:"kind"` // "selcal", "contact", "monitor" or "departure".
+	SELCAL       string `json:"selcal,omitempty"`
+	FrequencyKHz int    `json:"frequency_khz,omitempty"`
+	Frequency    string `json:"frequency,omitempty"` // e.g. "8891 kHz" or "134.350 MHz".
+	Band         string `json:"band,omitempty"`
+	Unit         string `json:"unit,omitempty"`
+	Source       string `json:"source,omitempty"`
+}
+
+// FlightCommsResponse is the JSON response for a flight's comm assignments.
+type FlightCommsResponse struct {
+	ICAOHex     string                   `json:"icao_hex"`
+	Callsign    string                   `json:"callsign"`
+	FlightDate  string                   `json:"flight_date"`
+	SELCAL      string                   `json:"selcal,omitempty"` // Latest SELCAL code.
+	Assignments []CommAssignmentResponse `json:"assignments"`
+}
+
+func commAssignmentToResponse(a storage.CommAssignment) CommAssignmentResponse {
+	resp := CommAssignmentResponse{
+		Timestamp:    a.Timestamp.UTC().Format(time.RFC3339),
+		Kind:         a.Kind,
+		SELCAL:       a.SELCAL,
+		FrequencyKHz: a.FrequencyKHz,
+		Band:         a.Band,
+		Unit:         a.Unit,
+		Source:       a.Source,
+	}
+	if a.Band == "hf" {
+		resp.Frequency = fmt.Sprintf("%d kHz", a.FrequencyKHz)
+	} else if a.FrequencyKHz > 0 {
+		resp.Frequency = fmt.Sprintf("%.3f MHz", float64(a.FrequencyKHz)/1000)
+	}
+	return resp
+}
+
+func (s *EnrichmentServer) handleGetFlightComms(w http.ResponseWriter, r *http.Request) {
+	icaoHex := strings.ToUpper(chi.URLParam(r, "icao_hex"))
+	callsign := strings.ToUpper(chi.URLParam(r, "callsign"))
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+
+	ctx := r.Context()
+	flights, err := s.pg.ListAircraftFlights(ctx, icaoHex, date, date, maxFlightLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	flight := findTrackedFlight(flights, callsign)
+	if flight == nil {
+		writeError(w, http.StatusNotFound, "No tracked flight found")
+		return
+	}
+
+	assignments, err := s.pg.GetCommAssignments(ctx, flight.Key, flight.FirstSeen, flight.LastSeen)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := FlightCommsResponse{
+		ICAOHex:     icaoHex,
+		Callsign:    flight.Callsign,
+		FlightDate:  flight.FlightDate.Format("2006-01-02"),
+		Assignments: make([]CommAssignmentResponse, 0, len(assignments)),
+	}
+	for _, a := range assignments {
+		if a.SELCAL != "" {
+			resp.SELCAL = a.SELCAL
+		}
+		resp.Assignments = append(resp.Assignments, commAssignmentToResponse(a))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SquawkResponse is the JSON representation of a transponder code assigned
+// to a flight.
+type SquawkResponse struct {
+	Timestamp string `json:"timestamp"`
+	Squawk    string `json:"squawk"`
+	Source    string `json:"source,omitempty"`
+	MessageID int64  `json:"message_id,omitempty"`
+}
+
+// FlightSquawksResponse is the JSON response for a flight's squawk history.
+type FlightSquawksResponse struct {
+	ICAOHex    string           `json:"icao_hex"`
+	Callsign   string           `json:"callsign"`
+	FlightDate string           `json:"flight_date"`
+	Squawks    []SquawkResponse `json:"squawks"`
+}
+
+func (s *EnrichmentServer) handleGetFlightSquawks(w http.ResponseWriter, r *http.Request) {
+	icaoHex := strings.ToUpper(chi.URLParam(r, "icao_hex"))
+	callsign := strings.ToUpper(chi.URLParam(r, "callsign"))
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+
+	ctx := r.Context()
+	flights, err := s.pg.ListAircraftFlights(ctx, icaoHex, date, date, maxFlightLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	flight := findTrackedFlight(flights, callsign)
+	if flight == nil {
+		writeError(w, http.StatusNotFound, "No tracked flight found")
+		return
+	}
+
+	squawks, err := s.pg.GetSquawkHistory(ctx, flight.Key, flight.FirstSeen, flight.LastSeen)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := FlightSquawksResponse{
+		ICAOHex:    icaoHex,
+		Callsign:   flight.Callsign,
+		FlightDate: flight.FlightDate.Format("2006-01-02"),
+		Squawks:    make([]SquawkResponse, 0, len(squawks)),
+	}
+	for _, sq := range squawks {
+		resp.Squawks = append(resp.Squawks, SquawkResponse{
+			Timestamp: sq.Timestamp.UTC().Format(time.RFC3339),
+			Squawk:    sq.Squawk,
+			Source:    sq.Source,
+			MessageID: sq.MessageID,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// findTrackedFlight returns the earliest tracked flight with a callsign, or
+// nil. Enrichment-only flights have no track.
+func findTrackedFlight(flights []storage.AircraftFlight, callsign string) *storage.AircraftFlight {
+	var found *storage.AircraftFlight
+	for i := range flights {
+		f := &flights[i]
+		if f.Key == "" || f.Callsign != callsign {
+			continue
+		}
+		if found == nil || f.FirstSeen.Before(found.FirstSeen) {
+			found = f
+		}
+	}
+	return found
+}
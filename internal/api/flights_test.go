@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestParseFlightRange(t *testing.T) {
+	today := time.Date(2026, 1, 24, 0, 0, 0, 0, time.UTC)
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantFrom  string
+		wantTo    string
+		wantLimit int
+		wantErr   bool
+	}{
+		{"defaults", "", "2025-12-25", "2026-01-24", defaultFlightLimit, false},
+		{"explicit range", "from=2026-01-01&to=2026-01-10&limit=5", "2026-01-01", "2026-01-10", 5, false},
+		{"from only", "from=2025-06-01", "2025-06-01", "2026-01-24", defaultFlightLimit, false},
+		{"limit capped", "limit=50000", "2025-12-25", "2026-01-24", maxFlightLimit, false},
+		{"bad date", "from=01/01/2026", "", "", 0, true},
+		{"reversed", "from=2026-01-10&to=2026-01-01", "", "", 0, true},
+		{"bad limit", "limit=0", "", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			from, to, limit, err := parseFlightRange(q, today)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !from.Equal(day(tt.wantFrom)) || !to.Equal(day(tt.wantTo)) {
+				t.Errorf("range = %s to %s, want %s to %s", from.Format("2006-01-02"), to.Format("2006-01-02"), tt.wantFrom, tt.wantTo)
+			}
+			if limit != tt.wantLimit {
+				t.Errorf("limit = %d, want %d", limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestAircraftFlightToResponse(t *testing.T) {
+	first := time.Date(2026, 1, 24, 9, 30, 0, 0, time.UTC)
+	f := storage.AircraftFlight{
+		ICAOHex:    "7C6DB8",
+		Callsign:   "QFA1",
+		FlightDate: first.Truncate(24 * time.Hour),
+		Origin:     "YSSY",
+		FirstSeen:  first,
+		LastSeen:   first.Add(2 * time.Hour),
+		MsgCount:   42,
+		Source:     "current",
+	}
+
+	resp := aircraftFlightToResponse(f)
+	if resp.FlightDate != "2026-01-24" || resp.FirstSeen != "2026-01-24T09:30:00Z" || resp.MessageCount != 42 {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Status != "in_progress" {
+		t.Errorf("Status = %q, want in_progress", resp.Status)
+	}
+
+	f.Source, f.Completion = "history", "arrived"
+	if got := aircraftFlightToResponse(f).Status; got != "arrived" {
+		t.Errorf("Status = %q, want arrived", got)
+	}
+
+	f.Source, f.Completion = "enrichment", ""
+	if got := aircraftFlightToResponse(f).Status; got != "unknown" {
+		t.Errorf("Status = %q, want unknown", got)
+	}
+
+	if resp.Fuel != nil {
+		t.Errorf("Fuel = %+v, want nil", resp.Fuel)
+	}
+	out, off, on, in := 12500, 12200, 3500, 3300
+	f.FuelOut, f.FuelOff, f.FuelOn, f.FuelIn = &out, &off, &on, &in
+	fuel := aircraftFlightToResponse(f).Fuel
+	if fuel == nil || *fuel.In != 3300 || *fuel.BlockBurn != 9200 || *fuel.AirborneBurn != 8700 {
+		t.Errorf("Fuel = %+v", fuel)
+	}
+}
+
+func TestFindTrackedFlight(t *testing.T) {
+	t0 := time.Date(2026, 1, 24, 6, 0, 0, 0, time.UTC)
+	flights := []storage.AircraftFlight{
+		{Key: "VHOQA/QFA2", Callsign: "QFA2", FirstSeen: t0},
+		{Key: "VHOQA/QFA1", Callsign: "QFA1", FirstSeen: t0.Add(8 * time.Hour)},
+		{Key: "VHOQA/QFA1", Callsign: "QFA1", FirstSeen: t0.Add(time.Hour)},
+		{Callsign: "QFA3", FirstSeen: t0, Source: "enrichment"},
+	}
+
+	if f := findTrackedFlight(flights, "QFA1"); f == nil || !f.FirstSeen.Equal(t0.Add(time.Hour)) {
+		t.Errorf("findTrackedFlight(QFA1) = %+v, want the earlier flight", f)
+	}
+	if f := findTrackedFlight(flights, "QFA3"); f != nil {
+		t.Errorf("findTrackedFlight(QFA3) = %+v, want nil for enrichment-only flight", f)
+	}
+}
+
+func TestCommAssignmentToResponse(t *testing.T) {
+	ts := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	hf := commAssignmentToResponse(storage.CommAssignment{Timestamp: ts, Kind: "contact", FrequencyKHz: 8891, Band: "hf", Unit: "GANDER RADIO"})
+	if hf.Frequency != "8891 kHz" || hf.Timestamp != "2026-01-24T10:00:00Z" || hf.Unit != "GANDER RADIO" {
+		t.Errorf("hf = %+v", hf)
+	}
+	if vhf := commAssignmentToResponse(storage.CommAssignment{Kind: "monitor", FrequencyKHz: 134350, Band: "vhf"}); vhf.Frequency != "134.350 MHz" {
+		t.Errorf("vhf frequency = %q", vhf.Frequency)
+	}
+	if sc := commAssignmentToResponse(storage.CommAssignment{Kind: "selcal", SELCAL: "ABCD"}); sc.Frequency != "" || sc.SELCAL != "ABCD" {
+		t.Errorf("selcal = %+v", sc)
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+//go:generate protoc -I ../../api --go_out=acarspb --go_opt=paths=source_relative --go-grpc_out=acarspb --go-grpc_opt=paths=source_relative acars.proto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/api/acarspb"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// grpcServer implements the Acars gRPC service on top of an
+// EnrichmentServer, sharing its enrichment lookups, cache and API keys.
+type grpcServer struct {
+	acarspb.UnimplementedAcarsServer
+	s   *EnrichmentServer
+	reg *registry.Registry
+}
+
+// GRPCServer returns a gRPC server for the Acars service (api/acars.proto).
+// Messages are parsed with reg; enrichment lookups and authentication are
+// those of the REST API, with the API key in the x-api-key or authorization
+// ("Bearer <key>") metadata.
+func (s *EnrichmentServer) GRPCServer(reg *registry.Registry) *grpc.Server {
+	var opts []grpc.ServerOption
+	if s.authEnabled {
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				ctx, err := s.grpcAuth(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if _, err := s.grpcAuth(ss.Context()); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+	s.startCacheInvalidation(context.Background())
+
+	gs := grpc.NewServer(opts...)
+	acarspb.RegisterAcarsServer(gs, &grpcServer{s: s, reg: reg})
+	return gs
+}
+
+// grpcAuth checks the API key in the request metadata, and returns ctx
+// carrying the tenants it is scoped to.
+func (s *EnrichmentServer) grpcAuth(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var apiKey string
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		apiKey = v[0]
+	} else if v := md.Get("authorization"); len(v) > 0 && strings.HasPrefix(v[0], "Bearer ") {
+		apiKey = strings.TrimPrefix(v[0], "Bearer ")
+	}
+
+	tenants, err := s.checkAPIKey(apiKey)
+	switch err {
+	case errAPIKeyRequired:
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	case errInvalidAPIKey:
+		return ctx, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return withTenants(ctx, tenants), nil
+}
+
+func (g *grpcServer) ParseMessage(_ context.Context, req *acarspb.ParseMessageRequest) (*acarspb.ParseMessageResponse, error) {
+	return g.parse(req)
+}
+
+func (g *grpcServer) StreamParsedMessages(stream acarspb.Acars_StreamParsedMessagesServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := g.parse(req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// parse dispatches a message as the decode tool does: repaired by the quality
+// checks, then run through every matching parser.
+func (g *grpcServer) parse(req *acarspb.ParseMessageRequest) (*acarspb.ParseMessageResponse, error) {
+	m := req.GetMessage()
+	if m == nil {
+		return nil, status.Error(codes.InvalidArgument, "message is required")
+	}
+
+	msg := &acars.Message{
+		ID:            acars.FlexInt64(m.GetId()),
+		Timestamp:     m.GetTimestamp(),
+		Tail:          m.GetTail(),
+		Label:         m.GetLabel(),
+		Text:          m.GetText(),
+		Frequency:     m.GetFrequency(),
+		BlockID:       m.GetBlockId(),
+		LinkDirection: m.GetLinkDirection(),
+		MsgNo:         m.GetMsgno(),
+		Mode:          m.GetMode(),
+		Ack:           m.GetAck(),
+	}
+	if m.GetFlight() != "" {
+		msg.Flight = &acars.Flight{Flight: m.GetFlight()}
+	}
+	if m.GetIcaoHex() != "" {
+		msg.Airframe = &acars.Airframe{Tail: m.GetTail(), ICAO: strings.ToUpper(m.GetIcaoHex())}
+	}
+
+	prepared, report := quality.Prepare(msg)
+	results := quality.Annotate(g.reg.Dispatch(prepared), report)
+
+	resp := &acarspb.ParseMessageResponse{MessageId: m.GetId()}
+	for _, r := range results {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "encode %s result: %v", r.Type(), err)
+		}
+		resp.Results = append(resp.Results, &acarspb.Result{Type: r.Type(), Data: data})
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) GetEnrichment(ctx context.Context, req *acarspb.GetEnrichmentRequest) (*acarspb.GetEnrichmentResponse, error) {
+	icaoHex := strings.ToUpper(req.GetIcaoHex())
+	if icaoHex == "" {
+		return nil, status.Error(codes.InvalidArgument, "icao_hex is required")
+	}
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.GetDate() != "" {
+		d, err := time.Parse("2006-01-02", req.GetDate())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Invalid date format (use YYYY-MM-DD)")
+		}
+		date = d
+	}
+
+	results, err := g.s.lookupEnrichments(ctx, icaoHex, strings.ToUpper(req.GetCallsign()), date, storage.DateLocal)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &acarspb.GetEnrichmentResponse{}
+	for _, e := range results {
+		resp.Enrichments = append(resp.Enrichments, enrichmentToProto(e))
+	}
+	return resp, nil
+}
+
+// enrichmentToProto converts a REST enrichment response to its gRPC message.
+func enrichmentToProto(e EnrichmentResponse) *acarspb.Enrichment {
+	pe := &acarspb.Enrichment{
+		IcaoHex:         e.ICAOHex,
+		Callsign:        e.Callsign,
+		FlightDate:      e.FlightDate,
+		Origin:          e.Origin,
+		Destination:     e.Destination,
+		Route:           e.Route,
+		Eta:             e.ETA,
+		DepartureRunway: e.DepartureRunway,
+		ArrivalRunway:   e.ArrivalRunway,
+		Sid:             e.SID,
+		Star:            e.STAR,
+		SidWaypoints:    e.SIDWaypoints,
+		StarWaypoints:   e.STARWaypoints,
+		Squawk:          e.Squawk,
+		PaxCount:        int32(e.PaxCount),
+		LastUpdated:     e.LastUpdated,
+	}
+	if len(e.PaxBreakdown) > 0 {
+		pe.PaxBreakdown = make(map[string]int32, len(e.PaxBreakdown))
+		for class, n := range e.PaxBreakdown {
+			pe.PaxBreakdown[class] = int32(n)
+		}
+	}
+	return pe
+}
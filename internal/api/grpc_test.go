@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/api/acarspb"
+	"acars_parser/internal/registry"
+)
+
+type echoResult struct {
+	ID   int64  `json:"message_id"`
+	Text string `json:"text"`
+}
+
+func (r *echoResult) Type() string     { return "echo" }
+func (r *echoResult) MessageID() int64 { return r.ID }
+
+// echoParser returns the text of every label H1 message.
+type echoParser struct{}
+
+func (echoParser) Name() string                { return "echo" }
+func (echoParser) Labels() []string            { return []string{"H1"} }
+func (echoParser) QuickCheck(text string) bool { return true }
+func (echoParser) Priority() int               { return 0 }
+func (echoParser) Parse(msg *acars.Message) registry.Result {
+	return &echoResult{ID: int64(msg.ID), Text: msg.Text}
+}
+
+func dialGRPC(t *testing.T, cfg Config) acarspb.AcarsClient {
+	reg := registry.New()
+	reg.Register(echoParser{})
+	gs := NewEnrichmentServer(nil, cfg).GRPCServer(reg)
+
+	ln := bufconn.Listen(1 << 20)
+	go func() { _ = gs.Serve(ln) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return acarspb.NewAcarsClient(conn)
+}
+
+func TestGRPCParseMessage(t *testing.T) {
+	client := dialGRPC(t, Config{})
+	ctx := context.Background()
+
+	resp, err := client.ParseMessage(ctx, &acarspb.ParseMessageRequest{
+		Message: &acarspb.Message{Id: 42, Label: "H1", Text: "HELLO"},
+	})
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if resp.GetMessageId() != 42 || len(resp.GetResults()) != 1 || resp.GetResults()[0].GetType() != "echo" {
+		t.Fatalf("ParseMessage() = %v", resp)
+	}
+	var data echoResult
+	if err := json.Unmarshal(resp.GetResults()[0].GetData(), &data); err != nil || data.Text != "HELLO" {
+		t.Errorf("result data = %s (%v)", resp.GetResults()[0].GetData(), err)
+	}
+
+	if _, err := client.ParseMessage(ctx, &acarspb.ParseMessageRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ParseMessage() without a message: %v", err)
+	}
+}
+
+func TestGRPCStreamParsedMessages(t *testing.T) {
+	client := dialGRPC(t, Config{})
+	stream, err := client.StreamParsedMessages(context.Background())
+	if err != nil {
+		t.Fatalf("StreamParsedMessages() error = %v", err)
+	}
+
+	msgs := []*acarspb.Message{
+		{Id: 1, Label: "H1", Text: "ONE"},
+		{Id: 2, Label: "Q0", Text: "UNPARSED"},
+		{Id: 3, Label: "H1", Text: "THREE"},
+	}
+	for _, m := range msgs {
+		if err := stream.Send(&acarspb.ParseMessageRequest{Message: m}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	_ = stream.CloseSend()
+
+	var got []string
+	for range msgs {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		got = append(got, strings.Repeat("*", len(resp.GetResults())))
+		if resp.GetMessageId() != int64(len(got)) {
+			t.Errorf("reply %d is for message %d", len(got), resp.GetMessageId())
+		}
+	}
+	if strings.Join(got, ",") != "*,,*" {
+		t.Errorf("results per message = %v", got)
+	}
+}
+
+func TestGRPCAuth(t *testing.T) {
+	client := dialGRPC(t, Config{AuthEnabled: true, APIKeys: []string{"secret"}})
+	req := &acarspb.ParseMessageRequest{Message: &acarspb.Message{Label: "H1", Text: "HELLO"}}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want codes.Code
+	}{
+		{"no key", nil, codes.Unauthenticated},
+		{"wrong key", metadata.Pairs("x-api-key", "guess"), codes.PermissionDenied},
+		{"x-api-key", metadata.Pairs("x-api-key", "secret"), codes.OK},
+		{"bearer", metadata.Pairs("authorization", "Bearer secret"), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+			if _, err := client.ParseMessage(ctx, req); status.Code(err) != tt.want {
+				t.Errorf("ParseMessage() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
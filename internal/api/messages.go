@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"acars_parser/internal/storage"
+	"acars_parser/internal/units"
+)
+
+// Limits on the message search endpoint.
+const (
+	defaultMessageWindow = 30 * 24 * time.Hour
+	defaultMessageLimit  = 100
+	maxMessageLimit      = 1000
+)
+
+// MessageResponse is the JSON representation of a stored message and its
+// parse result.
+type MessageResponse struct {
+	ID            uint64          `json:"id"`
+	Timestamp     string          `json:"timestamp"`
+	Label         string          `json:"label"`
+	ParserType    string          `json:"parser_type,omitempty"`
+	ParserName    string          `json:"parser_name,omitempty"`
+	ParserVersion uint32          `json:"parser_version,omitempty"`
+	Flight        string          `json:"flight,omitempty"`
+	Tail          string          `json:"tail,omitempty"`
+	Frequency     float64         `json:"frequency,omitempty"`
+	StationID     string          `json:"station_id,omitempty"`
+	Feeder        string          `json:"feeder,omitempty"`
+	Tenant        string          `json:"tenant,omitempty"`
+	Origin        string          `json:"origin,omitempty"`
+	Destination   string          `json:"destination,omitempty"`
+	RawText       string          `json:"raw_text"`
+	Result        json.RawMessage `json:"result,omitempty"`
+	Units         units.Values    `json:"units,omitempty"` // Altitudes, speeds and temperatures of Result in canonical units.
+	MissingFields []string        `json:"missing_fields,omitempty"`
+	Confidence    float32         `json:"confidence,omitempty"`
+}
+
+// MessagesResponse is the JSON response for a message search.
+type MessagesResponse struct {
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Offset   int               `json:"offset"`
+	Messages []MessageResponse `json:"messages"`
+}
+
+func messageToResponse(m storage.CHMessage) MessageResponse {
+	resp := MessageResponse{
+		ID:            m.ID,
+		Timestamp:     m.Timestamp.UTC().Format(time.RFC3339),
+		Label:         m.Label,
+		ParserType:    m.ParserType,
+		ParserName:    m.ParserName,
+		ParserVersion: m.ParserVersion,
+		Flight:        m.Flight,
+		Tail:          m.Tail,
+		Frequency:     m.Frequency,
+		StationID:     m.StationID,
+		Feeder:        m.Feeder,
+		Tenant:        m.Tenant,
+		Origin:        m.Origin,
+		Destination:   m.Destination,
+		RawText:       m.RawText,
+		Confidence:    m.Confidence,
+	}
+	if m.ParsedJSON != "" && m.ParsedJSON != "null" && json.Valid([]byte(m.ParsedJSON)) {
+		resp.Result = json.RawMessage(m.ParsedJSON)
+		var fields map[string]interface{}
+		if json.Unmarshal(resp.Result, &fields) == nil {
+			resp.Units = units.FromMap(fields)
+		}
+	}
+	if m.MissingFields != "" {
+		resp.MissingFields = strings.Split(m.MissingFields, ",")
+	}
+	return resp
+}
+
+// parseSearchTime reads a search bound as an RFC 3339 time or a date. A date
+// given as the end of the range includes the whole day.
+func parseSearchTime(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return t, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseMessageQuery reads the message search query parameters. The time range
+// defaults to the 30 days up to now; to is exclusive for a time and inclusive
+// for a date. The regex is checked here, as ClickHouse uses the same RE2
+// syntax, so that a bad pattern is a client error.
+func parseMessageQuery(q url.Values, now time.Time) (storage.CHQueryParams, error) {
+	p := storage.CHQueryParams{
+		Tail:       strings.ToUpper(q.Get("tail")),
+		Flight:     strings.ToUpper(q.Get("flight")),
+		Label:      strings.ToUpper(q.Get("label")),
+		ParserType: q.Get("parser_type"),
+		FullText:   q.Get("text"),
+		Pattern:    q.Get("regex"),
+		Limit:      defaultMessageLimit,
+		OrderBy:    "timestamp",
+		OrderDesc:  true,
+	}
+
+	var err error
+	p.To = now
+	if v := q.Get("to"); v != "" {
+		if p.To, err = parseSearchTime(v, true); err != nil {
+			return p, errors.New("invalid to (use RFC 3339 or YYYY-MM-DD)")
+		}
+	}
+	p.From = p.To.Add(-defaultMessageWindow)
+	if v := q.Get("from"); v != "" {
+		if p.From, err = parseSearchTime(v, false); err != nil {
+			return p, errors.New("invalid from (use RFC 3339 or YYYY-MM-DD)")
+		}
+	}
+	if !p.From.Before(p.To) {
+		return p, errors.New("from must be before to")
+	}
+
+	if p.Pattern != "" {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return p, errors.New("invalid regex: " + err.Error())
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if p.Limit, err = strconv.Atoi(v); err != nil || p.Limit < 1 {
+			return p, errors.New("limit must be a positive integer")
+		}
+		if p.Limit > maxMessageLimit {
+			p.Limit = maxMessageLimit
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if p.Offset, err = strconv.Atoi(v); err != nil || p.Offset < 0 {
+			return p, errors.New("offset must be a non-negative integer")
+		}
+	}
+	return p, nil
+}
+
+// messagesAvailable reports whether message search is configured, writing an
+// error response if not.
+func (s *EnrichmentServer) messagesAvailable(w http.ResponseWriter) bool {
+	if s.messages == nil {
+		writeError(w, http.StatusServiceUnavailable, "Message search is not enabled")
+		return false
+	}
+	return true
+}
+
+func (s *EnrichmentServer) handleSearchMessages(w http.ResponseWriter, r *http.Request) {
+	if !s.messagesAvailable(w) {
+		return
+	}
+	p, err := parseMessageQuery(r.URL.Query(), time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	p.Tenants = requestTenants(r.Context())
+
+	messages, err := s.messages.Query(r.Context(), p)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := MessagesResponse{
+		From:     p.From.UTC().Format(time.RFC3339),
+		To:       p.To.UTC().Format(time.RFC3339),
+		Offset:   p.Offset,
+		Messages: make([]MessageResponse, 0, len(messages)),
+	}
+	for _, m := range messages {
+		resp.Messages = append(resp.Messages, messageToResponse(m))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *EnrichmentServer) handleGetMessage(w http.ResponseWriter, r *http.Request) {
+	if !s.messagesAvailable(w) {
+		return
+	}
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	m, err := s.messages.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// A scoped key is not told that another tenant's message exists.
+	if tenants := requestTenants(r.Context()); m == nil || tenants != nil && !slices.Contains(tenants, m.Tenant) {
+		writeError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, messageToResponse(*m))
+}
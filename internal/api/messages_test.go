@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestParseMessageQuery(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	p, err := parseMessageQuery(url.Values{}, now)
+	if err != nil || !p.To.Equal(now) || !p.From.Equal(now.Add(-30*24*time.Hour)) || p.Limit != defaultMessageLimit || !p.OrderDesc {
+		t.Errorf("defaults = %+v, %v", p, err)
+	}
+
+	q := url.Values{
+		"tail": {"vh-xzb"}, "parser_type": {"pdc"}, "from": {"2026-09-01"}, "to": {"2026-09-30"},
+		"regex": {`RWY \d+`}, "limit": {"5000"}, "offset": {"200"},
+	}
+	p, err = parseMessageQuery(q, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Tail != "VH-XZB" || p.ParserType != "pdc" || p.Pattern != `RWY \d+` || p.Limit != maxMessageLimit || p.Offset != 200 {
+		t.Errorf("parsed = %+v", p)
+	}
+	if !p.From.Equal(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)) || !p.To.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("range = %v to %v, want September inclusive", p.From, p.To)
+	}
+
+	for _, bad := range []url.Values{
+		{"from": {"last month"}},
+		{"from": {"2026-10-02"}, "to": {"2026-10-01"}},
+		{"regex": {"("}},
+		{"limit": {"0"}},
+		{"offset": {"-1"}},
+	} {
+		if _, err := parseMessageQuery(bad, now); err == nil {
+			t.Errorf("parseMessageQuery(%v) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestMessageToResponse(t *testing.T) {
+	resp := messageToResponse(storage.CHMessage{
+		ID:            7,
+		Timestamp:     time.Date(2026, 9, 3, 4, 5, 6, 0, time.UTC),
+		Label:         "H1",
+		ParserType:    "pdc",
+		RawText:       "PDC QFA9",
+		ParsedJSON:    `{"flight":"QFA9","flight_level":"350"}`,
+		MissingFields: "runway,sid",
+	})
+	if resp.Timestamp != "2026-09-03T04:05:06Z" || string(resp.Result) != `{"flight":"QFA9","flight_level":"350"}` || len(resp.MissingFields) != 2 {
+		t.Errorf("response = %+v", resp)
+	}
+	if a, ok := resp.Units.Altitude(); !ok || a != 35000 {
+		t.Errorf("units = %+v, want an altitude of 35000 ft", resp.Units)
+	}
+	if resp := messageToResponse(storage.CHMessage{ParsedJSON: "null"}); resp.Result != nil {
+		t.Errorf("result of null = %s, want none", resp.Result)
+	}
+}
+
+func TestMessagesDisabled(t *testing.T) {
+	s := NewEnrichmentServer(nil, Config{})
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/messages?tail=VH-XZB", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 without a message store", rec.Code)
+	}
+}
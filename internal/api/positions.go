@@ -0,0 +1,385 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+)
+
+// Limits on the position search endpoints.
+const (
+	defaultPositionWindow = time.Hour
+	defaultPositionLimit  = 500
+	maxPositionLimit      = 5000
+	defaultNearRadiusNM   = 100
+	maxNearRadiusNM       = 1000
+)
+
+// PositionResponse is the JSON representation of a flight's latest position
+// within a search area.
+type PositionResponse struct {
+	ICAOHex      string   `json:"icao_hex,omitempty"`
+	Registration string   `json:"registration,omitempty"`
+	Callsign     string   `json:"callsign,omitempty"`
+	Origin       string   `json:"origin,omitempty"`
+	Destination  string   `json:"destination,omitempty"`
+	Timestamp    string   `json:"timestamp"`
+	Latitude     float64  `json:"latitude"`
+	Longitude    float64  `json:"longitude"`
+	Altitude     *int     `json:"altitude,omitempty"`
+	Source       string   `json:"source,omitempty"`
+	DistanceNM   *float64 `json:"distance_nm,omitempty"` // From the search point, for nearest searches.
+}
+
+// PositionsResponse is the JSON response for a position search.
+type PositionsResponse struct {
+	Since     string             `json:"since"`
+	Positions []PositionResponse `json:"positions"`
+}
+
+func positionToResponse(p storage.RecentPosition) PositionResponse {
+	return PositionResponse{
+		ICAOHex:      p.ICAOHex,
+		Registration: p.Registration,
+		Callsign:     p.Callsign,
+		Origin:       p.Origin,
+		Destination:  p.Destination,
+		Timestamp:    p.Timestamp.UTC().Format(time.RFC3339),
+		Latitude:     p.Latitude,
+		Longitude:    p.Longitude,
+		Altitude:     p.Altitude,
+		Source:       p.Source,
+	}
+}
+
+// parseFinite parses a number, rejecting NaN and infinities. ParseFloat
+// accepts them, and NaN compares false with every bound of a range check.
+func parseFinite(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errors.New("not a finite number")
+	}
+	return f, nil
+}
+
+// parseBBox reads a bounding box as west,south,east,north in degrees, the
+// order GeoJSON uses. West may exceed east for a box across the antimeridian.
+func parseBBox(v string) (storage.BBox, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) != 4 {
+		return storage.BBox{}, errors.New("bbox must be west,south,east,north")
+	}
+	var n [4]float64
+	for i, p := range parts {
+		f, err := parseFinite(strings.TrimSpace(p))
+		if err != nil {
+			return storage.BBox{}, errors.New("bbox must be west,south,east,north")
+		}
+		n[i] = f
+	}
+	box := storage.BBox{West: n[0], South: n[1], East: n[2], North: n[3]}
+	if box.South < -90 || box.North > 90 || box.South > box.North {
+		return box, errors.New("bbox latitudes must be within -90 to 90, south first")
+	}
+	if box.West < -180 || box.West > 180 || box.East < -180 || box.East > 180 {
+		return box, errors.New("bbox longitudes must be within -180 to 180")
+	}
+	return box, nil
+}
+
+// parsePositionQuery reads the since and limit query parameters. Since is an
+// RFC 3339 time or a date, and defaults to an hour before now.
+func parsePositionQuery(q url.Values, now time.Time) (since time.Time, limit int, err error) {
+	since = now.Add(-defaultPositionWindow)
+	if v := q.Get("since"); v != "" {
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			if since, err = time.Parse("2006-01-02", v); err != nil {
+				return since, 0, errors.New("invalid since (use RFC 3339 or YYYY-MM-DD)")
+			}
+		}
+	}
+
+	limit = defaultPositionLimit
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return since, 0, errors.New("limit must be a positive integer")
+		}
+		if limit > maxPositionLimit {
+			limit = maxPositionLimit
+		}
+	}
+	return since, limit, nil
+}
+
+// nearQuery is a search for the flights within a radius of a point.
+type nearQuery struct {
+	Lat, Lon float64
+	RadiusNM float64
+}
+
+// parseNearQuery reads the lat, lon and radius (nautical miles) parameters.
+func parseNearQuery(q url.Values) (nearQuery, error) {
+	var n nearQuery
+	var err error
+	if n.Lat, err = parseFinite(q.Get("lat")); err != nil || n.Lat < -90 || n.Lat > 90 {
+		return n, errors.New("lat must be a latitude in degrees")
+	}
+	if n.Lon, err = parseFinite(q.Get("lon")); err != nil || n.Lon < -180 || n.Lon > 180 {
+		return n, errors.New("lon must be a longitude in degrees")
+	}
+	n.RadiusNM = defaultNearRadiusNM
+	if v := q.Get("radius"); v != "" {
+		if n.RadiusNM, err = parseFinite(v); err != nil || n.RadiusNM <= 0 {
+			return n, errors.New("radius must be a positive number of nautical miles")
+		}
+		if n.RadiusNM > maxNearRadiusNM {
+			n.RadiusNM = maxNearRadiusNM
+		}
+	}
+	return n, nil
+}
+
+// bbox returns a box that contains the search circle, for the index to narrow
+// the search before distances are computed. Near a pole it spans every
+// longitude.
+func (n nearQuery) bbox() storage.BBox {
+	dLat := n.RadiusNM / 60
+	box := storage.BBox{South: math.Max(-90, n.Lat-dLat), North: math.Min(90, n.Lat+dLat), West: -180, East: 180}
+	if box.South == -90 || box.North == 90 {
+		return box
+	}
+	dLon := dLat / math.Cos(math.Max(math.Abs(box.South), math.Abs(box.North))*math.Pi/180)
+	if dLon >= 180 {
+		return box
+	}
+	box.West, box.East = n.Lon-dLon, n.Lon+dLon
+	if box.West < -180 {
+		box.West += 360
+	}
+	if box.East > 180 {
+		box.East -= 360
+	}
+	return box
+}
+
+// nearest returns the positions within the search radius, nearest first,
+// with their distances set.
+func (n nearQuery) nearest(positions []storage.RecentPosition, limit int) []PositionResponse {
+	out := make([]PositionResponse, 0, len(positions))
+	for _, p := range positions {
+		d := state.GreatCircleNM(n.Lat, n.Lon, p.Latitude, p.Longitude)
+		if d > n.RadiusNM {
+			continue
+		}
+		resp := positionToResponse(p)
+		d = math.Round(d*10) / 10
+		resp.DistanceNM = &d
+		out = append(out, resp)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return *out[i].DistanceNM < *out[j].DistanceNM })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (s *EnrichmentServer) handleGetPositions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	box, err := parseBBox(q.Get("bbox"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	since, limit, err := parsePositionQuery(q, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	positions, err := s.pg.GetRecentPositions(r.Context(), box, since, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := PositionsResponse{
+		Since:     since.UTC().Format(time.RFC3339),
+		Positions: make([]PositionResponse, 0, len(positions)),
+	}
+	for _, p := range positions {
+		resp.Positions = append(resp.Positions, positionToResponse(p))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *EnrichmentServer) handleGetPositionsNear(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	near, err := parseNearQuery(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	since, limit, err := parsePositionQuery(q, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The box holds more flights than the circle, so fetch the most the
+	// endpoint allows and keep the nearest.
+	positions, err := s.pg.GetRecentPositions(r.Context(), near.bbox(), since, maxPositionLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PositionsResponse{
+		Since:     since.UTC().Format(time.RFC3339),
+		Positions: near.nearest(positions, limit),
+	})
+}
+
+// Limits on the age of the position an estimate is extrapolated from.
+const (
+	defaultEstimateMaxAge = 6 * time.Hour
+	maxEstimateMaxAge     = 24 * time.Hour
+)
+
+// PositionEstimateResponse is the JSON response for an aircraft's estimated
+// current position: its latest ACARS position, dead-reckoned to the time of
+// the estimate.
+type PositionEstimateResponse struct {
+	ICAOHex        string           `json:"icao_hex"`
+	Callsign       string           `json:"callsign,omitempty"`
+	FlightDate     string           `json:"flight_date"`
+	Registration   string           `json:"registration,omitempty"`
+	Origin         string           `json:"origin,omitempty"`
+	Destination    string           `json:"destination,omitempty"`
+	Timestamp      string           `json:"timestamp"`
+	Latitude       float64          `json:"latitude"`
+	Longitude      float64          `json:"longitude"`
+	Altitude       *int             `json:"altitude,omitempty"`
+	Extrapolated   bool             `json:"extrapolated"`
+	AgeSeconds     int              `json:"age_seconds"` // From the last position to the estimate.
+	UncertaintyNM  float64          `json:"uncertainty_nm"`
+	GroundSpeed    int              `json:"ground_speed,omitempty"` // Knots.
+	Track          *int             `json:"track,omitempty"`        // Degrees true.
+	VelocitySource string           `json:"velocity_source"`        // "reported", "derived" or "none".
+	LastPosition   PositionResponse `json:"last_position"`
+}
+
+// parseEstimateQuery reads the at (RFC 3339, default now) and max_age
+// (duration, default 6h) query parameters.
+func parseEstimateQuery(q url.Values, now time.Time) (at time.Time, maxAge time.Duration, err error) {
+	at = now
+	if v := q.Get("at"); v != "" {
+		if at, err = time.Parse(time.RFC3339, v); err != nil {
+			return at, 0, errors.New("invalid at (use RFC 3339)")
+		}
+	}
+	maxAge = defaultEstimateMaxAge
+	if v := q.Get("max_age"); v != "" {
+		if maxAge, err = time.ParseDuration(v); err != nil || maxAge <= 0 || maxAge > maxEstimateMaxAge {
+			return at, 0, errors.New("max_age must be a duration up to 24h, e.g. 2h")
+		}
+	}
+	return at, maxAge, nil
+}
+
+// estimateToResponse converts an estimate of a flight's position.
+func estimateToResponse(f storage.AircraftFlight, est state.PositionEstimate) PositionEstimateResponse {
+	last := est.From
+	resp := PositionEstimateResponse{
+		ICAOHex:        f.ICAOHex,
+		Callsign:       f.Callsign,
+		FlightDate:     f.FlightDate.Format("2006-01-02"),
+		Registration:   f.Registration,
+		Origin:         f.Origin,
+		Destination:    f.Destination,
+		Timestamp:      est.Time.UTC().Format(time.RFC3339),
+		Latitude:       est.Latitude,
+		Longitude:      est.Longitude,
+		Extrapolated:   est.VelocitySource != state.VelocityNone && est.Age > 0,
+		AgeSeconds:     int(est.Age.Seconds()),
+		UncertaintyNM:  math.Round(est.UncertaintyNM*10) / 10,
+		GroundSpeed:    est.GroundSpeed,
+		VelocitySource: est.VelocitySource,
+		LastPosition: PositionResponse{
+			Timestamp: last.Time.UTC().Format(time.RFC3339),
+			Latitude:  last.Latitude,
+			Longitude: last.Longitude,
+			Source:    last.Source,
+		},
+	}
+	if est.Altitude != 0 {
+		resp.Altitude = &est.Altitude
+		resp.LastPosition.Altitude = &est.Altitude
+	}
+	if est.VelocitySource != state.VelocityNone {
+		resp.Track = &est.Track
+	}
+	return resp
+}
+
+func (s *EnrichmentServer) handleGetPositionEstimate(w http.ResponseWriter, r *http.Request) {
+	icaoHex := strings.ToUpper(chi.URLParam(r, "icao_hex"))
+	at, maxAge, err := parseEstimateQuery(r.URL.Query(), time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The aircraft's latest tracked flight up to the time of the estimate.
+	ctx := r.Context()
+	day := at.UTC().Truncate(24 * time.Hour)
+	flights, err := s.pg.ListAircraftFlights(ctx, icaoHex, day.AddDate(0, 0, -1), day, maxFlightLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var flight *storage.AircraftFlight
+	for i := range flights {
+		f := &flights[i]
+		if f.Key != "" && !f.FirstSeen.After(at) && (flight == nil || f.LastSeen.After(flight.LastSeen)) {
+			flight = f
+		}
+	}
+	if flight == nil {
+		writeError(w, http.StatusNotFound, "No tracked flight found")
+		return
+	}
+
+	positions, err := s.pg.GetFlightPositions(ctx, flight.Key, flight.FirstSeen, at)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	track := state.TrackFromPositions(positions)
+	if len(track) == 0 || at.Sub(track[len(track)-1].Time) > maxAge {
+		writeError(w, http.StatusNotFound, "No position within max_age")
+		return
+	}
+	if flight.Completion == "arrived" {
+		// An arrived flight stays at its last position.
+		last := track[len(track)-1]
+		last.GroundSpeed = 0
+		track, at = []state.TrackPoint{last}, last.Time
+	}
+
+	est, _ := state.Extrapolate(track, at)
+	flight.ICAOHex = icaoHex
+	writeJSON(w, http.StatusOK, estimateToResponse(*flight, est))
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+)
+
+func TestParseBBox(t *testing.T) {
+	box, err := parseBBox("150.5,-34.5,151.5,-33.5")
+	if err != nil || box != (storage.BBox{West: 150.5, South: -34.5, East: 151.5, North: -33.5}) {
+		t.Errorf("parseBBox = %+v, %v", box, err)
+	}
+	if box, err := parseBBox("170,-50,-170,-30"); err != nil || box.West != 170 || box.East != -170 {
+		t.Errorf("antimeridian box = %+v, %v", box, err)
+	}
+	for _, bad := range []string{"", "1,2,3", "a,b,c,d", "150,-30,151,-40", "150,-95,151,-30", "190,0,200,10", "NaN,NaN,NaN,NaN", "150,-34,Inf,-33"} {
+		if _, err := parseBBox(bad); err == nil {
+			t.Errorf("parseBBox(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestParsePositionQuery(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	since, limit, err := parsePositionQuery(url.Values{}, now)
+	if err != nil || !since.Equal(now.Add(-time.Hour)) || limit != defaultPositionLimit {
+		t.Errorf("defaults = %v, %d, %v", since, limit, err)
+	}
+	since, limit, err = parsePositionQuery(url.Values{"since": {"2026-10-17T06:00:00Z"}, "limit": {"99999"}}, now)
+	if err != nil || since.Hour() != 6 || limit != maxPositionLimit {
+		t.Errorf("parsed = %v, %d, %v", since, limit, err)
+	}
+	if _, _, err := parsePositionQuery(url.Values{"limit": {"-1"}}, now); err == nil {
+		t.Error("negative limit accepted")
+	}
+}
+
+func TestNearQuery(t *testing.T) {
+	n, err := parseNearQuery(url.Values{"lat": {"-33.95"}, "lon": {"151.18"}, "radius": {"60"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	box := n.bbox()
+	if box.South > -34.95 || box.North < -32.95 || box.West > 150 || box.East < 152.3 {
+		t.Errorf("bbox = %+v, want one containing 60 NM around Sydney", box)
+	}
+
+	pos := func(lat, lon float64, callsign string) storage.RecentPosition {
+		return storage.RecentPosition{FlightPosition: storage.FlightPosition{Latitude: lat, Longitude: lon}, Callsign: callsign}
+	}
+	got := n.nearest([]storage.RecentPosition{
+		pos(-33.0, 151.18, "QFA2"),  // 57 NM north.
+		pos(-33.95, 151.3, "QFA1"),  // 6 NM east.
+		pos(-35.3, 149.19, "VOZ10"), // Canberra, outside the radius.
+	}, 10)
+	if len(got) != 2 || got[0].Callsign != "QFA1" || got[1].Callsign != "QFA2" || *got[1].DistanceNM < 56 {
+		t.Errorf("nearest = %+v", got)
+	}
+
+	// A circle across the antimeridian wraps its box.
+	n = nearQuery{Lat: -17.75, Lon: 179.5, RadiusNM: 120}
+	if box := n.bbox(); box.West < box.East || box.East > -178 {
+		t.Errorf("antimeridian bbox = %+v", box)
+	}
+	// A circle over a pole spans every longitude.
+	n = nearQuery{Lat: 89, Lon: 0, RadiusNM: 120}
+	if box := n.bbox(); box.West != -180 || box.East != 180 || box.North != 90 {
+		t.Errorf("polar bbox = %+v", box)
+	}
+
+	for _, bad := range []url.Values{
+		{}, {"lat": {"91"}, "lon": {"0"}}, {"lat": {"0"}, "lon": {"0"}, "radius": {"0"}},
+		{"lat": {"NaN"}, "lon": {"0"}}, {"lat": {"0"}, "lon": {"nan"}}, {"lat": {"0"}, "lon": {"0"}, "radius": {"+Inf"}},
+	} {
+		if _, err := parseNearQuery(bad); err == nil {
+			t.Errorf("parseNearQuery(%v) succeeded, want error", bad)
+		}
+	}
+}
+
+// TestPositionsRejectNonFinite checks that the handlers answer 400 for NaN
+// and infinite coordinates before the database is queried.
+func TestPositionsRejectNonFinite(t *testing.T) {
+	server := NewEnrichmentServer(nil, Config{Port: 8081})
+	tests := []struct {
+		handler http.HandlerFunc
+		target  string
+	}{
+		{server.handleGetPositions, "/positions?bbox=NaN,NaN,NaN,NaN"},
+		{server.handleGetPositions, "/positions?bbox=150,-34,151,Inf"},
+		{server.handleGetPositionsNear, "/positions/near?lat=NaN&lon=151"},
+		{server.handleGetPositionsNear, "/positions/near?lat=-33.9&lon=-Inf"},
+		{server.handleGetPositionsNear, "/positions/near?lat=-33.9&lon=151&radius=Inf"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tt.target, rec.Code)
+		}
+	}
+}
+
+func TestParseEstimateQuery(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	at, maxAge, err := parseEstimateQuery(url.Values{}, now)
+	if err != nil || !at.Equal(now) || maxAge != defaultEstimateMaxAge {
+		t.Errorf("defaults = %v, %v, %v", at, maxAge, err)
+	}
+	at, maxAge, err = parseEstimateQuery(url.Values{"at": {"2026-10-17T06:00:00Z"}, "max_age": {"2h"}}, now)
+	if err != nil || at.Hour() != 6 || maxAge != 2*time.Hour {
+		t.Errorf("parsed = %v, %v, %v", at, maxAge, err)
+	}
+	for _, bad := range []url.Values{{"at": {"yesterday"}}, {"max_age": {"48h"}}, {"max_age": {"-1h"}}} {
+		if _, _, err := parseEstimateQuery(bad, now); err == nil {
+			t.Errorf("parseEstimateQuery(%v) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestEstimateToResponse(t *testing.T) {
+	t0 := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	est, _ := state.Extrapolate([]state.TrackPoint{{Time: t0, Latitude: -20, Longitude: 170, GroundSpeed: 450, Track: 45, Source: "adsc"}}, t0.Add(20*time.Minute))
+	resp := estimateToResponse(storage.AircraftFlight{ICAOHex: "7C6DB8", Callsign: "QFA1", FlightDate: t0}, est)
+	if !resp.Extrapolated || resp.AgeSeconds != 1200 || resp.VelocitySource != "reported" || resp.Track == nil || *resp.Track != 45 {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Latitude <= -20 || resp.Longitude <= 170 || resp.LastPosition.Source != "adsc" || resp.LastPosition.Timestamp != "2026-10-17T10:00:00Z" {
+		t.Errorf("position = %+v", resp)
+	}
+	if resp.UncertaintyNM != 8.5 || resp.FlightDate != "2026-10-17" {
+		t.Errorf("uncertainty/date = %v, %s", resp.UncertaintyNM, resp.FlightDate)
+	}
+}
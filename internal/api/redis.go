@@ -0,0 +1,272 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"time"
+)
+
+// redisKeyPrefix prefixes the Redis hashes holding each aircraft's lookups.
+const redisKeyPrefix = "acars:enrichment:"
+
+// redisPoolSize is the number of idle Redis connections kept for reuse.
+const redisPoolSize = 8
+
+// redisTimeout bounds each Redis round trip, so that a slow Redis degrades
+// to cache misses rather than slow responses.
+const redisTimeout = 500 * time.Millisecond
+
+// redisCache is a Cache in Redis, shared by every API instance. Each aircraft
+// is a hash of its lookups, so invalidating an aircraft is one DEL. Entries
+// carry their own expiry, as Redis expires whole hashes only.
+type redisCache struct {
+	addr     string
+	password string
+	ttl      time.Duration
+	idle     chan *redisConn
+}
+
+// NewRedisCache returns a cache in the Redis server at addr whose entries
+// live for ttl. Connections are made on first use.
+func NewRedisCache(addr, password string, ttl time.Duration) Cache {
+	return &redisCache{
+		addr:     addr,
+		password: password,
+		ttl:      ttl,
+		idle:     make(chan *redisConn, redisPoolSize),
+	}
+}
+
+func (c *redisCache) Get(ctx context.Context, icaoHex, key string) ([]byte, bool) {
+	reply, err := c.do(ctx, []string{"HGET", redisKeyPrefix + icaoHex, key})
+	if err != nil {
+		log.Printf("redis cache: %v", err)
+		return nil, false
+	}
+	v, ok := reply[0].([]byte)
+	if !ok {
+		return nil, false
+	}
+	expiry, value, found := bytes.Cut(v, []byte(":"))
+	if !found {
+		return nil, false
+	}
+	ms, err := strconv.ParseInt(string(expiry), 10, 64)
+	if err != nil || time.Now().UnixMilli() >= ms {
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *redisCache) Set(ctx context.Context, icaoHex, key string, value []byte) {
+	expiry := strconv.FormatInt(time.Now().Add(c.ttl).UnixMilli(), 10)
+	hash := redisKeyPrefix + icaoHex
+	_, err := c.do(ctx,
+		[]string{"HSET", hash, key, expiry + ":" + string(value)},
+		[]string{"PEXPIRE", hash, strconv.FormatInt(c.ttl.Milliseconds(), 10)},
+	)
+	if err != nil {
+		log.Printf("redis cache: %v", err)
+	}
+}
+
+func (c *redisCache) Invalidate(ctx context.Context, icaoHex string) {
+	var err error
+	if icaoHex != "" {
+		_, err = c.do(ctx, []string{"DEL", redisKeyPrefix + icaoHex})
+	} else {
+		err = c.deleteAll(ctx)
+	}
+	if err != nil {
+		log.Printf("redis cache: %v", err)
+	}
+}
+
+// deleteAll deletes every aircraft's hash.
+func (c *redisCache) deleteAll(ctx context.Context) error {
+	cursor := "0"
+	for {
+		reply, err := c.do(ctx, []string{"SCAN", cursor, "MATCH", redisKeyPrefix + "*", "COUNT", "1000"})
+		if err != nil {
+			return err
+		}
+		page, ok := reply[0].([]interface{})
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("unexpected SCAN reply %v", reply[0])
+		}
+		next, _ := page[0].([]byte)
+		keys, _ := page[1].([]interface{})
+		if len(keys) > 0 {
+			del := []string{"DEL"}
+			for _, k := range keys {
+				if b, ok := k.([]byte); ok {
+					del = append(del, string(b))
+				}
+			}
+			if _, err := c.do(ctx, del); err != nil {
+				return err
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// do sends the commands in one round trip and returns their replies. A Redis
+// error reply to any command is returned as an error.
+func (c *redisCache) do(ctx context.Context, cmds ...[]string) ([]interface{}, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := conn.pipeline(cmds)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		_ = conn.Close()
+		return nil, err
+	}
+	c.put(conn)
+	return replies, err
+}
+
+// get returns an idle connection, or dials a new one.
+func (c *redisCache) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: redisTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.password != "" {
+		if _, err := conn.pipeline([][]string{{"AUTH", c.password}}); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// put returns a connection to the idle pool, closing it if the pool is full.
+func (c *redisCache) put(conn *redisConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+}
+
+// redisError is an error reply from Redis. The connection remains usable.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is a connection speaking the Redis protocol (RESP).
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// pipeline writes the commands and reads a reply to each.
+func (c *redisConn) pipeline(cmds [][]string) ([]interface{}, error) {
+	_ = c.SetDeadline(time.Now().Add(redisTimeout))
+	var buf bytes.Buffer
+	for _, cmd := range cmds {
+		writeRedisCommand(&buf, cmd)
+	}
+	if _, err := c.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(cmds))
+	var replyErr error
+	for i := range cmds {
+		reply, err := readRedisReply(c.r)
+		var re redisError
+		switch {
+		case errors.As(err, &re):
+			if replyErr == nil {
+				replyErr = err
+			}
+		case err != nil:
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, replyErr
+}
+
+// writeRedisCommand encodes a command as a RESP array of bulk strings.
+func writeRedisCommand(w *bytes.Buffer, args []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(a), a)
+	}
+}
+
+// readRedisReply decodes one RESP reply: a string, integer (int64), bulk
+// string ([]byte), array ([]interface{}) or nil. Error replies are returned
+// as a redisError.
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", kind)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedisProtocol(t *testing.T) {
+	var buf bytes.Buffer
+	writeRedisCommand(&buf, []string{"HGET", "acars:enrichment:7C6CA3", "QFA9/2026-01-30"})
+	want := "*3\r\n$4\r\nHGET\r\n$23\r\nacars:enrichment:7C6CA3\r\n$15\r\nQFA9/2026-01-30\r\n"
+	if buf.String() != want {
+		t.Errorf("writeRedisCommand() = %q, want %q", buf.String(), want)
+	}
+
+	tests := []struct {
+		in   string
+		want interface{}
+	}{
+		{"+OK\r\n", "OK"},
+		{":3\r\n", int64(3)},
+		{"$5\r\nhello\r\n", []byte("hello")},
+		{"$-1\r\n", nil},
+		{"*2\r\n$1\r\n0\r\n*1\r\n$3\r\nkey\r\n", []interface{}{[]byte("0"), []interface{}{[]byte("key")}}},
+	}
+	for _, tt := range tests {
+		got, err := readRedisReply(bufio.NewReader(strings.NewReader(tt.in)))
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("readRedisReply(%q) = %#v, %v; want %#v", tt.in, got, err, tt.want)
+		}
+	}
+
+	_, err := readRedisReply(bufio.NewReader(strings.NewReader("-WRONGTYPE bad\r\n")))
+	if _, ok := err.(redisError); !ok {
+		t.Errorf("error reply gave %v, want redisError", err)
+	}
+}
+
+// fakeRedis serves the hash commands the cache uses from memory.
+func fakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	hashes := make(map[string]map[string]string)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			for {
+				reply, err := readRedisReply(r)
+				if err != nil {
+					_ = conn.Close()
+					break
+				}
+				var args []string
+				for _, a := range reply.([]interface{}) {
+					args = append(args, string(a.([]byte)))
+				}
+				switch args[0] {
+				case "HGET":
+					if v, ok := hashes[args[1]][args[2]]; ok {
+						_, _ = conn.Write([]byte("$" + itoa(len(v)) + "\r\n" + v + "\r\n"))
+					} else {
+						_, _ = conn.Write([]byte("$-1\r\n"))
+					}
+				case "HSET":
+					if hashes[args[1]] == nil {
+						hashes[args[1]] = make(map[string]string)
+					}
+					hashes[args[1]][args[2]] = args[3]
+					_, _ = conn.Write([]byte(":1\r\n"))
+				case "DEL":
+					delete(hashes, args[1])
+					_, _ = conn.Write([]byte(":1\r\n"))
+				default:
+					_, _ = conn.Write([]byte(":1\r\n"))
+				}
+			}
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRedisCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewRedisCache(fakeRedis(t), "", time.Minute)
+
+	c.Set(ctx, "7C6CA3", "QFA9/2026-01-30", []byte(`[{"callsign":"QFA9"}]`))
+	if v, ok := c.Get(ctx, "7C6CA3", "QFA9/2026-01-30"); !ok || string(v) != `[{"callsign":"QFA9"}]` {
+		t.Errorf("Get() = %q, %v", v, ok)
+	}
+	c.Invalidate(ctx, "7C6CA3")
+	if _, ok := c.Get(ctx, "7C6CA3", "QFA9/2026-01-30"); ok {
+		t.Error("Get() hit after Invalidate")
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"acars_parser/internal/state"
+)
+
+// Limits on the runway configuration window.
+const (
+	defaultRunwayWindow = time.Hour
+	maxRunwayWindow     = 24 * time.Hour
+)
+
+// airportICAORe matches an ICAO airport code.
+var airportICAORe = regexp.MustCompile(`^[A-Z]{4}$`)
+
+// RunwayUsageResponse is the number of arrivals planned to one runway.
+type RunwayUsageResponse struct {
+	Runway   string  `json:"runway"`
+	Flights  int     `json:"flights"`
+	Share    float64 `json:"share"`
+	LastSeen string  `json:"last_seen"`
+}
+
+// ATISRunwaysResponse is the runways and approaches in the airport's current
+// ATIS.
+type ATISRunwaysResponse struct {
+	Letter     string   `json:"letter,omitempty"`
+	Runways    []string `json:"runways"`
+	Approaches []string `json:"approaches,omitempty"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+// RunwayConfigResponse is the JSON response for an airport's runway
+// configuration: the arrival runways inferred from flight plans over the
+// window, and those its ATIS gives.
+type RunwayConfigResponse struct {
+	AirportICAO string                `json:"airport_icao"`
+	From        string                `json:"from"`
+	To          string                `json:"to"`
+	Flights     int                   `json:"flights"`
+	Runways     []string              `json:"runways"`
+	Confidence  float64               `json:"confidence"`
+	Usage       []RunwayUsageResponse `json:"usage"`
+	ATIS        *ATISRunwaysResponse  `json:"atis,omitempty"`
+}
+
+// parseRunwayWindow reads the window query parameter: a Go duration up to a
+// day, by default an hour.
+func parseRunwayWindow(q url.Values) (time.Duration, error) {
+	v := q.Get("window")
+	if v == "" {
+		return defaultRunwayWindow, nil
+	}
+	window, err := time.ParseDuration(v)
+	if err != nil || window <= 0 || window > maxRunwayWindow {
+		return 0, errors.New("window must be a duration up to 24h, e.g. 1h or 30m")
+	}
+	return window, nil
+}
+
+func (s *EnrichmentServer) handleGetRunwayConfig(w http.ResponseWriter, r *http.Request) {
+	icao := strings.ToUpper(chi.URLParam(r, "icao"))
+	if !airportICAORe.MatchString(icao) {
+		writeError(w, http.StatusBadRequest, "invalid ICAO airport code")
+		return
+	}
+	window, err := parseRunwayWindow(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	counts, err := s.pg.CountArrivalRunways(r.Context(), icao, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	atis, err := s.pg.GetATISCurrent(r.Context(), icao)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	cfg := state.InferRunwayConfig(counts)
+	resp := RunwayConfigResponse{
+		AirportICAO: icao,
+		From:        from.Format(time.RFC3339),
+		To:          to.Format(time.RFC3339),
+		Flights:     cfg.Flights,
+		Runways:     cfg.Runways,
+		Confidence:  cfg.Confidence,
+		Usage:       make([]RunwayUsageResponse, 0, len(counts)),
+	}
+	if resp.Runways == nil {
+		resp.Runways = []string{}
+	}
+	for _, c := range counts {
+		resp.Usage = append(resp.Usage, RunwayUsageResponse{
+			Runway:   c.Runway,
+			Flights:  c.Flights,
+			Share:    float64(c.Flights) / float64(cfg.Flights),
+			LastSeen: c.LastSeen.UTC().Format(time.RFC3339),
+		})
+	}
+	if atis != nil && len(atis.Runways) > 0 {
+		resp.ATIS = &ATISRunwaysResponse{
+			Letter:     atis.Letter,
+			Runways:    atis.Runways,
+			Approaches: atis.Approaches,
+			UpdatedAt:  atis.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseRunwayWindow(t *testing.T) {
+	if w, err := parseRunwayWindow(url.Values{}); err != nil || w != time.Hour {
+		t.Errorf("default = %v, %v", w, err)
+	}
+	if w, err := parseRunwayWindow(url.Values{"window": {"30m"}}); err != nil || w != 30*time.Minute {
+		t.Errorf("30m = %v, %v", w, err)
+	}
+	for _, bad := range []string{"soon", "0s", "-1h", "25h"} {
+		if _, err := parseRunwayWindow(url.Values{"window": {bad}}); err == nil {
+			t.Errorf("window %q accepted", bad)
+		}
+	}
+}
+
+func TestRunwayConfigBadAirport(t *testing.T) {
+	router := NewEnrichmentServer(nil, Config{}).Router()
+	for _, path := range []string{"/airports/YSS/runway-config", "/airports/YSSY1/runway-config", "/airports/YSSY/runway-config?window=2d"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"acars_parser/internal/schema"
+)
+
+// SchemaResponse is a result type and the version of its JSON Schema.
+type SchemaResponse struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+}
+
+// SchemasResponse is the JSON response listing the result schemas.
+type SchemasResponse struct {
+	Schemas []SchemaResponse `json:"schemas"`
+}
+
+func (s *EnrichmentServer) handleListSchemas(w http.ResponseWriter, r *http.Request) {
+	entries := schema.Entries()
+	resp := SchemasResponse{Schemas: make([]SchemaResponse, 0, len(entries))}
+	for _, e := range entries {
+		resp.Schemas = append(resp.Schemas, SchemaResponse{Type: e.Type, Version: e.Version, ID: e.ID()})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *EnrichmentServer) handleGetSchema(w http.ResponseWriter, r *http.Request) {
+	e, ok := schema.Lookup(chi.URLParam(r, "type"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "No schema for that result type")
+		return
+	}
+	body, err := e.JSON()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
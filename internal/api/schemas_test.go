@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSchemaEndpoints(t *testing.T) {
+	router := NewEnrichmentServer(nil, Config{Port: 8081}).Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schemas", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list: status %d", rec.Code)
+	}
+	var list SchemasResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, s := range list.Schemas {
+		if s.Type == "adsc" {
+			found = s.Version > 0 && s.ID != ""
+		}
+	}
+	if !found {
+		t.Errorf("adsc missing from %+v", list.Schemas)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schemas/adsc", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/schema+json" {
+		t.Fatalf("get: status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var doc map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["title"] != "adsc" || doc["type"] != "object" {
+		t.Errorf("unexpected schema: title %v, type %v", doc["title"], doc["type"])
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schemas/nonsense", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown type: status %d, want 404", rec.Code)
+	}
+}
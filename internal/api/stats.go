@@ -0,0 +1,194 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"acars_parser/internal/groundstation"
+	"acars_parser/internal/storage"
+)
+
+// CoverageDayResponse is the parse coverage of one label on one day.
+type CoverageDayResponse struct {
+	Date     string  `json:"date"`
+	Label    string  `json:"label"`
+	Total    int64   `json:"total"`
+	Parsed   int64   `json:"parsed"`
+	Coverage float64 `json:"coverage"` // Parsed / total, from 0 to 1.
+	// Change is the coverage less that of the label's previous day in the
+	// range; absent on its first day.
+	Change  *float64         `json:"change,omitempty"`
+	Parsers map[string]int64 `json:"parsers"` // Messages matched, by parser.
+}
+
+// CoverageResponse is the JSON response for the parse coverage trend.
+type CoverageResponse struct {
+	Label string                `json:"label,omitempty"`
+	From  string                `json:"from"`
+	To    string                `json:"to"`
+	Days  []CoverageDayResponse `json:"days"`
+}
+
+// coverageDays converts stored parse statistics, ordered by day, to the
+// per-day response, comparing each day with the label's previous one.
+func coverageDays(stats []storage.ParseStats) []CoverageDayResponse {
+	days := make([]CoverageDayResponse, 0, len(stats))
+	previous := make(map[string]float64)
+	for _, s := range stats {
+		day := CoverageDayResponse{
+			Date:     s.Day.Format("2006-01-02"),
+			Label:    s.Label,
+			Total:    s.Total,
+			Parsed:   s.Parsed,
+			Coverage: roundCoverage(s.Coverage()),
+			Parsers:  s.Matches,
+		}
+		if day.Parsers == nil {
+			day.Parsers = map[string]int64{}
+		}
+		if prev, ok := previous[s.Label]; ok {
+			change := roundCoverage(day.Coverage - prev)
+			day.Change = &change
+		}
+		previous[s.Label] = day.Coverage
+		days = append(days, day)
+	}
+	return days
+}
+
+// roundCoverage rounds a coverage fraction to four decimal places.
+func roundCoverage(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
+
+func (s *EnrichmentServer) handleGetCoverage(w http.ResponseWriter, r *http.Request) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to, err := parseDateRange(r.URL.Query(), today)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	label := strings.ToUpper(r.URL.Query().Get("label"))
+
+	stats, err := s.pg.GetParseStats(r.Context(), label, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CoverageResponse{
+		Label: label,
+		From:  from.Format("2006-01-02"),
+		To:    to.Format("2006-01-02"),
+		Days:  coverageDays(stats),
+	})
+}
+
+// GroundStationResponse is a ground station heard.
+type GroundStationResponse struct {
+	Kind         string `json:"kind"` // "ats" or "vdl2".
+	ID           string `json:"id"`
+	Provider     string `json:"provider,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Region       string `json:"region,omitempty"`
+	MessageCount int64  `json:"message_count"`
+	FirstHeard   string `json:"first_heard"`
+	LastHeard    string `json:"last_heard"`
+}
+
+// ProviderCoverageResponse is the traffic heard through one provider's ground
+// stations in one region.
+type ProviderCoverageResponse struct {
+	Provider string  `json:"provider"` // "unknown" without reference data.
+	Region   string  `json:"region"`   // "unknown" without reference data.
+	Stations int     `json:"stations"`
+	Messages int64   `json:"messages"`
+	Share    float64 `json:"share"` // Of the messages heard in the region, from 0 to 1.
+}
+
+// GroundStationsResponse is the JSON response for ground station coverage.
+type GroundStationsResponse struct {
+	Kind      string                     `json:"kind,omitempty"`
+	Providers []ProviderCoverageResponse `json:"providers"`
+	Stations  []GroundStationResponse    `json:"stations"`
+}
+
+// providerCoverage totals the stations heard per region and provider, with
+// each provider's share of its region's messages. Regions are ordered by name
+// and providers by messages, busiest first.
+func providerCoverage(stations []storage.GroundStation) []ProviderCoverageResponse {
+	type key struct{ region, provider string }
+	totals := make(map[key]*ProviderCoverageResponse)
+	regionMessages := make(map[string]int64)
+	for _, s := range stations {
+		k := key{region: s.Region, provider: s.Provider}
+		if k.region == "" {
+			k.region = "unknown"
+		}
+		if k.provider == "" {
+			k.provider = "unknown"
+		}
+		c, ok := totals[k]
+		if !ok {
+			c = &ProviderCoverageResponse{Provider: k.provider, Region: k.region}
+			totals[k] = c
+		}
+		c.Stations++
+		c.Messages += s.MessageCount
+		regionMessages[k.region] += s.MessageCount
+	}
+
+	out := make([]ProviderCoverageResponse, 0, len(totals))
+	for _, c := range totals {
+		if total := regionMessages[c.Region]; total > 0 {
+			c.Share = roundCoverage(float64(c.Messages) / float64(total))
+		}
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Region != out[j].Region {
+			return out[i].Region < out[j].Region
+		}
+		if out[i].Messages != out[j].Messages {
+			return out[i].Messages > out[j].Messages
+		}
+		return out[i].Provider < out[j].Provider
+	})
+	return out
+}
+
+func (s *EnrichmentServer) handleGetGroundStations(w http.ResponseWriter, r *http.Request) {
+	kind := strings.ToLower(r.URL.Query().Get("kind"))
+	if kind != "" && kind != groundstation.KindATS && kind != groundstation.KindVDL2 {
+		writeError(w, http.StatusBadRequest, "kind must be ats or vdl2")
+		return
+	}
+
+	stations, err := s.pg.ListGroundStations(r.Context(), kind)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := GroundStationsResponse{
+		Kind:      kind,
+		Providers: providerCoverage(stations),
+		Stations:  make([]GroundStationResponse, 0, len(stations)),
+	}
+	for _, gs := range stations {
+		resp.Stations = append(resp.Stations, GroundStationResponse{
+			Kind:         gs.Kind,
+			ID:           gs.StationID,
+			Provider:     gs.Provider,
+			Name:         gs.Name,
+			Region:       gs.Region,
+			MessageCount: gs.MessageCount,
+			FirstHeard:   gs.FirstHeard.UTC().Format(time.RFC3339),
+			LastHeard:    gs.LastHeard.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestCoverageDays(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	stats := []storage.ParseStats{
+		{Day: day(20), Label: "44", Total: 200, Parsed: 190, Matches: map[string]int64{"pos44": 190}},
+		{Day: day(20), Label: "H1", Total: 3, Parsed: 1},
+		{Day: day(21), Label: "44", Total: 300, Parsed: 240, Matches: map[string]int64{"pos44": 240}},
+	}
+
+	days := coverageDays(stats)
+	if len(days) != 3 {
+		t.Fatalf("got %d days, want 3", len(days))
+	}
+	if d := days[0]; d.Date != "2026-01-20" || d.Coverage != 0.95 || d.Change != nil || d.Parsers["pos44"] != 190 {
+		t.Errorf("days[0] = %+v", d)
+	}
+	if d := days[1]; d.Coverage != 0.3333 || d.Change != nil || d.Parsers == nil {
+		t.Errorf("days[1] = %+v", d)
+	}
+	if d := days[2]; d.Coverage != 0.8 || d.Change == nil || *d.Change != -0.15 {
+		t.Errorf("days[2] = %+v, change %v", d, d.Change)
+	}
+}
+
+func TestProviderCoverage(t *testing.T) {
+	stations := []storage.GroundStation{
+		{Kind: "vdl2", StationID: "10916C", Provider: "SITA", Region: "Europe", MessageCount: 300},
+		{Kind: "vdl2", StationID: "10916D", Provider: "SITA", Region: "Europe", MessageCount: 100},
+		{Kind: "vdl2", StationID: "20A3F1", Provider: "ARINC", Region: "Europe", MessageCount: 100},
+		{Kind: "ats", StationID: "BNECAYA", Provider: "ARINC", Region: "Oceania", MessageCount: 50},
+		{Kind: "ats", StationID: "XXXCDYA", MessageCount: 7},
+	}
+
+	got := providerCoverage(stations)
+	if len(got) != 4 {
+		t.Fatalf("providerCoverage() = %+v", got)
+	}
+	if c := got[0]; c.Region != "Europe" || c.Provider != "SITA" || c.Stations != 2 || c.Messages != 400 || c.Share != 0.8 {
+		t.Errorf("got[0] = %+v", c)
+	}
+	if c := got[1]; c.Provider != "ARINC" || c.Share != 0.2 {
+		t.Errorf("got[1] = %+v", c)
+	}
+	if c := got[2]; c.Region != "Oceania" || c.Share != 1 {
+		t.Errorf("got[2] = %+v", c)
+	}
+	if c := got[3]; c.Region != "unknown" || c.Provider != "unknown" || c.Messages != 7 {
+		t.Errorf("got[3] = %+v", c)
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// An API key may be scoped to tenants, the partner networks whose feeds the
+// pipeline ingests (see acars.Message.Tenant), by listing them after it:
+// "KEY:TENANT|TENANT". A scoped key only sees enrichment derived from those
+// tenants' feeds and messages they supplied, and is refused the endpoints
+// whose data has no tenant. A key without tenants sees everything.
+
+// parseAPIKey splits a configured API key into the key and its tenants,
+// which are nil for an unscoped key.
+func parseAPIKey(s string) (string, []string) {
+	key, list, scoped := strings.Cut(strings.TrimSpace(s), ":")
+	if !scoped {
+		return key, nil
+	}
+	tenants := []string{}
+	for _, t := range strings.Split(list, "|") {
+		if t = strings.TrimSpace(t); t != "" {
+			tenants = append(tenants, t)
+		}
+	}
+	return key, tenants
+}
+
+type tenantsKey struct{}
+
+// withTenants returns ctx carrying the tenants of the request's API key.
+func withTenants(ctx context.Context, tenants []string) context.Context {
+	if tenants == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantsKey{}, tenants)
+}
+
+// requestTenants returns the tenants a request is scoped to, or nil when it
+// is not scoped.
+func requestTenants(ctx context.Context) []string {
+	tenants, _ := ctx.Value(tenantsKey{}).([]string)
+	return tenants
+}
+
+// tenantsCacheKey returns the suffix that keeps the cached lookups of scoped
+// keys apart: "" when unscoped, otherwise the sorted tenants.
+func tenantsCacheKey(tenants []string) string {
+	if tenants == nil {
+		return ""
+	}
+	sorted := slices.Sorted(slices.Values(tenants))
+	return "/tenants=" + strings.Join(sorted, "|")
+}
+
+// unscoped refuses requests from keys scoped to tenants, for endpoints whose
+// data is not kept by tenant.
+func unscoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestTenants(r.Context()) != nil {
+			writeError(w, http.StatusForbidden, "Not available to API keys scoped to tenants")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
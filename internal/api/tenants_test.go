@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		in      string
+		key     string
+		tenants []string
+	}{
+		{"secret", "secret", nil},
+		{" secret ", "secret", nil},
+		{"secret:partner-a", "secret", []string{"partner-a"}},
+		{"secret:partner-a| partner-b", "secret", []string{"partner-a", "partner-b"}},
+		{"secret:", "secret", []string{}}, // Scoped to nothing.
+	}
+	for _, tt := range tests {
+		key, tenants := parseAPIKey(tt.in)
+		if key != tt.key || !reflect.DeepEqual(tenants, tt.tenants) {
+			t.Errorf("parseAPIKey(%q) = %q, %#v; want %q, %#v", tt.in, key, tenants, tt.key, tt.tenants)
+		}
+	}
+}
+
+func TestAuthMiddlewareTenants(t *testing.T) {
+	server := NewEnrichmentServer(nil, Config{
+		AuthEnabled: true,
+		APIKeys:     []string{"admin", "partner:partner-b|partner-a"},
+	})
+	var got []string
+	handler := server.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestTenants(r.Context())
+	}))
+
+	for key, want := range map[string][]string{"admin": nil, "partner": {"partner-b", "partner-a"}} {
+		req := httptest.NewRequest(http.MethodGet, "/airlines", nil)
+		req.Header.Set("X-API-Key", key)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: tenants = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestGRPCAuthTenants(t *testing.T) {
+	server := NewEnrichmentServer(nil, Config{AuthEnabled: true, APIKeys: []string{"partner:partner-a"}})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "partner"))
+	ctx, err := server.grpcAuth(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requestTenants(ctx); !reflect.DeepEqual(got, []string{"partner-a"}) {
+		t.Errorf("tenants = %v, want partner-a", got)
+	}
+}
+
+func TestScopedKeyEndpoints(t *testing.T) {
+	server := NewEnrichmentServer(nil, Config{
+		AuthEnabled: true,
+		APIKeys:     []string{"admin", "partner:partner-a"},
+	})
+	router := server.Router()
+
+	get := func(path, key string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// State without a tenant is refused to scoped keys.
+	for _, path := range []string{
+		"/aircraft/7C6CA3/flights",
+		"/aircraft/7C6CA3/flights/QFA9/2026-01-27/track",
+		"/stats/coverage",
+		"/emergencies",
+		"/positions?bbox=110,-45,155,-10",
+		"/winds",
+		"/turbulence",
+		"/airports/YSSY/runway-config",
+		"/aircraft/7C6CA3/position",
+	} {
+		if code := get(path, "partner"); code != http.StatusForbidden {
+			t.Errorf("%s with a scoped key: status %d, want 403", path, code)
+		}
+	}
+
+	// Reference data and message search stay open to them.
+	if code := get("/schemas", "partner"); code != http.StatusOK {
+		t.Errorf("/schemas with a scoped key: status %d, want 200", code)
+	}
+	if code := get("/messages", "partner"); code != http.StatusServiceUnavailable {
+		t.Errorf("/messages with a scoped key: status %d, want 503 (search is off)", code)
+	}
+	if code := get("/schemas", "admin"); code != http.StatusOK {
+		t.Errorf("/schemas with an unscoped key: status %d, want 200", code)
+	}
+}
+
+func TestTenantsCacheKey(t *testing.T) {
+	if got := tenantsCacheKey(nil); got != "" {
+		t.Errorf("unscoped = %q, want none", got)
+	}
+	a, b := tenantsCacheKey([]string{"b", "a"}), tenantsCacheKey([]string{"a", "b"})
+	if a != b || !strings.Contains(a, "a|b") {
+		t.Errorf("keys = %q and %q, want the same sorted key", a, b)
+	}
+	if tenantsCacheKey([]string{}) == "" {
+		t.Error("a key scoped to no tenants shares the unscoped cache")
+	}
+}
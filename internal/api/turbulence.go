@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+// severityRanks orders the severities accepted by min_severity.
+var severityRanks = map[string]int{"none": 0, "light": 1, "moderate": 2, "severe": 3, "extreme": 4}
+
+// TurbulenceReportResponse is the JSON representation of a turbulence or
+// wind shear report from an aircraft.
+type TurbulenceReportResponse struct {
+	Timestamp      string   `json:"timestamp"`
+	Phenomenon     string   `json:"phenomenon"`
+	Severity       string   `json:"severity,omitempty"`
+	SeverityText   string   `json:"severity_text,omitempty"`
+	EDRPeak        *float64 `json:"edr_peak,omitempty"`
+	EDRMean        *float64 `json:"edr_mean,omitempty"`
+	AirspeedChange int      `json:"airspeed_change,omitempty"`
+	Latitude       *float64 `json:"latitude,omitempty"`
+	Longitude      *float64 `json:"longitude,omitempty"`
+	Waypoint       string   `json:"waypoint,omitempty"`
+	FlightLevel    *int     `json:"flight_level,omitempty"`
+	FlightLevelTop *int     `json:"flight_level_top,omitempty"`
+	Registration   string   `json:"registration,omitempty"`
+	Flight         string   `json:"flight,omitempty"`
+	MessageID      int64    `json:"message_id,omitempty"`
+	Text           string   `json:"text"`
+}
+
+// TurbulenceReportsResponse is the JSON response for a turbulence report
+// search.
+type TurbulenceReportsResponse struct {
+	From    string                     `json:"from"`
+	To      string                     `json:"to"`
+	Reports []TurbulenceReportResponse `json:"reports"`
+}
+
+func turbulenceReportToResponse(r storage.TurbulenceReport) TurbulenceReportResponse {
+	return TurbulenceReportResponse{
+		Timestamp:      r.ReportedAt.UTC().Format(time.RFC3339),
+		Phenomenon:     r.Phenomenon,
+		Severity:       r.Severity,
+		SeverityText:   r.SeverityText,
+		EDRPeak:        r.EDRPeak,
+		EDRMean:        r.EDRMean,
+		AirspeedChange: r.AirspeedChange,
+		Latitude:       r.Latitude,
+		Longitude:      r.Longitude,
+		Waypoint:       r.Waypoint,
+		FlightLevel:    r.FlightLevel,
+		FlightLevelTop: r.FlightLevelTop,
+		Registration:   r.Registration,
+		Flight:         r.Flight,
+		MessageID:      r.MessageID,
+		Text:           r.Text,
+	}
+}
+
+// parseTurbulenceFilter reads the phenomenon and min_severity parameters.
+// Without min_severity every report is included, even those without a
+// severity.
+func parseTurbulenceFilter(q url.Values) (phenomenon string, minRank int, err error) {
+	phenomenon = q.Get("phenomenon")
+	if phenomenon != "" && phenomenon != "turbulence" && phenomenon != "wind_shear" {
+		return "", 0, errors.New("phenomenon must be turbulence or wind_shear")
+	}
+	minRank = -1
+	if v := q.Get("min_severity"); v != "" {
+		rank, ok := severityRanks[v]
+		if !ok {
+			return "", 0, errors.New("min_severity must be none, light, moderate, severe or extreme")
+		}
+		minRank = rank
+	}
+	return phenomenon, minRank, nil
+}
+
+func (s *EnrichmentServer) handleGetTurbulence(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	wq, err := parseWeatherQuery(q, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	phenomenon, minRank, err := parseTurbulenceFilter(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reports, err := s.pg.GetTurbulenceReports(r.Context(), wq, phenomenon, minRank)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := TurbulenceReportsResponse{
+		From:    wq.From.UTC().Format(time.RFC3339),
+		To:      wq.To.UTC().Format(time.RFC3339),
+		Reports: make([]TurbulenceReportResponse, 0, len(reports)),
+	}
+	for _, rep := range reports {
+		resp.Reports = append(resp.Reports, turbulenceReportToResponse(rep))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseTurbulenceFilter(t *testing.T) {
+	if p, rank, err := parseTurbulenceFilter(url.Values{}); err != nil || p != "" || rank != -1 {
+		t.Errorf("defaults = %q, %d, %v", p, rank, err)
+	}
+	if p, rank, err := parseTurbulenceFilter(url.Values{"phenomenon": {"wind_shear"}, "min_severity": {"moderate"}}); err != nil || p != "wind_shear" || rank != 2 {
+		t.Errorf("parsed = %q, %d, %v", p, rank, err)
+	}
+	for _, bad := range []url.Values{{"phenomenon": {"icing"}}, {"min_severity": {"MOD"}}} {
+		if _, _, err := parseTurbulenceFilter(bad); err == nil {
+			t.Errorf("parseTurbulenceFilter(%v) succeeded, want error", bad)
+		}
+	}
+}
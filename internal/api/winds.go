@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+)
+
+// Limits on the wind grid, weather observation and turbulence endpoints.
+const (
+	defaultWindWindow = 6 * time.Hour
+	defaultWindLimit  = 5000
+	maxWindLimit      = 50000
+)
+
+// parseWeatherQuery reads the weather query parameters: an optional bbox, since
+// and until (RFC 3339 times or dates; since defaults to six hours before now
+// and until to now), min_fl and max_fl, and limit.
+func parseWeatherQuery(q url.Values, now time.Time) (storage.WeatherQuery, error) {
+	wq := storage.WeatherQuery{From: now.Add(-defaultWindWindow), To: now, Limit: defaultWindLimit}
+	if v := q.Get("bbox"); v != "" {
+		box, err := parseBBox(v)
+		if err != nil {
+			return wq, err
+		}
+		wq.Box = &box
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &wq.From}, {"until", &wq.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", v); err != nil {
+				return wq, errors.New("invalid " + p.name + " (use RFC 3339 or YYYY-MM-DD)")
+			}
+		}
+		*p.t = t
+	}
+	if !wq.From.Before(wq.To) {
+		return wq, errors.New("since must be before until")
+	}
+	for _, p := range []struct {
+		name string
+		fl   *int
+	}{{"min_fl", &wq.MinFL}, {"max_fl", &wq.MaxFL}} {
+		if v := q.Get(p.name); v != "" {
+			fl, err := strconv.Atoi(v)
+			if err != nil || fl < 0 || fl > 999 {
+				return wq, errors.New(p.name + " must be a flight level from 0 to 999")
+			}
+			*p.fl = fl
+		}
+	}
+	if wq.MaxFL > 0 && wq.MinFL > wq.MaxFL {
+		return wq, errors.New("min_fl must not exceed max_fl")
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return wq, errors.New("limit must be a positive integer")
+		}
+		wq.Limit = min(limit, maxWindLimit)
+	}
+	return wq, nil
+}
+
+// parseFormat reads the format query parameter, which must be one of
+// formats. The first is the default.
+func parseFormat(q url.Values, formats ...string) (string, error) {
+	v := q.Get("format")
+	if v == "" {
+		return formats[0], nil
+	}
+	for _, f := range formats {
+		if v == f {
+			return v, nil
+		}
+	}
+	return "", errors.New("format must be " + strings.Join(formats, " or "))
+}
+
+func (s *EnrichmentServer) handleGetWinds(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	wq, err := parseWeatherQuery(q, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := parseFormat(q, "geojson", "csv")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cells, err := s.pg.GetWindGrid(r.Context(), wq)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var body []byte
+	contentType := "application/geo+json"
+	if format == "csv" {
+		var buf bytes.Buffer
+		err = state.WriteWindGridCSV(&buf, cells)
+		body, contentType = buf.Bytes(), "text/csv; charset=utf-8"
+	} else {
+		body, err = state.WindGridGeoJSON(cells)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// WeatherObservationResponse is the JSON representation of a wind, and
+// perhaps a temperature, reported by an aircraft.
+type WeatherObservationResponse struct {
+	Timestamp    string   `json:"timestamp"`
+	Latitude     float64  `json:"latitude"`
+	Longitude    float64  `json:"longitude"`
+	FlightLevel  int      `json:"flight_level"`
+	WindDir      float64  `json:"wind_dir"`
+	WindSpeed    float64  `json:"wind_speed"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	Source       string   `json:"source"`
+	Registration string   `json:"registration,omitempty"`
+	Flight       string   `json:"flight,omitempty"`
+	MessageID    int64    `json:"message_id,omitempty"`
+}
+
+// WeatherObservationsResponse is the JSON response for a weather observation
+// search.
+type WeatherObservationsResponse struct {
+	From         string                       `json:"from"`
+	To           string                       `json:"to"`
+	Observations []WeatherObservationResponse `json:"observations"`
+}
+
+func weatherObservationToResponse(o storage.WeatherObservation) WeatherObservationResponse {
+	return WeatherObservationResponse{
+		Timestamp:    o.ObservedAt.UTC().Format(time.RFC3339),
+		Latitude:     o.Latitude,
+		Longitude:    o.Longitude,
+		FlightLevel:  o.FlightLevel,
+		WindDir:      o.WindDir,
+		WindSpeed:    o.WindSpeed,
+		Temperature:  o.Temperature,
+		Source:       o.Source,
+		Registration: o.Registration,
+		Flight:       o.Flight,
+		MessageID:    o.MessageID,
+	}
+}
+
+// weatherObservationsCSVHeader is the header row of the CSV observations.
+var weatherObservationsCSVHeader = []string{"timestamp", "latitude", "longitude", "flight_level", "wind_dir",
+	"wind_speed", "temperature", "source", "registration", "flight", "message_id"}
+
+// writeWeatherObservationsCSV writes observations as CSV with a header row.
+// The temperature is empty when none was reported.
+func writeWeatherObservationsCSV(w io.Writer, obs []WeatherObservationResponse) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(weatherObservationsCSVHeader); err != nil {
+		return err
+	}
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, o := range obs {
+		temp := ""
+		if o.Temperature != nil {
+			temp = num(*o.Temperature)
+		}
+		err := cw.Write([]string{o.Timestamp, num(o.Latitude), num(o.Longitude), strconv.Itoa(o.FlightLevel),
+			num(o.WindDir), num(o.WindSpeed), temp, o.Source, o.Registration, o.Flight,
+			strconv.FormatInt(o.MessageID, 10)})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *EnrichmentServer) handleGetWeatherObservations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	wq, err := parseWeatherQuery(q, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := parseFormat(q, "json", "csv")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	obs, err := s.pg.GetWeatherObservations(r.Context(), wq)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := WeatherObservationsResponse{
+		From:         wq.From.UTC().Format(time.RFC3339),
+		To:           wq.To.UTC().Format(time.RFC3339),
+		Observations: make([]WeatherObservationResponse, 0, len(obs)),
+	}
+	for _, o := range obs {
+		resp.Observations = append(resp.Observations, weatherObservationToResponse(o))
+	}
+	if format == "json" {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeWeatherObservationsCSV(&buf, resp.Observations); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestParseWindQuery(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	q, err := parseWeatherQuery(url.Values{}, now)
+	if err != nil || q.Box != nil || !q.From.Equal(now.Add(-6*time.Hour)) || !q.To.Equal(now) || q.Limit != defaultWindLimit {
+		t.Errorf("defaults = %+v, %v", q, err)
+	}
+
+	q, err = parseWeatherQuery(url.Values{
+		"bbox": {"140,-40,155,-25"}, "since": {"2026-10-16"}, "until": {"2026-10-16T18:00:00Z"},
+		"min_fl": {"300"}, "max_fl": {"390"}, "limit": {"1000000"},
+	}, now)
+	if err != nil || q.Box == nil || q.Box.West != 140 || q.From.Day() != 16 || q.To.Hour() != 18 ||
+		q.MinFL != 300 || q.MaxFL != 390 || q.Limit != maxWindLimit {
+		t.Errorf("parsed = %+v, %v", q, err)
+	}
+
+	for _, bad := range []url.Values{
+		{"bbox": {"1,2,3"}},
+		{"since": {"yesterday"}},
+		{"since": {"2026-10-18"}},
+		{"min_fl": {"400"}, "max_fl": {"300"}},
+		{"max_fl": {"-1"}},
+		{"limit": {"0"}},
+	} {
+		if _, err := parseWeatherQuery(bad, now); err == nil {
+			t.Errorf("parseWeatherQuery(%v) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := parseFormat(url.Values{}, "geojson", "csv"); err != nil || f != "geojson" {
+		t.Errorf("default = %q, %v", f, err)
+	}
+	if f, err := parseFormat(url.Values{"format": {"csv"}}, "json", "csv"); err != nil || f != "csv" {
+		t.Errorf("csv = %q, %v", f, err)
+	}
+	if _, err := parseFormat(url.Values{"format": {"grib"}}, "geojson", "csv"); err == nil {
+		t.Error("grib accepted")
+	}
+}
+
+func TestWriteWeatherObservationsCSV(t *testing.T) {
+	temp := -52.5
+	obs := []WeatherObservationResponse{
+		weatherObservationToResponse(storage.WeatherObservation{
+			ObservedAt: time.Date(2026, 10, 17, 10, 25, 0, 0, time.UTC), Latitude: -33.5, Longitude: 150.25,
+			FlightLevel: 350, WindDir: 270, WindSpeed: 95, Temperature: &temp, Source: "adsc",
+			Registration: "VH-ZNA", Flight: "QFA3", MessageID: 42,
+		}),
+		{Timestamp: "2026-10-17T10:30:00Z", Latitude: -33.2, Longitude: 150.4, FlightLevel: 348, WindDir: 280, WindSpeed: 100, Source: "h2_wind"},
+	}
+	var buf bytes.Buffer
+	if err := writeWeatherObservationsCSV(&buf, obs); err != nil {
+		t.Fatal(err)
+	}
+	want := "timestamp,latitude,longitude,flight_level,wind_dir,wind_speed,temperature,source,registration,flight,message_id\n" +
+		"2026-10-17T10:25:00Z,-33.5,150.25,350,270,95,-52.5,adsc,VH-ZNA,QFA3,42\n" +
+		"2026-10-17T10:30:00Z,-33.2,150.4,348,280,100,,h2_wind,,,0\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, strings.TrimSpace(want))
+	}
+}
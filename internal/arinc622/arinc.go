@@ -1,6 +1,7 @@
-// Package arinc implements ARINC 622/623 message parsing with CRC validation.
-// This layer sits between raw ACARS messages and protocol-specific decoders (CPDLC, ADS-C, etc.).
-package arinc
+// Package arinc622 implements the ARINC 622 envelope shared by the FANS-1/A
+// applications (CPDLC, ADS-C, AFN): IMI, registration, hex payload and CRC.
+// This layer sits between raw ACARS messages and protocol-specific decoders.
+package arinc622
 
 import (
 	"encoding/hex"
@@ -20,43 +21,52 @@ const (
 	IMIDIS = "DIS" // ADS-C Disconnect.
 )
 
+// MaxPayloadBytes caps the binary payload, CRC included. An ACARS message of
+// 16 blocks of 220 characters holds about 1,700 bytes as hex, so a longer
+// payload is corrupt and is rejected before it is decoded.
+const MaxPayloadBytes = 2048
+
 // Error types for distinguishing failure modes.
 var (
 	ErrCRCFailed     = errors.New("crc_failed")
 	ErrParseFailed   = errors.New("parse_failed")
 	ErrTooShort      = errors.New("message_too_short")
+	ErrTooLong       = errors.New("message_too_long")
 	ErrInvalidHex    = errors.New("invalid_hex")
 	ErrUnknownFormat = errors.New("unknown_format")
 )
 
 // Result contains the parsed ARINC message components.
 type Result struct {
+	Preamble      string // Downlink text before the address, e.g., "F67A5Y0700".
 	GroundStation string // e.g., "SOUCAYA".
 	IMI           string // e.g., "AT1", "CR1".
-	Registration  string // e.g., "HL8251".
+	Registration  string // e.g., "HL8251", without padding dots.
 	Payload       []byte // CRC-stripped binary payload.
 	RawHex        string // Original hex including CRC (for diagnostics).
 }
 
-// messagePattern matches ARINC binary message format.
-// Format: /<ground_station>.<IMI>.<registration><hex_payload>
-// Ground station is 4-7 uppercase alphanumeric chars.
-// IMI is 2-3 chars (AT1, CR1, CC1, DR1, ADS, DIS).
-// Registration + hex follows.
-var messagePattern = regexp.MustCompile(`^/([A-Z0-9]{4,7})\.([A-Z]{2,3}[0-9])\.(.+)$`)
+// messagePattern matches the ARINC 622 address and IMI.
+// Uplink:   /<ground_station>.<IMI>.<registration><hex_payload>
+// Downlink: <preamble>/<ground_station>.<IMI>.<registration><hex_payload>
+// The downlink preamble is free text from the aircraft, usually the flight
+// ID. Ground station is 4-7 uppercase alphanumeric chars and IMI is 3 chars
+// (AT1, CR1, CC1, DR1, ADS, DIS).
+var messagePattern = regexp.MustCompile(`^([^/]*)/([A-Z0-9]{4,7})\.([A-Z][A-Z0-9]{2})\.(.+)$`)
 
-// Parse parses an ARINC binary message, validates CRC, and returns the payload.
+// Parse parses an ARINC 622 message, validates CRC, and returns the payload.
 // Returns ErrCRCFailed if CRC validation fails.
 // Returns other errors for format/parsing issues.
 func Parse(text string) (*Result, error) {
-	matches := messagePattern.FindStringSubmatch(text)
+	matches := messagePattern.FindStringSubmatch(strings.TrimSpace(text))
 	if matches == nil {
 		return nil, fmt.Errorf("%w: does not match ARINC format", ErrUnknownFormat)
 	}
 
-	groundStation := matches[1]
-	imi := matches[2]
-	regAndHex := matches[3]
+	preamble := matches[1]
+	groundStation := matches[2]
+	imi := matches[3]
+	regAndHex := matches[4]
 
 	// Split registration from hex payload.
 	// Registration is variable length (up to 7 chars), hex starts at first valid hex sequence.
@@ -65,6 +75,10 @@ func Parse(text string) (*Result, error) {
 		return nil, fmt.Errorf("%w: no hex payload found", ErrParseFailed)
 	}
 
+	if len(hexStr) > 2*MaxPayloadBytes {
+		return nil, fmt.Errorf("%w: %d hex characters", ErrTooLong, len(hexStr))
+	}
+
 	// Decode hex to binary.
 	hexData, err := hex.DecodeString(hexStr)
 	if err != nil {
@@ -85,9 +99,10 @@ func Parse(text string) (*Result, error) {
 	payload := hexData[:len(hexData)-2]
 
 	return &Result{
+		Preamble:      preamble,
 		GroundStation: groundStation,
 		IMI:           imi,
-		Registration:  registration,
+		Registration:  strings.TrimLeft(registration, "."),
 		Payload:       payload,
 		RawHex:        hexStr,
 	}, nil
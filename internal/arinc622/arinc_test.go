@@ -1,4 +1,4 @@
-package arinc
+package arinc622
 
 import (
 	"errors"
@@ -9,6 +9,7 @@ func TestParse(t *testing.T) {
 	tests := []struct {
 		name           string
 		text           string
+		wantPrefix     string
 		wantGS         string
 		wantIMI        string
 		wantReg        string
@@ -34,6 +35,16 @@ func TestParse(t *testing.T) {
 			wantReg:        "N514DN",
 			wantPayloadLen: 8, // 10 bytes - 2 bytes CRC = 8 bytes.
 		},
+		{
+			// ADS-C downlink with the flight ID before the address.
+			name:           "Downlink with preamble",
+			text:           "F67A5Y0700/FUKJJYA.ADS.N760GT0724F34BA86989C3C98D1D17231AE3868D09C408AB0D24B2D3A348C9C4013F23B1DB9071C9C4000E54A0E140040F54F1A0C004D45D",
+			wantPrefix:     "F67A5Y0700",
+			wantGS:         "FUKJJYA",
+			wantIMI:        "ADS",
+			wantReg:        "N760GT",
+			wantPayloadLen: 51,
+		},
 		{
 			// Truly truncated message - missing CRC bytes entirely.
 			name:    "Too short - missing CRC",
@@ -70,6 +81,9 @@ func TestParse(t *testing.T) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
+			if result.Preamble != tt.wantPrefix {
+				t.Errorf("Preamble = %q, want %q", result.Preamble, tt.wantPrefix)
+			}
 			if result.GroundStation != tt.wantGS {
 				t.Errorf("GroundStation = %q, want %q", result.GroundStation, tt.wantGS)
 			}
@@ -181,6 +195,36 @@ func parseHexByte(s string, b *byte) (int, error) {
 	return 2, nil
 }
 
+func TestFormat(t *testing.T) {
+	// Formatting a parsed message gives back its text.
+	for _, text := range []string{
+		"/ANCATYA.AT1.N514DN220012E8294A952882D8",
+		"/PIKCPYA.AT1.F-GSQC214823E24092E7",
+	} {
+		res, err := Parse(text)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", text, err)
+		}
+		got, err := Format(res.GroundStation, res.IMI, res.Registration, res.Payload)
+		if err != nil || got != text {
+			t.Errorf("Format = %q, %v, want %q", got, err, text)
+		}
+	}
+
+	// A short registration is padded with dots, which Parse strips again.
+	text, err := Format("QUKAXBA", IMIAT1, "B-LHL", []byte{0x21, 0x48})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := Parse(text); err != nil || res.Registration != "B-LHL" {
+		t.Errorf("Parse(%q) = %+v, %v", text, res, err)
+	}
+
+	if _, err := Format("QUKAXBA", IMIAT1, "TOOLONG", nil); !errors.Is(err, ErrParseFailed) {
+		t.Errorf("Format with a 7-character registration: err = %v", err)
+	}
+}
+
 func TestIsCPDLC(t *testing.T) {
 	if !IsCPDLC("AT1") {
 		t.Error("AT1 should be CPDLC")
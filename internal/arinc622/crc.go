@@ -0,0 +1,59 @@
+package arinc622
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"acars_parser/internal/crc"
+)
+
+// Text part length: IMI (3) + "." (1) + registration (6) = 10 bytes.
+// This matches libacars: LA_ARINC_IMI_LEN=3 + LA_ARINC_AIR_REG_LEN=7.
+const textPartLen = 10
+
+// validateCRC checks if the ARINC message CRC is valid.
+// The imi is the 3-character IMI (e.g., "AT1"), registration is the aircraft registration,
+// and hexData is the binary payload including the 2-byte CRC at the end.
+//
+// The CRC is computed over: IMI (3 bytes) + "." + registration (padded to 6 bytes) + binary data.
+// This matches libacars' la_is_crc_ok() function which copies 10 bytes of text.
+//
+// Returns true if CRC is valid, false otherwise.
+func validateCRC(imi, registration string, hexData []byte) bool {
+	// Build the text part: IMI (3) + "." (1) + registration (6) = 10 bytes.
+	regPart := "." + registration
+	for len(regPart) < 7 {
+		regPart += " "
+	}
+	if len(regPart) > 7 {
+		regPart = regPart[:7]
+	}
+
+	textPart := imi + regPart
+	if len(textPart) != textPartLen {
+		return false // Invalid format.
+	}
+
+	return crc.VerifyArincBinaryRaw(textPart, hexData)
+}
+
+// Format builds the text of an ARINC binary message with its CRC, the
+// inverse of Parse. A registration shorter than 6 characters is padded with
+// leading dots, as aircraft send it. It is used to make test vectors.
+func Format(groundStation, imi, registration string, payload []byte) (string, error) {
+	if len(registration) > 6 {
+		return "", fmt.Errorf("%w: registration %q is longer than 6 characters", ErrParseFailed, registration)
+	}
+	registration = strings.Repeat(".", 6-len(registration)) + registration
+	textPart := imi + "." + registration
+	if len(textPart) != textPartLen {
+		return "", fmt.Errorf("%w: IMI %q", ErrParseFailed, imi)
+	}
+
+	buf := make([]byte, 0, textPartLen+len(payload)+2)
+	buf = append(buf, textPart...)
+	buf = append(buf, payload...)
+	data := append(append([]byte(nil), payload...), crc.Calculate16Arinc(buf)...)
+	return "/" + groundStation + "." + textPart + strings.ToUpper(hex.EncodeToString(data)), nil
+}
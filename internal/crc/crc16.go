@@ -0,0 +1,204 @@
+package crc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variant describes a 16-bit CRC algorithm using the Rocksoft parameter model.
+type Variant struct {
+	Name      string // Catalogue name, e.g. "CRC-16/ARINC".
+	Poly      uint16 // Generator polynomial in normal (MSB-first) form.
+	Init      uint16 // Initial register value.
+	Reflected bool   // Input and output are bit-reflected (LSB-first).
+	XorOut    uint16 // Value XORed with the register to give the checksum.
+
+	table   *[256]uint16
+	residue uint16
+}
+
+// Standard CRC-16 variants seen on ACARS or commonly tried when identifying an
+// unknown checksum. Check values are for the ASCII string "123456789".
+var (
+	// ARINC is the ARINC 622/633 CRC used by FANS-1/A, ADS-C and H1 FPN
+	// uplinks (libacars). It is CRC-16/GENIBUS: check 0xD64E, residue 0x1D0F.
+	ARINC = newVariant("CRC-16/ARINC", 0x1021, 0xFFFF, false, 0xFFFF)
+
+	// CCITTFalse is CRC-16/IBM-3740, often called CCITT-FALSE. Check 0x29B1.
+	CCITTFalse = newVariant("CRC-16/CCITT-FALSE", 0x1021, 0xFFFF, false, 0x0000)
+
+	// XModem is CRC-16/XMODEM (CCITT with zero init). Check 0x31C3.
+	XModem = newVariant("CRC-16/XMODEM", 0x1021, 0x0000, false, 0x0000)
+
+	// Kermit is the reflected CCITT CRC used by Kermit and X.25 LAPB. Check 0x2189.
+	Kermit = newVariant("CRC-16/KERMIT", 0x1021, 0x0000, true, 0x0000)
+
+	// IBM is CRC-16/ARC (the original IBM/ANSI CRC-16). Check 0xBB3D.
+	IBM = newVariant("CRC-16/IBM", 0x8005, 0x0000, true, 0x0000)
+
+	// Modbus is CRC-16/MODBUS. Check 0x4B37.
+	Modbus = newVariant("CRC-16/MODBUS", 0x8005, 0xFFFF, true, 0x0000)
+)
+
+// Variants lists the known variants in the order FindVariant tries them.
+var Variants = []*Variant{ARINC, CCITTFalse, XModem, Kermit, IBM, Modbus}
+
+func newVariant(name string, poly, init uint16, reflected bool, xorOut uint16) *Variant {
+	v := &Variant{Name: name, Poly: poly, Init: init, Reflected: reflected, XorOut: xorOut}
+	v.table = makeTable(poly, reflected)
+	// Appending a valid checksum leaves the register at a constant that depends
+	// only on XorOut: the result of shifting XorOut through two zero bytes.
+	v.residue = v.update(xorOut, []byte{0, 0})
+	return v
+}
+
+// makeTable builds the byte-wise lookup table for the polynomial.
+func makeTable(poly uint16, reflected bool) *[256]uint16 {
+	var t [256]uint16
+	if reflected {
+		rpoly := reverse16(poly)
+		for i := range t {
+			crc := uint16(i)
+			for b := 0; b < 8; b++ {
+				if crc&1 != 0 {
+					crc = (crc >> 1) ^ rpoly
+				} else {
+					crc >>= 1
+				}
+			}
+			t[i] = crc
+		}
+		return &t
+	}
+	for i := range t {
+		crc := uint16(i) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return &t
+}
+
+func reverse16(x uint16) uint16 {
+	var r uint16
+	for i := 0; i < 16; i++ {
+		r = (r << 1) | (x & 1)
+		x >>= 1
+	}
+	return r
+}
+
+// update feeds data through the register without the final XOR.
+func (v *Variant) update(crc uint16, data []byte) uint16 {
+	if v.Reflected {
+		for _, b := range data {
+			crc = (crc >> 8) ^ v.table[(crc^uint16(b))&0xff]
+		}
+		return crc
+	}
+	for _, b := range data {
+		crc = (crc << 8) ^ v.table[((crc>>8)^uint16(b))&0xff]
+	}
+	return crc
+}
+
+// Residue is the register value left after processing a message followed by
+// its valid checksum. Verify checks against it.
+func (v *Variant) Residue() uint16 {
+	return v.residue
+}
+
+// Compute returns the checksum of data.
+func (v *Variant) Compute(data []byte) uint16 {
+	return v.update(v.Init, data) ^ v.XorOut
+}
+
+// Bytes returns the checksum in the byte order it is appended to a message:
+// big-endian for MSB-first variants, little-endian for reflected ones.
+func (v *Variant) Bytes(sum uint16) []byte {
+	if v.Reflected {
+		return []byte{byte(sum), byte(sum >> 8)}
+	}
+	return []byte{byte(sum >> 8), byte(sum)}
+}
+
+// Verify reports whether checksum is the valid 2-byte checksum of data. It runs
+// the CRC over data followed by the checksum bytes and compares the register
+// with the variant's residue, so the checksum is never decoded.
+func (v *Variant) Verify(data, checksum []byte) bool {
+	if len(checksum) != 2 {
+		return false
+	}
+	crc := v.update(v.Init, data)
+	return v.update(crc, checksum) == v.residue
+}
+
+// VerifyHex verifies a message whose checksum is the trailing 4 hex characters
+// of text, as in H1 FPN uplinks ("...,,,,75A7"). The hex is read in the
+// variant's byte order.
+func (v *Variant) VerifyHex(text string) bool {
+	body, checksum, err := SplitHexChecksum(text)
+	if err != nil {
+		return false
+	}
+	return v.Verify([]byte(body), checksum)
+}
+
+// SplitHexChecksum splits text into its body and the 2 bytes encoded by its
+// trailing 4 hex characters.
+func SplitHexChecksum(text string) (string, []byte, error) {
+	text = strings.TrimSpace(text)
+	if len(text) < 4 {
+		return "", nil, fmt.Errorf("text too short for a checksum")
+	}
+	hex := text[len(text)-4:]
+	for i := 0; i < 4; i++ {
+		if !IsHexDigit(hex[i]) {
+			return "", nil, fmt.Errorf("trailing %q is not a hex checksum", hex)
+		}
+	}
+	return text[:len(text)-4], []byte{HexToByte(hex[0], hex[1]), HexToByte(hex[2], hex[3])}, nil
+}
+
+// Match is a variant that accepts a checksum, with how the checksum relates to it.
+type Match struct {
+	Variant *Variant
+	Swapped bool // The checksum bytes were in the opposite of the variant's usual order.
+}
+
+// FindVariant returns every known variant for which checksum is valid over data.
+// Each variant is tried with the checksum bytes in its usual order and then
+// swapped, to catch senders that append the checksum the other way round.
+func FindVariant(data, checksum []byte) []Match {
+	if len(checksum) != 2 {
+		return nil
+	}
+	swapped := []byte{checksum[1], checksum[0]}
+
+	var matches []Match
+	for _, v := range Variants {
+		if v.Verify(data, checksum) {
+			matches = append(matches, Match{Variant: v})
+		} else if v.Verify(data, swapped) {
+			matches = append(matches, Match{Variant: v, Swapped: true})
+		}
+	}
+	return matches
+}
+
+// LookupVariant returns the known variant with the given name, ignoring case
+// and the "CRC-16/" prefix, or nil if there is none.
+func LookupVariant(name string) *Variant {
+	want := strings.TrimPrefix(strings.ToUpper(name), "CRC-16/")
+	for _, v := range Variants {
+		if strings.TrimPrefix(v.Name, "CRC-16/") == want {
+			return v
+		}
+	}
+	return nil
+}
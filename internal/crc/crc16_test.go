@@ -0,0 +1,105 @@
+package crc
+
+import "testing"
+
+func TestVariantCheckValues(t *testing.T) {
+	check := []byte("123456789")
+	tests := []struct {
+		variant *Variant
+		want    uint16
+	}{
+		{ARINC, 0xD64E},
+		{CCITTFalse, 0x29B1},
+		{XModem, 0x31C3},
+		{Kermit, 0x2189},
+		{IBM, 0xBB3D},
+		{Modbus, 0x4B37},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.variant.Name, func(t *testing.T) {
+			if got := tt.variant.Compute(check); got != tt.want {
+				t.Errorf("Compute = %04X, want %04X", got, tt.want)
+			}
+
+			// A computed checksum must verify, and a corrupted one must not.
+			sum := tt.variant.Bytes(tt.variant.Compute(check))
+			if !tt.variant.Verify(check, sum) {
+				t.Errorf("Verify(%X) = false, want true", sum)
+			}
+			sum[0] ^= 0x01
+			if tt.variant.Verify(check, sum) {
+				t.Errorf("Verify(corrupted %X) = true, want false", sum)
+			}
+		})
+	}
+}
+
+func TestARINCResidue(t *testing.T) {
+	if ARINC.Residue() != GoodValue16Arinc {
+		t.Errorf("ARINC residue = %04X, want %04X", ARINC.Residue(), GoodValue16Arinc)
+	}
+	for _, v := range []*Variant{CCITTFalse, XModem, Kermit, IBM, Modbus} {
+		if v.Residue() != 0 {
+			t.Errorf("%s residue = %04X, want 0", v.Name, v.Residue())
+		}
+	}
+}
+
+func TestVerifyHexFPN(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ARINC.VerifyHex(tc.fullMessage); got != tc.shouldVerify {
+				t.Errorf("VerifyHex = %v, want %v", got, tc.shouldVerify)
+			}
+		})
+	}
+}
+
+func TestFindVariant(t *testing.T) {
+	for _, tc := range testCases {
+		if !tc.shouldVerify {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			body, sum, err := SplitHexChecksum(tc.fullMessage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches := FindVariant([]byte(body), sum)
+			if len(matches) != 1 || matches[0].Variant != ARINC || matches[0].Swapped {
+				t.Errorf("FindVariant = %+v, want ARINC only", matches)
+			}
+		})
+	}
+
+	// A reflected variant's checksum given big-endian is found as swapped.
+	data := []byte("123456789")
+	sum := Modbus.Compute(data)
+	matches := FindVariant(data, []byte{byte(sum >> 8), byte(sum)})
+	if len(matches) != 1 || matches[0].Variant != Modbus || !matches[0].Swapped {
+		t.Errorf("FindVariant(swapped Modbus) = %+v, want swapped Modbus", matches)
+	}
+}
+
+func TestSplitHexChecksum(t *testing.T) {
+	body, sum, err := SplitHexChecksum("ABC/WD,,,,75a7\n")
+	if err != nil || body != "ABC/WD,,,," || sum[0] != 0x75 || sum[1] != 0xA7 {
+		t.Errorf("SplitHexChecksum = %q, %X, %v", body, sum, err)
+	}
+	if _, _, err := SplitHexChecksum("ABC/WD,,,,75G7"); err == nil {
+		t.Error("expected error for non-hex checksum")
+	}
+	if _, _, err := SplitHexChecksum("75"); err == nil {
+		t.Error("expected error for short text")
+	}
+}
+
+func TestLookupVariant(t *testing.T) {
+	if LookupVariant("arinc") != ARINC || LookupVariant("CRC-16/MODBUS") != Modbus {
+		t.Error("LookupVariant did not find known variants")
+	}
+	if LookupVariant("crc-32") != nil {
+		t.Error("LookupVariant found an unknown variant")
+	}
+}
@@ -0,0 +1,358 @@
+// Package crosscheck compares our ADS-C and CPDLC decoders with libacars, to
+// find bit-alignment and scaling bugs in the hand-written decoders.
+//
+// libacars is not linked: dumpvdl2 and dumphfdl decode the ARINC 622
+// applications in ACARS text with libacars and write its decode in their JSON
+// output, beside the raw text (under "arinc622" in the ACARS object). A frame
+// of that output therefore holds both sides of the comparison, and a capture
+// made with those tools can be checked without libacars installed here. Our
+// side is the parser of the same name run over the raw text.
+//
+// Fields are compared as mapped in Fields. MIAM, which libacars also decodes
+// (under "miam"), is counted but not compared, as there is no MIAM decoder
+// here.
+package crosscheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/hfdl"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/vdl2"
+)
+
+// Frame is the ACARS message of a dumpvdl2 or dumphfdl frame with libacars'
+// decode of it.
+type Frame struct {
+	Message *acars.Message
+
+	// Decoder is the parser comparable with libacars' decode: "adsc",
+	// "cpdlc", or empty when libacars decoded neither.
+	Decoder string
+	Ref     json.RawMessage // libacars' decode, under its "adsc" or "cpdlc" key.
+	CRCOK   bool            // libacars found the ARINC 622 CRC valid.
+	MIAM    bool            // libacars decoded MIAM, which is not compared.
+}
+
+// libacarsACARS is the part of an ACARS object that libacars wrote.
+type libacarsACARS struct {
+	ARINC622 *struct {
+		CRCOK bool            `json:"crc_ok"`
+		ADSC  json.RawMessage `json:"adsc"`
+		CPDLC json.RawMessage `json:"cpdlc"`
+	} `json:"arinc622"`
+	MIAM json.RawMessage `json:"miam"`
+}
+
+type envelope struct {
+	VDL2 *struct {
+		AVLC *struct {
+			ACARS *libacarsACARS `json:"acars"`
+		} `json:"avlc"`
+	} `json:"vdl2"`
+	HFDL *struct {
+		LPDU *struct {
+			HFNPDU *struct {
+				ACARS *libacarsACARS `json:"acars"`
+			} `json:"hfnpdu"`
+		} `json:"lpdu"`
+	} `json:"hfdl"`
+}
+
+// ErrNoMessage is returned for a frame without an ACARS message.
+var ErrNoMessage = errors.New("no ACARS message")
+
+// ParseFrame reads one line of dumpvdl2 or dumphfdl JSON output.
+func ParseFrame(line []byte) (*Frame, error) {
+	var env envelope
+	if err := json.Unmarshal(line, &env); err != nil {
+		return nil, fmt.Errorf("parse frame: %w", err)
+	}
+
+	var msg *acars.Message
+	var la *libacarsACARS
+	switch {
+	case env.VDL2 != nil:
+		d, err := vdl2.Decode(line)
+		if err != nil {
+			return nil, err
+		}
+		msg = d.Message
+		if env.VDL2.AVLC != nil {
+			la = env.VDL2.AVLC.ACARS
+		}
+	case env.HFDL != nil:
+		d, err := hfdl.Decode(line)
+		if err != nil {
+			return nil, err
+		}
+		msg = d.Message
+		if env.HFDL.LPDU != nil && env.HFDL.LPDU.HFNPDU != nil {
+			la = env.HFDL.LPDU.HFNPDU.ACARS
+		}
+	default:
+		return nil, errors.New("not a dumpvdl2 or dumphfdl frame")
+	}
+	if msg == nil || la == nil {
+		return nil, ErrNoMessage
+	}
+
+	f := &Frame{Message: msg, MIAM: len(la.MIAM) > 0}
+	if a := la.ARINC622; a != nil {
+		f.CRCOK = a.CRCOK
+		switch {
+		case len(a.ADSC) > 0:
+			f.Decoder, f.Ref = "adsc", a.ADSC
+		case len(a.CPDLC) > 0:
+			f.Decoder, f.Ref = "cpdlc", a.CPDLC
+		}
+	}
+	return f, nil
+}
+
+// Disagreement is a field on which our decoder and libacars differ. A value
+// is nil where the side did not have the field.
+type Disagreement struct {
+	Field    string      `json:"field"`
+	Ours     interface{} `json:"ours"`
+	Libacars interface{} `json:"libacars"`
+}
+
+// Report is the comparison of one frame.
+type Report struct {
+	Decoder       string
+	Compared      []string // Fields both sides were compared on.
+	Disagreements []Disagreement
+}
+
+// elementPattern matches the ASN.1 alternative names libacars writes for
+// CPDLC message elements (uM20Altitude, dM48PositionReport).
+var elementPattern = regexp.MustCompile(`^([ud])M(\d+)`)
+
+// Compare runs the frame's message through our decoder and compares the
+// result with libacars' decode. A frame libacars did not decode, or found the
+// CRC of invalid, is not compared and gives nil.
+func Compare(reg *registry.Registry, f *Frame) (*Report, error) {
+	if f.Decoder == "" || !f.CRCOK {
+		return nil, nil
+	}
+	p := reg.Lookup(f.Decoder)
+	if p == nil {
+		return nil, fmt.Errorf("no parser named %q", f.Decoder)
+	}
+	ref, err := flatten(f.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("libacars %s: %w", f.Decoder, err)
+	}
+
+	report := &Report{Decoder: f.Decoder}
+	result := p.Parse(f.Message)
+	if result == nil {
+		report.Disagreements = append(report.Disagreements, Disagreement{Field: "result", Libacars: "a result"})
+		return report, nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var ours map[string]interface{}
+	if err := json.Unmarshal(data, &ours); err != nil {
+		return nil, err
+	}
+	if msg, ok := ours["error"].(string); ok && msg != "" {
+		report.Disagreements = append(report.Disagreements, Disagreement{Field: "error", Ours: msg})
+		return report, nil
+	}
+
+	for _, field := range Fields {
+		if field.Decoder != f.Decoder {
+			continue
+		}
+		want, ok := ref.lookup(field.Ref)
+		if !ok {
+			continue
+		}
+		report.Compared = append(report.Compared, field.Name)
+		got := lookup(ours, field.Name)
+		if !equal(got, want, field.Tolerance) {
+			report.Disagreements = append(report.Disagreements, Disagreement{Field: field.Name, Ours: got, Libacars: want})
+		}
+	}
+
+	if f.Decoder == "cpdlc" {
+		if want := ref.elements(); len(want) > 0 {
+			report.Compared = append(report.Compared, ElementsField)
+			if got := elementIDs(ours); got != strings.Join(want, " ") {
+				report.Disagreements = append(report.Disagreements,
+					Disagreement{Field: ElementsField, Ours: got, Libacars: strings.Join(want, " ")})
+			}
+		}
+	}
+	return report, nil
+}
+
+// elementIDs returns the element IDs of a CPDLC result, as "dM48 dM65".
+func elementIDs(result map[string]interface{}) string {
+	prefix := "uM"
+	if result["direction"] == "downlink" {
+		prefix = "dM"
+	}
+	elements, _ := result["elements"].([]interface{})
+	ids := make([]string, 0, len(elements))
+	for _, e := range elements {
+		if m, ok := e.(map[string]interface{}); ok {
+			if id, ok := m["id"].(float64); ok {
+				ids = append(ids, prefix+strconv.Itoa(int(id)))
+			}
+		}
+	}
+	return strings.Join(ids, " ")
+}
+
+// lookup returns the value at a dotted path of a result, or nil.
+func lookup(m map[string]interface{}, path string) interface{} {
+	var v interface{} = m
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
+
+// equal compares our value with libacars'. Numbers are equal within
+// tolerance and strings regardless of case and of the padding and leading
+// dots some decoders keep. A value we omitted is taken as the zero value.
+func equal(ours, ref interface{}, tolerance float64) bool {
+	switch r := ref.(type) {
+	case float64:
+		o, _ := ours.(float64)
+		return math.Abs(o-r) <= tolerance+1e-9
+	case string:
+		o, _ := ours.(string)
+		return normalise(o) == normalise(r)
+	case bool:
+		o, _ := ours.(bool)
+		return o == r
+	}
+	return ours == nil && ref == nil
+}
+
+func normalise(s string) string {
+	return strings.ToUpper(strings.TrimLeft(strings.TrimSpace(s), "."))
+}
+
+// leaf is a value in libacars' decode and the object keys leading to it.
+type leaf struct {
+	keys  []string
+	value interface{}
+}
+
+// tree is libacars' decode flattened in document order, so that the first
+// match of a path is the first in the decode.
+type tree struct {
+	leaves []leaf
+	keys   []string // Every object key, in order.
+}
+
+// flatten reads a JSON document into a tree.
+func flatten(data []byte) (*tree, error) {
+	t := &tree{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := t.walk(dec, nil); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *tree) walk(dec *json.Decoder, keys []string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := k.(string)
+			t.keys = append(t.keys, key)
+			if err := t.walk(dec, append(keys[:len(keys):len(keys)], key)); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	case json.Delim('['):
+		for dec.More() {
+			if err := t.walk(dec, keys); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+	t.leaves = append(t.leaves, leaf{keys: keys, value: tok})
+	return nil
+}
+
+// lookup returns the first value whose keys end with the dotted path.
+func (t *tree) lookup(path string) (interface{}, bool) {
+	suffix := strings.Split(path, ".")
+	for _, l := range t.leaves {
+		if len(l.keys) < len(suffix) {
+			continue
+		}
+		tail := l.keys[len(l.keys)-len(suffix):]
+		match := true
+		for i := range suffix {
+			if tail[i] != suffix[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return l.value, true
+		}
+	}
+	return nil, false
+}
+
+// elements returns the CPDLC element IDs in the decode, as "dM48". They are
+// taken from the object keys naming ASN.1 alternatives, or where the decode
+// has none, from string values naming them.
+func (t *tree) elements() []string {
+	var ids []string
+	for _, k := range t.keys {
+		if m := elementPattern.FindStringSubmatch(k); m != nil {
+			ids = append(ids, m[1]+"M"+m[2])
+		}
+	}
+	if len(ids) > 0 {
+		return ids
+	}
+	for _, l := range t.leaves {
+		if s, ok := l.value.(string); ok {
+			if m := elementPattern.FindStringSubmatch(s); m != nil {
+				ids = append(ids, m[1]+"M"+m[2])
+			}
+		}
+	}
+	return ids
+}
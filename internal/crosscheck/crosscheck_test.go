@@ -0,0 +1,121 @@
+package crosscheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"acars_parser/internal/registry"
+
+	_ "acars_parser/internal/parsers" // Register all parsers.
+)
+
+const adscText = "/XYTGL7X.ADS.F-GXLI0725BFC82D8D46BC46CC1D0D25B0182C2CC745807725965029EF880A40B791"
+
+const cpdlcText = "/SOUCAYA.AT1.HL8251243F880C3D903BB412903604FE326C2479F4A64F7F62528B1A9CF8382738186AC28B16668E013DF464D8A7F0"
+
+// vdl2Frame returns a dumpvdl2 line carrying text with libacars' decode.
+func vdl2Frame(label, text, arinc622 string) []byte {
+	return []byte(`{"vdl2":{"t":{"sec":1769248800,"usec":0},"freq":136975000,"avlc":{"src":{"addr":"39AB81","type":"Aircraft"},"dst":{"addr":"10916D","type":"Ground station"},"frame_type":"I","acars":{"err":false,"crc_ok":true,"reg":".F-GXLI","label":"` +
+		label + `","msg_text":"` + text + `"` + arinc622 + `}}}}`)
+}
+
+func TestCompareADSC(t *testing.T) {
+	reg := registry.Default()
+
+	tests := []struct {
+		name string
+		alt  string
+		want []string // Fields disagreeing.
+	}{
+		{"agrees", "13794", nil},
+		{"altitude differs", "13800", []string{"altitude"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := vdl2Frame("B6", adscText, `,"arinc622":{"msg_type":"adsc_msg","crc_ok":true,"gs_addr":"XYTGL7X","air_addr":".F-GXLI","adsc":{"tags":[{"basic_report":{"lat":53.0847,"lon":8.0071,"alt":`+
+				tt.alt+`,"ts_sec":435.0,"pos_accuracy_nm":0.05,"redundancy":true,"tcas_health":true}},{"flight_id":{"id":""}}]}}`)
+			f, err := ParseFrame(line)
+			if err != nil {
+				t.Fatalf("ParseFrame: %v", err)
+			}
+			if f.Decoder != "adsc" || f.Message.Text != adscText {
+				t.Fatalf("frame = %+v", f)
+			}
+			report, err := Compare(reg, f)
+			if err != nil {
+				t.Fatalf("Compare: %v", err)
+			}
+			if got := strings.Join(report.Compared, " "); got != "latitude longitude altitude report_time_sec adsc_flight_id" {
+				t.Errorf("compared %s", got)
+			}
+			var got []string
+			for _, d := range report.Disagreements {
+				got = append(got, d.Field)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("disagreements = %+v, want %v", report.Disagreements, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareCPDLC(t *testing.T) {
+	reg := registry.Default()
+	decode := `,"arinc622":{"msg_type":"cpdlc_msg","crc_ok":true,"cpdlc":{"atc_downlink_msg":{"header":{"msg_id":8,"timestamp":{"hour":15,"min":56,"sec":32}},"msg_data":{"msg_elements":[{"msg_element":{"choice_label":"POSITION REPORT","choice":"dM48PositionReport"}}]}}}}`
+
+	// The same message in an HFDL frame.
+	line := `{"hfdl":{"t":{"sec":1706090500,"usec":0},"freq":8927000,"lpdu":{"err":false,"src":{"type":"Aircraft","id":15},"dst":{"type":"Ground station","id":2},"type":{"id":49},"hfnpdu":{"err":false,"type":{"id":255},"acars":{"err":false,"crc_ok":true,"reg":".HL8251","label":"AA","msg_text":"` +
+		cpdlcText + `"` + decode + `}}}}}`
+	f, err := ParseFrame([]byte(line))
+	if err != nil {
+		t.Fatalf("ParseFrame: %v", err)
+	}
+	report, err := Compare(reg, f)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if len(report.Disagreements) != 0 {
+		t.Errorf("disagreements = %+v", report.Disagreements)
+	}
+	if got := strings.Join(report.Compared, " "); !strings.Contains(got, "header.msg_id") || !strings.HasSuffix(got, ElementsField) {
+		t.Errorf("compared %s", got)
+	}
+
+	f, err = ParseFrame(vdl2Frame("AA", cpdlcText, strings.Replace(decode, "dM48", "dM49", 1)))
+	if err != nil {
+		t.Fatalf("ParseFrame: %v", err)
+	}
+	report, err = Compare(reg, f)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if len(report.Disagreements) != 1 || report.Disagreements[0].Field != ElementsField ||
+		report.Disagreements[0].Ours != "dM48" || report.Disagreements[0].Libacars != "dM49" {
+		t.Errorf("disagreements = %+v, want dM48 against dM49", report.Disagreements)
+	}
+}
+
+func TestCompareSkipped(t *testing.T) {
+	reg := registry.Default()
+
+	f, err := ParseFrame(vdl2Frame("B6", adscText, `,"arinc622":{"crc_ok":false,"adsc":{"tags":[]}}`))
+	if err != nil {
+		t.Fatalf("ParseFrame: %v", err)
+	}
+	if report, err := Compare(reg, f); report != nil || err != nil {
+		t.Errorf("CRC failure compared: %+v, %v", report, err)
+	}
+
+	f, err = ParseFrame(vdl2Frame("MA", "T02!<~abc", `,"miam":{"single_transfer":{}}`))
+	if err != nil {
+		t.Fatalf("ParseFrame: %v", err)
+	}
+	if !f.MIAM || f.Decoder != "" {
+		t.Errorf("MIAM frame = %+v", f)
+	}
+
+	if _, err := ParseFrame([]byte(`{"vdl2":{"t":{"sec":0},"avlc":{"frame_type":"S"}}}`)); !errors.Is(err, ErrNoMessage) {
+		t.Errorf("frame without ACARS: err = %v, want ErrNoMessage", err)
+	}
+}
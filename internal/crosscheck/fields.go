@@ -0,0 +1,57 @@
+package crosscheck
+
+// Field maps a field of one of our results to the libacars field it is
+// compared with.
+type Field struct {
+	Decoder string // Parser name: "adsc" or "cpdlc".
+	Name    string // Dotted path in our result's JSON.
+
+	// Ref is the path in libacars' JSON decode, matched as a suffix of the
+	// object keys leading to a value (array indices are not part of a path).
+	// The first match is taken, so tag names make a path unique, e.g.
+	// "basic_report.lat" is the latitude of the basic report.
+	Ref string
+
+	// Tolerance is the largest difference between numbers that is not a
+	// disagreement. libacars writes some values rounded, and resolutions
+	// differ between fields, so each field has its own.
+	Tolerance float64
+}
+
+// Fields are the fields compared. A field libacars did not write, such as a
+// tag the message does not carry, is not compared. A field it wrote that we
+// did not is compared as zero (our results omit empty values).
+var Fields = []Field{
+	// ADS-C basic report.
+	{Decoder: "adsc", Name: "latitude", Ref: "basic_report.lat", Tolerance: 0.0005},
+	{Decoder: "adsc", Name: "longitude", Ref: "basic_report.lon", Tolerance: 0.0005},
+	{Decoder: "adsc", Name: "altitude", Ref: "basic_report.alt", Tolerance: 4},
+	{Decoder: "adsc", Name: "report_time_sec", Ref: "basic_report.ts_sec", Tolerance: 0.125},
+
+	// ADS-C identification.
+	{Decoder: "adsc", Name: "adsc_flight_id", Ref: "flight_id.id"},
+	{Decoder: "adsc", Name: "airframe_id", Ref: "airframe_id.icao_addr"},
+
+	// ADS-C earth and air reference.
+	{Decoder: "adsc", Name: "earth_ref.track_deg", Ref: "earth_ref_data.true_trk_deg", Tolerance: 0.1},
+	{Decoder: "adsc", Name: "earth_ref.ground_speed_kts", Ref: "earth_ref_data.gnd_spd_kts", Tolerance: 0.5},
+	{Decoder: "adsc", Name: "earth_ref.vert_speed_fpm", Ref: "earth_ref_data.vert_spd_ftmin", Tolerance: 16},
+	{Decoder: "adsc", Name: "air_ref.heading_deg", Ref: "air_ref_data.true_hdg_deg", Tolerance: 0.1},
+	{Decoder: "adsc", Name: "air_ref.mach", Ref: "air_ref_data.spd_mach", Tolerance: 0.001},
+	{Decoder: "adsc", Name: "air_ref.vert_speed_fpm", Ref: "air_ref_data.vert_spd_ftmin", Tolerance: 16},
+
+	// ADS-C meteorological data.
+	{Decoder: "adsc", Name: "meteo.wind_speed_kts", Ref: "meteo_data.wind_spd_kts", Tolerance: 0.5},
+	{Decoder: "adsc", Name: "meteo.wind_direction_deg", Ref: "meteo_data.wind_dir_true_deg", Tolerance: 0.5},
+	{Decoder: "adsc", Name: "meteo.temperature_c", Ref: "meteo_data.temp_c", Tolerance: 0.25},
+
+	// CPDLC header. The elements are compared by ID (see Compare).
+	{Decoder: "cpdlc", Name: "header.msg_id", Ref: "header.msg_id"},
+	{Decoder: "cpdlc", Name: "header.msg_ref", Ref: "header.msg_ref"},
+	{Decoder: "cpdlc", Name: "header.timestamp.hours", Ref: "header.timestamp.hour"},
+	{Decoder: "cpdlc", Name: "header.timestamp.minutes", Ref: "header.timestamp.min"},
+	{Decoder: "cpdlc", Name: "header.timestamp.seconds", Ref: "header.timestamp.sec"},
+}
+
+// ElementsField is the name under which CPDLC element IDs are reported.
+const ElementsField = "elements"
@@ -0,0 +1,184 @@
+// Package dedup suppresses copies of an ACARS message received by more than one
+// ground station, or on both VHF and satellite, so that each downlink updates
+// state once.
+package dedup
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"acars_parser/internal/acars"
+)
+
+// DefaultWindow is how long a message is remembered. Copies of one downlink
+// relayed through different stations and networks arrive within seconds of
+// each other; satellite copies can lag VHF by up to a minute.
+const DefaultWindow = time.Minute
+
+// Stats counts the messages a Filter has checked.
+type Stats struct {
+	Checked    int
+	Suppressed int
+	Feeders    map[string]FeederStats // By feeder ID; "" for messages without one.
+}
+
+// FeederStats counts the messages received from one feeder. First is the
+// number it delivered before any other feeder: its contribution to the merged
+// feed. Messages minus First is the number it duplicated.
+type FeederStats struct {
+	Messages int
+	First    int
+}
+
+type entry struct {
+	key  uint64
+	seen time.Time
+}
+
+// copies is a remembered message: when it was first seen, and the feeders
+// that have delivered a copy, first deliverer first.
+type copies struct {
+	first   time.Time
+	feeders []string
+}
+
+// Filter reports messages whose tail, label and text match a message seen
+// within the window. The window is measured from the first copy, so a message
+// that is genuinely repeated later (e.g. a periodic report with unchanged text)
+// is passed again once the window has elapsed. It is safe for concurrent use.
+type Filter struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[uint64]*copies
+	queue  []entry // Ordered by time seen, for expiry.
+	stats  Stats
+}
+
+// New returns a Filter that remembers messages for window. A window that is
+// not positive takes DefaultWindow; commands that let dedup be disabled with
+// a zero window check for it before calling New.
+func New(window time.Duration) *Filter {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Filter{
+		window: window,
+		seen:   make(map[uint64]*copies),
+		stats:  Stats{Feeders: make(map[string]FeederStats)},
+	}
+}
+
+// Duplicate records a message received at ts and reports whether a copy of it
+// was already seen within the window. Timestamps should be roughly in order;
+// a copy that arrives before the first one is still treated as a duplicate.
+// The message's feeder (see acars.Message.FeederID) is recorded as having
+// heard it.
+func (f *Filter) Duplicate(msg *acars.Message, ts time.Time) bool {
+	key := Key(msg)
+	feeder := msg.FeederID()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stats.Checked++
+	fs := f.stats.Feeders[feeder]
+	fs.Messages++
+	f.expire(ts)
+
+	if c, ok := f.seen[key]; ok && ts.Sub(c.first) < f.window {
+		f.stats.Suppressed++
+		f.stats.Feeders[feeder] = fs
+		if !contains(c.feeders, feeder) {
+			c.feeders = append(c.feeders, feeder)
+		}
+		return true
+	}
+	fs.First++
+	f.stats.Feeders[feeder] = fs
+	f.seen[key] = &copies{first: ts, feeders: []string{feeder}}
+	f.queue = append(f.queue, entry{key: key, seen: ts})
+	return false
+}
+
+// HeardBy returns the feeders that have delivered a copy of a message within
+// the window, first deliverer first, or nil if it is not remembered.
+func (f *Filter) HeardBy(msg *acars.Message) []string {
+	key := Key(msg)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.seen[key]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), c.feeders...)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// expire forgets messages first seen more than a window before ts.
+func (f *Filter) expire(ts time.Time) {
+	cutoff := ts.Add(-f.window)
+	n := 0
+	for n < len(f.queue) && !f.queue[n].seen.After(cutoff) {
+		e := f.queue[n]
+		// The key may have been seen again since; only drop the matching entry.
+		if c := f.seen[e.key]; c != nil && c.first.Equal(e.seen) {
+			delete(f.seen, e.key)
+		}
+		n++
+	}
+	if n > 0 {
+		f.queue = append(f.queue[:0], f.queue[n:]...)
+	}
+}
+
+// Len returns the number of messages currently remembered.
+func (f *Filter) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.seen)
+}
+
+// Stats returns the number of messages checked and suppressed, in total and
+// by feeder.
+func (f *Filter) Stats() Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.stats
+	s.Feeders = make(map[string]FeederStats, len(f.stats.Feeders))
+	for k, v := range f.stats.Feeders {
+		s.Feeders[k] = v
+	}
+	return s
+}
+
+// Key hashes the fields that identify a downlink regardless of the station
+// or frequency it was received on: the tail, label and text. The tail is
+// taken from the airframe when the message has none, and leading dots and
+// surrounding whitespace are ignored.
+func Key(msg *acars.Message) uint64 {
+	tail := msg.Tail
+	if tail == "" && msg.Airframe != nil {
+		tail = msg.Airframe.Tail
+	}
+	tail = strings.TrimLeft(strings.ToUpper(strings.TrimSpace(tail)), ".")
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(tail))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(msg.Label))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(strings.TrimSpace(msg.Text)))
+	return h.Sum64()
+}
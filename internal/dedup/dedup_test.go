@@ -0,0 +1,105 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+)
+
+func TestDuplicate(t *testing.T) {
+	f := New(30 * time.Second)
+	base := time.Date(2026, 1, 30, 10, 0, 0, 0, time.UTC)
+
+	msg := &acars.Message{Tail: "VH-OQA", Label: "H1", Text: "#M1BPOSN33456W084123,ATL,100000"}
+	vhf := *msg
+	vhf.Frequency = 131.55
+	satcom := *msg
+	satcom.Tail = ".VH-OQA"
+	satcom.Station = &acars.Station{Ident: "SAT"}
+
+	if f.Duplicate(&vhf, base) {
+		t.Fatal("first copy reported as duplicate")
+	}
+	if !f.Duplicate(&satcom, base.Add(10*time.Second)) {
+		t.Error("copy from another station within the window not suppressed")
+	}
+
+	other := *msg
+	other.Label = "H2"
+	if f.Duplicate(&other, base.Add(11*time.Second)) {
+		t.Error("message with a different label suppressed")
+	}
+
+	// The window runs from the first copy.
+	if f.Duplicate(msg, base.Add(31*time.Second)) {
+		t.Error("repeat after the window suppressed")
+	}
+
+	s := f.Stats()
+	if s.Checked != 4 || s.Suppressed != 1 {
+		t.Errorf("Stats() = %+v, want 4 checked, 1 suppressed", s)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	f := New(time.Minute)
+	base := time.Date(2026, 1, 30, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 10; i++ {
+		f.Duplicate(&acars.Message{Tail: "N123AB", Label: "_d", Text: string(rune('A' + i))}, base.Add(time.Duration(i)*time.Second))
+	}
+	if f.Len() != 10 {
+		t.Fatalf("Len() = %d, want 10", f.Len())
+	}
+
+	f.Duplicate(&acars.Message{Tail: "N123AB", Label: "_d", Text: "Z"}, base.Add(65*time.Second))
+	if f.Len() != 5 {
+		t.Errorf("Len() after expiry = %d, want 5", f.Len())
+	}
+}
+
+func TestKeyUsesAirframeTail(t *testing.T) {
+	a := &acars.Message{Tail: "N123AB", Label: "10", Text: "POS"}
+	b := &acars.Message{Airframe: &acars.Airframe{Tail: "N123AB"}, Label: "10", Text: "POS "}
+	if Key(a) != Key(b) {
+		t.Error("tail from airframe and trailing space should not change the key")
+	}
+
+	c := &acars.Message{Tail: "N123AC", Label: "10", Text: "POS"}
+	if Key(a) == Key(c) {
+		t.Error("different tails produced the same key")
+	}
+}
+
+func TestFeeders(t *testing.T) {
+	f := New(time.Minute)
+	base := time.Date(2026, 1, 30, 10, 0, 0, 0, time.UTC)
+
+	msg := acars.Message{Tail: "VH-OQA", Label: "H1", Text: "POS", Feeder: "site-a"}
+	if f.HeardBy(&msg) != nil {
+		t.Error("HeardBy() before the message was seen should be nil")
+	}
+	f.Duplicate(&msg, base)
+
+	b := msg
+	b.Feeder = ""
+	b.Station = &acars.Station{ID: "YMML1"}
+	f.Duplicate(&b, base.Add(2*time.Second))
+	f.Duplicate(&msg, base.Add(3*time.Second))
+
+	other := acars.Message{Tail: "VH-OQB", Label: "H1", Text: "POS", Station: &acars.Station{ID: "YMML1"}}
+	f.Duplicate(&other, base.Add(4*time.Second))
+
+	if got := f.HeardBy(&msg); len(got) != 2 || got[0] != "site-a" || got[1] != "YMML1" {
+		t.Errorf("HeardBy() = %v, want [site-a YMML1]", got)
+	}
+
+	s := f.Stats()
+	if a := s.Feeders["site-a"]; a != (FeederStats{Messages: 2, First: 1}) {
+		t.Errorf("site-a = %+v, want 2 messages, 1 first", a)
+	}
+	if m := s.Feeders["YMML1"]; m != (FeederStats{Messages: 2, First: 1}) {
+		t.Errorf("YMML1 = %+v, want 2 messages, 1 first", m)
+	}
+}
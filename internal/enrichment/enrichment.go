@@ -4,9 +4,11 @@ package enrichment
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 	"time"
 
+	"acars_parser/internal/airport"
 	"acars_parser/internal/extractor"
 	"acars_parser/internal/registry"
 	"acars_parser/internal/storage"
@@ -20,23 +22,30 @@ import (
 //   - callsign: The flight number/callsign (from message or envelope)
 //   - timestamp: Message timestamp for determining flight date
 //   - results: Parsed results from the registry
+//
+// The flight is dated by its scheduled departure when a result gives one (see
+// FlightDates), and otherwise by the UTC date of the message.
 func ExtractEnrichment(icaoHex, callsign string, timestamp time.Time, results []registry.Result) *storage.FlightEnrichmentUpdate {
 	if icaoHex == "" {
 		return nil // Can't enrich without aircraft identifier
 	}
+	update := newUpdate(icaoHex, callsign, timestamp)
 
-	// Calculate flight date (UTC midnight).
-	flightDate := time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, time.UTC)
-
-	update := &storage.FlightEnrichmentUpdate{
-		ICAOHex:    strings.ToUpper(icaoHex),
-		Callsign:   extractor.NormaliseFlightNumber(callsign),
-		FlightDate: flightDate,
+	// Process each parsed result, noting the parsers that give enrichment.
+	var parsers []string
+	for _, result := range results {
+		if extractFromResult(update, result) {
+			parsers = append(parsers, result.Type())
+		}
 	}
+	update.Parser = strings.Join(parsers, ",")
 
-	// Process each parsed result.
-	for _, result := range results {
-		extractFromResult(update, result)
+	if update.ScheduledDeparture != nil {
+		origin := ""
+		if update.Origin != nil {
+			origin = *update.Origin
+		}
+		update.FlightDate, update.FlightDateUTC = FlightDates(*update.ScheduledDeparture, origin)
 	}
 
 	// If no callsign found, can't create a useful enrichment record.
@@ -52,12 +61,36 @@ func ExtractEnrichment(icaoHex, callsign string, timestamp time.Time, results []
 	return update
 }
 
-// extractFromResult extracts enrichment fields from a single parser result.
-func extractFromResult(update *storage.FlightEnrichmentUpdate, result registry.Result) {
+// NewUpdate returns an empty update for the flight, dated by the message
+// timestamp, for enrichment found other than in parse results. It returns nil
+// when the ICAO hex or callsign is empty.
+func NewUpdate(icaoHex, callsign string, timestamp time.Time) *storage.FlightEnrichmentUpdate {
+	if icaoHex == "" || extractor.NormaliseFlightNumber(callsign) == "" {
+		return nil
+	}
+	return newUpdate(icaoHex, callsign, timestamp)
+}
+
+// newUpdate returns an update for the flight dated by the UTC date of the
+// message.
+func newUpdate(icaoHex, callsign string, timestamp time.Time) *storage.FlightEnrichmentUpdate {
+	flightDate := dateOf(timestamp.UTC())
+	return &storage.FlightEnrichmentUpdate{
+		ICAOHex:       strings.ToUpper(icaoHex),
+		Callsign:      extractor.NormaliseFlightNumber(callsign),
+		FlightDate:    flightDate,
+		FlightDateUTC: flightDate,
+		MessageTime:   timestamp.UTC(),
+	}
+}
+
+// extractFromResult extracts enrichment fields from a single parser result. It
+// reports whether the result is of a type that gives enrichment.
+func extractFromResult(update *storage.FlightEnrichmentUpdate, result registry.Result) bool {
 	// Convert result to map for generic field access.
 	data := resultToMap(result)
 	if data == nil {
-		return
+		return false
 	}
 
 	// Try to extract callsign from various field names.
@@ -70,13 +103,25 @@ func extractFromResult(update *storage.FlightEnrichmentUpdate, result registry.R
 	switch result.Type() {
 	case "pdc":
 		extractPDC(update, data)
+		if dep, ok := DepartureTime(getStringField(data, "departure_time"), update.MessageTime); ok {
+			update.ScheduledDeparture = &dep
+		}
 	case "flight_plan":
 		extractFlightPlan(update, data)
 	case "loadsheet":
 		extractLoadsheet(update, data)
-	case "eta":
+	case "eta", "label44":
 		extractETA(update, data)
+	case "takeoff_performance":
+		extractTakeoffPerformance(update, data)
+	case "takeoff_data":
+		extractTakeoffData(update, data)
+	case "oceanic_clearance":
+		extractOceanicClearance(update, data)
+	default:
+		return false
 	}
+	return true
 }
 
 // extractPDC extracts enrichment data from a PDC (Pre-Departure Clearance) result.
@@ -109,6 +154,14 @@ func extractPDC(update *storage.FlightEnrichmentUpdate, data map[string]interfac
 			update.Route = route
 		}
 	}
+
+	// Resolved SID (only present when procedure data is loaded).
+	if proc := getProcedure(data, "sid_procedure"); proc != nil {
+		update.SIDWaypoints = proc.waypoints
+		if update.DepartureRunway == nil && len(proc.runways) == 1 {
+			update.DepartureRunway = &proc.runways[0]
+		}
+	}
 }
 
 // extractFlightPlan extracts enrichment data from an FPN (Flight Plan) result.
@@ -119,6 +172,26 @@ func extractFlightPlan(update *storage.FlightEnrichmentUpdate, data map[string]i
 	if v := getStringField(data, "destination"); v != "" {
 		update.Destination = &v
 	}
+	if v := getStringField(data, "departure"); v != "" {
+		update.SID = &v
+	}
+	if v := getStringField(data, "arrival"); v != "" {
+		update.STAR = &v
+	}
+
+	// Resolved SID and STAR (only present when procedure data is loaded).
+	if proc := getProcedure(data, "departure_procedure"); proc != nil {
+		update.SIDWaypoints = proc.waypoints
+		if len(proc.runways) == 1 {
+			update.DepartureRunway = &proc.runways[0]
+		}
+	}
+	if proc := getProcedure(data, "arrival_procedure"); proc != nil {
+		update.STARWaypoints = proc.waypoints
+		if len(proc.runways) == 1 {
+			update.ArrivalRunway = &proc.runways[0]
+		}
+	}
 
 	// Extract waypoints as route.
 	if waypoints, ok := data["waypoints"].([]interface{}); ok && len(waypoints) > 0 {
@@ -151,10 +224,75 @@ func extractLoadsheet(update *storage.FlightEnrichmentUpdate, data map[string]in
 		update.PaxCount = &paxInt
 	}
 
-	// TODO: Extract pax_breakdown when loadsheet parser supports class breakdown.
+	// Extract the passenger split by cabin class.
+	if breakdown, ok := data["pax_breakdown"].(map[string]interface{}); ok {
+		classes := make(map[string]int, len(breakdown))
+		for class, v := range breakdown {
+			if n, ok := v.(float64); ok && n > 0 {
+				classes[class] = int(n)
+			}
+		}
+		if len(classes) > 0 {
+			update.PaxBreakdown = classes
+		}
+	}
+}
+
+// extractTakeoffPerformance extracts the departure runway from a takeoff
+// performance request or response.
+func extractTakeoffPerformance(update *storage.FlightEnrichmentUpdate, data map[string]interface{}) {
+	if v := getStringField(data, "runway"); v != "" {
+		update.DepartureRunway = &v
+	}
 }
 
-// extractETA extracts enrichment data from an ETA result.
+// extractTakeoffData extracts the departure runway from takeoff data, when
+// it was computed for a single runway.
+func extractTakeoffData(update *storage.FlightEnrichmentUpdate, data map[string]interface{}) {
+	runways, ok := data["runways"].([]interface{})
+	if !ok || len(runways) != 1 {
+		return
+	}
+	if rwy, ok := runways[0].(map[string]interface{}); ok {
+		if v := getStringField(rwy, "runway"); v != "" {
+			update.DepartureRunway = &v
+		}
+	}
+}
+
+// extractOceanicClearance extracts the destination and the oceanic clearance
+// from an oceanic clearance result. Its route is kept with the clearance
+// rather than as the flight's route, of which it is only the oceanic part.
+func extractOceanicClearance(update *storage.FlightEnrichmentUpdate, data map[string]interface{}) {
+	if v := getStringField(data, "destination"); v != "" {
+		update.Destination = &v
+	}
+
+	c := &storage.OceanicClearance{
+		Track:      getStringField(data, "track"),
+		Route:      getStringSlice(data, "route"),
+		EntryPoint: getStringField(data, "entry_point"),
+	}
+	if len(c.Route) == 0 {
+		// A clearance naming only the track is cleared along its points.
+		c.Route = getStringSlice(data, "track_points")
+	}
+	if t, ok := DepartureTime(getStringField(data, "entry_time"), update.MessageTime); ok {
+		c.EntryTime = &t
+	}
+	if fl, err := strconv.Atoi(strings.TrimPrefix(getStringField(data, "flight_level"), "FL")); err == nil {
+		c.FlightLevel = fl
+	}
+	if m, err := strconv.Atoi(strings.TrimPrefix(getStringField(data, "mach"), "M")); err == nil {
+		c.Mach = float64(m) / 100
+	}
+	if c.Track != "" || len(c.Route) > 0 || c.FlightLevel > 0 || c.Mach > 0 {
+		update.OceanicClearance = c
+	}
+}
+
+// extractETA extracts enrichment data from an ETA result, or a Label 44
+// position, ETA or OOOI report.
 func extractETA(update *storage.FlightEnrichmentUpdate, data map[string]interface{}) {
 	if v := getStringField(data, "origin"); v != "" {
 		update.Origin = &v
@@ -163,8 +301,54 @@ func extractETA(update *storage.FlightEnrichmentUpdate, data map[string]interfac
 		update.Destination = &v
 	}
 
-	// TODO: Parse and store ETA time when we have a consistent format.
-	// The ETA field in eta.Result is a string like "1830" (HHMM).
+	// The ETA itself is fused with those of other sources by the state
+	// tracker (see state.FuseETAs), rather than taken from one report.
+}
+
+// departureWindow is how far a departure time may be from the message that
+// gives it: an HHMM time is taken on whichever day puts it nearest.
+const departureWindow = 12 * time.Hour
+
+// DepartureTime returns the instant of a scheduled departure given as an
+// HHMM or HH:MM UTC time of day, as clearances give it, taking the day that
+// puts it within 12 hours of the message timestamp. It returns false for an
+// empty or invalid time.
+func DepartureTime(hhmm string, timestamp time.Time) (time.Time, bool) {
+	hhmm = strings.TrimSuffix(strings.ReplaceAll(strings.TrimSpace(hhmm), ":", ""), "Z")
+	if len(hhmm) != 4 || timestamp.IsZero() {
+		return time.Time{}, false
+	}
+	h, err1 := strconv.Atoi(hhmm[:2])
+	m, err2 := strconv.Atoi(hhmm[2:])
+	if err1 != nil || err2 != nil || h > 23 || m > 59 {
+		return time.Time{}, false
+	}
+
+	ts := timestamp.UTC()
+	dep := time.Date(ts.Year(), ts.Month(), ts.Day(), h, m, 0, 0, time.UTC)
+	if d := dep.Sub(ts); d > departureWindow {
+		dep = dep.AddDate(0, 0, -1)
+	} else if d <= -departureWindow {
+		dep = dep.AddDate(0, 0, 1)
+	}
+	return dep, true
+}
+
+// FlightDates returns the date of a flight departing at departure: local to
+// the origin airport when the airport table gives its time zone, and in UTC
+// otherwise. The second date is always the UTC date. Dates are returned as
+// midnight UTC, as the flight_date column stores them.
+func FlightDates(departure time.Time, origin string) (local, utc time.Time) {
+	utc = dateOf(departure.UTC())
+	if loc, ok := airport.Location(origin); ok {
+		return dateOf(departure.In(loc)), utc
+	}
+	return utc, utc
+}
+
+// dateOf returns the calendar date of t, in t's location, as midnight UTC.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
 // resultToMap converts a registry.Result to a map via JSON for generic field access.
@@ -190,9 +374,44 @@ func getStringField(data map[string]interface{}, keys ...string) string {
 	return ""
 }
 
+// procedureFields holds the fields of a resolved SID/STAR in a result map.
+type procedureFields struct {
+	runways   []string
+	waypoints []string
+}
+
+// getProcedure returns the resolved procedure stored under key, or nil.
+func getProcedure(data map[string]interface{}, key string) *procedureFields {
+	m, ok := data[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	proc := &procedureFields{
+		runways:   getStringSlice(m, "runways"),
+		waypoints: getStringSlice(m, "waypoints"),
+	}
+	if len(proc.runways) == 0 && len(proc.waypoints) == 0 {
+		return nil
+	}
+	return proc
+}
+
+// getStringSlice returns the non-empty strings of an array field.
+func getStringSlice(data map[string]interface{}, key string) []string {
+	items, _ := data[key].([]interface{})
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 // hasEnrichmentData checks if the update has any enrichable data beyond the key fields.
 func hasEnrichmentData(u *storage.FlightEnrichmentUpdate) bool {
-	return u.Origin != nil || u.Destination != nil || len(u.Route) > 0 ||
+	return u.Origin != nil || u.Destination != nil || len(u.Route) > 0 || u.ScheduledDeparture != nil ||
 		u.ETA != nil || u.DepartureRunway != nil || u.ArrivalRunway != nil || u.SID != nil || u.Squawk != nil ||
-		u.PaxCount != nil || len(u.PaxBreakdown) > 0
-}
\ No newline at end of file
+		u.STAR != nil || len(u.SIDWaypoints) > 0 || len(u.STARWaypoints) > 0 ||
+		u.PaxCount != nil || len(u.PaxBreakdown) > 0 || u.OceanicClearance != nil
+}
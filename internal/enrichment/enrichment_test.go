@@ -4,7 +4,9 @@ import (
 	"testing"
 	"time"
 
+	"acars_parser/internal/airport"
 	"acars_parser/internal/extractor"
+	"acars_parser/internal/parsers/labelb2"
 	"acars_parser/internal/registry"
 )
 
@@ -17,6 +19,7 @@ type mockPDCResult struct {
 	SID          string   `json:"sid,omitempty"`
 	Squawk       string   `json:"squawk,omitempty"`
 	RouteWpts    []string `json:"route_waypoints,omitempty"`
+	DepTime      string   `json:"departure_time,omitempty"`
 }
 
 func (r *mockPDCResult) Type() string     { return "pdc" }
@@ -43,6 +46,8 @@ type mockLoadsheetResult struct {
 	Origin      string `json:"origin,omitempty"`
 	Destination string `json:"destination,omitempty"`
 	PAX         int    `json:"pax,omitempty"`
+
+	PaxBreakdown map[string]int `json:"pax_breakdown,omitempty"`
 }
 
 func (r *mockLoadsheetResult) Type() string     { return "loadsheet" }
@@ -115,6 +120,102 @@ func TestExtractFromFlightPlan(t *testing.T) {
 	}
 }
 
+func TestExtractFlightPlanProcedures(t *testing.T) {
+	timestamp := time.Date(2026, 1, 27, 14, 30, 0, 0, time.UTC)
+
+	fpnResult := &mockFPNProcedureResult{
+		FlightNum: "AAL123",
+		Departure: "KATZZ2",
+		Arrival:   "ANJLL4",
+		DepartureProcedure: &mockProcedure{
+			Ident:     "KATZZ2",
+			Runways:   []string{"17R"},
+			Waypoints: []string{"DEBBB", "KATZZ", "HRPER"},
+		},
+		ArrivalProcedure: &mockProcedure{
+			Ident:     "ANJLL4",
+			Runways:   []string{"24L", "24R"},
+			Waypoints: []string{"SEAVU", "ANJLL"},
+		},
+	}
+
+	update := ExtractEnrichment("A1B2C3", "", timestamp, []registry.Result{fpnResult})
+	if update == nil {
+		t.Fatal("expected update, got nil")
+	}
+	if update.SID == nil || *update.SID != "KATZZ2" {
+		t.Errorf("sid = %v, want KATZZ2", update.SID)
+	}
+	if update.STAR == nil || *update.STAR != "ANJLL4" {
+		t.Errorf("star = %v, want ANJLL4", update.STAR)
+	}
+	if update.DepartureRunway == nil || *update.DepartureRunway != "17R" {
+		t.Errorf("departure runway = %v, want 17R", update.DepartureRunway)
+	}
+	// The STAR serves two runways, so the arrival runway stays unknown.
+	if update.ArrivalRunway != nil {
+		t.Errorf("arrival runway = %v, want nil", *update.ArrivalRunway)
+	}
+	if len(update.SIDWaypoints) != 3 || len(update.STARWaypoints) != 2 {
+		t.Errorf("sid/star waypoints = %v / %v", update.SIDWaypoints, update.STARWaypoints)
+	}
+}
+
+// mockFPNProcedureResult is a flight plan with resolved procedures.
+type mockFPNProcedureResult struct {
+	FlightNum          string         `json:"flight_num,omitempty"`
+	Departure          string         `json:"departure,omitempty"`
+	Arrival            string         `json:"arrival,omitempty"`
+	DepartureProcedure *mockProcedure `json:"departure_procedure,omitempty"`
+	ArrivalProcedure   *mockProcedure `json:"arrival_procedure,omitempty"`
+}
+
+type mockProcedure struct {
+	Ident     string   `json:"ident"`
+	Runways   []string `json:"runways,omitempty"`
+	Waypoints []string `json:"waypoints,omitempty"`
+}
+
+func (r *mockFPNProcedureResult) Type() string     { return "flight_plan" }
+func (r *mockFPNProcedureResult) MessageID() int64 { return 0 }
+
+func TestExtractOceanicClearance(t *testing.T) {
+	timestamp := time.Date(2026, 2, 16, 12, 59, 0, 0, time.UTC)
+
+	clx := &labelb2.Result{
+		FlightNum:   "DAL48",
+		Destination: "KJFK",
+		Track:       "E",
+		Route:       []string{"ELSIR", "50N020W", "51N030W", "52N040W", "51N050W", "ALLRY"},
+		EntryPoint:  "ELSIR",
+		EntryTime:   "1342",
+		FlightLevel: "FL350",
+		Mach:        "M83",
+	}
+
+	update := ExtractEnrichment("A1B2C3", "", timestamp, []registry.Result{clx})
+
+	if update == nil {
+		t.Fatal("expected update, got nil")
+	}
+	if update.Destination == nil || *update.Destination != "KJFK" {
+		t.Errorf("destination = %v, want KJFK", update.Destination)
+	}
+	if update.Route != nil {
+		t.Errorf("route = %v, want the oceanic route kept apart", update.Route)
+	}
+	c := update.OceanicClearance
+	if c == nil {
+		t.Fatal("expected oceanic clearance, got nil")
+	}
+	if c.Track != "E" || c.EntryPoint != "ELSIR" || c.FlightLevel != 350 || c.Mach != 0.83 || len(c.Route) != 6 {
+		t.Errorf("oceanic clearance = %+v", c)
+	}
+	if want := time.Date(2026, 2, 16, 13, 42, 0, 0, time.UTC); c.EntryTime == nil || !c.EntryTime.Equal(want) {
+		t.Errorf("entry time = %v, want %v", c.EntryTime, want)
+	}
+}
+
 func TestExtractFromLoadsheet(t *testing.T) {
 	timestamp := time.Date(2026, 1, 27, 14, 30, 0, 0, time.UTC)
 
@@ -123,6 +224,8 @@ func TestExtractFromLoadsheet(t *testing.T) {
 		Origin:      "YSSY",
 		Destination: "KDFW",
 		PAX:         459,
+
+		PaxBreakdown: map[string]int{"F": 14, "J": 70, "W": 35, "Y": 340},
 	}
 
 	update := ExtractEnrichment("7C6CA3", "", timestamp, []registry.Result{loadsheetResult})
@@ -136,6 +239,56 @@ func TestExtractFromLoadsheet(t *testing.T) {
 	if update.PaxCount == nil || *update.PaxCount != 459 {
 		t.Errorf("pax_count = %v, want 459", update.PaxCount)
 	}
+	if len(update.PaxBreakdown) != 4 || update.PaxBreakdown["J"] != 70 || update.PaxBreakdown["Y"] != 340 {
+		t.Errorf("pax_breakdown = %v", update.PaxBreakdown)
+	}
+}
+
+// mockTakeoffResult implements registry.Result for testing takeoff
+// performance extraction.
+type mockTakeoffResult struct {
+	MessageType string `json:"message_type"`
+	Runway      string `json:"runway,omitempty"`
+}
+
+func (r *mockTakeoffResult) Type() string     { return "takeoff_performance" }
+func (r *mockTakeoffResult) MessageID() int64 { return 0 }
+
+// mockTakeoffDataResult implements registry.Result for testing takeoff data
+// extraction.
+type mockTakeoffDataResult struct {
+	Runways []map[string]string `json:"runways,omitempty"`
+}
+
+func (r *mockTakeoffDataResult) Type() string     { return "takeoff_data" }
+func (r *mockTakeoffDataResult) MessageID() int64 { return 0 }
+
+func TestExtractTakeoffRunway(t *testing.T) {
+	timestamp := time.Date(2026, 1, 27, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		result registry.Result
+		want   string
+	}{
+		{"performance response", &mockTakeoffResult{MessageType: "response", Runway: "28R"}, "28R"},
+		{"takeoff data for one runway", &mockTakeoffDataResult{Runways: []map[string]string{{"runway": "10R"}}}, "10R"},
+		{"takeoff data for several runways", &mockTakeoffDataResult{Runways: []map[string]string{{"runway": "10R"}, {"runway": "10L"}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := ExtractEnrichment("A1B2C3", "UAL123", timestamp, []registry.Result{tt.result})
+			if tt.want == "" {
+				if update != nil {
+					t.Errorf("expected nil, got %+v", update)
+				}
+				return
+			}
+			if update == nil || update.DepartureRunway == nil || *update.DepartureRunway != tt.want {
+				t.Errorf("departure runway = %v, want %s", update, tt.want)
+			}
+		})
+	}
 }
 
 func TestExtractMergesMultipleResults(t *testing.T) {
@@ -231,4 +384,95 @@ func TestNormaliseFlightNumber(t *testing.T) {
 			t.Errorf("NormaliseFlightNumber(%q) = %q, want %q", tt.input, got, tt.want)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestDepartureTime(t *testing.T) {
+	ts := time.Date(2026, 1, 27, 23, 40, 0, 0, time.UTC)
+	tests := []struct {
+		hhmm string
+		want time.Time
+		ok   bool
+	}{
+		{"2355", time.Date(2026, 1, 27, 23, 55, 0, 0, time.UTC), true},
+		{"0015Z", time.Date(2026, 1, 28, 0, 15, 0, 0, time.UTC), true}, // After midnight.
+		{"12:30", time.Date(2026, 1, 27, 12, 30, 0, 0, time.UTC), true},
+		{"1130", time.Date(2026, 1, 28, 11, 30, 0, 0, time.UTC), true}, // Nearer tomorrow.
+		{"2460", time.Time{}, false},
+		{"930", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := DepartureTime(tt.hhmm, ts)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("DepartureTime(%q) = %v, %v; want %v, %v", tt.hhmm, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFlightDateFromDeparture(t *testing.T) {
+	tbl := airport.NewTable()
+	if err := tbl.Add(airport.Airport{ICAO: "YSSY", IATA: "SYD", TZ: "Australia/Sydney"}); err != nil {
+		t.Fatal(err)
+	}
+	airport.SetDefault(tbl)
+	t.Cleanup(func() { airport.SetDefault(nil) })
+
+	jan27 := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	jan28 := time.Date(2026, 1, 28, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		origin    string
+		depTime   string
+		ts        time.Time
+		local     time.Time
+		utc       time.Time
+		departure bool
+	}{
+		// 13:30Z is 00:30 the next day in Sydney (UTC+11).
+		{"origin local", "YSSY", "1330", time.Date(2026, 1, 27, 13, 0, 0, 0, time.UTC), jan28, jan27, true},
+		// A clearance before midnight UTC for a departure after it.
+		{"red-eye", "KJFK", "0010", time.Date(2026, 1, 27, 23, 50, 0, 0, time.UTC), jan28, jan28, true},
+		{"no departure", "YSSY", "", time.Date(2026, 1, 27, 13, 0, 0, 0, time.UTC), jan27, jan27, false},
+	}
+	for _, tt := range tests {
+		update := ExtractEnrichment("7C6CA3", "QF1", tt.ts, []registry.Result{
+			&mockPDCResult{Origin: tt.origin, DepTime: tt.depTime},
+		})
+		if update == nil {
+			t.Fatalf("%s: no update", tt.name)
+		}
+		if !update.FlightDate.Equal(tt.local) || !update.FlightDateUTC.Equal(tt.utc) {
+			t.Errorf("%s: dates = %s, %s; want %s, %s", tt.name,
+				update.FlightDate.Format("2006-01-02"), update.FlightDateUTC.Format("2006-01-02"),
+				tt.local.Format("2006-01-02"), tt.utc.Format("2006-01-02"))
+		}
+		if (update.ScheduledDeparture != nil) != tt.departure {
+			t.Errorf("%s: scheduled departure = %v", tt.name, update.ScheduledDeparture)
+		}
+		if !update.MessageTime.Equal(tt.ts) {
+			t.Errorf("%s: message time = %v", tt.name, update.MessageTime)
+		}
+	}
+}
+
+func TestExtractEnrichmentParser(t *testing.T) {
+	ts := time.Date(2026, 1, 27, 10, 0, 0, 0, time.UTC)
+	update := ExtractEnrichment("7C6CA3", "QF1", ts, []registry.Result{
+		&mockPDCResult{Origin: "YSSY"},
+		&mockMETARResult{Station: "YSSY"},
+		&mockFPNResult{Destination: "KDFW"},
+	})
+	if update == nil {
+		t.Fatal("no update")
+	}
+	if update.Parser != "pdc,flight_plan" {
+		t.Errorf("parser = %q, want pdc,flight_plan", update.Parser)
+	}
+}
+
+// mockMETARResult implements registry.Result for a type that gives no enrichment.
+type mockMETARResult struct {
+	Station string `json:"station"`
+}
+
+func (r *mockMETARResult) Type() string     { return "metar" }
+func (r *mockMETARResult) MessageID() int64 { return 0 }
@@ -0,0 +1,61 @@
+// Package envflag reads command-line flag defaults from environment
+// variables, so that a container can be configured without a long argument
+// list. A flag given on the command line still takes precedence over the
+// environment, and the environment over the built-in default.
+//
+// A variable that is set but cannot be parsed is reported on stderr and the
+// built-in default is used, rather than silently ignored.
+package envflag
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Value returns the environment variable key parsed with fn, or def when it
+// is unset or empty. It serves flag types with their own syntax.
+func Value[T any](key string, def T, fn func(string) (T, error)) T {
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+	v, err := fn(s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring %s=%q: %v\n", key, s, err)
+		return def
+	}
+	return v
+}
+
+// String returns the value of the environment variable key, or def when it
+// is unset or empty.
+func String(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// Int returns the environment variable key as an integer, or def.
+func Int(key string, def int) int {
+	return Value(key, def, strconv.Atoi)
+}
+
+// Float64 returns the environment variable key as a number, or def.
+func Float64(key string, def float64) float64 {
+	return Value(key, def, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+}
+
+// Bool returns the environment variable key as a boolean (1, t, true, 0, f,
+// false and so on, as strconv.ParseBool), or def.
+func Bool(key string, def bool) bool {
+	return Value(key, def, strconv.ParseBool)
+}
+
+// Duration returns the environment variable key as a duration such as "90s",
+// or def.
+func Duration(key string, def time.Duration) time.Duration {
+	return Value(key, def, time.ParseDuration)
+}
@@ -0,0 +1,41 @@
+package envflag
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaults(t *testing.T) {
+	t.Setenv("ENVFLAG_HOST", "db")
+	t.Setenv("ENVFLAG_PORT", "5433")
+	t.Setenv("ENVFLAG_BAD_PORT", "five")
+	t.Setenv("ENVFLAG_WINDOW", "90s")
+	t.Setenv("ENVFLAG_RETAIN", "true")
+	t.Setenv("ENVFLAG_QUALITY", "0.5")
+	t.Setenv("ENVFLAG_EMPTY", "")
+
+	if got := String("ENVFLAG_HOST", "localhost"); got != "db" {
+		t.Errorf("String = %q, want db", got)
+	}
+	if got := String("ENVFLAG_EMPTY", "localhost"); got != "localhost" {
+		t.Errorf("String of empty = %q, want the default", got)
+	}
+	if got := Int("ENVFLAG_PORT", 5432); got != 5433 {
+		t.Errorf("Int = %d, want 5433", got)
+	}
+	if got := Int("ENVFLAG_BAD_PORT", 5432); got != 5432 {
+		t.Errorf("Int of unparsable = %d, want the default", got)
+	}
+	if got := Int("ENVFLAG_UNSET", 7); got != 7 {
+		t.Errorf("Int of unset = %d, want the default", got)
+	}
+	if got := Duration("ENVFLAG_WINDOW", time.Minute); got != 90*time.Second {
+		t.Errorf("Duration = %v, want 90s", got)
+	}
+	if got := Bool("ENVFLAG_RETAIN", false); !got {
+		t.Error("Bool = false, want true")
+	}
+	if got := Float64("ENVFLAG_QUALITY", 0); got != 0.5 {
+		t.Errorf("Float64 = %g, want 0.5", got)
+	}
+}
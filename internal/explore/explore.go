@@ -0,0 +1,659 @@
+// Package explore is a terminal explorer for the message corpus.
+//
+// It pages through the messages stored in ClickHouse, filtered by label,
+// parser type or text, and shows the selected message's raw text beside the
+// trace of the current parser registry, so a message that parses wrongly can
+// be understood without SQL. Messages are marked golden or flagged for
+// follow-up with single keystrokes; the marks are the review UI's annotations
+// in PostgreSQL, so the golden runner picks them up.
+//
+// The explorer is a Model in the style of the Elm architecture: Update
+// applies a key press, a resize or the outcome of a database call to the
+// state and returns the next database call to make, if any, and View renders
+// the state. Run drives a Model from a terminal.
+package explore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// Filter selects the messages shown. Empty fields match everything.
+type Filter struct {
+	Label      string
+	ParserType string
+	Text       string // Substring of the raw text.
+}
+
+// String describes the filter for the header line.
+func (f Filter) String() string {
+	var parts []string
+	for _, p := range []struct{ name, value string }{
+		{"label", f.Label}, {"type", f.ParserType}, {"text", f.Text},
+	} {
+		if p.value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", p.name, p.value))
+		}
+	}
+	if len(parts) == 0 {
+		return "all messages"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Store is the corpus the explorer reads and marks.
+type Store interface {
+	// Messages returns a page of the messages matching a filter, newest first.
+	Messages(ctx context.Context, f Filter, offset, limit int) ([]storage.CHMessage, error)
+	// Annotation returns a message's annotation, or nil if it has none.
+	Annotation(ctx context.Context, id int64) (*storage.GoldenAnnotation, error)
+	SetGolden(ctx context.Context, id int64, golden bool) error
+	SetFlag(ctx context.Context, id int64, flagged bool, reason string) error
+}
+
+// corpus is the Store of a ClickHouse message table and the PostgreSQL
+// annotations.
+type corpus struct {
+	ch *storage.ClickHouseDB
+	pg *storage.PostgresDB
+}
+
+// NewStore returns the Store of the messages in ch and the annotations in pg.
+func NewStore(ch *storage.ClickHouseDB, pg *storage.PostgresDB) Store {
+	return &corpus{ch: ch, pg: pg}
+}
+
+func (c *corpus) Messages(ctx context.Context, f Filter, offset, limit int) ([]storage.CHMessage, error) {
+	return c.ch.Query(ctx, storage.CHQueryParams{
+		Label:      f.Label,
+		ParserType: f.ParserType,
+		FullText:   f.Text,
+		OrderBy:    "id",
+		OrderDesc:  true,
+		Limit:      limit,
+		Offset:     offset,
+	})
+}
+
+func (c *corpus) Annotation(ctx context.Context, id int64) (*storage.GoldenAnnotation, error) {
+	return c.pg.GetGoldenAnnotation(ctx, id)
+}
+
+func (c *corpus) SetGolden(ctx context.Context, id int64, golden bool) error {
+	return c.pg.SetGolden(ctx, id, golden)
+}
+
+func (c *corpus) SetFlag(ctx context.Context, id int64, flagged bool, reason string) error {
+	return c.pg.SetFlag(ctx, id, flagged, reason)
+}
+
+// Msg is an input to Update: a KeyMsg, a ResizeMsg, or the outcome of a Cmd.
+type Msg interface{}
+
+// KeyMsg is a key press, as named by ParseKeys.
+type KeyMsg string
+
+// ResizeMsg reports the size of the terminal.
+type ResizeMsg struct {
+	Width, Height int
+}
+
+// Cmd is a database call to make. Its outcome is passed back to Update.
+type Cmd func(ctx context.Context) Msg
+
+// pageMsg is the outcome of loading a page.
+type pageMsg struct {
+	offset      int
+	messages    []storage.CHMessage
+	annotations map[uint64]*storage.GoldenAnnotation
+	err         error
+}
+
+// markedMsg is the outcome of marking a message.
+type markedMsg struct {
+	id         uint64
+	annotation *storage.GoldenAnnotation
+	status     string
+	err        error
+}
+
+// Default terminal size, used until the first ResizeMsg.
+const (
+	defaultWidth  = 100
+	defaultHeight = 30
+)
+
+// keyHelp is shown on the status line when there is nothing else to say.
+const keyHelp = "j/k move  n/p page  g golden  f flag  l label  t type  / text  c clear  r reload  q quit"
+
+// Model is the explorer's state.
+type Model struct {
+	store    Store
+	reg      *registry.Registry
+	filter   Filter
+	pageSize int
+
+	offset      int
+	messages    []storage.CHMessage
+	annotations map[uint64]*storage.GoldenAnnotation
+	cursor      int
+	trace       *registry.DispatchTrace // Of the selected message.
+	loading     bool
+
+	prompt *prompt
+	status string
+	width  int
+	height int
+	quit   bool
+}
+
+// prompt is a line of text being entered on the status line.
+type prompt struct {
+	label  string
+	value  string
+	submit func(m *Model, value string) Cmd
+}
+
+// New returns a Model showing the messages that match filter, pageSize at a
+// time, traced with reg.
+func New(store Store, reg *registry.Registry, filter Filter, pageSize int) *Model {
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	return &Model{
+		store:       store,
+		reg:         reg,
+		filter:      filter,
+		pageSize:    pageSize,
+		annotations: map[uint64]*storage.GoldenAnnotation{},
+		width:       defaultWidth,
+		height:      defaultHeight,
+	}
+}
+
+// Init returns the Cmd that loads the first page.
+func (m *Model) Init() Cmd {
+	return m.load(0)
+}
+
+// Quitting reports whether the user has asked to quit.
+func (m *Model) Quitting() bool {
+	return m.quit
+}
+
+// Selected returns the selected message, if the page has any.
+func (m *Model) Selected() (storage.CHMessage, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.messages) {
+		return storage.CHMessage{}, false
+	}
+	return m.messages[m.cursor], true
+}
+
+// load returns the Cmd that loads the page at offset with its annotations.
+func (m *Model) load(offset int) Cmd {
+	m.loading = true
+	store, filter, limit := m.store, m.filter, m.pageSize
+	return func(ctx context.Context) Msg {
+		msgs, err := store.Messages(ctx, filter, offset, limit)
+		if err != nil {
+			return pageMsg{offset: offset, err: err}
+		}
+		annotations := map[uint64]*storage.GoldenAnnotation{}
+		for _, msg := range msgs {
+			a, err := store.Annotation(ctx, int64(msg.ID))
+			if err != nil {
+				return pageMsg{offset: offset, err: err}
+			}
+			if a != nil {
+				annotations[msg.ID] = a
+			}
+		}
+		return pageMsg{offset: offset, messages: msgs, annotations: annotations}
+	}
+}
+
+// mark returns the Cmd that applies set to a message and reads back its
+// annotation.
+func (m *Model) mark(id uint64, status string, set func(ctx context.Context) error) Cmd {
+	store := m.store
+	return func(ctx context.Context) Msg {
+		if err := set(ctx); err != nil {
+			return markedMsg{id: id, err: err}
+		}
+		a, err := store.Annotation(ctx, int64(id))
+		return markedMsg{id: id, annotation: a, status: status, err: err}
+	}
+}
+
+// Update applies msg to the model and returns the next Cmd to run, if any.
+func (m *Model) Update(msg Msg) Cmd {
+	switch msg := msg.(type) {
+	case ResizeMsg:
+		if msg.Width > 0 && msg.Height > 0 {
+			m.width, m.height = msg.Width, msg.Height
+		}
+
+	case pageMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+			return nil
+		}
+		if len(msg.messages) == 0 && msg.offset > 0 {
+			m.status = "No more messages"
+			return nil
+		}
+		// Moving up from the top of a page selects the bottom of the one before.
+		if msg.offset < m.offset && m.cursor < 0 {
+			m.cursor = len(msg.messages) - 1
+		} else {
+			m.cursor = 0
+		}
+		m.offset, m.messages, m.annotations = msg.offset, msg.messages, msg.annotations
+		m.status = ""
+		m.retrace()
+
+	case markedMsg:
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+			return nil
+		}
+		if msg.annotation != nil {
+			m.annotations[msg.id] = msg.annotation
+		} else {
+			delete(m.annotations, msg.id)
+		}
+		m.status = msg.status
+
+	case KeyMsg:
+		if m.prompt != nil {
+			return m.editPrompt(string(msg))
+		}
+		return m.key(string(msg))
+	}
+	return nil
+}
+
+// key handles a key press outside a prompt.
+func (m *Model) key(k string) Cmd {
+	switch k {
+	case "q", KeyCtrlC:
+		m.quit = true
+	case "j", KeyDown:
+		return m.move(1)
+	case "k", KeyUp:
+		return m.move(-1)
+	case "n", KeyPageDown, KeyRight:
+		return m.nextPage()
+	case "p", KeyPageUp, KeyLeft:
+		return m.prevPage()
+	case KeyHome:
+		if m.offset > 0 {
+			return m.load(0)
+		}
+		m.cursor = 0
+		m.retrace()
+	case "r":
+		return m.load(m.offset)
+	case "g":
+		return m.toggleGolden()
+	case "f":
+		return m.toggleFlag()
+	case "l":
+		m.ask("Label", m.filter.Label, func(m *Model, v string) Cmd {
+			m.filter.Label = strings.ToUpper(v)
+			return m.load(0)
+		})
+	case "t":
+		m.ask("Parser type", m.filter.ParserType, func(m *Model, v string) Cmd {
+			m.filter.ParserType = v
+			return m.load(0)
+		})
+	case "/":
+		m.ask("Text", m.filter.Text, func(m *Model, v string) Cmd {
+			m.filter.Text = v
+			return m.load(0)
+		})
+	case "c":
+		m.filter = Filter{}
+		return m.load(0)
+	}
+	return nil
+}
+
+// move moves the selection, onto the next or previous page at the ends of
+// this one.
+func (m *Model) move(delta int) Cmd {
+	if m.loading {
+		return nil
+	}
+	next := m.cursor + delta
+	switch {
+	case next >= len(m.messages):
+		return m.nextPage()
+	case next < 0:
+		if m.offset == 0 {
+			return nil
+		}
+		m.cursor = -1
+		return m.load(max(0, m.offset-m.pageSize))
+	}
+	m.cursor = next
+	m.retrace()
+	return nil
+}
+
+func (m *Model) nextPage() Cmd {
+	if m.loading || len(m.messages) < m.pageSize {
+		m.status = "No more messages"
+		return nil
+	}
+	return m.load(m.offset + m.pageSize)
+}
+
+func (m *Model) prevPage() Cmd {
+	if m.loading || m.offset == 0 {
+		return nil
+	}
+	return m.load(max(0, m.offset-m.pageSize))
+}
+
+// toggleGolden marks the selected message golden, or unmarks it.
+func (m *Model) toggleGolden() Cmd {
+	msg, ok := m.Selected()
+	if !ok {
+		return nil
+	}
+	golden := true
+	if a := m.annotations[msg.ID]; a != nil {
+		golden = !a.IsGolden
+	}
+	status := fmt.Sprintf("Message %d marked golden", msg.ID)
+	if !golden {
+		status = fmt.Sprintf("Message %d no longer golden", msg.ID)
+	}
+	store := m.store
+	return m.mark(msg.ID, status, func(ctx context.Context) error {
+		return store.SetGolden(ctx, int64(msg.ID), golden)
+	})
+}
+
+// toggleFlag asks why the selected message is being flagged and flags it,
+// or unflags it if it is flagged.
+func (m *Model) toggleFlag() Cmd {
+	msg, ok := m.Selected()
+	if !ok {
+		return nil
+	}
+	store := m.store
+	if a := m.annotations[msg.ID]; a != nil && a.Flagged {
+		return m.mark(msg.ID, fmt.Sprintf("Message %d unflagged", msg.ID), func(ctx context.Context) error {
+			return store.SetFlag(ctx, int64(msg.ID), false, "")
+		})
+	}
+	m.ask("Flag reason", "", func(m *Model, reason string) Cmd {
+		return m.mark(msg.ID, fmt.Sprintf("Message %d flagged", msg.ID), func(ctx context.Context) error {
+			return store.SetFlag(ctx, int64(msg.ID), true, reason)
+		})
+	})
+	return nil
+}
+
+// ask opens a prompt on the status line.
+func (m *Model) ask(label, value string, submit func(m *Model, value string) Cmd) {
+	m.prompt = &prompt{label: label, value: value, submit: submit}
+}
+
+// editPrompt handles a key press in a prompt: Enter submits it and Escape
+// cancels it.
+func (m *Model) editPrompt(k string) Cmd {
+	p := m.prompt
+	switch k {
+	case KeyEnter:
+		m.prompt = nil
+		return p.submit(m, strings.TrimSpace(p.value))
+	case KeyEscape, KeyCtrlC:
+		m.prompt = nil
+	case KeyBackspace:
+		if _, size := utf8.DecodeLastRuneInString(p.value); size > 0 {
+			p.value = p.value[:len(p.value)-size]
+		}
+	default:
+		if utf8.RuneCountInString(k) == 1 {
+			p.value += k
+		}
+	}
+	return nil
+}
+
+// retrace traces the selected message through the registry.
+func (m *Model) retrace() {
+	msg, ok := m.Selected()
+	if !ok || m.reg == nil {
+		m.trace = nil
+		return
+	}
+	m.trace = m.reg.DispatchWithTrace(&acars.Message{ID: acars.FlexInt64(msg.ID), Label: msg.Label, Text: msg.RawText})
+}
+
+// View renders the model as lines of at most the terminal's width, one for
+// each row of the terminal: a header, the page of messages, the selected
+// message's raw text beside its trace, and a status line.
+func (m *Model) View() string {
+	w, h := max(m.width, 20), max(m.height, 8)
+	var lines []string
+
+	first, last := m.offset+1, m.offset+len(m.messages)
+	header := fmt.Sprintf(" Corpus explorer | %s | ", m.filter)
+	switch {
+	case m.loading && len(m.messages) == 0:
+		header += "loading"
+	case len(m.messages) == 0:
+		header += "no messages"
+	default:
+		header += fmt.Sprintf("messages %d-%d", first, last)
+	}
+	lines = append(lines, inverse(fit(header, w)))
+
+	for i, msg := range m.messages {
+		lines = append(lines, m.listRow(i, msg, w))
+	}
+
+	left := (w - 3) / 2
+	right := w - 3 - left
+	lines = append(lines, fit("── raw text "+strings.Repeat("─", w), left)+"─┼─"+fit("── trace "+strings.Repeat("─", w), right))
+
+	rows := h - len(lines) - 1
+	raw, trace := m.rawLines(left), m.traceLines()
+	for i := 0; i < rows; i++ {
+		var l, r string
+		if i < len(raw) {
+			l = raw[i]
+		}
+		if i < len(trace) {
+			r = trace[i]
+		}
+		lines = append(lines, fit(l, left)+" │ "+fit(r, right))
+	}
+
+	status := m.status
+	if m.prompt != nil {
+		status = m.prompt.label + ": " + m.prompt.value + "_"
+	} else if status == "" {
+		status = keyHelp
+	}
+	lines = append(lines, fit(status, w))
+	return strings.Join(lines, "\n")
+}
+
+// listRow renders a message in the page list, with its marks: G for golden
+// and F for flagged.
+func (m *Model) listRow(i int, msg storage.CHMessage, w int) string {
+	marks := ""
+	if a := m.annotations[msg.ID]; a != nil {
+		if a.IsGolden {
+			marks += "G"
+		}
+		if a.Flagged {
+			marks += "F"
+		}
+	}
+	parserType := msg.ParserType
+	if parserType == "" {
+		parserType = "-"
+	}
+	text := strings.Join(strings.Fields(msg.RawText), " ")
+	row := fmt.Sprintf("  %-10d %s  %-3s %-16s %-2s %s", msg.ID, msg.Timestamp.UTC().Format("2006-01-02 15:04"), msg.Label, parserType, marks, text)
+	if i == m.cursor {
+		return inverse(fit(">"+row[1:], w))
+	}
+	return fit(row, w)
+}
+
+// rawLines renders the selected message: its metadata, annotation and raw
+// text, wrapped to width.
+func (m *Model) rawLines(width int) []string {
+	msg, ok := m.Selected()
+	if !ok {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("#%d  %s  label %s", msg.ID, msg.Timestamp.UTC().Format("2006-01-02 15:04:05"), msg.Label)}
+	var who []string
+	for _, s := range []string{msg.Tail, msg.Flight} {
+		if s != "" {
+			who = append(who, s)
+		}
+	}
+	if msg.Origin != "" || msg.Destination != "" {
+		who = append(who, msg.Origin+"-"+msg.Destination)
+	}
+	if len(who) > 0 {
+		lines = append(lines, strings.Join(who, "  "))
+	}
+	if msg.ParserType != "" {
+		lines = append(lines, fmt.Sprintf("Stored as %s (%s v%d)", msg.ParserType, msg.ParserName, msg.ParserVersion))
+	}
+	if a := m.annotations[msg.ID]; a != nil {
+		if a.IsGolden {
+			lines = append(lines, "Golden")
+		}
+		if a.Flagged {
+			lines = append(lines, "Flagged: "+a.FlagReason)
+		}
+		if a.Annotation != "" {
+			lines = append(lines, "Note: "+a.Annotation)
+		}
+	}
+	lines = append(lines, "")
+	for _, l := range strings.Split(strings.ReplaceAll(msg.RawText, "\r", ""), "\n") {
+		lines = append(lines, wrap(l, width)...)
+	}
+	return lines
+}
+
+// traceLines renders the trace of the selected message: the results, then
+// each candidate parser with whether its QuickCheck passed and it matched,
+// and the fields of those that matched.
+func (m *Model) traceLines() []string {
+	t := m.trace
+	if t == nil {
+		return nil
+	}
+	var lines []string
+	if len(t.Results) == 0 {
+		lines = append(lines, "Results: none (unparsed)")
+	} else {
+		types := make([]string, len(t.Results))
+		for i, r := range t.Results {
+			types[i] = r.Type()
+		}
+		lines = append(lines, "Results: "+strings.Join(types, ", "))
+	}
+	lines = append(lines, "")
+	if len(t.Parsers) == 0 {
+		return append(lines, "No parsers for this label")
+	}
+	for _, p := range t.Parsers {
+		check, match := "fail", "no"
+		if p.QuickCheck {
+			check = "pass"
+		}
+		if p.Matched {
+			match = "yes"
+		}
+		lines = append(lines, fmt.Sprintf("%-20s %-9s %-4s %s", p.Parser, p.Stage, check, match))
+		if p.Error != "" {
+			lines = append(lines, "  error: "+p.Error)
+		}
+		if p.Detail != nil && p.Detail.QuickCheck != nil && p.Detail.QuickCheck.Reason != "" {
+			lines = append(lines, "  check: "+p.Detail.QuickCheck.Reason)
+		}
+		if !p.Matched {
+			continue
+		}
+		keys := make([]string, 0, len(p.Fields))
+		for k := range p.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v, err := json.Marshal(p.Fields[k])
+			if err != nil {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %s: %s", k, v))
+		}
+	}
+	return lines
+}
+
+// fit truncates or pads s to exactly width runes. Tabs and other control
+// characters become spaces, so they cannot move the cursor.
+func fit(s string, width int) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == width {
+			break
+		}
+		if r < 0x20 || r == 0x7f {
+			r = ' '
+		}
+		b.WriteRune(r)
+		n++
+	}
+	if n < width {
+		b.WriteString(strings.Repeat(" ", width-n))
+	}
+	return b.String()
+}
+
+// wrap splits a line into lines of at most width runes.
+func wrap(s string, width int) []string {
+	if width < 1 {
+		return nil
+	}
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return []string{""}
+	}
+	var out []string
+	for len(runes) > width {
+		out = append(out, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(out, string(runes))
+}
+
+// inverse renders a line in reverse video.
+func inverse(s string) string {
+	return "\x1b[7m" + s + "\x1b[0m"
+}
@@ -0,0 +1,259 @@
+package explore
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// fakeStore is an in-memory Store.
+type fakeStore struct {
+	messages    []storage.CHMessage
+	annotations map[int64]*storage.GoldenAnnotation
+	filters     []Filter // Filter of every Messages call.
+}
+
+func newFakeStore(n int) *fakeStore {
+	s := &fakeStore{annotations: map[int64]*storage.GoldenAnnotation{}}
+	for i := n; i >= 1; i-- {
+		s.messages = append(s.messages, storage.CHMessage{
+			ID:        uint64(i),
+			Timestamp: time.Date(2026, 9, 28, 21, i, 0, 0, time.UTC),
+			Label:     "H1",
+			RawText:   "PDC 282114 QFA9\tB789 YPPH",
+		})
+	}
+	return s
+}
+
+func (s *fakeStore) Messages(_ context.Context, f Filter, offset, limit int) ([]storage.CHMessage, error) {
+	s.filters = append(s.filters, f)
+	var out []storage.CHMessage
+	for _, m := range s.messages {
+		if f.Label != "" && m.Label != f.Label {
+			continue
+		}
+		out = append(out, m)
+	}
+	if offset >= len(out) {
+		return nil, nil
+	}
+	return out[offset:min(offset+limit, len(out))], nil
+}
+
+func (s *fakeStore) Annotation(_ context.Context, id int64) (*storage.GoldenAnnotation, error) {
+	if a, ok := s.annotations[id]; ok {
+		c := *a
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) annotation(id int64) *storage.GoldenAnnotation {
+	a, ok := s.annotations[id]
+	if !ok {
+		a = &storage.GoldenAnnotation{MessageID: id}
+		s.annotations[id] = a
+	}
+	return a
+}
+
+func (s *fakeStore) SetGolden(_ context.Context, id int64, golden bool) error {
+	s.annotation(id).IsGolden = golden
+	return nil
+}
+
+func (s *fakeStore) SetFlag(_ context.Context, id int64, flagged bool, reason string) error {
+	a := s.annotation(id)
+	a.Flagged, a.FlagReason = flagged, reason
+	return nil
+}
+
+// press sends keys to the model, running any Cmd they return.
+func press(m *Model, keys ...string) {
+	for _, k := range keys {
+		apply(m, m.Update(KeyMsg(k)))
+	}
+}
+
+// apply runs a Cmd and passes its outcome back, as Run does.
+func apply(m *Model, c Cmd) {
+	for c != nil {
+		c = m.Update(c(context.Background()))
+	}
+}
+
+func selectedID(t *testing.T, m *Model) uint64 {
+	t.Helper()
+	msg, ok := m.Selected()
+	if !ok {
+		t.Fatal("no message selected")
+	}
+	return msg.ID
+}
+
+func TestPaging(t *testing.T) {
+	m := New(newFakeStore(5), nil, Filter{}, 2)
+	apply(m, m.Init())
+	if got := selectedID(t, m); got != 5 {
+		t.Fatalf("first message = %d, want 5", got)
+	}
+
+	press(m, "j", "j")
+	if m.offset != 2 || selectedID(t, m) != 3 {
+		t.Errorf("after moving past the page: offset %d, message %d; want 2, 3", m.offset, selectedID(t, m))
+	}
+	press(m, "k")
+	if m.offset != 0 || selectedID(t, m) != 4 {
+		t.Errorf("after moving back: offset %d, message %d; want 0, 4 (the bottom of the previous page)", m.offset, selectedID(t, m))
+	}
+	press(m, "n", "n", "n")
+	if m.offset != 4 || selectedID(t, m) != 1 || m.status != "No more messages" {
+		t.Errorf("at the end: offset %d, message %d, status %q", m.offset, selectedID(t, m), m.status)
+	}
+	press(m, "p")
+	if m.offset != 2 {
+		t.Errorf("after the previous page: offset %d, want 2", m.offset)
+	}
+}
+
+func TestMarkGoldenAndFlag(t *testing.T) {
+	store := newFakeStore(3)
+	m := New(store, nil, Filter{}, 10)
+	apply(m, m.Init())
+
+	press(m, "g")
+	if a := store.annotations[3]; a == nil || !a.IsGolden {
+		t.Fatalf("message 3 not marked golden: %+v", a)
+	}
+	if !strings.Contains(m.View(), " G ") {
+		t.Errorf("list does not show the golden mark:\n%s", m.View())
+	}
+
+	press(m, "j", "f", "b", "a", "d", KeyBackspace, "d", " ", "f", "l", KeyEnter)
+	if a := store.annotations[2]; a == nil || !a.Flagged || a.FlagReason != "bad fl" {
+		t.Fatalf("message 2 not flagged with its reason: %+v", a)
+	}
+	if !strings.Contains(m.View(), "Flagged: bad fl") {
+		t.Errorf("view does not show the flag reason:\n%s", m.View())
+	}
+
+	press(m, "f")
+	if store.annotations[2].Flagged {
+		t.Error("second f did not unflag the message")
+	}
+	press(m, "k", "g")
+	if store.annotations[3].IsGolden {
+		t.Error("second g did not unmark the message")
+	}
+
+	// Escape cancels a prompt without flagging.
+	press(m, "j", "j", "f", "x", KeyEscape)
+	if a := store.annotations[1]; a != nil && a.Flagged {
+		t.Error("cancelled prompt flagged the message")
+	}
+}
+
+func TestFilters(t *testing.T) {
+	store := newFakeStore(3)
+	store.messages[1].Label = "B6"
+	m := New(store, nil, Filter{}, 10)
+	apply(m, m.Init())
+
+	press(m, "l", "b", "6", KeyEnter)
+	if m.filter.Label != "B6" || len(m.messages) != 1 || m.messages[0].ID != 2 {
+		t.Errorf("label filter: %+v, %d messages", m.filter, len(m.messages))
+	}
+	press(m, "/", "Q", "F", KeyEnter, "t", "p", "d", "c", KeyEnter)
+	want := Filter{Label: "B6", ParserType: "pdc", Text: "QF"}
+	if got := store.filters[len(store.filters)-1]; got != want {
+		t.Errorf("filter = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(m.View(), `label="B6" type="pdc" text="QF"`) {
+		t.Errorf("header does not show the filter:\n%s", m.View())
+	}
+	press(m, "c")
+	if m.filter != (Filter{}) || len(m.messages) != 3 {
+		t.Errorf("after clearing: %+v, %d messages", m.filter, len(m.messages))
+	}
+}
+
+func TestViewFitsTerminal(t *testing.T) {
+	store := newFakeStore(3)
+	store.messages[0].RawText = strings.Repeat("LONG LINE ", 30) + "\nSECOND"
+	m := New(store, nil, Filter{}, 10)
+	m.Update(ResizeMsg{Width: 60, Height: 20})
+	apply(m, m.Init())
+
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) != 20 {
+		t.Errorf("view has %d lines, want 20", len(lines))
+	}
+	for i, l := range lines {
+		l = strings.NewReplacer("\x1b[7m", "", "\x1b[0m", "").Replace(l)
+		if n := utf8.RuneCountInString(l); n != 60 {
+			t.Errorf("line %d is %d runes wide, want 60: %q", i, n, l)
+		}
+		if strings.ContainsAny(l, "\t\r") {
+			t.Errorf("line %d has control characters: %q", i, l)
+		}
+	}
+	if !strings.Contains(m.View(), "SECOND") {
+		t.Error("raw text is not shown")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	store := newFakeStore(1)
+	store.messages[0].Label = "B6"
+	store.messages[0].RawText = "NOT AN ADS-C REPORT"
+	reg := registry.Default()
+	reg.Sort()
+	m := New(store, reg, Filter{}, 10)
+	apply(m, m.Init())
+
+	view := m.View()
+	for _, want := range []string{"NOT AN ADS-C REPORT", "Results: none (unparsed)", "adsc"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view does not contain %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestQuit(t *testing.T) {
+	m := New(newFakeStore(1), nil, Filter{}, 10)
+	press(m, "q")
+	if !m.Quitting() {
+		t.Error("q did not quit")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := ParseKeys([]byte("jé\x1b[A\x1b[6~\r\x7f\x03\x1b[1;5C\x1bOB\x1b"))
+	want := []string{"j", "é", KeyUp, KeyPageDown, KeyEnter, KeyBackspace, KeyCtrlC, KeyDown, KeyEscape}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseKeys = %q, want %q", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	m := New(newFakeStore(2), nil, Filter{}, 10)
+	var out strings.Builder
+	err := Run(context.Background(), m, strings.NewReader("q"), &out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Quitting() {
+		t.Error("Run returned before quitting")
+	}
+	if s := out.String(); !strings.HasPrefix(s, enterScreen) || !strings.HasSuffix(s, leaveScreen) || !strings.Contains(s, "Corpus explorer") {
+		t.Errorf("output = %q", s)
+	}
+}
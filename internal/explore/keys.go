@@ -0,0 +1,89 @@
+package explore
+
+import "unicode/utf8"
+
+// Key names returned by ParseKeys for keys that are not printable runes.
+const (
+	KeyUp        = "up"
+	KeyDown      = "down"
+	KeyLeft      = "left"
+	KeyRight     = "right"
+	KeyHome      = "home"
+	KeyEnd       = "end"
+	KeyPageUp    = "pgup"
+	KeyPageDown  = "pgdown"
+	KeyDelete    = "delete"
+	KeyEnter     = "enter"
+	KeyEscape    = "esc"
+	KeyBackspace = "backspace"
+	KeyTab       = "tab"
+	KeyCtrlC     = "ctrl+c"
+)
+
+// csiKeys names the final bytes and parameters of the CSI sequences
+// terminals send for the keys the explorer uses.
+var csiKeys = map[string]string{
+	"A":  KeyUp,
+	"B":  KeyDown,
+	"C":  KeyRight,
+	"D":  KeyLeft,
+	"H":  KeyHome,
+	"F":  KeyEnd,
+	"1~": KeyHome,
+	"4~": KeyEnd,
+	"3~": KeyDelete,
+	"5~": KeyPageUp,
+	"6~": KeyPageDown,
+}
+
+// ParseKeys splits what a terminal in raw mode sent into keys: a printable
+// rune as itself, anything else by name (KeyUp and so on). Escape sequences
+// the explorer does not use are dropped. An escape at the end of the input
+// is the Escape key, as terminals send sequences in one write.
+func ParseKeys(b []byte) []string {
+	var keys []string
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c == 0x1b:
+			if i+1 >= len(b) || (b[i+1] != '[' && b[i+1] != 'O') {
+				keys = append(keys, KeyEscape)
+				i++
+				continue
+			}
+			// CSI or SS3: parameters, then a final byte from 0x40 to 0x7e.
+			j := i + 2
+			for j < len(b) && (b[j] < 0x40 || b[j] > 0x7e) {
+				j++
+			}
+			if j >= len(b) {
+				return keys
+			}
+			if k, ok := csiKeys[string(b[i+2:j+1])]; ok {
+				keys = append(keys, k)
+			}
+			i = j + 1
+		case c == 0x03:
+			keys = append(keys, KeyCtrlC)
+			i++
+		case c == '\r' || c == '\n':
+			keys = append(keys, KeyEnter)
+			i++
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, KeyBackspace)
+			i++
+		case c == '\t':
+			keys = append(keys, KeyTab)
+			i++
+		case c < 0x20:
+			i++
+		default:
+			r, size := utf8.DecodeRune(b[i:])
+			if r != utf8.RuneError {
+				keys = append(keys, string(r))
+			}
+			i += size
+		}
+	}
+	return keys
+}
@@ -0,0 +1,84 @@
+package explore
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// Terminal control sequences.
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l" // Alternate screen, cursor hidden.
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	home        = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+)
+
+// quitMsg stops Run when the input ends.
+type quitMsg struct{}
+
+// Run drives a Model: it reads key presses from in, which must be a terminal
+// in raw mode, takes terminal sizes from resize, runs the Model's Cmds and
+// redraws out after every update, until the user quits, the input ends or
+// ctx is done. The terminal is left on its normal screen.
+func Run(ctx context.Context, m *Model, in io.Reader, out io.Writer, resize <-chan ResizeMsg) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs := make(chan Msg, 16)
+	send := func(msg Msg) {
+		select {
+		case msgs <- msg:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			for _, k := range ParseKeys(buf[:n]) {
+				send(KeyMsg(k))
+			}
+			if err != nil {
+				send(quitMsg{})
+				return
+			}
+		}
+	}()
+	run := func(c Cmd) {
+		if c != nil {
+			go func() { send(c(ctx)) }()
+		}
+	}
+
+	if _, err := io.WriteString(out, enterScreen); err != nil {
+		return err
+	}
+	defer io.WriteString(out, leaveScreen)
+
+	run(m.Init())
+	for {
+		// Raw mode leaves newlines alone, so each line returns the cursor.
+		frame := home + strings.ReplaceAll(m.View(), "\n", clearLine+"\r\n") + clearLine + clearBelow
+		if _, err := io.WriteString(out, frame); err != nil {
+			return err
+		}
+
+		var msg Msg
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case r := <-resize:
+			msg = r
+		case msg = <-msgs:
+		}
+		if _, ok := msg.(quitMsg); ok {
+			return nil
+		}
+		run(m.Update(msg))
+		if m.Quitting() {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvWriter writes rows as RFC 4180 CSV with a header line of column names.
+// Missing values are written as empty fields and times as RFC 3339 UTC with
+// millisecond precision.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+// NewCSVWriter returns a Writer that writes cols as CSV to w. The header is
+// written immediately.
+func NewCSVWriter(w io.Writer, cols []Column) (Writer, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(cols))}
+	for i, c := range cols {
+		cw.record[i] = c.Name
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// WriteRow writes one row.
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	for i, v := range values {
+		c.record[i] = formatValue(v)
+	}
+	return c.w.Write(c.record)
+}
+
+// Close flushes buffered output.
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
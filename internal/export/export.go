@@ -0,0 +1,329 @@
+// Package export flattens stored parse results into tables of one row per
+// message, and writes them as CSV or Parquet files for analysis in tools such
+// as pandas or DuckDB.
+//
+// Each table has a fixed set of message columns followed by the fields of the
+// parsed result:
+//
+//   - Nested objects are flattened into dotted column names, following the
+//     field paths used by the golden runner (e.g. "meteo.wind_speed").
+//   - Arrays are kept as a single column holding compact JSON, so a route or
+//     wind table stays in one cell rather than spreading over a variable
+//     number of columns.
+//   - Result fields that repeat message metadata (message_id, timestamp) are
+//     dropped; a result field named like a message column is prefixed with
+//     "result.".
+//
+// A column's type is inferred from every value it holds: integers, floats,
+// booleans and strings; integer columns that also hold fractional numbers
+// widen to floats, and any other mix is written as strings. Result columns
+// are sorted by name, so exports of the same data always produce the same
+// schema. Building the schema needs one pass over the rows before writing
+// them; see Schema.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/golden"
+	"acars_parser/internal/storage"
+)
+
+// Kind is the value type of a column.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindFloat
+	KindBool
+	KindTime
+)
+
+// String returns the kind name.
+func (k Kind) String() string {
+	switch k {
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindBool:
+		return "bool"
+	case KindTime:
+		return "time"
+	default:
+		return "string"
+	}
+}
+
+// Column is one column of an export table.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// messageColumns are the leading columns of every table, taken from the
+// stored message rather than the parsed result.
+var messageColumns = []Column{
+	{"id", KindInt},
+	{"timestamp", KindTime},
+	{"label", KindString},
+	{"parser_name", KindString},
+	{"parser_version", KindInt},
+	{"flight", KindString},
+	{"tail", KindString},
+	{"origin", KindString},
+	{"destination", KindString},
+	{"confidence", KindFloat},
+	{"missing_fields", KindString},
+	{"raw_text", KindString},
+}
+
+// resultPrefix is prepended to result fields that share a message column name.
+const resultPrefix = "result."
+
+// Schema accumulates the result columns seen across a set of messages.
+// Observe every message to be exported, then call Columns.
+type Schema struct {
+	kinds map[string]Kind
+}
+
+// NewSchema returns an empty schema.
+func NewSchema() *Schema {
+	return &Schema{kinds: make(map[string]Kind)}
+}
+
+// Observe records the result fields of a stored message.
+func (s *Schema) Observe(m storage.CHMessage) error {
+	fields, err := Flatten(m.ParsedJSON)
+	if err != nil {
+		return fmt.Errorf("message %d: %w", m.ID, err)
+	}
+	for name, v := range fields {
+		k := kindOf(v)
+		if prev, ok := s.kinds[name]; ok {
+			k = widen(prev, k)
+		}
+		s.kinds[name] = k
+	}
+	return nil
+}
+
+// Columns returns the message columns followed by the observed result
+// columns in name order.
+func (s *Schema) Columns() []Column {
+	names := make([]string, 0, len(s.kinds))
+	for name := range s.kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cols := append([]Column(nil), messageColumns...)
+	for _, name := range names {
+		cols = append(cols, Column{Name: name, Kind: s.kinds[name]})
+	}
+	return cols
+}
+
+// Row converts a stored message into values for cols, in column order.
+// Values are int64, float64, bool, string or time.Time matching the column
+// kind, or nil when the message has no value for the column.
+func Row(cols []Column, m storage.CHMessage) ([]interface{}, error) {
+	fields, err := Flatten(m.ParsedJSON)
+	if err != nil {
+		return nil, fmt.Errorf("message %d: %w", m.ID, err)
+	}
+
+	meta := map[string]interface{}{
+		"id":             int64(m.ID),
+		"timestamp":      m.Timestamp.UTC(),
+		"label":          m.Label,
+		"parser_name":    m.ParserName,
+		"parser_version": int64(m.ParserVersion),
+		"flight":         m.Flight,
+		"tail":           m.Tail,
+		"origin":         m.Origin,
+		"destination":    m.Destination,
+		"confidence":     float32To64(m.Confidence),
+		"missing_fields": m.MissingFields,
+		"raw_text":       m.RawText,
+	}
+
+	row := make([]interface{}, len(cols))
+	for i, c := range cols {
+		if i < len(messageColumns) {
+			row[i] = meta[c.Name]
+			continue
+		}
+		v, ok := fields[c.Name]
+		if !ok {
+			continue
+		}
+		row[i] = convert(v, c.Kind)
+	}
+	return row, nil
+}
+
+// Flatten decodes a parsed result into a map of column name to value.
+// Values are json.Number, string or bool; arrays are re-encoded as compact
+// JSON strings and nulls are omitted.
+func Flatten(parsedJSON string) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	if strings.TrimSpace(parsedJSON) == "" {
+		return out, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(parsedJSON))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("decode parsed result: %w", err)
+	}
+
+	for key, v := range obj {
+		if golden.IgnoredFields[key] {
+			continue
+		}
+		name := key
+		if isMessageColumn(key) {
+			name = resultPrefix + key
+		}
+		if err := flattenValue(out, name, v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func flattenValue(out map[string]interface{}, name string, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for key, child := range val {
+			if err := flattenValue(out, name+"."+key, child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(val); err != nil {
+			return fmt.Errorf("encode %s: %w", name, err)
+		}
+		out[name] = strings.TrimSuffix(buf.String(), "\n")
+	default:
+		out[name] = val
+	}
+	return nil
+}
+
+// float32To64 widens a float32 via its shortest decimal form, so a stored
+// confidence of 0.9 exports as 0.9 rather than 0.8999999761581421.
+func float32To64(f float32) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'g', -1, 32), 64)
+	return v
+}
+
+func isMessageColumn(name string) bool {
+	for _, c := range messageColumns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// kindOf returns the column kind for a flattened value.
+func kindOf(v interface{}) Kind {
+	switch val := v.(type) {
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return KindInt
+		}
+		return KindFloat
+	case bool:
+		return KindBool
+	default:
+		return KindString
+	}
+}
+
+// widen returns the kind that can hold values of both a and b.
+func widen(a, b Kind) Kind {
+	switch {
+	case a == b:
+		return a
+	case (a == KindInt && b == KindFloat) || (a == KindFloat && b == KindInt):
+		return KindFloat
+	default:
+		return KindString
+	}
+}
+
+// convert returns v as the Go type of kind k. Schema inference guarantees v
+// fits k; string columns take the text form of any value.
+func convert(v interface{}, k Kind) interface{} {
+	switch k {
+	case KindInt:
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				return i
+			}
+		}
+	case KindFloat:
+		if n, ok := v.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				return f
+			}
+		}
+	case KindBool:
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	}
+	switch val := v.(type) {
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case string:
+		return val
+	}
+	return fmt.Sprint(v)
+}
+
+// Writer writes rows of an export table.
+type Writer interface {
+	// WriteRow writes one row of values as returned by Row.
+	WriteRow(values []interface{}) error
+	// Close flushes buffered rows and finalises the file. It does not close
+	// the underlying io.Writer.
+	Close() error
+}
+
+// formatValue renders a value as text for CSV output. Nil renders as an
+// empty string.
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	}
+	return fmt.Sprint(v)
+}
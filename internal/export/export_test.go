@@ -0,0 +1,209 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"acars_parser/internal/storage"
+)
+
+func message(id uint64, parsedJSON string) storage.CHMessage {
+	return storage.CHMessage{
+		ID:         id,
+		Timestamp:  time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC),
+		Label:      "B6",
+		ParserType: "adsc",
+		ParserName: "adsc",
+		Flight:     "QF1",
+		Tail:       "VH-OQA",
+		Confidence: 0.9,
+		ParsedJSON: parsedJSON,
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	fields, err := Flatten(`{"message_id":7,"timestamp":"x","flight":"QF1","altitude":35000,
+		"meteo":{"wind_speed":42.5,"temperature":null},"waypoints":[{"name":"TESAT"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dropped := range []string{"message_id", "timestamp", "flight", "meteo.temperature"} {
+		if _, ok := fields[dropped]; ok {
+			t.Errorf("field %q should not be present", dropped)
+		}
+	}
+	if got := fields["result.flight"]; got != "QF1" {
+		t.Errorf("result.flight = %v, want QF1", got)
+	}
+	if got := kindOf(fields["altitude"]); got != KindInt {
+		t.Errorf("altitude kind = %v, want int", got)
+	}
+	if got := kindOf(fields["meteo.wind_speed"]); got != KindFloat {
+		t.Errorf("meteo.wind_speed kind = %v, want float", got)
+	}
+	if got := fields["waypoints"]; got != `[{"name":"TESAT"}]` {
+		t.Errorf("waypoints = %v, want compact JSON", got)
+	}
+}
+
+func TestSchemaColumns(t *testing.T) {
+	s := NewSchema()
+	for i, js := range []string{
+		`{"altitude":35000,"speed":450,"code":"A1","active":true}`,
+		`{"altitude":35000.5,"speed":"fast","code":"B2","active":false}`,
+		`{"track":90}`,
+	} {
+		if err := s.Observe(message(uint64(i+1), js)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cols := s.Columns()
+	if len(cols) != len(messageColumns)+5 {
+		t.Fatalf("got %d columns, want %d", len(cols), len(messageColumns)+5)
+	}
+	if cols[0].Name != "id" || cols[1].Name != "timestamp" {
+		t.Errorf("message columns should lead, got %s, %s", cols[0].Name, cols[1].Name)
+	}
+
+	want := []Column{
+		{"active", KindBool},
+		{"altitude", KindFloat},
+		{"code", KindString},
+		{"speed", KindString},
+		{"track", KindInt},
+	}
+	for i, w := range want {
+		if got := cols[len(messageColumns)+i]; got != w {
+			t.Errorf("column %d = %+v, want %+v", len(messageColumns)+i, got, w)
+		}
+	}
+}
+
+func TestRowConvertsToColumnKind(t *testing.T) {
+	cols := append(append([]Column(nil), messageColumns...),
+		Column{"altitude", KindFloat},
+		Column{"speed", KindString},
+		Column{"track", KindInt},
+	)
+	row, err := Row(cols, message(5, `{"altitude":35000,"speed":450}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := len(messageColumns)
+	if row[0] != int64(5) {
+		t.Errorf("id = %v (%T), want int64 5", row[0], row[0])
+	}
+	if row[9] != 0.9 {
+		t.Errorf("confidence = %v, want 0.9", row[9])
+	}
+	if row[n] != float64(35000) {
+		t.Errorf("altitude = %v (%T), want float64 35000", row[n], row[n])
+	}
+	if row[n+1] != "450" {
+		t.Errorf("speed = %v (%T), want string 450", row[n+1], row[n+1])
+	}
+	if row[n+2] != nil {
+		t.Errorf("track = %v, want nil", row[n+2])
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	cols := append(append([]Column(nil), messageColumns...), Column{"altitude", KindInt})
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, js := range []string{`{"altitude":35000}`, `{}`} {
+		row, err := Row(cols, message(1, js))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "id,timestamp,label,") || !strings.HasSuffix(lines[0], ",altitude") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if want := "1,2026-01-24T10:00:00.000Z,B6,adsc,0,QF1,VH-OQA,,,0.9,,,35000"; lines[1] != want {
+		t.Errorf("row 1 = %q, want %q", lines[1], want)
+	}
+	if !strings.HasSuffix(lines[2], ",") {
+		t.Errorf("row 2 should end with an empty altitude, got %q", lines[2])
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	cols := append(append([]Column(nil), messageColumns...),
+		Column{"altitude", KindInt},
+		Column{"meteo.wind_speed", KindFloat},
+	)
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, js := range []string{`{"altitude":35000,"meteo":{"wind_speed":42.5}}`, `{}`} {
+		row, err := Row(cols, message(uint64(i+1), js))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	type record struct {
+		ID        *int64    `parquet:"id,optional"`
+		Timestamp time.Time `parquet:"timestamp,optional,timestamp(millisecond)"`
+		Tail      *string   `parquet:"tail,optional"`
+		Altitude  *int64    `parquet:"altitude,optional"`
+		WindSpeed *float64  `parquet:"meteo.wind_speed,optional"`
+	}
+	records, err := parquet.Read[record](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	r := records[0]
+	if r.ID == nil || *r.ID != 1 {
+		t.Errorf("id = %v, want 1", r.ID)
+	}
+	if !r.Timestamp.Equal(time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("timestamp = %v", r.Timestamp)
+	}
+	if r.Tail == nil || *r.Tail != "VH-OQA" {
+		t.Errorf("tail = %v, want VH-OQA", r.Tail)
+	}
+	if r.Altitude == nil || *r.Altitude != 35000 {
+		t.Errorf("altitude = %v, want 35000", r.Altitude)
+	}
+	if r.WindSpeed == nil || *r.WindSpeed != 42.5 {
+		t.Errorf("meteo.wind_speed = %v, want 42.5", r.WindSpeed)
+	}
+	if records[1].Altitude != nil || records[1].WindSpeed != nil {
+		t.Errorf("second record should have null result fields, got %+v", records[1])
+	}
+}
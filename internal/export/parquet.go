@@ -0,0 +1,107 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// parquetBatch is the number of rows buffered before they are handed to the
+// Parquet writer.
+const parquetBatch = 1024
+
+// parquetWriter writes rows to a Parquet file with one optional column per
+// export column. Parquet orders the columns of a group by name, so the file
+// columns are sorted rather than in export order; readers select by name.
+type parquetWriter struct {
+	w *parquet.Writer
+
+	// leaf maps each export column to its leaf column index in the file.
+	leaf []int
+	rows []parquet.Row
+}
+
+// NewParquetWriter returns a Writer that writes cols as a zstd-compressed
+// Parquet file to w. Times are stored as UTC millisecond timestamps.
+func NewParquetWriter(w io.Writer, cols []Column) (Writer, error) {
+	group := make(parquet.Group, len(cols))
+	for _, c := range cols {
+		if _, dup := group[c.Name]; dup {
+			return nil, fmt.Errorf("duplicate column %q", c.Name)
+		}
+		group[c.Name] = parquet.Optional(parquetNode(c.Kind))
+	}
+	schema := parquet.NewSchema("acars", group)
+
+	index := make(map[string]int, len(cols))
+	for i, f := range schema.Fields() {
+		index[f.Name()] = i
+	}
+	leaf := make([]int, len(cols))
+	for i, c := range cols {
+		leaf[i] = index[c.Name]
+	}
+
+	pw := parquet.NewWriter(w, schema, parquet.Compression(&zstd.Codec{}))
+	return &parquetWriter{w: pw, leaf: leaf}, nil
+}
+
+func parquetNode(k Kind) parquet.Node {
+	switch k {
+	case KindInt:
+		return parquet.Int(64)
+	case KindFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case KindBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case KindTime:
+		return parquet.Timestamp(parquet.Millisecond)
+	default:
+		return parquet.String()
+	}
+}
+
+// WriteRow buffers one row, writing a batch when the buffer is full.
+func (p *parquetWriter) WriteRow(values []interface{}) error {
+	row := make(parquet.Row, len(values))
+	for i, v := range values {
+		col := p.leaf[i]
+		switch val := v.(type) {
+		case nil:
+			row[col] = parquet.NullValue().Level(0, 0, col)
+			continue
+		case time.Time:
+			v = val.UnixMilli()
+		case string:
+			v = []byte(val)
+		}
+		row[col] = parquet.ValueOf(v).Level(0, 1, col)
+	}
+	p.rows = append(p.rows, row)
+	if len(p.rows) >= parquetBatch {
+		return p.flush()
+	}
+	return nil
+}
+
+func (p *parquetWriter) flush() error {
+	if len(p.rows) == 0 {
+		return nil
+	}
+	if _, err := p.w.WriteRows(p.rows); err != nil {
+		return err
+	}
+	p.rows = p.rows[:0]
+	return nil
+}
+
+// Close writes buffered rows and the file footer.
+func (p *parquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.w.Close()
+}
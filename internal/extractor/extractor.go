@@ -73,9 +73,10 @@ func Extract(msg *acars.Message, results []registry.Result) ExtractedData {
 	// Build the base flight update from the message metadata.
 	update := &FlightUpdate{}
 
-	// Extract identity from the message/airframe.
+	// Extract identity from the message/airframe. The ICAO address falls back
+	// to the link-layer address when the feed has no airframe data.
+	update.ICAOHex = strings.ToUpper(msg.AircraftICAO())
 	if msg.Airframe != nil {
-		update.ICAOHex = msg.Airframe.ICAO
 		update.Registration = msg.Airframe.Tail
 		update.TypeCode = msg.Airframe.ManufacturerModel
 		update.Operator = msg.Airframe.Owner
@@ -0,0 +1,112 @@
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Fixture is a test case of one parser, stored as <root>/<parser>/<case>.json,
+// where <parser> is the parser's name:
+//
+//	{
+//	  "note": "CLX clearance with a NAT track",
+//	  "message": {"id": 1, "label": "A1", "text": "CLX 1259 ..."},
+//	  "expected": {"message_id": 1, "flight_num": "DAL48", ...}
+//	}
+//
+// The message is in the flat JSON that decode reads. Expected is the whole
+// result as JSON, or null when the parser must not match the message.
+type Fixture struct {
+	Parser string `json:"-"`
+	Name   string `json:"-"`
+	Path   string `json:"-"`
+
+	Note     string                 `json:"note,omitempty"`
+	Message  json.RawMessage        `json:"message"`
+	Expected map[string]interface{} `json:"expected"`
+}
+
+// LoadFixtures reads the fixtures in the parser directories under root, by
+// parser and case name.
+func LoadFixtures(root string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(root, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+
+	fixtures := make([]Fixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		f.Parser = filepath.Base(filepath.Dir(path))
+		f.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		f.Path = path
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+// RunFixture runs the fixture's message through the parser it is filed
+// under, as the registry would: only if the parser takes the message's label
+// and its QuickCheck passes. It returns the result as JSON, nil when the
+// parser did not match, and its differences from the expectation. Unlike
+// Run, every field is compared, and an extra field is a difference too.
+func RunFixture(reg *registry.Registry, f Fixture) (map[string]interface{}, []FieldDiff, error) {
+	p := reg.Lookup(f.Parser)
+	if p == nil {
+		return nil, nil, fmt.Errorf("%s: no parser named %q", f.Path, f.Parser)
+	}
+	var msg acars.Message
+	if err := json.Unmarshal(f.Message, &msg); err != nil {
+		return nil, nil, fmt.Errorf("%s: message: %w", f.Path, err)
+	}
+
+	var actual map[string]interface{}
+	if labels := p.Labels(); (len(labels) == 0 || slices.Contains(labels, msg.Label)) && p.QuickCheck(msg.Text) {
+		if result := p.Parse(&msg); result != nil {
+			var err error
+			if actual, err = toMap(result); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", f.Path, err)
+			}
+		}
+	}
+
+	switch {
+	case f.Expected == nil && actual == nil:
+		return nil, nil, nil
+	case f.Expected == nil:
+		return actual, []FieldDiff{{Path: "", Kind: DiffExtra, Actual: "a result"}}, nil
+	case actual == nil:
+		return nil, []FieldDiff{{Path: "", Kind: DiffMissing, Expected: "a result"}}, nil
+	}
+	return actual, Diff(f.Expected, actual), nil
+}
+
+// WriteFixture rewrites the fixture's file with expected as its expectation,
+// keeping its note and message as they are.
+func WriteFixture(f Fixture, expected map[string]interface{}) error {
+	f.Expected = expected
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,301 @@
+// Package golden runs golden (hand-verified) messages through the parser
+// registry and compares the output against the expected JSON field by field.
+//
+// Golden cases can be loaded from a JSON export (the format produced by the
+// review UI's /api/export/json endpoint), from the legacy SQLite corpus
+// (is_golden = 1), or from PostgreSQL golden_annotations joined with the
+// ClickHouse messages table.
+package golden
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// Case is a single golden message with its expected parse output.
+// The JSON layout matches review.GoldenExport so exported files load directly.
+type Case struct {
+	ID         int64                  `json:"id"`
+	RawText    string                 `json:"raw_text"`
+	Label      string                 `json:"label"`
+	ParserType string                 `json:"parser_type"`
+	Expected   map[string]interface{} `json:"expected"`
+	Annotation string                 `json:"annotation,omitempty"`
+}
+
+// IgnoredFields are top-level fields that depend on message metadata rather than
+// parser behaviour, so they are excluded from comparison.
+var IgnoredFields = map[string]bool{
+	"message_id": true,
+	"timestamp":  true,
+}
+
+// DiffKind classifies a field difference.
+type DiffKind string
+
+const (
+	DiffMissing DiffKind = "missing" // Expected field absent from the output.
+	DiffChanged DiffKind = "changed" // Field present with a different value.
+	DiffExtra   DiffKind = "extra"   // Output field not present in the expectation.
+)
+
+// FieldDiff describes a difference at a single JSON path.
+type FieldDiff struct {
+	Path     string      `json:"path"`
+	Kind     DiffKind    `json:"kind"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+// Outcome is the result of running one golden case.
+type Outcome struct {
+	Case       Case        `json:"case"`
+	ActualType string      `json:"actual_type,omitempty"` // Result type selected for comparison.
+	Diffs      []FieldDiff `json:"diffs,omitempty"`
+	Regressed  bool        `json:"regressed"`
+}
+
+// Run parses the case with the registry and diffs the output against the expectation.
+// A case regresses if no result of the expected type is produced or if any expected
+// field is missing or changed. Extra fields are reported but are not regressions.
+func Run(reg *registry.Registry, c Case) Outcome {
+	out := Outcome{Case: c}
+
+	msg := &acars.Message{
+		ID:    acars.FlexInt64(c.ID),
+		Label: c.Label,
+		Text:  c.RawText,
+	}
+	results := reg.Dispatch(msg)
+
+	result := selectResult(results, c.ParserType)
+	if result == nil {
+		out.Regressed = true
+		out.Diffs = []FieldDiff{{Path: "type", Kind: DiffMissing, Expected: c.ParserType, Actual: resultTypes(results)}}
+		return out
+	}
+	out.ActualType = result.Type()
+
+	actual, err := toMap(result)
+	if err != nil {
+		out.Regressed = true
+		out.Diffs = []FieldDiff{{Path: "", Kind: DiffChanged, Expected: "valid JSON", Actual: err.Error()}}
+		return out
+	}
+
+	expected := make(map[string]interface{}, len(c.Expected))
+	for k, v := range c.Expected {
+		if !IgnoredFields[k] {
+			expected[k] = v
+		}
+	}
+	for k := range IgnoredFields {
+		delete(actual, k)
+	}
+
+	out.Diffs = Diff(expected, actual)
+	for _, d := range out.Diffs {
+		if d.Kind != DiffExtra {
+			out.Regressed = true
+			break
+		}
+	}
+	return out
+}
+
+// RunAll runs every case and returns the outcomes in input order.
+func RunAll(reg *registry.Registry, cases []Case) []Outcome {
+	outcomes := make([]Outcome, 0, len(cases))
+	for _, c := range cases {
+		outcomes = append(outcomes, Run(reg, c))
+	}
+	return outcomes
+}
+
+// selectResult returns the first result of the wanted type, or the first result
+// when no type is specified.
+func selectResult(results []registry.Result, wantType string) registry.Result {
+	for _, r := range results {
+		if wantType == "" || r.Type() == wantType {
+			return r
+		}
+	}
+	return nil
+}
+
+// resultTypes lists the types of the given results, or "none" when empty.
+func resultTypes(results []registry.Result) string {
+	if len(results) == 0 {
+		return "none"
+	}
+	types := make([]string, len(results))
+	for i, r := range results {
+		types[i] = r.Type()
+	}
+	return strings.Join(types, ",")
+}
+
+// toMap round-trips a result through JSON so it can be compared with the expectation.
+func toMap(result registry.Result) (map[string]interface{}, error) {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Diff compares two decoded JSON objects and returns the differences sorted by path.
+func Diff(expected, actual map[string]interface{}) []FieldDiff {
+	var diffs []FieldDiff
+	diffValue("", expected, actual, &diffs)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// diffValue recursively compares expected and actual at path.
+func diffValue(path string, expected, actual interface{}, diffs *[]FieldDiff) {
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		act, ok := actual.(map[string]interface{})
+		if !ok {
+			*diffs = append(*diffs, FieldDiff{Path: path, Kind: DiffChanged, Expected: expected, Actual: actual})
+			return
+		}
+		for k, ev := range exp {
+			av, present := act[k]
+			if !present {
+				*diffs = append(*diffs, FieldDiff{Path: joinPath(path, k), Kind: DiffMissing, Expected: ev})
+				continue
+			}
+			diffValue(joinPath(path, k), ev, av, diffs)
+		}
+		for k, av := range act {
+			if _, present := exp[k]; !present {
+				*diffs = append(*diffs, FieldDiff{Path: joinPath(path, k), Kind: DiffExtra, Actual: av})
+			}
+		}
+	case []interface{}:
+		act, ok := actual.([]interface{})
+		if !ok {
+			*diffs = append(*diffs, FieldDiff{Path: path, Kind: DiffChanged, Expected: expected, Actual: actual})
+			return
+		}
+		for i, ev := range exp {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			if i >= len(act) {
+				*diffs = append(*diffs, FieldDiff{Path: elemPath, Kind: DiffMissing, Expected: ev})
+				continue
+			}
+			diffValue(elemPath, ev, act[i], diffs)
+		}
+		for i := len(exp); i < len(act); i++ {
+			*diffs = append(*diffs, FieldDiff{Path: fmt.Sprintf("%s[%d]", path, i), Kind: DiffExtra, Actual: act[i]})
+		}
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			*diffs = append(*diffs, FieldDiff{Path: path, Kind: DiffChanged, Expected: expected, Actual: actual})
+		}
+	}
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// LoadFile reads golden cases from a JSON file containing an array of cases.
+func LoadFile(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// LoadSQLite reads golden cases from the legacy SQLite corpus. The expected
+// output is expected_json when set, otherwise the stored parsed_json.
+func LoadSQLite(db *storage.SQLiteDB) ([]Case, error) {
+	messages, err := db.GetGoldenMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	cases := make([]Case, 0, len(messages))
+	for _, m := range messages {
+		c := Case{
+			ID:         m.ID,
+			RawText:    m.RawText,
+			Label:      m.Label,
+			ParserType: m.ParserType,
+			Annotation: m.Annotation,
+		}
+		expected := m.ExpectedJSON
+		if expected == "" {
+			expected = m.ParsedJSON
+		}
+		if expected != "" {
+			if err := json.Unmarshal([]byte(expected), &c.Expected); err != nil {
+				return nil, fmt.Errorf("decode expected JSON for message %d: %w", m.ID, err)
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// LoadPostgres reads golden annotations from PostgreSQL and fetches the raw
+// messages from ClickHouse. The expected output is expected_json when set,
+// otherwise the stored parsed_json. Annotations whose message no longer exists
+// in ClickHouse are skipped.
+func LoadPostgres(ctx context.Context, pg *storage.PostgresDB, ch *storage.ClickHouseDB) ([]Case, error) {
+	annotations, err := pg.GetGoldenMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cases := make([]Case, 0, len(annotations))
+	for _, a := range annotations {
+		msg, err := ch.GetByID(ctx, uint64(a.MessageID))
+		if err != nil {
+			return nil, fmt.Errorf("fetch message %d: %w", a.MessageID, err)
+		}
+		if msg == nil {
+			continue
+		}
+
+		c := Case{
+			ID:         a.MessageID,
+			RawText:    msg.RawText,
+			Label:      msg.Label,
+			ParserType: msg.ParserType,
+			Annotation: a.Annotation,
+			Expected:   a.ExpectedJSON,
+		}
+		if len(c.Expected) == 0 && msg.ParsedJSON != "" {
+			if err := json.Unmarshal([]byte(msg.ParsedJSON), &c.Expected); err != nil {
+				return nil, fmt.Errorf("decode parsed JSON for message %d: %w", a.MessageID, err)
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
@@ -0,0 +1,150 @@
+package golden
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
+)
+
+func TestDiff(t *testing.T) {
+	expected := map[string]interface{}{
+		"origin":  "YSSY",
+		"runway":  "34L",
+		"nested":  map[string]interface{}{"a": 1.0},
+		"runways": []interface{}{"16L", "16R"},
+	}
+	actual := map[string]interface{}{
+		"origin":  "YSSY",
+		"runway":  "34R",
+		"nested":  map[string]interface{}{"a": 1.0, "b": 2.0},
+		"runways": []interface{}{"16L"},
+		"squawk":  "1234",
+	}
+
+	diffs := Diff(expected, actual)
+
+	want := []FieldDiff{
+		{Path: "nested.b", Kind: DiffExtra},
+		{Path: "runway", Kind: DiffChanged},
+		{Path: "runways[1]", Kind: DiffMissing},
+		{Path: "squawk", Kind: DiffExtra},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs %+v, want %d", len(diffs), diffs, len(want))
+	}
+	for i := range want {
+		if diffs[i].Path != want[i].Path || diffs[i].Kind != want[i].Kind {
+			t.Errorf("diff[%d] = %s %s, want %s %s", i, diffs[i].Path, diffs[i].Kind, want[i].Path, want[i].Kind)
+		}
+	}
+}
+
+func TestRunReportsMissingType(t *testing.T) {
+	reg := registry.Default()
+	reg.Sort()
+
+	out := Run(reg, Case{ID: 1, Label: "ZZ", RawText: "NOTHING TO SEE", ParserType: "pdc"})
+	if !out.Regressed {
+		t.Fatalf("expected regression when no result of the expected type is produced")
+	}
+}
+
+// TestGoldenFiles runs every golden case in testdata against the live registry.
+// Add cases by exporting golden messages from the review UI (/api/export/json)
+// into a new file in testdata.
+func TestGoldenFiles(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("no golden files in testdata")
+	}
+
+	reg := registry.Default()
+	reg.Sort()
+
+	for _, file := range files {
+		cases, err := LoadFile(file)
+		if err != nil {
+			t.Fatalf("load %s: %v", file, err)
+		}
+		for _, out := range RunAll(reg, cases) {
+			if !out.Regressed {
+				continue
+			}
+			for _, d := range out.Diffs {
+				if d.Kind == DiffExtra {
+					continue
+				}
+				t.Errorf("%s: message %d (%s): %s %s: expected %v, got %v",
+					filepath.Base(file), out.Case.ID, out.Case.ParserType, d.Path, d.Kind, d.Expected, d.Actual)
+			}
+		}
+	}
+}
+
+// TestAnchorsHold checks, against every golden message, that no parser's
+// QuickCheck passes for text without one of the anchors it declares, which
+// would make the registry's anchor index skip it.
+func TestAnchorsHold(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("no golden files in testdata")
+	}
+
+	for _, file := range files {
+		cases, err := LoadFile(file)
+		if err != nil {
+			t.Fatalf("load %s: %v", file, err)
+		}
+		for _, c := range cases {
+			upper := strings.ToUpper(c.RawText)
+			for _, p := range registry.Default().AllParsers() {
+				a, ok := p.(registry.Anchored)
+				if !ok || !p.QuickCheck(c.RawText) {
+					continue
+				}
+				found := false
+				for _, anchor := range a.Anchors() {
+					found = found || strings.Contains(upper, strings.ToUpper(anchor))
+				}
+				if !found {
+					t.Errorf("%s: message %d: %s QuickCheck passed without any of its anchors %q",
+						filepath.Base(file), c.ID, p.Name(), a.Anchors())
+				}
+			}
+		}
+	}
+}
+
+func TestRunFixture(t *testing.T) {
+	reg := registry.Default()
+	reg.Sort()
+	msg := []byte(`{"id":1,"label":"B2","text":"BAW117 CLRD TO EGLL VIA TRACK B, FL350, M082"}`)
+
+	// Expecting no match from a parser that matches.
+	_, diffs, err := RunFixture(reg, Fixture{Parser: "labelb2", Message: msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Kind != DiffExtra {
+		t.Errorf("diffs = %+v, want the result reported as extra", diffs)
+	}
+
+	// A parser that does not take the label is not run.
+	actual, diffs, err := RunFixture(reg, Fixture{Parser: "oceanic_clearance", Message: msg})
+	if err != nil || actual != nil || diffs != nil {
+		t.Errorf("RunFixture = %v, %+v, %v, want no result", actual, diffs, err)
+	}
+
+	if _, _, err := RunFixture(reg, Fixture{Parser: "nonesuch", Message: msg}); err == nil {
+		t.Error("no error for an unknown parser")
+	}
+}
@@ -0,0 +1,164 @@
+// Package groundstation identifies the ground stations a message was
+// exchanged with, and reads the reference data (provider, name and region)
+// used to break coverage down by network provider.
+//
+// Two kinds of station are recognised: ATS facilities, by the seven-character
+// address ADS-C and CPDLC messages carry (e.g. "BNECAYA"), and VDL2 ground
+// stations, by their 24-bit link-layer address.
+package groundstation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/vdl2"
+)
+
+// Station kinds.
+const (
+	KindATS  = "ats"  // ATS facility address from ADS-C and CPDLC.
+	KindVDL2 = "vdl2" // VDL2 ground station address.
+)
+
+var (
+	atsRe  = regexp.MustCompile(`^[A-Z0-9]{7}$`)
+	vdl2Re = regexp.MustCompile(`^[0-9A-F]{6}$`)
+)
+
+// ID identifies a ground station.
+type ID struct {
+	Kind string
+	ID   string
+}
+
+// Observed returns the ground stations a message and its parse results name,
+// each once: the ATS facility of ADS-C and CPDLC results, the ground station
+// of VDL2 XID frames, and the ground station end of VDL2 messages.
+func Observed(msg *acars.Message, results []registry.Result) []ID {
+	var out []ID
+	add := func(kind, id string) {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if !valid(kind, id) {
+			return
+		}
+		for _, seen := range out {
+			if seen.Kind == kind && seen.ID == id {
+				return
+			}
+		}
+		out = append(out, ID{Kind: kind, ID: id})
+	}
+
+	if msg != nil && msg.Source == vdl2.Source {
+		add(KindVDL2, msg.GroundStationHex())
+	}
+	for _, r := range results {
+		switch r.Type() {
+		case "adsc", "cpdlc":
+			add(KindATS, stringField(r, "ground_station"))
+		case "vdl2_xid":
+			add(KindVDL2, stringField(r, "ground_station_hex"))
+		}
+	}
+	return out
+}
+
+// valid reports whether id is a well-formed address of the kind.
+func valid(kind, id string) bool {
+	switch kind {
+	case KindATS:
+		return atsRe.MatchString(id)
+	case KindVDL2:
+		return vdl2Re.MatchString(id) && id != "000000"
+	}
+	return false
+}
+
+// stringField returns a top-level string field of a result's JSON.
+func stringField(r registry.Result, name string) string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return ""
+	}
+	s, _ := m[name].(string)
+	return s
+}
+
+// Station is the reference data of a ground station.
+type Station struct {
+	ID
+	Provider string // Network provider, e.g. "ARINC" or "SITA".
+	Name     string
+	Region   string
+}
+
+// LoadFile reads stations from a CSV file. See LoadCSV.
+func LoadFile(path string) ([]Station, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	stations, err := LoadCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return stations, nil
+}
+
+// LoadCSV reads stations from CSV with the columns
+//
+//	kind,id,provider,name,region
+//
+// where kind is "ats" or "vdl2". The name and region may be empty or absent,
+// providers are upper-cased, and a header row is skipped.
+func LoadCSV(r io.Reader) ([]Station, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	var stations []Station
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return stations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 3 {
+			return nil, fmt.Errorf("line %d: want kind,id,provider,name,region", line)
+		}
+		s := Station{
+			ID:       ID{Kind: strings.ToLower(strings.TrimSpace(rec[0])), ID: strings.ToUpper(strings.TrimSpace(rec[1]))},
+			Provider: strings.ToUpper(strings.TrimSpace(rec[2])),
+		}
+		if len(rec) > 3 {
+			s.Name = strings.TrimSpace(rec[3])
+		}
+		if len(rec) > 4 {
+			s.Region = strings.TrimSpace(rec[4])
+		}
+		if !valid(s.Kind, s.ID.ID) {
+			if line == 1 {
+				continue // Header row.
+			}
+			return nil, fmt.Errorf("line %d: invalid %s station %q", line, s.Kind, s.ID.ID)
+		}
+		stations = append(stations, s)
+	}
+}
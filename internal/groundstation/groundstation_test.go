@@ -0,0 +1,83 @@
+package groundstation
+
+import (
+	"strings"
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/vdl2"
+)
+
+type testResult struct {
+	kind          string
+	GroundStation string `json:"ground_station,omitempty"`
+	StationHex    string `json:"ground_station_hex,omitempty"`
+}
+
+func (r *testResult) Type() string     { return r.kind }
+func (r *testResult) MessageID() int64 { return 1 }
+
+func ids(got []ID) string {
+	s := make([]string, len(got))
+	for i, id := range got {
+		s[i] = id.Kind + ":" + id.ID
+	}
+	return strings.Join(s, ",")
+}
+
+func TestObserved(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *acars.Message
+		results []registry.Result
+		want    string
+	}{
+		{"adsc facility", &acars.Message{Label: "B6"}, []registry.Result{&testResult{kind: "adsc", GroundStation: "bnecaya"}}, "ats:BNECAYA"},
+		{"cpdlc and adsc once", &acars.Message{Label: "AA"}, []registry.Result{
+			&testResult{kind: "cpdlc", GroundStation: "AKLCDYA"},
+			&testResult{kind: "adsc", GroundStation: "AKLCDYA"},
+		}, "ats:AKLCDYA"},
+		{"vdl2 message", &acars.Message{Source: vdl2.Source, LinkDirection: "downlink", ToHex: "10916c"}, nil, "vdl2:10916C"},
+		{"vdl2 xid", &acars.Message{Source: vdl2.Source, LinkDirection: "uplink", FromHex: "10916C"},
+			[]registry.Result{&testResult{kind: "vdl2_xid", StationHex: "10916C"}}, "vdl2:10916C"},
+		{"acars feed has no vdl2 station", &acars.Message{LinkDirection: "downlink", ToHex: "10916C"}, nil, ""},
+		{"malformed facility", &acars.Message{}, []registry.Result{&testResult{kind: "adsc", GroundStation: "BNE"}}, ""},
+		{"other results ignored", &acars.Message{}, []registry.Result{&testResult{kind: "pdc", GroundStation: "BNECAYA"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(Observed(tt.msg, tt.results)); got != tt.want {
+				t.Errorf("Observed() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	const csv = `kind,id,provider,name,region
+ats,BNECAYA,arinc,Brisbane Centre,Oceania
+# VDL2 stations
+vdl2,10916c,SITA,,Europe
+ats,NYCODYA,ARINC
+`
+	stations, err := LoadCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("LoadCSV() error = %v", err)
+	}
+	if len(stations) != 3 {
+		t.Fatalf("got %d stations, want 3", len(stations))
+	}
+	if s := stations[0]; s.Kind != KindATS || s.ID.ID != "BNECAYA" || s.Provider != "ARINC" || s.Name != "Brisbane Centre" || s.Region != "Oceania" {
+		t.Errorf("stations[0] = %+v", s)
+	}
+	if s := stations[1]; s.Kind != KindVDL2 || s.ID.ID != "10916C" || s.Name != "" || s.Region != "Europe" {
+		t.Errorf("stations[1] = %+v", s)
+	}
+
+	for _, bad := range []string{"ats,BNECAYA\n", "ats,BNECAYA,ARINC\nhfdl,1,ARINC\n", "ats,BNECAYA,ARINC\nvdl2,XYZ,SITA\n"} {
+		if _, err := LoadCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadCSV(%q) succeeded, want error", bad)
+		}
+	}
+}
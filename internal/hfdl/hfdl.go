@@ -0,0 +1,279 @@
+// Package hfdl decodes the JSON output of dumphfdl.
+//
+// An HFDL frame carries either a squitter (SPDU) broadcast by a ground station,
+// or a link-layer PDU (LPDU) from or to an aircraft. LPDUs in turn carry an
+// HFDL network PDU (HFNPDU): enveloped ACARS, or the HFDL-only performance
+// data and frequency data reports, which include the aircraft position. ACARS
+// frames are returned as acars.Message for the parser registry; the others are
+// returned as results of their own so that their positions are not dropped.
+package hfdl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Source is the acars.Message source of messages decoded from HFDL.
+const Source = "hfdl"
+
+// HFNPDU type IDs from the HFDL specification (ARINC 753).
+const (
+	typePerformanceData = 209
+	typeFrequencyData   = 213
+	typeEnvelopedData   = 255
+)
+
+// Envelope is the top level of a dumphfdl JSON line.
+type Envelope struct {
+	HFDL *Frame `json:"hfdl"`
+}
+
+// Frame is one decoded HFDL frame.
+type Frame struct {
+	Station   string    `json:"station,omitempty"`
+	Time      Time      `json:"t"`
+	Freq      float64   `json:"freq"` // Hz.
+	BitRate   int       `json:"bit_rate,omitempty"`
+	SigLevel  float64   `json:"sig_level,omitempty"`
+	Slot      string    `json:"slot,omitempty"`
+	SPDU      *SPDU     `json:"spdu,omitempty"`
+	LPDU      *LPDU     `json:"lpdu,omitempty"`
+	Timestamp time.Time `json:"-"` // Set from Time by DecodeFrame.
+}
+
+// Time is the receive time of a frame.
+type Time struct {
+	Sec  int64 `json:"sec"`
+	Usec int64 `json:"usec"`
+}
+
+// Entity is the source or destination of a PDU.
+type Entity struct {
+	Type   string  `json:"type"` // "Aircraft" or "Ground station".
+	ID     int     `json:"id"`
+	Name   string  `json:"name,omitempty"`
+	ACInfo *ACInfo `json:"ac_info,omitempty"`
+}
+
+// ACInfo identifies an aircraft by its ICAO address.
+type ACInfo struct {
+	ICAO string `json:"icao"`
+}
+
+// Frequency is an HFDL channel of a ground station.
+type Frequency struct {
+	ID   int     `json:"id"`
+	Freq float64 `json:"freq"` // kHz.
+}
+
+// SPDU is a squitter broadcast by a ground station.
+type SPDU struct {
+	Err             bool       `json:"err"`
+	Src             Entity     `json:"src"`
+	SystableVersion int        `json:"systable_version"`
+	GSStatus        []GSStatus `json:"gs_status"`
+}
+
+// GSStatus is the status of one ground station in a squitter.
+type GSStatus struct {
+	GS      Entity      `json:"gs"`
+	UTCSync bool        `json:"utc_sync"`
+	Freqs   []Frequency `json:"freqs"`
+}
+
+// LPDU is a link-layer PDU.
+type LPDU struct {
+	Err    bool    `json:"err"`
+	Src    Entity  `json:"src"`
+	Dst    Entity  `json:"dst"`
+	Type   TypeID  `json:"type"`
+	HFNPDU *HFNPDU `json:"hfnpdu,omitempty"`
+	ACInfo *ACInfo `json:"ac_info,omitempty"` // Logon confirm.
+}
+
+// TypeID is a numbered PDU type.
+type TypeID struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// HFNPDU is an HFDL network PDU.
+type HFNPDU struct {
+	Err       bool          `json:"err"`
+	Type      TypeID        `json:"type"`
+	FlightID  string        `json:"flight_id,omitempty"`
+	Pos       *Position     `json:"pos,omitempty"`
+	Time      *ClockTime    `json:"time,omitempty"`
+	UTCTime   *ClockTime    `json:"utc_time,omitempty"`
+	FlightLeg int           `json:"flight_leg,omitempty"`
+	GS        *Entity       `json:"gs,omitempty"`
+	Frequency *Frequency    `json:"frequency,omitempty"`
+	FreqData  []FreqData    `json:"freq_data,omitempty"`
+	Cause     *ChangeCause  `json:"last_freq_change_cause,omitempty"`
+	ACARS     *ACARS        `json:"acars,omitempty"`
+	Searches  *SearchCounts `json:"freq_search_cnt,omitempty"`
+}
+
+// Position is an aircraft position reported in an HFNPDU.
+type Position struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// ClockTime is a time of day reported in an HFNPDU.
+type ClockTime struct {
+	Hour int `json:"hour"`
+	Min  int `json:"min"`
+	Sec  int `json:"sec"`
+}
+
+// FreqData lists the frequencies of one ground station that an aircraft is
+// listening on and has heard.
+type FreqData struct {
+	GS        Entity      `json:"gs"`
+	Listening []Frequency `json:"listening_on_freqs"`
+	Heard     []Frequency `json:"heard_on_freqs"`
+}
+
+// ChangeCause is the reason an aircraft last changed frequency.
+type ChangeCause struct {
+	Code  int    `json:"code"`
+	Descr string `json:"descr"`
+}
+
+// SearchCounts are the frequency searches made on the current and previous
+// flight legs.
+type SearchCounts struct {
+	CurLeg  int `json:"cur_leg"`
+	PrevLeg int `json:"prev_leg"`
+}
+
+// ACARS is an ACARS message enveloped in an HFNPDU.
+type ACARS struct {
+	Err      bool   `json:"err"`
+	CRCOK    bool   `json:"crc_ok"`
+	Reg      string `json:"reg"`
+	Mode     string `json:"mode,omitempty"`
+	Label    string `json:"label"`
+	BlockID  string `json:"blk_id,omitempty"`
+	Ack      string `json:"ack,omitempty"`
+	Flight   string `json:"flight,omitempty"`
+	MsgNum   string `json:"msg_num,omitempty"`
+	MsgNumSq string `json:"msg_num_seq,omitempty"`
+	Text     string `json:"msg_text"`
+}
+
+// Decoded is the content of one dumphfdl line: an ACARS message, HFDL
+// results, both or neither (e.g. logon and acknowledgement frames).
+type Decoded struct {
+	Message *acars.Message
+	Results []registry.Result
+}
+
+// ErrNotHFDL is returned for JSON that is not dumphfdl output.
+var ErrNotHFDL = errors.New("not a dumphfdl frame")
+
+// Decode decodes one line of dumphfdl JSON output.
+func Decode(data []byte) (*Decoded, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("decode hfdl: %w", err)
+	}
+	if env.HFDL == nil {
+		return nil, ErrNotHFDL
+	}
+	return DecodeFrame(env.HFDL), nil
+}
+
+// DecodeFrame extracts the ACARS message and HFDL results from a frame.
+func DecodeFrame(f *Frame) *Decoded {
+	f.Timestamp = time.Unix(f.Time.Sec, f.Time.Usec*1000).UTC()
+	out := &Decoded{}
+
+	if f.SPDU != nil && !f.SPDU.Err {
+		out.Results = append(out.Results, squitterResult(f))
+	}
+
+	l := f.LPDU
+	if l == nil || l.Err || l.HFNPDU == nil || l.HFNPDU.Err {
+		return out
+	}
+	switch l.HFNPDU.Type.ID {
+	case typePerformanceData:
+		out.Results = append(out.Results, performanceResult(f))
+	case typeFrequencyData:
+		out.Results = append(out.Results, frequencyDataResult(f))
+	case typeEnvelopedData:
+		if a := l.HFNPDU.ACARS; a != nil && !a.Err {
+			out.Message = acarsMessage(f, a)
+		}
+	}
+	return out
+}
+
+// aircraftICAO returns the ICAO address of the aircraft that sent or receives
+// an LPDU, when dumphfdl knows it.
+func aircraftICAO(l *LPDU) string {
+	for _, e := range []Entity{l.Src, l.Dst} {
+		if e.Type == "Aircraft" && e.ACInfo != nil && e.ACInfo.ICAO != "" {
+			return strings.ToUpper(e.ACInfo.ICAO)
+		}
+	}
+	if l.ACInfo != nil {
+		return strings.ToUpper(l.ACInfo.ICAO)
+	}
+	return ""
+}
+
+// groundStation returns the ground station end of an LPDU.
+func groundStation(l *LPDU) Entity {
+	if l.Src.Type == "Ground station" {
+		return l.Src
+	}
+	return l.Dst
+}
+
+func acarsMessage(f *Frame, a *ACARS) *acars.Message {
+	msg := &acars.Message{
+		Source:    Source,
+		Timestamp: f.Timestamp.Format(time.RFC3339Nano),
+		Tail:      strings.TrimLeft(a.Reg, "."),
+		Text:      a.Text,
+		Label:     a.Label,
+		Frequency: f.Freq / 1e6,
+		Mode:      a.Mode,
+		BlockID:   a.BlockID,
+		Ack:       a.Ack,
+		MsgNo:     a.MsgNum + a.MsgNumSq,
+	}
+	if hex := aircraftICAO(f.LPDU); hex != "" {
+		msg.Airframe = &acars.Airframe{Tail: msg.Tail, ICAO: hex}
+	}
+	if a.Flight != "" {
+		msg.Flight = &acars.Flight{Flight: strings.TrimSpace(a.Flight)}
+	}
+	if gs := groundStation(f.LPDU); gs.Name != "" || f.Station != "" {
+		msg.Station = &acars.Station{ID: f.Station, Ident: gs.Name}
+	}
+	return msg
+}
+
+// clock formats a time of day as HH:MM:SS.
+func clock(t *ClockTime) string {
+	if t == nil {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Min, t.Sec)
+}
+
+// validPosition reports whether a reported position is set. dumphfdl reports
+// 180/180 when the aircraft has no position.
+func validPosition(p *Position) bool {
+	return p != nil && !(p.Lat == 0 && p.Lon == 0) && p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
@@ -0,0 +1,113 @@
+package hfdl
+
+import (
+	"errors"
+	"testing"
+)
+
+const squitterJSON = `{"hfdl":{"app":{"name":"dumphfdl","ver":"1.4.1"},"station":"YPAD","t":{"sec":1706090400,"usec":250000},"freq":8927000,"bit_rate":300,"sig_level":-18.2,"slot":"S","spdu":{"err":false,"src":{"type":"Ground station","id":2,"name":"Molokai, Hawaii"},"spdu_version":0,"change_note":"None","frame_index":120,"frame_offset":0,"min_priority":0,"systable_version":51,"gs_status":[{"gs":{"type":"Ground station","id":2,"name":"Molokai, Hawaii"},"utc_sync":true,"freqs":[{"id":1,"freq":21937.0},{"id":4,"freq":8927.0}]},{"gs":{"type":"Ground station","id":7,"name":"Auckland, New Zealand"},"utc_sync":true,"freqs":[{"id":3,"freq":8921.0}]}]}}}`
+
+const performanceJSON = `{"hfdl":{"station":"YPAD","t":{"sec":1706090460,"usec":0},"freq":8927000,"lpdu":{"err":false,"src":{"type":"Aircraft","id":15,"ac_info":{"icao":"7c6db8"}},"dst":{"type":"Ground station","id":2,"name":"Molokai, Hawaii"},"type":{"id":48,"name":"Unnumbered data"},"hfnpdu":{"err":false,"type":{"id":209,"name":"Performance data"},"version":1,"flight_id":"QFA11  ","pos":{"lat":21.456,"lon":-157.875},"time":{"hour":10,"min":0,"sec":52},"flight_leg":2,"gs":{"type":"Ground station","id":2,"name":"Molokai, Hawaii"},"frequency":{"id":4,"freq":8927.0},"freq_search_cnt":{"cur_leg":3,"prev_leg":0},"last_freq_change_cause":{"code":1,"descr":"Too many NACKs"}}}}}`
+
+const frequencyDataJSON = `{"hfdl":{"t":{"sec":1706090470,"usec":0},"freq":8927000,"lpdu":{"err":false,"src":{"type":"Aircraft","id":15,"ac_info":{"icao":"7C6DB8"}},"dst":{"type":"Ground station","id":2},"type":{"id":48,"name":"Unnumbered data"},"hfnpdu":{"err":false,"type":{"id":213,"name":"Frequency data"},"flight_id":"QFA11","pos":{"lat":180.0,"lon":180.0},"utc_time":{"hour":10,"min":1,"sec":0},"freq_data":[{"gs":{"type":"Ground station","id":2,"name":"Molokai, Hawaii"},"listening_on_freqs":[{"id":4,"freq":8927.0}],"heard_on_freqs":[{"id":1,"freq":21937.0},{"id":4,"freq":8927.0}]}]}}}}`
+
+const acarsJSON = `{"hfdl":{"station":"YPAD","t":{"sec":1706090500,"usec":500000},"freq":8927000,"lpdu":{"err":false,"src":{"type":"Aircraft","id":15,"ac_info":{"icao":"7c6db8"}},"dst":{"type":"Ground station","id":2,"name":"Molokai, Hawaii"},"type":{"id":49,"name":"Unnumbered ack'ed data"},"hfnpdu":{"err":false,"type":{"id":255,"name":"Enveloped data"},"acars":{"err":false,"crc_ok":true,"more":false,"reg":".VH-OQA","mode":"2","label":"H1","blk_id":"5","ack":"!","flight":"QF0011","msg_num":"D01","msg_num_seq":"A","msg_text":"POSN21270W157525,VITAS,100052,370"}}}}}`
+
+func TestDecodeSquitter(t *testing.T) {
+	d, err := Decode([]byte(squitterJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if d.Message != nil || len(d.Results) != 1 {
+		t.Fatalf("got message %v and %d results, want 1 squitter", d.Message, len(d.Results))
+	}
+	sq, ok := d.Results[0].(*SquitterResult)
+	if !ok {
+		t.Fatalf("result is %T", d.Results[0])
+	}
+	if sq.StationID != 2 || sq.SystableVersion != 51 || sq.FrequencyKHz != 8927 || sq.Timestamp != "2024-01-24T10:00:00Z" {
+		t.Errorf("squitter = %+v", sq)
+	}
+	if len(sq.Stations) != 2 || sq.Stations[1].Name != "Auckland, New Zealand" || len(sq.Stations[0].FrequenciesKHz) != 2 {
+		t.Errorf("stations = %+v", sq.Stations)
+	}
+}
+
+func TestDecodePerformanceData(t *testing.T) {
+	d, err := Decode([]byte(performanceJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(d.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(d.Results))
+	}
+	p, ok := d.Results[0].(*PerformanceResult)
+	if !ok {
+		t.Fatalf("result is %T", d.Results[0])
+	}
+	if p.AircraftICAO != "7C6DB8" || p.FlightNumber != "QFA11" || p.Latitude != 21.456 || p.Longitude != -157.875 {
+		t.Errorf("identity or position = %+v", p)
+	}
+	if p.ReportTime != "10:00:52" || p.FrequencyKHz != 8927 || p.FrequencySearches != 3 || p.ChangeCause != "Too many NACKs" {
+		t.Errorf("performance data = %+v", p)
+	}
+}
+
+func TestDecodeFrequencyData(t *testing.T) {
+	d, err := Decode([]byte(frequencyDataJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	fd, ok := d.Results[0].(*FrequencyDataResult)
+	if !ok {
+		t.Fatalf("result is %T", d.Results[0])
+	}
+	// 180/180 means the aircraft has no position.
+	if fd.Latitude != 0 || fd.Longitude != 0 {
+		t.Errorf("position = %v,%v, want unset", fd.Latitude, fd.Longitude)
+	}
+	if len(fd.Stations) != 1 || len(fd.Stations[0].HeardKHz) != 2 || fd.ReportTime != "10:01:00" {
+		t.Errorf("frequency data = %+v", fd)
+	}
+}
+
+func TestDecodeEnvelopedACARS(t *testing.T) {
+	d, err := Decode([]byte(acarsJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(d.Results) != 0 || d.Message == nil {
+		t.Fatalf("got message %v and %d results, want a message only", d.Message, len(d.Results))
+	}
+	m := d.Message
+	if m.Source != Source || m.Tail != "VH-OQA" || m.Label != "H1" || m.BlockID != "5" || m.Frequency != 8.927 {
+		t.Errorf("message = %+v", m)
+	}
+	if m.Mode != "2" || m.Ack != "!" || m.MsgNo != "D01A" {
+		t.Errorf("mode %q, ack %q, msgno %q", m.Mode, m.Ack, m.MsgNo)
+	}
+	if m.Timestamp != "2024-01-24T10:01:40.5Z" {
+		t.Errorf("Timestamp = %q", m.Timestamp)
+	}
+	if m.Airframe == nil || m.Airframe.ICAO != "7C6DB8" || m.Flight == nil || m.Flight.Flight != "QF0011" {
+		t.Errorf("airframe %+v, flight %+v", m.Airframe, m.Flight)
+	}
+	if m.Station == nil || m.Station.ID != "YPAD" || m.Station.Ident != "Molokai, Hawaii" {
+		t.Errorf("station = %+v", m.Station)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode([]byte(`{"vdl2":{}}`)); !errors.Is(err, ErrNotHFDL) {
+		t.Errorf("non-HFDL error = %v, want ErrNotHFDL", err)
+	}
+	if _, err := Decode([]byte(`{"hfdl":`)); err == nil {
+		t.Error("expected error for truncated JSON")
+	}
+
+	// Frames that failed their CRC carry nothing usable.
+	d, err := Decode([]byte(`{"hfdl":{"t":{"sec":0,"usec":0},"freq":8927000,"lpdu":{"err":true}}}`))
+	if err != nil || d.Message != nil || len(d.Results) != 0 {
+		t.Errorf("errored LPDU = %+v, %v", d, err)
+	}
+}
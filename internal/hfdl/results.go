@@ -0,0 +1,153 @@
+package hfdl
+
+import (
+	"strings"
+	"time"
+)
+
+// StationStatus is the status of one ground station, as broadcast in a
+// squitter.
+type StationStatus struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name,omitempty"`
+	UTCSync        bool      `json:"utc_sync"`
+	FrequenciesKHz []float64 `json:"frequencies_khz,omitempty"`
+}
+
+// SquitterResult is a ground station squitter: the frequencies in use by the
+// sending station and the other stations it knows about.
+type SquitterResult struct {
+	Timestamp       string          `json:"timestamp"`
+	StationID       int             `json:"ground_station_id"`
+	Station         string          `json:"ground_station,omitempty"`
+	FrequencyKHz    float64         `json:"frequency_khz,omitempty"`
+	SystableVersion int             `json:"systable_version"`
+	Stations        []StationStatus `json:"stations,omitempty"`
+}
+
+func (r *SquitterResult) Type() string     { return "hfdl_squitter" }
+func (r *SquitterResult) MessageID() int64 { return 0 }
+
+// PerformanceResult is an HFNPDU performance data report, sent by an aircraft
+// on every frequency change and periodically thereafter.
+type PerformanceResult struct {
+	Timestamp         string  `json:"timestamp"`
+	AircraftICAO      string  `json:"aircraft_icao,omitempty"`
+	FlightNumber      string  `json:"flight_number,omitempty"`
+	Latitude          float64 `json:"latitude,omitempty"`
+	Longitude         float64 `json:"longitude,omitempty"`
+	ReportTime        string  `json:"report_time,omitempty"` // HH:MM:SS UTC.
+	FlightLeg         int     `json:"flight_leg,omitempty"`
+	StationID         int     `json:"ground_station_id,omitempty"`
+	Station           string  `json:"ground_station,omitempty"`
+	FrequencyKHz      float64 `json:"frequency_khz,omitempty"`
+	FrequencySearches int     `json:"frequency_searches,omitempty"` // On the current flight leg.
+	ChangeCause       string  `json:"frequency_change_cause,omitempty"`
+}
+
+func (r *PerformanceResult) Type() string     { return "hfdl_performance" }
+func (r *PerformanceResult) MessageID() int64 { return 0 }
+
+// StationFrequencies lists the frequencies of one ground station that an
+// aircraft is listening on and has heard.
+type StationFrequencies struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name,omitempty"`
+	ListeningKHz []float64 `json:"listening_khz,omitempty"`
+	HeardKHz     []float64 `json:"heard_khz,omitempty"`
+}
+
+// FrequencyDataResult is an HFNPDU frequency data report: the ground station
+// frequencies an aircraft can hear, used by the ground to choose a frequency.
+type FrequencyDataResult struct {
+	Timestamp    string               `json:"timestamp"`
+	AircraftICAO string               `json:"aircraft_icao,omitempty"`
+	FlightNumber string               `json:"flight_number,omitempty"`
+	Latitude     float64              `json:"latitude,omitempty"`
+	Longitude    float64              `json:"longitude,omitempty"`
+	ReportTime   string               `json:"report_time,omitempty"` // HH:MM:SS UTC.
+	Stations     []StationFrequencies `json:"stations,omitempty"`
+}
+
+func (r *FrequencyDataResult) Type() string     { return "hfdl_frequency_data" }
+func (r *FrequencyDataResult) MessageID() int64 { return 0 }
+
+func frequenciesKHz(freqs []Frequency) []float64 {
+	if len(freqs) == 0 {
+		return nil
+	}
+	out := make([]float64, len(freqs))
+	for i, f := range freqs {
+		out[i] = f.Freq
+	}
+	return out
+}
+
+func squitterResult(f *Frame) *SquitterResult {
+	s := f.SPDU
+	r := &SquitterResult{
+		Timestamp:       f.Timestamp.Format(time.RFC3339),
+		StationID:       s.Src.ID,
+		Station:         s.Src.Name,
+		FrequencyKHz:    f.Freq / 1e3,
+		SystableVersion: s.SystableVersion,
+	}
+	for _, gs := range s.GSStatus {
+		r.Stations = append(r.Stations, StationStatus{
+			ID:             gs.GS.ID,
+			Name:           gs.GS.Name,
+			UTCSync:        gs.UTCSync,
+			FrequenciesKHz: frequenciesKHz(gs.Freqs),
+		})
+	}
+	return r
+}
+
+func performanceResult(f *Frame) *PerformanceResult {
+	n := f.LPDU.HFNPDU
+	r := &PerformanceResult{
+		Timestamp:    f.Timestamp.Format(time.RFC3339),
+		AircraftICAO: aircraftICAO(f.LPDU),
+		FlightNumber: strings.TrimSpace(n.FlightID),
+		ReportTime:   clock(n.Time),
+		FlightLeg:    n.FlightLeg,
+	}
+	if validPosition(n.Pos) {
+		r.Latitude, r.Longitude = n.Pos.Lat, n.Pos.Lon
+	}
+	if n.GS != nil {
+		r.StationID, r.Station = n.GS.ID, n.GS.Name
+	}
+	if n.Frequency != nil {
+		r.FrequencyKHz = n.Frequency.Freq
+	}
+	if n.Searches != nil {
+		r.FrequencySearches = n.Searches.CurLeg
+	}
+	if n.Cause != nil {
+		r.ChangeCause = n.Cause.Descr
+	}
+	return r
+}
+
+func frequencyDataResult(f *Frame) *FrequencyDataResult {
+	n := f.LPDU.HFNPDU
+	r := &FrequencyDataResult{
+		Timestamp:    f.Timestamp.Format(time.RFC3339),
+		AircraftICAO: aircraftICAO(f.LPDU),
+		FlightNumber: strings.TrimSpace(n.FlightID),
+		ReportTime:   clock(n.UTCTime),
+	}
+	if validPosition(n.Pos) {
+		r.Latitude, r.Longitude = n.Pos.Lat, n.Pos.Lon
+	}
+	for _, fd := range n.FreqData {
+		r.Stations = append(r.Stations, StationFrequencies{
+			ID:           fd.GS.ID,
+			Name:         fd.GS.Name,
+			ListeningKHz: frequenciesKHz(fd.Listening),
+			HeardKHz:     frequenciesKHz(fd.Heard),
+		})
+	}
+	return r
+}
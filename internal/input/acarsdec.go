@@ -0,0 +1,139 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/msgtime"
+)
+
+// acarsdecMessage is the JSON shape written by acarsdec and vdlm2dec, relayed
+// unchanged by acars_router, and stored by ACARS Hub (which adds msg_time and
+// a hex icao).
+type acarsdecMessage struct {
+	Timestamp flexFloat       `json:"timestamp"` // Unix seconds with fraction.
+	MsgTime   flexFloat       `json:"msg_time"`  // ACARS Hub: Unix seconds.
+	StationID string          `json:"station_id"`
+	Feeder    string          `json:"feeder"` // Added by some feed aggregators.
+	Tenant    string          `json:"tenant"` // Likewise, by aggregators merging partner networks.
+	Channel   *int            `json:"channel"`
+	Freq      flexFloat       `json:"freq"` // MHz.
+	Mode      string          `json:"mode"`
+	Label     string          `json:"label"`
+	BlockID   string          `json:"block_id"`
+	Ack       json.RawMessage `json:"ack"` // The acknowledged block ID, or false for none.
+	MsgNo     string          `json:"msgno"`
+	Tail      string          `json:"tail"`
+	Flight    string          `json:"flight"`
+	Text      string          `json:"text"`
+	ICAO      json.RawMessage `json:"icao"` // vdlm2dec: a number; ACARS Hub: a hex string.
+	ToAddr    json.RawMessage `json:"toaddr"`
+	FromAddr  json.RawMessage `json:"fromaddr"`
+}
+
+// flexFloat is a number that ACARS Hub may store as a string.
+type flexFloat float64
+
+func (f *flexFloat) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*f = flexFloat(v)
+	return nil
+}
+
+// isAcarsdec reports whether the top-level keys are those of acarsdec-style
+// output: a "freq" in MHz (rather than the flat format's "frequency")
+// alongside one of the fields only these decoders write.
+func isAcarsdec(s shape) bool {
+	return s&shapeFreq != 0 && s&shapeAcarsdec != 0
+}
+
+func decodeAcarsdec(line []byte) (*acars.Message, error) {
+	var m acarsdecMessage
+	if err := json.Unmarshal(line, &m); err != nil {
+		return nil, fmt.Errorf("decode acarsdec message: %w", err)
+	}
+
+	msg := &acars.Message{
+		Tail:      strings.TrimLeft(strings.TrimSpace(m.Tail), "."),
+		Text:      m.Text,
+		Label:     m.Label,
+		Frequency: float64(m.Freq),
+		Mode:      m.Mode,
+		BlockID:   m.BlockID,
+		Ack:       ack(m.Ack),
+		MsgNo:     m.MsgNo,
+		Channel:   m.Channel,
+		Feeder:    strings.TrimSpace(m.Feeder),
+		Tenant:    strings.TrimSpace(m.Tenant),
+		FromHex:   address(m.FromAddr),
+		ToHex:     address(m.ToAddr),
+	}
+	ts := float64(m.Timestamp)
+	if ts == 0 {
+		ts = float64(m.MsgTime)
+	}
+	if ts > 0 {
+		msg.Timestamp = msgtime.FromEpoch(ts).Format(time.RFC3339Nano)
+	}
+	if icao := address(m.ICAO); icao != "" {
+		msg.Airframe = &acars.Airframe{Tail: msg.Tail, ICAO: icao}
+	}
+	if f := strings.TrimSpace(m.Flight); f != "" {
+		msg.Flight = &acars.Flight{Flight: f}
+	}
+	if m.StationID != "" {
+		msg.Station = &acars.Station{ID: m.StationID}
+	}
+	return msg, nil
+}
+
+// ack converts the acknowledgement field. acarsdec and vdlm2dec write false
+// for a message that acknowledges nothing and the block ID otherwise.
+func ack(v json.RawMessage) string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	if string(bytes.TrimSpace(v)) == "false" {
+		return acars.NoAck
+	}
+	return ""
+}
+
+// address converts a 24-bit address to six hex digits. vdlm2dec writes
+// addresses as JSON numbers; ACARS Hub writes them as hex strings.
+func address(v json.RawMessage) string {
+	v = bytes.TrimSpace(v)
+	if len(v) == 0 || string(v) == "null" {
+		return ""
+	}
+	var n uint32
+	if json.Unmarshal(v, &n) == nil {
+		if n == 0 || n > 0xFFFFFF {
+			return ""
+		}
+		return fmt.Sprintf("%06X", n)
+	}
+	var s string
+	if json.Unmarshal(v, &s) != nil {
+		return ""
+	}
+	n64, err := strconv.ParseUint(strings.TrimSpace(s), 16, 24)
+	if err != nil || n64 == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%06X", n64)
+}
@@ -0,0 +1,104 @@
+package input
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeAcarsdec(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantTime  string
+		wantTail  string
+		wantICAO  string
+		wantMsgNo string
+		wantAck   string
+		wantChan  int // -1 for none.
+	}{
+		{
+			name:      "acarsdec",
+			line:      `{"timestamp":1769248800.25,"station_id":"YSSY-ACARS","channel":1,"freq":131.550,"level":-21.4,"error":0,"mode":"2","label":"H1","block_id":"5","ack":false,"tail":".VH-OQA","flight":"QF0001","msgno":"D01A","text":"POS","end":true}`,
+			wantTime:  "2026-01-24T10:00:00.25Z",
+			wantTail:  "VH-OQA",
+			wantMsgNo: "D01A",
+			wantAck:   "!",
+			wantChan:  1,
+		},
+		{
+			name:     "vdlm2dec",
+			line:     `{"timestamp":1769248800.0,"station_id":"YSSY-VDL","channel":0,"freq":136.975,"icao":8154552,"toaddr":1085805,"mode":"2","label":"H1","block_id":"5","ack":"!","tail":"VH-OQA","msgno":"D02A","text":"POS"}`,
+			wantTime: "2026-01-24T10:00:00Z",
+			wantTail: "VH-OQA", wantICAO: "7C6DB8", wantMsgNo: "D02A",
+			wantAck: "!", wantChan: 0,
+		},
+		{
+			name:     "acars hub",
+			line:     `{"msg_time":1769248800,"station_id":"YSSY","freq":"131.550","icao":"7c6db8","label":"16","block_id":"2","tail":"VH-OQA","msgno":"M03A","text":"POS"}`,
+			wantTime: "2026-01-24T10:00:00Z",
+			wantTail: "VH-OQA", wantICAO: "7C6DB8", wantMsgNo: "M03A",
+			wantChan: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Decode([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if d.Format != FormatAcarsdec {
+				t.Errorf("Format = %q, want acarsdec", d.Format)
+			}
+			m := d.Message
+			if m.Timestamp != tt.wantTime || m.Tail != tt.wantTail || m.MsgNo != tt.wantMsgNo {
+				t.Errorf("message = %+v", m)
+			}
+			if m.AircraftICAO() != tt.wantICAO {
+				t.Errorf("AircraftICAO() = %q, want %q", m.AircraftICAO(), tt.wantICAO)
+			}
+			if m.Station == nil || m.Station.ID == "" || m.BlockID == "" || m.Frequency == 0 {
+				t.Errorf("station %+v, block %q, frequency %v", m.Station, m.BlockID, m.Frequency)
+			}
+			if m.Ack != tt.wantAck {
+				t.Errorf("Ack = %q, want %q", m.Ack, tt.wantAck)
+			}
+			if tt.wantChan < 0 && m.Channel != nil || tt.wantChan >= 0 && (m.Channel == nil || *m.Channel != tt.wantChan) {
+				t.Errorf("Channel = %v, want %d", m.Channel, tt.wantChan)
+			}
+		})
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`8154552`, "7C6DB8"},
+		{`"7c6db8"`, "7C6DB8"},
+		{`"a1b"`, "000A1B"},
+		{`0`, ""},
+		{`16777216`, ""},
+		{`"XYZ"`, ""},
+		{`null`, ""},
+	}
+	for _, tt := range tests {
+		if got := address(json.RawMessage(tt.in)); got != tt.want {
+			t.Errorf("address(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeAcarsdecFeeder(t *testing.T) {
+	d, err := Decode([]byte(`{"timestamp":1769248800,"station_id":"YSSY-ACARS","feeder":"sydney-north","tenant":"partner-a","freq":131.550,"label":"H1","tail":"VH-OQA","text":"POS"}`))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if d.Message.Feeder != "sydney-north" || d.Message.FeederID() != "sydney-north" {
+		t.Errorf("Feeder = %q, FeederID() = %q, want sydney-north", d.Message.Feeder, d.Message.FeederID())
+	}
+	if d.Message.Tenant != "partner-a" {
+		t.Errorf("Tenant = %q, want partner-a", d.Message.Tenant)
+	}
+}
@@ -0,0 +1,191 @@
+// Package input decodes receiver and feed output into ACARS messages.
+//
+// Each line of input is one JSON object. The format is detected from its
+// top-level keys:
+//
+//   - "hfdl": dumphfdl output. Enveloped ACARS is returned as a message; the
+//     HFDL-only squitter, performance data and frequency data PDUs are returned
+//     as results (see internal/hfdl).
+//   - "vdl2": dumpvdl2 output. ACARS is returned as a message carrying the
+//     AVLC addresses and direction; XID frames are returned as results (see
+//     internal/vdl2).
+//   - "message" holding an object: the NATS feed format (acars.NATSWrapper).
+//   - "freq" with "station_id", "msgno", "channel" or "msg_time": acarsdec and
+//     vdlm2dec output, as relayed by acars_router and stored by ACARS Hub.
+//   - anything else with a "text" or "label" key: a flat acars.Message.
+//
+// A Reader decodes lines from a file or pipe, and a NATSStream decodes the
+// messages published to a NATS subject, one line per message.
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/hfdl"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/vdl2"
+)
+
+// Formats reported in Decoded.Format.
+const (
+	FormatHFDL     = "hfdl"
+	FormatVDL2     = "vdl2"
+	FormatNATS     = "nats"
+	FormatAcarsdec = "acarsdec"
+	FormatFlat     = "flat"
+)
+
+// maxLineSize is the longest input line accepted. Multi-block messages with
+// nested decoder output can run to a few hundred kilobytes.
+const maxLineSize = 4 * 1024 * 1024
+
+// Decoded is the content of one input line. Message is nil for frames that
+// carry no ACARS message, such as HFDL squitters and VDL2 XIDs. Results holds
+// data decoded from the link layer, to be reported alongside the parser
+// results for Message.
+type Decoded struct {
+	Format  string
+	Message *acars.Message
+	Results []registry.Result
+}
+
+// ErrUnknownFormat is returned for JSON objects in no recognised format.
+var ErrUnknownFormat = errors.New("unrecognised input format")
+
+// Decode decodes one line of input. The format is told from the line's
+// top-level keys by a pass that skips over their values, and the line is then
+// decoded once, into the structure of its format.
+func Decode(line []byte) (*Decoded, error) {
+	s, err := sniff(line)
+	if err != nil {
+		return nil, fmt.Errorf("decode input: %w", err)
+	}
+
+	switch {
+	case s&shapeHFDL != 0:
+		d, err := hfdl.Decode(line)
+		if err != nil {
+			return nil, err
+		}
+		return &Decoded{Format: FormatHFDL, Message: d.Message, Results: d.Results}, nil
+
+	case s&shapeVDL2 != 0:
+		d, err := vdl2.Decode(line)
+		if err != nil {
+			return nil, err
+		}
+		return &Decoded{Format: FormatVDL2, Message: d.Message, Results: d.Results}, nil
+
+	case s&shapeNATS != 0:
+		var w acars.NATSWrapper
+		if err := json.Unmarshal(line, &w); err != nil {
+			return nil, fmt.Errorf("decode nats message: %w", err)
+		}
+		return &Decoded{Format: FormatNATS, Message: w.ToMessage()}, nil
+
+	case isAcarsdec(s):
+		msg, err := decodeAcarsdec(line)
+		if err != nil {
+			return nil, err
+		}
+		return &Decoded{Format: FormatAcarsdec, Message: msg}, nil
+
+	case s&shapeFlat != 0:
+		var msg acars.Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, fmt.Errorf("decode message: %w", err)
+		}
+		return &Decoded{Format: FormatFlat, Message: &msg}, nil
+	}
+	if !json.Valid(line) {
+		return nil, fmt.Errorf("decode input: %w", errSyntax)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Stream is a source of decoded messages. Next returns io.EOF at the end of
+// the input.
+type Stream interface {
+	Next() (*Decoded, error)
+}
+
+// NewStream returns a Stream reading the named input format: "json" for
+// JSON lines in any of the detected formats, or "raw" for acarsdec text
+// output and raw ACARS frames.
+func NewStream(format string, r io.Reader) (Stream, error) {
+	switch format {
+	case "", "json":
+		return NewReader(r), nil
+	case FormatRaw:
+		return NewRawReader(r), nil
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+// A Recorder is passed each line of input as it is read, before it is
+// decoded, such as to keep it in a write-ahead log (see internal/wal). The
+// line is only valid until the call returns.
+type Recorder func(line []byte)
+
+// Recordable is a Stream that passes its input to a Recorder. Reader and
+// NATSStream pass the lines they read; RawReader, whose input is not lines of
+// JSON, passes each message it decodes as a flat JSON line.
+type Recordable interface {
+	Stream
+	SetRecorder(Recorder)
+}
+
+// Reader decodes a stream of input lines.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+	record  Recorder
+}
+
+// SetRecorder sets the Recorder passed each line read.
+func (r *Reader) SetRecorder(rec Recorder) {
+	r.record = rec
+}
+
+// NewReader returns a Reader over r.
+func NewReader(r io.Reader) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &Reader{scanner: s}
+}
+
+// Next returns the next decoded line, skipping blank lines. It returns io.EOF
+// at the end of the input. A line that cannot be decoded returns an error
+// naming the line number; reading can continue with the next call.
+func (r *Reader) Next() (*Decoded, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if r.record != nil {
+			r.record(line)
+		}
+		d, err := Decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return d, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// Line returns the number of the line last read.
+func (r *Reader) Line() int {
+	return r.line
+}
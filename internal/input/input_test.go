@@ -0,0 +1,141 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantFormat string
+		wantLabel  string
+		wantTail   string
+		wantResult bool
+	}{
+		{
+			name:       "flat",
+			line:       `{"id":"12","timestamp":"2026-01-24T10:00:00Z","tail":"VH-OQA","label":"H1","text":"POS"}`,
+			wantFormat: FormatFlat, wantLabel: "H1", wantTail: "VH-OQA",
+		},
+		{
+			name:       "nats",
+			line:       `{"airframe":{"tail":"VH-OQA","icao":"7C6DB8"},"message":{"id":12,"label":"16","text":"POS"}}`,
+			wantFormat: FormatNATS, wantLabel: "16", wantTail: "VH-OQA",
+		},
+		{
+			name:       "hfdl acars",
+			line:       `{"hfdl":{"t":{"sec":1769248800,"usec":0},"freq":8927000,"lpdu":{"src":{"type":"Aircraft","id":1},"dst":{"type":"Ground station","id":2},"type":{"id":49},"hfnpdu":{"type":{"id":255},"acars":{"reg":".VH-OQA","label":"H1","msg_text":"POS"}}}}}`,
+			wantFormat: FormatHFDL, wantLabel: "H1", wantTail: "VH-OQA",
+		},
+		{
+			name:       "vdl2 acars",
+			line:       `{"vdl2":{"t":{"sec":1769248800,"usec":0},"freq":136975000,"avlc":{"src":{"addr":"7c6db8","type":"Aircraft","status":"Airborne"},"dst":{"addr":"10916D","type":"Ground station"},"frame_type":"I","acars":{"reg":".VH-OQA","label":"H1","msg_text":"POS"}}}}`,
+			wantFormat: FormatVDL2, wantLabel: "H1", wantTail: "VH-OQA",
+		},
+		{
+			name:       "hfdl squitter",
+			line:       `{"hfdl":{"t":{"sec":1769248800,"usec":0},"freq":8927000,"spdu":{"src":{"type":"Ground station","id":2},"systable_version":51}}}`,
+			wantFormat: FormatHFDL, wantResult: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Decode([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if d.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", d.Format, tt.wantFormat)
+			}
+			if tt.wantResult != (len(d.Results) > 0) {
+				t.Errorf("got %d results", len(d.Results))
+			}
+			if tt.wantLabel == "" {
+				if d.Message != nil {
+					t.Errorf("unexpected message %+v", d.Message)
+				}
+				return
+			}
+			if d.Message == nil || d.Message.Label != tt.wantLabel || d.Message.Tail != tt.wantTail {
+				t.Errorf("message = %+v", d.Message)
+			}
+		})
+	}
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	if _, err := Decode([]byte(`{"adsb":{"hex":"7C6DB8"}}`)); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("err = %v, want ErrUnknownFormat", err)
+	}
+	if _, err := Decode([]byte(`not json`)); err == nil {
+		t.Error("expected error for non-JSON line")
+	}
+}
+
+func TestReader(t *testing.T) {
+	in := strings.Join([]string{
+		`{"label":"H1","text":"A"}`,
+		``,
+		`garbage`,
+		`{"label":"16","text":"B"}`,
+	}, "\n")
+	r := NewReader(strings.NewReader(in))
+
+	d, err := r.Next()
+	if err != nil || d.Message.Text != "A" {
+		t.Fatalf("first line = %+v, %v", d, err)
+	}
+	if _, err := r.Next(); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("bad line error = %v, want line 3", err)
+	}
+	if d, err := r.Next(); err != nil || d.Message.Text != "B" || r.Line() != 4 {
+		t.Errorf("last line = %+v, %v at line %d", d, err, r.Line())
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want io.EOF", err)
+	}
+}
+
+func TestReaderRecorder(t *testing.T) {
+	r := NewReader(strings.NewReader("{\"label\":\"H1\",\"text\":\"A\"}\n\n  garbage  \n"))
+	var recorded []string
+	r.SetRecorder(func(line []byte) { recorded = append(recorded, string(line)) })
+	for {
+		if _, err := r.Next(); errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	// Lines that cannot be decoded are recorded too.
+	if want := []string{`{"label":"H1","text":"A"}`, `garbage`}; !reflect.DeepEqual(recorded, want) {
+		t.Errorf("recorded %q, want %q", recorded, want)
+	}
+}
+
+// benchmarkLines are a line of each format, as in TestDecode.
+var benchmarkLines = map[string]string{
+	FormatFlat:     `{"id":"12","timestamp":"2026-01-24T10:00:00Z","tail":"VH-OQA","label":"H1","text":"- #M1BPOSN33520E151110,TESAT,0912,370,IGMOR,0925,SCOTI,M48,28035,1620/TS091200,240126"}`,
+	FormatNATS:     `{"source":{"name":"acarshub","application":"acarsdec"},"station":{"ident":"YSSY-1"},"airframe":{"tail":"VH-OQA","icao":"7C6DB8","manufacturer":"Airbus","manufacturer_model":"A380-842"},"flight":{"flight":"QFA1","departing_airport":"YSSY","destination_airport":"EGLL"},"message":{"id":12,"timestamp":"2026-01-24T10:00:00Z","label":"16","text":"POSN33520E151110,TESAT,0912,370","tail":"VH-OQA","frequency":131.55,"block_id":"2","msgno":"M01A","mode":"2"}}`,
+	FormatAcarsdec: `{"timestamp":1769248800.123,"station_id":"YSSY-1","channel":2,"freq":131.550,"level":-42.1,"error":0,"mode":"2","label":"H1","block_id":"2","ack":false,"tail":".VH-OQA","flight":"QF0001","msgno":"M01A","text":"- #M1BPOSN33520E151110,TESAT,0912,370"}`,
+	FormatVDL2:     `{"vdl2":{"t":{"sec":1769248800,"usec":0},"freq":136975000,"avlc":{"src":{"addr":"7c6db8","type":"Aircraft","status":"Airborne"},"dst":{"addr":"10916D","type":"Ground station"},"frame_type":"I","acars":{"reg":".VH-OQA","label":"H1","msg_text":"POS"}}}}`,
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, format := range []string{FormatFlat, FormatNATS, FormatAcarsdec, FormatVDL2} {
+		line := []byte(benchmarkLines[format])
+		b.Run(format, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(line)))
+			for i := 0; i < b.N; i++ {
+				if d, err := Decode(line); err != nil || d.Format != format {
+					b.Fatalf("Decode = %+v, %v", d, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsTimeout bounds connecting to the NATS server.
+const natsTimeout = 10 * time.Second
+
+// natsBuffer is the number of received messages held while the consumer is
+// busy. The server drops messages for a subscriber whose buffer is full.
+const natsBuffer = 65536
+
+// NATSConfig configures a NATS subscription.
+type NATSConfig struct {
+	URL     string // e.g. nats://localhost:4222.
+	Creds   string // Credentials file; empty connects without one.
+	Subject string // May contain wildcards, e.g. acars.>.
+	// Queue is a queue group name. Subscribers in the same group share the
+	// messages of the subject between them; empty receives every message.
+	Queue string
+}
+
+// NATSStream decodes the messages published to a NATS subject. Each message
+// body is one input line, in any of the formats Decode detects.
+type NATSStream struct {
+	conn *nats.Conn
+	sub  *nats.Subscription
+	msgs chan *nats.Msg
+	done chan struct{}
+	once sync.Once
+	n    int
+
+	record Recorder
+}
+
+// NewNATSStream connects to the server and subscribes to the subject. The
+// client reconnects, without limit, if the connection drops later, so that a
+// long-running consumer does not stall on a server restart.
+func NewNATSStream(cfg NATSConfig) (*NATSStream, error) {
+	if cfg.Subject == "" {
+		return nil, fmt.Errorf("nats: no subject")
+	}
+	opts := []nats.Option{nats.Name("acars_parser input"), nats.Timeout(natsTimeout), nats.MaxReconnects(-1)}
+	if cfg.Creds != "" {
+		opts = append(opts, nats.UserCredentials(cfg.Creds))
+	}
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats %s: %w", cfg.URL, err)
+	}
+
+	s := newNATSStream(make(chan *nats.Msg, natsBuffer))
+	s.conn = conn
+	if cfg.Queue != "" {
+		s.sub, err = conn.ChanQueueSubscribe(cfg.Subject, cfg.Queue, s.msgs)
+	} else {
+		s.sub, err = conn.ChanSubscribe(cfg.Subject, s.msgs)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("subscribe to %s: %w", cfg.Subject, err)
+	}
+	return s, nil
+}
+
+func newNATSStream(msgs chan *nats.Msg) *NATSStream {
+	return &NATSStream{msgs: msgs, done: make(chan struct{})}
+}
+
+// Next waits for the next message and decodes it. After Close it returns the
+// messages already received, then io.EOF. A message that cannot be decoded
+// returns an error naming its sequence number; reading can continue with the
+// next call.
+func (s *NATSStream) Next() (*Decoded, error) {
+	var m *nats.Msg
+	select {
+	case m = <-s.msgs:
+	default:
+		select {
+		case m = <-s.msgs:
+		case <-s.done:
+			select {
+			case m = <-s.msgs:
+			default:
+				return nil, io.EOF
+			}
+		}
+	}
+
+	s.n++
+	if s.record != nil {
+		s.record(bytes.TrimSpace(m.Data))
+	}
+	d, err := Decode(m.Data)
+	if err != nil {
+		return nil, fmt.Errorf("nats message %d on %s: %w", s.n, m.Subject, err)
+	}
+	return d, nil
+}
+
+// SetRecorder sets the Recorder passed each message body received. It must
+// be called before Next.
+func (s *NATSStream) SetRecorder(rec Recorder) {
+	s.record = rec
+}
+
+// Close unsubscribes, so that Next returns io.EOF once the messages already
+// received are read, and disconnects. It is safe to call more than once and
+// from another goroutine than Next.
+func (s *NATSStream) Close() error {
+	var err error
+	s.once.Do(func() {
+		if s.sub != nil {
+			err = s.sub.Unsubscribe()
+		}
+		close(s.done)
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestNATSStream(t *testing.T) {
+	msgs := make(chan *nats.Msg, 4)
+	s := newNATSStream(msgs)
+	var recorded int
+	s.SetRecorder(func([]byte) { recorded++ })
+
+	msgs <- &nats.Msg{Subject: "acars.raw", Data: []byte(`{"tail":"VH-OQA","label":"H1","text":"POS"}`)}
+	msgs <- &nats.Msg{Subject: "acars.raw", Data: []byte(`not json`)}
+	msgs <- &nats.Msg{Subject: "acars.raw", Data: []byte(`{"tail":"VH-OQB","label":"16","text":"POS"}`)}
+
+	d, err := s.Next()
+	if err != nil || d.Message.Tail != "VH-OQA" {
+		t.Fatalf("first = %+v, %v", d, err)
+	}
+	if _, err := s.Next(); err == nil {
+		t.Fatal("undecodable message: want an error")
+	}
+
+	// Messages received before Close are still returned.
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	d, err = s.Next()
+	if err != nil || d.Message.Tail != "VH-OQB" {
+		t.Fatalf("after close = %+v, %v", d, err)
+	}
+	if _, err := s.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("drained: err = %v, want io.EOF", err)
+	}
+	if recorded != 3 {
+		t.Errorf("recorded %d messages, want all 3", recorded)
+	}
+}
@@ -0,0 +1,289 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/crc"
+)
+
+// FormatRaw is the Decoded.Format of messages read by a RawReader.
+const FormatRaw = "raw"
+
+// ACARS control characters (ARINC 618).
+const (
+	soh = 0x01
+	stx = 0x02
+	etx = 0x03
+	nak = 0x15 // Acknowledgement character of a block that acknowledges nothing.
+	etb = 0x17 // Ends a block that is followed by another.
+	del = 0x7F
+)
+
+// minFrameLen is the length of a frame with no text: SOH, mode, 7-character
+// address, acknowledgement, 2-character label, block ID, ETX and the 2-byte
+// block check sequence.
+const minFrameLen = 16
+
+// Errors returned for frames that fail their checks.
+var (
+	ErrParity = errors.New("parity error")
+	ErrBCS    = errors.New("block check sequence mismatch")
+	ErrFrame  = errors.New("malformed frame")
+)
+
+// ParseFrame decodes one ACARS frame as transmitted: 7-bit characters with
+// odd parity from SOH to ETX (or ETB), followed by the 2-byte block check
+// sequence (CRC-16/KERMIT over the characters after SOH up to and including
+// ETX) and an optional DEL. Frames with a parity error or a BCS mismatch are
+// rejected. Downlinks (numeric block IDs) have their message number and
+// flight ID split from the start of the text.
+func ParseFrame(frame []byte) (*acars.Message, error) {
+	if len(frame) < minFrameLen || frame[0] != soh {
+		return nil, ErrFrame
+	}
+	end := -1
+	for i := 13; i < len(frame); i++ {
+		if c := frame[i] & 0x7F; c == etx || c == etb {
+			end = i
+			break
+		}
+	}
+	if end < 0 || end+3 > len(frame) {
+		return nil, ErrFrame
+	}
+
+	body := frame[1 : end+1]
+	for i, c := range body {
+		if bits.OnesCount8(c)%2 == 0 {
+			return nil, fmt.Errorf("%w at offset %d", ErrParity, i+1)
+		}
+	}
+	if !crc.Kermit.Verify(body, frame[end+1:end+3]) {
+		return nil, ErrBCS
+	}
+
+	b := make([]byte, len(body))
+	for i, c := range body {
+		b[i] = c & 0x7F
+	}
+	// b: mode, address[7], ack, label[2], block ID, STX or ETX, text..., ETX.
+	label := []byte{b[9], b[10]}
+	if label[1] == del { // The "_DEL" general response label, shown as "_d".
+		label[1] = 'd'
+	}
+	msg := &acars.Message{
+		Mode:    string(b[0]),
+		Tail:    strings.TrimLeft(strings.TrimSpace(string(b[1:8])), "."),
+		Ack:     string(b[8]),
+		Label:   string(label),
+		BlockID: string(b[11]),
+	}
+	if b[8] == nak {
+		msg.Ack = acars.NoAck
+	}
+	if b[12] == stx {
+		msg.Text = string(b[13 : len(b)-1])
+	}
+	if c := b[11]; c >= '0' && c <= '9' && len(msg.Text) >= 10 {
+		msg.MsgNo = msg.Text[:4]
+		if f := strings.TrimSpace(msg.Text[4:10]); f != "" {
+			msg.Flight = &acars.Flight{Flight: f}
+		}
+		msg.Text = msg.Text[10:]
+	}
+	return msg, nil
+}
+
+// RawReader reads messages that are not JSON: acarsdec's text output, or a
+// capture of raw ACARS frames. The kind of input is detected from its first
+// bytes: a SOH character means raw frames.
+type RawReader struct {
+	r      *bufio.Reader
+	frames bool
+
+	// Text output state.
+	pending string // Header line of the next record.
+	line    int
+
+	record Recorder
+}
+
+// NewRawReader returns a RawReader over r.
+func NewRawReader(r io.Reader) *RawReader {
+	br := bufio.NewReaderSize(r, 64*1024)
+	head, _ := br.Peek(4096)
+	return &RawReader{r: br, frames: bytes.IndexByte(head, soh) >= 0}
+}
+
+// Next returns the next message. It returns io.EOF at the end of the input,
+// and an error naming the frame or line for input that cannot be decoded;
+// reading can continue with the next call.
+func (r *RawReader) Next() (*Decoded, error) {
+	var (
+		msg *acars.Message
+		err error
+	)
+	if r.frames {
+		msg, err = r.nextFrame()
+	} else {
+		msg, err = r.nextRecord()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.record != nil {
+		// The message is written as the flat JSON that Decode reads back.
+		if line, err := json.Marshal(msg); err == nil {
+			r.record(line)
+		}
+	}
+	return &Decoded{Format: FormatRaw, Message: msg}, nil
+}
+
+// SetRecorder sets the Recorder passed each message decoded, as a flat JSON
+// line. Input that cannot be decoded is not passed.
+func (r *RawReader) SetRecorder(rec Recorder) {
+	r.record = rec
+}
+
+// nextFrame reads from the next SOH to the end of its block check sequence.
+func (r *RawReader) nextFrame() (*acars.Message, error) {
+	if _, err := r.r.ReadBytes(soh); err != nil {
+		return nil, io.EOF
+	}
+	r.line++
+	frame := []byte{soh}
+	for {
+		c, err := r.r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", r.line, ErrFrame)
+		}
+		frame = append(frame, c)
+		if len(frame) > 13 && (c&0x7F == etx || c&0x7F == etb) {
+			break
+		}
+	}
+	bcs := make([]byte, 2)
+	if _, err := io.ReadFull(r.r, bcs); err != nil {
+		return nil, fmt.Errorf("frame %d: %w", r.line, ErrFrame)
+	}
+	msg, err := ParseFrame(append(frame, bcs...))
+	if err != nil {
+		return nil, fmt.Errorf("frame %d: %w", r.line, err)
+	}
+	return msg, nil
+}
+
+// Patterns for acarsdec's text output, e.g.:
+//
+//	[#1 (F:131.550 L:-21 E:0) 24/01/2026 10:00:00.125 --------------------------------
+//	Mode : 2 Label : H1 Id : 5 Ack : !
+//	Aircraft reg: .VH-OQA Flight id: QF0001
+//	No: D01A
+//	POSS33570E151108,...
+var (
+	textHeaderRe = regexp.MustCompile(`^\[#(\d+)\s+\(F:\s*([\d.]+)[^)]*\)\s+(\d{2}/\d{2}/\d{4} \d{2}:\d{2}:\d{2}(?:\.\d+)?)`)
+	textModeRe   = regexp.MustCompile(`^Mode\s*:\s*(\S)\s+Label\s*:\s*(\S{1,2})\s+Id\s*:\s*(\S)(?:\s+Ack\s*:\s*(\S))?`)
+	textRegRe    = regexp.MustCompile(`^Aircraft reg:\s*(\S*)(?:\s+Flight id:\s*(\S+))?`)
+	textNoRe     = regexp.MustCompile(`^No:\s*(\S+)`)
+)
+
+// nextRecord reads one acarsdec text record: a header line and the lines up
+// to the next header.
+func (r *RawReader) nextRecord() (*acars.Message, error) {
+	header := r.pending
+	for header == "" {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if textHeaderRe.MatchString(line) {
+			header = line
+		}
+	}
+	r.pending = ""
+	start := r.line
+
+	var body []string
+	for {
+		line, err := r.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if textHeaderRe.MatchString(line) {
+			r.pending = line
+			break
+		}
+		body = append(body, line)
+	}
+
+	msg, err := parseRecord(header, body)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", start, err)
+	}
+	return msg, nil
+}
+
+func (r *RawReader) readLine() (string, error) {
+	line, err := r.r.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	r.line++
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// parseRecord builds a message from an acarsdec text record.
+func parseRecord(header string, body []string) (*acars.Message, error) {
+	m := textHeaderRe.FindStringSubmatch(header)
+	freq, _ := strconv.ParseFloat(m[2], 64)
+	ts, err := time.Parse("02/01/2006 15:04:05", m[3]) // Accepts a fraction too.
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad time %q", ErrFrame, m[3])
+	}
+	msg := &acars.Message{Timestamp: ts.UTC().Format(time.RFC3339Nano), Frequency: freq}
+	// The text output numbers channels from 1; the JSON output from 0.
+	if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+		ch := n - 1
+		msg.Channel = &ch
+	}
+
+	var text []string
+	haveMode := false
+	for _, line := range body {
+		switch {
+		case !haveMode && textModeRe.MatchString(line):
+			f := textModeRe.FindStringSubmatch(line)
+			msg.Mode, msg.Label, msg.BlockID, msg.Ack, haveMode = f[1], f[2], f[3], f[4], true
+		case haveMode && len(text) == 0 && textRegRe.MatchString(line):
+			f := textRegRe.FindStringSubmatch(line)
+			msg.Tail = strings.TrimLeft(f[1], ".")
+			if f[2] != "" {
+				msg.Flight = &acars.Flight{Flight: f[2]}
+			}
+		case haveMode && len(text) == 0 && textNoRe.MatchString(line):
+			msg.MsgNo = textNoRe.FindStringSubmatch(line)[1]
+		case haveMode:
+			text = append(text, line)
+		}
+	}
+	if !haveMode {
+		return nil, fmt.Errorf("%w: no Mode/Label line", ErrFrame)
+	}
+	msg.Text = strings.TrimRight(strings.Join(text, "\n"), "\n")
+	return msg, nil
+}
@@ -0,0 +1,166 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// shape is what Decode needs of a line's top-level keys to tell its format.
+type shape uint8
+
+const (
+	shapeHFDL     shape = 1 << iota // "hfdl" holding an object.
+	shapeVDL2                       // "vdl2" holding an object.
+	shapeNATS                       // "message" holding an object.
+	shapeFreq                       // "freq".
+	shapeAcarsdec                   // "station_id", "msgno", "channel" or "msg_time".
+	shapeFlat                       // "text" or "label".
+)
+
+var errSyntax = errors.New("invalid JSON object")
+
+// sniff reads the top-level keys of a JSON object, skipping over their
+// values, so that the line can be decoded once into the structure of its
+// format rather than first into a map. Values are not checked: the decode
+// that follows does that.
+func sniff(line []byte) (shape, error) {
+	var s shape
+	i := skipSpace(line, 0)
+	if i >= len(line) || line[i] != '{' {
+		return 0, errSyntax
+	}
+	i = skipSpace(line, i+1)
+	if i < len(line) && line[i] == '}' {
+		return 0, nil
+	}
+	for {
+		if i >= len(line) || line[i] != '"' {
+			return 0, errSyntax
+		}
+		end := skipString(line, i)
+		if end < 0 {
+			return 0, errSyntax
+		}
+		key := line[i+1 : end-1]
+		if bytes.IndexByte(key, '\\') >= 0 {
+			var k string
+			if err := json.Unmarshal(line[i:end], &k); err != nil {
+				return 0, errSyntax
+			}
+			key = []byte(k)
+		}
+
+		i = skipSpace(line, end)
+		if i >= len(line) || line[i] != ':' {
+			return 0, errSyntax
+		}
+		i = skipSpace(line, i+1)
+		if i >= len(line) {
+			return 0, errSyntax
+		}
+		object := line[i] == '{'
+
+		switch string(key) {
+		case "hfdl":
+			s = setIf(s, shapeHFDL, object)
+		case "vdl2":
+			s = setIf(s, shapeVDL2, object)
+		case "message":
+			s = setIf(s, shapeNATS, object)
+		case "freq":
+			s |= shapeFreq
+		case "station_id", "msgno", "channel", "msg_time":
+			s |= shapeAcarsdec
+		case "text", "label":
+			s |= shapeFlat
+		}
+
+		if i = skipValue(line, i); i < 0 {
+			return 0, errSyntax
+		}
+		i = skipSpace(line, i)
+		if i >= len(line) {
+			return 0, errSyntax
+		}
+		switch line[i] {
+		case ',':
+			i = skipSpace(line, i+1)
+		case '}':
+			if skipSpace(line, i+1) != len(line) {
+				return 0, errSyntax
+			}
+			return s, nil
+		default:
+			return 0, errSyntax
+		}
+	}
+}
+
+// setIf sets or clears a bit of a shape, as a later duplicate key overrides
+// an earlier one.
+func setIf(s, bit shape, set bool) shape {
+	if set {
+		return s | bit
+	}
+	return s &^ bit
+}
+
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// skipString returns the index after the string starting at b[i], or -1 if
+// it is not terminated.
+func skipString(b []byte, i int) int {
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// skipValue returns the index after the value starting at b[i], or -1 if it
+// is not terminated. Objects and arrays are skipped by counting brackets
+// outside strings.
+func skipValue(b []byte, i int) int {
+	switch b[i] {
+	case '"':
+		return skipString(b, i)
+	case '{', '[':
+		depth := 0
+		for ; i < len(b); i++ {
+			switch b[i] {
+			case '"':
+				if i = skipString(b, i); i < 0 {
+					return -1
+				}
+				i--
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	}
+	// A number, true, false or null.
+	start := i
+	for i < len(b) && b[i] != ',' && b[i] != '}' && b[i] != ']' &&
+		b[i] != ' ' && b[i] != '\t' && b[i] != '\n' && b[i] != '\r' {
+		i++
+	}
+	if i == start {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,32 @@
+package input
+
+import "testing"
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		line string
+		want shape
+	}{
+		{`{}`, 0},
+		{` { "label" : "H1" , "text" : "A}\"{[" } `, shapeFlat},
+		{`{"hfdl":{"a":[1,{"b":"}"}]},"text":null}`, shapeHFDL | shapeFlat},
+		{`{"vdl2":"not an object"}`, 0},
+		{`{"message":"text","freq":131.55,"msgno":"M01A"}`, shapeFreq | shapeAcarsdec},
+		{`{"message":{"label":"16"},"message":null}`, 0},
+		{`{"message":{}}`, shapeNATS},
+		{`{"te\u0078t":"A"}`, shapeFlat},
+		{`{"channel":-1,"ok":true,"list":[],"x":1e5}`, shapeAcarsdec},
+	}
+	for _, tt := range tests {
+		got, err := sniff([]byte(tt.line))
+		if err != nil || got != tt.want {
+			t.Errorf("sniff(%s) = %b, %v, want %b", tt.line, got, err, tt.want)
+		}
+	}
+
+	for _, line := range []string{``, `[]`, `{"a"}`, `{"a":}`, `{"a":1,}`, `{"a":"x`, `{"a":{"b":1}`, `{"a":1} x`, `{"a":1 "b":2}`} {
+		if _, err := sniff([]byte(line)); err == nil {
+			t.Errorf("sniff(%s) accepted malformed JSON", line)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+//go:build integration
+
+// Package integration runs a sample corpus through the whole pipeline into a
+// PostgreSQL database and checks the state it leaves behind: the flight
+// enrichment, waypoints, routes, route changes, arrival runways and ATIS that
+// the unit tests of each package can only check in pieces.
+//
+// The tests need a PostgreSQL server and are left out of the default build.
+// Start one with the compose file in this directory and run them with the
+// integration tag:
+//
+//	docker compose -f internal/integration/docker-compose.yml up -d --wait
+//	POSTGRES_PORT=55432 go test -tags integration ./internal/integration
+//	docker compose -f internal/integration/docker-compose.yml down
+//
+// The server is found with the POSTGRES_* variables of the commands. Each run
+// creates a database of its own, applies the migrations to it with
+// CreateSchema and drops it at the end, so the server may be shared.
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"acars_parser/internal/dedup"
+	"acars_parser/internal/input"
+	"acars_parser/internal/msgtime"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/pipeline"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+)
+
+// openTestDatabase creates an empty database on the configured server,
+// applies the schema to it, and drops it when the test ends.
+func openTestDatabase(t *testing.T) *storage.PostgresDB {
+	t.Helper()
+	ctx := context.Background()
+	cfg := storage.AddPostgresFlags(flag.NewFlagSet("integration", flag.ContinueOnError))
+
+	admin, err := storage.OpenPostgres(ctx, *cfg)
+	if err != nil {
+		t.Fatalf("Error opening PostgreSQL at %s:%d (see the package documentation): %v", cfg.Host, cfg.Port, err)
+	}
+	name := fmt.Sprintf("acars_integration_%d", time.Now().UnixNano())
+	if _, err := admin.Pool().Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		admin.Close()
+		t.Fatalf("Error creating database: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Pool().Exec(ctx, "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)"); err != nil {
+			t.Errorf("Error dropping database %s: %v", name, err)
+		}
+		admin.Close()
+	})
+
+	cfg.Database = name
+	pg, err := storage.OpenPostgres(ctx, *cfg)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	t.Cleanup(pg.Close)
+	if err := pg.CreateSchema(ctx); err != nil {
+		t.Fatalf("Error creating schema: %v", err)
+	}
+	return pg
+}
+
+// processCorpus runs the messages of testdata/corpus.jsonl through the
+// pipeline, as the process command does, and returns its counts. Failures
+// reported for single messages fail the test.
+func processCorpus(t *testing.T, pg *storage.PostgresDB) pipeline.Stats {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "corpus.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	reg := registry.Default()
+	reg.Sort()
+	p := pipeline.New(pipeline.Stages{
+		Registry: reg,
+		Clock:    msgtime.New(msgtime.DefaultConfig()),
+		Filter:   dedup.New(time.Minute),
+		Tracker:  state.NewTracker(pg),
+	})
+	if err := p.Run(context.Background(), input.NewReader(f), func(err error) {
+		t.Errorf("Error processing corpus: %v", err)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return p.Stats()
+}
+
+func TestCorpus(t *testing.T) {
+	pg := openTestDatabase(t)
+	stats := processCorpus(t, pg)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("stats", func(t *testing.T) {
+		// The second PDC is a copy of the first, heard by another station.
+		want := pipeline.Stats{Inputs: 7, Messages: 7, Duplicates: 1, Parsed: 6}
+		if stats != want {
+			t.Errorf("stats = %+v, want %+v", stats, want)
+		}
+	})
+
+	t.Run("departure clearance enrichment", func(t *testing.T) {
+		e, err := pg.GetFlightEnrichment(ctx, "7C0001", "JST577", day, storage.DateUTC, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			t.Fatal("no enrichment for JST577")
+		}
+		if e.Origin != "YBBN" || e.Destination != "YMML" {
+			t.Errorf("origin, destination = %s, %s, want YBBN, YMML", e.Origin, e.Destination)
+		}
+		if e.DepartureRunway != "01R" || e.SID != "SANEG2" || e.Squawk != "1007" {
+			t.Errorf("runway, SID, squawk = %s, %s, %s, want 01R, SANEG2, 1007", e.DepartureRunway, e.SID, e.Squawk)
+		}
+		if want := []string{"SANEG", "OSOTI", "PKS", "DORSU", "ARBEY"}; !reflect.DeepEqual(e.Route, want) {
+			t.Errorf("route = %v, want %v", e.Route, want)
+		}
+	})
+
+	t.Run("oceanic clearance enrichment", func(t *testing.T) {
+		e, err := pg.GetFlightEnrichment(ctx, "ACF001", "DAL48", day, storage.DateUTC, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil || e.OceanicClearance == nil {
+			t.Fatalf("enrichment for DAL48 = %+v, want an oceanic clearance", e)
+		}
+		if e.Destination != "KJFK" {
+			t.Errorf("destination = %s, want KJFK", e.Destination)
+		}
+		oc := e.OceanicClearance
+		if oc.Track != "E" || oc.EntryPoint != "ELSIR" || oc.FlightLevel != 350 || oc.Mach != 0.83 {
+			t.Errorf("clearance = %+v, want track E from ELSIR at FL350, M.83", oc)
+		}
+		if want := time.Date(2026, 3, 1, 13, 42, 0, 0, time.UTC); oc.EntryTime == nil || !oc.EntryTime.Equal(want) {
+			t.Errorf("entry time = %v, want %v", oc.EntryTime, want)
+		}
+	})
+
+	t.Run("waypoints", func(t *testing.T) {
+		wp, err := pg.GetWaypoint(ctx, "BEGLA")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wp == nil {
+			t.Fatal("no waypoint BEGLA")
+		}
+		if wp.Latitude != 47.555 || wp.Longitude != 18.028 || wp.SourceCount != 1 {
+			t.Errorf("BEGLA = %+v, want 47.555, 18.028 from 1 source", wp)
+		}
+		// Positions named by their coordinates need no row.
+		if wp, err := pg.GetWaypoint(ctx, "50N020W"); err != nil || wp != nil {
+			t.Errorf("50N020W = %+v, %v, want no row", wp, err)
+		}
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		routes, err := pg.ListRoutes(ctx, storage.RouteQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(routes) != 2 {
+			t.Fatalf("got %d routes %+v, want 2", len(routes), routes)
+		}
+		if r := routes[0]; r.FlightPattern != "DL2317" || r.OriginICAO != "KCLT" || r.DestICAO != "KBOS" || r.ObservationCount != 2 {
+			t.Errorf("route = %+v, want DL2317 KCLT-KBOS seen twice", r)
+		}
+		r := routes[1]
+		if r.FlightPattern != "JST577" || r.OriginICAO != "YBBN" || r.DestICAO != "YMML" || r.ObservationCount != 1 {
+			t.Errorf("route = %+v, want JST577 YBBN-YMML seen once", r)
+		}
+		legs, err := pg.GetRouteLegs(ctx, r.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(legs) != 1 || legs[0].OriginICAO != "YBBN" || legs[0].DestICAO != "YMML" {
+			t.Errorf("legs = %+v, want one YBBN-YMML leg", legs)
+		}
+	})
+
+	// The flight plans reach the tracker wrapped with their quality report,
+	// as every result of the pipeline does.
+	t.Run("route changes", func(t *testing.T) {
+		plan, _, err := pg.GetFlightPlan(ctx, "N301DN/DL2317")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"VECTOR", "DISCO", "PVD", "EGGRL"}; plan == nil || !reflect.DeepEqual(plan.Waypoints, want) {
+			t.Fatalf("plan = %+v, want waypoints %v", plan, want)
+		}
+
+		var raw []byte
+		err = pg.Pool().QueryRow(ctx, `SELECT changes FROM route_changes WHERE flight_key = $1`, "N301DN/DL2317").Scan(&raw)
+		if err != nil {
+			t.Fatalf("Error reading route change: %v", err)
+		}
+		var c storage.RouteChange
+		if err := json.Unmarshal(raw, &c); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(c.AddedWaypoints, []string{"PVD"}) || len(c.RemovedWaypoints) != 0 {
+			t.Errorf("change = %+v, want PVD added", c)
+		}
+	})
+
+	t.Run("arrival runways", func(t *testing.T) {
+		counts, err := pg.CountArrivalRunways(ctx, "KBOS", day, day.Add(24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(counts) != 1 || counts[0].Runway != "22L" || counts[0].Flights != 1 {
+			t.Errorf("KBOS runways = %+v, want one flight on 22L", counts)
+		}
+	})
+
+	t.Run("atis", func(t *testing.T) {
+		a, err := pg.GetATISCurrent(ctx, "RKSI")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a == nil || a.Letter != "W" || a.ATISType != "ARR" {
+			t.Errorf("RKSI ATIS = %+v, want arrival information W", a)
+		}
+	})
+}
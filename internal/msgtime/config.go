@@ -0,0 +1,30 @@
+package msgtime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseSkews parses comma-separated STATION=DURATION pairs, e.g.
+// "YSSY-1=90s,YMML=-2m". A positive duration is a clock running fast.
+func ParseSkews(s string) (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		station, dur, ok := strings.Cut(pair, "=")
+		station = strings.TrimSpace(station)
+		if !ok || station == "" {
+			return nil, fmt.Errorf("clock skew %q: want STATION=DURATION", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(dur))
+		if err != nil {
+			return nil, fmt.Errorf("clock skew %q: %w", pair, err)
+		}
+		out[station] = d
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+//go:build !wasm
+
+package msgtime
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"acars_parser/internal/envflag"
+)
+
+// Flags holds the timestamp flags of a command.
+type Flags struct {
+	Skew            string // STATION=DURATION pairs, comma-separated.
+	EstimateSkew    bool
+	MinSkew         time.Duration
+	MaxEmbeddedSkew time.Duration
+}
+
+// AddFlags registers the timestamp flags on fs, with defaults from the
+// environment, and returns the Flags they fill.
+func AddFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.Skew, "clock-skew", os.Getenv("CLOCK_SKEW"), "Receiver clock offsets to remove, as STATION=DURATION pairs (e.g. YSSY-1=90s)")
+	fs.BoolVar(&f.EstimateSkew, "estimate-skew", envflag.Bool("ESTIMATE_SKEW", false), "Estimate receiver clock offsets from arrival times (live input only)")
+	fs.DurationVar(&f.MinSkew, "min-skew", envflag.Duration("MIN_SKEW", DefaultMinSkew), "Smallest estimated clock offset that is corrected")
+	fs.DurationVar(&f.MaxEmbeddedSkew, "max-embedded-skew", envflag.Duration("MAX_EMBEDDED_SKEW", DefaultMaxEmbeddedSkew), "Flag report times further than this from the message time (0 disables)")
+	return f
+}
+
+// Config returns the Normaliser configuration the flags describe.
+func (f *Flags) Config() (Config, error) {
+	skew, err := ParseSkews(f.Skew)
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{
+		Skew:            skew,
+		EstimateSkew:    f.EstimateSkew,
+		MinSkew:         f.MinSkew,
+		MaxEmbeddedSkew: f.MaxEmbeddedSkew,
+	}, nil
+}
@@ -0,0 +1,304 @@
+// Package msgtime normalises message timestamps to a canonical UTC time.
+//
+// Message times reach the parser in several forms: RFC 3339 strings from the
+// NATS feed and the JSON decoders, epoch seconds (with or without a fraction)
+// from acarsdec-style output and the SQLite corpus, and times of day embedded
+// in the message text, often in the airline's local time. Parse handles the
+// first two. A Normaliser sets acars.Message.Time from the decoder's time,
+// corrected for a known or estimated receiver clock offset, and falls back to
+// the arrival time when the decoder gave none. CheckEmbedded compares the
+// report times parsers extract from the text against the message time and
+// flags those that disagree by more than the configured limit, which points at
+// a wrong receiver clock, a local-time report or a corrupted message.
+package msgtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Sources of a normalised time.
+const (
+	SourceDecoder = "decoder" // The decoder's timestamp, corrected for clock skew.
+	SourceArrival = "arrival" // The arrival time; the decoder gave none.
+)
+
+// FlagEmbeddedTime is added to acars.Message.TimeFlags when a report time in
+// the message text is far from the message time.
+const FlagEmbeddedTime = "embedded_time_mismatch"
+
+// Defaults for Config.
+const (
+	DefaultMinSkew         = 5 * time.Second
+	DefaultMaxEmbeddedSkew = time.Hour
+)
+
+// skewWindow is the number of recent offsets the skew of a station is
+// estimated from.
+const skewWindow = 31
+
+// layouts are the textual formats Parse accepts, most common first. Layouts
+// without a zone are taken as UTC.
+var layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Parse parses a message timestamp: RFC 3339 (with or without a zone, with a
+// 'T' or a space), or epoch seconds, milliseconds or microseconds as a
+// decimal string. The result is in UTC.
+func Parse(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return time.Time{}, false
+	}
+	return FromEpoch(v), true
+}
+
+// FromEpoch converts an epoch time to UTC. Values above 1e14 are taken as
+// microseconds and above 1e11 as milliseconds, so that every unit gives a
+// time between 1973 and 5138.
+func FromEpoch(v float64) time.Time {
+	switch {
+	case v > 1e14:
+		v /= 1e6
+	case v > 1e11:
+		v /= 1e3
+	}
+	sec, frac := math.Modf(v)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e6))*1000).UTC()
+}
+
+// ParseClock parses a time of day as written in message text: HHMM, HHMMSS,
+// HH:MM or HH:MM:SS, optionally followed by Z. It returns the offset from
+// midnight.
+func ParseClock(s string) (time.Duration, bool) {
+	s = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "Z")
+	s = strings.ReplaceAll(s, ":", "")
+	if len(s) != 4 && len(s) != 6 {
+		return 0, false
+	}
+	var parts [3]int
+	for i := 0; i < len(s)/2; i++ {
+		n, err := strconv.Atoi(s[2*i : 2*i+2])
+		if err != nil {
+			return 0, false
+		}
+		parts[i] = n
+	}
+	if parts[0] > 23 || parts[1] > 59 || parts[2] > 59 {
+		return 0, false
+	}
+	return time.Duration(parts[0])*time.Hour + time.Duration(parts[1])*time.Minute +
+		time.Duration(parts[2])*time.Second, true
+}
+
+// ResolveClock places a time of day on the UTC day that brings it closest to
+// ref, so a report at 2355 read at 0005 falls on the previous day.
+func ResolveClock(clock time.Duration, ref time.Time) time.Time {
+	ref = ref.UTC()
+	day := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, time.UTC)
+	t := day.Add(clock)
+	switch diff := t.Sub(ref); {
+	case diff > 12*time.Hour:
+		t = t.AddDate(0, 0, -1)
+	case diff < -12*time.Hour:
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// Config configures a Normaliser.
+type Config struct {
+	// Skew holds fixed receiver clock offsets by station ID: how far ahead
+	// of true time the station's clock runs. They are subtracted from the
+	// decoder's timestamps.
+	Skew map[string]time.Duration
+
+	// EstimateSkew learns the offset of stations without a fixed one from
+	// the difference between decoder time and arrival time. Only use it for
+	// live input; a file read after the fact arrives hours or days late.
+	EstimateSkew bool
+
+	// MinSkew is the smallest estimated offset that is applied. Smaller
+	// offsets are indistinguishable from transport latency.
+	MinSkew time.Duration
+
+	// MaxEmbeddedSkew is the largest difference between a report time in
+	// the text and the message time that is not flagged.
+	MaxEmbeddedSkew time.Duration
+}
+
+// DefaultConfig returns a Config with no fixed offsets and no estimation.
+func DefaultConfig() Config {
+	return Config{MinSkew: DefaultMinSkew, MaxEmbeddedSkew: DefaultMaxEmbeddedSkew}
+}
+
+// Normaliser sets the canonical time of messages. It is safe for concurrent
+// use.
+type Normaliser struct {
+	cfg Config
+
+	mu      sync.Mutex
+	offsets map[string][]time.Duration // Recent decoder-minus-arrival offsets by station.
+}
+
+// New returns a Normaliser for cfg.
+func New(cfg Config) *Normaliser {
+	return &Normaliser{cfg: cfg, offsets: make(map[string][]time.Duration)}
+}
+
+// Normalise sets msg.Time to the canonical UTC time of the message and
+// rewrites msg.Timestamp to match in RFC 3339, so that consumers of either
+// see the same time. The decoder's timestamp is used when it parses, less the
+// station's clock offset; otherwise arrival is used (or the current time when
+// arrival is zero). It returns the source of the time and the offset
+// removed.
+func (n *Normaliser) Normalise(msg *acars.Message, arrival time.Time) (string, time.Duration) {
+	decoded, ok := Parse(msg.Timestamp)
+	if !ok {
+		if arrival.IsZero() {
+			arrival = time.Now()
+		}
+		msg.Time = arrival.UTC()
+		msg.Timestamp = msg.Time.Format(time.RFC3339Nano)
+		return SourceArrival, 0
+	}
+
+	station := msg.StationID()
+	skew := n.skew(station, decoded, arrival)
+	msg.Time = decoded.Add(-skew)
+	msg.Timestamp = msg.Time.Format(time.RFC3339Nano)
+	return SourceDecoder, skew
+}
+
+// Skew returns the clock offset currently applied to a station.
+func (n *Normaliser) Skew(station string) time.Duration {
+	if d, ok := n.cfg.Skew[station]; ok {
+		return d
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.estimate(station)
+}
+
+// skew returns the offset to remove from a decoder time, recording the
+// offset from arrival first when estimating.
+func (n *Normaliser) skew(station string, decoded, arrival time.Time) time.Duration {
+	if d, ok := n.cfg.Skew[station]; ok {
+		return d
+	}
+	if !n.cfg.EstimateSkew || station == "" || arrival.IsZero() {
+		return 0
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	window := append(n.offsets[station], decoded.Sub(arrival))
+	if len(window) > skewWindow {
+		window = window[len(window)-skewWindow:]
+	}
+	n.offsets[station] = window
+	return n.estimate(station)
+}
+
+// estimate returns the median recent offset of a station, or zero when it is
+// below MinSkew. The median ignores the occasional message delayed in
+// transit. The caller holds n.mu.
+func (n *Normaliser) estimate(station string) time.Duration {
+	window := n.offsets[station]
+	if len(window) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), window...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+	if median < n.cfg.MinSkew && median > -n.cfg.MinSkew {
+		return 0
+	}
+	return median
+}
+
+// Mismatch is a report time in the message text that disagrees with the
+// message time.
+type Mismatch struct {
+	Type     string        // Result type that reported the time.
+	Field    string        // Result field holding the time.
+	Reported time.Time     // The report time, placed on the nearest day.
+	Diff     time.Duration // Reported minus the message time.
+}
+
+// String describes the mismatch.
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s %s %s is %s from the message time", m.Type, m.Field,
+		m.Reported.Format("15:04:05"), m.Diff.Round(time.Minute))
+}
+
+// embeddedFields are the top-level result fields holding a time of day read
+// from the message text.
+var embeddedFields = []string{"report_time"}
+
+// CheckEmbedded compares the report times in results against msg.Time and
+// returns those more than MaxEmbeddedSkew away. When there are any,
+// FlagEmbeddedTime is added to msg.TimeFlags. msg.Time must be set.
+func (n *Normaliser) CheckEmbedded(msg *acars.Message, results []registry.Result) []Mismatch {
+	if msg.Time.IsZero() || n.cfg.MaxEmbeddedSkew <= 0 {
+		return nil
+	}
+	var out []Mismatch
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+		for _, field := range embeddedFields {
+			s, _ := m[field].(string)
+			clock, ok := ParseClock(s)
+			if !ok {
+				continue
+			}
+			reported := ResolveClock(clock, msg.Time)
+			diff := reported.Sub(msg.Time)
+			if diff > n.cfg.MaxEmbeddedSkew || diff < -n.cfg.MaxEmbeddedSkew {
+				out = append(out, Mismatch{Type: r.Type(), Field: field, Reported: reported, Diff: diff})
+			}
+		}
+	}
+	if len(out) > 0 && !hasFlag(msg.TimeFlags, FlagEmbeddedTime) {
+		msg.TimeFlags = append(msg.TimeFlags, FlagEmbeddedTime)
+	}
+	return out
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
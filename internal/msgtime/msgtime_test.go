@@ -0,0 +1,172 @@
+package msgtime
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+var ref = time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-01-24T10:00:00Z", ref},
+		{"2026-01-24T20:00:00+10:00", ref},
+		{"2026-01-24T10:00:00.25Z", ref.Add(250 * time.Millisecond)},
+		{"2026-01-24T10:00:00", ref},
+		{"2026-01-24 10:00:00", ref},
+		{"1769248800", ref},
+		{"1769248800.5", ref.Add(500 * time.Millisecond)},
+		{"1769248800000", ref},
+		{"1769248800000000", ref},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.in)
+		if !ok || !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("Parse(%q) = %v, %v; want %v", tt.in, got, ok, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "-5"} {
+		if got, ok := Parse(bad); ok {
+			t.Errorf("Parse(%q) = %v, want failure", bad, got)
+		}
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"1005", 10*time.Hour + 5*time.Minute, true},
+		{"100530", 10*time.Hour + 5*time.Minute + 30*time.Second, true},
+		{"10:05", 10*time.Hour + 5*time.Minute, true},
+		{"1005Z", 10*time.Hour + 5*time.Minute, true},
+		{"2460", 0, false},
+		{"105", 0, false},
+		{"AB12", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseClock(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseClock(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolveClock(t *testing.T) {
+	midnight := time.Date(2026, 1, 24, 0, 5, 0, 0, time.UTC)
+	got := ResolveClock(23*time.Hour+55*time.Minute, midnight)
+	if want := time.Date(2026, 1, 23, 23, 55, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("2355 near 0005 = %v, want %v", got, want)
+	}
+	late := time.Date(2026, 1, 24, 23, 55, 0, 0, time.UTC)
+	got = ResolveClock(5*time.Minute, late)
+	if want := time.Date(2026, 1, 25, 0, 5, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("0005 near 2355 = %v, want %v", got, want)
+	}
+}
+
+func TestNormaliseFixedSkew(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Skew = map[string]time.Duration{"YSSY-1": 90 * time.Second}
+	n := New(cfg)
+
+	msg := &acars.Message{Timestamp: "1769248890", Station: &acars.Station{ID: "YSSY-1"}}
+	src, skew := n.Normalise(msg, time.Time{})
+	if src != SourceDecoder || skew != 90*time.Second {
+		t.Errorf("source %q, skew %v", src, skew)
+	}
+	if !msg.Time.Equal(ref) || msg.Timestamp != "2026-01-24T10:00:00Z" {
+		t.Errorf("time %v, timestamp %q", msg.Time, msg.Timestamp)
+	}
+
+	// Other stations are left alone.
+	other := &acars.Message{Timestamp: "2026-01-24T10:00:00Z"}
+	if _, skew := n.Normalise(other, time.Time{}); skew != 0 || !other.Time.Equal(ref) {
+		t.Errorf("unconfigured station: skew %v, time %v", skew, other.Time)
+	}
+}
+
+func TestNormaliseArrival(t *testing.T) {
+	n := New(DefaultConfig())
+	msg := &acars.Message{}
+	if src, _ := n.Normalise(msg, ref); src != SourceArrival || !msg.Time.Equal(ref) {
+		t.Errorf("source %q, time %v", src, msg.Time)
+	}
+}
+
+func TestNormaliseEstimatedSkew(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EstimateSkew = true
+	n := New(cfg)
+
+	// A receiver two minutes fast, with messages arriving 1s after their
+	// (wrong) decoder time, and one message held up in transit.
+	var last time.Duration
+	for i := 0; i < 9; i++ {
+		arrival := ref.Add(time.Duration(i) * time.Minute)
+		decoded := arrival.Add(2*time.Minute - time.Second)
+		if i == 4 {
+			arrival = arrival.Add(10 * time.Minute)
+		}
+		msg := &acars.Message{Timestamp: decoded.Format(time.RFC3339), Station: &acars.Station{ID: "YMML"}}
+		_, last = n.Normalise(msg, arrival)
+	}
+	if last != 2*time.Minute-time.Second {
+		t.Errorf("estimated skew = %v, want 1m59s", last)
+	}
+	if got := n.Skew("YMML"); got != last {
+		t.Errorf("Skew() = %v, want %v", got, last)
+	}
+
+	// Offsets within MinSkew are treated as latency.
+	msg := &acars.Message{Timestamp: ref.Format(time.RFC3339), Station: &acars.Station{ID: "YBBN"}}
+	if _, skew := n.Normalise(msg, ref.Add(2*time.Second)); skew != 0 {
+		t.Errorf("latency corrected as skew: %v", skew)
+	}
+}
+
+// reportResult is a result carrying a report time, as several parsers do.
+type reportResult struct {
+	ReportTime string `json:"report_time,omitempty"`
+}
+
+func (r *reportResult) Type() string     { return "test" }
+func (r *reportResult) MessageID() int64 { return 0 }
+
+func TestCheckEmbedded(t *testing.T) {
+	n := New(DefaultConfig())
+
+	msg := &acars.Message{Time: ref}
+	if got := n.CheckEmbedded(msg, []registry.Result{&reportResult{ReportTime: "0955"}}); len(got) != 0 || len(msg.TimeFlags) != 0 {
+		t.Errorf("close report time flagged: %v, %v", got, msg.TimeFlags)
+	}
+
+	// A report in local time (UTC+10) is flagged.
+	got := n.CheckEmbedded(msg, []registry.Result{&reportResult{ReportTime: "2000"}, &reportResult{}})
+	if len(got) != 1 || got[0].Field != "report_time" || got[0].Diff != 10*time.Hour {
+		t.Fatalf("mismatches = %v", got)
+	}
+	if len(msg.TimeFlags) != 1 || msg.TimeFlags[0] != FlagEmbeddedTime {
+		t.Errorf("TimeFlags = %v", msg.TimeFlags)
+	}
+}
+
+func TestParseSkews(t *testing.T) {
+	got, err := ParseSkews("YSSY-1=90s, YMML=-2m")
+	if err != nil || got["YSSY-1"] != 90*time.Second || got["YMML"] != -2*time.Minute {
+		t.Errorf("ParseSkews = %v, %v", got, err)
+	}
+	for _, bad := range []string{"YSSY", "=5s", "YSSY=soon"} {
+		if _, err := ParseSkews(bad); err == nil {
+			t.Errorf("ParseSkews(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package navdata holds imported navigation reference data used to enrich
+// parsed messages, such as the fix sequence of each airway.
+package navdata
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Fix is a named navigation point.
+type Fix struct {
+	Ident     string  `json:"ident"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Airways is an airway database. The same airway identifier can be used by
+// unrelated airways in different regions, so each identifier maps to one or
+// more fix chains. The zero value is not usable; create one with NewAirways.
+type Airways struct {
+	chains map[string][][]Fix
+}
+
+// NewAirways returns an empty airway database.
+func NewAirways() *Airways {
+	return &Airways{chains: make(map[string][][]Fix)}
+}
+
+// Add records an airway as an ordered chain of fixes. Identifiers are
+// case-insensitive.
+func (a *Airways) Add(ident string, fixes []Fix) {
+	if len(fixes) < 2 {
+		return
+	}
+	ident = strings.ToUpper(strings.TrimSpace(ident))
+	chain := make([]Fix, len(fixes))
+	for i, f := range fixes {
+		f.Ident = strings.ToUpper(strings.TrimSpace(f.Ident))
+		chain[i] = f
+	}
+	a.chains[ident] = append(a.chains[ident], chain)
+}
+
+// Len returns the number of airway chains in the database.
+func (a *Airways) Len() int {
+	n := 0
+	for _, chains := range a.chains {
+		n += len(chains)
+	}
+	return n
+}
+
+// Expand returns the fixes along airway from one fix to another, inclusive of
+// both ends and in the order flown. Airways can be flown in either direction.
+// It returns false if no chain with that identifier contains both fixes.
+func (a *Airways) Expand(airway, from, to string) ([]Fix, bool) {
+	airway = strings.ToUpper(airway)
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+	if from == to {
+		return nil, false
+	}
+
+	for _, chain := range a.chains[airway] {
+		i, j := indexOf(chain, from), indexOf(chain, to)
+		if i < 0 || j < 0 {
+			continue
+		}
+		path := make([]Fix, 0, abs(j-i)+1)
+		if i < j {
+			path = append(path, chain[i:j+1]...)
+		} else {
+			for k := i; k >= j; k-- {
+				path = append(path, chain[k])
+			}
+		}
+		return path, true
+	}
+	return nil, false
+}
+
+func indexOf(chain []Fix, ident string) int {
+	for i, f := range chain {
+		if f.Ident == ident {
+			return i
+		}
+	}
+	return -1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// LoadAirwaysCSV reads an airway database from CSV with the columns
+//
+//	airway,sequence,fix,latitude,longitude
+//
+// and an optional header row. Rows for an airway must be in sequence order.
+// A new chain starts when the airway changes or the sequence number does not
+// increase, which allows regional airways that share an identifier.
+func LoadAirwaysCSV(r io.Reader) (*Airways, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 5
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	a := NewAirways()
+	var ident string
+	var chain []Fix
+	lastSeq := -1
+
+	flush := func() {
+		a.Add(ident, chain)
+		chain = nil
+	}
+
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		seq, err := strconv.Atoi(rec[1])
+		if err != nil {
+			if line == 1 {
+				continue // Header row.
+			}
+			return nil, fmt.Errorf("line %d: invalid sequence %q", line, rec[1])
+		}
+		lat, errLat := strconv.ParseFloat(rec[3], 64)
+		lon, errLon := strconv.ParseFloat(rec[4], 64)
+		if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("line %d: invalid coordinates %q,%q", line, rec[3], rec[4])
+		}
+
+		if !strings.EqualFold(rec[0], ident) || seq <= lastSeq {
+			flush()
+			ident = rec[0]
+		}
+		lastSeq = seq
+		chain = append(chain, Fix{Ident: rec[2], Latitude: lat, Longitude: lon})
+	}
+	flush()
+
+	return a, nil
+}
@@ -0,0 +1,68 @@
+package navdata
+
+import (
+	"strings"
+	"testing"
+)
+
+const testAirways = `airway,sequence,fix,latitude,longitude
+Q102,10,FEMID,27.1,-81.2
+Q102,20,KPASA,27.9,-82.6
+Q102,30,CIGAR,28.5,-84.0
+Q102,40,BACCA,29.0,-85.5
+# A second, unrelated Q102 elsewhere.
+Q102,10,ALPHA,-33.0,151.0
+Q102,20,BRAVO,-34.0,150.0
+`
+
+func TestLoadAirwaysCSV(t *testing.T) {
+	a, err := LoadAirwaysCSV(strings.NewReader(testAirways))
+	if err != nil {
+		t.Fatalf("LoadAirwaysCSV() error = %v", err)
+	}
+	if a.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", a.Len())
+	}
+
+	if _, err := LoadAirwaysCSV(strings.NewReader("J58,1,AEX,91,0\n")); err == nil {
+		t.Error("expected an error for an out-of-range latitude")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	a, err := LoadAirwaysCSV(strings.NewReader(testAirways))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		airway   string
+		from, to string
+		want     []string
+	}{
+		{"forward", "Q102", "FEMID", "CIGAR", []string{"FEMID", "KPASA", "CIGAR"}},
+		{"reverse", "q102", "bacca", "kpasa", []string{"BACCA", "CIGAR", "KPASA"}},
+		{"adjacent", "Q102", "CIGAR", "BACCA", []string{"CIGAR", "BACCA"}},
+		{"second chain", "Q102", "ALPHA", "BRAVO", []string{"ALPHA", "BRAVO"}},
+		{"fixes on different chains", "Q102", "FEMID", "BRAVO", nil},
+		{"unknown airway", "J58", "FEMID", "CIGAR", nil},
+		{"same fix", "Q102", "CIGAR", "CIGAR", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ok := a.Expand(tt.airway, tt.from, tt.to)
+			if ok != (tt.want != nil) {
+				t.Fatalf("Expand() ok = %v, want %v", ok, tt.want != nil)
+			}
+			var got []string
+			for _, f := range path {
+				got = append(got, f.Ident)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
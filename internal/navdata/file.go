@@ -0,0 +1,58 @@
+//go:build !wasm
+
+package navdata
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// The file loaders are left out of WebAssembly builds, which parse messages
+// in the browser and have no file system to load navigation data from.
+
+// LoadAirwaysFile reads an airway database from a CSV file. See LoadAirwaysCSV.
+func LoadAirwaysFile(path string) (*Airways, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	a, err := LoadAirwaysCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// LoadCIFPFile reads SID and STAR procedures from an ARINC 424 file. See LoadCIFP.
+func LoadCIFPFile(path string) (*Procedures, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	ps, err := LoadCIFP(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ps, nil
+}
+
+// LoadNATTrackFile reads the tracks of a NAT track message from a file. See
+// ParseNATMessage.
+func LoadNATTrackFile(path string, ref time.Time) ([]NATTrack, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	tracks, err := ParseNATMessage(f, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tracks, nil
+}
@@ -0,0 +1,59 @@
+package navdata
+
+import (
+	"fmt"
+	"math"
+
+	"acars_parser/internal/patterns"
+)
+
+// LatLonWaypoint recognises a waypoint named by its position rather than a
+// published identifier, such as the oceanic point 5740N (57N 040W) or
+// N25400W080030, in any encoding patterns.ParseRouteCoord reads. It returns
+// the position's canonical name (see LatLonName) and its coordinates to the
+// tenth of a minute, or false for any other name. Such names need no lookup:
+// the position is in the name.
+func LatLonWaypoint(name string) (canonical string, lat, lon float64, ok bool) {
+	lat, lon, ok = patterns.ParseRouteCoord(name)
+	if !ok {
+		return "", 0, 0, false
+	}
+	lat = math.Round(lat*600) / 600
+	lon = math.Round(lon*600) / 600
+	return LatLonName(lat, lon), lat, lon, true
+}
+
+// LatLonName returns the canonical name of a position: its ARINC 424
+// five-character code when it is a whole degree of latitude and longitude
+// (5740N, or 52N75 for 52N 175W), and otherwise its degrees, minutes and
+// tenths (N25400W080030).
+func LatLonName(lat, lon float64) string {
+	latTenths := int(math.Round(math.Abs(lat) * 600))
+	lonTenths := int(math.Round(math.Abs(lon) * 600))
+
+	if latTenths%600 == 0 && lonTenths%600 == 0 {
+		d, l := latTenths/600, lonTenths/600
+		quadrant := 'N' // North and west.
+		switch {
+		case lat >= 0 && lon >= 0:
+			quadrant = 'E'
+		case lat < 0 && lon >= 0:
+			quadrant = 'S'
+		case lat < 0 && lon < 0:
+			quadrant = 'W'
+		}
+		if l < 100 {
+			return fmt.Sprintf("%02d%02d%c", d, l, quadrant)
+		}
+		return fmt.Sprintf("%02d%c%02d", d, quadrant, l-100)
+	}
+
+	ns, ew := 'N', 'E'
+	if lat < 0 {
+		ns = 'S'
+	}
+	if lon < 0 {
+		ew = 'W'
+	}
+	return fmt.Sprintf("%c%02d%03d%c%03d%03d", ns, latTenths/600, latTenths%600, ew, lonTenths/600, lonTenths%600)
+}
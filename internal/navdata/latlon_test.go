@@ -0,0 +1,41 @@
+package navdata
+
+import "testing"
+
+func TestLatLonWaypoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		canonical string
+		lat, lon  float64
+	}{
+		{"5740N", "5740N", 57, -40},
+		{"N57000W040000", "5740N", 57, -40},
+		{"57N040W", "5740N", 57, -40},
+		{"N57W040", "5740N", 57, -40},
+		{"52N75", "52N75", 52, -175},
+		{"N52000W175000", "52N75", 52, -175},
+		{"4020E", "4020E", 40, 20},
+		{"S33000E151000", "33S51", -33, 151},
+		{"0510W", "0510W", -5, -10},
+		{"N25400W080030", "N25400W080030", 25 + 40.0/60, -(80 + 3.0/60)},
+		{"N2540.0W08003.0", "N25400W080030", 25 + 40.0/60, -(80 + 3.0/60)},
+		{"2540N08003W", "N25400W080030", 25 + 40.0/60, -(80 + 3.0/60)},
+		{"S3352.04E15118.01", "S33520E151180", -(33 + 52.0/60), 151.3},
+	}
+	for _, tt := range tests {
+		canonical, lat, lon, ok := LatLonWaypoint(tt.name)
+		if !ok || canonical != tt.canonical || !near(lat, tt.lat) || !near(lon, tt.lon) {
+			t.Errorf("LatLonWaypoint(%q) = %q, %v, %v, %v, want %q, %v, %v", tt.name, canonical, lat, lon, ok, tt.canonical, tt.lat, tt.lon)
+		}
+	}
+
+	for _, name := range []string{"TAPUZ", "N123", "N0450F350", "ALB7", ""} {
+		if _, _, _, ok := LatLonWaypoint(name); ok {
+			t.Errorf("LatLonWaypoint(%q) recognised a published name", name)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
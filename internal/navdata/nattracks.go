@@ -0,0 +1,243 @@
+package navdata
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// NATTrack is one of the North Atlantic organised tracks published each day
+// in the NAT track message, valid for one crossing period.
+type NATTrack struct {
+	Letter string `json:"letter"`
+	// Points are the track's fixes in order: named fixes as given, and
+	// coordinates by their canonical names (5720N; see LatLonName).
+	Points     []string  `json:"points"`
+	ValidFrom  time.Time `json:"valid_from"`
+	ValidTo    time.Time `json:"valid_to"`
+	TMI        int       `json:"tmi,omitempty"` // Track message identifier.
+	EastLevels []int     `json:"east_levels,omitempty"`
+	WestLevels []int     `json:"west_levels,omitempty"`
+}
+
+var (
+	// natValidityRe matches the validity of a part of the message, e.g.
+	// JUL 01/1130Z TO JUL 01/1900Z.
+	natValidityRe = regexp.MustCompile(`^([A-Z]{3}) (\d{2})/(\d{2})(\d{2})Z TO ([A-Z]{3}) (\d{2})/(\d{2})(\d{2})Z`)
+	// natTrackRe matches a track's line: its letter, then its points.
+	natTrackRe = regexp.MustCompile(`^([A-Z]) ([A-Z0-9/ ]+?)-?$`)
+	// natLevelsRe matches the flight levels of a track in each direction.
+	natLevelsRe = regexp.MustCompile(`^(EAST|WEST) LVLS ((?:\d{3}\s*)+|NIL)`)
+	// natTMIRe matches the track message identifier in the remarks.
+	natTMIRe = regexp.MustCompile(`\bTMI IS (\d{3})\b`)
+	// natPointRe matches a coordinate point, e.g. 57/20 for 57N 020W or
+	// 5730/20 for 5730N 020W.
+	natPointRe = regexp.MustCompile(`^(\d{2})(\d{2})?/(\d{2,3})$`)
+)
+
+var errNoNATTracks = errors.New("no NAT tracks found")
+
+// ParseNATMessage reads the tracks of a NAT track message, in the published
+// format, which may be in several parts:
+//
+//	(NAT-1/2 TRACKS FLS 310/390 INCLUSIVE
+//	JUL 01/1130Z TO JUL 01/1900Z
+//	PART ONE OF TWO PARTS-
+//	A SUNOT 57/20 58/30 59/40 58/50 PRAWN YDP
+//	EAST LVLS NIL
+//	WEST LVLS 310 320 330 340 350 360 370 380 390
+//	...
+//
+// The message gives no year, so its dates are taken in the year that puts
+// them nearest ref. Coordinates are north latitude and west longitude.
+func ParseNATMessage(r io.Reader, ref time.Time) ([]NATTrack, error) {
+	var tracks []NATTrack
+	var from, to time.Time
+	tmi := 0
+	var current *NATTrack
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.Join(strings.Fields(strings.ToUpper(sc.Text())), " ")
+		line = strings.TrimPrefix(line, "(")
+
+		if m := natValidityRe.FindStringSubmatch(line); m != nil {
+			from, to = natTime(m[1:5], ref), natTime(m[5:9], ref)
+			current = nil
+			continue
+		}
+		if m := natTMIRe.FindStringSubmatch(line); m != nil {
+			tmi, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := natLevelsRe.FindStringSubmatch(line); m != nil {
+			if current != nil {
+				levels := natLevels(m[2])
+				if m[1] == "EAST" {
+					current.EastLevels = levels
+				} else {
+					current.WestLevels = levels
+				}
+			}
+			continue
+		}
+		if m := natTrackRe.FindStringSubmatch(line); m != nil && !from.IsZero() {
+			points := natPoints(m[2])
+			if len(points) < 2 {
+				continue
+			}
+			tracks = append(tracks, NATTrack{Letter: m[1], Points: points, ValidFrom: from, ValidTo: to})
+			current = &tracks[len(tracks)-1]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, errNoNATTracks
+	}
+	for i := range tracks {
+		tracks[i].TMI = tmi
+	}
+	return tracks, nil
+}
+
+// natTime reads a date and time of the message (month, day, hours and
+// minutes) in the year nearest ref.
+func natTime(m []string, ref time.Time) time.Time {
+	month, err := time.Parse("Jan", m[0][:1]+strings.ToLower(m[0][1:]))
+	if err != nil {
+		return time.Time{}
+	}
+	day, _ := strconv.Atoi(m[1])
+	h, _ := strconv.Atoi(m[2])
+	mins, _ := strconv.Atoi(m[3])
+
+	ref = ref.UTC()
+	best := time.Time{}
+	for _, year := range []int{ref.Year() - 1, ref.Year(), ref.Year() + 1} {
+		t := time.Date(year, month.Month(), day, h, mins, 0, 0, time.UTC)
+		if best.IsZero() || absDuration(t.Sub(ref)) < absDuration(best.Sub(ref)) {
+			best = t
+		}
+	}
+	return best
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// natPoints reads the points of a track line.
+func natPoints(s string) []string {
+	var points []string
+	for _, f := range strings.Fields(s) {
+		if m := natPointRe.FindStringSubmatch(f); m != nil {
+			lat, _ := strconv.Atoi(m[1])
+			lon, _ := strconv.Atoi(m[3])
+			latMin := 0
+			if m[2] != "" {
+				latMin, _ = strconv.Atoi(m[2])
+			}
+			points = append(points, LatLonName(float64(lat)+float64(latMin)/60, -float64(lon)))
+			continue
+		}
+		if canonical, _, _, ok := LatLonWaypoint(f); ok {
+			points = append(points, canonical)
+			continue
+		}
+		if strings.ContainsAny(f, "/0123456789") {
+			return nil // Not a track: a line of remarks.
+		}
+		points = append(points, f)
+	}
+	return points
+}
+
+func natLevels(s string) []int {
+	var levels []int
+	for _, f := range strings.Fields(s) {
+		if fl, err := strconv.Atoi(f); err == nil {
+			levels = append(levels, fl)
+		}
+	}
+	return levels
+}
+
+// NATTracks holds the tracks of one or more NAT track messages. The zero
+// value is not usable; create one with NewNATTracks.
+type NATTracks struct {
+	byLetter map[string][]NATTrack // Ordered by ValidFrom.
+}
+
+// NewNATTracks returns a table of the given tracks.
+func NewNATTracks(tracks []NATTrack) *NATTracks {
+	t := &NATTracks{byLetter: make(map[string][]NATTrack)}
+	for _, tr := range tracks {
+		t.byLetter[tr.Letter] = append(t.byLetter[tr.Letter], tr)
+	}
+	for _, trs := range t.byLetter {
+		sort.Slice(trs, func(i, j int) bool { return trs[i].ValidFrom.Before(trs[j].ValidFrom) })
+	}
+	return t
+}
+
+// Len returns the number of tracks in the table.
+func (t *NATTracks) Len() int {
+	if t == nil {
+		return 0
+	}
+	n := 0
+	for _, trs := range t.byLetter {
+		n += len(trs)
+	}
+	return n
+}
+
+// natClearanceLead is how long before a track becomes valid a clearance on
+// it may be given.
+const natClearanceLead = 3 * time.Hour
+
+// Lookup returns the track with the letter that is valid at a time, or
+// failing that the one that becomes valid soonest within natClearanceLead
+// after it, as oceanic clearances are given before the aircraft enters the
+// track. It returns false when there is none, or t is nil.
+func (t *NATTracks) Lookup(letter string, at time.Time) (NATTrack, bool) {
+	if t == nil {
+		return NATTrack{}, false
+	}
+	trs := t.byLetter[strings.ToUpper(letter)]
+	for i := len(trs) - 1; i >= 0; i-- {
+		if !at.Before(trs[i].ValidFrom) && at.Before(trs[i].ValidTo) {
+			return trs[i], true
+		}
+	}
+	for _, tr := range trs {
+		if tr.ValidFrom.After(at) && tr.ValidFrom.Sub(at) <= natClearanceLead {
+			return tr, true
+		}
+	}
+	return NATTrack{}, false
+}
+
+var defaultNATTracks atomic.Pointer[NATTracks]
+
+// SetDefaultNATTracks sets the NAT tracks used to resolve the track letters
+// of oceanic clearances. It may be called again as new tracks are published.
+func SetDefaultNATTracks(t *NATTracks) {
+	defaultNATTracks.Store(t)
+}
+
+// DefaultNATTracks returns the configured NAT tracks, or nil.
+func DefaultNATTracks() *NATTracks {
+	return defaultNATTracks.Load()
+}
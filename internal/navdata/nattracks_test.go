@@ -0,0 +1,102 @@
+package navdata
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const natMessage = `(NAT-1/2 TRACKS FLS 310/390 INCLUSIVE
+JUL 01/1130Z TO JUL 01/1900Z
+PART ONE OF TWO PARTS-
+A SUNOT 57/20 58/30 59/40 58/50 PRAWN YDP
+EAST LVLS NIL
+WEST LVLS 310 320 330 340 350 360 370 380 390
+EUR RTS WEST NIL
+NAR NIL-
+B PIKIL 5630/20 57/30 58/40 57/50 PORGY HOIST
+EAST LVLS NIL
+WEST LVLS 310 320 330 340 350 360 370 380 390
+EUR RTS WEST NIL
+NAR NIL-)
+
+(NAT-2/2 TRACKS FLS 310/390 INCLUSIVE
+JUL 01/1130Z TO JUL 01/1900Z
+PART TWO OF TWO PARTS-
+REMARKS.
+1. TMI IS 182 AND OPERATORS ARE REMINDED TO INCLUDE THE TMI NUMBER AS
+PART OF THE OCEANIC CLEARANCE READ BACK.
+END OF PART TWO OF TWO PARTS)`
+
+func TestParseNATMessage(t *testing.T) {
+	ref := time.Date(2026, 6, 30, 22, 0, 0, 0, time.UTC)
+	tracks, err := ParseNATMessage(strings.NewReader(natMessage), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(tracks))
+	}
+
+	levels := []int{310, 320, 330, 340, 350, 360, 370, 380, 390}
+	want := NATTrack{
+		Letter:     "A",
+		Points:     []string{"SUNOT", "5720N", "5830N", "5940N", "5850N", "PRAWN", "YDP"},
+		ValidFrom:  time.Date(2026, 7, 1, 11, 30, 0, 0, time.UTC),
+		ValidTo:    time.Date(2026, 7, 1, 19, 0, 0, 0, time.UTC),
+		TMI:        182,
+		WestLevels: levels,
+	}
+	if !reflect.DeepEqual(tracks[0], want) {
+		t.Errorf("track A = %+v, want %+v", tracks[0], want)
+	}
+	if got := tracks[1].Points[1]; got != "N56300W020000" {
+		t.Errorf("track B point = %q, want N56300W020000", got)
+	}
+}
+
+func TestParseNATMessageYear(t *testing.T) {
+	msg := "DEC 31/2330Z TO JAN 01/0800Z\nA DOGAL 54/20 55/30 55/40 54/50 ALLRY"
+	tracks, err := ParseNATMessage(strings.NewReader(msg), time.Date(2027, 1, 1, 1, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tracks[0].ValidFrom; got.Year() != 2026 {
+		t.Errorf("valid from %v, want 2026", got)
+	}
+	if got := tracks[0].ValidTo; got.Year() != 2027 {
+		t.Errorf("valid to %v, want 2027", got)
+	}
+}
+
+func TestNATTracksLookup(t *testing.T) {
+	day := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	tracks := NewNATTracks([]NATTrack{
+		{Letter: "B", Points: []string{"PIKIL", "5620N"}, ValidFrom: day.Add(11*time.Hour + 30*time.Minute), ValidTo: day.Add(19 * time.Hour)},
+		{Letter: "B", Points: []string{"ELSIR", "5020N"}, ValidFrom: day.Add(25 * time.Hour), ValidTo: day.Add(32 * time.Hour)},
+	})
+
+	tests := []struct {
+		at   time.Duration
+		want string // First point, or "" for none.
+	}{
+		{12 * time.Hour, "PIKIL"},
+		{9 * time.Hour, "PIKIL"}, // A clearance before the track is valid.
+		{23 * time.Hour, "ELSIR"},
+		{20 * time.Hour, ""},
+	}
+	for _, tt := range tests {
+		tr, ok := tracks.Lookup("b", day.Add(tt.at))
+		got := ""
+		if ok {
+			got = tr.Points[0]
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(B, +%v) = %q, want %q", tt.at, got, tt.want)
+		}
+	}
+	if _, ok := (*NATTracks)(nil).Lookup("B", day); ok {
+		t.Error("Lookup on nil table succeeded")
+	}
+}
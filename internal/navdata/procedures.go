@@ -0,0 +1,308 @@
+package navdata
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// ProcedureKind distinguishes departure and arrival procedures.
+type ProcedureKind string
+
+const (
+	SID  ProcedureKind = "SID"
+	STAR ProcedureKind = "STAR"
+)
+
+// Procedure is a terminal procedure made of a common route plus runway and
+// en-route transitions, each an ordered list of fix identifiers.
+type Procedure struct {
+	Airport            string
+	Ident              string
+	Kind               ProcedureKind
+	Common             []string
+	RunwayTransitions  map[string][]string // Keyed by runway, e.g. "27L" or "09B" (both parallels).
+	EnrouteTransitions map[string][]string // Keyed by transition fix, e.g. "BRHMA".
+}
+
+// Runways returns the runways the procedure serves, sorted. "B" (both) runway
+// transitions are listed as the left and right parallels.
+func (p *Procedure) Runways() []string {
+	seen := make(map[string]bool)
+	for rw := range p.RunwayTransitions {
+		if strings.HasSuffix(rw, "B") && len(rw) == 3 {
+			seen[rw[:2]+"L"] = true
+			seen[rw[:2]+"R"] = true
+			continue
+		}
+		seen[rw] = true
+	}
+	runways := make([]string, 0, len(seen))
+	for rw := range seen {
+		runways = append(runways, rw)
+	}
+	sort.Strings(runways)
+	return runways
+}
+
+// runwayTransition returns the fixes of the transition serving runway. With no
+// runway given, a procedure with a single runway transition uses that one.
+func (p *Procedure) runwayTransition(runway string) ([]string, string) {
+	if runway != "" {
+		if fixes, ok := p.RunwayTransitions[runway]; ok {
+			return fixes, runway
+		}
+		if len(runway) == 3 {
+			if fixes, ok := p.RunwayTransitions[runway[:2]+"B"]; ok {
+				return fixes, runway
+			}
+		}
+		return nil, ""
+	}
+	if len(p.RunwayTransitions) == 1 {
+		for rw, fixes := range p.RunwayTransitions {
+			if !strings.HasSuffix(rw, "B") {
+				return fixes, rw
+			}
+			return fixes, ""
+		}
+	}
+	return nil, ""
+}
+
+// ResolvedProcedure is a procedure expanded for one flight.
+type ResolvedProcedure struct {
+	Ident      string   `json:"ident"`
+	Transition string   `json:"transition,omitempty"`
+	Runways    []string `json:"runways,omitempty"`
+	Waypoints  []string `json:"waypoints,omitempty"`
+}
+
+// Procedures is a terminal procedure database. The zero value is not usable;
+// create one with NewProcedures or LoadCIFP.
+type Procedures struct {
+	procs map[string]*Procedure
+}
+
+// NewProcedures returns an empty procedure database.
+func NewProcedures() *Procedures {
+	return &Procedures{procs: make(map[string]*Procedure)}
+}
+
+func procedureKey(airport string, kind ProcedureKind, ident string) string {
+	return strings.ToUpper(airport) + "|" + string(kind) + "|" + strings.ToUpper(ident)
+}
+
+// Add records a procedure, replacing any with the same airport, kind and ident.
+func (ps *Procedures) Add(p *Procedure) {
+	ps.procs[procedureKey(p.Airport, p.Kind, p.Ident)] = p
+}
+
+// Len returns the number of procedures in the database.
+func (ps *Procedures) Len() int {
+	return len(ps.procs)
+}
+
+// Lookup returns a procedure, or nil if it is unknown. ARINC 424 identifiers
+// are at most 6 characters, so longer identifiers are also tried truncated.
+func (ps *Procedures) Lookup(airport string, kind ProcedureKind, ident string) *Procedure {
+	if p := ps.procs[procedureKey(airport, kind, ident)]; p != nil {
+		return p
+	}
+	if len(ident) > 6 {
+		return ps.procs[procedureKey(airport, kind, ident[:6])]
+	}
+	return nil
+}
+
+// Resolve expands a procedure into the runways it serves and the fixes flown.
+// A SID is flown runway transition, common route, en-route transition; a STAR
+// the other way round. The runway narrows the runway transition; without one
+// the runway portion is only included when the procedure serves one runway.
+// It returns nil if the procedure is unknown.
+func (ps *Procedures) Resolve(airport string, kind ProcedureKind, ident, transition, runway string) *ResolvedProcedure {
+	p := ps.Lookup(airport, kind, ident)
+	if p == nil {
+		return nil
+	}
+	runway = NormaliseRunway(runway)
+	transition = strings.ToUpper(transition)
+
+	rwFixes, rw := p.runwayTransition(runway)
+	enroute := p.EnrouteTransitions[transition]
+
+	var parts [][]string
+	if kind == SID {
+		parts = [][]string{rwFixes, p.Common, enroute}
+	} else {
+		parts = [][]string{enroute, p.Common, rwFixes}
+	}
+
+	r := &ResolvedProcedure{Ident: p.Ident, Transition: transition}
+	for _, part := range parts {
+		for _, fix := range part {
+			if n := len(r.Waypoints); n > 0 && r.Waypoints[n-1] == fix {
+				continue
+			}
+			r.Waypoints = append(r.Waypoints, fix)
+		}
+	}
+	if rw != "" {
+		r.Runways = []string{rw}
+	} else {
+		r.Runways = p.Runways()
+	}
+	return r
+}
+
+// NormaliseRunway converts a runway designator such as "RW9L", "9L" or "09l"
+// to the two-digit form "09L". It returns "" for anything else.
+func NormaliseRunway(rw string) string {
+	rw = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rw)), "RW")
+	digits := 0
+	for digits < len(rw) && rw[digits] >= '0' && rw[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || digits > 2 {
+		return ""
+	}
+	suffix := rw[digits:]
+	if len(suffix) > 1 || (suffix != "" && !strings.ContainsAny(suffix, "LRCB")) {
+		return ""
+	}
+	if digits == 1 {
+		rw = "0" + rw
+	}
+	return rw
+}
+
+// The default procedure database is shared by the parsers that annotate
+// SID/STAR identifiers.
+var defaultProcedures atomic.Pointer[Procedures]
+
+// SetDefaultProcedures configures the procedure database used by parsers to
+// resolve SID/STAR identifiers. Pass nil to disable resolution.
+func SetDefaultProcedures(ps *Procedures) {
+	defaultProcedures.Store(ps)
+}
+
+// DefaultProcedures returns the configured procedure database, or nil.
+func DefaultProcedures() *Procedures {
+	return defaultProcedures.Load()
+}
+
+// segment classes of ARINC 424 route types.
+const (
+	segNone = iota
+	segRunway
+	segCommon
+	segEnroute
+)
+
+// sidRouteClass and starRouteClass map the ARINC 424 route type (column 20)
+// to the segment it describes. RNAV and FMS variants share the basic classes.
+var (
+	sidRouteClass = map[byte]int{
+		'1': segRunway, '4': segRunway, 'F': segRunway,
+		'2': segCommon, '5': segCommon, 'M': segCommon,
+		'3': segEnroute, '6': segEnroute, 'S': segEnroute, 'T': segEnroute, 'V': segEnroute,
+	}
+	starRouteClass = map[byte]int{
+		'1': segEnroute, '4': segEnroute, '7': segEnroute, 'F': segEnroute,
+		'2': segCommon, '5': segCommon, '8': segCommon, 'M': segCommon,
+		'3': segRunway, '6': segRunway, '9': segRunway, 'S': segRunway,
+	}
+)
+
+// LoadCIFP reads SID (subsection PD) and STAR (PE) procedures from ARINC 424
+// fixed-width records, such as the FAA CIFP. Other records, continuation
+// records and legs without a fix (e.g. heading legs) are ignored. Runway
+// threshold fixes ("RW27L") are not included in waypoint lists.
+func LoadCIFP(r io.Reader) (*Procedures, error) {
+	ps := NewProcedures()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 256), 64*1024)
+
+	for sc.Scan() {
+		line := sc.Text()
+		// Columns are 1-indexed in the specification: the fix ends at 34 and the
+		// continuation number is at 39.
+		if len(line) < 39 || (line[0] != 'S' && line[0] != 'T') || line[4] != 'P' {
+			continue
+		}
+
+		var kind ProcedureKind
+		var classes map[byte]int
+		switch line[12] {
+		case 'D':
+			kind, classes = SID, sidRouteClass
+		case 'E':
+			kind, classes = STAR, starRouteClass
+		default:
+			continue
+		}
+		if cont := line[38]; cont != '0' && cont != '1' && cont != ' ' {
+			continue
+		}
+
+		airport := strings.TrimSpace(line[6:10])
+		ident := strings.TrimSpace(line[13:19])
+		class := classes[line[19]]
+		transition := strings.TrimSpace(line[20:25])
+		fix := strings.TrimSpace(line[29:34])
+		if airport == "" || ident == "" || class == segNone {
+			continue
+		}
+
+		key := procedureKey(airport, kind, ident)
+		p := ps.procs[key]
+		if p == nil {
+			p = &Procedure{
+				Airport:            airport,
+				Ident:              ident,
+				Kind:               kind,
+				RunwayTransitions:  make(map[string][]string),
+				EnrouteTransitions: make(map[string][]string),
+			}
+			ps.procs[key] = p
+		}
+
+		if class == segRunway {
+			// Register the runway even if its legs carry no named fix.
+			rw := NormaliseRunway(transition)
+			if rw == "" {
+				continue
+			}
+			if _, ok := p.RunwayTransitions[rw]; !ok {
+				p.RunwayTransitions[rw] = nil
+			}
+			if fix != "" && NormaliseRunway(fix) == "" {
+				p.RunwayTransitions[rw] = appendFix(p.RunwayTransitions[rw], fix)
+			}
+			continue
+		}
+
+		if fix == "" || NormaliseRunway(fix) != "" {
+			continue
+		}
+		if class == segCommon {
+			p.Common = appendFix(p.Common, fix)
+		} else if transition != "" {
+			p.EnrouteTransitions[transition] = appendFix(p.EnrouteTransitions[transition], fix)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// appendFix appends a fix unless it repeats the previous one.
+func appendFix(fixes []string, fix string) []string {
+	if n := len(fixes); n > 0 && fixes[n-1] == fix {
+		return fixes
+	}
+	return append(fixes, fix)
+}
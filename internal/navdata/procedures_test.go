@@ -0,0 +1,117 @@
+package navdata
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// cifpRecord builds an ARINC 424 airport procedure record with the fields at
+// their specified columns, padded to 132 characters.
+func cifpRecord(sub byte, airport, ident string, routeType byte, transition string, seq int, fix string) string {
+	line := fmt.Sprintf("SUSAP %-4sK1%c%-6s%c%-5s %03d%-5sK1PC0", airport, sub, ident, routeType, transition, seq, fix)
+	return line + strings.Repeat(" ", 132-len(line))
+}
+
+func testCIFP() string {
+	records := []string{
+		// KATZZ2 SID: runway transitions for 09L and 27B, common route, BRHMA transition.
+		cifpRecord('D', "KDFW", "KATZZ2", '4', "RW09L", 10, ""),
+		cifpRecord('D', "KDFW", "KATZZ2", '4', "RW09L", 20, "DEBBB"),
+		cifpRecord('D', "KDFW", "KATZZ2", '4', "RW27B", 10, "RW27L"),
+		cifpRecord('D', "KDFW", "KATZZ2", '4', "RW27B", 20, "WSTRN"),
+		cifpRecord('D', "KDFW", "KATZZ2", '5', "ALL", 10, "KATZZ"),
+		cifpRecord('D', "KDFW", "KATZZ2", '5', "ALL", 20, "KATZZ"),
+		cifpRecord('D', "KDFW", "KATZZ2", '5', "ALL", 30, "HRPER"),
+		cifpRecord('D', "KDFW", "KATZZ2", '6', "BRHMA", 10, "HRPER"),
+		cifpRecord('D', "KDFW", "KATZZ2", '6', "BRHMA", 20, "BRHMA"),
+		// BEEKN1 STAR: en-route transition, common route, one runway transition.
+		cifpRecord('E', "KDFW", "BEEKN1", '4', "ABI", 10, "ABI"),
+		cifpRecord('E', "KDFW", "BEEKN1", '4', "ABI", 20, "BEEKN"),
+		cifpRecord('E', "KDFW", "BEEKN1", '5', "", 10, "BEEKN"),
+		cifpRecord('E', "KDFW", "BEEKN1", '5', "", 20, "CRIED"),
+		cifpRecord('E', "KDFW", "BEEKN1", '6', "RW17C", 10, "CRIED"),
+		cifpRecord('E', "KDFW", "BEEKN1", '6', "RW17C", 20, "JAYKE"),
+		// An approach record is ignored.
+		cifpRecord('F', "KDFW", "I17C", 'I', "", 10, "JAYKE"),
+	}
+	return strings.Join(records, "\n") + "\n"
+}
+
+func TestLoadCIFP(t *testing.T) {
+	ps, err := LoadCIFP(strings.NewReader(testCIFP()))
+	if err != nil {
+		t.Fatalf("LoadCIFP() error = %v", err)
+	}
+	if ps.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", ps.Len())
+	}
+
+	sid := ps.Lookup("KDFW", SID, "KATZZ2")
+	if sid == nil {
+		t.Fatal("KATZZ2 not loaded")
+	}
+	if got := strings.Join(sid.Runways(), " "); got != "09L 27L 27R" {
+		t.Errorf("Runways() = %q, want 09L 27L 27R", got)
+	}
+	if got := strings.Join(sid.Common, " "); got != "KATZZ HRPER" {
+		t.Errorf("Common = %q, want KATZZ HRPER", got)
+	}
+	if ps.Lookup("KDFW", STAR, "KATZZ2") != nil {
+		t.Error("KATZZ2 should not be found as a STAR")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ps, err := LoadCIFP(strings.NewReader(testCIFP()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		kind       ProcedureKind
+		ident      string
+		transition string
+		runway     string
+		runways    string
+		waypoints  string
+	}{
+		{"SID with runway and transition", SID, "KATZZ2", "BRHMA", "9L", "09L", "DEBBB KATZZ HRPER BRHMA"},
+		{"SID on a both-parallels transition", SID, "KATZZ2", "", "27R", "27R", "WSTRN KATZZ HRPER"},
+		{"SID without runway", SID, "KATZZ2", "BRHMA", "", "09L 27L 27R", "KATZZ HRPER BRHMA"},
+		{"STAR with single runway", STAR, "BEEKN1", "ABI", "", "17C", "ABI BEEKN CRIED JAYKE"},
+		{"long identifier is truncated", STAR, "BEEKN1A", "", "", "17C", "BEEKN CRIED JAYKE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ps.Resolve("KDFW", tt.kind, tt.ident, tt.transition, tt.runway)
+			if r == nil {
+				t.Fatal("Resolve() = nil")
+			}
+			if got := strings.Join(r.Runways, " "); got != tt.runways {
+				t.Errorf("Runways = %q, want %q", got, tt.runways)
+			}
+			if got := strings.Join(r.Waypoints, " "); got != tt.waypoints {
+				t.Errorf("Waypoints = %q, want %q", got, tt.waypoints)
+			}
+		})
+	}
+
+	if ps.Resolve("KDFW", SID, "NOPE1", "", "") != nil {
+		t.Error("Resolve() of an unknown procedure should be nil")
+	}
+}
+
+func TestNormaliseRunway(t *testing.T) {
+	tests := map[string]string{
+		"RW09L": "09L", "9L": "09L", "27": "27", "rw4r": "04R", "27B": "27B",
+		"ALL": "", "123": "", "09X": "", "": "",
+	}
+	for in, want := range tests {
+		if got := NormaliseRunway(in); got != want {
+			t.Errorf("NormaliseRunway(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
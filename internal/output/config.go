@@ -0,0 +1,115 @@
+package output
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"acars_parser/internal/envflag"
+)
+
+// Default topic templates.
+const (
+	DefaultMQTTTopic   = "acars/{kind}/{label}/{icao}"
+	DefaultKafkaTopic  = "acars.{kind}"
+	DefaultNATSSubject = "acars.{kind}.{label}"
+)
+
+// Config selects and configures the sinks to publish to.
+type Config struct {
+	MQTTBroker   string // Empty disables MQTT.
+	MQTTTopic    string
+	MQTTUser     string
+	MQTTPassword string
+	MQTTQoS      int
+	MQTTRetain   bool
+	KafkaBrokers string // Comma-separated; empty disables Kafka.
+	KafkaTopic   string
+	NATSURL      string // Empty disables NATS.
+	NATSSubject  string
+	NATSCreds    string
+	Format       string // Payload serialisation for every sink.
+}
+
+// AddFlags registers the sink flags on fs, with defaults from the
+// environment, and returns the Config they fill.
+func AddFlags(fs *flag.FlagSet) *Config {
+	c := &Config{}
+	fs.StringVar(&c.MQTTBroker, "mqtt", os.Getenv("MQTT_BROKER"), "MQTT broker URL, e.g. tcp://localhost:1883")
+	fs.StringVar(&c.MQTTTopic, "mqtt-topic", envflag.String("MQTT_TOPIC", DefaultMQTTTopic), "MQTT topic template")
+	fs.StringVar(&c.MQTTUser, "mqtt-user", os.Getenv("MQTT_USER"), "MQTT user")
+	fs.StringVar(&c.MQTTPassword, "mqtt-password", os.Getenv("MQTT_PASSWORD"), "MQTT password")
+	fs.IntVar(&c.MQTTQoS, "mqtt-qos", envflag.Int("MQTT_QOS", 0), "MQTT QoS (0-2)")
+	fs.BoolVar(&c.MQTTRetain, "mqtt-retain", envflag.Bool("MQTT_RETAIN", false), "Publish retained MQTT messages")
+	fs.StringVar(&c.KafkaBrokers, "kafka", os.Getenv("KAFKA_BROKERS"), "Comma-separated Kafka brokers")
+	fs.StringVar(&c.KafkaTopic, "kafka-topic", envflag.String("KAFKA_TOPIC", DefaultKafkaTopic), "Kafka topic template")
+	fs.StringVar(&c.NATSURL, "nats", os.Getenv("NATS_URL"), "NATS server URL, e.g. nats://localhost:4222")
+	fs.StringVar(&c.NATSSubject, "nats-subject", envflag.String("NATS_SUBJECT", DefaultNATSSubject), "NATS subject template")
+	fs.StringVar(&c.NATSCreds, "nats-creds", os.Getenv("NATS_CREDS"), "NATS credentials file")
+	fs.StringVar(&c.Format, "sink-format", envflag.String("SINK_FORMAT", FormatEvent), "Sink payload: event or data")
+	return c
+}
+
+// Enabled reports whether any sink is configured.
+func (c *Config) Enabled() bool {
+	return c.MQTTBroker != "" || c.KafkaBrokers != "" || c.NATSURL != ""
+}
+
+// Open connects to the configured sinks. It returns nil when none are
+// configured.
+func (c *Config) Open() (Sink, error) {
+	if c.Format != "" && c.Format != FormatEvent && c.Format != FormatData {
+		return nil, fmt.Errorf("unknown sink format %q", c.Format)
+	}
+
+	var sinks Multi
+	if c.MQTTBroker != "" {
+		if c.MQTTQoS < 0 || c.MQTTQoS > 2 {
+			return nil, fmt.Errorf("mqtt qos must be 0, 1 or 2, not %d", c.MQTTQoS)
+		}
+		s, err := NewMQTT(MQTTConfig{
+			Broker:   c.MQTTBroker,
+			Username: c.MQTTUser,
+			Password: c.MQTTPassword,
+			Topic:    c.MQTTTopic,
+			QoS:      byte(c.MQTTQoS),
+			Retain:   c.MQTTRetain,
+			Format:   c.Format,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if c.KafkaBrokers != "" {
+		var brokers []string
+		for _, b := range strings.Split(c.KafkaBrokers, ",") {
+			if b = strings.TrimSpace(b); b != "" {
+				brokers = append(brokers, b)
+			}
+		}
+		s, err := NewKafka(KafkaConfig{Brokers: brokers, Topic: c.KafkaTopic, Format: c.Format})
+		if err != nil {
+			sinks.Close()
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if c.NATSURL != "" {
+		s, err := NewNATS(NATSConfig{URL: c.NATSURL, Creds: c.NATSCreds, Subject: c.NATSSubject, Format: c.Format})
+		if err != nil {
+			sinks.Close()
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
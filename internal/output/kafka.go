@@ -0,0 +1,117 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig configures a Kafka sink.
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string // Topic template (see Topic).
+	Format  string // FormatEvent or FormatData.
+}
+
+// KafkaSink publishes events to Kafka. Messages are keyed by ICAO hex, so the
+// events of one aircraft stay in order on one partition. Writes are batched
+// in the background; a failed batch is reported by the next Publish or Close.
+// PublishNow writes through a second, unbatched writer and waits for the
+// brokers to acknowledge.
+type KafkaSink struct {
+	writer *kafka.Writer
+	now    *kafka.Writer
+	cfg    KafkaConfig
+
+	mu  sync.Mutex
+	err error // First write error not yet reported.
+}
+
+// NewKafka creates a Kafka sink. Connections are made on first publish.
+func NewKafka(cfg KafkaConfig) (*KafkaSink, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka: no brokers")
+	}
+	s := &KafkaSink{cfg: cfg}
+	s.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(cfg.Brokers...),
+		Balancer:               &kafka.Hash{},
+		BatchTimeout:           100 * time.Millisecond,
+		AllowAutoTopicCreation: true,
+		Async:                  true,
+		Completion: func(msgs []kafka.Message, err error) {
+			if err != nil {
+				s.setErr(fmt.Errorf("write %d messages to kafka: %w", len(msgs), err))
+			}
+		},
+	}
+	s.now = &kafka.Writer{
+		Addr:                   kafka.TCP(cfg.Brokers...),
+		Balancer:               &kafka.Hash{},
+		BatchSize:              1,
+		AllowAutoTopicCreation: true,
+		RequiredAcks:           kafka.RequireAll,
+	}
+	return s, nil
+}
+
+// Publish queues an event for writing.
+func (s *KafkaSink) Publish(ctx context.Context, e Event) error {
+	if err := s.takeErr(); err != nil {
+		return err
+	}
+	payload, err := Encode(e, s.cfg.Format)
+	if err != nil {
+		return err
+	}
+	topic := Topic(s.cfg.Topic, e)
+	err = s.writer.WriteMessages(ctx, kafka.Message{Topic: topic, Key: []byte(e.ICAOHex), Value: payload})
+	if err != nil {
+		return fmt.Errorf("write to kafka topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+// PublishNow writes an event at once, without waiting for the batch, and
+// returns once it is acknowledged.
+func (s *KafkaSink) PublishNow(ctx context.Context, e Event) error {
+	payload, err := Encode(e, s.cfg.Format)
+	if err != nil {
+		return err
+	}
+	topic := Topic(s.cfg.Topic, e)
+	err = s.now.WriteMessages(ctx, kafka.Message{Topic: topic, Key: []byte(e.ICAOHex), Value: payload})
+	if err != nil {
+		return fmt.Errorf("write to kafka topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the connections.
+func (s *KafkaSink) Close() error {
+	err := errors.Join(s.writer.Close(), s.now.Close())
+	if err != nil {
+		return err
+	}
+	return s.takeErr()
+}
+
+func (s *KafkaSink) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *KafkaSink) takeErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
@@ -0,0 +1,83 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttTimeout bounds how long connecting and each publish may take.
+const mqttTimeout = 10 * time.Second
+
+// MQTTConfig configures an MQTT sink.
+type MQTTConfig struct {
+	Broker   string // e.g. tcp://localhost:1883 or ssl://broker:8883.
+	ClientID string
+	Username string
+	Password string
+	Topic    string // Topic template (see Topic).
+	QoS      byte
+	Retain   bool
+	Format   string // FormatEvent or FormatData.
+}
+
+// MQTTSink publishes events to an MQTT broker.
+type MQTTSink struct {
+	client mqtt.Client
+	cfg    MQTTConfig
+}
+
+// NewMQTT connects to the broker. The client reconnects automatically if the
+// connection drops later.
+func NewMQTT(cfg MQTTConfig) (*MQTTSink, error) {
+	if cfg.ClientID == "" {
+		cfg.ClientID = "acars_parser"
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(mqttTimeout)
+	client := mqtt.NewClient(opts)
+
+	tok := client.Connect()
+	if !tok.WaitTimeout(mqttTimeout) {
+		return nil, fmt.Errorf("connect to mqtt %s: timed out", cfg.Broker)
+	}
+	if err := tok.Error(); err != nil {
+		return nil, fmt.Errorf("connect to mqtt %s: %w", cfg.Broker, err)
+	}
+	return &MQTTSink{client: client, cfg: cfg}, nil
+}
+
+// Publish publishes an event and waits for the broker to accept it (for QoS
+// above 0).
+func (s *MQTTSink) Publish(ctx context.Context, e Event) error {
+	payload, err := Encode(e, s.cfg.Format)
+	if err != nil {
+		return err
+	}
+	topic := Topic(s.cfg.Topic, e)
+	tok := s.client.Publish(topic, s.cfg.QoS, s.cfg.Retain, payload)
+	select {
+	case <-tok.Done():
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(mqttTimeout):
+		return fmt.Errorf("publish to %s: timed out", topic)
+	}
+	if err := tok.Error(); err != nil {
+		return fmt.Errorf("publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Close disconnects from the broker.
+func (s *MQTTSink) Close() error {
+	s.client.Disconnect(250)
+	return nil
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsTimeout bounds connecting, and flushing pending events on close.
+const natsTimeout = 10 * time.Second
+
+// NATSConfig configures a NATS sink.
+type NATSConfig struct {
+	URL     string // e.g. nats://localhost:4222.
+	Creds   string // Credentials file; empty connects without one.
+	Subject string // Subject template (see Topic).
+	Format  string // FormatEvent or FormatData.
+}
+
+// NATSSink publishes events to NATS subjects. Publishes are buffered by the
+// client and sent in the background; PublishNow also waits for the server to
+// acknowledge the buffer.
+type NATSSink struct {
+	conn *nats.Conn
+	cfg  NATSConfig
+}
+
+// NewNATS connects to the server. The client reconnects automatically if the
+// connection drops later.
+func NewNATS(cfg NATSConfig) (*NATSSink, error) {
+	opts := []nats.Option{nats.Name("acars_parser"), nats.Timeout(natsTimeout)}
+	if cfg.Creds != "" {
+		opts = append(opts, nats.UserCredentials(cfg.Creds))
+	}
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats %s: %w", cfg.URL, err)
+	}
+	return &NATSSink{conn: conn, cfg: cfg}, nil
+}
+
+// Publish queues an event for its subject.
+func (s *NATSSink) Publish(ctx context.Context, e Event) error {
+	payload, err := Encode(e, s.cfg.Format)
+	if err != nil {
+		return err
+	}
+	subject := Topic(s.cfg.Subject, e)
+	if err := s.conn.Publish(subject, payload); err != nil {
+		return fmt.Errorf("publish to %s: %w", subject, err)
+	}
+	return nil
+}
+
+// PublishNow publishes an event and waits for the server to receive it.
+func (s *NATSSink) PublishNow(ctx context.Context, e Event) error {
+	if err := s.Publish(ctx, e); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, natsTimeout)
+	defer cancel()
+	if err := s.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("flush nats: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending events and disconnects.
+func (s *NATSSink) Close() error {
+	err := s.conn.FlushTimeout(natsTimeout)
+	s.conn.Close()
+	return err
+}
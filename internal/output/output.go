@@ -0,0 +1,284 @@
+// Package output publishes parsed results and enrichment updates to external
+// systems.
+//
+// A Sink receives Events: one per parser result, one per flight enrichment
+// update, one per emergency event and one per change to a flight's plan.
+// Emergency events are published with PublishNow, ahead of anything a sink
+// has batched. Sinks route events to topics built from a template, so deployments
+// can split the stream per label, per result type or per aircraft. The MQTT,
+// Kafka and NATS sinks are opened from a Config, usually filled from
+// command-line flags by AddFlags.
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/schema"
+	"acars_parser/internal/storage"
+	"acars_parser/internal/units"
+)
+
+// Event kinds.
+const (
+	KindResult     = "result"
+	KindEnrichment = "enrichment"
+	KindEmergency  = "emergency"
+	KindRoute      = "route"
+)
+
+// Event is one published item. Result events are the canonical envelope of
+// a parse result: the same fields for every result type, so that consumers
+// can find the message metadata and provenance of a result without knowing
+// its type, and the type-specific payload in Data.
+type Event struct {
+	Kind      string `json:"kind"`
+	Type      string `json:"type"` // Result type, "flight_enrichment", the emergency kind, or "route_changed".
+	Timestamp string `json:"timestamp,omitempty"`
+	Label     string `json:"label,omitempty"`
+	ICAOHex   string `json:"icao_hex,omitempty"`
+	Tail      string `json:"tail,omitempty"`
+	Flight    string `json:"flight,omitempty"`
+
+	// Message metadata of result events.
+	MessageID int64   `json:"message_id,omitempty"`
+	Direction string  `json:"link_direction,omitempty"`
+	StationID string  `json:"station_id,omitempty"`
+	Feeder    string  `json:"feeder,omitempty"`
+	Tenant    string  `json:"tenant,omitempty"`
+	Frequency float64 `json:"frequency,omitempty"`
+
+	// Provenance of result events. Parser is empty for link-layer results,
+	// which no parser produced. SchemaVersion is the version of the result
+	// type's JSON Schema (see internal/schema).
+	Parser        string   `json:"parser,omitempty"`
+	ParserVersion int      `json:"parser_version,omitempty"`
+	SchemaVersion int      `json:"schema_version,omitempty"`
+	Confidence    *float64 `json:"confidence,omitempty"` // See Confidence.
+	DecodedAt     string   `json:"decoded_at,omitempty"`
+
+	// Units are the altitudes, speeds and temperatures of result events in
+	// canonical units, with the values Data reports them in.
+	Units units.Values `json:"units,omitempty"`
+
+	Data interface{} `json:"data"`
+}
+
+// Sink publishes events. Implementations must be safe for use by one
+// goroutine at a time; Close flushes anything buffered.
+type Sink interface {
+	Publish(ctx context.Context, e Event) error
+	Close() error
+}
+
+// PrioritySink is implemented by sinks that batch events but can also
+// deliver one at once.
+type PrioritySink interface {
+	Sink
+	// PublishNow publishes an event without batching and returns once it has
+	// been delivered.
+	PublishNow(ctx context.Context, e Event) error
+}
+
+// flusher is implemented by sinks that buffer events until flushed.
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// PublishNow publishes an event ahead of anything batched: with the sink's
+// own PublishNow if it is a PrioritySink, otherwise by publishing and then
+// flushing the sink if it buffers. It is used for emergency events.
+func PublishNow(ctx context.Context, s Sink, e Event) error {
+	if p, ok := s.(PrioritySink); ok {
+		return p.PublishNow(ctx, e)
+	}
+	if err := s.Publish(ctx, e); err != nil {
+		return err
+	}
+	if f, ok := s.(flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// ResultEvents returns an event per result, carrying the message metadata,
+// the parser behind the result and the time it was decoded.
+func ResultEvents(msg *acars.Message, results []registry.Attributed, decodedAt time.Time) []Event {
+	base := Event{Kind: KindResult, DecodedAt: decodedAt.UTC().Format(time.RFC3339Nano)}
+	if msg != nil {
+		base.Timestamp, base.Label, base.Tail = msg.Timestamp, msg.Label, msg.Tail
+		base.ICAOHex = strings.ToUpper(msg.AircraftICAO())
+		if msg.Flight != nil {
+			base.Flight = strings.TrimSpace(msg.Flight.Flight)
+		}
+		base.MessageID, base.Direction = int64(msg.ID), msg.LinkDirection
+		base.StationID, base.Feeder, base.Frequency = msg.StationID(), msg.Feeder, msg.Frequency
+		base.Tenant = msg.Tenant
+	}
+	events := make([]Event, len(results))
+	for i, a := range results {
+		e := base
+		e.Type, e.Data = a.Result.Type(), a.Result
+		e.Parser, e.ParserVersion = a.Parser, a.Version
+		if entry, ok := schema.Lookup(e.Type); ok {
+			e.SchemaVersion = entry.Version
+		}
+		if c, ok := Confidence(a.Result); ok {
+			e.Confidence = &c
+		}
+		e.Units = units.Normalise(a.Result)
+		events[i] = e
+	}
+	return events
+}
+
+// Confidence returns how far a result can be trusted, from 0 to 1: the
+// quality score of its message text (see quality.Annotate), multiplied by the
+// result's own score when it rates its parse (registry.Scored). It reports
+// false for a result with neither, such as a link-layer result.
+func Confidence(r registry.Result) (float64, bool) {
+	c, ok := 1.0, false
+	if a, annotated := r.(*quality.Annotated); annotated {
+		c, ok = a.Quality.Score, true
+		r = a.Unwrap()
+	}
+	if s, scored := r.(registry.Scored); scored {
+		c, ok = c*s.Confidence(), true
+	}
+	return math.Round(c*1000) / 1000, ok
+}
+
+// EnrichmentEvent returns the event for a flight enrichment update. Only the
+// fields set in the update are included.
+func EnrichmentEvent(u storage.FlightEnrichmentUpdate) Event {
+	return Event{
+		Kind:      KindEnrichment,
+		Type:      "flight_enrichment",
+		Timestamp: u.FlightDate.Format("2006-01-02"),
+		ICAOHex:   u.ICAOHex,
+		Flight:    u.Callsign,
+		Tenant:    u.Tenant,
+		Data:      u,
+	}
+}
+
+// EmergencyEvent returns the event for an emergency, typed by its kind.
+func EmergencyEvent(em storage.EmergencyEvent) Event {
+	return Event{
+		Kind:      KindEmergency,
+		Type:      em.Kind,
+		Timestamp: em.Timestamp.UTC().Format(time.RFC3339),
+		Label:     em.Label,
+		ICAOHex:   em.ICAOHex,
+		Tail:      em.Registration,
+		Flight:    em.Flight,
+		Data:      em,
+	}
+}
+
+// RouteChangeEvent returns the event for a change to a flight's plan.
+func RouteChangeEvent(c storage.RouteChange) Event {
+	return Event{
+		Kind:      KindRoute,
+		Type:      "route_changed",
+		Timestamp: c.Timestamp.UTC().Format(time.RFC3339),
+		ICAOHex:   c.ICAOHex,
+		Tail:      c.Registration,
+		Flight:    c.Flight,
+		MessageID: c.MessageID,
+		Data:      c,
+	}
+}
+
+// Serialisation formats.
+const (
+	FormatEvent = "event" // The whole Event as JSON.
+	FormatData  = "data"  // Only Event.Data as JSON.
+)
+
+// Encode serialises an event in the given format.
+func Encode(e Event, format string) ([]byte, error) {
+	switch format {
+	case "", FormatEvent:
+		return json.Marshal(e)
+	case FormatData:
+		return json.Marshal(e.Data)
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// Topic expands a topic template for an event. The placeholders {kind},
+// {type}, {label}, {icao}, {tail} and {flight} are replaced with the event's
+// values, made safe for use in MQTT topics, Kafka topic names and NATS
+// subjects by replacing
+// anything other than letters, digits, '-' and '_' with '_'. An empty value
+// becomes "unknown".
+func Topic(template string, e Event) string {
+	r := strings.NewReplacer(
+		"{kind}", topicPart(e.Kind),
+		"{type}", topicPart(e.Type),
+		"{label}", topicPart(e.Label),
+		"{icao}", topicPart(e.ICAOHex),
+		"{tail}", topicPart(e.Tail),
+		"{flight}", topicPart(e.Flight),
+	)
+	return r.Replace(template)
+}
+
+func topicPart(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// Multi publishes every event to each of its sinks.
+type Multi []Sink
+
+// Publish publishes to every sink, returning the errors joined.
+func (m Multi) Publish(ctx context.Context, e Event) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishNow publishes to every sink with PublishNow, returning the errors
+// joined.
+func (m Multi) PublishNow(ctx context.Context, e Event) error {
+	var errs []error
+	for _, s := range m {
+		if err := PublishNow(ctx, s, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink, returning the errors joined.
+func (m Multi) Close() error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
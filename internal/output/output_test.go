@@ -0,0 +1,253 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+type testResult struct {
+	Latitude float64 `json:"latitude"`
+}
+
+func (r *testResult) Type() string     { return "h1_position" }
+func (r *testResult) MessageID() int64 { return 7 }
+
+type recordingSink struct {
+	events []Event
+	err    error
+	closed bool
+}
+
+func (s *recordingSink) Publish(_ context.Context, e Event) error {
+	s.events = append(s.events, e)
+	return s.err
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return s.err
+}
+
+// flushingSink buffers events until flushed.
+type flushingSink struct {
+	recordingSink
+	flushed int
+}
+
+func (s *flushingSink) Flush(context.Context) error {
+	s.flushed = len(s.events)
+	return nil
+}
+
+// prioritySink records the events published with PublishNow separately.
+type prioritySink struct {
+	recordingSink
+	now []Event
+}
+
+func (s *prioritySink) PublishNow(_ context.Context, e Event) error {
+	s.now = append(s.now, e)
+	return nil
+}
+
+func TestResultEvents(t *testing.T) {
+	msg := &acars.Message{
+		Timestamp:     "2026-01-24T10:00:00Z",
+		Label:         "H1",
+		Tail:          "VH-OQA",
+		LinkDirection: "downlink",
+		FromHex:       "7c6db8",
+		Flight:        &acars.Flight{Flight: " QF1 "},
+		ID:            42,
+		Feeder:        "site-1",
+		Tenant:        "partner-a",
+	}
+	decodedAt := time.Date(2026, 1, 24, 10, 0, 1, 0, time.UTC)
+	events := ResultEvents(msg, []registry.Attributed{
+		{Result: &testResult{Latitude: -33.9}, Parser: "h1-pos", Version: 2},
+		{Result: &testResult{}},
+	}, decodedAt)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	e := events[0]
+	if e.Kind != KindResult || e.Type != "h1_position" || e.ICAOHex != "7C6DB8" || e.Flight != "QF1" || e.Label != "H1" {
+		t.Errorf("event = %+v", e)
+	}
+	if e.MessageID != 42 || e.Direction != "downlink" || e.Feeder != "site-1" || e.Tenant != "partner-a" || e.Parser != "h1-pos" ||
+		e.ParserVersion != 2 || e.SchemaVersion < 1 || e.DecodedAt != "2026-01-24T10:00:01Z" || e.Confidence != nil {
+		t.Errorf("envelope = %+v", e)
+	}
+	if events[1].Parser != "" || events[1].ParserVersion != 0 {
+		t.Errorf("unattributed envelope = %+v", events[1])
+	}
+
+	b, err := Encode(e, FormatData)
+	if err != nil || string(b) != `{"latitude":-33.9}` {
+		t.Errorf("data payload = %s, %v", b, err)
+	}
+	b, _ = Encode(e, FormatEvent)
+	if !strings.Contains(string(b), `"kind":"result"`) || !strings.Contains(string(b), `"data":{"latitude":-33.9}`) {
+		t.Errorf("event payload = %s", b)
+	}
+	if _, err := Encode(e, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+// scoredResult rates its own parse.
+type scoredResult struct{ testResult }
+
+func (r *scoredResult) Confidence() float64 { return 0.5 }
+
+func TestConfidence(t *testing.T) {
+	if _, ok := Confidence(&testResult{}); ok {
+		t.Error("unannotated, unscored result has a confidence")
+	}
+	if c, ok := Confidence(&scoredResult{}); !ok || c != 0.5 {
+		t.Errorf("scored = %v, %v", c, ok)
+	}
+	annotated := quality.Annotate([]registry.Result{&testResult{}, &scoredResult{}}, quality.Report{Score: 0.8})
+	if c, ok := Confidence(annotated[0]); !ok || c != 0.8 {
+		t.Errorf("annotated = %v, %v", c, ok)
+	}
+	if c, ok := Confidence(annotated[1]); !ok || c != 0.4 {
+		t.Errorf("annotated and scored = %v, %v", c, ok)
+	}
+}
+
+func TestEnrichmentEvent(t *testing.T) {
+	origin := "YSSY"
+	e := EnrichmentEvent(storage.FlightEnrichmentUpdate{
+		ICAOHex:    "7C6DB8",
+		Callsign:   "QFA1",
+		FlightDate: time.Date(2026, 1, 24, 0, 0, 0, 0, time.UTC),
+		Origin:     &origin,
+	})
+	b, _ := Encode(e, FormatData)
+	if got := string(b); got != `{"icao_hex":"7C6DB8","callsign":"QFA1","flight_date":"2026-01-24T00:00:00Z","origin":"YSSY"}` {
+		t.Errorf("payload = %s", got)
+	}
+	if Topic(DefaultMQTTTopic, e) != "acars/enrichment/unknown/7C6DB8" {
+		t.Errorf("topic = %s", Topic(DefaultMQTTTopic, e))
+	}
+}
+
+func TestRouteChangeEvent(t *testing.T) {
+	e := RouteChangeEvent(storage.RouteChange{
+		FlightKey:      "VHOQA/QFA1",
+		Timestamp:      time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC),
+		ICAOHex:        "7C6DB8",
+		Registration:   "VH-OQA",
+		Flight:         "QFA1",
+		AddedWaypoints: []string{"LIZZI"},
+		Fields:         []storage.PlanFieldChange{{Field: "arrival", Old: "ARBEY4", New: "LIZZI8"}},
+		MessageID:      42,
+	})
+	if e.Kind != KindRoute || e.Type != "route_changed" || e.Timestamp != "2026-01-24T10:00:00Z" || e.Tail != "VH-OQA" || e.MessageID != 42 {
+		t.Errorf("event = %+v", e)
+	}
+	b, _ := Encode(e, FormatData)
+	want := `{"flight_key":"VHOQA/QFA1","timestamp":"2026-01-24T10:00:00Z","icao_hex":"7C6DB8","registration":"VH-OQA","flight":"QFA1",` +
+		`"added_waypoints":["LIZZI"],"fields":[{"field":"arrival","old":"ARBEY4","new":"LIZZI8"}],"message_id":42}`
+	if got := string(b); got != want {
+		t.Errorf("payload = %s", got)
+	}
+}
+
+func TestTopic(t *testing.T) {
+	e := Event{Kind: KindResult, Type: "h1_position", Label: "_d", ICAOHex: "7C6DB8", Tail: "VH-OQA", Flight: "QF1/24"}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{DefaultMQTTTopic, "acars/result/_d/7C6DB8"},
+		{DefaultKafkaTopic, "acars.result"},
+		{"acars/{tail}/{flight}/{type}", "acars/VH-OQA/QF1_24/h1_position"},
+		{"acars/+/{label}#", "acars/+/_d#"}, // Only placeholder values are sanitised.
+	}
+	for _, tt := range tests {
+		if got := Topic(tt.template, e); got != tt.want {
+			t.Errorf("Topic(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestMulti(t *testing.T) {
+	a := &recordingSink{}
+	b := &recordingSink{err: errors.New("broker down")}
+	m := Multi{a, b}
+
+	err := m.Publish(context.Background(), Event{Type: "x"})
+	if err == nil || !strings.Contains(err.Error(), "broker down") {
+		t.Errorf("Publish error = %v", err)
+	}
+	if len(a.events) != 1 || len(b.events) != 1 {
+		t.Error("event not published to every sink")
+	}
+	if err := m.Close(); err == nil || !a.closed || !b.closed {
+		t.Errorf("Close = %v, closed %v %v", err, a.closed, b.closed)
+	}
+}
+
+func TestConfigOpen(t *testing.T) {
+	var c Config
+	if s, err := c.Open(); s != nil || err != nil || c.Enabled() {
+		t.Errorf("empty config = %v, %v", s, err)
+	}
+	c = Config{KafkaBrokers: "localhost:9092", Format: "xml"}
+	if _, err := c.Open(); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	c = Config{MQTTBroker: "tcp://localhost:1883", MQTTQoS: 3}
+	if _, err := c.Open(); err == nil {
+		t.Error("expected error for QoS 3")
+	}
+	c = Config{KafkaBrokers: " localhost:9092, ,localhost:9093", KafkaTopic: DefaultKafkaTopic}
+	s, err := c.Open()
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if k, ok := s.(*KafkaSink); !ok || len(k.cfg.Brokers) != 2 {
+		t.Errorf("sink = %#v", s)
+	}
+	s.Close()
+}
+
+func TestPublishNow(t *testing.T) {
+	lat := -38.5
+	em := storage.EmergencyEvent{
+		Kind:         "mayday",
+		Timestamp:    time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC),
+		ICAOHex:      "7C6DB8",
+		Registration: "VH-OQA",
+		Flight:       "QFA1",
+		Latitude:     &lat,
+	}
+	e := EmergencyEvent(em)
+	if e.Kind != KindEmergency || e.Type != "mayday" || e.Timestamp != "2026-01-24T10:00:00Z" || e.Tail != "VH-OQA" {
+		t.Errorf("event = %+v", e)
+	}
+
+	plain, flushing, priority := &recordingSink{}, &flushingSink{}, &prioritySink{}
+	if err := PublishNow(context.Background(), Multi{plain, flushing, priority}, e); err != nil {
+		t.Fatalf("PublishNow() error = %v", err)
+	}
+	if len(plain.events) != 1 {
+		t.Errorf("plain sink got %d events, want 1", len(plain.events))
+	}
+	if flushing.flushed != 1 {
+		t.Errorf("flushing sink flushed %d events, want 1", flushing.flushed)
+	}
+	if len(priority.now) != 1 || len(priority.events) != 0 {
+		t.Errorf("priority sink got %d now and %d batched, want 1 and 0", len(priority.now), len(priority.events))
+	}
+}
@@ -0,0 +1,48 @@
+package adsc
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/arinc622"
+)
+
+// fuzzSeeds are real ADS-C reports with valid CRCs.
+var fuzzSeeds = []string{
+	"/XYTGL7X.ADS.F-GXLI0725BFC82D8D46BC46CC1D0D25B0182C2CC745807725965029EF880A40B791",
+	"/QUKAXBA.ADS.G-ZBKO072495A7EE7786F6A4D21F7A5D",
+	"/XYTGL7X.ADS.F-GXLO0725A2E02967884D24581D0D25665826E6484D0110254F0025F2884D00815F",
+}
+
+// FuzzDecodePayload checks that tag decoding never panics or reads past the
+// end of a payload. Run it with
+//
+//	go test ./internal/parsers/adsc -run '^$' -fuzz FuzzDecodePayload
+func FuzzDecodePayload(f *testing.F) {
+	for _, text := range fuzzSeeds {
+		res, err := arinc622.Parse(text)
+		if err != nil {
+			f.Fatalf("seed %q: %v", text, err)
+		}
+		f.Add(res.Payload)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decodePayloadData(&Result{}, data)
+	})
+}
+
+// FuzzParse runs whole messages through the parser.
+func FuzzParse(f *testing.F) {
+	for _, text := range fuzzSeeds {
+		f.Add(text)
+	}
+
+	p := &Parser{}
+	f.Fuzz(func(t *testing.T, text string) {
+		if !p.QuickCheck(text) {
+			return
+		}
+		_ = p.Parse(&acars.Message{ID: 1, Label: "B6", Text: text})
+	})
+}
@@ -3,13 +3,12 @@
 package adsc
 
 import (
-	"encoding/hex"
 	"fmt"
 	"math"
 	"strings"
 
 	"acars_parser/internal/acars"
-	"acars_parser/internal/crc"
+	"acars_parser/internal/arinc622"
 	"acars_parser/internal/patterns"
 	"acars_parser/internal/registry"
 )
@@ -93,6 +92,8 @@ func (p *Parser) Name() string     { return "adsc" }
 func (p *Parser) Labels() []string { return []string{"B6"} }
 func (p *Parser) Priority() int    { return 10 }
 
+func (p *Parser) Anchors() []string { return []string{".ADS."} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	return strings.Contains(text, ".ADS.")
 }
@@ -102,63 +103,26 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 		return nil
 	}
 
-	text := strings.TrimSpace(msg.Text)
-
 	result := &Result{
 		MsgID:     int64(msg.ID),
 		Timestamp: msg.Timestamp,
 	}
 
-	// Find .ADS. marker.
-	adsIdx := strings.Index(text, ".ADS.")
-	if adsIdx < 0 {
-		return nil
+	// Parse the ARINC 622 envelope (validates CRC, extracts payload).
+	arincResult, err := arinc622.Parse(msg.Text)
+	if err != nil || arincResult.IMI != arinc622.IMIADS || len(arincResult.Payload) < 1 {
+		return nil // Not ADS-C, or CRC mismatch - reject message.
 	}
 
-	// Parse prefix: [link][flight]/[station].
-	prefix := text[:adsIdx]
-	if idx := strings.LastIndex(prefix, "/"); idx >= 0 {
-		result.GroundStation = prefix[idx+1:]
-		prefix = prefix[:idx]
-	}
-	// Extract flight ID (format like L46AKL0628 or J77ABA024R).
-	if m := patterns.ADSCFlightPattern.FindStringSubmatch(prefix); len(m) >= 2 {
+	result.GroundStation = arincResult.GroundStation
+	// Extract flight ID from the downlink preamble (format like L46AKL0628 or J77ABA024R).
+	if m := patterns.ADSCFlightPattern.FindStringSubmatch(arincResult.Preamble); len(m) >= 2 {
 		result.FlightID = m[1]
 	}
+	result.Registration = arincResult.Registration
+	result.RawHex = arincResult.RawHex
 
-	// Extract the raw text prefix for CRC verification.
-	// Format: IMI (3 chars) + separator/registration (7 chars) = 10 chars total.
-	prefixStart := adsIdx + 1 // Skip the dot before "ADS".
-	if len(text) < prefixStart+10 {
-		return nil
-	}
-
-	textPrefix := text[prefixStart : prefixStart+10]
-	hexPayload := text[prefixStart+10:]
-
-	// Validate hex payload.
-	if len(hexPayload) < 4 || len(hexPayload)%2 != 0 {
-		return nil
-	}
-	data, err := hex.DecodeString(hexPayload)
-	if err != nil || len(data) < 3 {
-		return nil
-	}
-
-	// Verify CRC using the raw 10-char text prefix.
-	if !crc.VerifyArincBinaryRaw(textPrefix, data) {
-		return nil // CRC mismatch - reject message.
-	}
-
-	// Extract clean registration from text prefix (chars 4-10, after "ADS.").
-	regPart := textPrefix[3:] // Skip "ADS".
-	regPart = strings.TrimLeft(regPart, ".") // Strip leading dots.
-	result.Registration = regPart
-	result.RawHex = hexPayload
-
-	// Strip CRC from payload before decoding.
-	data = data[:len(data)-2]
-	decodePayloadData(result, data)
+	decodePayloadData(result, arincResult.Payload)
 
 	return result
 }
@@ -224,6 +188,9 @@ func parseTag(result *Result, tag byte, data []byte, isFirst bool) int {
 			return -1
 		}
 		groupCnt := int(data[1])
+		if len(data) < 2+groupCnt*2 {
+			return -1
+		}
 		return 2 + groupCnt*2 // Approximate size.
 
 	// Cancel emergency mode.
@@ -708,82 +675,49 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 		return trace
 	}
 
-	text := strings.TrimSpace(msg.Text)
-
-	// Find .ADS. marker and extract components.
-	adsIdx := strings.Index(text, ".ADS.")
-	if adsIdx < 0 {
-		trace.QuickCheck.Reason = "No .ADS. marker found"
-		return trace
-	}
-
-	// Extract prefix.
-	prefix := text[:adsIdx]
-	groundStation := ""
-	flightID := ""
-	if idx := strings.LastIndex(prefix, "/"); idx >= 0 {
-		groundStation = prefix[idx+1:]
-		prefix = prefix[:idx]
-	}
-	if m := patterns.ADSCFlightPattern.FindStringSubmatch(prefix); len(m) >= 2 {
-		flightID = m[1]
-	}
-
-	trace.Extractors = append(trace.Extractors, registry.Extractor{
-		Name:    "ground_station",
-		Pattern: "prefix before .ADS. after last /",
-		Matched: groundStation != "",
-		Value:   groundStation,
-	})
+	arincResult, err := arinc622.Parse(msg.Text)
+	arincOK := err == nil && arincResult.IMI == arinc622.IMIADS
 
 	trace.Extractors = append(trace.Extractors, registry.Extractor{
-		Name:    "flight_id",
-		Pattern: patterns.ADSCFlightPattern.String(),
-		Matched: flightID != "",
-		Value:   flightID,
+		Name:    "arinc_parse",
+		Pattern: "ARINC 622 envelope parsing with CRC verification",
+		Matched: arincOK,
+		Value: func() string {
+			if err != nil {
+				return "error: " + err.Error()
+			}
+			return "IMI " + arincResult.IMI
+		}(),
 	})
 
-	// Extract text prefix and hex payload.
-	prefixStart := adsIdx + 1
-	if len(text) >= prefixStart+10 {
-		textPrefix := text[prefixStart : prefixStart+10]
-		hexPayload := text[prefixStart+10:]
+	if arincOK {
+		flightID := ""
+		if m := patterns.ADSCFlightPattern.FindStringSubmatch(arincResult.Preamble); len(m) >= 2 {
+			flightID = m[1]
+		}
 
 		trace.Extractors = append(trace.Extractors, registry.Extractor{
-			Name:    "text_prefix",
-			Pattern: "10-char CRC prefix after .ADS.",
-			Matched: true,
-			Value:   textPrefix,
+			Name:    "ground_station",
+			Pattern: "extracted from ARINC envelope",
+			Matched: arincResult.GroundStation != "",
+			Value:   arincResult.GroundStation,
 		})
 
-		// Validate hex.
-		validHex := len(hexPayload) >= 4 && len(hexPayload)%2 == 0
-		if validHex {
-			if _, err := hex.DecodeString(hexPayload); err != nil {
-				validHex = false
-			}
-		}
-
 		trace.Extractors = append(trace.Extractors, registry.Extractor{
-			Name:    "hex_payload",
-			Pattern: "hex-encoded binary data",
-			Matched: validHex,
-			Value:   fmt.Sprintf("%d chars", len(hexPayload)),
+			Name:    "flight_id",
+			Pattern: patterns.ADSCFlightPattern.String(),
+			Matched: flightID != "",
+			Value:   flightID,
 		})
 
-		// Check CRC.
-		if validHex {
-			data, _ := hex.DecodeString(hexPayload)
-			crcValid := len(data) >= 3 && crc.VerifyArincBinaryRaw(textPrefix, data)
-			trace.Extractors = append(trace.Extractors, registry.Extractor{
-				Name:    "crc_valid",
-				Pattern: "ARINC binary CRC verification",
-				Matched: crcValid,
-				Value:   fmt.Sprintf("%v", crcValid),
-			})
-			trace.Matched = crcValid
-		}
+		trace.Extractors = append(trace.Extractors, registry.Extractor{
+			Name:    "payload_size",
+			Pattern: "decoded binary payload",
+			Matched: len(arincResult.Payload) > 0,
+			Value:   fmt.Sprintf("%d bytes", len(arincResult.Payload)),
+		})
 	}
 
+	trace.Matched = arincOK
 	return trace
 }
@@ -0,0 +1,194 @@
+// Package afn parses FANS-1/A ATS Facilities Notification (AFN) messages:
+// the logon an aircraft sends an ATS facility before a CPDLC or ADS-C
+// session, and the facility's acknowledgement.
+package afn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Message types, after the ARINC 622 AFN message names.
+const (
+	TypeContact         = "contact"         // FN_CON: the aircraft logs on to a facility.
+	TypeAcknowledgement = "acknowledgement" // FN_AK: the facility answers a logon.
+)
+
+// Application is a FANS application named in a logon or acknowledgement.
+type Application struct {
+	Name    string `json:"name"`              // "ATC" (CPDLC) or "ADS" (ADS-C).
+	Version string `json:"version,omitempty"` // Application version, e.g. "01".
+	// Reason is the acknowledgement code; 0 accepts the logon.
+	Reason *int `json:"reason,omitempty"`
+}
+
+// Result represents a parsed AFN message.
+type Result struct {
+	MsgID         int64         `json:"message_id"`
+	Timestamp     string        `json:"timestamp"`
+	MessageType   string        `json:"message_type"` // "contact" or "acknowledgement".
+	Direction     string        `json:"direction"`    // "downlink" (contact) or "uplink".
+	GroundStation string        `json:"ground_station,omitempty"`
+	FlightNumber  string        `json:"flight_number,omitempty"`
+	Registration  string        `json:"registration,omitempty"`
+	ReportTime    string        `json:"report_time,omitempty"` // HHMMSS from the header.
+	Latitude      float64       `json:"latitude,omitempty"`
+	Longitude     float64       `json:"longitude,omitempty"`
+	Applications  []Application `json:"applications,omitempty"`
+	// Unparsed holds fields with tags the parser does not know.
+	Unparsed []string `json:"unparsed,omitempty"`
+}
+
+func (r *Result) Type() string     { return "afn" }
+func (r *Result) MessageID() int64 { return r.MsgID }
+
+var (
+	// messageRe matches the AFN envelope: an optional downlink preamble, the
+	// facility address, then the fields after "AFN/", each a 3-letter tag
+	// and its values, separated by "/".
+	messageRe = regexp.MustCompile(`^(?:[^/]*)/([A-Z0-9]{7})\.AFN/(FMH.*)$`)
+	// FMH<flight>,<registration>,<address>,<HHMMSS>: the message header.
+	headerRe = regexp.MustCompile(`^FMH([A-Z0-9]{2,8}),\.*([A-Z0-9-]{2,7}),[^,]*,(\d{6})`)
+	// FPO<N/S><DDMMm><E/W><DDDMMm>: the aircraft position, to a tenth of a
+	// minute.
+	positionRe = regexp.MustCompile(`^FPO([NS])(\d{2})(\d{3})([EW])(\d{3})(\d{3})`)
+	// FCO<application>,<version>: an application the aircraft logs on to.
+	contactRe = regexp.MustCompile(`^FCO([A-Z]{3}),(\d{2})`)
+	// FAK<reason>,<application>,<version>: the facility's answer for one
+	// application.
+	ackRe = regexp.MustCompile(`^FAK(\d),([A-Z]{3}),?(\d{2})`)
+)
+
+// Parser parses AFN messages (labels A0 and B0).
+type Parser struct{}
+
+func init() {
+	registry.Register(&Parser{})
+}
+
+func (p *Parser) Name() string     { return "afn" }
+func (p *Parser) Labels() []string { return []string{"A0", "B0"} }
+func (p *Parser) Priority() int    { return 50 }
+
+func (p *Parser) Anchors() []string { return []string{".AFN/FMH"} }
+
+// QuickCheck checks for the AFN header.
+func (p *Parser) QuickCheck(text string) bool {
+	return strings.Contains(text, ".AFN/FMH")
+}
+
+// Parse parses an AFN logon or acknowledgement. The trailing CRC is not
+// checked; fields are matched from their start, so it is ignored.
+func (p *Parser) Parse(msg *acars.Message) registry.Result {
+	m := messageRe.FindStringSubmatch(strings.TrimSpace(msg.Text))
+	if m == nil {
+		return nil
+	}
+	fields := strings.Split(m[2], "/")
+	header := headerRe.FindStringSubmatch(fields[0])
+	if header == nil {
+		return nil
+	}
+
+	result := &Result{
+		MsgID:         int64(msg.ID),
+		Timestamp:     msg.Timestamp,
+		GroundStation: m[1],
+		FlightNumber:  header[1],
+		Registration:  header[2],
+		ReportTime:    header[3],
+	}
+
+	for _, f := range fields[1:] {
+		if pm := positionRe.FindStringSubmatch(f); pm != nil {
+			result.Latitude = coordinate(pm[2], pm[3], pm[1] == "S")
+			result.Longitude = coordinate(pm[5], pm[6], pm[4] == "W")
+		} else if cm := contactRe.FindStringSubmatch(f); cm != nil {
+			result.MessageType = TypeContact
+			result.Applications = append(result.Applications, Application{Name: cm[1], Version: cm[2]})
+		} else if am := ackRe.FindStringSubmatch(f); am != nil {
+			result.MessageType = TypeAcknowledgement
+			reason, _ := strconv.Atoi(am[1])
+			result.Applications = append(result.Applications, Application{Name: am[2], Version: am[3], Reason: &reason})
+		} else if f != "" {
+			result.Unparsed = append(result.Unparsed, f)
+		}
+	}
+
+	switch result.MessageType {
+	case TypeContact:
+		result.Direction = "downlink"
+	case TypeAcknowledgement:
+		result.Direction = "uplink"
+	default:
+		return nil
+	}
+	return result
+}
+
+// coordinate converts whole degrees and minutes in tenths to decimal degrees.
+func coordinate(deg, tenthMin string, negative bool) float64 {
+	d, _ := strconv.Atoi(deg)
+	tm, _ := strconv.Atoi(tenthMin)
+	v := float64(d) + float64(tm)/600
+	if negative {
+		v = -v
+	}
+	return v
+}
+
+// ParseWithTrace implements registry.Traceable for detailed debugging.
+func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
+	trace := &registry.TraceResult{
+		ParserName: p.Name(),
+	}
+
+	quickCheckPassed := p.QuickCheck(msg.Text)
+	trace.QuickCheck = &registry.QuickCheck{
+		Passed: quickCheckPassed,
+	}
+	if !quickCheckPassed {
+		trace.QuickCheck.Reason = "No .AFN/FMH header found"
+		return trace
+	}
+
+	m := messageRe.FindStringSubmatch(strings.TrimSpace(msg.Text))
+	trace.Extractors = append(trace.Extractors, registry.Extractor{
+		Name:    "envelope",
+		Pattern: messageRe.String(),
+		Matched: m != nil,
+	})
+	if m == nil {
+		return trace
+	}
+
+	fields := strings.Split(m[2], "/")
+	header := headerRe.FindStringSubmatch(fields[0])
+	trace.Extractors = append(trace.Extractors, registry.Extractor{
+		Name:    "header",
+		Pattern: headerRe.String(),
+		Matched: header != nil,
+		Value:   fields[0],
+	})
+
+	result, _ := p.Parse(msg).(*Result)
+	trace.Extractors = append(trace.Extractors, registry.Extractor{
+		Name:    "message_type",
+		Pattern: "FCO (contact) or FAK (acknowledgement) fields",
+		Matched: result != nil,
+		Value: func() string {
+			if result == nil {
+				return ""
+			}
+			return fmt.Sprintf("%s, %d applications", result.MessageType, len(result.Applications))
+		}(),
+	})
+
+	trace.Matched = result != nil
+	return trace
+}
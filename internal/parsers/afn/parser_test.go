@@ -0,0 +1,52 @@
+package afn
+
+import (
+	"math"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestParser(t *testing.T) {
+	p := &Parser{}
+
+	contact := p.Parse(&acars.Message{ID: 1, Label: "B0",
+		Text: "/BNECAYA.AFN/FMHQFA41,.VH-OQA,,001530/FPOS33565E151107,0/FCOATC,01/FCOADS,01A9DC"})
+	r, ok := contact.(*Result)
+	if !ok {
+		t.Fatalf("contact: got %T", contact)
+	}
+	if r.MessageType != TypeContact || r.Direction != "downlink" || r.GroundStation != "BNECAYA" ||
+		r.FlightNumber != "QFA41" || r.Registration != "VH-OQA" || r.ReportTime != "001530" {
+		t.Errorf("contact = %+v", r)
+	}
+	if math.Abs(r.Latitude+33.9417) > 0.001 || math.Abs(r.Longitude-151.1783) > 0.001 {
+		t.Errorf("position = %.4f, %.4f", r.Latitude, r.Longitude)
+	}
+	if len(r.Applications) != 2 || r.Applications[0].Name != "ATC" || r.Applications[1].Name != "ADS" || r.Applications[1].Version != "01" {
+		t.Errorf("applications = %+v", r.Applications)
+	}
+
+	ack := p.Parse(&acars.Message{ID: 2, Label: "A0",
+		Text: "/BNECAYA.AFN/FMHQFA41,.VH-OQA,,001532/FAK0,ATC01/FAK1,ADS01"})
+	r, ok = ack.(*Result)
+	if !ok {
+		t.Fatalf("acknowledgement: got %T", ack)
+	}
+	if r.MessageType != TypeAcknowledgement || r.Direction != "uplink" || len(r.Applications) != 2 {
+		t.Fatalf("acknowledgement = %+v", r)
+	}
+	if *r.Applications[0].Reason != 0 || *r.Applications[1].Reason != 1 {
+		t.Errorf("reasons = %d, %d", *r.Applications[0].Reason, *r.Applications[1].Reason)
+	}
+
+	for _, text := range []string{
+		"/BNECAYA.AFN/FMHQFA41,.VH-OQA,,001530",
+		"/BNECAYA.ADS.VH-OQA0725BFC8",
+		"FMHQFA41,.VH-OQA,,001530/FCOATC,01",
+	} {
+		if r := p.Parse(&acars.Message{Text: text}); r != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", text, r)
+		}
+	}
+}
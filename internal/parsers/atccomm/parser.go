@@ -0,0 +1,149 @@
+// Package atccomm parses oceanic voice contact requests and frequency
+// transfers (labels B9 and A0): the crew asking ATC for voice contact, and
+// ATC telling the crew which facility and frequency to contact or monitor.
+package atccomm
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Message types.
+const (
+	TypeVoiceRequest = "voice_request" // Downlink: the crew asks for voice contact.
+	TypeContact      = "contact"       // Uplink: contact a facility on a frequency.
+	TypeMonitor      = "monitor"       // Uplink: monitor a frequency.
+)
+
+// Result represents a parsed voice contact request or frequency transfer.
+type Result struct {
+	MsgID       int64  `json:"message_id"`
+	Timestamp   string `json:"timestamp"`
+	Tail        string `json:"tail,omitempty"`
+	MessageType string `json:"message_type"` // "voice_request", "contact" or "monitor".
+	Direction   string `json:"direction"`    // "downlink" or "uplink".
+	Facility    string `json:"facility,omitempty"`
+	// FrequencyKHz is the requested or assigned frequency; a figure with a
+	// decimal point is read as MHz, a whole number as kHz.
+	FrequencyKHz          int    `json:"frequency_khz,omitempty"`
+	SecondaryFrequencyKHz int    `json:"secondary_frequency_khz,omitempty"`
+	SELCAL                string `json:"selcal,omitempty"` // Four letters, e.g. "ABCD".
+}
+
+func (r *Result) Type() string     { return "atc_comm" }
+func (r *Result) MessageID() int64 { return r.MsgID }
+
+var (
+	// "CONTACT GANDER RADIO ON 8891", "MONITOR SHANWICK 5649 KHZ",
+	// "REQ VOICE CONTACT SHANWICK 8879", "TRANSFER TO NEW YORK RADIO 13306".
+	facilityRe = regexp.MustCompile(`\b(CONTACT|MONITOR|CALL|TRANSFER TO|VOICE(?: CONTACT)?(?: WITH)?)\s+([A-Z][A-Z ]{1,30}?)\s+(?:ON\s+)?(?:FREQ(?:UENCY)?\s+)?(\d{1,3}\.\d{1,3}|\d{3,5})\s*(KHZ|MHZ)?\b`)
+	// "FREQ 13306", "FREQUENCY/134.35 MHZ": a frequency without a facility.
+	frequencyRe = regexp.MustCompile(`\bFREQ(?:UENCY)?\s*[:/]?\s*(\d{1,3}\.\d{1,3}|\d{3,5})\s*(KHZ|MHZ)?\b`)
+	// "SECONDARY 13291", "SEC FREQ 6628".
+	secondaryRe = regexp.MustCompile(`\b(?:SECONDARY|SEC|BACKUP)\s+(?:FREQ(?:UENCY)?\s+)?(\d{1,3}\.\d{1,3}|\d{3,5})\s*(KHZ|MHZ)?\b`)
+	// SELCAL codes are two pairs of the letters A-S without I, N and O:
+	// "SELCAL ABCD", "SELCAL/AB-CD".
+	selcalRe = regexp.MustCompile(`\bSELCAL\s*[:/]?\s*([A-HJ-MP-S]{2})-?([A-HJ-MP-S]{2})\b`)
+)
+
+// notFacility holds words that facilityRe can take for a facility when the
+// message names none, as in "VOICE REQ FREQ 13306".
+var notFacility = map[string]bool{"REQ": true, "REQUEST": true, "ON": true, "FREQ": true, "FREQUENCY": true}
+
+// Parser parses voice contact requests and frequency transfers.
+type Parser struct{}
+
+func init() {
+	registry.Register(&Parser{})
+}
+
+func (p *Parser) Name() string     { return "atccomm" }
+func (p *Parser) Labels() []string { return []string{"B9", "A0"} }
+func (p *Parser) Priority() int    { return 100 }
+
+// QuickCheck looks for the wording of a voice request or transfer. AFN
+// messages, which share label A0, are left to the afn parser.
+func (p *Parser) QuickCheck(text string) bool {
+	if strings.Contains(text, ".AFN/") {
+		return false
+	}
+	upper := strings.ToUpper(text)
+	for _, kw := range []string{"VOICE", "CONTACT", "MONITOR", "CALL", "TRANSFER", "FREQ", "SELCAL"} {
+		if strings.Contains(upper, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse extracts the facility, frequencies and SELCAL code. The direction is
+// taken from the link layer when known, otherwise from the label: B9 is sent
+// by the aircraft and A0 by the ground. Messages with neither a frequency nor
+// a SELCAL code are not returned.
+func (p *Parser) Parse(msg *acars.Message) registry.Result {
+	text := strings.ToUpper(strings.Join(strings.Fields(msg.Text), " "))
+	if text == "" {
+		return nil
+	}
+
+	result := &Result{
+		MsgID:     int64(msg.ID),
+		Timestamp: msg.Timestamp,
+		Tail:      msg.Tail,
+		Direction: msg.LinkDirection,
+	}
+	if result.Direction == "" {
+		result.Direction = "uplink"
+		if msg.Label == "B9" {
+			result.Direction = "downlink"
+		}
+	}
+
+	verb := ""
+	if m := facilityRe.FindStringSubmatch(text); m != nil {
+		verb = m[1]
+		if facility := strings.TrimSpace(m[2]); !notFacility[facility] {
+			result.Facility = facility
+		}
+		result.FrequencyKHz = kilohertz(m[3], m[4])
+	} else if m := frequencyRe.FindStringSubmatch(text); m != nil {
+		result.FrequencyKHz = kilohertz(m[1], m[2])
+	}
+	if m := secondaryRe.FindStringSubmatch(text); m != nil {
+		result.SecondaryFrequencyKHz = kilohertz(m[1], m[2])
+	}
+	if m := selcalRe.FindStringSubmatch(text); m != nil {
+		result.SELCAL = m[1] + m[2]
+	}
+	if result.FrequencyKHz == 0 && result.SELCAL == "" {
+		return nil
+	}
+
+	switch {
+	case result.Direction == "downlink":
+		result.MessageType = TypeVoiceRequest
+	case verb == "MONITOR":
+		result.MessageType = TypeMonitor
+	default:
+		result.MessageType = TypeContact
+	}
+	return result
+}
+
+// kilohertz converts a frequency as written to kHz. Without a unit, a figure
+// with a decimal point is taken as MHz and a whole number as kHz.
+func kilohertz(s, unit string) int {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return 0
+	}
+	if unit == "MHZ" || (unit == "" && strings.Contains(s, ".")) {
+		v *= 1000
+	}
+	return int(math.Round(v))
+}
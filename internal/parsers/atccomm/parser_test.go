@@ -0,0 +1,84 @@
+package atccomm
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestParse(t *testing.T) {
+	p := &Parser{}
+
+	tests := []struct {
+		name      string
+		label     string
+		direction string
+		text      string
+		want      *Result
+	}{
+		{
+			name:  "voice request",
+			label: "B9",
+			text:  "REQ VOICE CONTACT SHANWICK 8879 SELCAL AB-CD",
+			want:  &Result{MessageType: TypeVoiceRequest, Direction: "downlink", Facility: "SHANWICK", FrequencyKHz: 8879, SELCAL: "ABCD"},
+		},
+		{
+			name:  "voice request without facility",
+			label: "B9",
+			text:  "VOICE REQ FREQ 13306\nSELCAL/CDHJ",
+			want:  &Result{MessageType: TypeVoiceRequest, Direction: "downlink", FrequencyKHz: 13306, SELCAL: "CDHJ"},
+		},
+		{
+			name:  "contact with secondary",
+			label: "A0",
+			text:  "CONTACT GANDER RADIO ON 8891 SECONDARY 13291",
+			want:  &Result{MessageType: TypeContact, Direction: "uplink", Facility: "GANDER RADIO", FrequencyKHz: 8891, SecondaryFrequencyKHz: 13291},
+		},
+		{
+			name:  "monitor in MHz",
+			label: "A0",
+			text:  "MONITOR NY CENTER 134.35",
+			want:  &Result{MessageType: TypeMonitor, Direction: "uplink", Facility: "NY CENTER", FrequencyKHz: 134350},
+		},
+		{
+			name:      "transfer, direction from the link layer",
+			label:     "B9",
+			direction: "uplink",
+			text:      "TRANSFER TO NEW YORK RADIO 5.598 MHZ",
+			want:      &Result{MessageType: TypeContact, Direction: "uplink", Facility: "NEW YORK RADIO", FrequencyKHz: 5598},
+		},
+		{
+			name:  "no frequency or SELCAL",
+			label: "B9",
+			text:  "REQ VOICE CONTACT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &acars.Message{ID: 1, Label: tt.label, LinkDirection: tt.direction, Text: tt.text}
+			if !p.QuickCheck(msg.Text) {
+				t.Fatalf("QuickCheck(%q) = false", msg.Text)
+			}
+			got := p.Parse(msg)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Parse() = %+v, want nil", got)
+				}
+				return
+			}
+			r, ok := got.(*Result)
+			if !ok {
+				t.Fatalf("Parse() = %T", got)
+			}
+			tt.want.MsgID = 1
+			if *r != *tt.want {
+				t.Errorf("Parse() = %+v, want %+v", r, tt.want)
+			}
+		})
+	}
+
+	if p.QuickCheck("/BNECAYA.AFN/FMHQFA41,.VH-OQA,,001532/FAK0,ATC01") {
+		t.Error("QuickCheck accepted an AFN acknowledgement")
+	}
+}
@@ -8,27 +8,32 @@ import (
 	"strings"
 
 	"acars_parser/internal/acars"
+	"acars_parser/internal/patterns"
 	"acars_parser/internal/registry"
 )
 
 // Result represents parsed ATIS data.
 type Result struct {
-	MsgID       int64    `json:"message_id"`
-	Timestamp   string   `json:"timestamp"`
-	RawText     string   `json:"raw_text,omitempty"` // Full raw ATIS text.
-	Airport     string   `json:"airport,omitempty"`
-	ATISLetter  string   `json:"atis_letter,omitempty"`
-	ATISType    string   `json:"atis_type,omitempty"` // ARR, DEP, or empty for combined.
-	ATISTime    string   `json:"atis_time,omitempty"` // Zulu time of ATIS.
-	Runways     []string `json:"runways,omitempty"`
-	Approaches  []string `json:"approaches,omitempty"` // ILS, RNAV, etc.
-	Wind        string   `json:"wind,omitempty"`
-	Visibility  string   `json:"visibility,omitempty"`
-	Clouds      string   `json:"clouds,omitempty"`
-	Temperature string   `json:"temperature,omitempty"`
-	DewPoint    string   `json:"dew_point,omitempty"`
-	QNH         string   `json:"qnh,omitempty"`
-	Remarks     []string `json:"remarks,omitempty"`
+	MsgID            int64    `json:"message_id"`
+	Timestamp        string   `json:"timestamp"`
+	RawText          string   `json:"raw_text,omitempty"` // Full raw ATIS text.
+	Airport          string   `json:"airport,omitempty"`
+	ATISLetter       string   `json:"atis_letter,omitempty"`
+	ATISType         string   `json:"atis_type,omitempty"` // ARR, DEP, or empty for combined.
+	ATISTime         string   `json:"atis_time,omitempty"` // Zulu time of ATIS.
+	Format           string   `json:"format,omitempty"`    // Name of the envelope format that matched.
+	Runways          []string `json:"runways,omitempty"`
+	ArrivalRunways   []string `json:"arrival_runways,omitempty"`   // Runways announced for landing (subset of Runways).
+	DepartureRunways []string `json:"departure_runways,omitempty"` // Runways announced for departure (subset of Runways).
+	Approaches       []string `json:"approaches,omitempty"`        // ILS, RNAV, etc.
+	TransitionLevel  string   `json:"transition_level,omitempty"`  // Flight level, e.g. "70" for FL070.
+	Wind             string   `json:"wind,omitempty"`
+	Visibility       string   `json:"visibility,omitempty"`
+	Clouds           string   `json:"clouds,omitempty"`
+	Temperature      string   `json:"temperature,omitempty"`
+	DewPoint         string   `json:"dew_point,omitempty"`
+	QNH              string   `json:"qnh,omitempty"`
+	Remarks          []string `json:"remarks,omitempty"`
 }
 
 func (r *Result) Type() string     { return "atis" }
@@ -45,10 +50,48 @@ func (p *Parser) Name() string     { return "atis" }
 func (p *Parser) Labels() []string { return []string{"A9"} }
 func (p *Parser) Priority() int    { return 100 }
 
+// atisTypePattern matches the ATIS type qualifier. Combined ATIS bodies are
+// announced as "ARR/DEP", "ARR AND DEP" or "ARR DEP" and normalise to an empty type.
+const atisTypePattern = `(ARR(?:\s*/\s*DEP|\s+AND\s+DEP|\s+DEP)?|DEP)`
+
+// envelopeFormat describes one way an ATIS broadcast announces its airport and letter.
+type envelopeFormat struct {
+	name string
+	re   *regexp.Regexp
+	// Capture group indices. A zero index means the format has no such group.
+	airport, atisType, letter int
+}
+
+// envelopeFormats are tried in order; the first match wins.
+var envelopeFormats = []envelopeFormat{
+	{
+		// SITA TI2 envelope: /ICNDLXA.TI2/RKSI ARR ATIS O
+		name:     "ti2",
+		re:       regexp.MustCompile(`/[A-Z0-9]+\.TI2/([A-Z]{4})\s+(?:` + atisTypePattern + `\s+)?ATIS\s+([A-Z])\b`),
+		airport:  1,
+		atisType: 2,
+		letter:   3,
+	},
+	{
+		// EUROCONTROL-style D-ATIS: ATIS EDDF B, ATIS LFPG ARR INFO K
+		name:     "eurocontrol",
+		re:       regexp.MustCompile(`\bATIS\s+([A-Z]{4})\s+(?:` + atisTypePattern + `\s+)?(?:INFO(?:RMATION)?\s+)?([A-Z])\b`),
+		airport:  1,
+		atisType: 2,
+		letter:   3,
+	},
+	{
+		// Airport-first header without the TI2 envelope: EGLL ARR ATIS Q, LSZH ATIS INFO D
+		name:     "airport_first",
+		re:       regexp.MustCompile(`\b([A-Z]{4})\s+(?:` + atisTypePattern + `\s+)?ATIS\s+(?:INFO(?:RMATION)?\s+)?([A-Z])\b`),
+		airport:  1,
+		atisType: 2,
+		letter:   3,
+	},
+}
+
 // Patterns for ATIS parsing.
 var (
-	// Envelope: /ICNDLXA.TI2/RKSI ARR ATIS O
-	envelopeRe = regexp.MustCompile(`/([A-Z0-9]+)\.TI2/([A-Z]{4})\s+(ARR|DEP)?\s*ATIS\s+([A-Z])`)
 
 	// Time: 0500Z or 1806Z
 	timeRe = regexp.MustCompile(`\b(\d{4})Z\b`)
@@ -71,18 +114,36 @@ var (
 	// Clouds: CLD BKN 3500FT, CLD FEW 2000FT, CAVOK
 	cloudRe = regexp.MustCompile(`(?:CLD\s+([A-Z]+\s+\d+FT)|CAVOK)`)
 
-	// Runway: RWY 15L, RWY 34, RWY 07C
-	runwayRe = regexp.MustCompile(`RWY\s+(\d{1,2}[LCR]?)`)
+	// Runway: RWY 15L, RWY 34, RWY 07C, RUNWAY IN USE 25L AND 25R, RWYS 16L/16R
+	runwayRe = regexp.MustCompile(`\b(?:RWYS?|RUNWAYS?)(?:\s+IN\s+USE)?[\s:]+` + runwayListPattern)
+
+	// Runways announced for landing: LDG RWY 25L, LANDING RUNWAY 07R, ARR RWYS 27L AND 27R
+	arrivalRunwayRe = regexp.MustCompile(`\b(?:LDG|LANDING|ARR|ARRIVALS?)\s+(?:RWYS?|RUNWAYS?)(?:\s+IN\s+USE)?[\s:]+` + runwayListPattern)
+
+	// Runways announced for departure: DEP RWY 18, TAKE-OFF RUNWAY 25R, TKOF RWY 09
+	departureRunwayRe = regexp.MustCompile(`\b(?:DEP|DEPARTURES?|TKOF|TAKE-?OFF)\s+(?:RWYS?|RUNWAYS?)(?:\s+IN\s+USE)?[\s:]+` + runwayListPattern)
+
+	// Single runway designator within a list captured by the patterns above.
+	runwayDesignatorRe = regexp.MustCompile(`\d{1,2}[LCR]?`)
+
+	// Transition level: TRL 70, TRL FL070, TRANSITION LEVEL 60, TL 80
+	transitionLevelRe = regexp.MustCompile(`\b(?:TRL|TRANS(?:ITION)?\s+LEVEL|TL)\s*(?:FL\s*)?(\d{2,3})\b`)
 
 	// Approach: ILS APCH, ILS Z APCH, RNAV APCH
 	approachRe = regexp.MustCompile(`(ILS(?:\s+[A-Z])?\s+APCH|RNAV\s+APCH|VOR\s+APCH)`)
 )
 
+// runwayListPattern captures one or more runway designators joined by "/", ",", "&" or "AND".
+const runwayListPattern = `(\d{1,2}[LCR]?\b(?:\s*(?:/|,|&|AND)\s*\d{1,2}[LCR]?\b)*)`
+
+func (p *Parser) Anchors() []string { return []string{"ATIS"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	upper := strings.ToUpper(text)
 	return strings.Contains(upper, "ATIS") &&
 		(strings.Contains(upper, ".TI2/") || strings.Contains(upper, "QNH") ||
-			strings.Contains(upper, "WIND"))
+			strings.Contains(upper, "WIND") || strings.Contains(upper, "RWY") ||
+			strings.Contains(upper, "RUNWAY"))
 }
 
 func (p *Parser) Parse(msg *acars.Message) registry.Result {
@@ -99,10 +160,11 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 	text := strings.ToUpper(msg.Text)
 
 	// Extract envelope info.
-	if m := envelopeRe.FindStringSubmatch(text); len(m) >= 5 {
-		result.Airport = m[2]
-		result.ATISType = m[3] // May be empty.
-		result.ATISLetter = m[4]
+	if f, m := matchEnvelope(text); f != nil {
+		result.Format = f.name
+		result.Airport = m[f.airport]
+		result.ATISType = normaliseATISType(m[f.atisType])
+		result.ATISLetter = m[f.letter]
 	}
 
 	// Extract time.
@@ -154,13 +216,13 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 	}
 
 	// Extract runways (may have multiple).
-	runwayMatches := runwayRe.FindAllStringSubmatch(text, -1)
-	seen := make(map[string]bool)
-	for _, m := range runwayMatches {
-		if len(m) > 1 && !seen[m[1]] {
-			result.Runways = append(result.Runways, m[1])
-			seen[m[1]] = true
-		}
+	result.ArrivalRunways = extractRunwayList(arrivalRunwayRe, text)
+	result.DepartureRunways = extractRunwayList(departureRunwayRe, text)
+	result.Runways = extractRunwayList(runwayRe, text)
+
+	// Extract transition level.
+	if m := transitionLevelRe.FindStringSubmatch(text); len(m) > 1 {
+		result.TransitionLevel = strings.TrimLeft(m[1], "0")
 	}
 
 	// Extract approaches.
@@ -184,6 +246,50 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 	return result
 }
 
+// matchEnvelope returns the first envelope format that matches the text along with
+// its submatches. Formats without a fixed envelope must name a plausible ICAO airport
+// so that phrases such as "WITH ATIS B" are not mistaken for a header.
+func matchEnvelope(text string) (*envelopeFormat, []string) {
+	for i := range envelopeFormats {
+		f := &envelopeFormats[i]
+		m := f.re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		if f.name != "ti2" && !patterns.IsValidICAO(m[f.airport]) {
+			continue
+		}
+		return f, m
+	}
+	return nil, nil
+}
+
+// normaliseATISType maps the captured ATIS type qualifier to ARR, DEP or empty for combined.
+func normaliseATISType(s string) string {
+	switch {
+	case s == "ARR" || s == "DEP":
+		return s
+	default:
+		// Combined ARR/DEP broadcasts are stored without a type.
+		return ""
+	}
+}
+
+// extractRunwayList returns the unique runway designators captured by re, in order of appearance.
+func extractRunwayList(re *regexp.Regexp, text string) []string {
+	var runways []string
+	seen := make(map[string]bool)
+	for _, m := range re.FindAllStringSubmatch(text, -1) {
+		for _, rwy := range runwayDesignatorRe.FindAllString(m[1], -1) {
+			if !seen[rwy] {
+				runways = append(runways, rwy)
+				seen[rwy] = true
+			}
+		}
+	}
+	return runways
+}
+
 // extractRemarks finds noteworthy items in the ATIS.
 func extractRemarks(text string) []string {
 	var remarks []string
@@ -225,18 +331,37 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 	}
 
 	if !quickCheckPassed {
-		trace.QuickCheck.Reason = "No ATIS keywords found (ATIS, .TI2/, QNH, WIND)"
+		trace.QuickCheck.Reason = "No ATIS keywords found (ATIS, .TI2/, QNH, WIND, RWY, RUNWAY)"
 		return trace
 	}
 
 	text := strings.ToUpper(msg.Text)
 
+	// Record each envelope format attempt.
+	matchedFormat, _ := matchEnvelope(text)
+	for i := range envelopeFormats {
+		f := &envelopeFormats[i]
+		ft := registry.FormatTrace{
+			Name:    f.name,
+			Pattern: f.re.String(),
+		}
+		if f == matchedFormat {
+			m := f.re.FindStringSubmatch(text)
+			ft.Matched = true
+			ft.Captures = map[string]string{
+				"airport":   m[f.airport],
+				"atis_type": m[f.atisType],
+				"letter":    m[f.letter],
+			}
+		}
+		trace.Formats = append(trace.Formats, ft)
+	}
+
 	// Define all the patterns used for extraction.
 	extractors := []struct {
 		name    string
 		pattern *regexp.Regexp
 	}{
-		{"envelope", envelopeRe},
 		{"time", timeRe},
 		{"wind", windRe},
 		{"qnh", qnhRe},
@@ -245,6 +370,9 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 		{"visibility", visRe},
 		{"clouds", cloudRe},
 		{"runway", runwayRe},
+		{"arrival_runway", arrivalRunwayRe},
+		{"departure_runway", departureRunwayRe},
+		{"transition_level", transitionLevelRe},
 		{"approach", approachRe},
 	}
 
@@ -264,9 +392,8 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 		})
 	}
 
-	// ATIS parser doesn't use format patterns, so Formats stays empty.
 	// Determine if we matched overall (same logic as Parse).
-	if m := envelopeRe.FindStringSubmatch(text); len(m) >= 5 {
+	if matchedFormat != nil {
 		trace.Matched = true
 	} else if m := qnhRe.FindStringSubmatch(text); len(m) > 1 {
 		trace.Matched = true
@@ -275,4 +402,4 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 	}
 
 	return trace
-}
\ No newline at end of file
+}
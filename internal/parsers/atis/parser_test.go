@@ -93,3 +93,200 @@ func TestATISParser(t *testing.T) {
 		})
 	}
 }
+
+// TestATISEnvelopeFormats checks each envelope format against representative messages.
+func TestATISEnvelopeFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantFormat  string
+		wantAirport string
+		wantLetter  string
+		wantType    string
+	}{
+		{
+			name: "TI2 combined arrival and departure",
+			text: `/BKKDLXA.TI2/VTBS ARR/DEP ATIS K
+0930Z
+RWY 19L AND 19R IN USE
+WIND 200/08KT QNH 1008`,
+			wantFormat:  "ti2",
+			wantAirport: "VTBS",
+			wantLetter:  "K",
+			wantType:    "",
+		},
+		{
+			name: "TI2 departure",
+			text: `/SINDLXA.TI2/WSSS DEP ATIS C
+0200Z DEP RWY 02C WIND 340/05KT QNH 1010`,
+			wantFormat:  "ti2",
+			wantAirport: "WSSS",
+			wantLetter:  "C",
+			wantType:    "DEP",
+		},
+		{
+			name: "EUROCONTROL style",
+			text: `ATIS EDDF B
+0920Z
+EXPECT ILS APPROACH
+RUNWAY IN USE 25L AND 25R
+DEP RWY 18
+TRL 70
+WIND 240/08KT
+CAVOK
+QNH 1018`,
+			wantFormat:  "eurocontrol",
+			wantAirport: "EDDF",
+			wantLetter:  "B",
+			wantType:    "",
+		},
+		{
+			name: "EUROCONTROL style with type and INFO",
+			text: `ATIS LFPG ARR INFO K
+1030Z LDG RWY 26L 27R
+TRANSITION LEVEL 70
+WIND 250/12KT QNH 1012`,
+			wantFormat:  "eurocontrol",
+			wantAirport: "LFPG",
+			wantLetter:  "K",
+			wantType:    "ARR",
+		},
+		{
+			name: "Airport first",
+			text: `EGLL ARR ATIS Q
+0950Z
+ARR RWY 27L
+TL FL070
+WIND 230/14KT QNH 1009`,
+			wantFormat:  "airport_first",
+			wantAirport: "EGLL",
+			wantLetter:  "Q",
+			wantType:    "ARR",
+		},
+		{
+			name: "Airport first combined with AND",
+			text: `LSZH ARR AND DEP ATIS INFO D
+1120Z
+LDG RWY 14 DEP RWY 16 AND 28
+TRL 60
+WIND 300/06KT QNH 1021`,
+			wantFormat:  "airport_first",
+			wantAirport: "LSZH",
+			wantLetter:  "D",
+			wantType:    "",
+		},
+	}
+
+	p := &Parser{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !p.QuickCheck(tt.text) {
+				t.Fatalf("QuickCheck failed")
+			}
+
+			result := p.Parse(&acars.Message{Label: "A9", Text: tt.text})
+			if result == nil {
+				t.Fatalf("Parse returned nil")
+			}
+			r := result.(*Result)
+
+			if r.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", r.Format, tt.wantFormat)
+			}
+			if r.Airport != tt.wantAirport {
+				t.Errorf("Airport = %q, want %q", r.Airport, tt.wantAirport)
+			}
+			if r.ATISLetter != tt.wantLetter {
+				t.Errorf("ATISLetter = %q, want %q", r.ATISLetter, tt.wantLetter)
+			}
+			if r.ATISType != tt.wantType {
+				t.Errorf("ATISType = %q, want %q", r.ATISType, tt.wantType)
+			}
+		})
+	}
+}
+
+// TestATISRunwaysAndTransitionLevel checks runway-in-use phrasing and transition level extraction.
+func TestATISRunwaysAndTransitionLevel(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantRunways   []string
+		wantArrival   []string
+		wantDeparture []string
+		wantTRL       string
+	}{
+		{
+			name:        "Runway in use list",
+			text:        "ATIS EDDF B RUNWAY IN USE 25L AND 25R TRL 70 QNH 1018",
+			wantRunways: []string{"25L", "25R"},
+			wantTRL:     "70",
+		},
+		{
+			name:          "Separate landing and departure runways",
+			text:          "ATIS EDDM K LDG RWY 26R DEP RWY 26L TRANSITION LEVEL 60 QNH 1020",
+			wantRunways:   []string{"26R", "26L"},
+			wantArrival:   []string{"26R"},
+			wantDeparture: []string{"26L"},
+			wantTRL:       "60",
+		},
+		{
+			name:          "Slash separated runways with flight level prefix",
+			text:          "EHAM ATIS INFO F LANDING RUNWAYS 18R/06 TAKE-OFF RUNWAY 24 TRL FL055 QNH 1003",
+			wantRunways:   []string{"18R", "06", "24"},
+			wantArrival:   []string{"18R", "06"},
+			wantDeparture: []string{"24"},
+			wantTRL:       "55",
+		},
+	}
+
+	p := &Parser{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.Parse(&acars.Message{Label: "A9", Text: tt.text})
+			if result == nil {
+				t.Fatalf("Parse returned nil")
+			}
+			r := result.(*Result)
+
+			if !equalStrings(r.Runways, tt.wantRunways) {
+				t.Errorf("Runways = %v, want %v", r.Runways, tt.wantRunways)
+			}
+			if !equalStrings(r.ArrivalRunways, tt.wantArrival) {
+				t.Errorf("ArrivalRunways = %v, want %v", r.ArrivalRunways, tt.wantArrival)
+			}
+			if !equalStrings(r.DepartureRunways, tt.wantDeparture) {
+				t.Errorf("DepartureRunways = %v, want %v", r.DepartureRunways, tt.wantDeparture)
+			}
+			if r.TransitionLevel != tt.wantTRL {
+				t.Errorf("TransitionLevel = %q, want %q", r.TransitionLevel, tt.wantTRL)
+			}
+		})
+	}
+}
+
+// TestATISRejectsNonAirportHeader ensures free text mentioning ATIS is not taken as a header.
+func TestATISRejectsNonAirportHeader(t *testing.T) {
+	p := &Parser{}
+	result := p.Parse(&acars.Message{Label: "A9", Text: "COPY ATIS B WIND 270/10KT"})
+	if result == nil {
+		return
+	}
+	if r := result.(*Result); r.Airport != "" {
+		t.Errorf("Airport = %q, want empty", r.Airport)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
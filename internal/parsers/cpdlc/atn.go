@@ -0,0 +1,688 @@
+package cpdlc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Standard identifies the CPDLC application a message belongs to.
+type Standard string
+
+// CPDLC standards. FANS-1/A messages travel over ACARS in an ARINC 622
+// envelope whose IMI marks them (AT1); ATN B1 messages travel over VDL2 on
+// the ATN, with no ACARS envelope, so the medium selects the decoder.
+const (
+	StandardFANS  Standard = "fans_1a"
+	StandardATNB1 Standard = "atn_b1"
+)
+
+// ErrUnsupportedElement is returned for an ATN B1 element whose data the
+// decoder cannot read. PER data carries no lengths, so decoding stops there.
+var ErrUnsupportedElement = errors.New("unsupported element")
+
+// Sizes of the root alternatives of the ATN B1 element ID CHOICEs (uM0-uM236
+// and dM0-dM113). Later elements are extensions, carried as open types.
+const (
+	atnUplinkRoot   = 237
+	atnDownlinkRoot = 114
+)
+
+// ATNDecoder decodes ATN B1 CPDLC messages (ICAO Doc 9705 ATCUplinkMessage
+// and ATCDownlinkMessage, profiled by EUROCONTROL PM-CPDLC for LINK 2000+),
+// which use unaligned PER like FANS-1/A but a different ASN.1 module. Element
+// data is decoded for the LINK 2000+ message set; other elements with data
+// stop decoding with ErrUnsupportedElement.
+type ATNDecoder struct {
+	br        *BitReader
+	direction MessageDirection
+}
+
+// NewATNDecoder creates a new ATN B1 CPDLC decoder.
+func NewATNDecoder(data []byte, direction MessageDirection) *ATNDecoder {
+	return &ATNDecoder{
+		br:        NewBitReader(data),
+		direction: direction,
+	}
+}
+
+// DecodeMessage decodes a CPDLC message of either standard.
+func DecodeMessage(data []byte, standard Standard, direction MessageDirection) (*Message, error) {
+	switch standard {
+	case StandardFANS:
+		return NewDecoder(data, direction).Decode()
+	case StandardATNB1:
+		return NewATNDecoder(data, direction).Decode()
+	default:
+		return nil, fmt.Errorf("unknown CPDLC standard %q", standard)
+	}
+}
+
+// Decode decodes the message. When an element is unsupported, the message
+// holds the elements before it and the unsupported element's ID and label,
+// and the error wraps ErrUnsupportedElement.
+func (d *ATNDecoder) Decode() (*Message, error) {
+	msg := &Message{Direction: d.direction}
+
+	// ATCUplinkMessage / ATCDownlinkMessage is a SEQUENCE of header and
+	// messageData, with no optional fields.
+	header, err := d.decodeHeader()
+	if err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	msg.Header = *header
+
+	// messageData is a SEQUENCE of elementIds (SIZE 1..5) and an optional
+	// constrainedData, whose presence bit comes first.
+	hasConstrained, err := d.br.ReadBit()
+	if err != nil {
+		return nil, fmt.Errorf("constrainedData presence: %w", err)
+	}
+	count, err := d.br.ReadConstrainedInt(1, 5)
+	if err != nil {
+		return nil, fmt.Errorf("element count: %w", err)
+	}
+	for i := 0; i < count; i++ {
+		elem, err := d.decodeElement()
+		if elem != nil {
+			msg.Elements = append(msg.Elements, *elem)
+		}
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedElement) {
+				return msg, fmt.Errorf("element %d: %w", i, err)
+			}
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	if hasConstrained {
+		// Route clearance data for uM79/uM80 and friends.
+		return msg, fmt.Errorf("constrainedData: %w", ErrUnsupportedElement)
+	}
+
+	return msg, nil
+}
+
+// decodeHeader decodes an ATCMessageHeader: messageIdNumber, an optional
+// messageRefNumber, dateTime, and logicalAck (DEFAULT required).
+func (d *ATNDecoder) decodeHeader() (*MessageHeader, error) {
+	hasRef, err := d.br.ReadBit()
+	if err != nil {
+		return nil, fmt.Errorf("hasRef: %w", err)
+	}
+	hasLogicalAck, err := d.br.ReadBit()
+	if err != nil {
+		return nil, fmt.Errorf("hasLogicalAck: %w", err)
+	}
+
+	header := &MessageHeader{}
+	if header.MsgID, err = d.br.ReadConstrainedInt(0, 63); err != nil {
+		return nil, fmt.Errorf("msgID: %w", err)
+	}
+	if hasRef {
+		ref, err := d.br.ReadConstrainedInt(0, 63)
+		if err != nil {
+			return nil, fmt.Errorf("msgRef: %w", err)
+		}
+		header.MsgRef = &ref
+	}
+
+	// DateTimeGroup: year (1996-2095), month, day, then hours, minutes and
+	// seconds.
+	year, err := d.br.ReadConstrainedInt(1996, 2095)
+	if err != nil {
+		return nil, fmt.Errorf("year: %w", err)
+	}
+	month, err := d.br.ReadConstrainedInt(1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	day, err := d.br.ReadConstrainedInt(1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("day: %w", err)
+	}
+	header.Date = fmt.Sprintf("%04d-%02d-%02d", year, month, day)
+	if header.Timestamp, err = d.decodeTimestamp(); err != nil {
+		return nil, fmt.Errorf("timestamp: %w", err)
+	}
+
+	header.LogicalAck = "required"
+	if hasLogicalAck {
+		v, err := d.br.ReadConstrainedInt(0, 1)
+		if err != nil {
+			return nil, fmt.Errorf("logicalAck: %w", err)
+		}
+		if v == 1 {
+			header.LogicalAck = "not_required"
+		}
+	}
+	return header, nil
+}
+
+func (d *ATNDecoder) decodeTimestamp() (*Time, error) {
+	t, err := d.decodeTime()
+	if err != nil {
+		return nil, err
+	}
+	if t.Seconds, err = d.br.ReadConstrainedInt(0, 59); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// decodeElement decodes one element ID CHOICE and its data. The CHOICE is
+// extensible: an extension bit comes first, and an extension alternative is
+// an open type whose contents are skipped.
+func (d *ATNDecoder) decodeElement() (*MessageElement, error) {
+	root := atnDownlinkRoot
+	if d.direction == DirectionUplink {
+		root = atnUplinkRoot
+	}
+
+	extended, err := d.br.ReadBit()
+	if err != nil {
+		return nil, fmt.Errorf("extension bit: %w", err)
+	}
+	elem := &MessageElement{}
+	if extended {
+		idx, err := d.br.ReadNormallySmallNonNegative()
+		if err != nil {
+			return nil, fmt.Errorf("extension index: %w", err)
+		}
+		length, err := d.br.ReadLength()
+		if err != nil {
+			return nil, fmt.Errorf("open type length: %w", err)
+		}
+		if _, err := d.br.ReadBytes(length); err != nil {
+			return nil, fmt.Errorf("open type: %w", err)
+		}
+		elem.ID = root + idx
+	} else {
+		if elem.ID, err = d.br.ReadConstrainedInt(0, root-1); err != nil {
+			return nil, fmt.Errorf("element ID: %w", err)
+		}
+	}
+
+	if d.direction == DirectionUplink {
+		elem.Label = GetATNUplinkLabel(elem.ID)
+	} else {
+		elem.Label = GetATNDownlinkLabel(elem.ID)
+	}
+	if !extended {
+		if d.direction == DirectionUplink {
+			elem.Data, err = d.decodeUplinkData(elem.ID)
+		} else {
+			elem.Data, err = d.decodeDownlinkData(elem.ID)
+		}
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedElement) {
+				elem.Text = elem.Label
+				return elem, err
+			}
+			return nil, fmt.Errorf("element data: %w", err)
+		}
+	}
+	elem.Text = formatATNElementText(elem)
+	return elem, nil
+}
+
+// decodeUplinkData decodes the data of a LINK 2000+ uplink element.
+func (d *ATNDecoder) decodeUplinkData(elemID int) (interface{}, error) {
+	switch elemID {
+	case 0, 1, 2, 3, 4, 5, 72, 96, 107, 116, 133, 161, 162, 165, 179, 211, 222, 227, 231, 232:
+		return nil, nil
+	case 19, 20, 23:
+		return d.decodeLevel()
+	case 26, 28:
+		return d.decodeFields("level", "time")
+	case 27, 29:
+		return d.decodeFields("level", "position")
+	case 46, 47, 48, 92:
+		return d.decodeFields("position", "level")
+	case 51, 52, 53:
+		return d.decodeFields("position", "time")
+	case 74:
+		return d.decodePosition()
+	case 94, 215:
+		return d.decodeFields("direction", "degrees")
+	case 117, 120:
+		return d.decodeFields("unit", "frequency")
+	case 123:
+		return d.decodeCode()
+	case 160:
+		return d.decodeFacilityDesignation()
+	case 183, 196, 203, 205:
+		return d.decodeFreeText()
+	case 190:
+		return d.decodeDegrees()
+	default:
+		return nil, fmt.Errorf("%w: uM%d", ErrUnsupportedElement, elemID)
+	}
+}
+
+// decodeDownlinkData decodes the data of a LINK 2000+ downlink element.
+func (d *ATNDecoder) decodeDownlinkData(elemID int) (interface{}, error) {
+	switch elemID {
+	case 0, 1, 2, 3, 4, 5, 63, 65, 66, 99, 100, 107:
+		return nil, nil
+	case 6, 9, 10, 32, 82, 106:
+		return d.decodeLevel()
+	case 81:
+		return d.decodeFields("level", "time")
+	case 22:
+		return d.decodePosition()
+	case 89:
+		return d.decodeFields("unit", "frequency")
+	case 98:
+		return d.decodeFreeText()
+	case 109:
+		return d.decodeTime()
+	default:
+		return nil, fmt.Errorf("%w: dM%d", ErrUnsupportedElement, elemID)
+	}
+}
+
+// decodeFields decodes a SEQUENCE of the named types, keyed as the FANS-1/A
+// decoder keys them (a level is keyed "altitude").
+func (d *ATNDecoder) decodeFields(keys ...string) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		var v interface{}
+		var err error
+		switch key {
+		case "level":
+			key = "altitude"
+			v, err = d.decodeLevel()
+		case "time":
+			v, err = d.decodeTime()
+		case "position":
+			v, err = d.decodePosition()
+		case "direction":
+			// Eleven directions take four bits, so five values are unused.
+			var dir int
+			if dir, err = d.br.ReadConstrainedInt(0, len(offsetDirections)-1); err == nil {
+				if dir < len(offsetDirections) {
+					v = offsetDirections[dir]
+				} else {
+					err = fmt.Errorf("unknown direction %d", dir)
+				}
+			}
+		case "degrees":
+			v, err = d.decodeDegrees()
+		case "unit":
+			v, err = d.decodeUnitName()
+		case "frequency":
+			v, err = d.decodeFrequency()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// Type-specific decoders.
+
+// decodeLevel decodes a Level: a CHOICE of a single level or a block of two.
+// A single level is returned as an *Altitude, a block as a *BlockLevel.
+func (d *ATNDecoder) decodeLevel() (interface{}, error) {
+	block, err := d.br.ReadConstrainedInt(0, 1)
+	if err != nil {
+		return nil, err
+	}
+	lower, err := d.decodeLevelType()
+	if err != nil {
+		return nil, err
+	}
+	if block == 0 {
+		return lower, nil
+	}
+	upper, err := d.decodeLevelType()
+	if err != nil {
+		return nil, err
+	}
+	return &BlockLevel{Lower: lower, Upper: upper}, nil
+}
+
+func (d *ATNDecoder) decodeLevelType() (*Altitude, error) {
+	// LevelType is a CHOICE of 4 alternatives, 2 bits:
+	// 0: levelFeet (-60..7000, x10 ft)
+	// 1: levelMeters (-30..25000, m)
+	// 2: levelFlightLevel (30..700)
+	// 3: levelFlightLevelMetric (100..2500, x10 m)
+	choice, err := d.br.ReadConstrainedInt(0, 3)
+	if err != nil {
+		return nil, err
+	}
+	alt := &Altitude{}
+	var v int
+	switch choice {
+	case 0:
+		v, err = d.br.ReadConstrainedInt(-60, 7000)
+		alt.Type, alt.Value = "feet", v*10
+	case 1:
+		v, err = d.br.ReadConstrainedInt(-30, 25000)
+		alt.Type, alt.Value = "meters", v
+	case 2:
+		v, err = d.br.ReadConstrainedInt(30, 700)
+		alt.Type, alt.Value = "flight_level", v
+	case 3:
+		v, err = d.br.ReadConstrainedInt(100, 2500)
+		alt.Type, alt.Value = "flight_level_metric", v
+	}
+	if err != nil {
+		return nil, err
+	}
+	return alt, nil
+}
+
+// decodeTime decodes a Time of hours and minutes.
+func (d *ATNDecoder) decodeTime() (*Time, error) {
+	hours, err := d.br.ReadConstrainedInt(0, 23)
+	if err != nil {
+		return nil, err
+	}
+	minutes, err := d.br.ReadConstrainedInt(0, 59)
+	if err != nil {
+		return nil, err
+	}
+	return &Time{Hours: hours, Minutes: minutes}, nil
+}
+
+func (d *ATNDecoder) decodePosition() (*Position, error) {
+	// Position is a CHOICE of 5 alternatives, 3 bits: fixName, navaid,
+	// airport, latitudeLongitude and placeBearingDistance.
+	choice, err := d.br.ReadConstrainedInt(0, 4)
+	if err != nil {
+		return nil, err
+	}
+	pos := &Position{}
+	switch choice {
+	case 0:
+		pos.Type = "fix"
+		pos.Name, err = d.decodeSizedString(1, 5)
+	case 1:
+		pos.Type = "navaid"
+		pos.Name, err = d.decodeSizedString(1, 4)
+	case 2:
+		pos.Type = "airport"
+		pos.Name, err = d.decodeIA5String(4)
+	case 3:
+		pos.Type = "latlon"
+		pos.Latitude, pos.Longitude, err = d.decodeLatitudeLongitude()
+	default:
+		return nil, fmt.Errorf("%w: placeBearingDistance position", ErrUnsupportedElement)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
+
+// decodeLatitudeLongitude decodes a LatitudeLongitude, a SEQUENCE of an
+// optional latitude and an optional longitude.
+func (d *ATNDecoder) decodeLatitudeLongitude() (lat, lon *float64, err error) {
+	hasLat, err := d.br.ReadBit()
+	if err != nil {
+		return nil, nil, err
+	}
+	hasLon, err := d.br.ReadBit()
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasLat {
+		v, err := d.decodeAngle(89, 90000)
+		if err != nil {
+			return nil, nil, fmt.Errorf("latitude: %w", err)
+		}
+		lat = &v
+	}
+	if hasLon {
+		v, err := d.decodeAngle(179, 180000)
+		if err != nil {
+			return nil, nil, fmt.Errorf("longitude: %w", err)
+		}
+		lon = &v
+	}
+	return lat, lon, nil
+}
+
+// decodeAngle decodes a Latitude or Longitude: a CHOICE of thousandths of a
+// degree, whole degrees and hundredths of a minute, or degrees, minutes and
+// seconds, followed by the direction (north/east or south/west).
+func (d *ATNDecoder) decodeAngle(maxWholeDeg, maxMilliDeg int) (float64, error) {
+	choice, err := d.br.ReadConstrainedInt(0, 2)
+	if err != nil {
+		return 0, err
+	}
+	var v float64
+	switch choice {
+	case 0:
+		m, err := d.br.ReadConstrainedInt(0, maxMilliDeg)
+		if err != nil {
+			return 0, err
+		}
+		v = float64(m) / 1000
+	case 1:
+		deg, err := d.br.ReadConstrainedInt(0, maxWholeDeg)
+		if err != nil {
+			return 0, err
+		}
+		min, err := d.br.ReadConstrainedInt(0, 5999)
+		if err != nil {
+			return 0, err
+		}
+		v = float64(deg) + float64(min)/6000
+	default:
+		deg, err := d.br.ReadConstrainedInt(0, maxWholeDeg)
+		if err != nil {
+			return 0, err
+		}
+		min, err := d.br.ReadConstrainedInt(0, 59)
+		if err != nil {
+			return 0, err
+		}
+		sec, err := d.br.ReadConstrainedInt(0, 59)
+		if err != nil {
+			return 0, err
+		}
+		v = float64(deg) + float64(min)/60 + float64(sec)/3600
+	}
+	southOrWest, err := d.br.ReadBit()
+	if err != nil {
+		return 0, err
+	}
+	if southOrWest {
+		v = -v
+	}
+	return v, nil
+}
+
+func (d *ATNDecoder) decodeDegrees() (*Degrees, error) {
+	// Degrees is a CHOICE of degreesMagnetic and degreesTrue (1..360).
+	choice, err := d.br.ReadConstrainedInt(0, 1)
+	if err != nil {
+		return nil, err
+	}
+	v, err := d.br.ReadConstrainedInt(1, 360)
+	if err != nil {
+		return nil, err
+	}
+	return &Degrees{Magnetic: choice == 0, Value: v}, nil
+}
+
+// decodeUnitName decodes a UnitName: a facility designation, an optional
+// facility name and the facility function.
+func (d *ATNDecoder) decodeUnitName() (*UnitName, error) {
+	hasName, err := d.br.ReadBit()
+	if err != nil {
+		return nil, err
+	}
+	unit := &UnitName{}
+	if unit.Facility, err = d.decodeFacilityDesignation(); err != nil {
+		return nil, fmt.Errorf("facility: %w", err)
+	}
+	if hasName {
+		if unit.Name, err = d.decodeSizedString(3, 18); err != nil {
+			return nil, fmt.Errorf("facility name: %w", err)
+		}
+	}
+	fn, err := d.br.ReadConstrainedInt(0, len(facilityFunctions)-1)
+	if err != nil {
+		return nil, fmt.Errorf("facility function: %w", err)
+	}
+	unit.Function = facilityFunctions[fn]
+	return unit, nil
+}
+
+func (d *ATNDecoder) decodeFrequency() (*Frequency, error) {
+	// Frequency is a CHOICE of 4 alternatives, 2 bits:
+	// 0: frequencyhf (2850..28000 kHz)
+	// 1: frequencyvhf (23600..27398, x5 kHz)
+	// 2: frequencyuhf (9000..15999, x25 kHz)
+	// 3: frequencysatchannel (NumericString SIZE 12)
+	choice, err := d.br.ReadConstrainedInt(0, 3)
+	if err != nil {
+		return nil, err
+	}
+	freq := &Frequency{}
+	var v int
+	switch choice {
+	case 0:
+		v, err = d.br.ReadConstrainedInt(2850, 28000)
+		freq.Type, freq.Value = "hf", v
+	case 1:
+		v, err = d.br.ReadConstrainedInt(23600, 27398)
+		freq.Type, freq.Value = "vhf", v*5
+	case 2:
+		v, err = d.br.ReadConstrainedInt(9000, 15999)
+		freq.Type, freq.Value = "uhf", v*25
+	case 3:
+		// Twelve 4-bit digits; the channel is not kept.
+		for i := 0; i < 12 && err == nil; i++ {
+			_, err = d.br.ReadBits(4)
+		}
+		freq.Type = "satcom"
+	}
+	if err != nil {
+		return nil, err
+	}
+	return freq, nil
+}
+
+func (d *ATNDecoder) decodeCode() (*BeaconCode, error) {
+	// Code is a SEQUENCE SIZE (4) OF CodeOctalDigit (0..7).
+	var code [4]byte
+	for i := range code {
+		digit, err := d.br.ReadConstrainedInt(0, 7)
+		if err != nil {
+			return nil, err
+		}
+		code[i] = byte('0' + digit)
+	}
+	return &BeaconCode{Code: string(code[:])}, nil
+}
+
+// decodeFacilityDesignation decodes a 4-8 character ICAO facility
+// designation.
+func (d *ATNDecoder) decodeFacilityDesignation() (string, error) {
+	return d.decodeSizedString(4, 8)
+}
+
+func (d *ATNDecoder) decodeFreeText() (*FreeText, error) {
+	// FreeText is an IA5String SIZE (1..256).
+	text, err := d.decodeSizedString(1, maxFreeTextLength)
+	if err != nil {
+		return nil, err
+	}
+	return &FreeText{Text: text}, nil
+}
+
+// decodeSizedString decodes an IA5String whose length is constrained.
+func (d *ATNDecoder) decodeSizedString(minLen, maxLen int) (string, error) {
+	length, err := d.br.ReadConstrainedInt(minLen, maxLen)
+	if err != nil {
+		return "", err
+	}
+	return d.decodeIA5String(length)
+}
+
+func (d *ATNDecoder) decodeIA5String(length int) (string, error) {
+	return (&Decoder{br: d.br}).decodeIA5String(length)
+}
+
+// formatATNElementText substitutes an element's data into its label.
+func formatATNElementText(elem *MessageElement) string {
+	text := elem.Label
+	values := map[string]interface{}{}
+	switch data := elem.Data.(type) {
+	case map[string]interface{}:
+		values = data
+	case *Altitude, *BlockLevel:
+		values["altitude"] = data
+	case *Position:
+		values["position"] = data
+	case *Time:
+		values["time"] = data
+	case *Degrees:
+		values["degrees"] = data
+	case *BeaconCode:
+		text = substituteText(text, "[code]", data.String())
+	case *FreeText:
+		text = substituteText(text, "[freetext]", data.Text)
+	case string:
+		text = substituteText(text, "[facility]", data)
+	}
+
+	for key, placeholder := range map[string]string{
+		"altitude":  "[level]",
+		"position":  "[position]",
+		"time":      "[time]",
+		"degrees":   "[degrees]",
+		"direction": "[direction]",
+		"unit":      "[unitname]",
+		"frequency": "[frequency]",
+	} {
+		if v, ok := values[key].(fmt.Stringer); ok {
+			text = substituteText(text, placeholder, v.String())
+		} else if s, ok := values[key].(string); ok {
+			text = substituteText(text, placeholder, s)
+		}
+	}
+	return text
+}
+
+// ATN B1 facility functions, indexed by their encoding.
+var facilityFunctions = []string{"center", "approach", "tower", "final", "ground control", "clearance delivery", "departure", "control"}
+
+// UnitName is an ATN B1 ATC unit: its ICAO facility designation, optional
+// name and function.
+type UnitName struct {
+	Facility string `json:"facility"`
+	Name     string `json:"name,omitempty"`
+	Function string `json:"function"`
+}
+
+func (u *UnitName) String() string {
+	if u == nil {
+		return ""
+	}
+	parts := []string{u.Facility}
+	if u.Name != "" {
+		parts = append(parts, u.Name)
+	}
+	return strings.Join(append(parts, strings.ToUpper(u.Function)), " ")
+}
+
+// BlockLevel is an ATN B1 block level, between two levels.
+type BlockLevel struct {
+	Lower *Altitude `json:"lower"`
+	Upper *Altitude `json:"upper"`
+}
+
+func (b *BlockLevel) String() string {
+	if b == nil {
+		return ""
+	}
+	return b.Lower.String() + " TO " + b.Upper.String()
+}
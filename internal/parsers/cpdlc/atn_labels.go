@@ -0,0 +1,118 @@
+package cpdlc
+
+// ATN B1 uplink message labels for the LINK 2000+ message set (EUROCONTROL
+// PM-CPDLC, ICAO Doc 9880). Levels replace FANS-1/A altitudes; otherwise the
+// wording follows the FANS-1/A labels.
+var atnUplinkLabels = map[int]string{
+	0:   "UNABLE",
+	1:   "STANDBY",
+	3:   "ROGER",
+	4:   "AFFIRM",
+	5:   "NEGATIVE",
+	19:  "MAINTAIN [level]",
+	20:  "CLIMB TO [level]",
+	23:  "DESCEND TO [level]",
+	26:  "CLIMB TO REACH [level] BY [time]",
+	27:  "CLIMB TO REACH [level] BY [position]",
+	28:  "DESCEND TO REACH [level] BY [time]",
+	29:  "DESCEND TO REACH [level] BY [position]",
+	46:  "CROSS [position] AT [level]",
+	47:  "CROSS [position] AT OR ABOVE [level]",
+	48:  "CROSS [position] AT OR BELOW [level]",
+	51:  "CROSS [position] AT [time]",
+	52:  "CROSS [position] AT OR BEFORE [time]",
+	53:  "CROSS [position] AT OR AFTER [time]",
+	54:  "CROSS [position] BETWEEN [time] AND [time]",
+	55:  "CROSS [position] AT [speed]",
+	56:  "CROSS [position] AT OR LESS THAN [speed]",
+	57:  "CROSS [position] AT OR GREATER THAN [speed]",
+	61:  "CROSS [position] AT AND MAINTAIN [level] AT [speed]",
+	64:  "OFFSET [specifieddistance] [direction] OF ROUTE",
+	72:  "RESUME OWN NAVIGATION",
+	74:  "PROCEED DIRECT TO [position]",
+	79:  "CLEARED TO [position] VIA [routeclearance]",
+	80:  "CLEARED [routeclearance]",
+	82:  "CLEARED TO DEVIATE UP TO [specifieddistance] [direction] OF ROUTE",
+	92:  "HOLD AT [position] AS PUBLISHED MAINTAIN [level]",
+	94:  "TURN [direction] HEADING [degrees]",
+	96:  "CONTINUE PRESENT HEADING",
+	106: "MAINTAIN [speed]",
+	107: "MAINTAIN PRESENT SPEED",
+	108: "MAINTAIN [speed] OR GREATER",
+	109: "MAINTAIN [speed] OR LESS",
+	116: "RESUME NORMAL SPEED",
+	117: "CONTACT [unitname] [frequency]",
+	120: "MONITOR [unitname] [frequency]",
+	123: "SQUAWK [code]",
+	133: "REPORT PRESENT LEVEL",
+	159: "ERROR [errorinformation]",
+	160: "NEXT DATA AUTHORITY [facility]",
+	161: "END SERVICE",
+	162: "SERVICE UNAVAILABLE",
+	165: "THEN",
+	171: "CLIMB AT [verticalrate] MINIMUM",
+	172: "CLIMB AT [verticalrate] MAXIMUM",
+	173: "DESCEND AT [verticalrate] MINIMUM",
+	174: "DESCEND AT [verticalrate] MAXIMUM",
+	179: "SQUAWK IDENT",
+	183: "[freetext]",
+	190: "FLY HEADING [degrees]",
+	196: "[freetext]",
+	203: "[freetext]",
+	205: "[freetext]",
+	211: "REQUEST FORWARDED",
+	213: "[facilitydesignation] ALTIMETER [altimeter]",
+	215: "TURN [direction] [degrees]",
+	222: "NO SPEED RESTRICTION",
+	227: "LOGICAL ACKNOWLEDGEMENT",
+	231: "STATE PREFERRED LEVEL",
+	232: "STATE TOP OF DESCENT",
+	237: "REQUEST AGAIN WITH NEXT UNIT",
+}
+
+// ATN B1 downlink message labels for the LINK 2000+ message set.
+var atnDownlinkLabels = map[int]string{
+	0:   "WILCO",
+	1:   "UNABLE",
+	2:   "STANDBY",
+	3:   "ROGER",
+	4:   "AFFIRM",
+	5:   "NEGATIVE",
+	6:   "REQUEST [level]",
+	9:   "REQUEST CLIMB TO [level]",
+	10:  "REQUEST DESCENT TO [level]",
+	18:  "REQUEST [speed]",
+	22:  "REQUEST DIRECT TO [position]",
+	27:  "REQUEST WEATHER DEVIATION UP TO [specifieddistance] [direction] OF ROUTE",
+	32:  "PRESENT LEVEL [level]",
+	62:  "ERROR [errorinformation]",
+	63:  "NOT CURRENT DATA AUTHORITY",
+	65:  "DUE TO WEATHER",
+	66:  "DUE TO AIRCRAFT PERFORMANCE",
+	81:  "WE CAN ACCEPT [level] AT [time]",
+	82:  "WE CANNOT ACCEPT [level]",
+	89:  "MONITORING [unitname] [frequency]",
+	98:  "[freetext]",
+	99:  "CURRENT DATA AUTHORITY",
+	100: "LOGICAL ACKNOWLEDGEMENT",
+	106: "PREFERRED LEVEL [level]",
+	107: "NOT AUTHORIZED NEXT DATA AUTHORITY",
+	109: "TOP OF DESCENT [time]",
+}
+
+// GetATNUplinkLabel returns the label template for an ATN B1 uplink message ID.
+func GetATNUplinkLabel(msgID int) string {
+	if label, ok := atnUplinkLabels[msgID]; ok {
+		return label
+	}
+	return "(not in LINK 2000+ set)"
+}
+
+// GetATNDownlinkLabel returns the label template for an ATN B1 downlink
+// message ID.
+func GetATNDownlinkLabel(msgID int) string {
+	if label, ok := atnDownlinkLabels[msgID]; ok {
+		return label
+	}
+	return "(not in LINK 2000+ set)"
+}
@@ -0,0 +1,192 @@
+package cpdlc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+// atnWriter builds ATN B1 test messages bit by bit.
+type atnWriter struct {
+	t  *testing.T
+	bw *BitWriter
+}
+
+func newATNWriter(t *testing.T) *atnWriter {
+	return &atnWriter{t: t, bw: NewBitWriter()}
+}
+
+func (w *atnWriter) int(v, lower, upper int) *atnWriter {
+	w.t.Helper()
+	if err := w.bw.WriteConstrainedInt(v, lower, upper); err != nil {
+		w.t.Fatal(err)
+	}
+	return w
+}
+
+func (w *atnWriter) bit(b bool) *atnWriter {
+	w.bw.WriteBit(b)
+	return w
+}
+
+func (w *atnWriter) ia5(s string, minLen, maxLen int) *atnWriter {
+	w.t.Helper()
+	if minLen != maxLen {
+		w.int(len(s), minLen, maxLen)
+	}
+	for _, c := range []byte(s) {
+		if err := w.bw.WriteBits(uint32(c), 7); err != nil {
+			w.t.Fatal(err)
+		}
+	}
+	return w
+}
+
+// header writes msgID 12, an optional ref, 2026-03-14 10:20:30 and no
+// logicalAck.
+func (w *atnWriter) header(ref int) *atnWriter {
+	w.bit(ref >= 0).bit(false).int(12, 0, 63)
+	if ref >= 0 {
+		w.int(ref, 0, 63)
+	}
+	return w.int(2026, 1996, 2095).int(3, 1, 12).int(14, 1, 31).
+		int(10, 0, 23).int(20, 0, 59).int(30, 0, 59)
+}
+
+// elements writes messageData without constrainedData for count elements.
+func (w *atnWriter) elements(count int) *atnWriter {
+	return w.bit(false).int(count, 1, 5)
+}
+
+func (w *atnWriter) element(id, root int) *atnWriter {
+	return w.bit(false).int(id, 0, root-1)
+}
+
+func TestATNDecodeUplink(t *testing.T) {
+	w := newATNWriter(t).header(-1).elements(2)
+	// uM20 CLIMB TO FL350.
+	w.element(20, atnUplinkRoot).int(0, 0, 1).int(2, 0, 3).int(350, 30, 700)
+	// uM117 CONTACT EDYY MAASTRICHT CENTER 132.855.
+	w.element(117, atnUplinkRoot).bit(true).ia5("EDYY", 4, 8).ia5("MAASTRICHT", 3, 18).int(0, 0, 7)
+	w.int(1, 0, 3).int(132855/5, 23600, 27398)
+
+	msg, err := NewATNDecoder(w.bw.Bytes(), DirectionUplink).Decode()
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if msg.Header.MsgID != 12 || msg.Header.MsgRef != nil || msg.Header.Date != "2026-03-14" ||
+		msg.Header.Timestamp.String() != "10:20:30" || msg.Header.LogicalAck != "required" {
+		t.Errorf("header = %+v", msg.Header)
+	}
+	if len(msg.Elements) != 2 {
+		t.Fatalf("got %d elements, want 2", len(msg.Elements))
+	}
+	if got := msg.Elements[0].Text; got != "CLIMB TO FL350" {
+		t.Errorf("uM20 text = %q", got)
+	}
+	if got := msg.Elements[1].Text; got != "CONTACT EDYY MAASTRICHT CENTER 132.855 MHz" {
+		t.Errorf("uM117 text = %q", got)
+	}
+}
+
+func TestATNDecodeDownlink(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w *atnWriter)
+		want  string
+	}{
+		{"wilco", func(w *atnWriter) { w.element(0, atnDownlinkRoot) }, "WILCO"},
+		{"block level", func(w *atnWriter) {
+			w.element(6, atnDownlinkRoot).int(1, 0, 1).int(2, 0, 3).int(330, 30, 700).int(2, 0, 3).int(350, 30, 700)
+		}, "REQUEST FL330 TO FL350"},
+		{"direct to", func(w *atnWriter) {
+			w.element(22, atnDownlinkRoot).int(0, 0, 4).ia5("RILAX", 1, 5)
+		}, "REQUEST DIRECT TO RILAX"},
+		{"latlon", func(w *atnWriter) {
+			w.element(22, atnDownlinkRoot).int(3, 0, 4).bit(true).bit(true).
+				int(0, 0, 2).int(51500, 0, 90000).bit(false).
+				int(1, 0, 2).int(2, 0, 179).int(1800, 0, 5999).bit(true)
+		}, "REQUEST DIRECT TO 51.5000,-2.3000"},
+		{"free text", func(w *atnWriter) {
+			w.element(98, atnDownlinkRoot).ia5("DUE TO TURBULENCE", 1, 256)
+		}, "DUE TO TURBULENCE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newATNWriter(t).header(7).elements(1)
+			tt.write(w)
+			msg, err := NewATNDecoder(w.bw.Bytes(), DirectionDownlink).Decode()
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if msg.Header.MsgRef == nil || *msg.Header.MsgRef != 7 {
+				t.Errorf("msgRef = %v, want 7", msg.Header.MsgRef)
+			}
+			if len(msg.Elements) != 1 || msg.Elements[0].Text != tt.want {
+				t.Errorf("elements = %+v, want %q", msg.Elements, tt.want)
+			}
+		})
+	}
+}
+
+func TestATNDecodeExtensionAndUnsupported(t *testing.T) {
+	// An extension element is skipped, then uM106 (speed) stops decoding.
+	w := newATNWriter(t).header(-1).elements(3)
+	w.bit(true).bit(false).int(1, 0, 63) // Normally small index 1.
+	if err := w.bw.WriteLength(2); err != nil {
+		t.Fatal(err)
+	}
+	w.int(0xABCD, 0, 0xFFFF)
+	w.element(106, atnUplinkRoot).int(0, 0, 7)
+
+	msg, err := NewATNDecoder(w.bw.Bytes(), DirectionUplink).Decode()
+	if !errors.Is(err, ErrUnsupportedElement) {
+		t.Fatalf("Decode error = %v, want ErrUnsupportedElement", err)
+	}
+	if len(msg.Elements) != 2 {
+		t.Fatalf("got %d elements, want 2", len(msg.Elements))
+	}
+	if msg.Elements[0].ID != atnUplinkRoot+1 {
+		t.Errorf("extension element ID = %d, want %d", msg.Elements[0].ID, atnUplinkRoot+1)
+	}
+	if msg.Elements[1].ID != 106 || msg.Elements[1].Text != "MAINTAIN [speed]" {
+		t.Errorf("unsupported element = %+v", msg.Elements[1])
+	}
+}
+
+func TestATNDecodeUnknownDirection(t *testing.T) {
+	// uM94 TURN [direction] HEADING [degrees], with direction 14 of the
+	// sixteen its four bits allow; only eleven are defined.
+	w := newATNWriter(t).header(-1).elements(1)
+	w.element(94, atnUplinkRoot)
+	if err := w.bw.WriteBits(14, 4); err != nil {
+		t.Fatal(err)
+	}
+	w.int(0, 0, 1).int(90, 1, 360)
+
+	if _, err := NewATNDecoder(w.bw.Bytes(), DirectionUplink).Decode(); err == nil || !strings.Contains(err.Error(), "unknown direction 14") {
+		t.Errorf("Decode error = %v, want unknown direction 14", err)
+	}
+}
+
+func TestParseATN(t *testing.T) {
+	w := newATNWriter(t).header(-1).elements(1)
+	w.element(123, atnUplinkRoot).int(7, 0, 7).int(7, 0, 7).int(0, 0, 7).int(0, 0, 7)
+
+	p := &Parser{}
+	result := p.ParseATN(&acars.Message{ID: 1, LinkDirection: "uplink"}, w.bw.Bytes())
+	if result.Error != "" {
+		t.Fatalf("Error = %q", result.Error)
+	}
+	if result.Standard != StandardATNB1 || result.Direction != "uplink" || result.FormattedText != "SQUAWK 7700" {
+		t.Errorf("result = %+v", result)
+	}
+
+	result = p.ParseATN(&acars.Message{ID: 2, LinkDirection: "uplink"}, []byte{0x80})
+	if result.Error == "" || result.Elements != nil {
+		t.Errorf("truncated APDU: result = %+v", result)
+	}
+}
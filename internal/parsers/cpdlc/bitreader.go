@@ -69,7 +69,11 @@ func (br *BitReader) ReadBit() (bool, error) {
 }
 
 // ReadBytes reads a number of bytes (byte-aligned in the output, not the input).
+// A count larger than the bits left is rejected before anything is allocated.
 func (br *BitReader) ReadBytes(nbytes int) ([]byte, error) {
+	if nbytes < 0 || nbytes > br.Remaining()/8 {
+		return nil, ErrInsufficientBits
+	}
 	result := make([]byte, nbytes)
 	for i := 0; i < nbytes; i++ {
 		v, err := br.ReadBits(8)
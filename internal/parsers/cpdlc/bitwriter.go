@@ -0,0 +1,85 @@
+package cpdlc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrValueOutOfRange is returned when a value does not fit its constraint.
+var ErrValueOutOfRange = errors.New("value out of range")
+
+// BitWriter writes bits to a byte slice using UPER (Unaligned PER) rules. It
+// is the counterpart of BitReader.
+type BitWriter struct {
+	data  []byte
+	nbits int // Number of bits written.
+}
+
+// NewBitWriter creates an empty BitWriter.
+func NewBitWriter() *BitWriter {
+	return &BitWriter{}
+}
+
+// Len returns the number of bits written.
+func (bw *BitWriter) Len() int {
+	return bw.nbits
+}
+
+// Bytes returns the bits written, with the last byte padded with zero bits.
+func (bw *BitWriter) Bytes() []byte {
+	return append([]byte(nil), bw.data...)
+}
+
+// WriteBits writes the low nbits bits of v, up to 31, most significant first.
+func (bw *BitWriter) WriteBits(v uint32, nbits int) error {
+	if nbits < 0 || nbits > 31 {
+		return errors.New("invalid bit count (must be 0-31)")
+	}
+	if nbits < 31 && v >= 1<<nbits {
+		return fmt.Errorf("%w: %d does not fit in %d bits", ErrValueOutOfRange, v, nbits)
+	}
+	for i := nbits - 1; i >= 0; i-- {
+		if bw.nbits%8 == 0 {
+			bw.data = append(bw.data, 0)
+		}
+		if v&(1<<i) != 0 {
+			bw.data[bw.nbits/8] |= 0x80 >> (bw.nbits % 8)
+		}
+		bw.nbits++
+	}
+	return nil
+}
+
+// WriteBit writes a single bit.
+func (bw *BitWriter) WriteBit(b bool) {
+	var v uint32
+	if b {
+		v = 1
+	}
+	_ = bw.WriteBits(v, 1) // A single bit always fits.
+}
+
+// WriteConstrainedInt writes a constrained integer in the bits its range
+// needs, as read by ReadConstrainedInt.
+func (bw *BitWriter) WriteConstrainedInt(v, lower, upper int) error {
+	if upper < lower {
+		return errors.New("upper bound must be >= lower bound")
+	}
+	if v < lower || v > upper {
+		return fmt.Errorf("%w: %d not in %d-%d", ErrValueOutOfRange, v, lower, upper)
+	}
+	return bw.WriteBits(uint32(v-lower), bitsNeeded(upper-lower))
+}
+
+// WriteLength writes a length determinant as per X.691 section 10.9. Only the
+// short and medium forms (0-16383) are supported.
+func (bw *BitWriter) WriteLength(n int) error {
+	switch {
+	case n < 0 || n > 16383:
+		return fmt.Errorf("%w: length %d", ErrValueOutOfRange, n)
+	case n < 128:
+		return bw.WriteBits(uint32(n), 8)
+	default:
+		return bw.WriteBits(0x8000|uint32(n), 16)
+	}
+}
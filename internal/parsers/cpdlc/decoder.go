@@ -19,6 +19,29 @@ type MessageElement struct {
 	Text  string      `json:"text,omitempty"` // Formatted message text.
 }
 
+// maxFreeTextLength caps free text elements. The length determinant allows
+// up to 65536 characters, far beyond any real message, so a corrupt one is cut
+// short rather than read.
+const maxFreeTextLength = 256
+
+// Enumerated values, indexed by their encoding. The encoder maps names back
+// to these indices.
+var (
+	offsetDirections     = []string{"left", "right", "either side", "north", "south", "east", "west", "north-east", "north-west", "south-east", "south-west"}
+	runwayConfigurations = []string{"left", "right", "center", "none"}
+	procedureTypes       = []string{"arrival", "approach", "departure"}
+	severities           = []string{"none", "light", "moderate", "severe"} // Turbulence and icing.
+	errorDescriptions    = []string{
+		"unrecognized message reference number",
+		"logon data not accepted",
+		"insufficient resources",
+		"service unavailable",
+		"duplicate message reference number",
+		"no operational PDC",
+		"unexpected request reference",
+	}
+)
+
 // Decoder decodes FANS-1/A CPDLC messages.
 type Decoder struct {
 	br        *BitReader
@@ -802,9 +825,8 @@ func (d *Decoder) decodeDistanceOffset() (*DistanceOffset, error) {
 	if err != nil {
 		return nil, err
 	}
-	dirNames := []string{"left", "right", "either side", "north", "south", "east", "west", "north-east", "north-west", "south-east", "south-west"}
-	if dir < len(dirNames) {
-		offset.Direction = dirNames[dir]
+	if dir < len(offsetDirections) {
+		offset.Direction = offsetDirections[dir]
 	}
 
 	return offset, nil
@@ -930,18 +952,9 @@ func (d *Decoder) decodeErrorInfo() (*ErrorInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	errorDescs := []string{
-		"unrecognized message reference number",
-		"logon data not accepted",
-		"insufficient resources",
-		"service unavailable",
-		"duplicate message reference number",
-		"no operational PDC",
-		"unexpected request reference",
-	}
 	desc := ""
-	if code < len(errorDescs) {
-		desc = errorDescs[code]
+	if code < len(errorDescriptions) {
+		desc = errorDescriptions[code]
 	}
 	return &ErrorInfo{Code: code, Desc: desc}, nil
 }
@@ -961,8 +974,8 @@ func (d *Decoder) decodeFreeText() (*FreeText, error) {
 	if err != nil {
 		return nil, err
 	}
-	if length > 256 {
-		length = 256 // Cap at reasonable max.
+	if length > maxFreeTextLength {
+		length = maxFreeTextLength
 	}
 	text, err := d.decodeIA5String(length)
 	if err != nil {
@@ -1159,7 +1172,11 @@ func (d *Decoder) decodeLatLon() (float64, float64, error) {
 }
 
 func (d *Decoder) decodeIA5String(length int) (string, error) {
-	// IA5 characters are 7-bit ASCII.
+	// IA5 characters are 7-bit ASCII. A corrupt length is rejected before
+	// anything is allocated.
+	if length < 0 || length > d.br.Remaining()/7 {
+		return "", ErrInsufficientBits
+	}
 	result := make([]byte, length)
 	for i := 0; i < length; i++ {
 		v, err := d.br.ReadBits(7)
@@ -1477,10 +1494,9 @@ func (d *Decoder) decodeRunway() (*Runway, error) {
 		return nil, fmt.Errorf("configuration: %w", err)
 	}
 
-	configNames := []string{"left", "right", "center", "none"}
 	configName := "none"
-	if config < len(configNames) {
-		configName = configNames[config]
+	if config < len(runwayConfigurations) {
+		configName = runwayConfigurations[config]
 	}
 
 	return &Runway{
@@ -1498,10 +1514,9 @@ func (d *Decoder) decodeProcedureName() (*ProcedureName, error) {
 		return nil, fmt.Errorf("procedureType: %w", err)
 	}
 
-	typeNames := []string{"arrival", "approach", "departure"}
 	typeName := "unknown"
-	if procType < len(typeNames) {
-		typeName = typeNames[procType]
+	if procType < len(procedureTypes) {
+		typeName = procedureTypes[procType]
 	}
 
 	// FANSProcedure has 1 optional field (transition).
@@ -1734,9 +1749,8 @@ func (d *Decoder) decodePositionReport() (*PositionReport, error) {
 		if err != nil {
 			return nil, fmt.Errorf("turbulence: %w", err)
 		}
-		turbNames := []string{"none", "light", "moderate", "severe"}
-		if turb < len(turbNames) {
-			pr.Turbulence = turbNames[turb]
+		if turb < len(severities) {
+			pr.Turbulence = severities[turb]
 		}
 	}
 
@@ -1746,9 +1760,8 @@ func (d *Decoder) decodePositionReport() (*PositionReport, error) {
 		if err != nil {
 			return nil, fmt.Errorf("icing: %w", err)
 		}
-		icingNames := []string{"none", "light", "moderate", "severe"}
-		if icing < len(icingNames) {
-			pr.Icing = icingNames[icing]
+		if icing < len(severities) {
+			pr.Icing = severities[icing]
 		}
 	}
 
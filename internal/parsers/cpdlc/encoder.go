@@ -0,0 +1,894 @@
+package cpdlc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNotEncodable is returned for data the decoder keeps only as placeholder
+// text, such as a trackDetail route element, which cannot be encoded again.
+var ErrNotEncodable = errors.New("not encodable")
+
+// Encoder encodes CPDLC messages into FANS-1/A UPER bits. It is the inverse of
+// Decoder: decoding its output gives back the message it was given.
+//
+// Where the decoder folds several encodings into one value (an altitude in
+// feet may be QNH, QFE or GNSS), the encoder picks the first that can carry
+// the value, so the bits may differ from the original message while the
+// decoded data does not.
+type Encoder struct {
+	bw        *BitWriter
+	direction MessageDirection
+}
+
+// NewEncoder creates a new CPDLC encoder.
+func NewEncoder(direction MessageDirection) *Encoder {
+	return &Encoder{direction: direction}
+}
+
+// Encode encodes a message. Each element's ID and Data are encoded; the Label
+// and Text are derived and ignored.
+func (e *Encoder) Encode(msg *Message) ([]byte, error) {
+	if len(msg.Elements) < 1 || len(msg.Elements) > 5 {
+		return nil, fmt.Errorf("%w: %d elements, want 1-5", ErrValueOutOfRange, len(msg.Elements))
+	}
+	e.bw = NewBitWriter()
+
+	// Presence bit for the optional seqOf of additional elements.
+	e.bw.WriteBit(len(msg.Elements) > 1)
+
+	if err := e.encodeHeader(&msg.Header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	if err := e.encodeElement(&msg.Elements[0]); err != nil {
+		return nil, fmt.Errorf("element: %w", err)
+	}
+	if len(msg.Elements) > 1 {
+		if err := e.bw.WriteConstrainedInt(len(msg.Elements)-1, 1, 4); err != nil {
+			return nil, fmt.Errorf("seqOf count: %w", err)
+		}
+		for i := range msg.Elements[1:] {
+			if err := e.encodeElement(&msg.Elements[i+1]); err != nil {
+				return nil, fmt.Errorf("seqOf element %d: %w", i, err)
+			}
+		}
+	}
+
+	return e.bw.Bytes(), nil
+}
+
+// Encode encodes a message in its own direction.
+func Encode(msg *Message) ([]byte, error) {
+	return NewEncoder(msg.Direction).Encode(msg)
+}
+
+func (e *Encoder) encodeHeader(h *MessageHeader) error {
+	e.bw.WriteBit(h.MsgRef != nil)
+	e.bw.WriteBit(h.Timestamp != nil)
+	if err := e.bw.WriteConstrainedInt(h.MsgID, 0, 63); err != nil {
+		return fmt.Errorf("msgID: %w", err)
+	}
+	if h.MsgRef != nil {
+		if err := e.bw.WriteConstrainedInt(*h.MsgRef, 0, 63); err != nil {
+			return fmt.Errorf("msgRef: %w", err)
+		}
+	}
+	if h.Timestamp != nil {
+		if err := e.encodeTime(h.Timestamp); err != nil {
+			return fmt.Errorf("timestamp: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeElement(elem *MessageElement) error {
+	maxChoice := 128
+	if e.direction == DirectionUplink {
+		maxChoice = 182
+	}
+	if err := e.bw.WriteConstrainedInt(elem.ID, 0, maxChoice); err != nil {
+		return fmt.Errorf("element ID: %w", err)
+	}
+
+	var err error
+	if e.direction == DirectionUplink {
+		err = e.encodeUplinkData(elem.ID, elem.Data)
+	} else {
+		err = e.encodeDownlinkData(elem.ID, elem.Data)
+	}
+	if err != nil {
+		return fmt.Errorf("element data: %w", err)
+	}
+	return nil
+}
+
+// encodeUplinkData encodes uplink element-specific data, following
+// decodeUplinkData.
+func (e *Encoder) encodeUplinkData(elemID int, data interface{}) error {
+	switch elemID {
+	case 6, 19, 20, 23, 33, 34, 35, 36, 37, 38, 39, 40, 41, 128, 129, 148, 175:
+		return encodeAs(data, e.encodeAltitude)
+	case 7, 9, 11, 69, 71, 93:
+		return encodeAs(data, e.encodeTime)
+	case 8, 10, 12, 68, 70, 74, 75, 87, 130, 155:
+		return encodeAs(data, e.encodePosition)
+	case 13, 15, 17, 21, 24:
+		return e.encodeFields(data, "time", "altitude")
+	case 14, 16, 18, 22, 25, 42, 43, 44, 45, 46, 47, 48, 49, 92:
+		return e.encodeFields(data, "position", "altitude")
+	case 30, 31, 32, 180:
+		return e.encodeFields(data, "altitude1", "altitude2")
+	case 106, 108, 109, 111, 112, 113, 114, 115, 151:
+		return encodeAs(data, e.encodeSpeed)
+	case 110:
+		return e.encodeFields(data, "speed1", "speed2")
+	case 94, 95, 98:
+		return e.encodeFields(data, "direction", "degrees")
+	case 117, 120:
+		return e.encodeFields(data, "unit", "frequency")
+	case 123:
+		return encodeAs(data, e.encodeBeaconCode)
+	case 153:
+		return encodeAs(data, e.encodeAltimeter)
+	case 157:
+		return encodeAs(data, e.encodeFrequency)
+	case 158:
+		return encodeAs(data, e.encodeATISCode)
+	case 159:
+		return encodeAs(data, e.encodeErrorInfo)
+	case 160:
+		return encodeAs(data, e.encodeICAOFacility)
+	case 169, 170:
+		return encodeAs(data, e.encodeFreeText)
+	case 171, 172, 173, 174:
+		return encodeAs(data, e.encodeVerticalRate)
+	case 64, 82, 152:
+		return encodeAs(data, e.encodeDistanceOffset)
+	case 79, 83, 86:
+		return e.encodeFields(data, "position", "route_clearance")
+	case 80, 85:
+		return encodeAs(data, e.encodeRouteClearance)
+	case 84:
+		return e.encodeFields(data, "position", "procedure")
+	case 81, 99:
+		return encodeAs(data, e.encodeProcedureName)
+	default:
+		// No data, or a type the decoder skips.
+		return nil
+	}
+}
+
+// encodeDownlinkData encodes downlink element-specific data, following
+// decodeDownlinkData.
+func (e *Encoder) encodeDownlinkData(elemID int, data interface{}) error {
+	switch elemID {
+	case 6, 8, 9, 10, 28, 29, 30, 32, 37, 38, 54, 61, 72:
+		return encodeAs(data, e.encodeAltitude)
+	case 7, 76, 77:
+		return e.encodeFields(data, "altitude1", "altitude2")
+	case 43, 46:
+		return encodeAs(data, e.encodeTime)
+	case 22, 31, 33, 42, 44, 45:
+		return encodeAs(data, e.encodePosition)
+	case 11, 12:
+		return e.encodeFields(data, "position", "altitude")
+	case 13, 14:
+		return e.encodeFields(data, "time", "altitude")
+	case 18, 34, 39, 49:
+		return encodeAs(data, e.encodeSpeed)
+	case 19, 50:
+		return e.encodeFields(data, "speed1", "speed2")
+	case 35, 36, 70, 71:
+		return encodeAs(data, e.encodeDegrees)
+	case 21:
+		return encodeAs(data, e.encodeFrequency)
+	case 47:
+		return encodeAs(data, e.encodeBeaconCode)
+	case 62:
+		return encodeAs(data, e.encodeErrorInfo)
+	case 64:
+		return encodeAs(data, e.encodeICAOFacility)
+	case 67, 68:
+		return encodeAs(data, e.encodeFreeText)
+	case 73:
+		return encodeAs(data, e.encodeVersionNumber)
+	case 79:
+		return encodeAs(data, e.encodeATISCode)
+	case 15, 27, 60, 80:
+		return encodeAs(data, e.encodeDistanceOffset)
+	case 57:
+		return e.encodeFields(data, "remaining_fuel", "persons_on_board")
+	case 23:
+		return encodeAs(data, e.encodeProcedureName)
+	case 24, 40:
+		return encodeAs(data, e.encodeRouteClearance)
+	case 26, 59:
+		return e.encodeFields(data, "position", "route_clearance")
+	case 16:
+		return e.encodeFields(data, "position", "distance_offset")
+	case 17:
+		return e.encodeFields(data, "time", "distance_offset")
+	case 48:
+		return encodeAs(data, e.encodePositionReport)
+	case 78:
+		return e.encodeFields(data, "time", "distance", "to_from", "position")
+	default:
+		return nil
+	}
+}
+
+// encodeAs encodes data with fn after checking it has the type fn takes.
+func encodeAs[T any](data interface{}, fn func(T) error) error {
+	v, ok := data.(T)
+	if !ok {
+		var want T
+		return fmt.Errorf("data is %T, want %T", data, want)
+	}
+	return fn(v)
+}
+
+// encodeFields encodes the named fields of compound data in order. The keys
+// are those the decoder uses.
+func (e *Encoder) encodeFields(data interface{}, keys ...string) error {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("data is %T, want map[string]interface{}", data)
+	}
+	for _, key := range keys {
+		var err error
+		switch key {
+		case "time":
+			err = encodeAs(m[key], e.encodeTime)
+		case "altitude", "altitude1", "altitude2":
+			err = encodeAs(m[key], e.encodeAltitude)
+		case "position":
+			err = encodeAs(m[key], e.encodePosition)
+		case "speed1", "speed2":
+			err = encodeAs(m[key], e.encodeSpeed)
+		case "degrees":
+			err = encodeAs(m[key], e.encodeDegrees)
+		case "direction":
+			err = encodeAs(m[key], e.encodeLeftRight)
+		case "unit":
+			err = encodeAs(m[key], e.encodeICAOFacility)
+		case "frequency":
+			err = encodeAs(m[key], e.encodeFrequency)
+		case "route_clearance":
+			err = encodeAs(m[key], e.encodeRouteClearance)
+		case "procedure":
+			err = encodeAs(m[key], e.encodeProcedureName)
+		case "distance_offset":
+			err = encodeAs(m[key], e.encodeDistanceOffset)
+		case "distance":
+			err = encodeAs(m[key], e.encodeDistance)
+		case "to_from":
+			err = encodeAs(m[key], e.encodeToFrom)
+		case "remaining_fuel":
+			err = encodeAs(m[key], e.encodeRemainingFuel)
+		case "persons_on_board":
+			err = encodeAs(m[key], func(p *PersonsOnBoard) error {
+				return e.bw.WriteConstrainedInt(p.Count, 0, 1023)
+			})
+		default:
+			err = errors.New("unknown field")
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Type-specific encoders.
+
+func (e *Encoder) encodeAltitude(alt *Altitude) error {
+	// Choices as in decodeAltitude.
+	switch alt.Type {
+	case "feet":
+		if alt.Value%10 == 0 && alt.Value >= 0 && alt.Value <= 25000 {
+			return e.writeChoice(0, 7, alt.Value/10, 0, 2500) // QNH.
+		}
+		return e.writeChoice(4, 7, alt.Value, 0, 150000) // GNSS.
+	case "meters":
+		if alt.Value <= 16000 {
+			return e.writeChoice(1, 7, alt.Value, 0, 16000) // QNH.
+		}
+		return e.writeChoice(5, 7, alt.Value, 0, 50000) // GNSS.
+	case "flight_level":
+		return e.writeChoice(6, 7, alt.Value, 30, 600)
+	case "flight_level_metric":
+		return e.writeChoice(7, 7, alt.Value, 100, 2000)
+	default:
+		return fmt.Errorf("unknown altitude type %q", alt.Type)
+	}
+}
+
+func (e *Encoder) encodeSpeed(spd *Speed) error {
+	// Choices as in decodeSpeed. Indicated speeds are preferred to true and
+	// ground speeds, which decode the same.
+	switch spd.Type {
+	case "knots":
+		if spd.Value%10 != 0 {
+			return fmt.Errorf("%w: %d knots is not a multiple of 10", ErrValueOutOfRange, spd.Value)
+		}
+		if spd.Value <= 380 {
+			return e.writeChoice(0, 7, spd.Value/10, 7, 38)
+		}
+		return e.writeChoice(2, 7, spd.Value/10, 7, 70)
+	case "kph":
+		if spd.Value%10 != 0 {
+			return fmt.Errorf("%w: %d km/h is not a multiple of 10", ErrValueOutOfRange, spd.Value)
+		}
+		if spd.Value <= 1370 {
+			return e.writeChoice(1, 7, spd.Value/10, 10, 137)
+		}
+		return e.writeChoice(5, 7, spd.Value/10, 10, 265)
+	case "mach":
+		if spd.Value <= 92 {
+			return e.writeChoice(6, 7, spd.Value, 61, 92)
+		}
+		return e.writeChoice(7, 7, spd.Value, 93, 604)
+	default:
+		return fmt.Errorf("unknown speed type %q", spd.Type)
+	}
+}
+
+// writeChoice writes a CHOICE index followed by its constrained value.
+func (e *Encoder) writeChoice(choice, maxChoice, v, lower, upper int) error {
+	if err := e.bw.WriteConstrainedInt(choice, 0, maxChoice); err != nil {
+		return err
+	}
+	return e.bw.WriteConstrainedInt(v, lower, upper)
+}
+
+func (e *Encoder) encodeTime(t *Time) error {
+	if err := e.bw.WriteConstrainedInt(t.Hours, 0, 23); err != nil {
+		return err
+	}
+	if err := e.bw.WriteConstrainedInt(t.Minutes, 0, 59); err != nil {
+		return err
+	}
+	return e.bw.WriteConstrainedInt(t.Seconds, 0, 59)
+}
+
+func (e *Encoder) encodePosition(pos *Position) error {
+	switch pos.Type {
+	case "fix":
+		if err := e.bw.WriteConstrainedInt(0, 0, 4); err != nil {
+			return err
+		}
+		return e.encodeFixName(pos.Name)
+	case "navaid":
+		if err := e.bw.WriteConstrainedInt(1, 0, 4); err != nil {
+			return err
+		}
+		return e.encodeSizedString(pos.Name, 1, 4)
+	case "airport":
+		if err := e.bw.WriteConstrainedInt(2, 0, 4); err != nil {
+			return err
+		}
+		return e.encodeAirport(pos.Name)
+	case "latlon":
+		if pos.Latitude == nil || pos.Longitude == nil {
+			return errors.New("latlon position without coordinates")
+		}
+		if err := e.bw.WriteConstrainedInt(3, 0, 4); err != nil {
+			return err
+		}
+		return e.encodeLatLon(*pos.Latitude, *pos.Longitude)
+	case "place_bearing_distance":
+		if pos.Bearing == nil || pos.Distance == nil {
+			return errors.New("place_bearing_distance position without bearing or distance")
+		}
+		if err := e.bw.WriteConstrainedInt(4, 0, 4); err != nil {
+			return err
+		}
+		return e.encodePlaceBearingDistance(&PlaceBearingDistance{
+			FixName:      pos.Name,
+			Latitude:     pos.Latitude,
+			Longitude:    pos.Longitude,
+			Magnetic:     true,
+			Bearing:      pos.Bearing,
+			Distance:     pos.Distance,
+			DistanceUnit: pos.DistanceUnit,
+		})
+	default:
+		return fmt.Errorf("unknown position type %q", pos.Type)
+	}
+}
+
+func (e *Encoder) encodePlaceBearingDistance(pbd *PlaceBearingDistance) error {
+	hasLatLon := pbd.Latitude != nil && pbd.Longitude != nil
+	e.bw.WriteBit(hasLatLon)
+	if err := e.encodeFixName(pbd.FixName); err != nil {
+		return fmt.Errorf("fixName: %w", err)
+	}
+	if hasLatLon {
+		if err := e.encodeLatLon(*pbd.Latitude, *pbd.Longitude); err != nil {
+			return fmt.Errorf("latLon: %w", err)
+		}
+	}
+
+	degChoice := 1
+	if pbd.Magnetic {
+		degChoice = 0
+	}
+	if err := e.writeChoice(degChoice, 1, *pbd.Bearing, 1, 360); err != nil {
+		return fmt.Errorf("degrees: %w", err)
+	}
+
+	switch pbd.DistanceUnit {
+	case "nm":
+		return e.writeChoice(0, 1, *pbd.Distance, 0, 9999)
+	case "km":
+		return e.writeChoice(1, 1, *pbd.Distance, 1, 1024)
+	default:
+		return fmt.Errorf("unknown distance unit %q", pbd.DistanceUnit)
+	}
+}
+
+// encodeLatLon writes a latitude and longitude as degrees, minutes and
+// seconds, rounded to the nearest second.
+func (e *Encoder) encodeLatLon(lat, lon float64) error {
+	if err := e.encodeDMS(lat, 90); err != nil {
+		return fmt.Errorf("latitude: %w", err)
+	}
+	if err := e.encodeDMS(lon, 180); err != nil {
+		return fmt.Errorf("longitude: %w", err)
+	}
+	return nil
+}
+
+// encodeDMS writes an angle as degrees (0-maxDeg), minutes, seconds and a
+// hemisphere bit that is set for a negative angle (south or west).
+func (e *Encoder) encodeDMS(v float64, maxDeg int) error {
+	secs := int(math.Round(math.Abs(v) * 3600))
+	if err := e.bw.WriteConstrainedInt(secs/3600, 0, maxDeg); err != nil {
+		return err
+	}
+	if err := e.bw.WriteConstrainedInt(secs/60%60, 0, 59); err != nil {
+		return err
+	}
+	if err := e.bw.WriteConstrainedInt(secs%60, 0, 59); err != nil {
+		return err
+	}
+	e.bw.WriteBit(v < 0)
+	return nil
+}
+
+func (e *Encoder) encodeDegrees(deg *Degrees) error {
+	choice := 1
+	if deg.Magnetic {
+		choice = 0
+	}
+	return e.writeChoice(choice, 1, deg.Value, 1, 360)
+}
+
+func (e *Encoder) encodeLeftRight(dir string) error {
+	switch dir {
+	case "left":
+		return e.bw.WriteConstrainedInt(0, 0, 1)
+	case "right":
+		return e.bw.WriteConstrainedInt(1, 0, 1)
+	default:
+		return fmt.Errorf("unknown direction %q", dir)
+	}
+}
+
+func (e *Encoder) encodeDistanceOffset(offset *DistanceOffset) error {
+	var err error
+	switch offset.Unit {
+	case "nm":
+		err = e.writeChoice(0, 1, offset.Distance, 1, 128)
+	case "km":
+		err = e.writeChoice(1, 1, offset.Distance, 1, 256)
+	default:
+		err = fmt.Errorf("unknown distance unit %q", offset.Unit)
+	}
+	if err != nil {
+		return err
+	}
+	return e.encodeEnum(offset.Direction, offsetDirections)
+}
+
+// encodeEnum writes the index of name in values.
+func (e *Encoder) encodeEnum(name string, values []string) error {
+	for i, v := range values {
+		if v == name {
+			return e.bw.WriteConstrainedInt(i, 0, len(values)-1)
+		}
+	}
+	return fmt.Errorf("unknown value %q", name)
+}
+
+func (e *Encoder) encodeFrequency(freq *Frequency) error {
+	switch freq.Type {
+	case "hf":
+		return e.writeChoice(0, 3, freq.Value, 2850, 28000)
+	case "vhf":
+		return e.writeChoice(1, 3, freq.Value, 117000, 138000)
+	case "uhf":
+		return e.writeChoice(2, 3, freq.Value, 225000, 399975)
+	case "satcom":
+		// The decoder does not read the channel, so none is written.
+		return e.bw.WriteConstrainedInt(3, 0, 3)
+	default:
+		return fmt.Errorf("unknown frequency type %q", freq.Type)
+	}
+}
+
+func (e *Encoder) encodeBeaconCode(code *BeaconCode) error {
+	if len(code.Code) != 4 {
+		return fmt.Errorf("%w: beacon code %q", ErrValueOutOfRange, code.Code)
+	}
+	for i := 0; i < 4; i++ {
+		if err := e.bw.WriteConstrainedInt(int(code.Code[i])-'0', 0, 7); err != nil {
+			return fmt.Errorf("beacon code %q: %w", code.Code, err)
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeAltimeter(alt map[string]interface{}) error {
+	v, ok := alt["value"].(float64)
+	if !ok {
+		return errors.New("altimeter without value")
+	}
+	switch alt["type"] {
+	case "inhg":
+		return e.writeChoice(0, 1, int(math.Round(v*100)), 2200, 3200)
+	case "hpa":
+		return e.writeChoice(1, 1, int(math.Round(v*10)), 7500, 12500)
+	default:
+		return fmt.Errorf("unknown altimeter type %v", alt["type"])
+	}
+}
+
+func (e *Encoder) encodeATISCode(code string) error {
+	if len(code) != 1 {
+		return fmt.Errorf("%w: ATIS code %q", ErrValueOutOfRange, code)
+	}
+	return e.bw.WriteConstrainedInt(int(code[0])-'A', 0, 25)
+}
+
+func (e *Encoder) encodeErrorInfo(info *ErrorInfo) error {
+	return e.bw.WriteConstrainedInt(info.Code, 0, len(errorDescriptions)-1)
+}
+
+func (e *Encoder) encodeICAOFacility(name string) error {
+	if len(name) != 4 {
+		return fmt.Errorf("%w: facility %q is not 4 characters", ErrValueOutOfRange, name)
+	}
+	return e.encodeIA5String(name)
+}
+
+func (e *Encoder) encodeFreeText(ft *FreeText) error {
+	if len(ft.Text) > maxFreeTextLength {
+		return fmt.Errorf("%w: free text of %d characters", ErrValueOutOfRange, len(ft.Text))
+	}
+	if err := e.bw.WriteLength(len(ft.Text)); err != nil {
+		return err
+	}
+	return e.encodeIA5String(ft.Text)
+}
+
+func (e *Encoder) encodeVersionNumber(v int) error {
+	return e.bw.WriteConstrainedInt(v, 0, 15)
+}
+
+func (e *Encoder) encodeVerticalRate(vr *VerticalRate) error {
+	if vr.Value%100 == 0 && vr.Value >= 0 && vr.Value <= 6000 {
+		return e.writeChoice(0, 1, vr.Value/100, 0, 60)
+	}
+	// A metric rate, decoded to ft/min.
+	for v := 0; v <= 200; v++ {
+		if int(float64(v)*10*3.28084) == vr.Value {
+			return e.writeChoice(1, 1, v, 0, 200)
+		}
+	}
+	return fmt.Errorf("%w: vertical rate %d ft/min", ErrValueOutOfRange, vr.Value)
+}
+
+func (e *Encoder) encodeFixName(name string) error {
+	return e.encodeSizedString(name, 1, 5)
+}
+
+func (e *Encoder) encodeAirport(name string) error {
+	if len(name) != 4 {
+		return fmt.Errorf("%w: airport %q is not 4 characters", ErrValueOutOfRange, name)
+	}
+	return e.encodeIA5String(name)
+}
+
+// encodeSizedString writes a string whose length is a constrained integer.
+func (e *Encoder) encodeSizedString(s string, minLen, maxLen int) error {
+	if err := e.bw.WriteConstrainedInt(len(s), minLen, maxLen); err != nil {
+		return fmt.Errorf("length of %q: %w", s, err)
+	}
+	return e.encodeIA5String(s)
+}
+
+func (e *Encoder) encodeIA5String(s string) error {
+	for i := 0; i < len(s); i++ {
+		if s[i] > 0x7F {
+			return fmt.Errorf("%w: %q is not IA5", ErrValueOutOfRange, s)
+		}
+		if err := e.bw.WriteBits(uint32(s[i]), 7); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeRouteClearance(rc *RouteClearance) error {
+	present := []bool{
+		rc.AirportDeparture != "",
+		rc.AirportDestination != "",
+		rc.RunwayDeparture != nil,
+		rc.ProcedureDeparture != nil,
+		rc.RunwayArrival != nil,
+		rc.ProcedureApproach != nil,
+		rc.ProcedureArrival != nil,
+		rc.AirwayIntercept != "",
+		len(rc.RouteInformation) > 0,
+		rc.RouteInfoAdditional != "",
+	}
+	for _, p := range present {
+		e.bw.WriteBit(p)
+	}
+
+	if rc.AirportDeparture != "" {
+		if err := e.encodeAirport(rc.AirportDeparture); err != nil {
+			return fmt.Errorf("airportDeparture: %w", err)
+		}
+	}
+	if rc.AirportDestination != "" {
+		if err := e.encodeAirport(rc.AirportDestination); err != nil {
+			return fmt.Errorf("airportDestination: %w", err)
+		}
+	}
+	if rc.RunwayDeparture != nil {
+		if err := e.encodeRunway(rc.RunwayDeparture); err != nil {
+			return fmt.Errorf("runwayDeparture: %w", err)
+		}
+	}
+	if rc.ProcedureDeparture != nil {
+		if err := e.encodeProcedureName(rc.ProcedureDeparture); err != nil {
+			return fmt.Errorf("procedureDeparture: %w", err)
+		}
+	}
+	if rc.RunwayArrival != nil {
+		if err := e.encodeRunway(rc.RunwayArrival); err != nil {
+			return fmt.Errorf("runwayArrival: %w", err)
+		}
+	}
+	if rc.ProcedureApproach != nil {
+		if err := e.encodeProcedureName(rc.ProcedureApproach); err != nil {
+			return fmt.Errorf("procedureApproach: %w", err)
+		}
+	}
+	if rc.ProcedureArrival != nil {
+		if err := e.encodeProcedureName(rc.ProcedureArrival); err != nil {
+			return fmt.Errorf("procedureArrival: %w", err)
+		}
+	}
+	if rc.AirwayIntercept != "" {
+		if err := e.encodeSizedString(rc.AirwayIntercept, 2, 7); err != nil {
+			return fmt.Errorf("airwayIntercept: %w", err)
+		}
+	}
+	if len(rc.RouteInformation) > 0 {
+		if err := e.bw.WriteConstrainedInt(len(rc.RouteInformation), 1, 128); err != nil {
+			return fmt.Errorf("routeInformation count: %w", err)
+		}
+		for i, s := range rc.RouteInformation {
+			if err := e.encodeRouteInformationElement(s); err != nil {
+				return fmt.Errorf("routeInformation[%d]: %w", i, err)
+			}
+		}
+	}
+	if rc.RouteInfoAdditional != "" {
+		if err := e.encodeSizedString(rc.RouteInfoAdditional, 1, 256); err != nil {
+			return fmt.Errorf("routeInfoAdditional: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeRunway(rwy *Runway) error {
+	if err := e.bw.WriteConstrainedInt(rwy.Direction, 1, 36); err != nil {
+		return fmt.Errorf("direction: %w", err)
+	}
+	if err := e.encodeEnum(rwy.Configuration, runwayConfigurations); err != nil {
+		return fmt.Errorf("configuration: %w", err)
+	}
+	return nil
+}
+
+func (e *Encoder) encodeProcedureName(proc *ProcedureName) error {
+	if err := e.encodeEnum(proc.Type, procedureTypes); err != nil {
+		return fmt.Errorf("procedureType: %w", err)
+	}
+	e.bw.WriteBit(proc.Transition != "")
+	if err := e.encodeSizedString(proc.Name, 1, 6); err != nil {
+		return fmt.Errorf("procedure name: %w", err)
+	}
+	if proc.Transition != "" {
+		if err := e.encodeSizedString(proc.Transition, 1, 5); err != nil {
+			return fmt.Errorf("transition: %w", err)
+		}
+	}
+	return nil
+}
+
+// Route information elements are decoded to text, as formatted by
+// decodeRouteInformationElement.
+var (
+	routeLatLonRe = regexp.MustCompile(`^(-?\d+\.\d+),(-?\d+\.\d+)$`)
+	routePBDRe    = regexp.MustCompile(`^(\S{1,5}) (\d{3})/(\d+)(nm|km)$`)
+)
+
+// encodeRouteInformationElement encodes a route information element from its
+// decoded text. A fix, navaid or airport name is written as a fix and a longer
+// name as an airway, which decode to the same text.
+func (e *Encoder) encodeRouteInformationElement(s string) error {
+	if m := routeLatLonRe.FindStringSubmatch(s); m != nil {
+		lat, _ := strconv.ParseFloat(m[1], 64)
+		lon, _ := strconv.ParseFloat(m[2], 64)
+		if err := e.bw.WriteConstrainedInt(1, 0, 10); err != nil {
+			return err
+		}
+		return e.encodeLatLon(lat, lon)
+	}
+	if m := routePBDRe.FindStringSubmatch(s); m != nil {
+		bearing, _ := strconv.Atoi(m[2])
+		distance, err := strconv.Atoi(m[3])
+		if err != nil {
+			return fmt.Errorf("%w: distance in %q", ErrValueOutOfRange, s)
+		}
+		if err := e.bw.WriteConstrainedInt(3, 0, 10); err != nil {
+			return err
+		}
+		return e.encodePlaceBearingDistance(&PlaceBearingDistance{
+			FixName:      m[1],
+			Magnetic:     true,
+			Bearing:      &bearing,
+			Distance:     &distance,
+			DistanceUnit: m[4],
+		})
+	}
+
+	switch {
+	case strings.HasPrefix(s, "("):
+		return fmt.Errorf("%w: route element %s", ErrNotEncodable, s)
+	case len(s) >= 1 && len(s) <= 5:
+		if err := e.bw.WriteConstrainedInt(8, 0, 10); err != nil {
+			return err
+		}
+		return e.encodeFixName(s)
+	default:
+		if err := e.bw.WriteConstrainedInt(4, 0, 10); err != nil {
+			return err
+		}
+		return e.encodeSizedString(s, 2, 7)
+	}
+}
+
+func (e *Encoder) encodeRemainingFuel(fuel *RemainingFuel) error {
+	if err := e.bw.WriteConstrainedInt(fuel.Hours, 0, 99); err != nil {
+		return fmt.Errorf("fuel hours: %w", err)
+	}
+	if err := e.bw.WriteConstrainedInt(fuel.Minutes, 0, 59); err != nil {
+		return fmt.Errorf("fuel minutes: %w", err)
+	}
+	return nil
+}
+
+func (e *Encoder) encodePositionReport(pr *PositionReport) error {
+	if pr.Position == nil {
+		return errors.New("position report without position")
+	}
+	present := []bool{
+		pr.Time != nil,
+		pr.FixNext != nil,
+		pr.FixNextETA != nil,
+		pr.FixNextPlusOne != nil,
+		pr.Altitude != nil,
+		pr.Speed != nil,
+		pr.Temperature != nil,
+		pr.Wind != nil,
+		pr.Turbulence != "",
+		pr.Icing != "",
+	}
+	for _, p := range present {
+		e.bw.WriteBit(p)
+	}
+
+	if err := e.encodePosition(pr.Position); err != nil {
+		return fmt.Errorf("position: %w", err)
+	}
+	if pr.Time != nil {
+		if err := e.encodeTime(pr.Time); err != nil {
+			return fmt.Errorf("time: %w", err)
+		}
+	}
+	if pr.FixNext != nil {
+		if err := e.encodePosition(pr.FixNext); err != nil {
+			return fmt.Errorf("fixNext: %w", err)
+		}
+	}
+	if pr.FixNextETA != nil {
+		if err := e.encodeTime(pr.FixNextETA); err != nil {
+			return fmt.Errorf("fixNextETA: %w", err)
+		}
+	}
+	if pr.FixNextPlusOne != nil {
+		if err := e.encodePosition(pr.FixNextPlusOne); err != nil {
+			return fmt.Errorf("fixNextPlusOne: %w", err)
+		}
+	}
+	if pr.Altitude != nil {
+		if err := e.encodeAltitude(pr.Altitude); err != nil {
+			return fmt.Errorf("altitude: %w", err)
+		}
+	}
+	if pr.Speed != nil {
+		if err := e.encodeSpeed(pr.Speed); err != nil {
+			return fmt.Errorf("speed: %w", err)
+		}
+	}
+	if pr.Temperature != nil {
+		if err := e.bw.WriteConstrainedInt(*pr.Temperature, -100, 100); err != nil {
+			return fmt.Errorf("temperature: %w", err)
+		}
+	}
+	if pr.Wind != nil {
+		if err := e.encodeWind(pr.Wind); err != nil {
+			return fmt.Errorf("wind: %w", err)
+		}
+	}
+	if pr.Turbulence != "" {
+		if err := e.encodeEnum(pr.Turbulence, severities); err != nil {
+			return fmt.Errorf("turbulence: %w", err)
+		}
+	}
+	if pr.Icing != "" {
+		if err := e.encodeEnum(pr.Icing, severities); err != nil {
+			return fmt.Errorf("icing: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeWind(w *Wind) error {
+	if err := e.bw.WriteConstrainedInt(w.Direction, 0, 359); err != nil {
+		return fmt.Errorf("direction: %w", err)
+	}
+	switch w.Unit {
+	case "kt":
+		return e.writeChoice(0, 1, w.Speed, 0, 255)
+	case "km/h":
+		return e.writeChoice(1, 1, w.Speed, 0, 511)
+	default:
+		return fmt.Errorf("unknown wind unit %q", w.Unit)
+	}
+}
+
+func (e *Encoder) encodeDistance(dist *Distance) error {
+	switch dist.Unit {
+	case "nm":
+		return e.writeChoice(0, 1, dist.Value, 0, 9999)
+	case "km":
+		return e.writeChoice(1, 1, dist.Value, 0, 16000)
+	default:
+		return fmt.Errorf("unknown distance unit %q", dist.Unit)
+	}
+}
+
+func (e *Encoder) encodeToFrom(toFrom string) error {
+	return e.encodeEnum(toFrom, []string{"to", "from"})
+}
@@ -0,0 +1,210 @@
+package cpdlc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/arinc622"
+)
+
+// roundTrip encodes a message and decodes the result.
+func roundTrip(t *testing.T, msg *Message) *Message {
+	t.Helper()
+	data, err := Encode(msg)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got, err := NewDecoder(data, msg.Direction).Decode()
+	if err != nil {
+		t.Fatalf("Decode(%X): %v", data, err)
+	}
+	return got
+}
+
+func TestEncodeRoundTripSamples(t *testing.T) {
+	// Real messages that decode cleanly. The libacars dM48 sample in fuzzSeeds
+	// decodes to out-of-range values, which the encoder rightly rejects.
+	tests := []struct {
+		hex string
+		dir MessageDirection
+	}{
+		{"220012E8294A9528", DirectionUplink}, // uM160 NEXT DATA AUTHORITY RJJJ (/ANCATYA).
+		{"214823E240", DirectionUplink},       // uM137 CONFIRM ASSIGNED ROUTE (/PIKCPYA).
+		{"6184241F01DF74", DirectionDownlink}, // dM1 UNABLE.
+	}
+
+	for _, tt := range tests {
+		data, err := hex.DecodeString(tt.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := NewDecoder(data, tt.dir).Decode()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.hex, err)
+		}
+		encoded, err := Encode(msg)
+		if err != nil {
+			t.Fatalf("%s: Encode: %v", tt.hex, err)
+		}
+		got, err := NewDecoder(encoded, tt.dir).Decode()
+		if err != nil {
+			t.Fatalf("%s: Decode(%X): %v", tt.hex, encoded, err)
+		}
+		if !reflect.DeepEqual(got, msg) {
+			t.Errorf("%s: round trip\n got %s\nwant %s", tt.hex, jsonString(got), jsonString(msg))
+		}
+	}
+}
+
+func TestEncodeRoundTripElements(t *testing.T) {
+	lat, lon := -33.75, 151.5 // Whole minutes, so exact in binary.
+	bearing, distance := 90, 25
+	temp := -52
+
+	tests := []struct {
+		name string
+		msg  *Message
+	}{
+		{"uplink climb with timestamp", &Message{
+			Direction: DirectionUplink,
+			Header:    MessageHeader{MsgID: 12, MsgRef: intPtr(3), Timestamp: &Time{Hours: 14, Minutes: 5, Seconds: 30}},
+			Elements:  []MessageElement{{ID: 20, Data: &Altitude{Type: "flight_level", Value: 350}}},
+		}},
+		{"uplink route clearance", &Message{
+			Direction: DirectionUplink,
+			Elements: []MessageElement{{ID: 80, Data: &RouteClearance{
+				AirportDeparture:   "YSSY",
+				AirportDestination: "NZAA",
+				RunwayDeparture:    &Runway{Direction: 34, Configuration: "left"},
+				ProcedureDeparture: &ProcedureName{Type: "departure", Name: "KAMPI", Transition: "TESAT"},
+				AirwayIntercept:    "Y42",
+				RouteInformation:   []string{"TESAT", "33.5000,-160.2500", "SY 090/25nm", "L521"},
+			}}},
+		}},
+		{"uplink contact with altimeter", &Message{
+			Direction: DirectionUplink,
+			Header:    MessageHeader{MsgID: 1},
+			Elements: []MessageElement{
+				{ID: 117, Data: map[string]interface{}{"unit": "KZAK", "frequency": &Frequency{Type: "hf", Value: 8891}}},
+				{ID: 153, Data: map[string]interface{}{"type": "hpa", "value": 1013.2}},
+				{ID: 123, Data: &BeaconCode{Code: "7342"}},
+				{ID: 169, Data: &FreeText{Text: "WHEN ABLE REPORT POSITION"}},
+			},
+		}},
+		{"downlink position report", &Message{
+			Direction: DirectionDownlink,
+			Header:    MessageHeader{MsgID: 40},
+			Elements: []MessageElement{{ID: 48, Data: &PositionReport{
+				Position:    &Position{Type: "latlon", Latitude: &lat, Longitude: &lon},
+				Time:        &Time{Hours: 3, Minutes: 12},
+				FixNext:     &Position{Type: "place_bearing_distance", Name: "SY", Bearing: &bearing, Distance: &distance, DistanceUnit: "nm"},
+				FixNextETA:  &Time{Hours: 3, Minutes: 40},
+				Altitude:    &Altitude{Type: "feet", Value: 37000},
+				Speed:       &Speed{Type: "mach", Value: 82},
+				Temperature: &temp,
+				Wind:        &Wind{Direction: 270, Speed: 85, Unit: "kt"},
+				Turbulence:  "light",
+			}}},
+		}},
+		{"downlink mayday", &Message{
+			Direction: DirectionDownlink,
+			Elements: []MessageElement{
+				{ID: 56},
+				{ID: 57, Data: map[string]interface{}{
+					"remaining_fuel":   &RemainingFuel{Hours: 1, Minutes: 45},
+					"persons_on_board": &PersonsOnBoard{Count: 214},
+				}},
+				{ID: 80, Data: &DistanceOffset{Distance: 20, Unit: "nm", Direction: "right"}},
+			},
+		}},
+		{"downlink request at time", &Message{
+			Direction: DirectionDownlink,
+			Elements: []MessageElement{{ID: 78, Data: map[string]interface{}{
+				"time":     &Time{Hours: 22, Minutes: 10},
+				"distance": &Distance{Value: 120, Unit: "nm"},
+				"to_from":  "from",
+				"position": &Position{Type: "airport", Name: "YMML"},
+			}}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := roundTrip(t, tt.msg)
+			if len(got.Elements) != len(tt.msg.Elements) {
+				t.Fatalf("decoded %d elements, want %d", len(got.Elements), len(tt.msg.Elements))
+			}
+			if !reflect.DeepEqual(got.Header, tt.msg.Header) {
+				t.Errorf("header = %+v, want %+v", got.Header, tt.msg.Header)
+			}
+			for i, elem := range got.Elements {
+				want := tt.msg.Elements[i]
+				if elem.ID != want.ID || jsonString(elem.Data) != jsonString(want.Data) {
+					t.Errorf("element %d = %d %s, want %d %s", i, elem.ID, jsonString(elem.Data), want.ID, jsonString(want.Data))
+				}
+			}
+		})
+	}
+}
+
+// TestEncodeGoldenVector builds a complete message text, as used for golden
+// test vectors, and checks the parser reads it back.
+func TestEncodeGoldenVector(t *testing.T) {
+	data, err := Encode(&Message{
+		Direction: DirectionDownlink,
+		Header:    MessageHeader{MsgID: 7},
+		Elements:  []MessageElement{{ID: 6, Data: &Altitude{Type: "flight_level", Value: 380}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := arinc622.Format("ANCATYA", arinc622.IMIAT1, "N514DN", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := (&Parser{}).Parse(&acars.Message{ID: 1, Label: "AA", Text: text})
+	r, ok := result.(*Result)
+	if !ok || r.Error != "" {
+		t.Fatalf("Parse(%q) = %+v", text, result)
+	}
+	if r.Registration != "N514DN" || r.Header == nil || r.Header.MsgID != 7 || r.FormattedText != "REQUEST FL380" {
+		t.Errorf("Parse(%q) = %+v", text, r)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *Message
+		want error
+	}{
+		{"no elements", &Message{Direction: DirectionDownlink}, ErrValueOutOfRange},
+		{"element ID", &Message{Direction: DirectionDownlink, Elements: []MessageElement{{ID: 129}}}, ErrValueOutOfRange},
+		{"flight level", &Message{Direction: DirectionUplink, Elements: []MessageElement{{ID: 20, Data: &Altitude{Type: "flight_level", Value: 700}}}}, ErrValueOutOfRange},
+		{"route element", &Message{Direction: DirectionUplink, Elements: []MessageElement{{ID: 80, Data: &RouteClearance{RouteInformation: []string{"(track-detail)"}}}}}, ErrNotEncodable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Encode(tt.msg); !errors.Is(err, tt.want) {
+				t.Errorf("Encode error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Data of the wrong type is reported rather than encoded.
+	if _, err := Encode(&Message{Direction: DirectionUplink, Elements: []MessageElement{{ID: 20, Data: &Speed{Type: "mach", Value: 80}}}}); err == nil {
+		t.Error("expected an error for speed data on an altitude element")
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func jsonString(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,258 @@
+package cpdlc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/patterns"
+)
+
+// Kinds of free text content.
+const (
+	ContentRequest        = "request"         // A request worded as free text, e.g. REQUEST CLIMB FL380.
+	ContentPositionReport = "position_report" // A voice-style position report.
+	ContentClearance      = "clearance"       // A route clearance, such as an oceanic clearance.
+)
+
+// Requests read from free text.
+const (
+	RequestClimb            = "climb"
+	RequestDescent          = "descent"
+	RequestLevel            = "level"
+	RequestDirect           = "direct"
+	RequestSpeed            = "speed"
+	RequestOffset           = "offset"
+	RequestWeatherDeviation = "weather_deviation"
+)
+
+// FreeTextContent is structured data read from the text of a free text
+// element (dM67, dM68, uM169, uM170, and the ATN free text elements), which
+// crews and controllers use for what the message set has no element for, or
+// in its place.
+type FreeTextContent struct {
+	Kind    string `json:"kind"`              // ContentRequest, ContentPositionReport or ContentClearance.
+	Request string `json:"request,omitempty"` // What is requested, for requests.
+
+	Altitude *Altitude       `json:"altitude,omitempty"`
+	Speed    *Speed          `json:"speed,omitempty"`
+	Offset   *DistanceOffset `json:"offset,omitempty"`
+
+	// Position is the position reported, or the fix a direct routing is
+	// requested to; Time is when the aircraft was there.
+	Position *Position `json:"position,omitempty"`
+	Time     *Time     `json:"time,omitempty"`
+	// NextFix and NextETA are the estimate for the next position, and
+	// NextPlusOne the one after, of a position report.
+	NextFix     *Position `json:"next_fix,omitempty"`
+	NextETA     *Time     `json:"next_eta,omitempty"`
+	NextPlusOne *Position `json:"next_plus_one,omitempty"`
+
+	// Destination and Route are those of a clearance, the route as the
+	// waypoints and airways given after VIA.
+	Destination string   `json:"destination,omitempty"`
+	Route       []string `json:"route,omitempty"`
+}
+
+// A point in free text: a coordinate in any route encoding (N5530W02000,
+// 5530N02000W, 55N020W, 5520N), with the latitude and longitude possibly
+// apart, or a fix name.
+const pointPattern = `(?:[NS]\d{2,4}(?:\.\d)?[ /]?[EW]\d{3,5}(?:\.\d)?|\d{2,4}[NS][ /]?\d{3,5}[EW]|\d{2}[NESW]\d{2}|\d{4}[NESW]|[A-Z]{2,5})`
+
+var (
+	// requestRe matches the start of a request.
+	requestRe = regexp.MustCompile(`^(?:PILOTS?\s+)?(?:REQUEST|REQ|RQST|RQ)\b\s*`)
+	// levelRe matches a flight level, or an altitude in feet.
+	levelRe = regexp.MustCompile(`\b(?:FL|F)\s?(\d{2,3})\b|\b(\d{4,5})\s?(?:FT|FEET)\b`)
+	// machRe matches a Mach number, M.82, M082 or MACH .82.
+	machRe = regexp.MustCompile(`\b(?:MACH|M)\s?\.?0?(\d{2,3})\b`)
+	// knotsRe matches a speed in knots.
+	knotsRe = regexp.MustCompile(`\b(\d{3})\s?(?:KTS?|KNOTS)\b`)
+	// offsetRe matches a distance left or right of route.
+	offsetRe = regexp.MustCompile(`\b(\d{1,3})\s?(NM|KM)\s+(?:TO\s+THE\s+)?(LEFT|RIGHT|L|R)\b`)
+	// directRe matches the fix of a direct routing.
+	directRe = regexp.MustCompile(`\b(?:DIRECT|DCT|DIR)\s+(?:TO\s+)?(` + pointPattern + `)\b`)
+
+	// positionRe matches a position report: the position and time, the
+	// level, and the estimate for the next position and the one after.
+	positionRe = regexp.MustCompile(`^(?:POS(?:ITION)?|PSN)\s+(` + pointPattern + `)\s+(?:AT\s+)?(\d{4})Z?` +
+		`(?:\s+(?:FL|F)\s?(\d{3}))?` +
+		`(?:\s+(?:EST|ESTIMATE|ESTIMATING)\s+(` + pointPattern + `)\s+(?:AT\s+)?(\d{4})Z?)?` +
+		`(?:\s+(?:NEXT|THEN)\s+(` + pointPattern + `))?`)
+
+	// clearanceRe matches the destination of a clearance.
+	clearanceRe = regexp.MustCompile(`\b(?:CLRD|CLEARED)\s+(?:TO\s+)?([A-Z]{4})\b`)
+	// viaRe matches the route of a clearance, up to the level, speed or end.
+	viaRe = regexp.MustCompile(`\bVIA\s+(.+?)(?:\s+(?:FM|FROM|MAINTAIN|MNTN|CLIMB|DESCEND|CROSS|FL\s?\d|F\d{3}|M\.?\d{2,3}\b|MACH)|$)`)
+)
+
+// notDestinations are words that follow CLEARED TO but are not airports.
+var notDestinations = map[string]bool{"LAND": true, "JOIN": true, "HOLD": true, "TAXI": true, "PUSH": true}
+
+// ReadFreeText reads a request, position report or clearance from free text,
+// or returns nil if the text is none of these.
+func ReadFreeText(text string) *FreeTextContent {
+	text = strings.Join(strings.Fields(strings.ToUpper(text)), " ")
+	if text == "" {
+		return nil
+	}
+	if loc := requestRe.FindStringIndex(text); loc != nil {
+		return readRequest(text[loc[1]:])
+	}
+	if m := positionRe.FindStringSubmatch(text); m != nil {
+		return readPositionReport(m)
+	}
+	if m := clearanceRe.FindStringSubmatch(text); m != nil && !notDestinations[m[1]] {
+		return readClearance(text, m[1])
+	}
+	return nil
+}
+
+// readRequest reads what is requested from the text after REQUEST.
+func readRequest(text string) *FreeTextContent {
+	c := &FreeTextContent{Kind: ContentRequest}
+	c.Altitude = findLevel(text)
+
+	switch first := strings.SplitN(text, " ", 2)[0]; {
+	case first == "CLIMB" || first == "CLB":
+		c.Request = RequestClimb
+	case first == "DESCENT" || first == "DESCEND" || first == "DES" || first == "DESC":
+		c.Request = RequestDescent
+	case first == "WX" || first == "WEATHER":
+		c.Request = RequestWeatherDeviation
+		c.Offset = findOffset(text)
+	case first == "OFFSET":
+		c.Request = RequestOffset
+		c.Offset = findOffset(text)
+	case directRe.MatchString(text):
+		c.Request = RequestDirect
+		c.Position = readPoint(directRe.FindStringSubmatch(text)[1])
+		c.Altitude = nil
+	case machRe.MatchString(text) || knotsRe.MatchString(text):
+		c.Request = RequestSpeed
+		c.Speed = findSpeed(text)
+		c.Altitude = nil
+	case c.Altitude != nil:
+		c.Request = RequestLevel
+	default:
+		return nil
+	}
+	if c.Request == RequestWeatherDeviation || c.Request == RequestOffset {
+		c.Altitude = nil
+	}
+	if c.Position != nil && c.Position.Name == "TO" {
+		return nil // DIRECT TO with no fix.
+	}
+	return c
+}
+
+func readPositionReport(m []string) *FreeTextContent {
+	c := &FreeTextContent{
+		Kind:     ContentPositionReport,
+		Position: readPoint(m[1]),
+		Time:     readTime(m[2]),
+	}
+	if m[3] != "" {
+		fl, _ := strconv.Atoi(m[3])
+		c.Altitude = &Altitude{Type: "flight_level", Value: fl}
+	}
+	if m[4] != "" {
+		c.NextFix = readPoint(m[4])
+		c.NextETA = readTime(m[5])
+	}
+	if m[6] != "" {
+		c.NextPlusOne = readPoint(m[6])
+	}
+	if c.Time == nil || (m[5] != "" && c.NextETA == nil) {
+		return nil
+	}
+	return c
+}
+
+func readClearance(text, destination string) *FreeTextContent {
+	c := &FreeTextContent{Kind: ContentClearance, Destination: destination}
+	if m := viaRe.FindStringSubmatch(text); m != nil {
+		c.Route = strings.FieldsFunc(m[1], func(r rune) bool {
+			return r == ' ' || r == '/' || r == '.'
+		})
+	}
+	c.Altitude = findLevel(text)
+	c.Speed = findSpeed(text)
+	return c
+}
+
+// readPoint reads a point as a position, with its coordinates when it is
+// given as a coordinate.
+func readPoint(s string) *Position {
+	compact := strings.NewReplacer(" ", "", "/", "").Replace(s)
+	if lat, lon, ok := patterns.ParseRouteCoord(compact); ok {
+		return &Position{Type: "latlon", Name: compact, Latitude: &lat, Longitude: &lon}
+	}
+	return &Position{Type: "fix", Name: s}
+}
+
+// readTime reads an HHMM time, or returns nil.
+func readTime(s string) *Time {
+	if len(s) != 4 {
+		return nil
+	}
+	h, _ := strconv.Atoi(s[:2])
+	m, _ := strconv.Atoi(s[2:])
+	if h > 23 || m > 59 {
+		return nil
+	}
+	return &Time{Hours: h, Minutes: m}
+}
+
+func findLevel(text string) *Altitude {
+	m := levelRe.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	if m[1] != "" {
+		fl, _ := strconv.Atoi(m[1])
+		return &Altitude{Type: "flight_level", Value: fl}
+	}
+	ft, _ := strconv.Atoi(m[2])
+	return &Altitude{Type: "feet", Value: ft}
+}
+
+// findSpeed finds a Mach number (scaled by 100, as Speed holds it) or a
+// speed in knots.
+func findSpeed(text string) *Speed {
+	if m := machRe.FindStringSubmatch(text); m != nil {
+		v, _ := strconv.Atoi(m[1])
+		if len(m[1]) == 3 {
+			v /= 10
+		}
+		return &Speed{Type: "mach", Value: v}
+	}
+	if m := knotsRe.FindStringSubmatch(text); m != nil {
+		v, _ := strconv.Atoi(m[1])
+		return &Speed{Type: "knots", Value: v}
+	}
+	return nil
+}
+
+func findOffset(text string) *DistanceOffset {
+	m := offsetRe.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	d, _ := strconv.Atoi(m[1])
+	dir := "left"
+	if m[3] == "RIGHT" || m[3] == "R" {
+		dir = "right"
+	}
+	return &DistanceOffset{Distance: d, Unit: strings.ToLower(m[2]), Direction: dir}
+}
+
+// readFreeTextElements attaches the content of each free text element that
+// has any.
+func readFreeTextElements(elements []MessageElement) {
+	for _, e := range elements {
+		if ft, ok := e.Data.(*FreeText); ok && ft != nil {
+			ft.Content = ReadFreeText(ft.Text)
+		}
+	}
+}
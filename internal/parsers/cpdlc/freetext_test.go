@@ -0,0 +1,65 @@
+package cpdlc
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/arinc622"
+)
+
+func TestReadFreeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // JSON of the content, or "null".
+	}{
+		{"REQUEST CLIMB FL380 DUE WEATHER", `{"kind":"request","request":"climb","altitude":{"type":"flight_level","value":380}}`},
+		{"req descent to fl 340", `{"kind":"request","request":"descent","altitude":{"type":"flight_level","value":340}}`},
+		{"REQ FL360", `{"kind":"request","request":"level","altitude":{"type":"flight_level","value":360}}`},
+		{"REQUEST DIRECT TO TAPUZ", `{"kind":"request","request":"direct","position":{"type":"fix","name":"TAPUZ"}}`},
+		{"REQUEST DCT 5740N", `{"kind":"request","request":"direct","position":{"type":"latlon","latitude":57,"longitude":-40,"name":"5740N"}}`},
+		{"REQUEST M.84", `{"kind":"request","request":"speed","speed":{"type":"mach","value":84}}`},
+		{"REQUEST 290 KTS", `{"kind":"request","request":"speed","speed":{"type":"knots","value":290}}`},
+		{"REQUEST WEATHER DEVIATION UP TO 20NM LEFT", `{"kind":"request","request":"weather_deviation","offset":{"distance":20,"unit":"nm","direction":"left"}}`},
+		{"POSITION N5530W02000 AT 1215 FL370 ESTIMATING N5640W03000 AT 1301 NEXT N5750W04000",
+			`{"kind":"position_report","altitude":{"type":"flight_level","value":370},"position":{"type":"latlon","latitude":55.5,"longitude":-20,"name":"N5530W02000"},"time":{"hours":12,"minutes":15,"seconds":0},` +
+				`"next_fix":{"type":"latlon","latitude":56.666666666666664,"longitude":-30,"name":"N5640W03000"},"next_eta":{"hours":13,"minutes":1,"seconds":0},"next_plus_one":{"type":"latlon","latitude":57.833333333333336,"longitude":-40,"name":"N5750W04000"}}`},
+		{"POS TESAT 0912 F350", `{"kind":"position_report","altitude":{"type":"flight_level","value":350},"position":{"type":"fix","name":"TESAT"},"time":{"hours":9,"minutes":12,"seconds":0}}`},
+		{"CLRD TO EGLL VIA 5720N/5820N 59N020W BURAK FL350 M082",
+			`{"kind":"clearance","altitude":{"type":"flight_level","value":350},"speed":{"type":"mach","value":82},"destination":"EGLL","route":["5720N","5820N","59N020W","BURAK"]}`},
+		{"CLEARED TO LAND RWY 27L", "null"},
+		{"WHEN CAN WE EXPECT HIGHER", "null"},
+		{"REQUEST", "null"},
+		{"REQUEST DIRECT TO", "null"},
+		{"POS TESAT 2599", "null"},
+		{"", "null"},
+	}
+	for _, tt := range tests {
+		if got := jsonString(ReadFreeText(tt.text)); got != tt.want {
+			t.Errorf("ReadFreeText(%q)\n got %s\nwant %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseFreeTextContent(t *testing.T) {
+	data, err := Encode(&Message{
+		Direction: DirectionDownlink,
+		Header:    MessageHeader{MsgID: 3},
+		Elements:  []MessageElement{{ID: 67, Data: &FreeText{Text: "REQUEST CLIMB FL380 DUE WEATHER"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := arinc622.Format("ANCATYA", arinc622.IMIAT1, "N514DN", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, ok := (&Parser{}).Parse(&acars.Message{ID: 1, Label: "AA", Text: text}).(*Result)
+	if !ok || r.Error != "" || len(r.Elements) != 1 {
+		t.Fatalf("Parse(%q) = %+v", text, r)
+	}
+	ft, ok := r.Elements[0].Data.(*FreeText)
+	if !ok || ft.Content == nil || ft.Content.Request != RequestClimb || ft.Content.Altitude.Value != 380 {
+		t.Errorf("free text = %s", jsonString(r.Elements[0].Data))
+	}
+}
@@ -0,0 +1,68 @@
+package cpdlc
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/arinc622"
+)
+
+// fuzzSeeds are real CPDLC messages with valid CRCs.
+var fuzzSeeds = []string{
+	"/SOUCAYA.AT1.HL8251243F880C3D903BB412903604FE326C2479F4A64F7F62528B1A9CF8382738186AC28B16668E013DF464D8A7F0",
+	"/ANCATYA.AT1.N514DN220012E8294A952882D8",
+	"/PIKCPYA.AT1.F-GSQC214823E24092E7",
+}
+
+// FuzzDecoder checks that the bit-level decoder never panics and keeps its
+// output within the bounds of the message set. Run it with
+//
+//	go test ./internal/parsers/cpdlc -run '^$' -fuzz FuzzDecoder
+func FuzzDecoder(f *testing.F) {
+	for _, text := range fuzzSeeds {
+		res, err := arinc622.Parse(text)
+		if err != nil {
+			f.Fatalf("seed %q: %v", text, err)
+		}
+		f.Add(res.Payload, true)
+		f.Add(res.Payload, false)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, uplink bool) {
+		dir := DirectionDownlink
+		if uplink {
+			dir = DirectionUplink
+		}
+		msg, err := NewDecoder(data, dir).Decode()
+		if err != nil {
+			return
+		}
+		// A primary element plus at most four more.
+		if len(msg.Elements) < 1 || len(msg.Elements) > 5 {
+			t.Fatalf("decoded %d elements", len(msg.Elements))
+		}
+		for _, elem := range msg.Elements {
+			if ft, ok := elem.Data.(*FreeText); ok && len(ft.Text) > maxFreeTextLength {
+				t.Fatalf("free text of %d characters", len(ft.Text))
+			}
+		}
+		_ = formatMessage(msg)
+	})
+}
+
+// FuzzParser runs whole messages through the parser, covering the ARINC
+// envelope and CRC check as well as the decoder.
+func FuzzParser(f *testing.F) {
+	for _, text := range fuzzSeeds {
+		f.Add(text)
+	}
+
+	p := &Parser{}
+	f.Fuzz(func(t *testing.T, text string) {
+		msg := &acars.Message{ID: 1, Label: "AA", Text: text}
+		if !p.QuickCheck(text) {
+			return
+		}
+		_ = p.Parse(msg)
+	})
+}
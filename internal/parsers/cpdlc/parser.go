@@ -1,12 +1,13 @@
 package cpdlc
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
 
 	"acars_parser/internal/acars"
-	"acars_parser/internal/parsers/arinc"
+	"acars_parser/internal/arinc622"
 	"acars_parser/internal/registry"
 )
 
@@ -24,6 +25,7 @@ type Result struct {
 	Timestamp     string           `json:"timestamp"`
 	MessageType   string           `json:"message_type"` // "cpdlc", "connect_request", "connect_confirm", "disconnect".
 	Direction     string           `json:"direction"`    // "uplink" or "downlink".
+	Standard      Standard         `json:"standard,omitempty"`
 	GroundStation string           `json:"ground_station,omitempty"`
 	Registration  string           `json:"registration,omitempty"`
 	Header        *MessageHeader   `json:"header,omitempty"`
@@ -47,6 +49,11 @@ func (p *Parser) Name() string     { return "cpdlc" }
 func (p *Parser) Labels() []string { return []string{"AA", "BA"} }
 func (p *Parser) Priority() int    { return 50 } // Higher priority than generic parsers.
 
+// Version 2 added the content of free text elements.
+func (p *Parser) Version() int { return 2 }
+
+func (p *Parser) Anchors() []string { return []string{IMI_AT1, IMI_CR1, IMI_CC1, IMI_DR1} }
+
 // QuickCheck checks if the message contains CPDLC markers.
 func (p *Parser) QuickCheck(text string) bool {
 	return strings.Contains(text, IMI_AT1) ||
@@ -75,14 +82,16 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 	result.Direction = determineDirection(msg)
 
 	// Parse through ARINC layer (validates CRC, extracts payload).
-	arincResult, err := arinc.Parse(text)
+	arincResult, err := arinc622.Parse(text)
 	if err != nil {
 		// Categorise the error type.
-		if errors.Is(err, arinc.ErrCRCFailed) {
+		if errors.Is(err, arinc622.ErrCRCFailed) {
 			result.Error = "crc_failed"
-		} else if errors.Is(err, arinc.ErrTooShort) {
+		} else if errors.Is(err, arinc622.ErrTooShort) {
 			result.Error = "message_too_short"
-		} else if errors.Is(err, arinc.ErrUnknownFormat) {
+		} else if errors.Is(err, arinc622.ErrTooLong) {
+			result.Error = "message_too_long"
+		} else if errors.Is(err, arinc622.ErrUnknownFormat) {
 			return nil // Not an ARINC message, let other parsers handle it.
 		} else {
 			result.Error = "parse_failed: " + err.Error()
@@ -96,13 +105,13 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 
 	// Determine message type from IMI.
 	switch arincResult.IMI {
-	case arinc.IMIAT1:
+	case arinc622.IMIAT1:
 		result.MessageType = "cpdlc"
-	case arinc.IMICR1:
+	case arinc622.IMICR1:
 		result.MessageType = "connect_request"
-	case arinc.IMICC1:
+	case arinc622.IMICC1:
 		result.MessageType = "connect_confirm"
-	case arinc.IMIDR1:
+	case arinc622.IMIDR1:
 		result.MessageType = "disconnect"
 	default:
 		result.MessageType = "unknown"
@@ -133,6 +142,7 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 
 	result.Header = &cpdlcMsg.Header
 	result.Elements = cpdlcMsg.Elements
+	readFreeTextElements(result.Elements)
 
 	// Format the human-readable text.
 	result.FormattedText = formatMessage(cpdlcMsg)
@@ -140,6 +150,46 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 	return result
 }
 
+// ParseATN decodes an ATN B1 CPDLC APDU carried over VDL2 for the message's
+// aircraft. ATN messages have no ARINC envelope, so the caller supplies the
+// APDU; the ground station and registration are left to the caller too.
+func (p *Parser) ParseATN(msg *acars.Message, apdu []byte) *Result {
+	result := &Result{
+		MsgID:       int64(msg.ID),
+		Timestamp:   msg.Timestamp,
+		MessageType: "cpdlc",
+		Direction:   determineDirection(msg),
+		Standard:    StandardATNB1,
+		RawHex:      strings.ToUpper(hex.EncodeToString(apdu)),
+	}
+	if len(apdu) == 0 {
+		result.Error = "decode_failed: no payload data"
+		return result
+	}
+
+	direction := DirectionDownlink
+	if result.Direction == "uplink" {
+		direction = DirectionUplink
+	}
+
+	cpdlcMsg, err := NewATNDecoder(apdu, direction).Decode()
+	if cpdlcMsg == nil {
+		result.Error = "decode_failed: " + err.Error()
+		return result
+	}
+	if err != nil {
+		// The elements before the unsupported one are still reported.
+		result.Error = "unsupported_element"
+	}
+
+	result.Header = &cpdlcMsg.Header
+	result.Elements = cpdlcMsg.Elements
+	readFreeTextElements(result.Elements)
+	result.FormattedText = formatMessage(cpdlcMsg)
+
+	return result
+}
+
 // determineDirection determines the message direction using available indicators.
 // Priority: LinkDirection > BlockID > Label.
 func determineDirection(msg *acars.Message) string {
@@ -229,7 +279,7 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 	})
 
 	// Try ARINC layer parsing.
-	arincResult, err := arinc.Parse(text)
+	arincResult, err := arinc622.Parse(text)
 	arincOK := err == nil
 
 	trace.Extractors = append(trace.Extractors, registry.Extractor{
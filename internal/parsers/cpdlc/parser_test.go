@@ -192,7 +192,7 @@ func TestConstrainedInt(t *testing.T) {
 	}
 }
 
-// Note: TestIsValidHex and TestSplitRegistrationAndData moved to internal/parsers/arinc package.
+// Note: TestIsValidHex and TestSplitRegistrationAndData moved to internal/arinc622 package.
 
 func TestDecodeElementID(t *testing.T) {
 	// Test that specific hex data decodes to the expected element ID.
@@ -0,0 +1,70 @@
+package cpdlc
+
+// SchemaVariants lists the values an element's Data holds, for the result
+// schema (see internal/schema). Elements with a single value hold it as one
+// of the types here, or as a string (an ATIS code or facility designation) or
+// a version number. Elements combining several values, and altimeter settings,
+// hold a map, keyed as elementFields and altimeter describe.
+func (MessageElement) SchemaVariants() map[string][]interface{} {
+	return map[string][]interface{}{
+		"data": {
+			(*Altitude)(nil),
+			(*BlockLevel)(nil),
+			(*Time)(nil),
+			(*Position)(nil),
+			(*Speed)(nil),
+			(*Degrees)(nil),
+			(*DistanceOffset)(nil),
+			(*Frequency)(nil),
+			(*BeaconCode)(nil),
+			(*ErrorInfo)(nil),
+			(*FreeText)(nil),
+			(*VerticalRate)(nil),
+			(*RouteClearance)(nil),
+			(*ProcedureName)(nil),
+			(*PositionReport)(nil),
+			"",
+			0,
+			(*elementFields)(nil),
+			(*altimeter)(nil),
+		},
+	}
+}
+
+// elementFields describes the map an element combining several values holds,
+// such as uM46 CROSS [position] AT [altitude]. Each element has only the keys
+// of its own values; the decoders build the map, and this type is not used
+// beyond the schema.
+type elementFields struct {
+	Time           *Time           `json:"time,omitempty"`
+	Altitude       interface{}     `json:"altitude,omitempty"` // *Altitude, or *BlockLevel in ATN B1.
+	Altitude1      *Altitude       `json:"altitude1,omitempty"`
+	Altitude2      *Altitude       `json:"altitude2,omitempty"`
+	Speed1         *Speed          `json:"speed1,omitempty"`
+	Speed2         *Speed          `json:"speed2,omitempty"`
+	Position       *Position       `json:"position,omitempty"`
+	RouteClearance *RouteClearance `json:"route_clearance,omitempty"`
+	Procedure      *ProcedureName  `json:"procedure,omitempty"`
+	Direction      string          `json:"direction,omitempty"`
+	Degrees        *Degrees        `json:"degrees,omitempty"`
+	Unit           interface{}     `json:"unit,omitempty"` // ICAO unit name, or *UnitName in ATN B1.
+	Frequency      *Frequency      `json:"frequency,omitempty"`
+	RemainingFuel  *RemainingFuel  `json:"remaining_fuel,omitempty"`
+	PersonsOnBoard *PersonsOnBoard `json:"persons_on_board,omitempty"`
+	DistanceOffset *DistanceOffset `json:"distance_offset,omitempty"`
+	Distance       *Distance       `json:"distance,omitempty"`
+	ToFrom         string          `json:"to_from,omitempty"` // "to" or "from".
+}
+
+func (elementFields) SchemaVariants() map[string][]interface{} {
+	return map[string][]interface{}{
+		"altitude": {(*Altitude)(nil), (*BlockLevel)(nil)},
+		"unit":     {"", (*UnitName)(nil)},
+	}
+}
+
+// altimeter describes the map an altimeter setting (uM153) is held in.
+type altimeter struct {
+	Type  string  `json:"type"`  // "inhg" or "hpa".
+	Value float64 `json:"value"` // Inches of mercury, or hectopascals.
+}
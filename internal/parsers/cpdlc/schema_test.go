@@ -0,0 +1,89 @@
+package cpdlc
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestSchemaVariants decodes the data of every element from random bits and
+// checks that the result schema lists what the decoders returned.
+func TestSchemaVariants(t *testing.T) {
+	variants := map[reflect.Type]bool{}
+	for _, v := range (MessageElement{}).SchemaVariants()["data"] {
+		variants[reflect.TypeOf(v)] = true
+	}
+	fields := jsonFields(reflect.TypeOf(elementFields{}))
+	for k, vs := range (elementFields{}).SchemaVariants() {
+		for _, v := range vs {
+			fields[k] = append(fields[k], reflect.TypeOf(v))
+		}
+	}
+	altimeterFields := jsonFields(reflect.TypeOf(altimeter{}))
+
+	check := func(name string, data interface{}) {
+		if data == nil || reflect.ValueOf(data).Kind() == reflect.Pointer && reflect.ValueOf(data).IsNil() {
+			return
+		}
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			if !variants[reflect.TypeOf(data)] {
+				t.Errorf("%s: data of type %T is not in SchemaVariants", name, data)
+			}
+			return
+		}
+		for k, v := range m {
+			types, ok := fields[k]
+			if !ok {
+				types, ok = altimeterFields[k]
+			}
+			if !ok {
+				t.Errorf("%s: data key %q is not a field of elementFields", name, k)
+				continue
+			}
+			listed := false
+			for _, typ := range types {
+				listed = listed || reflect.TypeOf(v) == typ
+			}
+			if !listed {
+				t.Errorf("%s: data key %q holds a %T, not one of %v", name, k, v, types)
+			}
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	buf := make([]byte, 64)
+	for i := 0; i < 20; i++ {
+		rng.Read(buf)
+		for id := 0; id <= 255; id++ {
+			for _, dir := range []MessageDirection{DirectionUplink, DirectionDownlink} {
+				prefix := "uM"
+				decode := NewDecoder(buf, dir).decodeUplinkData
+				atn := NewATNDecoder(buf, dir).decodeUplinkData
+				if dir == DirectionDownlink {
+					prefix = "dM"
+					decode = NewDecoder(buf, dir).decodeDownlinkData
+					atn = NewATNDecoder(buf, dir).decodeDownlinkData
+				}
+				if data, err := decode(id); err == nil {
+					check("FANS "+prefix+strconv.Itoa(id), data)
+				}
+				if data, err := atn(id); err == nil {
+					check("ATN "+prefix+strconv.Itoa(id), data)
+				}
+			}
+		}
+	}
+}
+
+// jsonFields returns the types of a struct's fields by JSON name.
+func jsonFields(t reflect.Type) map[string][]reflect.Type {
+	fields := map[string][]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = []reflect.Type{t.Field(i).Type}
+	}
+	return fields
+}
@@ -30,6 +30,10 @@ type MessageHeader struct {
 	MsgID     int   `json:"msg_id"`              // Message identification number.
 	MsgRef    *int  `json:"msg_ref,omitempty"`   // Reference number (optional).
 	Timestamp *Time `json:"timestamp,omitempty"` // Timestamp (optional).
+
+	// ATN B1 only.
+	Date       string `json:"date,omitempty"`        // Message date, YYYY-MM-DD.
+	LogicalAck string `json:"logical_ack,omitempty"` // "required" or "not_required".
 }
 
 // Time represents a FANS timestamp (hours, minutes, seconds).
@@ -283,6 +287,9 @@ func (b *BeaconCode) String() string {
 // FreeText represents free-form text.
 type FreeText struct {
 	Text string `json:"text"`
+	// Content is read from Text when it holds a request, position report or
+	// clearance (see ReadFreeText).
+	Content *FreeTextContent `json:"content,omitempty"`
 }
 
 // ErrorInfo represents CPDLC error information.
@@ -55,6 +55,8 @@ func (p *Parser) Name() string           { return "dispatcher" }
 func (p *Parser) Labels() []string       { return []string{"RA", "25", "H1"} }
 func (p *Parser) Priority() int          { return 45 } // Lower than more specific parsers
 
+func (p *Parser) Anchors() []string { return []string{"DISPATCHER MSG"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	return strings.Contains(text, "DISPATCHER MSG")
 }
@@ -103,6 +103,8 @@ var tailPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`^(SE-[A-Z]{3})`),
 }
 
+func (p *Parser) Anchors() []string { return []string{".AT1.", ".CR1.", ".ADS"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	// Must start with envelope header.
 	return strings.HasPrefix(text, "/") && (strings.Contains(text, ".AT1.") ||
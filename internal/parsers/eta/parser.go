@@ -58,6 +58,8 @@ func (p *Parser) Labels() []string { return []string{"5Z"} }
 func (p *Parser) Priority() int    { return 100 }
 
 
+func (p *Parser) Anchors() []string { return []string{"/ET ", "/IR ", "/B6 ", "/OS ", "/C3 "} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	return strings.Contains(text, "/ET ") ||
 		strings.Contains(text, "/IR ") ||
@@ -0,0 +1,45 @@
+package parsers
+
+import (
+	"flag"
+	"testing"
+
+	"acars_parser/internal/golden"
+	"acars_parser/internal/registry"
+)
+
+var update = flag.Bool("update", false, "Rewrite the expected results of the fixtures in testdata")
+
+// TestFixtures runs each fixture in testdata/<parser>/<case>.json through the
+// parser it is filed under (see golden.Fixture). With -update, the expected
+// results are rewritten from the output instead: review the diff before
+// committing it.
+func TestFixtures(t *testing.T) {
+	fixtures, err := golden.LoadFixtures("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := registry.Default()
+	reg.Sort()
+
+	for _, f := range fixtures {
+		t.Run(f.Parser+"/"+f.Name, func(t *testing.T) {
+			actual, diffs, err := golden.RunFixture(reg, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *update {
+				if len(diffs) > 0 {
+					if err := golden.WriteFixture(f, actual); err != nil {
+						t.Fatal(err)
+					}
+					t.Logf("updated %s", f.Path)
+				}
+				return
+			}
+			for _, d := range diffs {
+				t.Errorf("%s %s: expected %v, got %v", d.Path, d.Kind, d.Expected, d.Actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package freetext classifies readable free-text crew and operations messages
+// (labels 21-23, 5U and the free-text uplinks) by keyword, for messages no
+// other parser understood.
+package freetext
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Categories, in order of precedence when two match equally often.
+const (
+	CategoryMedical     = "medical"
+	CategoryMaintenance = "maintenance"
+	CategoryDelay       = "delay"
+	CategoryCustoms     = "customs"
+	CategoryCatering    = "catering"
+)
+
+// Result is a free-text message tagged with the categories it matched.
+type Result struct {
+	MsgID      int64    `json:"message_id,omitempty"`
+	Timestamp  string   `json:"timestamp,omitempty"`
+	Tail       string   `json:"tail,omitempty"`
+	Category   string   `json:"category"`   // The category with the most keyword matches.
+	Categories []string `json:"categories"` // Every category matched, most matches first.
+	Keywords   []string `json:"keywords"`   // Keywords found, in text order.
+	Text       string   `json:"text"`
+}
+
+func (r *Result) Type() string     { return "free_text" }
+func (r *Result) MessageID() int64 { return r.MsgID }
+
+// rule is the keywords of one category, matched as whole words.
+type rule struct {
+	category string
+	re       *regexp.Regexp
+}
+
+// keywordRule compiles a category's keywords into one whole-word pattern.
+// Spaces in a keyword match any run of whitespace.
+func keywordRule(category string, keywords ...string) rule {
+	alts := make([]string, len(keywords))
+	for i, k := range keywords {
+		alts[i] = strings.ReplaceAll(regexp.QuoteMeta(k), " ", `\s+`)
+	}
+	return rule{category: category, re: regexp.MustCompile(`\b(` + strings.Join(alts, "|") + `)\b`)}
+}
+
+// rules are the keyword rules, in order of precedence.
+var rules = []rule{
+	keywordRule(CategoryMedical,
+		"MEDICAL", "MEDLINK", "MEDAIRE", "STAT MD", "DOCTOR", "PARAMEDIC", "PARAMEDICS",
+		"AMBULANCE", "UNCONSCIOUS", "CHEST PAIN", "SEIZURE", "CARDIAC", "DEFIB", "AED",
+		"ILL PAX", "SICK PAX", "PAX ILL", "PAX SICK", "NURSE"),
+	keywordRule(CategoryMaintenance,
+		"MAINT", "MAINTENANCE", "MX", "MCC", "TECH LOG", "TECHLOG", "DEFECT", "INOP",
+		"U/S", "MEL", "DEFERRAL", "DEFER", "ENGINEER", "ENGINEERING", "FAULT", "SNAG",
+		"WRITE UP", "WRITEUP", "REPAIR", "REPLACE", "LOGBOOK", "ECAM", "EICAS"),
+	keywordRule(CategoryDelay,
+		"DELAY", "DELAYED", "DELAYS", "DLY", "LATE", "SLOT", "CTOT", "NEW ETD",
+		"REVISED ETD", "HOLDING", "GATE HOLD", "WAITING FOR", "AWAITING"),
+	keywordRule(CategoryCustoms,
+		"CUSTOMS", "GENDEC", "GEN DEC", "GENERAL DECLARATION", "IMMIGRATION", "CBP",
+		"APIS", "QUARANTINE", "BIOSECURITY", "PASSPORT", "PASSPORTS", "VISA", "DEPORTEE", "INAD"),
+	keywordRule(CategoryCatering,
+		"CATERING", "CATERER", "MEAL", "MEALS", "SPML", "SPECIAL MEAL", "GALLEY",
+		"BEVERAGE", "BEVERAGES", "DRY ICE", "WATER UPLIFT", "TROLLEY", "TROLLEYS"),
+}
+
+// freeTextLabels are the labels whose unparsed messages are offered to the
+// classifier.
+var freeTextLabels = map[string]bool{
+	"20": true, "21": true, "22": true, "23": true, "5U": true, "RA": true, "C1": true,
+}
+
+// Readability thresholds: the share of letters and spaces in the text, and
+// the number of words of three letters or more.
+const (
+	minLetterRatio = 0.7
+	minWords       = 3
+)
+
+// Parser classifies free-text messages. It is registered as a catch-all, so
+// it only sees messages no other parser matched.
+type Parser struct{}
+
+func init() {
+	registry.RegisterCatchAll(&Parser{})
+}
+
+func (p *Parser) Name() string     { return "free_text" }
+func (p *Parser) Labels() []string { return []string{"20", "21", "22", "23", "5U", "RA", "C1"} }
+func (p *Parser) Priority() int    { return 200 }
+
+func (p *Parser) QuickCheck(text string) bool {
+	return readable(text)
+}
+
+func (p *Parser) Parse(msg *acars.Message) registry.Result {
+	// Catch-all parsers see every label.
+	if !freeTextLabels[msg.Label] {
+		return nil
+	}
+	text := strings.TrimSpace(msg.Text)
+	if !readable(text) {
+		return nil
+	}
+
+	categories, keywords := Classify(text)
+	if len(categories) == 0 {
+		return nil
+	}
+	return &Result{
+		MsgID:      int64(msg.ID),
+		Timestamp:  msg.Timestamp,
+		Tail:       msg.Tail,
+		Category:   categories[0],
+		Categories: categories,
+		Keywords:   keywords,
+		Text:       text,
+	}
+}
+
+// Classify returns the categories whose keywords appear in text, the one with
+// the most matches first and the rest by precedence, and the keywords found
+// in text order.
+func Classify(text string) (categories, keywords []string) {
+	upper := strings.ToUpper(text)
+
+	type hit struct {
+		pos     int
+		keyword string
+	}
+	var hits []hit
+	counts := make(map[string]int)
+	for _, r := range rules {
+		for _, m := range r.re.FindAllStringSubmatchIndex(upper, -1) {
+			hits = append(hits, hit{pos: m[2], keyword: strings.Join(strings.Fields(upper[m[2]:m[3]]), " ")})
+			counts[r.category]++
+		}
+		if counts[r.category] > 0 {
+			categories = append(categories, r.category)
+		}
+	}
+	if len(categories) == 0 {
+		return nil, nil
+	}
+
+	// Stable, so that ties keep their precedence.
+	sort.SliceStable(categories, func(i, j int) bool { return counts[categories[i]] > counts[categories[j]] })
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].pos < hits[j].pos })
+	seen := make(map[string]bool)
+	for _, h := range hits {
+		if !seen[h.keyword] {
+			seen[h.keyword] = true
+			keywords = append(keywords, h.keyword)
+		}
+	}
+	return categories, keywords
+}
+
+// readable reports whether text looks like prose rather than encoded data:
+// mostly letters and spaces, with a few real words.
+func readable(text string) bool {
+	var letters, total, words, wordLen int
+	for _, c := range text {
+		total++
+		switch {
+		case unicode.IsLetter(c):
+			letters++
+			wordLen++
+			continue
+		case unicode.IsSpace(c):
+			letters++
+		}
+		if wordLen >= 3 {
+			words++
+		}
+		wordLen = 0
+	}
+	if wordLen >= 3 {
+		words++
+	}
+	return total > 0 && words >= minWords && float64(letters)/float64(total) >= minLetterRatio
+}
@@ -0,0 +1,101 @@
+package freetext
+
+import (
+	"reflect"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestParser_Parse(t *testing.T) {
+	tests := []struct {
+		name         string
+		label        string
+		text         string
+		wantCategory string
+		wantAll      []string
+		wantKeywords []string
+	}{
+		{
+			name:         "medical",
+			label:        "21",
+			text:         "PAX IN 23C UNCONSCIOUS REQ MEDLINK PATCH AND AMBULANCE ON ARRIVAL",
+			wantCategory: CategoryMedical,
+			wantAll:      []string{CategoryMedical},
+			wantKeywords: []string{"UNCONSCIOUS", "MEDLINK", "AMBULANCE"},
+		},
+		{
+			name:         "maintenance",
+			label:        "22",
+			text:         "PLS ADVISE MX LEFT PACK INOP WILL WRITE IT UP IN TECH LOG",
+			wantCategory: CategoryMaintenance,
+			wantAll:      []string{CategoryMaintenance},
+			wantKeywords: []string{"MX", "INOP", "TECH LOG"},
+		},
+		{
+			name:         "delay with maintenance",
+			label:        "5U",
+			text:         "DEPARTURE DELAYED 40 MIN DUE LATE INBOUND CREW, ALSO AWAITING ENGINEER SIGN OFF",
+			wantCategory: CategoryDelay,
+			wantAll:      []string{CategoryDelay, CategoryMaintenance},
+			wantKeywords: []string{"DELAYED", "LATE", "AWAITING", "ENGINEER"},
+		},
+		{
+			name:         "customs",
+			label:        "23",
+			text:         "gendec sent to ops please confirm customs and immigration cleared",
+			wantCategory: CategoryCustoms,
+			wantAll:      []string{CategoryCustoms},
+			wantKeywords: []string{"GENDEC", "CUSTOMS", "IMMIGRATION"},
+		},
+		{
+			name:         "catering tie keeps precedence",
+			label:        "RA",
+			text:         "CATERING SHORT AT GATE, STAND BY FOR MAINTENANCE",
+			wantCategory: CategoryMaintenance,
+			wantAll:      []string{CategoryMaintenance, CategoryCatering},
+			wantKeywords: []string{"CATERING", "MAINTENANCE"},
+		},
+	}
+
+	parser := &Parser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parser.Parse(&acars.Message{ID: 7, Label: tt.label, Text: tt.text})
+			if result == nil {
+				t.Fatal("expected result, got nil")
+			}
+			r := result.(*Result)
+			if r.Category != tt.wantCategory {
+				t.Errorf("Category = %q, want %q", r.Category, tt.wantCategory)
+			}
+			if !reflect.DeepEqual(r.Categories, tt.wantAll) {
+				t.Errorf("Categories = %v, want %v", r.Categories, tt.wantAll)
+			}
+			if !reflect.DeepEqual(r.Keywords, tt.wantKeywords) {
+				t.Errorf("Keywords = %v, want %v", r.Keywords, tt.wantKeywords)
+			}
+		})
+	}
+}
+
+func TestParser_Rejects(t *testing.T) {
+	parser := &Parser{}
+	tests := []struct {
+		name  string
+		label string
+		text  string
+	}{
+		{"no keywords", "21", "GOOD MORNING FROM THE FLIGHT DECK"},
+		{"encoded", "21", "POSN38338W121179,350,KMHR,KPDX,DELAY,0807,0014"},
+		{"other label", "H1", "DEPARTURE DELAYED 40 MIN DUE LATE INBOUND CREW"},
+		{"keyword inside a word", "21", "SERVICE RELATED LATERAL MEALTIME CHANGE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := parser.Parse(&acars.Message{Label: tt.label, Text: tt.text}); result != nil {
+				t.Errorf("Parse() = %+v, want nil", result)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-// Package fuel parses fuel delivery receipt messages.
+// Package fuel parses fuel delivery receipts and fuel-on-board reports.
 package fuel
 
 import (
@@ -0,0 +1,169 @@
+package fuel
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// OOOI events a fuel figure may be reported at.
+const (
+	EventOut = "OUT" // Off blocks.
+	EventOff = "OFF" // Take-off.
+	EventOn  = "ON"  // Touchdown.
+	EventIn  = "IN"  // On blocks.
+)
+
+// oooiLabels maps the OOOI report labels to their event.
+var oooiLabels = map[string]string{
+	"QP": EventOut,
+	"QQ": EventOff,
+	"QR": EventOn,
+	"QS": EventIn,
+}
+
+// ReportResult is a fuel-on-board or uplift figure from an OOOI or
+// operational message.
+type ReportResult struct {
+	MsgID       int64  `json:"message_id,omitempty"`
+	Timestamp   string `json:"timestamp"`
+	Tail        string `json:"tail,omitempty"`
+	Flight      string `json:"flight,omitempty"`
+	Event       string `json:"event,omitempty"`         // OUT, OFF, ON or IN, when known.
+	FuelOnBoard int    `json:"fuel_on_board,omitempty"` // As reported.
+	Uplift      int    `json:"uplift,omitempty"`        // As reported.
+	Unit        string `json:"unit,omitempty"`          // Unit of the reported figures: kg, lb, t or l.
+	FOBKg       int    `json:"fob_kg,omitempty"`        // Fuel on board in kg; unset if the unit is unknown.
+	UpliftKg    int    `json:"uplift_kg,omitempty"`     // Uplift in kg; unset if the unit is unknown or volumetric.
+}
+
+func (r *ReportResult) Type() string     { return "fuel_report" }
+func (r *ReportResult) MessageID() int64 { return r.MsgID }
+
+// Figures are a number with optional thousands separators or decimals, then
+// an optional unit.
+const (
+	fuelNumber = `(\d{1,3}(?:,\d{3})+|\d+(?:\.\d+)?)`
+	fuelUnit   = `(?:\s*(KGS?|KILOS?|LBS?|POUNDS?|TONNES?|T|LTRS?|LITRES?|L)\b)?`
+)
+
+var (
+	// FOB 12500 KG, FOB/N123, FUEL ON BOARD: 9.8 T, FUEL REM 4500 LBS.
+	fobRe = regexp.MustCompile(`\b(?:FOB|FUEL\s+ON\s+BOARD|FUEL\s+REM(?:AINING)?|BLOCK\s+FUEL)\s*[:/=]?\s*N?` + fuelNumber + fuelUnit)
+	// FUEL 12500 KG. Without a qualifier the unit is required.
+	fuelRe = regexp.MustCompile(`\bFUEL\s*[:=]?\s*` + fuelNumber + `\s*(KGS?|LBS?)\b`)
+	// UPLIFT 8200 KG, FUEL UPLIFT: 10100 L, UPLIFTED 5.2T.
+	upliftRe = regexp.MustCompile(`\b(?:FUEL\s+)?UPLIFT(?:ED)?\s*[:=]?\s*` + fuelNumber + fuelUnit)
+	// OUT 0812, IN/1432.
+	eventRe = regexp.MustCompile(`\b(OUT|OFF|ON|IN)\s*[:/]?\s*\d{4}\b`)
+)
+
+// ReportParser parses fuel figures from OOOI and operational messages.
+type ReportParser struct{}
+
+func init() {
+	registry.Register(&ReportParser{})
+}
+
+func (p *ReportParser) Name() string     { return "fuel_report" }
+func (p *ReportParser) Labels() []string { return []string{"QP", "QQ", "QR", "QS", "5Z"} }
+func (p *ReportParser) Priority() int    { return 90 }
+
+func (p *ReportParser) Anchors() []string { return []string{"FOB", "FUEL", "UPLIFT"} }
+
+func (p *ReportParser) QuickCheck(text string) bool {
+	return strings.Contains(text, "FOB") || strings.Contains(text, "FUEL") || strings.Contains(text, "UPLIFT")
+}
+
+func (p *ReportParser) Parse(msg *acars.Message) registry.Result {
+	if msg.Text == "" {
+		return nil
+	}
+	text := strings.ToUpper(msg.Text)
+
+	result := &ReportResult{
+		MsgID:     int64(msg.ID),
+		Timestamp: msg.Timestamp,
+		Tail:      msg.Tail,
+		Event:     oooiLabels[msg.Label],
+	}
+	if msg.Flight != nil {
+		result.Flight = msg.Flight.Flight
+	}
+	if result.Event == "" {
+		if m := eventRe.FindStringSubmatch(text); m != nil {
+			result.Event = m[1]
+		}
+	}
+
+	var fobUnit, upliftUnit string
+	m := fobRe.FindStringSubmatch(text)
+	if m == nil {
+		m = fuelRe.FindStringSubmatch(text)
+	}
+	if m != nil {
+		result.FuelOnBoard, fobUnit = parseFigure(m[1]), normaliseUnit(m[2])
+		result.FOBKg = toKg(m[1], fobUnit)
+	}
+	if m := upliftRe.FindStringSubmatch(text); m != nil {
+		result.Uplift, upliftUnit = parseFigure(m[1]), normaliseUnit(m[2])
+		result.UpliftKg = toKg(m[1], upliftUnit)
+	}
+
+	if result.FuelOnBoard == 0 && result.Uplift == 0 {
+		return nil
+	}
+	result.Unit = fobUnit
+	if result.FuelOnBoard == 0 {
+		result.Unit = upliftUnit
+	}
+	return result
+}
+
+// normaliseUnit maps the unit spellings in messages to kg, lb, t or l.
+func normaliseUnit(u string) string {
+	switch {
+	case u == "":
+		return ""
+	case strings.HasPrefix(u, "K"):
+		return "kg"
+	case strings.HasPrefix(u, "LB"), strings.HasPrefix(u, "P"):
+		return "lb"
+	case strings.HasPrefix(u, "T"):
+		return "t"
+	default:
+		return "l"
+	}
+}
+
+// parseFigure returns a reported figure rounded to a whole number.
+func parseFigure(s string) int {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return int(math.Round(f))
+}
+
+// toKg converts a figure in a mass unit to kg. It returns 0 for volumes and
+// unknown units, since neither can be converted without more information.
+func toKg(s, unit string) int {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case "kg":
+		return int(math.Round(f))
+	case "lb":
+		return int(math.Round(f * 0.45359237))
+	case "t":
+		return int(math.Round(f * 1000))
+	default:
+		return 0
+	}
+}
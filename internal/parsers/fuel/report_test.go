@@ -0,0 +1,55 @@
+package fuel
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestReportParser_Parse(t *testing.T) {
+	parser := &ReportParser{}
+
+	tests := []struct {
+		name       string
+		label      string
+		text       string
+		wantEvent  string
+		wantFOB    int
+		wantUnit   string
+		wantFOBKg  int
+		wantUplift int
+		wantUpKg   int
+	}{
+		{"OUT report in kg", "QP", "OUT 0812 FOB 12500 KG", "OUT", 12500, "kg", 12500, 0, 0},
+		{"IN report in lbs", "QS", "KJFK IN 1432 FOB/8,400 LBS", "IN", 8400, "lb", 3810, 0, 0},
+		{"tonnes", "QQ", "OFF 0825 FUEL ON BOARD: 12.3 T", "OFF", 12, "t", 12300, 0, 0},
+		{"unit unknown", "QR", "ON 1420 FOB N0045", "ON", 45, "", 0, 0, 0},
+		{"event from text", "5Z", "/IN 1432 FUEL REM 3200 KGS", "IN", 3200, "kg", 3200, 0, 0},
+		{"fuel template", "5Z", "FUEL 9800 KG DEST EGLL", "", 9800, "kg", 9800, 0, 0},
+		{"uplift", "5Z", "FUEL UPLIFT: 8200 KG FOB 11400 KG", "", 11400, "kg", 11400, 8200, 8200},
+		{"uplift in litres", "5Z", "UPLIFT 10100 LTRS", "", 0, "l", 0, 10100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &acars.Message{ID: 1, Label: tt.label, Text: tt.text}
+			r, ok := parser.Parse(msg).(*ReportResult)
+			if !ok {
+				t.Fatalf("Parse(%q) returned no report", tt.text)
+			}
+			if r.Event != tt.wantEvent || r.FuelOnBoard != tt.wantFOB || r.Unit != tt.wantUnit || r.FOBKg != tt.wantFOBKg {
+				t.Errorf("event %q, fob %d %q (%d kg); want %q, %d %q (%d kg)",
+					r.Event, r.FuelOnBoard, r.Unit, r.FOBKg, tt.wantEvent, tt.wantFOB, tt.wantUnit, tt.wantFOBKg)
+			}
+			if r.Uplift != tt.wantUplift || r.UpliftKg != tt.wantUpKg {
+				t.Errorf("uplift %d (%d kg), want %d (%d kg)", r.Uplift, r.UpliftKg, tt.wantUplift, tt.wantUpKg)
+			}
+		})
+	}
+
+	// FUEL without a unit or qualifier is not a fuel figure.
+	for _, text := range []string{"FUEL 1230", "OUT 0812 FUEL OK"} {
+		if r := parser.Parse(&acars.Message{Label: "QP", Text: text}); r != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", text, r)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package h1
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"acars_parser/internal/navdata"
+)
+
+// airways is the optional airway database used to expand FPN routes.
+var airways atomic.Pointer[navdata.Airways]
+
+// SetAirways configures the airway database used to expand airway segments in
+// FPN routes into their intermediate fixes. Pass nil to disable expansion.
+// Without a database, routes list only the waypoints named in the message.
+func SetAirways(db *navdata.Airways) {
+	airways.Store(db)
+}
+
+// routeStep is a waypoint in a route together with the airway flown to reach it.
+type routeStep struct {
+	wpt    RouteWaypoint
+	airway string
+	// from is the preceding waypoint when it is directly adjacent in the route,
+	// so that the airway segment between them can be expanded.
+	from string
+}
+
+// parseRouteSequence splits a route into waypoints and the airways joining them.
+// Segments are separated by ".." and alternate waypoint and airway within a
+// segment: "CUSEK.T349.KNRAD..FEMID.Q102.CIGAR". An airway at the end of a
+// segment leads to the first waypoint of the next: "MUVIN,N31490E035327.L53..TAPUZ".
+func parseRouteSequence(route string) []routeStep {
+	var steps []routeStep
+	var pending, prev string
+
+	for _, segment := range strings.Split(route, "..") {
+		for i, elem := range strings.Split(segment, ".") {
+			if i%2 == 1 {
+				pending = elem
+				continue
+			}
+			wpt := parseWaypointWithCoords(elem)
+			if wpt == nil {
+				// A dropped element (e.g. a speed and level change) breaks the chain.
+				pending, prev = "", ""
+				continue
+			}
+			steps = append(steps, routeStep{wpt: *wpt, airway: pending, from: prev})
+			pending, prev = "", wpt.Name
+		}
+	}
+	return steps
+}
+
+// expandRouteWaypoints returns the route's waypoints with each airway segment
+// expanded into its intermediate fixes. Waypoints without coordinates in the
+// message take them from the airway database where the segment is known.
+func expandRouteWaypoints(route string, db *navdata.Airways) []RouteWaypoint {
+	steps := parseRouteSequence(route)
+	waypoints := make([]RouteWaypoint, 0, len(steps))
+
+	for _, step := range steps {
+		if step.airway != "" && step.from != "" {
+			if path, ok := db.Expand(step.airway, step.from, step.wpt.Name); ok {
+				fillCoords(&waypoints[len(waypoints)-1], path[0])
+				for _, f := range path[1 : len(path)-1] {
+					waypoints = append(waypoints, RouteWaypoint{
+						Name:      f.Ident,
+						Latitude:  f.Latitude,
+						Longitude: f.Longitude,
+						Airway:    step.airway,
+						Expanded:  true,
+					})
+				}
+				fillCoords(&step.wpt, path[len(path)-1])
+			}
+		}
+		step.wpt.Airway = step.airway
+		waypoints = append(waypoints, step.wpt)
+	}
+	return waypoints
+}
+
+// fillCoords sets a waypoint's position from a fix when the message gave none.
+func fillCoords(wpt *RouteWaypoint, f navdata.Fix) {
+	if wpt.Latitude == 0 && wpt.Longitude == 0 {
+		wpt.Latitude, wpt.Longitude = f.Latitude, f.Longitude
+	}
+}
@@ -0,0 +1,126 @@
+package h1
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/navdata"
+)
+
+func TestFPNAirwayExpansion(t *testing.T) {
+	db := navdata.NewAirways()
+	db.Add("T349", []navdata.Fix{
+		{Ident: "CUSEK", Latitude: 26.5, Longitude: -80.9},
+		{Ident: "DUCKS", Latitude: 26.2, Longitude: -80.6},
+		{Ident: "KNRAD", Latitude: 25.9, Longitude: -80.3},
+	})
+	db.Add("Q102", []navdata.Fix{
+		{Ident: "BACCA", Latitude: 29.0, Longitude: -85.5},
+		{Ident: "CIGAR", Latitude: 28.5, Longitude: -84.0},
+		{Ident: "KPASA", Latitude: 27.9, Longitude: -82.6},
+		{Ident: "FEMID", Latitude: 27.1, Longitude: -81.2},
+	})
+
+	msg := &acars.Message{
+		Label: "H1",
+		Text:  "FPN/RP:DA:KMCF:AA:KTIK:F:CUSEK.T349.KNRAD..FEMID.Q102.CIGAR.J58.AEX",
+	}
+	parser := &FPNParser{}
+
+	// Without a database only the first waypoint of each segment is listed.
+	plain := parser.Parse(msg).(*FPNResult)
+	if len(plain.Waypoints) != 2 {
+		t.Fatalf("unexpanded waypoints = %+v, want CUSEK and FEMID", plain.Waypoints)
+	}
+
+	SetAirways(db)
+	t.Cleanup(func() { SetAirways(nil) })
+
+	fp := parser.Parse(msg).(*FPNResult)
+	want := []RouteWaypoint{
+		{Name: "CUSEK", Latitude: 26.5, Longitude: -80.9},
+		{Name: "DUCKS", Latitude: 26.2, Longitude: -80.6, Airway: "T349", Expanded: true},
+		{Name: "KNRAD", Latitude: 25.9, Longitude: -80.3, Airway: "T349"},
+		{Name: "FEMID", Latitude: 27.1, Longitude: -81.2},
+		{Name: "KPASA", Latitude: 27.9, Longitude: -82.6, Airway: "Q102", Expanded: true},
+		{Name: "CIGAR", Latitude: 28.5, Longitude: -84.0, Airway: "Q102"},
+		// J58 is not in the database, so AEX keeps its name only.
+		{Name: "AEX", Airway: "J58"},
+	}
+	if len(fp.Waypoints) != len(want) {
+		t.Fatalf("got %d waypoints %+v, want %d", len(fp.Waypoints), fp.Waypoints, len(want))
+	}
+	for i := range want {
+		if fp.Waypoints[i] != want[i] {
+			t.Errorf("waypoint %d = %+v, want %+v", i, fp.Waypoints[i], want[i])
+		}
+	}
+}
+
+func TestParseRouteSequence(t *testing.T) {
+	steps := parseRouteSequence("MUVIN,N31490E035327.L53..TAPUZ..N25400W080030..N0450F350..VELOX.W13.DESPO")
+	want := []routeStep{
+		{wpt: RouteWaypoint{Name: "MUVIN"}},
+		{wpt: RouteWaypoint{Name: "TAPUZ"}, airway: "L53", from: "MUVIN"},
+		// A bare coordinate is a waypoint named as written.
+		{wpt: RouteWaypoint{Name: "N25400W080030"}, from: "TAPUZ"},
+		// The speed and level change is dropped, so VELOX has no adjacent predecessor.
+		{wpt: RouteWaypoint{Name: "VELOX"}},
+		{wpt: RouteWaypoint{Name: "DESPO"}, airway: "W13", from: "VELOX"},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("got %d steps %+v, want %d", len(steps), steps, len(want))
+	}
+	for i := range want {
+		got := steps[i]
+		if got.wpt.Name != want[i].wpt.Name || got.airway != want[i].airway || got.from != want[i].from {
+			t.Errorf("step %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestFPNProcedureResolution(t *testing.T) {
+	ps := navdata.NewProcedures()
+	ps.Add(&navdata.Procedure{
+		Airport:            "KDFW",
+		Ident:              "KATZZ2",
+		Kind:               navdata.SID,
+		Common:             []string{"KATZZ", "HRPER"},
+		RunwayTransitions:  map[string][]string{"17R": {"DEBBB"}},
+		EnrouteTransitions: map[string][]string{"BRHMA": {"HRPER", "BRHMA"}},
+	})
+	navdata.SetDefaultProcedures(ps)
+	t.Cleanup(func() { navdata.SetDefaultProcedures(nil) })
+
+	msg := &acars.Message{
+		Label: "H1",
+		Text:  "FPN/RP:DA:KDFW:AA:KLAX:D:KATZZ2.BRHMA:A:ANJLL4.SEAVU:F:BRHMA..GUP",
+	}
+	fp := (&FPNParser{}).Parse(msg).(*FPNResult)
+
+	dep := fp.DepartureProcedure
+	if dep == nil {
+		t.Fatal("DepartureProcedure not resolved")
+	}
+	if dep.Ident != "KATZZ2" || dep.Transition != "BRHMA" {
+		t.Errorf("DepartureProcedure = %+v, want KATZZ2.BRHMA", dep)
+	}
+	if len(dep.Runways) != 1 || dep.Runways[0] != "17R" {
+		t.Errorf("Runways = %v, want [17R]", dep.Runways)
+	}
+	want := []string{"DEBBB", "KATZZ", "HRPER", "BRHMA"}
+	if len(dep.Waypoints) != len(want) {
+		t.Fatalf("Waypoints = %v, want %v", dep.Waypoints, want)
+	}
+	for i := range want {
+		if dep.Waypoints[i] != want[i] {
+			t.Errorf("Waypoints = %v, want %v", dep.Waypoints, want)
+			break
+		}
+	}
+
+	// The STAR is not in the database.
+	if fp.ArrivalProcedure != nil {
+		t.Errorf("ArrivalProcedure = %+v, want nil", fp.ArrivalProcedure)
+	}
+}
@@ -103,6 +103,8 @@ func (p *MDCParser) Labels() []string { return []string{"H1"} }
 // Priority determines order when multiple parsers match. Lower than trajectory.
 func (p *MDCParser) Priority() int { return 40 }
 
+func (p *MDCParser) Anchors() []string { return []string{"MDC REPORT:"} }
+
 // QuickCheck performs a fast string check before expensive regex.
 func (p *MDCParser) QuickCheck(text string) bool {
 	return strings.Contains(text, "MDC REPORT:")
@@ -8,6 +8,7 @@ import (
 
 	"acars_parser/internal/acars"
 	"acars_parser/internal/crc"
+	"acars_parser/internal/navdata"
 	"acars_parser/internal/patterns"
 	"acars_parser/internal/registry"
 )
@@ -36,28 +37,38 @@ type RouteWaypoint struct {
 	Name      string  `json:"name"`
 	Latitude  float64 `json:"latitude,omitempty"`
 	Longitude float64 `json:"longitude,omitempty"`
+	// Airway and Expanded are only set when an airway database is configured
+	// (see SetAirways). Airway is the airway flown to reach the waypoint, and
+	// Expanded marks fixes taken from the database rather than the message.
+	Airway   string `json:"airway,omitempty"`
+	Expanded bool   `json:"expanded,omitempty"`
 }
 
 // FPNResult represents a parsed H1 FPN flight plan message.
 type FPNResult struct {
-	MsgID               int64           `json:"message_id"`
-	Timestamp           string          `json:"timestamp"`
-	Tail                string          `json:"tail,omitempty"`
-	FlightNum           string          `json:"flight_num,omitempty"`
-	Origin              string          `json:"origin"`
-	Destination         string          `json:"destination"`
-	Route               string          `json:"route,omitempty"`
-	Waypoints           []RouteWaypoint `json:"waypoints,omitempty"`
-	Departure           string          `json:"departure,omitempty"`
-	DepartureTransition string          `json:"departure_transition,omitempty"`
-	Arrival             string          `json:"arrival,omitempty"`
-	ArrivalTransition   string          `json:"arrival_transition,omitempty"`
-	Approach            string          `json:"approach,omitempty"`
-	ApproachType        string          `json:"approach_type,omitempty"`
-	ApproachRunway      string          `json:"approach_runway,omitempty"`
-	ApproachRoute       string          `json:"approach_route,omitempty"`
-	ApproachWaypoints   []RouteWaypoint `json:"approach_waypoints,omitempty"`
-	Truncated           bool            `json:"truncated,omitempty"`
+	MsgID               int64            `json:"message_id"`
+	Timestamp           string           `json:"timestamp"`
+	Tail                string           `json:"tail,omitempty"`
+	FlightNum           string           `json:"flight_num,omitempty"`
+	Origin              string           `json:"origin"`
+	Destination         string           `json:"destination"`
+	Route               string           `json:"route,omitempty"`
+	Waypoints           []RouteWaypoint  `json:"waypoints,omitempty"`
+	Departure           string           `json:"departure,omitempty"`
+	DepartureTransition string           `json:"departure_transition,omitempty"`
+	Arrival             string           `json:"arrival,omitempty"`
+	ArrivalTransition   string           `json:"arrival_transition,omitempty"`
+	Approach            string           `json:"approach,omitempty"`
+	ApproachType        string           `json:"approach_type,omitempty"`
+	ApproachRunway      string           `json:"approach_runway,omitempty"`
+	ApproachRoute       string           `json:"approach_route,omitempty"`
+	ApproachWaypoints   []RouteWaypoint  `json:"approach_waypoints,omitempty"`
+	Winds               []RouteWindLayer `json:"winds,omitempty"`
+	// DepartureProcedure and ArrivalProcedure are set when a procedure database
+	// is configured (see navdata.SetDefaultProcedures) and the SID/STAR is found.
+	DepartureProcedure *navdata.ResolvedProcedure `json:"departure_procedure,omitempty"`
+	ArrivalProcedure   *navdata.ResolvedProcedure `json:"arrival_procedure,omitempty"`
+	Truncated          bool                       `json:"truncated,omitempty"`
 }
 
 func (r *FPNResult) Type() string     { return "flight_plan" }
@@ -74,6 +85,12 @@ func (p *FPNParser) Name() string     { return "fpn" }
 func (p *FPNParser) Labels() []string { return []string{"H1", "4A", "HX"} }
 func (p *FPNParser) Priority() int    { return 10 }
 
+// Version 2 added /WD wind blocks, airway expansion and SID/STAR resolution,
+// and version 3 waypoints given only as coordinates.
+func (p *FPNParser) Version() int { return 3 }
+
+func (p *FPNParser) Anchors() []string { return []string{"FPN"} }
+
 func (p *FPNParser) QuickCheck(text string) bool {
 	return strings.Contains(text, "FPN") && strings.Contains(text, ":DA:")
 }
@@ -119,7 +136,11 @@ func (p *FPNParser) Parse(msg *acars.Message) registry.Result {
 
 	if route != "" {
 		fp.Route = route
-		fp.Waypoints = parseRouteWaypoints(route)
+		if db := airways.Load(); db != nil {
+			fp.Waypoints = expandRouteWaypoints(route, db)
+		} else {
+			fp.Waypoints = parseRouteWaypoints(route)
+		}
 	}
 
 	// Extract approach from :AP: section.
@@ -129,6 +150,19 @@ func (p *FPNParser) Parse(msg *acars.Message) registry.Result {
 		fp.Approach, fp.ApproachType, fp.ApproachRunway, fp.ApproachWaypoints = parseApproachSection(approach)
 	}
 
+	// Resolve the SID and STAR to runways and fixes when procedure data is loaded.
+	if ps := navdata.DefaultProcedures(); ps != nil {
+		if fp.Departure != "" {
+			fp.DepartureProcedure = ps.Resolve(origin, navdata.SID, fp.Departure, fp.DepartureTransition, extractRunway(tokens.GetRunway()))
+		}
+		if fp.Arrival != "" {
+			fp.ArrivalProcedure = ps.Resolve(dest, navdata.STAR, fp.Arrival, fp.ArrivalTransition, fp.ApproachRunway)
+		}
+	}
+
+	// Decode wind/temperature entries from /WD blocks.
+	fp.Winds = parseFPNWinds(tokens.WindData)
+
 	// Detect truncated messages.
 	fp.Truncated = detectTruncation(msg.Text, fp.Waypoints, route)
 
@@ -202,10 +236,30 @@ func (p *FPNParser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 		Value:   tokens.GetApproach(),
 	})
 
+	trace.Extractors = append(trace.Extractors, registry.Extractor{
+		Name:    "winds",
+		Pattern: "/WD blocks",
+		Matched: len(tokens.WindData) > 0,
+		Value:   strings.Join(tokens.WindData, "/"),
+	})
+
 	trace.Matched = tokens.GetOrigin() != "" && tokens.GetDestination() != ""
 	return trace
 }
 
+// parseFPNWinds decodes the /WD blocks of a flight plan. Each block uses the
+// same layout as a PWI route wind section: a flight level followed by
+// waypoint, wind (DDDSSS) and temperature entries.
+func parseFPNWinds(blocks []string) []RouteWindLayer {
+	var layers []RouteWindLayer
+	for _, block := range blocks {
+		if layer := parseRouteWindLayer(block); layer != nil {
+			layers = append(layers, *layer)
+		}
+	}
+	return layers
+}
+
 // parseRouteWaypoints extracts waypoints with coordinates from a route string.
 // Route format: "WAYPOINT,N31490E035327.AIRWAY..NEXT,COORDS"
 func parseRouteWaypoints(route string) []RouteWaypoint {
@@ -355,29 +409,11 @@ func detectTruncation(text string, waypoints []RouteWaypoint, route string) bool
 	// For messages with /WD section, verify CRC.
 	// Format: ...data/WD,,,,XXXX where XXXX is the 4-char hex checksum.
 	if idx := strings.Index(text, "/WD"); idx >= 0 {
-		// Find the checksum at the end (last 4 hex chars).
-		text = strings.TrimSpace(text)
-		if len(text) >= 4 {
-			checksumHex := text[len(text)-4:]
-			// Verify all 4 chars are hex digits.
-			if crc.IsHexDigit(checksumHex[0]) && crc.IsHexDigit(checksumHex[1]) &&
-				crc.IsHexDigit(checksumHex[2]) && crc.IsHexDigit(checksumHex[3]) {
-
-				// Decode checksum hex to bytes.
-				checksumBytes := []byte{
-					crc.HexToByte(checksumHex[0], checksumHex[1]),
-					crc.HexToByte(checksumHex[2], checksumHex[3]),
-				}
-
-				// Get message without the hex checksum string.
-				msgWithoutCRC := text[:len(text)-4]
-
-				// Verify using the shared CRC package.
-				if !crc.Verify16Arinc([]byte(msgWithoutCRC), checksumBytes) {
-					return true // CRC mismatch - message is corrupt/truncated.
-				}
-				return false // CRC valid - message is complete.
-			}
+		// The checksum is the last 4 hex chars; anything else falls through
+		// to the heuristics below.
+		if _, _, err := crc.SplitHexChecksum(text); err == nil {
+			// A CRC mismatch means the message is corrupt or truncated.
+			return !crc.ARINC.VerifyHex(text)
 		}
 	}
 
@@ -473,54 +509,17 @@ func isValidWaypoint(s string) bool {
 	return true
 }
 
-// parseWaypointCoords parses a coordinate string in the format N31490E035327 or S12345W098765.
+// parseWaypointCoords parses a waypoint's coordinates, such as N31490E035327
+// or S12345W098765, in any encoding patterns.ParseRouteCoord accepts.
 // Returns latitude and longitude in decimal degrees, or (0, 0) if parsing fails.
-// Format: [N|S]DDMMT[E|W]DDDMMT where DD/DDD=degrees, MM=minutes, T=tenths of minutes.
 func parseWaypointCoords(coordStr string) (lat, lon float64) {
-	if len(coordStr) < 11 {
-		return 0, 0
-	}
-
-	// Find the longitude direction marker (E or W) to split the string.
-	// It should be after the latitude portion.
-	lonDirIdx := -1
-	for i := 5; i < len(coordStr); i++ {
-		if coordStr[i] == 'E' || coordStr[i] == 'W' {
-			lonDirIdx = i
-			break
-		}
-	}
-
-	if lonDirIdx < 1 {
-		return 0, 0
-	}
-
-	// Split into latitude and longitude parts.
-	latPart := coordStr[:lonDirIdx]
-	lonPart := coordStr[lonDirIdx:]
-
-	// Parse latitude: [N|S]DDMMT (direction + 5 digits).
-	if len(latPart) < 2 {
-		return 0, 0
-	}
-	latDir := string(latPart[0])
-	latVal := latPart[1:]
-
-	// Parse longitude: [E|W]DDDMMT (direction + 6 digits).
-	if len(lonPart) < 2 {
-		return 0, 0
-	}
-	lonDir := string(lonPart[0])
-	lonVal := lonPart[1:]
-
-	lat = patterns.ParseLatitude(latVal, latDir)
-	lon = patterns.ParseLongitude(lonVal, lonDir)
-
+	lat, lon, _ = patterns.ParseRouteCoord(coordStr)
 	return lat, lon
 }
 
 // parseWaypointWithCoords extracts a waypoint name and its coordinates from a route segment.
-// Input format: "WAYPOINT,N31490E035327" or just "WAYPOINT".
+// Input format: "WAYPOINT,N31490E035327", just "WAYPOINT", or a bare coordinate
+// such as "N47000W094000" or "5275N", which is named as written.
 // Returns the RouteWaypoint with name and coordinates (if present).
 func parseWaypointWithCoords(segment string) *RouteWaypoint {
 	// Split on comma to separate waypoint name from coordinates.
@@ -531,13 +530,16 @@ func parseWaypointWithCoords(segment string) *RouteWaypoint {
 
 	name := parts[0]
 	if !isValidWaypoint(name) {
+		if lat, lon, ok := patterns.ParseRouteCoord(name); ok && len(parts) == 1 {
+			return &RouteWaypoint{Name: name, Latitude: lat, Longitude: lon}
+		}
 		return nil
 	}
 
 	wpt := &RouteWaypoint{Name: name}
 
 	// If there's a coordinate part, parse it.
-	if len(parts) == 2 && len(parts[1]) >= 11 {
+	if len(parts) == 2 {
 		wpt.Latitude, wpt.Longitude = parseWaypointCoords(parts[1])
 	}
 
@@ -775,6 +777,8 @@ func (p *PWIParser) Name() string     { return "pwi" }
 func (p *PWIParser) Labels() []string { return []string{"H1"} }
 func (p *PWIParser) Priority() int    { return 30 }
 
+func (p *PWIParser) Anchors() []string { return []string{"PWI/"} }
+
 func (p *PWIParser) QuickCheck(text string) bool {
 	return strings.Contains(text, "PWI/")
 }
@@ -38,6 +38,18 @@ func TestParseWaypointCoords(t *testing.T) {
 			wantLat: -33.866666666666667, // 33° 52.0' S
 			wantLon: 151.3,               // 151° 18.0' E
 		},
+		{
+			name:    "Decimal minutes",
+			input:   "N4749.5W12218.3",
+			wantLat: 47.825,   // 47° 49.5' N
+			wantLon: -122.305, // 122° 18.3' W
+		},
+		{
+			name:    "ICAO degrees and minutes",
+			input:   "4620N07805W",
+			wantLat: 46.333333333333336, // 46° 20' N
+			wantLon: -78.08333333333333, // 078° 05' W
+		},
 	}
 
 	for _, tt := range tests {
@@ -62,6 +74,32 @@ func abs(x float64) float64 {
 	return x
 }
 
+func TestFPNBareCoordinates(t *testing.T) {
+	msg := &acars.Message{
+		Label: "H1",
+		Text:  "FPN/RP:DA:KWRI:AA:KSKA:F:FJC..DLH..N47000W094000..N4730W100..5275N..52N75..N0450F350..CHOTE",
+	}
+	fpn := (&FPNParser{}).Parse(msg).(*FPNResult)
+
+	want := []RouteWaypoint{
+		{Name: "FJC"},
+		{Name: "DLH"},
+		{Name: "N47000W094000", Latitude: 47, Longitude: -94},
+		{Name: "N4730W100", Latitude: 47.5, Longitude: -100},
+		{Name: "5275N", Latitude: 52, Longitude: -75},
+		{Name: "52N75", Latitude: 52, Longitude: -175},
+		{Name: "CHOTE"},
+	}
+	if len(fpn.Waypoints) != len(want) {
+		t.Fatalf("got %d waypoints %+v, want %d", len(fpn.Waypoints), fpn.Waypoints, len(want))
+	}
+	for i := range want {
+		if fpn.Waypoints[i] != want[i] {
+			t.Errorf("waypoint %d = %+v, want %+v", i, fpn.Waypoints[i], want[i])
+		}
+	}
+}
+
 func TestFPNParseWithCoordinates(t *testing.T) {
 	testText := `FPN/FNRJA111/RP:DA:OJAI:AA:EGLL:F:MUVIN,N31490E035327.L53..TAPUZ,N32020E034314.W13..VELOX,N33490E034050.N71..DESPO,N34269E034229`
 
@@ -200,4 +238,41 @@ func TestDetectTruncation(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestFPNParseWinds(t *testing.T) {
+	msg := &acars.Message{
+		ID:    1,
+		Label: "H1",
+		Text:  "FPN/FNRJA111/RP:DA:OJAI:AA:EGLL:F:MUVIN..TAPUZ,49BE/WD360,MUVIN,321074,360M57.TAPUZ,303085,360M63/WD100,MUVIN,252039,100P05,1C2D",
+	}
+
+	result := (&FPNParser{}).Parse(msg)
+	fpn, ok := result.(*FPNResult)
+	if !ok {
+		t.Fatalf("Parse() = %T, want *FPNResult", result)
+	}
+
+	if len(fpn.Winds) != 2 {
+		t.Fatalf("got %d wind layers, want 2: %+v", len(fpn.Winds), fpn.Winds)
+	}
+
+	high := fpn.Winds[0]
+	if high.FlightLevel != 360 || len(high.Waypoints) != 2 {
+		t.Fatalf("first layer = %+v, want FL360 with 2 waypoints", high)
+	}
+	want := WaypointWind{Waypoint: "TAPUZ", WindDir: 303, WindSpeed: 85, Temperature: -63}
+	if high.Waypoints[1] != want {
+		t.Errorf("TAPUZ wind = %+v, want %+v", high.Waypoints[1], want)
+	}
+
+	low := fpn.Winds[1]
+	want = WaypointWind{Waypoint: "MUVIN", WindDir: 252, WindSpeed: 39, Temperature: 5}
+	if low.FlightLevel != 100 || len(low.Waypoints) != 1 || low.Waypoints[0] != want {
+		t.Errorf("second layer = %+v, want FL100 MUVIN %+v", low, want)
+	}
+
+	// The wind blocks must not leak into the route.
+	if fpn.Route != "MUVIN..TAPUZ,49BE" {
+		t.Errorf("Route = %q, want %q", fpn.Route, "MUVIN..TAPUZ,49BE")
+	}
+}
@@ -0,0 +1,192 @@
+package h1
+
+import (
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// =============================================================================
+// Sub-label router
+// =============================================================================
+
+// H1 downlinks are written by several onboard systems, each of which prefixes
+// its text with a sub-label: '#', a two-character system code and usually a
+// 'B' (or 'A'), e.g. "#M1BPOS..." from the left FMC or "#DFB..." from the
+// DFDAU. Ground uplinks carry the same prefix after "- ". What follows is the
+// message function identifier (MFI), such as POS or FPN. Free text from the
+// MCDU has no sub-label and starts with FTX.
+
+// System describes an onboard system that emits H1 messages.
+type System struct {
+	Code string // Short name stored in results, e.g. "fmc".
+	Name string // Human-readable name, e.g. "FMC (left)".
+}
+
+// subLabelSystems maps H1 sub-labels to the systems that emit them.
+var subLabelSystems = map[string]System{
+	"M1": {"fmc", "FMC (left)"},
+	"M2": {"fmc", "FMC (right)"},
+	"M3": {"fmc", "FMC (centre)"},
+	"DF": {"dfdau", "DFDAU / ACMS"},
+	"CF": {"cfdiu", "CFDIU / central maintenance"},
+	"EC": {"eicas", "Engine display"},
+	"EI": {"eicas", "EICAS"},
+	"S1": {"sdu", "SATCOM data unit (left)"},
+	"S2": {"sdu", "SATCOM data unit (right)"},
+}
+
+// mfiSystems maps MFIs sent without a sub-label to their systems.
+var mfiSystems = map[string]System{
+	"FTX": {"mcdu", "MCDU free text"},
+}
+
+// SubLabel is the routing prefix of an H1 message.
+type SubLabel struct {
+	Code   string // Sub-label, e.g. "M1"; empty for a bare MFI such as FTX.
+	MFI    string // Message function identifier, e.g. "POS"; may be empty.
+	Uplink bool   // The prefix was preceded by "- " (ground to air).
+	System System // Zero when the sub-label is not known.
+}
+
+// SplitSubLabel splits the sub-label prefix from an H1 message text and
+// returns it with the remaining text, which starts at the MFI. It returns
+// false when the text has no sub-label.
+func SplitSubLabel(text string) (SubLabel, string, bool) {
+	var sl SubLabel
+	rest := strings.TrimLeft(text, " \r\n")
+	if strings.HasPrefix(rest, "- #") {
+		sl.Uplink = true
+		rest = rest[2:]
+	}
+
+	if strings.HasPrefix(rest, "#") {
+		if len(rest) < 3 || !isUpperAlnum(rest[1]) || !isUpperAlnum(rest[2]) {
+			return SubLabel{}, text, false
+		}
+		sl.Code = rest[1:3]
+		sl.System = subLabelSystems[sl.Code]
+		if sl.System == (System{}) && sl.Code[0] == 'T' && isDigit(sl.Code[1]) {
+			sl.System = System{"cabin", "Cabin terminal " + sl.Code[1:]}
+		}
+		rest = rest[3:]
+		if len(rest) > 0 && (rest[0] == 'A' || rest[0] == 'B') {
+			rest = rest[1:]
+		}
+		sl.MFI = leadingMFI(rest)
+		return sl, rest, true
+	}
+
+	mfi := leadingMFI(rest)
+	system, ok := mfiSystems[mfi]
+	if !ok {
+		return SubLabel{}, text, false
+	}
+	sl.MFI = mfi
+	sl.System = system
+	return sl, rest, true
+}
+
+// leadingMFI returns the three-character MFI at the start of text, or "".
+func leadingMFI(text string) string {
+	if len(text) < 3 {
+		return ""
+	}
+	for i := 0; i < 3; i++ {
+		if !isUpperAlnum(text[i]) {
+			return ""
+		}
+	}
+	return text[:3]
+}
+
+func isUpperAlnum(c byte) bool { return (c >= 'A' && c <= 'Z') || isDigit(c) }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// SubLabelResult tags an H1 message that no H1 parser understood with the
+// system that sent it.
+type SubLabelResult struct {
+	MsgID      int64  `json:"message_id"`
+	Timestamp  string `json:"timestamp"`
+	Tail       string `json:"tail,omitempty"`
+	SubLabel   string `json:"sub_label,omitempty"`
+	MFI        string `json:"mfi,omitempty"`
+	System     string `json:"system"`
+	SystemName string `json:"system_name,omitempty"`
+	Uplink     bool   `json:"uplink,omitempty"`
+}
+
+func (r *SubLabelResult) Type() string     { return "h1_sublabel" }
+func (r *SubLabelResult) MessageID() int64 { return r.MsgID }
+
+// SubLabelParser routes H1 messages by sub-label. It is registered as a
+// catch-all, so it only runs when no parser matched the message as received.
+// It strips the sub-label and offers the remaining text to the H1 parsers,
+// which expect it to start at the MFI, and otherwise returns a SubLabelResult
+// naming the emitting system.
+type SubLabelParser struct {
+	parsers []registry.Parser
+}
+
+func init() {
+	registry.RegisterCatchAll(NewSubLabelParser())
+}
+
+// NewSubLabelParser returns a router over the H1 parsers.
+func NewSubLabelParser() *SubLabelParser {
+	return &SubLabelParser{parsers: []registry.Parser{
+		&FPNParser{},
+		&H1PosParser{},
+		&PWIParser{},
+		&MDCParser{},
+		&TrajectoryParser{},
+	}}
+}
+
+func (p *SubLabelParser) Name() string     { return "h1_sublabel" }
+func (p *SubLabelParser) Labels() []string { return []string{"H1"} }
+func (p *SubLabelParser) Priority() int    { return 100 }
+
+func (p *SubLabelParser) QuickCheck(text string) bool {
+	_, _, ok := SplitSubLabel(text)
+	return ok
+}
+
+func (p *SubLabelParser) Parse(msg *acars.Message) registry.Result {
+	// Catch-all parsers see every label.
+	if msg.Label != "H1" {
+		return nil
+	}
+	sl, rest, ok := SplitSubLabel(msg.Text)
+	if !ok {
+		return nil
+	}
+
+	stripped := *msg
+	stripped.Text = rest
+	for _, sub := range p.parsers {
+		if !sub.QuickCheck(rest) {
+			continue
+		}
+		if result := sub.Parse(&stripped); result != nil {
+			return result
+		}
+	}
+
+	system := sl.System.Code
+	if system == "" {
+		system = "unknown"
+	}
+	return &SubLabelResult{
+		MsgID:      int64(msg.ID),
+		Timestamp:  msg.Timestamp,
+		Tail:       msg.Tail,
+		SubLabel:   sl.Code,
+		MFI:        sl.MFI,
+		System:     system,
+		SystemName: sl.System.Name,
+		Uplink:     sl.Uplink,
+	}
+}
@@ -0,0 +1,86 @@
+package h1
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestSplitSubLabel(t *testing.T) {
+	tests := []struct {
+		text       string
+		wantOK     bool
+		wantCode   string
+		wantMFI    string
+		wantSystem string
+		wantUplink bool
+		wantRest   string
+	}{
+		{"#M1BPOSN33456W084123,ATL", true, "M1", "POS", "fmc", false, "POSN33456W084123,ATL"},
+		{"#DFBA32 REPORT", true, "DF", "A32", "dfdau", false, "A32 REPORT"},
+		{"#CFBFLR/FR2601071023", true, "CF", "FLR", "cfdiu", false, "FLR/FR2601071023"},
+		{"#T4BCAB MSG", true, "T4", "CAB", "cabin", false, "CAB MSG"},
+		{"- #M1AREQPWI", true, "M1", "REQ", "fmc", true, "REQPWI"},
+		{"#ZZB?", true, "ZZ", "", "", false, "?"},
+		{"FTX01.RUNWAY CHANGE", true, "", "FTX", "mcdu", false, "FTX01.RUNWAY CHANGE"},
+		{"POSN33456W084123,ATL", false, "", "", "", false, ""},
+		{"#", false, "", "", "", false, ""},
+		{"", false, "", "", "", false, ""},
+	}
+	for _, tt := range tests {
+		sl, rest, ok := SplitSubLabel(tt.text)
+		if ok != tt.wantOK {
+			t.Errorf("SplitSubLabel(%q) ok = %v, want %v", tt.text, ok, tt.wantOK)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if sl.Code != tt.wantCode || sl.MFI != tt.wantMFI || sl.System.Code != tt.wantSystem || sl.Uplink != tt.wantUplink || rest != tt.wantRest {
+			t.Errorf("SplitSubLabel(%q) = %+v, %q", tt.text, sl, rest)
+		}
+	}
+}
+
+func TestSubLabelParserRoutes(t *testing.T) {
+	p := NewSubLabelParser()
+
+	// The prefixed position report is handed to the POS parser.
+	msg := &acars.Message{ID: 7, Label: "H1", Text: "#M1BPOSN33456W084123,ATL,100530,350,MCN,1020,SAV,M52,27035"}
+	pos, ok := p.Parse(msg).(*H1PosResult)
+	if !ok {
+		t.Fatalf("Parse() did not route to the POS parser")
+	}
+	if pos.MsgID != 7 || pos.CurrentWaypoint != "ATL" || pos.FlightLevel != 350 || pos.ReportTime != "100530" {
+		t.Errorf("position = %+v", pos)
+	}
+	if msg.Text[0] != '#' {
+		t.Errorf("message text modified: %q", msg.Text)
+	}
+}
+
+func TestSubLabelParserTags(t *testing.T) {
+	p := NewSubLabelParser()
+
+	msg := &acars.Message{ID: 9, Label: "H1", Tail: "N123AB", Text: "#DFBA32 ENGINE REPORT 01"}
+	r, ok := p.Parse(msg).(*SubLabelResult)
+	if !ok {
+		t.Fatalf("Parse() did not return a sub-label result")
+	}
+	if r.Type() != "h1_sublabel" || r.SubLabel != "DF" || r.MFI != "A32" || r.System != "dfdau" || r.SystemName == "" {
+		t.Errorf("result = %+v", r)
+	}
+
+	unknown := p.Parse(&acars.Message{Label: "H1", Text: "#Q7BXYZ"}).(*SubLabelResult)
+	if unknown.System != "unknown" || unknown.SubLabel != "Q7" {
+		t.Errorf("unknown sub-label = %+v", unknown)
+	}
+
+	// Other labels and unprefixed text are left alone.
+	if r := p.Parse(&acars.Message{Label: "4A", Text: "#DFBA32"}); r != nil {
+		t.Errorf("non-H1 message tagged: %+v", r)
+	}
+	if r := p.Parse(&acars.Message{Label: "H1", Text: "REQPWI"}); r != nil {
+		t.Errorf("unprefixed message tagged: %+v", r)
+	}
+}
@@ -21,6 +21,11 @@ type FPNTokens struct {
 	// Sections maps section marker to its value.
 	// Example: {"DA": "YSSY", "AA": "YMML", "F": "WOL..LEECE"}
 	Sections map[string]string
+
+	// WindData holds the body of each /WD wind block, with block checksums removed.
+	// Example: ["390,KNRAD,270050,390M55.HRV,265045,390M56"] from
+	// "...49BE/WD390,KNRAD,270050,390M55.HRV,265045,390M56,75A7"
+	WindData []string
 }
 
 // sectionMarkerRe matches ARINC 622/633 section markers like :DA:, :AA:, :F:, etc.
@@ -73,6 +78,8 @@ func TokeniseFPN(text string) *FPNTokens {
 		Sections: make(map[string]string),
 	}
 
+	tokens.WindData = extractWindBlocks(text)
+
 	// Find all section marker positions.
 	matches := sectionMarkerRe.FindAllStringSubmatchIndex(text, -1)
 	if len(matches) == 0 {
@@ -145,6 +152,57 @@ func extractFlightNum(text string) string {
 	return ""
 }
 
+// extractWindBlocks returns the body of each /WD block in the message. Every
+// block may end with a 4-hex checksum (the last one is the message CRC), which
+// is dropped. Empty blocks such as "/WD,,,," are omitted.
+func extractWindBlocks(text string) []string {
+	idx := strings.Index(text, "/WD")
+	if idx < 0 {
+		return nil
+	}
+
+	var blocks []string
+	for _, part := range strings.Split(text[idx+1:], "/") {
+		if !strings.HasPrefix(part, "WD") {
+			continue
+		}
+		body := trimBlockChecksum(part[2:])
+		if strings.Trim(body, ",.") == "" {
+			continue
+		}
+		blocks = append(blocks, body)
+	}
+	return blocks
+}
+
+// trimBlockChecksum removes a trailing ",XXXX" or ".XXXX" checksum field. The
+// field must contain a digit so that a hex-lettered waypoint like "BEEF" is kept.
+func trimBlockChecksum(body string) string {
+	sep := strings.LastIndexAny(body, ",.")
+	field := body[sep+1:]
+	if len(field) != 4 {
+		return body
+	}
+	hasDigit := false
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case c >= 'A' && c <= 'F', c >= 'a' && c <= 'f':
+		default:
+			return body
+		}
+	}
+	if !hasDigit {
+		return body
+	}
+	if sep < 0 {
+		return ""
+	}
+	return body[:sep]
+}
+
 // GetOrigin returns the departure airport from the DA section.
 // Returns empty string if not present.
 func (t *FPNTokens) GetOrigin() string {
@@ -170,7 +228,7 @@ func (t *FPNTokens) GetDestination() string {
 }
 
 // GetRoute returns the flight route from the F section.
-// Strips any trailing /FN... suffix (flight number/checksum).
+// Strips any trailing /FN... suffix (flight number/checksum) and /WD wind blocks.
 // Returns empty string if not present.
 func (t *FPNTokens) GetRoute() string {
 	route := t.Sections["F"]
@@ -185,6 +243,10 @@ func (t *FPNTokens) GetRoute() string {
 	if idx := strings.Index(route, "/SN"); idx >= 0 {
 		route = route[:idx]
 	}
+	// Strip trailing /WD wind blocks (decoded separately into WindData).
+	if idx := strings.Index(route, "/WD"); idx >= 0 {
+		route = route[:idx]
+	}
 	return route
 }
 
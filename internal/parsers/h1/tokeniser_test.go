@@ -309,3 +309,49 @@ func TestGetters(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractWindBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "no wind section",
+			input: "FPN/SN123:DA:KSFO:AA:KLAX:F:WAYP1..WAYP2",
+			want:  nil,
+		},
+		{
+			name:  "empty wind block with CRC",
+			input: "FPN/ID23565S:DA:KMCF:AA:KTIK:V:HRV,282,AT3400,,49BE/WD,,,,75A7",
+			want:  nil,
+		},
+		{
+			name:  "single block with CRC",
+			input: "FPN:DA:KMCF:AA:KTIK:F:HRV..AEX,49BE/WD390,HRV,270050,390M55.AEX,265045,390M56,75A7",
+			want:  []string{"390,HRV,270050,390M55.AEX,265045,390M56"},
+		},
+		{
+			name:  "multiple blocks with intermediate checksum",
+			input: "FPN:DA:KMCF:AA:KTIK/WD390,HRV,270050,390M55,1C2D/WD350,HRV,260040,350M48.BEEF,255035,350M47,75A7",
+			want: []string{
+				"390,HRV,270050,390M55",
+				"350,HRV,260040,350M48.BEEF,255035,350M47",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TokeniseFPN(tt.input).WindData
+			if len(got) != len(tt.want) {
+				t.Fatalf("WindData = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("WindData[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -62,6 +62,8 @@ func (p *Parser) Name() string           { return "hazard_alert" }
 func (p *Parser) Labels() []string       { return []string{"_", "H1", "SA"} } // Various labels possible
 func (p *Parser) Priority() int          { return 60 }
 
+func (p *Parser) Anchors() []string { return []string{"HAZARD ALERT"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	return strings.Contains(text, "HAZARD ALERT")
 }
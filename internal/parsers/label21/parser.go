@@ -57,6 +57,8 @@ func (p *Parser) Name() string     { return "label21" }
 func (p *Parser) Labels() []string { return []string{"21"} }
 func (p *Parser) Priority() int    { return 100 }
 
+func (p *Parser) Anchors() []string { return []string{"POSN"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	return strings.Contains(text, "POSN")
 }
@@ -24,6 +24,21 @@ var Formats = []patterns.Format{
 			`(?P<callsign>[A-Z0-9]+),(?P<unknown>[^,]+),(?P<dest>{ICAO}),(?P<time>{TIME4})`,
 		Fields: []string{"fb", "airport", "lat_dir", "lat", "lon_dir", "lon", "callsign", "unknown", "dest", "time"},
 	},
+	// Position, ETA and OOOI report format, with or without a "00" prefix.
+	// The flight level is "GRD" on the ground and absent from OFF, ON and IN
+	// reports. The date is MMDD; the fuel on board is in thousands of the
+	// aircraft's unit.
+	// Example: 00POS01,N38338W121179,350,KMHR,KPDX,0807,0014,0123,004.9
+	// Example: 00OFF01,N38334W121176,KMHR,KPDX,0807,0014,0123,004.9
+	// Groups: kind, seq, lat_dir, lat, lon_dir, lon, fl, origin, dest, date, time, eta, fuel
+	{
+		Name: "report",
+		Pattern: `^(?:00)?(?P<kind>POS|ETA|OFF|ON|IN)(?P<seq>\d{2}),(?P<lat_dir>{LAT_DIR})(?P<lat>\d{5})` +
+			`(?P<lon_dir>{LON_DIR})(?P<lon>\d{6}),(?:(?P<fl>\d{1,3}|GRD),)?` +
+			`(?P<origin>{ICAO}),(?P<dest>{ICAO}),(?P<date>\d{4}),(?P<time>{TIME4}),(?P<eta>{TIME4})` +
+			`(?:,(?P<fuel>\d+(?:\.\d+)?))?`,
+		Fields: []string{"kind", "seq", "lat_dir", "lat", "lon_dir", "lon", "fl", "origin", "dest", "date", "time", "eta", "fuel"},
+	},
 	// POS position report format.
 	// Example: POS01,S33561E151234,350,YSSY,YMML,1234,1530
 	// Groups: unknown, lat_dir, lat, lon_dir, lon, fl, origin, dest, time1, time2
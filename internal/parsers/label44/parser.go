@@ -1,4 +1,5 @@
-// Package label44 parses Label 44 messages (runway info, FB positions, POS reports).
+// Package label44 parses Label 44 messages (runway info, FB positions, and
+// position, ETA and OOOI reports).
 package label44
 
 import (
@@ -35,21 +36,28 @@ type RunwayInfo struct {
 
 // Result represents a parsed Label 44 message.
 type Result struct {
-	MsgID       int64         `json:"message_id"`
-	Timestamp   string        `json:"timestamp"`
-	Tail        string        `json:"tail,omitempty"`
-	MessageType string        `json:"message_type"` // "runway", "fb", "pos"
-	Airport     string        `json:"airport,omitempty"`
-	Runways     []RunwayInfo  `json:"runways,omitempty"`
-	Procedures  []string      `json:"procedures,omitempty"`
-	Latitude    float64       `json:"latitude,omitempty"`
-	Longitude   float64       `json:"longitude,omitempty"`
-	FlightLevel int           `json:"flight_level,omitempty"`
-	Origin      string        `json:"origin,omitempty"`
-	Destination string        `json:"destination,omitempty"`
-	Callsign    string        `json:"callsign,omitempty"`
-	ReportTime  string        `json:"report_time,omitempty"`
-	RawData     string        `json:"raw_data,omitempty"`
+	MsgID       int64        `json:"message_id"`
+	Timestamp   string       `json:"timestamp"`
+	Tail        string       `json:"tail,omitempty"`
+	MessageType string       `json:"message_type"` // "runway", "fb", "pos", "eta", "off", "on", "in"
+	Airport     string       `json:"airport,omitempty"`
+	Runways     []RunwayInfo `json:"runways,omitempty"`
+	Procedures  []string     `json:"procedures,omitempty"`
+	Latitude    float64      `json:"latitude,omitempty"`
+	Longitude   float64      `json:"longitude,omitempty"`
+	FlightLevel int          `json:"flight_level,omitempty"`
+	Origin      string       `json:"origin,omitempty"`
+	Destination string       `json:"destination,omitempty"`
+	Callsign    string       `json:"callsign,omitempty"`
+	ReportTime  string       `json:"report_time,omitempty"`
+	ReportDate  string       `json:"report_date,omitempty"` // MMDD.
+	ETA         string       `json:"eta,omitempty"`
+	OffTime     string       `json:"off_time,omitempty"`
+	OnTime      string       `json:"on_time,omitempty"`
+	InTime      string       `json:"in_time,omitempty"`
+	OnGround    bool         `json:"on_ground,omitempty"`
+	FuelOnBoard float64      `json:"fuel_on_board,omitempty"` // Thousands of kg or lb.
+	RawData     string       `json:"raw_data,omitempty"`
 }
 
 func (r *Result) Type() string     { return "label44" }
@@ -66,6 +74,8 @@ func (p *Parser) Name() string     { return "label44" }
 func (p *Parser) Labels() []string { return []string{"44"} }
 func (p *Parser) Priority() int    { return 100 }
 
+// Version 2 added the ETA, OFF, ON and IN reports and the "00" prefixed forms.
+func (p *Parser) Version() int { return 2 }
 
 func (p *Parser) QuickCheck(text string) bool {
 	// Skip encoded/binary messages
@@ -74,7 +84,21 @@ func (p *Parser) QuickCheck(text string) bool {
 	}
 	return strings.Contains(text, "T/O RWY") ||
 		strings.Contains(text, "/FB ") ||
-		strings.HasPrefix(text, "POS")
+		isReport(text)
+}
+
+// reportKinds are the prefixes of position, ETA and OOOI reports.
+var reportKinds = []string{"POS", "ETA", "OFF", "ON", "IN"}
+
+// isReport reports whether text starts like a position, ETA or OOOI report.
+func isReport(text string) bool {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "00")
+	for _, kind := range reportKinds {
+		if strings.HasPrefix(text, kind) {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *Parser) Parse(msg *acars.Message) registry.Result {
@@ -99,6 +123,11 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 		return result
 	}
 
+	// Try position, ETA and OOOI reports
+	if result := p.parseReport(msg, text); result != nil {
+		return result
+	}
+
 	// Try POS position format
 	if result := p.parsePOSReport(msg, text); result != nil {
 		return result
@@ -228,6 +257,57 @@ func (p *Parser) parseFBPosition(msg *acars.Message, text string) *Result {
 	return result
 }
 
+func (p *Parser) parseReport(msg *acars.Message, text string) *Result {
+	compiler, err := getCompiler()
+	if err != nil {
+		return nil
+	}
+
+	match := compiler.Parse(text)
+	if match == nil || match.FormatName != "report" {
+		return nil
+	}
+
+	kind := match.Captures["kind"]
+	result := &Result{
+		MsgID:       int64(msg.ID),
+		Timestamp:   msg.Timestamp,
+		Tail:        msg.Tail,
+		MessageType: strings.ToLower(kind),
+		Origin:      match.Captures["origin"],
+		Destination: match.Captures["dest"],
+		ReportDate:  match.Captures["date"],
+		ReportTime:  match.Captures["time"],
+		ETA:         match.Captures["eta"],
+		Latitude:    patterns.ParseLatitude(match.Captures["lat"], match.Captures["lat_dir"]),
+		Longitude:   patterns.ParseLongitude(match.Captures["lon"], match.Captures["lon_dir"]),
+	}
+
+	// OOOI reports carry the event time for the flight lifecycle.
+	switch kind {
+	case "OFF":
+		result.OffTime = result.ReportTime
+	case "ON":
+		result.OnTime = result.ReportTime
+	case "IN":
+		result.InTime = result.ReportTime
+	}
+
+	switch fl := match.Captures["fl"]; fl {
+	case "":
+	case "GRD":
+		result.OnGround = true
+	default:
+		result.FlightLevel, _ = strconv.Atoi(fl)
+	}
+
+	if fuel, err := strconv.ParseFloat(match.Captures["fuel"], 64); err == nil {
+		result.FuelOnBoard = fuel
+	}
+
+	return result
+}
+
 func (p *Parser) parsePOSReport(msg *acars.Message, text string) *Result {
 	compiler, err := getCompiler()
 	if err != nil {
@@ -275,7 +355,7 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 	}
 
 	if !quickCheckPassed {
-		trace.QuickCheck.Reason = "No T/O RWY, /FB, or POS prefix found, or contains encoded characters"
+		trace.QuickCheck.Reason = "No T/O RWY, /FB, or POS/ETA/OFF/ON/IN prefix found, or contains encoded characters"
 		return trace
 	}
 
@@ -297,10 +377,11 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 		})
 	}
 
-	// Match if any of the three format types matched.
+	// Match if any of the message format types matched.
 	trace.Matched = compilerTrace.Match != nil &&
 		(compilerTrace.Match.FormatName == "runway_header" ||
 			compilerTrace.Match.FormatName == "fb_position" ||
+			compilerTrace.Match.FormatName == "report" ||
 			compilerTrace.Match.FormatName == "pos_report")
 	return trace
 }
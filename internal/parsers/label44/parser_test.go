@@ -0,0 +1,127 @@
+package label44
+
+import (
+	"math"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestParser_Reports(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantType   string
+		wantLat    float64
+		wantLon    float64
+		wantFL     int
+		wantGround bool
+		wantOrigin string
+		wantDest   string
+		wantDate   string
+		wantTime   string
+		wantETA    string
+		wantOff    string
+		wantOn     string
+		wantIn     string
+		wantFuel   float64
+	}{
+		{
+			name:     "position",
+			text:     "00POS01,N38338W121179,350,KMHR,KPDX,0807,0014,0123,004.9",
+			wantType: "pos", wantLat: 38.5633, wantLon: -121.2983, wantFL: 350,
+			wantOrigin: "KMHR", wantDest: "KPDX", wantDate: "0807", wantTime: "0014", wantETA: "0123", wantFuel: 4.9,
+		},
+		{
+			name:     "position on the ground",
+			text:     "00POS02,N38338W121179,GRD,KMHR,KPDX,0807,0001,0123,005.4",
+			wantType: "pos", wantLat: 38.5633, wantLon: -121.2983, wantGround: true,
+			wantOrigin: "KMHR", wantDest: "KPDX", wantDate: "0807", wantTime: "0001", wantETA: "0123", wantFuel: 5.4,
+		},
+		{
+			name:     "ETA without prefix",
+			text:     "ETA03,S33561E151234,240,YSSY,YMML,0124,2324,2330",
+			wantType: "eta", wantLat: -33.935, wantLon: 151.39, wantFL: 240,
+			wantOrigin: "YSSY", wantDest: "YMML", wantDate: "0124", wantTime: "2324", wantETA: "2330",
+		},
+		{
+			name:     "OFF",
+			text:     "00OFF01,N38334W121176,KMHR,KPDX,0807,0014,0123,004.9",
+			wantType: "off", wantLat: 38.5567, wantLon: -121.2933,
+			wantOrigin: "KMHR", wantDest: "KPDX", wantDate: "0807", wantTime: "0014", wantETA: "0123", wantOff: "0014", wantFuel: 4.9,
+		},
+		{
+			name:     "ON",
+			text:     "00ON01,N45352W122360,KMHR,KPDX,0807,0119,0123,002.1",
+			wantType: "on", wantLat: 45.5867, wantLon: -122.6,
+			wantOrigin: "KMHR", wantDest: "KPDX", wantDate: "0807", wantTime: "0119", wantETA: "0123", wantOn: "0119", wantFuel: 2.1,
+		},
+		{
+			name:     "IN",
+			text:     "00IN01,N45352W122360,KMHR,KPDX,0807,0126,0123,001.9",
+			wantType: "in", wantLat: 45.5867, wantLon: -122.6,
+			wantOrigin: "KMHR", wantDest: "KPDX", wantDate: "0807", wantTime: "0126", wantETA: "0123", wantIn: "0126", wantFuel: 1.9,
+		},
+	}
+
+	parser := &Parser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !parser.QuickCheck(tt.text) {
+				t.Fatal("QuickCheck failed")
+			}
+			result := parser.Parse(&acars.Message{ID: 1, Label: "44", Text: tt.text})
+			if result == nil {
+				t.Fatal("expected result, got nil")
+			}
+			r := result.(*Result)
+
+			if r.MessageType != tt.wantType {
+				t.Errorf("MessageType = %q, want %q", r.MessageType, tt.wantType)
+			}
+			if math.Abs(r.Latitude-tt.wantLat) > 0.001 || math.Abs(r.Longitude-tt.wantLon) > 0.001 {
+				t.Errorf("position = %f,%f, want %f,%f", r.Latitude, r.Longitude, tt.wantLat, tt.wantLon)
+			}
+			if r.FlightLevel != tt.wantFL || r.OnGround != tt.wantGround {
+				t.Errorf("FlightLevel = %d, OnGround = %v, want %d, %v", r.FlightLevel, r.OnGround, tt.wantFL, tt.wantGround)
+			}
+			if r.Origin != tt.wantOrigin || r.Destination != tt.wantDest {
+				t.Errorf("route = %s-%s, want %s-%s", r.Origin, r.Destination, tt.wantOrigin, tt.wantDest)
+			}
+			if r.ReportDate != tt.wantDate || r.ReportTime != tt.wantTime || r.ETA != tt.wantETA {
+				t.Errorf("date/time/ETA = %s/%s/%s, want %s/%s/%s", r.ReportDate, r.ReportTime, r.ETA, tt.wantDate, tt.wantTime, tt.wantETA)
+			}
+			if r.OffTime != tt.wantOff || r.OnTime != tt.wantOn || r.InTime != tt.wantIn {
+				t.Errorf("OFF/ON/IN = %q/%q/%q, want %q/%q/%q", r.OffTime, r.OnTime, r.InTime, tt.wantOff, tt.wantOn, tt.wantIn)
+			}
+			if r.FuelOnBoard != tt.wantFuel {
+				t.Errorf("FuelOnBoard = %v, want %v", r.FuelOnBoard, tt.wantFuel)
+			}
+		})
+	}
+}
+
+func TestParser_LegacyPOS(t *testing.T) {
+	parser := &Parser{}
+	result := parser.Parse(&acars.Message{ID: 1, Label: "44", Text: "POS01,S33561E151234,350,YSSY,YMML,1234,1530"})
+	if result == nil {
+		t.Fatal("expected result, got nil")
+	}
+	r := result.(*Result)
+	if r.MessageType != "pos" || r.ReportTime != "1234" || r.FlightLevel != 350 || r.ReportDate != "" {
+		t.Errorf("result = %+v", r)
+	}
+}
+
+func TestParser_Rejects(t *testing.T) {
+	parser := &Parser{}
+	for _, text := range []string{
+		"00POS01,N38338W121179",
+		"00XYZ01,N38338W121179,350,KMHR,KPDX,0807,0014,0123",
+		"INFO REQUEST",
+	} {
+		if result := parser.Parse(&acars.Message{Label: "44", Text: text}); result != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", text, result)
+		}
+	}
+}
@@ -6,31 +6,52 @@ import "acars_parser/internal/patterns"
 // Formats defines the known Label B2 message formats.
 var Formats = []patterns.Format{
 	// Oceanic clearance destination format.
-	// Example: CLRD TO EGLL
+	// Example: CLRD TO EGLL, CLEARED TO KJFK
 	{
 		Name:    "oceanic_dest",
-		Pattern: `CLRD TO (?P<dest>\w{4})`,
+		Pattern: `\b(?:CLRD|CLEARED) TO (?P<dest>[A-Z]{4})\b`,
 		Fields:  []string{"dest"},
 	},
 	// Oceanic fix format (lat/lon waypoint).
-	// Example: 50N030W, 51N040W
+	// Example: 50N030W, 5130N04000W
 	{
 		Name:    "oceanic_fix",
-		Pattern: `(?P<fix>\d{2}[NS]\d{3}[EW])`,
+		Pattern: `\b(?P<fix>\d{2}(?:\d{2})?[NS]\d{3}(?:\d{2})?[EW])\b`,
 		Fields:  []string{"fix"},
 	},
+	// NAT track letter.
+	// Example: NAT E, TRACK B, TRK Z
+	{
+		Name:    "track",
+		Pattern: `\b(?:NAT|TRACK|TRK)\s+(?P<track>[A-Z])\b`,
+		Fields:  []string{"track"},
+	},
+	// Oceanic entry point and the time it is to be crossed.
+	// Example: FM ELSIR/1342, CROSS 50N020W AT 1342Z
+	{
+		Name:    "entry",
+		Pattern: `\b(?:FM|FROM|CROSS)\s+(?P<entry>[A-Z]{5}|\d{2}(?:\d{2})?[NS]\d{3}(?:\d{2})?[EW]|\d{4}[NESW]|\d{2}[NESW]\d{2})\s*(?:/|AT\s+)(?P<time>\d{4})Z?\b`,
+		Fields:  []string{"entry", "time"},
+	},
+	// Cleared route, from VIA up to the entry, level or speed.
+	// Example: VIA ELSIR NAT E ELSIR 50N020W 51N030W FM ELSIR/1342
+	{
+		Name:    "route",
+		Pattern: `(?s)\bVIA\s+(?P<route>.+?)(?:\s+(?:FM|FROM|CROSS|MNTN|MAINTAIN|CLIMB|ATC)\b|\s+(?:FL\s?|F)\d{3}\b|\s+(?:MACH\s?|M)\.?\d{2,3}\b|\s*$)`,
+		Fields:  []string{"route"},
+	},
 	// Flight level format.
-	// Example: F350
+	// Example: F350, FL350
 	{
 		Name:    "flight_level",
-		Pattern: `F(?P<fl>\d{3})`,
+		Pattern: `\b(?:FL\s?|F)(?P<fl>\d{3})\b`,
 		Fields:  []string{"fl"},
 	},
 	// Mach number format.
-	// Example: M84, M084
+	// Example: M84, M084, M.82, MACH .82
 	{
 		Name:    "mach",
-		Pattern: `M0?(?P<mach>\d{2,3})`,
+		Pattern: `\b(?:MACH\s?|M)\.?0?(?P<mach>\d{2})\b`,
 		Fields:  []string{"mach"},
 	},
 	// Flight number from beginning of line.
@@ -1,4 +1,5 @@
-// Package labelb2 parses Label B2 oceanic clearance messages.
+// Package labelb2 parses oceanic clearances: those delivered on label A1 and
+// read back on label B2, and CLX clearances sent as free text.
 package labelb2
 
 import (
@@ -6,29 +7,40 @@ import (
 	"sync"
 
 	"acars_parser/internal/acars"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/navdata"
 	"acars_parser/internal/patterns"
 	"acars_parser/internal/registry"
 )
 
-// Result represents an oceanic clearance from label B2 messages.
+// Result represents an oceanic clearance.
 type Result struct {
 	MsgID       int64    `json:"message_id"`
 	Timestamp   string   `json:"timestamp"`
 	Tail        string   `json:"tail,omitempty"`
 	FlightNum   string   `json:"flight_num,omitempty"`
 	Destination string   `json:"destination,omitempty"`
-	Route       []string `json:"route,omitempty"`
+	Track       string   `json:"track,omitempty"`        // NAT track letter; empty for a random route.
+	TrackPoints []string `json:"track_points,omitempty"` // Points of the track, from the imported NAT tracks.
+	Route       []string `json:"route,omitempty"`        // Waypoints cleared after VIA.
+	EntryPoint  string   `json:"entry_point,omitempty"`
+	EntryTime   string   `json:"entry_time,omitempty"` // HHMM UTC at the entry point.
 	OceanicFix  []string `json:"oceanic_fixes,omitempty"`
-	FlightLevel string   `json:"flight_level,omitempty"`
-	Mach        string   `json:"mach,omitempty"`
+	FlightLevel string   `json:"flight_level,omitempty"` // e.g. FL350.
+	Mach        string   `json:"mach,omitempty"`         // e.g. M83.
 }
 
 func (r *Result) Type() string     { return "oceanic_clearance" }
 func (r *Result) MessageID() int64 { return r.MsgID }
 
-// Parser parses Label B2 oceanic clearance messages.
+// Parser parses oceanic clearances on their own labels: A1, on which they
+// are delivered, and B2, on which they are read back.
 type Parser struct{}
 
+// TextParser parses CLX oceanic clearances sent as free text on the
+// printer and cockpit uplink labels.
+type TextParser struct{}
+
 // Grok compiler singleton.
 var (
 	grokCompiler *patterns.Compiler
@@ -47,17 +59,46 @@ func getCompiler() (*patterns.Compiler, error) {
 
 func init() {
 	registry.Register(&Parser{})
+	registry.Register(&TextParser{})
 }
 
 func (p *Parser) Name() string     { return "labelb2" }
-func (p *Parser) Labels() []string { return []string{"B2"} }
+func (p *Parser) Labels() []string { return []string{"A1", "B2"} }
 func (p *Parser) Priority() int    { return 100 }
 
+// Version 2 added label A1, the track, route, entry point and entry time.
+// Version 3 added the points of the track.
+func (p *Parser) Version() int { return 3 }
+
 func (p *Parser) QuickCheck(text string) bool {
-	return true // Label check is sufficient for B2.
+	return true // Label check is sufficient for A1 and B2.
 }
 
 func (p *Parser) Parse(msg *acars.Message) registry.Result {
+	return parse(msg)
+}
+
+func (p *TextParser) Name() string     { return "oceanic_clearance" }
+func (p *TextParser) Labels() []string { return []string{"RA", "C1"} }
+func (p *TextParser) Priority() int    { return 100 }
+func (p *TextParser) Version() int     { return 3 }
+
+func (p *TextParser) Anchors() []string { return []string{"CLX", "OCEANIC"} }
+
+// QuickCheck looks for a clearance marked as a CLX or oceanic clearance, so
+// that departure clearances on the same labels are left to the PDC parser.
+func (p *TextParser) QuickCheck(text string) bool {
+	return (strings.Contains(text, "CLX") || strings.Contains(text, "OCEANIC")) &&
+		(strings.Contains(text, "CLRD TO") || strings.Contains(text, "CLEARED TO"))
+}
+
+func (p *TextParser) Parse(msg *acars.Message) registry.Result {
+	return parse(msg)
+}
+
+// parse reads an oceanic clearance, or returns nil if the message gives
+// neither a destination nor an oceanic fix.
+func parse(msg *acars.Message) registry.Result {
 	if msg.Text == "" {
 		return nil
 	}
@@ -82,6 +123,13 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 		switch match.FormatName {
 		case "oceanic_dest":
 			result.Destination = match.Captures["dest"]
+		case "track":
+			result.Track = match.Captures["track"]
+		case "entry":
+			result.EntryPoint = match.Captures["entry"]
+			result.EntryTime = match.Captures["time"]
+		case "route":
+			result.Route = routeOf(match.Captures["route"])
 		case "flight_level":
 			result.FlightLevel = "FL" + match.Captures["fl"]
 		case "mach":
@@ -99,6 +147,19 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 		}
 	}
 
+	if result.Track != "" {
+		result.TrackPoints = trackPoints(result.Track, msg.Timestamp)
+	}
+
+	// Without a FM or CROSS, the oceanic entry is the first point cleared,
+	// or the start of the track.
+	if result.EntryPoint == "" && len(result.Route) > 0 {
+		result.EntryPoint = result.Route[0]
+	}
+	if result.EntryPoint == "" && len(result.TrackPoints) > 0 {
+		result.EntryPoint = result.TrackPoints[0]
+	}
+
 	// Fallback: try to find flight number from first line if not found.
 	if result.FlightNum == "" {
 		lines := strings.Split(text, "\n")
@@ -128,6 +189,46 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 	return result
 }
 
+// trackPoints returns the points of a NAT track from the default NAT tracks
+// (see navdata.SetDefaultNATTracks), as valid at the message time, or nil
+// when none are loaded or the track is unknown.
+func trackPoints(letter, timestamp string) []string {
+	ts, ok := msgtime.Parse(timestamp)
+	if !ok {
+		return nil
+	}
+	track, ok := navdata.DefaultNATTracks().Lookup(letter, ts)
+	if !ok {
+		return nil
+	}
+	return track.Points
+}
+
+// routeOf returns the waypoints of a cleared route, leaving out the track
+// (given separately), the words around it, and a waypoint repeated straight
+// after itself, as CLX clearances give the entry point both after VIA and at
+// the start of the track.
+func routeOf(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\n' || r == '\r' || r == '\t' || r == ',' || r == '/' || r == '.'
+	})
+	var route []string
+	for i := 0; i < len(fields); i++ {
+		switch f := fields[i]; f {
+		case "NAT", "TRACK", "TRK":
+			if i+1 < len(fields) && len(fields[i+1]) == 1 {
+				i++ // The track letter.
+			}
+		case "RANDOM", "ROUTE", "RTE", "THEN", "DCT", "DIRECT":
+		default:
+			if len(route) == 0 || route[len(route)-1] != f {
+				route = append(route, f)
+			}
+		}
+	}
+	return route
+}
+
 func isAlpha(c byte) bool {
 	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
 }
@@ -142,12 +243,32 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 		ParserName: p.Name(),
 	}
 
-	// QuickCheck always passes for B2.
+	// QuickCheck always passes for A1 and B2.
 	trace.QuickCheck = &registry.QuickCheck{
 		Passed: true,
-		Reason: "Label check sufficient for B2",
+		Reason: "Label check sufficient for A1 and B2",
+	}
+	return traceFormats(trace, msg)
+}
+
+// ParseWithTrace implements registry.Traceable for detailed debugging.
+func (p *TextParser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
+	trace := &registry.TraceResult{
+		ParserName: p.Name(),
+	}
+
+	trace.QuickCheck = &registry.QuickCheck{
+		Passed: p.QuickCheck(msg.Text),
 	}
+	if !trace.QuickCheck.Passed {
+		trace.QuickCheck.Reason = "No CLX or OCEANIC clearance to a destination"
+		return trace
+	}
+	return traceFormats(trace, msg)
+}
 
+// traceFormats adds the formats tried to a trace whose QuickCheck passed.
+func traceFormats(trace *registry.TraceResult, msg *acars.Message) *registry.TraceResult {
 	compiler, err := getCompiler()
 	if err != nil {
 		trace.QuickCheck.Reason = "Failed to get compiler: " + err.Error()
@@ -165,7 +286,7 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 		})
 	}
 
-	// B2 matches if we found destination or oceanic fixes.
+	// A clearance matches if we found destination or oceanic fixes.
 	hasDestination := false
 	for _, ft := range compilerTrace.Formats {
 		if ft.Matched && ft.Name == "oceanic_dest" {
@@ -0,0 +1,121 @@
+package labelb2
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/navdata"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want *Result
+	}{
+		{
+			name: "CLX with NAT track",
+			text: "CLX 1259 160224 CZQX CLRNCE 555\nDAL48 CLRD TO KJFK VIA ELSIR\nNAT E\nELSIR 50N020W 51N030W 52N040W 51N050W ALLRY\nFM ELSIR/1342 MNTN F350 M083\nATC/ LEVEL CHANGE\nEND OF MESSAGE",
+			want: &Result{
+				FlightNum:   "DAL48",
+				Destination: "KJFK",
+				Track:       "E",
+				Route:       []string{"ELSIR", "50N020W", "51N030W", "52N040W", "51N050W", "ALLRY"},
+				EntryPoint:  "ELSIR",
+				EntryTime:   "1342",
+				OceanicFix:  []string{"50N020W", "51N030W", "52N040W", "51N050W"},
+				FlightLevel: "FL350",
+				Mach:        "M83",
+			},
+		},
+		{
+			name: "track only",
+			text: "BAW117 CLRD TO EGLL VIA TRACK B, FL350, M082",
+			want: &Result{FlightNum: "BAW117", Destination: "EGLL", Track: "B", FlightLevel: "FL350", Mach: "M82"},
+		},
+		{
+			name: "random route crossing a coordinate",
+			text: "UAL940 CLEARED TO EDDF VIA RANDOM ROUTE 5520N 5530N04000W 5620N DOGAL\nCROSS 5520N AT 0215Z FL370 MACH .84",
+			want: &Result{
+				FlightNum:   "UAL940",
+				Destination: "EDDF",
+				Route:       []string{"5520N", "5530N04000W", "5620N", "DOGAL"},
+				EntryPoint:  "5520N",
+				EntryTime:   "0215",
+				OceanicFix:  []string{"5530N04000W"},
+				FlightLevel: "FL370",
+				Mach:        "M84",
+			},
+		},
+		{
+			name: "no destination or fix",
+			text: "REQUEST OCEANIC CLEARANCE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := (&Parser{}).Parse(&acars.Message{ID: 1, Label: "A1", Text: tt.text})
+			if tt.want == nil {
+				if r != nil {
+					t.Fatalf("Parse() = %+v, want nil", r)
+				}
+				return
+			}
+			got, ok := r.(*Result)
+			if !ok {
+				t.Fatalf("Parse() = %T, want *Result", r)
+			}
+			tt.want.MsgID = 1
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResolvesTrack(t *testing.T) {
+	day := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	navdata.SetDefaultNATTracks(navdata.NewNATTracks([]navdata.NATTrack{{
+		Letter:    "B",
+		Points:    []string{"PIKIL", "5620N", "5730N", "5840N", "5750N", "PORGY", "HOIST"},
+		ValidFrom: day.Add(11*time.Hour + 30*time.Minute),
+		ValidTo:   day.Add(19 * time.Hour),
+	}}))
+	t.Cleanup(func() { navdata.SetDefaultNATTracks(nil) })
+
+	msg := &acars.Message{ID: 1, Label: "A1", Timestamp: "2026-07-01T10:15:00Z", Text: "BAW117 CLRD TO EGLL VIA TRACK B, FL350, M082"}
+	got := (&Parser{}).Parse(msg).(*Result)
+	want := []string{"PIKIL", "5620N", "5730N", "5840N", "5750N", "PORGY", "HOIST"}
+	if !reflect.DeepEqual(got.TrackPoints, want) {
+		t.Errorf("track points = %v, want %v", got.TrackPoints, want)
+	}
+	if got.EntryPoint != "PIKIL" {
+		t.Errorf("entry point = %q, want PIKIL", got.EntryPoint)
+	}
+
+	msg.Timestamp = "2026-07-01T21:00:00Z" // After the track expired.
+	if got := (&Parser{}).Parse(msg).(*Result); got.TrackPoints != nil {
+		t.Errorf("track points = %v, want none", got.TrackPoints)
+	}
+}
+
+func TestTextQuickCheck(t *testing.T) {
+	p := &TextParser{}
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"CLX 1259 160224 CZQX CLRNCE 555\nDAL48 CLRD TO KJFK VIA ELSIR", true},
+		{"OCEANIC CLEARANCE\nAAL100 CLEARED TO EGLL VIA TRACK C F360 M081", true},
+		{"PDC 001 AAL100 CLRD TO KLAX OFF 27L VIA SID", false},
+		{"REQUEST OCEANIC CLEARANCE", false},
+	}
+	for _, tt := range tests {
+		if got := p.QuickCheck(tt.text); got != tt.want {
+			t.Errorf("QuickCheck(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
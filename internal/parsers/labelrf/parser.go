@@ -58,6 +58,8 @@ func (p *Parser) Name() string     { return "labelrf" }
 func (p *Parser) Labels() []string { return []string{"RF"} }
 func (p *Parser) Priority() int    { return 100 }
 
+func (p *Parser) Anchors() []string { return []string{"FDASUB", "FDACOM", "FSTREQ"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	// Only process messages that contain flight subscription data.
 	return strings.Contains(text, "FDASUB") ||
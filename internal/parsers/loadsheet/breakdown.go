@@ -0,0 +1,77 @@
+package loadsheet
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// versionClassRe matches one class of a cabin version such as C30Y325:
+	// the class letter and its seat count.
+	versionClassRe = regexp.MustCompile(`([A-Z])(\d{1,3})`)
+	versionRe      = regexp.MustCompile(`^(?:[A-Z]\d{1,3})+$`)
+
+	// trimRe matches a take-off stabiliser setting, e.g. "STAB TO 1.2 UP",
+	// "STAB TRIM 4.5" or "THS 0.8 DN".
+	trimRe = regexp.MustCompile(`\b(?:STAB(?:\s+TRIM)?|THS|TRIM)\s+(?:TO\s+)?(\d{1,2}(?:\.\d{1,2})?)(?:\s*(UP|DN|DOWN|NU|ND)\b)?`)
+)
+
+// parseVersion returns the class letters of a cabin version such as
+// "J38W28Y214", in cabin order, or nil if the string is not a version.
+func parseVersion(version string) []string {
+	if !versionRe.MatchString(version) {
+		return nil
+	}
+	var classes []string
+	for _, m := range versionClassRe.FindAllStringSubmatch(version, -1) {
+		classes = append(classes, m[1])
+	}
+	return classes
+}
+
+// paxBreakdown returns the passengers by cabin class. Class-coded counts
+// ("F12 Y140") are used as given. Bare counts ("PAX/12/140") are only
+// assigned when the cabin version has the same number of classes, since
+// without it the split could equally be adults, children and infants.
+func paxBreakdown(fields map[string]string) map[string]int {
+	if coded := fields["pax_classes"]; coded != "" {
+		out := make(map[string]int)
+		for _, m := range versionClassRe.FindAllStringSubmatch(coded, -1) {
+			n, _ := strconv.Atoi(m[2])
+			out[m[1]] += n
+		}
+		return out
+	}
+
+	counts := strings.Split(fields["pax_breakdown"], "/")
+	classes := parseVersion(fields["version"])
+	if len(classes) < 2 || len(counts) != len(classes) {
+		return nil
+	}
+	out := make(map[string]int, len(classes))
+	for i, c := range classes {
+		n, err := strconv.Atoi(counts[i])
+		if err != nil {
+			return nil
+		}
+		out[c] += n
+	}
+	return out
+}
+
+// parseTrim returns the take-off stabiliser trim in a loadsheet, e.g.
+// "1.2 UP", or "" if none is given.
+func parseTrim(text string) string {
+	m := trimRe.FindStringSubmatch(text)
+	if m == nil {
+		return ""
+	}
+	switch m[2] {
+	case "DOWN", "ND":
+		m[2] = "DN"
+	case "NU":
+		m[2] = "UP"
+	}
+	return strings.TrimSpace(m[1] + " " + m[2])
+}
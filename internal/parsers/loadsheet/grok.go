@@ -17,8 +17,9 @@ type LoadsheetFormat struct {
 // LoadsheetFormats defines the known loadsheet message formats.
 // Order matters - more specific patterns should come first.
 var LoadsheetFormats = []LoadsheetFormat{
-	// Format A: Swiss/Lufthansa/Edelweiss/LOT/Saudia standard format with PAX line.
-	// This is the most common format with weights in KG.
+	// Format A: LIDO-style compact format (Swiss/Lufthansa/Edelweiss/LOT/Saudia)
+	// with PAX line. This is the most common format with weights in KG. The
+	// PAX/ counts follow the cabin classes of the version, when one is given.
 	// Example:
 	// LOADSHEET FINAL 1736 EDNO1
 	// LX1376/21     21JAN26
@@ -37,7 +38,7 @@ var LoadsheetFormats = []LoadsheetFormat{
 			`LOADSHEET\s+(?P<status>FINAL|PRELIM)\s+(?P<time>\d{4})\s+(?:EDNO?\s*(?P<edition>\d+))?` +
 			`.*?` +
 			`(?P<flight>[A-Z]{2}\d{1,4}[A-Z]?)/\d+\s+\d+[A-Z]{3}\d+\s*\n` +
-			`\s*(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<tail>[A-Z0-9-]+)\s+(?P<crew>\d+/\d+)` +
+			`\s*(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<tail>[A-Z0-9-]+)\s+(?P<crew>\d+/\d+)(?:[ \t]+(?P<version>(?:[A-Z]\d{1,3})+))?` +
 			`.*?` +
 			`ZFW\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
 			`.*?` +
@@ -54,6 +55,86 @@ var LoadsheetFormats = []LoadsheetFormat{
 		WeightUnit: "kg",
 	},
 
+	// Format A1: Sabre compact format with class-coded passenger counts and
+	// the cabin version after the crew.
+	// Example:
+	// LOADSHEET FINAL 1445 EDNO 1
+	// AA1234/17 17OCT26
+	// DFW ORD N123AA 2/5 F16Y156
+	// ZFW 55000 MAX 61688
+	// TOF 9000
+	// TOW 64000 MAX 72574
+	// TIF 5000
+	// LAW 59000 MAX 66360
+	// PAX F12 Y140 TTL 152
+	// MACZFW 22.1 MACTOW 21.8
+	// STAB TRIM 4.5
+	{
+		Name:   "sabre_classes",
+		Labels: []string{"C1", "RA", "H1", "30", "31", "2A", "22", "35", "45", "13", "42"},
+		Pattern: regexp.MustCompile(`(?s)` +
+			`LOADSHEET\s+(?P<status>FINAL|PRELIM)\s+(?P<time>\d{4})\s+(?:EDNO?\s*(?P<edition>\d+))?` +
+			`.*?` +
+			`(?P<flight>[A-Z0-9]{2}\d{1,4}[A-Z]?)/\d+\s+\d+[A-Z]{3}\d+\s*\n` +
+			`\s*(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<tail>[A-Z0-9-]+)\s+(?P<crew>\d+/\d+)(?:[ \t]+(?P<version>(?:[A-Z]\d{1,3})+))?` +
+			`.*?` +
+			`ZFW\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
+			`.*?` +
+			`TOF\s+(?P<tof>\d+)` +
+			`.*?` +
+			`TOW\s+(?P<tow>\d+)\s+MAX\s+(?P<tow_max>\d+)` +
+			`(?:.*?TIF\s+(?P<tif>\d+))?` +
+			`(?:.*?LAW\s+(?P<law>\d+)\s+MAX\s+(?P<law_max>\d+))?` +
+			`.*?` +
+			`PAX\s+(?P<pax_classes>(?:[A-Z]\d+\s+)+)TTL\s+(?P<pax_total>\d+)` +
+			`(?:.*?MACZFW\s+(?P<mac_zfw>[\d.]+))?` +
+			`(?:.*?MACTOW\s+(?P<mac_tow>[\d.]+))?`),
+		WeightUnit: "kg",
+	},
+
+	// Format A1b: Amadeus Altea format, the full IATA AHM 517 layout with
+	// spelt-out weight names, a passenger line and the cabin version.
+	// Example:
+	// LOADSHEET FINAL 0945 EDNO 2
+	// ALL WEIGHTS IN KILOGRAM
+	// FROM/TO FLIGHT      A/C REG  VERSION    CREW   DATE    TIME
+	// SYD MEL QF401/17    VHVXA    J12Y162    2/4    17OCT26 0945
+	// PASSENGER/CABIN BAG 12450 148/3/1 TTL 152 CAB 0
+	// DRY OPERATING WEIGHT 42100
+	// ZERO FUEL WEIGHT ACTUAL 56300 MAX 62732 L
+	// TAKE OFF FUEL 9800
+	// TAKE OFF WEIGHT ACTUAL 66100 MAX 79015
+	// TRIP FUEL 4200
+	// LANDING WEIGHT ACTUAL 61900 MAX 66360
+	// PAX/12/140
+	// MACZFW 24.6 MACTOW 23.9
+	// STAB TO 1.2 UP
+	{
+		Name:   "altea_ahm517",
+		Labels: []string{"C1", "RA", "H1", "30", "31", "2A", "22", "35", "45", "13", "42"},
+		Pattern: regexp.MustCompile(`(?s)` +
+			`LOADSHEET\s+(?P<status>FINAL|PRELIM)\s+(?P<time>\d{4})\s+(?:EDNO?\s*(?P<edition>\d+))?` +
+			`.*?` +
+			`ALL\s+WEIGHTS\s+IN\s+(?:KILOGRAMS?|KG)` +
+			`.*?` +
+			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<flight>[A-Z0-9]{2}\d{1,4}[A-Z]?)/\d+\s+(?P<tail>[A-Z0-9-]+)\s+(?P<version>(?:[A-Z]\d{1,3})+)\s+(?P<crew>\d+/\d+(?:/\d+)?)` +
+			`.*?` +
+			`PASSENGER/CABIN\s+BAG\s+\d+\s+[\d/]+\s+TTL\s+(?P<pax_total>\d+)` +
+			`(?:.*?DRY\s+OPERATING\s+WEIGHT\s+(?P<dow>\d+))?` +
+			`.*?` +
+			`ZERO\s+FUEL\s+WEIGHT\s+ACTUAL\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
+			`.*?` +
+			`TAKE\s+OFF\s+FUEL\s+(?P<tof>\d+)` +
+			`.*?` +
+			`TAKE\s+OFF\s+WEIGHT\s+ACTUAL\s+(?P<tow>\d+)\s+MAX\s+(?P<tow_max>\d+)` +
+			`(?:.*?TRIP\s+FUEL\s+(?P<tif>\d+))?` +
+			`(?:.*?LANDING\s+WEIGHT\s+ACTUAL\s+(?P<law>\d+)\s+MAX\s+(?P<law_max>\d+))?` +
+			`(?:.*?PAX/(?P<pax_breakdown>\d+(?:/\d+)+))?` +
+			`(?:.*?MACZFW\s+(?P<mac_zfw>[\d.]+))?` +
+			`(?:.*?MACTOW\s+(?P<mac_tow>[\d.]+))?`),
+		WeightUnit: "kg",
+	},
+
 	// Format A2: Standard format without PAX line (partial messages or cargo).
 	// Same as standard but LAW is the endpoint.
 	// Example:
@@ -70,7 +151,7 @@ var LoadsheetFormats = []LoadsheetFormat{
 			`LOADSHEET\s+(?P<status>FINAL|PRELIM)\s+(?P<time>\d{4})\s+(?:EDNO?\s*(?P<edition>\d+))?` +
 			`.*?` +
 			`(?P<flight>[A-Z]{2}\d{1,4}[A-Z]?)/\d+\s+\d+[A-Z]{3}\d+\s*\n` +
-			`\s*(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<tail>[A-Z0-9-]+)\s+(?P<crew>\d+/\d+)` +
+			`\s*(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<tail>[A-Z0-9-]+)\s+(?P<crew>\d+/\d+)(?:[ \t]+(?P<version>(?:[A-Z]\d{1,3})+))?` +
 			`.*?` +
 			`ZFW\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
 			`.*?` +
@@ -130,7 +211,7 @@ var LoadsheetFormats = []LoadsheetFormat{
 		Pattern: regexp.MustCompile(`(?s)` +
 			`L\s+O\s+A\s+D\s+S\s+H\s+E\s+E\s+T` +
 			`.*?` +
-			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<flight>[A-Z]{2}\s*\d{1,4})\s+(?P<tail>[A-Z0-9]+)\s+[A-Z0-9]+\s+(?P<crew>\d+/\d+)\s+(?P<date>\d+[A-Z]{3}\d+)` +
+			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<flight>[A-Z]{2}\s*\d{1,4})\s+(?P<tail>[A-Z0-9]+)\s+(?P<version>[A-Z0-9]+)\s+(?P<crew>\d+/\d+)\s+(?P<date>\d+[A-Z]{3}\d+)` +
 			`.*?` +
 			`ZERO\s+FUEL\s+WEIGHT\s+ACTUAL\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
 			`.*?` +
@@ -219,6 +300,7 @@ var LoadsheetFormats = []LoadsheetFormat{
 			`.*?` +
 			// Flight can be 2-letter or number+letter code (e.g., 3U6904 for Sichuan).
 			`FLIGHT:\s*(?P<flight>[A-Z0-9]{2}\d{1,4})/\d+[A-Z]{3}\d+\s+(?P<origin>[A-Z]{3,6})\s+(?P<tail>[A-Z0-9]+)` +
+			`(?:.*?VERSION:\s*(?P<version>[A-Z0-9]+))?` +
 			`.*?` +
 			`CREW:\s*(?P<crew>\d+/\d+(?:/\d+)?)` +
 			`.*?` +
@@ -319,7 +401,7 @@ var LoadsheetFormats = []LoadsheetFormat{
 			`.*?` +
 			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<flight>[A-Z]{2}\d{1,4})/\d+\s+(?P<tail>[A-Z0-9-]+)` +
 			`.*?` +
-			`(?P<crew>\d+/\d+)` +
+			`(?:(?P<version>(?:[A-Z]\d{1,3})+)\s+)?(?P<crew>\d+/\d+)` +
 			`.*?` +
 			`ZFW\s+ACT\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
 			`.*?` +
@@ -408,7 +490,7 @@ var LoadsheetFormats = []LoadsheetFormat{
 			`.*?` +
 			`(?P<flight>[A-Z]{2}\d{1,4})/\d+[A-Z]{3}\d+\s+\d+[A-Z]{3}\d+\s+EDNO-(?P<edition>\d+)` +
 			`.*?` +
-			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<tail>[A-Z0-9]+)\s+[A-Z0-9]+\s+(?P<crew>\d+/\d+)` +
+			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<tail>[A-Z0-9]+)\s+(?P<version>[A-Z0-9]+)\s+(?P<crew>\d+/\d+)` +
 			`.*?` +
 			`ZFW\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
 			`.*?` +
@@ -554,7 +636,7 @@ var LoadsheetFormats = []LoadsheetFormat{
 			`.*?` +
 			`FROM/TO\s+FLIGHT` +
 			`.*?` +
-			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<flight>[A-Z]{2}\d{1,4})/\d+[A-Z]{3}\s+(?P<tail>[A-Z0-9-]+)\s+[A-Z0-9]+\s+(?P<crew>\d+/\d+(?:/\d+)?)` +
+			`(?P<origin>[A-Z]{3})\s+(?P<destination>[A-Z]{3})\s+(?P<flight>[A-Z]{2}\d{1,4})/\d+[A-Z]{3}\s+(?P<tail>[A-Z0-9-]+)\s+(?P<version>[A-Z0-9]+)\s+(?P<crew>\d+/\d+(?:/\d+)?)` +
 			`.*?` +
 			`ZERO\s+FUEL\s+WEIGHT\s+EST\s+(?P<zfw>\d+)\s+MAX\s+(?P<zfw_max>\d+)` +
 			`.*?` +
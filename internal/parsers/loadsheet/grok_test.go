@@ -2,6 +2,8 @@ package loadsheet
 
 import (
 	"testing"
+
+	"acars_parser/internal/acars"
 )
 
 func TestMatchFormat_StandardKG(t *testing.T) {
@@ -175,4 +177,113 @@ func TestParseWeight(t *testing.T) {
 			t.Errorf("parseWeight(%q, %q) = %d, expected %d", tc.value, tc.unit, result, tc.expected)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestMatchFormat_SabreClasses(t *testing.T) {
+	text := `LOADSHEET FINAL 1445 EDNO 1
+AA1234/17 17OCT26
+DFW ORD N123AA 2/5 F16Y156
+ZFW 55000 MAX 61688
+TOF 9000
+TOW 64000 MAX 72574
+TIF 5000
+LAW 59000 MAX 66360
+PAX F12 Y140 TTL 152
+MACZFW 22.1 MACTOW 21.8
+STAB TRIM 4.5`
+
+	result, ok := (&Parser{}).Parse(&acars.Message{Label: "C1", Text: text}).(*Result)
+	if !ok {
+		t.Fatal("Expected sabre_classes format to match")
+	}
+	if result.FormatName != "sabre_classes" {
+		t.Errorf("Expected format name 'sabre_classes', got '%s'", result.FormatName)
+	}
+	if result.Flight != "AA1234" || result.Version != "F16Y156" || result.PAX != 152 || result.ZFW != 55000 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.PaxBreakdown) != 2 || result.PaxBreakdown["F"] != 12 || result.PaxBreakdown["Y"] != 140 {
+		t.Errorf("PaxBreakdown = %v", result.PaxBreakdown)
+	}
+	if result.MACTOW != "21.8" || result.Trim != "4.5" {
+		t.Errorf("MACTOW = %q, Trim = %q", result.MACTOW, result.Trim)
+	}
+}
+
+func TestMatchFormat_AlteaAHM517(t *testing.T) {
+	text := `LOADSHEET FINAL 0945 EDNO 2
+ALL WEIGHTS IN KILOGRAM
+FROM/TO FLIGHT      A/C REG  VERSION    CREW   DATE    TIME
+SYD MEL QF401/17    VHVXA    J12Y162    2/4    17OCT26 0945
+PASSENGER/CABIN BAG 12450 148/3/1 TTL 152 CAB 0
+DRY OPERATING WEIGHT 42100
+ZERO FUEL WEIGHT ACTUAL 56300 MAX 62732 L
+TAKE OFF FUEL 9800
+TAKE OFF WEIGHT ACTUAL 66100 MAX 79015
+TRIP FUEL 4200
+LANDING WEIGHT ACTUAL 61900 MAX 66360
+PAX/12/140
+MACZFW 24.6 MACTOW 23.9
+STAB TO 1.2 UP`
+
+	result, ok := (&Parser{}).Parse(&acars.Message{Label: "C1", Text: text}).(*Result)
+	if !ok {
+		t.Fatal("Expected altea_ahm517 format to match")
+	}
+	if result.FormatName != "altea_ahm517" {
+		t.Errorf("Expected format name 'altea_ahm517', got '%s'", result.FormatName)
+	}
+	if result.Flight != "QF401" || result.Origin != "SYD" || result.Destination != "MEL" || result.Tail != "VHVXA" {
+		t.Errorf("Unexpected header: %+v", result)
+	}
+	if result.DOW != 42100 || result.ZFW != 56300 || result.TOW != 66100 || result.LAW != 61900 || result.TIF != 4200 {
+		t.Errorf("Unexpected weights: %+v", result)
+	}
+	if result.PAX != 152 || result.PaxBreakdown["J"] != 12 || result.PaxBreakdown["Y"] != 140 {
+		t.Errorf("PAX = %d, PaxBreakdown = %v", result.PAX, result.PaxBreakdown)
+	}
+	if result.MACZFW != "24.6" || result.Trim != "1.2 UP" {
+		t.Errorf("MACZFW = %q, Trim = %q", result.MACZFW, result.Trim)
+	}
+}
+
+func TestPaxBreakdown(t *testing.T) {
+	tests := []struct {
+		fields map[string]string
+		want   map[string]int
+	}{
+		{map[string]string{"pax_classes": "F12 Y140 "}, map[string]int{"F": 12, "Y": 140}},
+		{map[string]string{"pax_breakdown": "20/21/312", "version": "J20W21Y312"}, map[string]int{"J": 20, "W": 21, "Y": 312}},
+		// Without a matching version the split is unknown.
+		{map[string]string{"pax_breakdown": "6/59"}, nil},
+		{map[string]string{"pax_breakdown": "6/59", "version": "C30Y325"}, map[string]int{"C": 6, "Y": 59}},
+		{map[string]string{"pax_breakdown": "6/59/1", "version": "C30Y325"}, nil},
+		{map[string]string{"pax_breakdown": "6/59", "version": "ETAZN"}, nil},
+	}
+	for _, tc := range tests {
+		got := paxBreakdown(tc.fields)
+		if len(got) != len(tc.want) {
+			t.Errorf("paxBreakdown(%v) = %v, want %v", tc.fields, got, tc.want)
+			continue
+		}
+		for k, v := range tc.want {
+			if got[k] != v {
+				t.Errorf("paxBreakdown(%v) = %v, want %v", tc.fields, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestParseTrim(t *testing.T) {
+	tests := map[string]string{
+		"STAB TO 1.2 UP":          "1.2 UP",
+		"STAB TRIM 4.5":           "4.5",
+		"THS 0.8 DOWN":            "0.8 DN",
+		"STAB TRIM SETTING\nNONE": "",
+		"ZFW 39754  MAX 46700":    "",
+	}
+	for text, want := range tests {
+		if got := parseTrim(text); got != want {
+			t.Errorf("parseTrim(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -30,11 +30,18 @@ type Result struct {
 	LAWMax       int    `json:"law_max,omitempty"`       // Maximum LAW (kg).
 	TOF          int    `json:"tof,omitempty"`           // Take Off Fuel (kg).
 	TIF          int    `json:"tif,omitempty"`           // Trip Fuel (kg).
+	DOW          int    `json:"dow,omitempty"`           // Dry Operating Weight (kg).
 	PAX          int    `json:"pax,omitempty"`           // Passenger count.
 	Crew         string `json:"crew,omitempty"`          // Crew configuration (e.g., "2/4").
+	Version      string `json:"version,omitempty"`       // Cabin version (e.g., "C30Y325").
 	MACZFW       string `json:"mac_zfw,omitempty"`       // MAC at ZFW.
 	MACTOW       string `json:"mac_tow,omitempty"`       // MAC at TOW.
+	Trim         string `json:"trim,omitempty"`          // Stabiliser trim for take-off (e.g., "1.2 UP").
 	Edition      string `json:"edition,omitempty"`       // Loadsheet edition number.
+
+	// PaxBreakdown is the passenger count by cabin class letter, e.g.
+	// {"C": 30, "Y": 325}.
+	PaxBreakdown map[string]int `json:"pax_breakdown,omitempty"`
 }
 
 func (r *Result) Type() string     { return "loadsheet" }
@@ -69,6 +76,9 @@ func (p *Parser) Labels() []string {
 
 func (p *Parser) Priority() int { return 60 } // Higher priority than weather.
 
+// Version 2 added the cabin version, passenger class breakdown, DOW and trim.
+func (p *Parser) Version() int { return 2 }
+
 // QuickCheck looks for loadsheet keywords.
 func (p *Parser) QuickCheck(text string) bool {
 	upper := strings.ToUpper(text)
@@ -128,10 +138,17 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 	result.TOF = parseWeight(fields["tof"], format.WeightUnit)
 	result.TIF = parseWeight(fields["tif"], format.WeightUnit)
 
-	// Extract passenger count.
+	result.DOW = parseWeight(fields["dow"], format.WeightUnit)
+
+	// Extract passenger count and, where the classes are known, the split.
 	if v, ok := fields["pax_total"]; ok {
 		result.PAX, _ = strconv.Atoi(v)
 	}
+	if classes := parseVersion(fields["version"]); classes != nil {
+		result.Version = fields["version"]
+	}
+	result.PaxBreakdown = paxBreakdown(fields)
+	result.Trim = parseTrim(msg.Text)
 
 	// Extract MAC values (these are percentages, not weights).
 	if v, ok := fields["mac_zfw"]; ok {
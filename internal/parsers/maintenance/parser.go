@@ -0,0 +1,316 @@
+// Package maintenance parses central maintenance computer fault reports
+// (Airbus CFDIU/CMC, Boeing CMC) sent on labels H2 and 32.
+//
+// The reports are loosely structured: keyed fields such as "ATA 21-31-34",
+// "PH 06" or "SOURCE: PACK CTLR 1" on lines or between slashes, with the
+// fault message as unkeyed text. Examples:
+//
+//	CFDIU FAULT REPORT
+//	FLT AFR1234 DATE 23MAY UTC 1432
+//	ATA 21-31-34 PH 06 CLASS 1
+//	PACK 1 REGUL FAULT
+//	SOURCE: PACK CTLR 1
+//
+//	CMC FAULT/ATA 36-11-00/CODE 36124/PHASE CRUISE/TIME 14:32Z/LRU PRSOV L/BLEED DUCT LEAK L
+package maintenance
+
+import (
+	"regexp"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// MaintenanceFault is one fault from a maintenance report.
+type MaintenanceFault struct {
+	ATA         string `json:"ata,omitempty"`          // ATA reference, e.g. "21-31-34".
+	ATAChapter  string `json:"ata_chapter,omitempty"`  // e.g. "21".
+	System      string `json:"system,omitempty"`       // ATA chapter name, e.g. "Air Conditioning".
+	Code        string `json:"fault_code,omitempty"`   // Fault or maintenance message code.
+	Text        string `json:"fault_text,omitempty"`   // Fault message.
+	LRU         string `json:"lru,omitempty"`          // Reporting or suspected unit.
+	Class       string `json:"class,omitempty"`        // Airbus fault class (1-3).
+	Phase       string `json:"phase,omitempty"`        // Flight phase as reported.
+	FlightPhase string `json:"flight_phase,omitempty"` // Normalised flight phase.
+	Time        string `json:"occurrence_time,omitempty"`
+}
+
+// MaintenanceFaultResult is a parsed maintenance fault report.
+type MaintenanceFaultResult struct {
+	MsgID        int64              `json:"message_id,omitempty"`
+	Timestamp    string             `json:"timestamp,omitempty"`
+	Tail         string             `json:"tail,omitempty"`
+	Source       string             `json:"source,omitempty"` // Reporting system, e.g. "CFDIU", "CMC".
+	FlightNumber string             `json:"flight_number,omitempty"`
+	Date         string             `json:"date,omitempty"` // As reported.
+	Faults       []MaintenanceFault `json:"faults"`
+}
+
+func (r *MaintenanceFaultResult) Type() string     { return "maintenance_fault" }
+func (r *MaintenanceFaultResult) MessageID() int64 { return r.MsgID }
+
+var (
+	// Keys of the report fields, longest first where one starts another.
+	// FAULT and FAILURE only introduce a field with a colon, as they also end
+	// fault messages ("PACK 1 REGUL FAULT").
+	keyRe = regexp.MustCompile(`\b(FAULT CODE|FLT PHASE|FLIGHT PHASE|FLT PH|PHASE|PH|ATA|CLASS|UTC|GMT|TIME|DATE|FLT|FLIGHT|CODE|FDE|MSG NO|LRU|SOURCE|SRC|IDENT)\b[:.]?\s*|\b(?:FAULT|FAILURE)(?: MSG| MESSAGE)?:\s*`)
+
+	// Reporting system headers.
+	sourceRe = regexp.MustCompile(`^(CFDIU|CFDS|CMCS|CMC|CMS|OMS|MCDU)\b`)
+
+	// Segments with nothing to report: headers and bare keywords.
+	headerRe = regexp.MustCompile(`^(?:(?:CFDIU|CFDS|CMCS|CMC|CMS|OMS|MCDU)\b.*|(?:REAL TIME |CURRENT LEG |POST FLIGHT )?(?:FAULT|FAILURE|MAINT(?:ENANCE)?)(?: REPORT| MSG| MESSAGE)?)$`)
+
+	// ATA references: chapter, section and subject, with or without
+	// separators ("21-31-34", "213134", "21-31", "2131").
+	ataRe = regexp.MustCompile(`^(\d{2})[- ]?(\d{2})(?:[- ]?(\d{2}))?\b`)
+)
+
+// Parser parses maintenance fault reports.
+type Parser struct{}
+
+func init() {
+	registry.Register(&Parser{})
+}
+
+func (p *Parser) Name() string     { return "maintenance_fault" }
+func (p *Parser) Labels() []string { return []string{"H2", "32"} }
+func (p *Parser) Priority() int    { return 50 }
+
+func (p *Parser) Anchors() []string { return []string{"ATA"} }
+
+func (p *Parser) QuickCheck(text string) bool {
+	return strings.Contains(text, "ATA") &&
+		(strings.Contains(text, "FAULT") || strings.Contains(text, "FAILURE") ||
+			strings.Contains(text, "CMC") || strings.Contains(text, "CFD"))
+}
+
+func (p *Parser) Parse(msg *acars.Message) registry.Result {
+	if !p.QuickCheck(msg.Text) {
+		return nil
+	}
+
+	result := &MaintenanceFaultResult{
+		MsgID:     int64(msg.ID),
+		Timestamp: msg.Timestamp,
+		Tail:      msg.Tail,
+	}
+
+	var cur MaintenanceFault
+	flush := func() {
+		if cur.ATA != "" || cur.Code != "" {
+			result.Faults = append(result.Faults, cur)
+		}
+		cur = MaintenanceFault{}
+	}
+	// set fills a fault field; a field already filled starts the next fault.
+	set := func(field *string, v string) {
+		if v == "" {
+			return
+		}
+		if *field != "" {
+			flush()
+		}
+		*field = v
+	}
+
+	for _, seg := range segments(msg.Text) {
+		if m := sourceRe.FindStringSubmatch(seg); m != nil && result.Source == "" {
+			result.Source = m[1]
+		}
+		if headerRe.MatchString(seg) {
+			continue
+		}
+
+		keys := keyRe.FindAllStringSubmatchIndex(seg, -1)
+		// Text before the first key is the fault message.
+		free := seg
+		if len(keys) > 0 {
+			free = seg[:keys[0][0]]
+		}
+		set(&cur.Text, cleanValue(free))
+
+		for i, k := range keys {
+			end := len(seg)
+			if i+1 < len(keys) {
+				end = keys[i+1][0]
+			}
+			value := cleanValue(seg[k[1]:end])
+			key := "FAULT"
+			if k[2] >= 0 {
+				key = seg[k[2]:k[3]]
+			}
+
+			switch key {
+			case "ATA":
+				ata, chapter := normaliseATA(value)
+				if ata == "" {
+					continue
+				}
+				set(&cur.ATA, ata)
+				cur.ATAChapter = chapter
+				cur.System = ataChapters[chapter]
+			case "FAULT CODE", "CODE", "FDE", "MSG NO":
+				set(&cur.Code, value)
+			case "FAULT":
+				set(&cur.Text, value)
+			case "LRU", "SOURCE", "SRC", "IDENT":
+				set(&cur.LRU, value)
+			case "CLASS":
+				set(&cur.Class, value)
+			case "FLT PHASE", "FLIGHT PHASE", "FLT PH", "PHASE", "PH":
+				set(&cur.Phase, value)
+				cur.FlightPhase = normalisePhase(value)
+			case "UTC", "GMT", "TIME":
+				set(&cur.Time, value)
+			case "DATE":
+				if result.Date == "" {
+					result.Date = value
+				}
+			case "FLT", "FLIGHT":
+				if result.FlightNumber == "" {
+					result.FlightNumber = value
+				}
+			}
+		}
+	}
+	flush()
+
+	if len(result.Faults) == 0 {
+		return nil
+	}
+	return result
+}
+
+// segments splits a report into its lines and slash-separated fields.
+// Slashes inside values ("N/A", "T/O", "L/G") are kept.
+func segments(text string) []string {
+	var out []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		for _, seg := range splitSlashes(line) {
+			if seg = strings.TrimSpace(seg); seg != "" {
+				out = append(out, seg)
+			}
+		}
+	}
+	return out
+}
+
+// splitSlashes splits a line at slashes that separate fields: those next to
+// a space or a word of two or more characters. Slashes between single
+// characters are abbreviations.
+func splitSlashes(line string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		if line[i] != '/' {
+			continue
+		}
+		before := strings.TrimRight(line[start:i], " ")
+		after := line[i+1:]
+		if lastWordLen(before) >= 2 || firstWordLen(after) >= 2 || strings.HasSuffix(line[:i], " ") || strings.HasPrefix(after, " ") {
+			parts = append(parts, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, line[start:])
+}
+
+func lastWordLen(s string) int {
+	return len(s) - strings.LastIndexAny(s, " /") - 1
+}
+
+func firstWordLen(s string) int {
+	if i := strings.IndexAny(s, " /"); i >= 0 {
+		return i
+	}
+	return len(s)
+}
+
+// cleanValue trims a field value and collapses its spaces.
+func cleanValue(s string) string {
+	return strings.Join(strings.Fields(strings.Trim(s, " :.,")), " ")
+}
+
+// normaliseATA formats an ATA reference as "CC-SS-SS" (or "CC-SS") and
+// returns it with its chapter.
+func normaliseATA(s string) (ata, chapter string) {
+	m := ataRe.FindStringSubmatch(s)
+	if m == nil {
+		return "", ""
+	}
+	ata = m[1] + "-" + m[2]
+	if m[3] != "" {
+		ata += "-" + m[3]
+	}
+	return ata, m[1]
+}
+
+// airbusPhases are the Airbus FWC flight phases, 1 to 10.
+var airbusPhases = map[string]string{
+	"1": "ground", "2": "ground", "3": "taxi", "4": "takeoff", "5": "takeoff",
+	"6": "en route", "7": "approach", "8": "landing", "9": "taxi", "10": "ground",
+}
+
+// phaseWords maps reported phase names and abbreviations to normalised
+// flight phases.
+var phaseWords = map[string]string{
+	"GROUND": "ground", "GND": "ground", "PREFLIGHT": "ground", "POSTFLIGHT": "ground",
+	"TAXI": "taxi", "TAXI OUT": "taxi", "TAXI IN": "taxi",
+	"TAKEOFF": "takeoff", "TAKE OFF": "takeoff", "T/O": "takeoff", "TO": "takeoff",
+	"CLIMB": "climb", "CLB": "climb",
+	"CRUISE": "cruise", "CRZ": "cruise",
+	"DESCENT": "descent", "DES": "descent",
+	"APPROACH": "approach", "APP": "approach", "APPR": "approach",
+	"LANDING": "landing", "LDG": "landing", "ROLLOUT": "landing",
+}
+
+// normalisePhase returns the flight phase of an Airbus phase number or a
+// phase name, or "" if it is not recognised.
+func normalisePhase(s string) string {
+	if p, ok := airbusPhases[strings.TrimLeft(s, "0")]; ok {
+		return p
+	}
+	return phaseWords[s]
+}
+
+// ataChapters names the ATA 100 chapters seen in fault reports.
+var ataChapters = map[string]string{
+	"21": "Air Conditioning",
+	"22": "Auto Flight",
+	"23": "Communications",
+	"24": "Electrical Power",
+	"25": "Equipment/Furnishings",
+	"26": "Fire Protection",
+	"27": "Flight Controls",
+	"28": "Fuel",
+	"29": "Hydraulic Power",
+	"30": "Ice and Rain Protection",
+	"31": "Indicating/Recording Systems",
+	"32": "Landing Gear",
+	"33": "Lights",
+	"34": "Navigation",
+	"35": "Oxygen",
+	"36": "Pneumatic",
+	"38": "Water/Waste",
+	"42": "Integrated Modular Avionics",
+	"44": "Cabin Systems",
+	"45": "Central Maintenance System",
+	"46": "Information Systems",
+	"47": "Inert Gas System",
+	"49": "Airborne Auxiliary Power",
+	"52": "Doors",
+	"56": "Windows",
+	"70": "Standard Practices - Engines",
+	"71": "Power Plant",
+	"72": "Engine",
+	"73": "Engine Fuel and Control",
+	"74": "Ignition",
+	"75": "Air",
+	"76": "Engine Controls",
+	"77": "Engine Indicating",
+	"78": "Exhaust",
+	"79": "Oil",
+	"80": "Starting",
+}
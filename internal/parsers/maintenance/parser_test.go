@@ -0,0 +1,104 @@
+package maintenance
+
+import (
+	"reflect"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestParser_Parse(t *testing.T) {
+	tests := []struct {
+		name       string
+		label      string
+		text       string
+		wantSource string
+		wantFlight string
+		wantDate   string
+		want       []MaintenanceFault
+	}{
+		{
+			name:  "Airbus CFDIU report",
+			label: "H2",
+			text: "CFDIU FAULT REPORT\n" +
+				"FLT AFR1234 DATE 23MAY UTC 1432\n" +
+				"ATA 21-31-34 PH 06 CLASS 1\n" +
+				"PACK 1 REGUL FAULT\n" +
+				"SOURCE: PACK CTLR 1",
+			wantSource: "CFDIU",
+			wantFlight: "AFR1234",
+			wantDate:   "23MAY",
+			want: []MaintenanceFault{{
+				ATA: "21-31-34", ATAChapter: "21", System: "Air Conditioning",
+				Text: "PACK 1 REGUL FAULT", LRU: "PACK CTLR 1", Class: "1",
+				Phase: "06", FlightPhase: "en route", Time: "1432",
+			}},
+		},
+		{
+			name:       "Boeing CMC slash separated",
+			label:      "32",
+			text:       "CMC FAULT/ATA 36-11-00/CODE 36124/PHASE CRUISE/TIME 14:32Z/LRU PRSOV L/BLEED DUCT LEAK L",
+			wantSource: "CMC",
+			want: []MaintenanceFault{{
+				ATA: "36-11-00", ATAChapter: "36", System: "Pneumatic",
+				Code: "36124", Text: "BLEED DUCT LEAK L", LRU: "PRSOV L",
+				Phase: "CRUISE", FlightPhase: "cruise", Time: "14:32Z",
+			}},
+		},
+		{
+			name:  "two faults",
+			label: "H2",
+			text: "FAULT MSG: HYD G SYS LO PR ATA 2930 PH 4 UTC 0812\n" +
+				"FAULT MSG: L/G SHOCK ABSORBER FAULT ATA 321100 PH 8 UTC 0955",
+			want: []MaintenanceFault{
+				{
+					ATA: "29-30", ATAChapter: "29", System: "Hydraulic Power",
+					Text: "HYD G SYS LO PR", Phase: "4", FlightPhase: "takeoff", Time: "0812",
+				},
+				{
+					ATA: "32-11-00", ATAChapter: "32", System: "Landing Gear",
+					Text: "L/G SHOCK ABSORBER FAULT", Phase: "8", FlightPhase: "landing", Time: "0955",
+				},
+			},
+		},
+	}
+
+	parser := &Parser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !parser.QuickCheck(tt.text) {
+				t.Fatal("QuickCheck failed")
+			}
+			result := parser.Parse(&acars.Message{ID: 3, Label: tt.label, Text: tt.text})
+			if result == nil {
+				t.Fatal("expected result, got nil")
+			}
+			r := result.(*MaintenanceFaultResult)
+			if r.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", r.Source, tt.wantSource)
+			}
+			if r.FlightNumber != tt.wantFlight {
+				t.Errorf("FlightNumber = %q, want %q", r.FlightNumber, tt.wantFlight)
+			}
+			if r.Date != tt.wantDate {
+				t.Errorf("Date = %q, want %q", r.Date, tt.wantDate)
+			}
+			if !reflect.DeepEqual(r.Faults, tt.want) {
+				t.Errorf("Faults = %+v, want %+v", r.Faults, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_Rejects(t *testing.T) {
+	parser := &Parser{}
+	for _, text := range []string{
+		"02A1432KSFO1N37372W122225 010P053269017G 100P037265031G",
+		"CMC FAULT REPORT\nNO FAULTS",
+		"FAULT MSG: ATA CHECK PENDING",
+	} {
+		if result := parser.Parse(&acars.Message{Label: "H2", Text: text}); result != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", text, result)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package pdc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// captureFields are the capture group names a format can use, as read by
+// buildResult.
+var captureFields = map[string]bool{
+	"flight": true, "origin": true, "origin_iata": true, "destination": true,
+	"dest_iata": true, "waypoint": true, "aircraft": true, "runway": true,
+	"runway2": true, "sid": true, "route": true, "squawk": true,
+	"altitude": true, "init_alt": true, "flight_level": true, "freq": true,
+	"atis": true, "dep_time": true,
+}
+
+// placeholderRe matches a {PLACEHOLDER} reference, which unlike a repetition
+// count such as {3} starts with a letter.
+var placeholderRe = regexp.MustCompile(`\{([A-Z][A-Z0-9_]*)\}`)
+
+// AddFormats validates and compiles formats and adds them to the compiler,
+// ordered by priority. A format added with the same priority as a built-in one
+// comes after it. Names must be unique, every {PLACEHOLDER} must be a base
+// pattern, and every field must be a named group of the pattern that the
+// parser reads.
+func (c *Compiler) AddFormats(formats ...PDCFormat) error {
+	names := make(map[string]bool, len(c.formats)+len(formats))
+	for _, f := range c.formats {
+		names[f.Name] = true
+	}
+
+	added := make([]PDCFormat, 0, len(formats))
+	for i, f := range formats {
+		if err := c.compileFormat(&f); err != nil {
+			if f.Name == "" {
+				return fmt.Errorf("format %d: %w", i+1, err)
+			}
+			return fmt.Errorf("format %s: %w", f.Name, err)
+		}
+		if names[f.Name] {
+			return fmt.Errorf("format %s: duplicate name", f.Name)
+		}
+		names[f.Name] = true
+		added = append(added, f)
+	}
+
+	c.formats = append(c.formats, added...)
+	sort.SliceStable(c.formats, func(i, j int) bool { return c.formats[i].Priority > c.formats[j].Priority })
+	return nil
+}
+
+// compileFormat checks a format definition and compiles its pattern.
+func (c *Compiler) compileFormat(f *PDCFormat) error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("no name")
+	}
+	if strings.TrimSpace(f.Pattern) == "" {
+		return errors.New("no pattern")
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(f.Pattern, -1) {
+		if _, ok := c.basePatterns[m[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {%s}", m[1])
+		}
+	}
+	re, err := regexp.Compile(c.expand(f.Pattern))
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]bool)
+	for _, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if !captureFields[name] {
+			return fmt.Errorf("unknown capture group %q", name)
+		}
+		groups[name] = true
+	}
+	if len(f.Fields) == 0 {
+		return errors.New("no fields")
+	}
+	for _, field := range f.Fields {
+		if !groups[field] {
+			return fmt.Errorf("field %q is not a named group of the pattern", field)
+		}
+	}
+	f.Compiled = re
+	return nil
+}
+
+// ReadFormats reads format definitions from a JSON or YAML file, or from every
+// .json, .yaml and .yml file in a directory in name order. A file holds a list
+// of formats:
+//
+//	[{"name": "example_airline",
+//	  "pattern": "(?P<flight>{FLIGHT})\\s+CLRD\\s+TO\\s+(?P<destination>{ICAO})",
+//	  "fields": ["flight", "destination"],
+//	  "priority": 1}]
+func ReadFormats(path string) ([]PDCFormat, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readFormatFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var formats []PDCFormat
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		if e.IsDir() {
+			continue
+		}
+		fs, err := readFormatFile(filepath.Join(path, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, fs...)
+	}
+	return formats, nil
+}
+
+// readFormatFile reads the formats in one file, as JSON for a .json file and
+// as YAML otherwise. Unknown keys are errors, to catch misspelt ones.
+func readFormatFile(path string) ([]PDCFormat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var formats []PDCFormat
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&formats)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(&formats); errors.Is(err, io.EOF) {
+			err = nil // An empty file.
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return formats, nil
+}
+
+// loadedCompiler replaces the built-in compiler once formats are loaded.
+var loadedCompiler atomic.Pointer[Compiler]
+
+// LoadFormats reads format definitions from a file or directory (see
+// ReadFormats) and makes the parser use them alongside the built-in formats.
+// It returns the number of formats loaded. Call it before parsing starts.
+func LoadFormats(path string) (int, error) {
+	formats, err := ReadFormats(path)
+	if err != nil {
+		return 0, err
+	}
+	c := NewCompiler()
+	if err := c.Compile(); err != nil {
+		return 0, err
+	}
+	if err := c.AddFormats(formats...); err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	loadedCompiler.Store(c)
+	return len(formats), nil
+}
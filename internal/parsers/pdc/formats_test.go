@@ -0,0 +1,115 @@
+package pdc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testFormatsYAML = `
+- name: test_cleared
+  pattern: 'TESTPDC\s+(?P<flight>{FLIGHT})\s+CLEARED\s+(?P<origin>{ICAO})\s+TO\s+(?P<destination>{ICAO})'
+  fields: [flight, origin, destination]
+  priority: 1
+`
+
+const testFormatsJSON = `[
+  {"name": "test_squawk", "pattern": "TESTPDC\\s+(?P<flight>{FLIGHT}).*?SQK\\s+(?P<squawk>{SQUAWK})", "fields": ["flight", "squawk"]}
+]`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "b.json", testFormatsJSON)
+	writeFile(t, dir, "a.yaml", testFormatsYAML)
+	writeFile(t, dir, "README.md", "not a format file")
+
+	formats, err := ReadFormats(dir)
+	if err != nil {
+		t.Fatalf("ReadFormats: %v", err)
+	}
+	if len(formats) != 2 || formats[0].Name != "test_cleared" || formats[1].Name != "test_squawk" {
+		t.Fatalf("ReadFormats = %+v, want test_cleared then test_squawk", formats)
+	}
+	if formats[0].Priority != 1 || len(formats[0].Fields) != 3 {
+		t.Errorf("test_cleared = %+v", formats[0])
+	}
+
+	if _, err := ReadFormats(writeFile(t, dir, "bad.json", `[{"name": "x", "patern": "X"}]`)); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestAddFormats(t *testing.T) {
+	c := NewCompiler()
+	if err := c.Compile(); err != nil {
+		t.Fatalf("failed to compile patterns: %v", err)
+	}
+	builtin := len(c.formats)
+
+	formats, err := ReadFormats(writeFile(t, t.TempDir(), "formats.yml", testFormatsYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	formats = append(formats, PDCFormat{
+		Name:    "test_squawk",
+		Pattern: `TESTPDC\s+(?P<flight>{FLIGHT}).*?SQK\s+(?P<squawk>{SQUAWK})`,
+		Fields:  []string{"flight", "squawk"},
+	})
+	if err := c.AddFormats(formats...); err != nil {
+		t.Fatalf("AddFormats: %v", err)
+	}
+	if len(c.formats) != builtin+2 {
+		t.Fatalf("compiler has %d formats, want %d", len(c.formats), builtin+2)
+	}
+	// Priority 1 sorts before the built-in formats, priority 0 after them.
+	if c.formats[0].Name != "test_cleared" || c.formats[len(c.formats)-1].Name != "test_squawk" {
+		t.Errorf("formats not ordered by priority: first %s, last %s", c.formats[0].Name, c.formats[len(c.formats)-1].Name)
+	}
+
+	result := c.Parse("TESTPDC QFA401 CLEARED YSSY TO YMML")
+	if result == nil || result.FormatName != "test_cleared" || result.Origin != "YSSY" || result.Destination != "YMML" {
+		t.Errorf("Parse = %+v, want test_cleared YSSY-YMML", result)
+	}
+}
+
+func TestAddFormatsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		format PDCFormat
+		want   string
+	}{
+		{"no name", PDCFormat{Pattern: `(?P<flight>{FLIGHT})`, Fields: []string{"flight"}}, "format 1: no name"},
+		{"duplicate", PDCFormat{Name: "us_delta", Pattern: `(?P<flight>{FLIGHT})`, Fields: []string{"flight"}}, "duplicate name"},
+		{"placeholder", PDCFormat{Name: "x", Pattern: `(?P<flight>{FLIGHTNO})`, Fields: []string{"flight"}}, "unknown placeholder {FLIGHTNO}"},
+		{"regex", PDCFormat{Name: "x", Pattern: `(?P<flight>{FLIGHT}`, Fields: []string{"flight"}}, "missing closing )"},
+		{"group", PDCFormat{Name: "x", Pattern: `(?P<callsign>{FLIGHT})`, Fields: []string{"callsign"}}, `unknown capture group "callsign"`},
+		{"field", PDCFormat{Name: "x", Pattern: `(?P<flight>{FLIGHT})`, Fields: []string{"flight", "squawk"}}, `field "squawk"`},
+		{"no fields", PDCFormat{Name: "x", Pattern: `(?P<flight>{FLIGHT})`}, "no fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompiler()
+			if err := c.Compile(); err != nil {
+				t.Fatal(err)
+			}
+			err := c.AddFormats(tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("AddFormats error = %v, want %q", err, tt.want)
+			}
+			if len(c.formats) != len(PDCFormats) {
+				t.Errorf("a rejected format was added")
+			}
+		})
+	}
+}
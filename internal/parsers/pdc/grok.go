@@ -47,15 +47,20 @@ var BasePatterns = map[string]string{
 }
 
 // PDCFormat represents a specific PDC message format with named capture groups.
+// Formats can also be loaded from files (see LoadFormats).
 type PDCFormat struct {
-	Name     string
-	Pattern  string         // Pattern with {PLACEHOLDER} syntax
-	Compiled *regexp.Regexp // Compiled regex (populated by Compile)
-	Fields   []string       // Field names in capture order
+	Name     string         `json:"name" yaml:"name"`
+	Pattern  string         `json:"pattern" yaml:"pattern"` // Pattern with {PLACEHOLDER} syntax
+	Compiled *regexp.Regexp `json:"-" yaml:"-"`             // Compiled regex (populated by Compile)
+	Fields   []string       `json:"fields" yaml:"fields"`   // Field names in capture order
+	// Priority orders a format among the others: when two capture as many
+	// fields, the higher priority wins. Built-in formats have priority 0.
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
 }
 
 // PDCFormats defines the known PDC message formats.
-// Order matters - more specific patterns should come first.
+// Order matters - when two formats capture as many fields, the earlier wins,
+// so more specific patterns should come first.
 var PDCFormats = []PDCFormat{
 	// Format 0: Compact APCDC format (single line)
 	// Example: C32PDC         1APCDC AC0564/31/31 YVR SFO 524 1804Z/0076/0000/  7
@@ -668,9 +673,9 @@ type PDCResult struct {
 	FormatName      string
 	FlightNumber    string
 	Origin          string // ICAO origin (4-letter code)
-	OriginIATA      string // IATA origin (3-letter code) - not used for enrichment
+	OriginIATA      string // IATA origin (3-letter code), resolved to Origin when unset
 	Destination     string // ICAO destination (4-letter code)
-	DestIATA        string // IATA destination (3-letter code) - not used for enrichment
+	DestIATA        string // IATA destination (3-letter code), resolved to Destination when unset
 	Aircraft        string
 	Runway          string
 	SID             string
@@ -683,12 +688,17 @@ type PDCResult struct {
 	DepartureTime   string
 }
 
-// Parse attempts to parse a PDC message using all known formats.
-// Returns the first successful match.
+// Parse attempts to parse a PDC message using all known formats. Several
+// patterns overlap, so every format is tried and the match that captures the
+// most fields is returned; on a tie the earlier format wins.
 func (c *Compiler) Parse(text string) *PDCResult {
 	upperText := strings.ToUpper(text)
 
-	for _, format := range c.formats {
+	var best *PDCFormat
+	var bestMatch []string
+	bestScore := 0
+	for i := range c.formats {
+		format := &c.formats[i]
 		if format.Compiled == nil {
 			continue
 		}
@@ -697,108 +707,129 @@ func (c *Compiler) Parse(text string) *PDCResult {
 		if match == nil {
 			continue
 		}
+		if score := captureScore(format, match); best == nil || score > bestScore {
+			best, bestMatch, bestScore = format, match, score
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+	return buildResult(best, bestMatch, upperText)
+}
 
-		result := &PDCResult{
-			FormatName: format.Name,
+// captureScore counts the named groups a match captured text for.
+func captureScore(format *PDCFormat, match []string) int {
+	score := 0
+	for i, name := range format.Compiled.SubexpNames() {
+		if i > 0 && name != "" && match[i] != "" {
+			score++
 		}
+	}
+	return score
+}
 
-		// Extract named groups.
-		for i, name := range format.Compiled.SubexpNames() {
-			if i == 0 || name == "" {
-				continue
+// buildResult fills a result from a format's match, then extracts the fields
+// the pattern did not capture from the whole text.
+func buildResult(format *PDCFormat, match []string, upperText string) *PDCResult {
+	result := &PDCResult{
+		FormatName: format.Name,
+	}
+
+	// Extract named groups.
+	for i, name := range format.Compiled.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		value := match[i]
+		switch name {
+		case "flight":
+			result.FlightNumber = value
+		case "origin":
+			result.Origin = value
+		case "origin_iata":
+			// IATA origin code - stored separately, see airport.ResolveIATA.
+			result.OriginIATA = value
+		case "destination":
+			result.Destination = value
+		case "dest_iata":
+			// IATA destination code - stored separately, see airport.ResolveIATA.
+			result.DestIATA = value
+		case "waypoint":
+			// Initial waypoint (for waypoint-based departures without named SID).
+			if result.SID == "" {
+				result.SID = value
 			}
-			value := match[i]
-			switch name {
-			case "flight":
-				result.FlightNumber = value
-			case "origin":
-				result.Origin = value
-			case "origin_iata":
-				// IATA origin code - stored separately, not used for enrichment.
-				result.OriginIATA = value
-			case "destination":
-				result.Destination = value
-			case "dest_iata":
-				// IATA destination code - stored separately, not used for enrichment.
-				result.DestIATA = value
-			case "waypoint":
-				// Initial waypoint (for waypoint-based departures without named SID).
-				if result.SID == "" {
-					result.SID = value
-				}
-			case "aircraft":
-				result.Aircraft = value
-			case "runway":
+		case "aircraft":
+			result.Aircraft = value
+		case "runway":
+			result.Runway = value
+		case "runway2":
+			// Alternate runway position (e.g., "EXPECT RUNWAY" at end of Australian format).
+			if result.Runway == "" {
 				result.Runway = value
-			case "runway2":
-				// Alternate runway position (e.g., "EXPECT RUNWAY" at end of Australian format).
-				if result.Runway == "" {
-					result.Runway = value
-				}
-			case "sid":
-				result.SID = normaliseSID(value)
-			case "route":
-				result.Route = cleanRoute(value)
-			case "squawk":
-				result.Squawk = value
-			case "altitude", "init_alt":
-				result.Altitude = value
-			case "flight_level":
-				result.FlightLevel = value
-			case "freq":
-				result.Frequency = value
-			case "atis":
-				result.ATIS = value
-			case "dep_time":
-				result.DepartureTime = value
 			}
+		case "sid":
+			result.SID = normaliseSID(value)
+		case "route":
+			result.Route = cleanRoute(value)
+		case "squawk":
+			result.Squawk = value
+		case "altitude", "init_alt":
+			result.Altitude = value
+		case "flight_level":
+			result.FlightLevel = value
+		case "freq":
+			result.Frequency = value
+		case "atis":
+			result.ATIS = value
+		case "dep_time":
+			result.DepartureTime = value
 		}
+	}
 
-		// Post-process: extract squawk if not in pattern.
-		if result.Squawk == "" {
-			result.Squawk = extractSquawk(upperText)
-		}
-
-		// Post-process: extract frequency if not in pattern.
-		if result.Frequency == "" {
-			result.Frequency = extractFrequency(upperText)
-		}
+	// Post-process: extract squawk if not in pattern.
+	if result.Squawk == "" {
+		result.Squawk = extractSquawk(upperText)
+	}
 
-		// Post-process: extract ATIS if not in pattern.
-		if result.ATIS == "" {
-			result.ATIS = extractATIS(upperText)
-		}
+	// Post-process: extract frequency if not in pattern.
+	if result.Frequency == "" {
+		result.Frequency = extractFrequency(upperText)
+	}
 
-		// Post-process: extract altitude if not in pattern.
-		if result.Altitude == "" {
-			result.Altitude = extractAltitude(upperText)
-		}
+	// Post-process: extract ATIS if not in pattern.
+	if result.ATIS == "" {
+		result.ATIS = extractATIS(upperText)
+	}
 
-		// Post-process: extract flight level if not in pattern.
-		if result.FlightLevel == "" {
-			result.FlightLevel = extractFlightLevel(upperText)
-		}
+	// Post-process: extract altitude if not in pattern.
+	if result.Altitude == "" {
+		result.Altitude = extractAltitude(upperText)
+	}
 
-		// Post-process: extract route if present.
-		if result.Route == "" {
-			result.Route = extractRoute(upperText)
-		}
+	// Post-process: extract flight level if not in pattern.
+	if result.FlightLevel == "" {
+		result.FlightLevel = extractFlightLevel(upperText)
+	}
 
-		// Post-process: remove origin from start and destination from end of route if duplicated.
-		if result.Route != "" && result.Origin != "" {
-			result.Route = strings.TrimPrefix(result.Route, result.Origin+" ")
-		}
-		if result.Route != "" && result.Destination != "" {
-			result.Route = strings.TrimSuffix(result.Route, " "+result.Destination)
-		}
-		if result.Route != "" {
-			result.Route = strings.TrimSpace(result.Route)
-		}
+	// Post-process: extract route if present.
+	if result.Route == "" {
+		result.Route = extractRoute(upperText)
+	}
 
-		return result
+	// Post-process: remove origin from start and destination from end of route if duplicated.
+	if result.Route != "" && result.Origin != "" {
+		result.Route = strings.TrimPrefix(result.Route, result.Origin+" ")
+	}
+	if result.Route != "" && result.Destination != "" {
+		result.Route = strings.TrimSuffix(result.Route, " "+result.Destination)
+	}
+	if result.Route != "" {
+		result.Route = strings.TrimSpace(result.Route)
 	}
 
-	return nil
+	return result
 }
 
 // PDCFormatTrace contains debug information about a PDC format match attempt.
@@ -807,6 +838,8 @@ type PDCFormatTrace struct {
 	Matched  bool              // Whether the pattern matched
 	Pattern  string            // The expanded regex pattern
 	Captures map[string]string // Captured groups (if matched)
+	Score    int               // Number of non-empty captures (if matched)
+	Selected bool              // Whether this match gave the result
 }
 
 // PDCParseTrace contains complete trace information for a PDC parse attempt.
@@ -824,14 +857,19 @@ type PDCExtractorTrace struct {
 	Value   string // Extracted value (if matched)
 }
 
-// ParseWithTrace attempts to parse a PDC message and returns detailed trace information.
+// ParseWithTrace attempts to parse a PDC message and returns detailed trace
+// information. Every format is reported, and the one Parse would choose is
+// marked as selected.
 func (c *Compiler) ParseWithTrace(text string) *PDCParseTrace {
 	upperText := strings.ToUpper(text)
 	trace := &PDCParseTrace{
 		Formats: make([]PDCFormatTrace, 0, len(c.formats)),
 	}
 
-	for _, format := range c.formats {
+	best := -1
+	var bestMatch []string
+	for i := range c.formats {
+		format := &c.formats[i]
 		ft := PDCFormatTrace{
 			Name:    format.Name,
 			Pattern: c.expand(format.Pattern),
@@ -853,57 +891,24 @@ func (c *Compiler) ParseWithTrace(text string) *PDCParseTrace {
 		ft.Matched = true
 		ft.Captures = make(map[string]string)
 
-		for i, name := range format.Compiled.SubexpNames() {
-			if i == 0 || name == "" {
+		for j, name := range format.Compiled.SubexpNames() {
+			if j == 0 || name == "" {
 				continue
 			}
-			ft.Captures[name] = match[i]
+			ft.Captures[name] = match[j]
 		}
+		ft.Score = captureScore(format, match)
 
+		if best < 0 || ft.Score > trace.Formats[best].Score {
+			best, bestMatch = i, match
+		}
 		trace.Formats = append(trace.Formats, ft)
+	}
 
-		// Build result from first match.
-		if trace.Result == nil {
-			trace.Result = &PDCResult{FormatName: format.Name}
-			for name, value := range ft.Captures {
-				switch name {
-				case "flight":
-					trace.Result.FlightNumber = value
-				case "origin":
-					trace.Result.Origin = value
-				case "origin_iata":
-					trace.Result.OriginIATA = value
-				case "destination":
-					trace.Result.Destination = value
-				case "dest_iata":
-					trace.Result.DestIATA = value
-				case "waypoint":
-					if trace.Result.SID == "" {
-						trace.Result.SID = value
-					}
-				case "aircraft":
-					trace.Result.Aircraft = value
-				case "runway":
-					trace.Result.Runway = value
-				case "runway2":
-					if trace.Result.Runway == "" {
-						trace.Result.Runway = value
-					}
-				case "sid":
-					trace.Result.SID = normaliseSID(value)
-				case "route":
-					trace.Result.Route = cleanRoute(value)
-				case "squawk":
-					trace.Result.Squawk = value
-				case "altitude":
-					trace.Result.Altitude = value
-				case "freq":
-					trace.Result.Frequency = value
-				case "atis":
-					trace.Result.ATIS = value
-				}
-			}
-		}
+	// Build the result from the richest match, as Parse does.
+	if best >= 0 {
+		trace.Formats[best].Selected = true
+		trace.Result = buildResult(&c.formats[best], bestMatch, upperText)
 	}
 
 	// Trace post-processing extractors.
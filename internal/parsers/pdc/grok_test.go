@@ -213,4 +213,45 @@ VOICE REQUEST FULL ROUTE`,
 			t.Errorf("expected nil for non-PDC message, got format %q", result.FormatName)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestParseRichestFormat(t *testing.T) {
+	c := NewCompiler()
+	c.formats = []PDCFormat{
+		{Name: "flight_only", Pattern: `PDC\s+(?P<flight>{FLIGHT})`},
+		{Name: "flight_origin", Pattern: `PDC\s+(?P<flight>{FLIGHT})\s+(?P<origin>{ICAO})`},
+		{Name: "flight_origin_late", Pattern: `(?P<flight>{FLIGHT})\s+(?P<origin>{ICAO})\s+(?P<runway2>X)?`},
+	}
+	if err := c.Compile(); err != nil {
+		t.Fatalf("failed to compile patterns: %v", err)
+	}
+
+	// All three match: the later flight_origin captures more than
+	// flight_only, and ties flight_origin_late, whose optional group is empty.
+	text := "PDC QFA401 YSSY 1900"
+	result := c.Parse(text)
+	if result == nil {
+		t.Fatal("expected match, got nil")
+	}
+	if result.FormatName != "flight_origin" || result.FlightNumber != "QFA401" || result.Origin != "YSSY" {
+		t.Errorf("Parse = %s %s %s, want flight_origin QFA401 YSSY", result.FormatName, result.FlightNumber, result.Origin)
+	}
+
+	trace := c.ParseWithTrace(text)
+	if len(trace.Formats) != 3 {
+		t.Fatalf("trace has %d formats, want 3", len(trace.Formats))
+	}
+	for i, want := range []struct {
+		score    int
+		selected bool
+	}{{1, false}, {2, true}, {2, false}} {
+		ft := trace.Formats[i]
+		if !ft.Matched || ft.Score != want.score || ft.Selected != want.selected {
+			t.Errorf("%s: matched %v, score %d, selected %v; want score %d, selected %v",
+				ft.Name, ft.Matched, ft.Score, ft.Selected, want.score, want.selected)
+		}
+	}
+	if trace.Result == nil || trace.Result.FormatName != "flight_origin" {
+		t.Errorf("trace result = %+v, want flight_origin", trace.Result)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"sync"
 
 	"acars_parser/internal/acars"
+	"acars_parser/internal/airport"
+	"acars_parser/internal/navdata"
 	"acars_parser/internal/registry"
 )
 
@@ -21,6 +23,8 @@ type Result struct {
 	AircraftICAO    string   `json:"aircraft_icao,omitempty"`
 	Origin          string   `json:"origin,omitempty"`
 	Destination     string   `json:"destination,omitempty"`
+	OriginIATA      string   `json:"origin_iata,omitempty"`
+	DestIATA        string   `json:"dest_iata,omitempty"`
 	DepartureTime   string   `json:"departure_time,omitempty"`
 	Runway          string   `json:"runway,omitempty"`
 	SID             string   `json:"sid,omitempty"`
@@ -35,11 +39,20 @@ type Result struct {
 	PDCFormat       string   `json:"pdc_format,omitempty"`
 	RawText         string   `json:"raw_text,omitempty"`
 	ParseConfidence float64  `json:"parse_confidence"`
+	// Validation lists the captured fields that failed a plausibility check
+	// (see Validate): dropped ones are left out of the result.
+	Validation []ValidationIssue `json:"validation,omitempty"`
+	// SIDProcedure is set when a procedure database is configured
+	// (see navdata.SetDefaultProcedures) and the SID is found in it.
+	SIDProcedure *navdata.ResolvedProcedure `json:"sid_procedure,omitempty"`
 }
 
 func (r *Result) Type() string     { return "pdc" }
 func (r *Result) MessageID() int64 { return r.MsgID }
 
+// Confidence returns the parse confidence, implementing registry.Scored.
+func (r *Result) Confidence() float64 { return r.ParseConfidence }
+
 // Parser parses Pre-Departure Clearance messages.
 type Parser struct {
 	IncludeRawText bool
@@ -52,8 +65,12 @@ var (
 	grokErr      error
 )
 
-// getCompiler returns the singleton grok compiler.
+// getCompiler returns the singleton grok compiler, or the one with the formats
+// given to LoadFormats.
 func getCompiler() (*Compiler, error) {
+	if c := loadedCompiler.Load(); c != nil {
+		return c, nil
+	}
 	grokOnce.Do(func() {
 		grokCompiler = NewCompiler()
 		grokErr = grokCompiler.Compile()
@@ -69,6 +86,13 @@ func (p *Parser) Name() string     { return "pdc" }
 func (p *Parser) Labels() []string { return nil } // Content-based, checks all labels.
 func (p *Parser) Priority() int    { return 500 } // Run after label-specific parsers.
 
+// Version 2 added SID procedure resolution, and version 3 field validation.
+func (p *Parser) Version() int { return 3 }
+
+// Anchors are the terms one of which QuickCheck requires: PDC, APCDC or
+// (PRE-)DEPARTURE CLEARANCE.
+func (p *Parser) Anchors() []string { return []string{"PDC", "APCDC", "DEPARTURE CLEARANCE"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	upper := strings.ToUpper(text)
 
@@ -161,11 +185,16 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 		return nil
 	}
 
+	// Drop the captures that cannot be right before using them.
+	result.Validation = Validate(grokResult)
+
 	// Use grok results as the sole source of parsed data.
 	result.PDCFormat = grokResult.FormatName
 	result.FlightNumber = grokResult.FlightNumber
 	result.Origin = grokResult.Origin
 	result.Destination = grokResult.Destination
+	result.OriginIATA = grokResult.OriginIATA
+	result.DestIATA = grokResult.DestIATA
 	result.Runway = grokResult.Runway
 	result.SID = grokResult.SID
 	result.Route = grokResult.Route
@@ -199,6 +228,21 @@ func (p *Parser) Parse(msg *acars.Message) registry.Result {
 		result.DepartureTime = ExtractDepartureTime(msg.Text)
 	}
 
+	// Formats that name airports only by IATA code get their ICAO codes from
+	// the airport table, when one is loaded.
+	if result.Origin == "" {
+		result.Origin = airport.ResolveIATA(result.OriginIATA)
+	}
+	if result.Destination == "" {
+		result.Destination = airport.ResolveIATA(result.DestIATA)
+	}
+
+	// Resolve the SID to its runways and fixes when procedure data is loaded.
+	if ps := navdata.DefaultProcedures(); ps != nil && result.SID != "" && result.Origin != "" {
+		sid, transition, _ := strings.Cut(result.SID, ".")
+		result.SIDProcedure = ps.Resolve(result.Origin, navdata.SID, sid, transition, result.Runway)
+	}
+
 	// Calculate confidence.
 	result.ParseConfidence = calculateConfidence(result)
 
@@ -239,6 +283,7 @@ func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
 			Matched:  ft.Matched,
 			Pattern:  ft.Pattern,
 			Captures: ft.Captures,
+			Selected: ft.Selected,
 		})
 	}
 
@@ -293,5 +338,15 @@ func calculateConfidence(pdc *Result) float64 {
 		score += 1
 	}
 
+	// Penalty for each doubtful value kept; dropped ones already score nothing.
+	for _, issue := range pdc.Validation {
+		if !issue.Dropped {
+			score -= 0.5
+		}
+	}
+	if score < 0 {
+		score = 0
+	}
+
 	return score / maxScore
-}
\ No newline at end of file
+}
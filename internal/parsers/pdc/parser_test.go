@@ -1,9 +1,11 @@
 package pdc
 
 import (
+	"strings"
 	"testing"
 
 	"acars_parser/internal/acars"
+	"acars_parser/internal/airport"
 )
 
 func TestParser(t *testing.T) {
@@ -254,6 +256,32 @@ DESTINATION KSEA`,
 	}
 }
 
+func TestResolveIATAAirports(t *testing.T) {
+	airports := airport.NewTable()
+	err := airports.LoadCSV(strings.NewReader("iata,icao\nYVR,CYVR\nSFO,KSFO\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &acars.Message{ID: 1, Text: "C32PDC         1APCDC AC0564/31/31 YVR SFO 524 1804Z/0076/0000/  7"}
+	p := &Parser{}
+
+	// Without an airport table only the IATA codes are known.
+	pdc, ok := p.Parse(msg).(*Result)
+	if !ok {
+		t.Fatal("expected *Result")
+	}
+	if pdc.OriginIATA != "YVR" || pdc.DestIATA != "SFO" || pdc.Origin != "" || pdc.Destination != "" {
+		t.Errorf("unresolved = %s/%s %s/%s", pdc.OriginIATA, pdc.DestIATA, pdc.Origin, pdc.Destination)
+	}
+
+	airport.SetDefault(airports)
+	defer airport.SetDefault(nil)
+	pdc = p.Parse(msg).(*Result)
+	if pdc.Origin != "CYVR" || pdc.Destination != "KSFO" {
+		t.Errorf("resolved = %s-%s, want CYVR-KSFO", pdc.Origin, pdc.Destination)
+	}
+}
+
 func TestRepublicAirways(t *testing.T) {
 	text := `QUHDQDDRP~1PDC SEQ 001
 RPA4783
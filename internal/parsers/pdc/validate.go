@@ -0,0 +1,236 @@
+package pdc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/airport"
+)
+
+// Reasons a captured field fails validation. A value that cannot be right is
+// dropped from the result; one that is merely doubtful is kept and flagged.
+const (
+	ReasonInvalidRunway      = "invalid_runway"       // Not a runway number 01 to 36. Dropped.
+	ReasonInvalidSquawk      = "invalid_squawk"       // Not four octal digits. Dropped.
+	ReasonReservedSquawk     = "reserved_squawk"      // 0000 or an emergency code. Dropped.
+	ReasonConspicuitySquawk  = "conspicuity_squawk"   // A code not assigned to a single flight. Flagged.
+	ReasonPlaceholderSID     = "placeholder_sid"      // A placeholder such as XXX. Dropped.
+	ReasonInvalidSID         = "invalid_sid"          // Not shaped like a procedure or fix name. Dropped.
+	ReasonInvalidAirport     = "invalid_airport"      // Not a valid ICAO or IATA code. Dropped.
+	ReasonRegionMismatch     = "region_mismatch"      // Origin outside the region of the format. Flagged.
+	ReasonUnknownAirport     = "unknown_airport"      // Not in the loaded airport table. Flagged.
+	ReasonInvalidFrequency   = "invalid_frequency"    // Outside the VHF airband. Dropped.
+	ReasonInvalidAltitude    = "invalid_altitude"     // Not a climb altitude in feet. Dropped.
+	ReasonInvalidFlightLevel = "invalid_flight_level" // Not FL010 to FL600. Dropped.
+	ReasonInvalidTime        = "invalid_time"         // Not an HHMM time of day. Dropped.
+)
+
+// ValidationIssue is a captured field that failed a plausibility check.
+// Issues are kept on the result so that the formats capturing garbage can be
+// found and tuned (see the analyzer's -pdc-validation).
+type ValidationIssue struct {
+	Field   string `json:"field"`
+	Value   string `json:"value"`
+	Reason  string `json:"reason"`
+	Dropped bool   `json:"dropped,omitempty"` // The value was removed from the result.
+}
+
+var (
+	runwayNumberRe  = regexp.MustCompile(`^(\d{1,2})[LRC]?$`)
+	sidNameRe       = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,7}$`)
+	sidDesignatorRe = regexp.MustCompile(`^([A-Z]+)\d[A-Z]?$`) // Name, procedure number and letter.
+	icaoCodeRe      = regexp.MustCompile(`^[A-HK-PR-WYZ][A-Z]{3}$`)
+	iataCodeRe      = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// placeholderSIDs are words PDC systems put where a SID would go.
+var placeholderSIDs = map[string]bool{
+	"NONE": true, "NIL": true, "NA": true, "SID": true, "DEP": true, "DCT": true,
+	"RWY": true, "RUNWAY": true, "RADAR": true, "VECTORS": true, "HDG": true,
+}
+
+// Squawk codes never assigned in a clearance, and conspicuity codes that are
+// not assigned to a single flight.
+var (
+	reservedSquawks    = map[string]bool{"0000": true, "7500": true, "7600": true, "7700": true}
+	conspicuitySquawks = map[string]bool{"1200": true, "2000": true, "7000": true}
+)
+
+// formatRegions lists the ICAO prefixes of the origins each regional format
+// is issued at. Formats not listed, such as DC1, are used worldwide.
+var formatRegions = map[string][]string{
+	"australian":          {"Y"},
+	"australian_regional": {"Y"},
+	"virgin_australia":    {"Y"},
+	"canadian_westjet":    {"C"},
+	"canadian_nav":        {"C"},
+	"canadian_jazz":       {"C"},
+	"qantas_pacific":      {"C"},
+	"us_delta":            usRegion,
+	"southwest":           usRegion,
+	"us_regional_route":   usRegion,
+	"us_regional":         usRegion,
+	"alaska_hawaiian":     usRegion,
+	"horizon_qxe":         usRegion,
+	"skywest_full":        usRegion,
+	"skywest_simple":      usRegion,
+	"republic_airways":    usRegion,
+	"private_jet":         usRegion,
+	"frontier_extended":   usRegion,
+	"frontier_agm":        usRegion,
+}
+
+// usRegion covers the contiguous states, Alaska, Hawaii and the Pacific
+// territories, and Puerto Rico and the Virgin Islands.
+var usRegion = []string{"K", "P", "TJ", "TI"}
+
+// Validate checks the fields of a parsed PDC against domain rules, removes
+// the values that cannot be right and returns every issue found, dropped or
+// flagged, in field order.
+func Validate(r *PDCResult) []ValidationIssue {
+	var issues []ValidationIssue
+	check := func(field string, value *string, reason string, drop bool) {
+		if reason == "" {
+			return
+		}
+		issues = append(issues, ValidationIssue{Field: field, Value: *value, Reason: reason, Dropped: drop})
+		if drop {
+			*value = ""
+		}
+	}
+
+	if r.Origin != "" {
+		reason, drop := checkICAO(r.Origin)
+		if reason == "" && !inRegion(r.Origin, formatRegions[r.FormatName]) {
+			reason = ReasonRegionMismatch
+		}
+		check("origin", &r.Origin, reason, drop)
+	}
+	if r.Destination != "" {
+		reason, drop := checkICAO(r.Destination)
+		check("destination", &r.Destination, reason, drop)
+	}
+	if r.OriginIATA != "" && !iataCodeRe.MatchString(r.OriginIATA) {
+		check("origin_iata", &r.OriginIATA, ReasonInvalidAirport, true)
+	}
+	if r.DestIATA != "" && !iataCodeRe.MatchString(r.DestIATA) {
+		check("dest_iata", &r.DestIATA, ReasonInvalidAirport, true)
+	}
+
+	if r.Runway != "" {
+		check("runway", &r.Runway, checkRunway(r.Runway), true)
+	}
+	if r.SID != "" {
+		check("sid", &r.SID, checkSID(r.SID), true)
+	}
+	if r.Squawk != "" {
+		reason := checkSquawk(r.Squawk)
+		check("squawk", &r.Squawk, reason, reason != ReasonConspicuitySquawk)
+	}
+	if r.Frequency != "" {
+		if f, err := strconv.ParseFloat(r.Frequency, 64); err != nil || f < 117.975 || f > 137 {
+			check("departure_freq", &r.Frequency, ReasonInvalidFrequency, true)
+		}
+	}
+	if r.Altitude != "" {
+		if ft, err := strconv.Atoi(r.Altitude); err != nil || ft <= 0 || ft > 60000 {
+			check("initial_altitude", &r.Altitude, ReasonInvalidAltitude, true)
+		}
+	}
+	if r.FlightLevel != "" {
+		if fl, err := strconv.Atoi(r.FlightLevel); err != nil || fl < 10 || fl > 600 {
+			check("flight_level", &r.FlightLevel, ReasonInvalidFlightLevel, true)
+		}
+	}
+	if r.DepartureTime != "" && !validHHMM(r.DepartureTime) {
+		check("departure_time", &r.DepartureTime, ReasonInvalidTime, true)
+	}
+	return issues
+}
+
+// checkICAO returns why an ICAO airport code fails validation, and whether it
+// should be dropped. A well-formed code missing from the loaded airport table
+// is only flagged, as the table may be incomplete.
+func checkICAO(code string) (string, bool) {
+	if !icaoCodeRe.MatchString(code) {
+		return ReasonInvalidAirport, true
+	}
+	if t := airport.Default(); t != nil {
+		if _, ok := t.ByICAO(code); !ok {
+			return ReasonUnknownAirport, false
+		}
+	}
+	return "", false
+}
+
+// inRegion reports whether an ICAO code starts with one of the prefixes, or
+// whether there are none to check against.
+func inRegion(code string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(code, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkRunway(rwy string) string {
+	m := runwayNumberRe.FindStringSubmatch(rwy)
+	if m == nil {
+		return ReasonInvalidRunway
+	}
+	if n, _ := strconv.Atoi(m[1]); n < 1 || n > 36 {
+		return ReasonInvalidRunway
+	}
+	return ""
+}
+
+// checkSID checks a SID, or a departure fix, ignoring any transition after
+// a dot. The placeholder check also looks at the name without its procedure
+// number and letter, so that XXX2 is caught as well as XXX.
+func checkSID(sid string) string {
+	name, _, _ := strings.Cut(sid, ".")
+	base := name
+	if m := sidDesignatorRe.FindStringSubmatch(name); m != nil {
+		base = m[1]
+	}
+	if name == "" || isPlaceholderSID(name) || isPlaceholderSID(base) {
+		return ReasonPlaceholderSID
+	}
+	if !sidNameRe.MatchString(name) {
+		return ReasonInvalidSID
+	}
+	return ""
+}
+
+// isPlaceholderSID reports whether a SID name is a placeholder word, or one
+// character repeated.
+func isPlaceholderSID(name string) bool {
+	return placeholderSIDs[name] || (len(name) > 1 && strings.Count(name, name[:1]) == len(name))
+}
+
+func checkSquawk(code string) string {
+	if len(code) != 4 || strings.Trim(code, "01234567") != "" {
+		return ReasonInvalidSquawk
+	}
+	if reservedSquawks[code] {
+		return ReasonReservedSquawk
+	}
+	if conspicuitySquawks[code] {
+		return ReasonConspicuitySquawk
+	}
+	return ""
+}
+
+func validHHMM(s string) bool {
+	if len(s) != 4 {
+		return false
+	}
+	h, err1 := strconv.Atoi(s[:2])
+	m, err2 := strconv.Atoi(s[2:])
+	return err1 == nil && err2 == nil && h < 24 && m < 60
+}
@@ -0,0 +1,97 @@
+package pdc
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/airport"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  PDCResult
+		field   string
+		reason  string
+		dropped bool
+	}{
+		{"clean", PDCResult{FormatName: "australian", Origin: "YSSY", Runway: "16L", SID: "ABBEY3", Squawk: "3041"}, "", "", false},
+		{"runway 99", PDCResult{Runway: "99"}, "runway", ReasonInvalidRunway, true},
+		{"runway 00", PDCResult{Runway: "00L"}, "runway", ReasonInvalidRunway, true},
+		{"placeholder SID", PDCResult{SID: "XXX"}, "sid", ReasonPlaceholderSID, true},
+		{"placeholder SID with number", PDCResult{SID: "XXX2"}, "sid", ReasonPlaceholderSID, true},
+		{"placeholder SID with number and letter", PDCResult{SID: "XXXX1A"}, "sid", ReasonPlaceholderSID, true},
+		{"word for SID", PDCResult{SID: "VECTORS"}, "sid", ReasonPlaceholderSID, true},
+		{"SID with transition", PDCResult{SID: "DEGES3S.SPR"}, "", "", false},
+		{"overlong SID", PDCResult{SID: "CLEAREDASFILED1"}, "sid", ReasonInvalidSID, true},
+		{"squawk 0000", PDCResult{Squawk: "0000"}, "squawk", ReasonReservedSquawk, true},
+		{"emergency squawk", PDCResult{Squawk: "7700"}, "squawk", ReasonReservedSquawk, true},
+		{"three-digit squawk", PDCResult{Squawk: "524"}, "squawk", ReasonInvalidSquawk, true},
+		{"conspicuity squawk", PDCResult{Squawk: "2000"}, "squawk", ReasonConspicuitySquawk, false},
+		{"invalid ICAO prefix", PDCResult{Destination: "XXXX"}, "destination", ReasonInvalidAirport, true},
+		{"origin outside region", PDCResult{FormatName: "us_delta", Origin: "YSSY"}, "origin", ReasonRegionMismatch, false},
+		{"Hawaii in the US region", PDCResult{FormatName: "alaska_hawaiian", Origin: "PHNL"}, "", "", false},
+		{"worldwide format", PDCResult{FormatName: "dc1_clearance", Origin: "EDDF"}, "", "", false},
+		{"frequency outside airband", PDCResult{Frequency: "243.000"}, "departure_freq", ReasonInvalidFrequency, true},
+		{"flight level", PDCResult{FlightLevel: "990"}, "flight_level", ReasonInvalidFlightLevel, true},
+		{"altitude", PDCResult{Altitude: "99999"}, "initial_altitude", ReasonInvalidAltitude, true},
+		{"departure time", PDCResult{DepartureTime: "2575"}, "departure_time", ReasonInvalidTime, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.result
+			issues := Validate(&r)
+			if tt.field == "" {
+				if len(issues) != 0 {
+					t.Errorf("issues = %+v, want none", issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("issues = %+v, want one", issues)
+			}
+			got := issues[0]
+			if got.Field != tt.field || got.Reason != tt.reason || got.Dropped != tt.dropped || got.Value == "" {
+				t.Errorf("issue = %+v, want %s %s dropped=%v", got, tt.field, tt.reason, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownAirport(t *testing.T) {
+	tbl := airport.NewTable()
+	if err := tbl.Add(airport.Airport{ICAO: "YSSY", IATA: "SYD"}); err != nil {
+		t.Fatal(err)
+	}
+	airport.SetDefault(tbl)
+	t.Cleanup(func() { airport.SetDefault(nil) })
+
+	r := PDCResult{Origin: "YSSY", Destination: "YZZZ"}
+	issues := Validate(&r)
+	if len(issues) != 1 || issues[0].Field != "destination" || issues[0].Reason != ReasonUnknownAirport || r.Destination != "YZZZ" {
+		t.Errorf("issues = %+v, destination %q", issues, r.Destination)
+	}
+}
+
+func TestParserDropsImplausibleFields(t *testing.T) {
+	msg := &acars.Message{ID: 7, Label: "RA", Text: `PDC UPLINK
+VOZ083 B738 YBCG 0750
+CLEARED TO YSSY VIA
+GOLD COAST SEVEN DEP: XXX
+ROUTE:SCOTT Q47 IDRAS
+CLIMB VIA SID TO: 6000
+DEP FREQ: 123.500
+SQUAWK 0000`}
+
+	res, ok := (&Parser{}).Parse(msg).(*Result)
+	if !ok {
+		t.Fatal("Parse returned no result")
+	}
+	if res.SID != "" || res.Squawk != "" || res.Origin != "YBCG" || res.DepartureFreq != "123.500" {
+		t.Errorf("result = %+v", res)
+	}
+	if len(res.Validation) != 2 || res.Validation[0].Reason != ReasonPlaceholderSID || res.Validation[1].Reason != ReasonReservedSquawk {
+		t.Errorf("validation = %+v", res.Validation)
+	}
+}
@@ -0,0 +1,391 @@
+// Package pirep parses turbulence and wind shear reports sent by aircraft:
+// free-text crew reports ("MOD TURB FL350 OVER TIDKA", "WINDSHEAR LOSS 15KT")
+// and automatic eddy dissipation rate (EDR) reports in H1 and H2 downlinks.
+// Uplinked advisories are left to the turbulence and hazard parsers.
+package pirep
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Phenomena reported.
+const (
+	PhenomenonTurbulence = "turbulence"
+	PhenomenonWindShear  = "wind_shear"
+)
+
+// Severities, in increasing order.
+const (
+	SeverityNone     = "none"
+	SeverityLight    = "light"
+	SeverityModerate = "moderate"
+	SeveritySevere   = "severe"
+	SeverityExtreme  = "extreme"
+)
+
+// SeverityRank orders the severities: 0 for none up to 4 for extreme, and -1
+// for anything else.
+func SeverityRank(s string) int {
+	switch s {
+	case SeverityNone:
+		return 0
+	case SeverityLight:
+		return 1
+	case SeverityModerate:
+		return 2
+	case SeveritySevere:
+		return 3
+	case SeverityExtreme:
+		return 4
+	}
+	return -1
+}
+
+// TurbulenceResult is a turbulence or wind shear report from an aircraft. A
+// report of both is recorded as wind shear, with the turbulence severity if
+// the shear has none of its own.
+type TurbulenceResult struct {
+	MsgID        int64  `json:"message_id"`
+	Timestamp    string `json:"timestamp"`
+	Tail         string `json:"tail,omitempty"`
+	Phenomenon   string `json:"phenomenon"`              // "turbulence" or "wind_shear".
+	Severity     string `json:"severity,omitempty"`      // "none" to "extreme"; the worse of a range.
+	SeverityText string `json:"severity_text,omitempty"` // As reported, e.g. "OCNL LGT-MOD".
+	Frequency    string `json:"frequency,omitempty"`     // "occasional", "intermittent" or "continuous".
+	// EDRPeak and EDRMean are eddy dissipation rates (m^2/3 s^-1).
+	EDRPeak *float64 `json:"edr_peak,omitempty"`
+	EDRMean *float64 `json:"edr_mean,omitempty"`
+	// AirspeedChange is the wind shear's airspeed gain (positive) or loss
+	// (negative) in knots.
+	AirspeedChange int     `json:"airspeed_change,omitempty"`
+	Latitude       float64 `json:"latitude,omitempty"`
+	Longitude      float64 `json:"longitude,omitempty"`
+	Waypoint       string  `json:"waypoint,omitempty"`
+	FlightLevel    int     `json:"flight_level,omitempty"`     // Bottom of a range.
+	FlightLevelTop int     `json:"flight_level_top,omitempty"` // Top of a range, when one is given.
+	Altitude       int     `json:"altitude,omitempty"`         // Feet, when given in feet rather than as a flight level.
+	Text           string  `json:"text"`
+}
+
+func (r *TurbulenceResult) Type() string     { return "turbulence_report" }
+func (r *TurbulenceResult) MessageID() int64 { return r.MsgID }
+
+var (
+	// severityRe matches a worded turbulence report: an optional frequency,
+	// an intensity or range of intensities, and the phenomenon.
+	severityRe = regexp.MustCompile(`\b(?:(OCNL|OCCASIONAL|INTMT|INTERMITTENT|CONS|CONT|CONTINUOUS)\s+)?` +
+		`(NIL|SMOOTH|SMTH|LGT|LIGHT|MOD|MDT|MODERATE|SEV|SEVERE|EXTRM|EXTREME)` +
+		`(?:\s*(?:-|/|TO)\s*(LGT|LIGHT|MOD|MDT|MODERATE|SEV|SEVERE|EXTRM|EXTREME))?` +
+		`\s+(?:TURB(?:ULENCE)?|TURBC|CHOP|CAT)\b`)
+	// shearRe matches a wind shear report with an optional intensity.
+	shearRe = regexp.MustCompile(`\b(?:(LGT|LIGHT|MOD|MDT|MODERATE|SEV|SEVERE)\s+)?(?:WIND\s?SHEAR|LLWS)\b`)
+	// wsRe matches the abbreviation WS, only when an airspeed change
+	// follows, as "WS" alone is too common in free text.
+	wsRe = regexp.MustCompile(`\bWS\s+(?:LOSS|GAIN|[+-]\s*\d)`)
+	// airspeedRe matches an airspeed gain or loss in knots.
+	airspeedRe = regexp.MustCompile(`\b(LOSS|GAIN)\s+(?:OF\s+)?(\d{1,2})\s*(?:KTS?|KNOTS|K)\b|([+-])\s*(\d{1,2})\s*(?:KTS?|KNOTS)\b`)
+	// edrRe matches an EDR value, optionally qualified as peak or mean.
+	edrRe = regexp.MustCompile(`\b(?:(PEAK|PK|MAX|MEAN|MED|AVG)\s*)?EDR\s*[:=/]?\s*(\d?\.\d{1,3})`)
+	// flightLevelRe matches a flight level or range of flight levels.
+	flightLevelRe = regexp.MustCompile(`\bFL\s?(\d{3})(?:\s*(?:-|/|TO)\s*(?:FL)?\s?(\d{3}))?\b`)
+	// altitudeRe matches an altitude in feet.
+	altitudeRe = regexp.MustCompile(`\b(\d{3,5})\s?(?:FT|FEET)\b`)
+	// latLonPrefixRe matches N4530W01520 or N45W020.
+	latLonPrefixRe = regexp.MustCompile(`\b([NS])(\d{2})(\d{2})?[\s/]?([EW])(\d{3})(\d{2})?\b`)
+	// latLonSuffixRe matches 4530N01520W or 45N020W.
+	latLonSuffixRe = regexp.MustCompile(`\b(\d{2})(\d{2})?([NS])[\s/]?(\d{3})(\d{2})?([EW])\b`)
+	// waypointRe matches a named point the report is at or near.
+	waypointRe = regexp.MustCompile(`\b(?:OVER|OVR|NEAR|NR|ABM|ABEAM|AT)\s+([A-Z]{3,5})\b`)
+)
+
+// notWaypoints are words that follow "OVER", "AT" and so on but are not
+// waypoints.
+var notWaypoints = map[string]bool{
+	"THE": true, "TOP": true, "THIS": true, "THAT": true, "TIME": true, "ALL": true, "LOW": true,
+	"HIGH": true, "LEVEL": true, "CRZ": true, "CLB": true, "DES": true, "DESC": true, "CLIMB": true,
+	"APPR": true, "APP": true, "FINAL": true, "TOD": true, "TOC": true, "ABOUT": true, "SEA": true,
+}
+
+// uplinkMarkers are phrases of uplinked forecasts and advisories, which
+// describe expected rather than encountered conditions.
+var uplinkMarkers = []string{"SIGMET", "AIRMET", "HAZARD ALERT", "ADVISORY"}
+
+// Parser parses turbulence and wind shear reports.
+type Parser struct{}
+
+func init() {
+	registry.Register(&Parser{})
+}
+
+func (p *Parser) Name() string { return "turbulence_report" }
+
+// Labels are the H1 and H2 downlinks that carry automatic EDR reports, and
+// the free-text downlinks crews report in.
+func (p *Parser) Labels() []string { return []string{"H1", "H2", "5U", "20", "21", "22", "23"} }
+func (p *Parser) Priority() int    { return 70 }
+
+func (p *Parser) Anchors() []string { return keywords }
+
+// keywords are those of turbulence, EDR and wind shear reports.
+var keywords = []string{"TURB", "CHOP", "EDR", "SHEAR", "LLWS", "WS "}
+
+// QuickCheck looks for turbulence, EDR or wind shear keywords.
+func (p *Parser) QuickCheck(text string) bool {
+	upper := strings.ToUpper(text)
+	for _, k := range keywords {
+		if strings.Contains(upper, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) Parse(msg *acars.Message) registry.Result {
+	if msg.Text == "" || uplink(msg) {
+		return nil
+	}
+	text := strings.TrimSpace(msg.Text)
+	upper := strings.ToUpper(text)
+	for _, m := range uplinkMarkers {
+		if strings.Contains(upper, m) {
+			return nil
+		}
+	}
+
+	result := &TurbulenceResult{
+		MsgID:      int64(msg.ID),
+		Timestamp:  msg.Timestamp,
+		Tail:       msg.Tail,
+		Phenomenon: PhenomenonTurbulence,
+		Text:       text,
+	}
+
+	// The worst worded report in the message.
+	for _, m := range severityRe.FindAllStringSubmatch(upper, -1) {
+		severity := severityWord(m[2])
+		if m[3] != "" {
+			severity = severityWord(m[3])
+		}
+		if SeverityRank(severity) <= SeverityRank(result.Severity) {
+			continue
+		}
+		result.Severity = severity
+		result.SeverityText = strings.Join(strings.Fields(strings.TrimSuffix(m[0], lastWord(m[0]))), " ")
+		result.Frequency = frequencyWord(m[1])
+	}
+
+	for _, m := range edrRe.FindAllStringSubmatch(upper, -1) {
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err != nil || v > 2 {
+			continue
+		}
+		switch m[1] {
+		case "MEAN", "MED", "AVG":
+			result.EDRMean = &v
+		default:
+			if result.EDRPeak == nil || v > *result.EDRPeak {
+				result.EDRPeak = &v
+			}
+		}
+	}
+	if result.Severity == "" {
+		if edr := result.EDRPeak; edr != nil {
+			result.Severity = EDRSeverity(*edr)
+		} else if edr := result.EDRMean; edr != nil {
+			result.Severity = EDRSeverity(*edr)
+		}
+	}
+
+	if m := shearRe.FindStringSubmatch(upper); m != nil || wsRe.MatchString(upper) {
+		result.Phenomenon = PhenomenonWindShear
+		if m != nil && m[1] != "" {
+			result.Severity = severityWord(m[1])
+			result.SeverityText = m[1]
+		}
+		if m := airspeedRe.FindStringSubmatch(upper); m != nil {
+			result.AirspeedChange = airspeedChange(m)
+		}
+	}
+
+	if result.Phenomenon == PhenomenonTurbulence && result.Severity == "" {
+		return nil
+	}
+
+	if m := flightLevelRe.FindStringSubmatch(upper); m != nil {
+		result.FlightLevel, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			top, _ := strconv.Atoi(m[2])
+			if top < result.FlightLevel {
+				result.FlightLevel, top = top, result.FlightLevel
+			}
+			result.FlightLevelTop = top
+		}
+	} else if m := altitudeRe.FindStringSubmatch(upper); m != nil {
+		result.Altitude, _ = strconv.Atoi(m[1])
+	}
+
+	if m := latLonPrefixRe.FindStringSubmatch(upper); m != nil {
+		result.Latitude = coordinate(m[2], m[3], m[1] == "S")
+		result.Longitude = coordinate(m[5], m[6], m[4] == "W")
+	} else if m := latLonSuffixRe.FindStringSubmatch(upper); m != nil {
+		result.Latitude = coordinate(m[1], m[2], m[3] == "S")
+		result.Longitude = coordinate(m[4], m[5], m[6] == "W")
+	}
+	if result.Latitude < -90 || result.Latitude > 90 || result.Longitude < -180 || result.Longitude > 180 {
+		result.Latitude, result.Longitude = 0, 0
+	}
+	for _, m := range waypointRe.FindAllStringSubmatch(upper, -1) {
+		if !notWaypoints[m[1]] {
+			result.Waypoint = m[1]
+			break
+		}
+	}
+
+	return result
+}
+
+// uplink reports whether the transport layer marks a message as an uplink.
+func uplink(msg *acars.Message) bool {
+	if msg.LinkDirection != "" {
+		return msg.LinkDirection == "uplink"
+	}
+	return msg.BlockID != "" && msg.BlockID[0] >= 'A' && msg.BlockID[0] <= 'Z'
+}
+
+// EDRSeverity classifies a peak EDR by the ICAO Annex 3 thresholds: below
+// 0.1 none, below 0.2 light, up to 0.45 moderate, and severe above.
+func EDRSeverity(edr float64) string {
+	switch {
+	case edr < 0.1:
+		return SeverityNone
+	case edr < 0.2:
+		return SeverityLight
+	case edr <= 0.45:
+		return SeverityModerate
+	default:
+		return SeveritySevere
+	}
+}
+
+// severityWord normalises a reported intensity.
+func severityWord(w string) string {
+	switch w {
+	case "NIL", "SMOOTH", "SMTH":
+		return SeverityNone
+	case "LGT", "LIGHT":
+		return SeverityLight
+	case "MOD", "MDT", "MODERATE":
+		return SeverityModerate
+	case "SEV", "SEVERE":
+		return SeveritySevere
+	case "EXTRM", "EXTREME":
+		return SeverityExtreme
+	}
+	return ""
+}
+
+// frequencyWord normalises a reported frequency.
+func frequencyWord(w string) string {
+	switch w {
+	case "OCNL", "OCCASIONAL":
+		return "occasional"
+	case "INTMT", "INTERMITTENT":
+		return "intermittent"
+	case "CONS", "CONT", "CONTINUOUS":
+		return "continuous"
+	}
+	return ""
+}
+
+// airspeedChange returns the knots gained (positive) or lost (negative) from
+// an airspeedRe match.
+func airspeedChange(m []string) int {
+	if m[1] != "" {
+		kt, _ := strconv.Atoi(m[2])
+		if m[1] == "LOSS" {
+			return -kt
+		}
+		return kt
+	}
+	kt, _ := strconv.Atoi(m[4])
+	if m[3] == "-" {
+		return -kt
+	}
+	return kt
+}
+
+// lastWord returns the last whitespace-separated word of s.
+func lastWord(s string) string {
+	f := strings.Fields(s)
+	if len(f) == 0 {
+		return ""
+	}
+	return f[len(f)-1]
+}
+
+// coordinate converts whole degrees and optional whole minutes to decimal
+// degrees.
+func coordinate(deg, min string, negative bool) float64 {
+	d, _ := strconv.Atoi(deg)
+	v := float64(d)
+	if min != "" {
+		m, _ := strconv.Atoi(min)
+		v += float64(m) / 60
+	}
+	if negative {
+		return -v
+	}
+	return v
+}
+
+// ParseWithTrace implements registry.Traceable for detailed debugging.
+func (p *Parser) ParseWithTrace(msg *acars.Message) *registry.TraceResult {
+	trace := &registry.TraceResult{
+		ParserName: p.Name(),
+	}
+
+	quickCheckPassed := p.QuickCheck(msg.Text)
+	trace.QuickCheck = &registry.QuickCheck{
+		Passed: quickCheckPassed,
+	}
+	if !quickCheckPassed {
+		trace.QuickCheck.Reason = "No turbulence, EDR or wind shear keyword found"
+		return trace
+	}
+	if uplink(msg) {
+		trace.QuickCheck.Reason = "Uplink"
+		return trace
+	}
+
+	upper := strings.ToUpper(msg.Text)
+	for _, e := range []struct {
+		name    string
+		pattern *regexp.Regexp
+	}{
+		{"severity", severityRe},
+		{"wind_shear", shearRe},
+		{"ws", wsRe},
+		{"airspeed_change", airspeedRe},
+		{"edr", edrRe},
+		{"flight_level", flightLevelRe},
+		{"altitude", altitudeRe},
+		{"lat_lon", latLonPrefixRe},
+		{"lat_lon_suffix", latLonSuffixRe},
+		{"waypoint", waypointRe},
+	} {
+		ext := registry.Extractor{Name: e.name, Pattern: e.pattern.String()}
+		if m := e.pattern.FindString(upper); m != "" {
+			ext.Matched = true
+			ext.Value = m
+		}
+		trace.Extractors = append(trace.Extractors, ext)
+	}
+
+	trace.Matched = p.Parse(msg) != nil
+	return trace
+}
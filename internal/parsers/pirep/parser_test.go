@@ -0,0 +1,86 @@
+package pirep
+
+import (
+	"math"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestParser(t *testing.T) {
+	p := &Parser{}
+
+	tests := []struct {
+		name  string
+		label string
+		text  string
+		want  TurbulenceResult
+		edr   float64
+	}{
+		{
+			name:  "crew report with waypoint",
+			label: "5U",
+			text:  "OCNL LGT-MOD TURB FL350-FL370 OVER TIDKA",
+			want: TurbulenceResult{Phenomenon: PhenomenonTurbulence, Severity: SeverityModerate, SeverityText: "OCNL LGT-MOD",
+				Frequency: "occasional", FlightLevel: 350, FlightLevelTop: 370, Waypoint: "TIDKA"},
+		},
+		{
+			name:  "crew report with position",
+			label: "21",
+			text:  "SEV TURB ENCOUNTERED 4530N02000W FL380 PAX SEATED",
+			want: TurbulenceResult{Phenomenon: PhenomenonTurbulence, Severity: SeveritySevere, SeverityText: "SEV",
+				FlightLevel: 380, Latitude: 45.5, Longitude: -20},
+		},
+		{
+			name:  "automatic EDR report",
+			label: "H1",
+			text:  "#DFB EDR PEAK EDR 0.32 MEAN EDR .12 N4530W02000 FL360",
+			want: TurbulenceResult{Phenomenon: PhenomenonTurbulence, Severity: SeverityModerate, FlightLevel: 360,
+				Latitude: 45.5, Longitude: -20},
+			edr: 0.32,
+		},
+		{
+			name:  "wind shear",
+			label: "5U",
+			text:  "WINDSHEAR ON FINAL RWY 34L LOSS 15KT 800FT",
+			want:  TurbulenceResult{Phenomenon: PhenomenonWindShear, AirspeedChange: -15, Altitude: 800},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := p.Parse(&acars.Message{ID: 7, Label: tt.label, Text: tt.text}).(*TurbulenceResult)
+			if !ok {
+				t.Fatal("Parse() = nil")
+			}
+			tt.want.MsgID, tt.want.Text = 7, tt.text
+			r.Latitude, r.Longitude = math.Round(r.Latitude*1e4)/1e4, math.Round(r.Longitude*1e4)/1e4
+			edrPeak := r.EDRPeak
+			r.EDRPeak, r.EDRMean = nil, nil
+			if *r != tt.want {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", *r, tt.want)
+			}
+			if tt.edr != 0 && (edrPeak == nil || *edrPeak != tt.edr) {
+				t.Errorf("EDRPeak = %v, want %v", edrPeak, tt.edr)
+			}
+		})
+	}
+
+	for _, msg := range []acars.Message{
+		{Label: "5U", Text: "PAX IN 12C REPORTS TURB IN LAV"},
+		{Label: "5U", Text: "MOD TURB FL340", LinkDirection: "uplink"},
+		{Label: "H1", Text: "SIGMET 3 VALID 0600/1000 SEV TURB FCST FL300-400"},
+		{Label: "5U", Text: "WS FWD GALLEY INOP"},
+	} {
+		if r := p.Parse(&msg); r != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", msg.Text, r)
+		}
+	}
+}
+
+func TestEDRSeverity(t *testing.T) {
+	for edr, want := range map[float64]string{0.05: SeverityNone, 0.15: SeverityLight, 0.2: SeverityModerate, 0.45: SeverityModerate, 0.5: SeveritySevere} {
+		if got := EDRSeverity(edr); got != want {
+			t.Errorf("EDRSeverity(%v) = %q, want %q", edr, got, want)
+		}
+	}
+}
@@ -111,6 +111,8 @@ func (p *Parser) Name() string           { return "takeoff_data" }
 func (p *Parser) Labels() []string       { return []string{"RA", "H1", "C1"} }
 func (p *Parser) Priority() int          { return 55 }
 
+func (p *Parser) Anchors() []string { return []string{"TAKEOFF DATA", "T/O DATA"} }
+
 func (p *Parser) QuickCheck(text string) bool {
 	return strings.Contains(text, "TAKEOFF DATA") || strings.Contains(text, "T/O DATA")
 }
@@ -0,0 +1,197 @@
+package takeoff
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// PerformanceResult represents a takeoff performance request (downlink) or
+// the takeoff data returned for it (uplink) on labels 1M and 15.
+type PerformanceResult struct {
+	MsgID       int64   `json:"message_id"`
+	Timestamp   string  `json:"timestamp"`
+	Tail        string  `json:"tail,omitempty"`
+	MessageType string  `json:"message_type"` // "request" or "response".
+	Airport     string  `json:"airport,omitempty"`
+	Runway      string  `json:"runway,omitempty"`
+	FlapSetting string  `json:"flap_setting,omitempty"`
+	AssumedTemp *int    `json:"assumed_temp,omitempty"` // Flex or assumed temperature, °C.
+	OAT         *int    `json:"oat,omitempty"`          // Outside air temperature, °C.
+	Wind        string  `json:"wind,omitempty"`
+	QNH         string  `json:"qnh,omitempty"`
+	TOW         float64 `json:"tow,omitempty"`      // Takeoff weight as reported.
+	TOWUnit     string  `json:"tow_unit,omitempty"` // "KG", "LB" or "T" when given.
+	V1          int     `json:"v1,omitempty"`
+	VR          int     `json:"vr,omitempty"`
+	V2          int     `json:"v2,omitempty"`
+}
+
+func (r *PerformanceResult) Type() string     { return "takeoff_performance" }
+func (r *PerformanceResult) MessageID() int64 { return r.MsgID }
+
+// PerformanceParser parses takeoff performance requests and responses.
+type PerformanceParser struct{}
+
+func init() {
+	registry.Register(&PerformanceParser{})
+}
+
+func (p *PerformanceParser) Name() string     { return "takeoff_performance" }
+func (p *PerformanceParser) Labels() []string { return []string{"1M", "15"} }
+func (p *PerformanceParser) Priority() int    { return 90 }
+
+// QuickCheck looks for takeoff data keywords.
+func (p *PerformanceParser) QuickCheck(text string) bool {
+	return perfHeaderRe.MatchString(strings.ToUpper(text))
+}
+
+// Performance message patterns.
+var (
+	// Header: TAKEOFF DATA, TAKE OFF PERF REQUEST, T/O DATA, TO DATA, TOREQ.
+	perfHeaderRe = regexp.MustCompile(`\b(?:TAKE ?OFF|T/O|TO)\s*(?:DATA|PERF(?:ORMANCE)?|REQ(?:UEST)?)\b|\bTOREQ\b|\bTODATA\b`)
+
+	// Requests: REQ, REQUEST, TOREQ.
+	perfRequestRe = regexp.MustCompile(`\bREQ(?:UEST)?\b|\bTOREQ\b`)
+
+	// Airport: APT KSFO, or after the header: TAKEOFF DATA KSFO.
+	perfAirportRe       = regexp.MustCompile(`\b(?:APT|ARPT|AIRPORT)[ :/]*([A-Z]{4})\b`)
+	perfHeaderAirportRe = regexp.MustCompile(`\b(?:DATA|PERF(?:ORMANCE)?|REQ(?:UEST)?|TOREQ|TODATA)[ /]+([A-Z]{4})\b`)
+
+	// Runway: RWY 28R, RW28R, RUNWAY 09, or straight after the airport.
+	perfRunwayRe        = regexp.MustCompile(`\b(?:RWY|RW|RUNWAY)[ :/]*(\d{2}[LRC]?)\b`)
+	perfAirportRunwayRe = regexp.MustCompile(`^[ /]+(\d{2}[LRC]?)\b`)
+
+	// Flaps: FLAPS 5, FLAP 15, CONF 1+F.
+	perfFlapRe = regexp.MustCompile(`\b(?:FLAPS?|FLP|CONF(?:IG)?)[ :/]*(\d{1,2}(?:\+F)?)`)
+
+	// Assumed temperature: ASSUMED TEMP 48C, FLEX 56, ATM 45, SEL TEMP 50.
+	perfAssumedRe = regexp.MustCompile(`\b(?:ASSUMED(?: TEMP)?|ASMD TEMP|ATM|FLEX(?: TEMP)?|SEL TEMP)[ :/]*(M?\d{1,2})C?\b`)
+
+	// Outside air temperature: OAT 18, OAT M5C, TEMP 25C.
+	perfOatRe = regexp.MustCompile(`\b(?:OAT|TEMP)[ :/]*(M?\d{1,2})C?\b`)
+
+	// Wind: WIND 280/12.
+	perfWindRe = regexp.MustCompile(`\bWIND[ :/]*(\d{3}/\d{1,3})\b`)
+
+	// QNH: QNH 1013, ALT 29.92.
+	perfQnhRe = regexp.MustCompile(`\b(?:QNH|ALT(?:IMETER)?)[ :/]*(\d{4}|\d{2}\.\d{2})\b`)
+
+	// Takeoff weight: TOW 165.4, GW 71500 KG, TOGW 412.5 LBS.
+	perfTowRe = regexp.MustCompile(`\b(?:TOW|TOGW|GWT?)[ :/]*(\d+(?:\.\d+)?)\s*(KGS?|LBS?|T)?\b`)
+
+	// V-speeds: V1 142, VR/146, V2=151.
+	perfV1Re = regexp.MustCompile(`\bV1[ :/=]*(\d{2,3})\b`)
+	perfVrRe = regexp.MustCompile(`\bVR[ :/=]*(\d{2,3})\b`)
+	perfV2Re = regexp.MustCompile(`\bV2[ :/=]*(\d{2,3})\b`)
+)
+
+func (p *PerformanceParser) Parse(msg *acars.Message) registry.Result {
+	text := strings.ToUpper(msg.Text)
+	if !perfHeaderRe.MatchString(text) {
+		return nil
+	}
+
+	result := &PerformanceResult{
+		MsgID:     int64(msg.ID),
+		Timestamp: msg.Timestamp,
+		Tail:      msg.Tail,
+	}
+
+	// Extract airport and runway.
+	if m := perfAirportRe.FindStringSubmatch(text); m != nil {
+		result.Airport = m[1]
+	} else if m := perfHeaderAirportRe.FindStringSubmatch(text); m != nil {
+		result.Airport = m[1]
+	}
+	if m := perfRunwayRe.FindStringSubmatch(text); m != nil {
+		result.Runway = m[1]
+	} else if i := strings.Index(text, result.Airport); result.Airport != "" && i >= 0 {
+		if m := perfAirportRunwayRe.FindStringSubmatch(text[i+len(result.Airport):]); m != nil {
+			result.Runway = m[1]
+		}
+	}
+
+	// Extract flap setting.
+	if m := perfFlapRe.FindStringSubmatch(text); m != nil {
+		result.FlapSetting = m[1]
+	}
+
+	// Extract temperatures. The assumed temperature is removed first, as
+	// "ASSUMED TEMP 48C" would otherwise also read as the OAT.
+	if m := perfAssumedRe.FindStringSubmatchIndex(text); m != nil {
+		t := parseTemp(text[m[2]:m[3]])
+		result.AssumedTemp = &t
+		text = text[:m[0]] + text[m[1]:]
+	}
+	if m := perfOatRe.FindStringSubmatch(text); m != nil {
+		t := parseTemp(m[1])
+		result.OAT = &t
+	}
+
+	// Extract wind and QNH.
+	if m := perfWindRe.FindStringSubmatch(text); m != nil {
+		result.Wind = m[1]
+	}
+	if m := perfQnhRe.FindStringSubmatch(text); m != nil {
+		result.QNH = m[1]
+	}
+
+	// Extract takeoff weight.
+	if m := perfTowRe.FindStringSubmatch(text); m != nil {
+		result.TOW, _ = strconv.ParseFloat(m[1], 64)
+		result.TOWUnit = normaliseUnit(m[2])
+	}
+
+	// Extract V-speeds.
+	if m := perfV1Re.FindStringSubmatch(text); m != nil {
+		result.V1, _ = strconv.Atoi(m[1])
+	}
+	if m := perfVrRe.FindStringSubmatch(text); m != nil {
+		result.VR, _ = strconv.Atoi(m[1])
+	}
+	if m := perfV2Re.FindStringSubmatch(text); m != nil {
+		result.V2, _ = strconv.Atoi(m[1])
+	}
+
+	// V-speeds only come back in the response; without them a message is a
+	// request only if it says so.
+	switch {
+	case result.V1 > 0 || result.VR > 0 || result.V2 > 0:
+		result.MessageType = "response"
+	case perfRequestRe.MatchString(text):
+		result.MessageType = "request"
+	default:
+		return nil
+	}
+
+	// A request must say what it is asking for.
+	if result.MessageType == "request" && result.Runway == "" && result.FlapSetting == "" && result.TOW == 0 {
+		return nil
+	}
+	return result
+}
+
+// parseTemp parses a temperature with an optional M (minus) prefix.
+func parseTemp(s string) int {
+	if strings.HasPrefix(s, "M") {
+		t, _ := strconv.Atoi(s[1:])
+		return -t
+	}
+	t, _ := strconv.Atoi(s)
+	return t
+}
+
+// normaliseUnit reduces weight units to "KG", "LB" or "T".
+func normaliseUnit(s string) string {
+	switch {
+	case strings.HasPrefix(s, "KG"):
+		return "KG"
+	case strings.HasPrefix(s, "LB"):
+		return "LB"
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package takeoff
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestPerformanceParser_Parse(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		text  string
+		want  PerformanceResult
+	}{
+		{
+			name:  "request",
+			label: "1M",
+			text:  "TAKEOFF DATA REQUEST\nAPT KSFO RWY 28R FLAPS 5\nTOW 165.4 OAT 18 WIND 280/12 QNH 29.92",
+			want: PerformanceResult{
+				MessageType: "request", Airport: "KSFO", Runway: "28R", FlapSetting: "5",
+				OAT: intPtr(18), Wind: "280/12", QNH: "29.92", TOW: 165.4,
+			},
+		},
+		{
+			name:  "response",
+			label: "15",
+			text:  "TAKEOFF DATA KSFO RWY 28R\nFLAPS 5 ASSUMED TEMP 48C OAT 18\nTOW 165.4\nV1 142 VR 146 V2 151",
+			want: PerformanceResult{
+				MessageType: "response", Airport: "KSFO", Runway: "28R", FlapSetting: "5",
+				AssumedTemp: intPtr(48), OAT: intPtr(18), TOW: 165.4, V1: 142, VR: 146, V2: 151,
+			},
+		},
+		{
+			name:  "Airbus compact response",
+			label: "1M",
+			text:  "T/O DATA LFPG 26R CONF 1+F FLEX 56 V1/145 VR/148 V2/152 TOW 71500 KG",
+			want: PerformanceResult{
+				MessageType: "response", Airport: "LFPG", Runway: "26R", FlapSetting: "1+F",
+				AssumedTemp: intPtr(56), TOW: 71500, TOWUnit: "KG", V1: 145, VR: 148, V2: 152,
+			},
+		},
+		{
+			name:  "negative OAT",
+			label: "1M",
+			text:  "TOREQ CYYZ 06L FLAP 15 OAT M12 GW 412.5 LBS",
+			want: PerformanceResult{
+				MessageType: "request", Airport: "CYYZ", Runway: "06L", FlapSetting: "15",
+				OAT: intPtr(-12), TOW: 412.5, TOWUnit: "LB",
+			},
+		},
+	}
+
+	parser := &PerformanceParser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !parser.QuickCheck(tt.text) {
+				t.Fatal("QuickCheck failed")
+			}
+			result := parser.Parse(&acars.Message{ID: 5, Label: tt.label, Text: tt.text})
+			if result == nil {
+				t.Fatal("expected result, got nil")
+			}
+			r := result.(*PerformanceResult)
+			if r.MessageType != tt.want.MessageType {
+				t.Errorf("MessageType = %q, want %q", r.MessageType, tt.want.MessageType)
+			}
+			if r.Airport != tt.want.Airport || r.Runway != tt.want.Runway {
+				t.Errorf("Airport/Runway = %q/%q, want %q/%q", r.Airport, r.Runway, tt.want.Airport, tt.want.Runway)
+			}
+			if r.FlapSetting != tt.want.FlapSetting {
+				t.Errorf("FlapSetting = %q, want %q", r.FlapSetting, tt.want.FlapSetting)
+			}
+			if !equalTemp(r.AssumedTemp, tt.want.AssumedTemp) || !equalTemp(r.OAT, tt.want.OAT) {
+				t.Errorf("AssumedTemp/OAT = %v/%v, want %v/%v", r.AssumedTemp, r.OAT, tt.want.AssumedTemp, tt.want.OAT)
+			}
+			if r.Wind != tt.want.Wind || r.QNH != tt.want.QNH {
+				t.Errorf("Wind/QNH = %q/%q, want %q/%q", r.Wind, r.QNH, tt.want.Wind, tt.want.QNH)
+			}
+			if r.TOW != tt.want.TOW || r.TOWUnit != tt.want.TOWUnit {
+				t.Errorf("TOW = %v %q, want %v %q", r.TOW, r.TOWUnit, tt.want.TOW, tt.want.TOWUnit)
+			}
+			if r.V1 != tt.want.V1 || r.VR != tt.want.VR || r.V2 != tt.want.V2 {
+				t.Errorf("V1/VR/V2 = %d/%d/%d, want %d/%d/%d", r.V1, r.VR, r.V2, tt.want.V1, tt.want.VR, tt.want.V2)
+			}
+		})
+	}
+}
+
+func TestPerformanceParser_Rejects(t *testing.T) {
+	parser := &PerformanceParser{}
+	for _, text := range []string{
+		"FST01EGLLKJFKN513E00045",
+		"TAKEOFF DATA RECEIVED THANKS",
+		"TAKEOFF DATA REQUEST",
+	} {
+		if result := parser.Parse(&acars.Message{Label: "15", Text: text}); result != nil {
+			t.Errorf("Parse(%q) = %+v, want nil", text, result)
+		}
+	}
+}
+
+func equalTemp(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -41,6 +41,8 @@ func (p *Parser) Name() string     { return "turbulence" }
 func (p *Parser) Labels() []string { return []string{"C1"} }
 func (p *Parser) Priority() int    { return 65 } // Higher priority than weather.
 
+func (p *Parser) Anchors() []string { return []string{"TURB"} }
+
 // QuickCheck looks for turbulence keywords.
 func (p *Parser) QuickCheck(text string) bool {
 	upper := strings.ToUpper(text)
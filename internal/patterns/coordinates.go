@@ -191,4 +191,132 @@ func ParseDecimalCoord(s string, dir string) float64 {
 		return -val
 	}
 	return val
-}
\ No newline at end of file
+}
+
+// ParseRouteCoord parses a position written as a route element or waypoint
+// coordinate, returning decimal degrees. It returns false when s is not one
+// of the encodings below, or is out of range. Supported encodings:
+//   - N31490E035327 (NDDMMT EDDDMMT, tenths of minutes; N47000W094000 is a
+//     whole degree)
+//   - N4749W12218 (NDDMM EDDDMM) and N47W122 (NDD EDDD)
+//   - N4749.5W12218.3 (NDDMM.M EDDDMM.M, decimal minutes)
+//   - 4749N12218W (DDMMN DDDMME) and 47N122W (DDN DDDE), as in ICAO flight
+//     plans
+//   - 5275N and 52N75 (ARINC 424 whole-degree codes, see parseARINCCoord)
+func ParseRouteCoord(s string) (lat, lon float64, ok bool) {
+	s = strings.TrimSpace(s)
+	if len(s) == 5 {
+		return parseARINCCoord(s)
+	}
+	if s == "" {
+		return 0, 0, false
+	}
+
+	var latDir, latVal, lonDir, lonVal string
+	if s[0] == 'N' || s[0] == 'S' {
+		// Direction before the value: N4749W12218.
+		i := strings.IndexAny(s, "EW")
+		if i < 2 {
+			return 0, 0, false
+		}
+		latDir, latVal, lonDir, lonVal = s[:1], s[1:i], s[i:i+1], s[i+1:]
+	} else {
+		// Direction after the value: 4749N12218W.
+		i := strings.IndexAny(s, "NS")
+		if i < 1 || s[len(s)-1] != 'E' && s[len(s)-1] != 'W' {
+			return 0, 0, false
+		}
+		latDir, latVal, lonDir, lonVal = s[i:i+1], s[:i], s[len(s)-1:], s[i+1:len(s)-1]
+	}
+
+	lat, ok = parseRouteDegrees(latVal, 2, 90)
+	if !ok {
+		return 0, 0, false
+	}
+	lon, ok = parseRouteDegrees(lonVal, 3, 180)
+	if !ok {
+		return 0, 0, false
+	}
+	if latDir == "S" {
+		lat = -lat
+	}
+	if lonDir == "W" {
+		lon = -lon
+	}
+	return lat, lon, true
+}
+
+// parseRouteDegrees parses degrees of degDigits digits followed by nothing,
+// whole minutes (MM), minutes and tenths (MMT) or decimal minutes (MM.M), and
+// checks the result is no more than max.
+func parseRouteDegrees(s string, degDigits int, max float64) (float64, bool) {
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if len(whole) < degDigits || !allDigits(whole) || hasFrac && (frac == "" || !allDigits(frac) || len(whole) != degDigits+2) {
+		return 0, false
+	}
+	deg, _ := strconv.Atoi(whole[:degDigits])
+	var min float64
+	switch rest := whole[degDigits:]; len(rest) {
+	case 0:
+	case 2:
+		min, _ = strconv.ParseFloat(rest+"."+frac, 64)
+	case 3:
+		m, _ := strconv.Atoi(rest)
+		min = float64(m) / 10
+	default:
+		return 0, false
+	}
+	v := float64(deg) + min/60
+	if min >= 60 || v > max {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseARINCCoord parses a five-character ARINC 424 latitude/longitude
+// waypoint: two digits of latitude, the last two digits of longitude and a
+// letter giving the quadrant, N (north and west), E (north and east), S (south
+// and east) or W (south and west). The letter is last for a longitude below
+// 100 degrees (5275N is 52N 075W) and third for 100 or more (52N75 is 52N
+// 175W).
+func parseARINCCoord(s string) (lat, lon float64, ok bool) {
+	var digits string
+	var quadrant byte
+	switch {
+	case allDigits(s[:4]):
+		digits, quadrant = s[:4], s[4]
+	case allDigits(s[:2]) && allDigits(s[3:]):
+		digits, quadrant = s[:2]+s[3:], s[2]
+		lon = 100
+	default:
+		return 0, 0, false
+	}
+
+	d, _ := strconv.Atoi(digits[:2])
+	l, _ := strconv.Atoi(digits[2:])
+	lat, lon = float64(d), lon+float64(l)
+	if lat > 90 || lon > 180 {
+		return 0, 0, false
+	}
+	switch quadrant {
+	case 'N':
+		lon = -lon
+	case 'E':
+	case 'S':
+		lat = -lat
+	case 'W':
+		lat, lon = -lat, -lon
+	default:
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+func allDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -228,4 +228,43 @@ func TestParseDecimalCoord(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestParseRouteCoord(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantLat float64
+		wantLon float64
+		wantOK  bool
+	}{
+		{"N31490E035327", 31.816667, 35.545, true},   // Minutes and tenths.
+		{"N47000W094000", 47, -94, true},             // Whole degrees.
+		{"S33520E151180", -33.866667, 151.3, true},   // Southern hemisphere.
+		{"N4749W12218", 47.816667, -122.3, true},     // Whole minutes.
+		{"N47W122", 47, -122, true},                  // Degrees only.
+		{"N4749.5W12218.3", 47.825, -122.305, true},  // Decimal minutes.
+		{"4620N07805W", 46.333333, -78.083333, true}, // ICAO, direction after.
+		{"46N078W", 46, -78, true},
+		{"5275N", 52, -75, true}, // ARINC 424, longitude below 100.
+		{"52N75", 52, -175, true},
+		{"5020E", 50, 20, true},
+		{"0510S", -5, 10, true},
+		{"07N10", 7, -110, true},
+		{"4540W", -45, -40, true},
+		{"N4790W12218", 0, 0, false}, // 90 minutes.
+		{"N9100W12218", 0, 0, false}, // Latitude out of range.
+		{"5290X", 0, 0, false},       // Not a quadrant.
+		{"N0450F350", 0, 0, false},   // Speed and level.
+		{"N123", 0, 0, false},        // Airway.
+		{"TAPUZ", 0, 0, false},       // Named fix.
+		{"SWEET", 0, 0, false},
+		{"N47.5W122", 0, 0, false}, // Decimal without minutes.
+		{"", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		lat, lon, ok := ParseRouteCoord(tt.input)
+		if ok != tt.wantOK || !almostEqual(lat, tt.wantLat, 0.0001) || !almostEqual(lon, tt.wantLon, 0.0001) {
+			t.Errorf("ParseRouteCoord(%q) = %v, %v, %v, want %v, %v, %v", tt.input, lat, lon, ok, tt.wantLat, tt.wantLon, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"acars_parser/internal/dedup"
+	"acars_parser/internal/envflag"
+	"acars_parser/internal/input"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/output"
+	"acars_parser/internal/queue"
+	"acars_parser/internal/state"
+	"acars_parser/internal/storage"
+	"acars_parser/internal/wal"
+)
+
+// Config is the configuration of the process command, read from a file and
+// the environment. Every section is optional; the defaults match those of the
+// decode and replay commands.
+type Config struct {
+	Input       InputConfig     `json:"input"`
+	DedupWindow Duration        `json:"dedup_window"` // 0 keeps every copy.
+	MinQuality  float64         `json:"min_quality"`
+	Clock       ClockConfig     `json:"clock"`
+	Postgres    PostgresConfig  `json:"postgres"`
+	Lifecycle   LifecycleConfig `json:"lifecycle"`
+	Output      OutputConfig    `json:"output"`
+	Queue       QueueConfig     `json:"queue"`
+	WAL         WALConfig       `json:"wal"`
+
+	RegistryFile string `json:"registry_file,omitempty"`
+	AirwaysFile  string `json:"airways_file,omitempty"`
+	CIFPFile     string `json:"cifp_file,omitempty"`
+	PDCFormats   string `json:"pdc_formats,omitempty"` // PDC format definitions, a file or directory.
+	Alerts       string `json:"alerts,omitempty"`      // Alert rules file (see internal/alert).
+
+	// StatsInterval is how often running totals are logged; 0 logs them
+	// only on exit.
+	StatsInterval Duration `json:"stats_interval"`
+}
+
+// InputConfig selects where messages are read from: a NATS subject, files,
+// or stdin when neither is given.
+type InputConfig struct {
+	NATS     *NATSInput `json:"nats,omitempty"`
+	Files    []string   `json:"files,omitempty"`
+	Format   string     `json:"format,omitempty"`    // Format of files and stdin: json or raw.
+	FeederID string     `json:"feeder_id,omitempty"` // Feeder of messages that do not name one.
+
+	// Tenant is the tenant of messages that name none, and Tenants that of
+	// the messages of each feeder (see Stages).
+	Tenant  string            `json:"tenant,omitempty"`
+	Tenants map[string]string `json:"tenants,omitempty"`
+}
+
+// NATSInput is the NATS subject messages are read from.
+type NATSInput struct {
+	URL     string `json:"url"`
+	Creds   string `json:"creds,omitempty"`
+	Subject string `json:"subject"`
+	Queue   string `json:"queue,omitempty"`
+}
+
+// ClockConfig configures message time normalisation (see internal/msgtime).
+type ClockConfig struct {
+	Skew            map[string]Duration `json:"skew,omitempty"` // Fixed offsets by station ID.
+	EstimateSkew    bool                `json:"estimate_skew,omitempty"`
+	MinSkew         Duration            `json:"min_skew"`
+	MaxEmbeddedSkew Duration            `json:"max_embedded_skew"`
+}
+
+// PostgresConfig is the PostgreSQL state database.
+type PostgresConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Database string `json:"database"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	SSLMode  string `json:"sslmode,omitempty"`
+}
+
+// LifecycleConfig sets when flights are archived (see state.Lifecycle).
+type LifecycleConfig struct {
+	Inactivity   Duration `json:"inactivity"`
+	ArrivalGrace Duration `json:"arrival_grace"`
+}
+
+// QueueConfig sets up the queue between parsing and the state tracker (see
+// Pipeline.SetQueue). A size of 0, the default, applies each message to state
+// before the next is read.
+type QueueConfig struct {
+	Size        int    `json:"size"`                   // Messages held in memory.
+	SpillDir    string `json:"spill_dir,omitempty"`    // Where messages go once memory is full.
+	SegmentSize int    `json:"segment_size,omitempty"` // Messages per spill file.
+
+	// Policies says, by result type or "unparsed", whether messages are
+	// dropped ("drop") or kept ("keep", the default) while the queue is full.
+	Policies map[string]string `json:"policies,omitempty"`
+
+	// DrainTimeout is how long an interrupted process waits for the queue
+	// to be applied before it saves what is left to the spill directory.
+	DrainTimeout Duration `json:"drain_timeout"`
+}
+
+// WALConfig sets up the write-ahead log of raw input (see internal/wal). It
+// is off without a directory.
+type WALConfig struct {
+	Dir         string   `json:"dir,omitempty"`
+	SegmentSize int64    `json:"segment_size,omitempty"` // Bytes of input per segment.
+	SegmentAge  Duration `json:"segment_age,omitempty"`
+	Retention   Duration `json:"retention,omitempty"` // 0 keeps every segment.
+}
+
+// OutputConfig selects the sinks results, enrichment updates and emergency
+// events are published to (see internal/output). None are required.
+type OutputConfig struct {
+	Format string       `json:"format,omitempty"` // event or data.
+	NATS   *NATSOutput  `json:"nats,omitempty"`
+	MQTT   *MQTTOutput  `json:"mqtt,omitempty"`
+	Kafka  *KafkaOutput `json:"kafka,omitempty"`
+}
+
+// NATSOutput is a NATS sink.
+type NATSOutput struct {
+	URL     string `json:"url"`
+	Creds   string `json:"creds,omitempty"`
+	Subject string `json:"subject,omitempty"` // Subject template.
+}
+
+// MQTTOutput is an MQTT sink.
+type MQTTOutput struct {
+	Broker   string `json:"broker"`
+	Topic    string `json:"topic,omitempty"` // Topic template.
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+	QoS      int    `json:"qos,omitempty"`
+	Retain   bool   `json:"retain,omitempty"`
+}
+
+// KafkaOutput is a Kafka sink.
+type KafkaOutput struct {
+	Brokers []string `json:"brokers"`
+	Topic   string   `json:"topic,omitempty"` // Topic template.
+}
+
+// Duration is a time.Duration written in a configuration file as a string,
+// such as "90s" or "6h".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"90s\": %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// DefaultConfig returns the configuration used for anything a file leaves
+// out.
+func DefaultConfig() Config {
+	lifecycle := state.DefaultLifecycle()
+	return Config{
+		Input:       InputConfig{Format: "json"},
+		DedupWindow: Duration(dedup.DefaultWindow),
+		Clock: ClockConfig{
+			MinSkew:         Duration(msgtime.DefaultMinSkew),
+			MaxEmbeddedSkew: Duration(msgtime.DefaultMaxEmbeddedSkew),
+		},
+		Postgres: PostgresConfig{
+			Host:     "localhost",
+			Port:     5432,
+			Database: "acars_state",
+			User:     "acars",
+			Password: "acars",
+		},
+		Lifecycle: LifecycleConfig{
+			Inactivity:   Duration(lifecycle.Inactivity),
+			ArrivalGrace: Duration(lifecycle.Grace),
+		},
+		Output: OutputConfig{Format: output.FormatEvent},
+		Queue:  QueueConfig{DrainTimeout: Duration(30 * time.Second)},
+	}
+}
+
+// Load reads a configuration file over the defaults, then applies the
+// environment variables listed in applyEnv over both, so that a container can
+// change a setting without a new file. An empty path uses the defaults and
+// the environment alone. References to environment variables in the file, as
+// $VAR or ${VAR}, are replaced with their values before it is parsed, so that
+// secrets can be kept out of it. Unknown keys are an error, so that a
+// misspelt setting is not silently ignored.
+func Load(path string) (*Config, error) {
+	c := DefaultConfig()
+	name := "environment"
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(strings.NewReader(os.ExpandEnv(string(b))))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		name = path
+	}
+	c.applyEnv()
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &c, nil
+}
+
+// applyEnv overrides settings with the environment variables of the matching
+// decode and replay flags. A variable for a sink or the NATS input adds that
+// section when the file has none.
+func (c *Config) applyEnv() {
+	str := func(key string, v *string) { *v = envflag.String(key, *v) }
+	dur := func(key string, v *Duration) { *v = Duration(envflag.Duration(key, time.Duration(*v))) }
+	set := func(key string) bool { return os.Getenv(key) != "" }
+
+	if c.Input.NATS == nil && set("INPUT_NATS_URL") {
+		c.Input.NATS = &NATSInput{}
+	}
+	if n := c.Input.NATS; n != nil {
+		str("INPUT_NATS_URL", &n.URL)
+		str("INPUT_NATS_CREDS", &n.Creds)
+		str("INPUT_NATS_SUBJECT", &n.Subject)
+		str("INPUT_NATS_QUEUE", &n.Queue)
+	}
+	str("INPUT_FORMAT", &c.Input.Format)
+	str("FEEDER_ID", &c.Input.FeederID)
+	str("TENANT", &c.Input.Tenant)
+	dur("DEDUP_WINDOW", &c.DedupWindow)
+	c.MinQuality = envflag.Float64("MIN_QUALITY", c.MinQuality)
+
+	c.Clock.Skew = envflag.Value("CLOCK_SKEW", c.Clock.Skew, func(s string) (map[string]Duration, error) {
+		skews, err := msgtime.ParseSkews(s)
+		out := make(map[string]Duration, len(skews))
+		for station, d := range skews {
+			out[station] = Duration(d)
+		}
+		return out, err
+	})
+	c.Clock.EstimateSkew = envflag.Bool("ESTIMATE_SKEW", c.Clock.EstimateSkew)
+	dur("MIN_SKEW", &c.Clock.MinSkew)
+	dur("MAX_EMBEDDED_SKEW", &c.Clock.MaxEmbeddedSkew)
+
+	str("POSTGRES_HOST", &c.Postgres.Host)
+	c.Postgres.Port = envflag.Int("POSTGRES_PORT", c.Postgres.Port)
+	str("POSTGRES_DATABASE", &c.Postgres.Database)
+	str("POSTGRES_USER", &c.Postgres.User)
+	str("POSTGRES_PASSWORD", &c.Postgres.Password)
+	str("POSTGRES_SSLMODE", &c.Postgres.SSLMode)
+	dur("INACTIVITY", &c.Lifecycle.Inactivity)
+	dur("ARRIVAL_GRACE", &c.Lifecycle.ArrivalGrace)
+
+	str("REGISTRY_FILE", &c.RegistryFile)
+	str("AIRWAYS_FILE", &c.AirwaysFile)
+	str("CIFP_FILE", &c.CIFPFile)
+	str("PDC_FORMATS", &c.PDCFormats)
+	str("ALERT_RULES", &c.Alerts)
+	dur("STATS_INTERVAL", &c.StatsInterval)
+	c.Queue.Size = envflag.Int("QUEUE_SIZE", c.Queue.Size)
+	str("QUEUE_SPILL_DIR", &c.Queue.SpillDir)
+	dur("QUEUE_DRAIN_TIMEOUT", &c.Queue.DrainTimeout)
+	str("WAL_DIR", &c.WAL.Dir)
+	dur("WAL_RETENTION", &c.WAL.Retention)
+
+	str("SINK_FORMAT", &c.Output.Format)
+	if c.Output.NATS == nil && set("NATS_URL") {
+		c.Output.NATS = &NATSOutput{}
+	}
+	if n := c.Output.NATS; n != nil {
+		str("NATS_URL", &n.URL)
+		str("NATS_CREDS", &n.Creds)
+		str("NATS_SUBJECT", &n.Subject)
+	}
+	if c.Output.MQTT == nil && set("MQTT_BROKER") {
+		c.Output.MQTT = &MQTTOutput{}
+	}
+	if m := c.Output.MQTT; m != nil {
+		str("MQTT_BROKER", &m.Broker)
+		str("MQTT_TOPIC", &m.Topic)
+		str("MQTT_USER", &m.User)
+		str("MQTT_PASSWORD", &m.Password)
+		m.QoS = envflag.Int("MQTT_QOS", m.QoS)
+		m.Retain = envflag.Bool("MQTT_RETAIN", m.Retain)
+	}
+	if c.Output.Kafka == nil && set("KAFKA_BROKERS") {
+		c.Output.Kafka = &KafkaOutput{}
+	}
+	if k := c.Output.Kafka; k != nil {
+		if set("KAFKA_BROKERS") {
+			k.Brokers = strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+		}
+		str("KAFKA_TOPIC", &k.Topic)
+	}
+}
+
+func (c *Config) validate() error {
+	if c.Input.NATS != nil {
+		if len(c.Input.Files) > 0 {
+			return fmt.Errorf("input: nats and files are exclusive")
+		}
+		if c.Input.NATS.URL == "" || c.Input.NATS.Subject == "" {
+			return fmt.Errorf("input: nats needs a url and a subject")
+		}
+	}
+	if _, err := input.NewStream(c.Input.Format, bytes.NewReader(nil)); err != nil {
+		return fmt.Errorf("input: %w", err)
+	}
+	if c.MinQuality < 0 || c.MinQuality > 1 {
+		return fmt.Errorf("min_quality must be from 0 to 1, not %g", c.MinQuality)
+	}
+	if c.DedupWindow < 0 || c.StatsInterval < 0 || c.Queue.DrainTimeout < 0 {
+		return fmt.Errorf("durations must not be negative")
+	}
+	if o := c.Output; o.NATS != nil && o.NATS.URL == "" ||
+		o.MQTT != nil && o.MQTT.Broker == "" ||
+		o.Kafka != nil && len(o.Kafka.Brokers) == 0 {
+		return fmt.Errorf("output: each sink needs a url, broker or brokers")
+	}
+	if c.Queue.Size < 0 || c.Queue.SegmentSize < 0 {
+		return fmt.Errorf("queue: sizes must not be negative")
+	}
+	if c.WAL.SegmentSize < 0 || c.WAL.SegmentAge < 0 || c.WAL.Retention < 0 {
+		return fmt.Errorf("wal: sizes and durations must not be negative")
+	}
+	for typ, policy := range c.Queue.Policies {
+		if policy != "drop" && policy != "keep" {
+			return fmt.Errorf("queue: policy for %s must be drop or keep, not %q", typ, policy)
+		}
+	}
+	return nil
+}
+
+// TimeConfig returns the message time normalisation the file configures.
+func (c *Config) TimeConfig() msgtime.Config {
+	cfg := msgtime.Config{
+		EstimateSkew:    c.Clock.EstimateSkew,
+		MinSkew:         time.Duration(c.Clock.MinSkew),
+		MaxEmbeddedSkew: time.Duration(c.Clock.MaxEmbeddedSkew),
+	}
+	if len(c.Clock.Skew) > 0 {
+		cfg.Skew = make(map[string]time.Duration, len(c.Clock.Skew))
+		for station, d := range c.Clock.Skew {
+			cfg.Skew[station] = time.Duration(d)
+		}
+	}
+	return cfg
+}
+
+// StorageConfig returns the state database connection settings.
+func (c *Config) StorageConfig() storage.PostgresConfig {
+	p := c.Postgres
+	return storage.PostgresConfig{
+		Host:     p.Host,
+		Port:     p.Port,
+		Database: p.Database,
+		User:     p.User,
+		Password: p.Password,
+		SSLMode:  p.SSLMode,
+	}
+}
+
+// StateLifecycle returns when flights are archived.
+func (c *Config) StateLifecycle() state.Lifecycle {
+	return state.Lifecycle{
+		Inactivity: time.Duration(c.Lifecycle.Inactivity),
+		Grace:      time.Duration(c.Lifecycle.ArrivalGrace),
+	}
+}
+
+// StateQueue returns the state queue the file configures. It is only used
+// when Queue.Size is above 0.
+func (c *Config) StateQueue() StateQueue {
+	q := c.Queue
+	sq := StateQueue{Config: queue.Config{Size: q.Size, Dir: q.SpillDir, SegmentSize: q.SegmentSize}}
+	for typ, policy := range q.Policies {
+		if policy == "drop" {
+			if sq.Drop == nil {
+				sq.Drop = make(map[string]bool)
+			}
+			sq.Drop[typ] = true
+		}
+	}
+	return sq
+}
+
+// WALConfig returns the write-ahead log the file configures. It is only used
+// when WAL.Dir is set.
+func (c *Config) WALConfig() wal.Config {
+	return wal.Config{
+		Dir:         c.WAL.Dir,
+		SegmentSize: c.WAL.SegmentSize,
+		SegmentAge:  time.Duration(c.WAL.SegmentAge),
+		Retention:   time.Duration(c.WAL.Retention),
+	}
+}
+
+// SinkConfig returns the sinks to open, with the default topic templates
+// where the file gives none.
+func (c *Config) SinkConfig() *output.Config {
+	oc := &output.Config{Format: c.Output.Format}
+	if n := c.Output.NATS; n != nil {
+		oc.NATSURL, oc.NATSCreds, oc.NATSSubject = n.URL, n.Creds, orDefault(n.Subject, output.DefaultNATSSubject)
+	}
+	if m := c.Output.MQTT; m != nil {
+		oc.MQTTBroker, oc.MQTTTopic = m.Broker, orDefault(m.Topic, output.DefaultMQTTTopic)
+		oc.MQTTUser, oc.MQTTPassword = m.User, m.Password
+		oc.MQTTQoS, oc.MQTTRetain = m.QoS, m.Retain
+	}
+	if k := c.Output.Kafka; k != nil {
+		oc.KafkaBrokers, oc.KafkaTopic = strings.Join(k.Brokers, ","), orDefault(k.Topic, output.DefaultKafkaTopic)
+	}
+	return oc
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/output"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "process.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("TEST_PG_PASSWORD", "s3cret")
+	path := writeConfig(t, `{
+		"input": {"nats": {"url": "nats://localhost:4222", "subject": "acars.>"}, "feeder_id": "SYD-1",
+			"tenant": "plane-watch", "tenants": {"MEL-2": "partner-a"}},
+		"dedup_window": "30s",
+		"clock": {"skew": {"YSSY-1": "90s"}},
+		"postgres": {"host": "db", "password": "${TEST_PG_PASSWORD}"},
+		"lifecycle": {"inactivity": "3h"},
+		"output": {"nats": {"url": "nats://localhost:4222"}}
+	}`)
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Input.NATS.Subject != "acars.>" || c.Input.FeederID != "SYD-1" ||
+		c.Input.Tenant != "plane-watch" || c.Input.Tenants["MEL-2"] != "partner-a" {
+		t.Errorf("input = %+v", c.Input)
+	}
+	if time.Duration(c.DedupWindow) != 30*time.Second {
+		t.Errorf("dedup_window = %v", time.Duration(c.DedupWindow))
+	}
+
+	pg := c.StorageConfig()
+	if pg.Host != "db" || pg.Port != 5432 || pg.Password != "s3cret" || pg.Database != "acars_state" {
+		t.Errorf("postgres = %+v, want the file's host and password over the defaults", pg)
+	}
+
+	lc := c.StateLifecycle()
+	if lc.Inactivity != 3*time.Hour || lc.Grace != 30*time.Minute {
+		t.Errorf("lifecycle = %+v", lc)
+	}
+
+	tc := c.TimeConfig()
+	if tc.Skew["YSSY-1"] != 90*time.Second || tc.MaxEmbeddedSkew != time.Hour {
+		t.Errorf("clock = %+v", tc)
+	}
+
+	oc := c.SinkConfig()
+	if oc.NATSURL != "nats://localhost:4222" || oc.NATSSubject != output.DefaultNATSSubject || oc.MQTTBroker != "" {
+		t.Errorf("output = %+v", oc)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unknown key", `{"dedup_widow": "1m"}`, "unknown field"},
+		{"bad duration", `{"dedup_window": 60}`, "duration"},
+		{"nats and files", `{"input": {"nats": {"url": "nats://x", "subject": "a"}, "files": ["a.jsonl"]}}`, "exclusive"},
+		{"nats without subject", `{"input": {"nats": {"url": "nats://x"}}}`, "subject"},
+		{"bad format", `{"input": {"format": "xml"}}`, "xml"},
+		{"bad quality", `{"min_quality": 2}`, "min_quality"},
+		{"sink without broker", `{"output": {"mqtt": {"topic": "a"}}}`, "output"},
+		{"negative queue", `{"queue": {"size": -1}}`, "queue"},
+		{"negative retention", `{"wal": {"retention": "-1h"}}`, "wal"},
+		{"bad policy", `{"queue": {"policies": {"pdc": "discard"}}}`, "drop or keep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadQueue(t *testing.T) {
+	t.Setenv("QUEUE_SPILL_DIR", "/var/spool/acars")
+	path := writeConfig(t, `{"queue": {"size": 500, "policies": {"unparsed": "drop", "pdc": "keep"}}}`)
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sq := c.StateQueue()
+	if sq.Size != 500 || sq.Dir != "/var/spool/acars" {
+		t.Errorf("queue = %+v, want the file's size and the environment's directory", sq.Config)
+	}
+	if !sq.Drop[Unparsed] || sq.Drop["pdc"] || len(sq.Drop) != 1 {
+		t.Errorf("drop = %v, want only unparsed", sq.Drop)
+	}
+	if time.Duration(c.Queue.DrainTimeout) != 30*time.Second {
+		t.Errorf("drain_timeout = %v, want the default", time.Duration(c.Queue.DrainTimeout))
+	}
+}
+
+func TestLoadWAL(t *testing.T) {
+	t.Setenv("WAL_RETENTION", "48h")
+	path := writeConfig(t, `{"wal": {"dir": "/var/lib/acars/wal", "segment_age": "15m"}}`)
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := c.WALConfig()
+	if w.Dir != "/var/lib/acars/wal" || w.SegmentAge != 15*time.Minute || w.Retention != 48*time.Hour || w.SegmentSize != 0 {
+		t.Errorf("wal = %+v", w)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("POSTGRES_HOST", "pg.internal")
+	t.Setenv("DEDUP_WINDOW", "2m")
+	t.Setenv("INPUT_NATS_URL", "nats://nats:4222")
+	t.Setenv("INPUT_NATS_SUBJECT", "acars.raw")
+	t.Setenv("MQTT_BROKER", "tcp://mqtt:1883")
+	t.Setenv("MQTT_QOS", "two")
+
+	c, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Postgres.Host != "pg.internal" || time.Duration(c.DedupWindow) != 2*time.Minute {
+		t.Errorf("postgres host = %q, dedup_window = %v", c.Postgres.Host, time.Duration(c.DedupWindow))
+	}
+	if n := c.Input.NATS; n == nil || n.URL != "nats://nats:4222" || n.Subject != "acars.raw" {
+		t.Errorf("input nats = %+v, want it added from the environment", n)
+	}
+	if m := c.Output.MQTT; m == nil || m.Broker != "tcp://mqtt:1883" || m.QoS != 0 {
+		t.Errorf("output mqtt = %+v, want the broker and the default qos", m)
+	}
+
+	path := writeConfig(t, `{"postgres": {"host": "db", "user": "process"}}`)
+	if c, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Postgres.Host != "pg.internal" || c.Postgres.User != "process" {
+		t.Errorf("postgres = %+v, want the environment over the file", c.Postgres)
+	}
+}
@@ -0,0 +1,244 @@
+// Package pipeline runs decoded input through every processing stage in one
+// process: message time normalisation, deduplication, quality repair, the
+// parser registry, alerting, publishing and the state tracker. It is the core
+// of the process command, which replaces a shell pipeline of decode and
+// separate consumers with one daemon configured from one file (see Config).
+//
+// For each decoded message the stages run in this order:
+//
+//  1. The message is attributed to the configured feeder if it names none,
+//     and to its feeder's tenant if it names no tenant, and its time is
+//     normalised (see internal/msgtime).
+//  2. Copies of a message already seen within the dedup window are dropped
+//     (see internal/dedup).
+//  3. The text is repaired and scored (see internal/quality), and the message
+//     is dispatched through the registry.
+//  4. The results, and any link-layer results decoded with the message, are
+//     published to the sink and matched against the alert rules.
+//  5. Messages scoring at least the minimum quality update PostgreSQL state
+//     through the tracker, which also publishes enrichment updates and
+//     emergency events to the sink. With a state queue (see SetQueue), they
+//     are applied by a worker behind the queue instead, so that a database
+//     outage holds up state but not the stages before it.
+//
+// Frames without an ACARS message, such as HFDL squitters, only have their
+// link-layer results published.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/alert"
+	"acars_parser/internal/dedup"
+	"acars_parser/internal/input"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/output"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/queue"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/state"
+)
+
+// Stages are the components a Pipeline runs messages through. Registry and
+// Clock are required; a nil Filter, Tracker, Sink or Alerts skips that stage.
+type Stages struct {
+	Registry *registry.Registry
+	Clock    *msgtime.Normaliser
+	Filter   *dedup.Filter
+	Tracker  *state.Tracker
+	Sink     output.Sink
+	Alerts   *alert.Engine
+
+	FeederID   string  // Feeder of messages that do not name one.
+	MinQuality float64 // Messages scoring below this do not update state.
+
+	// Tenants maps feeders, by Message.FeederID, to the tenant whose feed
+	// they belong to. Messages that name no tenant are attributed to their
+	// feeder's, else to TenantID.
+	Tenants  map[string]string
+	TenantID string
+
+	// Ready, if set, reports whether the state database can be reached, so
+	// that the state queue retries updates that fail while it cannot.
+	Ready func(context.Context) error
+}
+
+// Stats counts the input a Pipeline has processed.
+type Stats struct {
+	Inputs        int // Lines or NATS messages read.
+	Undecodable   int
+	Messages      int // ACARS messages, before deduplication.
+	Duplicates    int
+	Parsed        int // Messages at least one parser matched.
+	LowQuality    int // Messages skipped by the tracker for their quality.
+	StateFailed   int // Messages the tracker failed to apply.
+	StateRetries  int // Updates retried while the database was down.
+	Queued        int // Messages in the state queue's memory.
+	Spilled       int // Messages in the state queue's spill directory.
+	Dropped       int // Messages dropped from a full state queue.
+	Published     int
+	PublishFailed int
+	Alerted       int
+	AlertFailed   int // Messages whose alerts failed.
+}
+
+// Pipeline processes decoded input. Stats may be read from another
+// goroutine while Run is going.
+type Pipeline struct {
+	s     Stages
+	apply func(context.Context, *acars.Message, []registry.Result) error
+
+	// The state queue and its worker, if SetQueue was called.
+	queue        *queue.Queue[stateJob]
+	drop         map[string]bool
+	workerReport func(error)
+	stopWorker   context.CancelFunc
+	workerDone   chan struct{}
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New returns a Pipeline running the given stages.
+func New(s Stages) *Pipeline {
+	p := &Pipeline{s: s}
+	if s.Tracker != nil {
+		p.apply = s.Tracker.Apply
+	}
+	return p
+}
+
+// Stats returns the counts so far.
+func (p *Pipeline) Stats() Stats {
+	p.mu.Lock()
+	stats := p.stats
+	p.mu.Unlock()
+	if p.queue != nil {
+		stats.Queued, stats.Spilled = p.queue.Depth()
+	}
+	return stats
+}
+
+func (p *Pipeline) count(f func(*Stats)) {
+	p.mu.Lock()
+	f(&p.stats)
+	p.mu.Unlock()
+}
+
+// Run processes the stream until it returns io.EOF. Input that cannot be
+// decoded, and failures of a stage for one message, are passed to report
+// (which may be nil) and processing continues; Run only returns an error for
+// a stream that fails for good. To stop a live stream, close it: Run returns
+// once the input already received is processed. ctx is used for writes, so
+// it should outlive the stream.
+func (p *Pipeline) Run(ctx context.Context, in input.Stream, report func(error)) error {
+	if report == nil {
+		report = func(error) {}
+	}
+	for {
+		d, err := in.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		p.count(func(s *Stats) { s.Inputs++ })
+		if err != nil {
+			p.count(func(s *Stats) { s.Undecodable++ })
+			report(err)
+			continue
+		}
+		if err := p.Process(ctx, d); err != nil {
+			report(err)
+		}
+	}
+}
+
+// Process runs one decoded input through the stages. The error joins the
+// failures of every stage; the stages after a failed one still run.
+func (p *Pipeline) Process(ctx context.Context, d *input.Decoded) error {
+	msg := d.Message
+	if msg == nil {
+		return p.publish(ctx, output.ResultEvents(nil, registry.Unattributed(d.Results), time.Now()))
+	}
+
+	p.count(func(s *Stats) { s.Messages++ })
+	if msg.Feeder == "" {
+		msg.Feeder = p.s.FeederID
+	}
+	if msg.Tenant == "" {
+		msg.Tenant = p.s.TenantID
+		if t, ok := p.s.Tenants[msg.FeederID()]; ok {
+			msg.Tenant = t
+		}
+	}
+	p.s.Clock.Normalise(msg, time.Now())
+	// The same downlink is delivered once per station and feeder that heard it.
+	if p.s.Filter != nil && p.s.Filter.Duplicate(msg, msg.Time) {
+		p.count(func(s *Stats) { s.Duplicates++ })
+		return nil
+	}
+
+	msg, q := quality.Prepare(msg)
+	attributed := p.s.Registry.DispatchAttributed(msg)
+	parsed := registry.Results(attributed)
+	p.s.Clock.CheckEmbedded(msg, parsed)
+	results := quality.Annotate(parsed, q)
+	if len(results) > 0 {
+		p.count(func(s *Stats) { s.Parsed++ })
+	}
+	for i := range attributed {
+		attributed[i].Result = results[i]
+	}
+	all := append(append([]registry.Result(nil), d.Results...), results...)
+
+	var errs []error
+	events := output.ResultEvents(msg, append(registry.Unattributed(d.Results), attributed...), time.Now())
+	if err := p.publish(ctx, events); err != nil {
+		errs = append(errs, err)
+	}
+
+	if p.s.Alerts != nil {
+		sent, err := p.s.Alerts.Notify(ctx, msg, all)
+		p.count(func(s *Stats) { s.Alerted += sent })
+		if err != nil {
+			p.count(func(s *Stats) { s.AlertFailed++ })
+			errs = append(errs, err)
+		}
+	}
+
+	if p.apply != nil {
+		if !q.OK(p.s.MinQuality) {
+			p.count(func(s *Stats) { s.LowQuality++ })
+		} else if p.queue != nil {
+			if err := p.enqueue(ctx, msg, q, results); err != nil {
+				errs = append(errs, err)
+			}
+		} else if err := p.apply(ctx, msg, results); err != nil {
+			p.count(func(s *Stats) { s.StateFailed++ })
+			errs = append(errs, fmt.Errorf("message %d: %w", msg.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publish sends events to the sink, continuing past failures.
+func (p *Pipeline) publish(ctx context.Context, events []output.Event) error {
+	if p.s.Sink == nil {
+		return nil
+	}
+	var errs []error
+	for _, e := range events {
+		if err := p.s.Sink.Publish(ctx, e); err != nil {
+			p.count(func(s *Stats) { s.PublishFailed++ })
+			errs = append(errs, err)
+			continue
+		}
+		p.count(func(s *Stats) { s.Published++ })
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/dedup"
+	"acars_parser/internal/input"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/output"
+	"acars_parser/internal/registry"
+)
+
+type echoResult struct {
+	ID   int64  `json:"id"`
+	Text string `json:"text"`
+}
+
+func (r *echoResult) Type() string     { return "echo" }
+func (r *echoResult) MessageID() int64 { return r.ID }
+
+type echoParser struct{}
+
+func (echoParser) Name() string                { return "echo" }
+func (echoParser) Labels() []string            { return []string{"H1"} }
+func (echoParser) QuickCheck(text string) bool { return true }
+func (echoParser) Priority() int               { return 0 }
+func (echoParser) Parse(msg *acars.Message) registry.Result {
+	return &echoResult{ID: int64(msg.ID), Text: msg.Text}
+}
+
+type recordingSink struct {
+	events []output.Event
+	err    error
+}
+
+func (s *recordingSink) Publish(_ context.Context, e output.Event) error {
+	s.events = append(s.events, e)
+	return s.err
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func testStages(sink output.Sink) Stages {
+	reg := registry.New()
+	reg.Register(echoParser{})
+	return Stages{
+		Registry: reg,
+		Clock:    msgtime.New(msgtime.DefaultConfig()),
+		Filter:   dedup.New(time.Minute),
+		Sink:     sink,
+		FeederID: "SYD-1",
+	}
+}
+
+func TestRun(t *testing.T) {
+	lines := strings.Join([]string{
+		`{"id":1,"timestamp":"2026-03-01T10:00:00Z","tail":"VH-OQA","label":"H1","text":"POS"}`,
+		`not json`,
+		// A copy of the first message heard by another station.
+		`{"id":2,"timestamp":"2026-03-01T10:00:02Z","tail":"VH-OQA","label":"H1","text":"POS"}`,
+		`{"id":3,"timestamp":"2026-03-01T10:01:00Z","tail":"VH-OQB","label":"Q0","text":"LINK TEST"}`,
+	}, "\n")
+
+	sink := &recordingSink{}
+	p := New(testStages(sink))
+	var reported []error
+	if err := p.Run(context.Background(), input.NewReader(strings.NewReader(lines)), func(err error) {
+		reported = append(reported, err)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := p.Stats()
+	want := Stats{Inputs: 4, Undecodable: 1, Messages: 3, Duplicates: 1, Parsed: 1, Published: 1}
+	if got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+	if len(reported) != 1 {
+		t.Errorf("reported = %v, want the undecodable line", reported)
+	}
+	if len(sink.events) != 1 || sink.events[0].Type != "echo" || sink.events[0].Tail != "VH-OQA" {
+		t.Fatalf("events = %+v, want one echo event for VH-OQA", sink.events)
+	}
+	if e := sink.events[0]; e.Parser != "echo" || e.ParserVersion != registry.DefaultVersion ||
+		e.MessageID != 1 || e.Feeder != "SYD-1" || e.Confidence == nil || e.DecodedAt == "" {
+		t.Errorf("envelope = %+v", e)
+	}
+}
+
+func TestProcessStampsFeeder(t *testing.T) {
+	p := New(testStages(nil))
+	named := &acars.Message{Timestamp: "2026-03-01T10:00:00Z", Label: "H1", Text: "A", Feeder: "MEL-2"}
+	anon := &acars.Message{Timestamp: "2026-03-01T10:00:00Z", Label: "H1", Text: "B"}
+	for _, m := range []*acars.Message{named, anon} {
+		if err := p.Process(context.Background(), &input.Decoded{Message: m}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if named.Feeder != "MEL-2" || anon.Feeder != "SYD-1" {
+		t.Errorf("feeders = %q, %q; want MEL-2, SYD-1", named.Feeder, anon.Feeder)
+	}
+	if anon.Time.IsZero() {
+		t.Error("message time not normalised")
+	}
+}
+
+func TestProcessStampsTenant(t *testing.T) {
+	s := testStages(nil)
+	s.TenantID = "plane-watch"
+	s.Tenants = map[string]string{"MEL-2": "partner-a"}
+	p := New(s)
+	named := &acars.Message{Timestamp: "2026-03-01T10:00:00Z", Label: "H1", Text: "A", Feeder: "MEL-2", Tenant: "partner-b"}
+	partner := &acars.Message{Timestamp: "2026-03-01T10:00:00Z", Label: "H1", Text: "B", Feeder: "MEL-2"}
+	anon := &acars.Message{Timestamp: "2026-03-01T10:00:00Z", Label: "H1", Text: "C"}
+	for _, m := range []*acars.Message{named, partner, anon} {
+		if err := p.Process(context.Background(), &input.Decoded{Message: m}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if named.Tenant != "partner-b" || partner.Tenant != "partner-a" || anon.Tenant != "plane-watch" {
+		t.Errorf("tenants = %q, %q, %q; want partner-b, partner-a, plane-watch", named.Tenant, partner.Tenant, anon.Tenant)
+	}
+}
+
+func TestProcessPublishFailure(t *testing.T) {
+	sink := &recordingSink{err: errors.New("broker down")}
+	p := New(testStages(sink))
+	msg := &acars.Message{Timestamp: "2026-03-01T10:00:00Z", Label: "H1", Text: "POS"}
+	err := p.Process(context.Background(), &input.Decoded{Message: msg})
+	if err == nil || !strings.Contains(err.Error(), "broker down") {
+		t.Fatalf("err = %v, want the publish error", err)
+	}
+	if s := p.Stats(); s.PublishFailed != 1 || s.Parsed != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestProcessLinkLayerOnly(t *testing.T) {
+	sink := &recordingSink{}
+	p := New(testStages(sink))
+	d := &input.Decoded{Format: input.FormatHFDL, Results: []registry.Result{&echoResult{ID: 9}}}
+	if err := p.Process(context.Background(), d); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s.Messages != 0 || s.Published != 1 {
+		t.Errorf("stats = %+v, want one publish and no messages", s)
+	}
+}
@@ -0,0 +1,185 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/queue"
+	"acars_parser/internal/registry"
+)
+
+// StateQueue configures the queue between parsing and the tracker (see
+// Pipeline.SetQueue).
+type StateQueue struct {
+	queue.Config
+
+	// Drop holds the result types whose messages are dropped, rather than
+	// queued, while the queue is full; Unparsed stands for messages no
+	// parser matched. A message is dropped only when all of its results
+	// are of dropped types.
+	Drop map[string]bool
+
+	// Report, if set, is passed the failures of the state worker.
+	Report func(error)
+}
+
+// Unparsed is the result type that stands for unparsed messages in the drop
+// policies of a StateQueue.
+const Unparsed = "unparsed"
+
+// Waits between retries of a state update while the database is down.
+var (
+	retryMin = time.Second
+	retryMax = 30 * time.Second
+)
+
+// stateJob is a message waiting in the state queue. Only the message and its
+// quality are kept when it is spilled to disk; its results are parsed again
+// when it is read back.
+type stateJob struct {
+	Message *acars.Message `json:"message"` // After quality repair.
+	Time    time.Time      `json:"time"`    // Message.Time, which is not marshalled.
+	Quality quality.Report `json:"quality"`
+
+	parsed  bool
+	results []registry.Result
+}
+
+// SetQueue puts a queue between parsing and the tracker, so that a slow or
+// unreachable database holds up the state updates but not the parsing,
+// publishing and alerting of the messages behind them. A worker applies the
+// queued messages to state in the order they were read. While the database
+// is down, which the Ready stage reports, a failed update is retried until
+// it is back. The queue is filled and spilled as sq sets out (see
+// internal/queue): without a spill directory a full queue holds up the input.
+// Close stops the worker.
+func (p *Pipeline) SetQueue(sq StateQueue) error {
+	q, err := queue.Open[stateJob](sq.Config)
+	if err != nil {
+		return err
+	}
+	p.queue, p.drop, p.workerReport = q, sq.Drop, sq.Report
+	if p.workerReport == nil {
+		p.workerReport = func(error) {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stopWorker = cancel
+	p.workerDone = make(chan struct{})
+	go p.work(ctx)
+	return nil
+}
+
+// enqueue adds a message to the state queue, unless its results may be
+// dropped and the queue is full.
+func (p *Pipeline) enqueue(ctx context.Context, msg *acars.Message, q quality.Report, results []registry.Result) error {
+	job := stateJob{Message: msg, Time: msg.Time, Quality: q, parsed: true, results: results}
+	err := p.queue.Push(ctx, job, p.droppable(results))
+	if errors.Is(err, queue.ErrFull) {
+		p.count(func(s *Stats) { s.Dropped++ })
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("queue message %d: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// droppable reports whether the drop policies allow the message with these
+// results to be dropped.
+func (p *Pipeline) droppable(results []registry.Result) bool {
+	if len(results) == 0 {
+		return p.drop[Unparsed]
+	}
+	for _, r := range results {
+		if !p.drop[r.Type()] {
+			return false
+		}
+	}
+	return true
+}
+
+// work applies queued messages to state until the queue is closed and empty,
+// or ctx is done. A message in hand when ctx is done is put back.
+func (p *Pipeline) work(ctx context.Context) {
+	defer close(p.workerDone)
+	for {
+		job, err := p.queue.Pop(ctx)
+		if err == io.EOF || ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			p.workerReport(err)
+			continue
+		}
+		if !job.parsed {
+			job.Message.Time = job.Time
+			job.results = quality.Annotate(registry.Results(p.s.Registry.DispatchAttributed(job.Message)), job.Quality)
+			job.parsed = true
+		}
+		if err := p.applyState(ctx, job.Message, job.results); err != nil {
+			if ctx.Err() != nil {
+				if err := p.queue.Requeue(job); err != nil {
+					p.workerReport(err)
+				}
+				return
+			}
+			p.workerReport(err)
+		}
+	}
+}
+
+// applyState applies a message to state, retrying while the database is
+// down.
+func (p *Pipeline) applyState(ctx context.Context, msg *acars.Message, results []registry.Result) error {
+	for wait := retryMin; ; wait = min(2*wait, retryMax) {
+		err := p.apply(ctx, msg, results)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if p.s.Ready == nil || p.s.Ready(ctx) == nil {
+			// The failure is the message's own.
+			p.count(func(s *Stats) { s.StateFailed++ })
+			return fmt.Errorf("message %d: %w", msg.ID, err)
+		}
+		p.count(func(s *Stats) { s.StateRetries++ })
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Close stops the state queue, if there is one, once the messages in it are
+// applied or ctx is done. Messages left unapplied are kept in the spill
+// directory for the next run to apply; without one they are lost, and Close
+// says how many.
+func (p *Pipeline) Close(ctx context.Context) error {
+	if p.queue == nil {
+		return nil
+	}
+	p.queue.Close()
+	select {
+	case <-p.workerDone:
+	case <-ctx.Done():
+		p.stopWorker()
+		<-p.workerDone
+	}
+	p.stopWorker()
+	lost, err := p.queue.Save()
+	if err != nil {
+		return fmt.Errorf("save state queue: %w", err)
+	}
+	if lost > 0 {
+		return fmt.Errorf("%d queued messages were not applied to state", lost)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/input"
+	"acars_parser/internal/queue"
+	"acars_parser/internal/registry"
+)
+
+// stateRecorder is a tracker that records the messages applied to it.
+type stateRecorder struct {
+	mu      sync.Mutex
+	applied []string
+	results []int
+	times   []time.Time
+	fail    int           // Calls to fail before succeeding.
+	hold    chan struct{} // If set, each call waits for a value.
+	entered chan struct{} // If set, each call sends a value on entry.
+}
+
+func (r *stateRecorder) apply(ctx context.Context, msg *acars.Message, results []registry.Result) error {
+	if r.entered != nil {
+		r.entered <- struct{}{}
+	}
+	if r.hold != nil {
+		select {
+		case <-r.hold:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("connection refused")
+	}
+	r.applied = append(r.applied, msg.Text)
+	r.results = append(r.results, len(results))
+	r.times = append(r.times, msg.Time)
+	return nil
+}
+
+func queuedPipeline(t *testing.T, r *stateRecorder, sq StateQueue) *Pipeline {
+	t.Helper()
+	p := New(testStages(nil))
+	p.apply = r.apply
+	if err := p.SetQueue(sq); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func process(t *testing.T, p *Pipeline, label string, texts ...string) {
+	t.Helper()
+	for i, text := range texts {
+		msg := &acars.Message{Timestamp: fmt.Sprintf("2026-03-01T10:%02d:00Z", i), Label: label, Text: text}
+		if err := p.Process(context.Background(), &input.Decoded{Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	r := &stateRecorder{}
+	p := queuedPipeline(t, r, StateQueue{Config: queue.Config{Size: 2}})
+	process(t, p, "H1", "A", "B", "C", "D", "E")
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B", "C", "D", "E"}; !reflect.DeepEqual(r.applied, want) {
+		t.Errorf("applied %v, want %v", r.applied, want)
+	}
+}
+
+func TestQueueDropPolicy(t *testing.T) {
+	r := &stateRecorder{hold: make(chan struct{}), entered: make(chan struct{}, 10)}
+	p := queuedPipeline(t, r, StateQueue{Config: queue.Config{Size: 1}, Drop: map[string]bool{Unparsed: true}})
+
+	process(t, p, "H1", "A")
+	<-r.entered // A is being applied.
+	process(t, p, "H1", "B")
+	process(t, p, "Q0", "C") // Unparsed, and the queue is full.
+	if s := p.Stats(); s.Dropped != 1 || s.Queued != 1 {
+		t.Errorf("stats = %+v, want one dropped and one queued", s)
+	}
+
+	// A parsed message is never dropped: it waits for room.
+	done := make(chan error)
+	go func() {
+		msg := &acars.Message{Timestamp: "2026-03-01T11:00:00Z", Label: "H1", Text: "D"}
+		done <- p.Process(context.Background(), &input.Decoded{Message: msg})
+	}()
+	close(r.hold)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B", "D"}; !reflect.DeepEqual(r.applied, want) {
+		t.Errorf("applied %v, want %v", r.applied, want)
+	}
+}
+
+func TestQueueRetriesWhileDown(t *testing.T) {
+	retryMin, retryMax = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { retryMin, retryMax = time.Second, 30*time.Second })
+
+	// The database is down for the first two attempts.
+	r := &stateRecorder{fail: 2}
+	down := 2
+	p := New(testStages(nil))
+	p.apply = r.apply
+	p.s.Ready = func(context.Context) error {
+		if down == 0 {
+			return nil
+		}
+		down--
+		return errors.New("connection refused")
+	}
+	if err := p.SetQueue(StateQueue{Config: queue.Config{Size: 4}}); err != nil {
+		t.Fatal(err)
+	}
+	process(t, p, "H1", "A", "B")
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B"}; !reflect.DeepEqual(r.applied, want) {
+		t.Errorf("applied %v, want %v", r.applied, want)
+	}
+	if s := p.Stats(); s.StateRetries != 2 || s.StateFailed != 0 {
+		t.Errorf("stats = %+v, want two retries and no failures", s)
+	}
+}
+
+func TestQueueFailureWhileUp(t *testing.T) {
+	r := &stateRecorder{fail: 1}
+	var reported []error
+	p := New(testStages(nil))
+	p.apply = r.apply
+	p.s.Ready = func(context.Context) error { return nil }
+	if err := p.SetQueue(StateQueue{Config: queue.Config{Size: 4}, Report: func(err error) { reported = append(reported, err) }}); err != nil {
+		t.Fatal(err)
+	}
+	process(t, p, "H1", "A", "B")
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"B"}; !reflect.DeepEqual(r.applied, want) {
+		t.Errorf("applied %v, want %v", r.applied, want)
+	}
+	if s := p.Stats(); s.StateFailed != 1 || s.StateRetries != 0 || len(reported) != 1 {
+		t.Errorf("stats = %+v with %d reported, want one failure", s, len(reported))
+	}
+}
+
+func TestQueueSpillAndResume(t *testing.T) {
+	dir := t.TempDir()
+	sq := StateQueue{Config: queue.Config{Size: 1, Dir: dir}}
+	r := &stateRecorder{hold: make(chan struct{}), entered: make(chan struct{}, 10)}
+	p := queuedPipeline(t, r, sq)
+	process(t, p, "H1", "A", "B", "C", "D")
+	<-r.entered
+	if s := p.Stats(); s.Queued+s.Spilled != 3 || s.Spilled == 0 {
+		t.Errorf("stats = %+v, want three waiting with some spilled", s)
+	}
+
+	// Stopping before the queue is applied keeps it, and the message being
+	// applied, for the next run.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r = &stateRecorder{}
+	p = queuedPipeline(t, r, sq)
+	if s := p.Stats(); s.Spilled != 4 {
+		t.Errorf("resumed with %d spilled, want 4", s.Spilled)
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B", "C", "D"}; !reflect.DeepEqual(r.applied, want) {
+		t.Errorf("applied %v, want %v", r.applied, want)
+	}
+	// Spilled messages are parsed again, and keep their time.
+	if want := []int{1, 1, 1, 1}; !reflect.DeepEqual(r.results, want) {
+		t.Errorf("results per message = %v, want %v", r.results, want)
+	}
+	if r.times[1].IsZero() {
+		t.Error("spilled message lost its time")
+	}
+}
+
+func TestQueueCloseWithoutSpill(t *testing.T) {
+	r := &stateRecorder{hold: make(chan struct{}), entered: make(chan struct{}, 10)}
+	p := queuedPipeline(t, r, StateQueue{Config: queue.Config{Size: 4}})
+	process(t, p, "H1", "A", "B")
+	<-r.entered
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Close(ctx); err == nil {
+		t.Error("closing with messages unapplied and nowhere to keep them: no error")
+	}
+}
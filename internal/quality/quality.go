@@ -0,0 +1,267 @@
+// Package quality scores ACARS message text for signs of corruption, repairs
+// the simple cases, and annotates parse results with the score so that
+// consumers can discard low-confidence parses.
+//
+// ACARS text is 7-bit ASCII with odd parity. Bit errors on VHF show up as
+// control characters, characters with the parity bit left set, replacement
+// characters from a failed decode, or every character received twice.
+package quality
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Issue names recorded in a Report.
+const (
+	IssueControl     = "control_characters" // C0 control characters or DEL in the text.
+	IssueParity      = "parity_errors"      // Characters outside 7-bit ASCII.
+	IssueUndecodable = "undecodable"        // Replacement characters that cannot be repaired.
+	IssueDoubled     = "doubled_characters" // Every character received twice.
+	IssueLabel       = "invalid_label"      // Label is not two printable characters.
+	IssueLabelText   = "label_text_mismatch"
+)
+
+// Report is the quality assessment of one message.
+type Report struct {
+	Score    float64  `json:"score"` // 1 is clean, 0 is unusable.
+	Issues   []string `json:"issues,omitempty"`
+	Repaired bool     `json:"repaired,omitempty"` // The text was changed before parsing.
+}
+
+// OK reports whether the score is at least min.
+func (r Report) OK(min float64) bool {
+	return r.Score >= min
+}
+
+// Labels that never carry text, and labels whose messages always do.
+var (
+	textlessLabels = map[string]bool{"_d": true, "Q0": true}
+	textLabels     = map[string]bool{"H1": true, "80": true, "5Z": true, "16": true, "44": true}
+)
+
+// Penalties subtracted from a score of 1. Character corruption scales with
+// the share of affected characters, up to its maximum.
+const (
+	penaltyCharBase  = 0.2
+	penaltyCharMax   = 0.7
+	penaltyDoubled   = 0.1
+	penaltyLabel     = 0.5
+	penaltyLabelText = 0.5
+)
+
+// Prepare assesses a message and returns a copy with its text repaired, along
+// with the report. The original message is not modified.
+func Prepare(msg *acars.Message) (*acars.Message, Report) {
+	report := Assess(msg.Label, msg.Text)
+	repaired, _ := Repair(msg.Text)
+	if repaired == msg.Text {
+		return msg, report
+	}
+	out := *msg
+	out.Text = repaired
+	report.Repaired = true
+	return &out, report
+}
+
+// Assess scores message text without changing it.
+func Assess(label, text string) Report {
+	var r Report
+	score := 1.0
+
+	control, parity, undecodable, total := countBadChars(text)
+	if total > 0 {
+		if control > 0 {
+			r.Issues = append(r.Issues, IssueControl)
+			score -= charPenalty(control, total)
+		}
+		if parity > 0 {
+			r.Issues = append(r.Issues, IssueParity)
+			score -= charPenalty(parity, total)
+		}
+		if undecodable > 0 {
+			r.Issues = append(r.Issues, IssueUndecodable)
+			score -= charPenalty(undecodable, total)
+		}
+	}
+	if _, ok := undouble(text); ok {
+		r.Issues = append(r.Issues, IssueDoubled)
+		score -= penaltyDoubled
+	}
+
+	if !validLabel(label) {
+		r.Issues = append(r.Issues, IssueLabel)
+		score -= penaltyLabel
+	} else if empty := strings.TrimSpace(text) == ""; (textlessLabels[label] && !empty) || (textLabels[label] && empty) {
+		r.Issues = append(r.Issues, IssueLabelText)
+		score -= penaltyLabelText
+	}
+
+	r.Score = math.Round(math.Max(score, 0)*100) / 100
+	return r
+}
+
+// Repair applies the repairs that are safe to make without context: control
+// characters and DEL are removed, characters with the parity bit set have it
+// cleared, and text with every character doubled is halved. It returns the
+// repaired text and the issues that were fixed.
+func Repair(text string) (string, []string) {
+	var fixed []string
+	control, parity, _, _ := countBadChars(text)
+
+	if control > 0 || parity > 0 {
+		var b strings.Builder
+		b.Grow(len(text))
+		for i := 0; i < len(text); {
+			c, size := utf8.DecodeRuneInString(text[i:])
+			if c == utf8.RuneError && size == 1 {
+				c = rune(text[i]) // A raw high byte.
+			}
+			i += size
+			switch {
+			case isControl(c):
+				continue
+			case c >= 0x80 && c <= 0xFF:
+				if c&0x7F >= 0x20 && c&0x7F < 0x7F {
+					b.WriteRune(c & 0x7F)
+				}
+			default:
+				b.WriteRune(c)
+			}
+		}
+		text = b.String()
+		if control > 0 {
+			fixed = append(fixed, IssueControl)
+		}
+		if parity > 0 {
+			fixed = append(fixed, IssueParity)
+		}
+	}
+
+	if halved, ok := undouble(text); ok {
+		text = halved
+		fixed = append(fixed, IssueDoubled)
+	}
+	return text, fixed
+}
+
+// countBadChars counts control characters, characters with the parity bit set
+// (Latin-1 range or raw high bytes) and replacement characters.
+func countBadChars(text string) (control, parity, undecodable, total int) {
+	for i := 0; i < len(text); {
+		c, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		total++
+		switch {
+		case c == utf8.RuneError && size == 1:
+			parity++ // A raw high byte.
+		case c == utf8.RuneError:
+			undecodable++
+		case isControl(c):
+			control++
+		case c >= 0x80 && c <= 0xFF:
+			parity++
+		case c > 0xFF:
+			undecodable++
+		}
+	}
+	return control, parity, undecodable, total
+}
+
+// isControl reports whether c is a C0 control character other than the
+// whitespace used for formatting, or DEL.
+func isControl(c rune) bool {
+	return (c < 0x20 && c != '\n' && c != '\r' && c != '\t') || c == 0x7F
+}
+
+func charPenalty(bad, total int) float64 {
+	return math.Min(penaltyCharBase+2*float64(bad)/float64(total), penaltyCharMax)
+}
+
+func validLabel(label string) bool {
+	if len(label) != 2 {
+		return false
+	}
+	return label[0] > 0x20 && label[0] < 0x7F && label[1] > 0x20 && label[1] < 0x7F
+}
+
+// minDoubled is the shortest text considered for undoubling, so that short
+// payloads that happen to be made of pairs ("1100AABB") are left alone.
+const minDoubled = 12
+
+// undouble halves text in which every character, whitespace included, appears
+// twice in a row ("PPOOSS  11222233" to "POS 1223"). It returns false unless
+// the whole text has that form.
+func undouble(text string) (string, bool) {
+	if len(text) < minDoubled || len(text)%2 != 0 {
+		return "", false
+	}
+	var b strings.Builder
+	distinct := false
+	for i := 0; i < len(text); i += 2 {
+		if text[i] != text[i+1] {
+			return "", false
+		}
+		if i > 0 && text[i] != text[i-2] {
+			distinct = true
+		}
+		b.WriteByte(text[i])
+	}
+	if !distinct {
+		return "", false // A single repeated character, e.g. padding.
+	}
+	return b.String(), true
+}
+
+// Annotated is a parse result with the quality report of its message. It
+// marshals as the wrapped result with an added "quality" field.
+type Annotated struct {
+	registry.Result
+	Quality Report
+}
+
+// MarshalJSON adds the quality report to the wrapped result's fields.
+func (a *Annotated) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(a.Result)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	fields["quality"] = a.Quality
+	return json.Marshal(fields)
+}
+
+// Unwrap returns the wrapped result.
+func (a *Annotated) Unwrap() registry.Result {
+	return a.Result
+}
+
+// Annotate wraps each result with the report.
+func Annotate(results []registry.Result, report Report) []registry.Result {
+	out := make([]registry.Result, len(results))
+	for i, r := range results {
+		out[i] = &Annotated{Result: r, Quality: report}
+	}
+	return out
+}
+
+// Filter returns the results whose quality score is at least min. Results
+// without a quality annotation are kept.
+func Filter(results []registry.Result, min float64) []registry.Result {
+	var out []registry.Result
+	for _, r := range results {
+		if a, ok := r.(*Annotated); ok && !a.Quality.OK(min) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+package quality
+
+import (
+	"encoding/json"
+	"testing"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+func hasIssue(r Report, issue string) bool {
+	for _, i := range r.Issues {
+		if i == issue {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		name      string
+		label     string
+		text      string
+		wantScore float64
+		wantIssue string
+	}{
+		{"clean", "H1", "#M1BPOSN33456W084123,ATL,100000", 1, ""},
+		{"clean multiline", "80", "3N01 POSRPT\r\nPOS N3345.6", 1, ""},
+		{"textless label", "_d", "", 1, ""},
+		{"control characters", "H1", "\x01POS N33456\x7F", 0.47, IssueControl},
+		{"parity bit set", "H1", "POS N33456W08412\xC3", 0.68, IssueParity},
+		{"replacement character", "H1", "POS N33456W0841�", 0.68, IssueUndecodable},
+		{"doubled", "H1", "PPOOSS  NN3333445566", 0.9, IssueDoubled},
+		{"invalid label", "H", "POS", 0.5, IssueLabel},
+		{"text on textless label", "_d", "POS N33456", 0.5, IssueLabelText},
+		{"empty H1", "H1", "  ", 0.5, IssueLabelText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Assess(tt.label, tt.text)
+			if r.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %v (issues %v)", r.Score, tt.wantScore, r.Issues)
+			}
+			if tt.wantIssue == "" && len(r.Issues) > 0 {
+				t.Errorf("Issues = %v, want none", r.Issues)
+			}
+			if tt.wantIssue != "" && !hasIssue(r, tt.wantIssue) {
+				t.Errorf("Issues = %v, want %s", r.Issues, tt.wantIssue)
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		want  string
+		fixed int
+	}{
+		{"clean", "POS N33456W084123", "POS N33456W084123", 0},
+		{"strip SOH and DEL", "\x01POS N33456\x7F\x17", "POS N33456", 1},
+		{"clear parity bit", "POS N33456W084±²³", "POS N33456W084123", 1},
+		{"clear parity bit on raw byte", "POS N33456W08412\xB3", "POS N33456W084123", 1},
+		{"undouble", "PPOOSS  NN3333445566", "POS N33456", 1},
+		{"short pairs kept", "1100AABB", "1100AABB", 0},
+		{"padding kept", "------------", "------------", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fixed := Repair(tt.text)
+			if got != tt.want {
+				t.Errorf("Repair() = %q, want %q", got, tt.want)
+			}
+			if len(fixed) != tt.fixed {
+				t.Errorf("fixed = %v, want %d issues", fixed, tt.fixed)
+			}
+		})
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	msg := &acars.Message{Label: "H1", Text: "\x01POS N33456"}
+	out, report := Prepare(msg)
+
+	if out.Text != "POS N33456" {
+		t.Errorf("repaired text = %q", out.Text)
+	}
+	if msg.Text != "\x01POS N33456" {
+		t.Error("Prepare modified the original message")
+	}
+	if !report.Repaired || report.Score >= 1 {
+		t.Errorf("report = %+v, want repaired with reduced score", report)
+	}
+
+	clean := &acars.Message{Label: "H1", Text: "POS N33456"}
+	if out, report := Prepare(clean); out != clean || report.Repaired {
+		t.Error("clean message should be returned unchanged")
+	}
+}
+
+type testResult struct {
+	Flight string `json:"flight"`
+}
+
+func (r *testResult) Type() string     { return "test" }
+func (r *testResult) MessageID() int64 { return 7 }
+
+func TestAnnotate(t *testing.T) {
+	results := Annotate([]registry.Result{&testResult{Flight: "QFA1"}}, Report{Score: 0.4, Issues: []string{IssueParity}})
+
+	if results[0].Type() != "test" || results[0].MessageID() != 7 {
+		t.Error("annotated result does not delegate to the wrapped result")
+	}
+
+	b, err := json.Marshal(results[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var m map[string]interface{}
+	_ = json.Unmarshal(b, &m)
+	if m["flight"] != "QFA1" {
+		t.Errorf("wrapped fields missing: %s", b)
+	}
+	q, _ := m["quality"].(map[string]interface{})
+	if q["score"] != 0.4 {
+		t.Errorf("quality missing: %s", b)
+	}
+
+	if kept := Filter(results, 0.5); len(kept) != 0 {
+		t.Errorf("Filter kept %d results below the minimum", len(kept))
+	}
+	if kept := Filter(results, 0.4); len(kept) != 1 {
+		t.Errorf("Filter dropped a result at the minimum")
+	}
+}
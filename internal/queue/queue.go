@@ -0,0 +1,503 @@
+// Package queue is a bounded FIFO queue between a fast producer and a slow
+// consumer, such as the pipeline's parsing and its PostgreSQL writes.
+//
+// A Queue holds up to Size items in memory. When it is full, Push either
+// waits for room, returns ErrFull for items the caller is willing to lose,
+// or, when a directory is configured, spills the item to disk. Spilled items
+// are appended to numbered segment files of JSON lines and read back in
+// order once the items in memory are consumed, so that a consumer outage of
+// minutes neither blocks the producer nor loses items. While anything is
+// spilled, new items are spilled after it, keeping the queue in order.
+//
+// Segment files outlive the process. Save, called when the consumer stops
+// before the queue is empty, writes the items still in memory ahead of those
+// spilled and records how far the first segment was read, and Open resumes
+// from there. After a crash, the items already read from the first segment
+// are read again.
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultSegmentSize is the number of items per segment file unless
+// configured otherwise.
+const DefaultSegmentSize = 10000
+
+// Errors returned by Push.
+var (
+	ErrFull   = errors.New("queue full")
+	ErrClosed = errors.New("queue closed")
+)
+
+// Config sizes a Queue.
+type Config struct {
+	Size        int    // Items held in memory; at least 1.
+	Dir         string // Directory of segment files; "" does not spill.
+	SegmentSize int    // Items per segment file (default DefaultSegmentSize).
+}
+
+// Segment files are named by a sequence number, so that they sort in the
+// order they are read. Numbering starts well above zero so that Save can put
+// a segment before the first.
+const (
+	segmentSuffix = ".seg"
+	firstSegment  = 1 << 32
+	cursorFile    = "cursor"
+)
+
+// Queue is a bounded FIFO queue of T, which must marshal to JSON when the
+// queue spills. Fields of T not marshalled are lost for spilled items. It is
+// safe for concurrent use.
+type Queue[T any] struct {
+	cfg Config
+
+	mu      sync.Mutex
+	changed chan struct{} // Closed, and replaced, on every change.
+	mem     []T
+	closed  bool
+
+	segments []uint64      // Sequence numbers of the segment files, in order.
+	spilled  int           // Items on disk not yet read.
+	w        *os.File      // The last segment, open for appending.
+	written  int           // Items in the last segment.
+	r        *os.File      // The first segment, open for reading.
+	rd       *bufio.Reader // Reads r.
+	read     int           // Items read from the first segment.
+	unspilt  bool          // The last item popped was read from r.
+}
+
+// Open returns a queue, taking up the items spilled to cfg.Dir by a previous
+// queue where it left off.
+func Open[T any](cfg Config) (*Queue[T], error) {
+	if cfg.Size < 1 {
+		return nil, fmt.Errorf("queue size must be at least 1, not %d", cfg.Size)
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = DefaultSegmentSize
+	}
+	q := &Queue[T]{cfg: cfg, changed: make(chan struct{})}
+	if cfg.Dir == "" {
+		return q, nil
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := q.recover(); err != nil {
+		return nil, fmt.Errorf("recover %s: %w", cfg.Dir, err)
+	}
+	return q, nil
+}
+
+// recover finds the segment files in the directory, and skips the items of
+// the first that the cursor records as read.
+func (q *Queue[T]) recover() error {
+	entries, err := os.ReadDir(q.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentSuffix)
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		n, err := countLines(q.segmentPath(seq))
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, seq)
+		q.spilled += n
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i] < q.segments[j] })
+	if len(q.segments) == 0 {
+		return nil
+	}
+
+	var seq uint64
+	var read int
+	b, err := os.ReadFile(filepath.Join(q.cfg.Dir, cursorFile))
+	if err == nil {
+		_, _ = fmt.Sscan(string(b), &seq, &read)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if seq == q.segments[0] && read > 0 {
+		if err := q.openReader(); err != nil {
+			return err
+		}
+		for ; q.read < read; q.read++ {
+			if _, err := q.rd.ReadBytes('\n'); err != nil {
+				break
+			}
+			q.spilled--
+		}
+	}
+	return nil
+}
+
+// countLines returns the number of lines in a file, counting a last line
+// without a newline.
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n := 0
+	rd := bufio.NewReader(f)
+	for {
+		line, err := rd.ReadBytes('\n')
+		if len(line) > 0 {
+			n++
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (q *Queue[T]) segmentPath(seq uint64) string {
+	return filepath.Join(q.cfg.Dir, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+}
+
+// signal wakes the goroutines waiting for a change. q.mu must be held.
+func (q *Queue[T]) signal() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// wait releases q.mu until the queue changes or ctx is done, and reacquires
+// it.
+func (q *Queue[T]) wait(ctx context.Context) error {
+	ch := q.changed
+	q.mu.Unlock()
+	defer q.mu.Lock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Push adds v to the queue. When the queue is full, an item that may be
+// dropped is refused with ErrFull, and any other is spilled to disk, or else
+// Push waits until there is room or ctx is done. The queue counts as full
+// while anything is spilled.
+func (q *Queue[T]) Push(ctx context.Context, v T, drop bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		switch {
+		case q.closed:
+			return ErrClosed
+		case q.spilled == 0 && len(q.mem) < q.cfg.Size:
+			q.mem = append(q.mem, v)
+			q.signal()
+			return nil
+		case drop:
+			return ErrFull
+		case q.cfg.Dir != "":
+			if err := q.spill(v); err != nil {
+				return err
+			}
+			q.signal()
+			return nil
+		}
+		if err := q.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// spill appends v to the last segment, starting a new one when it is full.
+// q.mu must be held.
+func (q *Queue[T]) spill(v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("spill: %w", err)
+	}
+	if q.w == nil || q.written >= q.cfg.SegmentSize {
+		seq := uint64(firstSegment)
+		if n := len(q.segments); n > 0 {
+			seq = q.segments[n-1] + 1
+		}
+		if err := q.closeWriter(); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("spill: %w", err)
+		}
+		q.segments = append(q.segments, seq)
+		q.w, q.written = f, 0
+	}
+	if _, err := q.w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("spill: %w", err)
+	}
+	q.written++
+	q.spilled++
+	return nil
+}
+
+func (q *Queue[T]) closeWriter() error {
+	if q.w == nil {
+		return nil
+	}
+	err := q.w.Close()
+	q.w = nil
+	return err
+}
+
+// Pop removes and returns the item at the head of the queue, waiting for one
+// if it is empty. It returns io.EOF once the queue is closed and empty. An
+// error reading a spilled item is returned with the item skipped, so that the
+// next Pop carries on.
+func (q *Queue[T]) Pop(ctx context.Context) (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		switch {
+		case len(q.mem) > 0:
+			v := q.mem[0]
+			var zero T
+			q.mem[0] = zero
+			q.mem = q.mem[1:]
+			q.unspilt = false
+			q.signal()
+			return v, nil
+		case q.spilled > 0:
+			v, err := q.unspill()
+			q.unspilt = err == nil && q.r != nil
+			q.signal()
+			return v, err
+		case q.closed:
+			var zero T
+			return zero, io.EOF
+		}
+		if err := q.wait(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// unspill reads the next spilled item, removing each segment once it is
+// read. q.mu must be held and q.spilled must be positive.
+func (q *Queue[T]) unspill() (T, error) {
+	var v T
+	for {
+		if q.r == nil {
+			if err := q.openReader(); err != nil {
+				q.spilled = 0
+				return v, err
+			}
+		}
+		line, err := q.rd.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			q.spilled = 0
+			return v, err
+		}
+		if len(line) == 0 {
+			if len(q.segments) == 1 {
+				q.spilled = 0
+				return v, fmt.Errorf("segment %d ends early", q.segments[0])
+			}
+			if err := q.removeFirst(); err != nil {
+				return v, err
+			}
+			continue
+		}
+
+		q.read++
+		q.spilled--
+		seq, n := q.segments[0], q.read
+		if q.spilled == 0 {
+			// Everything on disk has been read.
+			if err := q.removeAll(); err != nil {
+				return v, err
+			}
+		}
+		if err := json.Unmarshal(line, &v); err != nil {
+			return v, fmt.Errorf("spilled item %d of segment %d: %w", n, seq, err)
+		}
+		return v, nil
+	}
+}
+
+func (q *Queue[T]) openReader() error {
+	f, err := os.Open(q.segmentPath(q.segments[0]))
+	if err != nil {
+		return err
+	}
+	q.r, q.rd, q.read = f, bufio.NewReader(f), 0
+	return nil
+}
+
+// removeFirst deletes the first segment, which has been read.
+func (q *Queue[T]) removeFirst() error {
+	q.closeReader()
+	seq := q.segments[0]
+	q.segments = q.segments[1:]
+	return os.Remove(q.segmentPath(seq))
+}
+
+// removeAll deletes every segment, all of which have been read.
+func (q *Queue[T]) removeAll() error {
+	q.closeReader()
+	if err := q.closeWriter(); err != nil {
+		return err
+	}
+	for len(q.segments) > 0 {
+		seq := q.segments[0]
+		q.segments = q.segments[1:]
+		if err := os.Remove(q.segmentPath(seq)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *Queue[T]) closeReader() {
+	if q.r != nil {
+		_ = q.r.Close()
+	}
+	q.r, q.rd, q.read = nil, nil, 0
+}
+
+// Requeue puts v, the item last returned by Pop, back at the head of the
+// queue, for a consumer that stops before handling it. It may be used after
+// Close, and v is kept however full the queue is.
+func (q *Queue[T]) Requeue(v T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	defer q.signal()
+	if q.unspilt {
+		// Step the reader back over the item, which is still on disk.
+		q.unspilt = false
+		read := q.read - 1
+		q.closeReader()
+		if err := q.openReader(); err != nil {
+			return err
+		}
+		for ; q.read < read; q.read++ {
+			if _, err := q.rd.ReadBytes('\n'); err != nil {
+				return err
+			}
+		}
+		q.spilled++
+		return nil
+	}
+	q.mem = append([]T{v}, q.mem...)
+	return nil
+}
+
+// Depth returns the number of items held in memory and spilled to disk.
+func (q *Queue[T]) Depth() (memory, spilled int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.mem), q.spilled
+}
+
+// Close stops the queue taking items. Pop returns the items already queued,
+// then io.EOF.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.signal()
+}
+
+// Save closes the queue and its files. Items still held in memory are
+// written to disk ahead of those already spilled, and the position reached
+// in the first segment is recorded, for the next Open of the directory to
+// resume from. Without a directory, the items in memory are lost; Save
+// returns how many.
+func (q *Queue[T]) Save() (lost int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.signal()
+	if q.cfg.Dir == "" {
+		lost = len(q.mem)
+		q.mem = nil
+		return lost, nil
+	}
+
+	if err := q.closeWriter(); err != nil {
+		return 0, err
+	}
+	read := q.read
+	q.closeReader()
+	q.read = read
+
+	if len(q.mem) > 0 {
+		// The held items precede those spilled. Nothing has been read
+		// from disk while items were held, so they go in a segment
+		// before the first.
+		seq := uint64(firstSegment)
+		if len(q.segments) > 0 {
+			seq = q.segments[0] - 1
+		}
+		if err := q.writeSegment(seq, q.mem); err != nil {
+			return 0, err
+		}
+		q.segments = append([]uint64{seq}, q.segments...)
+		q.spilled += len(q.mem)
+		q.mem = nil
+	}
+	if err := q.saveCursor(filepath.Join(q.cfg.Dir, cursorFile)); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// saveCursor records how many items of the first segment have been read.
+func (q *Queue[T]) saveCursor(path string) error {
+	if len(q.segments) == 0 || q.read == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, fmt.Appendf(nil, "%d %d\n", q.segments[0], q.read), 0o644)
+}
+
+// writeSegment writes items to a new segment file.
+func (q *Queue[T]) writeSegment(seq uint64, items []T) error {
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, v := range items {
+		if err := enc.Encode(v); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
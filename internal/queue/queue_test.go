@@ -0,0 +1,260 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type item struct {
+	N int `json:"n"`
+}
+
+func push(t *testing.T, q *Queue[item], from, to int) {
+	t.Helper()
+	for n := from; n < to; n++ {
+		if err := q.Push(context.Background(), item{n}, false); err != nil {
+			t.Fatalf("push %d: %v", n, err)
+		}
+	}
+}
+
+func pop(t *testing.T, q *Queue[item], count int) []int {
+	t.Helper()
+	var got []int
+	for range count {
+		v, err := q.Pop(context.Background())
+		if err != nil {
+			t.Fatalf("pop: %v", err)
+		}
+		got = append(got, v.N)
+	}
+	return got
+}
+
+func seq(from, to int) []int {
+	var s []int
+	for n := from; n < to; n++ {
+		s = append(s, n)
+	}
+	return s
+}
+
+func TestMemory(t *testing.T) {
+	q, err := Open[item](Config{Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(t, q, 0, 2)
+	if err := q.Push(context.Background(), item{2}, true); !errors.Is(err, ErrFull) {
+		t.Errorf("droppable item when full: %v, want ErrFull", err)
+	}
+
+	// Without a directory, a full queue waits for room.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Push(ctx, item{2}, false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("push to a full queue: %v, want a timeout", err)
+	}
+	done := make(chan error)
+	go func() { done <- q.Push(context.Background(), item{2}, false) }()
+	if got := pop(t, q, 1); got[0] != 0 {
+		t.Errorf("popped %v, want 0", got)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	q.Close()
+	if got := pop(t, q, 2); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("popped %v after closing, want [1 2]", got)
+	}
+	if _, err := q.Pop(context.Background()); err != io.EOF {
+		t.Errorf("pop from closed empty queue: %v, want EOF", err)
+	}
+	if err := q.Push(context.Background(), item{3}, false); !errors.Is(err, ErrClosed) {
+		t.Errorf("push to closed queue: %v, want ErrClosed", err)
+	}
+}
+
+func TestSpill(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open[item](Config{Size: 3, Dir: dir, SegmentSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(t, q, 0, 12)
+	if mem, spilled := q.Depth(); mem != 3 || spilled != 9 {
+		t.Errorf("depth = %d, %d; want 3, 9", mem, spilled)
+	}
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segs) != 3 {
+		t.Errorf("%d segment files, want 3", len(segs))
+	}
+
+	// Items pushed while some are spilled follow them, even once there is
+	// room in memory.
+	got := pop(t, q, 5)
+	push(t, q, 12, 14)
+	got = append(got, pop(t, q, 9)...)
+	if !reflect.DeepEqual(got, seq(0, 14)) {
+		t.Errorf("popped %v, want 0 to 13 in order", got)
+	}
+	if mem, spilled := q.Depth(); mem != 0 || spilled != 0 {
+		t.Errorf("depth = %d, %d after draining", mem, spilled)
+	}
+	if segs, _ := filepath.Glob(filepath.Join(dir, "*.seg")); len(segs) != 0 {
+		t.Errorf("segment files left once read: %v", segs)
+	}
+
+	// With nothing spilled, items are held in memory again.
+	push(t, q, 14, 15)
+	if mem, spilled := q.Depth(); mem != 1 || spilled != 0 {
+		t.Errorf("depth = %d, %d; want 1, 0", mem, spilled)
+	}
+}
+
+func TestSaveAndResume(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{Size: 3, Dir: dir, SegmentSize: 4}
+	q, err := Open[item](cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(t, q, 0, 10)
+	got := pop(t, q, 4) // 0-2 from memory, 3 from the first segment.
+	push(t, q, 10, 11)  // Spilled after 9.
+	if lost, err := q.Save(); err != nil || lost != 0 {
+		t.Fatalf("save: %d lost, %v", lost, err)
+	}
+
+	// The next queue resumes in the first segment.
+	q, err = Open[item](cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mem, spilled := q.Depth(); mem != 0 || spilled != 7 {
+		t.Errorf("resumed depth = %d, %d; want 0, 7", mem, spilled)
+	}
+	got = append(got, pop(t, q, 2)...)
+	if _, err := q.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = Open[item](cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+	for {
+		v, err := q.Pop(context.Background())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.N)
+	}
+	if !reflect.DeepEqual(got, seq(0, 11)) {
+		t.Errorf("popped %v across restarts, want 0 to 10 in order", got)
+	}
+}
+
+func TestSaveMemoryOnly(t *testing.T) {
+	q, err := Open[item](Config{Size: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(t, q, 0, 2)
+	if lost, err := q.Save(); err != nil || lost != 2 {
+		t.Errorf("save = %d, %v; want 2 lost", lost, err)
+	}
+}
+
+// Items held in memory at a save are written ahead of those spilled.
+func TestSaveHeldItems(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{Size: 2, Dir: dir, SegmentSize: 2}
+	q, err := Open[item](cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(t, q, 0, 5) // 0-1 in memory, 2-4 spilled.
+	if _, err := q.Save(); err != nil {
+		t.Fatal(err)
+	}
+	q, err = Open[item](cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mem, spilled := q.Depth(); mem != 0 || spilled != 5 {
+		t.Errorf("resumed depth = %d, %d; want 0, 5", mem, spilled)
+	}
+	if got := pop(t, q, 5); !reflect.DeepEqual(got, seq(0, 5)) {
+		t.Errorf("popped %v, want 0 to 4", got)
+	}
+}
+
+func TestCorruptItem(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "00000000004294967296.seg"), []byte("{\"n\":1}\nnot json\n{\"n\":2}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	q, err := Open[item](Config{Size: 2, Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	var errs int
+	for range 3 {
+		v, err := q.Pop(context.Background())
+		if err != nil {
+			errs++
+			continue
+		}
+		got = append(got, v.N)
+	}
+	if errs != 1 || !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("popped %v with %d errors, want [1 2] and one error", got, errs)
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{Size: 2, Dir: dir, SegmentSize: 3}
+	q, err := Open[item](cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(t, q, 0, 6) // 0-1 in memory, 2-5 spilled.
+
+	got := pop(t, q, 1)
+	if err := q.Requeue(item{got[0]}); err != nil {
+		t.Fatal(err)
+	}
+	got = pop(t, q, 4) // 0-1 from memory, 2-3 from disk.
+	if err := q.Requeue(item{got[3]}); err != nil {
+		t.Fatal(err)
+	}
+	if mem, spilled := q.Depth(); mem != 0 || spilled != 3 {
+		t.Errorf("depth = %d, %d after requeueing a spilled item; want 0, 3", mem, spilled)
+	}
+
+	// A requeued item survives a save.
+	if _, err := q.Save(); err != nil {
+		t.Fatal(err)
+	}
+	q, err = Open[item](cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pop(t, q, 3); !reflect.DeepEqual(got, []int{3, 4, 5}) {
+		t.Errorf("popped %v after a restart, want [3 4 5]", got)
+	}
+}
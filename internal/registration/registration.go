@@ -0,0 +1,366 @@
+// Package registration maps aircraft registrations (tails) to ICAO 24-bit
+// addresses and back. Countries that allocate addresses algorithmically (the
+// US N-number block and the Australian VH block) are computed; other
+// registrations come from an imported registry.
+package registration
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resolver maps registrations to ICAO hex addresses. Imported entries take
+// precedence over the country algorithms. It is safe for concurrent use.
+type Resolver struct {
+	mu    sync.RWMutex
+	byReg map[string]string
+	byHex map[string]string
+}
+
+// NewResolver returns a resolver that uses only the country algorithms until
+// entries are added.
+func NewResolver() *Resolver {
+	return &Resolver{
+		byReg: make(map[string]string),
+		byHex: make(map[string]string),
+	}
+}
+
+// Add records a registration and its ICAO hex address.
+func (r *Resolver) Add(reg, icaoHex string) error {
+	reg = Normalise(reg)
+	hex, ok := normaliseHex(icaoHex)
+	if reg == "" || !ok {
+		return fmt.Errorf("invalid registration %q or hex %q", reg, icaoHex)
+	}
+	r.mu.Lock()
+	r.byReg[reg] = hex
+	r.byHex[hex] = reg
+	r.mu.Unlock()
+	return nil
+}
+
+// Len returns the number of imported entries.
+func (r *Resolver) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.byReg)
+}
+
+// ICAOHex returns the upper-case ICAO hex address for a registration, from the
+// imported registry or the country algorithms.
+func (r *Resolver) ICAOHex(reg string) (string, bool) {
+	reg = Normalise(reg)
+	if reg == "" {
+		return "", false
+	}
+	r.mu.RLock()
+	hex, ok := r.byReg[reg]
+	r.mu.RUnlock()
+	if ok {
+		return hex, true
+	}
+	if addr, ok := algorithmicHex(reg); ok {
+		return formatHex(addr), true
+	}
+	return "", false
+}
+
+// Registration returns the registration for an ICAO hex address, from the
+// imported registry or the country algorithms.
+func (r *Resolver) Registration(icaoHex string) (string, bool) {
+	hex, ok := normaliseHex(icaoHex)
+	if !ok {
+		return "", false
+	}
+	r.mu.RLock()
+	reg, ok := r.byHex[hex]
+	r.mu.RUnlock()
+	if ok {
+		return reg, true
+	}
+	addr, _ := strconv.ParseUint(hex, 16, 32)
+	return algorithmicRegistration(uint32(addr))
+}
+
+// LoadFile imports a registry CSV file. See LoadCSV.
+func (r *Resolver) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := r.LoadCSV(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadCSV imports registrations from CSV whose first two columns are
+//
+//	registration,icao_hex
+//
+// Further columns are ignored and a header row is skipped.
+func (r *Resolver) LoadCSV(rd io.Reader) error {
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(rec) < 2 {
+			return fmt.Errorf("line %d: want registration,icao_hex", line)
+		}
+		if err := r.Add(rec[0], rec[1]); err != nil {
+			if line == 1 {
+				continue // Header row.
+			}
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// Normalise cleans up a registration as it appears in ACARS messages: it is
+// upper-cased, leading dots and spaces are removed, and an Australian
+// registration missing its dash ("VHOQA") gains one.
+func Normalise(reg string) string {
+	reg = strings.ToUpper(strings.TrimSpace(reg))
+	reg = strings.TrimLeft(reg, ". ")
+	if len(reg) == 5 && strings.HasPrefix(reg, "VH") && isLetters(reg[2:]) {
+		reg = "VH-" + reg[2:]
+	}
+	return reg
+}
+
+func normaliseHex(s string) (string, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) != 6 {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(s, 16, 32); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+func formatHex(addr uint32) string {
+	return fmt.Sprintf("%06X", addr)
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// algorithmicHex computes the address of a registration in a country that
+// allocates addresses by formula.
+func algorithmicHex(reg string) (uint32, bool) {
+	if strings.HasPrefix(reg, "N") {
+		return nNumberToICAO(reg)
+	}
+	if strings.HasPrefix(reg, "VH-") {
+		return australiaToICAO(reg)
+	}
+	return 0, false
+}
+
+// algorithmicRegistration is the inverse of algorithmicHex.
+func algorithmicRegistration(addr uint32) (string, bool) {
+	if reg, ok := icaoToNNumber(addr); ok {
+		return reg, true
+	}
+	return icaoToAustralia(addr)
+}
+
+// =============================================================================
+// Australia (VH-AAA to VH-ZZZ)
+// =============================================================================
+
+// Australian addresses are allocated from 7C0000 in registration order, with
+// each letter taking a base-36 digit (A=0 ... Z=25).
+const (
+	australiaBase   = 0x7C0000
+	australiaStride = 36
+)
+
+func australiaToICAO(reg string) (uint32, bool) {
+	suffix := strings.TrimPrefix(reg, "VH-")
+	if len(suffix) != 3 || !isLetters(suffix) {
+		return 0, false
+	}
+	var addr uint32
+	for i := 0; i < 3; i++ {
+		addr = addr*australiaStride + uint32(suffix[i]-'A')
+	}
+	return australiaBase + addr, true
+}
+
+func icaoToAustralia(addr uint32) (string, bool) {
+	if addr < australiaBase {
+		return "", false
+	}
+	offset := addr - australiaBase
+	var letters [3]byte
+	for i := 2; i >= 0; i-- {
+		letters[i] = byte(offset % australiaStride)
+		offset /= australiaStride
+	}
+	if offset != 0 {
+		return "", false
+	}
+	for i, l := range letters {
+		if l > 25 {
+			return "", false
+		}
+		letters[i] = 'A' + l
+	}
+	return "VH-" + string(letters[:]), true
+}
+
+// =============================================================================
+// United States (N1 to N99999)
+// =============================================================================
+
+// US addresses run from A00001 (N1) to ADF7C7 (N99999). N-numbers are a
+// digit 1-9, up to four more digits, and up to two trailing letters (never I
+// or O), at most five characters after the N. Addresses are allocated in
+// lexical order, so each position is a bucket of fixed size.
+const (
+	nNumberBase    = 0xA00001
+	nNumberLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+	// suffixSize counts the letter suffixes that can follow a digit: none, one
+	// letter, or two letters.
+	suffixSize = 1 + len(nNumberLetters)*(1+len(nNumberLetters))
+	// bucket4Size counts what can follow a fourth digit: nothing, one letter
+	// or a fifth digit.
+	bucket4Size = 1 + len(nNumberLetters) + 10
+	bucket3Size = 10*bucket4Size + suffixSize
+	bucket2Size = 10*bucket3Size + suffixSize
+	bucket1Size = 10*bucket2Size + suffixSize
+)
+
+// nNumberBuckets are the sizes of the digit positions after the first.
+var nNumberBuckets = [...]int{bucket2Size, bucket3Size, bucket4Size}
+
+func validNNumber(reg string) bool {
+	if len(reg) < 2 || len(reg) > 6 || reg[0] != 'N' || reg[1] < '1' || reg[1] > '9' {
+		return false
+	}
+	s := reg[1:]
+	digits := 0
+	for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	letters := s[digits:]
+	if len(letters) > 2 || (digits == 4 && len(letters) > 1) {
+		return false
+	}
+	for i := 0; i < len(letters); i++ {
+		if !strings.ContainsRune(nNumberLetters, rune(letters[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// suffixOffset is the offset of a letter suffix within a suffix block.
+func suffixOffset(s string) int {
+	if s == "" {
+		return 0
+	}
+	n := (len(nNumberLetters)+1)*strings.IndexByte(nNumberLetters, s[0]) + 1
+	if len(s) == 2 {
+		n += strings.IndexByte(nNumberLetters, s[1]) + 1
+	}
+	return n
+}
+
+func nNumberToICAO(reg string) (uint32, bool) {
+	if !validNNumber(reg) {
+		return 0, false
+	}
+	s := reg[1:]
+	offset := int(s[0]-'1') * bucket1Size
+
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			if i == 4 {
+				// A single letter after four digits.
+				return uint32(nNumberBase + offset + 1 + strings.IndexByte(nNumberLetters, c)), true
+			}
+			return uint32(nNumberBase + offset + suffixOffset(s[i:])), true
+		}
+		if i == 4 {
+			// A fifth digit.
+			return uint32(nNumberBase + offset + 1 + len(nNumberLetters) + int(c-'0')), true
+		}
+		offset += suffixSize + int(c-'0')*nNumberBuckets[i-1]
+	}
+	return uint32(nNumberBase + offset), true
+}
+
+func icaoToNNumber(addr uint32) (string, bool) {
+	if addr < nNumberBase || int(addr-nNumberBase) >= 9*bucket1Size {
+		return "", false
+	}
+	offset := int(addr - nNumberBase)
+
+	var b strings.Builder
+	b.WriteByte('N')
+	b.WriteByte(byte('1' + offset/bucket1Size))
+	offset %= bucket1Size
+
+	for _, size := range nNumberBuckets {
+		if offset < suffixSize {
+			b.WriteString(suffixString(offset))
+			return b.String(), true
+		}
+		offset -= suffixSize
+		b.WriteByte(byte('0' + offset/size))
+		offset %= size
+	}
+
+	// After four digits: nothing, a letter, or a fifth digit.
+	switch {
+	case offset == 0:
+	case offset <= len(nNumberLetters):
+		b.WriteByte(nNumberLetters[offset-1])
+	default:
+		b.WriteByte(byte('0' + offset - 1 - len(nNumberLetters)))
+	}
+	return b.String(), true
+}
+
+// suffixString is the inverse of suffixOffset.
+func suffixString(offset int) string {
+	if offset == 0 {
+		return ""
+	}
+	offset--
+	first := nNumberLetters[offset/(len(nNumberLetters)+1)]
+	rest := offset % (len(nNumberLetters) + 1)
+	if rest == 0 {
+		return string(first)
+	}
+	return string([]byte{first, nNumberLetters[rest-1]})
+}
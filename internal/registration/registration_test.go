@@ -0,0 +1,98 @@
+package registration
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestICAOHexAlgorithms(t *testing.T) {
+	tests := []struct {
+		reg  string
+		want string
+	}{
+		{"N1", "A00001"},
+		{"N1A", "A00002"},
+		{"N1AA", "A00003"},
+		{"N10", "A0025A"},
+		{"N99999", "ADF7C7"},
+		{".N99999", "ADF7C7"},
+		{"VH-AAA", "7C0000"},
+		{"VH-OQA", "7C4920"},
+		{"vhoqa", "7C4920"},
+		{"VH-ZZZ", "7C822D"},
+	}
+
+	r := NewResolver()
+	for _, tt := range tests {
+		got, ok := r.ICAOHex(tt.reg)
+		if !ok || got != tt.want {
+			t.Errorf("ICAOHex(%q) = %q, %v; want %q", tt.reg, got, ok, tt.want)
+		}
+	}
+
+	for _, reg := range []string{"N0123", "N1I", "N12345A", "N1234AB", "N123456", "G-EUPT", "VH-OQ1", ""} {
+		if got, ok := r.ICAOHex(reg); ok {
+			t.Errorf("ICAOHex(%q) = %q, want no match", reg, got)
+		}
+	}
+}
+
+// TestNNumberRoundTrip checks every US address maps to a valid N-number that
+// maps back to the same address.
+func TestNNumberRoundTrip(t *testing.T) {
+	for addr := uint32(nNumberBase); addr <= 0xADF7C7; addr++ {
+		reg, ok := icaoToNNumber(addr)
+		if !ok {
+			t.Fatalf("icaoToNNumber(%06X) failed", addr)
+		}
+		back, ok := nNumberToICAO(reg)
+		if !ok || back != addr {
+			t.Fatalf("%06X -> %s -> %06X (ok=%v)", addr, reg, back, ok)
+		}
+	}
+	if _, ok := icaoToNNumber(0xADF7C8); ok {
+		t.Error("ADF7C8 is outside the US N-number block")
+	}
+}
+
+func TestAustraliaRoundTrip(t *testing.T) {
+	count := 0
+	for addr := uint32(australiaBase); addr < australiaBase+36*36*36; addr++ {
+		reg, ok := icaoToAustralia(addr)
+		if !ok {
+			continue
+		}
+		count++
+		back, ok := australiaToICAO(reg)
+		if !ok || back != addr {
+			t.Fatalf("%06X -> %s -> %06X (ok=%v)", addr, reg, back, ok)
+		}
+	}
+	if count != 26*26*26 {
+		t.Errorf("got %d registrations, want %d", count, 26*26*26)
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	r := NewResolver()
+	csv := "registration,icao_hex,type\nG-EUPT,400A0B,A319\n9V-SKA,76CDA1,A388\n"
+	if err := r.LoadCSV(strings.NewReader(csv)); err != nil {
+		t.Fatalf("LoadCSV() error = %v", err)
+	}
+	if r.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", r.Len())
+	}
+	if hex, ok := r.ICAOHex("g-eupt"); !ok || hex != "400A0B" {
+		t.Errorf("ICAOHex(G-EUPT) = %q, %v", hex, ok)
+	}
+	if reg, ok := r.Registration("76cda1"); !ok || reg != "9V-SKA" {
+		t.Errorf("Registration(76CDA1) = %q, %v", reg, ok)
+	}
+	if reg, ok := r.Registration("7C4920"); !ok || reg != "VH-OQA" {
+		t.Errorf("Registration(7C4920) = %q, %v", reg, ok)
+	}
+
+	if err := r.LoadCSV(strings.NewReader("G-EUPT,400A0B\nG-EUPU,XYZ\n")); err == nil {
+		t.Error("expected an error for an invalid hex address")
+	}
+}
@@ -0,0 +1,192 @@
+package registry
+
+// Anchored is implemented by parsers whose QuickCheck can only pass for text
+// containing one of a few substrings, such as "FPN" or ".ADS.". Sort builds,
+// for each label, an Aho-Corasick automaton over the anchors of the label's
+// parsers, so that dispatch finds in one pass over the text which anchors it
+// contains and skips the parsers none of whose anchors appear, without
+// calling their QuickCheck. QuickCheck is still called for the others.
+type Anchored interface {
+	// Anchors returns the substrings, one of which text must contain for
+	// QuickCheck to pass. They are matched ignoring ASCII case, so they also
+	// hold for a QuickCheck of the upper-cased text. An empty list means the
+	// parser is always checked.
+	Anchors() []string
+}
+
+// parserAnchors returns a parser's anchors, or nil if it has none.
+func parserAnchors(p Parser) []string {
+	if a, ok := p.(Anchored); ok {
+		return a.Anchors()
+	}
+	return nil
+}
+
+// anchorIndex is the dispatch index of one label: its candidate parsers, the
+// label's and then the global ones, and the automaton over their anchors.
+type anchorIndex struct {
+	parsers []Parser
+	// anchors lists each parser's anchor numbers, nil for a parser always
+	// checked.
+	anchors [][]int
+	matcher *anchorMatcher // nil if no parser has anchors.
+}
+
+func newAnchorIndex(parsers []Parser) *anchorIndex {
+	idx := &anchorIndex{parsers: parsers, anchors: make([][]int, len(parsers))}
+	var patterns []string
+	number := make(map[string]int)
+	for i, p := range parsers {
+		for _, a := range parserAnchors(p) {
+			if a == "" {
+				continue
+			}
+			key := foldASCII(a)
+			n, ok := number[key]
+			if !ok {
+				n = len(patterns)
+				number[key] = n
+				patterns = append(patterns, key)
+			}
+			idx.anchors[i] = append(idx.anchors[i], n)
+		}
+	}
+	if len(patterns) > 0 {
+		idx.matcher = newAnchorMatcher(patterns)
+	}
+	return idx
+}
+
+// candidates calls fn, in order, for each parser whose anchors appear in text
+// and whose QuickCheck passes, until fn returns false.
+func (idx *anchorIndex) candidates(text string, fn func(Parser) bool) {
+	var found []bool
+	if idx.matcher != nil {
+		found = idx.matcher.match(text)
+	}
+	for i, p := range idx.parsers {
+		if idx.anchors[i] != nil && !anyFound(found, idx.anchors[i]) {
+			continue
+		}
+		if !p.QuickCheck(text) {
+			continue
+		}
+		if !fn(p) {
+			return
+		}
+	}
+}
+
+func anyFound(found []bool, anchors []int) bool {
+	for _, n := range anchors {
+		if found[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// anchorMatcher is an Aho-Corasick automaton that finds which of a set of
+// patterns occur in a text, ignoring ASCII case. Bytes are mapped to classes,
+// one for each byte the patterns use and class 0 for all others, and every
+// state has a transition for every class, so matching is a table lookup per
+// byte.
+type anchorMatcher struct {
+	class    [256]uint8
+	classes  int
+	next     []int32 // next[state*classes+class] is the state after a byte.
+	outputs  [][]int // Patterns ending at each state, through suffix links.
+	patterns int
+}
+
+// newAnchorMatcher builds a matcher over patterns already folded to upper
+// case.
+func newAnchorMatcher(patterns []string) *anchorMatcher {
+	m := &anchorMatcher{classes: 1, patterns: len(patterns)}
+	for _, p := range patterns {
+		for i := 0; i < len(p); i++ {
+			b := p[i]
+			if m.class[b] == 0 {
+				m.class[b] = uint8(m.classes)
+				m.classes++
+			}
+		}
+	}
+	for b := 'a'; b <= 'z'; b++ {
+		m.class[b] = m.class[b-'a'+'A']
+	}
+
+	// Build the trie, with -1 for missing transitions.
+	m.next = make([]int32, m.classes)
+	m.outputs = [][]int{nil}
+	for i := range m.next {
+		m.next[i] = -1
+	}
+	for n, p := range patterns {
+		state := int32(0)
+		for i := 0; i < len(p); i++ {
+			c := int32(m.class[p[i]])
+			if m.next[state*int32(m.classes)+c] < 0 {
+				m.next[state*int32(m.classes)+c] = int32(len(m.outputs))
+				m.outputs = append(m.outputs, nil)
+				for j := 0; j < m.classes; j++ {
+					m.next = append(m.next, -1)
+				}
+			}
+			state = m.next[state*int32(m.classes)+c]
+		}
+		m.outputs[state] = append(m.outputs[state], n)
+	}
+
+	// Fill in the missing transitions breadth first from the suffix links,
+	// and inherit the outputs of each state's suffix.
+	fail := make([]int32, len(m.outputs))
+	var queue []int32
+	for c := 0; c < m.classes; c++ {
+		if s := m.next[c]; s < 0 {
+			m.next[c] = 0
+		} else {
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		m.outputs[state] = append(m.outputs[state], m.outputs[fail[state]]...)
+		for c := 0; c < m.classes; c++ {
+			i := state*int32(m.classes) + int32(c)
+			via := m.next[fail[state]*int32(m.classes)+int32(c)]
+			if s := m.next[i]; s < 0 {
+				m.next[i] = via
+			} else {
+				fail[s] = via
+				queue = append(queue, s)
+			}
+		}
+	}
+	return m
+}
+
+// match returns which patterns occur in text.
+func (m *anchorMatcher) match(text string) []bool {
+	found := make([]bool, m.patterns)
+	state := int32(0)
+	for i := 0; i < len(text); i++ {
+		state = m.next[state*int32(m.classes)+int32(m.class[text[i]])]
+		for _, n := range m.outputs[state] {
+			found[n] = true
+		}
+	}
+	return found
+}
+
+// foldASCII returns s with ASCII letters in upper case.
+func foldASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
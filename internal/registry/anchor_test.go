@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+func TestAnchorMatcher(t *testing.T) {
+	m := newAnchorMatcher([]string{"FPN", "PWI/", ".ADS.", "ADS", "DSX"})
+	tests := []struct {
+		text string
+		want []bool
+	}{
+		{"- #M1BFPN/RP:DA:YSSY", []bool{true, false, false, false, false}},
+		{"/BOMCAYA.ADS.VH-OQA07", []bool{false, false, true, true, false}},
+		{"pwi/wd390", []bool{false, true, false, false, false}},
+		{"ADSX", []bool{false, false, false, true, true}},
+		{"FP N PWI", []bool{false, false, false, false, false}},
+		{"", []bool{false, false, false, false, false}},
+	}
+	for _, tt := range tests {
+		if got := m.match(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("match(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+// anchoredParser is a testParser that declares its keyword as its anchor and
+// counts its QuickCheck calls.
+type anchoredParser struct {
+	testParser
+	checks int
+}
+
+func (p *anchoredParser) Anchors() []string { return []string{p.keyword} }
+func (p *anchoredParser) QuickCheck(text string) bool {
+	p.checks++
+	return p.testParser.QuickCheck(text)
+}
+
+func TestDispatchSkipsUnanchored(t *testing.T) {
+	fpn := &anchoredParser{testParser: testParser{name: "fpn", labels: []string{"H1"}, keyword: "FPN", priority: 10}}
+	pwi := &anchoredParser{testParser: testParser{name: "pwi", labels: []string{"H1"}, keyword: "PWI/", priority: 20}}
+	pdc := &anchoredParser{testParser: testParser{name: "pdc", keyword: "PDC", priority: 500}}
+	r := New()
+	r.Register(fpn)
+	r.Register(pwi)
+	r.Register(&testParser{name: "plain", labels: []string{"H1"}, keyword: "POS", priority: 30})
+	r.Register(pdc)
+	r.RegisterCatchAll(&testParser{name: "fallback"})
+
+	msg := &acars.Message{Label: "H1", Text: "POS FPN"}
+	unsorted := r.Dispatch(msg)
+	fpn.checks, pwi.checks, pdc.checks = 0, 0, 0
+
+	r.Sort()
+	if got := r.Dispatch(msg); !reflect.DeepEqual(got, unsorted) {
+		t.Errorf("sorted dispatch = %v, want %v", got, unsorted)
+	}
+	if fpn.checks != 1 || pwi.checks != 0 || pdc.checks != 0 {
+		t.Errorf("QuickCheck calls = fpn %d, pwi %d, pdc %d, want 1, 0, 0", fpn.checks, pwi.checks, pdc.checks)
+	}
+
+	// Global parsers are indexed for labels without parsers of their own.
+	if got := r.DispatchFirst(&acars.Message{Label: "ZZ", Text: "PDC CLEARANCE"}); got == nil || got.Type() != "pdc" {
+		t.Errorf("DispatchFirst on another label = %v, want pdc", got)
+	}
+	if got := r.Dispatch(&acars.Message{Label: "ZZ", Text: "nothing"}); len(got) != 1 || got[0].Type() != "fallback" {
+		t.Errorf("Dispatch without anchors = %v, want fallback", got)
+	}
+}
@@ -15,6 +15,12 @@ type Result interface {
 	MessageID() int64 // The original message ID
 }
 
+// Scored is implemented by results that rate their own parse, such as a PDC
+// whose fields were not all found.
+type Scored interface {
+	Confidence() float64 // From 0 (a guess) to 1 (certain).
+}
+
 // Parser is implemented by each message parser.
 type Parser interface {
 	// Name returns the parser's unique identifier.
@@ -52,6 +58,11 @@ type Registry struct {
 
 	// sorted tracks whether parsers have been sorted
 	sorted bool
+
+	// index holds the anchor index of each label with parsers, and
+	// globalIndex that of other labels. Both are built by Sort.
+	index       map[string]*anchorIndex
+	globalIndex *anchorIndex
 }
 
 // New creates a new Registry instance.
@@ -129,9 +140,42 @@ func (r *Registry) Sort() {
 		return r.catchAll[i].Priority() < r.catchAll[j].Priority()
 	})
 
+	r.index = make(map[string]*anchorIndex, len(r.byLabel))
+	for label, parsers := range r.byLabel {
+		candidates := append(append([]Parser{}, parsers...), r.global...)
+		r.index[label] = newAnchorIndex(candidates)
+	}
+	r.globalIndex = newAnchorIndex(r.global)
+
 	r.sorted = true
 }
 
+// eachCandidate calls fn, in dispatch order, for each label-specific and then
+// global parser whose QuickCheck passes for a message, until fn returns false.
+// Once sorted, parsers whose anchors do not appear in the text are skipped
+// without calling their QuickCheck.
+func (r *Registry) eachCandidate(msg *acars.Message, fn func(Parser) bool) {
+	if r.sorted {
+		idx, ok := r.index[msg.Label]
+		if !ok {
+			idx = r.globalIndex
+		}
+		idx.candidates(msg.Text, fn)
+		return
+	}
+
+	for _, p := range r.byLabel[msg.Label] {
+		if p.QuickCheck(msg.Text) && !fn(p) {
+			return
+		}
+	}
+	for _, p := range r.global {
+		if p.QuickCheck(msg.Text) && !fn(p) {
+			return
+		}
+	}
+}
+
 // Dispatch routes a message to appropriate parsers and returns all results.
 // Multiple parsers can match the same message (e.g., PDC + route info).
 // Note: Sort() should be called before Dispatch() for optimal performance.
@@ -142,30 +186,17 @@ func (r *Registry) Dispatch(msg *acars.Message) []Result {
 
 	var results []Result
 
-	// 1. Try label-specific parsers first (most efficient path)
-	if parsers, ok := r.byLabel[msg.Label]; ok {
-		for _, p := range parsers {
-			// Quick check before expensive parse
-			if !p.QuickCheck(msg.Text) {
-				continue
-			}
-			if result := p.Parse(msg); result != nil {
-				results = append(results, result)
-			}
-		}
-	}
-
-	// 2. Try global (content-based) parsers
-	for _, p := range r.global {
-		if !p.QuickCheck(msg.Text) {
-			continue
-		}
+	// 1. Try label-specific parsers first (most efficient path), then
+	// global (content-based) parsers, each passing its quick check before
+	// the expensive parse.
+	r.eachCandidate(msg, func(p Parser) bool {
 		if result := p.Parse(msg); result != nil {
 			results = append(results, result)
 		}
-	}
+		return true
+	})
 
-	// 3. If nothing matched, try catch-all parsers
+	// 2. If nothing matched, try catch-all parsers
 	if len(results) == 0 && len(r.catchAll) > 0 {
 		for _, p := range r.catchAll {
 			if result := p.Parse(msg); result != nil {
@@ -183,26 +214,14 @@ func (r *Registry) DispatchFirst(msg *acars.Message) Result {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	// Try label-specific parsers
-	if parsers, ok := r.byLabel[msg.Label]; ok {
-		for _, p := range parsers {
-			if !p.QuickCheck(msg.Text) {
-				continue
-			}
-			if result := p.Parse(msg); result != nil {
-				return result
-			}
-		}
-	}
-
-	// Try global parsers
-	for _, p := range r.global {
-		if !p.QuickCheck(msg.Text) {
-			continue
-		}
-		if result := p.Parse(msg); result != nil {
-			return result
-		}
+	// Try label-specific, then global parsers
+	var first Result
+	r.eachCandidate(msg, func(p Parser) bool {
+		first = p.Parse(msg)
+		return first == nil
+	})
+	if first != nil {
+		return first
 	}
 
 	// Try catch-all
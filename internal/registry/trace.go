@@ -1,37 +1,43 @@
 // Package registry provides tracing interfaces for parser debugging.
 package registry
 
-import "acars_parser/internal/acars"
+import (
+	"encoding/json"
+	"fmt"
+
+	"acars_parser/internal/acars"
+)
 
 // TraceResult contains trace information from a parser's attempt to parse a message.
 type TraceResult struct {
-	ParserName string        // Name of the parser.
-	QuickCheck *QuickCheck   // QuickCheck result (nil if not applicable).
-	Formats    []FormatTrace // Format/pattern match attempts (for grok-style parsers).
-	Extractors []Extractor   // Post-processing extractor results.
-	Matched    bool          // Whether the parser matched the message.
+	ParserName string        `json:"parser"`                // Name of the parser.
+	QuickCheck *QuickCheck   `json:"quick_check,omitempty"` // QuickCheck result (nil if not applicable).
+	Formats    []FormatTrace `json:"formats,omitempty"`     // Format/pattern match attempts (for grok-style parsers).
+	Extractors []Extractor   `json:"extractors,omitempty"`  // Post-processing extractor results.
+	Matched    bool          `json:"matched"`               // Whether the parser matched the message.
 }
 
 // QuickCheck contains the result of a parser's quick check.
 type QuickCheck struct {
-	Passed bool   // Whether the quick check passed.
-	Reason string // Optional reason for the result.
+	Passed bool   `json:"passed"`           // Whether the quick check passed.
+	Reason string `json:"reason,omitempty"` // Optional reason for the result.
 }
 
 // FormatTrace contains debug information about a format/pattern match attempt.
 type FormatTrace struct {
-	Name     string            // Format or pattern name.
-	Matched  bool              // Whether the pattern matched.
-	Pattern  string            // The regex pattern used.
-	Captures map[string]string // Captured groups (if matched).
+	Name     string            `json:"name"`               // Format or pattern name.
+	Matched  bool              `json:"matched"`            // Whether the pattern matched.
+	Pattern  string            `json:"pattern,omitempty"`  // The regex pattern used.
+	Captures map[string]string `json:"captures,omitempty"` // Captured groups (if matched).
+	Selected bool              `json:"selected,omitempty"` // Whether this match gave the result, when several formats match.
 }
 
 // Extractor contains debug information about a field extractor.
 type Extractor struct {
-	Name    string // Extractor name (e.g., "squawk", "frequency").
-	Pattern string // The regex pattern used.
-	Matched bool   // Whether the extractor matched.
-	Value   string // Extracted value (if matched).
+	Name    string `json:"name"`              // Extractor name (e.g., "squawk", "frequency").
+	Pattern string `json:"pattern,omitempty"` // The regex pattern used.
+	Matched bool   `json:"matched"`           // Whether the extractor matched.
+	Value   string `json:"value,omitempty"`   // Extracted value (if matched).
 }
 
 // Traceable is implemented by parsers that support debug tracing.
@@ -41,4 +47,137 @@ type Traceable interface {
 	// ParseWithTrace attempts to parse the message and returns detailed trace information.
 	// The trace includes information about which patterns were tried and their results.
 	ParseWithTrace(msg *acars.Message) *TraceResult
-}
\ No newline at end of file
+}
+
+// Dispatch stages, in the order Dispatch tries them.
+const (
+	StageLabel    = "label"     // Parser registered for the message's label.
+	StageGlobal   = "global"    // Content-based parser that checks every message.
+	StageCatchAll = "catch_all" // Runs only when nothing else matched.
+)
+
+// ParserTrace records how one candidate parser handled a message during
+// DispatchWithTrace.
+type ParserTrace struct {
+	Parser     string                 `json:"parser"`
+	Stage      string                 `json:"stage"`
+	Priority   int                    `json:"priority"`
+	QuickCheck bool                   `json:"quick_check"` // Catch-all parsers have no QuickCheck and always report true.
+	Matched    bool                   `json:"matched"`     // Parse returned a result.
+	ResultType string                 `json:"result_type,omitempty"`
+	Fields     map[string]interface{} `json:"fields,omitempty"` // Non-empty top-level fields of the result.
+	Error      string                 `json:"error,omitempty"`  // Set if the parser panicked.
+	Detail     *TraceResult           `json:"detail,omitempty"` // From parsers that implement Traceable.
+}
+
+// DispatchTrace is the outcome of DispatchWithTrace.
+type DispatchTrace struct {
+	Label   string        `json:"label"`
+	Results []Result      `json:"results"` // Identical to what Dispatch returns.
+	Parsers []ParserTrace `json:"parsers"` // Every candidate parser, in dispatch order.
+}
+
+// DispatchWithTrace dispatches a message exactly as Dispatch does and records,
+// for every candidate parser, whether QuickCheck passed, whether it matched, the
+// fields it extracted, and any panic. Parsers that implement Traceable also have
+// their detailed trace attached. This re-runs Traceable parsers, so it is meant
+// for debugging single messages rather than bulk processing.
+func (r *Registry) DispatchWithTrace(msg *acars.Message) *DispatchTrace {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trace := &DispatchTrace{Label: msg.Label, Results: []Result{}, Parsers: []ParserTrace{}}
+
+	run := func(p Parser, stage string, quickCheck bool) {
+		pt := ParserTrace{Parser: p.Name(), Stage: stage, Priority: p.Priority(), QuickCheck: true}
+		if quickCheck {
+			pt.QuickCheck = p.QuickCheck(msg.Text)
+		}
+		if pt.QuickCheck {
+			result, err := safeParse(p, msg)
+			if err != nil {
+				pt.Error = err.Error()
+			} else if result != nil {
+				pt.Matched = true
+				pt.ResultType = result.Type()
+				pt.Fields = resultFields(result)
+				trace.Results = append(trace.Results, result)
+			}
+		}
+		if tp, ok := p.(Traceable); ok {
+			pt.Detail = safeTrace(tp, msg)
+		}
+		trace.Parsers = append(trace.Parsers, pt)
+	}
+
+	for _, p := range r.byLabel[msg.Label] {
+		run(p, StageLabel, true)
+	}
+	for _, p := range r.global {
+		run(p, StageGlobal, true)
+	}
+	if len(trace.Results) == 0 {
+		for _, p := range r.catchAll {
+			run(p, StageCatchAll, false)
+		}
+	}
+
+	return trace
+}
+
+// safeParse runs Parse, converting a panic into an error.
+func safeParse(p Parser, msg *acars.Message) (result Result, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return p.Parse(msg), nil
+}
+
+// safeTrace runs ParseWithTrace, returning nil if it panics.
+func safeTrace(tp Traceable, msg *acars.Message) (tr *TraceResult) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			tr = nil
+		}
+	}()
+	return tp.ParseWithTrace(msg)
+}
+
+// resultFields round-trips a result through JSON and returns its non-empty
+// top-level fields.
+func resultFields(result Result) map[string]interface{} {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil
+	}
+	for k, v := range fields {
+		if isEmptyValue(v) {
+			delete(fields, k)
+		}
+	}
+	return fields
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case float64:
+		return val == 0
+	case bool:
+		return !val
+	case []interface{}:
+		return len(val) == 0
+	case map[string]interface{}:
+		return len(val) == 0
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+type testResult struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	Empty string `json:"empty"`
+}
+
+func (r *testResult) Type() string     { return r.Kind }
+func (r *testResult) MessageID() int64 { return 0 }
+
+// testParser matches messages containing its keyword.
+type testParser struct {
+	name     string
+	labels   []string
+	keyword  string
+	priority int
+	panics   bool
+}
+
+func (p *testParser) Name() string     { return p.name }
+func (p *testParser) Labels() []string { return p.labels }
+func (p *testParser) Priority() int    { return p.priority }
+func (p *testParser) QuickCheck(text string) bool {
+	return strings.Contains(text, p.keyword)
+}
+func (p *testParser) Parse(msg *acars.Message) Result {
+	if p.panics {
+		panic("boom")
+	}
+	return &testResult{Kind: p.name, Value: p.keyword}
+}
+
+type traceableParser struct{ testParser }
+
+func (p *traceableParser) ParseWithTrace(msg *acars.Message) *TraceResult {
+	return &TraceResult{ParserName: p.name, Matched: p.QuickCheck(msg.Text)}
+}
+
+func TestDispatchWithTrace(t *testing.T) {
+	r := New()
+	r.Register(&testParser{name: "second", labels: []string{"H1"}, keyword: "POS", priority: 20})
+	r.Register(&traceableParser{testParser{name: "first", labels: []string{"H1"}, keyword: "FPN", priority: 10}})
+	r.Register(&testParser{name: "other_label", labels: []string{"5Z"}, keyword: "POS"})
+	r.Register(&testParser{name: "global", keyword: "CRASH", panics: true})
+	r.RegisterCatchAll(&testParser{name: "unknown"})
+	r.Sort()
+
+	msg := &acars.Message{Label: "H1", Text: "POS CRASH"}
+	trace := r.DispatchWithTrace(msg)
+
+	// Results must match Dispatch for non-panicking parsers.
+	if len(trace.Results) != 1 || trace.Results[0].Type() != "second" {
+		t.Fatalf("results = %v, want [second]", trace.Results)
+	}
+
+	want := []struct {
+		parser     string
+		stage      string
+		quickCheck bool
+		matched    bool
+	}{
+		{"first", StageLabel, false, false},
+		{"second", StageLabel, true, true},
+		{"global", StageGlobal, true, false},
+	}
+	if len(trace.Parsers) != len(want) {
+		t.Fatalf("got %d parser traces, want %d: %+v", len(trace.Parsers), len(want), trace.Parsers)
+	}
+	for i, w := range want {
+		pt := trace.Parsers[i]
+		if pt.Parser != w.parser || pt.Stage != w.stage || pt.QuickCheck != w.quickCheck || pt.Matched != w.matched {
+			t.Errorf("parser %d = %+v, want %+v", i, pt, w)
+		}
+	}
+
+	if trace.Parsers[0].Detail == nil || trace.Parsers[0].Detail.Matched {
+		t.Errorf("traceable parser detail = %+v, want unmatched trace", trace.Parsers[0].Detail)
+	}
+	if got := trace.Parsers[1].Fields; got["value"] != "POS" || got["empty"] != nil {
+		t.Errorf("fields = %v, want value=POS without empty fields", got)
+	}
+	if !strings.Contains(trace.Parsers[2].Error, "boom") {
+		t.Errorf("error = %q, want panic message", trace.Parsers[2].Error)
+	}
+}
+
+func TestDispatchWithTraceCatchAll(t *testing.T) {
+	r := New()
+	r.Register(&testParser{name: "pos", labels: []string{"H1"}, keyword: "POS"})
+	r.RegisterCatchAll(&testParser{name: "unknown"})
+	r.Sort()
+
+	trace := r.DispatchWithTrace(&acars.Message{Label: "H1", Text: "NOTHING HERE"})
+	if len(trace.Results) != 1 || trace.Results[0].Type() != "unknown" {
+		t.Fatalf("results = %v, want [unknown]", trace.Results)
+	}
+	last := trace.Parsers[len(trace.Parsers)-1]
+	if last.Stage != StageCatchAll || !last.Matched {
+		t.Errorf("catch-all trace = %+v", last)
+	}
+}
@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"sort"
+
+	"acars_parser/internal/acars"
+)
+
+// Versioned is implemented by parsers that track changes to their output.
+// A parser bumps its version whenever a change would alter the result for
+// messages it has already parsed, so that stored results from the older
+// version can be found and reparsed.
+type Versioned interface {
+	Version() int
+}
+
+// DefaultVersion is the version of parsers that do not implement Versioned.
+const DefaultVersion = 1
+
+// ParserVersion returns a parser's version, or DefaultVersion if it does not
+// implement Versioned.
+func ParserVersion(p Parser) int {
+	if v, ok := p.(Versioned); ok {
+		return v.Version()
+	}
+	return DefaultVersion
+}
+
+// Attributed is a parse result together with the parser that produced it.
+type Attributed struct {
+	Result  Result
+	Parser  string
+	Version int
+}
+
+// DispatchAttributed dispatches a message exactly as Dispatch does and records
+// the name and version of the parser behind each result, for storage.
+func (r *Registry) DispatchAttributed(msg *acars.Message) []Attributed {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []Attributed
+	try := func(p Parser) {
+		if result := p.Parse(msg); result != nil {
+			results = append(results, Attributed{Result: result, Parser: p.Name(), Version: ParserVersion(p)})
+		}
+	}
+
+	r.eachCandidate(msg, func(p Parser) bool {
+		try(p)
+		return true
+	})
+	if len(results) == 0 {
+		for _, p := range r.catchAll {
+			try(p)
+		}
+	}
+	return results
+}
+
+// Results returns the results of attributed results, in order.
+func Results(attributed []Attributed) []Result {
+	out := make([]Result, len(attributed))
+	for i, a := range attributed {
+		out[i] = a.Result
+	}
+	return out
+}
+
+// Unattributed returns results that no parser produced, such as link-layer
+// results, as attributed results without a parser.
+func Unattributed(results []Result) []Attributed {
+	out := make([]Attributed, len(results))
+	for i, r := range results {
+		out[i] = Attributed{Result: r}
+	}
+	return out
+}
+
+// Lookup returns the registered parser with a name, or nil.
+func (r *Registry) Lookup(name string) Parser {
+	for _, p := range r.AllParsers() {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// ParserInfo is the name and version of a registered parser.
+type ParserInfo struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// Versions returns the name and version of every registered parser, sorted
+// by name.
+func (r *Registry) Versions() []ParserInfo {
+	parsers := r.AllParsers()
+	infos := make([]ParserInfo, 0, len(parsers))
+	for _, p := range parsers {
+		infos = append(infos, ParserInfo{Name: p.Name(), Version: ParserVersion(p)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
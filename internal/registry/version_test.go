@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"testing"
+
+	"acars_parser/internal/acars"
+)
+
+type versionedParser struct {
+	testParser
+	version int
+}
+
+func (p *versionedParser) Version() int { return p.version }
+
+func TestDispatchAttributed(t *testing.T) {
+	r := New()
+	r.Register(&versionedParser{testParser: testParser{name: "fpn", labels: []string{"H1"}, keyword: "FPN"}, version: 3})
+	r.Register(&testParser{name: "pdc", keyword: "CLRD"})
+	r.RegisterCatchAll(&testParser{name: "fallback"})
+	r.Sort()
+
+	got := r.DispatchAttributed(&acars.Message{Label: "H1", Text: "FPN CLRD"})
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	if got[0].Parser != "fpn" || got[0].Version != 3 {
+		t.Errorf("first result = %s v%d, want fpn v3", got[0].Parser, got[0].Version)
+	}
+	if got[1].Parser != "pdc" || got[1].Version != DefaultVersion {
+		t.Errorf("second result = %s v%d, want pdc v%d", got[1].Parser, got[1].Version, DefaultVersion)
+	}
+
+	// Results match Dispatch.
+	plain := r.Dispatch(&acars.Message{Label: "H1", Text: "FPN CLRD"})
+	for i := range plain {
+		if plain[i].Type() != got[i].Result.Type() {
+			t.Errorf("result %d: %s, Dispatch gave %s", i, got[i].Result.Type(), plain[i].Type())
+		}
+	}
+
+	fallback := r.DispatchAttributed(&acars.Message{Label: "H1", Text: "OTHER"})
+	if len(fallback) != 1 || fallback[0].Parser != "fallback" {
+		t.Errorf("catch-all result = %+v", fallback)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	r := New()
+	r.Register(&versionedParser{testParser: testParser{name: "fpn", labels: []string{"H1", "4A"}}, version: 2})
+	r.Register(&testParser{name: "adsc", labels: []string{"B6"}})
+
+	infos := r.Versions()
+	want := []ParserInfo{{Name: "adsc", Version: 1}, {Name: "fpn", Version: 2}}
+	if len(infos) != len(want) {
+		t.Fatalf("Versions() = %+v, want %+v", infos, want)
+	}
+	for i := range want {
+		if infos[i] != want[i] {
+			t.Errorf("Versions()[%d] = %+v, want %+v", i, infos[i], want[i])
+		}
+	}
+
+	if p := r.Lookup("fpn"); p == nil || ParserVersion(p) != 2 {
+		t.Error("Lookup(fpn) did not return the versioned parser")
+	}
+	if r.Lookup("missing") != nil {
+		t.Error("Lookup(missing) should return nil")
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"strconv"
 	"strings"
 
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
 	"acars_parser/internal/storage"
 )
 
@@ -66,21 +68,24 @@ func (s *Server) Run() error {
 
 // APIMessage is the JSON representation of a message.
 type APIMessage struct {
-	ID            int64             `json:"id"`
-	Timestamp     string            `json:"timestamp"`
-	Label         string            `json:"label"`
-	ParserType    string            `json:"parser_type"`
-	Flight        string            `json:"flight"`
-	Tail          string            `json:"tail"`
-	Origin        string            `json:"origin"`
-	Destination   string            `json:"destination"`
-	RawText       string            `json:"raw_text"`
-	Parsed        map[string]interface{} `json:"parsed"`
-	MissingFields []string          `json:"missing_fields"`
-	Confidence    float64           `json:"confidence"`
-	IsGolden      bool              `json:"is_golden"`
-	Annotation    string            `json:"annotation"`
-	Expected      map[string]interface{} `json:"expected,omitempty"`
+	ID            int64                   `json:"id"`
+	Timestamp     string                  `json:"timestamp"`
+	Label         string                  `json:"label"`
+	ParserType    string                  `json:"parser_type"`
+	Flight        string                  `json:"flight"`
+	Tail          string                  `json:"tail"`
+	Origin        string                  `json:"origin"`
+	Destination   string                  `json:"destination"`
+	RawText       string                  `json:"raw_text"`
+	Parsed        map[string]interface{}  `json:"parsed"`
+	MissingFields []string                `json:"missing_fields"`
+	Confidence    float64                 `json:"confidence"`
+	IsGolden      bool                    `json:"is_golden"`
+	Annotation    string                  `json:"annotation"`
+	Expected      map[string]interface{}  `json:"expected,omitempty"`
+	Flagged       bool                    `json:"flagged"`
+	FlagReason    string                  `json:"flag_reason,omitempty"`
+	Trace         *registry.DispatchTrace `json:"trace,omitempty"`
 }
 
 func messageToAPI(m *storage.CHMessage, annotation *storage.GoldenAnnotation) APIMessage {
@@ -112,6 +117,8 @@ func messageToAPI(m *storage.CHMessage, annotation *storage.GoldenAnnotation) AP
 		api.IsGolden = annotation.IsGolden
 		api.Annotation = annotation.Annotation
 		api.Expected = annotation.ExpectedJSON
+		api.Flagged = annotation.Flagged
+		api.FlagReason = annotation.FlagReason
 	}
 
 	return api
@@ -132,6 +139,7 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 		Label:      q.Get("label"),
 		HasMissing: q.Get("has_missing") == "true",
 		FullText:   q.Get("search"),
+		Category:   q.Get("category"),
 		OrderBy:    q.Get("order"),
 		OrderDesc:  q.Get("desc") != "false",
 	}
@@ -141,8 +149,23 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 		params.ParserType = s.filter
 	}
 
-	// Golden filter.
-	goldenOnly := q.Get("golden") == "true"
+	// Annotation filters are resolved to ID lists in PostgreSQL.
+	if q.Get("golden") != "" || q.Get("flagged") != "" {
+		if s.pg == nil {
+			http.Error(w, "PostgreSQL not configured", http.StatusServiceUnavailable)
+			return
+		}
+		empty, err := s.applyAnnotationFilter(ctx, &params, q.Get("golden"), q.Get("flagged"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if empty {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode([]APIMessage{})
+			return
+		}
+	}
 
 	// Pagination.
 	if limit, err := strconv.Atoi(q.Get("limit")); err == nil && limit > 0 {
@@ -161,7 +184,7 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert to API format.
-	var result []APIMessage
+	result := []APIMessage{}
 	for _, m := range messages {
 		// Fetch annotation from PostgreSQL if available.
 		var annotation *storage.GoldenAnnotation
@@ -169,9 +192,6 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 			annotation, _ = s.pg.GetGoldenAnnotation(ctx, int64(m.ID))
 		}
 
-		if goldenOnly && (annotation == nil || !annotation.IsGolden) {
-			continue
-		}
 		result = append(result, messageToAPI(&m, annotation))
 	}
 
@@ -196,7 +216,7 @@ func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		s.getMessage(w, id)
+		s.getMessage(w, id, r.URL.Query().Get("trace") == "true")
 	case http.MethodPost, http.MethodPatch:
 		// Check for sub-action.
 		if len(parts) > 1 {
@@ -207,6 +227,8 @@ func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
 				s.setAnnotation(w, r, id)
 			case "expected":
 				s.setExpected(w, r, id)
+			case "flag":
+				s.setFlag(w, r, id)
 			default:
 				http.Error(w, "Unknown action", http.StatusBadRequest)
 			}
@@ -218,7 +240,106 @@ func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) getMessage(w http.ResponseWriter, id int64) {
+// applyAnnotationFilter restricts params to messages matching the golden and
+// flagged query values ("true" or "false"; empty means no filter). It reports
+// true when the filter cannot match any message.
+func (s *Server) applyAnnotationFilter(ctx context.Context, params *storage.CHQueryParams, golden, flagged string) (bool, error) {
+	// A "true" filter selects annotated messages. A "false" filter excludes the
+	// annotated messages with the flag set, since unannotated messages have no row.
+	var include, exclude []int64
+	haveInclude := false
+
+	for _, f := range []struct {
+		value  string
+		filter func(v bool) storage.AnnotationFilter
+	}{
+		{golden, func(v bool) storage.AnnotationFilter { return storage.AnnotationFilter{Golden: &v} }},
+		{flagged, func(v bool) storage.AnnotationFilter { return storage.AnnotationFilter{Flagged: &v} }},
+	} {
+		if f.value == "" {
+			continue
+		}
+		ids, err := s.pg.GetAnnotatedMessageIDs(ctx, f.filter(true))
+		if err != nil {
+			return false, err
+		}
+		if f.value == "true" {
+			if haveInclude {
+				include = intersectIDs(include, ids)
+			} else {
+				include = ids
+				haveInclude = true
+			}
+		} else {
+			exclude = append(exclude, ids...)
+		}
+	}
+
+	if haveInclude {
+		include = subtractIDs(include, exclude)
+		if len(include) == 0 {
+			return true, nil
+		}
+		params.IDs = toUint64s(include)
+		return false, nil
+	}
+	params.ExcludeIDs = toUint64s(exclude)
+	return false, nil
+}
+
+// intersectIDs returns the IDs present in both sorted slices.
+func intersectIDs(a, b []int64) []int64 {
+	var out []int64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// subtractIDs returns the IDs in a that are not in b.
+func subtractIDs(a, b []int64) []int64 {
+	if len(b) == 0 {
+		return a
+	}
+	drop := make(map[int64]bool, len(b))
+	for _, id := range b {
+		drop[id] = true
+	}
+	var out []int64
+	for _, id := range a {
+		if !drop[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func toUint64s(ids []int64) []uint64 {
+	out := make([]uint64, len(ids))
+	for i, id := range ids {
+		out[i] = uint64(id)
+	}
+	return out
+}
+
+// traceMessage re-parses a message with the default registry and records how
+// each candidate parser handled it.
+func traceMessage(m *storage.CHMessage) *registry.DispatchTrace {
+	reg := registry.Default()
+	reg.Sort()
+	return reg.DispatchWithTrace(&acars.Message{ID: acars.FlexInt64(m.ID), Label: m.Label, Text: m.RawText})
+}
+
+func (s *Server) getMessage(w http.ResponseWriter, id int64, withTrace bool) {
 	ctx := context.Background()
 
 	msg, err := s.ch.GetByID(ctx, uint64(id))
@@ -237,8 +358,13 @@ func (s *Server) getMessage(w http.ResponseWriter, id int64) {
 		annotation, _ = s.pg.GetGoldenAnnotation(ctx, id)
 	}
 
+	api := messageToAPI(msg, annotation)
+	if withTrace {
+		api.Trace = traceMessage(msg)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(messageToAPI(msg, annotation))
+	_ = json.NewEncoder(w).Encode(api)
 }
 
 func (s *Server) setGolden(w http.ResponseWriter, r *http.Request, id int64) {
@@ -289,6 +415,31 @@ func (s *Server) setAnnotation(w http.ResponseWriter, r *http.Request, id int64)
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) setFlag(w http.ResponseWriter, r *http.Request, id int64) {
+	if s.pg == nil {
+		http.Error(w, "PostgreSQL not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx := context.Background()
+
+	var body struct {
+		Flagged bool   `json:"flagged"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.pg.SetFlag(ctx, id, body.Flagged, body.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (s *Server) setExpected(w http.ResponseWriter, r *http.Request, id int64) {
 	if s.pg == nil {
 		http.Error(w, "PostgreSQL not configured", http.StatusServiceUnavailable)
@@ -0,0 +1,26 @@
+package review
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntersectIDs(t *testing.T) {
+	got := intersectIDs([]int64{1, 3, 5, 7}, []int64{2, 3, 4, 7, 9})
+	if want := []int64{3, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("intersectIDs = %v, want %v", got, want)
+	}
+	if got := intersectIDs([]int64{1, 2}, nil); len(got) != 0 {
+		t.Errorf("intersectIDs with empty = %v, want none", got)
+	}
+}
+
+func TestSubtractIDs(t *testing.T) {
+	got := subtractIDs([]int64{1, 2, 3, 4}, []int64{2, 4, 6})
+	if want := []int64{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("subtractIDs = %v, want %v", got, want)
+	}
+	if got := subtractIDs([]int64{1, 2}, nil); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("subtractIDs with empty = %v, want [1 2]", got)
+	}
+}
@@ -8,6 +8,7 @@ const state = {
     limit: 50,
     filters: {
         type: '',
+        category: '',
         hasMissing: false,
         search: '',
         goldenOnly: false
@@ -16,12 +17,45 @@ const state = {
 
 // Initialise the application.
 async function init() {
+    loadDecoder();
     await loadTypes();
     await loadStats();
     await loadMessages();
     setupEventListeners();
 }
 
+// Load the WebAssembly decoder (see cmd/wasm), if it has been built into the
+// static files, for live previews of edited messages.
+async function loadDecoder() {
+    try {
+        await new Promise((resolve, reject) => {
+            const script = document.createElement('script');
+            script.src = 'wasm_exec.js';
+            script.onload = resolve;
+            script.onerror = reject;
+            document.head.appendChild(script);
+        });
+        const go = new Go();
+        const result = await WebAssembly.instantiateStreaming(fetch('acars.wasm'), go.importObject);
+        go.run(result.instance);
+    } catch (err) {
+        console.info('WebAssembly decoder not available; previews disabled.');
+    }
+}
+
+// Decode edited message text in the browser and show the results.
+function renderPreview(label, text) {
+    const output = document.getElementById('preview-output');
+    const parsed = JSON.parse(acarsParseJSON(JSON.stringify({ label, text })));
+    if (parsed.error) {
+        output.textContent = parsed.error;
+    } else if (parsed.results.length === 0) {
+        output.textContent = '(no parser matched)';
+    } else {
+        output.textContent = JSON.stringify(parsed.results, null, 2);
+    }
+}
+
 // Load parser types for filter dropdown.
 async function loadTypes() {
     try {
@@ -61,6 +95,7 @@ async function loadMessages() {
     try {
         const params = new URLSearchParams();
         if (state.filters.type) params.set('type', state.filters.type);
+        if (state.filters.category) params.set('category', state.filters.category);
         if (state.filters.hasMissing) params.set('has_missing', 'true');
         if (state.filters.search) params.set('search', state.filters.search);
         if (state.filters.goldenOnly) params.set('golden', 'true');
@@ -205,6 +240,14 @@ function renderDetail(msg) {
             <div class="raw-text">${escapeHtml(msg.raw_text)}</div>
         </div>
 
+        ${typeof acarsParseJSON === 'function' ? `
+            <div class="detail-section">
+                <h3>Preview</h3>
+                <textarea id="preview-input" spellcheck="false">${escapeHtml(msg.raw_text)}</textarea>
+                <div class="raw-text" id="preview-output"></div>
+            </div>
+        ` : ''}
+
         <div class="detail-section">
             <h3>Parsed Fields</h3>
             <div class="parsed-fields">${fieldsHtml || '<em>No fields parsed</em>'}</div>
@@ -231,6 +274,11 @@ function renderDetail(msg) {
     `;
 
     // Event handlers.
+    const previewInput = document.getElementById('preview-input');
+    if (previewInput) {
+        previewInput.addEventListener('input', () => renderPreview(msg.label, previewInput.value));
+        renderPreview(msg.label, previewInput.value);
+    }
     document.getElementById('toggle-golden').addEventListener('click', () => toggleGolden(msg.id, !msg.is_golden));
     document.getElementById('save-annotation').addEventListener('click', () => {
         const annotation = document.getElementById('annotation-input').value;
@@ -281,6 +329,13 @@ function setupEventListeners() {
         loadMessages();
     });
 
+    // Free-text category filter.
+    document.getElementById('category-filter').addEventListener('change', (e) => {
+        state.filters.category = e.target.value;
+        state.offset = 0;
+        loadMessages();
+    });
+
     // Missing filter.
     document.getElementById('missing-filter').addEventListener('change', (e) => {
         state.filters.hasMissing = e.target.value === 'has_missing';
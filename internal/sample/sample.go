@@ -0,0 +1,107 @@
+// Package sample draws a stratified sample of stored messages and scrubs the
+// registrations and flight numbers in it, so that a realistic fixture set can
+// be shared with parser contributors without identifying aircraft or flights.
+package sample
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Unparsed is the outcome of a message no parser matched.
+const Unparsed = "unparsed"
+
+// Record is a stored message as it is sampled and written. Parsed, the
+// stored result, is read by the Scrubber for the identifiers it names and is
+// not written out.
+type Record struct {
+	ID         int64     `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Label      string    `json:"label"`
+	Tail       string    `json:"tail,omitempty"`
+	Flight     *Flight   `json:"flight,omitempty"`
+	Text       string    `json:"text"`
+	ParserType string    `json:"parser_type"`
+	Parsed     string    `json:"-"`
+}
+
+// Flight is the flight of a Record, in the shape of acars.Flight, so that the
+// written records can be read back as decode's JSON input.
+type Flight struct {
+	Flight string `json:"flight"`
+}
+
+// Stratum is the group a message is sampled in: its label and its parser
+// outcome, the parser type or Unparsed.
+type Stratum struct {
+	Label   string
+	Outcome string
+}
+
+// Sampler keeps a uniform random sample of up to a fixed number of records
+// from each stratum, by reservoir sampling, so that the corpus is read once
+// and only the sample is held in memory. It is not safe for concurrent use.
+type Sampler struct {
+	per  int
+	rng  *rand.Rand
+	seen map[Stratum]int
+	kept map[Stratum][]Record
+}
+
+// NewSampler returns a Sampler keeping up to per records of each stratum.
+// The same seed over the same records in the same order gives the same
+// sample.
+func NewSampler(per int, seed int64) *Sampler {
+	return &Sampler{
+		per:  per,
+		rng:  rand.New(rand.NewSource(seed)),
+		seen: make(map[Stratum]int),
+		kept: make(map[Stratum][]Record),
+	}
+}
+
+// Add offers a record to the sample.
+func (s *Sampler) Add(r Record) {
+	if r.ParserType == "" {
+		r.ParserType = Unparsed
+	}
+	key := Stratum{Label: r.Label, Outcome: r.ParserType}
+	s.seen[key]++
+	kept := s.kept[key]
+	if len(kept) < s.per {
+		s.kept[key] = append(kept, r)
+		return
+	}
+	// Keep the nth record with probability per/n.
+	if i := s.rng.Intn(s.seen[key]); i < s.per {
+		kept[i] = r
+	}
+}
+
+// Seen returns the number of records offered from each stratum.
+func (s *Sampler) Seen() map[Stratum]int {
+	return s.seen
+}
+
+// Records returns the sample, by label, outcome and time.
+func (s *Sampler) Records() []Record {
+	var out []Record
+	for _, kept := range s.kept {
+		out = append(out, kept...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Label != b.Label {
+			return a.Label < b.Label
+		}
+		if a.ParserType != b.ParserType {
+			return a.ParserType < b.ParserType
+		}
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return a.ID < b.ID
+	})
+	return out
+}
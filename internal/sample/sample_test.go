@@ -0,0 +1,91 @@
+package sample
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(seed int64) []Record {
+		s := NewSampler(3, seed)
+		for i := 0; i < 100; i++ {
+			s.Add(Record{ID: int64(i), Timestamp: base.Add(time.Duration(i) * time.Minute), Label: "H1", ParserType: "pdc"})
+		}
+		s.Add(Record{ID: 100, Timestamp: base, Label: "H1"})
+		s.Add(Record{ID: 101, Timestamp: base, Label: "16", ParserType: "waypoint_position"})
+		return s.Records()
+	}
+
+	got := sample(1)
+	if len(got) != 5 {
+		t.Fatalf("got %d records, want 5 (3 pdc, 1 unparsed, 1 from label 16)", len(got))
+	}
+	if got[0].ID != 101 || got[4].ParserType != Unparsed {
+		t.Errorf("records not ordered by label and outcome: %+v", got)
+	}
+	for _, r := range got[1:4] {
+		if r.ParserType != "pdc" {
+			t.Errorf("record %d outcome = %q, want pdc", r.ID, r.ParserType)
+		}
+	}
+	if !reflect.DeepEqual(sample(1), got) {
+		t.Error("the same seed gave a different sample")
+	}
+}
+
+func TestScrubber(t *testing.T) {
+	s := NewScrubber([]byte("key"))
+
+	tests := []struct {
+		got, orig, prefix string
+	}{
+		{s.Registration("VH-OFW"), "VH-OFW", "VH-"},
+		{s.Registration("N901XD"), "N901XD", "N"},
+		{s.FlightNumber("JST577"), "JST577", "JST"},
+		{s.FlightNumber("U2123"), "U2123", "U2"},
+	}
+	for _, tt := range tests {
+		if tt.got == tt.orig || len(tt.got) != len(tt.orig) || !strings.HasPrefix(tt.got, tt.prefix) {
+			t.Errorf("pseudonym of %s = %s, want another of the same shape starting %s", tt.orig, tt.got, tt.prefix)
+		}
+		for i := len(tt.prefix); i < len(tt.orig); i++ {
+			if isLetter(tt.orig[i]) != isLetter(tt.got[i]) {
+				t.Errorf("pseudonym of %s = %s, want letters for letters and digits for digits", tt.orig, tt.got)
+				break
+			}
+		}
+	}
+	if s.Registration("VH-OFW") != s.Registration("VH-OFW") {
+		t.Error("pseudonyms differ between calls")
+	}
+	if NewScrubber([]byte("other")).Registration("VH-OFW") == s.Registration("VH-OFW") {
+		t.Error("pseudonyms do not depend on the key")
+	}
+}
+
+func TestScrub(t *testing.T) {
+	s := NewScrubber([]byte("key"))
+	r := s.Scrub(Record{
+		Label:  "H1",
+		Tail:   "VH-OFW",
+		Flight: &Flight{Flight: "JQ577"},
+		Text:   ".MELOJJQ 301036\nAN VH-OFW/MA 511A\nPDC 301035\nJST577 A21N YBBN 1120\nREG VHOFW JST5770",
+		Parsed: `{"flight_number":"JST577","tail":"VH-OFW","origin":"YBBN"}`,
+	})
+
+	reg, flight := s.Registration("VH-OFW"), s.FlightNumber("JST577")
+	want := ".MELOJJQ 301036\nAN " + reg + "/MA 511A\nPDC 301035\n" + flight + " A21N YBBN 1120\nREG " +
+		strings.ReplaceAll(reg, "-", "") + " JST5770"
+	if r.Text != want {
+		t.Errorf("text =\n%s\nwant\n%s", r.Text, want)
+	}
+	if r.Tail != reg || r.Flight.Flight != s.FlightNumber("JQ577") {
+		t.Errorf("tail, flight = %s, %s, want %s, %s", r.Tail, r.Flight.Flight, reg, s.FlightNumber("JQ577"))
+	}
+	if r.Parsed != "" {
+		t.Error("the stored result was kept")
+	}
+}
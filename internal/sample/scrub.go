@@ -0,0 +1,186 @@
+package sample
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Scrubber replaces the registrations and flight numbers of records with
+// pseudonyms of the same shape: letters for letters and digits for digits,
+// keeping the nationality prefix of a registration and the airline code of a
+// flight number, which parsers rely on. A pseudonym is derived from the
+// original with HMAC-SHA256 under a key, so an identifier gets the same
+// pseudonym throughout a set, and in every set scrubbed with the same key,
+// and the original cannot be found from it without the key.
+type Scrubber struct {
+	key []byte
+}
+
+// NewScrubber returns a Scrubber deriving pseudonyms under key.
+func NewScrubber(key []byte) *Scrubber {
+	return &Scrubber{key: key}
+}
+
+// registrationFields and flightFields are the fields of a stored result that
+// name the aircraft and the flight.
+var (
+	registrationFields = []string{"tail", "registration", "aircraft_registration"}
+	flightFields       = []string{"flight", "flight_number", "flight_num", "flight_id", "callsign"}
+)
+
+// minIdentifier is the length below which an identifier is not replaced in
+// the text, as it would match unrelated words.
+const minIdentifier = 3
+
+// Registration returns the pseudonym of a registration. The part up to the
+// dash (VH-, 9H-) is kept, or the first letter of one without a dash (the N
+// of N901XD).
+func (s *Scrubber) Registration(reg string) string {
+	dots := len(reg) - len(strings.TrimLeft(reg, "."))
+	keep := dots + 1
+	if i := strings.IndexByte(reg, '-'); i >= 0 {
+		keep = i + 1
+	}
+	return s.pseudonym("registration", reg, keep)
+}
+
+// FlightNumber returns the pseudonym of a flight number. Its airline code is
+// kept: three letters for an ICAO callsign (JST577), else two characters for
+// an IATA code (JQ577, U2123).
+func (s *Scrubber) FlightNumber(flight string) string {
+	keep := 2
+	if len(flight) >= 3 && isLetter(flight[0]) && isLetter(flight[1]) && isLetter(flight[2]) {
+		keep = 3
+	}
+	return s.pseudonym("flight", flight, keep)
+}
+
+// pseudonym replaces the characters of s after the first keep with letters
+// and digits drawn from its HMAC. The first digit replaced is never a zero,
+// so that a number keeps its length.
+func (s *Scrubber) pseudonym(kind, value string, keep int) string {
+	if keep >= len(value) {
+		return value
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(kind + ":" + value))
+	sum := mac.Sum(nil)
+
+	out := []byte(value)
+	firstDigit := true
+	for i := keep; i < len(out); i++ {
+		b := sum[(i-keep)%len(sum)]
+		switch c := out[i]; {
+		case c >= '0' && c <= '9':
+			if firstDigit {
+				out[i] = '1' + b%9
+			} else {
+				out[i] = '0' + b%10
+			}
+			firstDigit = false
+		case isLetter(c):
+			out[i] = 'A' + b%26
+		}
+	}
+	return string(out)
+}
+
+// Scrub returns the record with its tail and flight, and every registration
+// and flight number it or its stored result names, replaced in its text.
+// Other identifying text, such as names in free text, is kept.
+func (s *Scrubber) Scrub(r Record) Record {
+	names := make(map[string]string)
+	addRegistration := func(reg string) {
+		reg = strings.ToUpper(strings.TrimSpace(reg))
+		if len(reg) < minIdentifier {
+			return
+		}
+		pseudo := s.Registration(reg)
+		names[reg] = pseudo
+		// Registrations are also sent without their dash.
+		if strings.Contains(reg, "-") {
+			names[strings.ReplaceAll(reg, "-", "")] = strings.ReplaceAll(pseudo, "-", "")
+		}
+	}
+	addFlight := func(flight string) {
+		flight = strings.ToUpper(strings.TrimSpace(flight))
+		if len(flight) >= minIdentifier {
+			names[flight] = s.FlightNumber(flight)
+		}
+	}
+
+	addRegistration(r.Tail)
+	if r.Flight != nil {
+		addFlight(r.Flight.Flight)
+	}
+	var parsed map[string]interface{}
+	if r.Parsed != "" && json.Unmarshal([]byte(r.Parsed), &parsed) == nil {
+		for _, f := range registrationFields {
+			if v, ok := parsed[f].(string); ok {
+				addRegistration(v)
+			}
+		}
+		for _, f := range flightFields {
+			if v, ok := parsed[f].(string); ok {
+				addFlight(v)
+			}
+		}
+	}
+
+	if r.Tail != "" {
+		r.Tail = s.Registration(strings.ToUpper(strings.TrimSpace(r.Tail)))
+	}
+	if r.Flight != nil {
+		r.Flight = &Flight{Flight: s.FlightNumber(strings.ToUpper(strings.TrimSpace(r.Flight.Flight)))}
+	}
+	r.Text = replaceNames(r.Text, names)
+	r.Parsed = ""
+	return r
+}
+
+// replaceNames replaces each name in text where it stands as a word, longest
+// names first so that a name inside a longer one is not replaced first.
+func replaceNames(text string, names map[string]string) string {
+	order := make([]string, 0, len(names))
+	for name := range names {
+		order = append(order, name)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if len(order[i]) != len(order[j]) {
+			return len(order[i]) > len(order[j])
+		}
+		return order[i] < order[j]
+	})
+	for _, name := range order {
+		text = replaceWord(text, name, names[name])
+	}
+	return text
+}
+
+// replaceWord replaces old in s where it is not part of a longer run of
+// letters and digits.
+func replaceWord(s, old, new string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := i + len(old)
+		if (i > 0 && isAlnum(s[i-1])) || (end < len(s) && isAlnum(s[end])) {
+			b.WriteString(s[:end])
+		} else {
+			b.WriteString(s[:i])
+			b.WriteString(new)
+		}
+		s = s[end:]
+	}
+}
+
+func isLetter(c byte) bool { return c >= 'A' && c <= 'Z' }
+
+func isAlnum(c byte) bool { return isLetter(c) || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') }
@@ -0,0 +1,295 @@
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Object is a JSON Schema, or a part of one.
+type Object map[string]interface{}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Variants is implemented by structs with interface fields, to list the
+// values each of those fields may hold, by JSON name. The schema of such a
+// field is then the union of the values' schemas rather than any value. The
+// values are zero values of the types (nil pointers will do), not nil.
+type Variants interface {
+	SchemaVariants() map[string][]interface{}
+}
+
+// generator builds the schema of a Go type as encoding/json marshals it.
+// Named structs other than the root are placed in $defs, under their package
+// and type name (e.g. "adsc.BasicReport"), and referred to from where they
+// are used.
+type generator struct {
+	defs  map[string]Object
+	names map[reflect.Type]string
+}
+
+// generate returns the schema of the JSON encoding of v, which must be a
+// struct or a pointer to one.
+func generate(v interface{}) (Object, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a struct", v)
+	}
+	g := &generator{defs: map[string]Object{}, names: map[reflect.Type]string{}}
+	s, err := g.object(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.defs) > 0 {
+		s["$defs"] = g.defs
+	}
+	return s, nil
+}
+
+// schema returns the schema of a type.
+func (g *generator) schema(t reflect.Type) (Object, error) {
+	switch {
+	case t == timeType:
+		return Object{"type": "string", "format": "date-time"}, nil
+	case t == rawMessageType:
+		return Object{}, nil
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType),
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return nil, fmt.Errorf("%s has a custom JSON encoding", t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Object{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Object{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return Object{"type": "number"}, nil
+	case reflect.String:
+		return Object{"type": "string"}, nil
+	case reflect.Interface:
+		return Object{}, nil
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return Object{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := Object{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			s["minItems"], s["maxItems"] = t.Len(), t.Len()
+		}
+		return s, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%s has non-string keys", t)
+		}
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return Object{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	return nil, fmt.Errorf("%s cannot be encoded as JSON", t)
+}
+
+// ref returns a reference to the definition of a named struct, adding the
+// definition the first time the struct is seen.
+func (g *generator) ref(t reflect.Type) (Object, error) {
+	name, ok := g.names[t]
+	if !ok {
+		name = path.Base(t.PkgPath()) + "." + t.Name()
+		if _, taken := g.defs[name]; taken {
+			return nil, fmt.Errorf("two types are named %s", name)
+		}
+		g.names[t] = name
+		g.defs[name] = nil // Reserved, in case the struct refers to itself.
+		obj, err := g.object(t)
+		if err != nil {
+			return nil, err
+		}
+		g.defs[name] = obj
+	}
+	return Object{"$ref": "#/$defs/" + name}, nil
+}
+
+// field is a struct field as encoding/json sees it.
+type field struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+	quoted    bool // The ",string" option.
+}
+
+// object returns the schema of a struct. Fields that are not omitted when
+// empty are required, and those that marshal nil as null may be null.
+func (g *generator) object(t reflect.Type) (Object, error) {
+	var variants map[string][]interface{}
+	if v, ok := reflect.New(t).Interface().(Variants); ok {
+		variants = v.SchemaVariants()
+	}
+	props := Object{}
+	var required []string
+	for _, f := range fields(t) {
+		var s Object
+		var err error
+		if f.quoted {
+			s = Object{"type": "string"}
+		} else if vs := variants[f.name]; len(vs) > 0 && f.typ.Kind() == reflect.Interface {
+			if s, err = g.union(vs); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.name, err)
+			}
+		} else if s, err = g.schema(f.typ); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.name, err)
+		}
+		if !f.omitEmpty {
+			required = append(required, f.name)
+			switch f.typ.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Map:
+				s = nullable(s)
+			}
+		}
+		props[f.name] = s
+	}
+	s := Object{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s, nil
+}
+
+// union returns the schema of a value that is one of the given values.
+func (g *generator) union(values []interface{}) (Object, error) {
+	var alts []Object
+	seen := map[string]bool{}
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		if t == nil {
+			return nil, errors.New("nil variant")
+		}
+		s, err := g.schema(t)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		if seen[string(b)] {
+			continue
+		}
+		seen[string(b)] = true
+		alts = append(alts, s)
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return Object{"anyOf": alts}, nil
+}
+
+// nullable returns a schema that also allows null.
+func nullable(s Object) Object {
+	if typ, ok := s["type"].(string); ok {
+		out := Object{}
+		for k, v := range s {
+			out[k] = v
+		}
+		out["type"] = []string{typ, "null"}
+		return out
+	}
+	if len(s) == 0 {
+		return s
+	}
+	return Object{"anyOf": []Object{s, {"type": "null"}}}
+}
+
+// fields returns the fields of a struct that encoding/json marshals, with
+// those of embedded structs promoted unless the outer struct has a field of
+// the same name.
+func fields(t reflect.Type) []field {
+	var out []field
+	seen := map[string]bool{}
+	var walk func(t reflect.Type)
+	var embedded []reflect.Type
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			ft := sf.Type
+			if sf.Anonymous && name == "" {
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					embedded = append(embedded, ft)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			f := field{name: name, typ: sf.Type}
+			for _, o := range strings.Split(opts, ",") {
+				switch o {
+				case "omitempty", "omitzero":
+					f.omitEmpty = true
+				case "string":
+					switch ft.Kind() {
+					case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+						reflect.Float32, reflect.Float64, reflect.String:
+						f.quoted = true
+					}
+				}
+			}
+			out = append(out, f)
+		}
+	}
+	walk(t)
+	// Embedded structs are walked after the outer fields, breadth first, so
+	// that shallower fields win.
+	for len(embedded) > 0 {
+		level := embedded
+		embedded = nil
+		for _, et := range level {
+			walk(et)
+		}
+	}
+	return out
+}
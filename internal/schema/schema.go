@@ -0,0 +1,150 @@
+// Package schema publishes JSON Schema documents for parse results.
+//
+// Every result type has an entry in the table in types.go with an explicit
+// schema version. The schema is generated from the result struct, so it
+// always describes what the parser emits; the version tells consumers when
+// that has changed. The schema of every current version is checked in under
+// api/schemas, and Check fails when a struct no longer matches the file for
+// its version, so a change to a result struct cannot be merged without a
+// version bump.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"acars_parser/internal/registry"
+)
+
+// Dialect is the JSON Schema dialect of the documents.
+const Dialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Entry is a result type and the version of its schema.
+type Entry struct {
+	Type    string
+	Version int
+	Result  registry.Result // A zero value of the result struct.
+}
+
+// ID returns the $id of the entry's schema, e.g. "urn:acars-parser:result:pdc:v2".
+func (e Entry) ID() string {
+	return fmt.Sprintf("urn:acars-parser:result:%s:v%d", e.Type, e.Version)
+}
+
+// FileName returns the name of the entry's schema file, e.g. "pdc.v2.json".
+func (e Entry) FileName() string {
+	return fmt.Sprintf("%s.v%d.json", e.Type, e.Version)
+}
+
+// Document returns the entry's JSON Schema.
+func (e Entry) Document() (Object, error) {
+	s, err := generate(e.Result)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.Type, err)
+	}
+	s["$schema"] = Dialect
+	s["$id"] = e.ID()
+	s["title"] = e.Type
+	s["x-version"] = e.Version
+	return s, nil
+}
+
+// JSON returns the entry's JSON Schema, indented and with a trailing
+// newline, as it is written to its file.
+func (e Entry) JSON() ([]byte, error) {
+	s, err := e.Document()
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.Type, err)
+	}
+	return append(b, '\n'), nil
+}
+
+// Entries returns the entry of every result type, sorted by type.
+func Entries() []Entry {
+	out := make([]Entry, len(entries))
+	copy(out, entries)
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// Lookup returns the entry of a result type.
+func Lookup(typ string) (Entry, bool) {
+	for _, e := range entries {
+		if e.Type == typ {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Check compares the schema of every entry with its file in dir, returning a
+// problem for each schema that has no file (its version is new, so it must be
+// written) or that differs from its file (the struct changed, so the version
+// must be bumped).
+func Check(dir string) []error {
+	var problems []error
+	for _, e := range Entries() {
+		want, err := e.JSON()
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		got, err := os.ReadFile(filepath.Join(dir, e.FileName()))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			problems = append(problems, fmt.Errorf("%s: no schema file for version %d; write it with schema -write", e.Type, e.Version))
+		case err != nil:
+			problems = append(problems, err)
+		case !bytes.Equal(got, want):
+			problems = append(problems, fmt.Errorf("%s: result struct no longer matches %s; bump its version in internal/schema/types.go and write the new schema with schema -write", e.Type, e.FileName()))
+		}
+	}
+	return problems
+}
+
+// Write writes the schema file of every entry that has none to dir,
+// returning the names of the files written. Existing files are never
+// overwritten, as consumers may rely on them; a schema that differs from its
+// file is an error, and needs a version bump.
+func Write(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	var problems []error
+	for _, e := range Entries() {
+		b, err := e.JSON()
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		path := filepath.Join(dir, e.FileName())
+		existing, err := os.ReadFile(path)
+		if err == nil {
+			if !bytes.Equal(existing, b) {
+				problems = append(problems, fmt.Errorf("%s: result struct no longer matches %s; bump its version in internal/schema/types.go", e.Type, e.FileName()))
+			}
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			problems = append(problems, err)
+			continue
+		}
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		written = append(written, e.FileName())
+	}
+	return written, errors.Join(problems...)
+}
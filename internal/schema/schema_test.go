@@ -0,0 +1,209 @@
+package schema
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSchemaFiles fails when a result struct has changed without its schema
+// version being bumped, or when a new version has not been written.
+func TestSchemaFiles(t *testing.T) {
+	for _, p := range Check("../../api/schemas") {
+		t.Error(p)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if got := e.Result.Type(); got != e.Type {
+			t.Errorf("entry %q has a result of type %q", e.Type, got)
+		}
+		if seen[e.Type] {
+			t.Errorf("entry %q is listed twice", e.Type)
+		}
+		seen[e.Type] = true
+		if e.Version < 1 {
+			t.Errorf("entry %q has version %d", e.Type, e.Version)
+		}
+	}
+}
+
+// TestEveryResultTypeHasSchema finds the Type methods of the result structs
+// in the source and checks that each type has an entry.
+func TestEveryResultTypeHasSchema(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, dir := range []string{"../parsers", "../hfdl", "../vdl2"} {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			f, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || fn.Name.Name != "Type" || fn.Body == nil || len(fn.Body.List) != 1 {
+					continue
+				}
+				ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+				if !ok || len(ret.Results) != 1 {
+					continue
+				}
+				lit, ok := ret.Results[0].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				typ, _ := strconv.Unquote(lit.Value)
+				if _, ok := Lookup(typ); !ok {
+					t.Errorf("%s: result type %q has no schema entry in types.go", fset.Position(fn.Pos()), typ)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type testInner struct {
+	Name string `json:"name"`
+}
+
+type testEmbedded struct {
+	Shared string `json:"shared"`
+	Extra  int    `json:"extra,omitempty"`
+}
+
+type testResult struct {
+	testEmbedded
+	Shared    bool                   `json:"shared"` // Shadows the embedded field.
+	Count     int                    `json:"count"`
+	Ratio     float64                `json:"ratio,omitempty"`
+	When      time.Time              `json:"when"`
+	Tags      []string               `json:"tags"`
+	Inner     *testInner             `json:"inner,omitempty"`
+	Others    []testInner            `json:"others,omitempty"`
+	Labels    map[string]int         `json:"labels,omitempty"`
+	Raw       json.RawMessage        `json:"raw,omitempty"`
+	Quoted    int                    `json:"quoted,string"`
+	Anonymous struct{ X, Y float64 } `json:"anonymous"`
+	Skipped   string                 `json:"-"`
+	unexport  string
+}
+
+func TestGenerate(t *testing.T) {
+	s, err := generate(&testResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	props := got["properties"].(map[string]interface{})
+
+	want := map[string]string{
+		"shared":    `{"type":"boolean"}`,
+		"extra":     `{"type":"integer"}`,
+		"count":     `{"type":"integer"}`,
+		"ratio":     `{"type":"number"}`,
+		"when":      `{"format":"date-time","type":"string"}`,
+		"tags":      `{"items":{"type":"string"},"type":["array","null"]}`,
+		"inner":     `{"$ref":"#/$defs/schema.testInner"}`,
+		"others":    `{"items":{"$ref":"#/$defs/schema.testInner"},"type":"array"}`,
+		"labels":    `{"additionalProperties":{"type":"integer"},"type":"object"}`,
+		"raw":       `{}`,
+		"quoted":    `{"type":"string"}`,
+		"anonymous": `{"properties":{"X":{"type":"number"},"Y":{"type":"number"}},"required":["X","Y"],"type":"object"}`,
+	}
+	for name, w := range want {
+		b, _ := json.Marshal(props[name])
+		if string(b) != w {
+			t.Errorf("%s = %s, want %s", name, b, w)
+		}
+	}
+	if len(props) != len(want) {
+		t.Errorf("got %d properties, want %d", len(props), len(want))
+	}
+
+	b, _ = json.Marshal(got["required"])
+	if w := `["anonymous","count","quoted","shared","tags","when"]`; string(b) != w {
+		t.Errorf("required = %s, want %s", b, w)
+	}
+	b, _ = json.Marshal(got["$defs"])
+	if w := `{"schema.testInner":{"properties":{"name":{"type":"string"}},"required":["name"],"type":"object"}}`; string(b) != w {
+		t.Errorf("$defs = %s, want %s", b, w)
+	}
+}
+
+type testVariants struct {
+	Value interface{} `json:"value,omitempty"`
+	Other interface{} `json:"other"`
+}
+
+func (testVariants) SchemaVariants() map[string][]interface{} {
+	return map[string][]interface{}{"value": {(*testInner)(nil), "", 0, 1}}
+}
+
+func TestGenerateVariants(t *testing.T) {
+	s, err := generate(&testVariants{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(s["properties"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Variants of the same type are listed once; fields without variants
+	// may hold any value.
+	if w := `{"other":{},"value":{"anyOf":[{"$ref":"#/$defs/schema.testInner"},{"type":"string"},{"type":"integer"}]}}`; string(b) != w {
+		t.Errorf("properties = %s, want %s", b, w)
+	}
+	if _, ok := s["$defs"].(map[string]Object)["schema.testInner"]; !ok {
+		t.Errorf("$defs = %v, want schema.testInner", s["$defs"])
+	}
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	type withFunc struct {
+		F func() `json:"f"`
+	}
+	if _, err := generate(&withFunc{}); err == nil {
+		t.Error("expected an error for a func field")
+	}
+	if _, err := generate("not a struct"); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
+
+func TestEntryDocument(t *testing.T) {
+	e, ok := Lookup("pdc")
+	if !ok {
+		t.Fatal("no pdc entry")
+	}
+	s, err := e.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s["$schema"] != Dialect || s["$id"] != e.ID() || s["title"] != "pdc" || s["x-version"] != e.Version {
+		t.Errorf("unexpected metadata: %v %v %v %v", s["$schema"], s["$id"], s["title"], s["x-version"])
+	}
+	if e.FileName() != "pdc.v"+strconv.Itoa(e.Version)+".json" {
+		t.Errorf("FileName() = %q", e.FileName())
+	}
+}
@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"acars_parser/internal/hfdl"
+	"acars_parser/internal/parsers/adsc"
+	"acars_parser/internal/parsers/afn"
+	"acars_parser/internal/parsers/agfsr"
+	"acars_parser/internal/parsers/atccomm"
+	"acars_parser/internal/parsers/atis"
+	"acars_parser/internal/parsers/cpdlc"
+	"acars_parser/internal/parsers/crew"
+	"acars_parser/internal/parsers/delay"
+	"acars_parser/internal/parsers/dispatch"
+	"acars_parser/internal/parsers/envelope"
+	"acars_parser/internal/parsers/eta"
+	"acars_parser/internal/parsers/freetext"
+	"acars_parser/internal/parsers/fst"
+	"acars_parser/internal/parsers/fuel"
+	"acars_parser/internal/parsers/gateassign"
+	"acars_parser/internal/parsers/h1"
+	"acars_parser/internal/parsers/h2wind"
+	"acars_parser/internal/parsers/hazard"
+	"acars_parser/internal/parsers/label10"
+	"acars_parser/internal/parsers/label16"
+	"acars_parser/internal/parsers/label21"
+	"acars_parser/internal/parsers/label22"
+	"acars_parser/internal/parsers/label44"
+	"acars_parser/internal/parsers/label4j"
+	"acars_parser/internal/parsers/label5l"
+	"acars_parser/internal/parsers/label80"
+	"acars_parser/internal/parsers/label83"
+	"acars_parser/internal/parsers/labelb2"
+	"acars_parser/internal/parsers/labelb3"
+	"acars_parser/internal/parsers/labelrf"
+	"acars_parser/internal/parsers/landingdata"
+	"acars_parser/internal/parsers/loadsheet"
+	"acars_parser/internal/parsers/maintenance"
+	"acars_parser/internal/parsers/mediaadv"
+	"acars_parser/internal/parsers/parking"
+	"acars_parser/internal/parsers/paxbag"
+	"acars_parser/internal/parsers/paxconn"
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/parsers/pirep"
+	"acars_parser/internal/parsers/sq"
+	"acars_parser/internal/parsers/takeoff"
+	"acars_parser/internal/parsers/turbulence"
+	"acars_parser/internal/parsers/weather"
+	"acars_parser/internal/vdl2"
+)
+
+// entries lists every result type with the current version of its schema.
+// Bump a type's version whenever a change to its struct changes the JSON it
+// marshals to (a field added, removed, renamed or retyped), then write the
+// new schema file with schema -write. Versions only ever go up.
+var entries = []Entry{
+	{"adsc", 1, &adsc.Result{}},
+	{"afn", 1, &afn.Result{}},
+	{"agfsr", 1, &agfsr.Result{}},
+	{"atc_comm", 1, &atccomm.Result{}},
+	{"atis", 1, &atis.Result{}},
+	{"cpdlc", 2, &cpdlc.Result{}},
+	{"crew_list", 1, &crew.Result{}},
+	{"delay_summary", 1, &delay.Result{}},
+	{"dispatcher", 1, &dispatch.Result{}},
+	{"envelope", 1, &envelope.Result{}},
+	{"eta", 1, &eta.Result{}},
+	{"flight_plan", 1, &h1.FPNResult{}},
+	{"flight_subscription", 1, &labelrf.Result{}},
+	{"free_text", 1, &freetext.Result{}},
+	{"fst", 1, &fst.Result{}},
+	{"fuel_delivery", 1, &fuel.Result{}},
+	{"fuel_report", 1, &fuel.ReportResult{}},
+	{"gate_assignment", 1, &gateassign.Result{}},
+	{"gate_info", 1, &labelb3.Result{}},
+	{"h1_position", 1, &h1.H1PosResult{}},
+	{"h1_sublabel", 1, &h1.SubLabelResult{}},
+	{"h2_wind", 1, &h2wind.Result{}},
+	{"hazard_alert", 1, &hazard.HazardResult{}},
+	{"hfdl_frequency_data", 1, &hfdl.FrequencyDataResult{}},
+	{"hfdl_performance", 1, &hfdl.PerformanceResult{}},
+	{"hfdl_squitter", 1, &hfdl.SquitterResult{}},
+	{"label10_position", 1, &label10.Result{}},
+	{"label22_position", 1, &label22.Result{}},
+	{"label44", 1, &label44.Result{}},
+	{"label83_position", 1, &label83.Result{}},
+	{"landing_data", 1, &landingdata.Result{}},
+	{"loadsheet", 1, &loadsheet.Result{}},
+	{"maintenance_fault", 1, &maintenance.MaintenanceFaultResult{}},
+	{"mdc", 1, &h1.MDCResult{}},
+	{"media_advisory", 1, &mediaadv.Result{}},
+	{"oceanic_clearance", 3, &labelb2.Result{}},
+	{"parking_info", 1, &parking.Result{}},
+	{"pax_bag", 1, &paxbag.Result{}},
+	{"pax_conn_status", 1, &paxconn.Result{}},
+	{"pdc", 2, &pdc.Result{}},
+	{"pos_weather", 1, &label4j.Result{}},
+	{"position", 1, &label80.Result{}},
+	{"position_report", 1, &label21.Result{}},
+	{"pwi", 1, &h1.PWIResult{}},
+	{"route", 1, &label5l.Result{}},
+	{"sq_position", 1, &sq.Result{}},
+	{"takeoff_data", 1, &takeoff.Result{}},
+	{"takeoff_performance", 1, &takeoff.PerformanceResult{}},
+	{"trajectory", 1, &h1.TrajectoryResult{}},
+	{"turbulence", 1, &turbulence.Result{}},
+	{"turbulence_report", 1, &pirep.TurbulenceResult{}},
+	{"vdl2_xid", 1, &vdl2.XIDResult{}},
+	{"waypoint_position", 1, &label16.Result{}},
+	{"weather", 1, &weather.Result{}},
+}
@@ -0,0 +1,80 @@
+package state
+
+import (
+	"encoding/json"
+	"strings"
+
+	"acars_parser/internal/registry"
+)
+
+// Logon activity kinds.
+const (
+	LogonContact         = "contact"         // AFN contact: the aircraft logs on.
+	LogonAcknowledgement = "acknowledgement" // AFN acknowledgement from the facility.
+	LogonCPDLC           = "cpdlc"           // CPDLC traffic with the facility.
+)
+
+// LogonActivity is what one message says about an aircraft's FANS logon to
+// an ATS facility.
+type LogonActivity struct {
+	Kind         string
+	Registration string
+	Facility     string
+	Flight       string
+	Applications string // For contacts, e.g. "ATC01,ADS01".
+	Accepted     bool   // For acknowledgements: every application accepted.
+}
+
+// LogonActivities returns the logon activity in a message's parse results:
+// AFN contacts and acknowledgements, and CPDLC messages, which are linked to
+// the logon before them. Results without a registration or facility address
+// are skipped.
+func LogonActivities(results []registry.Result) []LogonActivity {
+	var out []LogonActivity
+	for _, r := range results {
+		if r.Type() != "afn" && r.Type() != "cpdlc" {
+			continue
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+
+		a := LogonActivity{Kind: LogonCPDLC}
+		a.Registration, _ = m["registration"].(string)
+		a.Facility, _ = m["ground_station"].(string)
+		a.Registration = strings.ToUpper(strings.TrimLeft(a.Registration, "."))
+		if a.Registration == "" || len(a.Facility) != 7 {
+			continue
+		}
+
+		if r.Type() == "afn" {
+			a.Kind, _ = m["message_type"].(string)
+			a.Flight, _ = m["flight_number"].(string)
+			apps, _ := m["applications"].([]interface{})
+			var names []string
+			a.Accepted = len(apps) > 0
+			for _, app := range apps {
+				am, _ := app.(map[string]interface{})
+				name, _ := am["name"].(string)
+				version, _ := am["version"].(string)
+				names = append(names, name+version)
+				if reason, ok := am["reason"].(float64); ok && reason != 0 {
+					a.Accepted = false
+				}
+			}
+			if a.Kind == LogonContact {
+				a.Applications = strings.Join(names, ",")
+			}
+		}
+		if a.Kind != LogonContact && a.Kind != LogonAcknowledgement && a.Kind != LogonCPDLC {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package state
+
+import (
+	"testing"
+
+	"acars_parser/internal/parsers/afn"
+	"acars_parser/internal/registry"
+)
+
+func TestLogonActivities(t *testing.T) {
+	contact := &afn.Result{MessageType: afn.TypeContact, GroundStation: "BNECAYA", FlightNumber: "QFA41",
+		Registration: "VH-OQA", Applications: []afn.Application{{Name: "ATC", Version: "01"}, {Name: "ADS", Version: "01"}}}
+	accepted, rejected := 0, 1
+	ack := &afn.Result{MessageType: afn.TypeAcknowledgement, GroundStation: "BNECAYA", Registration: "VH-OQA",
+		Applications: []afn.Application{{Name: "ATC", Version: "01", Reason: &accepted}, {Name: "ADS", Version: "01", Reason: &rejected}}}
+	cpdlc := mapResult{"registration": "VH-OQA", "ground_station": "BNECAYA", "message_type": "cpdlc"}
+
+	got := LogonActivities([]registry.Result{contact, ack, cpdlc, mapResult{"ground_station": "BNECAYA"}})
+	if len(got) != 3 {
+		t.Fatalf("LogonActivities() = %+v", got)
+	}
+	if got[0].Kind != LogonContact || got[0].Applications != "ATC01,ADS01" || got[0].Flight != "QFA41" {
+		t.Errorf("contact = %+v", got[0])
+	}
+	if got[1].Kind != LogonAcknowledgement || got[1].Accepted {
+		t.Errorf("acknowledgement = %+v, want not accepted", got[1])
+	}
+	if got[2].Kind != LogonCPDLC || got[2].Registration != "VH-OQA" || got[2].Facility != "BNECAYA" {
+		t.Errorf("cpdlc = %+v", got[2])
+	}
+}
@@ -0,0 +1,151 @@
+package state
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// Comm assignment kinds.
+const (
+	CommSELCAL    = "selcal"    // The flight's SELCAL code.
+	CommContact   = "contact"   // Told to contact a unit on a frequency.
+	CommMonitor   = "monitor"   // Told to monitor a frequency.
+	CommDeparture = "departure" // Departure frequency from a clearance.
+)
+
+var (
+	// SELCAL codes are two pairs of the letters A-S without I, N and O:
+	// "SELCAL ABCD", "SELCAL/AB-CD".
+	selcalRe = regexp.MustCompile(`\bSELCAL\s*[:/]?\s*([A-HJ-MP-S]{2})-?([A-HJ-MP-S]{2})\b`)
+	// "CONTACT GANDER RADIO ON 8891", "MONITOR SHANWICK 5649 KHZ",
+	// "CONTACT NY CENTER 134.35".
+	contactRe = regexp.MustCompile(`\b(CONTACT|MONITOR)\s+([A-Z][A-Z ]{1,30}?)\s+(?:ON\s+|FREQ\s+)?(\d{3,5}(?:\.\d{1,3})?)\s*(KHZ|MHZ)?\b`)
+)
+
+// cpdlcCommKinds maps the CPDLC uplinks carrying a unit and frequency to
+// their kind.
+var cpdlcCommKinds = map[int]string{
+	117: CommContact, 118: CommContact, 119: CommContact,
+	120: CommMonitor, 121: CommMonitor, 122: CommMonitor,
+}
+
+// CommAssignments returns the SELCAL codes and frequencies given to a flight
+// in a message, at the message time. Frequencies come from CPDLC CONTACT and
+// MONITOR uplinks, ATC comm transfers, clearance departure frequencies and free
+// text; SELCAL codes come from ATC comm messages and free text such as oceanic
+// clearance requests. The frequency a crew asks for in a voice request is not
+// an assignment, so the free text of such a request is not read for them.
+func CommAssignments(ts time.Time, text string, results []registry.Result) []storage.CommAssignment {
+	var out []storage.CommAssignment
+	voiceRequest := false
+	add := func(a storage.CommAssignment) {
+		a.Timestamp = ts
+		for _, seen := range out {
+			if seen.Kind == a.Kind && seen.SELCAL == a.SELCAL && seen.FrequencyKHz == a.FrequencyKHz {
+				return
+			}
+		}
+		out = append(out, a)
+	}
+
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+
+		if v, _ := m["departure_freq"].(string); v != "" {
+			if khz, band, ok := parseFrequency(v, ""); ok {
+				add(storage.CommAssignment{Kind: CommDeparture, FrequencyKHz: khz, Band: band, Source: r.Type()})
+			}
+		}
+
+		if r.Type() == "atc_comm" {
+			if v, _ := m["selcal"].(string); v != "" {
+				add(storage.CommAssignment{Kind: CommSELCAL, SELCAL: v, Source: r.Type()})
+			}
+			kind, _ := m["message_type"].(string)
+			if m["direction"] != "uplink" {
+				voiceRequest = true
+			} else if kind == CommContact || kind == CommMonitor {
+				unit, _ := m["facility"].(string)
+				for _, field := range []string{"frequency_khz", "secondary_frequency_khz"} {
+					v, _ := m[field].(float64)
+					if khz, band, ok := parseFrequency(strconv.Itoa(int(v)), "KHZ"); ok {
+						add(storage.CommAssignment{Kind: kind, FrequencyKHz: khz, Band: band, Unit: unit, Source: r.Type()})
+					}
+				}
+			}
+		}
+
+		elements, _ := m["elements"].([]interface{})
+		for _, e := range elements {
+			em, _ := e.(map[string]interface{})
+			id, _ := em["id"].(float64)
+			kind := cpdlcCommKinds[int(id)]
+			if kind == "" || m["direction"] != "uplink" {
+				continue
+			}
+			data, _ := em["data"].(map[string]interface{})
+			freq, _ := data["frequency"].(map[string]interface{})
+			band, _ := freq["type"].(string)
+			khz, _ := freq["value"].(float64)
+			if band == "" || band == "satcom" || khz <= 0 {
+				continue
+			}
+			unit, _ := data["unit"].(string)
+			add(storage.CommAssignment{Kind: kind, FrequencyKHz: int(khz), Band: band, Unit: strings.TrimSpace(unit), Source: r.Type()})
+		}
+	}
+
+	upper := strings.ToUpper(text)
+	for _, m := range selcalRe.FindAllStringSubmatch(upper, -1) {
+		add(storage.CommAssignment{Kind: CommSELCAL, SELCAL: m[1] + m[2], Source: "text"})
+	}
+	if voiceRequest {
+		return out
+	}
+	for _, m := range contactRe.FindAllStringSubmatch(upper, -1) {
+		khz, band, ok := parseFrequency(m[3], m[4])
+		if !ok {
+			continue
+		}
+		add(storage.CommAssignment{Kind: strings.ToLower(m[1]), FrequencyKHz: khz, Band: band, Unit: strings.TrimSpace(m[2]), Source: "text"})
+	}
+	return out
+}
+
+// parseFrequency converts a frequency as written in a message to kHz and
+// returns its band. Without a unit, a figure with a decimal point is taken as
+// MHz and a whole number as kHz. Figures outside the aeronautical HF, VHF and
+// UHF bands are rejected, which filters out times, levels and other numbers.
+func parseFrequency(s, unit string) (int, string, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v <= 0 {
+		return 0, "", false
+	}
+	if unit == "MHZ" || (unit == "" && strings.Contains(s, ".")) {
+		v *= 1000
+	}
+	khz := int(math.Round(v))
+	switch {
+	case khz >= 2850 && khz <= 28000:
+		return khz, "hf", true
+	case khz >= 117975 && khz <= 137000:
+		return khz, "vhf", true
+	case khz >= 225000 && khz <= 400000:
+		return khz, "uhf", true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,96 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"acars_parser/internal/parsers/atccomm"
+	"acars_parser/internal/registry"
+)
+
+// mapResult is a result with arbitrary JSON content.
+type mapResult map[string]interface{}
+
+func (r mapResult) Type() string     { return "cpdlc" }
+func (r mapResult) MessageID() int64 { return 1 }
+
+func TestCommAssignmentsText(t *testing.T) {
+	ts := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	text := "CLRNCE 123 BAW117 CLRD TO KJFK VIA 55N020W SELCAL AB-CD\nCONTACT GANDER RADIO ON 8891 AT 30W\nMONITOR NY CENTER 134.35 FL350"
+
+	got := CommAssignments(ts, text, nil)
+	if len(got) != 3 {
+		t.Fatalf("CommAssignments() = %+v", got)
+	}
+	if got[0].Kind != CommSELCAL || got[0].SELCAL != "ABCD" || !got[0].Timestamp.Equal(ts) {
+		t.Errorf("selcal = %+v", got[0])
+	}
+	if got[1].Kind != CommContact || got[1].Unit != "GANDER RADIO" || got[1].FrequencyKHz != 8891 || got[1].Band != "hf" {
+		t.Errorf("contact = %+v", got[1])
+	}
+	if got[2].Kind != CommMonitor || got[2].Unit != "NY CENTER" || got[2].FrequencyKHz != 134350 || got[2].Band != "vhf" {
+		t.Errorf("monitor = %+v", got[2])
+	}
+
+	// Numbers outside the aeronautical bands are not frequencies, and I, N
+	// and O are not SELCAL letters.
+	for _, text := range []string{"CONTACT DISPATCH ON 1234", "CONTACT OPS 555.12", "SELCAL ABNO"} {
+		if got := CommAssignments(ts, text, nil); len(got) != 0 {
+			t.Errorf("CommAssignments(%q) = %+v", text, got)
+		}
+	}
+}
+
+func TestCommAssignmentsResults(t *testing.T) {
+	var cpdlc mapResult
+	_ = json.Unmarshal([]byte(`{"direction": "uplink", "elements": [
+		{"id": 117, "data": {"unit": "CZQX", "frequency": {"type": "hf", "value": 5616}}},
+		{"id": 120, "data": {"unit": "KZNY", "frequency": {"type": "satcom", "value": 0}}},
+		{"id": 19}
+	]}`), &cpdlc)
+	pdc := mapResult{"departure_freq": "124.7"}
+
+	got := CommAssignments(time.Now(), "", []registry.Result{pdc, cpdlc})
+	if len(got) != 2 {
+		t.Fatalf("CommAssignments() = %+v", got)
+	}
+	if got[0].Kind != CommDeparture || got[0].FrequencyKHz != 124700 || got[0].Band != "vhf" {
+		t.Errorf("departure = %+v", got[0])
+	}
+	if got[1].Kind != CommContact || got[1].Unit != "CZQX" || got[1].FrequencyKHz != 5616 || got[1].Source != "cpdlc" {
+		t.Errorf("contact = %+v", got[1])
+	}
+
+	// Downlinks are not assignments.
+	cpdlc["direction"] = "downlink"
+	if got := CommAssignments(time.Now(), "", []registry.Result{cpdlc}); len(got) != 0 {
+		t.Errorf("downlink gave %+v", got)
+	}
+}
+
+func TestCommAssignmentsATCComm(t *testing.T) {
+	transfer := &atccomm.Result{MessageType: atccomm.TypeContact, Direction: "uplink", Facility: "GANDER RADIO",
+		FrequencyKHz: 8891, SecondaryFrequencyKHz: 13291, SELCAL: "ABCD"}
+	got := CommAssignments(time.Now(), "CONTACT GANDER RADIO ON 8891 SECONDARY 13291", []registry.Result{transfer})
+	if len(got) != 3 {
+		t.Fatalf("CommAssignments() = %+v", got)
+	}
+	if got[0].Kind != CommSELCAL || got[0].SELCAL != "ABCD" || got[0].Source != "atc_comm" {
+		t.Errorf("selcal = %+v", got[0])
+	}
+	if got[1].Kind != CommContact || got[1].Unit != "GANDER RADIO" || got[1].FrequencyKHz != 8891 || got[1].Source != "atc_comm" {
+		t.Errorf("contact = %+v", got[1])
+	}
+	if got[2].FrequencyKHz != 13291 || got[2].Band != "hf" {
+		t.Errorf("secondary = %+v", got[2])
+	}
+
+	// A voice request gives the flight's SELCAL, but the frequency it asks
+	// for is not an assignment, even where the text reads like one.
+	request := &atccomm.Result{MessageType: atccomm.TypeVoiceRequest, Direction: "downlink", Facility: "SHANWICK", FrequencyKHz: 8879, SELCAL: "CDHJ"}
+	got = CommAssignments(time.Now(), "REQ VOICE CONTACT SHANWICK 8879 SELCAL CDHJ", []registry.Result{request})
+	if len(got) != 1 || got[0].Kind != CommSELCAL || got[0].SELCAL != "CDHJ" {
+		t.Errorf("voice request gave %+v", got)
+	}
+}
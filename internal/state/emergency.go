@@ -0,0 +1,126 @@
+package state
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/extractor"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+	"acars_parser/internal/units"
+)
+
+// Emergency kinds.
+const (
+	EmergencyADSC   = "adsc_emergency" // ADS-C emergency basic report (tag 9).
+	EmergencyMayday = "mayday"         // CPDLC dM56 MAYDAY MAYDAY MAYDAY.
+	EmergencyPan    = "pan"            // CPDLC dM55 PAN PAN PAN.
+	EmergencySquawk = "squawk_7700"    // Clearance or CPDLC uplink assigning 7700.
+)
+
+// CPDLC downlink elements declaring an emergency.
+const (
+	cpdlcPan    = 55
+	cpdlcMayday = 56
+)
+
+// emergencySquawk is the transponder code for a general emergency.
+const emergencySquawk = "7700"
+
+// EmergencyKind returns the kind of emergency a parse result reports, or ""
+// if it reports none.
+func EmergencyKind(r registry.Result) string {
+	e, ok := resultEmergency(r)
+	if !ok {
+		return ""
+	}
+	return e.Kind
+}
+
+// Emergencies returns the emergency events a message reports through its
+// parse results, at the message time: ADS-C emergency reports, CPDLC MAYDAY
+// and PAN downlinks, and 7700 squawk assignments. The aircraft and flight are
+// those the message identifies; at most one event of each kind is returned.
+func Emergencies(ts time.Time, msg *acars.Message, results []registry.Result) []storage.EmergencyEvent {
+	var out []storage.EmergencyEvent
+	seen := make(map[string]bool)
+	for _, r := range results {
+		e, ok := resultEmergency(r)
+		if !ok || seen[e.Kind] {
+			continue
+		}
+		seen[e.Kind] = true
+		e.Timestamp, e.MessageID, e.Label = ts, int64(msg.ID), msg.Label
+		out = append(out, e)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+
+	if f := extractor.Extract(msg, results).Flight; f != nil {
+		for i := range out {
+			out[i].ICAOHex = strings.ToUpper(f.ICAOHex)
+			out[i].Registration, out[i].Flight = f.Registration, f.FlightNumber
+		}
+	}
+	return out
+}
+
+// resultEmergency returns the emergency a parse result reports, without the
+// message's identity and time.
+func resultEmergency(r registry.Result) (storage.EmergencyEvent, bool) {
+	e := storage.EmergencyEvent{Source: r.Type()}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return e, false
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return e, false
+	}
+
+	if r.Type() == "adsc" && m["message_type"] == "emergency" {
+		e.Kind, e.Detail = EmergencyADSC, "ADS-C emergency report"
+		lat, latOK := m["latitude"].(float64)
+		lon, lonOK := m["longitude"].(float64)
+		if latOK && lonOK {
+			e.Latitude, e.Longitude = &lat, &lon
+		}
+		if alt, ok := units.AltitudeFeet(m["altitude"]); ok {
+			a := int(math.Round(alt))
+			e.Altitude = &a
+		}
+		return e, true
+	}
+
+	if m["direction"] == "downlink" {
+		elements, _ := m["elements"].([]interface{})
+		for _, el := range elements {
+			em, _ := el.(map[string]interface{})
+			id, _ := em["id"].(float64)
+			switch int(id) {
+			case cpdlcMayday:
+				e.Kind = EmergencyMayday
+			case cpdlcPan:
+				e.Kind = EmergencyPan
+			default:
+				continue
+			}
+			if e.Detail, _ = m["formatted_text"].(string); e.Detail == "" {
+				e.Detail, _ = em["text"].(string)
+			}
+			return e, true
+		}
+	}
+
+	for _, s := range Squawks(time.Time{}, 0, []registry.Result{r}) {
+		if s.Squawk == emergencySquawk {
+			e.Kind, e.Detail = EmergencySquawk, "squawk "+emergencySquawk
+			return e, true
+		}
+	}
+	return e, false
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+type adscResult struct {
+	MessageType string  `json:"message_type"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	Altitude    int     `json:"altitude,omitempty"`
+}
+
+func (r *adscResult) Type() string     { return "adsc" }
+func (r *adscResult) MessageID() int64 { return 1 }
+
+func TestEmergencies(t *testing.T) {
+	ts := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	msg := &acars.Message{ID: 42, Label: "B6", Tail: "VH-OQA", LinkDirection: "downlink", FromHex: "7c6db8", Flight: &acars.Flight{Flight: "QF1"}}
+
+	var mayday mapResult
+	_ = json.Unmarshal([]byte(`{"direction": "downlink", "formatted_text": "MAYDAY MAYDAY MAYDAY", "elements": [
+		{"id": 56, "text": "MAYDAY MAYDAY MAYDAY"},
+		{"id": 57, "text": "120 MINUTES OF FUEL 220 SOULS"}
+	]}`), &mayday)
+	emergency := &adscResult{MessageType: "emergency", Latitude: -38.5, Longitude: 165.2, Altitude: 35000}
+
+	got := Emergencies(ts, msg, []registry.Result{emergency, mayday, mapResult{"squawk": "7700"}, &adscResult{MessageType: "emergency"}})
+	if len(got) != 3 {
+		t.Fatalf("Emergencies() = %+v", got)
+	}
+	e := got[0]
+	if e.Kind != EmergencyADSC || e.Source != "adsc" || e.ICAOHex != "7C6DB8" || e.Registration != "VH-OQA" || e.Flight != "QF1" || e.MessageID != 42 || !e.Timestamp.Equal(ts) {
+		t.Errorf("adsc event = %+v", e)
+	}
+	if e.Latitude == nil || *e.Latitude != -38.5 || e.Altitude == nil || *e.Altitude != 35000 {
+		t.Errorf("adsc position = %v, %v", e.Latitude, e.Altitude)
+	}
+	if e := got[1]; e.Kind != EmergencyMayday || e.Detail != "MAYDAY MAYDAY MAYDAY" || e.Latitude != nil {
+		t.Errorf("mayday event = %+v", e)
+	}
+	if e := got[2]; e.Kind != EmergencySquawk {
+		t.Errorf("squawk event = %+v", e)
+	}
+}
+
+func TestEmergencyKind(t *testing.T) {
+	var pan, uplink mapResult
+	_ = json.Unmarshal([]byte(`{"direction": "downlink", "elements": [{"id": 55, "text": "PAN PAN PAN"}]}`), &pan)
+	_ = json.Unmarshal([]byte(`{"direction": "uplink", "elements": [{"id": 123, "data": {"code": "7700"}}]}`), &uplink)
+
+	tests := []struct {
+		name string
+		r    registry.Result
+		want string
+	}{
+		{"pan", pan, EmergencyPan},
+		{"cpdlc squawk 7700", uplink, EmergencySquawk},
+		{"pdc squawk", mapResult{"squawk": "2021"}, ""},
+		{"adsc basic", &adscResult{MessageType: "basic"}, ""},
+		{"adsc cancel", &adscResult{MessageType: "cancel_emergency"}, ""},
+	}
+	for _, tt := range tests {
+		if got := EmergencyKind(tt.r); got != tt.want {
+			t.Errorf("%s: EmergencyKind() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package state
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/airport"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// Kinds of ETA source. Each flight keeps the latest ETA of each kind.
+const (
+	ETASourceMovement = "movement" // Company ETA and movement messages (label 5Z).
+	ETASourcePosition = "position" // Position reports giving the ETA at the destination.
+	ETASourceCPDLC    = "cpdlc"    // CPDLC position reports (dM48) whose next fix is the destination.
+)
+
+// etaResultSources maps the result types that report an ETA at the
+// destination to their kind of source.
+var etaResultSources = map[string]string{
+	"eta":              ETASourceMovement,
+	"label44":          ETASourcePosition,
+	"position":         ETASourcePosition,
+	"position_report":  ETASourcePosition,
+	"label10_position": ETASourcePosition,
+	"agfsr":            ETASourcePosition,
+}
+
+// etaBaseUncertainty is the uncertainty, in minutes, of an ETA of each kind
+// of source when reported: the FMS estimates of position reports are better
+// than the company's, and those sent to ATC better still.
+var etaBaseUncertainty = map[string]float64{
+	ETASourceCPDLC:    3,
+	ETASourcePosition: 4,
+	ETASourceMovement: 8,
+}
+
+// The uncertainty of an ETA grows by etaHorizonGrowth of the time from its
+// report to the arrival it estimates, and by etaAgeGrowth of the time between
+// its report and the flight's latest.
+const (
+	etaHorizonGrowth = 0.05
+	etaAgeGrowth     = 0.1
+)
+
+// An ETA may be up to etaBefore before the message that reports it, as a late
+// report's is, and otherwise is taken as the next such time of day. Reports
+// more than etaStale older than a flight's latest are left out of its fused
+// ETA, as from an earlier flight under the same key.
+const (
+	etaBefore = 2 * time.Hour
+	etaStale  = 12 * time.Hour
+)
+
+// ETAReports returns the ETAs at the destination a message's parse results
+// report, at the message time, keyed by flight, with one report of each kind
+// of source. Label 44 reports after landing are left out, and the ETA at a
+// CPDLC position report's next fix (dM48) is only taken when the fix is an
+// airport the airport table knows.
+func ETAReports(key string, ts time.Time, messageID int64, results []registry.Result) []storage.ETAReport {
+	var out []storage.ETAReport
+	add := func(source, hhmm, dest string) {
+		eta, ok := ArrivalTime(hhmm, ts)
+		if !ok {
+			return
+		}
+		if len(dest) == 3 {
+			dest = airport.ResolveIATA(dest)
+		}
+		for _, seen := range out {
+			if seen.Source == source {
+				return
+			}
+		}
+		out = append(out, storage.ETAReport{
+			FlightKey: key, Source: source, ETA: eta, ReportedAt: ts, Destination: dest, MessageID: messageID,
+		})
+	}
+
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+
+		if source, ok := etaResultSources[r.Type()]; ok {
+			if kind, _ := m["message_type"].(string); r.Type() == "label44" && (kind == "on" || kind == "in") {
+				continue
+			}
+			eta, _ := m["eta"].(string)
+			dest, _ := m["destination"].(string)
+			if dest == "" {
+				dest, _ = m["dest_icao"].(string)
+			}
+			add(source, eta, dest)
+			continue
+		}
+
+		elements, _ := m["elements"].([]interface{})
+		for _, e := range elements {
+			em, _ := e.(map[string]interface{})
+			if id, _ := em["id"].(float64); int(id) != cpdlcPositionReport || m["direction"] != "downlink" {
+				continue
+			}
+			data, _ := em["data"].(map[string]interface{})
+			fix, _ := data["fix_next"].(map[string]interface{})
+			eta, _ := data["fix_next_eta"].(map[string]interface{})
+			name, _ := fix["name"].(string)
+			if eta == nil || !isKnownAirport(name) {
+				continue
+			}
+			h, _ := eta["hours"].(float64)
+			min, _ := eta["minutes"].(float64)
+			add(ETASourceCPDLC, strconv.Itoa(int(h)*100+int(min)), name)
+		}
+	}
+	return out
+}
+
+// isKnownAirport reports whether the default airport table has an airport
+// with the ICAO code.
+func isKnownAirport(icao string) bool {
+	t := airport.Default()
+	if t == nil || len(icao) != 4 {
+		return false
+	}
+	_, ok := t.ByICAO(icao)
+	return ok
+}
+
+// ArrivalTime returns the instant of an ETA given as an HHMM time of day
+// ("2330", "23:30" or "23.30"), or HHMMSS, reported at ts: the first such time
+// after etaBefore before ts. It returns false for an empty or invalid time.
+func ArrivalTime(hhmm string, ts time.Time) (time.Time, bool) {
+	hhmm = strings.NewReplacer(":", "", ".", "").Replace(strings.TrimSpace(hhmm))
+	if len(hhmm) == 6 {
+		hhmm = hhmm[:4]
+	}
+	if len(hhmm) != 4 || ts.IsZero() {
+		return time.Time{}, false
+	}
+	h, err1 := strconv.Atoi(hhmm[:2])
+	m, err2 := strconv.Atoi(hhmm[2:])
+	if err1 != nil || err2 != nil || h > 23 || m > 59 {
+		return time.Time{}, false
+	}
+
+	ts = ts.UTC()
+	eta := time.Date(ts.Year(), ts.Month(), ts.Day(), h, m, 0, 0, time.UTC)
+	if eta.Before(ts.Add(-etaBefore)) {
+		eta = eta.AddDate(0, 0, 1)
+	} else if !eta.Before(ts.Add(24*time.Hour - etaBefore)) {
+		eta = eta.AddDate(0, 0, -1)
+	}
+	return eta, true
+}
+
+// FusedETA is a flight's ETA estimated from the reports of every source.
+type FusedETA struct {
+	ETA time.Time
+	// Uncertainty is one standard deviation of the estimate, to the minute.
+	Uncertainty time.Duration
+	Sources     []string // Sources the estimate was made from, most weight first.
+}
+
+// FuseETAs estimates a flight's ETA from the latest report of each source.
+// Each report is weighted by the inverse square of its uncertainty: that of
+// its kind of source, growing with how far ahead it looked and how much older
+// it is than the latest report. Reports for a destination other than the
+// latest one given, stale reports (see etaStale) and reports of an arrival
+// more than etaBefore before the latest report are left out. The uncertainty
+// of the estimate includes the spread of the reports, so that sources which
+// disagree give a less certain ETA. It returns false when no report is left.
+func FuseETAs(reports []storage.ETAReport) (FusedETA, bool) {
+	var latest, destAt time.Time
+	var dest string
+	for _, r := range reports {
+		if r.ReportedAt.After(latest) {
+			latest = r.ReportedAt
+		}
+		if r.Destination != "" && r.ReportedAt.After(destAt) {
+			dest, destAt = r.Destination, r.ReportedAt
+		}
+	}
+
+	type weighted struct {
+		source string
+		offset float64 // Minutes from latest.
+		weight float64
+	}
+	var used []weighted
+	var total float64
+	for _, r := range reports {
+		age := latest.Sub(r.ReportedAt)
+		if age > etaStale || r.ETA.Before(latest.Add(-etaBefore)) || (r.Destination != "" && r.Destination != dest) {
+			continue
+		}
+		base, ok := etaBaseUncertainty[r.Source]
+		if !ok {
+			continue
+		}
+		sigma := base + etaHorizonGrowth*math.Max(0, r.ETA.Sub(r.ReportedAt).Minutes()) + etaAgeGrowth*age.Minutes()
+		w := 1 / (sigma * sigma)
+		used = append(used, weighted{r.Source, r.ETA.Sub(latest).Minutes(), w})
+		total += w
+	}
+	if len(used) == 0 {
+		return FusedETA{}, false
+	}
+
+	var mean float64
+	for _, u := range used {
+		mean += u.weight * u.offset
+	}
+	mean /= total
+	variance := 1 / total
+	for _, u := range used {
+		variance += u.weight * (u.offset - mean) * (u.offset - mean) / total
+	}
+
+	sort.SliceStable(used, func(i, j int) bool { return used[i].weight > used[j].weight })
+	fused := FusedETA{
+		ETA:         latest.Add(time.Duration(mean * float64(time.Minute))).Round(time.Minute),
+		Uncertainty: time.Duration(math.Max(1, math.Round(math.Sqrt(variance)))) * time.Minute,
+	}
+	for _, u := range used {
+		fused.Sources = append(fused.Sources, u.source)
+	}
+	return fused, true
+}
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"acars_parser/internal/airport"
+	"acars_parser/internal/parsers/eta"
+	"acars_parser/internal/parsers/label44"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+func TestArrivalTime(t *testing.T) {
+	ts := time.Date(2026, 1, 24, 23, 10, 0, 0, time.UTC)
+	tests := []struct {
+		eta  string
+		want time.Time
+		ok   bool
+	}{
+		{"2330", time.Date(2026, 1, 24, 23, 30, 0, 0, time.UTC), true},
+		{"01:15", time.Date(2026, 1, 25, 1, 15, 0, 0, time.UTC), true},   // After midnight.
+		{"2200", time.Date(2026, 1, 24, 22, 0, 0, 0, time.UTC), true},    // A late report.
+		{"203000", time.Date(2026, 1, 25, 20, 30, 0, 0, time.UTC), true}, // A long flight.
+		{"2460", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ArrivalTime(tt.eta, ts)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ArrivalTime(%q) = %v, %v, want %v, %v", tt.eta, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestETAReports(t *testing.T) {
+	tbl := airport.NewTable()
+	if err := tbl.Add(airport.Airport{ICAO: "YMML", IATA: "MEL"}); err != nil {
+		t.Fatal(err)
+	}
+	airport.SetDefault(tbl)
+	t.Cleanup(func() { airport.SetDefault(nil) })
+
+	ts := time.Date(2026, 1, 24, 22, 0, 0, 0, time.UTC)
+	var cpdlc mapResult
+	_ = json.Unmarshal([]byte(`{"direction": "downlink", "elements": [
+		{"id": 48, "data": {"fix_next": {"type": "fix", "name": "YMML"}, "fix_next_eta": {"hours": 23, "minutes": 28}}}
+	]}`), &cpdlc)
+	results := []registry.Result{
+		&label44.Result{MessageType: "eta", Destination: "YMML", ETA: "2330"},
+		&label44.Result{MessageType: "pos", Destination: "YMML", ETA: "2331"}, // A second position report.
+		&eta.Result{Destination: "MEL", ETA: "2335"},
+		cpdlc,
+	}
+	got := ETAReports("VHOQA/QFA1", ts, 42, results)
+	if len(got) != 3 {
+		t.Fatalf("ETAReports() = %+v", got)
+	}
+	want := []struct {
+		source string
+		eta    time.Time
+	}{
+		{ETASourcePosition, time.Date(2026, 1, 24, 23, 30, 0, 0, time.UTC)},
+		{ETASourceMovement, time.Date(2026, 1, 24, 23, 35, 0, 0, time.UTC)},
+		{ETASourceCPDLC, time.Date(2026, 1, 24, 23, 28, 0, 0, time.UTC)},
+	}
+	for i, w := range want {
+		r := got[i]
+		if r.Source != w.source || !r.ETA.Equal(w.eta) || r.Destination != "YMML" || r.FlightKey != "VHOQA/QFA1" ||
+			r.MessageID != 42 || !r.ReportedAt.Equal(ts) {
+			t.Errorf("report %d = %+v, want %s at %v", i, r, w.source, w.eta)
+		}
+	}
+
+	// Reports after landing, and CPDLC fixes that are not airports, give none.
+	cpdlc["elements"].([]interface{})[0].(map[string]interface{})["data"].(map[string]interface{})["fix_next"] =
+		map[string]interface{}{"type": "fix", "name": "ARBEY"}
+	if got := ETAReports("VHOQA/QFA1", ts, 1, []registry.Result{&label44.Result{MessageType: "on", ETA: "2330"}, cpdlc}); len(got) != 0 {
+		t.Errorf("ETAReports() = %+v, want none", got)
+	}
+}
+
+func TestFuseETAs(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 24, h, m, 0, 0, time.UTC) }
+
+	// A single report gives its own ETA.
+	fused, ok := FuseETAs([]storage.ETAReport{{Source: ETASourcePosition, ETA: at(23, 30), ReportedAt: at(22, 0)}})
+	if !ok || !fused.ETA.Equal(at(23, 30)) || fused.Uncertainty != 9*time.Minute || len(fused.Sources) != 1 {
+		t.Errorf("single = %+v, %v", fused, ok)
+	}
+
+	// The fused ETA lies between the reports, nearest the most certain.
+	fused, ok = FuseETAs([]storage.ETAReport{
+		{Source: ETASourceCPDLC, ETA: at(23, 28), ReportedAt: at(23, 0)},
+		{Source: ETASourceMovement, ETA: at(23, 40), ReportedAt: at(22, 0)},
+	})
+	if !ok || fused.ETA.Before(at(23, 28)) || !fused.ETA.Before(at(23, 34)) {
+		t.Errorf("fused ETA = %v", fused.ETA)
+	}
+	if len(fused.Sources) != 2 || fused.Sources[0] != ETASourceCPDLC {
+		t.Errorf("sources = %v", fused.Sources)
+	}
+	if fused.Uncertainty < 4*time.Minute {
+		t.Errorf("uncertainty = %v, want the spread of the reports included", fused.Uncertainty)
+	}
+
+	// Stale reports and those for an earlier destination are left out.
+	fused, ok = FuseETAs([]storage.ETAReport{
+		{Source: ETASourcePosition, ETA: at(23, 30), ReportedAt: at(23, 0), Destination: "YMAV"},
+		{Source: ETASourceMovement, ETA: at(23, 0), ReportedAt: at(22, 0), Destination: "YMML"},
+		{Source: ETASourceCPDLC, ETA: at(8, 0), ReportedAt: at(6, 0)},
+	})
+	if !ok || !fused.ETA.Equal(at(23, 30)) || len(fused.Sources) != 1 {
+		t.Errorf("diverted = %+v, %v", fused, ok)
+	}
+
+	if _, ok := FuseETAs(nil); ok {
+		t.Error("FuseETAs(nil) reported an ETA")
+	}
+}
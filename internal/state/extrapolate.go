@@ -0,0 +1,135 @@
+package state
+
+import (
+	"math"
+	"time"
+)
+
+// How the velocity a position is extrapolated with was found.
+const (
+	VelocityReported = "reported" // Given with the latest position.
+	VelocityDerived  = "derived"  // From the latest two positions.
+	VelocityNone     = "none"     // Unknown: the position is not moved.
+)
+
+// Positions are extrapolated for up to maxExtrapolation after they were
+// reported, and velocity derived from two positions no more than
+// maxDerivationGap apart.
+const (
+	maxExtrapolation = 2 * time.Hour
+	maxDerivationGap = 90 * time.Minute
+)
+
+// The uncertainty of an estimate is positionErrorNM, that of the position
+// itself, and a share of the distance flown since: reportedDrift for a
+// reported velocity, derivedDrift for a derived one. Without a velocity, the
+// aircraft may have flown anywhere within unknownSpeedKnots of it.
+const (
+	positionErrorNM   = 1
+	reportedDrift     = 0.05
+	derivedDrift      = 0.15
+	unknownSpeedKnots = 480
+)
+
+// PositionEstimate is a flight's position dead-reckoned from its latest
+// report.
+type PositionEstimate struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	Altitude  int        // Feet, as last reported; 0 if unknown.
+	From      TrackPoint // Position extrapolated from.
+	// Age is the time from the position extrapolated from to the estimate.
+	Age time.Duration
+	// GroundSpeed (knots) and Track (degrees true) are the velocity
+	// extrapolated with, found as VelocitySource says.
+	GroundSpeed    int
+	Track          int
+	VelocitySource string
+	// UncertaintyNM is the radius, in nautical miles, the aircraft is
+	// expected to be within.
+	UncertaintyNM float64
+}
+
+// Extrapolate estimates where a flight is at a time from its track: the
+// latest position moved along a great circle by its ground speed and track.
+// The velocity reported with the position is used, or else that between it
+// and the position before, if no more than maxDerivationGap earlier. The
+// position is moved for at most maxExtrapolation, and not at all for a time
+// before it. It returns false for an empty track.
+func Extrapolate(track []TrackPoint, at time.Time) (PositionEstimate, bool) {
+	if len(track) == 0 {
+		return PositionEstimate{}, false
+	}
+	last := track[len(track)-1]
+	est := PositionEstimate{
+		Time:           at,
+		Latitude:       last.Latitude,
+		Longitude:      last.Longitude,
+		Altitude:       last.Altitude,
+		From:           last,
+		Age:            at.Sub(last.Time),
+		VelocitySource: VelocityNone,
+	}
+	if est.Age < 0 {
+		est.Age = 0
+	}
+
+	drift := 0.0
+	switch {
+	case last.GroundSpeed > 0:
+		est.GroundSpeed, est.Track, est.VelocitySource = last.GroundSpeed, last.Track, VelocityReported
+		drift = reportedDrift
+	case len(track) >= 2:
+		prev := track[len(track)-2]
+		gap := last.Time.Sub(prev.Time)
+		dist := GreatCircleNM(prev.Latitude, prev.Longitude, last.Latitude, last.Longitude)
+		if gap > 0 && gap <= maxDerivationGap && dist > 0 {
+			est.GroundSpeed = int(math.Round(dist / gap.Hours()))
+			est.Track = int(math.Round(finalBearing(prev.Latitude, prev.Longitude, last.Latitude, last.Longitude))) % 360
+			est.VelocitySource = VelocityDerived
+			drift = derivedDrift
+		}
+	}
+
+	moved := est.Age
+	if moved > maxExtrapolation {
+		moved = maxExtrapolation
+	}
+	if est.VelocitySource == VelocityNone {
+		est.UncertaintyNM = positionErrorNM + unknownSpeedKnots*est.Age.Hours()
+		return est, true
+	}
+	dist := float64(est.GroundSpeed) * moved.Hours()
+	est.Latitude, est.Longitude = destinationPoint(last.Latitude, last.Longitude, float64(est.Track), dist)
+	est.UncertaintyNM = positionErrorNM + drift*dist + unknownSpeedKnots*(est.Age-moved).Hours()
+	return est, true
+}
+
+// destinationPoint returns the point reached from a point by travelling a
+// distance (nautical miles) along a great circle with an initial bearing
+// (degrees true).
+func destinationPoint(lat, lon, bearing, distNM float64) (float64, float64) {
+	rlat, rlon, rb := lat*math.Pi/180, lon*math.Pi/180, bearing*math.Pi/180
+	d := distNM / earthRadiusNM
+	lat2 := math.Asin(math.Sin(rlat)*math.Cos(d) + math.Cos(rlat)*math.Sin(d)*math.Cos(rb))
+	lon2 := rlon + math.Atan2(math.Sin(rb)*math.Sin(d)*math.Cos(rlat), math.Cos(d)-math.Sin(rlat)*math.Sin(lat2))
+	lon2 = math.Mod(lon2*180/math.Pi+540, 360) - 180
+	return roundCoord(lat2 * 180 / math.Pi), roundCoord(lon2)
+}
+
+// finalBearing returns the bearing (degrees true, 0 to 360) at the second of
+// two points of the great circle from the first.
+func finalBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	return math.Mod(initialBearing(lat2, lon2, lat1, lon1)+180, 360)
+}
+
+// initialBearing returns the bearing (degrees true, 0 to 360) from the first
+// point along the great circle to the second.
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	rlat1, rlat2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(rlat2)
+	x := math.Cos(rlat1)*math.Sin(rlat2) - math.Sin(rlat1)*math.Cos(rlat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
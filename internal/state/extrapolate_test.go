@@ -0,0 +1,69 @@
+package state
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMapVelocity(t *testing.T) {
+	tests := []struct {
+		m           map[string]interface{}
+		wantGS      int
+		wantTrack   int
+		description string
+	}{
+		{map[string]interface{}{"ground_speed": 452.0, "track": 87.6}, 452, 88, "top-level fields"},
+		{map[string]interface{}{"earth_ref": map[string]interface{}{"ground_speed_kts": 480.4, "track_deg": 360.0}}, 480, 0, "ADS-C earth reference"},
+		{map[string]interface{}{"earth_ref": map[string]interface{}{"ground_speed_kts": 480.0, "track_deg": 90.0, "track_invalid": true}}, 0, 0, "invalid track"},
+		{map[string]interface{}{"ground_speed": 452.0}, 0, 0, "no track"},
+		{map[string]interface{}{"ground_speed": 2000.0, "track": 90.0}, 0, 0, "implausible speed"},
+	}
+	for _, tt := range tests {
+		if gs, trk := mapVelocity(tt.m); gs != tt.wantGS || trk != tt.wantTrack {
+			t.Errorf("%s: mapVelocity = %d, %d, want %d, %d", tt.description, gs, trk, tt.wantGS, tt.wantTrack)
+		}
+	}
+}
+
+func TestExtrapolate(t *testing.T) {
+	t0 := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+
+	// Due east along the equator at 480 kt for 30 minutes is 240 NM, 4 degrees.
+	est, ok := Extrapolate([]TrackPoint{{Time: t0, GroundSpeed: 480, Track: 90, Altitude: 37000}}, t0.Add(30*time.Minute))
+	if !ok || est.VelocitySource != VelocityReported || math.Abs(est.Latitude) > 1e-4 || math.Abs(est.Longitude-3.9983) > 1e-3 {
+		t.Errorf("reported = %+v", est)
+	}
+	if est.Age != 30*time.Minute || est.Altitude != 37000 || est.UncertaintyNM != 13 {
+		t.Errorf("reported age/altitude/uncertainty = %v, %d, %v", est.Age, est.Altitude, est.UncertaintyNM)
+	}
+
+	// Without a reported velocity, it is derived from the last two positions:
+	// one degree of latitude north in 10 minutes is 360 kt.
+	track := []TrackPoint{
+		{Time: t0, Latitude: -34, Longitude: 151},
+		{Time: t0.Add(10 * time.Minute), Latitude: -33, Longitude: 151},
+	}
+	est, _ = Extrapolate(track, t0.Add(20*time.Minute))
+	if est.VelocitySource != VelocityDerived || est.Track != 0 || est.GroundSpeed < 359 || est.GroundSpeed > 361 ||
+		math.Abs(est.Latitude+32) > 0.01 {
+		t.Errorf("derived = %+v", est)
+	}
+
+	// Positions too far apart give no velocity, and the position is not moved.
+	track[0].Time = t0.Add(-3 * time.Hour)
+	est, _ = Extrapolate(track, t0.Add(40*time.Minute))
+	if est.VelocitySource != VelocityNone || est.Latitude != -33 || est.UncertaintyNM != 1+480*0.5 {
+		t.Errorf("no velocity = %+v", est)
+	}
+
+	// Extrapolation stops after two hours, and the uncertainty keeps growing.
+	est, _ = Extrapolate([]TrackPoint{{Time: t0, GroundSpeed: 480, Track: 90}}, t0.Add(3*time.Hour))
+	if math.Abs(est.Longitude-15.99) > 0.02 || est.UncertaintyNM != 1+0.05*960+480 {
+		t.Errorf("capped = %+v", est)
+	}
+
+	if _, ok := Extrapolate(nil, t0); ok {
+		t.Error("Extrapolate(nil) gave an estimate")
+	}
+}
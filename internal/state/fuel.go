@@ -0,0 +1,77 @@
+package state
+
+import (
+	"encoding/json"
+
+	"acars_parser/internal/registry"
+)
+
+// Fuel is the fuel on board in kg at each OOOI event. Unreported events are
+// nil.
+type Fuel struct {
+	Out *int
+	Off *int
+	On  *int
+	In  *int
+}
+
+// FuelReadings returns the fuel on board reported by a message's results.
+// Only results carrying an OOOI event and a figure in kg (fuel_report) are
+// used; figures in an unknown unit cannot be compared across a flight.
+func FuelReadings(results []registry.Result) Fuel {
+	var f Fuel
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m struct {
+			Event string `json:"event"`
+			FOBKg int    `json:"fob_kg"`
+		}
+		if err := json.Unmarshal(b, &m); err != nil || m.FOBKg <= 0 {
+			continue
+		}
+		kg := m.FOBKg
+		switch m.Event {
+		case "OUT":
+			f.Out = &kg
+		case "OFF":
+			f.Off = &kg
+		case "ON":
+			f.On = &kg
+		case "IN":
+			f.In = &kg
+		}
+	}
+	return f
+}
+
+// FuelBurn is the fuel used over each part of a flight, in kg. A part is nil
+// unless the fuel at both its ends is known.
+type FuelBurn struct {
+	Block    *int // OUT to IN.
+	Airborne *int // OFF to ON.
+	TaxiOut  *int // OUT to OFF.
+	TaxiIn   *int // ON to IN.
+}
+
+// Burn returns the fuel used between the reported events.
+func (f Fuel) Burn() FuelBurn {
+	return FuelBurn{
+		Block:    burn(f.Out, f.In),
+		Airborne: burn(f.Off, f.On),
+		TaxiOut:  burn(f.Out, f.Off),
+		TaxiIn:   burn(f.On, f.In),
+	}
+}
+
+// burn returns the fuel used between two readings. An increase means fuel
+// was uplifted or a reading is wrong, so it is not reported as a burn.
+func burn(from, to *int) *int {
+	if from == nil || to == nil || *to > *from {
+		return nil
+	}
+	b := *from - *to
+	return &b
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"testing"
+
+	"acars_parser/internal/registry"
+)
+
+type fuelResult struct {
+	Event string `json:"event,omitempty"`
+	FOBKg int    `json:"fob_kg,omitempty"`
+}
+
+func (r *fuelResult) Type() string     { return "fuel_report" }
+func (r *fuelResult) MessageID() int64 { return 1 }
+
+func intPtr(v int) *int { return &v }
+
+func TestFuelReadings(t *testing.T) {
+	f := FuelReadings([]registry.Result{
+		&fuelResult{Event: "OUT", FOBKg: 12500},
+		&fuelResult{Event: "IN", FOBKg: 3200},
+		&fuelResult{Event: "OFF"},      // No figure in kg.
+		&fuelResult{FOBKg: 9000},       // No event.
+		&arrivalResult{OnTime: "0412"}, // Not a fuel result.
+	})
+	if f.Out == nil || *f.Out != 12500 || f.In == nil || *f.In != 3200 {
+		t.Errorf("FuelReadings() = %+v", f)
+	}
+	if f.Off != nil || f.On != nil {
+		t.Errorf("unexpected readings: off %v, on %v", f.Off, f.On)
+	}
+}
+
+func TestFuelBurn(t *testing.T) {
+	f := Fuel{Out: intPtr(12500), Off: intPtr(12200), On: intPtr(3500), In: intPtr(3300)}
+	b := f.Burn()
+	for name, tt := range map[string]struct {
+		got  *int
+		want int
+	}{
+		"block":    {b.Block, 9200},
+		"airborne": {b.Airborne, 8700},
+		"taxi out": {b.TaxiOut, 300},
+		"taxi in":  {b.TaxiIn, 200},
+	} {
+		if tt.got == nil || *tt.got != tt.want {
+			t.Errorf("%s burn = %v, want %d", name, tt.got, tt.want)
+		}
+	}
+
+	// Missing readings and increases give no burn.
+	b = Fuel{Out: intPtr(3000), In: intPtr(9000), On: intPtr(2000)}.Burn()
+	if b.Block != nil || b.Airborne != nil || b.TaxiOut != nil || b.TaxiIn != nil {
+		t.Errorf("Burn() = %+v, want all nil", b)
+	}
+}
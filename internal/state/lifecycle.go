@@ -0,0 +1,88 @@
+package state
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"acars_parser/internal/extractor"
+	"acars_parser/internal/registry"
+)
+
+// Completion reasons recorded in flight_state and flight_history.
+const (
+	CompletionArrived  = "arrived"  // An ON or IN event was received.
+	CompletionInactive = "inactive" // No message within the inactivity timeout.
+)
+
+// Lifecycle controls when flights in flight_state are complete and archived.
+type Lifecycle struct {
+	// Inactivity is how long a flight may go without a message before it is
+	// archived as inactive. Oceanic flights can be silent for hours.
+	Inactivity time.Duration
+	// Grace is how long an arrived flight stays current, so that the IN event
+	// and post-arrival messages update it before it is archived. A message
+	// for the same flight after the grace period starts a new flight.
+	Grace time.Duration
+}
+
+// DefaultLifecycle returns the lifecycle used unless SetLifecycle is called.
+func DefaultLifecycle() Lifecycle {
+	return Lifecycle{Inactivity: 6 * time.Hour, Grace: 30 * time.Minute}
+}
+
+// Cutoffs returns the times before which, at now, arrived flights and
+// inactive flights are archived.
+func (l Lifecycle) Cutoffs(now time.Time) (completedBefore, inactiveBefore time.Time) {
+	return now.Add(-l.Grace), now.Add(-l.Inactivity)
+}
+
+// Reopens reports whether a message at ts for a flight that completed at
+// completedAt belongs to a new flight.
+func (l Lifecycle) Reopens(completedAt, ts time.Time) bool {
+	return ts.Sub(completedAt) > l.Grace
+}
+
+// OOOI labels for the ON (touchdown) and IN (on blocks) reports.
+var arrivalLabels = map[string]bool{"QR": true, "QS": true}
+
+// IsArrival reports whether a message records the flight's arrival: an ON
+// or IN OOOI report label, or a parse result carrying an ON or IN time.
+func IsArrival(label string, results []registry.Result) bool {
+	if arrivalLabels[label] {
+		return true
+	}
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+		for _, field := range []string{"on_time", "in_time"} {
+			if v, _ := m[field].(string); strings.TrimSpace(v) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FlightKey returns the flight_state key of a flight: the aircraft
+// (registration, or ICAO hex when the registration is unknown) and the flight
+// number. It returns "" if the flight number or the aircraft is unknown.
+func FlightKey(f *extractor.FlightUpdate) string {
+	if f == nil || f.FlightNumber == "" {
+		return ""
+	}
+	aircraft := strings.ToUpper(strings.ReplaceAll(strings.TrimLeft(f.Registration, "."), "-", ""))
+	if aircraft == "" {
+		aircraft = strings.ToUpper(f.ICAOHex)
+	}
+	if aircraft == "" {
+		return ""
+	}
+	return aircraft + "/" + f.FlightNumber
+}
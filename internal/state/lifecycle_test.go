@@ -0,0 +1,85 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/extractor"
+	"acars_parser/internal/registry"
+)
+
+type arrivalResult struct {
+	OnTime string `json:"on_time,omitempty"`
+	InTime string `json:"in_time,omitempty"`
+}
+
+func (r *arrivalResult) Type() string     { return "position" }
+func (r *arrivalResult) MessageID() int64 { return 1 }
+
+func TestIsArrival(t *testing.T) {
+	tests := []struct {
+		name    string
+		label   string
+		results []registry.Result
+		want    bool
+	}{
+		{"ON report label", "QR", nil, true},
+		{"IN report label", "QS", nil, true},
+		{"OFF report label", "QQ", nil, false},
+		{"result with ON time", "80", []registry.Result{&arrivalResult{OnTime: "0412"}}, true},
+		{"result with IN time", "80", []registry.Result{&arrivalResult{InTime: "0420"}}, true},
+		{"result without times", "80", []registry.Result{&arrivalResult{}}, false},
+		{"blank time", "80", []registry.Result{&arrivalResult{OnTime: " "}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsArrival(tt.label, tt.results); got != tt.want {
+				t.Errorf("IsArrival() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlightKey(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *extractor.FlightUpdate
+		want string
+	}{
+		{"registration", &extractor.FlightUpdate{Registration: ".VH-OQA", ICAOHex: "7c4ee8", FlightNumber: "QFA1"}, "VHOQA/QFA1"},
+		{"ICAO hex only", &extractor.FlightUpdate{ICAOHex: "7c4ee8", FlightNumber: "QFA1"}, "7C4EE8/QFA1"},
+		{"no flight number", &extractor.FlightUpdate{Registration: "VH-OQA"}, ""},
+		{"no aircraft", &extractor.FlightUpdate{FlightNumber: "QFA1"}, ""},
+		{"nil", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FlightKey(tt.f); got != tt.want {
+				t.Errorf("FlightKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	l := DefaultLifecycle()
+	now := time.Date(2026, 1, 24, 12, 0, 0, 0, time.UTC)
+
+	completedBefore, inactiveBefore := l.Cutoffs(now)
+	if want := now.Add(-30 * time.Minute); !completedBefore.Equal(want) {
+		t.Errorf("completedBefore = %v, want %v", completedBefore, want)
+	}
+	if want := now.Add(-6 * time.Hour); !inactiveBefore.Equal(want) {
+		t.Errorf("inactiveBefore = %v, want %v", inactiveBefore, want)
+	}
+
+	arrived := now.Add(-time.Hour)
+	if l.Reopens(arrived, arrived.Add(10*time.Minute)) {
+		t.Error("message within the grace period should update the arrived flight")
+	}
+	if !l.Reopens(arrived, arrived.Add(time.Hour)) {
+		t.Error("message after the grace period should start a new flight")
+	}
+}
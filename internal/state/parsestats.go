@@ -0,0 +1,71 @@
+package state
+
+import (
+	"sort"
+	"time"
+
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// ParseCounter accumulates parse statistics per day (of message time) and
+// label, for storage with PostgresDB.SaveParseStats. Its counts are totals
+// since it was created, so saving them again replaces the earlier save.
+// It is not safe for concurrent use.
+type ParseCounter struct {
+	stats map[parseStatsKey]*storage.ParseStats
+}
+
+type parseStatsKey struct {
+	day   time.Time
+	label string
+}
+
+// NewParseCounter returns an empty ParseCounter.
+func NewParseCounter() *ParseCounter {
+	return &ParseCounter{stats: make(map[parseStatsKey]*storage.ParseStats)}
+}
+
+// Count records a message received at ts with its attributed parse results.
+func (c *ParseCounter) Count(ts time.Time, label string, results []registry.Attributed) {
+	key := parseStatsKey{day: ts.UTC().Truncate(24 * time.Hour), label: label}
+	s := c.stats[key]
+	if s == nil {
+		s = &storage.ParseStats{Day: key.day, Label: label, Matches: make(map[string]int64)}
+		c.stats[key] = s
+	}
+
+	s.Total++
+	if len(results) > 0 {
+		s.Parsed++
+	}
+	seen := make(map[string]bool, len(results))
+	for _, r := range results {
+		// A parser counts once per message, however many results it gave.
+		if !seen[r.Parser] {
+			seen[r.Parser] = true
+			s.Matches[r.Parser]++
+		}
+	}
+}
+
+// Stats returns the statistics counted so far, ordered by day and label.
+func (c *ParseCounter) Stats() []storage.ParseStats {
+	stats := make([]storage.ParseStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		matches := make(map[string]int64, len(s.Matches))
+		for p, n := range s.Matches {
+			matches[p] = n
+		}
+		cp := *s
+		cp.Matches = matches
+		stats = append(stats, cp)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].Day.Equal(stats[j].Day) {
+			return stats[i].Day.Before(stats[j].Day)
+		}
+		return stats[i].Label < stats[j].Label
+	})
+	return stats
+}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/registry"
+)
+
+func TestParseCounter(t *testing.T) {
+	c := NewParseCounter()
+	day1 := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(20 * time.Hour)
+
+	c.Count(day1, "44", []registry.Attributed{{Parser: "pos44"}})
+	c.Count(day1.Add(time.Hour), "44", nil)
+	c.Count(day1, "H1", []registry.Attributed{{Parser: "h1_fpn"}, {Parser: "h1_fpn"}, {Parser: "route"}})
+	c.Count(day2, "44", []registry.Attributed{{Parser: "pos44"}})
+
+	stats := c.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("got %d stats, want 3: %+v", len(stats), stats)
+	}
+
+	s := stats[0]
+	if s.Label != "44" || !s.Day.Equal(day1.Truncate(24*time.Hour)) || s.Total != 2 || s.Parsed != 1 || s.Matches["pos44"] != 1 {
+		t.Errorf("stats[0] = %+v", s)
+	}
+	if s.Coverage() != 0.5 {
+		t.Errorf("Coverage() = %v, want 0.5", s.Coverage())
+	}
+
+	s = stats[1]
+	if s.Label != "H1" || s.Total != 1 || s.Parsed != 1 || s.Matches["h1_fpn"] != 1 || s.Matches["route"] != 1 {
+		t.Errorf("stats[1] = %+v", s)
+	}
+
+	s = stats[2]
+	if s.Label != "44" || !s.Day.Equal(time.Date(2026, 1, 25, 0, 0, 0, 0, time.UTC)) || s.Total != 1 {
+		t.Errorf("stats[2] = %+v", s)
+	}
+
+	// Stats are copies, and counting continues from the totals.
+	stats[0].Matches["pos44"] = 99
+	c.Count(day1, "44", []registry.Attributed{{Parser: "pos44"}})
+	if s := c.Stats()[0]; s.Total != 3 || s.Matches["pos44"] != 2 {
+		t.Errorf("after another message, stats[0] = %+v", s)
+	}
+}
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// earthRadiusNM is the mean Earth radius in nautical miles.
+const earthRadiusNM = 3440.065
+
+// MaxGroundSpeedKnots is the highest ground speed accepted between two
+// positions of a flight: Mach 1.2 at the sea-level speed of sound (661.5 kt).
+// Airliners cruise well below this even with a strong jet stream tailwind, so
+// faster movement comes from a decoding error such as a sign flip or a
+// misaligned bitstream.
+const MaxGroundSpeedKnots = 1.2 * 661.5
+
+// positionSlackNM is the distance allowed on top of MaxGroundSpeedKnots, to
+// absorb rounding, and reports stamped with the receive time rather than the
+// time of the fix.
+const positionSlackNM = 10
+
+// GreatCircleNM returns the great-circle distance between two points in
+// nautical miles.
+func GreatCircleNM(lat1, lon1, lat2, lon2 float64) float64 {
+	rlat1, rlat2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := rlat2 - rlat1
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rlat1)*math.Cos(rlat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusNM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// implausible returns why p cannot follow prev, or "" if it can.
+func implausible(prev, p TrackPoint) string {
+	dist := GreatCircleNM(prev.Latitude, prev.Longitude, p.Latitude, p.Longitude)
+	elapsed := p.Time.Sub(prev.Time)
+	if elapsed < 0 {
+		elapsed = -elapsed
+	}
+	if dist <= MaxGroundSpeedKnots*elapsed.Hours()+positionSlackNM {
+		return ""
+	}
+	if elapsed == 0 {
+		return fmt.Sprintf("%.0f NM from the previous position (%s) at the same time", dist, prev.Source)
+	}
+	return fmt.Sprintf("implied ground speed %.0f kt over %.0f NM in %s from the previous position (%s) exceeds %.0f kt",
+		dist/elapsed.Hours(), dist, elapsed.Round(time.Second), prev.Source, MaxGroundSpeedKnots)
+}
+
+// CheckPosition returns the reason a new position of a flight is rejected, or
+// "" if it is plausible. The position is compared against the last accepted
+// position (nil for the first position of the flight). When the last accepted
+// position is the outlier, later positions agree with each other instead, so a
+// position that fails against the last accepted one but is plausible after
+// the most recently rejected one (nil if none since the last accepted
+// position) is accepted.
+func CheckPosition(lastAccepted, lastRejected *TrackPoint, p TrackPoint) string {
+	if lastAccepted == nil {
+		return ""
+	}
+	reason := implausible(*lastAccepted, p)
+	if reason != "" && lastRejected != nil && implausible(*lastRejected, p) == "" {
+		return ""
+	}
+	return reason
+}
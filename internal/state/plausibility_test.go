@@ -0,0 +1,70 @@
+package state
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGreatCircleNM(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", -33.946, 151.177, -33.946, 151.177, 0},
+		{"one degree of latitude", 0, 0, 1, 0, 60.04},
+		{"Sydney to Melbourne", -33.946, 151.177, -37.673, 144.843, 380.9},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 60.04},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GreatCircleNM(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > 0.5 {
+				t.Errorf("GreatCircleNM() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPosition(t *testing.T) {
+	t0 := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	prev := TrackPoint{Time: t0, Latitude: -33.9, Longitude: 151.2, Source: "adsc"}
+
+	// 480 kt for ten minutes is 80 NM.
+	if reason := CheckPosition(&prev, nil, TrackPoint{Time: t0.Add(10 * time.Minute), Latitude: -32.6, Longitude: 151.2}); reason != "" {
+		t.Errorf("plausible position rejected: %s", reason)
+	}
+	if reason := CheckPosition(nil, nil, TrackPoint{Time: t0, Latitude: 33.9, Longitude: -151.2}); reason != "" {
+		t.Errorf("first position rejected: %s", reason)
+	}
+
+	// A latitude sign error puts the aircraft 4000 NM away ten minutes later.
+	flipped := TrackPoint{Time: t0.Add(10 * time.Minute), Latitude: 33.9, Longitude: 151.2, Source: "adsc"}
+	reason := CheckPosition(&prev, nil, flipped)
+	if !strings.Contains(reason, "implied ground speed") || !strings.Contains(reason, "(adsc)") {
+		t.Errorf("sign error reason = %q", reason)
+	}
+
+	// Positions reported at the same time from different parsers must agree.
+	if reason := CheckPosition(&prev, nil, TrackPoint{Time: t0, Latitude: -33.95, Longitude: 151.25}); reason != "" {
+		t.Errorf("nearby simultaneous position rejected: %s", reason)
+	}
+	if reason := CheckPosition(&prev, nil, TrackPoint{Time: t0, Latitude: -20, Longitude: 151.2}); !strings.Contains(reason, "same time") {
+		t.Errorf("distant simultaneous position reason = %q", reason)
+	}
+
+	// When the accepted position was the outlier, positions that agree with
+	// the last rejected one are accepted.
+	bad := TrackPoint{Time: t0, Latitude: 33.9, Longitude: 151.2}
+	good := TrackPoint{Time: t0.Add(5 * time.Minute), Latitude: -33.9, Longitude: 151.3}
+	next := TrackPoint{Time: t0.Add(10 * time.Minute), Latitude: -33.8, Longitude: 151.4}
+	if CheckPosition(&bad, nil, good) == "" {
+		t.Fatal("position after an outlier should fail against the outlier")
+	}
+	if reason := CheckPosition(&bad, &good, next); reason != "" {
+		t.Errorf("position agreeing with the rejected one was rejected: %s", reason)
+	}
+}
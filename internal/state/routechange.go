@@ -0,0 +1,98 @@
+package state
+
+import (
+	"acars_parser/internal/parsers/h1"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// FlightPlanOf returns the flight plan a message's FPN result gives, or nil
+// if it has none. Results wrapped by quality.Annotate are unwrapped. Truncated
+// plans are ignored, as the waypoints they lack would read as removed.
+func FlightPlanOf(results []registry.Result) *storage.FlightPlan {
+	for _, r := range results {
+		if w, ok := r.(interface{ Unwrap() registry.Result }); ok {
+			r = w.Unwrap()
+		}
+		fpn, ok := r.(*h1.FPNResult)
+		if !ok || fpn.Truncated || len(fpn.Waypoints) == 0 {
+			continue
+		}
+		plan := &storage.FlightPlan{
+			Origin:              fpn.Origin,
+			Destination:         fpn.Destination,
+			Departure:           fpn.Departure,
+			DepartureTransition: fpn.DepartureTransition,
+			Arrival:             fpn.Arrival,
+			ArrivalTransition:   fpn.ArrivalTransition,
+			Approach:            fpn.Approach,
+			ApproachRunway:      fpn.ApproachRunway,
+		}
+		for _, wp := range fpn.Waypoints {
+			if wp.Name != "" {
+				plan.Waypoints = append(plan.Waypoints, wp.Name)
+			}
+		}
+		return plan
+	}
+	return nil
+}
+
+// DiffFlightPlans compares a flight's stored plan with a newer one. It returns
+// the plan to store, which keeps the old value of any airport or procedure the
+// new plan leaves out, and the change set: the waypoints added and removed,
+// in route order, and the airports and procedures given a new value. The
+// change set is nil when nothing changed.
+func DiffFlightPlans(old, updated storage.FlightPlan) (storage.FlightPlan, *storage.RouteChange) {
+	merged := updated
+	c := &storage.RouteChange{
+		AddedWaypoints:   missingFrom(old.Waypoints, updated.Waypoints),
+		RemovedWaypoints: missingFrom(updated.Waypoints, old.Waypoints),
+	}
+
+	fields := []struct {
+		name     string
+		old, new string
+		merged   *string
+	}{
+		{"origin", old.Origin, updated.Origin, &merged.Origin},
+		{"destination", old.Destination, updated.Destination, &merged.Destination},
+		{"departure", old.Departure, updated.Departure, &merged.Departure},
+		{"departure_transition", old.DepartureTransition, updated.DepartureTransition, &merged.DepartureTransition},
+		{"arrival", old.Arrival, updated.Arrival, &merged.Arrival},
+		{"arrival_transition", old.ArrivalTransition, updated.ArrivalTransition, &merged.ArrivalTransition},
+		{"approach", old.Approach, updated.Approach, &merged.Approach},
+		{"approach_runway", old.ApproachRunway, updated.ApproachRunway, &merged.ApproachRunway},
+	}
+	for _, f := range fields {
+		switch {
+		case f.new == "":
+			*f.merged = f.old
+		case f.new != f.old:
+			c.Fields = append(c.Fields, storage.PlanFieldChange{Field: f.name, Old: f.old, New: f.new})
+		}
+	}
+
+	if len(c.AddedWaypoints) == 0 && len(c.RemovedWaypoints) == 0 && len(c.Fields) == 0 {
+		return merged, nil
+	}
+	return merged, c
+}
+
+// missingFrom returns the waypoints of b that are not in a, in b's order. A
+// waypoint b lists more often than a counts once for each extra time.
+func missingFrom(a, b []string) []string {
+	have := make(map[string]int, len(a))
+	for _, wp := range a {
+		have[wp]++
+	}
+	var out []string
+	for _, wp := range b {
+		if have[wp] > 0 {
+			have[wp]--
+			continue
+		}
+		out = append(out, wp)
+	}
+	return out
+}
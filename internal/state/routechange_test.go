@@ -0,0 +1,73 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"acars_parser/internal/parsers/h1"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+func TestFlightPlanOf(t *testing.T) {
+	fpn := &h1.FPNResult{
+		Origin: "YSSY", Destination: "YMML", Arrival: "ARBEY4", Approach: "I16L",
+		Waypoints: []h1.RouteWaypoint{{Name: "WOL"}, {Name: ""}, {Name: "ARBEY"}},
+	}
+	plan := FlightPlanOf([]registry.Result{fpn})
+	if plan == nil || plan.Origin != "YSSY" || plan.Arrival != "ARBEY4" || !reflect.DeepEqual(plan.Waypoints, []string{"WOL", "ARBEY"}) {
+		t.Errorf("FlightPlanOf() = %+v", plan)
+	}
+
+	// The pipeline passes results wrapped with their quality report.
+	annotated := quality.Annotate([]registry.Result{fpn}, quality.Report{Score: 1})
+	if got := FlightPlanOf(annotated); !reflect.DeepEqual(got, plan) {
+		t.Errorf("FlightPlanOf(annotated) = %+v, want %+v", got, plan)
+	}
+
+	fpn.Truncated = true
+	if plan := FlightPlanOf([]registry.Result{fpn}); plan != nil {
+		t.Errorf("truncated plan gave %+v", plan)
+	}
+	if plan := FlightPlanOf([]registry.Result{&h1.FPNResult{Origin: "YSSY"}}); plan != nil {
+		t.Errorf("plan without waypoints gave %+v", plan)
+	}
+}
+
+func TestDiffFlightPlans(t *testing.T) {
+	old := storage.FlightPlan{
+		Origin: "YSSY", Destination: "YMML", Departure: "KEVIN7",
+		Waypoints: []string{"WOL", "NONUT", "ARBEY", "NONUT"},
+		Arrival:   "ARBEY4", Approach: "I16L", ApproachRunway: "16",
+	}
+
+	if _, c := DiffFlightPlans(old, old); c != nil {
+		t.Errorf("same plan gave %+v", c)
+	}
+
+	// A reroute onto a new arrival; the departure is left out of the new plan.
+	updated := storage.FlightPlan{
+		Origin: "YSSY", Destination: "YMML",
+		Waypoints: []string{"WOL", "NONUT", "LIZZI"},
+		Arrival:   "LIZZI8", Approach: "I34", ApproachRunway: "34",
+	}
+	merged, c := DiffFlightPlans(old, updated)
+	if c == nil {
+		t.Fatal("reroute gave no change")
+	}
+	if !reflect.DeepEqual(c.AddedWaypoints, []string{"LIZZI"}) || !reflect.DeepEqual(c.RemovedWaypoints, []string{"ARBEY", "NONUT"}) {
+		t.Errorf("waypoints added %v, removed %v", c.AddedWaypoints, c.RemovedWaypoints)
+	}
+	want := []storage.PlanFieldChange{
+		{Field: "arrival", Old: "ARBEY4", New: "LIZZI8"},
+		{Field: "approach", Old: "I16L", New: "I34"},
+		{Field: "approach_runway", Old: "16", New: "34"},
+	}
+	if !reflect.DeepEqual(c.Fields, want) {
+		t.Errorf("fields = %+v", c.Fields)
+	}
+	if merged.Departure != "KEVIN7" || merged.Arrival != "LIZZI8" || !reflect.DeepEqual(merged.Waypoints, updated.Waypoints) {
+		t.Errorf("merged = %+v", merged)
+	}
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/storage"
+)
+
+// runwayRe matches a runway designator: its magnetic heading in tens of
+// degrees and an optional L, R or C.
+var runwayRe = regexp.MustCompile(`^(0[1-9]|[12][0-9]|3[0-6])([LRC]?)$`)
+
+// minRunwayShare is the share of a window's arrivals a runway needs to be
+// counted as part of the configuration in use.
+const minRunwayShare = 0.15
+
+// ArrivalRunway returns the arrival runway a flight plan gives, from its
+// approach, in the form "16L". It reports false when the plan has none or
+// the approach is not to a runway, such as a circling "VOR-A".
+func ArrivalRunway(plan storage.FlightPlan) (string, bool) {
+	rwy := strings.ToUpper(strings.TrimSpace(plan.ApproachRunway))
+	rwy = strings.TrimPrefix(strings.TrimPrefix(rwy, "RWY"), "RW")
+	if len(rwy) == 1 || (len(rwy) == 2 && !strings.ContainsAny(rwy[1:], "0123456789")) {
+		rwy = "0" + rwy
+	}
+	if !runwayRe.MatchString(rwy) {
+		return "", false
+	}
+	return rwy, true
+}
+
+// RunwayConfig is the arrival runway configuration inferred for an airport.
+type RunwayConfig struct {
+	Runways []string // Runways in use, most used first.
+	Flights int      // Arrivals counted.
+	// Confidence is the share of the arrivals counted that were planned to
+	// the runways in use, from 0 to 1.
+	Confidence float64
+}
+
+// InferRunwayConfig infers the arrival runways in use from the arrivals
+// planned to each runway, most used first. A runway whose reciprocal had more
+// arrivals (or as many, less recently) is taken to be from before a change of
+// direction and left out, as are runways with under minRunwayShare of the
+// arrivals.
+func InferRunwayConfig(counts []storage.RunwayCount) RunwayConfig {
+	var cfg RunwayConfig
+	byRunway := make(map[string]storage.RunwayCount, len(counts))
+	for _, c := range counts {
+		cfg.Flights += c.Flights
+		byRunway[c.Runway] = c
+	}
+	if cfg.Flights == 0 {
+		return cfg
+	}
+
+	inUse := 0
+	for _, c := range counts {
+		if float64(c.Flights) < minRunwayShare*float64(cfg.Flights) {
+			continue
+		}
+		if r, ok := byRunway[reciprocal(c.Runway)]; ok &&
+			(r.Flights > c.Flights || (r.Flights == c.Flights && r.LastSeen.After(c.LastSeen))) {
+			continue
+		}
+		cfg.Runways = append(cfg.Runways, c.Runway)
+		inUse += c.Flights
+	}
+	cfg.Confidence = float64(inUse) / float64(cfg.Flights)
+	return cfg
+}
+
+// reciprocal returns the runway designator for the other end of a runway:
+// "34R" for "16L".
+func reciprocal(rwy string) string {
+	m := runwayRe.FindStringSubmatch(rwy)
+	if m == nil {
+		return ""
+	}
+	heading, _ := strconv.Atoi(m[1])
+	heading = (heading+17)%36 + 1
+	side := map[string]string{"L": "R", "R": "L", "C": "C", "": ""}[m[2]]
+	return strconv.Itoa(heading/10) + strconv.Itoa(heading%10) + side
+}
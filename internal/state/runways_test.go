@@ -0,0 +1,59 @@
+package state
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestArrivalRunway(t *testing.T) {
+	tests := map[string]string{"16L": "16L", "RW34": "34", "RWY07R": "07R", "7": "07", "7L": "07L"}
+	for in, want := range tests {
+		if got, ok := ArrivalRunway(storage.FlightPlan{ApproachRunway: in}); !ok || got != want {
+			t.Errorf("ArrivalRunway(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, bad := range []string{"", "A", "37", "00", "16X"} {
+		if got, ok := ArrivalRunway(storage.FlightPlan{ApproachRunway: bad}); ok {
+			t.Errorf("ArrivalRunway(%q) = %q, want none", bad, got)
+		}
+	}
+}
+
+func TestReciprocal(t *testing.T) {
+	for rwy, want := range map[string]string{"16L": "34R", "34R": "16L", "18": "36", "36": "18", "01C": "19C", "07": "25"} {
+		if got := reciprocal(rwy); got != want {
+			t.Errorf("reciprocal(%q) = %q, want %q", rwy, got, want)
+		}
+	}
+}
+
+func TestInferRunwayConfig(t *testing.T) {
+	now := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	counts := []storage.RunwayCount{
+		{Runway: "16R", Flights: 9, LastSeen: now},
+		{Runway: "16L", Flights: 7, LastSeen: now},
+		{Runway: "34L", Flights: 3, LastSeen: now.Add(-50 * time.Minute)}, // Before the change of direction.
+		{Runway: "07", Flights: 1, LastSeen: now},                         // Too few to count.
+	}
+	cfg := InferRunwayConfig(counts)
+	if !reflect.DeepEqual(cfg.Runways, []string{"16R", "16L"}) || cfg.Flights != 20 || math.Abs(cfg.Confidence-0.8) > 1e-9 {
+		t.Errorf("InferRunwayConfig() = %+v", cfg)
+	}
+
+	// With as many arrivals each way, the direction seen last wins.
+	cfg = InferRunwayConfig([]storage.RunwayCount{
+		{Runway: "16R", Flights: 4, LastSeen: now.Add(-time.Hour)},
+		{Runway: "34L", Flights: 4, LastSeen: now},
+	})
+	if !reflect.DeepEqual(cfg.Runways, []string{"34L"}) {
+		t.Errorf("tie = %+v", cfg)
+	}
+
+	if cfg := InferRunwayConfig(nil); cfg.Flights != 0 || cfg.Runways != nil || cfg.Confidence != 0 {
+		t.Errorf("no arrivals = %+v", cfg)
+	}
+}
@@ -0,0 +1,63 @@
+package state
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// cpdlcSquawk is the CPDLC uplink assigning a transponder code (uM123 SQUAWK
+// [beaconcode]).
+const cpdlcSquawk = 123
+
+// squawkRe matches a valid transponder code: four octal digits.
+var squawkRe = regexp.MustCompile(`^[0-7]{4}$`)
+
+// Squawks returns the transponder codes assigned to a flight by a message's
+// parse results, at the message time: the squawk of clearances (PDC) and
+// CPDLC SQUAWK uplinks. Codes that are not four octal digits are dropped.
+func Squawks(ts time.Time, messageID int64, results []registry.Result) []storage.SquawkAssignment {
+	var out []storage.SquawkAssignment
+	add := func(code, source string) {
+		if !squawkRe.MatchString(code) {
+			return
+		}
+		for _, seen := range out {
+			if seen.Squawk == code {
+				return
+			}
+		}
+		out = append(out, storage.SquawkAssignment{Timestamp: ts, Squawk: code, Source: source, MessageID: messageID})
+	}
+
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+
+		if v, _ := m["squawk"].(string); v != "" {
+			add(v, r.Type())
+		}
+
+		elements, _ := m["elements"].([]interface{})
+		for _, e := range elements {
+			em, _ := e.(map[string]interface{})
+			if id, _ := em["id"].(float64); int(id) != cpdlcSquawk || m["direction"] != "uplink" {
+				continue
+			}
+			data, _ := em["data"].(map[string]interface{})
+			if code, _ := data["code"].(string); code != "" {
+				add(code, r.Type())
+			}
+		}
+	}
+	return out
+}
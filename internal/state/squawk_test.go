@@ -0,0 +1,36 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"acars_parser/internal/registry"
+)
+
+func TestSquawks(t *testing.T) {
+	ts := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	var cpdlc mapResult
+	_ = json.Unmarshal([]byte(`{"direction": "uplink", "elements": [
+		{"id": 123, "data": {"code": "4721"}},
+		{"id": 124}
+	]}`), &cpdlc)
+	pdc := mapResult{"squawk": "2021"}
+
+	got := Squawks(ts, 42, []registry.Result{pdc, cpdlc, mapResult{"squawk": "2021"}, mapResult{"squawk": "189"}})
+	if len(got) != 2 {
+		t.Fatalf("Squawks() = %+v", got)
+	}
+	if got[0].Squawk != "2021" || got[0].MessageID != 42 || !got[0].Timestamp.Equal(ts) {
+		t.Errorf("pdc squawk = %+v", got[0])
+	}
+	if got[1].Squawk != "4721" || got[1].Source != "cpdlc" {
+		t.Errorf("cpdlc squawk = %+v", got[1])
+	}
+
+	// Codes with non-octal digits and downlinked codes are dropped.
+	cpdlc["direction"] = "downlink"
+	if got := Squawks(ts, 1, []registry.Result{cpdlc, mapResult{"squawk": "7800"}}); len(got) != 0 {
+		t.Errorf("Squawks() = %+v, want none", got)
+	}
+}
@@ -0,0 +1,251 @@
+package state
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+	"acars_parser/internal/units"
+)
+
+// TrackPoint is one timestamped position of a flight.
+type TrackPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	Altitude  int // Feet; 0 when not reported.
+	// GroundSpeed (knots) and Track (degrees true) are the velocity reported
+	// with the position. GroundSpeed is 0 when none was.
+	GroundSpeed int
+	Track       int
+	Source      string // Result type that reported the position.
+}
+
+// trackPrecision is the number of decimal places positions are rounded to
+// (about 1 m), so that the same report decoded twice compares equal.
+const trackPrecision = 1e5
+
+// cpdlcPositionReport is the CPDLC downlink element ID of a position report.
+const cpdlcPositionReport = 48
+
+// Positions returns the positions reported by a message's parse results, at
+// the message time. Top-level latitude/longitude fields (ADS-C basic reports,
+// H1 POS, labels 15, 16 and others) and CPDLC position reports (dM48) are
+// used, with the ground speed and track of results that give both. Positions
+// at exactly 0,0 or out of range are dropped as unset.
+func Positions(ts time.Time, results []registry.Result) []TrackPoint {
+	var points []TrackPoint
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+
+		if p, ok := mapPosition(m); ok {
+			p.Time, p.Source = ts, r.Type()
+			points = append(points, p)
+		}
+
+		elements, _ := m["elements"].([]interface{})
+		for _, e := range elements {
+			em, _ := e.(map[string]interface{})
+			if id, _ := em["id"].(float64); int(id) != cpdlcPositionReport || m["direction"] != "downlink" {
+				continue
+			}
+			data, _ := em["data"].(map[string]interface{})
+			pos, _ := data["position"].(map[string]interface{})
+			p, ok := mapPosition(pos)
+			if !ok {
+				continue
+			}
+			if alt, _ := data["altitude"].(map[string]interface{}); alt != nil {
+				p.Altitude = cpdlcAltitudeFeet(alt)
+			}
+			p.Time, p.Source = ts, r.Type()
+			points = append(points, p)
+		}
+	}
+	return BuildTrack(points)
+}
+
+// mapPosition reads latitude, longitude and altitude from a result map.
+func mapPosition(m map[string]interface{}) (TrackPoint, bool) {
+	lat, hasLat := m["latitude"].(float64)
+	lon, hasLon := m["longitude"].(float64)
+	if !hasLat || !hasLon || (lat == 0 && lon == 0) || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return TrackPoint{}, false
+	}
+	p := TrackPoint{Latitude: roundCoord(lat), Longitude: roundCoord(lon)}
+	if v, ok := units.AltitudeFeet(m["altitude"]); ok {
+		p.Altitude = int(math.Round(v))
+	}
+	if v, ok := units.FlightLevelFeet(m["flight_level"]); ok && p.Altitude == 0 {
+		p.Altitude = int(math.Round(v))
+	}
+	p.GroundSpeed, p.Track = mapVelocity(m)
+	return p, true
+}
+
+// mapVelocity reads the ground speed and track from a result map: top-level
+// ground_speed and track fields, or an ADS-C earth reference group. It returns
+// zeros unless both are given and valid.
+func mapVelocity(m map[string]interface{}) (groundSpeed, track int) {
+	gs, hasGS := m["ground_speed"].(float64)
+	trk, hasTrack := m["track"].(float64)
+	if ref, ok := m["earth_ref"].(map[string]interface{}); ok && !hasGS {
+		gs, hasGS = ref["ground_speed_kts"].(float64)
+		trk, hasTrack = ref["track_deg"].(float64)
+		if invalid, _ := ref["track_invalid"].(bool); invalid {
+			hasTrack = false
+		}
+	}
+	if !hasGS || !hasTrack || gs <= 0 || gs > MaxGroundSpeedKnots || trk < 0 || trk > 360 {
+		return 0, 0
+	}
+	return int(math.Round(gs)), int(math.Round(trk)) % 360
+}
+
+// cpdlcAltitudeFeet converts a CPDLC altitude to feet.
+func cpdlcAltitudeFeet(alt map[string]interface{}) int {
+	if v, ok := units.AltitudeFeet(alt); ok {
+		return int(math.Round(v))
+	}
+	v, _ := alt["value"].(float64)
+	return int(v)
+}
+
+func roundCoord(v float64) float64 {
+	return math.Round(v*trackPrecision) / trackPrecision
+}
+
+// BuildTrack orders points by time and removes duplicates: points at the same
+// second and position, as produced when several parsers or receivers report
+// the same fix. An altitude or velocity reported by any of the duplicates is
+// kept. The input slice is not modified.
+func BuildTrack(points []TrackPoint) []TrackPoint {
+	sorted := make([]TrackPoint, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	track := sorted[:0]
+	for _, p := range sorted {
+		p.Time = p.Time.Truncate(time.Second)
+		p.Latitude, p.Longitude = roundCoord(p.Latitude), roundCoord(p.Longitude)
+		if dup := duplicateIn(track, p); dup >= 0 {
+			if track[dup].Altitude == 0 {
+				track[dup].Altitude = p.Altitude
+			}
+			if track[dup].GroundSpeed == 0 {
+				track[dup].GroundSpeed, track[dup].Track = p.GroundSpeed, p.Track
+			}
+			continue
+		}
+		track = append(track, p)
+	}
+	return track
+}
+
+// duplicateIn returns the index of a point in the sorted track with the same
+// time and position as p, or -1.
+func duplicateIn(track []TrackPoint, p TrackPoint) int {
+	for i := len(track) - 1; i >= 0 && track[i].Time.Equal(p.Time); i-- {
+		if track[i].Latitude == p.Latitude && track[i].Longitude == p.Longitude {
+			return i
+		}
+	}
+	return -1
+}
+
+// TrackFromPositions converts stored flight positions to a track.
+func TrackFromPositions(positions []storage.FlightPosition) []TrackPoint {
+	points := make([]TrackPoint, len(positions))
+	for i, p := range positions {
+		points[i] = trackPointFromPosition(p)
+	}
+	return BuildTrack(points)
+}
+
+func trackPointFromPosition(p storage.FlightPosition) TrackPoint {
+	tp := TrackPoint{Time: p.Timestamp, Latitude: p.Latitude, Longitude: p.Longitude, Source: p.Source}
+	if p.Altitude != nil {
+		tp.Altitude = *p.Altitude
+	}
+	if p.GroundSpeed != nil && p.Track != nil {
+		tp.GroundSpeed, tp.Track = *p.GroundSpeed, *p.Track
+	}
+	return tp
+}
+
+// feetToMetres converts altitudes for GeoJSON, whose elevations are metres.
+const feetToMetres = 0.3048
+
+// TrackGeoJSON returns a track as a GeoJSON FeatureCollection: a LineString of
+// the whole track, with the point times in its "coordTimes" property, followed
+// by a Point for each position. Coordinates are [longitude, latitude] with
+// the altitude in metres as a third element when known. The properties are
+// added to the LineString feature, whose geometry is null for tracks of fewer
+// than two points.
+func TrackGeoJSON(points []TrackPoint, properties map[string]interface{}) ([]byte, error) {
+	line := make([][]float64, 0, len(points))
+	times := make([]string, 0, len(points))
+	features := make([]geoJSONFeature, 0, len(points)+1)
+
+	for _, p := range points {
+		coord := []float64{p.Longitude, p.Latitude}
+		if p.Altitude != 0 {
+			coord = append(coord, math.Round(float64(p.Altitude)*feetToMetres))
+		}
+		line = append(line, coord)
+		times = append(times, p.Time.UTC().Format(time.RFC3339))
+
+		props := map[string]interface{}{
+			"time":   p.Time.UTC().Format(time.RFC3339),
+			"source": p.Source,
+		}
+		if p.Altitude != 0 {
+			props["altitude_ft"] = p.Altitude
+		}
+		features = append(features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   &geoJSONGeometry{Type: "Point", Coordinates: coord},
+			Properties: props,
+		})
+	}
+
+	lineProps := map[string]interface{}{"coordTimes": times}
+	for k, v := range properties {
+		lineProps[k] = v
+	}
+	lineFeature := geoJSONFeature{Type: "Feature", Properties: lineProps}
+	if len(points) >= 2 {
+		lineFeature.Geometry = &geoJSONGeometry{Type: "LineString", Coordinates: line}
+	}
+
+	return json.Marshal(geoJSONCollection{
+		Type:     "FeatureCollection",
+		Features: append([]geoJSONFeature{lineFeature}, features...),
+	})
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
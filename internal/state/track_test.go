@@ -0,0 +1,133 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+type positionResult struct {
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	FlightLevel int     `json:"flight_level,omitempty"`
+}
+
+func (r *positionResult) Type() string     { return "h1_position" }
+func (r *positionResult) MessageID() int64 { return 1 }
+
+type cpdlcResult struct {
+	Direction string                   `json:"direction"`
+	Elements  []map[string]interface{} `json:"elements"`
+}
+
+func (r *cpdlcResult) Type() string     { return "cpdlc" }
+func (r *cpdlcResult) MessageID() int64 { return 2 }
+
+func TestPositions(t *testing.T) {
+	ts := time.Date(2026, 1, 24, 10, 15, 30, 0, time.UTC)
+
+	report := map[string]interface{}{
+		"id": 48,
+		"data": map[string]interface{}{
+			"position": map[string]interface{}{"type": "latlon", "latitude": -33.5, "longitude": 151.25},
+			"altitude": map[string]interface{}{"type": "flight_level", "value": 350},
+		},
+	}
+	results := []registry.Result{
+		&positionResult{Latitude: -33.946111, Longitude: 151.177222, FlightLevel: 360},
+		&positionResult{Latitude: -33.946111, Longitude: 151.177222}, // Same fix from a second parser.
+		&positionResult{}, // No position.
+		&cpdlcResult{Direction: "downlink", Elements: []map[string]interface{}{report}},
+		&cpdlcResult{Direction: "uplink", Elements: []map[string]interface{}{report}},
+	}
+
+	points := Positions(ts, results)
+	if len(points) != 2 {
+		t.Fatalf("got %d points, want 2: %+v", len(points), points)
+	}
+	if p := points[0]; p.Latitude != -33.94611 || p.Longitude != 151.17722 || p.Altitude != 36000 || p.Source != "h1_position" {
+		t.Errorf("first point = %+v", p)
+	}
+	if p := points[1]; p.Latitude != -33.5 || p.Altitude != 35000 || p.Source != "cpdlc" || !p.Time.Equal(ts) {
+		t.Errorf("CPDLC point = %+v", p)
+	}
+}
+
+func TestBuildTrack(t *testing.T) {
+	t0 := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	in := []TrackPoint{
+		{Time: t0.Add(20 * time.Minute), Latitude: -30, Longitude: 150},
+		{Time: t0, Latitude: -33.9, Longitude: 151.2},
+		{Time: t0.Add(20*time.Minute + 300*time.Millisecond), Latitude: -30, Longitude: 150, Altitude: 37000},
+		{Time: t0.Add(10 * time.Minute), Latitude: -32, Longitude: 151},
+	}
+
+	track := BuildTrack(in)
+	if len(track) != 3 {
+		t.Fatalf("got %d points, want 3: %+v", len(track), track)
+	}
+	for i := 1; i < len(track); i++ {
+		if track[i].Time.Before(track[i-1].Time) {
+			t.Errorf("track not in time order at %d", i)
+		}
+	}
+	if track[2].Altitude != 37000 {
+		t.Errorf("duplicate altitude not kept: %+v", track[2])
+	}
+	if !in[0].Time.Equal(t0.Add(20 * time.Minute)) {
+		t.Error("BuildTrack modified its input")
+	}
+}
+
+func TestTrackGeoJSON(t *testing.T) {
+	t0 := time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)
+	alt := 10000
+	track := TrackFromPositions([]storage.FlightPosition{
+		{Timestamp: t0, Latitude: -33.9, Longitude: 151.2, Source: "pdc"},
+		{Timestamp: t0.Add(time.Hour), Latitude: -30, Longitude: 150, Altitude: &alt, Source: "adsc"},
+	})
+
+	b, err := TrackGeoJSON(track, map[string]interface{}{"callsign": "QFA1"})
+	if err != nil {
+		t.Fatalf("TrackGeoJSON: %v", err)
+	}
+
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry *struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(b, &fc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 3 {
+		t.Fatalf("got %s with %d features, want FeatureCollection with 3", fc.Type, len(fc.Features))
+	}
+
+	line := fc.Features[0]
+	if line.Geometry.Type != "LineString" || line.Properties["callsign"] != "QFA1" {
+		t.Errorf("line feature = %+v", line)
+	}
+	if got := string(line.Geometry.Coordinates); got != "[[151.2,-33.9],[150,-30,3048]]" {
+		t.Errorf("coordinates = %s", got)
+	}
+	if times, _ := line.Properties["coordTimes"].([]interface{}); len(times) != 2 || times[0] != "2026-01-24T10:00:00Z" {
+		t.Errorf("coordTimes = %v", line.Properties["coordTimes"])
+	}
+	if p := fc.Features[2]; p.Geometry.Type != "Point" || p.Properties["altitude_ft"] != float64(10000) {
+		t.Errorf("point feature = %+v", p)
+	}
+
+	single, _ := TrackGeoJSON(track[:1], nil)
+	if err := json.Unmarshal(single, &fc); err != nil || fc.Features[0].Geometry != nil {
+		t.Errorf("single-point track should have a null line geometry: %s", single)
+	}
+}
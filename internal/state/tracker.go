@@ -0,0 +1,790 @@
+// Package state applies data extracted from parsed ACARS messages to the
+// PostgreSQL state tables (aircraft, waypoints, routes, ATIS, flight state and
+// flight enrichment), and archives flights once they are complete.
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/airline"
+	"acars_parser/internal/enrichment"
+	"acars_parser/internal/extractor"
+	"acars_parser/internal/groundstation"
+	"acars_parser/internal/msgtime"
+	"acars_parser/internal/navdata"
+	"acars_parser/internal/output"
+	"acars_parser/internal/registration"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// Stats counts the state rows written by a Tracker.
+type Stats struct {
+	Aircraft    int
+	Waypoints   int
+	Routes      int
+	ATIS        int
+	Enrichments int
+	Flights     int // flight_state upserts.
+	Archived    int // Flights moved to flight_history.
+	Positions   int // Track positions recorded.
+	// RejectedPositions counts positions that failed the plausibility check.
+	RejectedPositions int
+	Comms             int // SELCAL codes and frequencies recorded.
+	Squawks           int // Transponder code assignments recorded.
+	Emergencies       int // Emergency events recorded.
+	GroundStations    int // Messages counted against a ground station.
+	Logons            int // AFN logons recorded.
+	LinkedCPDLC       int // CPDLC messages linked to a logon.
+	Winds             int // Weather observations recorded and added to the wind grid.
+	Turbulence        int // Turbulence and wind shear reports recorded.
+	RouteChanges      int // Changes to flight plans recorded.
+	ArrivalRunways    int // Planned arrival runways recorded.
+	ETAReports        int // ETAs recorded to be fused.
+}
+
+// Tracker writes extracted message data to PostgreSQL.
+// It is not safe for concurrent use; messages should be applied in time order.
+type Tracker struct {
+	pg        *storage.PostgresDB
+	resolver  *registration.Resolver
+	airlines  *airline.Table
+	lifecycle Lifecycle
+	sink      output.Sink
+	lastSweep time.Time // Message time of the last expiry sweep.
+	latest    time.Time // Latest message time applied.
+	stats     Stats
+}
+
+// sweepInterval is the message time between automatic expiry sweeps.
+const sweepInterval = 10 * time.Minute
+
+// NewTracker creates a Tracker that writes to the given PostgreSQL database.
+// Registrations are resolved to ICAO hex with the country algorithms only
+// until SetResolver supplies an imported registry, and callsigns are stored as
+// reported until SetAirlines supplies an airline table.
+func NewTracker(pg *storage.PostgresDB) *Tracker {
+	return &Tracker{pg: pg, resolver: registration.NewResolver(), airlines: airline.NewTable(), lifecycle: DefaultLifecycle()}
+}
+
+// SetResolver sets the resolver used to find the ICAO hex of aircraft that
+// are not yet in the aircraft table.
+func (t *Tracker) SetResolver(r *registration.Resolver) {
+	t.resolver = r
+}
+
+// SetAirlines sets the airline table used to store IATA flight numbers
+// ("QF1255") under their ICAO callsign ("QFA1255").
+func (t *Tracker) SetAirlines(a *airline.Table) {
+	t.airlines = a
+}
+
+// SetSink sets a sink that every flight enrichment update, emergency event and
+// route change is published to, after it has been written to PostgreSQL. Emergency events
+// are published with output.PublishNow.
+func (t *Tracker) SetSink(s output.Sink) {
+	t.sink = s
+}
+
+// SetLifecycle sets the timeouts used to complete and archive flights.
+func (t *Tracker) SetLifecycle(l Lifecycle) {
+	t.lifecycle = l
+}
+
+// Stats returns the number of rows written since the Tracker was created.
+func (t *Tracker) Stats() Stats {
+	return t.stats
+}
+
+// Apply extracts state from a message and its parse results and upserts it.
+// The message timestamp is used for first_seen/last_seen so that replayed
+// history keeps its original timing, and flights are expired against message
+// time every sweepInterval. Emergencies the message reports are recorded and
+// published first, before any other state is written.
+func (t *Tracker) Apply(ctx context.Context, msg *acars.Message, results []registry.Result) error {
+	ts := ParseTimestamp(msg.Timestamp)
+	if err := t.applyEmergencies(ctx, msg, ts, results); err != nil {
+		return err
+	}
+	data := extractor.Extract(msg, results)
+
+	for _, gs := range groundstation.Observed(msg, results) {
+		if err := t.pg.RecordGroundStation(ctx, gs.Kind, gs.ID, ts); err != nil {
+			return err
+		}
+		t.stats.GroundStations++
+	}
+	if err := t.applyLogons(ctx, msg, ts, results); err != nil {
+		return err
+	}
+
+	var icaoHex string
+	if f := data.Flight; f != nil {
+		f.FlightNumber = t.airlines.NormaliseCallsign(f.FlightNumber)
+		if err := t.applyFlight(ctx, f, ts); err != nil {
+			return err
+		}
+		var err error
+		if icaoHex, err = t.resolveICAOHex(ctx, f); err != nil {
+			return err
+		}
+		reported := flightReport{
+			arrival: IsArrival(msg.Label, results),
+			fuel:    FuelReadings(results),
+			points:  Positions(ts, results),
+			comms:   CommAssignments(ts, msg.Text, results),
+			squawks: Squawks(ts, int64(msg.ID), results),
+		}
+		if err := t.applyFlightState(ctx, f, icaoHex, ts, reported); err != nil {
+			return err
+		}
+		if err := t.applyFlightPlan(ctx, msg, f, icaoHex, ts, results); err != nil {
+			return err
+		}
+	}
+
+	for _, wp := range data.Waypoints {
+		// Positions named by their coordinates, such as the oceanic 5740N,
+		// are resolved from the name and need no row.
+		if _, _, _, ok := navdata.LatLonWaypoint(wp.Name); ok {
+			continue
+		}
+		err := t.pg.UpsertWaypoint(ctx, storage.Waypoint{
+			Name:        wp.Name,
+			Latitude:    wp.Latitude,
+			Longitude:   wp.Longitude,
+			SourceCount: 1,
+			FirstSeen:   ts,
+			LastSeen:    ts,
+		})
+		if err != nil {
+			return fmt.Errorf("upsert waypoint %s: %w", wp.Name, err)
+		}
+		t.stats.Waypoints++
+	}
+
+	if a := data.ATIS; a != nil {
+		err := t.pg.UpsertATISCurrent(ctx, storage.ATISCurrent{
+			AirportICAO: a.AirportICAO,
+			Letter:      a.Letter,
+			ATISType:    a.ATISType,
+			ATISTime:    a.ATISTime,
+			RawText:     a.RawText,
+			Runways:     a.Runways,
+			Approaches:  a.Approaches,
+			Wind:        a.Wind,
+			Visibility:  a.Visibility,
+			Clouds:      a.Clouds,
+			Temperature: a.Temperature,
+			DewPoint:    a.DewPoint,
+			QNH:         a.QNH,
+			Remarks:     a.Remarks,
+			UpdatedAt:   ts,
+		})
+		if err != nil {
+			return fmt.Errorf("upsert atis %s: %w", a.AirportICAO, err)
+		}
+		t.stats.ATIS++
+	}
+
+	if err := t.applyWinds(ctx, msg, data.Flight, ts, results); err != nil {
+		return err
+	}
+	if err := t.applyTurbulence(ctx, msg, data.Flight, ts, results); err != nil {
+		return err
+	}
+
+	eta, err := t.applyETAs(ctx, msg, data.Flight, ts, results)
+	if err != nil {
+		return err
+	}
+	if err := t.applyEnrichment(ctx, msg, data.Flight, icaoHex, ts, results, eta); err != nil {
+		return err
+	}
+
+	if ts.After(t.latest) {
+		t.latest = ts
+	}
+	if t.latest.Sub(t.lastSweep) >= sweepInterval {
+		if _, err := t.Expire(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expire archives the flights that, as of the latest message applied, arrived
+// more than the grace period ago or have been inactive for longer than the
+// inactivity timeout. It returns the number of flights archived.
+func (t *Tracker) Expire(ctx context.Context) (int64, error) {
+	if t.latest.IsZero() {
+		return 0, nil
+	}
+	t.lastSweep = t.latest
+	completedBefore, inactiveBefore := t.lifecycle.Cutoffs(t.latest)
+	n, err := t.pg.ArchiveExpiredFlightStates(ctx, completedBefore, inactiveBefore)
+	if err != nil {
+		return 0, err
+	}
+	t.stats.Archived += int(n)
+	return n, nil
+}
+
+// applyLogons records AFN logons and their acknowledgements, and links CPDLC
+// messages to the logon to their facility that preceded them.
+func (t *Tracker) applyLogons(ctx context.Context, msg *acars.Message, ts time.Time, results []registry.Result) error {
+	for _, a := range LogonActivities(results) {
+		switch a.Kind {
+		case LogonContact:
+			err := t.pg.InsertAFNLogon(ctx, storage.AFNLogon{
+				Registration: a.Registration,
+				Facility:     a.Facility,
+				Flight:       a.Flight,
+				Applications: a.Applications,
+				LogonAt:      ts,
+				MessageID:    int64(msg.ID),
+			})
+			if err != nil {
+				return err
+			}
+			t.stats.Logons++
+		case LogonAcknowledgement:
+			if _, err := t.pg.AcknowledgeAFNLogon(ctx, a.Registration, a.Facility, ts, a.Accepted); err != nil {
+				return err
+			}
+		case LogonCPDLC:
+			linked, err := t.pg.LinkCPDLCToAFNLogon(ctx, a.Registration, a.Facility, ts)
+			if err != nil {
+				return err
+			}
+			if linked {
+				t.stats.LinkedCPDLC++
+			}
+		}
+	}
+	return nil
+}
+
+// applyWinds records the winds aloft a message reports as weather
+// observations and adds the new ones to the wind grid, so that replayed
+// reports are not counted twice. PWI route winds are placed at the waypoints
+// in the waypoints table.
+func (t *Tracker) applyWinds(ctx context.Context, msg *acars.Message, f *extractor.FlightUpdate, ts time.Time, results []registry.Result) error {
+	var lookupErr error
+	obs := WindObservations(ts, results, t.waypointLookup(ctx, &lookupErr))
+	if lookupErr != nil {
+		return lookupErr
+	}
+	if len(obs) == 0 {
+		return nil
+	}
+
+	registration, flight := reporter(msg, f)
+	var added []WindObservation
+	for _, o := range obs {
+		inserted, err := t.pg.InsertWeatherObservation(ctx, storage.WeatherObservation{
+			ObservedAt:   o.Time,
+			Latitude:     o.Latitude,
+			Longitude:    o.Longitude,
+			FlightLevel:  o.FlightLevel,
+			WindDir:      o.WindDir,
+			WindSpeed:    o.WindSpeed,
+			Temperature:  o.Temperature,
+			Source:       o.Source,
+			Registration: registration,
+			Flight:       flight,
+			MessageID:    int64(msg.ID),
+		})
+		if err != nil {
+			return err
+		}
+		if inserted {
+			added = append(added, o)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	if err := t.pg.AddWindCells(ctx, GridWinds(added)); err != nil {
+		return err
+	}
+	t.stats.Winds += len(added)
+	return nil
+}
+
+// applyTurbulence records the turbulence and wind shear reports in a
+// message. Reports without a position of their own are placed at their
+// waypoint from the waypoints table, or at a position the message reports.
+func (t *Tracker) applyTurbulence(ctx context.Context, msg *acars.Message, f *extractor.FlightUpdate, ts time.Time, results []registry.Result) error {
+	var lookupErr error
+	reports := TurbulenceReports(ts, results, t.waypointLookup(ctx, &lookupErr))
+	if lookupErr != nil {
+		return lookupErr
+	}
+	registration, flight := reporter(msg, f)
+	for _, r := range reports {
+		r.Registration, r.Flight, r.MessageID = registration, flight, int64(msg.ID)
+		inserted, err := t.pg.InsertTurbulenceReport(ctx, r)
+		if err != nil {
+			return err
+		}
+		if inserted {
+			t.stats.Turbulence++
+		}
+	}
+	return nil
+}
+
+// waypointLookup returns a lookup of waypoint positions in the waypoints
+// table, or from the name of a waypoint named by its coordinates. The first
+// error it meets is stored in errp, and the waypoint reported as unknown.
+func (t *Tracker) waypointLookup(ctx context.Context, errp *error) WaypointLookup {
+	return func(name string) (float64, float64, bool) {
+		if _, lat, lon, ok := navdata.LatLonWaypoint(name); ok {
+			return lat, lon, true
+		}
+		w, err := t.pg.GetWaypoint(ctx, name)
+		if err != nil {
+			if *errp == nil {
+				*errp = fmt.Errorf("get waypoint %s: %w", name, err)
+			}
+			return 0, 0, false
+		}
+		if w == nil {
+			return 0, 0, false
+		}
+		return w.Latitude, w.Longitude, true
+	}
+}
+
+// reporter returns the registration and flight number of the aircraft that
+// sent a message: those extracted from the message, or else its tail.
+func reporter(msg *acars.Message, f *extractor.FlightUpdate) (registration, flight string) {
+	if f != nil {
+		return f.Registration, f.FlightNumber
+	}
+	return msg.Tail, ""
+}
+
+// flightReport is what one message reports about a flight beyond its
+// identity.
+type flightReport struct {
+	arrival bool
+	fuel    Fuel
+	points  []TrackPoint
+	comms   []storage.CommAssignment
+	squawks []storage.SquawkAssignment
+}
+
+// applyFlightState updates the current state of a flight and appends the
+// message's positions to its track. A flight that completed more than the
+// grace period before the message is archived first, so that the message
+// starts a new flight, and an arrival marks it complete. Fuel readings are
+// kept per OOOI event so that burn can be computed once the flight is in, and
+// SELCAL codes, frequencies and squawks are added to the flight's history.
+func (t *Tracker) applyFlightState(ctx context.Context, f *extractor.FlightUpdate, icaoHex string, ts time.Time, reported flightReport) error {
+	key := FlightKey(f)
+	if key == "" {
+		return nil
+	}
+
+	current, err := t.pg.GetFlightState(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get flight state %s: %w", key, err)
+	}
+	if current != nil && current.CompletedAt != nil && t.lifecycle.Reopens(*current.CompletedAt, ts) {
+		if _, err := t.pg.ArchiveFlightState(ctx, key); err != nil {
+			return err
+		}
+		t.stats.Archived++
+	}
+
+	fs := storage.FlightState{
+		Key:          key,
+		ICAOHex:      strings.ToUpper(icaoHex),
+		Registration: f.Registration,
+		FlightNumber: f.FlightNumber,
+		Origin:       f.Origin,
+		Destination:  f.Destination,
+		FirstSeen:    ts,
+		LastSeen:     ts,
+		MsgCount:     1,
+		FuelOut:      reported.fuel.Out,
+		FuelOff:      reported.fuel.Off,
+		FuelOn:       reported.fuel.On,
+		FuelIn:       reported.fuel.In,
+	}
+	positions, latest, err := t.checkPositions(ctx, key, reported.points)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		fs.Latitude, fs.Longitude = &latest.Latitude, &latest.Longitude
+	}
+	if f.Altitude != 0 {
+		fs.Altitude = &f.Altitude
+	}
+	if f.GroundSpeed != 0 {
+		fs.GroundSpeed = &f.GroundSpeed
+	}
+	if f.Track != 0 {
+		fs.Track = &f.Track
+	}
+	if err := t.pg.UpsertFlightState(ctx, fs); err != nil {
+		return fmt.Errorf("upsert flight state %s: %w", key, err)
+	}
+	t.stats.Flights++
+
+	if len(positions) > 0 {
+		if err := t.pg.InsertFlightPositions(ctx, key, positions); err != nil {
+			return err
+		}
+	}
+	if len(reported.comms) > 0 {
+		if err := t.pg.InsertCommAssignments(ctx, key, reported.comms); err != nil {
+			return err
+		}
+		t.stats.Comms += len(reported.comms)
+	}
+	if len(reported.squawks) > 0 {
+		if err := t.pg.InsertSquawks(ctx, key, reported.squawks); err != nil {
+			return err
+		}
+		t.stats.Squawks += len(reported.squawks)
+	}
+
+	if reported.arrival {
+		if _, err := t.pg.CompleteFlightState(ctx, key, ts, CompletionArrived); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPositions runs the plausibility check on a message's positions against
+// the flight's track. It returns the positions to store, rejected ones with
+// their reason, and the latest accepted position, which is nil if every
+// position was rejected.
+func (t *Tracker) checkPositions(ctx context.Context, key string, points []TrackPoint) ([]storage.FlightPosition, *TrackPoint, error) {
+	if len(points) == 0 {
+		return nil, nil, nil
+	}
+
+	accepted, rejected, err := t.pg.GetLastFlightPositions(ctx, key, points[len(points)-1].Time)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get last position %s: %w", key, err)
+	}
+	var lastAccepted, lastRejected, latest *TrackPoint
+	if accepted != nil {
+		p := trackPointFromPosition(*accepted)
+		lastAccepted = &p
+	}
+	if rejected != nil {
+		p := trackPointFromPosition(*rejected)
+		lastRejected = &p
+	}
+
+	positions := make([]storage.FlightPosition, len(points))
+	for i := range points {
+		p := points[i]
+		positions[i] = storage.FlightPosition{Timestamp: p.Time, Latitude: p.Latitude, Longitude: p.Longitude, Source: p.Source}
+		if p.Altitude != 0 {
+			positions[i].Altitude = &p.Altitude
+		}
+		if p.GroundSpeed != 0 {
+			positions[i].GroundSpeed, positions[i].Track = &p.GroundSpeed, &p.Track
+		}
+		if reason := CheckPosition(lastAccepted, lastRejected, p); reason != "" {
+			positions[i].Rejection = reason
+			lastRejected = &p
+			t.stats.RejectedPositions++
+			continue
+		}
+		lastAccepted, lastRejected, latest = &p, nil, &p
+		t.stats.Positions++
+	}
+	return positions, latest, nil
+}
+
+// applyFlight records the aircraft and, when origin and destination are known, the route.
+func (t *Tracker) applyFlight(ctx context.Context, f *extractor.FlightUpdate, ts time.Time) error {
+	if f.ICAOHex != "" && f.Registration != "" {
+		err := t.pg.UpsertAircraft(ctx, storage.Aircraft{
+			ICAOHex:      strings.ToUpper(f.ICAOHex),
+			Registration: f.Registration,
+			TypeCode:     f.TypeCode,
+			Operator:     f.Operator,
+			FirstSeen:    ts,
+			LastSeen:     ts,
+			MsgCount:     1,
+		})
+		if err != nil {
+			return fmt.Errorf("upsert aircraft %s: %w", f.ICAOHex, err)
+		}
+		t.stats.Aircraft++
+	}
+
+	if f.FlightNumber == "" || f.Origin == "" || f.Destination == "" || f.Origin == f.Destination {
+		return nil
+	}
+
+	routeID, err := t.pg.UpsertRoute(ctx, storage.Route{
+		FlightPattern:    f.FlightNumber,
+		OriginICAO:       f.Origin,
+		DestICAO:         f.Destination,
+		ObservationCount: 1,
+		FirstSeen:        ts,
+		LastSeen:         ts,
+	})
+	if err != nil {
+		return fmt.Errorf("upsert route %s: %w", f.FlightNumber, err)
+	}
+	t.stats.Routes++
+
+	err = t.pg.UpsertRouteLeg(ctx, storage.RouteLeg{
+		RouteID:          routeID,
+		Sequence:         1,
+		OriginICAO:       f.Origin,
+		DestICAO:         f.Destination,
+		ObservationCount: 1,
+		FirstSeen:        ts,
+		LastSeen:         ts,
+	})
+	if err != nil {
+		return fmt.Errorf("upsert route leg %s: %w", f.FlightNumber, err)
+	}
+
+	if f.Registration != "" {
+		err = t.pg.UpsertRouteAircraft(ctx, storage.RouteAircraft{
+			RouteID:          routeID,
+			Registration:     f.Registration,
+			ObservationCount: 1,
+			FirstSeen:        ts,
+			LastSeen:         ts,
+		})
+		if err != nil {
+			return fmt.Errorf("upsert route aircraft %s: %w", f.Registration, err)
+		}
+	}
+
+	return nil
+}
+
+// resolveICAOHex returns the ICAO hex of the aircraft in a flight update. When
+// the message carries none (e.g. messages replayed from the SQLite corpus), the
+// aircraft table is consulted, then the registration resolver. It returns ""
+// if the aircraft is unknown.
+func (t *Tracker) resolveICAOHex(ctx context.Context, f *extractor.FlightUpdate) (string, error) {
+	if f.ICAOHex != "" || f.Registration == "" {
+		return f.ICAOHex, nil
+	}
+	a, err := t.pg.GetAircraftByRegistration(ctx, f.Registration)
+	if err != nil {
+		return "", fmt.Errorf("lookup aircraft %s: %w", f.Registration, err)
+	}
+	if a != nil {
+		return a.ICAOHex, nil
+	}
+	if hex, ok := t.resolver.ICAOHex(f.Registration); ok {
+		return hex, nil
+	}
+	return "", nil
+}
+
+// messageHash identifies a message by its content, so that messages of
+// different feeds or databases that share an ID are told apart. The time is
+// left out, as the same message is timestamped differently by live input and
+// the stores it is replayed from.
+func messageHash(msg *acars.Message) string {
+	h := sha256.New()
+	for _, s := range []string{msg.Label, strings.ToUpper(strings.TrimLeft(strings.TrimSpace(msg.Tail), ".")), msg.Text} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// applyEnrichment writes flight enrichment data for the aircraft with the
+// resolved ICAO hex, with the flight's fused ETA when the message reported
+// one.
+func (t *Tracker) applyEnrichment(ctx context.Context, msg *acars.Message, f *extractor.FlightUpdate, icaoHex string, ts time.Time, results []registry.Result, eta *FusedETA) error {
+	if f == nil || len(results) == 0 {
+		return nil
+	}
+
+	update := enrichment.ExtractEnrichment(icaoHex, f.FlightNumber, ts, results)
+	if eta != nil {
+		if update == nil {
+			update = enrichment.NewUpdate(icaoHex, f.FlightNumber, ts)
+		}
+		if update != nil {
+			source, uncertainty := strings.Join(eta.Sources, ","), int(eta.Uncertainty/time.Minute)
+			update.ETA, update.ETASource, update.ETAUncertainty = &eta.ETA, &source, &uncertainty
+			update.Parser = strings.TrimPrefix(update.Parser+",eta_fusion", ",")
+		}
+	}
+	if update == nil {
+		return nil
+	}
+	update.Callsign = t.airlines.NormaliseCallsign(update.Callsign)
+	update.MessageID, update.MessageHash = int64(msg.ID), messageHash(msg)
+	update.Tenant = msg.Tenant
+	if err := t.pg.UpsertFlightEnrichment(ctx, *update); err != nil {
+		return fmt.Errorf("upsert enrichment %s/%s: %w", update.ICAOHex, update.Callsign, err)
+	}
+	t.stats.Enrichments++
+	if t.sink != nil {
+		if err := t.sink.Publish(ctx, output.EnrichmentEvent(*update)); err != nil {
+			return fmt.Errorf("publish enrichment %s/%s: %w", update.ICAOHex, update.Callsign, err)
+		}
+	}
+	return nil
+}
+
+// applyETAs records the ETAs a message reports for a flight, and returns the
+// flight's ETA fused from the latest report of each source. It returns nil
+// when the message reports none.
+func (t *Tracker) applyETAs(ctx context.Context, msg *acars.Message, f *extractor.FlightUpdate, ts time.Time, results []registry.Result) (*FusedETA, error) {
+	key := FlightKey(f)
+	if key == "" {
+		return nil, nil
+	}
+	reports := ETAReports(key, ts, int64(msg.ID), results)
+	if len(reports) == 0 {
+		return nil, nil
+	}
+	for _, r := range reports {
+		if err := t.pg.RecordETAReport(ctx, r); err != nil {
+			return nil, err
+		}
+		t.stats.ETAReports++
+	}
+
+	stored, err := t.pg.GetETAReports(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	eta, ok := FuseETAs(stored)
+	if !ok {
+		return nil, nil
+	}
+	return &eta, nil
+}
+
+// applyFlightPlan compares the flight plan a message gives with the one
+// stored for the flight. A change is recorded and published as a
+// "route_changed" event, and the newer plan stored along with the arrival
+// runway it gives at the destination. Plans older than the stored one are
+// ignored.
+func (t *Tracker) applyFlightPlan(ctx context.Context, msg *acars.Message, f *extractor.FlightUpdate, icaoHex string, ts time.Time, results []registry.Result) error {
+	key := FlightKey(f)
+	plan := FlightPlanOf(results)
+	if key == "" || plan == nil {
+		return nil
+	}
+
+	stored, updated, err := t.pg.GetFlightPlan(ctx, key)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		if ts.Before(updated) {
+			return nil
+		}
+		merged, change := DiffFlightPlans(*stored, *plan)
+		plan = &merged
+		if change != nil {
+			change.FlightKey, change.Timestamp, change.MessageID = key, ts, int64(msg.ID)
+			change.ICAOHex, change.Registration, change.Flight = strings.ToUpper(icaoHex), f.Registration, f.FlightNumber
+			if err := t.recordRouteChange(ctx, msg, *change); err != nil {
+				return err
+			}
+		}
+	}
+	if err := t.pg.SaveFlightPlan(ctx, key, *plan, ts, int64(msg.ID)); err != nil {
+		return err
+	}
+
+	rwy, ok := ArrivalRunway(*plan)
+	if !ok || plan.Destination == "" {
+		return nil
+	}
+	err = t.pg.RecordArrivalRunway(ctx, storage.ArrivalRunway{
+		AirportICAO: plan.Destination,
+		FlightKey:   key,
+		Runway:      rwy,
+		Approach:    plan.Approach,
+		ObservedAt:  ts,
+	})
+	if err != nil {
+		return err
+	}
+	t.stats.ArrivalRunways++
+	return nil
+}
+
+// recordRouteChange stores a route change and, when it is new, publishes it.
+func (t *Tracker) recordRouteChange(ctx context.Context, msg *acars.Message, c storage.RouteChange) error {
+	inserted, err := t.pg.InsertRouteChange(ctx, c)
+	if err != nil || !inserted {
+		return err
+	}
+	t.stats.RouteChanges++
+	if t.sink == nil {
+		return nil
+	}
+	e := output.RouteChangeEvent(c)
+	e.Label, e.Tenant = msg.Label, msg.Tenant
+	if err := t.sink.Publish(ctx, e); err != nil {
+		return fmt.Errorf("publish route change %s: %w", c.FlightKey, err)
+	}
+	return nil
+}
+
+// applyEmergencies records the emergency events a message reports and
+// publishes each at once. Aircraft without an ICAO hex are resolved from
+// their registration, without a database lookup.
+func (t *Tracker) applyEmergencies(ctx context.Context, msg *acars.Message, ts time.Time, results []registry.Result) error {
+	events := Emergencies(ts, msg, results)
+	if len(events) == 0 {
+		return nil
+	}
+	for i := range events {
+		e := &events[i]
+		e.Flight = t.airlines.NormaliseCallsign(e.Flight)
+		if e.ICAOHex == "" && e.Registration != "" {
+			if hex, ok := t.resolver.ICAOHex(e.Registration); ok {
+				e.ICAOHex = strings.ToUpper(hex)
+			}
+		}
+	}
+	if err := t.pg.InsertEmergencyEvents(ctx, events); err != nil {
+		return err
+	}
+	t.stats.Emergencies += len(events)
+	if t.sink == nil {
+		return nil
+	}
+	for _, e := range events {
+		if err := output.PublishNow(ctx, t.sink, output.EmergencyEvent(e)); err != nil {
+			return fmt.Errorf("publish emergency %s: %w", e.Kind, err)
+		}
+	}
+	return nil
+}
+
+// ParseTimestamp converts an ACARS message timestamp to a time.Time, in any
+// of the forms msgtime.Parse accepts. Returns the current time if the
+// timestamp cannot be parsed.
+func ParseTimestamp(s string) time.Time {
+	if ts, ok := msgtime.Parse(s); ok {
+		return ts
+	}
+	return time.Now().UTC()
+}
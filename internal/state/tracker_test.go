@@ -0,0 +1,53 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/acars"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want time.Time
+	}{
+		{"RFC 3339", "2026-01-24T10:15:30Z", time.Date(2026, 1, 24, 10, 15, 30, 0, time.UTC)},
+		{"RFC 3339 with offset", "2026-01-24T20:15:30+10:00", time.Date(2026, 1, 24, 10, 15, 30, 0, time.UTC)},
+		{"Unix seconds", "1769249730", time.Date(2026, 1, 24, 10, 15, 30, 0, time.UTC)},
+		{"Unix seconds with fraction", "1769249730.5", time.Date(2026, 1, 24, 10, 15, 30, 500000000, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseTimestamp(tt.in)
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimestampInvalidFallsBackToNow(t *testing.T) {
+	before := time.Now().Add(-time.Second)
+	got := ParseTimestamp("not a time")
+	if got.Before(before) {
+		t.Errorf("ParseTimestamp(invalid) = %v, want approximately now", got)
+	}
+}
+
+func TestMessageHash(t *testing.T) {
+	msg := acars.Message{ID: 42, Label: "H1", Tail: ".VH-OQA", Text: "PDC 301035", Timestamp: "2026-03-01T10:00:00Z"}
+	replayed := msg
+	replayed.Tail, replayed.Timestamp = "VH-OQA", "1772359200"
+	other := msg
+	other.Text = "POSS33570E151108"
+
+	if messageHash(&msg) != messageHash(&replayed) {
+		t.Error("the same message replayed from a store has another hash")
+	}
+	if messageHash(&msg) == messageHash(&other) {
+		t.Error("messages of two feeds sharing an ID have the same hash")
+	}
+}
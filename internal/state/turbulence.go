@@ -0,0 +1,102 @@
+package state
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+	"acars_parser/internal/units"
+)
+
+// severityRanks orders the turbulence report severities.
+var severityRanks = map[string]int{"none": 0, "light": 1, "moderate": 2, "severe": 3, "extreme": 4}
+
+// TurbulenceReports returns the turbulence and wind shear reports in a
+// message's parse results, at the message time. A report without a position
+// of its own is placed at its waypoint, if the lookup knows it, or else at a
+// position reported by a result of the message other than a turbulence
+// report; without a level, it takes that position's altitude. Registration, flight and message ID are
+// left for the caller to fill.
+func TurbulenceReports(ts time.Time, results []registry.Result, lookup WaypointLookup) []storage.TurbulenceReport {
+	var out []storage.TurbulenceReport
+	var others []registry.Result
+	for _, r := range results {
+		if r.Type() != "turbulence_report" {
+			others = append(others, r)
+		}
+	}
+	var positions []TrackPoint
+	if len(others) < len(results) {
+		positions = Positions(ts, others)
+	}
+
+	for _, r := range results {
+		if r.Type() != "turbulence_report" {
+			continue
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+
+		rep := storage.TurbulenceReport{ReportedAt: ts, SeverityRank: -1}
+		rep.Phenomenon, _ = m["phenomenon"].(string)
+		rep.Severity, _ = m["severity"].(string)
+		rep.SeverityText, _ = m["severity_text"].(string)
+		rep.Waypoint, _ = m["waypoint"].(string)
+		rep.Text, _ = m["text"].(string)
+		if rank, ok := severityRanks[rep.Severity]; ok {
+			rep.SeverityRank = rank
+		}
+		if v, ok := m["edr_peak"].(float64); ok {
+			rep.EDRPeak = &v
+		}
+		if v, ok := m["edr_mean"].(float64); ok {
+			rep.EDRMean = &v
+		}
+		if v, ok := m["airspeed_change"].(float64); ok {
+			rep.AirspeedChange = int(v)
+		}
+		if v, ok := units.FlightLevelFeet(m["flight_level"]); ok && v > 0 {
+			fl := int(math.Round(v / 100))
+			rep.FlightLevel = &fl
+		} else if v, ok := units.AltitudeFeet(m["altitude"]); ok && v > 0 {
+			fl := int(math.Round(v / 100))
+			rep.FlightLevel = &fl
+		}
+		if v, ok := units.FlightLevelFeet(m["flight_level_top"]); ok && v > 0 {
+			top := int(math.Round(v / 100))
+			rep.FlightLevelTop = &top
+		}
+
+		lat, lon, placed := 0.0, 0.0, false
+		if p, ok := mapPosition(m); ok {
+			lat, lon, placed = p.Latitude, p.Longitude, true
+		} else if lookup != nil && rep.Waypoint != "" {
+			lat, lon, placed = lookup(rep.Waypoint)
+		}
+		if !placed || rep.FlightLevel == nil {
+			for _, p := range positions {
+				if !placed {
+					lat, lon, placed = p.Latitude, p.Longitude, true
+				}
+				if rep.FlightLevel == nil && p.Altitude > 0 {
+					fl := int(math.Round(float64(p.Altitude) / 100))
+					rep.FlightLevel = &fl
+				}
+				break
+			}
+		}
+		if placed {
+			rep.Latitude, rep.Longitude = &lat, &lon
+		}
+		out = append(out, rep)
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/parsers/pirep"
+	"acars_parser/internal/registry"
+)
+
+func TestTurbulenceReports(t *testing.T) {
+	ts := time.Date(2026, 10, 17, 10, 25, 0, 0, time.UTC)
+	edr := 0.32
+	placed := &pirep.TurbulenceResult{Phenomenon: pirep.PhenomenonTurbulence, Severity: pirep.SeverityModerate,
+		EDRPeak: &edr, Latitude: 45.5, Longitude: -20, FlightLevel: 360, Text: "EDR .32"}
+	atWaypoint := &pirep.TurbulenceResult{Phenomenon: pirep.PhenomenonTurbulence, Severity: pirep.SeveritySevere,
+		Waypoint: "TIDKA", Text: "SEV TURB OVER TIDKA"}
+	shear := &pirep.TurbulenceResult{Phenomenon: pirep.PhenomenonWindShear, AirspeedChange: -15, Text: "WINDSHEAR LOSS 15KT"}
+	position := mapResult{"latitude": -33.9, "longitude": 151.2, "altitude": 2000.0}
+
+	lookup := func(name string) (float64, float64, bool) { return 46.4, 6.1, name == "TIDKA" }
+	got := TurbulenceReports(ts, []registry.Result{placed, atWaypoint, shear, position}, lookup)
+	if len(got) != 3 {
+		t.Fatalf("TurbulenceReports() = %+v, want 3", got)
+	}
+
+	if r := got[0]; r.SeverityRank != 2 || *r.EDRPeak != 0.32 || *r.Latitude != 45.5 || *r.FlightLevel != 360 {
+		t.Errorf("placed = %+v", r)
+	}
+	// Placed at the waypoint; the level comes from the message's position.
+	if r := got[1]; r.SeverityRank != 3 || *r.Latitude != 46.4 || *r.Longitude != 6.1 || *r.FlightLevel != 20 {
+		t.Errorf("at waypoint = %+v", r)
+	}
+	if r := got[2]; r.Phenomenon != "wind_shear" || r.SeverityRank != -1 || r.AirspeedChange != -15 || *r.Latitude != -33.9 {
+		t.Errorf("shear = %+v", r)
+	}
+
+	if got := TurbulenceReports(ts, []registry.Result{atWaypoint}, nil); len(got) != 1 || got[0].Latitude != nil || got[0].FlightLevel != nil {
+		t.Errorf("unplaced = %+v, want no position or level", got)
+	}
+}
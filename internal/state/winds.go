@@ -0,0 +1,259 @@
+package state
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// WindObservation is a wind (and perhaps temperature) reported by an aircraft
+// at a position and flight level.
+type WindObservation struct {
+	Time        time.Time
+	Latitude    float64
+	Longitude   float64
+	FlightLevel int
+	WindDir     float64  // Degrees true the wind blows from.
+	WindSpeed   float64  // Knots.
+	Temperature *float64 // Static air temperature, degrees Celsius; nil when not reported.
+	Source      string   // Result type that reported the wind.
+}
+
+// WaypointLookup returns the position of a named waypoint, reporting whether
+// it is known.
+type WaypointLookup func(name string) (lat, lon float64, ok bool)
+
+// Wind grid resolution.
+const (
+	windGridDegrees = 1.0       // Size of a cell's latitude/longitude square.
+	windGridLevels  = 10        // Flight levels in a band.
+	windGridPeriod  = time.Hour // Length of a cell's period.
+)
+
+// WindObservations returns the winds aloft reported by a message's parse
+// results, at the message time: PWI route winds at waypoints the lookup can
+// place, H2 wind report layers and ADS-C meteorological groups. PWI climb and
+// descent winds are not used, as they have no position, nor are H2
+// temperatures, which may be deviations from ISA rather than air
+// temperatures. Reports without a direction or a positive flight level are
+// dropped. lookup may be nil, in which case PWI winds are skipped.
+func WindObservations(ts time.Time, results []registry.Result, lookup WaypointLookup) []WindObservation {
+	var out []WindObservation
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+		add := func(lat, lon, fl float64, l map[string]interface{}, dirKey, speedKey, tempKey string) {
+			dir, hasDir := l[dirKey].(float64)
+			speed, hasSpeed := l[speedKey].(float64)
+			if !hasDir || !hasSpeed || speed < 0 || fl <= 0 ||
+				(lat == 0 && lon == 0) || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+				return
+			}
+			o := WindObservation{Time: ts, Latitude: roundCoord(lat), Longitude: roundCoord(lon), FlightLevel: int(math.Round(fl)),
+				WindDir: math.Mod(dir, 360), WindSpeed: speed, Source: r.Type()}
+			if t, ok := l[tempKey].(float64); ok && tempKey != "" {
+				o.Temperature = &t
+			}
+			out = append(out, o)
+		}
+
+		if lookup != nil {
+			for _, rl := range objects(m["route_winds"]) {
+				fl, _ := rl["flight_level"].(float64)
+				for _, wpt := range objects(rl["waypoints"]) {
+					name, _ := wpt["waypoint"].(string)
+					if lat, lon, ok := lookup(name); ok && name != "" {
+						add(lat, lon, fl, wpt, "wind_dir", "wind_speed", "temperature")
+					}
+				}
+			}
+		}
+
+		lat, _ := m["latitude"].(float64)
+		lon, _ := m["longitude"].(float64)
+		for _, l := range objects(m["wind_layers"]) {
+			fl, _ := l["flight_level"].(float64)
+			add(lat, lon, fl, l, "wind_dir", "wind_speed", "")
+		}
+		if meteo, ok := m["meteo"].(map[string]interface{}); ok {
+			if invalid, _ := meteo["wind_dir_invalid"].(bool); !invalid {
+				alt, _ := m["altitude"].(float64)
+				add(lat, lon, alt/100, meteo, "wind_direction_deg", "wind_speed_kts", "temperature_c")
+			}
+		}
+	}
+	return out
+}
+
+// objects returns the elements of a JSON array that are objects.
+func objects(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	var out []map[string]interface{}
+	for _, e := range list {
+		if m, ok := e.(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// GridWinds adds observations up into wind grid cells: one-degree squares,
+// bands of ten flight levels (FL335 to FL344 are FL340) and hours. Cells are
+// returned sorted by time, flight level and position.
+func GridWinds(obs []WindObservation) []storage.WindCell {
+	type key struct {
+		t        int64
+		lat, lon float64
+		fl       int
+	}
+	cells := map[key]*storage.WindCell{}
+	for _, o := range obs {
+		k := key{
+			t:   o.Time.UTC().Truncate(windGridPeriod).Unix(),
+			lat: cellCentre(o.Latitude),
+			lon: cellCentre(o.Longitude),
+			fl:  int(math.Round(float64(o.FlightLevel)/windGridLevels)) * windGridLevels,
+		}
+		c := cells[k]
+		if c == nil {
+			c = &storage.WindCell{Time: time.Unix(k.t, 0).UTC(), Latitude: k.lat, Longitude: k.lon, FlightLevel: k.fl}
+			cells[k] = c
+		}
+		u, v := windComponents(o.WindDir, o.WindSpeed)
+		c.Observations++
+		c.SumU += u
+		c.SumV += v
+		if o.Temperature != nil {
+			c.TempObservations++
+			c.SumTemp += *o.Temperature
+		}
+	}
+
+	out := make([]storage.WindCell, 0, len(cells))
+	for _, c := range cells {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch {
+		case !a.Time.Equal(b.Time):
+			return a.Time.Before(b.Time)
+		case a.FlightLevel != b.FlightLevel:
+			return a.FlightLevel < b.FlightLevel
+		case a.Latitude != b.Latitude:
+			return a.Latitude < b.Latitude
+		default:
+			return a.Longitude < b.Longitude
+		}
+	})
+	return out
+}
+
+// cellCentre returns the centre of the grid square containing a coordinate.
+func cellCentre(v float64) float64 {
+	return math.Floor(v/windGridDegrees)*windGridDegrees + windGridDegrees/2
+}
+
+// windComponents splits a wind into the knots it blows towards the east (u)
+// and the north (v).
+func windComponents(dir, speed float64) (u, v float64) {
+	rad := dir * math.Pi / 180
+	return -speed * math.Sin(rad), -speed * math.Cos(rad)
+}
+
+// WindCellMean is the mean wind and temperature of a wind grid cell.
+type WindCellMean struct {
+	WindDir     float64  // Degrees true the wind blows from, rounded.
+	WindSpeed   float64  // Knots, rounded to one decimal place.
+	Temperature *float64 // Degrees Celsius, rounded to one decimal place; nil when none was reported.
+}
+
+// MeanWind returns the vector mean wind of a cell, and its mean temperature.
+func MeanWind(c storage.WindCell) WindCellMean {
+	var m WindCellMean
+	if c.Observations > 0 {
+		u, v := c.SumU/float64(c.Observations), c.SumV/float64(c.Observations)
+		m.WindSpeed = math.Round(math.Hypot(u, v)*10) / 10
+		if m.WindSpeed > 0 {
+			m.WindDir = math.Mod(math.Round(math.Atan2(-u, -v)*180/math.Pi)+360, 360)
+		}
+	}
+	if c.TempObservations > 0 {
+		t := math.Round(c.SumTemp/float64(c.TempObservations)*10) / 10
+		m.Temperature = &t
+	}
+	return m
+}
+
+// WindGridGeoJSON returns wind grid cells as a GeoJSON FeatureCollection of
+// Points at the cell centres, with the cell's time, flight level, number of
+// observations and mean wind and temperature as properties.
+func WindGridGeoJSON(cells []storage.WindCell) ([]byte, error) {
+	features := make([]geoJSONFeature, 0, len(cells))
+	for _, c := range cells {
+		mean := MeanWind(c)
+		props := map[string]interface{}{
+			"time":         c.Time.UTC().Format(time.RFC3339),
+			"flight_level": c.FlightLevel,
+			"observations": c.Observations,
+			"wind_dir":     mean.WindDir,
+			"wind_speed":   mean.WindSpeed,
+		}
+		if mean.Temperature != nil {
+			props["temperature"] = *mean.Temperature
+		}
+		features = append(features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   &geoJSONGeometry{Type: "Point", Coordinates: []float64{c.Longitude, c.Latitude}},
+			Properties: props,
+		})
+	}
+	return json.Marshal(geoJSONCollection{Type: "FeatureCollection", Features: features})
+}
+
+// WindGridCSVHeader is the header row written by WriteWindGridCSV.
+var WindGridCSVHeader = []string{"time", "latitude", "longitude", "flight_level", "observations", "wind_dir", "wind_speed", "temperature"}
+
+// WriteWindGridCSV writes wind grid cells as CSV with a header row, one cell
+// per row. The temperature is empty when none was reported.
+func WriteWindGridCSV(w io.Writer, cells []storage.WindCell) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(WindGridCSVHeader); err != nil {
+		return err
+	}
+	for _, c := range cells {
+		mean := MeanWind(c)
+		temp := ""
+		if mean.Temperature != nil {
+			temp = strconv.FormatFloat(*mean.Temperature, 'f', -1, 64)
+		}
+		err := cw.Write([]string{
+			c.Time.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(c.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(c.Longitude, 'f', -1, 64),
+			strconv.Itoa(c.FlightLevel),
+			strconv.Itoa(c.Observations),
+			strconv.FormatFloat(mean.WindDir, 'f', -1, 64),
+			strconv.FormatFloat(mean.WindSpeed, 'f', -1, 64),
+			temp,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
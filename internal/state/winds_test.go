@@ -0,0 +1,97 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/registry"
+)
+
+func TestWindObservations(t *testing.T) {
+	ts := time.Date(2026, 10, 17, 10, 25, 0, 0, time.UTC)
+	pwi := mapResult{
+		"climb_winds": []interface{}{map[string]interface{}{"flight_level": 100.0, "wind_dir": 270.0, "wind_speed": 20.0}},
+		"route_winds": []interface{}{map[string]interface{}{
+			"flight_level": 350.0,
+			"waypoints": []interface{}{
+				map[string]interface{}{"waypoint": "BOREE", "wind_dir": 270.0, "wind_speed": 95.0, "temperature": -52.0},
+				map[string]interface{}{"waypoint": "NOWHERE", "wind_dir": 260.0, "wind_speed": 90.0},
+			},
+		}},
+	}
+	h2 := mapResult{"latitude": -33.2, "longitude": 150.4, "wind_layers": []interface{}{
+		map[string]interface{}{"flight_level": 348.0, "wind_dir": 280.0, "wind_speed": 100.0, "temperature": -50.0},
+	}}
+	adsc := mapResult{"latitude": -33.7, "longitude": 150.9, "altitude": 34996.0,
+		"meteo": map[string]interface{}{"wind_speed_kts": 80.0, "wind_direction_deg": 250.0, "temperature_c": -49.5}}
+	invalid := mapResult{"latitude": -33.7, "longitude": 150.9, "altitude": 35000.0,
+		"meteo": map[string]interface{}{"wind_speed_kts": 80.0, "wind_direction_deg": 0.0, "wind_dir_invalid": true}}
+
+	lookup := func(name string) (float64, float64, bool) {
+		return -33.5, 150.5, name == "BOREE"
+	}
+	got := WindObservations(ts, []registry.Result{pwi, h2, adsc, invalid}, lookup)
+	if len(got) != 3 {
+		t.Fatalf("WindObservations() = %+v, want 3", got)
+	}
+	if got[0].FlightLevel != 350 || got[0].WindSpeed != 95 || got[0].Temperature == nil || *got[0].Temperature != -52 {
+		t.Errorf("PWI = %+v", got[0])
+	}
+	if got[1].FlightLevel != 348 || got[1].Temperature != nil {
+		t.Errorf("H2 = %+v, want no temperature", got[1])
+	}
+	if got[2].FlightLevel != 350 || got[2].WindDir != 250 || *got[2].Temperature != -49.5 {
+		t.Errorf("ADS-C = %+v", got[2])
+	}
+
+	if got := WindObservations(ts, []registry.Result{pwi}, nil); len(got) != 0 {
+		t.Errorf("without lookup = %+v, want none", got)
+	}
+
+	cells := GridWinds(got)
+	if len(cells) != 1 {
+		t.Fatalf("GridWinds() = %+v, want one cell", cells)
+	}
+	c := cells[0]
+	if !c.Time.Equal(ts.Truncate(time.Hour)) || c.Latitude != -33.5 || c.Longitude != 150.5 || c.FlightLevel != 350 ||
+		c.Observations != 3 || c.TempObservations != 2 {
+		t.Errorf("cell = %+v", c)
+	}
+	mean := MeanWind(c)
+	if mean.WindDir < 250 || mean.WindDir > 280 || mean.WindSpeed < 85 || mean.WindSpeed > 95 || *mean.Temperature != -50.8 {
+		t.Errorf("MeanWind() = %+v", mean)
+	}
+
+	body, err := WindGridGeoJSON(cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fc geoJSONCollection
+	if err := json.Unmarshal(body, &fc); err != nil || len(fc.Features) != 1 || fc.Features[0].Properties["flight_level"] != 350.0 {
+		t.Errorf("WindGridGeoJSON() = %s, %v", body, err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteWindGridCSV(&buf, cells); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "2026-10-17T10:00:00Z,-33.5,150.5,350,3,") || !strings.HasSuffix(lines[1], ",-50.8") {
+		t.Errorf("WriteWindGridCSV() = %q", buf.String())
+	}
+}
+
+func TestMeanWindWrapsNorth(t *testing.T) {
+	cells := GridWinds([]WindObservation{
+		{Latitude: 10, Longitude: 10, FlightLevel: 300, WindDir: 350, WindSpeed: 50},
+		{Latitude: 10, Longitude: 10, FlightLevel: 300, WindDir: 10, WindSpeed: 50},
+	})
+	mean := MeanWind(cells[0])
+	if mean.WindDir != 0 || math.Abs(mean.WindSpeed-49.2) > 0.1 || mean.Temperature != nil {
+		t.Errorf("MeanWind() = %+v, want 000/49", mean)
+	}
+}
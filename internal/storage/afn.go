@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AFNLogon is a FANS logon (AFN contact) by an aircraft to an ATS facility,
+// stored in afn_logons.
+type AFNLogon struct {
+	Registration string
+	Facility     string // Seven-character ATS facility address, e.g. "BNECAYA".
+	Flight       string
+	Applications string // Applications and versions logged on to, e.g. "ATC01,ADS01".
+	LogonAt      time.Time
+	MessageID    int64 // ClickHouse message ID; 0 if unknown.
+}
+
+// InsertAFNLogon stores a logon. A logon already stored for the aircraft and
+// facility at the same time is skipped, so replaying history does not
+// duplicate it.
+func (d *PostgresDB) InsertAFNLogon(ctx context.Context, l AFNLogon) error {
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO afn_logons (registration, facility, flight, applications, logon_at, message_id)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, 0))
+		ON CONFLICT (registration, facility, logon_at) DO NOTHING
+	`, l.Registration, l.Facility, l.Flight, l.Applications, l.LogonAt, l.MessageID)
+	if err != nil {
+		return fmt.Errorf("insert afn logon %s %s: %w", l.Registration, l.Facility, err)
+	}
+	return nil
+}
+
+// AcknowledgeAFNLogon records a facility's answer to the aircraft's latest
+// unanswered logon to it at or before ts. It reports whether there was one.
+func (d *PostgresDB) AcknowledgeAFNLogon(ctx context.Context, registration, facility string, ts time.Time, accepted bool) (bool, error) {
+	tag, err := d.pool.Exec(ctx, `
+		UPDATE afn_logons SET acknowledged_at = $3, accepted = $4
+		WHERE id = (
+			SELECT id FROM afn_logons
+			WHERE registration = $1 AND facility = $2 AND logon_at <= $3 AND acknowledged_at IS NULL
+			ORDER BY logon_at DESC
+			LIMIT 1
+		)
+	`, registration, facility, ts, accepted)
+	if err != nil {
+		return false, fmt.Errorf("acknowledge afn logon %s %s: %w", registration, facility, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// LinkCPDLCToAFNLogon counts a CPDLC message exchanged with a facility at ts
+// against the aircraft's latest logon to it at or before ts, widening the
+// logon's CPDLC first and last times to include it. It reports whether there
+// was a logon to link to.
+func (d *PostgresDB) LinkCPDLCToAFNLogon(ctx context.Context, registration, facility string, ts time.Time) (bool, error) {
+	tag, err := d.pool.Exec(ctx, `
+		UPDATE afn_logons SET
+			cpdlc_first_at = LEAST(cpdlc_first_at, $3),
+			cpdlc_last_at = GREATEST(cpdlc_last_at, $3),
+			cpdlc_count = cpdlc_count + 1
+		WHERE id = (
+			SELECT id FROM afn_logons
+			WHERE registration = $1 AND facility = $2 AND logon_at <= $3
+			ORDER BY logon_at DESC
+			LIMIT 1
+		)
+	`, registration, facility, ts)
+	if err != nil {
+		return false, fmt.Errorf("link cpdlc to afn logon %s %s: %w", registration, facility, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
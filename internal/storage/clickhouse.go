@@ -120,6 +120,44 @@ func (d *ClickHouseDB) CreateSchema(ctx context.Context) error {
 	// Add bloom filter index for full-text search (ignore error if already exists).
 	_ = d.conn.Exec(ctx, `ALTER TABLE messages ADD INDEX IF NOT EXISTS idx_raw_text_bloom raw_text TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 1`)
 
+	// Skip indexes for searching by airframe and flight, which are not in the
+	// sort key. They cover parts written after they were added; MATERIALIZE
+	// INDEX builds them for older parts.
+	_ = d.conn.Exec(ctx, `ALTER TABLE messages ADD INDEX IF NOT EXISTS idx_tail tail TYPE bloom_filter GRANULARITY 4`)
+	_ = d.conn.Exec(ctx, `ALTER TABLE messages ADD INDEX IF NOT EXISTS idx_flight flight TYPE bloom_filter GRANULARITY 4`)
+
+	// Parser attribution was added after messages. Rows stored before it have an
+	// empty parser_name and version 0.
+	for _, q := range []string{
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS parser_name LowCardinality(String) DEFAULT '' AFTER parser_type`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS parser_version UInt32 DEFAULT 0 AFTER parser_name`,
+	} {
+		if err := d.conn.Exec(ctx, q); err != nil {
+			return fmt.Errorf("add parser version columns: %w", err)
+		}
+	}
+
+	// Receive frequency and station were added later still. Rows stored before
+	// them, and messages from sources that report neither, have frequency 0
+	// and an empty station_id.
+	for _, q := range []string{
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS frequency Float64 DEFAULT 0 AFTER tail`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS station_id LowCardinality(String) DEFAULT '' AFTER frequency`,
+	} {
+		if err := d.conn.Exec(ctx, q); err != nil {
+			return fmt.Errorf("add frequency columns: %w", err)
+		}
+	}
+
+	// The feeder that supplied each message, for merged multi-site feeds.
+	if err := d.conn.Exec(ctx, `ALTER TABLE messages ADD COLUMN IF NOT EXISTS feeder LowCardinality(String) DEFAULT '' AFTER station_id`); err != nil {
+		return fmt.Errorf("add feeder column: %w", err)
+	}
+	// The tenant, or partner network, whose feed supplied each message.
+	if err := d.conn.Exec(ctx, `ALTER TABLE messages ADD COLUMN IF NOT EXISTS tenant LowCardinality(String) DEFAULT '' AFTER feeder`); err != nil {
+		return fmt.Errorf("add tenant column: %w", err)
+	}
+
 	return nil
 }
 
@@ -129,8 +167,14 @@ type CHMessage struct {
 	Timestamp     time.Time
 	Label         string
 	ParserType    string
+	ParserName    string // Parser that produced ParsedJSON; empty for rows stored before versioning.
+	ParserVersion uint32
 	Flight        string
 	Tail          string
+	Frequency     float64 // Receive frequency in MHz; 0 if unknown.
+	StationID     string  // Receiving station; empty if unknown.
+	Feeder        string  // Site that supplied the message; empty if unknown.
+	Tenant        string  // Partner network whose feed supplied the message; empty if unknown.
 	Origin        string
 	Destination   string
 	RawText       string
@@ -146,8 +190,14 @@ type CHInsertParams struct {
 	Timestamp     time.Time
 	Label         string
 	ParserType    string
+	ParserName    string
+	ParserVersion uint32
 	Flight        string
 	Tail          string
+	Frequency     float64 // Receive frequency in MHz; 0 if unknown.
+	StationID     string  // Receiving station; empty if unknown.
+	Feeder        string  // Site that supplied the message; empty if unknown.
+	Tenant        string  // Partner network whose feed supplied the message; empty if unknown.
 	Origin        string
 	Destination   string
 	RawText       string
@@ -166,9 +216,9 @@ func (d *ClickHouseDB) Insert(ctx context.Context, p CHInsertParams) error {
 	missingFields := strings.Join(p.MissingFields, ",")
 
 	err = d.conn.Exec(ctx, `
-		INSERT INTO messages (id, timestamp, label, parser_type, flight, tail, origin, destination, raw_text, parsed_json, missing_fields, confidence)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, p.ID, p.Timestamp, p.Label, p.ParserType, p.Flight, p.Tail, p.Origin, p.Destination, p.RawText, string(parsedJSON), missingFields, p.Confidence)
+		INSERT INTO messages (id, timestamp, label, parser_type, parser_name, parser_version, flight, tail, frequency, station_id, feeder, tenant, origin, destination, raw_text, parsed_json, missing_fields, confidence)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.ID, p.Timestamp, p.Label, p.ParserType, p.ParserName, p.ParserVersion, p.Flight, p.Tail, p.Frequency, p.StationID, p.Feeder, p.Tenant, p.Origin, p.Destination, p.RawText, string(parsedJSON), missingFields, p.Confidence)
 	if err != nil {
 		return fmt.Errorf("insert message: %w", err)
 	}
@@ -183,7 +233,7 @@ func (d *ClickHouseDB) InsertBatch(ctx context.Context, messages []CHInsertParam
 	}
 
 	batch, err := d.conn.PrepareBatch(ctx, `
-		INSERT INTO messages (id, timestamp, label, parser_type, flight, tail, origin, destination, raw_text, parsed_json, missing_fields, confidence)
+		INSERT INTO messages (id, timestamp, label, parser_type, parser_name, parser_version, flight, tail, frequency, station_id, feeder, tenant, origin, destination, raw_text, parsed_json, missing_fields, confidence)
 	`)
 	if err != nil {
 		return fmt.Errorf("prepare batch: %w", err)
@@ -196,7 +246,7 @@ func (d *ClickHouseDB) InsertBatch(ctx context.Context, messages []CHInsertParam
 		}
 		missingFields := strings.Join(p.MissingFields, ",")
 
-		err = batch.Append(p.ID, p.Timestamp, p.Label, p.ParserType, p.Flight, p.Tail, p.Origin, p.Destination, p.RawText, string(parsedJSON), missingFields, p.Confidence)
+		err = batch.Append(p.ID, p.Timestamp, p.Label, p.ParserType, p.ParserName, p.ParserVersion, p.Flight, p.Tail, p.Frequency, p.StationID, p.Feeder, p.Tenant, p.Origin, p.Destination, p.RawText, string(parsedJSON), missingFields, p.Confidence)
 		if err != nil {
 			return fmt.Errorf("append to batch: %w", err)
 		}
@@ -209,14 +259,42 @@ func (d *ClickHouseDB) InsertBatch(ctx context.Context, messages []CHInsertParam
 	return nil
 }
 
+// ReplaceBatch replaces stored messages with new parse results: rows with the
+// same IDs are deleted and the new rows inserted. Used when reparsing.
+func (d *ClickHouseDB) ReplaceBatch(ctx context.Context, messages []CHInsertParams) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	if err := d.conn.Exec(ctx, `DELETE FROM messages WHERE id IN ?`, ids); err != nil {
+		return fmt.Errorf("delete messages: %w", err)
+	}
+	return d.InsertBatch(ctx, messages)
+}
+
 // CHQueryParams contains filtering options for querying messages.
 type CHQueryParams struct {
 	ID           uint64
+	IDs          []uint64 // Restrict to these IDs (ignored when empty).
+	ExcludeIDs   []uint64 // Exclude these IDs.
 	ParserType   string
+	ParserName   string
+	BelowVersion uint32    // Restrict to rows whose parser_version is lower (ignored when 0).
+	AfterID      uint64    // Restrict to IDs above this, for paging by ID.
+	From         time.Time // Only messages at or after this time (zero = no lower bound).
+	To           time.Time // Only messages before this time (zero = no upper bound).
 	Label        string
 	Flight       string
+	Tail         string // Exact match on tail.
 	HasMissing   bool
-	FullText     string // LIKE match on raw_text.
+	FullText     string   // LIKE match on raw_text.
+	Pattern      string   // Regular expression (RE2) matched against raw_text.
+	Category     string   // The "category" of the stored result (free_text).
+	Tenants      []string // Restrict to messages from these tenants (ignored when nil).
 	Limit        int
 	Offset       int
 	OrderBy      string
@@ -232,10 +310,38 @@ func (d *ClickHouseDB) Query(ctx context.Context, p CHQueryParams) ([]CHMessage,
 		conditions = append(conditions, "id = ?")
 		args = append(args, p.ID)
 	}
+	if len(p.IDs) > 0 {
+		conditions = append(conditions, "id IN ?")
+		args = append(args, p.IDs)
+	}
+	if len(p.ExcludeIDs) > 0 {
+		conditions = append(conditions, "id NOT IN ?")
+		args = append(args, p.ExcludeIDs)
+	}
 	if p.ParserType != "" {
 		conditions = append(conditions, "parser_type = ?")
 		args = append(args, p.ParserType)
 	}
+	if p.AfterID != 0 {
+		conditions = append(conditions, "id > ?")
+		args = append(args, p.AfterID)
+	}
+	if !p.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, p.From)
+	}
+	if !p.To.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, p.To)
+	}
+	if p.ParserName != "" {
+		conditions = append(conditions, "parser_name = ?")
+		args = append(args, p.ParserName)
+	}
+	if p.BelowVersion > 0 {
+		conditions = append(conditions, "parser_version < ?")
+		args = append(args, p.BelowVersion)
+	}
 	if p.Label != "" {
 		conditions = append(conditions, "label = ?")
 		args = append(args, p.Label)
@@ -244,6 +350,10 @@ func (d *ClickHouseDB) Query(ctx context.Context, p CHQueryParams) ([]CHMessage,
 		conditions = append(conditions, "flight LIKE ?")
 		args = append(args, "%"+p.Flight+"%")
 	}
+	if p.Tail != "" {
+		conditions = append(conditions, "tail = ?")
+		args = append(args, p.Tail)
+	}
 	if p.HasMissing {
 		conditions = append(conditions, "missing_fields != ''")
 	}
@@ -251,8 +361,20 @@ func (d *ClickHouseDB) Query(ctx context.Context, p CHQueryParams) ([]CHMessage,
 		conditions = append(conditions, "raw_text LIKE ?")
 		args = append(args, "%"+p.FullText+"%")
 	}
+	if p.Pattern != "" {
+		conditions = append(conditions, "match(raw_text, ?)")
+		args = append(args, p.Pattern)
+	}
+	if p.Category != "" {
+		conditions = append(conditions, "JSONExtractString(parsed_json, 'category') = ?")
+		args = append(args, p.Category)
+	}
+	if p.Tenants != nil {
+		conditions = append(conditions, "tenant IN ?")
+		args = append(args, p.Tenants)
+	}
 
-	query := `SELECT id, timestamp, label, parser_type, flight, tail, origin, destination, raw_text, parsed_json, missing_fields, confidence, created_at FROM messages`
+	query := `SELECT id, timestamp, label, parser_type, parser_name, parser_version, flight, tail, frequency, station_id, feeder, tenant, origin, destination, raw_text, parsed_json, missing_fields, confidence, created_at FROM messages`
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -287,8 +409,8 @@ func (d *ClickHouseDB) Query(ctx context.Context, p CHQueryParams) ([]CHMessage,
 	var messages []CHMessage
 	for rows.Next() {
 		var m CHMessage
-		err := rows.Scan(&m.ID, &m.Timestamp, &m.Label, &m.ParserType, &m.Flight, &m.Tail,
-			&m.Origin, &m.Destination, &m.RawText, &m.ParsedJSON, &m.MissingFields, &m.Confidence, &m.CreatedAt)
+		err := rows.Scan(&m.ID, &m.Timestamp, &m.Label, &m.ParserType, &m.ParserName, &m.ParserVersion, &m.Flight, &m.Tail,
+			&m.Frequency, &m.StationID, &m.Feeder, &m.Tenant, &m.Origin, &m.Destination, &m.RawText, &m.ParsedJSON, &m.MissingFields, &m.Confidence, &m.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan row: %w", err)
 		}
@@ -423,6 +545,42 @@ func (d *ClickHouseDB) CountByType(ctx context.Context) (map[string]uint64, erro
 	return counts, nil
 }
 
+// ParserVersionCount is the number of stored messages parsed by one version
+// of a parser.
+type ParserVersionCount struct {
+	ParserName    string
+	ParserVersion uint32
+	Count         uint64
+}
+
+// CountByParserVersion returns message counts grouped by parser name and
+// version. Rows stored before versioning have an empty name and version 0.
+func (d *ClickHouseDB) CountByParserVersion(ctx context.Context) ([]ParserVersionCount, error) {
+	rows, err := d.conn.Query(ctx, `
+		SELECT parser_name, parser_version, count()
+		FROM messages
+		GROUP BY parser_name, parser_version
+		ORDER BY parser_name, parser_version
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []ParserVersionCount
+	for rows.Next() {
+		var c ParserVersionCount
+		if err := rows.Scan(&c.ParserName, &c.ParserVersion, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan count by parser version: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate count by parser version: %w", err)
+	}
+	return counts, nil
+}
+
 // Distinct returns distinct values for a given column.
 func (d *ClickHouseDB) Distinct(ctx context.Context, column string) ([]string, error) {
 	// Validate column name to prevent SQL injection.
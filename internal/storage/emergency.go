@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EmergencyEvent is an emergency or abnormal situation reported by a message,
+// stored in emergency_events.
+type EmergencyEvent struct {
+	ID           int64     `json:"id,omitempty"`
+	Kind         string    `json:"kind"` // e.g. "adsc_emergency", "mayday", "pan", "squawk_7700".
+	Timestamp    time.Time `json:"timestamp"`
+	ICAOHex      string    `json:"icao_hex,omitempty"`
+	Registration string    `json:"registration,omitempty"`
+	Flight       string    `json:"flight,omitempty"`
+	Label        string    `json:"label,omitempty"`
+	Source       string    `json:"source,omitempty"` // Result type of the reporting message.
+	Detail       string    `json:"detail,omitempty"`
+	Latitude     *float64  `json:"latitude,omitempty"`
+	Longitude    *float64  `json:"longitude,omitempty"`
+	Altitude     *int      `json:"altitude,omitempty"`
+	MessageID    int64     `json:"message_id,omitempty"` // ClickHouse message ID; 0 if unknown.
+}
+
+// InsertEmergencyEvents stores emergency events. An event of the same kind
+// already stored for the aircraft at the same time is skipped, so replaying
+// history does not duplicate them.
+func (d *PostgresDB) InsertEmergencyEvents(ctx context.Context, events []EmergencyEvent) error {
+	for _, e := range events {
+		_, err := d.pool.Exec(ctx, `
+			INSERT INTO emergency_events (kind, ts, icao_hex, registration, flight, label,
+				source, detail, latitude, longitude, altitude, message_id)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''),
+				NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, NULLIF($12, 0))
+			ON CONFLICT (kind, ts, icao_hex, registration) DO NOTHING
+		`, e.Kind, e.Timestamp, e.ICAOHex, e.Registration, e.Flight, e.Label,
+			e.Source, e.Detail, e.Latitude, e.Longitude, e.Altitude, e.MessageID)
+		if err != nil {
+			return fmt.Errorf("insert emergency event %s: %w", e.Kind, err)
+		}
+	}
+	return nil
+}
+
+// GetEmergencyEvents retrieves the emergency events since a time, newest
+// first, up to limit. An empty kind returns every kind.
+func (d *PostgresDB) GetEmergencyEvents(ctx context.Context, kind string, since time.Time, limit int) ([]EmergencyEvent, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT id, kind, ts, icao_hex, registration, COALESCE(flight, ''), COALESCE(label, ''),
+		       COALESCE(source, ''), COALESCE(detail, ''), latitude, longitude, altitude,
+		       COALESCE(message_id, 0)
+		FROM emergency_events
+		WHERE ts >= $1 AND ($2 = '' OR kind = $2)
+		ORDER BY ts DESC, id DESC
+		LIMIT $3
+	`, since, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []EmergencyEvent
+	for rows.Next() {
+		var e EmergencyEvent
+		err := rows.Scan(&e.ID, &e.Kind, &e.Timestamp, &e.ICAOHex, &e.Registration, &e.Flight, &e.Label,
+			&e.Source, &e.Detail, &e.Latitude, &e.Longitude, &e.Altitude, &e.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ETAReport is an estimated time of arrival reported for a flight by one kind
+// of source, stored in eta_reports.
+type ETAReport struct {
+	FlightKey   string
+	Source      string // Kind of source, e.g. "position" or "cpdlc".
+	ETA         time.Time
+	ReportedAt  time.Time
+	Destination string // Airport the ETA is for, if given.
+	MessageID   int64
+}
+
+// RecordETAReport stores an ETA report. A flight keeps the latest report of
+// each source: an earlier one is ignored.
+func (d *PostgresDB) RecordETAReport(ctx context.Context, r ETAReport) error {
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO eta_reports (flight_key, source, eta, reported_at, destination, message_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6::bigint, 0))
+		ON CONFLICT (flight_key, source) DO UPDATE SET
+			eta = EXCLUDED.eta,
+			reported_at = EXCLUDED.reported_at,
+			destination = EXCLUDED.destination,
+			message_id = EXCLUDED.message_id
+		WHERE eta_reports.reported_at <= EXCLUDED.reported_at
+	`, r.FlightKey, r.Source, r.ETA, r.ReportedAt, r.Destination, r.MessageID)
+	if err != nil {
+		return fmt.Errorf("record eta report %s/%s: %w", r.FlightKey, r.Source, err)
+	}
+	return nil
+}
+
+// GetETAReports returns the latest ETA report of each source for a flight,
+// newest first.
+func (d *PostgresDB) GetETAReports(ctx context.Context, flightKey string) ([]ETAReport, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT source, eta, reported_at, COALESCE(destination, ''), COALESCE(message_id, 0)
+		FROM eta_reports
+		WHERE flight_key = $1
+		ORDER BY reported_at DESC
+	`, flightKey)
+	if err != nil {
+		return nil, fmt.Errorf("get eta reports %s: %w", flightKey, err)
+	}
+	defer rows.Close()
+
+	var reports []ETAReport
+	for rows.Next() {
+		r := ETAReport{FlightKey: flightKey}
+		if err := rows.Scan(&r.Source, &r.ETA, &r.ReportedAt, &r.Destination, &r.MessageID); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FeederStats is the traffic one feeder supplied to a merged multi-site feed.
+type FeederStats struct {
+	Feeder    string
+	Messages  uint64    // Messages stored from the feeder, copies included.
+	Exclusive uint64    // Messages no other feeder supplied.
+	Stations  uint64    // Distinct receiving stations behind the feeder.
+	Days      uint64    // Days with at least one message.
+	FirstSeen time.Time // Time of the first message.
+	LastSeen  time.Time // Time of the latest message.
+}
+
+// FeederCounts returns the traffic of each feeder between from and to (zero
+// times are unbounded), busiest first. Copies of a message are matched on
+// tail, label and text within the same minute; a message only one feeder
+// supplied counts as exclusive to it. Messages without a feeder are left out.
+func (d *ClickHouseDB) FeederCounts(ctx context.Context, from, to time.Time) ([]FeederStats, error) {
+	conditions := []string{"feeder != ''"}
+	var args []interface{}
+	if !from.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, from)
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, to)
+	}
+
+	rows, err := d.conn.Query(ctx, `
+		SELECT feeder, count() AS cnt, countIf(sites = 1), uniqExact(station_id),
+		       uniqExact(toDate(timestamp)), min(timestamp), max(timestamp)
+		FROM (
+			SELECT feeder, station_id, timestamp,
+			       uniqExact(feeder) OVER (PARTITION BY tail, label, raw_text, toStartOfMinute(timestamp)) AS sites
+			FROM messages
+			WHERE `+strings.Join(conditions, " AND ")+`
+		)
+		GROUP BY feeder
+		ORDER BY cnt DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []FeederStats
+	for rows.Next() {
+		var s FeederStats
+		if err := rows.Scan(&s.Feeder, &s.Messages, &s.Exclusive, &s.Stations, &s.Days, &s.FirstSeen, &s.LastSeen); err != nil {
+			return nil, fmt.Errorf("scan feeder stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate feeder stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"flag"
+
+	"acars_parser/internal/envflag"
+)
+
+// AddPostgresFlags registers the PostgreSQL connection flags on fs, with
+// defaults from the POSTGRES_* environment variables, and returns the
+// PostgresConfig they fill.
+func AddPostgresFlags(fs *flag.FlagSet) *PostgresConfig {
+	c := &PostgresConfig{}
+	fs.StringVar(&c.Host, "pg-host", envflag.String("POSTGRES_HOST", "localhost"), "PostgreSQL host")
+	fs.IntVar(&c.Port, "pg-port", envflag.Int("POSTGRES_PORT", 5432), "PostgreSQL port")
+	fs.StringVar(&c.User, "pg-user", envflag.String("POSTGRES_USER", "acars"), "PostgreSQL user")
+	fs.StringVar(&c.Password, "pg-password", envflag.String("POSTGRES_PASSWORD", "acars"), "PostgreSQL password")
+	fs.StringVar(&c.Database, "pg-database", envflag.String("POSTGRES_DATABASE", "acars_state"), "PostgreSQL database")
+	fs.StringVar(&c.SSLMode, "pg-sslmode", envflag.String("POSTGRES_SSLMODE", "disable"), "PostgreSQL SSL mode (disable, require, verify-ca, verify-full)")
+	return c
+}
+
+// AddClickHouseFlags registers the ClickHouse connection flags on fs, with
+// defaults from the CLICKHOUSE_* environment variables, and returns the
+// ClickHouseConfig they fill.
+func AddClickHouseFlags(fs *flag.FlagSet) *ClickHouseConfig {
+	c := &ClickHouseConfig{}
+	fs.StringVar(&c.Host, "ch-host", envflag.String("CLICKHOUSE_HOST", "localhost"), "ClickHouse host")
+	fs.IntVar(&c.Port, "ch-port", envflag.Int("CLICKHOUSE_PORT", 9000), "ClickHouse port")
+	fs.StringVar(&c.User, "ch-user", envflag.String("CLICKHOUSE_USER", "default"), "ClickHouse user")
+	fs.StringVar(&c.Password, "ch-password", envflag.String("CLICKHOUSE_PASSWORD", ""), "ClickHouse password")
+	fs.StringVar(&c.Database, "ch-database", envflag.String("CLICKHOUSE_DATABASE", "acars"), "ClickHouse database")
+	return c
+}
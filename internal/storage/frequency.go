@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FrequencyCount is the number of messages received on one frequency with
+// one label by one station on one day.
+type FrequencyCount struct {
+	Day       time.Time
+	Frequency float64 // MHz.
+	Label     string
+	StationID string
+	Count     uint64
+}
+
+// FrequencyParams filters the frequency counts. Zero values match everything.
+type FrequencyParams struct {
+	From  time.Time
+	To    time.Time
+	Label string
+}
+
+// FrequencyCounts returns the messages per frequency per label per day and
+// receiving station, busiest first. Messages without a known frequency are
+// left out.
+func (d *ClickHouseDB) FrequencyCounts(ctx context.Context, p FrequencyParams) ([]FrequencyCount, error) {
+	conditions := []string{"frequency > 0"}
+	var args []interface{}
+	if !p.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, p.From)
+	}
+	if !p.To.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, p.To)
+	}
+	if p.Label != "" {
+		conditions = append(conditions, "label = ?")
+		args = append(args, p.Label)
+	}
+
+	rows, err := d.conn.Query(ctx, `
+		SELECT toDate(timestamp) AS day, round(frequency, 3) AS freq, label, station_id, count() AS cnt
+		FROM messages
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY day, freq, label, station_id
+		ORDER BY cnt DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []FrequencyCount
+	for rows.Next() {
+		var c FrequencyCount
+		if err := rows.Scan(&c.Day, &c.Frequency, &c.Label, &c.StationID, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan frequency count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate frequency counts: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GroundStation is a ground station heard, stored in ground_stations, with
+// its reference data from ground_station_info when known.
+type GroundStation struct {
+	Kind         string
+	StationID    string
+	Provider     string
+	Name         string
+	Region       string
+	MessageCount int64
+	FirstHeard   time.Time
+	LastHeard    time.Time
+}
+
+// GroundStationInfo is the reference data of a ground station, stored in
+// ground_station_info.
+type GroundStationInfo struct {
+	Kind      string
+	StationID string
+	Provider  string
+	Name      string
+	Region    string
+}
+
+// RecordGroundStation counts a message exchanged with a ground station at ts,
+// widening the station's first and last heard times to include it.
+func (d *PostgresDB) RecordGroundStation(ctx context.Context, kind, stationID string, ts time.Time) error {
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO ground_stations (kind, station_id, message_count, first_heard, last_heard)
+		VALUES ($1, $2, 1, $3, $3)
+		ON CONFLICT (kind, station_id) DO UPDATE SET
+			message_count = ground_stations.message_count + 1,
+			first_heard = LEAST(ground_stations.first_heard, EXCLUDED.first_heard),
+			last_heard = GREATEST(ground_stations.last_heard, EXCLUDED.last_heard)
+	`, kind, stationID, ts)
+	if err != nil {
+		return fmt.Errorf("record ground station %s %s: %w", kind, stationID, err)
+	}
+	return nil
+}
+
+// UpsertGroundStationInfo inserts or updates ground station reference records
+// in one transaction.
+func (d *PostgresDB) UpsertGroundStationInfo(ctx context.Context, stations []GroundStationInfo) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, s := range stations {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO ground_station_info (kind, station_id, provider, name, region, updated_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NOW())
+			ON CONFLICT (kind, station_id) DO UPDATE SET
+				provider = EXCLUDED.provider,
+				name = EXCLUDED.name,
+				region = EXCLUDED.region,
+				updated_at = NOW()
+		`, s.Kind, s.StationID, s.Provider, s.Name, s.Region)
+		if err != nil {
+			return fmt.Errorf("upsert ground station %s %s: %w", s.Kind, s.StationID, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ListGroundStations retrieves the ground stations heard, with their
+// reference data, busiest first. An empty kind returns every kind.
+func (d *PostgresDB) ListGroundStations(ctx context.Context, kind string) ([]GroundStation, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT g.kind, g.station_id, COALESCE(i.provider, ''), COALESCE(i.name, ''), COALESCE(i.region, ''),
+		       g.message_count, g.first_heard, g.last_heard
+		FROM ground_stations g
+		LEFT JOIN ground_station_info i ON i.kind = g.kind AND i.station_id = g.station_id
+		WHERE $1 = '' OR g.kind = $1
+		ORDER BY g.message_count DESC, g.kind, g.station_id
+	`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stations []GroundStation
+	for rows.Next() {
+		var s GroundStation
+		err := rows.Scan(&s.Kind, &s.StationID, &s.Provider, &s.Name, &s.Region,
+			&s.MessageCount, &s.FirstHeard, &s.LastHeard)
+		if err != nil {
+			return nil, err
+		}
+		stations = append(stations, s)
+	}
+	return stations, rows.Err()
+}
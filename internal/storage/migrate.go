@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// postgresMigrations holds the PostgreSQL schema migrations. Each migration is
+// a pair of files, NNNN_name.up.sql and NNNN_name.down.sql, applied in version
+// order. Released migrations must not be edited; add a new one instead.
+//
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// migrationLockID is the advisory lock held while migrating, so that two
+// processes starting together do not apply the same migration twice.
+const migrationLockID = 0x61636172 // "acar"
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string // Empty if the migration cannot be reverted.
+}
+
+// AppliedMigration is a migration recorded in schema_migrations.
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the migrations in a directory of fsys, in version
+// order. Every version must have an up file and versions must be unique.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", e.Name(), err)
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// PostgresMigrations returns the embedded PostgreSQL migrations.
+func PostgresMigrations() ([]Migration, error) {
+	return LoadMigrations(postgresMigrations, "migrations/postgres")
+}
+
+// CreateSchema brings the PostgreSQL schema up to date by applying every
+// pending migration.
+func (d *PostgresDB) CreateSchema(ctx context.Context) error {
+	_, err := d.MigrateUp(ctx, 0)
+	return err
+}
+
+// AppliedMigrations returns the migrations recorded in schema_migrations, in
+// version order.
+func (d *PostgresDB) AppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	if err := d.createMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := d.pool.Query(ctx, `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// MigrateUp applies the pending migrations up to and including version
+// target, or all of them if target is 0. It returns the migrations applied.
+func (d *PostgresDB) MigrateUp(ctx context.Context, target int) ([]Migration, error) {
+	migrations, err := PostgresMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = d.withMigrationLock(ctx, func(conn *pgx.Conn, applied map[int]bool) error {
+		for _, m := range migrations {
+			if applied[m.Version] || (target > 0 && m.Version > target) {
+				continue
+			}
+			if err := runMigration(ctx, conn, m, m.Up, true); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateDown reverts the latest applied migrations, newest first, until only
+// those up to version target remain. It returns the migrations reverted.
+func (d *PostgresDB) MigrateDown(ctx context.Context, target int) ([]Migration, error) {
+	migrations, err := PostgresMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = d.withMigrationLock(ctx, func(conn *pgx.Conn, applied map[int]bool) error {
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if !applied[m.Version] || m.Version <= target {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s cannot be reverted", m.Version, m.Name)
+			}
+			if err := runMigration(ctx, conn, m, m.Down, false); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// createMigrationsTable creates the table recording applied migrations.
+func (d *PostgresDB) createMigrationsTable(ctx context.Context) error {
+	_, err := d.pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version         INTEGER PRIMARY KEY,
+			name            TEXT NOT NULL,
+			applied_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// withMigrationLock runs fn on one connection holding the migration advisory
+// lock, with the set of versions already applied.
+func (d *PostgresDB) withMigrationLock(ctx context.Context, fn func(conn *pgx.Conn, applied map[int]bool) error) error {
+	if err := d.createMigrationsTable(ctx); err != nil {
+		return err
+	}
+	conn, err := d.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("lock migrations: %w", err)
+	}
+	defer func() { _, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID) }()
+
+	// Read the applied versions under the lock, as another process may have
+	// just finished migrating.
+	rows, err := conn.Query(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	applied := make(map[int]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+	return fn(conn.Conn(), applied)
+}
+
+// runMigration runs one direction of a migration and records it, in a single
+// transaction.
+func runMigration(ctx context.Context, conn *pgx.Conn, m Migration, sql string, up bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", m.Version, m.Name, direction, err)
+	}
+	if up {
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("record migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	return tx.Commit(ctx)
+}
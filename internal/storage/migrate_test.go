@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_add_b.up.sql":    {Data: []byte("ALTER TABLE a ADD COLUMN b INTEGER;")},
+		"m/0002_add_b.down.sql":  {Data: []byte("ALTER TABLE a DROP COLUMN b;")},
+		"m/0001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER);")},
+	}
+	got, err := LoadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Version != 1 || got[0].Name != "create_a" || got[1].Version != 2 {
+		t.Fatalf("LoadMigrations() = %+v", got)
+	}
+	if got[0].Down != "" || got[1].Down == "" {
+		t.Errorf("down migrations = %q, %q", got[0].Down, got[1].Down)
+	}
+
+	bad := []fstest.MapFS{
+		{"m/0001_a.down.sql": {Data: []byte("DROP TABLE a;")}},
+		{"m/0001_a.up.sql": {}, "m/0001_b.down.sql": {}},
+		{"m/create_a.sql": {}},
+	}
+	for _, fsys := range bad {
+		if _, err := LoadMigrations(fsys, "m"); err == nil {
+			t.Errorf("LoadMigrations(%v) succeeded", fsys)
+		}
+	}
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	migrations, err := PostgresMigrations()
+	if err != nil {
+		t.Fatalf("PostgresMigrations() error = %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s: versions must be consecutive from 1", m.Version, m.Name)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrateRoundTrip(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+	ctx := context.Background()
+
+	migrations, _ := PostgresMigrations()
+	latest := migrations[len(migrations)-1].Version
+
+	// Revert and reapply the latest migration.
+	reverted, err := pg.MigrateDown(ctx, latest-1)
+	if err != nil || len(reverted) != 1 {
+		t.Fatalf("MigrateDown() = %v, %v", reverted, err)
+	}
+	applied, err := pg.MigrateUp(ctx, 0)
+	if err != nil || len(applied) != 1 || applied[0].Version != latest {
+		t.Fatalf("MigrateUp() = %v, %v", applied, err)
+	}
+	if again, err := pg.MigrateUp(ctx, 0); err != nil || len(again) != 0 {
+		t.Errorf("second MigrateUp() = %v, %v", again, err)
+	}
+}
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS airlines;
+DROP TABLE IF EXISTS flight_enrichment;
+DROP TABLE IF EXISTS golden_annotations;
+DROP TABLE IF EXISTS flight_positions;
+DROP TABLE IF EXISTS flight_history;
+DROP TABLE IF EXISTS flight_state;
+DROP TABLE IF EXISTS atis_current;
+DROP TABLE IF EXISTS aircraft_callsigns;
+DROP TABLE IF EXISTS route_aircraft;
+DROP TABLE IF EXISTS route_legs;
+DROP TABLE IF EXISTS routes;
+DROP TABLE IF EXISTS waypoints;
+DROP TABLE IF EXISTS aircraft;
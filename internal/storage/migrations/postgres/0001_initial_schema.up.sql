@@ -0,0 +1,231 @@
+-- Baseline schema. Every statement is idempotent so that databases created
+-- before schema migrations were introduced can be brought under them.
+
+-- Reference data: Aircraft
+CREATE TABLE IF NOT EXISTS aircraft (
+	icao_hex        TEXT PRIMARY KEY,
+	registration    TEXT NOT NULL,
+	type_code       TEXT,
+	operator        TEXT,
+	first_seen      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_seen       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	msg_count       INTEGER NOT NULL DEFAULT 1,
+	synced_at       TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_aircraft_registration ON aircraft(registration);
+CREATE INDEX IF NOT EXISTS idx_aircraft_synced ON aircraft(synced_at);
+
+-- Reference data: Waypoints
+CREATE TABLE IF NOT EXISTS waypoints (
+	name            TEXT PRIMARY KEY,
+	latitude        DOUBLE PRECISION NOT NULL,
+	longitude       DOUBLE PRECISION NOT NULL,
+	source_count    INTEGER NOT NULL DEFAULT 1,
+	first_seen      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_seen       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	synced_at       TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_waypoints_synced ON waypoints(synced_at);
+
+-- Reference data: Routes
+CREATE TABLE IF NOT EXISTS routes (
+	id                  SERIAL PRIMARY KEY,
+	flight_pattern      TEXT NOT NULL,
+	origin_icao         TEXT NOT NULL,
+	dest_icao           TEXT NOT NULL,
+	is_multi_stop       BOOLEAN NOT NULL DEFAULT FALSE,
+	observation_count   INTEGER NOT NULL DEFAULT 1,
+	first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	synced_at           TIMESTAMPTZ,
+	UNIQUE(flight_pattern, origin_icao, dest_icao)
+);
+
+CREATE INDEX IF NOT EXISTS idx_routes_pattern ON routes(flight_pattern);
+CREATE INDEX IF NOT EXISTS idx_routes_synced ON routes(synced_at);
+
+-- Reference data: Route legs
+CREATE TABLE IF NOT EXISTS route_legs (
+	route_id            INTEGER NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+	sequence            INTEGER NOT NULL,
+	origin_icao         TEXT NOT NULL,
+	dest_icao           TEXT NOT NULL,
+	observation_count   INTEGER NOT NULL DEFAULT 1,
+	first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (route_id, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_route_legs_airports ON route_legs(origin_icao, dest_icao);
+
+-- Reference data: Aircraft on routes
+CREATE TABLE IF NOT EXISTS route_aircraft (
+	route_id            INTEGER NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+	registration        TEXT NOT NULL,
+	observation_count   INTEGER NOT NULL DEFAULT 1,
+	first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (route_id, registration)
+);
+
+CREATE INDEX IF NOT EXISTS idx_route_aircraft_registration ON route_aircraft(registration);
+
+-- Reference data: Callsign prefixes
+CREATE TABLE IF NOT EXISTS aircraft_callsigns (
+	registration        TEXT PRIMARY KEY,
+	iata_prefix         TEXT NOT NULL,
+	icao_prefix         TEXT NOT NULL,
+	observation_count   INTEGER NOT NULL DEFAULT 1,
+	first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_aircraft_callsigns_iata ON aircraft_callsigns(iata_prefix);
+
+-- Operational: Current ATIS
+CREATE TABLE IF NOT EXISTS atis_current (
+	airport_icao    TEXT PRIMARY KEY,
+	letter          TEXT NOT NULL,
+	atis_type       TEXT,
+	atis_time       TEXT,
+	raw_text        TEXT,
+	runways         JSONB,
+	approaches      JSONB,
+	wind            TEXT,
+	visibility      TEXT,
+	clouds          TEXT,
+	temperature     TEXT,
+	dew_point       TEXT,
+	qnh             TEXT,
+	remarks         JSONB,
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	synced_at       TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_atis_current_synced ON atis_current(synced_at);
+
+-- Ephemeral: Flight state
+CREATE TABLE IF NOT EXISTS flight_state (
+	key             TEXT PRIMARY KEY,
+	icao_hex        TEXT,
+	registration    TEXT,
+	flight_number   TEXT,
+	origin          TEXT,
+	destination     TEXT,
+	latitude        DOUBLE PRECISION,
+	longitude       DOUBLE PRECISION,
+	altitude        INTEGER,
+	ground_speed    INTEGER,
+	track           INTEGER,
+	waypoints       JSONB,
+	first_seen      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_seen       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	msg_count       INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_flight_state_flight ON flight_state(flight_number);
+CREATE INDEX IF NOT EXISTS idx_flight_state_last_seen ON flight_state(last_seen);
+
+-- Completed flights archived from flight_state
+CREATE TABLE IF NOT EXISTS flight_history (
+	id              BIGSERIAL PRIMARY KEY,
+	key             TEXT NOT NULL,
+	icao_hex        TEXT,
+	registration    TEXT,
+	flight_number   TEXT,
+	origin          TEXT,
+	destination     TEXT,
+	latitude        DOUBLE PRECISION,
+	longitude       DOUBLE PRECISION,
+	altitude        INTEGER,
+	ground_speed    INTEGER,
+	track           INTEGER,
+	waypoints       JSONB,
+	first_seen      TIMESTAMPTZ NOT NULL,
+	last_seen       TIMESTAMPTZ NOT NULL,
+	msg_count       INTEGER NOT NULL,
+	completed_at    TIMESTAMPTZ NOT NULL,
+	completion      TEXT NOT NULL,
+	archived_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_flight_history_icao ON flight_history(icao_hex, first_seen DESC);
+CREATE INDEX IF NOT EXISTS idx_flight_history_reg ON flight_history(registration, first_seen DESC);
+CREATE INDEX IF NOT EXISTS idx_flight_history_flight ON flight_history(flight_number);
+
+-- Positions of current and archived flights, keyed like flight_state
+CREATE TABLE IF NOT EXISTS flight_positions (
+	flight_key      TEXT NOT NULL,
+	ts              TIMESTAMPTZ NOT NULL,
+	latitude        DOUBLE PRECISION NOT NULL,
+	longitude       DOUBLE PRECISION NOT NULL,
+	altitude        INTEGER,
+	source          TEXT,
+	PRIMARY KEY (flight_key, ts, latitude, longitude)
+);
+
+-- Golden annotations (references ClickHouse message IDs)
+CREATE TABLE IF NOT EXISTS golden_annotations (
+	message_id      BIGINT PRIMARY KEY,
+	is_golden       BOOLEAN NOT NULL DEFAULT FALSE,
+	annotation      TEXT,
+	expected_json   JSONB,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Flight enrichment data for ADS-B tracking integration
+CREATE TABLE IF NOT EXISTS flight_enrichment (
+	id              SERIAL PRIMARY KEY,
+	icao_hex        VARCHAR(6) NOT NULL,
+	callsign        VARCHAR(10),
+	flight_date     DATE NOT NULL,
+	origin           VARCHAR(4),
+	destination      VARCHAR(4),
+	route            JSONB,
+	eta              TIMESTAMPTZ,
+	departure_runway VARCHAR(6),
+	arrival_runway   VARCHAR(6),
+	sid              VARCHAR(12),
+	squawk           VARCHAR(4),
+	pax_count        INTEGER,
+	pax_breakdown    JSONB,
+	created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (icao_hex, callsign, flight_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_lookup
+	ON flight_enrichment (icao_hex, callsign, flight_date);
+CREATE INDEX IF NOT EXISTS idx_enrichment_hex_date
+	ON flight_enrichment (icao_hex, flight_date);
+
+-- Airline reference data (IATA/ICAO designators), imported rather than derived
+CREATE TABLE IF NOT EXISTS airlines (
+	icao_code       VARCHAR(3) PRIMARY KEY,
+	iata_code       VARCHAR(2),
+	name            TEXT,
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_airlines_iata ON airlines(iata_code);
+
+CREATE INDEX IF NOT EXISTS idx_golden_is_golden ON golden_annotations(is_golden) WHERE is_golden = TRUE;
+
+-- Columns added before schema migrations were introduced. Databases created
+-- by earlier releases may lack them.
+ALTER TABLE golden_annotations ADD COLUMN IF NOT EXISTS flagged BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE golden_annotations ADD COLUMN IF NOT EXISTS flag_reason TEXT;
+CREATE INDEX IF NOT EXISTS idx_golden_flagged ON golden_annotations(flagged) WHERE flagged = TRUE;
+
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS star VARCHAR(12);
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS sid_waypoints JSONB;
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS star_waypoints JSONB;
+
+ALTER TABLE flight_state ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+ALTER TABLE flight_state ADD COLUMN IF NOT EXISTS completion TEXT;
+
+ALTER TABLE flight_positions ADD COLUMN IF NOT EXISTS rejection TEXT;
+CREATE INDEX IF NOT EXISTS idx_flight_positions_rejected ON flight_positions(source) WHERE rejection IS NOT NULL;
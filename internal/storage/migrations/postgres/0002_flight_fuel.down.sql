@@ -0,0 +1,8 @@
+ALTER TABLE flight_history DROP COLUMN IF EXISTS fuel_in_kg;
+ALTER TABLE flight_history DROP COLUMN IF EXISTS fuel_on_kg;
+ALTER TABLE flight_history DROP COLUMN IF EXISTS fuel_off_kg;
+ALTER TABLE flight_history DROP COLUMN IF EXISTS fuel_out_kg;
+ALTER TABLE flight_state DROP COLUMN IF EXISTS fuel_in_kg;
+ALTER TABLE flight_state DROP COLUMN IF EXISTS fuel_on_kg;
+ALTER TABLE flight_state DROP COLUMN IF EXISTS fuel_off_kg;
+ALTER TABLE flight_state DROP COLUMN IF EXISTS fuel_out_kg;
@@ -0,0 +1,9 @@
+-- Fuel on board in kg at each OOOI event.
+ALTER TABLE flight_state ADD COLUMN IF NOT EXISTS fuel_out_kg INTEGER;
+ALTER TABLE flight_state ADD COLUMN IF NOT EXISTS fuel_off_kg INTEGER;
+ALTER TABLE flight_state ADD COLUMN IF NOT EXISTS fuel_on_kg INTEGER;
+ALTER TABLE flight_state ADD COLUMN IF NOT EXISTS fuel_in_kg INTEGER;
+ALTER TABLE flight_history ADD COLUMN IF NOT EXISTS fuel_out_kg INTEGER;
+ALTER TABLE flight_history ADD COLUMN IF NOT EXISTS fuel_off_kg INTEGER;
+ALTER TABLE flight_history ADD COLUMN IF NOT EXISTS fuel_on_kg INTEGER;
+ALTER TABLE flight_history ADD COLUMN IF NOT EXISTS fuel_in_kg INTEGER;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS comm_assignments;
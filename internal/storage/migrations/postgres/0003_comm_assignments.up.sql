@@ -0,0 +1,12 @@
+-- SELCAL codes and frequencies assigned to flights, keyed like flight_state
+CREATE TABLE IF NOT EXISTS comm_assignments (
+	flight_key      TEXT NOT NULL,
+	ts              TIMESTAMPTZ NOT NULL,
+	kind            TEXT NOT NULL,
+	selcal          TEXT NOT NULL DEFAULT '',
+	frequency_khz   INTEGER NOT NULL DEFAULT 0,
+	band            TEXT,
+	unit            TEXT,
+	source          TEXT,
+	PRIMARY KEY (flight_key, ts, kind, selcal, frequency_khz)
+);
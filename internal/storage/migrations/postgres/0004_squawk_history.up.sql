@@ -0,0 +1,9 @@
+-- Transponder codes assigned to flights, keyed like flight_state
+CREATE TABLE IF NOT EXISTS squawk_history (
+	flight_key      TEXT NOT NULL,
+	ts              TIMESTAMPTZ NOT NULL,
+	squawk          VARCHAR(4) NOT NULL,
+	source          TEXT,
+	message_id      BIGINT,
+	PRIMARY KEY (flight_key, ts, squawk)
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS flight_enrichment_notify_truncate ON flight_enrichment;
+DROP TRIGGER IF EXISTS flight_enrichment_notify ON flight_enrichment;
+DROP FUNCTION IF EXISTS notify_flight_enrichment();
@@ -0,0 +1,25 @@
+-- Notify listeners when enrichment changes, so that API caches can drop their
+-- copies of an aircraft's enrichment. The payload is the aircraft's icao_hex,
+-- or empty when the table is truncated.
+CREATE OR REPLACE FUNCTION notify_flight_enrichment() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'TRUNCATE' THEN
+		PERFORM pg_notify('flight_enrichment_changed', '');
+	ELSIF TG_OP = 'DELETE' THEN
+		PERFORM pg_notify('flight_enrichment_changed', OLD.icao_hex);
+	ELSE
+		PERFORM pg_notify('flight_enrichment_changed', NEW.icao_hex);
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS flight_enrichment_notify ON flight_enrichment;
+CREATE TRIGGER flight_enrichment_notify
+	AFTER INSERT OR UPDATE OR DELETE ON flight_enrichment
+	FOR EACH ROW EXECUTE FUNCTION notify_flight_enrichment();
+
+DROP TRIGGER IF EXISTS flight_enrichment_notify_truncate ON flight_enrichment;
+CREATE TRIGGER flight_enrichment_notify_truncate
+	AFTER TRUNCATE ON flight_enrichment
+	FOR EACH STATEMENT EXECUTE FUNCTION notify_flight_enrichment();
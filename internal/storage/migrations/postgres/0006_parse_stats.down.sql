@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS parse_stats_parsers;
+DROP TABLE IF EXISTS parse_stats;
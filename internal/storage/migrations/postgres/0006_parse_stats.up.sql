@@ -0,0 +1,18 @@
+-- Parse coverage per day (of message time) and label, for trending
+CREATE TABLE IF NOT EXISTS parse_stats (
+	day             DATE NOT NULL,
+	label           TEXT NOT NULL,
+	total           BIGINT NOT NULL DEFAULT 0,
+	parsed          BIGINT NOT NULL DEFAULT 0,
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (day, label)
+);
+
+-- Messages matched by each parser, per day and label
+CREATE TABLE IF NOT EXISTS parse_stats_parsers (
+	day             DATE NOT NULL,
+	label           TEXT NOT NULL,
+	parser          TEXT NOT NULL,
+	matches         BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (day, label, parser)
+);
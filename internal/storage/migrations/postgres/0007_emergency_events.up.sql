@@ -0,0 +1,21 @@
+-- Emergency and abnormal events: ADS-C emergency reports, CPDLC MAYDAY and
+-- PAN downlinks, and 7700 squawk assignments
+CREATE TABLE IF NOT EXISTS emergency_events (
+	id              BIGSERIAL PRIMARY KEY,
+	kind            TEXT NOT NULL,
+	ts              TIMESTAMPTZ NOT NULL,
+	icao_hex        VARCHAR(6) NOT NULL DEFAULT '',
+	registration    TEXT NOT NULL DEFAULT '',
+	flight          TEXT,
+	label           TEXT,
+	source          TEXT,
+	detail          TEXT,
+	latitude        DOUBLE PRECISION,
+	longitude       DOUBLE PRECISION,
+	altitude        INTEGER,
+	message_id      BIGINT,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (kind, ts, icao_hex, registration)
+);
+
+CREATE INDEX IF NOT EXISTS idx_emergency_events_ts ON emergency_events (ts DESC);
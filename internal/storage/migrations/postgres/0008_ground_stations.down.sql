@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ground_station_info;
+DROP TABLE IF EXISTS ground_stations;
@@ -0,0 +1,21 @@
+-- Ground stations heard, keyed by kind ("ats" facility address or "vdl2"
+-- link-layer address) and identifier
+CREATE TABLE IF NOT EXISTS ground_stations (
+	kind            TEXT NOT NULL,
+	station_id      TEXT NOT NULL,
+	message_count   BIGINT NOT NULL DEFAULT 0,
+	first_heard     TIMESTAMPTZ NOT NULL,
+	last_heard      TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (kind, station_id)
+);
+
+-- Ground station reference data: network provider, name and region
+CREATE TABLE IF NOT EXISTS ground_station_info (
+	kind            TEXT NOT NULL,
+	station_id      TEXT NOT NULL,
+	provider        TEXT NOT NULL,
+	name            TEXT,
+	region          TEXT,
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (kind, station_id)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_flight_history_key;
+DROP INDEX IF EXISTS idx_flight_positions_recent;
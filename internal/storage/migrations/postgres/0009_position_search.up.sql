@@ -0,0 +1,6 @@
+-- Recent accepted positions by time and place, for bounding-box and
+-- nearest-aircraft searches
+CREATE INDEX IF NOT EXISTS idx_flight_positions_recent ON flight_positions(ts, latitude, longitude) WHERE rejection IS NULL;
+
+-- Archived flights by key, to name the flight a position belongs to
+CREATE INDEX IF NOT EXISTS idx_flight_history_key ON flight_history(key, first_seen);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_routes_last_seen;
+ALTER TABLE routes DROP COLUMN IF EXISTS historic_at;
@@ -0,0 +1,5 @@
+-- Routes no longer flown: set when a route has not been observed for a while,
+-- cleared when it is observed again
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS historic_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_routes_last_seen ON routes(last_seen);
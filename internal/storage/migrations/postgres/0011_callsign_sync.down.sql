@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_aircraft_callsigns_synced;
+ALTER TABLE aircraft_callsigns DROP COLUMN IF EXISTS synced_at;
@@ -0,0 +1,5 @@
+-- Sync watermark for callsign mappings pushed to planewatch-atc, as for the
+-- other reference tables
+ALTER TABLE aircraft_callsigns ADD COLUMN IF NOT EXISTS synced_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_aircraft_callsigns_synced ON aircraft_callsigns(synced_at);
@@ -0,0 +1,11 @@
+-- Airport reference data (IATA/ICAO codes), imported rather than derived, to
+-- resolve clearances that name airports only by IATA code
+CREATE TABLE IF NOT EXISTS airports (
+	icao_code       VARCHAR(4) PRIMARY KEY,
+	iata_code       VARCHAR(3),
+	name            TEXT,
+	country         TEXT,
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_airports_iata ON airports(iata_code);
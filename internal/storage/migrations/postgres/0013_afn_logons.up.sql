@@ -0,0 +1,19 @@
+-- FANS logons (AFN contacts) per aircraft and ATS facility, with the
+-- facility's acknowledgement and the CPDLC traffic that followed
+CREATE TABLE IF NOT EXISTS afn_logons (
+	id              BIGSERIAL PRIMARY KEY,
+	registration    TEXT NOT NULL,
+	facility        VARCHAR(7) NOT NULL,
+	flight          TEXT,
+	applications    TEXT,
+	logon_at        TIMESTAMPTZ NOT NULL,
+	acknowledged_at TIMESTAMPTZ,
+	accepted        BOOLEAN,
+	cpdlc_first_at  TIMESTAMPTZ,
+	cpdlc_last_at   TIMESTAMPTZ,
+	cpdlc_count     INTEGER NOT NULL DEFAULT 0,
+	message_id      BIGINT,
+	UNIQUE (registration, facility, logon_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_afn_logons_aircraft ON afn_logons (registration, facility, logon_at DESC);
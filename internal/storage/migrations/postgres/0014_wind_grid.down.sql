@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wind_grid;
@@ -0,0 +1,15 @@
+-- Winds aloft reported by aircraft (PWI, H2 wind reports and ADS-C meteo
+-- groups), gridded by 1-degree square, 10-flight-level band and hour
+CREATE TABLE IF NOT EXISTS wind_grid (
+	cell_time         TIMESTAMPTZ NOT NULL,
+	latitude          DOUBLE PRECISION NOT NULL,
+	longitude         DOUBLE PRECISION NOT NULL,
+	flight_level      INTEGER NOT NULL,
+	observations      INTEGER NOT NULL,
+	sum_u             DOUBLE PRECISION NOT NULL,
+	sum_v             DOUBLE PRECISION NOT NULL,
+	temp_observations INTEGER NOT NULL DEFAULT 0,
+	sum_temp          DOUBLE PRECISION NOT NULL DEFAULT 0,
+	updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (cell_time, latitude, longitude, flight_level)
+);
@@ -0,0 +1,19 @@
+-- Winds and temperatures aloft reported by aircraft (PWI route winds, H2 wind
+-- reports and ADS-C meteo groups), one row per report
+CREATE TABLE IF NOT EXISTS weather_observations (
+	id           BIGSERIAL PRIMARY KEY,
+	observed_at  TIMESTAMPTZ NOT NULL,
+	latitude     DOUBLE PRECISION NOT NULL,
+	longitude    DOUBLE PRECISION NOT NULL,
+	flight_level INTEGER NOT NULL,
+	wind_dir     DOUBLE PRECISION NOT NULL,
+	wind_speed   DOUBLE PRECISION NOT NULL,
+	temperature  DOUBLE PRECISION,
+	source       TEXT NOT NULL,
+	registration TEXT,
+	flight       TEXT,
+	message_id   BIGINT,
+	UNIQUE (observed_at, latitude, longitude, flight_level, source)
+);
+
+CREATE INDEX IF NOT EXISTS idx_weather_observations_position ON weather_observations (latitude, longitude, observed_at);
@@ -0,0 +1,25 @@
+-- Turbulence and wind shear reported by aircraft: crew reports and automatic
+-- EDR reports, with the position and level where known
+CREATE TABLE IF NOT EXISTS turbulence_reports (
+	id               BIGSERIAL PRIMARY KEY,
+	reported_at      TIMESTAMPTZ NOT NULL,
+	phenomenon       TEXT NOT NULL,
+	severity         TEXT,
+	severity_rank    SMALLINT NOT NULL DEFAULT -1,
+	severity_text    TEXT,
+	edr_peak         DOUBLE PRECISION,
+	edr_mean         DOUBLE PRECISION,
+	airspeed_change  INTEGER,
+	latitude         DOUBLE PRECISION,
+	longitude        DOUBLE PRECISION,
+	waypoint         TEXT,
+	flight_level     INTEGER,
+	flight_level_top INTEGER,
+	registration     TEXT NOT NULL DEFAULT '',
+	flight           TEXT,
+	message_id       BIGINT,
+	text             TEXT NOT NULL,
+	UNIQUE (reported_at, registration, phenomenon)
+);
+
+CREATE INDEX IF NOT EXISTS idx_turbulence_reports_position ON turbulence_reports (latitude, longitude, reported_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_enrichment_hex_departure;
+DROP INDEX IF EXISTS idx_enrichment_hex_date_utc;
+ALTER TABLE flight_enrichment DROP COLUMN IF EXISTS flight_date_utc;
+ALTER TABLE flight_enrichment DROP COLUMN IF EXISTS scheduled_departure;
+ALTER TABLE airports DROP COLUMN IF EXISTS tz;
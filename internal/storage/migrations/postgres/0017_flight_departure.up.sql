@@ -0,0 +1,14 @@
+-- Flights are dated by their scheduled departure, local to the origin airport
+-- when its time zone is known, so a red-eye keeps one flight_date. The UTC
+-- date is kept alongside for clients that query by it.
+ALTER TABLE airports ADD COLUMN IF NOT EXISTS tz TEXT;
+
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS scheduled_departure TIMESTAMPTZ;
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS flight_date_utc DATE;
+UPDATE flight_enrichment SET flight_date_utc = flight_date WHERE flight_date_utc IS NULL;
+ALTER TABLE flight_enrichment ALTER COLUMN flight_date_utc SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_hex_date_utc
+	ON flight_enrichment (icao_hex, flight_date_utc);
+CREATE INDEX IF NOT EXISTS idx_enrichment_hex_departure
+	ON flight_enrichment (icao_hex, scheduled_departure);
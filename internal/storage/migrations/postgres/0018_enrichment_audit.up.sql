@@ -0,0 +1,16 @@
+-- Field changes made to flight_enrichment rows, with the message and parser
+-- behind each, so that a row's values can be traced to their sources
+CREATE TABLE IF NOT EXISTS enrichment_audit (
+	id               BIGSERIAL PRIMARY KEY,
+	enrichment_id    INTEGER NOT NULL REFERENCES flight_enrichment (id) ON DELETE CASCADE,
+	changed_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	field            TEXT NOT NULL,
+	old_value        JSONB,
+	new_value        JSONB,
+	update_callsign  TEXT NOT NULL,
+	merged           BOOLEAN NOT NULL DEFAULT FALSE,
+	message_id       BIGINT,
+	parser           TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_audit_row ON enrichment_audit (enrichment_id, changed_at);
@@ -0,0 +1,9 @@
+-- Messages whose enrichment updates have been applied, so that a replayed
+-- message is not applied twice
+CREATE TABLE IF NOT EXISTS enrichment_messages (
+	message_id       BIGINT PRIMARY KEY,
+	enrichment_id    INTEGER NOT NULL REFERENCES flight_enrichment (id) ON DELETE CASCADE,
+	applied_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_messages_row ON enrichment_messages (enrichment_id);
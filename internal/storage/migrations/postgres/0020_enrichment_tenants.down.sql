@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_flight_enrichment_tenants;
+ALTER TABLE flight_enrichment DROP COLUMN IF EXISTS tenants;
@@ -0,0 +1,5 @@
+-- Tenants, or partner networks, whose feeds contributed to each enrichment,
+-- so that the enrichment API can show a partner only what its feeds derived
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS tenants TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_flight_enrichment_tenants ON flight_enrichment USING GIN (tenants);
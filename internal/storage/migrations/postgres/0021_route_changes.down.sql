@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS route_changes;
+DROP TABLE IF EXISTS flight_plans;
@@ -0,0 +1,21 @@
+-- The latest flight plan of each flight, keyed like flight_state, that the
+-- next plan for the flight is compared against
+CREATE TABLE IF NOT EXISTS flight_plans (
+	flight_key      TEXT PRIMARY KEY,
+	plan            JSONB NOT NULL,
+	updated_at      TIMESTAMPTZ NOT NULL,
+	message_id      BIGINT
+);
+
+-- Changes between successive flight plans of a flight: waypoints added and
+-- removed, and changed airports and procedures
+CREATE TABLE IF NOT EXISTS route_changes (
+	flight_key      TEXT NOT NULL,
+	ts              TIMESTAMPTZ NOT NULL,
+	icao_hex        TEXT NOT NULL DEFAULT '',
+	registration    TEXT NOT NULL DEFAULT '',
+	flight          TEXT,
+	changes         JSONB NOT NULL,
+	message_id      BIGINT,
+	PRIMARY KEY (flight_key, ts)
+);
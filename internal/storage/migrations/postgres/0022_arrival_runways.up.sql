@@ -0,0 +1,12 @@
+-- The arrival runway each flight's latest flight plan gives, by destination,
+-- from which the runway configuration in use at an airport is inferred
+CREATE TABLE IF NOT EXISTS arrival_runways (
+	airport_icao    TEXT NOT NULL,
+	flight_key      TEXT NOT NULL,
+	runway          TEXT NOT NULL,
+	approach        TEXT,
+	observed_at     TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (airport_icao, flight_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_arrival_runways_airport ON arrival_runways (airport_icao, observed_at);
@@ -0,0 +1,3 @@
+ALTER TABLE flight_enrichment DROP COLUMN IF EXISTS eta_uncertainty_minutes;
+ALTER TABLE flight_enrichment DROP COLUMN IF EXISTS eta_source;
+DROP TABLE IF EXISTS eta_reports;
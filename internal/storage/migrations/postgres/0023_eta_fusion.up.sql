@@ -0,0 +1,15 @@
+-- The latest ETA each kind of source has reported for a flight, keyed like
+-- flight_state, that are fused into flight_enrichment.eta
+CREATE TABLE IF NOT EXISTS eta_reports (
+	flight_key      TEXT NOT NULL,
+	source          TEXT NOT NULL,
+	eta             TIMESTAMPTZ NOT NULL,
+	reported_at     TIMESTAMPTZ NOT NULL,
+	destination     TEXT,
+	message_id      BIGINT,
+	PRIMARY KEY (flight_key, source)
+);
+
+-- The sources a fused ETA was estimated from, and its uncertainty
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS eta_source TEXT;
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS eta_uncertainty_minutes INTEGER;
@@ -0,0 +1,2 @@
+ALTER TABLE flight_positions DROP COLUMN IF EXISTS track;
+ALTER TABLE flight_positions DROP COLUMN IF EXISTS ground_speed;
@@ -0,0 +1,4 @@
+-- The ground speed and track reported with a position, from which the
+-- position is extrapolated between reports
+ALTER TABLE flight_positions ADD COLUMN IF NOT EXISTS ground_speed INTEGER;
+ALTER TABLE flight_positions ADD COLUMN IF NOT EXISTS track INTEGER;
@@ -0,0 +1 @@
+-- The waypoints deleted are not restored: their positions are in their names.
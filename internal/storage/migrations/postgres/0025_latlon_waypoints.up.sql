@@ -0,0 +1,7 @@
+-- Waypoints named by their coordinates, such as 5740N (57N 040W) or
+-- N25400W080030, are resolved from the name and no longer stored
+DELETE FROM waypoints
+WHERE name ~ '^[NS][0-9]{2}([0-9]{2}([0-9]|\.[0-9]+)?)?[EW][0-9]{3}([0-9]{2}([0-9]|\.[0-9]+)?)?$'
+   OR name ~ '^[0-9]{2}([0-9]{2}([0-9]|\.[0-9]+)?)?[NS][0-9]{3}([0-9]{2}([0-9]|\.[0-9]+)?)?[EW]$'
+   OR name ~ '^[0-9]{4}[NESW]$'
+   OR name ~ '^[0-9]{2}[NESW][0-9]{2}$';
@@ -0,0 +1 @@
+ALTER TABLE flight_enrichment DROP COLUMN IF EXISTS oceanic_clearance;
@@ -0,0 +1,3 @@
+-- The latest oceanic clearance given to the flight: NAT track, oceanic route,
+-- entry point and time, flight level and Mach
+ALTER TABLE flight_enrichment ADD COLUMN IF NOT EXISTS oceanic_clearance JSONB;
@@ -0,0 +1,15 @@
+-- The North Atlantic organised tracks of each NAT track message imported,
+-- used to resolve the track letter of oceanic clearances to its points
+CREATE TABLE IF NOT EXISTS nat_tracks (
+	letter      TEXT NOT NULL,
+	valid_from  TIMESTAMPTZ NOT NULL,
+	valid_to    TIMESTAMPTZ NOT NULL,
+	tmi         INTEGER,
+	points      JSONB NOT NULL,
+	east_levels JSONB,
+	west_levels JSONB,
+	imported_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (letter, valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nat_tracks_valid_to ON nat_tracks (valid_to);
@@ -0,0 +1,6 @@
+-- Message IDs are only unique within the feed or database that numbered
+-- them, so a message is also told apart by a hash of its content. Messages
+-- recorded before have no hash, and are applied once more if replayed
+ALTER TABLE enrichment_messages ADD COLUMN IF NOT EXISTS message_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE enrichment_messages DROP CONSTRAINT IF EXISTS enrichment_messages_pkey;
+ALTER TABLE enrichment_messages ADD PRIMARY KEY (message_id, message_hash);
@@ -0,0 +1,5 @@
+ALTER TABLE enrichment_messages DROP CONSTRAINT IF EXISTS enrichment_messages_pkey;
+DELETE FROM enrichment_messages a USING enrichment_messages b
+	WHERE a.message_id = b.message_id AND a.message_hash = b.message_hash AND a.ctid > b.ctid;
+ALTER TABLE enrichment_messages DROP COLUMN IF EXISTS tenant;
+ALTER TABLE enrichment_messages ADD PRIMARY KEY (message_id, message_hash);
@@ -0,0 +1,5 @@
+-- The tenant whose feed supplied each applied message, so that the same
+-- message from two partner networks adds each tenant to the enrichment
+ALTER TABLE enrichment_messages ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT '';
+ALTER TABLE enrichment_messages DROP CONSTRAINT IF EXISTS enrichment_messages_pkey;
+ALTER TABLE enrichment_messages ADD PRIMARY KEY (tenant, message_id, message_hash);
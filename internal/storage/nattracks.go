@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NATTrack is a North Atlantic organised track, stored in nat_tracks.
+type NATTrack struct {
+	Letter     string
+	Points     []string
+	ValidFrom  time.Time
+	ValidTo    time.Time
+	TMI        int // Track message identifier; 0 if not given.
+	EastLevels []int
+	WestLevels []int
+}
+
+// UpsertNATTracks stores the tracks of a NAT track message in one
+// transaction. A track imported again, with the same letter and validity,
+// replaces the stored one, as the message may be amended.
+func (d *PostgresDB) UpsertNATTracks(ctx context.Context, tracks []NATTrack) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, t := range tracks {
+		points, err := json.Marshal(t.Points)
+		if err != nil {
+			return fmt.Errorf("marshal nat track %s points: %w", t.Letter, err)
+		}
+		east, west := marshalLevels(t.EastLevels), marshalLevels(t.WestLevels)
+		_, err = tx.Exec(ctx, `
+			INSERT INTO nat_tracks (letter, valid_from, valid_to, tmi, points, east_levels, west_levels, imported_at)
+			VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7, NOW())
+			ON CONFLICT (letter, valid_from) DO UPDATE SET
+				valid_to = EXCLUDED.valid_to,
+				tmi = EXCLUDED.tmi,
+				points = EXCLUDED.points,
+				east_levels = EXCLUDED.east_levels,
+				west_levels = EXCLUDED.west_levels,
+				imported_at = NOW()
+		`, t.Letter, t.ValidFrom, t.ValidTo, t.TMI, points, east, west)
+		if err != nil {
+			return fmt.Errorf("upsert nat track %s: %w", t.Letter, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// marshalLevels returns the JSON of a track's flight levels, or nil for none.
+func marshalLevels(levels []int) []byte {
+	if len(levels) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(levels)
+	return b
+}
+
+// ListNATTracks returns the tracks still valid at or after a time, ordered by
+// validity and letter. A zero time lists every track.
+func (d *PostgresDB) ListNATTracks(ctx context.Context, validAfter time.Time) ([]NATTrack, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT letter, valid_from, valid_to, COALESCE(tmi, 0), points, east_levels, west_levels
+		FROM nat_tracks
+		WHERE valid_to >= $1
+		ORDER BY valid_from, letter
+	`, validAfter)
+	if err != nil {
+		return nil, fmt.Errorf("list nat tracks: %w", err)
+	}
+	defer rows.Close()
+
+	var tracks []NATTrack
+	for rows.Next() {
+		var t NATTrack
+		var points, east, west []byte
+		if err := rows.Scan(&t.Letter, &t.ValidFrom, &t.ValidTo, &t.TMI, &points, &east, &west); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(points, &t.Points)
+		if len(east) > 0 {
+			_ = json.Unmarshal(east, &t.EastLevels)
+		}
+		if len(west) > 0 {
+			_ = json.Unmarshal(west, &t.WestLevels)
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, rows.Err()
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpsertNATTracks(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	from := time.Date(2001, 7, 1, 11, 30, 0, 0, time.UTC)
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM nat_tracks WHERE valid_from = $1", from)
+	}
+	cleanup()
+	defer cleanup()
+
+	track := NATTrack{
+		Letter:     "A",
+		Points:     []string{"SUNOT", "5720N", "5830N", "PRAWN"},
+		ValidFrom:  from,
+		ValidTo:    from.Add(7*time.Hour + 30*time.Minute),
+		TMI:        182,
+		WestLevels: []int{310, 320},
+	}
+	if err := pg.UpsertNATTracks(ctx, []NATTrack{track}); err != nil {
+		t.Fatal(err)
+	}
+	// An amended message replaces the track.
+	track.Points = []string{"SUNOT", "5720N", "5930N", "PRAWN"}
+	if err := pg.UpsertNATTracks(ctx, []NATTrack{track}); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks, err := pg.ListNATTracks(ctx, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []NATTrack
+	for _, tr := range tracks {
+		if tr.ValidFrom.Equal(from) {
+			tr.ValidFrom, tr.ValidTo = tr.ValidFrom.UTC(), tr.ValidTo.UTC()
+			got = append(got, tr)
+		}
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0], track) {
+		t.Errorf("tracks = %+v, want %+v", got, track)
+	}
+
+	if tracks, err := pg.ListNATTracks(ctx, from.Add(8*time.Hour)); err != nil {
+		t.Fatal(err)
+	} else {
+		for _, tr := range tracks {
+			if tr.ValidFrom.Equal(from) {
+				t.Errorf("expired track listed: %+v", tr)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ParseStats is the parse coverage of one label on one day (of message
+// time), stored in parse_stats and parse_stats_parsers.
+type ParseStats struct {
+	Day     time.Time
+	Label   string
+	Total   int64            // Messages seen.
+	Parsed  int64            // Messages at least one parser matched.
+	Matches map[string]int64 // Messages matched, by parser name.
+}
+
+// Coverage returns the fraction of messages parsed, or 0 if none were seen.
+func (s ParseStats) Coverage() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Parsed) / float64(s.Total)
+}
+
+// SaveParseStats stores parse statistics, replacing those already stored for
+// the same day and label. Counts are not added to the stored ones, so
+// re-processing a day records the coverage of the parsers that processed it
+// last rather than counting its messages twice.
+func (d *PostgresDB) SaveParseStats(ctx context.Context, stats []ParseStats) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, s := range stats {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO parse_stats (day, label, total, parsed, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (day, label) DO UPDATE SET
+				total = EXCLUDED.total,
+				parsed = EXCLUDED.parsed,
+				updated_at = NOW()
+		`, s.Day, s.Label, s.Total, s.Parsed)
+		if err != nil {
+			return fmt.Errorf("save parse stats %s %s: %w", s.Day.Format("2006-01-02"), s.Label, err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM parse_stats_parsers WHERE day = $1 AND label = $2`, s.Day, s.Label); err != nil {
+			return fmt.Errorf("save parse stats %s %s: %w", s.Day.Format("2006-01-02"), s.Label, err)
+		}
+		for parser, n := range s.Matches {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO parse_stats_parsers (day, label, parser, matches)
+				VALUES ($1, $2, $3, $4)
+			`, s.Day, s.Label, parser, n)
+			if err != nil {
+				return fmt.Errorf("save parse stats %s %s: %w", s.Day.Format("2006-01-02"), s.Label, err)
+			}
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// GetParseStats retrieves the parse statistics from day from to day to,
+// inclusive, for one label or, if label is empty, every label. They are
+// ordered by day and then label.
+func (d *PostgresDB) GetParseStats(ctx context.Context, label string, from, to time.Time) ([]ParseStats, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT s.day, s.label, s.total, s.parsed, COALESCE(p.parser, ''), COALESCE(p.matches, 0)
+		FROM parse_stats s
+		LEFT JOIN parse_stats_parsers p ON p.day = s.day AND p.label = s.label
+		WHERE s.day BETWEEN $1 AND $2 AND ($3 = '' OR s.label = $3)
+		ORDER BY s.day, s.label
+	`, from, to, label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []ParseStats
+	for rows.Next() {
+		var s ParseStats
+		var parser string
+		var matches int64
+		if err := rows.Scan(&s.Day, &s.Label, &s.Total, &s.Parsed, &parser, &matches); err != nil {
+			return nil, err
+		}
+		if n := len(stats); n == 0 || !stats[n-1].Day.Equal(s.Day) || stats[n-1].Label != s.Label {
+			s.Matches = make(map[string]int64)
+			stats = append(stats, s)
+		}
+		if parser != "" {
+			stats[len(stats)-1].Matches[parser] = matches
+		}
+	}
+	return stats, rows.Err()
+}
@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"math"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -69,182 +73,9 @@ func (d *PostgresDB) Close() {
 	d.pool.Close()
 }
 
-// CreateSchema creates the PostgreSQL tables.
-func (d *PostgresDB) CreateSchema(ctx context.Context) error {
-	schema := `
-	-- Reference data: Aircraft
-	CREATE TABLE IF NOT EXISTS aircraft (
-		icao_hex        TEXT PRIMARY KEY,
-		registration    TEXT NOT NULL,
-		type_code       TEXT,
-		operator        TEXT,
-		first_seen      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		last_seen       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		msg_count       INTEGER NOT NULL DEFAULT 1,
-		synced_at       TIMESTAMPTZ
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_aircraft_registration ON aircraft(registration);
-	CREATE INDEX IF NOT EXISTS idx_aircraft_synced ON aircraft(synced_at);
-
-	-- Reference data: Waypoints
-	CREATE TABLE IF NOT EXISTS waypoints (
-		name            TEXT PRIMARY KEY,
-		latitude        DOUBLE PRECISION NOT NULL,
-		longitude       DOUBLE PRECISION NOT NULL,
-		source_count    INTEGER NOT NULL DEFAULT 1,
-		first_seen      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		last_seen       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		synced_at       TIMESTAMPTZ
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_waypoints_synced ON waypoints(synced_at);
-
-	-- Reference data: Routes
-	CREATE TABLE IF NOT EXISTS routes (
-		id                  SERIAL PRIMARY KEY,
-		flight_pattern      TEXT NOT NULL,
-		origin_icao         TEXT NOT NULL,
-		dest_icao           TEXT NOT NULL,
-		is_multi_stop       BOOLEAN NOT NULL DEFAULT FALSE,
-		observation_count   INTEGER NOT NULL DEFAULT 1,
-		first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		synced_at           TIMESTAMPTZ,
-		UNIQUE(flight_pattern, origin_icao, dest_icao)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_routes_pattern ON routes(flight_pattern);
-	CREATE INDEX IF NOT EXISTS idx_routes_synced ON routes(synced_at);
-
-	-- Reference data: Route legs
-	CREATE TABLE IF NOT EXISTS route_legs (
-		route_id            INTEGER NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
-		sequence            INTEGER NOT NULL,
-		origin_icao         TEXT NOT NULL,
-		dest_icao           TEXT NOT NULL,
-		observation_count   INTEGER NOT NULL DEFAULT 1,
-		first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		PRIMARY KEY (route_id, sequence)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_route_legs_airports ON route_legs(origin_icao, dest_icao);
-
-	-- Reference data: Aircraft on routes
-	CREATE TABLE IF NOT EXISTS route_aircraft (
-		route_id            INTEGER NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
-		registration        TEXT NOT NULL,
-		observation_count   INTEGER NOT NULL DEFAULT 1,
-		first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		PRIMARY KEY (route_id, registration)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_route_aircraft_registration ON route_aircraft(registration);
-
-	-- Reference data: Callsign prefixes
-	CREATE TABLE IF NOT EXISTS aircraft_callsigns (
-		registration        TEXT PRIMARY KEY,
-		iata_prefix         TEXT NOT NULL,
-		icao_prefix         TEXT NOT NULL,
-		observation_count   INTEGER NOT NULL DEFAULT 1,
-		first_seen          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		last_seen           TIMESTAMPTZ NOT NULL DEFAULT NOW()
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_aircraft_callsigns_iata ON aircraft_callsigns(iata_prefix);
-
-	-- Operational: Current ATIS
-	CREATE TABLE IF NOT EXISTS atis_current (
-		airport_icao    TEXT PRIMARY KEY,
-		letter          TEXT NOT NULL,
-		atis_type       TEXT,
-		atis_time       TEXT,
-		raw_text        TEXT,
-		runways         JSONB,
-		approaches      JSONB,
-		wind            TEXT,
-		visibility      TEXT,
-		clouds          TEXT,
-		temperature     TEXT,
-		dew_point       TEXT,
-		qnh             TEXT,
-		remarks         JSONB,
-		updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		synced_at       TIMESTAMPTZ
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_atis_current_synced ON atis_current(synced_at);
-
-	-- Ephemeral: Flight state
-	CREATE TABLE IF NOT EXISTS flight_state (
-		key             TEXT PRIMARY KEY,
-		icao_hex        TEXT,
-		registration    TEXT,
-		flight_number   TEXT,
-		origin          TEXT,
-		destination     TEXT,
-		latitude        DOUBLE PRECISION,
-		longitude       DOUBLE PRECISION,
-		altitude        INTEGER,
-		ground_speed    INTEGER,
-		track           INTEGER,
-		waypoints       JSONB,
-		first_seen      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		last_seen       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		msg_count       INTEGER NOT NULL DEFAULT 1
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_flight_state_flight ON flight_state(flight_number);
-	CREATE INDEX IF NOT EXISTS idx_flight_state_last_seen ON flight_state(last_seen);
-
-	-- Golden annotations (references ClickHouse message IDs)
-	CREATE TABLE IF NOT EXISTS golden_annotations (
-		message_id      BIGINT PRIMARY KEY,
-		is_golden       BOOLEAN NOT NULL DEFAULT FALSE,
-		annotation      TEXT,
-		expected_json   JSONB,
-		created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
-	);
-
-	-- Flight enrichment data for ADS-B tracking integration
-	CREATE TABLE IF NOT EXISTS flight_enrichment (
-		id              SERIAL PRIMARY KEY,
-		icao_hex        VARCHAR(6) NOT NULL,
-		callsign        VARCHAR(10),
-		flight_date     DATE NOT NULL,
-		origin           VARCHAR(4),
-		destination      VARCHAR(4),
-		route            JSONB,
-		eta              TIMESTAMPTZ,
-		departure_runway VARCHAR(6),
-		arrival_runway   VARCHAR(6),
-		sid              VARCHAR(12),
-		squawk           VARCHAR(4),
-		pax_count        INTEGER,
-		pax_breakdown    JSONB,
-		created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		UNIQUE (icao_hex, callsign, flight_date)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_enrichment_lookup
-		ON flight_enrichment (icao_hex, callsign, flight_date);
-	CREATE INDEX IF NOT EXISTS idx_enrichment_hex_date
-		ON flight_enrichment (icao_hex, flight_date);
-	`
-
-	_, err := d.pool.Exec(ctx, schema)
-	if err != nil {
-		return fmt.Errorf("create schema: %w", err)
-	}
-
-	// Create partial index separately (IF NOT EXISTS syntax differs).
-	_, _ = d.pool.Exec(ctx, `CREATE INDEX IF NOT EXISTS idx_golden_is_golden ON golden_annotations(is_golden) WHERE is_golden = TRUE`)
-
-	return nil
+// Ping checks that PostgreSQL can be reached.
+func (d *PostgresDB) Ping(ctx context.Context) error {
+	return d.pool.Ping(ctx)
 }
 
 // Aircraft represents an aircraft record.
@@ -364,9 +195,31 @@ type Route struct {
 	FirstSeen        time.Time
 	LastSeen         time.Time
 	SyncedAt         *time.Time
+	HistoricAt       *time.Time // When the route was marked as no longer flown; nil if current.
+}
+
+// DefaultRouteHalfLife is how long after a route was last observed its
+// confidence halves.
+const DefaultRouteHalfLife = 90 * 24 * time.Hour
+
+// routeObservationScale sets how quickly confidence grows with observations:
+// one observation scores 0.28, three 0.63 and ten 0.96.
+const routeObservationScale = 3
+
+// Confidence scores how likely the route is still flown, from 0 to 1. It
+// grows with the number of observations and halves for every halfLife since
+// the route was last observed, so that a route an airline dropped long ago
+// scores low however often it was seen before.
+func (r Route) Confidence(now time.Time, halfLife time.Duration) float64 {
+	c := 1 - math.Exp(-float64(r.ObservationCount)/routeObservationScale)
+	if age := now.Sub(r.LastSeen); age > 0 && halfLife > 0 {
+		c *= math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+	return c
 }
 
-// UpsertRoute inserts or updates a route record, returning the route ID.
+// UpsertRoute inserts or updates a route record, returning the route ID. A
+// route marked historic is current again once observed.
 func (d *PostgresDB) UpsertRoute(ctx context.Context, r Route) (int, error) {
 	var id int
 	err := d.pool.QueryRow(ctx, `
@@ -375,7 +228,8 @@ func (d *PostgresDB) UpsertRoute(ctx context.Context, r Route) (int, error) {
 		ON CONFLICT (flight_pattern, origin_icao, dest_icao) DO UPDATE SET
 			is_multi_stop = EXCLUDED.is_multi_stop,
 			observation_count = routes.observation_count + 1,
-			last_seen = EXCLUDED.last_seen
+			last_seen = EXCLUDED.last_seen,
+			historic_at = NULL
 		RETURNING id
 	`, r.FlightPattern, r.OriginICAO, r.DestICAO, r.IsMultiStop, r.ObservationCount, r.FirstSeen, r.LastSeen).Scan(&id)
 	return id, err
@@ -435,6 +289,7 @@ type AircraftCallsign struct {
 	ObservationCount int
 	FirstSeen        time.Time
 	LastSeen         time.Time
+	SyncedAt         *time.Time
 }
 
 // UpsertAircraftCallsign inserts or updates a callsign mapping.
@@ -549,18 +404,30 @@ type FlightState struct {
 	FirstSeen    time.Time
 	LastSeen     time.Time
 	MsgCount     int
+	CompletedAt  *time.Time // Set once the flight has arrived or gone inactive.
+	Completion   string     // Why the flight completed: "arrived" or "inactive".
+	FuelOut      *int       // Fuel on board in kg at OUT, OFF, ON and IN.
+	FuelOff      *int
+	FuelOn       *int
+	FuelIn       *int
 }
 
-// UpsertFlightState inserts or updates flight state.
+// UpsertFlightState inserts or updates flight state. Empty text fields, nil
+// positions and nil fuel figures keep the stored value.
 func (d *PostgresDB) UpsertFlightState(ctx context.Context, fs FlightState) error {
-	waypointsJSON, err := json.Marshal(fs.Waypoints)
-	if err != nil {
-		return fmt.Errorf("marshal waypoints: %w", err)
+	var waypointsJSON []byte
+	if len(fs.Waypoints) > 0 {
+		var err error
+		if waypointsJSON, err = json.Marshal(fs.Waypoints); err != nil {
+			return fmt.Errorf("marshal waypoints: %w", err)
+		}
 	}
 
-	_, err = d.pool.Exec(ctx, `
-		INSERT INTO flight_state (key, icao_hex, registration, flight_number, origin, destination, latitude, longitude, altitude, ground_speed, track, waypoints, first_seen, last_seen, msg_count)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO flight_state (key, icao_hex, registration, flight_number, origin, destination, latitude, longitude, altitude, ground_speed, track, waypoints, first_seen, last_seen, msg_count,
+			fuel_out_kg, fuel_off_kg, fuel_on_kg, fuel_in_kg)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13, $14, $15,
+			$16, $17, $18, $19)
 		ON CONFLICT (key) DO UPDATE SET
 			icao_hex = COALESCE(EXCLUDED.icao_hex, flight_state.icao_hex),
 			registration = COALESCE(EXCLUDED.registration, flight_state.registration),
@@ -572,22 +439,34 @@ func (d *PostgresDB) UpsertFlightState(ctx context.Context, fs FlightState) erro
 			altitude = COALESCE(EXCLUDED.altitude, flight_state.altitude),
 			ground_speed = COALESCE(EXCLUDED.ground_speed, flight_state.ground_speed),
 			track = COALESCE(EXCLUDED.track, flight_state.track),
-			waypoints = EXCLUDED.waypoints,
-			last_seen = EXCLUDED.last_seen,
-			msg_count = flight_state.msg_count + 1
-	`, fs.Key, fs.ICAOHex, fs.Registration, fs.FlightNumber, fs.Origin, fs.Destination, fs.Latitude, fs.Longitude, fs.Altitude, fs.GroundSpeed, fs.Track, waypointsJSON, fs.FirstSeen, fs.LastSeen, fs.MsgCount)
+			waypoints = COALESCE(EXCLUDED.waypoints, flight_state.waypoints),
+			last_seen = GREATEST(EXCLUDED.last_seen, flight_state.last_seen),
+			msg_count = flight_state.msg_count + 1,
+			fuel_out_kg = COALESCE(EXCLUDED.fuel_out_kg, flight_state.fuel_out_kg),
+			fuel_off_kg = COALESCE(EXCLUDED.fuel_off_kg, flight_state.fuel_off_kg),
+			fuel_on_kg = COALESCE(EXCLUDED.fuel_on_kg, flight_state.fuel_on_kg),
+			fuel_in_kg = COALESCE(EXCLUDED.fuel_in_kg, flight_state.fuel_in_kg)
+	`, fs.Key, fs.ICAOHex, fs.Registration, fs.FlightNumber, fs.Origin, fs.Destination, fs.Latitude, fs.Longitude, fs.Altitude, fs.GroundSpeed, fs.Track, waypointsJSON, fs.FirstSeen, fs.LastSeen, fs.MsgCount,
+		fs.FuelOut, fs.FuelOff, fs.FuelOn, fs.FuelIn)
 	return err
 }
 
+// flightStateColumns are the flight_state columns read into a FlightState.
+const flightStateColumns = `key, COALESCE(icao_hex, ''), COALESCE(registration, ''), COALESCE(flight_number, ''),
+	COALESCE(origin, ''), COALESCE(destination, ''), latitude, longitude, altitude, ground_speed, track,
+	waypoints, first_seen, last_seen, msg_count, completed_at, COALESCE(completion, ''),
+	fuel_out_kg, fuel_off_kg, fuel_on_kg, fuel_in_kg`
+
 // GetFlightState retrieves flight state by key.
 func (d *PostgresDB) GetFlightState(ctx context.Context, key string) (*FlightState, error) {
 	var fs FlightState
 	var waypointsJSON []byte
 
 	err := d.pool.QueryRow(ctx, `
-		SELECT key, icao_hex, registration, flight_number, origin, destination, latitude, longitude, altitude, ground_speed, track, waypoints, first_seen, last_seen, msg_count
+		SELECT `+flightStateColumns+`
 		FROM flight_state WHERE key = $1
-	`, key).Scan(&fs.Key, &fs.ICAOHex, &fs.Registration, &fs.FlightNumber, &fs.Origin, &fs.Destination, &fs.Latitude, &fs.Longitude, &fs.Altitude, &fs.GroundSpeed, &fs.Track, &waypointsJSON, &fs.FirstSeen, &fs.LastSeen, &fs.MsgCount)
+	`, key).Scan(&fs.Key, &fs.ICAOHex, &fs.Registration, &fs.FlightNumber, &fs.Origin, &fs.Destination, &fs.Latitude, &fs.Longitude, &fs.Altitude, &fs.GroundSpeed, &fs.Track, &waypointsJSON, &fs.FirstSeen, &fs.LastSeen, &fs.MsgCount, &fs.CompletedAt, &fs.Completion,
+		&fs.FuelOut, &fs.FuelOff, &fs.FuelOn, &fs.FuelIn)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -599,49 +478,149 @@ func (d *PostgresDB) GetFlightState(ctx context.Context, key string) (*FlightSta
 	return &fs, nil
 }
 
+// CompleteFlightState marks a flight as complete at the given time. A flight
+// that is already complete keeps its first completion. It reports whether a
+// current flight was marked.
+func (d *PostgresDB) CompleteFlightState(ctx context.Context, key string, at time.Time, completion string) (bool, error) {
+	tag, err := d.pool.Exec(ctx, `
+		UPDATE flight_state SET completed_at = $2, completion = $3
+		WHERE key = $1 AND completed_at IS NULL
+	`, key, at, completion)
+	if err != nil {
+		return false, fmt.Errorf("complete flight state %s: %w", key, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// archiveFlightStates moves the flight_state rows matching the condition to
+// flight_history in one statement. Rows that were never marked complete are
+// archived as inactive, completing at their last message.
+func (d *PostgresDB) archiveFlightStates(ctx context.Context, where string, args ...interface{}) (int64, error) {
+	tag, err := d.pool.Exec(ctx, `
+		WITH moved AS (
+			DELETE FROM flight_state WHERE `+where+`
+			RETURNING *
+		)
+		INSERT INTO flight_history (key, icao_hex, registration, flight_number, origin, destination,
+			latitude, longitude, altitude, ground_speed, track, waypoints, first_seen, last_seen, msg_count,
+			completed_at, completion, fuel_out_kg, fuel_off_kg, fuel_on_kg, fuel_in_kg)
+		SELECT key, icao_hex, registration, flight_number, origin, destination,
+			latitude, longitude, altitude, ground_speed, track, waypoints, first_seen, last_seen, msg_count,
+			COALESCE(completed_at, last_seen), COALESCE(completion, 'inactive'),
+			fuel_out_kg, fuel_off_kg, fuel_on_kg, fuel_in_kg
+		FROM moved
+	`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("archive flight state: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ArchiveFlightState moves one flight from flight_state to flight_history,
+// whether or not it is complete. It returns false if there was no such flight.
+func (d *PostgresDB) ArchiveFlightState(ctx context.Context, key string) (bool, error) {
+	n, err := d.archiveFlightStates(ctx, "key = $1", key)
+	return n > 0, err
+}
+
+// ArchiveExpiredFlightStates moves flights completed before completedBefore,
+// and flights not seen since inactiveBefore, from flight_state to
+// flight_history. It returns the number of flights archived.
+func (d *PostgresDB) ArchiveExpiredFlightStates(ctx context.Context, completedBefore, inactiveBefore time.Time) (int64, error) {
+	return d.archiveFlightStates(ctx, "completed_at < $1 OR last_seen < $2", completedBefore, inactiveBefore)
+}
+
 // GoldenAnnotation represents a golden message annotation.
 type GoldenAnnotation struct {
 	MessageID    int64
 	IsGolden     bool
 	Annotation   string
 	ExpectedJSON map[string]interface{}
+	Flagged      bool   // Marked for follow-up during review.
+	FlagReason   string // Why the message was flagged.
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
 
 // FlightEnrichment represents enrichment data for a specific flight operation.
+//
+// FlightDate is the date of the flight's scheduled departure, local to the
+// origin airport when its time zone is known and in UTC otherwise, or the UTC
+// date of its first message when no departure time has been seen.
+// FlightDateUTC is the same date in UTC.
 type FlightEnrichment struct {
-	ICAOHex        string         `json:"icao_hex"`
-	Callsign       string         `json:"callsign"`
-	FlightDate     time.Time      `json:"flight_date"`
-	Origin         string         `json:"origin,omitempty"`
-	Destination    string         `json:"destination,omitempty"`
-	Route          []string       `json:"route,omitempty"`
-	ETA            *time.Time     `json:"eta,omitempty"`
-	DepartureRunway string        `json:"departure_runway,omitempty"`
-	ArrivalRunway  string         `json:"arrival_runway,omitempty"`
-	SID            string         `json:"sid,omitempty"`
-	Squawk         string         `json:"squawk,omitempty"`
-	PaxCount       *int           `json:"pax_count,omitempty"`
-	PaxBreakdown   map[string]int `json:"pax_breakdown,omitempty"`
-	UpdatedAt      time.Time      `json:"updated_at"`
+	ICAOHex            string            `json:"icao_hex"`
+	Callsign           string            `json:"callsign"`
+	FlightDate         time.Time         `json:"flight_date"`
+	FlightDateUTC      time.Time         `json:"flight_date_utc"`
+	ScheduledDeparture *time.Time        `json:"scheduled_departure,omitempty"`
+	Origin             string            `json:"origin,omitempty"`
+	Destination        string            `json:"destination,omitempty"`
+	Route              []string          `json:"route,omitempty"`
+	ETA                *time.Time        `json:"eta,omitempty"`
+	ETASource          string            `json:"eta_source,omitempty"`
+	ETAUncertainty     *int              `json:"eta_uncertainty_minutes,omitempty"`
+	DepartureRunway    string            `json:"departure_runway,omitempty"`
+	ArrivalRunway      string            `json:"arrival_runway,omitempty"`
+	SID                string            `json:"sid,omitempty"`
+	STAR               string            `json:"star,omitempty"`
+	SIDWaypoints       []string          `json:"sid_waypoints,omitempty"`
+	STARWaypoints      []string          `json:"star_waypoints,omitempty"`
+	Squawk             string            `json:"squawk,omitempty"`
+	PaxCount           *int              `json:"pax_count,omitempty"`
+	PaxBreakdown       map[string]int    `json:"pax_breakdown,omitempty"`
+	OceanicClearance   *OceanicClearance `json:"oceanic_clearance,omitempty"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+// OceanicClearance is the latest oceanic clearance given to a flight. Its
+// route is only the cleared oceanic part, so it is kept apart from the
+// flight's route.
+type OceanicClearance struct {
+	Track       string     `json:"track,omitempty"` // NAT track letter; empty for a random route.
+	Route       []string   `json:"route,omitempty"`
+	EntryPoint  string     `json:"entry_point,omitempty"`
+	EntryTime   *time.Time `json:"entry_time,omitempty"`
+	FlightLevel int        `json:"flight_level,omitempty"`
+	Mach        float64    `json:"mach,omitempty"` // e.g. 0.83.
 }
 
 // FlightEnrichmentUpdate contains fields to upsert. Nil pointers are not updated.
+// FlightDate and FlightDateUTC are dated as for FlightEnrichment. MessageTime,
+// when set, lets a message without a departure time join the flight whose
+// scheduled departure is near it, however that flight is dated. MessageID and
+// Parser name the source of the update in the enrichment audit trail.
+// MessageHash tells apart messages of different feeds or databases that share
+// an ID (see UpsertFlightEnrichment). Tenant,
+// when set, is added to the tenants the row is derived from (see
+// GetFlightEnrichment).
 type FlightEnrichmentUpdate struct {
-	ICAOHex        string
-	Callsign       string
-	FlightDate     time.Time
-	Origin         *string
-	Destination    *string
-	Route          []string
-	ETA            *time.Time
-	DepartureRunway *string
-	ArrivalRunway  *string
-	SID            *string
-	Squawk         *string
-	PaxCount       *int
-	PaxBreakdown   map[string]int
+	ICAOHex            string            `json:"icao_hex"`
+	Callsign           string            `json:"callsign"`
+	FlightDate         time.Time         `json:"flight_date"`
+	FlightDateUTC      time.Time         `json:"flight_date_utc,omitzero"`
+	ScheduledDeparture *time.Time        `json:"scheduled_departure,omitempty"`
+	MessageTime        time.Time         `json:"-"`
+	MessageID          int64             `json:"-"` // ClickHouse message ID; 0 if unknown.
+	MessageHash        string            `json:"-"` // Hash of the message content; empty if unknown.
+	Parser             string            `json:"-"` // Result types the update was taken from.
+	Tenant             string            `json:"-"` // Partner network whose feed supplied the message.
+	Origin             *string           `json:"origin,omitempty"`
+	Destination        *string           `json:"destination,omitempty"`
+	Route              []string          `json:"route,omitempty"`
+	ETA                *time.Time        `json:"eta,omitempty"`
+	ETASource          *string           `json:"eta_source,omitempty"`
+	ETAUncertainty     *int              `json:"eta_uncertainty_minutes,omitempty"`
+	DepartureRunway    *string           `json:"departure_runway,omitempty"`
+	ArrivalRunway      *string           `json:"arrival_runway,omitempty"`
+	SID                *string           `json:"sid,omitempty"`
+	STAR               *string           `json:"star,omitempty"`
+	SIDWaypoints       []string          `json:"sid_waypoints,omitempty"`
+	STARWaypoints      []string          `json:"star_waypoints,omitempty"`
+	Squawk             *string           `json:"squawk,omitempty"`
+	PaxCount           *int              `json:"pax_count,omitempty"`
+	PaxBreakdown       map[string]int    `json:"pax_breakdown,omitempty"`
+	OceanicClearance   *OceanicClearance `json:"oceanic_clearance,omitempty"`
 }
 
 // extractFlightNumber extracts the numeric suffix from an airline callsign.
@@ -660,7 +639,9 @@ func extractFlightNumber(callsign string) string {
 }
 
 // UpsertFlightEnrichment inserts or updates enrichment data.
-// Only non-nil fields are updated on conflict.
+// Only non-nil fields are updated on conflict. Each write is announced on
+// EnrichmentChannel by a trigger, so that API caches can invalidate, and each
+// field it changes is recorded in enrichment_audit (see GetEnrichmentAudit).
 //
 // CALLSIGN MATCHING STRATEGY:
 // Airlines use both IATA (2-letter) and ICAO (3-letter) callsign formats interchangeably:
@@ -669,36 +650,106 @@ func extractFlightNumber(callsign string) string {
 //
 // To avoid duplicate enrichment records for the same flight, we match on the numeric
 // flight number suffix rather than the exact callsign. This is safe because:
-//   1. We also match on icao_hex (unique aircraft identifier)
-//   2. We also match on flight_date
-//   3. The same physical aircraft cannot fly for two different airlines on the same day
-//      with the same flight number
+//  1. We also match on icao_hex (unique aircraft identifier)
+//  2. We also match on flight_date
+//  3. The same physical aircraft cannot fly for two different airlines on the same day
+//     with the same flight number
 //
 // When a match is found, we prefer the longer (ICAO) callsign format as it's more
 // specific and standardised for ATC communications.
 //
 // Validated against corpus: 1,096 duplicate rows (5%) were caused by IATA/ICAO
 // format differences, with zero false positives detected.
+//
+// FLIGHT DATE MATCHING:
+// A flight is dated by its scheduled departure once one is known, which need
+// not be the UTC date of a given message: a red-eye's later messages fall on
+// the next UTC day. So a row also matches when:
+//   - the update has no departure time and the row's scheduled departure is
+//     between departureMatchBefore before and departureMatchAfter after
+//     the message; or
+//   - the update has a departure time, and the row has a departure time
+//     within departureMatchSlip of it, or none and the UTC date of the
+//     message. The row is then re-dated by the departure.
+//
+// CONCURRENCY AND REPLAYS:
+// The write runs in one transaction holding an advisory lock on the aircraft
+// and flight number, so that concurrent writers of the same flight, in any
+// callsign format, are serialised rather than each inserting a row or
+// overwriting the other's update. An update with a MessageID is applied once:
+// the message is recorded in enrichment_messages with the write, and an
+// update from a message already recorded is skipped. A message is identified
+// by its ID and MessageHash, as an ID is only unique within the feed or
+// database that numbered it, and by its Tenant, so that the same message
+// from another partner network still adds that tenant to the row.
 func (d *PostgresDB) UpsertFlightEnrichment(ctx context.Context, u FlightEnrichmentUpdate) error {
 	if u.ICAOHex == "" || u.FlightDate.IsZero() {
 		return nil // Can't upsert without key fields.
 	}
+	if u.FlightDateUTC.IsZero() {
+		u.FlightDateUTC = u.FlightDate
+	}
 
 	// Extract flight number for fuzzy callsign matching.
 	flightNum := extractFlightNumber(u.Callsign)
 
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Writers of the same flight take turns. The lock is released when the
+	// transaction ends.
+	lockKey := flightNum
+	if lockKey == "" {
+		lockKey = u.Callsign
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('flight_enrichment/' || $1 || '/' || $2))`, u.ICAOHex, lockKey); err != nil {
+		return fmt.Errorf("lock enrichment: %w", err)
+	}
+	if u.MessageID != 0 {
+		var applied bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM enrichment_messages WHERE tenant = $1 AND message_id = $2 AND message_hash = $3)`,
+			u.Tenant, u.MessageID, u.MessageHash).Scan(&applied); err != nil {
+			return fmt.Errorf("check enrichment message: %w", err)
+		}
+		if applied {
+			return nil // A replay of a message already applied.
+		}
+	}
+
 	// Try to find an existing row with matching icao_hex, flight_date, and flight number suffix.
 	// This allows QF1255 and QFA1255 to match and be merged into the same record.
 	var existingID int
 	var existingCallsign string
 	if flightNum != "" {
-		findQuery := `
+		// The regex pattern matches callsigns ending with the flight number.
+		conds := []string{"flight_date = $2"}
+		findArgs := []interface{}{u.ICAOHex, u.FlightDate, flightNum}
+		switch {
+		case u.ScheduledDeparture != nil:
+			conds = append(conds, fmt.Sprintf("scheduled_departure BETWEEN $4::timestamptz - interval '%[1]d seconds' AND $4::timestamptz + interval '%[1]d seconds'", int(departureMatchSlip.Seconds())))
+			findArgs = append(findArgs, *u.ScheduledDeparture)
+			if !u.MessageTime.IsZero() {
+				conds = append(conds, "(scheduled_departure IS NULL AND flight_date_utc = $5)")
+				findArgs = append(findArgs, utcDate(u.MessageTime))
+			}
+		case !u.MessageTime.IsZero():
+			conds = append(conds, fmt.Sprintf("scheduled_departure BETWEEN $4::timestamptz - interval '%d seconds' AND $4::timestamptz + interval '%d seconds'",
+				int(departureMatchBefore.Seconds()), int(departureMatchAfter.Seconds())))
+			findArgs = append(findArgs, u.MessageTime)
+		}
+		findQuery := fmt.Sprintf(`
 			SELECT id, callsign FROM flight_enrichment
-			WHERE icao_hex = $1 AND flight_date = $2 AND callsign ~ ($3 || '$')
+			WHERE icao_hex = $1 AND callsign ~ ($3 || '$') AND (%s)
+			ORDER BY flight_date = $2 DESC, updated_at DESC
 			LIMIT 1
-		`
-		// The regex pattern matches callsigns ending with the flight number.
-		_ = d.pool.QueryRow(ctx, findQuery, u.ICAOHex, u.FlightDate, flightNum).Scan(&existingID, &existingCallsign)
+		`, strings.Join(conds, " OR "))
+		err := tx.QueryRow(ctx, findQuery, findArgs...).Scan(&existingID, &existingCallsign)
+		if err != nil && err != pgx.ErrNoRows {
+			return fmt.Errorf("find enrichment: %w", err)
+		}
 	}
 
 	// Determine which callsign to use. Prefer the longer (ICAO) format as it's more specific.
@@ -721,10 +772,10 @@ func (d *PostgresDB) UpsertFlightEnrichment(ctx context.Context, u FlightEnrichm
 	}
 
 	// Build dynamic column lists and values based on which fields are set.
-	columns := []string{"icao_hex", "callsign", "flight_date"}
-	placeholders := []string{"$1", "$2", "$3"}
-	args := []interface{}{u.ICAOHex, callsignToUse, u.FlightDate}
-	argIdx := 4
+	columns := []string{"icao_hex", "callsign", "flight_date", "flight_date_utc"}
+	placeholders := []string{"$1", "$2", "$3", "$4"}
+	args := []interface{}{u.ICAOHex, callsignToUse, u.FlightDate, u.FlightDateUTC}
+	argIdx := 5
 
 	var setClauses []string
 	setClauses = append(setClauses, "updated_at = NOW()")
@@ -734,6 +785,21 @@ func (d *PostgresDB) UpsertFlightEnrichment(ctx context.Context, u FlightEnrichm
 		argIdx++
 	}
 
+	if u.ScheduledDeparture != nil {
+		columns = append(columns, "scheduled_departure")
+		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+		args = append(args, *u.ScheduledDeparture)
+		setClauses = append(setClauses, fmt.Sprintf("scheduled_departure = $%d", argIdx))
+		argIdx++
+		// A matched row is re-dated by the departure.
+		updateClauses = append(updateClauses,
+			fmt.Sprintf("scheduled_departure = $%d", updateIdx),
+			fmt.Sprintf("flight_date = $%d", updateIdx+1),
+			fmt.Sprintf("flight_date_utc = $%d", updateIdx+2))
+		updateArgs = append(updateArgs, *u.ScheduledDeparture, u.FlightDate, u.FlightDateUTC)
+		updateIdx += 3
+	}
+
 	if u.Origin != nil {
 		columns = append(columns, "origin")
 		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
@@ -778,6 +844,26 @@ func (d *PostgresDB) UpsertFlightEnrichment(ctx context.Context, u FlightEnrichm
 		updateArgs = append(updateArgs, *u.ETA)
 		updateIdx++
 	}
+	if u.ETASource != nil {
+		columns = append(columns, "eta_source")
+		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+		args = append(args, *u.ETASource)
+		setClauses = append(setClauses, fmt.Sprintf("eta_source = $%d", argIdx))
+		argIdx++
+		updateClauses = append(updateClauses, fmt.Sprintf("eta_source = $%d", updateIdx))
+		updateArgs = append(updateArgs, *u.ETASource)
+		updateIdx++
+	}
+	if u.ETAUncertainty != nil {
+		columns = append(columns, "eta_uncertainty_minutes")
+		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+		args = append(args, *u.ETAUncertainty)
+		setClauses = append(setClauses, fmt.Sprintf("eta_uncertainty_minutes = $%d", argIdx))
+		argIdx++
+		updateClauses = append(updateClauses, fmt.Sprintf("eta_uncertainty_minutes = $%d", updateIdx))
+		updateArgs = append(updateArgs, *u.ETAUncertainty)
+		updateIdx++
+	}
 	if u.DepartureRunway != nil {
 		columns = append(columns, "departure_runway")
 		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
@@ -808,6 +894,44 @@ func (d *PostgresDB) UpsertFlightEnrichment(ctx context.Context, u FlightEnrichm
 		updateArgs = append(updateArgs, *u.SID)
 		updateIdx++
 	}
+	if u.STAR != nil {
+		columns = append(columns, "star")
+		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+		args = append(args, *u.STAR)
+		setClauses = append(setClauses, fmt.Sprintf("star = COALESCE($%d, flight_enrichment.star)", argIdx))
+		argIdx++
+		updateClauses = append(updateClauses, fmt.Sprintf("star = COALESCE($%d, flight_enrichment.star)", updateIdx))
+		updateArgs = append(updateArgs, *u.STAR)
+		updateIdx++
+	}
+	if len(u.SIDWaypoints) > 0 {
+		sidJSON, err := json.Marshal(u.SIDWaypoints)
+		if err != nil {
+			return fmt.Errorf("marshal sid_waypoints: %w", err)
+		}
+		columns = append(columns, "sid_waypoints")
+		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+		args = append(args, sidJSON)
+		setClauses = append(setClauses, fmt.Sprintf("sid_waypoints = $%d", argIdx))
+		argIdx++
+		updateClauses = append(updateClauses, fmt.Sprintf("sid_waypoints = $%d", updateIdx))
+		updateArgs = append(updateArgs, sidJSON)
+		updateIdx++
+	}
+	if len(u.STARWaypoints) > 0 {
+		starJSON, err := json.Marshal(u.STARWaypoints)
+		if err != nil {
+			return fmt.Errorf("marshal star_waypoints: %w", err)
+		}
+		columns = append(columns, "star_waypoints")
+		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+		args = append(args, starJSON)
+		setClauses = append(setClauses, fmt.Sprintf("star_waypoints = $%d", argIdx))
+		argIdx++
+		updateClauses = append(updateClauses, fmt.Sprintf("star_waypoints = $%d", updateIdx))
+		updateArgs = append(updateArgs, starJSON)
+		updateIdx++
+	}
 	if u.Squawk != nil {
 		columns = append(columns, "squawk")
 		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
@@ -842,78 +966,332 @@ func (d *PostgresDB) UpsertFlightEnrichment(ctx context.Context, u FlightEnrichm
 		updateArgs = append(updateArgs, breakdownJSON)
 		updateIdx++
 	}
+	if u.OceanicClearance != nil {
+		clearanceJSON, err := json.Marshal(u.OceanicClearance)
+		if err != nil {
+			return fmt.Errorf("marshal oceanic_clearance: %w", err)
+		}
+		columns = append(columns, "oceanic_clearance")
+		placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+		args = append(args, clearanceJSON)
+		setClauses = append(setClauses, fmt.Sprintf("oceanic_clearance = $%d", argIdx))
+		argIdx++
+		updateClauses = append(updateClauses, fmt.Sprintf("oceanic_clearance = $%d", updateIdx))
+		updateArgs = append(updateArgs, clearanceJSON)
+		updateIdx++
+	}
 
 	if len(setClauses) == 1 { // Only updated_at.
 		return nil // Nothing to update.
 	}
 
+	// The row is derived from the tenant's feed, as well as any before.
+	if u.Tenant != "" {
+		columns = append(columns, "tenants")
+		placeholders = append(placeholders, fmt.Sprintf("ARRAY[$%d::text]", argIdx))
+		args = append(args, u.Tenant)
+		setClauses = append(setClauses, fmt.Sprintf("tenants = %s", addTenant("flight_enrichment.tenants", argIdx)))
+		argIdx++
+		updateClauses = append(updateClauses, fmt.Sprintf("tenants = %s", addTenant("tenants", updateIdx)))
+		updateArgs = append(updateArgs, u.Tenant)
+		updateIdx++
+	}
+
+	// Lock the row to be written, if there is one, and keep its values for the audit trail.
+	var old *FlightEnrichment
+	if existingID > 0 {
+		old, err = scanEnrichment(tx.QueryRow(ctx, `SELECT `+enrichmentColumns+` FROM flight_enrichment WHERE id = $1 FOR UPDATE`, existingID))
+	} else {
+		old, err = scanEnrichment(tx.QueryRow(ctx, `
+			SELECT `+enrichmentColumns+` FROM flight_enrichment
+			WHERE icao_hex = $1 AND callsign = $2 AND flight_date = $3
+			FOR UPDATE
+		`, u.ICAOHex, callsignToUse, u.FlightDate))
+	}
+	if err != nil && err != pgx.ErrNoRows {
+		return fmt.Errorf("read enrichment: %w", err)
+	}
+
 	// If we found an existing row with a matching flight number (but possibly different
 	// callsign format), update that row directly by ID. This merges IATA/ICAO variants.
+	id := existingID
 	if existingID > 0 {
 		updateQuery := fmt.Sprintf(`
 			UPDATE flight_enrichment SET %s WHERE id = $%d
 		`, strings.Join(updateClauses, ", "), updateIdx)
 		updateArgs = append(updateArgs, existingID)
-		_, err := d.pool.Exec(ctx, updateQuery, updateArgs...)
-		return err
+		if _, err := tx.Exec(ctx, updateQuery, updateArgs...); err != nil {
+			return err
+		}
+	} else {
+		// No existing row found - insert new row with ON CONFLICT for exact callsign matches.
+		query := fmt.Sprintf(`
+			INSERT INTO flight_enrichment (%s)
+			VALUES (%s)
+			ON CONFLICT (icao_hex, callsign, flight_date) DO UPDATE SET %s
+			RETURNING id
+		`, strings.Join(columns, ", "), strings.Join(placeholders, ", "), strings.Join(setClauses, ", "))
+		if err := tx.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+			return err
+		}
 	}
 
-	// No existing row found - insert new row with ON CONFLICT for exact callsign matches.
-	query := fmt.Sprintf(`
-		INSERT INTO flight_enrichment (%s)
-		VALUES (%s)
-		ON CONFLICT (icao_hex, callsign, flight_date) DO UPDATE SET %s
-	`, strings.Join(columns, ", "), strings.Join(placeholders, ", "), strings.Join(setClauses, ", "))
+	updated, err := scanEnrichment(tx.QueryRow(ctx, `SELECT `+enrichmentColumns+` FROM flight_enrichment WHERE id = $1`, id))
+	if err != nil {
+		return fmt.Errorf("read enrichment: %w", err)
+	}
+	merged := existingID > 0 && existingCallsign != u.Callsign
+	for _, c := range diffEnrichment(old, updated) {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO enrichment_audit (enrichment_id, field, old_value, new_value, update_callsign, merged, message_id, parser)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7::bigint, 0), NULLIF($8, ''))
+		`, id, c.Field, []byte(c.OldValue), []byte(c.NewValue), u.Callsign, merged, u.MessageID, u.Parser)
+		if err != nil {
+			return fmt.Errorf("audit enrichment %s: %w", c.Field, err)
+		}
+	}
+	if u.MessageID != 0 {
+		_, err := tx.Exec(ctx, `INSERT INTO enrichment_messages (tenant, message_id, message_hash, enrichment_id) VALUES ($1, $2, $3, $4)`,
+			u.Tenant, u.MessageID, u.MessageHash, id)
+		if err != nil {
+			return fmt.Errorf("record enrichment message: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
 
-	_, err := d.pool.Exec(ctx, query, args...)
-	return err
+// addTenant returns the SQL for the tenants array column with the tenant in
+// parameter n added, unless it is already there.
+func addTenant(column string, n int) string {
+	return fmt.Sprintf("CASE WHEN $%[2]d::text = ANY(%[1]s) THEN %[1]s ELSE array_append(%[1]s, $%[2]d::text) END", column, n)
+}
+
+// EnrichmentChange is a change made to one field of a flight enrichment.
+type EnrichmentChange struct {
+	Field     string          // JSON name of the field, as in FlightEnrichment.
+	OldValue  json.RawMessage // JSON value; nil when the field was unset.
+	NewValue  json.RawMessage // JSON value; nil when the field was cleared.
+	ChangedAt time.Time
+	// UpdateCallsign is the callsign the update was made under. Merged is
+	// set when it differs from that of the row it was merged into by
+	// flight number.
+	UpdateCallsign string
+	Merged         bool
+	MessageID      int64  // ClickHouse message ID; 0 if unknown.
+	Parser         string // Result types the update was taken from.
+}
+
+// diffEnrichment returns the fields, by JSON name, whose values differ between
+// old, which is nil for a new row, and updated. updated_at is left out.
+func diffEnrichment(old, updated *FlightEnrichment) []EnrichmentChange {
+	fields := func(e *FlightEnrichment) map[string]json.RawMessage {
+		m := map[string]json.RawMessage{}
+		if e != nil {
+			b, _ := json.Marshal(e)
+			_ = json.Unmarshal(b, &m)
+		}
+		delete(m, "updated_at")
+		return m
+	}
+	before, after := fields(old), fields(updated)
+
+	var names []string
+	for name := range after {
+		names = append(names, name)
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []EnrichmentChange
+	for _, name := range names {
+		if !bytes.Equal(before[name], after[name]) {
+			changes = append(changes, EnrichmentChange{Field: name, OldValue: before[name], NewValue: after[name]})
+		}
+	}
+	return changes
+}
+
+// DateBasis selects the date of a flight that a lookup matches.
+type DateBasis int
+
+const (
+	// DateLocal matches flight_date: the date of the scheduled departure at
+	// the origin airport when its time zone is known.
+	DateLocal DateBasis = iota
+	// DateUTC matches flight_date_utc, the same date in UTC.
+	DateUTC
+)
+
+// column returns the flight_enrichment column a basis matches.
+func (b DateBasis) column() string {
+	if b == DateUTC {
+		return "flight_date_utc"
+	}
+	return "flight_date"
+}
+
+// Scheduled departures are matched to messages within these windows (see
+// UpsertFlightEnrichment): a flight may have departed up to
+// departureMatchBefore before a message about it, or depart up to
+// departureMatchAfter after, and a departure time may slip by up to
+// departureMatchSlip between messages.
+const (
+	departureMatchBefore = 20 * time.Hour
+	departureMatchAfter  = 12 * time.Hour
+	departureMatchSlip   = 6 * time.Hour
+)
+
+// utcDate returns the UTC date of t as midnight UTC.
+func utcDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
-// GetFlightEnrichment retrieves enrichment data for a specific flight.
+// enrichmentColumns are the flight_enrichment columns read by scanEnrichment.
+const enrichmentColumns = `icao_hex, callsign, flight_date, flight_date_utc, scheduled_departure,
+	origin, destination, route, eta, eta_source, eta_uncertainty_minutes, departure_runway, arrival_runway, sid, star,
+	sid_waypoints, star_waypoints, squawk, pax_count, pax_breakdown, oceanic_clearance, updated_at`
+
+// GetFlightEnrichment retrieves enrichment data for a specific flight, by the
+// date basis given.
 // Uses fuzzy callsign matching (by flight number suffix) to handle IATA/ICAO variants.
 // See UpsertFlightEnrichment for details on the matching strategy.
-func (d *PostgresDB) GetFlightEnrichment(ctx context.Context, icaoHex, callsign string, flightDate time.Time) (*FlightEnrichment, error) {
-	// Extract flight number for fuzzy matching.
-	flightNum := extractFlightNumber(callsign)
+//
+// Non-nil tenants restrict the lookup to enrichment derived, at least in
+// part, from the feeds of those tenants; nil looks at every row.
+func (d *PostgresDB) GetFlightEnrichment(ctx context.Context, icaoHex, callsign string, flightDate time.Time, basis DateBasis, tenants []string) (*FlightEnrichment, error) {
+	where, args := enrichmentMatch(icaoHex, callsign, flightDate, basis, tenants)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM flight_enrichment
+		WHERE %s
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`, enrichmentColumns, where)
 
-	var query string
-	var args []interface{}
+	e, err := scanEnrichment(d.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
 
-	if flightNum != "" {
+// enrichmentMatch returns the condition, and its arguments, selecting the
+// enrichment for a flight as GetFlightEnrichment does.
+func enrichmentMatch(icaoHex, callsign string, flightDate time.Time, basis DateBasis, tenants []string) (string, []interface{}) {
+	var where string
+	var args []interface{}
+	// Extract flight number for fuzzy matching.
+	if flightNum := extractFlightNumber(callsign); flightNum != "" {
 		// Use fuzzy matching on flight number suffix to find IATA/ICAO variants.
-		query = `
-			SELECT icao_hex, callsign, flight_date, origin, destination, route,
-			       eta, departure_runway, arrival_runway, sid, squawk, pax_count, pax_breakdown, updated_at
-			FROM flight_enrichment
-			WHERE icao_hex = $1 AND flight_date = $2 AND callsign ~ ($3 || '$')
-		`
+		where = fmt.Sprintf("icao_hex = $1 AND %s = $2 AND callsign ~ ($3 || '$')", basis.column())
 		args = []interface{}{icaoHex, flightDate, flightNum}
 	} else {
 		// No flight number extracted - fall back to exact callsign match.
-		query = `
-			SELECT icao_hex, callsign, flight_date, origin, destination, route,
-			       eta, departure_runway, arrival_runway, sid, squawk, pax_count, pax_breakdown, updated_at
-			FROM flight_enrichment
-			WHERE icao_hex = $1 AND callsign = $2 AND flight_date = $3
-		`
+		where = fmt.Sprintf("icao_hex = $1 AND callsign = $2 AND %s = $3", basis.column())
 		args = []interface{}{icaoHex, callsign, flightDate}
 	}
+	if tenants != nil {
+		where += fmt.Sprintf(" AND tenants && $%d", len(args)+1)
+		args = append(args, tenants)
+	}
+	return where, args
+}
 
+// GetEnrichmentAudit returns the changes made to the enrichment for a flight,
+// matched as by GetFlightEnrichment, oldest first. It returns nil when the
+// flight has no enrichment or its changes predate the audit trail.
+func (d *PostgresDB) GetEnrichmentAudit(ctx context.Context, icaoHex, callsign string, flightDate time.Time, basis DateBasis, tenants []string) ([]EnrichmentChange, error) {
+	where, args := enrichmentMatch(icaoHex, callsign, flightDate, basis, tenants)
+	rows, err := d.pool.Query(ctx, fmt.Sprintf(`
+		SELECT field, old_value, new_value, changed_at, update_callsign, merged,
+			COALESCE(message_id, 0), COALESCE(parser, '')
+		FROM enrichment_audit
+		WHERE enrichment_id = (
+			SELECT id FROM flight_enrichment
+			WHERE %s
+			ORDER BY updated_at DESC
+			LIMIT 1
+		)
+		ORDER BY changed_at, id
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []EnrichmentChange
+	for rows.Next() {
+		var c EnrichmentChange
+		var oldValue, newValue []byte
+		if err := rows.Scan(&c.Field, &oldValue, &newValue, &c.ChangedAt, &c.UpdateCallsign, &c.Merged, &c.MessageID, &c.Parser); err != nil {
+			return nil, err
+		}
+		c.OldValue, c.NewValue = oldValue, newValue
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// GetFlightEnrichmentsByAircraft returns all enrichments for an aircraft on a
+// given date, by the date basis given, and restricted to tenants as by
+// GetFlightEnrichment.
+// This is useful when an aircraft may have multiple flights (callsigns) on the same day.
+func (d *PostgresDB) GetFlightEnrichmentsByAircraft(ctx context.Context, icaoHex string, flightDate time.Time, basis DateBasis, tenants []string) ([]FlightEnrichment, error) {
+	where := fmt.Sprintf("icao_hex = $1 AND %s = $2", basis.column())
+	args := []interface{}{icaoHex, flightDate}
+	if tenants != nil {
+		where += " AND tenants && $3"
+		args = append(args, tenants)
+	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM flight_enrichment
+		WHERE %s
+		ORDER BY updated_at DESC
+	`, enrichmentColumns, where)
+
+	rows, err := d.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []FlightEnrichment
+	for rows.Next() {
+		e, err := scanEnrichment(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *e)
+	}
+
+	return results, rows.Err()
+}
+
+// scanEnrichment reads a row of enrichmentColumns.
+func scanEnrichment(row pgx.Row) (*FlightEnrichment, error) {
 	var e FlightEnrichment
-	var routeJSON, breakdownJSON []byte
-	var origin, destination, depRunway, arrRunway, sid, squawk *string
+	var routeJSON, breakdownJSON, clearanceJSON []byte
+	var sidWaypointsJSON, starWaypointsJSON []byte
+	var origin, destination, etaSource, depRunway, arrRunway, sid, star, squawk *string
 	var paxCount *int
 	var eta *time.Time
 
-	err := d.pool.QueryRow(ctx, query, args...).Scan(
-		&e.ICAOHex, &e.Callsign, &e.FlightDate,
+	err := row.Scan(
+		&e.ICAOHex, &e.Callsign, &e.FlightDate, &e.FlightDateUTC, &e.ScheduledDeparture,
 		&origin, &destination, &routeJSON,
-		&eta, &depRunway, &arrRunway, &sid, &squawk, &paxCount, &breakdownJSON, &e.UpdatedAt,
+		&eta, &etaSource, &e.ETAUncertainty, &depRunway, &arrRunway, &sid, &star, &sidWaypointsJSON, &starWaypointsJSON,
+		&squawk, &paxCount, &breakdownJSON, &clearanceJSON, &e.UpdatedAt,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
 
@@ -932,6 +1310,15 @@ func (d *PostgresDB) GetFlightEnrichment(ctx context.Context, icaoHex, callsign
 	if sid != nil {
 		e.SID = *sid
 	}
+	if star != nil {
+		e.STAR = *star
+	}
+	if len(sidWaypointsJSON) > 0 {
+		_ = json.Unmarshal(sidWaypointsJSON, &e.SIDWaypoints)
+	}
+	if len(starWaypointsJSON) > 0 {
+		_ = json.Unmarshal(starWaypointsJSON, &e.STARWaypoints)
+	}
 	if squawk != nil {
 		e.Squawk = *squawk
 	}
@@ -941,85 +1328,66 @@ func (d *PostgresDB) GetFlightEnrichment(ctx context.Context, icaoHex, callsign
 	if eta != nil {
 		e.ETA = eta
 	}
+	if etaSource != nil {
+		e.ETASource = *etaSource
+	}
 	if len(routeJSON) > 0 {
 		_ = json.Unmarshal(routeJSON, &e.Route)
 	}
 	if len(breakdownJSON) > 0 {
 		_ = json.Unmarshal(breakdownJSON, &e.PaxBreakdown)
 	}
+	if len(clearanceJSON) > 0 {
+		_ = json.Unmarshal(clearanceJSON, &e.OceanicClearance)
+	}
 
 	return &e, nil
 }
 
-// GetFlightEnrichmentsByAircraft returns all enrichments for an aircraft on a given date.
-// This is useful when an aircraft may have multiple flights (callsigns) on the same day.
-func (d *PostgresDB) GetFlightEnrichmentsByAircraft(ctx context.Context, icaoHex string, flightDate time.Time) ([]FlightEnrichment, error) {
-	query := `
-		SELECT icao_hex, callsign, flight_date, origin, destination, route,
-		       eta, departure_runway, arrival_runway, sid, squawk, pax_count, pax_breakdown, updated_at
-		FROM flight_enrichment
-		WHERE icao_hex = $1 AND flight_date = $2
-		ORDER BY updated_at DESC
-	`
+// EnrichmentChannel is the notification channel on which a trigger on
+// flight_enrichment announces changes. The payload is the aircraft's icao_hex,
+// or empty when the table was truncated.
+const EnrichmentChannel = "flight_enrichment_changed"
+
+// ListenEnrichmentChanges calls fn with the icao_hex of each aircraft whose
+// enrichment changes, however it was written, until ctx is cancelled. fn is
+// called with an empty icao_hex whenever every aircraft must be assumed
+// changed: on connecting, after a truncate, and after reconnecting, when
+// notifications may have been missed. Connection errors are retried.
+func (d *PostgresDB) ListenEnrichmentChanges(ctx context.Context, fn func(icaoHex string)) {
+	for ctx.Err() == nil {
+		err := d.listenEnrichmentChanges(ctx, fn)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("enrichment change listener: %v; retrying", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
 
-	rows, err := d.pool.Query(ctx, query, icaoHex, flightDate)
+func (d *PostgresDB) listenEnrichmentChanges(ctx context.Context, fn func(icaoHex string)) error {
+	conn, err := d.pool.Acquire(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	// The connection holds a LISTEN, so close it rather than return it to
+	// the pool.
+	defer conn.Hijack().Close(context.Background())
 
-	var results []FlightEnrichment
-	for rows.Next() {
-		var e FlightEnrichment
-		var routeJSON, breakdownJSON []byte
-		var origin, destination, depRunway, arrRunway, sid, squawk *string
-		var paxCount *int
-		var eta *time.Time
-
-		err := rows.Scan(
-			&e.ICAOHex, &e.Callsign, &e.FlightDate,
-			&origin, &destination, &routeJSON,
-			&eta, &depRunway, &arrRunway, &sid, &squawk, &paxCount, &breakdownJSON, &e.UpdatedAt,
-		)
+	if _, err := conn.Exec(ctx, `LISTEN `+EnrichmentChannel); err != nil {
+		return err
+	}
+	fn("")
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
 		if err != nil {
-			return nil, err
-		}
-
-		if origin != nil {
-			e.Origin = *origin
-		}
-		if destination != nil {
-			e.Destination = *destination
-		}
-		if depRunway != nil {
-			e.DepartureRunway = *depRunway
-		}
-		if arrRunway != nil {
-			e.ArrivalRunway = *arrRunway
-		}
-		if sid != nil {
-			e.SID = *sid
-		}
-		if squawk != nil {
-			e.Squawk = *squawk
-		}
-		if paxCount != nil {
-			e.PaxCount = paxCount
+			return err
 		}
-		if eta != nil {
-			e.ETA = eta
-		}
-		if len(routeJSON) > 0 {
-			_ = json.Unmarshal(routeJSON, &e.Route)
-		}
-		if len(breakdownJSON) > 0 {
-			_ = json.Unmarshal(breakdownJSON, &e.PaxBreakdown)
-		}
-
-		results = append(results, e)
+		fn(n.Payload)
 	}
-
-	return results, rows.Err()
 }
 
 // UpsertGoldenAnnotation inserts or updates a golden annotation.
@@ -1046,10 +1414,12 @@ func (d *PostgresDB) GetGoldenAnnotation(ctx context.Context, messageID int64) (
 	var g GoldenAnnotation
 	var expectedJSON []byte
 
+	var annotation, flagReason *string
+
 	err := d.pool.QueryRow(ctx, `
-		SELECT message_id, is_golden, annotation, expected_json, created_at, updated_at
+		SELECT message_id, is_golden, annotation, expected_json, flagged, flag_reason, created_at, updated_at
 		FROM golden_annotations WHERE message_id = $1
-	`, messageID).Scan(&g.MessageID, &g.IsGolden, &g.Annotation, &expectedJSON, &g.CreatedAt, &g.UpdatedAt)
+	`, messageID).Scan(&g.MessageID, &g.IsGolden, &annotation, &expectedJSON, &g.Flagged, &flagReason, &g.CreatedAt, &g.UpdatedAt)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -1057,6 +1427,12 @@ func (d *PostgresDB) GetGoldenAnnotation(ctx context.Context, messageID int64) (
 		return nil, err
 	}
 
+	if annotation != nil {
+		g.Annotation = *annotation
+	}
+	if flagReason != nil {
+		g.FlagReason = *flagReason
+	}
 	_ = json.Unmarshal(expectedJSON, &g.ExpectedJSON)
 	return &g, nil
 }
@@ -1064,7 +1440,7 @@ func (d *PostgresDB) GetGoldenAnnotation(ctx context.Context, messageID int64) (
 // GetGoldenMessages retrieves all golden annotations.
 func (d *PostgresDB) GetGoldenMessages(ctx context.Context) ([]GoldenAnnotation, error) {
 	rows, err := d.pool.Query(ctx, `
-		SELECT message_id, is_golden, annotation, expected_json, created_at, updated_at
+		SELECT message_id, is_golden, annotation, expected_json, flagged, flag_reason, created_at, updated_at
 		FROM golden_annotations WHERE is_golden = TRUE
 	`)
 	if err != nil {
@@ -1076,10 +1452,17 @@ func (d *PostgresDB) GetGoldenMessages(ctx context.Context) ([]GoldenAnnotation,
 	for rows.Next() {
 		var g GoldenAnnotation
 		var expectedJSON []byte
+		var annotation, flagReason *string
 
-		if err := rows.Scan(&g.MessageID, &g.IsGolden, &g.Annotation, &expectedJSON, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		if err := rows.Scan(&g.MessageID, &g.IsGolden, &annotation, &expectedJSON, &g.Flagged, &flagReason, &g.CreatedAt, &g.UpdatedAt); err != nil {
 			return nil, err
 		}
+		if annotation != nil {
+			g.Annotation = *annotation
+		}
+		if flagReason != nil {
+			g.FlagReason = *flagReason
+		}
 		_ = json.Unmarshal(expectedJSON, &g.ExpectedJSON)
 		annotations = append(annotations, g)
 	}
@@ -1113,6 +1496,85 @@ func (d *PostgresDB) SetAnnotation(ctx context.Context, messageID int64, annotat
 	return err
 }
 
+// SetFlag flags or unflags a message for follow-up, with an optional reason.
+// Unflagging clears the reason.
+func (d *PostgresDB) SetFlag(ctx context.Context, messageID int64, flagged bool, reason string) error {
+	if !flagged {
+		reason = ""
+	}
+	now := time.Now()
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO golden_annotations (message_id, flagged, flag_reason, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $4)
+		ON CONFLICT (message_id) DO UPDATE SET
+			flagged = EXCLUDED.flagged,
+			flag_reason = EXCLUDED.flag_reason,
+			updated_at = EXCLUDED.updated_at
+	`, messageID, flagged, reason, now)
+	return err
+}
+
+// AnnotationFilter selects annotated message IDs. Nil fields are not filtered on.
+type AnnotationFilter struct {
+	Golden  *bool
+	Flagged *bool
+}
+
+// GetAnnotatedMessageIDs returns the IDs of annotated messages matching the filter,
+// in ascending order.
+func (d *PostgresDB) GetAnnotatedMessageIDs(ctx context.Context, f AnnotationFilter) ([]int64, error) {
+	query := `SELECT message_id FROM golden_annotations WHERE TRUE`
+	var args []interface{}
+	if f.Golden != nil {
+		args = append(args, *f.Golden)
+		query += fmt.Sprintf(" AND is_golden = $%d", len(args))
+	}
+	if f.Flagged != nil {
+		args = append(args, *f.Flagged)
+		query += fmt.Sprintf(" AND flagged = $%d", len(args))
+	}
+	query += " ORDER BY message_id"
+
+	rows, err := d.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ResetDerivedState truncates the tables that are rebuilt from the message corpus:
+// aircraft, waypoints, routes (with legs and aircraft), callsigns, current ATIS,
+// flight enrichment with its audit trail and applied messages, flight state
+// with its history, positions, comm assignments, squawks, flight plans, route
+// changes and arrival runways, ETA reports, emergency events, ground station counts, AFN
+// logons, the wind grid, weather observations and turbulence reports.
+// Golden annotations and reference tables are left untouched.
+func (d *PostgresDB) ResetDerivedState(ctx context.Context) error {
+	_, err := d.pool.Exec(ctx, `
+		TRUNCATE aircraft, waypoints, routes, route_legs, route_aircraft,
+			aircraft_callsigns, atis_current, flight_enrichment, enrichment_audit, enrichment_messages,
+			flight_state, flight_history, flight_positions, comm_assignments, squawk_history,
+			flight_plans, route_changes, arrival_runways, eta_reports,
+			emergency_events, ground_stations, afn_logons, wind_grid,
+			weather_observations, turbulence_reports
+		RESTART IDENTITY
+	`)
+	if err != nil {
+		return fmt.Errorf("truncate derived state: %w", err)
+	}
+	return nil
+}
+
 // Pool returns the underlying connection pool for advanced operations.
 func (d *PostgresDB) Pool() *pgxpool.Pool {
 	return d.pool
@@ -1142,14 +1604,25 @@ func (d *PostgresDB) ListWaypoints(ctx context.Context, minSources int) ([]Waypo
 	return waypoints, rows.Err()
 }
 
-// ListRoutes retrieves all routes with at least minObservations.
-func (d *PostgresDB) ListRoutes(ctx context.Context, minObservations int) ([]Route, error) {
+// RouteQuery selects the routes ListRoutes returns.
+type RouteQuery struct {
+	MinObservations int
+	SeenSince       time.Time // Only routes last observed at or after this (zero = any).
+	IncludeHistoric bool      // Include routes marked historic.
+	// Unsynced restricts to routes observed since they were last synced
+	// (see MarkRoutesSynced), or never synced.
+	Unsynced bool
+}
+
+// ListRoutes retrieves the routes matching q, by flight pattern.
+func (d *PostgresDB) ListRoutes(ctx context.Context, q RouteQuery) ([]Route, error) {
 	rows, err := d.pool.Query(ctx, `
-		SELECT id, flight_pattern, origin_icao, dest_icao, is_multi_stop, observation_count, first_seen, last_seen, synced_at
+		SELECT id, flight_pattern, origin_icao, dest_icao, is_multi_stop, observation_count, first_seen, last_seen, synced_at, historic_at
 		FROM routes
-		WHERE observation_count >= $1
+		WHERE observation_count >= $1 AND last_seen >= $2 AND ($3 OR historic_at IS NULL)
+			AND (NOT $4 OR synced_at IS NULL OR last_seen > synced_at)
 		ORDER BY flight_pattern
-	`, minObservations)
+	`, q.MinObservations, q.SeenSince, q.IncludeHistoric, q.Unsynced)
 	if err != nil {
 		return nil, err
 	}
@@ -1158,7 +1631,7 @@ func (d *PostgresDB) ListRoutes(ctx context.Context, minObservations int) ([]Rou
 	var routes []Route
 	for rows.Next() {
 		var r Route
-		if err := rows.Scan(&r.ID, &r.FlightPattern, &r.OriginICAO, &r.DestICAO, &r.IsMultiStop, &r.ObservationCount, &r.FirstSeen, &r.LastSeen, &r.SyncedAt); err != nil {
+		if err := rows.Scan(&r.ID, &r.FlightPattern, &r.OriginICAO, &r.DestICAO, &r.IsMultiStop, &r.ObservationCount, &r.FirstSeen, &r.LastSeen, &r.SyncedAt, &r.HistoricAt); err != nil {
 			return nil, err
 		}
 		routes = append(routes, r)
@@ -1166,6 +1639,78 @@ func (d *PostgresDB) ListRoutes(ctx context.Context, minObservations int) ([]Rou
 	return routes, rows.Err()
 }
 
+// MarkRoutesSynced records routes as synced up to the last_seen they were
+// sent with, so that a route observed again while the sync ran is sent again
+// next time.
+func (d *PostgresDB) MarkRoutesSynced(ctx context.Context, routes []Route) error {
+	ids := make([]int, len(routes))
+	seen := make([]time.Time, len(routes))
+	for i, r := range routes {
+		ids[i], seen[i] = r.ID, r.LastSeen
+	}
+	_, err := d.pool.Exec(ctx, `
+		UPDATE routes r SET synced_at = v.last_seen
+		FROM unnest($1::int[], $2::timestamptz[]) AS v(id, last_seen)
+		WHERE r.id = v.id
+	`, ids, seen)
+	return err
+}
+
+// ListAircraftCallsigns retrieves the callsign mappings by registration. With
+// unsynced, only those observed since they were last synced, or never synced.
+func (d *PostgresDB) ListAircraftCallsigns(ctx context.Context, unsynced bool) ([]AircraftCallsign, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT registration, iata_prefix, icao_prefix, observation_count, first_seen, last_seen, synced_at
+		FROM aircraft_callsigns
+		WHERE NOT $1 OR synced_at IS NULL OR last_seen > synced_at
+		ORDER BY registration
+	`, unsynced)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var callsigns []AircraftCallsign
+	for rows.Next() {
+		var c AircraftCallsign
+		if err := rows.Scan(&c.Registration, &c.IATAPrefix, &c.ICAOPrefix, &c.ObservationCount, &c.FirstSeen, &c.LastSeen, &c.SyncedAt); err != nil {
+			return nil, err
+		}
+		callsigns = append(callsigns, c)
+	}
+	return callsigns, rows.Err()
+}
+
+// MarkAircraftCallsignsSynced records callsign mappings as synced up to the
+// last_seen they were sent with, as MarkRoutesSynced does for routes.
+func (d *PostgresDB) MarkAircraftCallsignsSynced(ctx context.Context, callsigns []AircraftCallsign) error {
+	regs := make([]string, len(callsigns))
+	seen := make([]time.Time, len(callsigns))
+	for i, c := range callsigns {
+		regs[i], seen[i] = c.Registration, c.LastSeen
+	}
+	_, err := d.pool.Exec(ctx, `
+		UPDATE aircraft_callsigns c SET synced_at = v.last_seen
+		FROM unnest($1::text[], $2::timestamptz[]) AS v(registration, last_seen)
+		WHERE c.registration = v.registration
+	`, regs, seen)
+	return err
+}
+
+// MarkHistoricRoutes marks the routes not observed since before as historic,
+// so that exports leave them out without losing their history. It returns the
+// number of routes marked. Routes already marked keep their original time.
+func (d *PostgresDB) MarkHistoricRoutes(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := d.pool.Exec(ctx, `
+		UPDATE routes SET historic_at = NOW()
+		WHERE last_seen < $1 AND historic_at IS NULL
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
 // GetRouteLegs retrieves all legs for a route.
 func (d *PostgresDB) GetRouteLegs(ctx context.Context, routeID int) ([]RouteLeg, error) {
 	rows, err := d.pool.Query(ctx, `
@@ -1189,3 +1734,491 @@ func (d *PostgresDB) GetRouteLegs(ctx context.Context, routeID int) ([]RouteLeg,
 	}
 	return legs, rows.Err()
 }
+
+// Airline represents an airline reference record.
+type Airline struct {
+	ICAOCode  string
+	IATACode  string
+	Name      string
+	UpdatedAt time.Time
+}
+
+// UpsertAirlines inserts or updates airline reference records in one transaction.
+func (d *PostgresDB) UpsertAirlines(ctx context.Context, airlines []Airline) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, a := range airlines {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO airlines (icao_code, iata_code, name, updated_at)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NOW())
+			ON CONFLICT (icao_code) DO UPDATE SET
+				iata_code = EXCLUDED.iata_code,
+				name = EXCLUDED.name,
+				updated_at = NOW()
+		`, a.ICAOCode, a.IATACode, a.Name)
+		if err != nil {
+			return fmt.Errorf("upsert airline %s: %w", a.ICAOCode, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ListAirlines retrieves all airlines ordered by ICAO code.
+func (d *PostgresDB) ListAirlines(ctx context.Context) ([]Airline, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT icao_code, COALESCE(iata_code, ''), COALESCE(name, ''), updated_at
+		FROM airlines
+		ORDER BY icao_code
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var airlines []Airline
+	for rows.Next() {
+		var a Airline
+		if err := rows.Scan(&a.ICAOCode, &a.IATACode, &a.Name, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		airlines = append(airlines, a)
+	}
+	return airlines, rows.Err()
+}
+
+// GetAirlinesByCode retrieves the airlines with an ICAO or IATA code. An IATA
+// code can return several airlines, as designators are reused.
+func (d *PostgresDB) GetAirlinesByCode(ctx context.Context, code string) ([]Airline, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT icao_code, COALESCE(iata_code, ''), COALESCE(name, ''), updated_at
+		FROM airlines
+		WHERE icao_code = $1 OR iata_code = $1
+		ORDER BY icao_code
+	`, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var airlines []Airline
+	for rows.Next() {
+		var a Airline
+		if err := rows.Scan(&a.ICAOCode, &a.IATACode, &a.Name, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		airlines = append(airlines, a)
+	}
+	return airlines, rows.Err()
+}
+
+// Airport represents an airport reference record.
+type Airport struct {
+	ICAOCode  string
+	IATACode  string
+	Name      string
+	Country   string
+	TZ        string // IANA time zone.
+	UpdatedAt time.Time
+}
+
+// UpsertAirports inserts or updates airport reference records in one transaction.
+func (d *PostgresDB) UpsertAirports(ctx context.Context, airports []Airport) error {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, a := range airports {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO airports (icao_code, iata_code, name, country, tz, updated_at)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NOW())
+			ON CONFLICT (icao_code) DO UPDATE SET
+				iata_code = EXCLUDED.iata_code,
+				name = EXCLUDED.name,
+				country = EXCLUDED.country,
+				tz = EXCLUDED.tz,
+				updated_at = NOW()
+		`, a.ICAOCode, a.IATACode, a.Name, a.Country, a.TZ)
+		if err != nil {
+			return fmt.Errorf("upsert airport %s: %w", a.ICAOCode, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ListAirports retrieves all airports ordered by ICAO code.
+func (d *PostgresDB) ListAirports(ctx context.Context) ([]Airport, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT icao_code, COALESCE(iata_code, ''), COALESCE(name, ''), COALESCE(country, ''), COALESCE(tz, ''), updated_at
+		FROM airports
+		ORDER BY icao_code
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var airports []Airport
+	for rows.Next() {
+		var a Airport
+		if err := rows.Scan(&a.ICAOCode, &a.IATACode, &a.Name, &a.Country, &a.TZ, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		airports = append(airports, a)
+	}
+	return airports, rows.Err()
+}
+
+// AircraftFlight is one flight in an airframe's operating history.
+type AircraftFlight struct {
+	Key          string // flight_state key; empty for enrichment-only flights.
+	ICAOHex      string
+	Registration string
+	Callsign     string
+	FlightDate   time.Time // UTC date of the first message.
+	Origin       string
+	Destination  string
+	FirstSeen    time.Time
+	LastSeen     time.Time
+	MsgCount     int
+	Completion   string // "arrived", "inactive", or "" for a flight in progress.
+	Source       string // "current", "history" or "enrichment".
+	FuelOut      *int   // Fuel on board in kg at OUT, OFF, ON and IN, if reported.
+	FuelOff      *int
+	FuelOn       *int
+	FuelIn       *int
+}
+
+// ListAircraftFlights retrieves the flights of an aircraft with a flight date
+// in [from, to], most recent first. Flights come from flight_state and
+// flight_history; enrichment rows with no matching tracked flight (recorded
+// before flight tracking, or without a known registration) are included with
+// their creation and update times as first and last seen and no message count.
+func (d *PostgresDB) ListAircraftFlights(ctx context.Context, icaoHex string, from, to time.Time, limit int) ([]AircraftFlight, error) {
+	rows, err := d.pool.Query(ctx, `
+		WITH tracked AS (
+			SELECT key, COALESCE(icao_hex, '') AS icao_hex, COALESCE(registration, '') AS registration,
+				COALESCE(flight_number, '') AS callsign, (first_seen AT TIME ZONE 'UTC')::date AS flight_date,
+				COALESCE(origin, '') AS origin, COALESCE(destination, '') AS destination,
+				first_seen, last_seen, msg_count, COALESCE(completion, '') AS completion, 'current' AS source,
+				fuel_out_kg, fuel_off_kg, fuel_on_kg, fuel_in_kg
+			FROM flight_state WHERE icao_hex = $1
+			UNION ALL
+			SELECT key, COALESCE(icao_hex, ''), COALESCE(registration, ''),
+				COALESCE(flight_number, ''), (first_seen AT TIME ZONE 'UTC')::date,
+				COALESCE(origin, ''), COALESCE(destination, ''),
+				first_seen, last_seen, msg_count, completion, 'history',
+				fuel_out_kg, fuel_off_kg, fuel_on_kg, fuel_in_kg
+			FROM flight_history WHERE icao_hex = $1
+		)
+		SELECT * FROM (
+			SELECT * FROM tracked
+			UNION ALL
+			SELECT '', e.icao_hex, '', COALESCE(e.callsign, ''), e.flight_date,
+				COALESCE(e.origin, ''), COALESCE(e.destination, ''),
+				e.created_at, e.updated_at, 0, '', 'enrichment',
+				NULL, NULL, NULL, NULL
+			FROM flight_enrichment e
+			WHERE e.icao_hex = $1 AND NOT EXISTS (
+				SELECT 1 FROM tracked t
+				WHERE t.callsign = e.callsign AND t.flight_date = e.flight_date
+			)
+		) flights
+		WHERE flight_date BETWEEN $2 AND $3
+		ORDER BY first_seen DESC
+		LIMIT $4
+	`, icaoHex, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flights []AircraftFlight
+	for rows.Next() {
+		var f AircraftFlight
+		err := rows.Scan(&f.Key, &f.ICAOHex, &f.Registration, &f.Callsign, &f.FlightDate, &f.Origin, &f.Destination,
+			&f.FirstSeen, &f.LastSeen, &f.MsgCount, &f.Completion, &f.Source,
+			&f.FuelOut, &f.FuelOff, &f.FuelOn, &f.FuelIn)
+		if err != nil {
+			return nil, err
+		}
+		flights = append(flights, f)
+	}
+	return flights, rows.Err()
+}
+
+// FlightPosition is one position of a flight, stored in flight_positions.
+type FlightPosition struct {
+	Timestamp time.Time
+	Latitude  float64
+	Longitude float64
+	Altitude  *int // Feet.
+	// GroundSpeed (knots) and Track (degrees true) are set when the report
+	// gave the aircraft's velocity.
+	GroundSpeed *int
+	Track       *int
+	Source      string
+	Rejection   string // Why the position failed the plausibility check; empty if accepted.
+}
+
+// InsertFlightPositions adds positions to a flight's track. Positions already
+// stored for the flight at the same time and place are skipped. Rejected
+// positions are stored with their reason for parser debugging.
+func (d *PostgresDB) InsertFlightPositions(ctx context.Context, key string, positions []FlightPosition) error {
+	for _, p := range positions {
+		_, err := d.pool.Exec(ctx, `
+			INSERT INTO flight_positions (flight_key, ts, latitude, longitude, altitude, ground_speed, track, source, rejection)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+			ON CONFLICT (flight_key, ts, latitude, longitude) DO UPDATE SET
+				altitude = COALESCE(flight_positions.altitude, EXCLUDED.altitude),
+				ground_speed = COALESCE(flight_positions.ground_speed, EXCLUDED.ground_speed),
+				track = COALESCE(flight_positions.track, EXCLUDED.track)
+		`, key, p.Timestamp, p.Latitude, p.Longitude, p.Altitude, p.GroundSpeed, p.Track, p.Source, p.Rejection)
+		if err != nil {
+			return fmt.Errorf("insert flight position %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// GetFlightPositions retrieves the accepted positions of a flight between from
+// and to, inclusive, in time order. Archived flights share their key with later
+// flights of the same aircraft and flight number, so the range should be the
+// flight's first and last seen times.
+func (d *PostgresDB) GetFlightPositions(ctx context.Context, key string, from, to time.Time) ([]FlightPosition, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT ts, latitude, longitude, altitude, ground_speed, track, COALESCE(source, '')
+		FROM flight_positions
+		WHERE flight_key = $1 AND ts BETWEEN $2 AND $3 AND rejection IS NULL
+		ORDER BY ts
+	`, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []FlightPosition
+	for rows.Next() {
+		var p FlightPosition
+		if err := rows.Scan(&p.Timestamp, &p.Latitude, &p.Longitude, &p.Altitude, &p.GroundSpeed, &p.Track, &p.Source); err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+// GetLastFlightPositions retrieves the latest accepted position of a flight at
+// or before a time, and the latest position rejected after it. Either is nil
+// if there is none.
+func (d *PostgresDB) GetLastFlightPositions(ctx context.Context, key string, before time.Time) (accepted, rejected *FlightPosition, err error) {
+	rows, err := d.pool.Query(ctx, `
+		WITH accepted AS (
+			SELECT ts, latitude, longitude, altitude, COALESCE(source, '') AS source, '' AS rejection
+			FROM flight_positions
+			WHERE flight_key = $1 AND ts <= $2 AND rejection IS NULL
+			ORDER BY ts DESC LIMIT 1
+		)
+		SELECT * FROM accepted
+		UNION ALL
+		(SELECT ts, latitude, longitude, altitude, COALESCE(source, ''), rejection
+		FROM flight_positions
+		WHERE flight_key = $1 AND ts <= $2 AND rejection IS NOT NULL
+			AND ts >= COALESCE((SELECT ts FROM accepted), '-infinity')
+		ORDER BY ts DESC LIMIT 1)
+	`, key, before)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p FlightPosition
+		if err := rows.Scan(&p.Timestamp, &p.Latitude, &p.Longitude, &p.Altitude, &p.Source, &p.Rejection); err != nil {
+			return nil, nil, err
+		}
+		if p.Rejection == "" {
+			accepted = &p
+		} else {
+			rejected = &p
+		}
+	}
+	return accepted, rejected, rows.Err()
+}
+
+// BBox is a latitude and longitude range in degrees. A box with West greater
+// than East crosses the antimeridian.
+type BBox struct {
+	South, West, North, East float64
+}
+
+// RecentPosition is the latest position of a flight within a search area,
+// with the identity of the flight it belongs to.
+type RecentPosition struct {
+	FlightPosition
+	FlightKey    string
+	ICAOHex      string
+	Registration string
+	Callsign     string
+	Origin       string
+	Destination  string
+}
+
+// GetRecentPositions retrieves the latest accepted position of each flight
+// within box at or after since, newest first. Flights are named from
+// flight_state, or from flight_history once archived.
+func (d *PostgresDB) GetRecentPositions(ctx context.Context, box BBox, since time.Time, limit int) ([]RecentPosition, error) {
+	lonCond := "longitude BETWEEN $4 AND $5"
+	if box.West > box.East {
+		lonCond = "(longitude >= $4 OR longitude <= $5)"
+	}
+	rows, err := d.pool.Query(ctx, `
+		SELECT p.flight_key, p.ts, p.latitude, p.longitude, p.altitude, COALESCE(p.source, ''),
+			COALESCE(f.icao_hex, ''), COALESCE(f.registration, ''), COALESCE(f.flight_number, ''),
+			COALESCE(f.origin, ''), COALESCE(f.destination, '')
+		FROM (
+			SELECT DISTINCT ON (flight_key) flight_key, ts, latitude, longitude, altitude, source
+			FROM flight_positions
+			WHERE rejection IS NULL AND ts >= $1 AND latitude BETWEEN $2 AND $3 AND `+lonCond+`
+			ORDER BY flight_key, ts DESC
+		) p
+		LEFT JOIN LATERAL (
+			SELECT icao_hex, registration, flight_number, origin, destination
+			FROM flight_state WHERE key = p.flight_key AND first_seen <= p.ts
+			UNION ALL
+			(SELECT icao_hex, registration, flight_number, origin, destination
+			FROM flight_history WHERE key = p.flight_key AND p.ts BETWEEN first_seen AND last_seen
+			ORDER BY first_seen DESC LIMIT 1)
+			LIMIT 1
+		) f ON TRUE
+		ORDER BY p.ts DESC
+		LIMIT $6
+	`, since, box.South, box.North, box.West, box.East, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []RecentPosition
+	for rows.Next() {
+		var p RecentPosition
+		err := rows.Scan(&p.FlightKey, &p.Timestamp, &p.Latitude, &p.Longitude, &p.Altitude, &p.Source,
+			&p.ICAOHex, &p.Registration, &p.Callsign, &p.Origin, &p.Destination)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+// CommAssignment is a SELCAL code or radio frequency given to a flight, stored
+// in comm_assignments.
+type CommAssignment struct {
+	Timestamp    time.Time
+	Kind         string // "selcal", "contact", "monitor" or "departure".
+	SELCAL       string // Four letters, e.g. "ABCD"; empty for frequencies.
+	FrequencyKHz int    // 0 for SELCAL codes.
+	Band         string // "hf", "vhf", "uhf" or "satcom"; empty for SELCAL codes.
+	Unit         string // ATC unit to contact, if given.
+	Source       string // Result type or "text".
+}
+
+// InsertCommAssignments adds comm assignments to a flight. An assignment
+// already stored for the flight at the same time is skipped.
+func (d *PostgresDB) InsertCommAssignments(ctx context.Context, key string, assignments []CommAssignment) error {
+	for _, a := range assignments {
+		_, err := d.pool.Exec(ctx, `
+			INSERT INTO comm_assignments (flight_key, ts, kind, selcal, frequency_khz, band, unit, source)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''))
+			ON CONFLICT (flight_key, ts, kind, selcal, frequency_khz) DO UPDATE SET
+				unit = COALESCE(comm_assignments.unit, EXCLUDED.unit)
+		`, key, a.Timestamp, a.Kind, a.SELCAL, a.FrequencyKHz, a.Band, a.Unit, a.Source)
+		if err != nil {
+			return fmt.Errorf("insert comm assignment %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// GetCommAssignments retrieves the comm assignments of a flight between from
+// and to, inclusive, in time order. As with positions, the range should be the
+// flight's first and last seen times.
+func (d *PostgresDB) GetCommAssignments(ctx context.Context, key string, from, to time.Time) ([]CommAssignment, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT ts, kind, selcal, frequency_khz, COALESCE(band, ''), COALESCE(unit, ''), COALESCE(source, '')
+		FROM comm_assignments
+		WHERE flight_key = $1 AND ts BETWEEN $2 AND $3
+		ORDER BY ts, kind
+	`, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []CommAssignment
+	for rows.Next() {
+		var a CommAssignment
+		if err := rows.Scan(&a.Timestamp, &a.Kind, &a.SELCAL, &a.FrequencyKHz, &a.Band, &a.Unit, &a.Source); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// SquawkAssignment is a transponder code given to a flight, stored in
+// squawk_history.
+type SquawkAssignment struct {
+	Timestamp time.Time
+	Squawk    string
+	Source    string // Result type of the assigning message.
+	MessageID int64  // ClickHouse message ID; 0 if unknown.
+}
+
+// InsertSquawks adds transponder codes to a flight's squawk history. A code
+// already stored for the flight at the same time is skipped.
+func (d *PostgresDB) InsertSquawks(ctx context.Context, key string, squawks []SquawkAssignment) error {
+	for _, s := range squawks {
+		_, err := d.pool.Exec(ctx, `
+			INSERT INTO squawk_history (flight_key, ts, squawk, source, message_id)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, 0))
+			ON CONFLICT (flight_key, ts, squawk) DO NOTHING
+		`, key, s.Timestamp, s.Squawk, s.Source, s.MessageID)
+		if err != nil {
+			return fmt.Errorf("insert squawk %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// GetSquawkHistory retrieves the transponder codes assigned to a flight
+// between from and to, inclusive, in time order. As with positions, the range
+// should be the flight's first and last seen times.
+func (d *PostgresDB) GetSquawkHistory(ctx context.Context, key string, from, to time.Time) ([]SquawkAssignment, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT ts, squawk, COALESCE(source, ''), COALESCE(message_id, 0)
+		FROM squawk_history
+		WHERE flight_key = $1 AND ts BETWEEN $2 AND $3
+		ORDER BY ts
+	`, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var squawks []SquawkAssignment
+	for rows.Next() {
+		var s SquawkAssignment
+		if err := rows.Scan(&s.Timestamp, &s.Squawk, &s.Source, &s.MessageID); err != nil {
+			return nil, err
+		}
+		squawks = append(squawks, s)
+	}
+	return squawks, rows.Err()
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"os"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -98,7 +100,7 @@ func TestUpsertFlightEnrichment(t *testing.T) {
 	}
 
 	// Query and verify both fields present.
-	result, err := pg.GetFlightEnrichment(ctx, "7C6CA3", "QF008", flightDate)
+	result, err := pg.GetFlightEnrichment(ctx, "7C6CA3", "QF008", flightDate, DateLocal, nil)
 	if err != nil {
 		t.Fatalf("get failed: %v", err)
 	}
@@ -163,7 +165,7 @@ func TestUpsertFlightEnrichment_PaxData(t *testing.T) {
 		t.Fatalf("upsert failed: %v", err)
 	}
 
-	result, err := pg.GetFlightEnrichment(ctx, "TESTPX", "TEST1", flightDate)
+	result, err := pg.GetFlightEnrichment(ctx, "TESTPX", "TEST1", flightDate, DateLocal, nil)
 	if err != nil {
 		t.Fatalf("get failed: %v", err)
 	}
@@ -186,7 +188,7 @@ func TestGetFlightEnrichment_NotFound(t *testing.T) {
 	ctx := context.Background()
 	flightDate := time.Date(2099, 12, 31, 0, 0, 0, 0, time.UTC)
 
-	result, err := pg.GetFlightEnrichment(ctx, "NONEXISTENT", "FAKE999", flightDate)
+	result, err := pg.GetFlightEnrichment(ctx, "NONEXISTENT", "FAKE999", flightDate, DateLocal, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -223,4 +225,361 @@ func TestUpsertFlightEnrichment_MissingKey(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected nil error for missing flight_date, got: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func TestUpsertFlightEnrichment_RedEye(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM flight_enrichment WHERE icao_hex = 'TESTRE'")
+	}
+	cleanup()
+	defer cleanup()
+
+	// Clearance for a 23:30Z departure from New York, 18:30 local.
+	departure := time.Date(2026, 1, 27, 23, 30, 0, 0, time.UTC)
+	jan27 := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	err := pg.UpsertFlightEnrichment(ctx, FlightEnrichmentUpdate{
+		ICAOHex:            "TESTRE",
+		Callsign:           "BAW178",
+		FlightDate:         jan27,
+		FlightDateUTC:      jan27,
+		ScheduledDeparture: &departure,
+		MessageTime:        departure.Add(-30 * time.Minute),
+		DepartureRunway:    stringPtr("31L"),
+	})
+	if err != nil {
+		t.Fatalf("clearance upsert failed: %v", err)
+	}
+
+	// A message after midnight UTC, with no departure time, joins the flight.
+	seen := time.Date(2026, 1, 28, 3, 0, 0, 0, time.UTC)
+	err = pg.UpsertFlightEnrichment(ctx, FlightEnrichmentUpdate{
+		ICAOHex:     "TESTRE",
+		Callsign:    "BA178",
+		FlightDate:  time.Date(2026, 1, 28, 0, 0, 0, 0, time.UTC),
+		MessageTime: seen,
+		Destination: stringPtr("EGLL"),
+	})
+	if err != nil {
+		t.Fatalf("en-route upsert failed: %v", err)
+	}
+
+	for _, basis := range []DateBasis{DateLocal, DateUTC} {
+		all, err := pg.GetFlightEnrichmentsByAircraft(ctx, "TESTRE", jan27, basis, nil)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		if len(all) != 1 {
+			t.Fatalf("basis %d: %d rows, want the flight in one", basis, len(all))
+		}
+		e := all[0]
+		if e.Destination != "EGLL" || e.DepartureRunway != "31L" || e.ScheduledDeparture == nil || !e.ScheduledDeparture.Equal(departure) {
+			t.Errorf("basis %d: %+v", basis, e)
+		}
+	}
+}
+
+func TestDiffEnrichment(t *testing.T) {
+	date := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	old := &FlightEnrichment{ICAOHex: "7C6CA3", Callsign: "QF8", FlightDate: date, FlightDateUTC: date, Origin: "YSSY", Squawk: "4521", UpdatedAt: date}
+	updated := *old
+	updated.Callsign = "QFA8"
+	updated.Destination = "KDFW"
+	updated.Squawk = ""
+	updated.UpdatedAt = date.Add(time.Hour)
+
+	var got []string
+	for _, c := range diffEnrichment(old, &updated) {
+		got = append(got, c.Field+": "+string(c.OldValue)+" -> "+string(c.NewValue))
+	}
+	want := []string{`callsign: "QF8" -> "QFA8"`, `destination:  -> "KDFW"`, `squawk: "4521" -> `}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+
+	if changes := diffEnrichment(old, old); len(changes) != 0 {
+		t.Errorf("unchanged row: %+v", changes)
+	}
+	if changes := diffEnrichment(nil, old); len(changes) != 6 || changes[0].Field != "callsign" || changes[0].OldValue != nil {
+		t.Errorf("new row: %+v", changes)
+	}
+}
+
+func TestUpsertFlightEnrichment_Audit(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	flightDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM flight_enrichment WHERE icao_hex = 'TESTAU'")
+	}
+	cleanup()
+	defer cleanup()
+
+	updates := []FlightEnrichmentUpdate{
+		{ICAOHex: "TESTAU", Callsign: "QF8", FlightDate: flightDate, Origin: stringPtr("YSSY"), MessageID: 1, Parser: "pdc"},
+		{ICAOHex: "TESTAU", Callsign: "QF8", FlightDate: flightDate, Origin: stringPtr("YSSY"), MessageID: 2, Parser: "pdc"},
+		{ICAOHex: "TESTAU", Callsign: "QFA8", FlightDate: flightDate, Destination: stringPtr("KDFW"), MessageID: 3, Parser: "flight_plan"},
+	}
+	for _, u := range updates {
+		if err := pg.UpsertFlightEnrichment(ctx, u); err != nil {
+			t.Fatalf("upsert failed: %v", err)
+		}
+	}
+
+	changes, err := pg.GetEnrichmentAudit(ctx, "TESTAU", "QFA8", flightDate, DateLocal, nil)
+	if err != nil {
+		t.Fatalf("audit failed: %v", err)
+	}
+	var got []string
+	for _, c := range changes {
+		if c.MessageID == 2 {
+			t.Errorf("repeated values were audited: %+v", c)
+		}
+		if c.MessageID == 3 && (!c.Merged || c.Parser != "flight_plan" || c.UpdateCallsign != "QFA8") {
+			t.Errorf("merge not recorded: %+v", c)
+		}
+		got = append(got, c.Field)
+	}
+	// The first message creates the row; the third renames it and adds the destination.
+	want := []string{"callsign", "flight_date", "flight_date_utc", "icao_hex", "origin", "callsign", "destination"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("audited fields = %v, want %v", got, want)
+	}
+}
+
+func TestUpsertFlightEnrichment_Concurrent(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	flightDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM flight_enrichment WHERE icao_hex = 'TESTCC'")
+	}
+	cleanup()
+	defer cleanup()
+
+	// Processors write the same flight at once, under both callsign formats.
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u := FlightEnrichmentUpdate{ICAOHex: "TESTCC", Callsign: "QF9", FlightDate: flightDate, MessageID: int64(9_000_000_000 + i)}
+			if i%2 == 0 {
+				u.Callsign = "QFA9"
+				u.Origin = stringPtr("YPPH")
+			} else {
+				u.Destination = stringPtr("EGLL")
+			}
+			errs <- pg.UpsertFlightEnrichment(ctx, u)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("upsert failed: %v", err)
+		}
+	}
+
+	all, err := pg.GetFlightEnrichmentsByAircraft(ctx, "TESTCC", flightDate, DateLocal, nil)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("%d rows, want the flight in one: %+v", len(all), all)
+	}
+	if e := all[0]; e.Callsign != "QFA9" || e.Origin != "YPPH" || e.Destination != "EGLL" {
+		t.Errorf("lost an update: %+v", e)
+	}
+	var applied int
+	if err := pg.pool.QueryRow(ctx, "SELECT COUNT(*) FROM enrichment_messages WHERE message_id >= 9000000000 AND message_id < 9000000000 + $1", writers).Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != writers {
+		t.Errorf("%d messages recorded, want %d", applied, writers)
+	}
+}
+
+func TestUpsertFlightEnrichment_Replay(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	flightDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM flight_enrichment WHERE icao_hex = 'TESTRP'")
+	}
+	cleanup()
+	defer cleanup()
+
+	updates := []FlightEnrichmentUpdate{
+		{ICAOHex: "TESTRP", Callsign: "QFA9", FlightDate: flightDate, Squawk: stringPtr("1234"), MessageID: 9_100_000_001},
+		{ICAOHex: "TESTRP", Callsign: "QFA9", FlightDate: flightDate, Squawk: stringPtr("4321"), MessageID: 9_100_000_002},
+		// A replay of the first message does not undo the second.
+		{ICAOHex: "TESTRP", Callsign: "QFA9", FlightDate: flightDate, Squawk: stringPtr("1234"), MessageID: 9_100_000_001},
+	}
+	for _, u := range updates {
+		if err := pg.UpsertFlightEnrichment(ctx, u); err != nil {
+			t.Fatalf("upsert failed: %v", err)
+		}
+	}
+
+	e, err := pg.GetFlightEnrichment(ctx, "TESTRP", "QFA9", flightDate, DateLocal, nil)
+	if err != nil || e == nil {
+		t.Fatalf("get failed: %v, %v", e, err)
+	}
+	if e.Squawk != "4321" {
+		t.Errorf("squawk = %s, want 4321: the replayed message was applied again", e.Squawk)
+	}
+}
+
+func TestUpsertFlightEnrichment_SharedMessageID(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	flightDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM flight_enrichment WHERE icao_hex = 'TESTID'")
+	}
+	cleanup()
+	defer cleanup()
+
+	updates := []FlightEnrichmentUpdate{
+		{ICAOHex: "TESTID", Callsign: "QFA9", FlightDate: flightDate, Squawk: stringPtr("1234"), MessageID: 9_200_000_001, MessageHash: "feed-a"},
+		// Another feed numbered a different message the same.
+		{ICAOHex: "TESTID", Callsign: "QFA9", FlightDate: flightDate, Squawk: stringPtr("4321"), MessageID: 9_200_000_001, MessageHash: "feed-b"},
+	}
+	for _, u := range updates {
+		if err := pg.UpsertFlightEnrichment(ctx, u); err != nil {
+			t.Fatalf("upsert failed: %v", err)
+		}
+	}
+
+	e, err := pg.GetFlightEnrichment(ctx, "TESTID", "QFA9", flightDate, DateLocal, nil)
+	if err != nil || e == nil {
+		t.Fatalf("get failed: %v, %v", e, err)
+	}
+	if e.Squawk != "4321" {
+		t.Errorf("squawk = %s, want 4321: the second feed's message was taken for a replay", e.Squawk)
+	}
+}
+
+func TestFlightEnrichment_Tenants(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	flightDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM flight_enrichment WHERE icao_hex IN ('TESTTN', 'TESTTO')")
+	}
+	cleanup()
+	defer cleanup()
+
+	updates := []FlightEnrichmentUpdate{
+		{ICAOHex: "TESTTN", Callsign: "QFA10", FlightDate: flightDate, Origin: stringPtr("YSSY"), Tenant: "partner-a"},
+		{ICAOHex: "TESTTN", Callsign: "QF10", FlightDate: flightDate, Destination: stringPtr("EGLL"), Tenant: "partner-b"},
+		{ICAOHex: "TESTTN", Callsign: "QFA10", FlightDate: flightDate, Squawk: stringPtr("1234"), Tenant: "partner-a"},
+		{ICAOHex: "TESTTO", Callsign: "QFA11", FlightDate: flightDate, Origin: stringPtr("YMML")},
+	}
+	for _, u := range updates {
+		if err := pg.UpsertFlightEnrichment(ctx, u); err != nil {
+			t.Fatalf("upsert failed: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		icaoHex, callsign string
+		tenants           []string
+		found             bool
+	}{
+		{"TESTTN", "QFA10", nil, true},
+		{"TESTTN", "QFA10", []string{"partner-a"}, true},
+		{"TESTTN", "QFA10", []string{"partner-b"}, true}, // Merged by flight number.
+		{"TESTTN", "QFA10", []string{"partner-c"}, false},
+		{"TESTTN", "QFA10", []string{}, false},
+		{"TESTTO", "QFA11", nil, true},
+		{"TESTTO", "QFA11", []string{"partner-a"}, false}, // From no tenant's feed.
+	} {
+		e, err := pg.GetFlightEnrichment(ctx, tt.icaoHex, tt.callsign, flightDate, DateLocal, tt.tenants)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (e != nil) != tt.found {
+			t.Errorf("%s for %v: found = %v, want %v", tt.icaoHex, tt.tenants, e != nil, tt.found)
+		}
+	}
+
+	var tenants []string
+	if err := pg.pool.QueryRow(ctx, "SELECT tenants FROM flight_enrichment WHERE icao_hex = 'TESTTN'").Scan(&tenants); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tenants, []string{"partner-a", "partner-b"}) {
+		t.Errorf("tenants = %v, want each once", tenants)
+	}
+	all, err := pg.GetFlightEnrichmentsByAircraft(ctx, "TESTTN", flightDate, DateLocal, []string{"partner-c"})
+	if err != nil || len(all) != 0 {
+		t.Errorf("by aircraft for another tenant = %v, %v", all, err)
+	}
+}
+
+func TestFlightEnrichment_TenantsShareMessageID(t *testing.T) {
+	pg := setupTestPostgres(t)
+	if pg == nil {
+		t.Skip("No PostgreSQL connection available")
+	}
+	defer pg.Close()
+
+	ctx := context.Background()
+	flightDate := time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC)
+	cleanup := func() {
+		_, _ = pg.pool.Exec(ctx, "DELETE FROM flight_enrichment WHERE icao_hex = 'TESTTM'")
+	}
+	cleanup()
+	defer cleanup()
+
+	// Two partner networks deliver the same message, under the same ID.
+	for _, tenant := range []string{"partner-a", "partner-b"} {
+		u := FlightEnrichmentUpdate{ICAOHex: "TESTTM", Callsign: "QFA12", FlightDate: flightDate, Origin: stringPtr("YSSY"),
+			MessageID: 9_300_000_001, MessageHash: "same", Tenant: tenant}
+		if err := pg.UpsertFlightEnrichment(ctx, u); err != nil {
+			t.Fatalf("upsert failed: %v", err)
+		}
+	}
+
+	e, err := pg.GetFlightEnrichment(ctx, "TESTTM", "QFA12", flightDate, DateLocal, []string{"partner-b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil {
+		t.Error("partner-b cannot see the flight: its message was taken for partner-a's")
+	}
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/envflag"
+)
+
+// Retention is how long rows are kept in each of the PostgreSQL tables that
+// grow without bound. A zero duration keeps rows forever.
+type Retention struct {
+	FlightState   time.Duration // Flights not seen for this long are archived to flight_history, and their eta_reports deleted.
+	FlightHistory time.Duration // By completion time.
+	Positions     time.Duration // flight_positions, by position time.
+	Comms         time.Duration // comm_assignments, by assignment time.
+	Squawks       time.Duration // squawk_history, by assignment time.
+	RouteChanges  time.Duration // route_changes by change time, flight_plans by update time and arrival_runways by plan time.
+	Emergencies   time.Duration // emergency_events, by event time.
+	Logons        time.Duration // afn_logons, by logon time.
+	Winds         time.Duration // wind_grid, by cell hour.
+	Observations  time.Duration // weather_observations, by observation time.
+	Turbulence    time.Duration // turbulence_reports, by report time.
+	Enrichment    time.Duration // flight_enrichment, by flight date.
+	ATIS          time.Duration // atis_current, by update time.
+}
+
+// DefaultRetention returns the retention used unless configured otherwise.
+// Flight history and enrichment are kept, as the API serves them.
+func DefaultRetention() Retention {
+	return Retention{
+		FlightState:  48 * time.Hour,
+		Positions:    90 * 24 * time.Hour,
+		Comms:        90 * 24 * time.Hour,
+		Squawks:      90 * 24 * time.Hour,
+		RouteChanges: 90 * 24 * time.Hour,
+		Logons:       90 * 24 * time.Hour,
+		Winds:        30 * 24 * time.Hour,
+		Observations: 90 * 24 * time.Hour,
+		Turbulence:   90 * 24 * time.Hour,
+		ATIS:         30 * 24 * time.Hour,
+	}
+}
+
+// AddRetentionFlags registers the retention flags on fs, with defaults from
+// the KEEP_* environment variables (KEEP_FLIGHT_STATE for -keep-flight-state,
+// and so on) or else DefaultRetention, and returns the Retention they fill. Durations accept a
+// "d" suffix for days, e.g. "90d"; "0" keeps rows forever.
+func AddRetentionFlags(fs *flag.FlagSet) *Retention {
+	r := DefaultRetention()
+	r.FlightState = envflag.Value("KEEP_FLIGHT_STATE", r.FlightState, ParseRetention)
+	r.FlightHistory = envflag.Value("KEEP_FLIGHT_HISTORY", r.FlightHistory, ParseRetention)
+	r.Positions = envflag.Value("KEEP_POSITIONS", r.Positions, ParseRetention)
+	r.Comms = envflag.Value("KEEP_COMMS", r.Comms, ParseRetention)
+	r.Squawks = envflag.Value("KEEP_SQUAWKS", r.Squawks, ParseRetention)
+	r.RouteChanges = envflag.Value("KEEP_ROUTE_CHANGES", r.RouteChanges, ParseRetention)
+	r.Emergencies = envflag.Value("KEEP_EMERGENCIES", r.Emergencies, ParseRetention)
+	r.Logons = envflag.Value("KEEP_LOGONS", r.Logons, ParseRetention)
+	r.Winds = envflag.Value("KEEP_WINDS", r.Winds, ParseRetention)
+	r.Observations = envflag.Value("KEEP_OBSERVATIONS", r.Observations, ParseRetention)
+	r.Turbulence = envflag.Value("KEEP_TURBULENCE", r.Turbulence, ParseRetention)
+	r.Enrichment = envflag.Value("KEEP_ENRICHMENT", r.Enrichment, ParseRetention)
+	r.ATIS = envflag.Value("KEEP_ATIS", r.ATIS, ParseRetention)
+	fs.Var((*retentionValue)(&r.FlightState), "keep-flight-state", "Archive current flights not seen for this long, and delete ETA reports as old")
+	fs.Var((*retentionValue)(&r.FlightHistory), "keep-flight-history", "Delete archived flights completed this long ago (0 = keep)")
+	fs.Var((*retentionValue)(&r.Positions), "keep-positions", "Delete flight positions older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Comms), "keep-comms", "Delete comm assignments older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Squawks), "keep-squawks", "Delete squawk history older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.RouteChanges), "keep-route-changes", "Delete route changes, flight plans and arrival runways older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Emergencies), "keep-emergencies", "Delete emergency events older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Logons), "keep-logons", "Delete AFN logons older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Winds), "keep-winds", "Delete wind grid cells older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Observations), "keep-observations", "Delete weather observations older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Turbulence), "keep-turbulence", "Delete turbulence reports older than this (0 = keep)")
+	fs.Var((*retentionValue)(&r.Enrichment), "keep-enrichment", "Delete flight enrichment for flights this long ago (0 = keep)")
+	fs.Var((*retentionValue)(&r.ATIS), "keep-atis", "Delete ATIS not updated for this long (0 = keep)")
+	return &r
+}
+
+// ParseRetention parses a retention duration: a Go duration, a whole number
+// of days with a "d" suffix, or "0".
+func ParseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid retention %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid retention %q", s)
+	}
+	return d, nil
+}
+
+// retentionValue is a flag.Value for a retention duration.
+type retentionValue time.Duration
+
+func (v *retentionValue) String() string {
+	d := time.Duration(*v)
+	if d > 0 && d%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	}
+	return d.String()
+}
+
+func (v *retentionValue) Set(s string) error {
+	d, err := ParseRetention(s)
+	if err != nil {
+		return err
+	}
+	*v = retentionValue(d)
+	return nil
+}
+
+// PruneResult is the outcome of pruning one table.
+type PruneResult struct {
+	Table  string
+	Cutoff time.Time
+	Rows   int64 // Rows deleted (or archived), or that would be in a dry run.
+}
+
+// pruneRule deletes the rows of a table whose column is before a cutoff.
+type pruneRule struct {
+	table  string
+	column string
+	keep   time.Duration
+}
+
+// Prune applies the retention policy as of now. Current flights past their
+// retention are archived to flight_history rather than deleted, before
+// history retention is applied. With dryRun set, nothing is changed and the
+// results give the rows that would be affected.
+func (d *PostgresDB) Prune(ctx context.Context, r Retention, now time.Time, dryRun bool) ([]PruneResult, error) {
+	var results []PruneResult
+
+	if r.FlightState > 0 {
+		cutoff := now.Add(-r.FlightState)
+		var n int64
+		var err error
+		if dryRun {
+			err = d.pool.QueryRow(ctx, `SELECT COUNT(*) FROM flight_state WHERE last_seen < $1`, cutoff).Scan(&n)
+		} else {
+			n, err = d.archiveFlightStates(ctx, "last_seen < $1", cutoff)
+		}
+		if err != nil {
+			return results, fmt.Errorf("prune flight_state: %w", err)
+		}
+		results = append(results, PruneResult{Table: "flight_state", Cutoff: cutoff, Rows: n})
+	}
+
+	rules := []pruneRule{
+		{"eta_reports", "reported_at", r.FlightState},
+		{"flight_history", "completed_at", r.FlightHistory},
+		{"flight_positions", "ts", r.Positions},
+		{"comm_assignments", "ts", r.Comms},
+		{"squawk_history", "ts", r.Squawks},
+		{"route_changes", "ts", r.RouteChanges},
+		{"flight_plans", "updated_at", r.RouteChanges},
+		{"arrival_runways", "observed_at", r.RouteChanges},
+		{"emergency_events", "ts", r.Emergencies},
+		{"afn_logons", "logon_at", r.Logons},
+		{"wind_grid", "cell_time", r.Winds},
+		{"weather_observations", "observed_at", r.Observations},
+		{"turbulence_reports", "reported_at", r.Turbulence},
+		{"flight_enrichment", "flight_date", r.Enrichment},
+		{"atis_current", "updated_at", r.ATIS},
+	}
+	for _, rule := range rules {
+		if rule.keep <= 0 {
+			continue
+		}
+		cutoff := now.Add(-rule.keep)
+		var n int64
+		var err error
+		if dryRun {
+			err = d.pool.QueryRow(ctx, `SELECT COUNT(*) FROM `+rule.table+` WHERE `+rule.column+` < $1`, cutoff).Scan(&n)
+		} else {
+			tag, execErr := d.pool.Exec(ctx, `DELETE FROM `+rule.table+` WHERE `+rule.column+` < $1`, cutoff)
+			n, err = tag.RowsAffected(), execErr
+		}
+		if err != nil {
+			return results, fmt.Errorf("prune %s: %w", rule.table, err)
+		}
+		results = append(results, PruneResult{Table: rule.table, Cutoff: cutoff, Rows: n})
+	}
+	return results, nil
+}
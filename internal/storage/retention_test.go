@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"90d", 90 * 24 * time.Hour},
+		{"48h", 48 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+		{"0", 0},
+		{"0d", 0},
+	}
+	for _, tt := range tests {
+		if got, err := ParseRetention(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseRetention(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "d", "1.5d", "-2d", "-1h", "soon"} {
+		if got, err := ParseRetention(bad); err == nil {
+			t.Errorf("ParseRetention(%q) = %v, want error", bad, got)
+		}
+	}
+}
+
+func TestAddRetentionFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	r := AddRetentionFlags(fs)
+	if err := fs.Parse([]string{"-keep-positions", "30d", "-keep-atis", "0", "-keep-flight-history", "365d"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if r.Positions != 30*24*time.Hour || r.ATIS != 0 || r.FlightHistory != 365*24*time.Hour {
+		t.Errorf("retention = %+v", *r)
+	}
+	if r.FlightState != DefaultRetention().FlightState {
+		t.Errorf("FlightState = %v, want the default", r.FlightState)
+	}
+	if got := fs.Lookup("keep-comms").DefValue; got != "90d" {
+		t.Errorf("keep-comms default = %q, want 90d", got)
+	}
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRouteConfidence(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		name string
+		obs  int
+		age  time.Duration
+		want float64
+	}{
+		{"seen once today", 1, 0, 0.2835},
+		{"seen often today", 30, 0, 1},
+		{"seen often a half-life ago", 30, 90 * day, 0.5},
+		{"seen often two years ago", 500, 730 * day, 0.0036},
+		{"last seen in the future", 3, -day, 0.6321},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Route{ObservationCount: tt.obs, LastSeen: now.Add(-tt.age)}
+			if got := r.Confidence(now, DefaultRouteHalfLife); math.Abs(got-tt.want) > 0.0005 {
+				t.Errorf("Confidence() = %.4f, want %.4f", got, tt.want)
+			}
+		})
+	}
+	if got := (Route{ObservationCount: 30, LastSeen: now.Add(-365 * day)}).Confidence(now, 0); got < 0.99 {
+		t.Errorf("Confidence() without decay = %.4f, want the observation score", got)
+	}
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FlightPlan is the route of a flight as an FPN message gives it, stored in
+// flight_plans as the plan the next one for the flight is compared against.
+type FlightPlan struct {
+	Origin              string   `json:"origin,omitempty"`
+	Destination         string   `json:"destination,omitempty"`
+	Waypoints           []string `json:"waypoints,omitempty"`
+	Departure           string   `json:"departure,omitempty"` // SID.
+	DepartureTransition string   `json:"departure_transition,omitempty"`
+	Arrival             string   `json:"arrival,omitempty"` // STAR.
+	ArrivalTransition   string   `json:"arrival_transition,omitempty"`
+	Approach            string   `json:"approach,omitempty"`
+	ApproachRunway      string   `json:"approach_runway,omitempty"`
+}
+
+// PlanFieldChange is a flight plan field whose value changed. Old or New is
+// empty when the field was added or dropped.
+type PlanFieldChange struct {
+	Field string `json:"field"` // JSON name of the FlightPlan field, e.g. "arrival".
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// RouteChange is the difference between a flight's plan and the plan that
+// replaced it, stored in route_changes.
+type RouteChange struct {
+	FlightKey        string            `json:"flight_key"`
+	Timestamp        time.Time         `json:"timestamp"`
+	ICAOHex          string            `json:"icao_hex,omitempty"`
+	Registration     string            `json:"registration,omitempty"`
+	Flight           string            `json:"flight,omitempty"`
+	AddedWaypoints   []string          `json:"added_waypoints,omitempty"`
+	RemovedWaypoints []string          `json:"removed_waypoints,omitempty"`
+	Fields           []PlanFieldChange `json:"fields,omitempty"`
+	MessageID        int64             `json:"message_id,omitempty"` // ClickHouse message ID; 0 if unknown.
+}
+
+// routeChangeSet is the part of a RouteChange stored in route_changes.changes.
+type routeChangeSet struct {
+	AddedWaypoints   []string          `json:"added_waypoints,omitempty"`
+	RemovedWaypoints []string          `json:"removed_waypoints,omitempty"`
+	Fields           []PlanFieldChange `json:"fields,omitempty"`
+}
+
+// GetFlightPlan retrieves the stored plan of a flight and the time of the
+// message that gave it. It returns nil if the flight has no plan.
+func (d *PostgresDB) GetFlightPlan(ctx context.Context, key string) (*FlightPlan, time.Time, error) {
+	var raw []byte
+	var updated time.Time
+	err := d.pool.QueryRow(ctx, `
+		SELECT plan, updated_at FROM flight_plans WHERE flight_key = $1
+	`, key).Scan(&raw, &updated)
+	if err == pgx.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get flight plan %s: %w", key, err)
+	}
+	var plan FlightPlan
+	if err := json.Unmarshal(raw, &plan); err != nil {
+		return nil, time.Time{}, fmt.Errorf("decode flight plan %s: %w", key, err)
+	}
+	return &plan, updated, nil
+}
+
+// SaveFlightPlan stores the plan of a flight given by a message at ts. A plan
+// already stored from a later message is kept.
+func (d *PostgresDB) SaveFlightPlan(ctx context.Context, key string, plan FlightPlan, ts time.Time, messageID int64) error {
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("encode flight plan %s: %w", key, err)
+	}
+	_, err = d.pool.Exec(ctx, `
+		INSERT INTO flight_plans (flight_key, plan, updated_at, message_id)
+		VALUES ($1, $2, $3, NULLIF($4, 0))
+		ON CONFLICT (flight_key) DO UPDATE SET
+			plan = EXCLUDED.plan,
+			updated_at = EXCLUDED.updated_at,
+			message_id = EXCLUDED.message_id
+		WHERE flight_plans.updated_at <= EXCLUDED.updated_at
+	`, key, raw, ts, messageID)
+	if err != nil {
+		return fmt.Errorf("save flight plan %s: %w", key, err)
+	}
+	return nil
+}
+
+// InsertRouteChange stores a change to a flight's plan. It reports whether
+// the change was new: one already stored for the flight at the same time is
+// skipped, so replaying history does not duplicate it.
+func (d *PostgresDB) InsertRouteChange(ctx context.Context, c RouteChange) (bool, error) {
+	raw, err := json.Marshal(routeChangeSet{
+		AddedWaypoints:   c.AddedWaypoints,
+		RemovedWaypoints: c.RemovedWaypoints,
+		Fields:           c.Fields,
+	})
+	if err != nil {
+		return false, fmt.Errorf("encode route change %s: %w", c.FlightKey, err)
+	}
+	tag, err := d.pool.Exec(ctx, `
+		INSERT INTO route_changes (flight_key, ts, icao_hex, registration, flight, changes, message_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, 0))
+		ON CONFLICT (flight_key, ts) DO NOTHING
+	`, c.FlightKey, c.Timestamp, c.ICAOHex, c.Registration, c.Flight, raw, c.MessageID)
+	if err != nil {
+		return false, fmt.Errorf("insert route change %s: %w", c.FlightKey, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
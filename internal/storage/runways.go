@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ArrivalRunway is the arrival runway a flight's plan gives at its
+// destination, stored in arrival_runways.
+type ArrivalRunway struct {
+	AirportICAO string
+	FlightKey   string
+	Runway      string // e.g. "16L".
+	Approach    string // Approach procedure, if given, e.g. "ILS16L".
+	ObservedAt  time.Time
+}
+
+// RunwayCount is the number of flights planned to arrive on a runway.
+type RunwayCount struct {
+	Runway   string
+	Flights  int
+	LastSeen time.Time
+}
+
+// RecordArrivalRunway stores the arrival runway of a flight. A flight has one
+// runway per airport: a later plan replaces it, and an earlier one is ignored.
+func (d *PostgresDB) RecordArrivalRunway(ctx context.Context, a ArrivalRunway) error {
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO arrival_runways (airport_icao, flight_key, runway, approach, observed_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		ON CONFLICT (airport_icao, flight_key) DO UPDATE SET
+			runway = EXCLUDED.runway,
+			approach = EXCLUDED.approach,
+			observed_at = EXCLUDED.observed_at
+		WHERE arrival_runways.observed_at <= EXCLUDED.observed_at
+	`, a.AirportICAO, a.FlightKey, a.Runway, a.Approach, a.ObservedAt)
+	if err != nil {
+		return fmt.Errorf("record arrival runway %s/%s: %w", a.AirportICAO, a.FlightKey, err)
+	}
+	return nil
+}
+
+// CountArrivalRunways counts the flights planned to arrive on each runway of
+// an airport whose plan was last seen between from and to, inclusive, most
+// used first.
+func (d *PostgresDB) CountArrivalRunways(ctx context.Context, airportICAO string, from, to time.Time) ([]RunwayCount, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT runway, COUNT(*), MAX(observed_at)
+		FROM arrival_runways
+		WHERE airport_icao = $1 AND observed_at BETWEEN $2 AND $3
+		GROUP BY runway
+		ORDER BY COUNT(*) DESC, MAX(observed_at) DESC
+	`, airportICAO, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("count arrival runways %s: %w", airportICAO, err)
+	}
+	defer rows.Close()
+
+	var counts []RunwayCount
+	for rows.Next() {
+		var c RunwayCount
+		if err := rows.Scan(&c.Runway, &c.Flights, &c.LastSeen); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
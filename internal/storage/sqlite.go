@@ -419,3 +419,117 @@ func (d *SQLiteDB) Count(parserType string) (int, error) {
 	}
 	return count, err
 }
+
+// ScanParams contains filtering options for streaming messages in time order.
+type ScanParams struct {
+	Label string    // Filter by ACARS label (exact match).
+	From  time.Time // Only messages at or after this time (zero = no lower bound).
+	To    time.Time // Only messages before this time (zero = no upper bound).
+	Limit int       // Max messages to visit (0 = all).
+}
+
+// ForEachByTime calls fn for every message matching p in ascending timestamp order.
+// Rows are streamed from a single cursor so that large corpora are not held in memory.
+// Iteration stops at the first error returned by fn.
+func (d *SQLiteDB) ForEachByTime(p ScanParams, fn func(*Message) error) error {
+	var conditions []string
+	var args []interface{}
+
+	if p.Label != "" {
+		conditions = append(conditions, "label = ?")
+		args = append(args, p.Label)
+	}
+	if !p.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, p.From.UTC().Format(time.RFC3339))
+	}
+	if !p.To.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, p.To.UTC().Format(time.RFC3339))
+	}
+
+	query := `SELECT id, timestamp, label, parser_type, flight, tail,
+			origin, destination, raw_text, parsed_json, missing_fields, confidence,
+			is_golden, annotation, expected_json
+			FROM messages`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY timestamp, id"
+	if p.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", p.Limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("query messages: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var m Message
+		var ts, missing, annotation, expectedJSON sql.NullString
+		var confidence sql.NullFloat64
+		var isGolden sql.NullInt64
+
+		err := rows.Scan(&m.ID, &ts, &m.Label, &m.ParserType, &m.Flight, &m.Tail,
+			&m.Origin, &m.Destination, &m.RawText, &m.ParsedJSON, &missing, &confidence,
+			&isGolden, &annotation, &expectedJSON)
+		if err != nil {
+			return fmt.Errorf("scan row: %w", err)
+		}
+
+		if ts.Valid {
+			m.Timestamp, _ = time.Parse(time.RFC3339, ts.String)
+		}
+		if missing.Valid {
+			m.MissingFields = missing.String
+		}
+		if confidence.Valid {
+			m.Confidence = confidence.Float64
+		}
+		if isGolden.Valid {
+			m.IsGolden = isGolden.Int64 == 1
+		}
+		if annotation.Valid {
+			m.Annotation = annotation.String
+		}
+		if expectedJSON.Valid {
+			m.ExpectedJSON = expectedJSON.String
+		}
+
+		if err := fn(&m); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetGoldenMessages retrieves all messages flagged as golden.
+func (d *SQLiteDB) GetGoldenMessages() ([]Message, error) {
+	rows, err := d.db.Query(`SELECT id, label, parser_type, raw_text, parsed_json, annotation, expected_json
+			FROM messages WHERE is_golden = 1 ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("query golden messages: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var messages []Message
+	for rows.Next() {
+		var m Message
+		var annotation, expectedJSON sql.NullString
+		if err := rows.Scan(&m.ID, &m.Label, &m.ParserType, &m.RawText, &m.ParsedJSON, &annotation, &expectedJSON); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		m.IsGolden = true
+		if annotation.Valid {
+			m.Annotation = annotation.String
+		}
+		if expectedJSON.Valid {
+			m.ExpectedJSON = expectedJSON.String
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TurbulenceReport is a turbulence or wind shear report from an aircraft,
+// stored in turbulence_reports.
+type TurbulenceReport struct {
+	ReportedAt     time.Time
+	Phenomenon     string // "turbulence" or "wind_shear".
+	Severity       string // "none" to "extreme"; empty when not reported.
+	SeverityRank   int    // 0 (none) to 4 (extreme); -1 when not reported.
+	SeverityText   string // As reported, e.g. "OCNL LGT-MOD".
+	EDRPeak        *float64
+	EDRMean        *float64
+	AirspeedChange int      // Wind shear airspeed gain or loss, knots.
+	Latitude       *float64 // Nil when the position is unknown.
+	Longitude      *float64
+	Waypoint       string
+	FlightLevel    *int // Nil when the level is unknown.
+	FlightLevelTop *int
+	Registration   string
+	Flight         string
+	MessageID      int64 // ClickHouse message ID; 0 if unknown.
+	Text           string
+}
+
+// InsertTurbulenceReport stores a report. It reports whether it was new: a
+// report of the same phenomenon already stored for the aircraft at the same
+// time is skipped, so replaying history does not duplicate it.
+func (d *PostgresDB) InsertTurbulenceReport(ctx context.Context, r TurbulenceReport) (bool, error) {
+	tag, err := d.pool.Exec(ctx, `
+		INSERT INTO turbulence_reports (reported_at, phenomenon, severity, severity_rank, severity_text,
+			edr_peak, edr_mean, airspeed_change, latitude, longitude, waypoint, flight_level, flight_level_top,
+			registration, flight, message_id, text)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, $7, NULLIF($8, 0), $9, $10, NULLIF($11, ''),
+			$12, $13, $14, NULLIF($15, ''), NULLIF($16, 0), $17)
+		ON CONFLICT (reported_at, registration, phenomenon) DO NOTHING
+	`, r.ReportedAt, r.Phenomenon, r.Severity, r.SeverityRank, r.SeverityText,
+		r.EDRPeak, r.EDRMean, r.AirspeedChange, r.Latitude, r.Longitude, r.Waypoint, r.FlightLevel, r.FlightLevelTop,
+		r.Registration, r.Flight, r.MessageID, r.Text)
+	if err != nil {
+		return false, fmt.Errorf("insert turbulence report %s %v: %w", r.Registration, r.ReportedAt, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetTurbulenceReports retrieves the reports matching a query, newest first.
+// Reports below minSeverityRank are left out, as are reports without a
+// position when the query has a box, or without a level when it has a flight
+// level range. phenomenon, when not empty, selects one phenomenon.
+func (d *PostgresDB) GetTurbulenceReports(ctx context.Context, q WeatherQuery, phenomenon string, minSeverityRank int) ([]TurbulenceReport, error) {
+	cond, args := q.where("reported_at")
+	args = append(args, phenomenon, minSeverityRank, q.Limit)
+	n := len(args)
+	rows, err := d.pool.Query(ctx, `
+		SELECT reported_at, phenomenon, COALESCE(severity, ''), severity_rank, COALESCE(severity_text, ''),
+			edr_peak, edr_mean, COALESCE(airspeed_change, 0), latitude, longitude, COALESCE(waypoint, ''),
+			flight_level, flight_level_top, registration, COALESCE(flight, ''), COALESCE(message_id, 0), text
+		FROM turbulence_reports
+		WHERE `+cond+fmt.Sprintf(`
+			AND ($%d = '' OR phenomenon = $%d) AND severity_rank >= $%d
+		ORDER BY reported_at DESC
+		LIMIT $%d`, n-2, n-2, n-1, n), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []TurbulenceReport
+	for rows.Next() {
+		var r TurbulenceReport
+		err := rows.Scan(&r.ReportedAt, &r.Phenomenon, &r.Severity, &r.SeverityRank, &r.SeverityText,
+			&r.EDRPeak, &r.EDRMean, &r.AirspeedChange, &r.Latitude, &r.Longitude, &r.Waypoint,
+			&r.FlightLevel, &r.FlightLevelTop, &r.Registration, &r.Flight, &r.MessageID, &r.Text)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WindCell is the winds aloft reported in one cell of the wind grid (a
+// latitude/longitude square, a band of flight levels and an hour), stored in
+// wind_grid. Winds are kept as sums of their east (U) and north (V)
+// components so that cells can be added to as reports arrive and averaged as
+// vectors when read.
+type WindCell struct {
+	Time         time.Time // Start of the cell's hour.
+	Latitude     float64   // Centre of the cell.
+	Longitude    float64
+	FlightLevel  int // Centre of the band.
+	Observations int
+	SumU         float64 // Knots towards the east.
+	SumV         float64 // Knots towards the north.
+	// Temperatures are summed separately, as not every report has one.
+	TempObservations int
+	SumTemp          float64 // Degrees Celsius.
+	UpdatedAt        time.Time
+}
+
+// WeatherQuery selects wind grid cells, weather observations or turbulence
+// reports.
+type WeatherQuery struct {
+	From, To time.Time // Cell hours or report times in [From, To).
+	Box      *BBox     // Positions within the box; nil for everywhere.
+	MinFL    int       // From this flight level; 0 for no minimum.
+	MaxFL    int       // Up to this flight level; 0 for no maximum.
+	Limit    int
+}
+
+// where returns the SQL conditions for the query, with times compared against
+// timeColumn, and their arguments ($1 onwards).
+func (q WeatherQuery) where(timeColumn string) (string, []interface{}) {
+	args := []interface{}{q.From, q.To}
+	conds := []string{timeColumn + " >= $1", timeColumn + " < $2"}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if b := q.Box; b != nil {
+		conds = append(conds, "latitude BETWEEN "+arg(b.South)+" AND "+arg(b.North))
+		if b.West > b.East {
+			conds = append(conds, "(longitude >= "+arg(b.West)+" OR longitude <= "+arg(b.East)+")")
+		} else {
+			conds = append(conds, "longitude BETWEEN "+arg(b.West)+" AND "+arg(b.East))
+		}
+	}
+	if q.MinFL > 0 {
+		conds = append(conds, "flight_level >= "+arg(q.MinFL))
+	}
+	if q.MaxFL > 0 {
+		conds = append(conds, "flight_level <= "+arg(q.MaxFL))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// AddWindCells adds reports to the wind grid, creating cells as needed.
+func (d *PostgresDB) AddWindCells(ctx context.Context, cells []WindCell) error {
+	for _, c := range cells {
+		_, err := d.pool.Exec(ctx, `
+			INSERT INTO wind_grid (cell_time, latitude, longitude, flight_level,
+				observations, sum_u, sum_v, temp_observations, sum_temp, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+			ON CONFLICT (cell_time, latitude, longitude, flight_level) DO UPDATE SET
+				observations = wind_grid.observations + EXCLUDED.observations,
+				sum_u = wind_grid.sum_u + EXCLUDED.sum_u,
+				sum_v = wind_grid.sum_v + EXCLUDED.sum_v,
+				temp_observations = wind_grid.temp_observations + EXCLUDED.temp_observations,
+				sum_temp = wind_grid.sum_temp + EXCLUDED.sum_temp,
+				updated_at = NOW()
+		`, c.Time, c.Latitude, c.Longitude, c.FlightLevel,
+			c.Observations, c.SumU, c.SumV, c.TempObservations, c.SumTemp)
+		if err != nil {
+			return fmt.Errorf("add wind cell %v %.1f,%.1f FL%d: %w", c.Time, c.Latitude, c.Longitude, c.FlightLevel, err)
+		}
+	}
+	return nil
+}
+
+// GetWindGrid retrieves the cells of the wind grid matching a query, newest
+// hour first, then by flight level and position.
+func (d *PostgresDB) GetWindGrid(ctx context.Context, q WeatherQuery) ([]WindCell, error) {
+	cond, args := q.where("cell_time")
+	rows, err := d.pool.Query(ctx, `
+		SELECT cell_time, latitude, longitude, flight_level, observations, sum_u, sum_v,
+			temp_observations, sum_temp, updated_at
+		FROM wind_grid
+		WHERE `+cond+`
+		ORDER BY cell_time DESC, flight_level, latitude, longitude
+		LIMIT `+fmt.Sprintf("$%d", len(args)+1), append(args, q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []WindCell
+	for rows.Next() {
+		var c WindCell
+		if err := rows.Scan(&c.Time, &c.Latitude, &c.Longitude, &c.FlightLevel, &c.Observations,
+			&c.SumU, &c.SumV, &c.TempObservations, &c.SumTemp, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		cells = append(cells, c)
+	}
+	return cells, rows.Err()
+}
+
+// WeatherObservation is a wind, and perhaps a temperature, reported by an
+// aircraft at a position, flight level and time, stored in
+// weather_observations.
+type WeatherObservation struct {
+	ObservedAt   time.Time
+	Latitude     float64
+	Longitude    float64
+	FlightLevel  int
+	WindDir      float64  // Degrees true the wind blows from.
+	WindSpeed    float64  // Knots.
+	Temperature  *float64 // Static air temperature, degrees Celsius.
+	Source       string   // Result type that reported it, e.g. "adsc" or "pwi".
+	Registration string
+	Flight       string
+	MessageID    int64 // ClickHouse message ID; 0 if unknown.
+}
+
+// InsertWeatherObservation stores an observation. It reports whether it was
+// new: an observation already stored from the same source at the same time,
+// position and flight level is skipped, so replaying history does not
+// duplicate it.
+func (d *PostgresDB) InsertWeatherObservation(ctx context.Context, o WeatherObservation) (bool, error) {
+	tag, err := d.pool.Exec(ctx, `
+		INSERT INTO weather_observations (observed_at, latitude, longitude, flight_level, wind_dir, wind_speed,
+			temperature, source, registration, flight, message_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, 0))
+		ON CONFLICT (observed_at, latitude, longitude, flight_level, source) DO NOTHING
+	`, o.ObservedAt, o.Latitude, o.Longitude, o.FlightLevel, o.WindDir, o.WindSpeed,
+		o.Temperature, o.Source, o.Registration, o.Flight, o.MessageID)
+	if err != nil {
+		return false, fmt.Errorf("insert weather observation %v %.3f,%.3f FL%d: %w", o.ObservedAt, o.Latitude, o.Longitude, o.FlightLevel, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetWeatherObservations retrieves the observations matching a query, newest
+// first.
+func (d *PostgresDB) GetWeatherObservations(ctx context.Context, q WeatherQuery) ([]WeatherObservation, error) {
+	cond, args := q.where("observed_at")
+	rows, err := d.pool.Query(ctx, `
+		SELECT observed_at, latitude, longitude, flight_level, wind_dir, wind_speed, temperature, source,
+			COALESCE(registration, ''), COALESCE(flight, ''), COALESCE(message_id, 0)
+		FROM weather_observations
+		WHERE `+cond+`
+		ORDER BY observed_at DESC, flight_level
+		LIMIT `+fmt.Sprintf("$%d", len(args)+1), append(args, q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var obs []WeatherObservation
+	for rows.Next() {
+		var o WeatherObservation
+		if err := rows.Scan(&o.ObservedAt, &o.Latitude, &o.Longitude, &o.FlightLevel, &o.WindDir, &o.WindSpeed,
+			&o.Temperature, &o.Source, &o.Registration, &o.Flight, &o.MessageID); err != nil {
+			return nil, err
+		}
+		obs = append(obs, o)
+	}
+	return obs, rows.Err()
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeatherQueryWhere(t *testing.T) {
+	from := time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)
+	q := WeatherQuery{From: from, To: from.Add(6 * time.Hour)}
+	cond, args := q.where("observed_at")
+	if cond != "observed_at >= $1 AND observed_at < $2" || len(args) != 2 {
+		t.Errorf("where() = %q, %v", cond, args)
+	}
+
+	q.Box = &BBox{West: 170, South: -50, East: -170, North: -30}
+	q.MinFL, q.MaxFL = 300, 400
+	cond, args = q.where("cell_time")
+	want := "cell_time >= $1 AND cell_time < $2 AND latitude BETWEEN $3 AND $4 AND (longitude >= $5 OR longitude <= $6)" +
+		" AND flight_level >= $7 AND flight_level <= $8"
+	if cond != want || len(args) != 8 || args[2] != -50.0 || args[4] != 170.0 || args[7] != 400 {
+		t.Errorf("where() = %q, %v", cond, args)
+	}
+}
@@ -0,0 +1,94 @@
+// Package templates normalises ACARS message text into structural templates
+// and counts the most frequent templates in a single streaming pass.
+//
+// A template replaces variable tokens (times, frequencies, airports, flight
+// numbers and so on) with placeholders such as <TIME> and <ICAO>, so messages
+// with the same layout collapse to the same string. Lines are joined with " | ".
+package templates
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tokenPatterns classify variable tokens. Order matters: the first match wins.
+var tokenPatterns = []struct {
+	Name    string
+	Pattern *regexp.Regexp
+}{
+	{"<FREQ>", regexp.MustCompile(`^\d{2,3}\.\d{1,3}$`)},
+	{"<TIME>", regexp.MustCompile(`^[0-2]\d[0-5]\d$`)},
+	{"<SQWK>", regexp.MustCompile(`^[0-7]{4}$`)},
+	{"<FL>", regexp.MustCompile(`^FL\d{2,3}$`)},
+	{"<RWY>", regexp.MustCompile(`^\d{1,2}[LCR]?$`)},
+	{"<ICAO>", regexp.MustCompile(`^[A-Z]{4}$`)},
+	{"<FLIGHT>", regexp.MustCompile(`^[A-Z]{2,3}\d{1,4}[A-Z]?$`)},
+	{"<TAIL>", regexp.MustCompile(`^[A-Z]{1,2}-?[A-Z]{0,3}\d{1,5}[A-Z]{0,2}$`)},
+	{"<ACFT>", regexp.MustCompile(`^[A-Z]\d{2,3}[A-Z]?$`)},
+	{"<NUM>", regexp.MustCompile(`^\d+$`)},
+	{"<WPT5>", regexp.MustCompile(`^[A-Z]{5}$`)},
+	{"<CODE>", regexp.MustCompile(`^[A-Z]{3,4}$`)},
+	{"<ALNUM>", regexp.MustCompile(`^[A-Z0-9]{6,}$`)},
+}
+
+// literalKeywords are kept verbatim because they carry the message structure.
+var literalKeywords = map[string]bool{
+	"PDC": true, "CLRD": true, "CLEARED": true, "TO": true, "VIA": true,
+	"OFF": true, "RWY": true, "RUNWAY": true, "SID": true, "DEP": true,
+	"SQUAWK": true, "XPNDR": true, "FREQ": true, "ATIS": true,
+	"CLIMB": true, "MAINTAIN": true, "EXPECT": true, "CONTACT": true,
+	"FROM": true, "AT": true, "ON": true, "FOR": true, "WITH": true,
+	"POS": true, "POSITION": true, "ETA": true, "ETD": true,
+	"ROUTE": true, "DIRECT": true, "DCT": true, "ALT": true, "FL": true,
+}
+
+// wordRe matches plain words that are kept verbatim when no pattern applies.
+var wordRe = regexp.MustCompile(`^[A-Z]{3,8}$`)
+
+// Normalise converts message text into its template.
+func Normalise(text string) string {
+	text = strings.ToUpper(text)
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, line := range strings.Split(text, "\n") {
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(" | ")
+		}
+		for i, tok := range tokens {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(ClassifyToken(tok))
+		}
+	}
+	return b.String()
+}
+
+// ClassifyToken returns the placeholder for a single upper-case token, or the
+// token itself when it is a structural keyword or short literal.
+func ClassifyToken(tok string) string {
+	if literalKeywords[tok] {
+		return tok
+	}
+
+	for _, tp := range tokenPatterns {
+		if tp.Pattern.MatchString(tok) {
+			return tp.Name
+		}
+	}
+
+	if len(tok) <= 2 {
+		return tok
+	}
+
+	if wordRe.MatchString(tok) {
+		return tok
+	}
+
+	return "<OTHER>"
+}
@@ -0,0 +1,102 @@
+package templates
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNormalise(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "PDC",
+			text: "PDC 1234 QFA1 YSSY\nCLRD TO WSSS VIA ABBEY1",
+			want: "PDC <TIME> <FLIGHT> <ICAO> | CLRD TO <ICAO> VIA <TAIL>",
+		},
+		{
+			name: "frequency and squawk",
+			text: "contact 121.7 squawk 4721",
+			want: "CONTACT <FREQ> SQUAWK <SQWK>",
+		},
+		{
+			name: "blank lines dropped",
+			text: "\n  \nRWY 16L\n\n",
+			want: "RWY <RWY>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalise(tt.text); got != tt.want {
+				t.Errorf("Normalise(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCounterExactBelowCapacity(t *testing.T) {
+	c := NewCounter(10)
+	for i := 0; i < 5; i++ {
+		c.Add("A", "a")
+	}
+	for i := 0; i < 3; i++ {
+		c.Add("B", "b")
+	}
+	c.Add("C", "c")
+
+	if c.Total() != 9 {
+		t.Errorf("Total() = %d, want 9", c.Total())
+	}
+	if c.Distinct() != 3 {
+		t.Errorf("Distinct() = %d, want 3", c.Distinct())
+	}
+
+	top := c.Top(2)
+	if len(top) != 2 || top[0].Template != "A" || top[0].Count != 5 || top[1].Template != "B" || top[1].Count != 3 {
+		t.Errorf("Top(2) = %+v", top)
+	}
+	if top[0].Example != "a" || top[0].Error != 0 {
+		t.Errorf("Top(2)[0] = %+v, want example a with no error", top[0])
+	}
+}
+
+func TestCounterKeepsHeavyHitters(t *testing.T) {
+	c := NewCounter(20)
+
+	// Interleave two frequent templates with many one-off templates that
+	// overflow the counter's capacity.
+	for i := 0; i < 2000; i++ {
+		switch i % 4 {
+		case 0:
+			c.Add("HEAVY1", "")
+		case 1:
+			c.Add("HEAVY2", "")
+		default:
+			c.Add(fmt.Sprintf("RARE%d", i), "")
+		}
+	}
+
+	top := c.Top(2)
+	if len(top) != 2 {
+		t.Fatalf("Top(2) returned %d entries", len(top))
+	}
+	got := map[string]Entry{top[0].Template: top[0], top[1].Template: top[1]}
+	for _, name := range []string{"HEAVY1", "HEAVY2"} {
+		e, ok := got[name]
+		if !ok {
+			t.Fatalf("%s missing from Top(2): %+v", name, top)
+		}
+		// Space-Saving never underestimates, and the true count lies within Error.
+		if e.Count < 500 || e.Count-e.Error > 500 {
+			t.Errorf("%s count %d (error %d), true count 500", name, e.Count, e.Error)
+		}
+	}
+
+	// 1000 one-off templates plus the two heavy ones.
+	if d := c.Distinct(); d < 950 || d > 1050 {
+		t.Errorf("Distinct() = %d, want about 1002", d)
+	}
+}
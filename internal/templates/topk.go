@@ -0,0 +1,175 @@
+package templates
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// DefaultCapacity is the number of templates a Counter tracks when none is given.
+const DefaultCapacity = 1000
+
+// Entry is a template with its estimated count.
+type Entry struct {
+	Template string `json:"template"`
+	Count    int    `json:"count"`
+	Error    int    `json:"error,omitempty"` // Maximum overstatement of Count; zero if tracked since first seen.
+	Example  string `json:"example"`
+}
+
+// Counter counts template frequencies in bounded memory using the Space-Saving
+// algorithm. It tracks at most capacity templates; when full, a new template
+// replaces the least frequent one and inherits its count as the error bound.
+// Every template whose true count exceeds Total()/capacity is guaranteed to be
+// tracked, so the top results are exact for skewed corpora.
+type Counter struct {
+	capacity int
+	entries  entryHeap
+	index    map[string]*heapEntry
+	total    int
+	distinct distinctEstimator
+}
+
+// NewCounter creates a counter that tracks up to capacity templates.
+func NewCounter(capacity int) *Counter {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Counter{
+		capacity: capacity,
+		index:    make(map[string]*heapEntry, capacity),
+		distinct: newDistinctEstimator(),
+	}
+}
+
+// Add counts one occurrence of template. The example is kept for templates
+// that are not yet tracked.
+func (c *Counter) Add(template, example string) {
+	c.total++
+	c.distinct.add(template)
+
+	if e, ok := c.index[template]; ok {
+		e.Count++
+		heap.Fix(&c.entries, e.pos)
+		return
+	}
+
+	if len(c.entries) < c.capacity {
+		e := &heapEntry{Entry: Entry{Template: template, Count: 1, Example: example}}
+		heap.Push(&c.entries, e)
+		c.index[template] = e
+		return
+	}
+
+	// Replace the least frequent template.
+	least := c.entries[0]
+	delete(c.index, least.Template)
+	least.Error = least.Count
+	least.Count++
+	least.Template = template
+	least.Example = example
+	c.index[template] = least
+	heap.Fix(&c.entries, 0)
+}
+
+// AddText normalises text and counts the resulting template, keeping text as the example.
+func (c *Counter) AddText(text string) {
+	c.Add(Normalise(text), text)
+}
+
+// Total returns the number of occurrences counted.
+func (c *Counter) Total() int {
+	return c.total
+}
+
+// Distinct returns an estimate of the number of distinct templates counted.
+// It is exact while fewer templates than the counter's capacity have been seen.
+func (c *Counter) Distinct() int {
+	if len(c.index) < c.capacity {
+		return len(c.index)
+	}
+	return c.distinct.estimate()
+}
+
+// Top returns the n most frequent templates, highest count first.
+func (c *Counter) Top(n int) []Entry {
+	out := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		out = append(out, e.Entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Template < out[j].Template
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// heapEntry is an Entry with its position in the min-heap.
+type heapEntry struct {
+	Entry
+	pos int
+}
+
+// entryHeap is a min-heap ordered by count.
+type entryHeap []*heapEntry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	e := x.(*heapEntry)
+	e.pos = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// distinctBits is the size of the linear counting bitmap. 2^16 bits (8 KiB)
+// keeps the estimate within a few percent up to several hundred thousand
+// distinct templates.
+const distinctBits = 1 << 16
+
+// distinctEstimator estimates cardinality with linear counting.
+type distinctEstimator struct {
+	words []uint64
+}
+
+func newDistinctEstimator() distinctEstimator {
+	return distinctEstimator{words: make([]uint64, distinctBits/64)}
+}
+
+func (d *distinctEstimator) add(s string) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	bit := h.Sum64() % distinctBits
+	d.words[bit/64] |= 1 << (bit % 64)
+}
+
+func (d *distinctEstimator) estimate() int {
+	zero := 0
+	for _, w := range d.words {
+		zero += 64 - bits.OnesCount64(w)
+	}
+	if zero == 0 {
+		// The bitmap is saturated; this is a lower bound.
+		return int(distinctBits * math.Log(distinctBits))
+	}
+	return int(math.Round(-distinctBits * math.Log(float64(zero)/distinctBits)))
+}
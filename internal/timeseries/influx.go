@@ -0,0 +1,137 @@
+package timeseries
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"acars_parser/internal/output"
+)
+
+// Batching defaults shared by the sinks.
+const (
+	DefaultBatchSize     = 500
+	DefaultFlushInterval = 5 * time.Second
+)
+
+// influxTimeout bounds each write request.
+const influxTimeout = 10 * time.Second
+
+// InfluxConfig configures an InfluxDB sink. Bucket selects the InfluxDB 2.x
+// write API; Database selects the 1.x API.
+type InfluxConfig struct {
+	URL           string // e.g. http://localhost:8086
+	Token         string // API token (2.x) or "user:password" (1.x).
+	Org           string
+	Bucket        string
+	Database      string
+	BatchSize     int           // Points per write (default DefaultBatchSize).
+	FlushInterval time.Duration // Longest a point is held (default DefaultFlushInterval).
+}
+
+// InfluxSink writes points to InfluxDB in line protocol. Points are batched,
+// and a batch is written when it is full, when a point arrives after the
+// flush interval has passed, or on Close.
+type InfluxSink struct {
+	cfg      InfluxConfig
+	writeURL string
+	client   *http.Client
+
+	buf       []byte
+	pending   int
+	lastFlush time.Time
+}
+
+// NewInflux creates an InfluxDB sink. No connection is made until the first
+// batch is written.
+func NewInflux(cfg InfluxConfig) (*InfluxSink, error) {
+	u, err := url.Parse(strings.TrimRight(cfg.URL, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("influx: invalid url %q", cfg.URL)
+	}
+	q := url.Values{"precision": {"ns"}}
+	switch {
+	case cfg.Bucket != "":
+		u.Path += "/api/v2/write"
+		q.Set("bucket", cfg.Bucket)
+		if cfg.Org != "" {
+			q.Set("org", cfg.Org)
+		}
+	case cfg.Database != "":
+		u.Path += "/write"
+		q.Set("db", cfg.Database)
+	default:
+		return nil, fmt.Errorf("influx: a bucket or database is required")
+	}
+	u.RawQuery = q.Encode()
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	return &InfluxSink{
+		cfg:       cfg,
+		writeURL:  u.String(),
+		client:    &http.Client{Timeout: influxTimeout},
+		lastFlush: time.Now(),
+	}, nil
+}
+
+// Publish queues the event's points, writing the batch when due.
+func (s *InfluxSink) Publish(ctx context.Context, e output.Event) error {
+	for _, p := range Points(e) {
+		if len(p.Fields) == 0 {
+			continue
+		}
+		s.buf = AppendLine(s.buf, p)
+		s.pending++
+	}
+	if s.pending >= s.cfg.BatchSize || (s.pending > 0 && time.Since(s.lastFlush) >= s.cfg.FlushInterval) {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush writes the queued points. The batch is dropped if the write fails,
+// so one bad batch does not block the ones after it.
+func (s *InfluxSink) Flush(ctx context.Context) error {
+	s.lastFlush = time.Now()
+	if s.pending == 0 {
+		return nil
+	}
+	body, n := s.buf, s.pending
+	s.buf, s.pending = nil, 0
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.cfg.Token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("write %d points to influx: %w", n, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("write %d points to influx: %s: %s", n, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Close writes any queued points.
+func (s *InfluxSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), influxTimeout)
+	defer cancel()
+	return s.Flush(ctx)
+}
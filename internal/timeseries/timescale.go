@@ -0,0 +1,137 @@
+package timeseries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"acars_parser/internal/output"
+)
+
+// timescaleTimeout bounds schema creation and each batch insert.
+const timescaleTimeout = 30 * time.Second
+
+// TimescaleSchema creates the points table. Tags and fields are stored as
+// JSONB so that new measurements need no schema change; Grafana queries read
+// them with, for example, (fields->>'wind_speed_kt')::float.
+const TimescaleSchema = `
+CREATE TABLE IF NOT EXISTS acars_points (
+	time        TIMESTAMPTZ NOT NULL,
+	measurement TEXT NOT NULL,
+	icao_hex    TEXT,
+	flight      TEXT,
+	tags        JSONB NOT NULL DEFAULT '{}',
+	fields      JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_acars_points_measurement_time ON acars_points (measurement, time DESC);
+CREATE INDEX IF NOT EXISTS idx_acars_points_icao_time ON acars_points (icao_hex, time DESC);
+`
+
+// timescaleHypertable turns the table into a hypertable when the timescaledb
+// extension is installed. Without it the table stays a plain PostgreSQL
+// table, which works for small volumes.
+const timescaleHypertable = `
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb') THEN
+		PERFORM create_hypertable('acars_points', 'time', if_not_exists => TRUE);
+	END IF;
+END $$;
+`
+
+// TimescaleConfig configures a TimescaleDB sink.
+type TimescaleConfig struct {
+	DSN           string // PostgreSQL connection URL.
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// TimescaleSink writes points to the acars_points table of a TimescaleDB (or
+// plain PostgreSQL) database. Points are batched as by InfluxSink.
+type TimescaleSink struct {
+	pool *pgxpool.Pool
+	cfg  TimescaleConfig
+
+	rows      [][]interface{}
+	lastFlush time.Time
+}
+
+// NewTimescale connects to the database and creates the schema.
+func NewTimescale(ctx context.Context, cfg TimescaleConfig) (*TimescaleSink, error) {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timescaleTimeout)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("open timescale: %w", err)
+	}
+	if _, err := pool.Exec(ctx, TimescaleSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("create timescale schema: %w", err)
+	}
+	if _, err := pool.Exec(ctx, timescaleHypertable); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("create hypertable: %w", err)
+	}
+	return &TimescaleSink{pool: pool, cfg: cfg, lastFlush: time.Now()}, nil
+}
+
+// Publish queues the event's points, writing the batch when due.
+func (s *TimescaleSink) Publish(ctx context.Context, e output.Event) error {
+	for _, p := range Points(e) {
+		if len(p.Fields) == 0 {
+			continue
+		}
+		s.rows = append(s.rows, []interface{}{
+			p.Time, p.Measurement, nullable(p.Tags["icao"]), nullable(p.Tags["flight"]), p.Tags, p.Fields,
+		})
+	}
+	if len(s.rows) >= s.cfg.BatchSize || (len(s.rows) > 0 && time.Since(s.lastFlush) >= s.cfg.FlushInterval) {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush inserts the queued points. The batch is dropped if the insert fails.
+func (s *TimescaleSink) Flush(ctx context.Context) error {
+	s.lastFlush = time.Now()
+	if len(s.rows) == 0 {
+		return nil
+	}
+	rows := s.rows
+	s.rows = nil
+
+	ctx, cancel := context.WithTimeout(ctx, timescaleTimeout)
+	defer cancel()
+	_, err := s.pool.CopyFrom(ctx, pgx.Identifier{"acars_points"},
+		[]string{"time", "measurement", "icao_hex", "flight", "tags", "fields"},
+		pgx.CopyFromRows(rows))
+	if err != nil {
+		return fmt.Errorf("insert %d points into timescale: %w", len(rows), err)
+	}
+	return nil
+}
+
+// Close inserts any queued points and closes the connection pool.
+func (s *TimescaleSink) Close() error {
+	err := s.Flush(context.Background())
+	s.pool.Close()
+	return err
+}
+
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,288 @@
+// Package timeseries converts parsed results into time-series points and
+// writes them to InfluxDB or TimescaleDB, so that positions, winds aloft and
+// engine metrics can be plotted in Grafana without a separate ETL step.
+//
+// Three measurements are produced from result events:
+//
+//   - position: latitude, longitude and altitude from any result carrying a
+//     position (the same positions the flight tracker uses).
+//   - wind: wind direction, speed and temperature from H1 PWI climb, descent
+//     and route winds, H2 wind reports and ADS-C meteorological groups.
+//   - engine: engine trend parameters from H1 MDC reports, one point per
+//     engine.
+//
+// Every point is tagged with the aircraft ICAO hex, tail, flight and the
+// result type it came from. The sinks implement output.Sink, so they can be
+// combined with the MQTT and Kafka sinks using output.Multi.
+package timeseries
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/output"
+	"acars_parser/internal/registry"
+	"acars_parser/internal/state"
+)
+
+// Measurement names.
+const (
+	MeasurementPosition = "position"
+	MeasurementWind     = "wind"
+	MeasurementEngine   = "engine"
+)
+
+// Point is one time-series sample.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]float64
+	Time        time.Time
+}
+
+// Points returns the points for an event. Events other than results, and
+// results without positions, winds or engine data, give none.
+func Points(e output.Event) []Point {
+	r, ok := e.Data.(registry.Result)
+	if e.Kind != output.KindResult || !ok {
+		return nil
+	}
+	ts := state.ParseTimestamp(e.Timestamp)
+	base := map[string]string{
+		"icao":   e.ICAOHex,
+		"tail":   e.Tail,
+		"flight": e.Flight,
+		"source": e.Type,
+	}
+	point := func(measurement string, tags map[string]string, fields map[string]float64) Point {
+		all := make(map[string]string, len(base)+len(tags))
+		for k, v := range base {
+			if v != "" {
+				all[k] = v
+			}
+		}
+		for k, v := range tags {
+			if v != "" {
+				all[k] = v
+			}
+		}
+		return Point{Measurement: measurement, Tags: all, Fields: fields, Time: ts}
+	}
+
+	var points []Point
+	for _, p := range state.Positions(ts, []registry.Result{r}) {
+		fields := map[string]float64{"latitude": p.Latitude, "longitude": p.Longitude}
+		if p.Altitude != 0 {
+			fields["altitude_ft"] = float64(p.Altitude)
+		}
+		points = append(points, point(MeasurementPosition, nil, fields))
+	}
+
+	m := resultMap(r)
+	if m == nil {
+		return points
+	}
+	for _, w := range winds(m) {
+		points = append(points, point(MeasurementWind, w.tags, w.fields))
+	}
+	for _, eng := range engines(m) {
+		points = append(points, point(MeasurementEngine, eng.tags, eng.fields))
+	}
+	return points
+}
+
+// sample is the tags and fields of one point before the event tags are added.
+type sample struct {
+	tags   map[string]string
+	fields map[string]float64
+}
+
+// winds reads wind samples from a result map: PWI climb, descent and route
+// winds, H2 wind layers, and ADS-C meteorological data.
+func winds(m map[string]interface{}) []sample {
+	var out []sample
+	layer := func(phase string, l map[string]interface{}, extra map[string]float64) {
+		fields := map[string]float64{}
+		copyNumber(fields, "wind_dir", l, "wind_dir")
+		copyNumber(fields, "wind_speed_kt", l, "wind_speed")
+		copyNumber(fields, "temperature_c", l, "temperature")
+		if len(fields) == 0 {
+			return
+		}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		tags := map[string]string{"phase": phase}
+		if fl, ok := l["flight_level"].(float64); ok && fl > 0 {
+			tags["flight_level"] = strconv.Itoa(int(fl))
+		}
+		if wpt, _ := l["waypoint"].(string); wpt != "" {
+			tags["waypoint"] = wpt
+		}
+		out = append(out, sample{tags: tags, fields: fields})
+	}
+
+	for _, phase := range []string{"climb", "descent"} {
+		for _, l := range objects(m[phase+"_winds"]) {
+			layer(phase, l, nil)
+		}
+	}
+	for _, rl := range objects(m["route_winds"]) {
+		for _, wpt := range objects(rl["waypoints"]) {
+			wpt["flight_level"] = rl["flight_level"]
+			layer("route", wpt, nil)
+		}
+	}
+
+	// H2 wind reports give the layers observed at one position.
+	pos := map[string]float64{}
+	copyNumber(pos, "latitude", m, "latitude")
+	copyNumber(pos, "longitude", m, "longitude")
+	for _, l := range objects(m["wind_layers"]) {
+		layer("report", l, pos)
+	}
+
+	if meteo, ok := m["meteo"].(map[string]interface{}); ok {
+		fields := map[string]float64{}
+		copyNumber(fields, "wind_speed_kt", meteo, "wind_speed_kts")
+		if invalid, _ := meteo["wind_dir_invalid"].(bool); !invalid {
+			copyNumber(fields, "wind_dir", meteo, "wind_direction_deg")
+		}
+		copyNumber(fields, "temperature_c", meteo, "temperature_c")
+		for k, v := range pos {
+			fields[k] = v
+		}
+		copyNumber(fields, "altitude_ft", m, "altitude")
+		out = append(out, sample{tags: map[string]string{"phase": "report"}, fields: fields})
+	}
+	return out
+}
+
+// engines reads the MDC engine trend parameters. Parameters prefixed with
+// left_ and right_ become one point per engine; the rest (airspeed, altitude
+// and total air temperature) become a point without an engine tag.
+func engines(m map[string]interface{}) []sample {
+	trend, ok := m["engine_trend"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	perEngine := map[string]map[string]float64{}
+	aircraft := map[string]float64{}
+	for k, v := range trend {
+		f, ok := v.(float64)
+		if !ok {
+			continue
+		}
+		engine, param, found := strings.Cut(k, "_")
+		if found && (engine == "left" || engine == "right") {
+			if perEngine[engine] == nil {
+				perEngine[engine] = map[string]float64{}
+			}
+			perEngine[engine][param] = f
+			continue
+		}
+		aircraft[k] = f
+	}
+
+	var out []sample
+	for _, engine := range []string{"left", "right"} {
+		if fields := perEngine[engine]; len(fields) > 0 {
+			out = append(out, sample{tags: map[string]string{"engine": engine}, fields: fields})
+		}
+	}
+	if len(aircraft) > 0 {
+		out = append(out, sample{fields: aircraft})
+	}
+	return out
+}
+
+// resultMap converts a result to a map through its JSON form, as the
+// extractor and tracker do.
+func resultMap(r registry.Result) map[string]interface{} {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+func objects(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	out := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if o, ok := item.(map[string]interface{}); ok {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+func copyNumber(dst map[string]float64, name string, src map[string]interface{}, key string) {
+	if v, ok := src[key].(float64); ok {
+		dst[name] = v
+	}
+}
+
+// AppendLine appends the point in InfluxDB line protocol, with a nanosecond
+// timestamp and a trailing newline. Points without fields are skipped, as
+// line protocol requires at least one.
+func AppendLine(b []byte, p Point) []byte {
+	if len(p.Fields) == 0 {
+		return b
+	}
+	b = append(b, escape(p.Measurement, ", ")...)
+	for _, k := range sortedKeys(p.Tags) {
+		b = append(b, ',')
+		b = append(b, escape(k, ",= ")...)
+		b = append(b, '=')
+		b = append(b, escape(p.Tags[k], ",= ")...)
+	}
+	sep := byte(' ')
+	fieldKeys := make([]string, 0, len(p.Fields))
+	for k := range p.Fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+	for _, k := range fieldKeys {
+		b = append(b, sep)
+		sep = ','
+		b = append(b, escape(k, ",= ")...)
+		b = append(b, '=')
+		b = strconv.AppendFloat(b, p.Fields[k], 'f', -1, 64)
+	}
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, p.Time.UnixNano(), 10)
+	return append(b, '\n')
+}
+
+// escape backslash-escapes the given special characters, as line protocol
+// requires in measurement names, tag keys, tag values and field keys.
+func escape(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,173 @@
+package timeseries
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/output"
+	"acars_parser/internal/parsers/adsc"
+	"acars_parser/internal/parsers/h1"
+	"acars_parser/internal/parsers/h2wind"
+)
+
+func event(data interface{ Type() string }) output.Event {
+	e := output.Event{
+		Kind:      output.KindResult,
+		Type:      data.Type(),
+		Timestamp: "2026-01-24T10:00:00Z",
+		ICAOHex:   "7C6DB8",
+		Flight:    "QF1",
+	}
+	e.Data = data
+	return e
+}
+
+func byMeasurement(points []Point, measurement string) []Point {
+	var out []Point
+	for _, p := range points {
+		if p.Measurement == measurement {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func TestPointsPWI(t *testing.T) {
+	r := &h1.PWIResult{
+		ClimbWinds: []h1.AltitudeWind{{FlightLevel: 100, WindDir: 270, WindSpeed: 35}},
+		RouteWinds: []h1.RouteWindLayer{{
+			FlightLevel: 350,
+			Waypoints:   []h1.WaypointWind{{Waypoint: "TESAT", WindDir: 280, WindSpeed: 90, Temperature: -52}},
+		}},
+	}
+	winds := byMeasurement(Points(event(r)), MeasurementWind)
+	if len(winds) != 2 {
+		t.Fatalf("got %d wind points, want 2", len(winds))
+	}
+	climb, route := winds[0], winds[1]
+	if climb.Tags["phase"] != "climb" || climb.Tags["flight_level"] != "100" || climb.Fields["wind_speed_kt"] != 35 {
+		t.Errorf("climb point = %+v", climb)
+	}
+	if route.Tags["waypoint"] != "TESAT" || route.Tags["flight_level"] != "350" || route.Fields["temperature_c"] != -52 {
+		t.Errorf("route point = %+v", route)
+	}
+	if route.Tags["icao"] != "7C6DB8" || route.Tags["source"] != "pwi" || !route.Time.Equal(time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("event tags = %+v at %v", route.Tags, route.Time)
+	}
+}
+
+func TestPointsH2Wind(t *testing.T) {
+	r := &h2wind.Result{
+		Latitude: -33.9, Longitude: 151.2,
+		WindLayers: []h2wind.WindLayer{{FlightLevel: 390, Temperature: -56, WindDir: 250, WindSpeed: 110}},
+	}
+	points := Points(event(r))
+	if len(byMeasurement(points, MeasurementPosition)) != 1 {
+		t.Errorf("expected a position point, got %+v", points)
+	}
+	winds := byMeasurement(points, MeasurementWind)
+	if len(winds) != 1 || winds[0].Fields["latitude"] != -33.9 || winds[0].Fields["wind_dir"] != 250 {
+		t.Errorf("wind points = %+v", winds)
+	}
+}
+
+func TestPointsADSCMeteo(t *testing.T) {
+	r := &adsc.Result{
+		Latitude: -20.5, Longitude: 160.1, Altitude: 37000,
+		Meteo: &adsc.MeteoData{WindSpeed: 45, WindDirection: 123, WindDirInvalid: true, Temperature: -48.5},
+	}
+	points := Points(event(r))
+	pos := byMeasurement(points, MeasurementPosition)
+	if len(pos) != 1 || pos[0].Fields["altitude_ft"] != 37000 {
+		t.Errorf("position points = %+v", pos)
+	}
+	winds := byMeasurement(points, MeasurementWind)
+	if len(winds) != 1 {
+		t.Fatalf("got %d wind points, want 1", len(winds))
+	}
+	if _, ok := winds[0].Fields["wind_dir"]; ok {
+		t.Error("invalid wind direction was written")
+	}
+	if winds[0].Fields["temperature_c"] != -48.5 || winds[0].Fields["altitude_ft"] != 37000 {
+		t.Errorf("wind point = %+v", winds[0])
+	}
+}
+
+func TestPointsEngine(t *testing.T) {
+	r := &h1.MDCResult{EngineTrend: &h1.EngineTrendData{LeftN1: 92.5, RightN1: 92.1, LeftITT: 780, Airspeed: 250}}
+	engines := byMeasurement(Points(event(r)), MeasurementEngine)
+	if len(engines) != 3 {
+		t.Fatalf("got %d engine points, want 3: %+v", len(engines), engines)
+	}
+	if engines[0].Tags["engine"] != "left" || engines[0].Fields["n1"] != 92.5 || engines[0].Fields["itt"] != 780 {
+		t.Errorf("left engine = %+v", engines[0])
+	}
+	if engines[1].Tags["engine"] != "right" || engines[1].Fields["n1"] != 92.1 {
+		t.Errorf("right engine = %+v", engines[1])
+	}
+	if _, ok := engines[2].Tags["engine"]; ok || engines[2].Fields["airspeed"] != 250 {
+		t.Errorf("aircraft point = %+v", engines[2])
+	}
+}
+
+func TestPointsIgnoresEnrichment(t *testing.T) {
+	if p := Points(output.Event{Kind: output.KindEnrichment, Data: &h1.PWIResult{}}); p != nil {
+		t.Errorf("got %+v for an enrichment event", p)
+	}
+}
+
+func TestAppendLine(t *testing.T) {
+	p := Point{
+		Measurement: "wind",
+		Tags:        map[string]string{"waypoint": "A B", "icao": "7C6DB8", "flight": "QF1,2"},
+		Fields:      map[string]float64{"wind_speed_kt": 90, "temperature_c": -52.5},
+		Time:        time.Unix(1769248800, 0),
+	}
+	want := `wind,flight=QF1\,2,icao=7C6DB8,waypoint=A\ B temperature_c=-52.5,wind_speed_kt=90 1769248800000000000` + "\n"
+	if got := string(AppendLine(nil, p)); got != want {
+		t.Errorf("AppendLine = %q, want %q", got, want)
+	}
+	if got := AppendLine(nil, Point{Measurement: "wind"}); len(got) != 0 {
+		t.Errorf("point without fields = %q", got)
+	}
+}
+
+func TestInfluxSink(t *testing.T) {
+	var gotQuery, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotQuery, gotAuth, gotBody = r.URL.Path+"?"+r.URL.RawQuery, r.Header.Get("Authorization"), string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := NewInflux(InfluxConfig{URL: srv.URL, Token: "secret", Org: "pw", Bucket: "acars"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &h1.PWIResult{ClimbWinds: []h1.AltitudeWind{{FlightLevel: 100, WindDir: 270, WindSpeed: 35}}}
+	if err := s.Publish(context.Background(), event(r)); err != nil {
+		t.Fatal(err)
+	}
+	if gotBody != "" {
+		t.Error("batch written before it was full")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gotQuery != "/api/v2/write?bucket=acars&org=pw&precision=ns" || gotAuth != "Token secret" {
+		t.Errorf("request = %s with %q", gotQuery, gotAuth)
+	}
+	if !strings.HasPrefix(gotBody, "wind,flight=QF1,flight_level=100,icao=7C6DB8,phase=climb,source=pwi wind_dir=270,wind_speed_kt=35 ") {
+		t.Errorf("body = %q", gotBody)
+	}
+
+	if _, err := NewInflux(InfluxConfig{URL: srv.URL}); err == nil {
+		t.Error("expected error without a bucket or database")
+	}
+}
@@ -0,0 +1,318 @@
+// Package units converts the altitudes, speeds and temperatures in parse
+// results to canonical units.
+//
+// Parsers report these as the message gives them: feet, metres or flight
+// levels; knots, km/h or Mach (as 0.82, 82 or 820); degrees Celsius as
+// numbers or as strings such as "M45", "MS8" or "-45". Normalise reads a
+// result's fields by name and returns each quantity in one unit, with the
+// field it came from and its original value, so consumers need not guess.
+// The result itself is not changed.
+package units
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"acars_parser/internal/registry"
+)
+
+// Canonical units.
+const (
+	Feet    = "ft"   // Altitudes; flight levels are pressure altitudes in hundreds of feet.
+	Knots   = "kt"   // Speeds.
+	Mach    = "mach" // Mach number, e.g. 0.82.
+	Celsius = "degC" // Temperatures.
+)
+
+// Conversion factors.
+const (
+	metresPerFoot = 0.3048
+	kmhPerKnot    = 1.852
+)
+
+// Value is a quantity in its canonical unit.
+type Value struct {
+	Value    float64     `json:"value"`
+	Unit     string      `json:"unit"`
+	Field    string      `json:"field"`    // Result field it was read from, e.g. "flight_level" or "meteo.temperature_c".
+	Original interface{} `json:"original"` // The field's value as the parser reported it.
+}
+
+// Values are the quantities of a result, by name: "altitude",
+// "altitude_top", "initial_altitude", "transition_level", "ground_speed",
+// "airspeed", "speed", "mach", "wind_speed", "temperature",
+// "total_air_temperature" and "assumed_temperature".
+type Values map[string]Value
+
+// Altitude returns the altitude in feet, if the result gives one.
+func (v Values) Altitude() (float64, bool) {
+	a, ok := v["altitude"]
+	return a.Value, ok
+}
+
+// rule reads a quantity from the first of its fields that converts.
+type rule struct {
+	quantity string
+	unit     string
+	fields   []string
+	convert  func(field string, v interface{}) (float64, bool)
+}
+
+// rules lists the quantities in the order they are looked for. Where a
+// result has both, an altitude is preferred to a flight level, as it is the
+// more precise.
+var rules = []rule{
+	{"altitude", Feet, []string{"altitude", "altitude_ft", "altitude_low", "flight_level"}, feet},
+	{"altitude_top", Feet, []string{"flight_level_top", "altitude_hi"}, feet},
+	{"initial_altitude", Feet, []string{"initial_altitude"}, feet},
+	{"transition_level", Feet, []string{"transition_level"}, feet},
+	{"ground_speed", Knots, []string{"ground_speed", "ground_speed_kts"}, knots},
+	{"airspeed", Knots, []string{"airspeed"}, knots},
+	{"speed", Knots, []string{"speed"}, knots},
+	{"mach", Mach, []string{"mach", "speed"}, mach},
+	{"wind_speed", Knots, []string{"wind_speed", "wind_speed_kts"}, knots},
+	{"temperature", Celsius, []string{"temperature", "temperature_c"}, celsius},
+	{"total_air_temperature", Celsius, []string{"total_air_temp"}, celsius},
+	{"assumed_temperature", Celsius, []string{"assumed_temp", "flex_temp"}, celsius},
+}
+
+// Normalise returns the quantities of a result in canonical units.
+func Normalise(r registry.Result) Values {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	return FromMap(m)
+}
+
+// FromMap returns the quantities of a result decoded from JSON, such as a
+// stored result. The result's top-level fields are read first, then those of
+// the objects nested in it (ADS-C "meteo" and "earth_ref", for instance),
+// breadth first. Lists, such as route winds and trajectory points, are not
+// read. It returns nil when the result has none.
+func FromMap(m map[string]interface{}) Values {
+	var out Values
+	levels := []struct {
+		prefix string
+		m      map[string]interface{}
+	}{{"", m}}
+	for len(levels) > 0 {
+		next := levels[:0:0]
+		for _, l := range levels {
+			for _, r := range rules {
+				if _, done := out[r.quantity]; done {
+					continue
+				}
+				for _, f := range r.fields {
+					raw, ok := l.m[f]
+					if !ok {
+						continue
+					}
+					if v, ok := r.convert(f, raw); ok {
+						if out == nil {
+							out = Values{}
+						}
+						out[r.quantity] = Value{Value: round(v), Unit: r.unit, Field: l.prefix + f, Original: raw}
+						break
+					}
+				}
+			}
+			keys := make([]string, 0, len(l.m))
+			for k := range l.m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if nested, ok := l.m[k].(map[string]interface{}); ok {
+					next = append(next, struct {
+						prefix string
+						m      map[string]interface{}
+					}{l.prefix + k + ".", nested})
+				}
+			}
+		}
+		levels = next
+	}
+	return out
+}
+
+// round rounds a converted value to three decimal places, which is finer
+// than any message reports.
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// AltitudeFeet converts an altitude to feet: a number of feet, a string such
+// as "35000", "35000FT", "FL350" or "1500M", or a CPDLC altitude object
+// ({"type": "flight_level", "value": 350}).
+func AltitudeFeet(v interface{}) (float64, bool) {
+	return feet("altitude", v)
+}
+
+// FlightLevelFeet converts a flight level to feet: a number or string of
+// hundreds of feet, such as 350 or "350", or an altitude such as "FL350" or
+// "35000FT".
+func FlightLevelFeet(v interface{}) (float64, bool) {
+	return feet("flight_level", v)
+}
+
+// feet converts an altitude to feet. Plain numbers are flight levels when
+// the field is one, and feet otherwise.
+func feet(field string, v interface{}) (float64, bool) {
+	flightLevel := strings.HasPrefix(field, "flight_level") || field == "transition_level"
+	switch v := v.(type) {
+	case float64:
+		if flightLevel {
+			return v * 100, true
+		}
+		return v, true
+	case string:
+		s := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+		switch {
+		case strings.HasPrefix(s, "FL"):
+			flightLevel, s = true, s[2:]
+		case strings.HasPrefix(s, "F") && len(s) > 1 && s[1] >= '0' && s[1] <= '9':
+			flightLevel, s = true, s[1:]
+		case strings.HasSuffix(s, "FT"):
+			flightLevel, s = false, strings.TrimSuffix(s, "FT")
+		case strings.HasSuffix(s, "M"):
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, "M"), 64)
+			return n / metresPerFoot, err == nil
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		return feet(map[bool]string{true: "flight_level", false: "altitude"}[flightLevel], n)
+	case map[string]interface{}:
+		n, ok := v["value"].(float64)
+		if !ok {
+			return 0, false
+		}
+		switch v["type"] {
+		case "feet":
+			return n, true
+		case "flight_level":
+			return n * 100, true
+		case "meters":
+			return n / metresPerFoot, true
+		case "flight_level_metric": // Tens of metres.
+			return n * 10 / metresPerFoot, true
+		}
+	}
+	return 0, false
+}
+
+// knots converts a speed to knots: a number of knots, a string with an
+// optional "KT" or "KMH" suffix, or a CPDLC speed object in knots or km/h.
+func knots(_ string, v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		s := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+		perKnot := 1.0
+		for _, suffix := range []string{"KMH", "KPH", "KM/H"} {
+			if strings.HasSuffix(s, suffix) {
+				s, perKnot = strings.TrimSuffix(s, suffix), kmhPerKnot
+			}
+		}
+		s = strings.TrimSuffix(strings.TrimSuffix(s, "KTS"), "KT")
+		n, err := strconv.ParseFloat(s, 64)
+		return n / perKnot, err == nil
+	case map[string]interface{}:
+		n, ok := v["value"].(float64)
+		if !ok {
+			return 0, false
+		}
+		switch v["type"] {
+		case "knots":
+			return n, true
+		case "kph":
+			return n / kmhPerKnot, true
+		}
+	}
+	return 0, false
+}
+
+// mach converts a Mach number given as 0.82, or in hundredths (82) or
+// thousandths (820), or as a string such as "M82", "M.82" or "820", or a
+// CPDLC speed object in Mach. Only the "mach" field, or a speed object, is
+// read as a Mach number.
+func mach(field string, v interface{}) (float64, bool) {
+	var n float64
+	switch v := v.(type) {
+	case float64:
+		if field != "mach" {
+			return 0, false
+		}
+		n = v
+	case string:
+		if field != "mach" {
+			return 0, false
+		}
+		s := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(v)), "M")
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		if strings.Contains(s, ".") {
+			return f, true
+		}
+		n = f
+	case map[string]interface{}:
+		f, ok := v["value"].(float64)
+		if !ok || v["type"] != "mach" {
+			return 0, false
+		}
+		n = f
+	default:
+		return 0, false
+	}
+	switch {
+	case n < 10:
+		return n, true
+	case n < 100:
+		return n / 100, true
+	default:
+		return n / 1000, true
+	}
+}
+
+// celsius converts a temperature to degrees Celsius: a number, or a string
+// such as "-45", "M45", "MS45", "P05", "PS5" or "-45C", or "-49F" in
+// Fahrenheit.
+func celsius(_ string, v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		s := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+		fahrenheit := strings.HasSuffix(s, "F")
+		s = strings.TrimSuffix(strings.TrimSuffix(s, "F"), "C")
+		sign := 1.0
+		switch {
+		case strings.HasPrefix(s, "MS"), strings.HasPrefix(s, "PS"):
+			sign, s = map[bool]float64{true: -1, false: 1}[s[0] == 'M'], s[2:]
+		case strings.HasPrefix(s, "M"), strings.HasPrefix(s, "P"):
+			sign, s = map[bool]float64{true: -1, false: 1}[s[0] == 'M'], s[1:]
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		n *= sign
+		if fahrenheit {
+			n = (n - 32) * 5 / 9
+		}
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,148 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFromMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		result map[string]interface{}
+		want   map[string]float64 // Quantity to value.
+		field  map[string]string  // Quantity to field, where it matters.
+	}{
+		{
+			name:   "position report",
+			result: map[string]interface{}{"altitude": 37000.0, "ground_speed": 482.0, "temperature": -56.0, "mach": 0.82},
+			want:   map[string]float64{"altitude": 37000, "ground_speed": 482, "temperature": -56, "mach": 0.82},
+		},
+		{
+			name:   "flight level number",
+			result: map[string]interface{}{"flight_level": 350.0},
+			want:   map[string]float64{"altitude": 35000},
+		},
+		{
+			name:   "altitude preferred to flight level",
+			result: map[string]interface{}{"flight_level": 350.0, "altitude": 35012.0},
+			want:   map[string]float64{"altitude": 35012},
+			field:  map[string]string{"altitude": "altitude"},
+		},
+		{
+			name:   "PDC strings",
+			result: map[string]interface{}{"flight_level": "350", "initial_altitude": "5000"},
+			want:   map[string]float64{"altitude": 35000, "initial_altitude": 5000},
+		},
+		{
+			name:   "flight level strings",
+			result: map[string]interface{}{"altitude_low": "FL300", "altitude_hi": "FL340", "transition_level": "70"},
+			want:   map[string]float64{"altitude": 30000, "altitude_top": 34000, "transition_level": 7000},
+		},
+		{
+			name:   "envelope altitude",
+			result: map[string]interface{}{"altitude": "FL350"},
+			want:   map[string]float64{"altitude": 35000},
+		},
+		{
+			name:   "Mach in thousandths and temperature with sign prefix",
+			result: map[string]interface{}{"mach": "820", "temperature": "M45"},
+			want:   map[string]float64{"mach": 0.82, "temperature": -45},
+		},
+		{
+			name:   "Mach in hundredths",
+			result: map[string]interface{}{"mach": "M82", "temperature": "PS05"},
+			want:   map[string]float64{"mach": 0.82, "temperature": 5},
+		},
+		{
+			name: "ADS-C nested groups",
+			result: map[string]interface{}{
+				"altitude":  33000.0,
+				"earth_ref": map[string]interface{}{"ground_speed_kts": 470.5},
+				"meteo":     map[string]interface{}{"wind_speed_kts": 42.0, "temperature_c": -48.5},
+				"air_ref":   map[string]interface{}{"mach": 0.79},
+			},
+			want:  map[string]float64{"altitude": 33000, "ground_speed": 470.5, "wind_speed": 42, "temperature": -48.5, "mach": 0.79},
+			field: map[string]string{"temperature": "meteo.temperature_c", "ground_speed": "earth_ref.ground_speed_kts"},
+		},
+		{
+			name:   "CPDLC speed objects",
+			result: map[string]interface{}{"speed": map[string]interface{}{"type": "mach", "value": 84.0}},
+			want:   map[string]float64{"mach": 0.84},
+		},
+		{
+			name:   "CPDLC speed in km/h",
+			result: map[string]interface{}{"speed": map[string]interface{}{"type": "kph", "value": 926.0}},
+			want:   map[string]float64{"speed": 500},
+		},
+		{
+			name:   "CPDLC metric altitude",
+			result: map[string]interface{}{"altitude": map[string]interface{}{"type": "meters", "value": 10100.0}},
+			want:   map[string]float64{"altitude": 33136.483},
+		},
+		{
+			name:   "takeoff temperatures",
+			result: map[string]interface{}{"flex_temp": 52.0, "total_air_temp": "-12"},
+			want:   map[string]float64{"assumed_temperature": 52, "total_air_temperature": -12},
+		},
+		{
+			name:   "unreadable values are left out",
+			result: map[string]interface{}{"altitude": "UNKN", "temperature": true, "routes": []interface{}{map[string]interface{}{"altitude": 1.0}}},
+			want:   map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromMap(tt.result)
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d quantities %+v, want %d", len(got), got, len(tt.want))
+			}
+			for q, w := range tt.want {
+				v, ok := got[q]
+				if !ok || math.Abs(v.Value-w) > 0.001 {
+					t.Errorf("%s = %+v, want %v", q, v, w)
+				}
+			}
+			for q, f := range tt.field {
+				if got[q].Field != f {
+					t.Errorf("%s read from %q, want %q", q, got[q].Field, f)
+				}
+			}
+		})
+	}
+}
+
+type testResult struct {
+	FlightLevel string `json:"flight_level"`
+}
+
+func (r *testResult) Type() string     { return "test" }
+func (r *testResult) MessageID() int64 { return 0 }
+
+func TestNormaliseKeepsOriginal(t *testing.T) {
+	a := Normalise(&testResult{FlightLevel: "FL350"})["altitude"]
+	if a.Value != 35000 || a.Unit != Feet || a.Field != "flight_level" || a.Original != "FL350" {
+		t.Errorf("altitude = %+v", a)
+	}
+}
+
+func TestConverters(t *testing.T) {
+	if v, ok := AltitudeFeet("35000FT"); !ok || v != 35000 {
+		t.Errorf("AltitudeFeet(35000FT) = %v, %v", v, ok)
+	}
+	if v, ok := AltitudeFeet(map[string]interface{}{"type": "flight_level_metric", "value": 1010.0}); !ok || math.Round(v) != 33136 {
+		t.Errorf("AltitudeFeet(metric flight level) = %v, %v", v, ok)
+	}
+	if v, ok := FlightLevelFeet(390.0); !ok || v != 39000 {
+		t.Errorf("FlightLevelFeet(390) = %v, %v", v, ok)
+	}
+	if v, ok := celsius("", "-49F"); !ok || math.Abs(v+45) > 0.001 {
+		t.Errorf("celsius(-49F) = %v, %v", v, ok)
+	}
+	if v, ok := knots("", "250KT"); !ok || v != 250 {
+		t.Errorf("knots(250KT) = %v, %v", v, ok)
+	}
+	if _, ok := mach("speed", 250.0); ok {
+		t.Error("a plain speed was read as a Mach number")
+	}
+}
@@ -0,0 +1,180 @@
+// Package vdl2 decodes the JSON output of dumpvdl2.
+//
+// A VDL Mode 2 frame is an AVLC frame between an aircraft and a ground
+// station, each identified by a 24-bit address: the ICAO address for
+// aircraft, and an address assigned by the service provider for ground
+// stations. Information frames carry ACARS, which is returned as an
+// acars.Message with the link-layer addresses and direction attached, so the
+// aircraft can be correlated by ICAO address without a registration lookup.
+// XID frames, exchanged when an aircraft joins or hands off between ground
+// stations, are returned as results carrying the aircraft position and
+// destination airport they report.
+package vdl2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/registry"
+)
+
+// Source is the acars.Message source of messages decoded from VDL2.
+const Source = "vdl2"
+
+// Address types used by dumpvdl2.
+const (
+	typeAircraft      = "Aircraft"
+	typeGroundStation = "Ground station"
+)
+
+// Envelope is the top level of a dumpvdl2 JSON line.
+type Envelope struct {
+	VDL2 *Frame `json:"vdl2"`
+}
+
+// Frame is one decoded VDL2 burst.
+type Frame struct {
+	Station   string    `json:"station,omitempty"`
+	Time      Time      `json:"t"`
+	Freq      float64   `json:"freq"`          // Hz.
+	Idx       *int      `json:"idx,omitempty"` // Receiver channel index.
+	SigLevel  float64   `json:"sig_level,omitempty"`
+	AVLC      *AVLC     `json:"avlc,omitempty"`
+	Timestamp time.Time `json:"-"` // Set from Time by DecodeFrame.
+}
+
+// Time is the receive time of a frame.
+type Time struct {
+	Sec  int64 `json:"sec"`
+	Usec int64 `json:"usec"`
+}
+
+// Address is the source or destination of an AVLC frame.
+type Address struct {
+	Addr   string `json:"addr"`
+	Type   string `json:"type"`             // "Aircraft", "Ground station" or "All stations".
+	Status string `json:"status,omitempty"` // Aircraft only: "Airborne" or "On ground".
+}
+
+// AVLC is an aviation VHF link control frame.
+type AVLC struct {
+	Src       Address `json:"src"`
+	Dst       Address `json:"dst"`
+	CR        string  `json:"cr,omitempty"`
+	FrameType string  `json:"frame_type"` // "I", "S" or "U".
+	Cmd       string  `json:"cmd,omitempty"`
+	ACARS     *ACARS  `json:"acars,omitempty"`
+	XID       *XID    `json:"xid,omitempty"`
+}
+
+// ACARS is an ACARS message carried in an AVLC information frame.
+type ACARS struct {
+	Err     bool   `json:"err"`
+	CRCOK   bool   `json:"crc_ok"`
+	Reg     string `json:"reg"`
+	Mode    string `json:"mode,omitempty"`
+	Label   string `json:"label"`
+	BlockID string `json:"blk_id,omitempty"`
+	Ack     string `json:"ack,omitempty"`
+	Flight  string `json:"flight,omitempty"`
+	MsgNum  string `json:"msg_num,omitempty"`
+	MsgSeq  string `json:"msg_num_seq,omitempty"`
+	Text    string `json:"msg_text"`
+}
+
+// XID is an exchange identification frame.
+type XID struct {
+	Err       bool       `json:"err"`
+	Type      string     `json:"type"`
+	TypeDescr string     `json:"type_descr,omitempty"`
+	PubParams []XIDParam `json:"pub_params,omitempty"`
+	VDLParams []XIDParam `json:"vdl_params,omitempty"`
+}
+
+// XIDParam is one XID parameter. The value's shape depends on the name.
+type XIDParam struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Decoded is the content of one dumpvdl2 line: an ACARS message, XID
+// results, or neither (e.g. supervisory frames).
+type Decoded struct {
+	Message *acars.Message
+	Results []registry.Result
+}
+
+// ErrNotVDL2 is returned for JSON that is not dumpvdl2 output.
+var ErrNotVDL2 = errors.New("not a dumpvdl2 frame")
+
+// Decode decodes one line of dumpvdl2 JSON output.
+func Decode(data []byte) (*Decoded, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("decode vdl2: %w", err)
+	}
+	if env.VDL2 == nil {
+		return nil, ErrNotVDL2
+	}
+	return DecodeFrame(env.VDL2), nil
+}
+
+// DecodeFrame extracts the ACARS message and XID results from a frame.
+func DecodeFrame(f *Frame) *Decoded {
+	f.Timestamp = time.Unix(f.Time.Sec, f.Time.Usec*1000).UTC()
+	out := &Decoded{}
+
+	a := f.AVLC
+	if a == nil {
+		return out
+	}
+	if a.ACARS != nil && !a.ACARS.Err {
+		out.Message = acarsMessage(f, a.ACARS)
+	}
+	if a.XID != nil && !a.XID.Err {
+		out.Results = append(out.Results, xidResult(f))
+	}
+	return out
+}
+
+// direction returns the link direction of an AVLC frame, or "" when neither
+// end is an aircraft.
+func direction(a *AVLC) string {
+	switch {
+	case a.Src.Type == typeAircraft:
+		return "downlink"
+	case a.Dst.Type == typeAircraft:
+		return "uplink"
+	}
+	return ""
+}
+
+func acarsMessage(f *Frame, a *ACARS) *acars.Message {
+	msg := &acars.Message{
+		Source:        Source,
+		Timestamp:     f.Timestamp.Format(time.RFC3339Nano),
+		Tail:          strings.TrimLeft(a.Reg, "."),
+		Text:          a.Text,
+		Label:         a.Label,
+		Frequency:     f.Freq / 1e6,
+		Mode:          a.Mode,
+		BlockID:       a.BlockID,
+		Ack:           a.Ack,
+		MsgNo:         a.MsgNum + a.MsgSeq,
+		Channel:       f.Idx,
+		LinkDirection: direction(f.AVLC),
+		FromHex:       strings.ToUpper(f.AVLC.Src.Addr),
+		ToHex:         strings.ToUpper(f.AVLC.Dst.Addr),
+	}
+	if a.Flight != "" {
+		msg.Flight = &acars.Flight{Flight: strings.TrimSpace(a.Flight)}
+	}
+	if f.Station != "" {
+		msg.Station = &acars.Station{ID: f.Station}
+	}
+	return msg
+}
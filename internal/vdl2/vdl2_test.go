@@ -0,0 +1,80 @@
+package vdl2
+
+import (
+	"errors"
+	"testing"
+)
+
+const downlinkJSON = `{"vdl2":{"app":{"name":"dumpvdl2","ver":"2.3.0"},"station":"YSSY-1","t":{"sec":1769248800,"usec":125000},"freq":136975000,"burst_len_octets":78,"idx":2,"sig_level":-21.5,"avlc":{"src":{"addr":"7c6db8","type":"Aircraft","status":"Airborne"},"dst":{"addr":"10916D","type":"Ground station"},"cr":"Command","frame_type":"I","rseq":3,"sseq":4,"poll":false,"acars":{"err":false,"crc_ok":true,"more":false,"reg":".VH-OQA","mode":"2","label":"H1","blk_id":"5","ack":"!","flight":"QF0001","msg_num":"D01","msg_num_seq":"A","msg_text":"POSS33570E151108,ABC,100052,370"}}}}`
+
+const uplinkJSON = `{"vdl2":{"t":{"sec":1769248810,"usec":0},"freq":136975000,"avlc":{"src":{"addr":"10916D","type":"Ground station"},"dst":{"addr":"7C6DB8","type":"Aircraft","status":"Airborne"},"frame_type":"I","acars":{"reg":".VH-OQA","label":"_d","blk_id":"A","msg_text":""}}}}`
+
+const xidJSON = `{"vdl2":{"t":{"sec":1769248820,"usec":0},"freq":136975000,"avlc":{"src":{"addr":"7C6DB8","type":"Aircraft","status":"Airborne"},"dst":{"addr":"10916D","type":"Ground station"},"cr":"Command","frame_type":"U","cmd":"XID","pf":true,"xid":{"err":false,"type":"XID_CMD_LCR","type_descr":"Link Connection Refused","pub_params":[{"name":"param_set_id","value":"8885:1993"}],"vdl_params":[{"name":"param_set_id","value":"V"},{"name":"ac_location","value":{"loc":{"lat":-33.5,"lon":151.3},"alt":24000}},{"name":"dst_airport","value":"YMML"},{"name":"modulation_support","value":["VDL-M2, D8PSK, 31500 bps"]}]}}}}`
+
+func TestDecodeDownlink(t *testing.T) {
+	d, err := Decode([]byte(downlinkJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	m := d.Message
+	if m == nil {
+		t.Fatal("no message")
+	}
+	if m.Source != Source || m.Tail != "VH-OQA" || m.Label != "H1" || m.Frequency != 136.975 || m.Timestamp != "2026-01-24T10:00:00.125Z" {
+		t.Errorf("message = %+v", m)
+	}
+	if m.LinkDirection != "downlink" || m.FromHex != "7C6DB8" || m.ToHex != "10916D" {
+		t.Errorf("link = %s %s -> %s", m.LinkDirection, m.FromHex, m.ToHex)
+	}
+	if m.AircraftICAO() != "7C6DB8" || m.GroundStationHex() != "10916D" {
+		t.Errorf("aircraft %s, ground station %s", m.AircraftICAO(), m.GroundStationHex())
+	}
+	if m.Flight == nil || m.Flight.Flight != "QF0001" || m.Station == nil || m.Station.ID != "YSSY-1" {
+		t.Errorf("flight %+v, station %+v", m.Flight, m.Station)
+	}
+	if m.Mode != "2" || m.BlockID != "5" || m.Ack != "!" || m.MsgNo != "D01A" || m.Channel == nil || *m.Channel != 2 {
+		t.Errorf("header: mode %q, block %q, ack %q, msgno %q, channel %v", m.Mode, m.BlockID, m.Ack, m.MsgNo, m.Channel)
+	}
+}
+
+func TestDecodeUplink(t *testing.T) {
+	d, err := Decode([]byte(uplinkJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	m := d.Message
+	if m.LinkDirection != "uplink" || m.AircraftICAO() != "7C6DB8" || m.GroundStationHex() != "10916D" {
+		t.Errorf("uplink = %+v", m)
+	}
+}
+
+func TestDecodeXID(t *testing.T) {
+	d, err := Decode([]byte(xidJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if d.Message != nil || len(d.Results) != 1 {
+		t.Fatalf("got message %v and %d results, want 1 XID", d.Message, len(d.Results))
+	}
+	x, ok := d.Results[0].(*XIDResult)
+	if !ok {
+		t.Fatalf("result is %T", d.Results[0])
+	}
+	if x.AircraftICAO != "7C6DB8" || x.GroundStation != "10916D" || x.AircraftStatus != "Airborne" || x.XIDType != "XID_CMD_LCR" {
+		t.Errorf("addresses = %+v", x)
+	}
+	if x.Latitude != -33.5 || x.Longitude != 151.3 || x.Altitude != 24000 || x.DestinationAirport != "YMML" {
+		t.Errorf("position and destination = %+v", x)
+	}
+}
+
+func TestDecodeNotVDL2(t *testing.T) {
+	if _, err := Decode([]byte(`{"hfdl":{}}`)); !errors.Is(err, ErrNotVDL2) {
+		t.Errorf("err = %v, want ErrNotVDL2", err)
+	}
+	// Supervisory frames carry nothing to return.
+	d, err := Decode([]byte(`{"vdl2":{"t":{"sec":0,"usec":0},"avlc":{"src":{"addr":"7C6DB8","type":"Aircraft"},"dst":{"addr":"10916D","type":"Ground station"},"frame_type":"S","cmd":"Receive Ready"}}}`))
+	if err != nil || d.Message != nil || len(d.Results) != 0 {
+		t.Errorf("supervisory frame = %+v, %v", d, err)
+	}
+}
@@ -0,0 +1,90 @@
+package vdl2
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// XIDResult is the link-layer data of an XID frame: the addresses of both
+// ends and the position and destination an aircraft reports when it joins a
+// ground station.
+type XIDResult struct {
+	Timestamp          string  `json:"timestamp"`
+	XIDType            string  `json:"xid_type,omitempty"` // e.g. "GSIF", "XID_CMD_LCR".
+	AircraftICAO       string  `json:"aircraft_icao,omitempty"`
+	AircraftStatus     string  `json:"aircraft_status,omitempty"` // "Airborne" or "On ground".
+	GroundStation      string  `json:"ground_station_hex,omitempty"`
+	Latitude           float64 `json:"latitude,omitempty"`
+	Longitude          float64 `json:"longitude,omitempty"`
+	Altitude           int     `json:"altitude,omitempty"` // Feet.
+	DestinationAirport string  `json:"destination,omitempty"`
+	FrequencyMHz       float64 `json:"frequency_mhz,omitempty"`
+}
+
+func (r *XIDResult) Type() string     { return "vdl2_xid" }
+func (r *XIDResult) MessageID() int64 { return 0 }
+
+// xidLocation is the value of the ac_location parameter. dumpvdl2 nests the
+// position under "loc" with the altitude beside it; a bare position is also
+// accepted.
+type xidLocation struct {
+	Loc *struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"loc"`
+	Lat *float64 `json:"lat"`
+	Lon *float64 `json:"lon"`
+	Alt int      `json:"alt"`
+}
+
+func (l xidLocation) position() (lat, lon float64, ok bool) {
+	switch {
+	case l.Loc != nil:
+		lat, lon = l.Loc.Lat, l.Loc.Lon
+	case l.Lat != nil && l.Lon != nil:
+		lat, lon = *l.Lat, *l.Lon
+	default:
+		return 0, 0, false
+	}
+	if (lat == 0 && lon == 0) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+func xidResult(f *Frame) *XIDResult {
+	a := f.AVLC
+	r := &XIDResult{
+		Timestamp:    f.Timestamp.Format(time.RFC3339),
+		XIDType:      a.XID.Type,
+		FrequencyMHz: f.Freq / 1e6,
+	}
+	for _, end := range []Address{a.Src, a.Dst} {
+		switch end.Type {
+		case typeAircraft:
+			r.AircraftICAO, r.AircraftStatus = strings.ToUpper(end.Addr), end.Status
+		case typeGroundStation:
+			r.GroundStation = strings.ToUpper(end.Addr)
+		}
+	}
+
+	for _, p := range a.XID.VDLParams {
+		switch p.Name {
+		case "ac_location":
+			var loc xidLocation
+			if json.Unmarshal(p.Value, &loc) != nil {
+				continue
+			}
+			if lat, lon, ok := loc.position(); ok {
+				r.Latitude, r.Longitude, r.Altitude = lat, lon, loc.Alt
+			}
+		case "dst_airport":
+			var apt string
+			if json.Unmarshal(p.Value, &apt) == nil {
+				r.DestinationAirport = strings.TrimSpace(apt)
+			}
+		}
+	}
+	return r
+}
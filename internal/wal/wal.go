@@ -0,0 +1,379 @@
+// Package wal is a write-ahead log of raw input: every line read, before it
+// is decoded or parsed, appended to gzip-compressed JSON lines segments.
+//
+// A Writer appends to a segment named for the time it was started, such as
+// 20260301T100000.000000000Z.jsonl.gz, and starts a new one once the segment
+// holds SegmentSize bytes of input or is SegmentAge old. The segment being
+// written has an .open suffix until it is finished; a segment left open by a
+// crash is finished when the next Writer is opened on the directory, and
+// holds the input up to its last flush. Segments whose input is all older
+// than Retention are deleted as new ones are started.
+//
+// Finished segments are ordinary gzip files of input lines, so any tool that
+// reads JSON lines can read them once decompressed. A Reader reads a run of
+// segments as one stream of lines, for input.NewReader; replay -wal reads it
+// that way.
+package wal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for the zero fields of a Config.
+const (
+	DefaultSegmentSize = 64 << 20 // Bytes of input, before compression.
+	DefaultSegmentAge  = time.Hour
+)
+
+// ErrRead is wrapped by the errors a Reader returns, which end the stream.
+var ErrRead = errors.New("wal: read")
+
+// flushInterval bounds the input a crash can lose from the open segment. It
+// is a variable so that tests can shorten it.
+var flushInterval = time.Second
+
+// Segment file names.
+const (
+	segmentExt = ".jsonl.gz"
+	openSuffix = ".open"
+	timeLayout = "20060102T150405.000000000Z"
+)
+
+// Config configures a Writer.
+type Config struct {
+	Dir         string
+	SegmentSize int64         // Start a new segment after this many bytes of input.
+	SegmentAge  time.Duration // Start a new segment once the open one is this old.
+	Retention   time.Duration // Delete segments whose input is all older than this; 0 keeps them.
+}
+
+// Stats counts what a Writer has written.
+type Stats struct {
+	Lines    int
+	Bytes    int64 // Input bytes, before compression.
+	Segments int   // Segments started.
+	Failed   int   // Lines that could not be written.
+}
+
+// Writer appends input lines to the log. It is safe for concurrent use.
+type Writer struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	f        *os.File
+	gz       *gzip.Writer
+	path     string // Of the open segment, without openSuffix.
+	started  time.Time
+	size     int64
+	lastSync time.Time
+	pending  bool  // Lines written since the last flush.
+	flushErr error // From a background flush, returned by the next Append.
+	stats    Stats
+
+	done    chan struct{}
+	flushWG sync.WaitGroup
+	stop    sync.Once
+}
+
+// Open returns a Writer appending to segments in cfg.Dir, creating it if
+// needed, and finishes any segment left open by an earlier Writer.
+func Open(cfg Config) (*Writer, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("wal: no directory")
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = DefaultSegmentSize
+	}
+	if cfg.SegmentAge <= 0 {
+		cfg.SegmentAge = DefaultSegmentAge
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+	open, err := filepath.Glob(filepath.Join(cfg.Dir, "*"+segmentExt+openSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+	for _, path := range open {
+		if err := os.Rename(path, strings.TrimSuffix(path, openSuffix)); err != nil {
+			return nil, fmt.Errorf("wal: finish %s: %w", path, err)
+		}
+	}
+	w := &Writer{cfg: cfg, now: time.Now, done: make(chan struct{})}
+	w.flushWG.Add(1)
+	go w.flushLoop(flushInterval)
+	return w, nil
+}
+
+// flushLoop flushes lines left in the open segment's buffer once input goes
+// quiet, until the Writer is closed.
+func (w *Writer) flushLoop(interval time.Duration) {
+	defer w.flushWG.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			w.mu.Lock()
+			if err := w.flush(); err != nil && w.flushErr == nil {
+				w.flushErr = err
+			}
+			w.mu.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// flush writes the lines buffered since the last flush to the file.
+func (w *Writer) flush() error {
+	if w.gz == nil || !w.pending {
+		return nil
+	}
+	w.pending = false
+	if err := w.gz.Flush(); err != nil {
+		return fmt.Errorf("wal: flush %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// Append writes one line of input, which must not contain a newline. The
+// segment is flushed to the file at most a second after the line is written,
+// by the next Append or, when input goes quiet, in the background. An error
+// of a background flush is returned by the next Append.
+func (w *Writer) Append(line []byte) (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer func() {
+		if err != nil {
+			w.stats.Failed++
+		}
+	}()
+	if err := w.flushErr; err != nil {
+		w.flushErr = nil
+		return err
+	}
+	now := w.now()
+	if w.gz != nil && (w.size >= w.cfg.SegmentSize || now.Sub(w.started) >= w.cfg.SegmentAge) {
+		if err := w.finish(); err != nil {
+			return err
+		}
+	}
+	if w.gz == nil {
+		if err := w.start(now); err != nil {
+			return err
+		}
+	}
+	if _, err := w.gz.Write(line); err != nil {
+		return fmt.Errorf("wal: write %s: %w", w.path, err)
+	}
+	if _, err := w.gz.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("wal: write %s: %w", w.path, err)
+	}
+	w.size += int64(len(line)) + 1
+	w.stats.Lines++
+	w.stats.Bytes += int64(len(line)) + 1
+	w.pending = true
+	if now.Sub(w.lastSync) >= flushInterval {
+		w.lastSync = now
+		return w.flush()
+	}
+	return nil
+}
+
+// start opens a new segment and deletes those past the retention.
+func (w *Writer) start(now time.Time) error {
+	w.path = filepath.Join(w.cfg.Dir, now.UTC().Format(timeLayout)+segmentExt)
+	f, err := os.OpenFile(w.path+openSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+	w.f, w.gz = f, gzip.NewWriter(f)
+	w.started, w.size, w.lastSync, w.pending = now, 0, now, false
+	w.stats.Segments++
+	if w.cfg.Retention > 0 {
+		return w.expire(now.Add(-w.cfg.Retention))
+	}
+	return nil
+}
+
+// expire deletes the finished segments whose successor started before
+// cutoff, so that every segment left may hold input from after it.
+func (w *Writer) expire(cutoff time.Time) error {
+	segs, err := Segments(w.cfg.Dir, cutoff, time.Time{})
+	if err != nil {
+		return err
+	}
+	all, err := Segments(w.cfg.Dir, time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, path := range all[:len(all)-len(segs)] {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("wal: %w", err)
+		}
+	}
+	return nil
+}
+
+// finish completes the open segment and gives it its final name.
+func (w *Writer) finish() error {
+	gzErr := w.gz.Close()
+	closeErr := w.f.Close()
+	w.f, w.gz = nil, nil
+	if err := errors.Join(gzErr, closeErr); err != nil {
+		return fmt.Errorf("wal: finish %s: %w", w.path, err)
+	}
+	if err := os.Rename(w.path+openSuffix, w.path); err != nil {
+		return fmt.Errorf("wal: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the counts so far.
+func (w *Writer) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// Close stops the background flush and finishes the open segment.
+func (w *Writer) Close() error {
+	w.stop.Do(func() { close(w.done) })
+	w.flushWG.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.gz == nil {
+		return nil
+	}
+	return w.finish()
+}
+
+// Segments returns the finished segments in dir, oldest first, that may hold
+// input from from up to to. A segment holds input from its start up to the
+// start of the next, so the one before from is included. A zero from or to
+// leaves that end open.
+func Segments(dir string, from, to time.Time) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		return nil, fmt.Errorf("wal: %w", err)
+	}
+	type segment struct {
+		path  string
+		start time.Time
+	}
+	var segs []segment
+	for _, path := range paths {
+		start, err := time.Parse(timeLayout, strings.TrimSuffix(filepath.Base(path), segmentExt))
+		if err != nil {
+			continue // Not a segment.
+		}
+		segs = append(segs, segment{path, start})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].start.Before(segs[j].start) })
+
+	var out []string
+	for i, s := range segs {
+		if !to.IsZero() && !s.start.Before(to) {
+			break
+		}
+		if !from.IsZero() && i+1 < len(segs) && !segs[i+1].start.After(from) {
+			continue
+		}
+		out = append(out, s.path)
+	}
+	return out, nil
+}
+
+// Reader reads the lines of a run of segments in turn.
+type Reader struct {
+	paths []string
+	f     *os.File
+	gz    *gzip.Reader
+	r     *bufio.Reader
+	line  []byte // Unread part of the current line.
+}
+
+// NewReader returns a Reader over the segments at paths, as returned by
+// Segments.
+func NewReader(paths []string) *Reader {
+	return &Reader{paths: paths}
+}
+
+// Read reads the decompressed segments. A segment cut short, such as the
+// last one written before a crash, ends at its last complete line.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.line) == 0 {
+		if r.r == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+			path := r.paths[0]
+			r.paths = r.paths[1:]
+			if err := r.open(path); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		line, err := r.r.ReadBytes('\n')
+		if err == nil {
+			r.line = line
+			continue
+		}
+		name := r.f.Name()
+		r.closeSegment()
+		// A line without its newline was cut off.
+		if err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, fmt.Errorf("%w %s: %w", ErrRead, name, err)
+		}
+	}
+	n := copy(p, r.line)
+	r.line = r.line[n:]
+	return n, nil
+}
+
+// open starts reading the segment at path. An empty segment, started just
+// before a crash, is skipped.
+func (r *Reader) open(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRead, err)
+	}
+	gz, err := gzip.NewReader(f)
+	if err == io.EOF {
+		f.Close()
+		return nil
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("%w %s: %w", ErrRead, path, err)
+	}
+	r.f, r.gz, r.r = f, gz, bufio.NewReader(gz)
+	return nil
+}
+
+func (r *Reader) closeSegment() {
+	_ = r.gz.Close()
+	_ = r.f.Close()
+	r.f, r.gz, r.r = nil, nil, nil
+}
+
+// Close closes the segment being read.
+func (r *Reader) Close() error {
+	if r.r != nil {
+		r.closeSegment()
+	}
+	r.paths, r.line = nil, nil
+	return nil
+}
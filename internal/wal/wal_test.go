@@ -0,0 +1,197 @@
+package wal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// clock is a Writer clock moved by the tests.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func openAt(t *testing.T, cfg Config, c *clock) *Writer {
+	t.Helper()
+	w, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = c.now
+	return w
+}
+
+func appendLines(t *testing.T, w *Writer, lines ...string) {
+	t.Helper()
+	for _, l := range lines {
+		if err := w.Append([]byte(l)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readAll(t *testing.T, paths []string) []string {
+	t.Helper()
+	r := NewReader(paths)
+	defer r.Close()
+	var lines []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	c := &clock{t0}
+	w := openAt(t, Config{Dir: dir, SegmentSize: 10, SegmentAge: time.Hour}, c)
+	appendLines(t, w, `{"n":1}`, `{"n":2}`) // The second fills the segment.
+	c.t = t0.Add(time.Minute)
+	appendLines(t, w, `{"n":3}`)
+	c.t = t0.Add(2 * time.Hour) // Past the segment's age.
+	appendLines(t, w, `{"n":4}`)
+
+	// The open segment is not listed until it is finished.
+	if segs, _ := Segments(dir, time.Time{}, time.Time{}); len(segs) != 2 {
+		t.Errorf("%d segments listed before closing, want 2", len(segs))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	segs, err := Segments(dir, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 3 || filepath.Base(segs[0]) != "20260301T100000.000000000Z.jsonl.gz" {
+		t.Fatalf("segments = %v", segs)
+	}
+	want := []string{`{"n":1}`, `{"n":2}`, `{"n":3}`, `{"n":4}`}
+	if got := readAll(t, segs); !reflect.DeepEqual(got, want) {
+		t.Errorf("read %v, want %v", got, want)
+	}
+	if s := w.Stats(); s.Lines != 4 || s.Segments != 3 || s.Failed != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+
+	// A window selects the segments that may hold its input.
+	segs, _ = Segments(dir, t0.Add(30*time.Second), t0.Add(90*time.Minute))
+	if got := readAll(t, segs); !reflect.DeepEqual(got, want[:3]) {
+		t.Errorf("read %v from the window, want %v", got, want[:3])
+	}
+}
+
+func TestRecoverOpenSegment(t *testing.T) {
+	dir := t.TempDir()
+	c := &clock{t0}
+	w := openAt(t, Config{Dir: dir}, c)
+	appendLines(t, w, `{"n":1}`)
+	c.t = t0.Add(2 * time.Second) // Flushes.
+	appendLines(t, w, `{"n":2}`)
+	// Crash: the file is left open, with a line after the flush.
+	appendLines(t, w, `{"n":3}`)
+	w.f.Close()
+
+	w = openAt(t, Config{Dir: dir}, &clock{t0.Add(time.Hour)})
+	defer w.Close()
+	segs, err := Segments(dir, time.Time{}, time.Time{})
+	if err != nil || len(segs) != 1 {
+		t.Fatalf("segments = %v, %v; want the recovered one", segs, err)
+	}
+	if got := readAll(t, segs); !reflect.DeepEqual(got, []string{`{"n":1}`, `{"n":2}`}) {
+		t.Errorf("read %v, want the lines up to the flush", got)
+	}
+}
+
+func TestFlushWhenQuiet(t *testing.T) {
+	defer func(d time.Duration) { flushInterval = d }(flushInterval)
+	flushInterval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	w := openAt(t, Config{Dir: dir}, &clock{t0})
+	defer w.Close()
+	appendLines(t, w, `{"n":1}`)
+
+	// Read the open segment as a crash would leave it, until the line is there.
+	open := filepath.Join(dir, t0.Format(timeLayout)+segmentExt+openSuffix)
+	var got string
+	for deadline := time.Now().Add(time.Second); got == "" && time.Now().Before(deadline); {
+		time.Sleep(flushInterval)
+		f, err := os.Open(open)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gz, err := gzip.NewReader(f); err == nil {
+			got, _ = bufio.NewReader(gz).ReadString('\n')
+		}
+		f.Close()
+	}
+	if got != `{"n":1}`+"\n" {
+		t.Errorf("open segment holds %q, want the line flushed without another Append", got)
+	}
+}
+
+func TestReadCutLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "20260301T100000.000000000Z.jsonl.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte("{\"n\":1}\n{\"n\":"))
+	gz.Flush()
+	f.Close()
+	empty := filepath.Join(dir, "20260301T110000.000000000Z.jsonl.gz")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, []string{path, empty}); !reflect.DeepEqual(got, []string{`{"n":1}`}) {
+		t.Errorf("read %v, want the complete line only", got)
+	}
+}
+
+func TestReadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "20260301T100000.000000000Z.jsonl.gz")
+	if err := os.WriteFile(path, []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := io.ReadAll(NewReader([]string{path}))
+	if !errors.Is(err, ErrRead) || !strings.Contains(err.Error(), path) {
+		t.Errorf("err = %v, want ErrRead naming the segment", err)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	c := &clock{t0}
+	w := openAt(t, Config{Dir: dir, SegmentAge: time.Hour, Retention: 2 * time.Hour}, c)
+	for h := range 5 {
+		c.t = t0.Add(time.Duration(h) * time.Hour)
+		appendLines(t, w, `{}`)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// At 14:00 the segments of 10:00 and 11:00 hold nothing after 12:00.
+	segs, _ := Segments(dir, time.Time{}, time.Time{})
+	var names []string
+	for _, s := range segs {
+		names = append(names, filepath.Base(s)[:15])
+	}
+	if want := []string{"20260301T120000", "20260301T130000", "20260301T140000"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("segments = %v, want %v", names, want)
+	}
+}
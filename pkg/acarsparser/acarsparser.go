@@ -0,0 +1,111 @@
+// Package acarsparser is the public Go API of the ACARS parser, for programs
+// that embed the decoder rather than running the command-line tools.
+//
+// Parse runs a message through every parser and returns what they extracted:
+//
+//	msg := &acarsparser.Message{ID: 1, Label: "H1", Text: text}
+//	for _, r := range acarsparser.Parse(msg) {
+//		switch r := r.(type) {
+//		case *acarsparser.PDC:
+//			fmt.Println(r.FlightNumber, r.Runway, r.SID, r.Squawk)
+//		case *acarsparser.ADSC:
+//			...
+//		}
+//	}
+//
+// ParseJSON does the same for a line of feed or decoder output (NATS,
+// acarsdec, dumpvdl2, dumphfdl or flat JSON), as the decode tool reads.
+//
+// Compatibility: the names exported here are kept across releases. The
+// result types alias the parsers' own types, which may gain fields but do not
+// rename or remove them; their JSON field names are stable. Result types not
+// named here are returned by Parse too, and can be handled by their Type.
+package acarsparser
+
+import (
+	"sync"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/input"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/parsers/adsc"
+	"acars_parser/internal/parsers/atis"
+	"acars_parser/internal/parsers/cpdlc"
+	"acars_parser/internal/parsers/eta"
+	"acars_parser/internal/parsers/fuel"
+	"acars_parser/internal/parsers/h1"
+	"acars_parser/internal/parsers/label80"
+	"acars_parser/internal/parsers/loadsheet"
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/parsers/weather"
+	"acars_parser/internal/quality"
+	"acars_parser/internal/registry"
+)
+
+// Message is an ACARS message with its transport metadata.
+type Message = acars.Message
+
+// Airframe, Flight and Station are the metadata a feed may attach to a
+// message.
+type (
+	Airframe = acars.Airframe
+	Flight   = acars.Flight
+	Station  = acars.Station
+)
+
+// Result is a parser result. Type names the kind of result, e.g. "pdc", and
+// MessageID is the ID of the message it was parsed from.
+type Result = registry.Result
+
+// Major result types, returned as pointers.
+type (
+	PDC        = pdc.Result        // Pre-departure clearance ("pdc").
+	CPDLC      = cpdlc.Result      // CPDLC (FANS-1/A) uplink or downlink ("cpdlc").
+	ADSC       = adsc.Result       // ADS-C periodic, event or contract report ("adsc").
+	ATIS       = atis.Result       // D-ATIS broadcast ("atis").
+	Weather    = weather.Result    // METAR, TAF or other weather ("weather").
+	FlightPlan = h1.FPNResult      // FMS flight plan ("flight_plan").
+	Position   = h1.H1PosResult    // H1 position report ("h1_position").
+	Label80    = label80.Result    // Label 80 position report ("position").
+	ETA        = eta.Result        // ETA report ("eta").
+	Loadsheet  = loadsheet.Result  // Final loadsheet ("loadsheet").
+	FuelReport = fuel.ReportResult // Fuel on board at an OOOI event ("fuel_report").
+)
+
+var sortOnce sync.Once
+
+// defaultRegistry returns the registry holding every parser, sorted for
+// dispatch.
+func defaultRegistry() *registry.Registry {
+	reg := registry.Default()
+	sortOnce.Do(reg.Sort)
+	return reg
+}
+
+// Parse runs msg through every parser that accepts it and returns their
+// results, or nil if none matched. Text damaged in transmission (parity
+// errors, doubled characters) is repaired before parsing; msg itself is not
+// modified. Parse is safe for concurrent use.
+func Parse(msg *Message) []Result {
+	if msg == nil {
+		return nil
+	}
+	prepared, _ := quality.Prepare(msg)
+	return defaultRegistry().Dispatch(prepared)
+}
+
+// ParseJSON decodes one line of feed or decoder output and parses the
+// message it holds. msg is nil for frames without an ACARS message, such as
+// HFDL squitters and VDL2 XIDs; the data decoded from the link layer of such
+// frames is returned among the results.
+func ParseJSON(line []byte) (msg *Message, results []Result, err error) {
+	d, err := input.Decode(line)
+	if err != nil {
+		return nil, nil, err
+	}
+	results = append(results, d.Results...)
+	if d.Message != nil {
+		results = append(results, Parse(d.Message)...)
+	}
+	return d.Message, results, nil
+}
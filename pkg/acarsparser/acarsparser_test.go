@@ -0,0 +1,66 @@
+package acarsparser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const pdcText = `.MELOJJQ 301036
+AGM
+AN VH-OFW/MA 511A
+-  /
+PDC 301035
+JST577 A21N YBBN 1120
+CLEARED TO YMML VIA
+SANEG TWO DEP
+ROUTE:SANEG Q35 OSOTI Q35 PKS Q35 DORSU H119 ARBEY DCT
+CLIMB VIA SID TO: 6000
+DEP FREQ: 118.450
+SQUAWK 1007
+XXX EXPECT RUNWAY 01R XXX`
+
+// findPDC returns the first PDC among results.
+func findPDC(results []Result) *PDC {
+	for _, r := range results {
+		if p, ok := r.(*PDC); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+func TestParse(t *testing.T) {
+	results := Parse(&Message{ID: 7, Label: "H1", Text: pdcText})
+	p := findPDC(results)
+	if p == nil {
+		t.Fatalf("Parse() = %v, want a PDC", results)
+	}
+	if p.MessageID() != 7 || p.FlightNumber != "JST577" || p.Destination != "YMML" || p.Squawk != "1007" {
+		t.Errorf("PDC = %+v", p)
+	}
+
+	if got := Parse(&Message{Label: "_d", Text: ""}); len(got) != 0 {
+		t.Errorf("Parse() of an empty message = %v", got)
+	}
+	if got := Parse(nil); got != nil {
+		t.Errorf("Parse(nil) = %v", got)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	line, _ := json.Marshal(map[string]interface{}{"id": 9, "label": "H1", "tail": "VH-OFW", "text": pdcText})
+	msg, results, err := ParseJSON(line)
+	if err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	if msg == nil || msg.Tail != "VH-OFW" {
+		t.Errorf("ParseJSON() message = %+v", msg)
+	}
+	if p := findPDC(results); p == nil || p.MessageID() != 9 {
+		t.Errorf("ParseJSON() results = %v", results)
+	}
+
+	if _, _, err := ParseJSON([]byte(`not json`)); err == nil {
+		t.Error("ParseJSON() of invalid input succeeded")
+	}
+}
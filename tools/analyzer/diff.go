@@ -0,0 +1,485 @@
+// Corpus diff logic for comparing two parser builds over the same messages.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/golden"
+	_ "acars_parser/internal/parsers" // Register all parsers.
+	"acars_parser/internal/registry"
+	"acars_parser/internal/storage"
+)
+
+// unparsedType is the parser type recorded for messages no parser accepted.
+const unparsedType = "unparsed"
+
+// ParseRecord is the parse outcome of one message for one parser build.
+// A snapshot file is a JSONL stream of these records.
+type ParseRecord struct {
+	ID         uint64                 `json:"id"`
+	Label      string                 `json:"label"`
+	ParserType string                 `json:"parser_type"`
+	Parsed     map[string]interface{} `json:"parsed,omitempty"`
+}
+
+// corpusMessage is a message read from ClickHouse with its stored parse output.
+type corpusMessage struct {
+	id         uint64
+	label      string
+	rawText    string
+	parserType string
+	parsedJSON string
+}
+
+// CorpusDiff is the delta between a baseline and the current parser build.
+type CorpusDiff struct {
+	Baseline       string              `json:"baseline"`
+	Compared       int                 `json:"compared"`
+	MissingInBase  int                 `json:"missing_in_baseline"`
+	BaseParsed     int                 `json:"baseline_parsed"`
+	CurrentParsed  int                 `json:"current_parsed"`
+	TypeChanges    []TypeChange        `json:"type_changes"`
+	FieldChanges   []ParserFieldChange `json:"field_changes"`
+	LabelDeltas    []LabelDelta        `json:"label_deltas"`
+	typeChangeIdx  map[string]*TypeChange
+	fieldChangeIdx map[string]*ParserFieldChange
+	labelIdx       map[string]*LabelDelta
+}
+
+// TypeChange counts messages whose parser type moved between builds.
+type TypeChange struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Count     int      `json:"count"`
+	SampleIDs []uint64 `json:"sample_ids"`
+}
+
+// ParserFieldChange counts field differences for messages whose parser type
+// stayed the same.
+type ParserFieldChange struct {
+	ParserType string         `json:"parser_type"`
+	Messages   int            `json:"messages"` // Messages with at least one field difference.
+	Gained     map[string]int `json:"gained,omitempty"`
+	Lost       map[string]int `json:"lost,omitempty"`
+	Changed    map[string]int `json:"changed,omitempty"`
+	SampleIDs  []uint64       `json:"sample_ids"`
+}
+
+// LabelDelta is the parse-rate change for one ACARS label.
+type LabelDelta struct {
+	Label         string  `json:"label"`
+	Total         int     `json:"total"`
+	BaseParsed    int     `json:"baseline_parsed"`
+	CurrentParsed int     `json:"current_parsed"`
+	BaseRate      float64 `json:"baseline_rate"`
+	CurrentRate   float64 `json:"current_rate"`
+	RateDelta     float64 `json:"rate_delta"`
+}
+
+// diffIgnoredFields are excluded from field comparison because they depend on
+// message metadata rather than parser behaviour.
+var diffIgnoredFields = map[string]bool{
+	"raw_text":         true,
+	"parse_confidence": true,
+}
+
+// maxSampleIDs limits how many example message IDs are kept per bucket.
+const maxSampleIDs = 5
+
+func newCorpusDiff(baseline string) *CorpusDiff {
+	return &CorpusDiff{
+		Baseline:       baseline,
+		typeChangeIdx:  make(map[string]*TypeChange),
+		fieldChangeIdx: make(map[string]*ParserFieldChange),
+		labelIdx:       make(map[string]*LabelDelta),
+	}
+}
+
+// Add records the comparison of one message between the two builds.
+func (d *CorpusDiff) Add(base, current ParseRecord) {
+	d.Compared++
+
+	baseType := normaliseParserType(base.ParserType)
+	curType := normaliseParserType(current.ParserType)
+
+	ld := d.labelIdx[current.Label]
+	if ld == nil {
+		ld = &LabelDelta{Label: current.Label}
+		d.labelIdx[current.Label] = ld
+	}
+	ld.Total++
+	if baseType != unparsedType {
+		d.BaseParsed++
+		ld.BaseParsed++
+	}
+	if curType != unparsedType {
+		d.CurrentParsed++
+		ld.CurrentParsed++
+	}
+
+	if baseType != curType {
+		key := baseType + "\x00" + curType
+		tc := d.typeChangeIdx[key]
+		if tc == nil {
+			tc = &TypeChange{From: baseType, To: curType}
+			d.typeChangeIdx[key] = tc
+		}
+		tc.Count++
+		if len(tc.SampleIDs) < maxSampleIDs {
+			tc.SampleIDs = append(tc.SampleIDs, current.ID)
+		}
+		return
+	}
+	if curType == unparsedType {
+		return
+	}
+
+	diffs := golden.Diff(stripIgnored(base.Parsed), stripIgnored(current.Parsed))
+	if len(diffs) == 0 {
+		return
+	}
+
+	fc := d.fieldChangeIdx[curType]
+	if fc == nil {
+		fc = &ParserFieldChange{
+			ParserType: curType,
+			Gained:     make(map[string]int),
+			Lost:       make(map[string]int),
+			Changed:    make(map[string]int),
+		}
+		d.fieldChangeIdx[curType] = fc
+	}
+	fc.Messages++
+	if len(fc.SampleIDs) < maxSampleIDs {
+		fc.SampleIDs = append(fc.SampleIDs, current.ID)
+	}
+
+	// Count each field at most once per message, using the top-level name so
+	// array elements and nested objects roll up into their parent field.
+	seen := make(map[string]bool)
+	for _, fd := range diffs {
+		field := topLevelField(fd.Path)
+		key := string(fd.Kind) + "\x00" + field
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		switch fd.Kind {
+		case golden.DiffExtra:
+			fc.Gained[field]++
+		case golden.DiffMissing:
+			fc.Lost[field]++
+		case golden.DiffChanged:
+			fc.Changed[field]++
+		}
+	}
+}
+
+// Finish flattens the aggregates into sorted slices.
+func (d *CorpusDiff) Finish() {
+	d.TypeChanges = d.TypeChanges[:0]
+	for _, tc := range d.typeChangeIdx {
+		d.TypeChanges = append(d.TypeChanges, *tc)
+	}
+	sort.Slice(d.TypeChanges, func(i, j int) bool {
+		a, b := d.TypeChanges[i], d.TypeChanges[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	d.FieldChanges = d.FieldChanges[:0]
+	for _, fc := range d.fieldChangeIdx {
+		d.FieldChanges = append(d.FieldChanges, *fc)
+	}
+	sort.Slice(d.FieldChanges, func(i, j int) bool {
+		a, b := d.FieldChanges[i], d.FieldChanges[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+		return a.ParserType < b.ParserType
+	})
+
+	d.LabelDeltas = d.LabelDeltas[:0]
+	for _, ld := range d.labelIdx {
+		if ld.Total > 0 {
+			ld.BaseRate = float64(ld.BaseParsed) / float64(ld.Total) * 100
+			ld.CurrentRate = float64(ld.CurrentParsed) / float64(ld.Total) * 100
+		}
+		ld.RateDelta = ld.CurrentRate - ld.BaseRate
+		d.LabelDeltas = append(d.LabelDeltas, *ld)
+	}
+	sort.Slice(d.LabelDeltas, func(i, j int) bool {
+		a, b := d.LabelDeltas[i], d.LabelDeltas[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Label < b.Label
+	})
+}
+
+// HasChanges reports whether the two builds differ on any compared message.
+func (d *CorpusDiff) HasChanges() bool {
+	return len(d.typeChangeIdx) > 0 || len(d.fieldChangeIdx) > 0
+}
+
+func normaliseParserType(t string) string {
+	if t == "" {
+		return unparsedType
+	}
+	return t
+}
+
+func topLevelField(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+func stripIgnored(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if golden.IgnoredFields[k] || diffIgnoredFields[k] {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// parseWithRegistry parses a corpus message with the current registry. The
+// first result is used, matching the parser type stored at ingest time.
+func parseWithRegistry(reg *registry.Registry, m corpusMessage) ParseRecord {
+	rec := ParseRecord{ID: m.id, Label: m.label, ParserType: unparsedType}
+
+	results := reg.Dispatch(&acars.Message{
+		ID:    acars.FlexInt64(m.id),
+		Label: m.label,
+		Text:  m.rawText,
+	})
+	if len(results) == 0 {
+		return rec
+	}
+
+	rec.ParserType = results[0].Type()
+	if b, err := json.Marshal(results[0]); err == nil {
+		_ = json.Unmarshal(b, &rec.Parsed)
+	}
+	return rec
+}
+
+// storedRecord builds a baseline record from the parse output stored in ClickHouse.
+func storedRecord(m corpusMessage) ParseRecord {
+	rec := ParseRecord{ID: m.id, Label: m.label, ParserType: normaliseParserType(m.parserType)}
+	if m.parsedJSON != "" {
+		_ = json.Unmarshal([]byte(m.parsedJSON), &rec.Parsed)
+	}
+	return rec
+}
+
+// forEachCorpusMessage streams messages from ClickHouse in ID order.
+func forEachCorpusMessage(ctx context.Context, ch *storage.ClickHouseDB, label string, limit int, fn func(corpusMessage) error) error {
+	query := `SELECT id, label, raw_text, parser_type, parsed_json FROM messages`
+	var args []interface{}
+	if label != "" {
+		query += ` WHERE label = ?`
+		args = append(args, label)
+	}
+	query += ` ORDER BY id`
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+
+	rows, err := ch.Conn().Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m corpusMessage
+		if err := rows.Scan(&m.id, &m.label, &m.rawText, &m.parserType, &m.parsedJSON); err != nil {
+			return fmt.Errorf("scan message: %w", err)
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// WriteSnapshot parses the corpus with the current registry and writes one
+// ParseRecord per line. Run it on the baseline build, then compare a later
+// build against the file with -diff -baseline FILE.
+func WriteSnapshot(ctx context.Context, ch *storage.ClickHouseDB, w io.Writer, label string, limit int) (int, error) {
+	reg := registry.Default()
+	reg.Sort()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	count := 0
+	err := forEachCorpusMessage(ctx, ch, label, limit, func(m corpusMessage) error {
+		count++
+		return enc.Encode(parseWithRegistry(reg, m))
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+// loadSnapshot reads a snapshot file into a map keyed by message ID.
+func loadSnapshot(path string) (map[uint64]ParseRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	records := make(map[uint64]ParseRecord)
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var rec ParseRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		records[rec.ID] = rec
+	}
+	return records, nil
+}
+
+// DiffCorpus parses the corpus with the current registry and compares it with
+// the baseline. The baseline is a snapshot file when snapshotPath is set,
+// otherwise the parser_type and parsed_json stored in ClickHouse.
+func DiffCorpus(ctx context.Context, ch *storage.ClickHouseDB, snapshotPath, label string, limit int) (*CorpusDiff, error) {
+	var snapshot map[uint64]ParseRecord
+	baseline := "stored parsed_json"
+	if snapshotPath != "" {
+		var err error
+		snapshot, err = loadSnapshot(snapshotPath)
+		if err != nil {
+			return nil, err
+		}
+		baseline = snapshotPath
+	}
+
+	reg := registry.Default()
+	reg.Sort()
+
+	diff := newCorpusDiff(baseline)
+	err := forEachCorpusMessage(ctx, ch, label, limit, func(m corpusMessage) error {
+		base := storedRecord(m)
+		if snapshot != nil {
+			rec, ok := snapshot[m.id]
+			if !ok {
+				diff.MissingInBase++
+				return nil
+			}
+			base = rec
+		}
+		diff.Add(base, parseWithRegistry(reg, m))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	diff.Finish()
+	return diff, nil
+}
+
+// PrintDiff outputs a corpus diff in a readable format.
+func PrintDiff(d *CorpusDiff, topN int) {
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println("                    CORPUS DIFF")
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println()
+
+	fmt.Printf("Baseline:           %s\n", d.Baseline)
+	fmt.Printf("Compared:           %d\n", d.Compared)
+	if d.MissingInBase > 0 {
+		fmt.Printf("Not in baseline:    %d (skipped)\n", d.MissingInBase)
+	}
+	fmt.Printf("Parsed (baseline):  %d (%.1f%%)\n", d.BaseParsed, pct(d.BaseParsed, d.Compared))
+	fmt.Printf("Parsed (current):   %d (%.1f%%)\n", d.CurrentParsed, pct(d.CurrentParsed, d.Compared))
+	fmt.Println()
+
+	fmt.Println("PARSER TYPE CHANGES")
+	fmt.Println("───────────────────")
+	if len(d.TypeChanges) == 0 {
+		fmt.Println("(none)")
+	}
+	for i, tc := range d.TypeChanges {
+		if i >= topN {
+			fmt.Printf("... %d more\n", len(d.TypeChanges)-topN)
+			break
+		}
+		fmt.Printf("%-20s -> %-20s %8d  e.g. %v\n", tc.From, tc.To, tc.Count, tc.SampleIDs)
+	}
+	fmt.Println()
+
+	fmt.Println("FIELD CHANGES (Same parser type)")
+	fmt.Println("─────────────")
+	if len(d.FieldChanges) == 0 {
+		fmt.Println("(none)")
+	}
+	for _, fc := range d.FieldChanges {
+		fmt.Printf("\n%s: %d messages differ, e.g. %v\n", fc.ParserType, fc.Messages, fc.SampleIDs)
+		printFieldCounts("+", fc.Gained)
+		printFieldCounts("-", fc.Lost)
+		printFieldCounts("~", fc.Changed)
+	}
+	fmt.Println()
+
+	fmt.Println("PARSE RATE BY LABEL")
+	fmt.Println("───────────────────")
+	fmt.Printf("%-10s %8s %9s %9s %8s\n", "Label", "Total", "Baseline", "Current", "Delta")
+	for _, ld := range d.LabelDeltas {
+		if ld.BaseParsed == ld.CurrentParsed {
+			continue
+		}
+		label := ld.Label
+		if label == "" {
+			label = "(empty)"
+		}
+		fmt.Printf("%-10s %8d %8.1f%% %8.1f%% %+7.1f%%\n", label, ld.Total, ld.BaseRate, ld.CurrentRate, ld.RateDelta)
+	}
+}
+
+func printFieldCounts(marker string, counts map[string]int) {
+	fields := make([]string, 0, len(counts))
+	for f := range counts {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if counts[fields[i]] != counts[fields[j]] {
+			return counts[fields[i]] > counts[fields[j]]
+		}
+		return fields[i] < fields[j]
+	})
+	for _, f := range fields {
+		fmt.Printf("  %s %-24s %8d\n", marker, f, counts[f])
+	}
+}
+
+func pct(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestCorpusDiff(t *testing.T) {
+	d := newCorpusDiff("test")
+
+	// Unchanged message.
+	d.Add(
+		ParseRecord{ID: 1, Label: "H1", ParserType: "pdc", Parsed: map[string]interface{}{"flight": "QF1"}},
+		ParseRecord{ID: 1, Label: "H1", ParserType: "pdc", Parsed: map[string]interface{}{"flight": "QF1"}},
+	)
+	// Newly parsed message.
+	d.Add(
+		ParseRecord{ID: 2, Label: "H1", ParserType: ""},
+		ParseRecord{ID: 2, Label: "H1", ParserType: "pdc", Parsed: map[string]interface{}{"flight": "QF2"}},
+	)
+	// Same type with a gained, lost and changed field.
+	d.Add(
+		ParseRecord{ID: 3, Label: "5Z", ParserType: "atis", Parsed: map[string]interface{}{
+			"letter": "A", "qnh": "1013", "message_id": float64(3),
+		}},
+		ParseRecord{ID: 3, Label: "5Z", ParserType: "atis", Parsed: map[string]interface{}{
+			"letter": "B", "runways": []interface{}{"16L"}, "message_id": float64(99),
+		}},
+	)
+	d.Finish()
+
+	if !d.HasChanges() {
+		t.Fatal("expected changes")
+	}
+	if d.Compared != 3 || d.BaseParsed != 2 || d.CurrentParsed != 3 {
+		t.Errorf("counts = %d/%d/%d, want 3/2/3", d.Compared, d.BaseParsed, d.CurrentParsed)
+	}
+
+	if len(d.TypeChanges) != 1 {
+		t.Fatalf("type changes = %+v, want 1", d.TypeChanges)
+	}
+	if tc := d.TypeChanges[0]; tc.From != "unparsed" || tc.To != "pdc" || tc.Count != 1 {
+		t.Errorf("type change = %+v", tc)
+	}
+
+	if len(d.FieldChanges) != 1 {
+		t.Fatalf("field changes = %+v, want 1", d.FieldChanges)
+	}
+	fc := d.FieldChanges[0]
+	if fc.Gained["runways"] != 1 || fc.Lost["qnh"] != 1 || fc.Changed["letter"] != 1 {
+		t.Errorf("field change = %+v", fc)
+	}
+	if _, ok := fc.Changed["message_id"]; ok {
+		t.Error("message_id should be ignored")
+	}
+
+	for _, ld := range d.LabelDeltas {
+		if ld.Label == "H1" && (ld.BaseRate != 50 || ld.CurrentRate != 100 || ld.RateDelta != 50) {
+			t.Errorf("H1 delta = %+v", ld)
+		}
+	}
+}
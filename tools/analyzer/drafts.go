@@ -0,0 +1,448 @@
+// Draft patterns: suggestions saved for testing and promotion to a format.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/patterns"
+	"acars_parser/internal/storage"
+)
+
+// Draft is a candidate format pattern saved from a suggestion. Drafts are kept
+// one per YAML file in the drafts directory, so they can be edited by hand
+// between testing and promotion.
+type Draft struct {
+	Name        string         `yaml:"name" json:"name"`
+	Label       string         `yaml:"label" json:"label"`
+	Pattern     string         `yaml:"pattern" json:"pattern"` // Pattern with {PLACEHOLDER} syntax
+	Fields      []string       `yaml:"fields" json:"fields"`   // Named groups in capture order
+	Template    string         `yaml:"template" json:"template"`
+	ClusterSize int            `yaml:"cluster_size" json:"cluster_size"`
+	Examples    []DraftExample `yaml:"examples" json:"examples"`
+	Created     time.Time      `yaml:"created" json:"created"`
+	Test        *DraftTest     `yaml:"test,omitempty" json:"test,omitempty"`
+}
+
+// DraftExample is a message from the cluster a draft was saved from.
+type DraftExample struct {
+	ID   uint64 `yaml:"id" json:"id"`
+	Text string `yaml:"text" json:"text"`
+}
+
+// DraftTest records the last test of a draft against the corpus.
+type DraftTest struct {
+	Tested           time.Time      `yaml:"tested" json:"tested"`
+	Matches          int            `yaml:"matches" json:"matches"`
+	Total            int            `yaml:"total" json:"total"`
+	FieldCounts      map[string]int `yaml:"field_counts" json:"field_counts"` // Matches capturing each field
+	SampleMatches    []uint64       `yaml:"sample_matches,omitempty" json:"sample_matches,omitempty"`
+	SampleNonMatches []uint64       `yaml:"sample_non_matches,omitempty" json:"sample_non_matches,omitempty"`
+}
+
+// draftNameRe matches a valid draft name, which is also the format name.
+var draftNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// tokenClass is how a template token becomes part of a pattern: a
+// placeholder captured under the first unused name, or, when names is empty,
+// an uncaptured regex.
+type tokenClass struct {
+	pattern string
+	names   []string
+}
+
+// tokenClasses maps the template tokens (see internal/templates) to patterns.
+var tokenClasses = map[string]tokenClass{
+	"<ICAO>":   {`{ICAO}`, []string{"origin", "destination"}},
+	"<FLIGHT>": {`{FLIGHT}`, []string{"flight"}},
+	"<TIME>":   {`{TIME4}`, []string{"time"}},
+	"<SQWK>":   {`{SQUAWK}`, []string{"squawk"}},
+	"<FREQ>":   {`{FREQ}`, []string{"freq"}},
+	"<FL>":     {`FL{FL}`, []string{"flight_level"}},
+	"<RWY>":    {`{RUNWAY}`, []string{"runway"}},
+	"<TAIL>":   {`{TAIL}`, []string{"tail"}},
+	"<ACFT>":   {`{AIRCRAFT}`, []string{"aircraft"}},
+	"<WPT5>":   {`{WAYPOINT}`, []string{"waypoint"}},
+	"<NUM>":    {`\d+`, nil},
+	"<CODE>":   {`[A-Z]{3,4}`, nil},
+	"<ALNUM>":  {`[A-Z0-9]+`, nil},
+	"<OTHER>":  {`\S+`, nil},
+}
+
+// cueClasses override a token's class when it follows a keyword, as in
+// "TO <ICAO>" or "MAINTAIN <NUM>".
+var cueClasses = map[string]map[string]tokenClass{
+	"<ICAO>": {
+		"FROM": {`{ICAO}`, []string{"origin"}},
+		"DEP":  {`{ICAO}`, []string{"origin"}},
+		"TO":   {`{ICAO}`, []string{"destination"}},
+		"DEST": {`{ICAO}`, []string{"destination"}},
+	},
+	"<TIME>": {
+		"DEP": {`{TIME4}`, []string{"dep_time"}},
+		"ETD": {`{TIME4}`, []string{"dep_time"}},
+		"ETA": {`{TIME4}`, []string{"eta"}},
+	},
+	"<NUM>": {
+		"ALT":      {`{ALTITUDE}`, []string{"altitude"}},
+		"CLIMB":    {`{ALTITUDE}`, []string{"altitude"}},
+		"MAINTAIN": {`{ALTITUDE}`, []string{"altitude"}},
+	},
+}
+
+// grokFromTemplate builds a grok-style pattern from a message template,
+// capturing the token classes that carry data under field names inferred from
+// the class and the keyword before it. Literals are matched as they are, and
+// tokens and lines are separated by any whitespace. It returns the pattern and
+// its named groups in order.
+func grokFromTemplate(template string) (string, []string) {
+	var parts, fields []string
+	used := make(map[string]bool)
+	prev := ""
+
+	for _, tok := range strings.Fields(template) {
+		if tok == "|" {
+			continue
+		}
+		class, ok := tokenClasses[tok]
+		if !ok {
+			parts = append(parts, regexp.QuoteMeta(tok))
+			prev = tok
+			continue
+		}
+		if cue, ok := cueClasses[tok][prev]; ok {
+			class = cue
+		}
+		prev = tok
+
+		if len(class.names) == 0 {
+			parts = append(parts, class.pattern)
+			continue
+		}
+		name := fieldName(class.names, used)
+		used[name] = true
+		fields = append(fields, name)
+		if prefix, ph, ok := strings.Cut(class.pattern, "{"); ok && prefix != "" {
+			// A literal prefix such as FL stays outside the group.
+			parts = append(parts, fmt.Sprintf("%s(?P<%s>{%s)", prefix, name, ph))
+		} else {
+			parts = append(parts, fmt.Sprintf("(?P<%s>%s)", name, class.pattern))
+		}
+	}
+	return strings.Join(parts, `\s+`), fields
+}
+
+// fieldName returns the first of names not yet used, or else the first name
+// with the lowest free numeric suffix from 2.
+func fieldName(names []string, used map[string]bool) string {
+	for _, n := range names {
+		if !used[n] {
+			return n
+		}
+	}
+	for i := 2; ; i++ {
+		if n := names[0] + strconv.Itoa(i); !used[n] {
+			return n
+		}
+	}
+}
+
+// newDraft makes a draft from a suggestion.
+func newDraft(name string, s PatternSuggestion, now time.Time) (*Draft, error) {
+	if !draftNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid draft name %q: use lower case letters, digits and underscores", name)
+	}
+	pattern, fields := grokFromTemplate(s.TemplatePattern)
+	d := &Draft{
+		Name:        name,
+		Label:       s.Label,
+		Pattern:     pattern,
+		Fields:      fields,
+		Template:    s.TemplatePattern,
+		ClusterSize: s.MessageCount,
+		Created:     now.UTC(),
+	}
+	for i, text := range s.Examples {
+		ex := DraftExample{Text: text}
+		if i < len(s.ExampleIDs) {
+			ex.ID = s.ExampleIDs[i]
+		}
+		d.Examples = append(d.Examples, ex)
+	}
+	return d, nil
+}
+
+// SaveSuggestionDraft saves the suggestion for a cluster as a draft.
+func SaveSuggestionDraft(dir, name string, cluster int, suggestions []PatternSuggestion) (string, error) {
+	for _, s := range suggestions {
+		if s.ClusterID != cluster {
+			continue
+		}
+		d, err := newDraft(name, s, time.Now())
+		if err != nil {
+			return "", err
+		}
+		return SaveDraft(dir, d)
+	}
+	return "", fmt.Errorf("no cluster %d among the %d suggestions", cluster, len(suggestions))
+}
+
+// draftPath returns the file a draft is kept in.
+func draftPath(dir, name string) string {
+	return filepath.Join(dir, name+".yaml")
+}
+
+// SaveDraft writes a draft to the drafts directory, creating the directory if
+// needed, and returns the file written.
+func SaveDraft(dir string, d *Draft) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	path := draftPath(dir, d.Name)
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// LoadDraft reads a draft from the drafts directory.
+func LoadDraft(dir, name string) (*Draft, error) {
+	if !draftNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid draft name %q", name)
+	}
+	data, err := os.ReadFile(draftPath(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	var d Draft
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("%s: %w", draftPath(dir, name), err)
+	}
+	return &d, nil
+}
+
+// ListDrafts reads every draft in the drafts directory, in name order. A
+// missing directory has no drafts.
+func ListDrafts(dir string) ([]*Draft, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var drafts []*Draft
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if !ok || e.IsDir() {
+			continue
+		}
+		d, err := LoadDraft(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	return drafts, nil
+}
+
+// compileDraft expands a draft's placeholders with the shared base patterns.
+func compileDraft(d *Draft) (*patterns.Compiler, error) {
+	c := patterns.NewCompiler([]patterns.Format{{Name: d.Name, Pattern: d.Pattern, Fields: d.Fields}}, nil)
+	if err := c.Compile(); err != nil {
+		return nil, fmt.Errorf("draft %s: %w", d.Name, err)
+	}
+	return c, nil
+}
+
+// TestDraft matches a draft against up to limit messages with its label
+// (2000 when limit is 0), as the parsers do on upper-cased text, and records
+// the result in the draft.
+func TestDraft(ctx context.Context, ch *storage.ClickHouseDB, d *Draft, limit int) error {
+	c, err := compileDraft(d)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		limit = testSampleSize
+	}
+	t := &DraftTest{Tested: time.Now().UTC(), FieldCounts: make(map[string]int)}
+	err = scanLabel(ctx, ch, d.Label, limit, func(id uint64, text string) {
+		t.Total++
+		m := c.Parse(text)
+		if m == nil {
+			if len(t.SampleNonMatches) < 5 {
+				t.SampleNonMatches = append(t.SampleNonMatches, id)
+			}
+			return
+		}
+		t.Matches++
+		if len(t.SampleMatches) < 5 {
+			t.SampleMatches = append(t.SampleMatches, id)
+		}
+		for name, v := range m.Captures {
+			if v != "" {
+				t.FieldCounts[name]++
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	d.Test = t
+	return nil
+}
+
+// PrintDrafts writes one line per draft.
+func PrintDrafts(drafts []*Draft) {
+	if len(drafts) == 0 {
+		fmt.Println("No drafts.")
+		return
+	}
+	for _, d := range drafts {
+		result := "untested"
+		if d.Test != nil {
+			result = fmt.Sprintf("%d/%d match (%.1f%%)", d.Test.Matches, d.Test.Total, d.Test.rate())
+		}
+		fmt.Printf("%-24s %-4s %5d messages  %-26s %s\n", d.Name, d.Label, d.ClusterSize, result, strings.Join(d.Fields, ", "))
+	}
+}
+
+// PrintDraftTest writes a draft's test result.
+func PrintDraftTest(d *Draft) {
+	fmt.Printf("Draft: %s (label %s)\n", d.Name, d.Label)
+	fmt.Printf("Pattern: %s\n", d.Pattern)
+	if d.Test == nil {
+		fmt.Println("Not tested.")
+		return
+	}
+	t := d.Test
+	fmt.Printf("Result: %d/%d match (%.1f%%)\n\n", t.Matches, t.Total, t.rate())
+	if len(d.Fields) > 0 {
+		fmt.Println("Fields captured:")
+		for _, f := range d.Fields {
+			fmt.Printf("  %-16s %d\n", f, t.FieldCounts[f])
+		}
+		fmt.Println()
+	}
+	if len(t.SampleMatches) > 0 {
+		fmt.Printf("Sample matches: %v\n", t.SampleMatches)
+	}
+	if len(t.SampleNonMatches) > 0 {
+		fmt.Printf("Sample non-matches: %v\n", t.SampleNonMatches)
+	}
+}
+
+func (t *DraftTest) rate() float64 {
+	if t.Total == 0 {
+		return 0
+	}
+	return float64(t.Matches) / float64(t.Total) * 100
+}
+
+// EmitGo writes a draft as a patterns.Format literal for a parser's grok.go
+// Formats list.
+func EmitGo(w io.Writer, d *Draft) error {
+	fmt.Fprintf(w, "\t// %s format (label %s), from a cluster of %d messages.\n", humanName(d.Name), d.Label, d.ClusterSize)
+	if len(d.Examples) > 0 {
+		fmt.Fprintf(w, "\t// Example: %s\n", strings.Join(strings.Fields(d.Examples[0].Text), " "))
+	}
+	if len(d.Fields) > 0 {
+		fmt.Fprintf(w, "\t// Groups: %s\n", strings.Join(d.Fields, ", "))
+	}
+	if d.Test != nil {
+		fmt.Fprintf(w, "\t// Matched %d of %d %s messages.\n", d.Test.Matches, d.Test.Total, d.Label)
+	}
+	fmt.Fprintf(w, "\t{\n")
+	fmt.Fprintf(w, "\t\tName:    %q,\n", d.Name)
+	fmt.Fprintf(w, "\t\tPattern: %s,\n", goPatternLiteral(d.Pattern))
+	quoted := make([]string, len(d.Fields))
+	for i, f := range d.Fields {
+		quoted[i] = strconv.Quote(f)
+	}
+	_, err := fmt.Fprintf(w, "\t\tFields:  []string{%s},\n\t},\n", strings.Join(quoted, ", "))
+	return err
+}
+
+// goPatternLiteral writes a pattern as a raw string, split into concatenated
+// lines at token boundaries when it is long.
+func goPatternLiteral(pattern string) string {
+	if strings.Contains(pattern, "`") {
+		return strconv.Quote(pattern)
+	}
+	const width = 72
+	var lines []string
+	var line strings.Builder
+	for i, part := range strings.Split(pattern, `\s+`) {
+		if i > 0 {
+			line.WriteString(`\s+`)
+		}
+		if line.Len() > 0 && line.Len()+len(part) > width {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		line.WriteString(part)
+	}
+	lines = append(lines, line.String())
+	return "`" + strings.Join(lines, "` +\n\t\t\t`") + "`"
+}
+
+// humanName turns a format name such as acme_pdc into "Acme pdc".
+func humanName(name string) string {
+	s := strings.ReplaceAll(name, "_", " ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// pdcFormat converts a draft to a PDC format definition, expanding the
+// placeholders the PDC compiler does not define with the shared base patterns.
+func pdcFormat(d *Draft) pdc.PDCFormat {
+	pattern := regexp.MustCompile(`\{([A-Z][A-Z0-9_]*)\}`).ReplaceAllStringFunc(d.Pattern, func(ph string) string {
+		name := ph[1 : len(ph)-1]
+		if _, ok := pdc.BasePatterns[name]; ok {
+			return ph
+		}
+		if re, ok := patterns.BasePatterns[name]; ok {
+			return re
+		}
+		return ph
+	})
+	return pdc.PDCFormat{Name: d.Name, Pattern: pattern, Fields: d.Fields, Priority: 1}
+}
+
+// EmitYAML writes a draft as an entry for a PDC format file (see
+// pdc.ReadFormats). It returns the error the PDC compiler reports for the
+// format, if any, after writing it: the format still needs editing before the
+// parser will load it.
+func EmitYAML(w io.Writer, d *Draft) error {
+	f := pdcFormat(d)
+	data, err := yaml.Marshal([]pdc.PDCFormat{f})
+	if err != nil {
+		return err
+	}
+	var header []string
+	header = append(header, fmt.Sprintf("# %s: label %s, from a cluster of %d messages.", d.Name, d.Label, d.ClusterSize))
+	if d.Test != nil {
+		header = append(header, fmt.Sprintf("# Matched %d of %d %s messages.", d.Test.Matches, d.Test.Total, d.Label))
+	}
+	if _, err := fmt.Fprintf(w, "%s\n%s", strings.Join(header, "\n"), data); err != nil {
+		return err
+	}
+
+	c := pdc.NewCompiler()
+	if err := c.Compile(); err != nil {
+		return err
+	}
+	return c.AddFormats(f)
+}
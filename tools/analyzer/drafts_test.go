@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/templates"
+)
+
+const pdcExample = "PDC 001 QFA9 YPPH\nCLRD TO EGLL OFF RWY 21 SQUAWK 4521 FL350 DEP 2145"
+
+func TestGrokFromTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		pattern  string
+		fields   []string
+	}{
+		{
+			template: "<FLIGHT> <ICAO> <ICAO> <ICAO>",
+			pattern:  `(?P<flight>{FLIGHT})\s+(?P<origin>{ICAO})\s+(?P<destination>{ICAO})\s+(?P<origin2>{ICAO})`,
+			fields:   []string{"flight", "origin", "destination", "origin2"},
+		},
+		{
+			template: "CLRD TO <ICAO> | FROM <ICAO>",
+			pattern:  `CLRD\s+TO\s+(?P<destination>{ICAO})\s+FROM\s+(?P<origin>{ICAO})`,
+			fields:   []string{"destination", "origin"},
+		},
+		{
+			template: "RWY <RWY> OR <RWY> <FL> MAINTAIN <NUM> <OTHER> 1.5",
+			pattern:  `RWY\s+(?P<runway>{RUNWAY})\s+OR\s+(?P<runway2>{RUNWAY})\s+FL(?P<flight_level>{FL})\s+MAINTAIN\s+(?P<altitude>{ALTITUDE})\s+\S+\s+1\.5`,
+			fields:   []string{"runway", "runway2", "flight_level", "altitude"},
+		},
+	}
+	for _, tt := range tests {
+		pattern, fields := grokFromTemplate(tt.template)
+		if pattern != tt.pattern {
+			t.Errorf("%q: pattern\n got %s\nwant %s", tt.template, pattern, tt.pattern)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%q: fields = %v, want %v", tt.template, fields, tt.fields)
+		}
+	}
+}
+
+func testDraft(t *testing.T) *Draft {
+	t.Helper()
+	d, err := newDraft("acme_pdc", PatternSuggestion{
+		ClusterID:       1,
+		MessageCount:    42,
+		Label:           "H1",
+		TemplatePattern: templates.Normalise(pdcExample),
+		Examples:        []string{pdcExample},
+		ExampleIDs:      []uint64{7},
+	}, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDraftMatchesItsCluster(t *testing.T) {
+	d := testDraft(t)
+	want := []string{"flight", "origin", "destination", "runway", "squawk", "flight_level", "dep_time"}
+	if !reflect.DeepEqual(d.Fields, want) {
+		t.Errorf("fields = %v, want %v", d.Fields, want)
+	}
+
+	c, err := compileDraft(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := c.Parse(pdcExample)
+	if m == nil {
+		t.Fatalf("pattern %s does not match its example", d.Pattern)
+	}
+	for field, value := range map[string]string{"flight": "QFA9", "destination": "EGLL", "runway": "21", "flight_level": "350", "dep_time": "2145"} {
+		if got := m.Captures[field]; got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+
+	if _, err := newDraft("Bad Name", PatternSuggestion{}, time.Now()); err == nil {
+		t.Error("invalid name accepted")
+	}
+}
+
+func TestDraftSaveLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "drafts")
+	d := testDraft(t)
+	d.Test = &DraftTest{Tested: d.Created, Matches: 40, Total: 50, FieldCounts: map[string]int{"flight": 40}}
+
+	path, err := SaveDraft(dir, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "acme_pdc.yaml") {
+		t.Errorf("path = %s", path)
+	}
+	got, err := LoadDraft(dir, "acme_pdc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Errorf("loaded %+v, want %+v", got, d)
+	}
+
+	drafts, err := ListDrafts(dir)
+	if err != nil || len(drafts) != 1 || drafts[0].Name != "acme_pdc" {
+		t.Errorf("ListDrafts = %v, %v", drafts, err)
+	}
+	if drafts, err := ListDrafts(filepath.Join(dir, "missing")); err != nil || drafts != nil {
+		t.Errorf("missing directory: %v, %v", drafts, err)
+	}
+
+	if _, err := SaveSuggestionDraft(dir, "other", 3, []PatternSuggestion{{ClusterID: 1}}); err == nil {
+		t.Error("saved a cluster that does not exist")
+	}
+}
+
+func TestEmitGo(t *testing.T) {
+	d := testDraft(t)
+	d.Test = &DraftTest{Matches: 40, Total: 50}
+	var b strings.Builder
+	if err := EmitGo(&b, d); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"// Acme pdc format (label H1), from a cluster of 42 messages.",
+		"// Example: PDC 001 QFA9 YPPH CLRD TO EGLL",
+		"// Matched 40 of 50 H1 messages.",
+		`Name:    "acme_pdc",`,
+		"` +\n\t\t\t`",
+		`Fields:  []string{"flight", "origin", "destination", "runway", "squawk", "flight_level", "dep_time"},`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestEmitYAML(t *testing.T) {
+	d := testDraft(t)
+	var b strings.Builder
+	if err := EmitYAML(&b, d); err != nil {
+		t.Fatalf("PDC compiler rejected the format: %v\n%s", err, b.String())
+	}
+
+	// The output is a format file the PDC parser can read.
+	path := filepath.Join(t.TempDir(), "acme.yaml")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	formats, err := pdc.ReadFormats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(formats) != 1 || formats[0].Name != "acme_pdc" || formats[0].Priority != 1 {
+		t.Fatalf("formats = %+v", formats)
+	}
+	if strings.Contains(formats[0].Pattern, "{FL}") {
+		t.Errorf("placeholder the PDC compiler lacks was not expanded: %s", formats[0].Pattern)
+	}
+
+	// Fields the PDC parser does not read are reported.
+	d.Fields = append(d.Fields, "tail")
+	d.Pattern += `\s+(?P<tail>{TAIL})`
+	if err := EmitYAML(&strings.Builder{}, d); err == nil || !strings.Contains(err.Error(), "tail") {
+		t.Errorf("err = %v, want an unknown capture group", err)
+	}
+}
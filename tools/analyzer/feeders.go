@@ -0,0 +1,101 @@
+// Per-site report for merged multi-site feeds.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+// FeederReport is the traffic and health of each feeder of a merged feed.
+type FeederReport struct {
+	Latest  time.Time     `json:"latest"` // Newest message from any feeder.
+	Feeders []FeederUsage `json:"feeders"`
+}
+
+// FeederUsage is one feeder's contribution and health. Quiet is how long
+// before the newest message of any feeder its own latest message was: a
+// healthy site stays close to zero.
+type FeederUsage struct {
+	Feeder       string    `json:"feeder"`
+	Messages     uint64    `json:"messages"`
+	Exclusive    uint64    `json:"exclusive"`
+	ExclusivePct float64   `json:"exclusive_percentage"`
+	Stations     uint64    `json:"stations"`
+	Days         uint64    `json:"days"`
+	PerDay       float64   `json:"messages_per_day"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	Quiet        string    `json:"quiet"`
+}
+
+// AnalyzeFeeders reads the feeder traffic from ClickHouse and summarises it.
+// See SummariseFeeders.
+func AnalyzeFeeders(ctx context.Context, ch *storage.ClickHouseDB) (*FeederReport, error) {
+	stats, err := ch.FeederCounts(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("feeder counts: %w", err)
+	}
+	return SummariseFeeders(stats), nil
+}
+
+// SummariseFeeders derives each feeder's exclusive share, daily rate and
+// quiet time, keeping the busiest-first order of stats.
+func SummariseFeeders(stats []storage.FeederStats) *FeederReport {
+	r := &FeederReport{}
+	for _, s := range stats {
+		if s.LastSeen.After(r.Latest) {
+			r.Latest = s.LastSeen
+		}
+	}
+	for _, s := range stats {
+		u := FeederUsage{
+			Feeder:    s.Feeder,
+			Messages:  s.Messages,
+			Exclusive: s.Exclusive,
+			Stations:  s.Stations,
+			Days:      s.Days,
+			FirstSeen: s.FirstSeen,
+			LastSeen:  s.LastSeen,
+			Quiet:     r.Latest.Sub(s.LastSeen).Round(time.Second).String(),
+		}
+		if s.Messages > 0 {
+			u.ExclusivePct = float64(s.Exclusive) / float64(s.Messages) * 100
+		}
+		if s.Days > 0 {
+			u.PerDay = float64(s.Messages) / float64(s.Days)
+		}
+		r.Feeders = append(r.Feeders, u)
+	}
+	return r
+}
+
+// PrintFeeders writes the feeder report as text.
+func PrintFeeders(r *FeederReport, topN int) {
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println("                    FEEDERS")
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println()
+
+	if len(r.Feeders) == 0 {
+		fmt.Println("(none - no messages with a feeder)")
+		fmt.Println()
+		return
+	}
+	fmt.Printf("Latest message:     %s\n", r.Latest.UTC().Format(time.RFC3339))
+	fmt.Println()
+
+	fmt.Printf("%-20s %10s %10s %7s %8s %6s %10s %10s\n",
+		"Feeder", "Messages", "Exclusive", "Pct", "Stations", "Days", "Per day", "Quiet")
+	for i, f := range r.Feeders {
+		if i >= topN {
+			fmt.Printf("... %d more\n", len(r.Feeders)-topN)
+			break
+		}
+		fmt.Printf("%-20s %10d %10d %6.1f%% %8d %6d %10.1f %10s\n",
+			f.Feeder, f.Messages, f.Exclusive, f.ExclusivePct, f.Stations, f.Days, f.PerDay, f.Quiet)
+	}
+	fmt.Println()
+}
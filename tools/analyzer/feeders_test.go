@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestSummariseFeeders(t *testing.T) {
+	latest := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	r := SummariseFeeders([]storage.FeederStats{
+		{Feeder: "site-a", Messages: 300, Exclusive: 120, Stations: 2, Days: 3, LastSeen: latest},
+		{Feeder: "site-b", Messages: 100, Exclusive: 0, Stations: 1, Days: 2, LastSeen: latest.Add(-90 * time.Minute)},
+	})
+
+	if !r.Latest.Equal(latest) || len(r.Feeders) != 2 {
+		t.Fatalf("report = %+v", r)
+	}
+	a, b := r.Feeders[0], r.Feeders[1]
+	if a.Feeder != "site-a" || a.ExclusivePct != 40 || a.PerDay != 100 || a.Quiet != "0s" {
+		t.Errorf("site-a = %+v", a)
+	}
+	if b.ExclusivePct != 0 || b.PerDay != 50 || b.Quiet != "1h30m0s" {
+		t.Errorf("site-b = %+v", b)
+	}
+}
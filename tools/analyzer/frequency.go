@@ -0,0 +1,165 @@
+// Frequency usage report for choosing which channels to monitor.
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"acars_parser/internal/storage"
+)
+
+// FrequencyReport is the message traffic per receive frequency.
+type FrequencyReport struct {
+	Messages    uint64           `json:"messages"`
+	Days        int              `json:"days"`
+	Frequencies []FrequencyUsage `json:"frequencies"`
+}
+
+// FrequencyUsage is the traffic on one frequency, broken down by label,
+// receiving station and day.
+type FrequencyUsage struct {
+	Frequency float64      `json:"frequency_mhz"`
+	Messages  uint64       `json:"messages"`
+	Pct       float64      `json:"percentage"`
+	Days      int          `json:"days"`
+	PerDay    float64      `json:"messages_per_day"`
+	Labels    []UsageCount `json:"labels"`
+	Stations  []UsageCount `json:"stations"`
+	Daily     []UsageCount `json:"daily"`
+}
+
+// UsageCount is the number of messages for one label, station or day.
+type UsageCount struct {
+	Key      string `json:"key"`
+	Messages uint64 `json:"messages"`
+}
+
+// AnalyzeFrequencies reads the frequency counts from ClickHouse and
+// summarises them. See SummariseFrequencies.
+func AnalyzeFrequencies(ctx context.Context, ch *storage.ClickHouseDB, label string, topN int) (*FrequencyReport, error) {
+	counts, err := ch.FrequencyCounts(ctx, storage.FrequencyParams{Label: label})
+	if err != nil {
+		return nil, fmt.Errorf("frequency counts: %w", err)
+	}
+	return SummariseFrequencies(counts, topN), nil
+}
+
+// SummariseFrequencies totals daily counts per frequency, busiest first, with
+// the top labels and stations of each frequency and every day it was heard.
+func SummariseFrequencies(counts []storage.FrequencyCount, topN int) *FrequencyReport {
+	type usage struct {
+		total    uint64
+		labels   map[string]uint64
+		stations map[string]uint64
+		daily    map[string]uint64
+	}
+	byFreq := make(map[float64]*usage)
+	days := make(map[string]bool)
+	report := &FrequencyReport{}
+
+	for _, c := range counts {
+		u := byFreq[c.Frequency]
+		if u == nil {
+			u = &usage{
+				labels:   make(map[string]uint64),
+				stations: make(map[string]uint64),
+				daily:    make(map[string]uint64),
+			}
+			byFreq[c.Frequency] = u
+		}
+		day := c.Day.UTC().Format("2006-01-02")
+		station := c.StationID
+		if station == "" {
+			station = "(unknown)"
+		}
+		u.total += c.Count
+		u.labels[c.Label] += c.Count
+		u.stations[station] += c.Count
+		u.daily[day] += c.Count
+		days[day] = true
+		report.Messages += c.Count
+	}
+	report.Days = len(days)
+
+	for freq, u := range byFreq {
+		fu := FrequencyUsage{
+			Frequency: freq,
+			Messages:  u.total,
+			Days:      len(u.daily),
+			Labels:    topUsage(u.labels, topN),
+			Stations:  topUsage(u.stations, topN),
+			Daily:     topUsage(u.daily, 0),
+		}
+		fu.PerDay = float64(u.total) / float64(fu.Days)
+		if report.Messages > 0 {
+			fu.Pct = float64(u.total) / float64(report.Messages) * 100
+		}
+		sort.Slice(fu.Daily, func(i, j int) bool { return fu.Daily[i].Key < fu.Daily[j].Key })
+		report.Frequencies = append(report.Frequencies, fu)
+	}
+	sort.Slice(report.Frequencies, func(i, j int) bool {
+		a, b := report.Frequencies[i], report.Frequencies[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+		return a.Frequency < b.Frequency
+	})
+	return report
+}
+
+// topUsage returns the n largest counts, largest first; n <= 0 returns all.
+func topUsage(m map[string]uint64, n int) []UsageCount {
+	out := make([]UsageCount, 0, len(m))
+	for k, v := range m {
+		out = append(out, UsageCount{Key: k, Messages: v})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Messages != out[j].Messages {
+			return out[i].Messages > out[j].Messages
+		}
+		return out[i].Key < out[j].Key
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// PrintFrequencies writes the frequency report as text.
+func PrintFrequencies(r *FrequencyReport, topN int) {
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println("                    FREQUENCY USAGE")
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println()
+
+	fmt.Printf("Messages:           %d\n", r.Messages)
+	fmt.Printf("Days:               %d\n", r.Days)
+	fmt.Println()
+
+	fmt.Println("FREQUENCIES (Busiest first)")
+	fmt.Println("───────────")
+	if len(r.Frequencies) == 0 {
+		fmt.Println("(none - no messages with a receive frequency)")
+	}
+	fmt.Printf("%-10s %10s %8s %6s %10s\n", "MHz", "Messages", "Pct", "Days", "Per day")
+	for i, f := range r.Frequencies {
+		if i >= topN {
+			fmt.Printf("... %d more\n", len(r.Frequencies)-topN)
+			break
+		}
+		fmt.Printf("%-10.3f %10d %7.1f%% %6d %10.1f\n", f.Frequency, f.Messages, f.Pct, f.Days, f.PerDay)
+		fmt.Printf("  labels:   %s\n", formatUsage(f.Labels))
+		fmt.Printf("  stations: %s\n", formatUsage(f.Stations))
+	}
+	fmt.Println()
+}
+
+func formatUsage(counts []UsageCount) string {
+	parts := make([]string, 0, len(counts))
+	for _, c := range counts {
+		parts = append(parts, fmt.Sprintf("%s=%d", c.Key, c.Messages))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestSummariseFrequencies(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	counts := []storage.FrequencyCount{
+		{Day: day1, Frequency: 131.55, Label: "H1", StationID: "YSSY1", Count: 40},
+		{Day: day2, Frequency: 131.55, Label: "H1", StationID: "YSSY1", Count: 20},
+		{Day: day2, Frequency: 131.55, Label: "5Z", StationID: "YMML1", Count: 10},
+		{Day: day1, Frequency: 131.725, Label: "Q0", StationID: "", Count: 30},
+	}
+
+	r := SummariseFrequencies(counts, 1)
+	if r.Messages != 100 || r.Days != 2 {
+		t.Fatalf("messages/days = %d/%d, want 100/2", r.Messages, r.Days)
+	}
+	if len(r.Frequencies) != 2 {
+		t.Fatalf("frequencies = %+v, want 2", r.Frequencies)
+	}
+
+	f := r.Frequencies[0]
+	if f.Frequency != 131.55 || f.Messages != 70 || f.Pct != 70 || f.Days != 2 || f.PerDay != 35 {
+		t.Errorf("busiest = %+v", f)
+	}
+	if len(f.Labels) != 1 || f.Labels[0] != (UsageCount{Key: "H1", Messages: 60}) {
+		t.Errorf("labels = %+v, want top H1=60", f.Labels)
+	}
+	if len(f.Stations) != 1 || f.Stations[0].Key != "YSSY1" {
+		t.Errorf("stations = %+v, want top YSSY1", f.Stations)
+	}
+	if len(f.Daily) != 2 || f.Daily[0].Key != "2026-03-01" || f.Daily[1].Messages != 30 {
+		t.Errorf("daily = %+v", f.Daily)
+	}
+
+	if s := r.Frequencies[1].Stations; len(s) != 1 || s[0].Key != "(unknown)" {
+		t.Errorf("stations = %+v, want (unknown)", s)
+	}
+}
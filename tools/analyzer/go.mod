@@ -1,5 +0,0 @@
-module analyzer
-
-go 1.25.3
-
-require github.com/mattn/go-sqlite3 v1.14.33
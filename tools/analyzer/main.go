@@ -8,20 +8,17 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"regexp"
 	"sort"
 	"strings"
 
+	"acars_parser/internal/parsers/h1"
 	"acars_parser/internal/storage"
+	"acars_parser/internal/templates"
 )
 
 func main() {
 	// ClickHouse connection flags.
-	chHost := flag.String("ch-host", "localhost", "ClickHouse host")
-	chPort := flag.Int("ch-port", 9000, "ClickHouse port")
-	chUser := flag.String("ch-user", "default", "ClickHouse user")
-	chPassword := flag.String("ch-password", "", "ClickHouse password")
-	chDB := flag.String("ch-db", "acars", "ClickHouse database")
+	chCfg := storage.AddClickHouseFlags(flag.CommandLine)
 
 	outputFormat := flag.String("format", "text", "Output format: text, json")
 	showTemplates := flag.Bool("templates", false, "Include template analysis (slower)")
@@ -30,18 +27,69 @@ func main() {
 	suggest := flag.Bool("suggest", false, "Generate pattern suggestions for a label (requires -label)")
 	minCluster := flag.Int("min-cluster", 3, "Minimum cluster size for suggestions")
 	testPattern := flag.String("test", "", "Test a regex pattern against the corpus")
+	diffMode := flag.Bool("diff", false, "Compare the current parsers against a baseline over the corpus")
+	baseline := flag.String("baseline", "", "Snapshot file to use as the -diff baseline (default: stored parsed_json)")
+	snapshot := flag.String("snapshot", "", "Parse the corpus with the current parsers and write a snapshot file")
+	unparsedClusters := flag.Bool("unparsed-clusters", false, "Cluster unparsed messages across all labels and suggest patterns")
+	frequencies := flag.Bool("frequencies", false, "Report message traffic per receive frequency, label and station")
+	feeders := flag.Bool("feeders", false, "Report message traffic and health per feeder site")
+	pdcValidation := flag.Bool("pdc-validation", false, "Report PDC captures that fail validation, by format, field and reason")
+	limit := flag.Int("limit", 0, "Maximum messages to read in -diff, -snapshot, -unparsed-clusters, -pdc-validation and -templates (0 for all)")
+	draftsDir := flag.String("drafts", "drafts", "Directory of draft patterns")
+	saveDraft := flag.String("save-draft", "", "Save the -cluster suggestion of -suggest or -unparsed-clusters as a named draft")
+	cluster := flag.Int("cluster", 1, "Cluster number of the suggestion to save with -save-draft")
+	listDrafts := flag.Bool("list-drafts", false, "List the draft patterns")
+	testDraft := flag.String("test-draft", "", "Test a draft pattern against the corpus and record the result")
+	promote := flag.String("promote", "", "Print a draft pattern as a format definition")
+	emit := flag.String("emit", "go", "Format definition to print with -promote: go, yaml")
 
 	flag.Parse()
 
 	ctx := context.Background()
 
-	ch, err := storage.OpenClickHouse(ctx, storage.ClickHouseConfig{
-		Host:     *chHost,
-		Port:     *chPort,
-		Database: *chDB,
-		User:     *chUser,
-		Password: *chPassword,
-	})
+	// Draft modes that do not read the corpus.
+	if *listDrafts {
+		drafts, err := ListDrafts(*draftsDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading drafts: %v\n", err)
+			os.Exit(1)
+		}
+		if *outputFormat == "json" {
+			data, _ := json.MarshalIndent(drafts, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			PrintDrafts(drafts)
+		}
+		return
+	}
+	if *promote != "" {
+		d, err := LoadDraft(*draftsDir, *promote)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading draft: %v\n", err)
+			os.Exit(1)
+		}
+		if d.Test == nil {
+			fmt.Fprintf(os.Stderr, "Warning: draft %s has not been tested; run -test-draft %s first\n", d.Name, d.Name)
+		}
+		switch *emit {
+		case "go":
+			err = EmitGo(os.Stdout, d)
+		case "yaml":
+			if err = EmitYAML(os.Stdout, d); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: the PDC parser would not load this format: %v\n", err)
+				err = nil
+			}
+		default:
+			err = fmt.Errorf("unknown -emit %q: use go or yaml", *emit)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ch, err := storage.OpenClickHouse(ctx, *chCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening ClickHouse: %v\n", err)
 		os.Exit(1)
@@ -68,6 +116,147 @@ func main() {
 		return
 	}
 
+	// Draft testing mode.
+	if *testDraft != "" {
+		d, err := LoadDraft(*draftsDir, *testDraft)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading draft: %v\n", err)
+			os.Exit(1)
+		}
+		if err := TestDraft(ctx, ch, d, *limit); err != nil {
+			fmt.Fprintf(os.Stderr, "Error testing draft: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := SaveDraft(*draftsDir, d); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving draft: %v\n", err)
+			os.Exit(1)
+		}
+		if *outputFormat == "json" {
+			data, _ := json.MarshalIndent(d, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			PrintDraftTest(d)
+		}
+		return
+	}
+
+	// Snapshot mode.
+	if *snapshot != "" {
+		f, err := os.Create(*snapshot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		count, err := WriteSnapshot(ctx, ch, f, *label, *limit)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d records to %s\n", count, *snapshot)
+		return
+	}
+
+	// Diff mode.
+	if *diffMode {
+		fmt.Fprintf(os.Stderr, "Re-parsing corpus...\n")
+		diff, err := DiffCorpus(ctx, ch, *baseline, *label, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error diffing corpus: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *outputFormat == "json" {
+			data, _ := json.MarshalIndent(diff, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			PrintDiff(diff, *topN)
+		}
+		return
+	}
+
+	// Unparsed cluster mode.
+	if *unparsedClusters {
+		fmt.Fprintf(os.Stderr, "Clustering unparsed messages...\n")
+		suggestions, err := SuggestUnparsedClusters(ctx, ch, *label, *minCluster, *topN, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error clustering unparsed messages: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *saveDraft != "" {
+			path, err := SaveSuggestionDraft(*draftsDir, *saveDraft, *cluster, suggestions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving draft: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Saved draft %s to %s\n", *saveDraft, path)
+			return
+		}
+
+		if *outputFormat == "json" {
+			data, _ := json.MarshalIndent(suggestions, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			PrintSuggestions(ctx, suggestions, ch)
+		}
+		return
+	}
+
+	// Frequency usage mode.
+	if *frequencies {
+		report, err := AnalyzeFrequencies(ctx, ch, *label, *topN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error analysing frequencies: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *outputFormat == "json" {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			PrintFrequencies(report, *topN)
+		}
+		return
+	}
+
+	// Feeder report mode.
+	if *feeders {
+		report, err := AnalyzeFeeders(ctx, ch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error analysing feeders: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *outputFormat == "json" {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			PrintFeeders(report, *topN)
+		}
+		return
+	}
+
+	// PDC validation mode.
+	if *pdcValidation {
+		fmt.Fprintf(os.Stderr, "Re-parsing PDCs...\n")
+		report, err := AnalyzePDCValidation(ctx, ch, *label, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error validating PDCs: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *outputFormat == "json" {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			PrintPDCValidation(report, *topN)
+		}
+		return
+	}
+
 	// Suggestion mode.
 	if *suggest {
 		if *label == "" {
@@ -77,11 +266,21 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Generating pattern suggestions for label %s...\n", *label)
 		suggestions := SuggestPatterns(ctx, ch, *label, *minCluster, *topN)
 
+		if *saveDraft != "" {
+			path, err := SaveSuggestionDraft(*draftsDir, *saveDraft, *cluster, suggestions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving draft: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Saved draft %s to %s\n", *saveDraft, path)
+			return
+		}
+
 		if *outputFormat == "json" {
 			data, _ := json.MarshalIndent(suggestions, "", "  ")
 			fmt.Println(string(data))
 		} else {
-			PrintSuggestions(ctx, suggestions, ch, *label)
+			PrintSuggestions(ctx, suggestions, ch)
 		}
 		return
 	}
@@ -103,6 +302,11 @@ func main() {
 	report.LabelParsing = analyzeLabelParsing(ctx, ch, *label)
 	fmt.Fprintf(os.Stderr, "  - Label parsing complete\n")
 
+	if *label == "" || *label == "H1" {
+		report.H1SubLabels = analyzeH1SubLabels(ctx, ch, *topN)
+		fmt.Fprintf(os.Stderr, "  - H1 sub-labels complete\n")
+	}
+
 	report.ContentPatterns = analyzeContentPatterns(ctx, ch, *label, *topN)
 	fmt.Fprintf(os.Stderr, "  - Content patterns complete\n")
 
@@ -110,7 +314,7 @@ func main() {
 	fmt.Fprintf(os.Stderr, "  - Field coverage complete\n")
 
 	if *showTemplates {
-		report.TemplateAnalysis = analyzeTemplates(ctx, ch, *label, *topN)
+		report.TemplateAnalysis = analyzeTemplates(ctx, ch, *label, *topN, *limit)
 		fmt.Fprintf(os.Stderr, "  - Template analysis complete\n")
 	}
 
@@ -129,6 +333,7 @@ type AnalysisReport struct {
 	LabelDistribution []LabelCount           `json:"label_distribution"`
 	ParserCoverage    []ParserCount          `json:"parser_coverage"`
 	LabelParsing      []LabelParseStats      `json:"label_parsing"`
+	H1SubLabels       []SubLabelStats        `json:"h1_sublabels,omitempty"`
 	ContentPatterns   []LabelContentPatterns `json:"content_patterns"`
 	FieldCoverage     []FieldCoverageStats   `json:"field_coverage"`
 	TemplateAnalysis  []LabelTemplates       `json:"template_analysis,omitempty"`
@@ -164,6 +369,17 @@ type LabelParseStats struct {
 	TopParsers []string `json:"top_parsers"`
 }
 
+// SubLabelStats is the parse coverage of the H1 messages from one onboard
+// system. Messages only tagged by the sub-label router count as unparsed.
+type SubLabelStats struct {
+	SubLabel  string  `json:"sub_label"`
+	System    string  `json:"system,omitempty"`
+	Total     int     `json:"total"`
+	Parsed    int     `json:"parsed"`
+	ParseRate float64 `json:"parse_rate"`
+	Example   string  `json:"example,omitempty"`
+}
+
 type LabelContentPatterns struct {
 	Label      string         `json:"label"`
 	Keywords   []KeywordCount `json:"keywords"`
@@ -325,6 +541,42 @@ func analyzeLabelParsing(ctx context.Context, ch *storage.ClickHouseDB, filterLa
 	return results
 }
 
+func analyzeH1SubLabels(ctx context.Context, ch *storage.ClickHouseDB, topN int) []SubLabelStats {
+	conn := ch.Conn()
+	rows, err := conn.Query(ctx, `
+		SELECT
+			if(startsWith(text, 'FTX'), 'FTX', extract(text, '^(?:- )?#([A-Z0-9]{2})')) as sl,
+			COUNT(*) as total,
+			countIf(parser_type NOT IN ('', 'unparsed', 'h1_sublabel')) as parsed,
+			any(text) as example
+		FROM messages
+		WHERE label = 'H1'
+		GROUP BY sl
+		ORDER BY total DESC
+		LIMIT ?`, topN)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var results []SubLabelStats
+	for rows.Next() {
+		var ss SubLabelStats
+		_ = rows.Scan(&ss.SubLabel, &ss.Total, &ss.Parsed, &ss.Example)
+		if sl, _, ok := h1.SplitSubLabel(ss.Example); ok {
+			ss.System = sl.System.Name
+		}
+		if ss.Total > 0 {
+			ss.ParseRate = float64(ss.Parsed) / float64(ss.Total) * 100
+		}
+		if len(ss.Example) > 60 {
+			ss.Example = ss.Example[:60]
+		}
+		results = append(results, ss)
+	}
+	return results
+}
+
 // Keywords to look for in messages - these indicate potential data value.
 var interestingKeywords = []string{
 	// Clearances/PDC.
@@ -542,117 +794,93 @@ func analyzeFieldCoverage(ctx context.Context, ch *storage.ClickHouseDB) []Field
 	return results
 }
 
-// Template analysis - reuses logic from templates command.
-var tokenPatterns = []struct {
-	Name    string
-	Pattern *regexp.Regexp
-}{
-	{"<FREQ>", regexp.MustCompile(`^\d{2,3}\.\d{1,3}$`)},
-	{"<TIME>", regexp.MustCompile(`^[0-2]\d[0-5]\d$`)},
-	{"<SQWK>", regexp.MustCompile(`^[0-7]{4}$`)},
-	{"<FL>", regexp.MustCompile(`^FL\d{2,3}$`)},
-	{"<RWY>", regexp.MustCompile(`^\d{1,2}[LCR]?$`)},
-	{"<ICAO>", regexp.MustCompile(`^[A-Z]{4}$`)},
-	{"<FLIGHT>", regexp.MustCompile(`^[A-Z]{2,3}\d{1,4}[A-Z]?$`)},
-	{"<TAIL>", regexp.MustCompile(`^[A-Z]{1,2}-?[A-Z]{0,3}\d{1,5}[A-Z]{0,2}$`)},
-	{"<ACFT>", regexp.MustCompile(`^[A-Z]\d{2,3}[A-Z]?$`)},
-	{"<NUM>", regexp.MustCompile(`^\d+$`)},
-	{"<WPT5>", regexp.MustCompile(`^[A-Z]{5}$`)},
-	{"<CODE>", regexp.MustCompile(`^[A-Z]{3,4}$`)},
-	{"<ALNUM>", regexp.MustCompile(`^[A-Z0-9]{6,}$`)},
-}
+// templateLabels is the number of busiest labels included in template analysis.
+const templateLabels = 20
 
-var literalKeywords = map[string]bool{
-	"PDC": true, "CLRD": true, "CLEARED": true, "TO": true, "VIA": true,
-	"OFF": true, "RWY": true, "RUNWAY": true, "SID": true, "DEP": true,
-	"SQUAWK": true, "XPNDR": true, "FREQ": true, "ATIS": true,
-	"CLIMB": true, "MAINTAIN": true, "EXPECT": true, "CONTACT": true,
-	"FROM": true, "AT": true, "ON": true, "FOR": true, "WITH": true,
-	"POS": true, "POSITION": true, "ETA": true, "ETD": true,
-	"ROUTE": true, "DIRECT": true, "DCT": true, "ALT": true, "FL": true,
-}
-
-func analyzeTemplates(ctx context.Context, ch *storage.ClickHouseDB, filterLabel string, topN int) []LabelTemplates {
-	conn := ch.Conn()
+// templateCapacity is the number of templates tracked per label.
+const templateCapacity = 2000
 
-	// Get labels to analyze.
-	query := `SELECT label, COUNT(*) as cnt FROM messages GROUP BY label HAVING cnt >= 10 ORDER BY cnt DESC LIMIT 20`
+// analyzeTemplates normalises every message in a single streaming pass and keeps
+// a bounded top-K template counter per label. Progress is reported on stderr.
+func analyzeTemplates(ctx context.Context, ch *storage.ClickHouseDB, filterLabel string, topN, limit int) []LabelTemplates {
+	query := `SELECT label, raw_text FROM messages`
+	var args []interface{}
 	if filterLabel != "" {
-		query = `SELECT label, COUNT(*) as cnt FROM messages WHERE label = '` + filterLabel + `' GROUP BY label`
+		query += ` WHERE label = ?`
+		args = append(args, filterLabel)
+	}
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
 	}
 
-	labelRows, err := conn.Query(ctx, query)
-	if err != nil {
-		return nil
+	var expected int
+	if limit <= 0 {
+		countQuery := `SELECT COUNT(*) FROM messages`
+		if filterLabel != "" {
+			countQuery += ` WHERE label = ?`
+		}
+		_ = ch.Conn().QueryRow(ctx, countQuery, args...).Scan(&expected)
+	} else {
+		expected = limit
 	}
-	defer labelRows.Close()
 
-	var labels []string
-	for labelRows.Next() {
-		var l string
-		var cnt int
-		_ = labelRows.Scan(&l, &cnt)
-		labels = append(labels, l)
+	rows, err := ch.Conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil
 	}
+	defer rows.Close()
 
-	var results []LabelTemplates
-	for _, lbl := range labels {
-		rows, err := conn.Query(ctx, `SELECT raw_text FROM messages WHERE label = ? LIMIT 2000`, lbl)
-		if err != nil {
+	counters := make(map[string]*templates.Counter)
+	progress := newProgress("templates", expected)
+	for rows.Next() {
+		var lbl, text string
+		if err := rows.Scan(&lbl, &text); err != nil {
 			continue
 		}
-
-		templates := make(map[string][]string) // template -> examples
-		var total int
-
-		for rows.Next() {
-			var text string
-			_ = rows.Scan(&text)
-			total++
-
-			tmpl := normaliseToTemplate(text)
-			if len(templates[tmpl]) < 2 {
-				templates[tmpl] = append(templates[tmpl], text)
-			}
+		c := counters[lbl]
+		if c == nil {
+			c = templates.NewCounter(templateCapacity)
+			counters[lbl] = c
 		}
-		rows.Close()
+		c.AddText(text)
+		progress.tick()
+	}
+	progress.done()
 
-		// Recount properly.
-		templateCounts := make(map[string]int)
-		templateExamples := make(map[string]string)
-		rows2, _ := conn.Query(ctx, `SELECT raw_text FROM messages WHERE label = ? LIMIT 5000`, lbl)
-		if rows2 != nil {
-			for rows2.Next() {
-				var text string
-				_ = rows2.Scan(&text)
-				tmpl := normaliseToTemplate(text)
-				templateCounts[tmpl]++
-				if _, ok := templateExamples[tmpl]; !ok {
-					templateExamples[tmpl] = text
-				}
-			}
-			rows2.Close()
+	// Keep the busiest labels with enough messages to be worth reporting.
+	var labels []string
+	for lbl, c := range counters {
+		if filterLabel != "" || c.Total() >= 10 {
+			labels = append(labels, lbl)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		ti, tj := counters[labels[i]].Total(), counters[labels[j]].Total()
+		if ti != tj {
+			return ti > tj
 		}
+		return labels[i] < labels[j]
+	})
+	if len(labels) > templateLabels {
+		labels = labels[:templateLabels]
+	}
 
+	var results []LabelTemplates
+	for _, lbl := range labels {
+		c := counters[lbl]
 		var topTemplates []TemplateCount
-		for tmpl, cnt := range templateCounts {
+		for _, e := range c.Top(topN) {
 			topTemplates = append(topTemplates, TemplateCount{
-				Template: truncate(tmpl, 100),
-				Count:    cnt,
-				Example:  truncate(templateExamples[tmpl], 200),
+				Template: truncate(e.Template, 100),
+				Count:    e.Count,
+				Example:  truncate(e.Example, 200),
 			})
 		}
-		sort.Slice(topTemplates, func(i, j int) bool {
-			return topTemplates[i].Count > topTemplates[j].Count
-		})
-		if len(topTemplates) > topN {
-			topTemplates = topTemplates[:topN]
-		}
 
 		results = append(results, LabelTemplates{
 			Label:           lbl,
-			TotalMessages:   total,
-			UniqueTemplates: len(templateCounts),
+			TotalMessages:   c.Total(),
+			UniqueTemplates: c.Distinct(),
 			TopTemplates:    topTemplates,
 		})
 	}
@@ -660,55 +888,6 @@ func analyzeTemplates(ctx context.Context, ch *storage.ClickHouseDB, filterLabel
 	return results
 }
 
-func normaliseToTemplate(text string) string {
-	text = strings.ToUpper(text)
-	lines := strings.Split(text, "\n")
-
-	var normalisedLines []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		tokens := strings.Fields(line)
-		var normalisedTokens []string
-
-		for _, tok := range tokens {
-			norm := classifyToken(tok)
-			normalisedTokens = append(normalisedTokens, norm)
-		}
-
-		if len(normalisedTokens) > 0 {
-			normalisedLines = append(normalisedLines, strings.Join(normalisedTokens, " "))
-		}
-	}
-
-	return strings.Join(normalisedLines, " | ")
-}
-
-func classifyToken(tok string) string {
-	if literalKeywords[tok] {
-		return tok
-	}
-
-	for _, tp := range tokenPatterns {
-		if tp.Pattern.MatchString(tok) {
-			return tp.Name
-		}
-	}
-
-	if len(tok) <= 2 {
-		return tok
-	}
-
-	if regexp.MustCompile(`^[A-Z]{3,8}$`).MatchString(tok) {
-		return tok
-	}
-
-	return "<OTHER>"
-}
-
 func truncate(s string, max int) string {
 	s = strings.ReplaceAll(s, "\n", " ")
 	s = strings.ReplaceAll(s, "\t", " ")
@@ -771,6 +950,25 @@ func printTextReport(report *AnalysisReport, topN int) {
 	}
 	fmt.Println()
 
+	// H1 sub-labels.
+	if len(report.H1SubLabels) > 0 {
+		fmt.Println("H1 SUB-LABELS (Coverage per emitting system)")
+		fmt.Println("─────────────")
+		fmt.Printf("%-8s %-28s %8s %8s %8s\n", "Sub", "System", "Total", "Parsed", "Rate")
+		for _, ss := range report.H1SubLabels {
+			sub := ss.SubLabel
+			if sub == "" {
+				sub = "(none)"
+			}
+			system := ss.System
+			if system == "" {
+				system = "-"
+			}
+			fmt.Printf("%-8s %-28s %8d %8d %7.1f%%\n", sub, system, ss.Total, ss.Parsed, ss.ParseRate)
+		}
+		fmt.Println()
+	}
+
 	// Content patterns.
 	fmt.Println("CONTENT PATTERNS (Keywords found per label)")
 	fmt.Println("────────────────")
@@ -0,0 +1,149 @@
+// Report of PDC fields that fail validation, for tuning the PDC formats.
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"acars_parser/internal/acars"
+	"acars_parser/internal/parsers/pdc"
+	"acars_parser/internal/storage"
+)
+
+// maxValidationExamples is how many distinct values and message IDs are kept
+// for each kind of failure.
+const maxValidationExamples = 5
+
+// PDCValidationReport counts the PDC captures that failed validation, by
+// format, field and reason, across the corpus.
+type PDCValidationReport struct {
+	Parsed     int                 `json:"parsed"`      // Messages the PDC parser parsed.
+	WithIssues int                 `json:"with_issues"` // Of those, messages with an issue.
+	Failures   []PDCValidationItem `json:"failures"`    // Most frequent first.
+}
+
+// PDCValidationItem is one kind of validation failure of one format.
+type PDCValidationItem struct {
+	Format     string   `json:"format"`
+	Field      string   `json:"field"`
+	Reason     string   `json:"reason"`
+	Dropped    bool     `json:"dropped"`
+	Count      int      `json:"count"`
+	Values     []string `json:"values"`      // Distinct captured values.
+	MessageIDs []uint64 `json:"message_ids"` // Messages to look at.
+}
+
+// Add counts the validation issues of a parsed PDC.
+func (r *PDCValidationReport) Add(id uint64, res *pdc.Result) {
+	r.Parsed++
+	if len(res.Validation) == 0 {
+		return
+	}
+	r.WithIssues++
+
+	for _, issue := range res.Validation {
+		var item *PDCValidationItem
+		for i := range r.Failures {
+			f := &r.Failures[i]
+			if f.Format == res.PDCFormat && f.Field == issue.Field && f.Reason == issue.Reason {
+				item = f
+				break
+			}
+		}
+		if item == nil {
+			r.Failures = append(r.Failures, PDCValidationItem{
+				Format: res.PDCFormat, Field: issue.Field, Reason: issue.Reason, Dropped: issue.Dropped,
+			})
+			item = &r.Failures[len(r.Failures)-1]
+		}
+		item.Count++
+		if len(item.MessageIDs) < maxValidationExamples {
+			item.MessageIDs = append(item.MessageIDs, id)
+		}
+		if len(item.Values) < maxValidationExamples && !containsString(item.Values, issue.Value) {
+			item.Values = append(item.Values, issue.Value)
+		}
+	}
+}
+
+// Finish sorts the failures, most frequent first.
+func (r *PDCValidationReport) Finish() {
+	sort.SliceStable(r.Failures, func(i, j int) bool {
+		a, b := r.Failures[i], r.Failures[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Format != b.Format {
+			return a.Format < b.Format
+		}
+		return a.Field < b.Field
+	})
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzePDCValidation parses the corpus with the current PDC parser and
+// reports the captures that fail validation.
+func AnalyzePDCValidation(ctx context.Context, ch *storage.ClickHouseDB, label string, limit int) (*PDCValidationReport, error) {
+	parser := &pdc.Parser{}
+	report := &PDCValidationReport{}
+	err := forEachCorpusMessage(ctx, ch, label, limit, func(m corpusMessage) error {
+		if !parser.QuickCheck(m.rawText) {
+			return nil
+		}
+		res, ok := parser.Parse(&acars.Message{
+			ID:    acars.FlexInt64(m.id),
+			Label: m.label,
+			Text:  m.rawText,
+		}).(*pdc.Result)
+		if ok {
+			report.Add(m.id, res)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Finish()
+	return report, nil
+}
+
+// PrintPDCValidation writes the validation report as text.
+func PrintPDCValidation(r *PDCValidationReport, topN int) {
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println("                    PDC VALIDATION")
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println()
+
+	fmt.Printf("PDCs parsed:        %d\n", r.Parsed)
+	fmt.Printf("With issues:        %d (%.1f%%)\n", r.WithIssues, pct(r.WithIssues, r.Parsed))
+	fmt.Println()
+	if len(r.Failures) == 0 {
+		fmt.Println("(none)")
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("%-22s %-18s %-22s %-8s %7s  %s\n", "Format", "Field", "Reason", "Action", "Count", "Values")
+	for i, f := range r.Failures {
+		if i >= topN {
+			fmt.Printf("... %d more\n", len(r.Failures)-topN)
+			break
+		}
+		action := "flagged"
+		if f.Dropped {
+			action = "dropped"
+		}
+		fmt.Printf("%-22s %-18s %-22s %-8s %7d  %q\n", f.Format, f.Field, f.Reason, action, f.Count, f.Values)
+		fmt.Printf("%-22s messages: %v\n", "", f.MessageIDs)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"acars_parser/internal/parsers/pdc"
+)
+
+func TestPDCValidationReport(t *testing.T) {
+	r := &PDCValidationReport{}
+	r.Add(1, &pdc.Result{PDCFormat: "australian"})
+	r.Add(2, &pdc.Result{PDCFormat: "australian", Validation: []pdc.ValidationIssue{
+		{Field: "sid", Value: "XXX", Reason: pdc.ReasonPlaceholderSID, Dropped: true},
+	}})
+	r.Add(3, &pdc.Result{PDCFormat: "australian", Validation: []pdc.ValidationIssue{
+		{Field: "sid", Value: "XXX", Reason: pdc.ReasonPlaceholderSID, Dropped: true},
+		{Field: "squawk", Value: "0000", Reason: pdc.ReasonReservedSquawk, Dropped: true},
+	}})
+	r.Add(4, &pdc.Result{PDCFormat: "us_delta", Validation: []pdc.ValidationIssue{
+		{Field: "sid", Value: "NONE", Reason: pdc.ReasonPlaceholderSID, Dropped: true},
+	}})
+	r.Finish()
+
+	if r.Parsed != 4 || r.WithIssues != 3 || len(r.Failures) != 3 {
+		t.Fatalf("report = %+v", r)
+	}
+	first := r.Failures[0]
+	if first.Format != "australian" || first.Field != "sid" || first.Count != 2 ||
+		len(first.Values) != 1 || len(first.MessageIDs) != 2 || first.MessageIDs[1] != 3 {
+		t.Errorf("first failure = %+v", first)
+	}
+	if r.Failures[1].Field != "squawk" || r.Failures[2].Format != "us_delta" {
+		t.Errorf("order = %+v", r.Failures)
+	}
+}
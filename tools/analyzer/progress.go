@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// progressInterval is how often progress is written to stderr.
+const progressInterval = 2 * time.Second
+
+// progress reports the number of rows processed on stderr, with a percentage
+// when the expected total is known.
+type progress struct {
+	name     string
+	expected int
+	count    int
+	start    time.Time
+	last     time.Time
+}
+
+func newProgress(name string, expected int) *progress {
+	now := time.Now()
+	return &progress{name: name, expected: expected, start: now, last: now}
+}
+
+func (p *progress) tick() {
+	p.count++
+	if p.count%1000 != 0 {
+		return
+	}
+	if now := time.Now(); now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.print("\r")
+	}
+}
+
+func (p *progress) done() {
+	p.print("\r")
+	fmt.Fprintln(os.Stderr)
+}
+
+func (p *progress) print(prefix string) {
+	elapsed := time.Since(p.start).Seconds()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(p.count) / elapsed
+	}
+	if p.expected > 0 {
+		fmt.Fprintf(os.Stderr, "%s  - %s: %d/%d (%.1f%%, %.0f msg/s)", prefix, p.name, p.count, p.expected, pct(p.count, p.expected), rate)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s  - %s: %d (%.0f msg/s)", prefix, p.name, p.count, rate)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"acars_parser/internal/storage"
+	"acars_parser/internal/templates"
 )
 
 // PatternSuggestion represents a suggested regex pattern for a message cluster.
@@ -48,7 +49,7 @@ func SuggestPatterns(ctx context.Context, ch *storage.ClickHouseDB, label string
 		var text string
 		_ = rows.Scan(&id, &text)
 
-		template := normaliseToTemplate(text)
+		template := templates.Normalise(text)
 		clusters[template] = append(clusters[template], msgInfo{id, text})
 	}
 
@@ -81,6 +82,85 @@ func SuggestPatterns(ctx context.Context, ch *storage.ClickHouseDB, label string
 	return suggestions
 }
 
+// SuggestUnparsedClusters groups unparsed messages across all labels by label and
+// template, ranks the clusters by size, and suggests a regex for each. Only the
+// count and the first few examples of each cluster are held in memory.
+func SuggestUnparsedClusters(ctx context.Context, ch *storage.ClickHouseDB, filterLabel string, minClusterSize, maxSuggestions, limit int) ([]PatternSuggestion, error) {
+	query := `SELECT id, label, raw_text FROM messages WHERE (parser_type = '' OR parser_type = 'unparsed')`
+	var args []interface{}
+	if filterLabel != "" {
+		query += ` AND label = ?`
+		args = append(args, filterLabel)
+	}
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+
+	rows, err := ch.Conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query unparsed messages: %w", err)
+	}
+	defer rows.Close()
+
+	type cluster struct {
+		label    string
+		template string
+		count    int
+		examples []msgInfo
+	}
+	clusters := make(map[string]*cluster)
+
+	for rows.Next() {
+		var id uint64
+		var lbl, text string
+		if err := rows.Scan(&id, &lbl, &text); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+
+		template := templates.Normalise(text)
+		key := lbl + "\x00" + template
+		c := clusters[key]
+		if c == nil {
+			c = &cluster{label: lbl, template: template}
+			clusters[key] = c
+		}
+		c.count++
+		if len(c.examples) < 3 {
+			c.examples = append(c.examples, msgInfo{id, text})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages: %w", err)
+	}
+
+	var ranked []*cluster
+	for _, c := range clusters {
+		if c.count >= minClusterSize {
+			ranked = append(ranked, c)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].count != ranked[j].count {
+			return ranked[i].count > ranked[j].count
+		}
+		if ranked[i].label != ranked[j].label {
+			return ranked[i].label < ranked[j].label
+		}
+		return ranked[i].template < ranked[j].template
+	})
+	if len(ranked) > maxSuggestions {
+		ranked = ranked[:maxSuggestions]
+	}
+
+	suggestions := make([]PatternSuggestion, 0, len(ranked))
+	for i, c := range ranked {
+		suggestion := generatePatternSuggestion(c.examples, c.template, c.label, i+1)
+		suggestion.MessageCount = c.count
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
 func generatePatternSuggestion(messages []msgInfo, template, label string, clusterID int) PatternSuggestion {
 	suggestion := PatternSuggestion{
 		ClusterID:       clusterID,
@@ -238,6 +318,9 @@ func tokenToGroupName(token string) string {
 	}
 }
 
+// testSampleSize is the number of messages a pattern is tested against.
+const testSampleSize = 2000
+
 // TestPattern tests a regex pattern against the corpus and returns match statistics.
 func TestPattern(ctx context.Context, ch *storage.ClickHouseDB, pattern string, label string) (matches int, total int, sampleMatches []uint64, sampleNonMatches []uint64) {
 	re, err := regexp.Compile(pattern)
@@ -245,17 +328,7 @@ func TestPattern(ctx context.Context, ch *storage.ClickHouseDB, pattern string,
 		return 0, 0, nil, nil
 	}
 
-	conn := ch.Conn()
-	rows, err := conn.Query(ctx, `SELECT id, raw_text FROM messages WHERE label = ? LIMIT 2000`, label)
-	if err != nil {
-		return 0, 0, nil, nil
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var id uint64
-		var text string
-		_ = rows.Scan(&id, &text)
+	_ = scanLabel(ctx, ch, label, testSampleSize, func(id uint64, text string) {
 		total++
 
 		if re.MatchString(text) {
@@ -268,13 +341,33 @@ func TestPattern(ctx context.Context, ch *storage.ClickHouseDB, pattern string,
 				sampleNonMatches = append(sampleNonMatches, id)
 			}
 		}
-	}
+	})
 
 	return matches, total, sampleMatches, sampleNonMatches
 }
 
-// PrintSuggestions outputs pattern suggestions in a readable format.
-func PrintSuggestions(ctx context.Context, suggestions []PatternSuggestion, ch *storage.ClickHouseDB, label string) {
+// scanLabel calls fn with the ID and text of up to limit messages with a label.
+func scanLabel(ctx context.Context, ch *storage.ClickHouseDB, label string, limit int, fn func(id uint64, text string)) error {
+	rows, err := ch.Conn().Query(ctx, fmt.Sprintf(`SELECT id, raw_text FROM messages WHERE label = ? LIMIT %d`, limit), label)
+	if err != nil {
+		return fmt.Errorf("query messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			return fmt.Errorf("scan message: %w", err)
+		}
+		fn(id, text)
+	}
+	return rows.Err()
+}
+
+// PrintSuggestions outputs pattern suggestions in a readable format. Each suggested
+// regex is tested against its own cluster's label when ch is set.
+func PrintSuggestions(ctx context.Context, suggestions []PatternSuggestion, ch *storage.ClickHouseDB) {
 	fmt.Println("═══════════════════════════════════════════════════════════════")
 	fmt.Println("                    PATTERN SUGGESTIONS")
 	fmt.Println("═══════════════════════════════════════════════════════════════")
@@ -309,8 +402,10 @@ func PrintSuggestions(ctx context.Context, suggestions []PatternSuggestion, ch *
 
 		// Test the pattern.
 		if ch != nil && s.SuggestedRegex != "" {
-			matches, total, _, _ := TestPattern(ctx, ch, s.SuggestedRegex, label)
-			fmt.Printf("Test Results: %d/%d messages match (%.1f%%)\n", matches, total, float64(matches)/float64(total)*100)
+			matches, total, _, _ := TestPattern(ctx, ch, s.SuggestedRegex, s.Label)
+			if total > 0 {
+				fmt.Printf("Test Results: %d/%d messages match (%.1f%%)\n", matches, total, float64(matches)/float64(total)*100)
+			}
 		}
 
 		fmt.Println()
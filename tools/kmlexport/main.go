@@ -1,6 +1,7 @@
 // Package main provides a tool to export waypoints from the PostgreSQL database to KML format.
 // KML (Keyhole Markup Language) files can be viewed in Google Earth, Google Maps, and
-// other mapping applications.
+// other mapping applications. With -nat-tracks, the current and coming NAT tracks
+// (imported with maintenance nat-tracks) are exported as lines instead.
 package main
 
 import (
@@ -9,8 +10,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"acars_parser/internal/navdata"
 	"acars_parser/internal/storage"
 )
 
@@ -34,8 +37,9 @@ type Document struct {
 
 // Style defines the visual appearance of features.
 type Style struct {
-	ID        string    `xml:"id,attr"`
-	IconStyle IconStyle `xml:"IconStyle"`
+	ID        string     `xml:"id,attr"`
+	IconStyle *IconStyle `xml:"IconStyle,omitempty"`
+	LineStyle *LineStyle `xml:"LineStyle,omitempty"`
 }
 
 // IconStyle defines how icons are displayed.
@@ -44,6 +48,12 @@ type IconStyle struct {
 	Icon  Icon    `xml:"Icon"`
 }
 
+// LineStyle defines how lines are displayed.
+type LineStyle struct {
+	Color string  `xml:"color"` // Format: aabbggrr
+	Width float64 `xml:"width,omitempty"`
+}
+
 // Icon specifies the icon image.
 type Icon struct {
 	Href string `xml:"href"`
@@ -54,7 +64,8 @@ type Placemark struct {
 	Name         string        `xml:"name"`
 	Description  string        `xml:"description,omitempty"`
 	StyleURL     string        `xml:"styleUrl,omitempty"`
-	Point        Point         `xml:"Point"`
+	Point        *Point        `xml:"Point,omitempty"`
+	LineString   *LineString   `xml:"LineString,omitempty"`
 	ExtendedData *ExtendedData `xml:"ExtendedData,omitempty"`
 }
 
@@ -63,6 +74,12 @@ type Point struct {
 	Coordinates string `xml:"coordinates"` // Format: lon,lat,altitude
 }
 
+// LineString represents a path through geographic locations.
+type LineString struct {
+	Tessellate  int    `xml:"tessellate,omitempty"` // 1 to follow the earth's surface.
+	Coordinates string `xml:"coordinates"`          // Format: lon,lat,altitude separated by spaces
+}
+
 // ExtendedData holds custom data associated with a placemark.
 type ExtendedData struct {
 	Data []Data `xml:"Data"`
@@ -76,28 +93,19 @@ type Data struct {
 
 func main() {
 	// PostgreSQL connection flags.
-	pgHost := flag.String("pg-host", "localhost", "PostgreSQL host")
-	pgPort := flag.Int("pg-port", 5432, "PostgreSQL port")
-	pgUser := flag.String("pg-user", "acars", "PostgreSQL user")
-	pgPassword := flag.String("pg-password", "", "PostgreSQL password")
-	pgDB := flag.String("pg-db", "acars", "PostgreSQL database")
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
 
 	output := flag.String("output", "", "Output KML file (default: stdout)")
 	minSources := flag.Int("min-sources", 1, "Minimum source count to include a waypoint")
 	showStats := flag.Bool("stats", false, "Show statistics only, don't export")
+	natTracks := flag.Bool("nat-tracks", false, "Export the current and coming NAT tracks instead of waypoints")
 	verbose := flag.Bool("v", false, "Verbose output")
 
 	flag.Parse()
 
 	ctx := context.Background()
 
-	pg, err := storage.OpenPostgres(ctx, storage.PostgresConfig{
-		Host:     *pgHost,
-		Port:     *pgPort,
-		Database: *pgDB,
-		User:     *pgUser,
-		Password: *pgPassword,
-	})
+	pg, err := storage.OpenPostgres(ctx, *pgCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening PostgreSQL: %v\n", err)
 		os.Exit(1)
@@ -110,24 +118,41 @@ func main() {
 		return
 	}
 
-	// Query waypoints.
-	waypoints, err := pg.ListWaypoints(ctx, *minSources)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error querying waypoints: %v\n", err)
-		os.Exit(1)
-	}
+	var kml KML
+	if *natTracks {
+		tracks, err := pg.ListNATTracks(ctx, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error querying NAT tracks: %v\n", err)
+			os.Exit(1)
+		}
+		if len(tracks) == 0 {
+			fmt.Fprintf(os.Stderr, "No current NAT tracks found\n")
+			os.Exit(0)
+		}
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "Exporting %d NAT tracks to KML\n", len(tracks))
+		}
+		kml = generateTrackKML(ctx, pg, tracks)
+	} else {
+		// Query waypoints.
+		waypoints, err := pg.ListWaypoints(ctx, *minSources)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error querying waypoints: %v\n", err)
+			os.Exit(1)
+		}
 
-	if len(waypoints) == 0 {
-		fmt.Fprintf(os.Stderr, "No waypoints found matching criteria\n")
-		os.Exit(0)
-	}
+		if len(waypoints) == 0 {
+			fmt.Fprintf(os.Stderr, "No waypoints found matching criteria\n")
+			os.Exit(0)
+		}
 
-	if *verbose {
-		fmt.Fprintf(os.Stderr, "Exporting %d waypoints to KML\n", len(waypoints))
-	}
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "Exporting %d waypoints to KML\n", len(waypoints))
+		}
 
-	// Generate KML.
-	kml := generateKML(waypoints)
+		// Generate KML.
+		kml = generateKML(waypoints)
+	}
 
 	// Marshal to XML.
 	xmlData, err := xml.MarshalIndent(kml, "", "  ")
@@ -172,7 +197,7 @@ func generateKML(waypoints []storage.Waypoint) KML {
 			Name:        wp.Name,
 			Description: description,
 			StyleURL:    "#waypointStyle",
-			Point: Point{
+			Point: &Point{
 				Coordinates: coords,
 			},
 			ExtendedData: &ExtendedData{
@@ -193,7 +218,7 @@ func generateKML(waypoints []storage.Waypoint) KML {
 			Styles: []Style{
 				{
 					ID: "waypointStyle",
-					IconStyle: IconStyle{
+					IconStyle: &IconStyle{
 						Scale: 0.8,
 						Icon: Icon{
 							Href: "http://maps.google.com/mapfiles/kml/shapes/triangle.png",
@@ -206,6 +231,67 @@ func generateKML(waypoints []storage.Waypoint) KML {
 	}
 }
 
+// generateTrackKML creates a KML document with a line for each NAT track.
+// Coordinate points are placed from their names and named fixes from the
+// waypoints table; fixes not found there are left out of the line.
+func generateTrackKML(ctx context.Context, pg *storage.PostgresDB, tracks []storage.NATTrack) KML {
+	placemarks := make([]Placemark, 0, len(tracks))
+	for _, tr := range tracks {
+		var coords []string
+		for _, name := range tr.Points {
+			if _, lat, lon, ok := navdata.LatLonWaypoint(name); ok {
+				coords = append(coords, fmt.Sprintf("%.6f,%.6f,0", lon, lat))
+				continue
+			}
+			wp, err := pg.GetWaypoint(ctx, name)
+			if err != nil || wp == nil {
+				continue
+			}
+			coords = append(coords, fmt.Sprintf("%.6f,%.6f,0", wp.Longitude, wp.Latitude))
+		}
+		if len(coords) < 2 {
+			continue
+		}
+
+		placemarks = append(placemarks, Placemark{
+			Name: "NAT " + tr.Letter,
+			Description: fmt.Sprintf(
+				"%s\nValid: %s to %s",
+				strings.Join(tr.Points, " "),
+				tr.ValidFrom.Format("2006-01-02 15:04 UTC"),
+				tr.ValidTo.Format("2006-01-02 15:04 UTC"),
+			),
+			StyleURL: "#trackStyle",
+			LineString: &LineString{
+				Tessellate:  1,
+				Coordinates: strings.Join(coords, " "),
+			},
+			ExtendedData: &ExtendedData{
+				Data: []Data{
+					{Name: "tmi", Value: fmt.Sprintf("%d", tr.TMI)},
+					{Name: "valid_from", Value: tr.ValidFrom.Format(time.RFC3339)},
+					{Name: "valid_to", Value: tr.ValidTo.Format(time.RFC3339)},
+				},
+			},
+		})
+	}
+
+	return KML{
+		Namespace: "http://www.opengis.net/kml/2.2",
+		Document: Document{
+			Name:        "NAT Tracks",
+			Description: fmt.Sprintf("North Atlantic organised tracks. Generated %s.", time.Now().Format("2006-01-02 15:04:05")),
+			Styles: []Style{
+				{
+					ID:        "trackStyle",
+					LineStyle: &LineStyle{Color: "ff0000ff", Width: 2},
+				},
+			},
+			Placemarks: placemarks,
+		},
+	}
+}
+
 // showWaypointStats displays statistics about the waypoints in the database.
 func showWaypointStats(ctx context.Context, pg *storage.PostgresDB) {
 	pool := pg.Pool()
@@ -1,6 +1,15 @@
 // Package main provides a tool to export routes from the PostgreSQL database to CSV format.
 // The output is compatible with the planewatch-atc import_routes.rake task, which expects:
 // callsign,ICAO1,ICAO2,ICAO3,...
+//
+// Routes are observed for as long as the state tables are kept, so a routing
+// an airline dropped long ago is still stored. -max-age and -min-confidence
+// limit the export to recently observed routes, and -mark-historic flags the
+// stale ones so that later exports leave them out without deleting them.
+//
+// With -push, routes and aircraft callsign mappings are sent straight to the
+// planewatch-atc HTTP API instead of through the CSV file. Only what was
+// observed since the last push is sent; the watermark is kept in synced_at.
 package main
 
 import (
@@ -10,54 +19,93 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
+	"acars_parser/internal/envflag"
 	"acars_parser/internal/storage"
 )
 
 // RouteExport represents a flight route with its airport sequence.
 type RouteExport struct {
 	FlightPattern string
-	Airports      []string // Ordered list of ICAO codes (origin, intermediate stops, destination).
+	Airports      []string      // Ordered list of ICAO codes (origin, intermediate stops, destination).
+	Source        storage.Route // The stored route, for its observations and sync watermark.
 }
 
 func main() {
 	// PostgreSQL connection flags.
-	pgHost := flag.String("pg-host", "localhost", "PostgreSQL host")
-	pgPort := flag.Int("pg-port", 5432, "PostgreSQL port")
-	pgUser := flag.String("pg-user", "acars", "PostgreSQL user")
-	pgPassword := flag.String("pg-password", "", "PostgreSQL password")
-	pgDB := flag.String("pg-db", "acars", "PostgreSQL database")
+	pgCfg := storage.AddPostgresFlags(flag.CommandLine)
 
 	output := flag.String("output", "", "Output CSV file (default: stdout)")
 	minObservations := flag.Int("min-obs", 1, "Minimum observation count to include a route")
+	var maxAge, markHistoric time.Duration
+	flag.Func("max-age", "Only export routes observed within this long, e.g. 180d (default: any age)", durationFlag(&maxAge))
+	minConfidence := flag.Float64("min-confidence", 0, "Only export routes with at least this confidence (0-1)")
+	halfLife := storage.DefaultRouteHalfLife
+	flag.Func("half-life", "Time since a route was last observed over which its confidence halves (default: 90d)", durationFlag(&halfLife))
+	includeHistoric := flag.Bool("include-historic", false, "Export routes marked historic too")
+	flag.Func("mark-historic", "Mark routes not observed for this long as historic before exporting, e.g. 365d", durationFlag(&markHistoric))
 	showStats := flag.Bool("stats", false, "Show statistics only, don't export")
+	pushURL := flag.String("push", envflag.String("ATC_API_URL", ""), "Push routes and callsign mappings to this planewatch-atc API URL instead of writing CSV")
+	pushToken := flag.String("push-token", envflag.String("ATC_API_TOKEN", ""), "Bearer token for the planewatch-atc API")
+	pushBatch := flag.Int("push-batch", defaultPushBatch, "Routes or callsign mappings per request")
+	pushAll := flag.Bool("push-all", false, "Push everything, not just what was observed since the last push")
 	verbose := flag.Bool("v", false, "Verbose output")
 
 	flag.Parse()
 
 	ctx := context.Background()
 
-	pg, err := storage.OpenPostgres(ctx, storage.PostgresConfig{
-		Host:     *pgHost,
-		Port:     *pgPort,
-		Database: *pgDB,
-		User:     *pgUser,
-		Password: *pgPassword,
-	})
+	pg, err := storage.OpenPostgres(ctx, *pgCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening PostgreSQL: %v\n", err)
 		os.Exit(1)
 	}
 	defer pg.Close()
 
+	now := time.Now().UTC()
+	if markHistoric > 0 {
+		n, err := pg.MarkHistoricRoutes(ctx, now.Add(-markHistoric))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error marking historic routes: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Marked %d routes not observed since %s as historic\n", n, now.Add(-markHistoric).Format("2006-01-02"))
+	}
+
 	// Show stats mode.
 	if *showStats {
-		showRouteStats(ctx, pg)
+		showRouteStats(ctx, pg, now, halfLife)
 		return
 	}
 
 	// Query routes.
-	routes, err := getRoutes(ctx, pg, *minObservations)
+	q := storage.RouteQuery{MinObservations: *minObservations, IncludeHistoric: *includeHistoric}
+	if maxAge > 0 {
+		q.SeenSince = now.Add(-maxAge)
+	}
+	keep := func(r storage.Route) bool {
+		return r.Confidence(now, halfLife) >= *minConfidence
+	}
+
+	if *pushURL != "" {
+		pq := q
+		pq.Unsynced = !*pushAll
+		routes, err := getRoutes(ctx, pg, pq, keep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error querying routes: %v\n", err)
+			os.Exit(1)
+		}
+		if err := push(ctx, pg, newPushClient(*pushURL, *pushToken, *pushBatch), routes, !*pushAll); err != nil {
+			fmt.Fprintf(os.Stderr, "Error pushing to planewatch-atc: %v\n", err)
+			os.Exit(1)
+		}
+		if *output == "" {
+			return
+		}
+	}
+
+	routes, err := getRoutes(ctx, pg, q, keep)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error querying routes: %v\n", err)
 		os.Exit(1)
@@ -110,11 +158,48 @@ func main() {
 	}
 }
 
-// getRoutes retrieves routes from the database with the specified minimum observation count.
+// push sends routes and then callsign mappings, recording each accepted batch
+// as synced. With unsynced, only callsign mappings observed since the last
+// push are sent, as for the routes already selected.
+func push(ctx context.Context, pg *storage.PostgresDB, c *pushClient, routes []RouteExport, unsynced bool) error {
+	n, err := c.pushRoutes(ctx, routes, func(batch []RouteExport) error {
+		stored := make([]storage.Route, len(batch))
+		for i, r := range batch {
+			stored[i] = r.Source
+		}
+		return pg.MarkRoutesSynced(ctx, stored)
+	})
+	fmt.Fprintf(os.Stderr, "Pushed %d of %d routes\n", n, len(routes))
+	if err != nil {
+		return err
+	}
+
+	callsigns, err := pg.ListAircraftCallsigns(ctx, unsynced)
+	if err != nil {
+		return fmt.Errorf("querying callsign mappings: %w", err)
+	}
+	n, err = c.pushCallsigns(ctx, callsigns, func(batch []storage.AircraftCallsign) error {
+		return pg.MarkAircraftCallsignsSynced(ctx, batch)
+	})
+	fmt.Fprintf(os.Stderr, "Pushed %d of %d callsign mappings\n", n, len(callsigns))
+	return err
+}
+
+// durationFlag returns a flag.Func setter for a duration that may be given in
+// days, as the retention flags are.
+func durationFlag(d *time.Duration) func(string) error {
+	return func(s string) error {
+		v, err := storage.ParseRetention(s)
+		*d = v
+		return err
+	}
+}
+
+// getRoutes retrieves the routes matching q that keep accepts.
 // It reconstructs the airport sequence from the route_legs table.
-func getRoutes(ctx context.Context, pg *storage.PostgresDB, minObservations int) ([]RouteExport, error) {
-	// Query all routes meeting the observation threshold.
-	dbRoutes, err := pg.ListRoutes(ctx, minObservations)
+func getRoutes(ctx context.Context, pg *storage.PostgresDB, q storage.RouteQuery, keep func(storage.Route) bool) ([]RouteExport, error) {
+	// Query all routes meeting the thresholds.
+	dbRoutes, err := pg.ListRoutes(ctx, q)
 	if err != nil {
 		return nil, fmt.Errorf("querying routes: %w", err)
 	}
@@ -126,6 +211,9 @@ func getRoutes(ctx context.Context, pg *storage.PostgresDB, minObservations int)
 	// Build routes with airport sequences.
 	routes := make([]RouteExport, 0, len(dbRoutes))
 	for _, r := range dbRoutes {
+		if !keep(r) {
+			continue
+		}
 		legs, err := pg.GetRouteLegs(ctx, r.ID)
 		if err != nil {
 			continue
@@ -140,6 +228,7 @@ func getRoutes(ctx context.Context, pg *storage.PostgresDB, minObservations int)
 		routes = append(routes, RouteExport{
 			FlightPattern: r.FlightPattern,
 			Airports:      airports,
+			Source:        r,
 		})
 	}
 
@@ -170,7 +259,7 @@ func buildAirportSequence(legs []storage.RouteLeg) []string {
 }
 
 // showRouteStats displays statistics about the routes in the database.
-func showRouteStats(ctx context.Context, pg *storage.PostgresDB) {
+func showRouteStats(ctx context.Context, pg *storage.PostgresDB, now time.Time, halfLife time.Duration) {
 	pool := pg.Pool()
 
 	var total int
@@ -190,6 +279,9 @@ func showRouteStats(ctx context.Context, pg *storage.PostgresDB) {
 	fmt.Println("────────────────")
 	fmt.Printf("Total routes:        %d\n", total)
 	fmt.Printf("Multi-stop routes:   %d\n", multiStop)
+	var historic int
+	_ = pool.QueryRow(ctx, "SELECT COUNT(*) FROM routes WHERE historic_at IS NOT NULL").Scan(&historic)
+	fmt.Printf("Historic routes:     %d\n", historic)
 	fmt.Printf("Average observations: %.1f\n", avgObs)
 	if maxPattern != "" {
 		fmt.Printf("Most observed:       %s (%d observations)\n", maxPattern, maxObs)
@@ -222,6 +314,29 @@ func showRouteStats(ctx context.Context, pg *storage.PostgresDB) {
 		}
 	}
 
+	// Confidence distribution of the current routes.
+	if routes, err := pg.ListRoutes(ctx, storage.RouteQuery{}); err == nil {
+		buckets := []struct {
+			name string
+			min  float64
+			n    int
+		}{{"0.9-1.0", 0.9, 0}, {"0.5-0.9", 0.5, 0}, {"0.1-0.5", 0.1, 0}, {"0-0.1", 0, 0}}
+		for _, r := range routes {
+			c := r.Confidence(now, halfLife)
+			for i := range buckets {
+				if c >= buckets[i].min {
+					buckets[i].n++
+					break
+				}
+			}
+		}
+		fmt.Printf("\nConfidence Distribution (half-life %s):\n", halfLife)
+		fmt.Printf("%-15s %10s\n", "Confidence", "Count")
+		for _, b := range buckets {
+			fmt.Printf("%-15s %10d\n", b.name, b.n)
+		}
+	}
+
 	// Top 10 most common flight patterns.
 	fmt.Println("\nTop 10 Most Observed Routes:")
 	topRows, err := pool.Query(ctx, `
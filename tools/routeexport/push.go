@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+// pushTimeout bounds each request to the planewatch-atc API.
+const pushTimeout = 30 * time.Second
+
+// Defaults for pushing to the planewatch-atc API.
+const (
+	defaultPushBatch   = 500
+	defaultPushRetries = 4
+	pushBackoff        = time.Second
+)
+
+// pushClient sends routes and callsign mappings to the planewatch-atc API as
+// JSON batches, retrying a batch when the server is unavailable.
+type pushClient struct {
+	baseURL string
+	token   string
+	batch   int
+	retries int           // Attempts per batch.
+	backoff time.Duration // Wait before the first retry, doubled for each one after.
+	client  *http.Client
+}
+
+func newPushClient(baseURL, token string, batch int) *pushClient {
+	if batch <= 0 {
+		batch = defaultPushBatch
+	}
+	return &pushClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		batch:   batch,
+		retries: defaultPushRetries,
+		backoff: pushBackoff,
+		client:  &http.Client{Timeout: pushTimeout},
+	}
+}
+
+// pushRoute is the JSON form of a route sent to planewatch-atc: the same
+// callsign and airports as a CSV row, with its observations.
+type pushRoute struct {
+	Callsign         string    `json:"callsign"`
+	Airports         []string  `json:"airports"`
+	ObservationCount int       `json:"observation_count"`
+	FirstSeen        time.Time `json:"first_seen"`
+	LastSeen         time.Time `json:"last_seen"`
+}
+
+// pushCallsign is the JSON form of an aircraft callsign mapping.
+type pushCallsign struct {
+	Registration     string    `json:"registration"`
+	IATAPrefix       string    `json:"iata_prefix"`
+	ICAOPrefix       string    `json:"icao_prefix"`
+	ObservationCount int       `json:"observation_count"`
+	LastSeen         time.Time `json:"last_seen"`
+}
+
+// pushRoutes posts routes to /routes as {"routes": [...]}. done is called
+// after each batch is accepted, to advance the sync watermark, so an
+// interrupted push resumes where it stopped. It returns the number sent.
+func (c *pushClient) pushRoutes(ctx context.Context, routes []RouteExport, done func([]RouteExport) error) (int, error) {
+	return pushBatches(ctx, c, "/routes", routes, func(batch []RouteExport) any {
+		body := make([]pushRoute, len(batch))
+		for i, r := range batch {
+			body[i] = pushRoute{
+				Callsign:         r.FlightPattern,
+				Airports:         r.Airports,
+				ObservationCount: r.Source.ObservationCount,
+				FirstSeen:        r.Source.FirstSeen.UTC(),
+				LastSeen:         r.Source.LastSeen.UTC(),
+			}
+		}
+		return map[string]any{"routes": body}
+	}, done)
+}
+
+// pushCallsigns posts callsign mappings to /aircraft_callsigns as
+// {"aircraft_callsigns": [...]}, as pushRoutes does for routes.
+func (c *pushClient) pushCallsigns(ctx context.Context, callsigns []storage.AircraftCallsign, done func([]storage.AircraftCallsign) error) (int, error) {
+	return pushBatches(ctx, c, "/aircraft_callsigns", callsigns, func(batch []storage.AircraftCallsign) any {
+		body := make([]pushCallsign, len(batch))
+		for i, cs := range batch {
+			body[i] = pushCallsign{
+				Registration:     cs.Registration,
+				IATAPrefix:       cs.IATAPrefix,
+				ICAOPrefix:       cs.ICAOPrefix,
+				ObservationCount: cs.ObservationCount,
+				LastSeen:         cs.LastSeen.UTC(),
+			}
+		}
+		return map[string]any{"aircraft_callsigns": body}
+	}, done)
+}
+
+// pushBatches posts items to path in batches, stopping at the first batch
+// that fails after its retries.
+func pushBatches[T any](ctx context.Context, c *pushClient, path string, items []T, body func([]T) any, done func([]T) error) (int, error) {
+	sent := 0
+	for start := 0; start < len(items); start += c.batch {
+		batch := items[start:min(start+c.batch, len(items))]
+		payload, err := json.Marshal(body(batch))
+		if err != nil {
+			return sent, err
+		}
+		if err := c.post(ctx, path, payload); err != nil {
+			return sent, err
+		}
+		if err := done(batch); err != nil {
+			return sent, fmt.Errorf("record sync: %w", err)
+		}
+		sent += len(batch)
+	}
+	return sent, nil
+}
+
+// post sends one batch, retrying on network errors, 429 and 5xx responses.
+// Other responses mean the batch itself was refused, so it is not retried.
+func (c *pushClient) post(ctx context.Context, path string, payload []byte) error {
+	wait := c.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		var after time.Duration
+		retry, after, err = c.try(ctx, path, payload)
+		if err == nil || !retry || attempt >= c.retries {
+			return err
+		}
+		if after > wait {
+			wait = after
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// try makes one request. It reports whether a failure is worth retrying, and
+// how long the server asked to wait with Retry-After.
+func (c *pushClient) try(ctx context.Context, path string, payload []byte) (retry bool, after time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, 0, fmt.Errorf("push %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, 0, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("push %s: %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	if s, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && s > 0 {
+		after = time.Duration(s) * time.Second
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, after, err
+}
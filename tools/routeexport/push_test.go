@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"acars_parser/internal/storage"
+)
+
+func TestPushRoutes(t *testing.T) {
+	var calls atomic.Int32
+	var got []pushRoute
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/routes" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		// The first attempt fails, to be retried.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body struct {
+			Routes []pushRoute `json:"routes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		got = append(got, body.Routes...)
+	}))
+	defer srv.Close()
+
+	c := newPushClient(srv.URL+"/api/", "secret", 2)
+	c.backoff = time.Millisecond
+	seen := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	routes := []RouteExport{
+		{FlightPattern: "QFA1", Airports: []string{"YSSY", "WSSS", "EGLL"}, Source: storage.Route{ID: 1, ObservationCount: 4, LastSeen: seen}},
+		{FlightPattern: "QFA2", Airports: []string{"EGLL", "WSSS", "YSSY"}, Source: storage.Route{ID: 2}},
+		{FlightPattern: "JST501", Airports: []string{"YMML", "YSSY"}, Source: storage.Route{ID: 3}},
+	}
+
+	var synced []int
+	n, err := c.pushRoutes(context.Background(), routes, func(batch []RouteExport) error {
+		for _, r := range batch {
+			synced = append(synced, r.Source.ID)
+		}
+		return nil
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("pushRoutes = %d, %v", n, err)
+	}
+	if calls.Load() != 3 || len(synced) != 3 {
+		t.Errorf("%d requests and %d routes synced, want 3 requests for 2 batches and a retry", calls.Load(), len(synced))
+	}
+	if len(got) != 3 || got[0].Callsign != "QFA1" || len(got[0].Airports) != 3 || got[0].ObservationCount != 4 || !got[0].LastSeen.Equal(seen) {
+		t.Errorf("received %+v", got)
+	}
+}
+
+func TestPushRefused(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "unknown airport", http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+
+	c := newPushClient(srv.URL, "", 0)
+	c.backoff = time.Millisecond
+	n, err := c.pushCallsigns(context.Background(), []storage.AircraftCallsign{{Registration: "VH-OQA"}}, func([]storage.AircraftCallsign) error {
+		t.Error("refused batch recorded as synced")
+		return nil
+	})
+	if n != 0 || err == nil || !strings.Contains(err.Error(), "unknown airport") {
+		t.Errorf("pushCallsigns = %d, %v", n, err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("%d requests, want a refused batch not retried", calls.Load())
+	}
+}